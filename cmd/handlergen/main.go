@@ -0,0 +1,270 @@
+// Command handlergen writes the bus wiring of the command and query handlers. It scans the
+// internal/<module>/app/command and app/query packages for exported *Handler types and gives each
+// a Decorated method that wraps it in the decorators of pkg/decorator, with the type arguments
+// taken from its Handle method, so main.go never repeats them by hand.
+//
+//	go run ./cmd/handlergen
+//	go run ./cmd/handlergen -check
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileName is the generated file of each handler package
+const fileName = "handlers_gen.go"
+
+// header marks the file as generated for go vet, linters and reviewers
+const header = "// Code generated by handlergen; DO NOT EDIT.\n\n"
+
+func main() {
+	root := flag.String("root", ".", "root of the module")
+	check := flag.Bool("check", false, "fail instead of writing when a generated file is out of date")
+	flag.Parse()
+
+	files, err := generate(*root)
+	if err != nil {
+		log.Fatalf("Failed to generate handler wiring: %v", err)
+	}
+
+	var stale []string
+	for _, path := range sortedKeys(files) {
+		current, err := os.ReadFile(path)
+		if err == nil && bytes.Equal(current, files[path]) {
+			continue
+		}
+		if *check {
+			stale = append(stale, path)
+			continue
+		}
+		if err := os.WriteFile(path, files[path], 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+		log.Printf("Handler wiring written to %s", path)
+	}
+	if len(stale) > 0 {
+		log.Fatalf("Handler wiring is out of date, run go generate: %s", strings.Join(stale, ", "))
+	}
+}
+
+// handler is a command or query handler found in a package
+type handler struct {
+	Name    string
+	Pointer bool
+	// Input is the command or query type and Result the type Handle returns besides the error,
+	// empty for commands without a result
+	Input, Result string
+}
+
+// generate returns the generated file of every handler package under root, by path
+func generate(root string) (map[string][]byte, error) {
+	dirs, err := filepath.Glob(filepath.Join(root, "internal", "*", "app", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, dir := range dirs {
+		kind := filepath.Base(dir)
+		if kind != "command" && kind != "query" {
+			continue
+		}
+		src, err := generatePackage(dir, kind)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		if src != nil {
+			files[filepath.Join(dir, fileName)] = src
+		}
+	}
+	return files, nil
+}
+
+// generatePackage returns the generated file of the package in dir, or nil when it has no handlers
+func generatePackage(dir, kind string) ([]byte, error) {
+	fset := token.NewFileSet()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg string
+	var handlers []handler
+	// imports are the packages the handler types refer to, by the name they are referred to with
+	imports := map[string]string{}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == fileName {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		pkg = f.Name.Name
+
+		for _, decl := range f.Decls {
+			h, refs, ok := handlerOf(decl)
+			if !ok {
+				continue
+			}
+			for _, name := range refs {
+				path, err := importPath(f, name)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", h.Name, err)
+				}
+				if other, ok := imports[name]; ok && other != path {
+					return nil, fmt.Errorf("%s refers to %s and %s as %s; import one of them under another name", h.Name, other, path, name)
+				}
+				imports[name] = path
+			}
+			handlers = append(handlers, h)
+		}
+	}
+	if len(handlers) == 0 {
+		return nil, nil
+	}
+	sort.Slice(handlers, func(i, j int) bool { return handlers[i].Name < handlers[j].Name })
+	imports["decorator"] = "github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "package %s\n\nimport (\n", pkg)
+	for _, name := range sortedKeys(imports) {
+		path := imports[name]
+		if name == filepath.Base(path) {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&b, "\t%s %q\n", name, path)
+		}
+	}
+	b.WriteString(")\n")
+	for _, h := range handlers {
+		writeDecorated(&b, kind, h)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// handlerOf reports whether decl is the Handle method of an exported *Handler type, and the
+// package names its command and result types refer to
+func handlerOf(decl ast.Decl) (handler, []string, bool) {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok || fn.Recv == nil || fn.Name.Name != "Handle" {
+		return handler{}, nil, false
+	}
+
+	var h handler
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		h.Pointer, recv = true, star.X
+	}
+	ident, ok := recv.(*ast.Ident)
+	if !ok || !ident.IsExported() || !strings.HasSuffix(ident.Name, "Handler") {
+		return handler{}, nil, false
+	}
+	h.Name = ident.Name
+
+	params, results := fields(fn.Type.Params), fields(fn.Type.Results)
+	if len(params) != 2 || len(results) < 1 || len(results) > 2 || types.ExprString(results[len(results)-1]) != "error" {
+		return handler{}, nil, false
+	}
+	h.Input = types.ExprString(params[1])
+	refs := packageRefs(params[1])
+	if len(results) == 2 {
+		h.Result = types.ExprString(results[0])
+		refs = append(refs, packageRefs(results[0])...)
+	}
+	return h, refs, true
+}
+
+// fields lists the types of a parameter or result list, once per name
+func fields(list *ast.FieldList) []ast.Expr {
+	if list == nil {
+		return nil
+	}
+	var exprs []ast.Expr
+	for _, f := range list.List {
+		for range max(len(f.Names), 1) {
+			exprs = append(exprs, f.Type)
+		}
+	}
+	return exprs
+}
+
+// packageRefs returns the names of the packages expr refers to, e.g. domain in []domain.Order
+func packageRefs(expr ast.Expr) []string {
+	var names []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				names = append(names, x.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return names
+}
+
+// importPath returns the path of the package f imports as name
+func importPath(f *ast.File, name string) (string, error) {
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return "", err
+		}
+		if spec.Name != nil && spec.Name.Name == name || spec.Name == nil && filepath.Base(path) == name {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no import named %s", name)
+}
+
+func writeDecorated(b *bytes.Buffer, kind string, h handler) {
+	recv := h.Name
+	if h.Pointer {
+		recv = "*" + h.Name
+	}
+
+	var apply, returns string
+	switch {
+	case kind == "query":
+		apply = fmt.Sprintf("ApplyQueryDecorators[%s, %s]", h.Input, h.Result)
+		returns = fmt.Sprintf("QueryHandler[%s, %s]", h.Input, h.Result)
+	case h.Result == "":
+		apply = fmt.Sprintf("ApplyCommandDecorators[%s]", h.Input)
+		returns = fmt.Sprintf("CommandHandler[%s]", h.Input)
+	default:
+		apply = fmt.Sprintf("ApplyCommandResultDecorators[%s, %s]", h.Input, h.Result)
+		returns = fmt.Sprintf("CommandResultHandler[%s, %s]", h.Input, h.Result)
+	}
+	name, _, _ := strings.Cut(apply, "[")
+	fmt.Fprintf(b, "\n// Decorated wraps the handler in the shared %s decorators, see decorator.%s\n", kind, name)
+	fmt.Fprintf(b, "func (h %s) Decorated() decorator.%s {\n\treturn decorator.%s(h)\n}\n", recv, returns, apply)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
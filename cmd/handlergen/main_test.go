@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_UpToDate(t *testing.T) {
+	files, err := generate(filepath.Join("..", ".."))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for path, want := range files {
+		got, err := os.ReadFile(path)
+		require.NoError(t, err, "run go generate to add %s", path)
+		assert.Equal(t, string(want), string(got), "run go generate to update %s", path)
+	}
+}
+
+func TestGeneratePackage(t *testing.T) {
+	dir := t.TempDir()
+	src := `package command
+
+import (
+	"context"
+
+	shopDomain "example.com/shop/internal/shop/domain"
+)
+
+type CloseShopCommand struct{}
+
+type CloseShopHandler struct{}
+
+func (h *CloseShopHandler) Handle(ctx context.Context, cmd CloseShopCommand) error { return nil }
+
+type OpenShopCommand struct{}
+
+type OpenShopHandler struct{}
+
+func (h OpenShopHandler) Handle(ctx context.Context, cmd OpenShopCommand) ([]shopDomain.Shop, error) { return nil, nil }
+
+type helperHandler struct{}
+
+func (h *helperHandler) Handle(ctx context.Context, cmd OpenShopCommand) error { return nil }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shop.go"), []byte(src), 0o644))
+
+	got, err := generatePackage(dir, "command")
+
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by handlergen; DO NOT EDIT.
+
+package command
+
+import (
+	shopDomain "example.com/shop/internal/shop/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// Decorated wraps the handler in the shared command decorators, see decorator.ApplyCommandDecorators
+func (h *CloseShopHandler) Decorated() decorator.CommandHandler[CloseShopCommand] {
+	return decorator.ApplyCommandDecorators[CloseShopCommand](h)
+}
+
+// Decorated wraps the handler in the shared command decorators, see decorator.ApplyCommandResultDecorators
+func (h OpenShopHandler) Decorated() decorator.CommandResultHandler[OpenShopCommand, []shopDomain.Shop] {
+	return decorator.ApplyCommandResultDecorators[OpenShopCommand, []shopDomain.Shop](h)
+}
+`, string(got))
+}
@@ -0,0 +1,30 @@
+// Command openapi writes the OpenAPI document generated from the route registry.
+//
+//	go run ./cmd/openapi -out api/openapi.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/server"
+)
+
+func main() {
+	out := flag.String("out", "api/openapi.json", "path of the generated document")
+	flag.Parse()
+
+	doc := server.DocumentationRouter().OpenAPI(server.APIInfo)
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal OpenAPI document: %v", err)
+	}
+
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *out, err)
+	}
+	log.Printf("OpenAPI document written to %s", *out)
+}
@@ -0,0 +1,79 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Client calls the public API of an Environment
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func (e *Environment) Client() *Client {
+	return &Client{BaseURL: e.BaseURL, HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Do sends body as JSON and decodes a 2xx response into out when it is not nil. Other statuses are
+// returned without an error so scenarios can assert on them.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+	return c.send(ctx, method, path, payload, nil, out)
+}
+
+// SendWebhook delivers a payment callback signed like the fake gateway signs them
+func (c *Client) SendWebhook(ctx context.Context, event any) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	mac := hmac.New(sha256.New, []byte(WebhookSecret))
+	mac.Write(payload)
+	headers := map[string]string{"X-Webhook-Signature": "sha256=" + hex.EncodeToString(mac.Sum(nil))}
+	return c.send(ctx, http.MethodPost, "/webhooks/payments", payload, headers, nil)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, headers map[string]string, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode >= 300 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode %s %s: %w", method, path, err)
+	}
+	return resp.StatusCode, nil
+}
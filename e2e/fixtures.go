@@ -0,0 +1,50 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Product is a product as returned by the API
+type Product struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Stock int    `json:"stock"`
+	Price *Price `json:"price,omitempty"`
+}
+
+type Price struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Fixtures is the catalog every scenario starts from
+type Fixtures struct {
+	Desk        Product
+	SoldOutLamp Product
+}
+
+// Seed creates the fixtures through the API, so they pass the same validation as real data
+func Seed(ctx context.Context, c *Client) (*Fixtures, error) {
+	var f Fixtures
+	products := []struct {
+		into *Product
+		req  Product
+	}{
+		{&f.Desk, Product{Name: "Standing Desk", Stock: 5, Price: &Price{Amount: 12900, Currency: "EUR"}}},
+		{&f.SoldOutLamp, Product{Name: "Desk Lamp", Stock: 0, Price: &Price{Amount: 2900, Currency: "EUR"}}},
+	}
+	for _, p := range products {
+		status, err := c.Do(ctx, http.MethodPost, "/products", p.req, p.into)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusCreated {
+			return nil, fmt.Errorf("create product %q: status %d", p.req.Name, status)
+		}
+	}
+	return &f, nil
+}
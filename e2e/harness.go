@@ -0,0 +1,265 @@
+//go:build e2e
+
+// Package e2e runs scenarios against a real server process through its public API.
+// Run it with `make e2e`; see Start for the dependencies it brings up.
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Deps selects how the server's dependencies are provided
+type Deps string
+
+const (
+	// DepsMemory runs the server on a sqlite file in a temporary directory; it needs nothing installed
+	DepsMemory Deps = "memory"
+	// DepsPostgres runs the server on a throwaway postgres container, like production
+	DepsPostgres Deps = "postgres"
+)
+
+// WebhookSecret signs the fake gateway callbacks sent by the scenarios
+const WebhookSecret = "e2e-webhook-secret"
+
+const (
+	postgresImage = "postgres:15-alpine"
+	readyTimeout  = time.Minute
+	stopTimeout   = 10 * time.Second
+)
+
+// Environment is a running server with its dependencies
+type Environment struct {
+	BaseURL string
+
+	dir      string
+	logPath  string
+	server   *exec.Cmd
+	exited   chan error
+	teardown []func() error
+}
+
+// Start builds the server from the module root, brings up its dependencies and starts it on a free port.
+// The server uses the fake payment gateway and only logs emails, so scenarios never reach a third party.
+func Start(ctx context.Context, deps Deps) (env *Environment, err error) {
+	dir, err := os.MkdirTemp("", "aiio-e2e-")
+	if err != nil {
+		return nil, err
+	}
+	env = &Environment{dir: dir, logPath: filepath.Join(dir, "server.log")}
+	env.teardown = append(env.teardown, func() error { return os.RemoveAll(dir) })
+	defer func() {
+		if err != nil {
+			env.Close()
+		}
+	}()
+
+	root, err := filepath.Abs("..")
+	if err != nil {
+		return nil, err
+	}
+	binary := filepath.Join(dir, "aiiobackend")
+	build := exec.CommandContext(ctx, "go", "build", "-o", binary, ".")
+	build.Dir = root
+	if out, err := build.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("build server: %w\n%s", err, out)
+	}
+
+	dbEnv, err := env.startDatabase(ctx, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	env.BaseURL = "http://127.0.0.1:" + strconv.Itoa(port)
+	if err := env.startServer(binary, port, dbEnv); err != nil {
+		return nil, err
+	}
+	if err := env.waitReady(ctx); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Close stops the server and removes its dependencies and files
+func (e *Environment) Close() error {
+	var errs []error
+	for i := len(e.teardown) - 1; i >= 0; i-- {
+		errs = append(errs, e.teardown[i]())
+	}
+	e.teardown = nil
+	return errors.Join(errs...)
+}
+
+// Logs returns what the server wrote so far, to be printed when a scenario fails
+func (e *Environment) Logs() string {
+	logs, err := os.ReadFile(e.logPath)
+	if err != nil {
+		return err.Error()
+	}
+	return string(logs)
+}
+
+// startDatabase returns the environment variables pointing the server at its database
+func (e *Environment) startDatabase(ctx context.Context, deps Deps) ([]string, error) {
+	switch deps {
+	case DepsMemory:
+		return []string{"DB_DRIVER=sqlite", "DB_NAME=" + filepath.Join(e.dir, "e2e.db")}, nil
+	case DepsPostgres:
+		return e.startPostgres(ctx)
+	default:
+		return nil, fmt.Errorf("unknown dependencies %q, expected %s or %s", deps, DepsMemory, DepsPostgres)
+	}
+}
+
+func (e *Environment) startPostgres(ctx context.Context) ([]string, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("aiio-e2e-postgres-%d", os.Getpid())
+	run := exec.CommandContext(ctx, "docker", "run", "--detach", "--rm", "--name", name,
+		"--env", "POSTGRES_PASSWORD=postgres", "--env", "POSTGRES_DB=aiio_e2e",
+		"--publish", fmt.Sprintf("127.0.0.1:%d:5432", port), postgresImage)
+	if out, err := run.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("start postgres container: %w\n%s", err, out)
+	}
+	e.teardown = append(e.teardown, func() error {
+		return exec.Command("docker", "rm", "--force", name).Run()
+	})
+
+	// Check over TCP: during initialization postgres only listens on its socket and restarts afterwards
+	err = poll(ctx, func() error {
+		return exec.CommandContext(ctx, "docker", "exec", name, "pg_isready", "--host", "127.0.0.1", "--username", "postgres").Run()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("postgres not ready: %w", err)
+	}
+	return []string{
+		"DB_DRIVER=postgres",
+		"DB_HOST=127.0.0.1",
+		"DB_PORT=" + strconv.Itoa(port),
+		"DB_USER=postgres",
+		"DB_PASSWORD=postgres",
+		"DB_NAME=aiio_e2e",
+		"DB_SSLMODE=disable",
+	}, nil
+}
+
+func (e *Environment) startServer(binary string, port int, dbEnv []string) error {
+	logs, err := os.Create(e.logPath)
+	if err != nil {
+		return err
+	}
+
+	e.server = exec.Command(binary)
+	// Run outside the module so a developer's .env is not loaded
+	e.server.Dir = e.dir
+	e.server.Stdout, e.server.Stderr = logs, logs
+	// Later entries win, so these override whatever the caller has exported
+	e.server.Env = append(os.Environ(), dbEnv...)
+	e.server.Env = append(e.server.Env,
+		"HTTP_ADDR=127.0.0.1:"+strconv.Itoa(port),
+		"DB_LOG_LEVEL=silent",
+		"DB_AUTO_MIGRATE=true",
+		"RBAC_ENABLED=false",
+		"PAYMENT_GATEWAY=fake",
+		"PAYMENT_WEBHOOK_SECRET="+WebhookSecret,
+		"EMAIL_PROVIDER=smtp",
+		"SMTP_HOST=",
+		"ADAPTER_MODE=live",
+		"INFRA_BOOTSTRAP=false",
+		"OTEL_EXPORTER_OTLP_ENDPOINT=",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT=",
+	)
+	if err := e.server.Start(); err != nil {
+		logs.Close()
+		return fmt.Errorf("start server: %w", err)
+	}
+
+	e.exited = make(chan error, 1)
+	go func() {
+		e.exited <- e.server.Wait()
+		logs.Close()
+	}()
+	e.teardown = append(e.teardown, e.stopServer)
+	return nil
+}
+
+// stopServer asks the server to shut down and kills it when it doesn't in time
+func (e *Environment) stopServer() error {
+	select {
+	case <-e.exited:
+		return nil
+	default:
+	}
+	if err := e.server.Process.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+	select {
+	case <-e.exited:
+	case <-time.After(stopTimeout):
+		return e.server.Process.Kill()
+	}
+	return nil
+}
+
+func (e *Environment) waitReady(ctx context.Context) error {
+	return poll(ctx, func() error {
+		select {
+		case err := <-e.exited:
+			e.exited <- err
+			return fmt.Errorf("%w: server exited: %v\n%s", errStopPolling, err, e.Logs())
+		default:
+		}
+		resp, err := http.Get(e.BaseURL + "/openapi.json")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET /openapi.json: %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+var errStopPolling = errors.New("giving up")
+
+// poll retries check until it succeeds, returns errStopPolling or readyTimeout passes
+func poll(ctx context.Context, check func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	for {
+		err := check()
+		if err == nil || errors.Is(err, errStopPolling) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
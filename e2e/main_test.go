@@ -0,0 +1,51 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
+
+var (
+	client   *Client
+	fixtures *Fixtures
+)
+
+// TestMain starts one server for all scenarios; E2E_DEPS selects memory (default) or postgres
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+	deps := DepsMemory
+	if d := os.Getenv("E2E_DEPS"); d != "" {
+		deps = Deps(d)
+	}
+
+	env, err := Start(ctx, deps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "start e2e environment: %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := env.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "tear down e2e environment: %v\n", err)
+		}
+	}()
+
+	client = env.Client()
+	if fixtures, err = Seed(ctx, client); err != nil {
+		fmt.Fprintf(os.Stderr, "seed fixtures: %v\n%s", err, env.Logs())
+		return 1
+	}
+
+	code := m.Run()
+	if code != 0 {
+		fmt.Fprintf(os.Stderr, "server logs:\n%s", env.Logs())
+	}
+	return code
+}
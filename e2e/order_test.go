@@ -0,0 +1,179 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	// AddressID is the address orders of the user ship to
+	AddressID string `json:"-"`
+}
+
+type address struct {
+	ID string `json:"id"`
+}
+
+type order struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Quantity int    `json:"quantity"`
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+type payments struct {
+	Payments []struct {
+		Reference string `json:"reference"`
+		Amount    int64  `json:"amount"`
+		Status    string `json:"status"`
+	} `json:"payments"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		Products []struct {
+			Name  string `json:"name"`
+			Stock int    `json:"stock"`
+		} `json:"products"`
+	} `json:"data"`
+}
+
+func register(t *testing.T, ctx context.Context) user {
+	var u user
+	status, err := client.Do(ctx, http.MethodPost, "/users", map[string]string{
+		"email":    fmt.Sprintf("e2e-%d@example.com", time.Now().UnixNano()),
+		"password": "Correct-horse-battery-9",
+	}, &u)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, status)
+
+	var a address
+	status, err = client.Do(ctx, http.MethodPost, fmt.Sprintf("/users/%s/addresses", u.ID), map[string]string{
+		"name":        "E2E Customer",
+		"line1":       "Main St 1",
+		"city":        "Berlin",
+		"postal_code": "10115",
+		"country":     "DE",
+	}, &a)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, status)
+	u.AddressID = a.ID
+	return u
+}
+
+func stockOf(t *testing.T, ctx context.Context, id string) int {
+	var p Product
+	status, err := client.Do(ctx, http.MethodGet, fmt.Sprintf("/products/%s", id), nil, &p)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	return p.Stock
+}
+
+// TestScenario_RegisterBrowseOrderCancel walks a customer through the shop: the order is cancelled
+// by the gateway reporting its payment as failed, which must put the stock back
+func TestScenario_RegisterBrowseOrderCancel(t *testing.T) {
+	ctx := context.Background()
+
+	// Register
+	customer := register(t, ctx)
+
+	// Browse: only products in stock are listed
+	var catalog graphQLResponse
+	status, err := client.Do(ctx, http.MethodPost, "/graphql", map[string]string{
+		"query": `{ products(filter: {inStock: true}) { name stock } }`,
+	}, &catalog)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	names := make([]string, len(catalog.Data.Products))
+	for i, p := range catalog.Data.Products {
+		names[i] = p.Name
+	}
+	assert.Contains(t, names, fixtures.Desk.Name)
+	assert.NotContains(t, names, fixtures.SoldOutLamp.Name)
+	stock := stockOf(t, ctx, fixtures.Desk.ID)
+
+	// Order
+	var placed order
+	status, err = client.Do(ctx, http.MethodPost, "/orders", map[string]any{
+		"user_id":             customer.ID,
+		"product_id":          fixtures.Desk.ID,
+		"shipping_address_id": customer.AddressID,
+		"quantity":            2,
+		"payment":             map[string]any{"method": "pm_card_visa", "amount": 2 * fixtures.Desk.Price.Amount, "currency": "EUR"},
+	}, &placed)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "CONFIRMED", placed.Status)
+	assert.Equal(t, 2*fixtures.Desk.Price.Amount, placed.Total)
+	assert.Equal(t, stock-2, stockOf(t, ctx, fixtures.Desk.ID))
+
+	var paid payments
+	status, err = client.Do(ctx, http.MethodGet, fmt.Sprintf("/orders/%s/payments", placed.ID), nil, &paid)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, paid.Payments, 1)
+	assert.Equal(t, "AUTHORIZED", paid.Payments[0].Status)
+
+	// Cancel
+	status, err = client.SendWebhook(ctx, map[string]any{
+		"id":        fmt.Sprintf("evt_failed_%s", placed.ID),
+		"type":      "failed",
+		"reference": paid.Payments[0].Reference,
+		"amount":    paid.Payments[0].Amount,
+		"currency":  "EUR",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	var cancelled order
+	status, err = client.Do(ctx, http.MethodGet, fmt.Sprintf("/orders/%s", placed.ID), nil, &cancelled)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "CANCELLED", cancelled.Status)
+	assert.Equal(t, stock, stockOf(t, ctx, fixtures.Desk.ID), "stock of the cancelled order is put back")
+}
+
+func TestScenario_DeclinedPaymentKeepsStock(t *testing.T) {
+	ctx := context.Background()
+	customer := register(t, ctx)
+	stock := stockOf(t, ctx, fixtures.Desk.ID)
+
+	status, err := client.Do(ctx, http.MethodPost, "/orders", map[string]any{
+		"user_id":             customer.ID,
+		"product_id":          fixtures.Desk.ID,
+		"shipping_address_id": customer.AddressID,
+		"quantity":            1,
+		"payment":             map[string]any{"method": "pm_card_declined", "amount": fixtures.Desk.Price.Amount, "currency": "EUR"},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPaymentRequired, status)
+	assert.Equal(t, stock, stockOf(t, ctx, fixtures.Desk.ID))
+}
+
+func TestScenario_SoldOutProduct(t *testing.T) {
+	ctx := context.Background()
+	customer := register(t, ctx)
+
+	status, err := client.Do(ctx, http.MethodPost, "/orders", map[string]any{
+		"user_id":             customer.ID,
+		"product_id":          fixtures.SoldOutLamp.ID,
+		"shipping_address_id": customer.AddressID,
+		"quantity":            1,
+		"payment":             map[string]any{"method": "pm_card_visa", "amount": fixtures.SoldOutLamp.Price.Amount, "currency": "EUR"},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, status)
+}
@@ -0,0 +1,19 @@
+package main
+
+import orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+
+// orderHooks are the extension points of order placement. A build embedding this service adds
+// its business rules from a file of its own in this package, leaving the handlers untouched:
+//
+//	func init() {
+//		orderHooks.PreOrderPlacement = append(orderHooks.PreOrderPlacement, orderDomain.PreOrderPlacementFunc(
+//			func(ctx context.Context, p orderDomain.Placement) error {
+//				if p.Quantity.Int() > 50 {
+//					return fmt.Errorf("%w: orders above 50 units need a sales contract", orderDomain.ErrOrderRejected)
+//				}
+//				return nil
+//			}))
+//	}
+//
+// The hooks run for every order, placed through REST, GraphQL or a checkout.
+var orderHooks orderDomain.Hooks
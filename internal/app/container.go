@@ -0,0 +1,151 @@
+// Package app builds the object graph of the service. Modules register providers, functions
+// building one dependency from others, on a Container; resolving a type runs its provider once
+// and every dependency it resolves in turn. Registering a provider for a type that has one
+// replaces it, so configuration picks an adapter by registering it last.
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Module registers the providers of one module, e.g. its repositories
+type Module interface {
+	Provide(c *Container)
+}
+
+type provider struct {
+	build func(c *Container) (any, error)
+}
+
+// Container resolves dependencies, each built once; the containers passed to providers share the
+// providers and built dependencies of the one made by New
+type Container struct {
+	registry  *registry
+	instances *instances
+	// chain lists the types being built by the provider this container was passed to, to report
+	// dependency cycles
+	chain []reflect.Type
+}
+
+type registry struct {
+	mu        sync.Mutex
+	providers map[reflect.Type]provider
+}
+
+type instances struct {
+	mu     sync.Mutex
+	values map[reflect.Type]any
+}
+
+func New() *Container {
+	return &Container{registry: &registry{providers: make(map[reflect.Type]provider)}, instances: &instances{values: make(map[reflect.Type]any)}}
+}
+
+// Install registers the providers of the modules in order
+func (c *Container) Install(modules ...Module) {
+	for _, m := range modules {
+		m.Provide(c)
+	}
+}
+
+// Provide registers fn as the provider of T, built once for the whole container
+func Provide[T any](c *Container, fn func(c *Container) (T, error)) {
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+	c.registry.providers[reflect.TypeFor[T]()] = provider{build: func(c *Container) (any, error) { return fn(c) }}
+}
+
+// Value registers v as T, e.g. for dependencies main builds itself such as the database
+func Value[T any](c *Container, v T) {
+	Provide(c, func(*Container) (T, error) { return v, nil })
+}
+
+// Decorate wraps the provider registered for T so that fn gets what it builds, e.g. to add a
+// cache in front of a repository; it panics when T has no provider
+func Decorate[T any](c *Container, fn func(c *Container, next T) (T, error)) {
+	t := reflect.TypeFor[T]()
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+	p, ok := c.registry.providers[t]
+	if !ok {
+		panic(fmt.Sprintf("app: decorating %s without a provider", t))
+	}
+	build := p.build
+	p.build = func(c *Container) (any, error) {
+		next, err := build(c)
+		if err != nil {
+			return nil, err
+		}
+		return fn(c, next.(T))
+	}
+	c.registry.providers[t] = p
+}
+
+// Resolve returns the T of c, building it and its dependencies on first use
+func Resolve[T any](c *Container) (T, error) {
+	var zero T
+	v, err := c.resolve(reflect.TypeFor[T]())
+	if err != nil {
+		return zero, err
+	}
+	// Providers of interfaces may return nil, which doesn't assert to T
+	t, _ := v.(T)
+	return t, nil
+}
+
+// MustResolve is Resolve for main, where a dependency that can't be built ends the process
+func MustResolve[T any](c *Container) T {
+	v, err := Resolve[T](c)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (c *Container) resolve(t reflect.Type) (any, error) {
+	for i, r := range c.chain {
+		if r == t {
+			return nil, fmt.Errorf("app: dependency cycle: %s", describe(append(c.chain[i:len(c.chain):len(c.chain)], t)))
+		}
+	}
+
+	c.registry.mu.Lock()
+	p, ok := c.registry.providers[t]
+	c.registry.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("app: no provider for %s", t)
+	}
+
+	built := c.instances
+	built.mu.Lock()
+	v, ok := built.values[t]
+	built.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	// The lock is not held while building, since providers resolve their dependencies; concurrent
+	// first uses of a type may both build it, and the first result is kept
+	v, err := p.build(&Container{registry: c.registry, instances: built, chain: append(c.chain[:len(c.chain):len(c.chain)], t)})
+	if err != nil {
+		return nil, fmt.Errorf("app: build %s: %w", t, err)
+	}
+	built.mu.Lock()
+	defer built.mu.Unlock()
+	if existing, ok := built.values[t]; ok {
+		return existing, nil
+	}
+	built.values[t] = v
+	return v, nil
+}
+
+func describe(types []reflect.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,73 @@
+package app_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/app"
+	"github.com/stretchr/testify/assert"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type plainGreeter struct{ name string }
+
+func (g plainGreeter) Greet() string { return "hello " + g.name }
+
+type loudGreeter struct{ next greeter }
+
+func (g loudGreeter) Greet() string { return g.next.Greet() + "!" }
+
+func TestContainer_BuildsSingletonsOnce(t *testing.T) {
+	c := app.New()
+	builds := 0
+	app.Value(c, "ada")
+	app.Provide(c, func(c *app.Container) (greeter, error) {
+		builds++
+		name, err := app.Resolve[string](c)
+		return plainGreeter{name: name}, err
+	})
+
+	first := app.MustResolve[greeter](c)
+	second := app.MustResolve[greeter](c)
+
+	assert.Equal(t, "hello ada", first.Greet())
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, builds)
+}
+
+func TestContainer_LaterProvidersAndDecoratorsSwapAdapters(t *testing.T) {
+	c := app.New()
+	app.Provide(c, func(*app.Container) (greeter, error) { return plainGreeter{name: "plain"}, nil })
+	app.Provide(c, func(*app.Container) (greeter, error) { return plainGreeter{name: "swapped"}, nil })
+	app.Decorate(c, func(_ *app.Container, next greeter) (greeter, error) { return loudGreeter{next: next}, nil })
+
+	assert.Equal(t, "hello swapped!", app.MustResolve[greeter](c).Greet())
+}
+
+func TestContainer_ReportsMissingProvidersAndCycles(t *testing.T) {
+	c := app.New()
+	_, err := app.Resolve[greeter](c)
+	assert.ErrorContains(t, err, "no provider for app_test.greeter")
+
+	app.Provide(c, func(c *app.Container) (greeter, error) {
+		name, err := app.Resolve[string](c)
+		return plainGreeter{name: name}, err
+	})
+	app.Provide(c, func(c *app.Container) (string, error) {
+		g, err := app.Resolve[greeter](c)
+		if err != nil {
+			return "", err
+		}
+		return g.Greet(), nil
+	})
+	_, err = app.Resolve[greeter](c)
+	assert.ErrorContains(t, err, "dependency cycle: app_test.greeter -> string -> app_test.greeter")
+
+	failing := app.New()
+	app.Provide(failing, func(*app.Container) (greeter, error) { return nil, errors.New("boom") })
+	_, err = app.Resolve[greeter](failing)
+	assert.ErrorContains(t, err, "boom")
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+)
+
+// OrderModule provides the order repository; it needs the *gorm.DB and *metrics.Metrics of the
+// service
+type OrderModule struct{}
+
+func (OrderModule) Provide(c *Container) {
+	Provide(c, func(c *Container) (domain.OrderRepository, error) {
+		db, m, err := storage(c)
+		if err != nil {
+			return nil, err
+		}
+		return adapter.NewValidatingOrderRepository(adapter.NewInstrumentedOrderRepository(adapter.NewGormOrderRepository(db), m)), nil
+	})
+}
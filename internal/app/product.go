@@ -0,0 +1,71 @@
+package app
+
+import (
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/cache"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/metrics"
+	"gorm.io/gorm"
+)
+
+// ProductModule provides the product repositories; it needs the *gorm.DB, *metrics.Metrics and
+// *cache.Store of the service
+type ProductModule struct{}
+
+func (ProductModule) Provide(c *Container) {
+	Provide(c, func(c *Container) (domain.ProductRepository, error) {
+		db, m, err := storage(c)
+		if err != nil {
+			return nil, err
+		}
+		repo := adapter.NewValidatingProductRepository(
+			adapter.NewInstrumentedProductRepository(adapter.NewGormProductRepository(db), m),
+		)
+		return invalidating(c, repo, adapter.NewInvalidatingProductRepository)
+	})
+	Provide(c, func(c *Container) (domain.StockReservationRepository, error) {
+		db, m, err := storage(c)
+		if err != nil {
+			return nil, err
+		}
+		products, err := Resolve[domain.ProductRepository](c)
+		if err != nil {
+			return nil, err
+		}
+		repo := adapter.NewInstrumentedStockReservationRepository(adapter.NewGormStockReservationRepository(db, products), m)
+		return invalidating(c, repo, adapter.NewInvalidatingStockReservationRepository)
+	})
+	Provide(c, func(c *Container) (domain.ProductImageRepository, error) {
+		db, err := Resolve[*gorm.DB](c)
+		if err != nil {
+			return nil, err
+		}
+		return adapter.NewGormProductImageRepository(db), nil
+	})
+}
+
+// storage resolves what every instrumented Gorm repository is built from
+func storage(c *Container) (*gorm.DB, *metrics.Metrics, error) {
+	db, err := Resolve[*gorm.DB](c)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := Resolve[*metrics.Metrics](c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, m, nil
+}
+
+// invalidating wraps repo to invalidate the query cache on writes; with the cache disabled by a
+// zero QUERY_CACHE_TTL there is nothing to invalidate, and the plain repository is used
+func invalidating[R any](c *Container, repo R, wrap func(R, *cache.Store) R) (R, error) {
+	store, err := Resolve[*cache.Store](c)
+	if err != nil {
+		return repo, err
+	}
+	if store.TTL <= 0 {
+		return repo, nil
+	}
+	return wrap(repo, store), nil
+}
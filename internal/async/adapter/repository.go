@@ -0,0 +1,85 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormCommandRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCommandRepository(db *gorm.DB) domain.CommandRepository {
+	return &GormCommandRepository{db: db}
+}
+
+func (r *GormCommandRepository) Create(ctx context.Context, c *domain.Command) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(c).Error)
+}
+
+func (r *GormCommandRepository) GetByID(ctx context.Context, id int64) (*domain.Command, error) {
+	var c domain.Command
+	if err := persistence.Conn(ctx, r.db).First(&c, id).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &c, nil
+}
+
+func (r *GormCommandRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Command, error) {
+	var c domain.Command
+	if err := persistence.Conn(ctx, r.db).Where("public_id = ?", publicID).First(&c).Error; err != nil {
+		if errors.Is(persistence.TranslateError(err), persistence.ErrNotFound) {
+			return nil, domain.ErrCommandNotFound
+		}
+		return nil, persistence.TranslateError(err)
+	}
+	return &c, nil
+}
+
+func (r *GormCommandRepository) Start(ctx context.Context, id int64, at time.Time) error {
+	return r.transition(ctx, id, []domain.CommandStatus{domain.CommandPending}, map[string]interface{}{
+		"status":     domain.CommandRunning,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"updated_at": at,
+	})
+}
+
+func (r *GormCommandRepository) Retry(ctx context.Context, id int64) error {
+	return r.transition(ctx, id, []domain.CommandStatus{domain.CommandRunning}, map[string]interface{}{
+		"status": domain.CommandPending,
+	})
+}
+
+func (r *GormCommandRepository) Complete(ctx context.Context, id int64, result domain.Result, at time.Time) error {
+	return r.transition(ctx, id, []domain.CommandStatus{domain.CommandPending, domain.CommandRunning}, map[string]interface{}{
+		"status":          result.Status,
+		"response_status": result.ResponseStatus,
+		"response":        result.Response,
+		"completed_at":    at,
+		"updated_at":      at,
+	})
+}
+
+// transition updates the command when it is in one of from, and fails with persistence.ErrNotFound otherwise
+func (r *GormCommandRepository) transition(ctx context.Context, id int64, from []domain.CommandStatus, updates map[string]interface{}) error {
+	result := persistence.Conn(ctx, r.db).Model(&domain.Command{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return persistence.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormCommandRepository) DeleteCompletedBefore(ctx context.Context, t time.Time) (int64, error) {
+	result := persistence.Conn(ctx, r.db).Where("completed_at < ?", t).Delete(&domain.Command{})
+	return result.RowsAffected, persistence.TranslateError(result.Error)
+}
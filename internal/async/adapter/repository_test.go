@@ -0,0 +1,74 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newRepository(t *testing.T) domain.CommandRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.Command{}))
+	return adapter.NewGormCommandRepository(db)
+}
+
+func TestGormCommandRepository_Transitions(t *testing.T) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+
+	c := domain.NewCommand(ctx, "order.place", 7, now)
+	assert.NoError(t, repo.Create(ctx, c))
+
+	assert.NoError(t, repo.Start(ctx, c.ID, now))
+	assert.ErrorIs(t, repo.Start(ctx, c.ID, now), persistence.ErrNotFound, "a running command cannot start again")
+	assert.NoError(t, repo.Retry(ctx, c.ID))
+	assert.NoError(t, repo.Start(ctx, c.ID, now))
+
+	result := domain.Result{Status: domain.CommandSucceeded, ResponseStatus: 201, Response: `{"id":"ord_1"}`}
+	assert.NoError(t, repo.Complete(ctx, c.ID, result, now))
+	assert.ErrorIs(t, repo.Complete(ctx, c.ID, domain.Result{Status: domain.CommandFailed}, now), persistence.ErrNotFound, "the first result wins")
+
+	stored, err := repo.GetByPublicID(ctx, c.PublicID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CommandSucceeded, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, `{"id":"ord_1"}`, stored.Response)
+	assert.Equal(t, "default", stored.TenantID)
+
+	_, err = repo.GetByPublicID(ctx, "cmd_unknown")
+	assert.ErrorIs(t, err, domain.ErrCommandNotFound)
+}
+
+func TestGormCommandRepository_DeleteCompletedBefore(t *testing.T) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+
+	old := domain.NewCommand(ctx, "order.place", 7, now.Add(-48*time.Hour))
+	recent := domain.NewCommand(ctx, "order.place", 7, now)
+	pending := domain.NewCommand(ctx, "order.place", 7, now.Add(-48*time.Hour))
+	for _, c := range []*domain.Command{old, recent, pending} {
+		assert.NoError(t, repo.Create(ctx, c))
+	}
+	done := domain.Result{Status: domain.CommandSucceeded, ResponseStatus: 201}
+	assert.NoError(t, repo.Complete(ctx, old.ID, done, now.Add(-47*time.Hour)))
+	assert.NoError(t, repo.Complete(ctx, recent.ID, done, now))
+
+	deleted, err := repo.DeleteCompletedBefore(ctx, now.Add(-24*time.Hour))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.GetByID(ctx, old.ID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+	_, err = repo.GetByID(ctx, pending.ID)
+	assert.NoError(t, err, "pending commands are kept")
+}
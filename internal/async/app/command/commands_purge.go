@@ -0,0 +1,35 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+)
+
+// PurgeCompletedCommandsCommand deletes the commands whose result was kept long enough for clients to fetch it
+type PurgeCompletedCommandsCommand struct{}
+
+type PurgeCompletedCommandsHandler struct {
+	Commands domain.CommandRepository
+	// Retention is how long the result of a command stays available after it completed
+	Retention time.Duration
+	Now       func() time.Time
+}
+
+func (h *PurgeCompletedCommandsHandler) Handle(ctx context.Context, cmd PurgeCompletedCommandsCommand) error {
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	deleted, err := h.Commands.DeleteCompletedBefore(ctx, now().Add(-h.Retention))
+	if err != nil {
+		return fmt.Errorf("delete completed commands: %w", err)
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "purged completed commands", "count", deleted)
+	}
+	return nil
+}
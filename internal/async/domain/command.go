@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+)
+
+var ErrCommandNotFound = errors.New("command not found")
+
+// CommandPublicIDPrefix starts the public IDs of commands, e.g. "cmd_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const CommandPublicIDPrefix = "cmd"
+
+// CommandStatus is how far an accepted command got
+type CommandStatus string
+
+const (
+	CommandPending CommandStatus = "PENDING"
+	// CommandRunning is being processed by a worker
+	CommandRunning   CommandStatus = "RUNNING"
+	CommandSucceeded CommandStatus = "SUCCEEDED"
+	CommandFailed    CommandStatus = "FAILED"
+)
+
+// Command is a request accepted with 202 and processed by a background job. It keeps what the
+// request was bound to, so the job runs for the same tenant, mode and caller, and the response the
+// synchronous endpoint would have given, so clients polling for it see the same result.
+type Command struct {
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the command to clients polling for its result
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	// Kind is the job kind processing the command, e.g. "order.place"
+	Kind string `gorm:"type:varchar(100);not null"`
+	// OwnerID is the user the command acts for; they may read its result with the own permission
+	OwnerID int64 `gorm:"not null;index"`
+
+	TenantID string `gorm:"type:varchar(64);not null"`
+	Sandbox  bool   `gorm:"not null;default:false"`
+	// CallerID is the authenticated user who issued the command, nil for anonymous requests
+	CallerID *int64
+
+	Status   CommandStatus `gorm:"type:varchar(20);not null"`
+	Attempts int           `gorm:"not null;default:0"`
+	// ResponseStatus and Response are the HTTP status and JSON body of the final result
+	ResponseStatus int
+	Response       string `gorm:"type:text"`
+
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time
+	CompletedAt *time.Time `gorm:"index"`
+}
+
+func (Command) TableName() string {
+	return "async_commands"
+}
+
+// NewCommand creates the pending command of kind for ownerID, bound to the tenant, mode and caller of ctx
+func NewCommand(ctx context.Context, kind string, ownerID int64, at time.Time) *Command {
+	c := &Command{
+		PublicID:  publicid.New(CommandPublicIDPrefix),
+		Kind:      kind,
+		OwnerID:   ownerID,
+		TenantID:  tenant.FromContext(ctx),
+		Sandbox:   mode.FromContext(ctx).IsSandbox(),
+		Status:    CommandPending,
+		CreatedAt: at,
+	}
+	if callerID, ok := auth.UserID(ctx); ok {
+		c.CallerID = &callerID
+	}
+	return c
+}
+
+// Bind returns ctx bound to the tenant, mode and caller the command was issued with
+func (c *Command) Bind(ctx context.Context) context.Context {
+	ctx = tenant.WithID(ctx, c.TenantID)
+	ctx = mode.With(ctx, mode.Of(c.Sandbox))
+	if c.CallerID != nil {
+		ctx = auth.WithUserID(ctx, *c.CallerID)
+	}
+	return ctx
+}
+
+// Done reports whether the command has its final result
+func (c *Command) Done() bool {
+	return c.Status == CommandSucceeded || c.Status == CommandFailed
+}
+
+// Result is the final outcome of a command
+type Result struct {
+	Status         CommandStatus
+	ResponseStatus int
+	Response       string
+}
+
+type CommandRepository interface {
+	Create(ctx context.Context, c *Command) error
+	// GetByID and GetByPublicID return persistence.ErrNotFound for unknown commands
+	GetByID(ctx context.Context, id int64) (*Command, error)
+	GetByPublicID(ctx context.Context, publicID string) (*Command, error)
+	// Start moves a pending command to running and counts the attempt; it returns
+	// persistence.ErrNotFound when the command is not pending, so a command runs once at a time
+	Start(ctx context.Context, id int64, at time.Time) error
+	// Retry moves a running command back to pending
+	Retry(ctx context.Context, id int64) error
+	// Complete stores the result of a command that is not done yet; it returns
+	// persistence.ErrNotFound when the command is done already
+	Complete(ctx context.Context, id int64, result Result, at time.Time) error
+	// DeleteCompletedBefore removes the commands completed before t and returns how many it removed
+	DeleteCompletedBefore(ctx context.Context, t time.Time) (int64, error)
+}
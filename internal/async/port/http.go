@@ -0,0 +1,172 @@
+package port
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// CommandResponse is a command accepted with 202 and, once it is done, its result; ID is the
+// public "cmd_" ID
+type CommandResponse struct {
+	ID     string               `json:"id"`
+	Kind   string               `json:"kind"`
+	Status domain.CommandStatus `json:"status"`
+	// ResponseStatus and Response are the status and body the synchronous endpoint would have
+	// answered with; they are set once the command succeeded or failed
+	ResponseStatus int             `json:"response_status,omitempty"`
+	Response       json.RawMessage `json:"response,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty"`
+}
+
+// HTTPServer lets clients poll or stream the results of accepted commands
+type HTTPServer struct {
+	Commands domain.CommandRepository
+
+	// PollInterval is how often GET /commands/{id}/events checks the command
+	PollInterval time.Duration
+	// StreamTimeout ends a stream still waiting for the result; clients reconnect
+	StreamTimeout time.Duration
+
+	// Auth requires order:read:own for commands acting for the caller, or order:read:any; nil
+	// disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the command endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/commands/{id}",
+		Summary:  "Get the status of a command accepted with 202, and its result once it is done",
+		Tags:     []string{"commands"},
+		Response: CommandResponse{},
+		Handler:  s.getCommand,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/commands/{id}/events",
+		Summary:  "Stream the status of a command as server-sent \"status\" events until it is done",
+		Tags:     []string{"commands"},
+		Response: CommandResponse{},
+		Handler:  s.streamCommand,
+		StringID: true,
+	})
+}
+
+// WriteAccepted answers a request accepted as command c with 202, pointing to where its result will be
+func WriteAccepted(w http.ResponseWriter, c *domain.Command) {
+	w.Header().Set("Location", "/commands/"+c.PublicID)
+	httpx.WriteJSON(w, http.StatusAccepted, toCommandResponse(c))
+}
+
+func (s *HTTPServer) getCommand(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.ownedCommand(w, r)
+	if !ok {
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, toCommandResponse(c))
+}
+
+// streamCommand sends the command as a "status" event, then again whenever its status changes
+// until it is done or the stream times out
+func (s *HTTPServer) streamCommand(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.ownedCommand(w, r)
+	if !ok {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(c *domain.Command) error {
+		data, err := json.Marshal(toCommandResponse(c))
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	if send(c) != nil || c.Done() {
+		return
+	}
+
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(s.StreamTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timeout.C:
+			return
+		case <-ticker.C:
+		}
+
+		next, err := s.Commands.GetByID(r.Context(), c.ID)
+		if err != nil {
+			return
+		}
+		if next.Status != c.Status {
+			if send(next) != nil {
+				return
+			}
+		}
+		if next.Done() {
+			return
+		}
+		c = next
+	}
+}
+
+// ownedCommand loads the command of the {id} path parameter, writing the error response when
+// it is missing, belongs to another tenant or acts for another user
+func (s *HTTPServer) ownedCommand(w http.ResponseWriter, r *http.Request) (*domain.Command, bool) {
+	c, err := s.Commands.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err == nil && c.TenantID != tenant.FromContext(r.Context()) {
+		err = domain.ErrCommandNotFound
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrCommandNotFound) {
+			httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+			return nil, false
+		}
+		httpx.WriteError(w, err)
+		return nil, false
+	}
+	if err := auth.CheckOwned(r.Context(), s.Auth, userDomain.PermissionOrderReadAny, userDomain.PermissionOrderReadOwn, c.OwnerID); err != nil {
+		auth.WriteError(w, err)
+		return nil, false
+	}
+	return c, true
+}
+
+func toCommandResponse(c *domain.Command) CommandResponse {
+	resp := CommandResponse{
+		ID:             c.PublicID,
+		Kind:           c.Kind,
+		Status:         c.Status,
+		ResponseStatus: c.ResponseStatus,
+		CreatedAt:      c.CreatedAt,
+		CompletedAt:    c.CompletedAt,
+	}
+	if c.Response != "" {
+		resp.Response = json.RawMessage(c.Response)
+	}
+	return resp
+}
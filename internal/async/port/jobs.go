@@ -0,0 +1,28 @@
+package port
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// PurgeCompletedCommandsJob deletes the commands completed before the retention period
+type PurgeCompletedCommandsJob struct{}
+
+func (PurgeCompletedCommandsJob) Kind() string {
+	return "async.purge_completed_commands"
+}
+
+// JobServer runs the command use cases triggered by background jobs
+type JobServer struct {
+	PurgeCompletedCommands decorator.CommandHandler[command.PurgeCompletedCommandsCommand]
+}
+
+// RegisterJobs adds the command job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, _ PurgeCompletedCommandsJob) error {
+		return s.PurgeCompletedCommands.Handle(ctx, command.PurgeCompletedCommandsCommand{})
+	})
+}
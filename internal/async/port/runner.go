@@ -0,0 +1,170 @@
+package port
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// ErrorCodeCommandInterrupted is returned with 500 for a command whose worker died while running it;
+// the command may have taken effect, so clients check before issuing it again
+const ErrorCodeCommandInterrupted = "command_interrupted"
+
+// Enqueuer is the part of jobs.Queue the runner needs
+type Enqueuer interface {
+	Enqueue(ctx context.Context, job jobs.Job, opts ...jobs.EnqueueOption) error
+}
+
+// Runner accepts commands for background processing and runs them from their jobs. A command
+// runs at most once to completion: a server error hands it back to the job queue for another
+// attempt, but a worker dying mid-run fails it, since it may have taken effect.
+type Runner struct {
+	Commands domain.CommandRepository
+	Jobs     Enqueuer
+	// MaxAttempts is how many times a command failing with a server error is run before the failure is final
+	MaxAttempts int
+	Now         func() time.Time
+}
+
+// Preferred reports whether the client asked for an asynchronous response with "Prefer: respond-async"
+func Preferred(r *http.Request) bool {
+	for _, value := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(preference, ";")
+			name, _, _ = strings.Cut(name, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Accept stores a pending command acting for ownerID and enqueues the job processing it, built
+// from the ID of the command. The kind of the command is the kind of the job.
+func (r *Runner) Accept(ctx context.Context, ownerID int64, job func(commandID int64) jobs.Job) (*domain.Command, error) {
+	c := domain.NewCommand(ctx, job(0).Kind(), ownerID, r.now())
+	if err := r.Commands.Create(ctx, c); err != nil {
+		return nil, fmt.Errorf("save command: %w", err)
+	}
+	if err := r.Jobs.Enqueue(ctx, job(c.ID), jobs.WithUniqueKey("command-"+c.PublicID)); err != nil {
+		// Nothing will run the command, so it must not stay pending
+		r.complete(context.WithoutCancel(ctx), c, internalError(http.StatusText(http.StatusInternalServerError), ""))
+		return nil, fmt.Errorf("enqueue command %s: %w", c.PublicID, err)
+	}
+	return c, nil
+}
+
+// Run runs the command with commandID and stores its result. handle writes the response as the
+// synchronous endpoint would, with a context bound to the tenant, mode and caller of the command.
+// It returns an error to have the job retried.
+func (r *Runner) Run(ctx context.Context, commandID int64, handle func(ctx context.Context, w http.ResponseWriter)) error {
+	c, err := r.Commands.GetByID(ctx, commandID)
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		slog.WarnContext(ctx, "skipping job of purged command", "command_id", commandID)
+		return nil
+	case err != nil:
+		return fmt.Errorf("get command %d: %w", commandID, err)
+	}
+
+	switch c.Status {
+	case domain.CommandSucceeded, domain.CommandFailed:
+		return nil
+	case domain.CommandRunning:
+		slog.ErrorContext(ctx, "command interrupted while running", "command_id", c.PublicID, "kind", c.Kind)
+		r.complete(ctx, c, internalError("the command was interrupted; check whether it took effect before issuing it again", ErrorCodeCommandInterrupted))
+		return nil
+	}
+
+	if err := r.Commands.Start(ctx, c.ID, r.now()); err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("start command %s: %w", c.PublicID, err)
+	}
+	c.Attempts++
+
+	w := newResponseBuffer()
+	handle(c.Bind(ctx), w)
+	// A handler writing nothing answered 200 with an empty body
+	w.WriteHeader(http.StatusOK)
+
+	// Bookkeeping outlives a cancelled job, so a drained worker hands the command back
+	ctx = context.WithoutCancel(ctx)
+	if w.status >= http.StatusInternalServerError && c.Attempts < r.MaxAttempts {
+		if err := r.Commands.Retry(ctx, c.ID); err != nil {
+			return fmt.Errorf("hand command %s back: %w", c.PublicID, err)
+		}
+		return fmt.Errorf("command %s failed with status %d", c.PublicID, w.status)
+	}
+
+	result := domain.Result{Status: domain.CommandSucceeded, ResponseStatus: w.status, Response: w.body.String()}
+	if w.status >= http.StatusBadRequest {
+		result.Status = domain.CommandFailed
+	}
+	r.complete(ctx, c, result)
+	return nil
+}
+
+// complete stores the result of c; a command completed in the meantime keeps its result
+func (r *Runner) complete(ctx context.Context, c *domain.Command, result domain.Result) {
+	err := r.Commands.Complete(ctx, c.ID, result, r.now())
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		slog.ErrorContext(ctx, "storing command result failed", "command_id", c.PublicID, "error", err)
+	}
+}
+
+func (r *Runner) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// internalError is the failed result of a command that could not run
+func internalError(message, code string) domain.Result {
+	w := newResponseBuffer()
+	if code == "" {
+		httpx.WriteErrorStatus(w, http.StatusInternalServerError, errors.New(message))
+	} else {
+		httpx.WriteErrorCode(w, http.StatusInternalServerError, code, errors.New(message))
+	}
+	return domain.Result{Status: domain.CommandFailed, ResponseStatus: w.status, Response: w.body.String()}
+}
+
+// responseBuffer records the response a command would have sent
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: make(http.Header)}
+}
+
+func (w *responseBuffer) Header() http.Header {
+	return w.header
+}
+
+func (w *responseBuffer) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseBuffer) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
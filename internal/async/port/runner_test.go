@@ -0,0 +1,172 @@
+package port_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/async/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testJob struct {
+	CommandID int64 `json:"command_id"`
+}
+
+func (testJob) Kind() string {
+	return "test.run"
+}
+
+type recordingEnqueuer struct {
+	jobs []jobs.Job
+	err  error
+}
+
+func (e *recordingEnqueuer) Enqueue(ctx context.Context, job jobs.Job, opts ...jobs.EnqueueOption) error {
+	e.jobs = append(e.jobs, job)
+	return e.err
+}
+
+func newRunner(t *testing.T) (*port.Runner, *recordingEnqueuer) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.Command{}))
+	enqueuer := &recordingEnqueuer{}
+	return &port.Runner{Commands: adapter.NewGormCommandRepository(db), Jobs: enqueuer, MaxAttempts: 2}, enqueuer
+}
+
+func accept(t *testing.T, r *port.Runner, ctx context.Context) *domain.Command {
+	t.Helper()
+	c, err := r.Accept(ctx, 7, func(commandID int64) jobs.Job { return testJob{CommandID: commandID} })
+	if err != nil {
+		t.Fatalf("Expected the command accepted, got %v", err)
+	}
+	return c
+}
+
+func TestRunner_RunsCommandWithRequestContext(t *testing.T) {
+	r, enqueuer := newRunner(t)
+	ctx := auth.WithUserID(mode.With(tenant.WithID(context.Background(), "acme"), mode.Sandbox), 42)
+	c := accept(t, r, ctx)
+	assert.Equal(t, "test.run", c.Kind)
+	assert.Equal(t, []jobs.Job{testJob{CommandID: c.ID}}, enqueuer.jobs)
+
+	err := r.Run(context.Background(), c.ID, func(ctx context.Context, w http.ResponseWriter) {
+		callerID, _ := auth.UserID(ctx)
+		assert.Equal(t, int64(42), callerID)
+		assert.Equal(t, "acme", tenant.FromContext(ctx))
+		assert.True(t, mode.FromContext(ctx).IsSandbox())
+		httpx.WriteJSON(w, http.StatusCreated, map[string]string{"id": "ord_1"})
+	})
+
+	assert.NoError(t, err)
+	done, _ := r.Commands.GetByID(context.Background(), c.ID)
+	assert.Equal(t, domain.CommandSucceeded, done.Status)
+	assert.Equal(t, http.StatusCreated, done.ResponseStatus)
+	assert.JSONEq(t, `{"id":"ord_1"}`, done.Response)
+
+	ran := false
+	assert.NoError(t, r.Run(context.Background(), c.ID, func(ctx context.Context, w http.ResponseWriter) { ran = true }))
+	assert.False(t, ran, "a done command does not run again")
+}
+
+func TestRunner_StoresClientErrors(t *testing.T) {
+	r, _ := newRunner(t)
+	c := accept(t, r, context.Background())
+
+	err := r.Run(context.Background(), c.ID, func(ctx context.Context, w http.ResponseWriter) {
+		httpx.WriteErrorStatus(w, http.StatusConflict, errors.New("insufficient stock"))
+	})
+
+	assert.NoError(t, err)
+	done, _ := r.Commands.GetByID(context.Background(), c.ID)
+	assert.Equal(t, domain.CommandFailed, done.Status)
+	assert.Equal(t, http.StatusConflict, done.ResponseStatus)
+	assert.Contains(t, done.Response, "insufficient stock")
+}
+
+func TestRunner_RetriesServerErrors(t *testing.T) {
+	r, _ := newRunner(t)
+	c := accept(t, r, context.Background())
+	fail := func(ctx context.Context, w http.ResponseWriter) {
+		httpx.WriteError(w, errors.New("database unavailable"))
+	}
+
+	assert.Error(t, r.Run(context.Background(), c.ID, fail), "the job is retried")
+	retried, _ := r.Commands.GetByID(context.Background(), c.ID)
+	assert.Equal(t, domain.CommandPending, retried.Status)
+
+	assert.NoError(t, r.Run(context.Background(), c.ID, fail), "the last attempt is final")
+	done, _ := r.Commands.GetByID(context.Background(), c.ID)
+	assert.Equal(t, domain.CommandFailed, done.Status)
+	assert.Equal(t, http.StatusInternalServerError, done.ResponseStatus)
+}
+
+func TestRunner_FailsInterruptedCommands(t *testing.T) {
+	r, _ := newRunner(t)
+	c := accept(t, r, context.Background())
+	assert.NoError(t, r.Commands.Start(context.Background(), c.ID, c.CreatedAt))
+
+	ran := false
+	err := r.Run(context.Background(), c.ID, func(ctx context.Context, w http.ResponseWriter) { ran = true })
+
+	assert.NoError(t, err)
+	assert.False(t, ran, "a command that may have taken effect is not run twice")
+	done, _ := r.Commands.GetByID(context.Background(), c.ID)
+	assert.Equal(t, domain.CommandFailed, done.Status)
+	assert.Contains(t, done.Response, port.ErrorCodeCommandInterrupted)
+}
+
+func TestRunner_FailsCommandsThatCannotBeEnqueued(t *testing.T) {
+	r, enqueuer := newRunner(t)
+	enqueuer.err = errors.New("queue down")
+
+	_, err := r.Accept(context.Background(), 7, func(commandID int64) jobs.Job { return testJob{CommandID: commandID} })
+
+	assert.Error(t, err)
+	c, _ := r.Commands.GetByID(context.Background(), 1)
+	assert.Equal(t, domain.CommandFailed, c.Status)
+}
+
+func TestPreferred(t *testing.T) {
+	tests := map[string]bool{
+		"":                              false,
+		"respond-async":                 true,
+		"return=minimal, respond-async": true,
+		"Respond-Async; wait=10":        true,
+		"handling=lenient":              false,
+	}
+	for header, want := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		if header != "" {
+			r.Header.Set("Prefer", header)
+		}
+		assert.Equal(t, want, port.Preferred(r), header)
+	}
+}
+
+func TestWriteAccepted(t *testing.T) {
+	c := domain.NewCommand(context.Background(), "order.place", 7, time.Now())
+	w := httptest.NewRecorder()
+
+	port.WriteAccepted(w, c)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/commands/"+c.PublicID, w.Header().Get("Location"))
+	var resp port.CommandResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, domain.CommandPending, resp.Status)
+}
@@ -0,0 +1,283 @@
+package adapter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// beforeKey holds the rows an update or delete is about to change
+const beforeKey = "audit:before"
+
+// Plugin records every create, update and delete made through GORM models into audit_logs.
+// Rows are read back before and after the write, so the trail shows stored values even for
+// expression updates such as stock = stock - 1. Entries are written on the connection of the
+// write, which puts them in the same transaction. Raw SQL is not audited.
+type Plugin struct {
+	excluded map[string]bool
+	redacted map[string]bool
+	now      func() time.Time
+}
+
+type PluginOption func(*Plugin)
+
+// WithExcludedTables skips high volume tables whose history has no audit value, e.g. job records
+func WithExcludedTables(tables ...string) PluginOption {
+	return func(p *Plugin) {
+		for _, t := range tables {
+			p.excluded[t] = true
+		}
+	}
+}
+
+// WithRedactedColumns records that sensitive columns changed without storing their values
+func WithRedactedColumns(columns ...string) PluginOption {
+	return func(p *Plugin) {
+		for _, c := range columns {
+			p.redacted[c] = true
+		}
+	}
+}
+
+func NewPlugin(opts ...PluginOption) *Plugin {
+	p := &Plugin{
+		excluded: map[string]bool{domain.Entry{}.TableName(): true},
+		redacted: make(map[string]bool),
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Plugin) Name() string {
+	return "audit"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().After("gorm:create").Register("audit:after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("audit:before_update", p.captureBefore); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("audit:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("audit:before_delete", p.captureBefore); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("audit:after_delete", p.afterDelete)
+}
+
+func (p *Plugin) audited(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && stmt.Schema != nil && len(stmt.Schema.PrimaryFields) > 0 && !p.excluded[stmt.Table]
+}
+
+func (p *Plugin) afterCreate(db *gorm.DB) {
+	if !p.audited(db) || db.RowsAffected == 0 {
+		return
+	}
+
+	var entries []domain.Entry
+	for _, keys := range primaryKeysOf(db.Statement) {
+		after, err := p.load(db, keys...)
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("audit: load created row: %w", err))
+			return
+		}
+		// Rows skipped by ON CONFLICT DO NOTHING are not there to audit
+		for _, row := range after {
+			entries = append(entries, p.entry(db, domain.ActionCreate, row, nil, row))
+		}
+	}
+	p.record(db, entries)
+}
+
+// captureBefore stores the rows an update or delete matches; writes matching nothing are not audited
+func (p *Plugin) captureBefore(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	conditions := conditionsOf(db.Statement)
+	if len(conditions) == 0 {
+		return
+	}
+
+	rows, err := p.load(db, conditions...)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: load rows before write: %w", err))
+		return
+	}
+	db.InstanceSet(beforeKey, rows)
+}
+
+func (p *Plugin) afterUpdate(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+
+	var entries []domain.Entry
+	for _, before := range p.before(db) {
+		after, err := p.load(db, primaryKeyConditions(db.Statement.Schema, before)...)
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("audit: load updated row: %w", err))
+			return
+		}
+		if len(after) == 0 {
+			continue
+		}
+		entry := p.entry(db, domain.ActionUpdate, before, before, after[0])
+		if len(entry.Changes) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	p.record(db, entries)
+}
+
+func (p *Plugin) afterDelete(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+
+	var entries []domain.Entry
+	for _, before := range p.before(db) {
+		entries = append(entries, p.entry(db, domain.ActionDelete, before, before, nil))
+	}
+	p.record(db, entries)
+}
+
+func (p *Plugin) before(db *gorm.DB) []map[string]any {
+	rows, _ := db.InstanceGet(beforeKey)
+	before, _ := rows.([]map[string]any)
+	return before
+}
+
+// load reads rows of the statement's table on the statement's connection, so a write inside
+// a transaction sees its own uncommitted changes; outside one it reads from the primary
+func (p *Plugin) load(db *gorm.DB, conditions ...clause.Expression) ([]map[string]any, error) {
+	var rows []map[string]any
+	// Context goes into the same Session call; a second Session would drop NewDB and reuse the write statement
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true, Context: persistence.WithPrimary(db.Statement.Context)}).
+		Table(db.Statement.Table).
+		Clauses(clause.Where{Exprs: conditions}).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for column, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[column] = string(b)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func (p *Plugin) entry(db *gorm.DB, action domain.Action, row, before, after map[string]any) domain.Entry {
+	changes := domain.Diff(before, after)
+	for column, change := range changes {
+		if p.redacted[column] {
+			changes[column] = redact(change)
+		}
+	}
+
+	ctx := db.Statement.Context
+	return domain.Entry{
+		EntityType: db.Statement.Table,
+		EntityID:   entityID(db.Statement.Schema, row),
+		Action:     action,
+		Actor:      domain.ActorFromContext(ctx),
+		TenantID:   tenant.FromContext(ctx),
+		Changes:    changes,
+		CreatedAt:  p.now(),
+	}
+}
+
+func (p *Plugin) record(db *gorm.DB, entries []domain.Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true, Context: db.Statement.Context}).Create(&entries).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("audit: record %d entries: %w", len(entries), err))
+	}
+}
+
+func redact(c domain.Change) domain.Change {
+	if c.From != nil {
+		c.From = domain.RedactedValue
+	}
+	if c.To != nil {
+		c.To = domain.RedactedValue
+	}
+	return c
+}
+
+// entityID joins the primary key values of a row, e.g. "42" or "7:3" for composite keys
+func entityID(s *schema.Schema, row map[string]any) string {
+	parts := make([]string, len(s.PrimaryFields))
+	for i, f := range s.PrimaryFields {
+		parts[i] = fmt.Sprint(row[f.DBName])
+	}
+	return strings.Join(parts, ":")
+}
+
+func primaryKeyConditions(s *schema.Schema, row map[string]any) []clause.Expression {
+	conditions := make([]clause.Expression, len(s.PrimaryFields))
+	for i, f := range s.PrimaryFields {
+		conditions[i] = clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: row[f.DBName]}
+	}
+	return conditions
+}
+
+// primaryKeysOf returns a condition set per model in the statement whose primary key is set
+func primaryKeysOf(stmt *gorm.Statement) [][]clause.Expression {
+	var keys [][]clause.Expression
+	addModel := func(rv reflect.Value) {
+		var conditions []clause.Expression
+		for _, f := range stmt.Schema.PrimaryFields {
+			value, zero := f.ValueOf(stmt.Context, rv)
+			if zero {
+				return
+			}
+			conditions = append(conditions, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: value})
+		}
+		keys = append(keys, conditions)
+	}
+
+	rv := reflect.Indirect(stmt.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			addModel(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		addModel(rv)
+	}
+	return keys
+}
+
+// conditionsOf combines the WHERE clause of an update or delete with the primary key of its model
+func conditionsOf(stmt *gorm.Statement) []clause.Expression {
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+	if keys := primaryKeysOf(stmt); len(keys) == 1 {
+		conditions = append(conditions, keys[0]...)
+	}
+	return conditions
+}
@@ -0,0 +1,97 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/audit/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID     int64 `gorm:"primaryKey"`
+	Name   string
+	Stock  int
+	Secret string
+}
+
+type event struct {
+	ID   int64 `gorm:"primaryKey"`
+	Name string
+}
+
+func setupAuditedDB(t *testing.T) (*gorm.DB, domain.EntryRepository) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Entry{}, &widget{}, &event{}))
+	require.NoError(t, db.Use(adapter.NewPlugin(adapter.WithExcludedTables("events"), adapter.WithRedactedColumns("secret"))))
+	return db, adapter.NewGormEntryRepository(db)
+}
+
+func TestPlugin_RecordsWrites(t *testing.T) {
+	db, repo := setupAuditedDB(t)
+	ctx := tenant.WithID(auth.WithUserID(context.Background(), 42), "acme")
+
+	w := &widget{Name: "Bolt", Stock: 10, Secret: "s3cret"}
+	require.NoError(t, db.WithContext(ctx).Create(w).Error)
+	require.NoError(t, db.WithContext(ctx).Model(&widget{}).Where("id = ?", w.ID).Update("stock", gorm.Expr("stock - ?", 3)).Error)
+	w.Name, w.Stock, w.Secret = "Nut", 7, "changed"
+	require.NoError(t, db.Save(w).Error)
+	require.NoError(t, db.WithContext(ctx).Model(w).Update("stock", 7).Error, "writes that change nothing are not recorded")
+	require.NoError(t, db.WithContext(ctx).Delete(w).Error)
+
+	entries, err := repo.ListByEntity(ctx, "widgets", "1", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	deleted, renamed, decremented, created := entries[0], entries[1], entries[2], entries[3]
+
+	assert.Equal(t, domain.ActionCreate, created.Action)
+	assert.Equal(t, "user:42", created.Actor)
+	assert.Equal(t, "acme", created.TenantID)
+	assert.Equal(t, "Bolt", created.Changes["name"].To)
+	assert.Equal(t, domain.RedactedValue, created.Changes["secret"].To)
+
+	assert.Equal(t, domain.ActionUpdate, decremented.Action)
+	assert.Equal(t, map[string]domain.Change{"stock": {From: float64(10), To: float64(7)}}, decremented.Changes)
+
+	assert.Equal(t, domain.ActorSystem, renamed.Actor)
+	assert.Equal(t, domain.Change{From: "Bolt", To: "Nut"}, renamed.Changes["name"])
+	assert.Equal(t, domain.Change{From: domain.RedactedValue, To: domain.RedactedValue}, renamed.Changes["secret"])
+	assert.NotContains(t, renamed.Changes, "stock")
+
+	assert.Equal(t, domain.ActionDelete, deleted.Action)
+	assert.Equal(t, "Nut", deleted.Changes["name"].From)
+	assert.Nil(t, deleted.Changes["name"].To)
+}
+
+func TestPlugin_SkipsExcludedTables(t *testing.T) {
+	db, repo := setupAuditedDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&event{Name: "tick"}).Error)
+
+	entries, err := repo.ListByEntity(ctx, "events", "1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestPlugin_RollsBackWithTheWrite(t *testing.T) {
+	db, repo := setupAuditedDB(t)
+	ctx := context.Background()
+
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&widget{ID: 5, Name: "Washer"}).Error)
+		return assert.AnError
+	})
+
+	entries, err := repo.ListByEntity(ctx, "widgets", "5", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,30 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormEntryRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEntryRepository(db *gorm.DB) domain.EntryRepository {
+	return &GormEntryRepository{db: db}
+}
+
+func (r *GormEntryRepository) ListByEntity(ctx context.Context, entityType, entityID string, limit int) ([]domain.Entry, error) {
+	var entries []domain.Entry
+	err := r.db.WithContext(ctx).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return entries, nil
+}
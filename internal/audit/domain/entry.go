@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/repair"
+)
+
+// ActorSystem is the actor of writes made without an authenticated user, e.g. by background jobs
+const ActorSystem = "system"
+
+// RedactedValue replaces the values of sensitive columns such as password hashes
+const RedactedValue = "[redacted]"
+
+// Action is the kind of write recorded in the audit trail
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Change is the value of a column before and after a write; From is nil for creates, To for deletes
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Entry records who changed which entity, how and when
+type Entry struct {
+	ID         int64             `gorm:"primaryKey"`
+	EntityType string            `gorm:"type:varchar(64);not null;index:idx_audit_logs_entity,priority:1"`
+	EntityID   string            `gorm:"type:varchar(255);not null;index:idx_audit_logs_entity,priority:2"`
+	Action     Action            `gorm:"type:varchar(10);not null"`
+	Actor      string            `gorm:"type:varchar(64);not null"`
+	TenantID   string            `gorm:"type:varchar(64);not null"`
+	Changes    map[string]Change `gorm:"serializer:json;type:text"`
+	CreatedAt  time.Time         `gorm:"not null;index"`
+}
+
+func (Entry) TableName() string {
+	return "audit_logs"
+}
+
+// ActorFromContext names the authenticated user of ctx as "user:<id>", the data repair script it
+// runs in as "repair:<script>", or ActorSystem
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := repair.Actor(ctx); ok {
+		return actor
+	}
+	if id, ok := auth.UserID(ctx); ok {
+		return "user:" + strconv.FormatInt(id, 10)
+	}
+	return ActorSystem
+}
+
+// Diff returns the columns whose value differs between two snapshots of a row
+func Diff(before, after map[string]any) map[string]Change {
+	changes := make(map[string]Change)
+	for column, to := range after {
+		from := before[column]
+		if !reflect.DeepEqual(from, to) {
+			changes[column] = Change{From: from, To: to}
+		}
+	}
+	for column, from := range before {
+		if _, ok := after[column]; !ok {
+			changes[column] = Change{From: from}
+		}
+	}
+	return changes
+}
+
+type EntryRepository interface {
+	// ListByEntity returns the newest entries of an entity first
+	ListByEntity(ctx context.Context, entityType, entityID string, limit int) ([]Entry, error)
+}
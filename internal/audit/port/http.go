@@ -0,0 +1,101 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// AuditEntryResponse is a single recorded write
+type AuditEntryResponse struct {
+	ID         int64                    `json:"id"`
+	EntityType string                   `json:"entity_type"`
+	EntityID   string                   `json:"entity_id"`
+	Action     domain.Action            `json:"action"`
+	Actor      string                   `json:"actor"`
+	TenantID   string                   `json:"tenant_id"`
+	Changes    map[string]domain.Change `json:"changes"`
+	CreatedAt  time.Time                `json:"created_at"`
+}
+
+// AuditLogResponse lists the history of an entity, newest first
+type AuditLogResponse struct {
+	Entries []dto.Sparse[AuditEntryResponse] `json:"entries"`
+}
+
+// HTTPServer exposes the audit log over HTTP
+type HTTPServer struct {
+	Entries domain.EntryRepository
+
+	// Auth restricts the audit log to staff; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the audit endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/audit-logs",
+		Summary:  "List the recorded changes of an entity, e.g. ?entity_type=orders&entity_id=42&fields=action,actor,created_at",
+		Tags:     []string{"audit"},
+		Response: AuditLogResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionAuditRead, s.listEntries),
+	})
+}
+
+func (s *HTTPServer) listEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	entityType, entityID := query.Get("entity_type"), query.Get("entity_id")
+
+	var errs validation.Errors
+	errs.Check(entityType != "", "entity_type", "is required")
+	errs.Check(entityID != "", "entity_id", "is required")
+	limit := defaultLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxLimit {
+			errs.Add("limit", fmt.Sprintf("must be between 1 and %d, got %q", maxLimit, value))
+		}
+		limit = n
+	}
+	fields, err := dto.ParseFields[AuditEntryResponse](r)
+	errs.Merge("", err)
+	if err := errs.Err(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	entries, err := s.Entries.ListByEntity(r.Context(), entityType, entityID, limit)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, AuditLogResponse{Entries: dto.Map(entries, toAuditEntryResponse, fields)})
+}
+
+func toAuditEntryResponse(e *domain.Entry) AuditEntryResponse {
+	return AuditEntryResponse{
+		ID:         e.ID,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     e.Action,
+		Actor:      e.Actor,
+		TenantID:   e.TenantID,
+		Changes:    e.Changes,
+		CreatedAt:  e.CreatedAt,
+	}
+}
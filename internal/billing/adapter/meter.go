@@ -0,0 +1,96 @@
+package adapter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+)
+
+// Meter counts API calls in memory and flushes the aggregated counters to the repository
+// periodically, so requests never wait on a database write
+type Meter struct {
+	repo     domain.UsageRepository
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[domain.UsageKey]int64
+
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func NewMeter(repo domain.UsageRepository, interval time.Duration) *Meter {
+	return &Meter{
+		repo:     repo,
+		interval: interval,
+		now:      time.Now,
+		pending:  make(map[domain.UsageKey]int64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Record counts one call; it only touches memory
+func (m *Meter) Record(tenantID, apiKeyID string) {
+	key := domain.UsageKey{TenantID: tenantID, APIKeyID: apiKeyID, Day: domain.DayOf(m.now())}
+
+	m.mu.Lock()
+	m.pending[key]++
+	m.mu.Unlock()
+}
+
+// Start flushes in the background every interval until Close
+func (m *Meter) Start() {
+	m.started = true
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.Flush(context.Background()); err != nil {
+					slog.Error("flushing API usage failed", "error", err)
+				}
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Flush writes the pending counters; on failure they are kept for the next flush
+func (m *Meter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	batch := m.pending
+	m.pending = make(map[domain.UsageKey]int64)
+	m.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := m.repo.AddCalls(ctx, batch); err != nil {
+		m.mu.Lock()
+		for key, n := range batch {
+			m.pending[key] += n
+		}
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close stops the background loop and flushes what is left
+func (m *Meter) Close(ctx context.Context) error {
+	if m.started {
+		close(m.stop)
+		<-m.done
+	}
+	return m.Flush(ctx)
+}
@@ -0,0 +1,75 @@
+package adapter_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUsageRepository struct {
+	domain.UsageRepository
+	mu      sync.Mutex
+	err     error
+	flushed []map[domain.UsageKey]int64
+}
+
+func (r *fakeUsageRepository) AddCalls(ctx context.Context, calls map[domain.UsageKey]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.flushed = append(r.flushed, calls)
+	return nil
+}
+
+func totalCalls(batch map[domain.UsageKey]int64, tenantID string) int64 {
+	var total int64
+	for key, n := range batch {
+		if key.TenantID == tenantID {
+			total += n
+		}
+	}
+	return total
+}
+
+func TestMeter_FlushAggregatesCalls(t *testing.T) {
+	repo := &fakeUsageRepository{}
+	meter := adapter.NewMeter(repo, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		meter.Record("acme", "key-1")
+	}
+	meter.Record("globex", "")
+
+	assert.NoError(t, meter.Flush(context.Background()))
+	if assert.Len(t, repo.flushed, 1) {
+		assert.Len(t, repo.flushed[0], 2, "one row per tenant, key and day")
+		assert.Equal(t, int64(3), totalCalls(repo.flushed[0], "acme"))
+	}
+
+	assert.NoError(t, meter.Flush(context.Background()))
+	assert.Len(t, repo.flushed, 1, "empty flushes don't hit the repository")
+}
+
+func TestMeter_KeepsCallsWhenFlushFails(t *testing.T) {
+	repo := &fakeUsageRepository{err: errors.New("db down")}
+	meter := adapter.NewMeter(repo, time.Hour)
+
+	meter.Record("acme", "")
+	assert.Error(t, meter.Flush(context.Background()))
+
+	repo.err = nil
+	meter.Record("acme", "")
+	assert.NoError(t, meter.Close(context.Background()))
+
+	if assert.Len(t, repo.flushed, 1) {
+		assert.Equal(t, int64(2), totalCalls(repo.flushed[0], "acme"))
+	}
+}
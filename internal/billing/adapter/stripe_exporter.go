@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+)
+
+// DefaultStripeURL is the Stripe API base URL
+const DefaultStripeURL = "https://api.stripe.com"
+
+// StripeUsageExporter reports daily usage as Stripe billing meter events.
+// The event identifier is derived from tenant and day, so Stripe drops re-exports of the same day.
+type StripeUsageExporter struct {
+	baseURL   string
+	secretKey secret.Source
+	eventName string
+	accounts  domain.BillingAccountRepository
+	client    *http.Client
+}
+
+func NewStripeUsageExporter(baseURL string, secretKey secret.Source, eventName string, accounts domain.BillingAccountRepository, client *http.Client) domain.UsageExporter {
+	if baseURL == "" {
+		baseURL = DefaultStripeURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &StripeUsageExporter{baseURL: baseURL, secretKey: secretKey, eventName: eventName, accounts: accounts, client: client}
+}
+
+func (e *StripeUsageExporter) ExportUsage(ctx context.Context, statement domain.UsageStatement) error {
+	account, err := e.accounts.GetByTenant(ctx, statement.TenantID)
+	if err != nil {
+		return fmt.Errorf("get billing account of tenant %s: %w", statement.TenantID, err)
+	}
+
+	day := domain.DayOf(statement.Day)
+	form := url.Values{
+		"event_name":                  {e.eventName},
+		"identifier":                  {statement.TenantID + "-" + day.Format("2006-01-02")},
+		"timestamp":                   {strconv.FormatInt(day.Unix(), 10)},
+		"payload[stripe_customer_id]": {account.ProviderCustomerID},
+		"payload[value]":              {strconv.FormatInt(statement.Calls, 10)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/v1/billing/meter_events", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	key, err := e.secretKey.Secret(ctx)
+	if err != nil {
+		return fmt.Errorf("read stripe secret key: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe meter event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("stripe meter event: unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// LogUsageExporter only logs statements; used when no billing provider is configured
+type LogUsageExporter struct{}
+
+func (LogUsageExporter) ExportUsage(ctx context.Context, statement domain.UsageStatement) error {
+	slog.InfoContext(ctx, "usage export skipped, no billing provider configured",
+		"tenant_id", statement.TenantID, "day", statement.Day.Format("2006-01-02"), "calls", statement.Calls)
+	return nil
+}
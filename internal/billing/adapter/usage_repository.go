@@ -0,0 +1,96 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUsageRepository(db *gorm.DB) domain.UsageRepository {
+	return &GormUsageRepository{db: db}
+}
+
+// AddCalls upserts all counters in one statement, adding to existing rows
+func (r *GormUsageRepository) AddCalls(ctx context.Context, calls map[domain.UsageKey]int64) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]domain.APIUsage, 0, len(calls))
+	for key, n := range calls {
+		rows = append(rows, domain.APIUsage{
+			TenantID:  key.TenantID,
+			APIKeyID:  key.APIKeyID,
+			Day:       domain.DayOf(key.Day),
+			Calls:     n,
+			UpdatedAt: now,
+		})
+	}
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "api_key_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"calls":      gorm.Expr("api_usage.calls + excluded.calls"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&rows).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormUsageRepository) ListUsage(ctx context.Context, tenantID string, from, to time.Time) ([]domain.APIUsage, error) {
+	var usage []domain.APIUsage
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND day BETWEEN ? AND ?", tenantID, domain.DayOf(from), domain.DayOf(to)).
+		Order("day, api_key_id").
+		Find(&usage).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return usage, nil
+}
+
+func (r *GormUsageRepository) TotalsByTenant(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	var rows []struct {
+		TenantID string
+		Calls    int64
+	}
+	err := r.db.WithContext(ctx).Model(&domain.APIUsage{}).
+		Select("tenant_id, SUM(calls) AS calls").
+		Where("day BETWEEN ? AND ?", domain.DayOf(from), domain.DayOf(to)).
+		Group("tenant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	totals := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		totals[row.TenantID] = row.Calls
+	}
+	return totals, nil
+}
+
+type GormBillingAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewGormBillingAccountRepository(db *gorm.DB) domain.BillingAccountRepository {
+	return &GormBillingAccountRepository{db: db}
+}
+
+func (r *GormBillingAccountRepository) GetByTenant(ctx context.Context, tenantID string) (*domain.BillingAccount, error) {
+	var account domain.BillingAccount
+	if err := r.db.WithContext(ctx).First(&account, "tenant_id = ?", tenantID).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &account, nil
+}
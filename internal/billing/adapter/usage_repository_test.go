@@ -0,0 +1,48 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.APIUsage{}, &domain.BillingAccount{}))
+	return db
+}
+
+func TestGormUsageRepository_AddCallsAccumulates(t *testing.T) {
+	repo := adapter.NewGormUsageRepository(setupTestDB(t))
+	ctx := context.Background()
+	may18 := time.Date(2024, 5, 18, 0, 0, 0, 0, time.UTC)
+	may19 := may18.AddDate(0, 0, 1)
+
+	assert.NoError(t, repo.AddCalls(ctx, map[domain.UsageKey]int64{
+		{TenantID: "acme", APIKeyID: "key-1", Day: may18}: 10,
+		{TenantID: "acme", Day: may19}:                    5,
+		{TenantID: "globex", Day: may18}:                  7,
+	}))
+	assert.NoError(t, repo.AddCalls(ctx, map[domain.UsageKey]int64{
+		{TenantID: "acme", APIKeyID: "key-1", Day: may18}: 3,
+	}))
+
+	usage, err := repo.ListUsage(ctx, "acme", may18, may19)
+	assert.NoError(t, err)
+	if assert.Len(t, usage, 2) {
+		assert.Equal(t, int64(13), usage[0].Calls)
+		assert.Equal(t, "key-1", usage[0].APIKeyID)
+		assert.Equal(t, int64(5), usage[1].Calls)
+	}
+
+	totals, err := repo.TotalsByTenant(ctx, may18, may18)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"acme": 13, "globex": 7}, totals)
+}
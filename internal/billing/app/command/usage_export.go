@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	billingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+)
+
+// ExportUsageCommand sends the usage of one finished day to the billing provider
+type ExportUsageCommand struct {
+	Day time.Time
+}
+
+type ExportUsageHandler struct {
+	UsageRepo billingDomain.UsageRepository
+	Exporter  billingDomain.UsageExporter
+}
+
+// Handle exports every tenant with usage on the day; one failing tenant doesn't block the others
+func (h *ExportUsageHandler) Handle(ctx context.Context, cmd ExportUsageCommand) error {
+	day := billingDomain.DayOf(cmd.Day)
+
+	totals, err := h.UsageRepo.TotalsByTenant(ctx, day, day)
+	if err != nil {
+		return fmt.Errorf("get usage totals: %w", err)
+	}
+
+	var errs []error
+	for tenantID, calls := range totals {
+		statement := billingDomain.UsageStatement{TenantID: tenantID, Day: day, Calls: calls}
+		if err := h.Exporter.ExportUsage(ctx, statement); err != nil {
+			errs = append(errs, fmt.Errorf("export usage of tenant %s: %w", tenantID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
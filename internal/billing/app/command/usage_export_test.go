@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	billingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+)
+
+// MockUsageRepository returns fixed totals
+type MockUsageRepository struct {
+	billingDomain.UsageRepository
+	totals map[string]int64
+}
+
+func (m *MockUsageRepository) TotalsByTenant(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	return m.totals, nil
+}
+
+// MockExporter records statements and fails for the tenants in failFor
+type MockExporter struct {
+	statements []billingDomain.UsageStatement
+	failFor    map[string]bool
+}
+
+func (m *MockExporter) ExportUsage(ctx context.Context, statement billingDomain.UsageStatement) error {
+	if m.failFor[statement.TenantID] {
+		return errors.New("provider unavailable")
+	}
+	m.statements = append(m.statements, statement)
+	return nil
+}
+
+func TestExportUsageHandler_Handle(t *testing.T) {
+	exporter := &MockExporter{failFor: map[string]bool{"globex": true}}
+	handler := &ExportUsageHandler{
+		UsageRepo: &MockUsageRepository{totals: map[string]int64{"acme": 1200, "globex": 30}},
+		Exporter:  exporter,
+	}
+
+	err := handler.Handle(context.Background(), ExportUsageCommand{Day: time.Date(2024, 5, 18, 15, 30, 0, 0, time.UTC)})
+
+	if err == nil {
+		t.Error("Expected the failing tenant to be reported")
+	}
+	if len(exporter.statements) != 1 {
+		t.Fatalf("Expected the other tenant to be exported, got %d statements", len(exporter.statements))
+	}
+	statement := exporter.statements[0]
+	if statement.TenantID != "acme" || statement.Calls != 1200 || !statement.Day.Equal(time.Date(2024, 5, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected statement: %+v", statement)
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// PriceTier charges PerThousand for every thousand calls up to UpTo; UpTo 0 means no upper bound
+type PriceTier struct {
+	UpTo        int64
+	PerThousand int64 // minor units
+}
+
+// Pricing is a graduated price list: each call is charged at the rate of the tier it falls in
+type Pricing struct {
+	Currency string
+	Tiers    []PriceTier
+}
+
+// DefaultPricing bills the platform API per calendar month
+var DefaultPricing = Pricing{
+	Currency: "USD",
+	Tiers: []PriceTier{
+		{UpTo: 100_000, PerThousand: 0},
+		{UpTo: 1_000_000, PerThousand: 50},
+		{UpTo: 0, PerThousand: 20},
+	},
+}
+
+// Charge prices calls across the tiers, rounding half up to the minor unit once at the end
+func (p Pricing) Charge(calls int64) (money.Money, error) {
+	var thousandths, previous int64
+	for _, tier := range p.Tiers {
+		if calls <= previous {
+			break
+		}
+		inTier := calls - previous
+		if tier.UpTo > 0 {
+			if tier.UpTo <= previous {
+				return money.Money{}, fmt.Errorf("price tiers must be ascending, got %d after %d", tier.UpTo, previous)
+			}
+			inTier = min(inTier, tier.UpTo-previous)
+		}
+		thousandths += inTier * tier.PerThousand
+		if tier.UpTo == 0 {
+			previous = calls
+			break
+		}
+		previous = tier.UpTo
+	}
+
+	if calls > previous {
+		return money.Money{}, fmt.Errorf("price tiers end at %d calls, got %d", previous, calls)
+	}
+	return money.New((thousandths+500)/1000, p.Currency)
+}
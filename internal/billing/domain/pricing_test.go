@@ -0,0 +1,40 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPricing_Charge(t *testing.T) {
+	tests := []struct {
+		name     string
+		calls    int64
+		expected int64
+	}{
+		{name: "no calls", calls: 0, expected: 0},
+		{name: "within free tier", calls: 100_000, expected: 0},
+		{name: "into second tier", calls: 101_000, expected: 50},
+		{name: "second tier rounds half up", calls: 100_010, expected: 1},
+		{name: "end of second tier", calls: 1_000_000, expected: 45_000},
+		{name: "into open tier", calls: 3_000_000, expected: 45_000 + 40_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charge, err := domain.DefaultPricing.Charge(tt.calls)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, charge.Amount)
+			assert.Equal(t, "USD", charge.Currency)
+		})
+	}
+}
+
+func TestPricing_ChargeBeyondLastTier(t *testing.T) {
+	pricing := domain.Pricing{Currency: "EUR", Tiers: []domain.PriceTier{{UpTo: 1000, PerThousand: 10}}}
+
+	_, err := pricing.Charge(1001)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// UsageReport summarizes a tenant's API calls over a date range with the charge they accrue
+type UsageReport struct {
+	TenantID   string
+	From       time.Time
+	To         time.Time
+	Days       []APIUsage
+	TotalCalls int64
+	Charge     money.Money
+}
+
+// NewUsageReport prices the total calls of the range as one billing period
+func NewUsageReport(tenantID string, from, to time.Time, days []APIUsage, pricing Pricing) (*UsageReport, error) {
+	report := &UsageReport{TenantID: tenantID, From: DayOf(from), To: DayOf(to), Days: days}
+	for _, day := range days {
+		report.TotalCalls += day.Calls
+	}
+
+	charge, err := pricing.Charge(report.TotalCalls)
+	if err != nil {
+		return nil, err
+	}
+	report.Charge = charge
+	return report, nil
+}
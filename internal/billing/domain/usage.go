@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// APIUsage is the number of API calls made by a tenant with one API key on one UTC day.
+// Calls without an API key are counted under an empty APIKeyID.
+type APIUsage struct {
+	TenantID  string    `gorm:"primaryKey;type:varchar(64)"`
+	APIKeyID  string    `gorm:"primaryKey;type:varchar(64)"`
+	Day       time.Time `gorm:"primaryKey;type:date"`
+	Calls     int64     `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (APIUsage) TableName() string {
+	return "api_usage"
+}
+
+// UsageKey identifies an APIUsage row
+type UsageKey struct {
+	TenantID string
+	APIKeyID string
+	Day      time.Time
+}
+
+// DayOf truncates t to the UTC day it falls on
+func DayOf(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+type UsageRepository interface {
+	// AddCalls increments the counters of every key in a single batch
+	AddCalls(ctx context.Context, calls map[UsageKey]int64) error
+	// ListUsage returns the usage rows of a tenant for days in [from, to], ordered by day
+	ListUsage(ctx context.Context, tenantID string, from, to time.Time) ([]APIUsage, error)
+	// TotalsByTenant returns the calls per tenant for days in [from, to]
+	TotalsByTenant(ctx context.Context, from, to time.Time) (map[string]int64, error)
+}
+
+// UsageStatement is the usage of a tenant handed to the billing provider
+type UsageStatement struct {
+	TenantID string
+	Day      time.Time
+	Calls    int64
+}
+
+// UsageExporter is the port to the external billing provider
+type UsageExporter interface {
+	// ExportUsage reports a tenant's calls for one day; exporting the same statement twice must not double bill
+	ExportUsage(ctx context.Context, statement UsageStatement) error
+}
+
+// BillingAccount links a tenant to its customer record at the billing provider
+type BillingAccount struct {
+	TenantID           string `gorm:"primaryKey;type:varchar(64)"`
+	ProviderCustomerID string `gorm:"type:varchar(255);not null"`
+}
+
+type BillingAccountRepository interface {
+	GetByTenant(ctx context.Context, tenantID string) (*BillingAccount, error)
+}
@@ -0,0 +1,150 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+const dayLayout = "2006-01-02"
+
+// Recorder counts an API call without blocking the request
+type Recorder interface {
+	Record(tenantID, apiKeyID string)
+}
+
+// DailyUsageResponse is the number of calls on one day
+type DailyUsageResponse struct {
+	Day      string `json:"day"`
+	APIKeyID string `json:"api_key_id,omitempty"`
+	Calls    int64  `json:"calls"`
+}
+
+// ChargeResponse is an amount in minor units of the currency
+type ChargeResponse struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// BillingUsageResponse is the body of GET /billing/usage
+type BillingUsageResponse struct {
+	TenantID        string               `json:"tenant_id"`
+	From            string               `json:"from"`
+	To              string               `json:"to"`
+	Days            []DailyUsageResponse `json:"days"`
+	TotalCalls      int64                `json:"total_calls"`
+	EstimatedCharge ChargeResponse       `json:"estimated_charge"`
+}
+
+// HTTPServer exposes metered API usage to tenants
+type HTTPServer struct {
+	UsageRepo domain.UsageRepository
+	Pricing   domain.Pricing
+	Now       func() time.Time
+}
+
+// RegisterRoutes adds the billing endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/billing/usage",
+		Summary:  "Get the metered API calls of the current tenant; from and to default to the current month",
+		Tags:     []string{"billing"},
+		Response: BillingUsageResponse{},
+		Handler:  s.getUsage,
+	})
+}
+
+func (s *HTTPServer) getUsage(w http.ResponseWriter, r *http.Request) {
+	from, to, err := s.usageRange(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	tenantID := tenant.FromContext(r.Context())
+	days, err := s.UsageRepo.ListUsage(r.Context(), tenantID, from, to)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	report, err := domain.NewUsageReport(tenantID, from, to, days, s.Pricing)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := BillingUsageResponse{
+		TenantID:   report.TenantID,
+		From:       report.From.Format(dayLayout),
+		To:         report.To.Format(dayLayout),
+		Days:       []DailyUsageResponse{},
+		TotalCalls: report.TotalCalls,
+		EstimatedCharge: ChargeResponse{
+			Amount:   report.Charge.Amount,
+			Currency: report.Charge.Currency,
+		},
+	}
+	for _, day := range report.Days {
+		resp.Days = append(resp.Days, DailyUsageResponse{
+			Day:      day.Day.Format(dayLayout),
+			APIKeyID: day.APIKeyID,
+			Calls:    day.Calls,
+		})
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// usageRange parses the from and to query parameters as YYYY-MM-DD
+func (s *HTTPServer) usageRange(r *http.Request) (time.Time, time.Time, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	today := domain.DayOf(now())
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := today
+
+	var errs validation.Errors
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		day, err := time.Parse(dayLayout, value)
+		if err != nil {
+			errs.Add("from", fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", value))
+		}
+		from = day
+	}
+	if value := query.Get("to"); value != "" {
+		day, err := time.Parse(dayLayout, value)
+		if err != nil {
+			errs.Add("to", fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", value))
+		}
+		to = day
+	}
+	if err := errs.Err(); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	errs.Check(!to.Before(from), "to", "must not be before from")
+	return from, to, errs.Err()
+}
+
+// MeteringMiddleware records every request against the tenant and the API key returned by keyID.
+// It must run after tenant.Middleware; keyID may be nil while requests carry no API key.
+func MeteringMiddleware(meter Recorder, keyID func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var apiKeyID string
+			if keyID != nil {
+				apiKeyID = keyID(r)
+			}
+			meter.Record(tenant.FromContext(r.Context()), apiKeyID)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package port
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/billing/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// ExportUsageJob sends one day of usage to the billing provider.
+// Day is YYYY-MM-DD; an empty Day exports the day before the job runs.
+type ExportUsageJob struct {
+	Day string `json:"day,omitempty"`
+}
+
+func (ExportUsageJob) Kind() string {
+	return "billing.export_usage"
+}
+
+// JobServer runs the billing use cases triggered by background jobs
+type JobServer struct {
+	ExportUsage decorator.CommandHandler[command.ExportUsageCommand]
+}
+
+// RegisterJobs adds the billing job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, s.exportUsage)
+}
+
+func (s *JobServer) exportUsage(ctx context.Context, job ExportUsageJob) error {
+	day := time.Now().AddDate(0, 0, -1)
+	if job.Day != "" {
+		parsed, err := time.Parse(dayLayout, job.Day)
+		if err != nil {
+			return fmt.Errorf("parse day %q: %w", job.Day, err)
+		}
+		day = parsed
+	}
+	return s.ExportUsage.Handle(ctx, command.ExportUsageCommand{Day: day})
+}
@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// GormAccountMover moves the checkout sessions of merged users
+type GormAccountMover struct {
+	db *gorm.DB
+}
+
+func NewGormAccountMover(db *gorm.DB) userDomain.AccountMover {
+	return &GormAccountMover{db: db}
+}
+
+// MoveAccount rewrites the public user ID the sessions report too; open sessions of both users
+// stay open, as each holds a cart of its own
+func (m *GormAccountMover) MoveAccount(ctx context.Context, fromUserID, toUserID int64) ([]userDomain.MergeItem, error) {
+	db := persistence.Conn(ctx, m.db)
+	var to userDomain.User
+	if err := db.Select("id", "public_id").First(&to, toUserID).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	result := db.Model(&domain.Session{}).Where("user_id = ?", fromUserID).
+		Updates(map[string]any{"user_id": toUserID, "user_public_id": to.PublicID})
+	if result.Error != nil {
+		return nil, persistence.TranslateError(result.Error)
+	}
+	return []userDomain.MergeItem{{Kind: "checkout_sessions", Moved: int(result.RowsAffected)}}, nil
+}
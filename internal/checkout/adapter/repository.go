@@ -0,0 +1,30 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormSessionRepository struct {
+	*persistence.GenericRepository[domain.Session, string]
+	db *gorm.DB
+}
+
+func NewGormSessionRepository(db *gorm.DB) domain.SessionRepository {
+	return &GormSessionRepository{GenericRepository: persistence.NewGenericRepository[domain.Session, string](db), db: db}
+}
+
+func (r *GormSessionRepository) Save(ctx context.Context, s *domain.Session) error {
+	return persistence.TranslateError(r.db.WithContext(ctx).Save(s).Error)
+}
+
+func (r *GormSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", domain.StatusOpen, before).
+		Delete(&domain.Session{})
+	return result.RowsAffected, persistence.TranslateError(result.Error)
+}
@@ -0,0 +1,70 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.Session{}))
+	return db
+}
+
+func TestGormSessionRepository_SaveAndResume(t *testing.T) {
+	repo := adapter.NewGormSessionRepository(setupTestDB(t))
+	ctx := context.Background()
+	now := time.Now()
+
+	s, err := domain.NewSession(1, domain.CartItem{ProductID: 2, ProductName: "Desk", Quantity: 1}, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Save(ctx, s))
+
+	assert.NoError(t, s.SetAddress(domain.Address{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", PostalCode: "10115", Country: "DE"}, now))
+	assert.NoError(t, s.ChooseShipping(domain.ShippingStandard, now))
+	assert.NoError(t, s.SetPayment(domain.PaymentIntent{Method: "pm_card_visa", Amount: money.Money{Amount: 999, Currency: "USD"}}, now))
+	assert.NoError(t, repo.Save(ctx, s))
+
+	resumed, err := repo.GetByID(ctx, s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StepReview, resumed.NextStep())
+	assert.Equal(t, s.Address, resumed.Address)
+	assert.Equal(t, s.Payment, resumed.Payment)
+	assert.Equal(t, "Desk", resumed.Cart.ProductName)
+
+	_, err = repo.GetByID(ctx, "missing")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGormSessionRepository_DeleteExpired(t *testing.T) {
+	repo := adapter.NewGormSessionRepository(setupTestDB(t))
+	ctx := context.Background()
+	now := time.Now()
+
+	expired, _ := domain.NewSession(1, domain.CartItem{ProductID: 2, Quantity: 1}, now.Add(-time.Minute))
+	active, _ := domain.NewSession(1, domain.CartItem{ProductID: 2, Quantity: 1}, now.Add(time.Hour))
+	completed, _ := domain.NewSession(1, domain.CartItem{ProductID: 2, Quantity: 1}, now.Add(-time.Minute))
+	completed.Status = domain.StatusCompleted
+	for _, s := range []*domain.Session{expired, active, completed} {
+		assert.NoError(t, repo.Save(ctx, s))
+	}
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = repo.GetByID(ctx, expired.ID)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+	_, err = repo.GetByID(ctx, completed.ID)
+	assert.NoError(t, err, "completed sessions stay linked to their order")
+}
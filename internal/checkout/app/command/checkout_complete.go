@@ -0,0 +1,108 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	deliveryCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/app/command"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	orderCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// CompleteCheckoutCommand turns a checkout session whose steps are all done into an order
+type CompleteCheckoutCommand struct {
+	SessionID string `validate:"required"`
+}
+
+type CompleteCheckoutHandler struct {
+	Sessions   domain.SessionRepository
+	PlaceOrder decorator.CommandResultHandler[orderCommand.PlaceOrderCommand, *orderDomain.Order]
+	// Addresses receives the address of the checkout, since orders ship to an address of the address book
+	Addresses userDomain.AddressRepository
+	// RecordEstimate stores the delivery window promised for the order; nil skips delivery estimates
+	RecordEstimate decorator.CommandResultHandler[deliveryCommand.RecordEstimateCommand, *deliveryDomain.Estimate]
+	Now            func() time.Time
+}
+
+func (h *CompleteCheckoutHandler) Handle(ctx context.Context, cmd CompleteCheckoutCommand) (*domain.Session, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	s, err := getSession(ctx, h.Sessions, cmd.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := nowFunc(h.Now)()
+	if err := s.CanComplete(now); err != nil {
+		return nil, err
+	}
+
+	if s.ShippingAddressID == nil {
+		if err := h.saveAddress(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+
+	// A failed order (declined payment, no stock) leaves the session open so the client can fix the step and retry
+	o, err := h.PlaceOrder.Handle(ctx, orderCommand.PlaceOrderCommand{
+		UserID:            s.UserID,
+		ProductID:         s.Cart.ProductID,
+		Quantity:          s.Cart.Quantity,
+		ShippingAddressID: *s.ShippingAddressID,
+		Payments:          []orderCommand.PaymentDetails{{Method: s.Payment.Method, Amount: s.Payment.Amount}},
+		CouponCode:        s.CouponCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Complete(o.ID, o.PublicID, now); err != nil {
+		return nil, err
+	}
+	if err := h.Sessions.Save(ctx, s); err != nil {
+		return nil, fmt.Errorf("save checkout session %s of order %d: %w", s.ID, o.ID, err)
+	}
+
+	// The order is placed either way, so a missing estimate only leaves it out of the accuracy report
+	if h.RecordEstimate != nil {
+		_, err := h.RecordEstimate.Handle(ctx, deliveryCommand.RecordEstimateCommand{
+			OrderID:  o.ID,
+			Method:   string(s.Shipping),
+			Country:  s.Address.Country,
+			PlacedAt: now,
+			// Coordinates pick the nearest warehouse, when the address has them
+			Coordinates: s.Address.Point(),
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "recording delivery estimate failed", "order_id", o.ID, "error", err)
+		}
+	}
+	return s, nil
+}
+
+// saveAddress adds the address of the checkout to the address book of the user. The session
+// remembers it, so a retry after a failed order does not add the address again.
+func (h *CompleteCheckoutHandler) saveAddress(ctx context.Context, s *domain.Session) error {
+	a, err := userDomain.NewAddress(s.UserID, userDomain.AddressDetails{Address: s.Address})
+	if err != nil {
+		return err
+	}
+	if err := h.Addresses.Create(ctx, a); err != nil {
+		return fmt.Errorf("add address of checkout session %s: %w", s.ID, err)
+	}
+
+	s.SaveAddress(a.ID)
+	if err := h.Sessions.Save(ctx, s); err != nil {
+		return fmt.Errorf("save checkout session %s: %w", s.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	deliveryCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/app/command"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	orderCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MockSessionRepository keeps sessions in memory
+type MockSessionRepository struct {
+	sessions map[string]domain.Session
+}
+
+func (m *MockSessionRepository) Save(ctx context.Context, s *domain.Session) error {
+	if m.sessions == nil {
+		m.sessions = make(map[string]domain.Session)
+	}
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+func (m *MockSessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (m *MockSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// MockAddressRepository records the created addresses; only Create is used by checkout
+type MockAddressRepository struct {
+	userDomain.AddressRepository
+	created []userDomain.Address
+}
+
+func (m *MockAddressRepository) Create(ctx context.Context, a *userDomain.Address) error {
+	a.ID = int64(len(m.created) + 1)
+	m.created = append(m.created, *a)
+	return nil
+}
+
+// MockPlaceOrderHandler records the placed order and fails with err when set
+type MockPlaceOrderHandler struct {
+	placed *orderCommand.PlaceOrderCommand
+	err    error
+}
+
+func (m *MockPlaceOrderHandler) Handle(ctx context.Context, cmd orderCommand.PlaceOrderCommand) (*orderDomain.Order, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.placed = &cmd
+	return &orderDomain.Order{ID: 42, UserID: cmd.UserID, ProductID: cmd.ProductID, Quantity: orderDomain.Quantity(cmd.Quantity)}, nil
+}
+
+// MockRecordEstimateHandler records the estimated order
+type MockRecordEstimateHandler struct {
+	recorded *deliveryCommand.RecordEstimateCommand
+}
+
+func (m *MockRecordEstimateHandler) Handle(ctx context.Context, cmd deliveryCommand.RecordEstimateCommand) (*deliveryDomain.Estimate, error) {
+	m.recorded = &cmd
+	return &deliveryDomain.Estimate{OrderID: cmd.OrderID}, nil
+}
+
+func newReviewedSession(t *testing.T, repo *MockSessionRepository, now time.Time) *domain.Session {
+	s, err := domain.NewSession(1, domain.CartItem{ProductID: 7, Quantity: 2}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s.Address = domain.Address{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", PostalCode: "10115", Country: "DE"}
+	s.Shipping = domain.ShippingStandard
+	s.Payment = domain.PaymentIntent{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}
+	repo.Save(context.Background(), s)
+	return s
+}
+
+func TestCompleteCheckoutHandler_PlacesOrder(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	sessions := &MockSessionRepository{}
+	s := newReviewedSession(t, sessions, now)
+	placeOrder := &MockPlaceOrderHandler{}
+	addresses := &MockAddressRepository{}
+	estimates := &MockRecordEstimateHandler{}
+	handler := &CompleteCheckoutHandler{Sessions: sessions, PlaceOrder: placeOrder, Addresses: addresses, RecordEstimate: estimates, Now: func() time.Time { return now }}
+
+	// Act
+	completed, err := handler.Handle(context.Background(), CompleteCheckoutCommand{SessionID: s.ID})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if placeOrder.placed == nil || placeOrder.placed.ProductID != 7 || placeOrder.placed.Quantity != 2 {
+		t.Fatalf("Expected an order of 2 x product 7, got %+v", placeOrder.placed)
+	}
+	if len(placeOrder.placed.Payments) != 1 || placeOrder.placed.Payments[0].Amount.Amount != 2500 {
+		t.Errorf("Expected the session payment to be authorized, got %+v", placeOrder.placed.Payments)
+	}
+	if len(addresses.created) != 1 || addresses.created[0].UserID != 1 || addresses.created[0].City != "Berlin" {
+		t.Fatalf("Expected the session address to be added to the address book, got %+v", addresses.created)
+	}
+	if placeOrder.placed.ShippingAddressID != addresses.created[0].ID {
+		t.Errorf("Expected the order to ship to address %d, got %d", addresses.created[0].ID, placeOrder.placed.ShippingAddressID)
+	}
+	if completed.Status != domain.StatusCompleted || *completed.OrderID != 42 {
+		t.Errorf("Expected the session to be completed with order 42, got %s", completed.Status)
+	}
+	if stored, _ := sessions.GetByID(context.Background(), s.ID); stored.Status != domain.StatusCompleted {
+		t.Errorf("Expected the completed session to be saved, got %s", stored.Status)
+	}
+	if estimates.recorded == nil || estimates.recorded.OrderID != 42 || estimates.recorded.Method != "standard" || estimates.recorded.Country != "DE" {
+		t.Errorf("Expected a standard delivery estimate to DE for order 42, got %+v", estimates.recorded)
+	}
+}
+
+func TestCompleteCheckoutHandler_DeclinedPaymentKeepsSessionOpen(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	sessions := &MockSessionRepository{}
+	s := newReviewedSession(t, sessions, now)
+	addresses := &MockAddressRepository{}
+	handler := &CompleteCheckoutHandler{
+		Sessions:   sessions,
+		PlaceOrder: &MockPlaceOrderHandler{err: paymentDomain.ErrPaymentDeclined},
+		Addresses:  addresses,
+		Now:        func() time.Time { return now },
+	}
+
+	// Act
+	_, err := handler.Handle(context.Background(), CompleteCheckoutCommand{SessionID: s.ID})
+	_, retryErr := handler.Handle(context.Background(), CompleteCheckoutCommand{SessionID: s.ID})
+
+	// Assert
+	if !errors.Is(err, paymentDomain.ErrPaymentDeclined) || !errors.Is(retryErr, paymentDomain.ErrPaymentDeclined) {
+		t.Fatalf("Expected ErrPaymentDeclined, got %v and %v", err, retryErr)
+	}
+	if stored, _ := sessions.GetByID(context.Background(), s.ID); stored.NextStep() != domain.StepReview {
+		t.Errorf("Expected the session to stay open for a retry, got step %s", stored.NextStep())
+	}
+	if len(addresses.created) != 1 {
+		t.Errorf("Expected the retry to reuse the saved address, got %d addresses", len(addresses.created))
+	}
+}
+
+func TestCompleteCheckoutHandler_RejectsIncompleteAndExpiredSessions(t *testing.T) {
+	now := time.Now()
+	sessions := &MockSessionRepository{}
+
+	incomplete, _ := domain.NewSession(1, domain.CartItem{ProductID: 7, Quantity: 1}, now.Add(time.Hour))
+	sessions.Save(context.Background(), incomplete)
+	expired := newReviewedSession(t, sessions, now)
+
+	placeOrder := &MockPlaceOrderHandler{}
+	handler := &CompleteCheckoutHandler{Sessions: sessions, PlaceOrder: placeOrder, Addresses: &MockAddressRepository{}, Now: func() time.Time { return now.Add(2 * time.Hour) }}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		expected  error
+	}{
+		{name: "missing session", sessionID: "unknown", expected: domain.ErrSessionNotFound},
+		{name: "expired session", sessionID: expired.ID, expected: domain.ErrSessionExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.Handle(context.Background(), CompleteCheckoutCommand{SessionID: tt.sessionID})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	handler.Now = func() time.Time { return now }
+	if _, err := handler.Handle(context.Background(), CompleteCheckoutCommand{SessionID: incomplete.ID}); !errors.Is(err, domain.ErrSessionIncomplete) {
+		t.Errorf("Expected ErrSessionIncomplete, got %v", err)
+	}
+	if placeOrder.placed != nil {
+		t.Errorf("Expected no order to be placed, got %+v", placeOrder.placed)
+	}
+}
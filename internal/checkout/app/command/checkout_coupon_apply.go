@@ -0,0 +1,77 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	couponDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// ApplyCouponCommand applies a coupon to a checkout; an empty code removes the applied one
+type ApplyCouponCommand struct {
+	SessionID string `validate:"required"`
+	Code      string
+}
+
+// ApplyCouponHandler checks a coupon applies to the cart of a checkout. Its use is only counted
+// when the order is placed, so abandoned checkouts don't use up limited coupons.
+type ApplyCouponHandler struct {
+	Sessions    domain.SessionRepository
+	Coupons     couponDomain.CouponRepository
+	ProductRepo productDomain.ProductRepository
+	TTL         time.Duration
+	Now         func() time.Time
+}
+
+func (h *ApplyCouponHandler) Handle(ctx context.Context, cmd ApplyCouponCommand) (*domain.Session, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	s, err := getSession(ctx, h.Sessions, cmd.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := nowFunc(h.Now)()
+	code := couponDomain.NormalizeCode(cmd.Code)
+	if code != "" {
+		if err := h.check(ctx, s, code, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.ApplyCoupon(code, now); err != nil {
+		return nil, err
+	}
+	s.Extend(now.Add(sessionTTL(h.TTL)))
+
+	if err := h.Sessions.Save(ctx, s); err != nil {
+		return nil, fmt.Errorf("save checkout session %s: %w", s.ID, err)
+	}
+	return s, nil
+}
+
+// check reports why the coupon of code doesn't apply to the cart of s, if it doesn't
+func (h *ApplyCouponHandler) check(ctx context.Context, s *domain.Session, code string, now time.Time) error {
+	c, err := h.Coupons.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return couponDomain.ErrCouponNotFound
+		}
+		return fmt.Errorf("get coupon %s: %w", code, err)
+	}
+	p, err := h.ProductRepo.GetByID(ctx, s.Cart.ProductID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return productDomain.ErrProductNotFound
+		}
+		return fmt.Errorf("get product %d: %w", s.Cart.ProductID, err)
+	}
+	return c.Check(p.Price().Mul(int64(s.Cart.Quantity)), now)
+}
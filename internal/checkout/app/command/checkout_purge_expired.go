@@ -0,0 +1,29 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+)
+
+// PurgeExpiredCheckoutsCommand deletes abandoned checkout sessions
+type PurgeExpiredCheckoutsCommand struct{}
+
+type PurgeExpiredCheckoutsHandler struct {
+	Sessions domain.SessionRepository
+	Now      func() time.Time
+}
+
+func (h *PurgeExpiredCheckoutsHandler) Handle(ctx context.Context, cmd PurgeExpiredCheckoutsCommand) error {
+	deleted, err := h.Sessions.DeleteExpired(ctx, nowFunc(h.Now)())
+	if err != nil {
+		return fmt.Errorf("delete expired checkout sessions: %w", err)
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "purged expired checkout sessions", "count", deleted)
+	}
+	return nil
+}
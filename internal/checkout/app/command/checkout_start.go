@@ -0,0 +1,93 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// DefaultSessionTTL is how long a checkout stays resumable after its last step
+const DefaultSessionTTL = 30 * time.Minute
+
+// StartCheckoutCommand opens a checkout session for a snapshot of the cart
+type StartCheckoutCommand struct {
+	UserID    int64 `validate:"required,gt=0"`
+	ProductID int64 `validate:"required,gt=0"`
+	Quantity  int   `validate:"required,gt=0"`
+}
+
+type StartCheckoutHandler struct {
+	Sessions    domain.SessionRepository
+	UserRepo    userDomain.UserRepository
+	ProductRepo productDomain.ProductRepository
+	TTL         time.Duration
+	Now         func() time.Time
+}
+
+func (h *StartCheckoutHandler) Handle(ctx context.Context, cmd StartCheckoutCommand) (*domain.Session, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	u, err := h.UserRepo.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, userDomain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("get user %d: %w", cmd.UserID, err)
+	}
+
+	p, err := h.ProductRepo.GetByID(ctx, cmd.ProductID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, productDomain.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("get product %d: %w", cmd.ProductID, err)
+	}
+
+	// Stock is only reserved when the checkout completes, an open session doesn't hold any
+	cart := domain.CartItem{ProductID: p.ID, ProductPublicID: p.PublicID, ProductName: p.Name, Quantity: cmd.Quantity}
+	s, err := domain.NewSession(u.ID, cart, nowFunc(h.Now)().Add(sessionTTL(h.TTL)))
+	if err != nil {
+		return nil, err
+	}
+	s.UserPublicID = u.PublicID
+
+	if err := h.Sessions.Save(ctx, s); err != nil {
+		return nil, fmt.Errorf("save checkout session: %w", err)
+	}
+	return s, nil
+}
+
+// getSession loads a session, reporting a missing one as domain.ErrSessionNotFound
+func getSession(ctx context.Context, sessions domain.SessionRepository, id string) (*domain.Session, error) {
+	s, err := sessions.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("get checkout session: %w", err)
+	}
+	return s, nil
+}
+
+func sessionTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 {
+		return ttl
+	}
+	return DefaultSessionTTL
+}
+
+func nowFunc(now func() time.Time) func() time.Time {
+	if now != nil {
+		return now
+	}
+	return time.Now
+}
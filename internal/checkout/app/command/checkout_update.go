@@ -0,0 +1,58 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// UpdateCheckoutCommand completes one or more checkout steps; steps left nil are kept as they are
+type UpdateCheckoutCommand struct {
+	SessionID string `validate:"required"`
+	Address   *domain.Address
+	Shipping  *domain.ShippingMethod
+	Payment   *domain.PaymentIntent
+}
+
+type UpdateCheckoutHandler struct {
+	Sessions domain.SessionRepository
+	TTL      time.Duration
+	Now      func() time.Time
+}
+
+func (h *UpdateCheckoutHandler) Handle(ctx context.Context, cmd UpdateCheckoutCommand) (*domain.Session, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	s, err := getSession(ctx, h.Sessions, cmd.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := nowFunc(h.Now)()
+	if cmd.Address != nil {
+		if err := s.SetAddress(*cmd.Address, now); err != nil {
+			return nil, err
+		}
+	}
+	if cmd.Shipping != nil {
+		if err := s.ChooseShipping(*cmd.Shipping, now); err != nil {
+			return nil, err
+		}
+	}
+	if cmd.Payment != nil {
+		if err := s.SetPayment(*cmd.Payment, now); err != nil {
+			return nil, err
+		}
+	}
+	s.Extend(now.Add(sessionTTL(h.TTL)))
+
+	if err := h.Sessions.Save(ctx, s); err != nil {
+		return nil, fmt.Errorf("save checkout session %s: %w", s.ID, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,250 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+var (
+	ErrSessionNotFound   = errors.New("checkout session not found")
+	ErrSessionExpired    = errors.New("checkout session expired")
+	ErrSessionCompleted  = errors.New("checkout session is already completed")
+	ErrSessionIncomplete = errors.New("checkout session is incomplete")
+)
+
+// ErrorCodeSessionExpired is returned with 410 when a client resumes an expired checkout
+const ErrorCodeSessionExpired = "checkout_expired"
+
+// SessionStatus is the lifecycle state of a checkout session
+type SessionStatus string
+
+const (
+	StatusOpen      SessionStatus = "OPEN"
+	StatusCompleted SessionStatus = "COMPLETED"
+)
+
+// Step is the next checkout step a client has to complete; clients resume from it after an interruption
+type Step string
+
+const (
+	StepAddress  Step = "address"
+	StepShipping Step = "shipping"
+	StepPayment  Step = "payment"
+	StepReview   Step = "review"
+	StepDone     Step = "done"
+)
+
+// CartItem is the snapshot of the cart taken when checkout starts
+type CartItem struct {
+	ProductID       int64  `gorm:"not null"`
+	ProductPublicID string `gorm:"type:varchar(32)"`
+	ProductName     string `gorm:"type:varchar(255)"`
+	Quantity        int    `gorm:"not null"`
+}
+
+// Address is the shipping address of a checkout
+type Address = address.Address
+
+// ShippingMethod is the delivery option chosen by the customer
+type ShippingMethod string
+
+const (
+	ShippingStandard ShippingMethod = "standard"
+	ShippingExpress  ShippingMethod = "express"
+)
+
+func (m ShippingMethod) IsValid() bool {
+	return m == ShippingStandard || m == ShippingExpress
+}
+
+// PaymentIntent is the payment the order will be authorized with once checkout completes
+type PaymentIntent struct {
+	Method string      `gorm:"type:varchar(255)"`
+	Amount money.Money `gorm:"type:varchar(32)"`
+}
+
+func (p PaymentIntent) IsZero() bool {
+	return p.Method == ""
+}
+
+// Session is a resumable multi-step checkout; it turns into an order once every step is done
+type Session struct {
+	ID      string   `gorm:"primaryKey;type:varchar(32)"`
+	UserID  int64    `gorm:"index;not null"`
+	Cart    CartItem `gorm:"embedded;embeddedPrefix:cart_"`
+	Address Address  `gorm:"embedded;embeddedPrefix:address_"`
+	// ShippingAddressID is Address once added to the address book of the user, which orders ship to
+	ShippingAddressID *int64
+	Shipping          ShippingMethod `gorm:"type:varchar(20)"`
+	Payment           PaymentIntent  `gorm:"embedded;embeddedPrefix:payment_"`
+	Status            SessionStatus  `gorm:"type:varchar(20);not null"`
+	OrderID           *int64
+	ExpiresAt         time.Time `gorm:"index;not null"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	// TenantID is the tenant the session belongs to; see tenant.Plugin
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';index"`
+
+	// CouponCode is the coupon the order will be placed with, if any
+	CouponCode string `gorm:"type:varchar(32)"`
+
+	// UserPublicID and OrderPublicID are the IDs the checkout API reports for the user and the placed order
+	UserPublicID  string `gorm:"type:varchar(32)"`
+	OrderPublicID string `gorm:"type:varchar(32)"`
+}
+
+func (Session) TableName() string {
+	return "checkout_sessions"
+}
+
+// NewSession starts a checkout for the cart with a random, unguessable ID
+func NewSession(userID int64, cart CartItem, expiresAt time.Time) (*Session, error) {
+	var errs validation.Errors
+	errs.Check(userID > 0, "user_id", "is required")
+	errs.Check(cart.ProductID > 0, "product_id", "is required")
+	errs.Check(cart.Quantity > 0, "quantity", "must be greater than zero")
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{ID: id, UserID: userID, Cart: cart, Status: StatusOpen, ExpiresAt: expiresAt}, nil
+}
+
+// NextStep reports the first step the client still has to complete
+func (s *Session) NextStep() Step {
+	switch {
+	case s.Status == StatusCompleted:
+		return StepDone
+	case s.Address.IsZero():
+		return StepAddress
+	case s.Shipping == "":
+		return StepShipping
+	case s.Payment.IsZero():
+		return StepPayment
+	default:
+		return StepReview
+	}
+}
+
+func (s *Session) SetAddress(a Address, now time.Time) error {
+	if err := s.checkOpen(now); err != nil {
+		return err
+	}
+	a = a.Normalize()
+	var errs validation.Errors
+	errs.Merge("address", a.Validate())
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	s.Address = a
+	s.ShippingAddressID = nil
+	return nil
+}
+
+func (s *Session) ChooseShipping(m ShippingMethod, now time.Time) error {
+	if err := s.checkOpen(now); err != nil {
+		return err
+	}
+	if !m.IsValid() {
+		var errs validation.Errors
+		errs.Add("method", fmt.Sprintf("must be %q or %q", ShippingStandard, ShippingExpress))
+		return errs
+	}
+	s.Shipping = m
+	return nil
+}
+
+func (s *Session) SetPayment(p PaymentIntent, now time.Time) error {
+	if err := s.checkOpen(now); err != nil {
+		return err
+	}
+	var errs validation.Errors
+	errs.Check(p.Method != "", "method", "is required")
+	errs.Check(p.Amount.Amount > 0, "amount", "must be greater than 0")
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	s.Payment = p
+	return nil
+}
+
+// ApplyCoupon records the coupon the order will be placed with; an empty code removes it
+func (s *Session) ApplyCoupon(code string, now time.Time) error {
+	if err := s.checkOpen(now); err != nil {
+		return err
+	}
+	s.CouponCode = code
+	return nil
+}
+
+// CanComplete reports why the session cannot be turned into an order yet, if it cannot
+func (s *Session) CanComplete(now time.Time) error {
+	if err := s.checkOpen(now); err != nil {
+		return err
+	}
+	if step := s.NextStep(); step != StepReview {
+		return fmt.Errorf("%w: %s step is missing", ErrSessionIncomplete, step)
+	}
+	return nil
+}
+
+// SaveAddress records the address book entry Address was saved as
+func (s *Session) SaveAddress(addressID int64) {
+	s.ShippingAddressID = &addressID
+}
+
+// Complete links the placed order to the session
+func (s *Session) Complete(orderID int64, orderPublicID string, now time.Time) error {
+	if err := s.CanComplete(now); err != nil {
+		return err
+	}
+	s.Status = StatusCompleted
+	s.OrderID = &orderID
+	s.OrderPublicID = orderPublicID
+	return nil
+}
+
+// Extend pushes the expiry back; every completed step gives the client another TTL to continue
+func (s *Session) Extend(expiresAt time.Time) {
+	if expiresAt.After(s.ExpiresAt) {
+		s.ExpiresAt = expiresAt
+	}
+}
+
+func (s *Session) checkOpen(now time.Time) error {
+	if s.Status == StatusCompleted {
+		return ErrSessionCompleted
+	}
+	if !now.Before(s.ExpiresAt) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate checkout session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type SessionRepository interface {
+	Save(ctx context.Context, s *Session) error
+	GetByID(ctx context.Context, id string) (*Session, error)
+	// DeleteExpired removes open sessions that expired before the given time and returns how many were removed
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
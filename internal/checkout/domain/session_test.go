@@ -0,0 +1,79 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+var validAddress = domain.Address{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", PostalCode: "10115", Country: "de"}
+
+func TestSession_StepsLeadToReview(t *testing.T) {
+	now := time.Now()
+	s, err := domain.NewSession(1, domain.CartItem{ProductID: 2, Quantity: 3}, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, s.ID, 32)
+	assert.Equal(t, domain.StepAddress, s.NextStep())
+
+	assert.NoError(t, s.SetAddress(validAddress, now))
+	assert.Equal(t, "DE", s.Address.Country)
+	assert.Equal(t, domain.StepShipping, s.NextStep())
+
+	assert.NoError(t, s.ChooseShipping(domain.ShippingExpress, now))
+	assert.Equal(t, domain.StepPayment, s.NextStep())
+
+	assert.ErrorIs(t, s.Complete(10, "ord_10", now), domain.ErrSessionIncomplete)
+
+	assert.NoError(t, s.SetPayment(domain.PaymentIntent{Method: "pm_card_visa", Amount: money.Money{Amount: 1500, Currency: "EUR"}}, now))
+	assert.Equal(t, domain.StepReview, s.NextStep())
+
+	assert.NoError(t, s.Complete(10, "ord_10", now))
+	assert.Equal(t, domain.StepDone, s.NextStep())
+	assert.Equal(t, int64(10), *s.OrderID)
+	assert.Equal(t, "ord_10", s.OrderPublicID)
+	assert.ErrorIs(t, s.SetAddress(validAddress, now), domain.ErrSessionCompleted)
+}
+
+func TestSession_RejectsInvalidSteps(t *testing.T) {
+	now := time.Now()
+	s, err := domain.NewSession(1, domain.CartItem{ProductID: 2, Quantity: 1}, now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	err = s.SetAddress(domain.Address{Country: "Germany"}, now)
+	var errs validation.Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 4)
+
+	assert.True(t, validation.IsValidationError(s.ChooseShipping("teleport", now)))
+	assert.True(t, validation.IsValidationError(s.SetPayment(domain.PaymentIntent{Method: "pm_card_visa"}, now)))
+	assert.Equal(t, domain.StepAddress, s.NextStep())
+}
+
+func TestSession_Expiry(t *testing.T) {
+	now := time.Now()
+	s, err := domain.NewSession(1, domain.CartItem{ProductID: 2, Quantity: 1}, now.Add(time.Minute))
+	assert.NoError(t, err)
+
+	s.Extend(now.Add(30 * time.Minute))
+	assert.NoError(t, s.SetAddress(validAddress, now.Add(10*time.Minute)))
+
+	s.Extend(now)
+	assert.Equal(t, now.Add(30*time.Minute), s.ExpiresAt, "extending never shortens a session")
+	assert.ErrorIs(t, s.ChooseShipping(domain.ShippingStandard, now.Add(30*time.Minute)), domain.ErrSessionExpired)
+}
+
+func TestSession_ApplyCoupon(t *testing.T) {
+	now := time.Now()
+	s, err := domain.NewSession(1, domain.CartItem{ProductID: 2, Quantity: 1}, now.Add(time.Minute))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.ApplyCoupon("SUMMER10", now))
+	assert.Equal(t, "SUMMER10", s.CouponCode)
+	assert.NoError(t, s.ApplyCoupon("", now))
+	assert.Empty(t, s.CouponCode, "an empty code removes the coupon")
+	assert.ErrorIs(t, s.ApplyCoupon("SUMMER10", now.Add(time.Minute)), domain.ErrSessionExpired)
+}
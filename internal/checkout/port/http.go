@@ -0,0 +1,462 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	couponDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+const dayLayout = "2006-01-02"
+
+// StartCheckoutRequest is the body of POST /checkout/sessions; UserID and ProductID are public IDs
+type StartCheckoutRequest struct {
+	UserID    string `json:"user_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// AddressPayload is the shipping address step of a checkout
+type AddressPayload struct {
+	Name  string `json:"name"`
+	Line1 string `json:"line1"`
+	Line2 string `json:"line2,omitempty"`
+	City  string `json:"city"`
+	// Region is the state or province where the country requires one, see GET /address-formats/{country}
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	// Latitude and Longitude optionally place the address so the nearest warehouse ships the order
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+}
+
+// ShippingRequest is the body of PUT /checkout/sessions/{id}/shipping
+type ShippingRequest struct {
+	Method domain.ShippingMethod `json:"method"`
+}
+
+// PaymentPayload is the payment step of a checkout; Amount is in minor units of Currency
+type PaymentPayload struct {
+	Method   string `json:"method"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// CouponRequest is the body of PUT /checkout/sessions/{id}/coupon
+type CouponRequest struct {
+	Code string `json:"code"`
+}
+
+// CartItemResponse is the cart snapshot taken when the checkout started
+type CartItemResponse struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// DeliveryEstimatePayload is the range of days, in YYYY-MM-DD format, an order is expected to arrive in
+type DeliveryEstimatePayload struct {
+	Earliest string `json:"earliest"`
+	Latest   string `json:"latest"`
+}
+
+// CheckoutSessionResponse is the public representation of a checkout session.
+// NextStep tells a resuming client which page to show.
+type CheckoutSessionResponse struct {
+	ID        string                `json:"id"`
+	UserID    string                `json:"user_id"`
+	Status    domain.SessionStatus  `json:"status"`
+	NextStep  domain.Step           `json:"next_step"`
+	Cart      CartItemResponse      `json:"cart"`
+	Address   *AddressPayload       `json:"address,omitempty"`
+	Shipping  domain.ShippingMethod `json:"shipping,omitempty"`
+	Payment   *PaymentPayload       `json:"payment,omitempty"`
+	OrderID   string                `json:"order_id,omitempty"`
+	ExpiresAt time.Time             `json:"expires_at"`
+	// CouponCode is the coupon the order will be placed with; its use is counted then
+	CouponCode string `json:"coupon_code,omitempty"`
+	// EstimatedDelivery is quoted once the address and shipping steps are done, and is the
+	// window promised for the order once the checkout completes
+	EstimatedDelivery *DeliveryEstimatePayload `json:"estimated_delivery,omitempty"`
+}
+
+// HTTPServer exposes the checkout use cases over HTTP
+type HTTPServer struct {
+	StartCheckout    decorator.CommandResultHandler[command.StartCheckoutCommand, *domain.Session]
+	UpdateCheckout   decorator.CommandResultHandler[command.UpdateCheckoutCommand, *domain.Session]
+	CompleteCheckout decorator.CommandResultHandler[command.CompleteCheckoutCommand, *domain.Session]
+	ApplyCoupon      decorator.CommandResultHandler[command.ApplyCouponCommand, *domain.Session]
+	Sessions         domain.SessionRepository
+	// UserRepo and ProductRepo resolve the public IDs of started checkouts
+	UserRepo    userDomain.UserRepository
+	ProductRepo productDomain.ProductRepository
+	// Estimator quotes the delivery of open sessions and Estimates holds the windows recorded for
+	// placed orders; without them responses have no estimated delivery
+	Estimator *deliveryDomain.Estimator
+	Estimates deliveryDomain.EstimateRepository
+
+	// Auth limits customers to checking out for themselves; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the checkout endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/checkout/sessions",
+		Summary:  "Start a checkout for a cart",
+		Tags:     []string{"checkout"},
+		Request:  StartCheckoutRequest{},
+		Response: CheckoutSessionResponse{},
+		Status:   http.StatusCreated,
+		Handler:  s.startCheckout,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/checkout/sessions/{id}",
+		Summary:  "Resume a checkout session",
+		Tags:     []string{"checkout"},
+		Response: CheckoutSessionResponse{},
+		Handler:  s.getSession,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/checkout/sessions/{id}/address",
+		Summary:  "Set the shipping address of a checkout",
+		Tags:     []string{"checkout"},
+		Request:  AddressPayload{},
+		Response: CheckoutSessionResponse{},
+		Handler:  s.setAddress,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/checkout/sessions/{id}/shipping",
+		Summary:  "Choose the shipping method of a checkout",
+		Tags:     []string{"checkout"},
+		Request:  ShippingRequest{},
+		Response: CheckoutSessionResponse{},
+		Handler:  s.chooseShipping,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/checkout/sessions/{id}/payment",
+		Summary:  "Set the payment of a checkout",
+		Tags:     []string{"checkout"},
+		Request:  PaymentPayload{},
+		Response: CheckoutSessionResponse{},
+		Handler:  s.setPayment,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/checkout/sessions/{id}/coupon",
+		Summary:  "Apply a coupon to a checkout",
+		Tags:     []string{"checkout"},
+		Request:  CouponRequest{},
+		Response: CheckoutSessionResponse{},
+		Handler:  s.applyCoupon,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodDelete,
+		Path:     "/checkout/sessions/{id}/coupon",
+		Summary:  "Remove the coupon of a checkout",
+		Tags:     []string{"checkout"},
+		Response: CheckoutSessionResponse{},
+		Handler:  s.removeCoupon,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/checkout/sessions/{id}/complete",
+		Summary:  "Complete a checkout and place its order",
+		Tags:     []string{"checkout"},
+		Response: CheckoutSessionResponse{},
+		Status:   http.StatusCreated,
+		Handler:  s.completeCheckout,
+		StringID: true,
+	})
+}
+
+func (s *HTTPServer) startCheckout(w http.ResponseWriter, r *http.Request) {
+	var req StartCheckoutRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	userID, productID, err := s.resolve(r.Context(), req)
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	if err := auth.CheckOwned(r.Context(), s.Auth, userDomain.PermissionOrderCreateAny, userDomain.PermissionOrderCreateOwn, userID); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+
+	session, err := s.StartCheckout.Handle(r.Context(), command.StartCheckoutCommand{
+		UserID:    userID,
+		ProductID: productID,
+		Quantity:  req.Quantity,
+	})
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, s.sessionResponse(r.Context(), session))
+}
+
+// resolve maps the public user and product IDs of the request to primary keys
+func (s *HTTPServer) resolve(ctx context.Context, req StartCheckoutRequest) (int64, int64, error) {
+	var errs validation.Errors
+	errs.Check(req.UserID != "", "user_id", "is required")
+	errs.Check(req.ProductID != "", "product_id", "is required")
+	if err := errs.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	u, err := s.UserRepo.GetByPublicID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return 0, 0, userDomain.ErrUserNotFound
+		}
+		return 0, 0, fmt.Errorf("get user %s: %w", req.UserID, err)
+	}
+	p, err := s.ProductRepo.GetByPublicID(ctx, req.ProductID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return 0, 0, productDomain.ErrProductNotFound
+		}
+		return 0, 0, fmt.Errorf("get product %s: %w", req.ProductID, err)
+	}
+	return u.ID, p.ID, nil
+}
+
+func (s *HTTPServer) getSession(w http.ResponseWriter, r *http.Request) {
+	session, err := s.Sessions.GetByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, s.sessionResponse(r.Context(), session))
+}
+
+func (s *HTTPServer) setAddress(w http.ResponseWriter, r *http.Request) {
+	var req AddressPayload
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	address := domain.Address{
+		Name:       req.Name,
+		Line1:      req.Line1,
+		Line2:      req.Line2,
+		City:       req.City,
+		Region:     req.Region,
+		PostalCode: req.PostalCode,
+		Country:    req.Country,
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+	}
+	s.update(w, r, command.UpdateCheckoutCommand{SessionID: r.PathValue("id"), Address: &address})
+}
+
+func (s *HTTPServer) chooseShipping(w http.ResponseWriter, r *http.Request) {
+	var req ShippingRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	s.update(w, r, command.UpdateCheckoutCommand{SessionID: r.PathValue("id"), Shipping: &req.Method})
+}
+
+func (s *HTTPServer) setPayment(w http.ResponseWriter, r *http.Request) {
+	var req PaymentPayload
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	amount, err := money.New(req.Amount, req.Currency)
+	if err != nil {
+		var errs validation.Errors
+		errs.Add("currency", err.Error())
+		httpx.WriteError(w, errs)
+		return
+	}
+	payment := domain.PaymentIntent{Method: req.Method, Amount: amount}
+	s.update(w, r, command.UpdateCheckoutCommand{SessionID: r.PathValue("id"), Payment: &payment})
+}
+
+func (s *HTTPServer) update(w http.ResponseWriter, r *http.Request, cmd command.UpdateCheckoutCommand) {
+	session, err := s.UpdateCheckout.Handle(r.Context(), cmd)
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, s.sessionResponse(r.Context(), session))
+}
+
+func (s *HTTPServer) applyCoupon(w http.ResponseWriter, r *http.Request) {
+	var req CouponRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	if req.Code == "" {
+		var errs validation.Errors
+		errs.Add("code", "is required")
+		httpx.WriteError(w, errs)
+		return
+	}
+
+	s.coupon(w, r, command.ApplyCouponCommand{SessionID: r.PathValue("id"), Code: req.Code})
+}
+
+func (s *HTTPServer) removeCoupon(w http.ResponseWriter, r *http.Request) {
+	s.coupon(w, r, command.ApplyCouponCommand{SessionID: r.PathValue("id")})
+}
+
+func (s *HTTPServer) coupon(w http.ResponseWriter, r *http.Request, cmd command.ApplyCouponCommand) {
+	session, err := s.ApplyCoupon.Handle(r.Context(), cmd)
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, s.sessionResponse(r.Context(), session))
+}
+
+func (s *HTTPServer) completeCheckout(w http.ResponseWriter, r *http.Request) {
+	session, err := s.CompleteCheckout.Handle(r.Context(), command.CompleteCheckoutCommand{SessionID: r.PathValue("id")})
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, s.sessionResponse(r.Context(), session))
+}
+
+// sessionResponse adds the delivery estimate to the response of a session
+func (s *HTTPServer) sessionResponse(ctx context.Context, session *domain.Session) CheckoutSessionResponse {
+	resp := toSessionResponse(session)
+
+	var window deliveryDomain.Window
+	switch {
+	case session.OrderID != nil && s.Estimates != nil:
+		e, err := s.Estimates.GetByOrderID(ctx, *session.OrderID)
+		if err != nil {
+			if !errors.Is(err, persistence.ErrNotFound) {
+				slog.WarnContext(ctx, "loading delivery estimate failed", "order_id", *session.OrderID, "error", err)
+			}
+			return resp
+		}
+		window = e.Window()
+	case session.Status == domain.StatusOpen && session.Shipping != "" && !session.Address.IsZero() && s.Estimator != nil:
+		// Destinations without a transit time get no quote; they are still accepted
+		to := deliveryDomain.Destination{Country: session.Address.Country, Point: session.Address.Point()}
+		w, err := s.Estimator.Estimate(ctx, time.Now(), string(session.Shipping), to)
+		if err != nil {
+			return resp
+		}
+		window = w
+	default:
+		return resp
+	}
+
+	resp.EstimatedDelivery = &DeliveryEstimatePayload{
+		Earliest: window.Earliest.Format(dayLayout),
+		Latest:   window.Latest.Format(dayLayout),
+	}
+	return resp
+}
+
+func toSessionResponse(s *domain.Session) CheckoutSessionResponse {
+	resp := CheckoutSessionResponse{
+		ID:       s.ID,
+		UserID:   s.UserPublicID,
+		Status:   s.Status,
+		NextStep: s.NextStep(),
+		Cart: CartItemResponse{
+			ProductID:   s.Cart.ProductPublicID,
+			ProductName: s.Cart.ProductName,
+			Quantity:    s.Cart.Quantity,
+		},
+		Shipping:   s.Shipping,
+		OrderID:    s.OrderPublicID,
+		ExpiresAt:  s.ExpiresAt,
+		CouponCode: s.CouponCode,
+	}
+	if !s.Address.IsZero() {
+		resp.Address = &AddressPayload{
+			Name:       s.Address.Name,
+			Line1:      s.Address.Line1,
+			Line2:      s.Address.Line2,
+			City:       s.Address.City,
+			Region:     s.Address.Region,
+			PostalCode: s.Address.PostalCode,
+			Country:    s.Address.Country,
+			Latitude:   s.Address.Latitude,
+			Longitude:  s.Address.Longitude,
+		}
+	}
+	if !s.Payment.IsZero() {
+		resp.Payment = &PaymentPayload{Method: s.Payment.Method, Amount: s.Payment.Amount.Amount, Currency: s.Payment.Amount.Currency}
+	}
+	return resp
+}
+
+// writeCheckoutError maps checkout and order domain errors onto HTTP status codes
+func writeCheckoutError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, userDomain.ErrUserNotFound), errors.Is(err, productDomain.ErrProductNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, userDomain.ErrUserDeactivated):
+		httpx.WriteErrorStatus(w, http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrSessionExpired):
+		httpx.WriteErrorCode(w, http.StatusGone, domain.ErrorCodeSessionExpired, err)
+	case errors.Is(err, domain.ErrSessionCompleted), errors.Is(err, domain.ErrSessionIncomplete),
+		errors.Is(err, productDomain.ErrInsufficientStock), errors.Is(err, orderDomain.ErrInvalidTransition):
+		httpx.WriteErrorStatus(w, http.StatusConflict, err)
+	case errors.Is(err, paymentDomain.ErrPaymentDeclined):
+		httpx.WriteErrorCode(w, http.StatusPaymentRequired, paymentDomain.ErrorCodePaymentDeclined, err)
+	case errors.Is(err, quotaDomain.ErrQuotaExceeded):
+		httpx.WriteErrorCode(w, http.StatusTooManyRequests, quotaDomain.ErrorCodeQuotaExceeded, err)
+	case errors.Is(err, couponDomain.ErrCouponNotFound), errors.Is(err, couponDomain.ErrCouponInactive),
+		errors.Is(err, couponDomain.ErrCouponUsedUp), errors.Is(err, couponDomain.ErrCouponNotApplicable):
+		httpx.WriteErrorCode(w, http.StatusUnprocessableEntity, couponDomain.ErrorCodeCouponRejected, err)
+	case errors.Is(err, orderDomain.ErrOrderRejected):
+		httpx.WriteErrorCode(w, http.StatusUnprocessableEntity, orderDomain.ErrorCodeOrderRejected, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
@@ -0,0 +1,28 @@
+package port
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// PurgeExpiredCheckoutsJob deletes checkout sessions that were abandoned before completion
+type PurgeExpiredCheckoutsJob struct{}
+
+func (PurgeExpiredCheckoutsJob) Kind() string {
+	return "checkout.purge_expired_sessions"
+}
+
+// JobServer runs the checkout use cases triggered by background jobs
+type JobServer struct {
+	PurgeExpiredCheckouts decorator.CommandHandler[command.PurgeExpiredCheckoutsCommand]
+}
+
+// RegisterJobs adds the checkout job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, _ PurgeExpiredCheckoutsJob) error {
+		return s.PurgeExpiredCheckouts.Handle(ctx, command.PurgeExpiredCheckoutsCommand{})
+	})
+}
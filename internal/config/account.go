@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+type AccountConfig struct {
+	// PasswordResetURL and EmailVerificationURL are the front-end pages the emailed links open;
+	// the token is appended as ?token=
+	PasswordResetURL     string
+	EmailVerificationURL string
+
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+
+	// TokenMaxAttempts locks an emailed token after that many wrong guesses
+	TokenMaxAttempts int
+	// TokenRequestLimit is how many tokens of each kind a user may request per
+	// TokenRequestWindow; further requests send no email
+	TokenRequestLimit  int
+	TokenRequestWindow time.Duration
+}
+
+func loadAccountConfig(s *source) AccountConfig {
+	return AccountConfig{
+		PasswordResetURL:     s.String("PASSWORD_RESET_URL", "http://localhost:3000/password/reset"),
+		EmailVerificationURL: s.String("EMAIL_VERIFICATION_URL", "http://localhost:3000/email-verification"),
+		PasswordResetTTL:     s.Duration("PASSWORD_RESET_TTL", time.Hour),
+		EmailVerificationTTL: s.Duration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		TokenMaxAttempts:     s.Int("ACCOUNT_TOKEN_MAX_ATTEMPTS", 5),
+		TokenRequestLimit:    s.Int("ACCOUNT_TOKEN_REQUEST_LIMIT", 3),
+		TokenRequestWindow:   s.Duration("ACCOUNT_TOKEN_REQUEST_WINDOW", time.Hour),
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+type AsyncConfig struct {
+	// OrdersEnabled lets POST /orders answer 202 and place the order in a job for requests sent
+	// with "Prefer: respond-async"
+	OrdersEnabled bool
+
+	// Retention is how long the result of a command stays available after it completed
+	Retention time.Duration
+	// PurgeInterval is how often the commands past their retention are deleted
+	PurgeInterval time.Duration
+
+	// PollInterval is how often a GET /commands/{id}/events stream checks its command
+	PollInterval time.Duration
+	// StreamTimeout ends a stream still waiting for the result; clients reconnect
+	StreamTimeout time.Duration
+}
+
+func loadAsyncConfig(s *source) AsyncConfig {
+	return AsyncConfig{
+		OrdersEnabled: s.Bool("ASYNC_ORDERS_ENABLED", true),
+		Retention:     s.Duration("ASYNC_COMMAND_RETENTION", 24*time.Hour),
+		PurgeInterval: s.Duration("ASYNC_COMMAND_PURGE_INTERVAL", time.Hour),
+		PollInterval:  s.Duration("ASYNC_COMMAND_POLL_INTERVAL", 500*time.Millisecond),
+		StreamTimeout: s.Duration("ASYNC_COMMAND_STREAM_TIMEOUT", time.Minute),
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "slices"
+
+type AuditConfig struct {
+	// Enabled records every model write in the audit log
+	Enabled bool
+
+	// ExcludedTables are not audited, on top of the built-in bookkeeping tables
+	ExcludedTables []string
+}
+
+// auditBookkeepingTables churn on every request or job and carry no business changes; projected
+// read models are rebuilt from events and would be audited again on every replay
+var auditBookkeepingTables = []string{"jobs", "api_usage", "usage_counters", "login_attempts", "account_tokens", "async_commands", "processed_webhooks", "order_summaries", "projection_checkpoints", "order_number_sequences", "orders_history", "products_history", "webhook_deliveries", "webhook_attempts"}
+
+func loadAuditConfig(s *source) AuditConfig {
+	return AuditConfig{
+		Enabled:        s.Bool("AUDIT_ENABLED", true),
+		ExcludedTables: slices.Concat(auditBookkeepingTables, s.List("AUDIT_EXCLUDED_TABLES", ",")),
+	}
+}
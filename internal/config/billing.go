@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+type BillingConfig struct {
+	// FlushInterval is how often metered API calls are written to the database
+	FlushInterval time.Duration
+
+	// ExportInterval is how often the previous day's usage is sent to the billing provider
+	ExportInterval time.Duration
+
+	// StripeSecretKey enables the Stripe exporter; usage is only logged when empty
+	StripeSecretKey string
+	StripeAPIURL    string
+	// StripeMeterEventName is the event name of the Stripe billing meter for API calls
+	StripeMeterEventName string
+}
+
+func loadBillingConfig(s *source) BillingConfig {
+	return BillingConfig{
+		FlushInterval:        s.Duration("BILLING_FLUSH_INTERVAL", 10*time.Second),
+		ExportInterval:       s.Duration("BILLING_EXPORT_INTERVAL", time.Hour),
+		StripeSecretKey:      s.Secret("STRIPE_SECRET_KEY", ""),
+		StripeAPIURL:         s.String("STRIPE_API_URL", "https://api.stripe.com"),
+		StripeMeterEventName: s.String("STRIPE_METER_EVENT_NAME", "api_calls"),
+	}
+}
@@ -0,0 +1,12 @@
+package config
+
+type BootstrapConfig struct {
+	// OnStartup ensures broker topics, Redis keyspaces and storage buckets exist before serving
+	OnStartup bool
+}
+
+func loadBootstrapConfig(s *source) BootstrapConfig {
+	return BootstrapConfig{
+		OnStartup: s.Bool("INFRA_BOOTSTRAP", false),
+	}
+}
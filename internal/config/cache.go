@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type QueryCacheConfig struct {
+	// TTL bounds how long a list query result is served from memory; zero disables the cache
+	TTL time.Duration
+
+	// MaxEntries bounds the number of results each instance keeps
+	MaxEntries int
+}
+
+func loadQueryCacheConfig(s *source) QueryCacheConfig {
+	return QueryCacheConfig{
+		TTL:        s.Duration("QUERY_CACHE_TTL", 30*time.Second),
+		MaxEntries: s.Int("QUERY_CACHE_MAX_ENTRIES", 10000),
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/canary"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// ReleasePricing places the orders of its candidate users with the CANARY_ pricing settings
+const ReleasePricing = "pricing"
+
+// releases are the releases with a candidate implementation to route users to
+var releases = []string{ReleasePricing}
+
+type CanaryConfig struct {
+	// Releases are comma-separated name=percent pairs, e.g. "pricing=10"; the percentage of users
+	// gets the candidate implementation of the release. Empty keeps everyone on the stable ones.
+	Releases string
+
+	// Pricing is the candidate of the pricing release; settings it leaves out are the stable ones
+	Pricing PricingConfig
+}
+
+func loadCanaryConfig(s *source, pricing PricingConfig) CanaryConfig {
+	return CanaryConfig{
+		Releases: s.String("CANARY_RELEASES", ""),
+		Pricing:  loadPricingConfig(s, "CANARY_", pricing),
+	}
+}
+
+// Rollout parses the releases; the caller sets the observer of the rollout
+func (c CanaryConfig) Rollout() (*canary.Rollout, error) {
+	parsed, err := canary.ParseReleases(c.Releases)
+	if err != nil {
+		var errs validation.Errors
+		errs.Add("CANARY_RELEASES", err.Error())
+		return nil, errs
+	}
+	for name := range parsed {
+		if !slices.Contains(releases, name) {
+			var errs validation.Errors
+			errs.Add("CANARY_RELEASES", fmt.Sprintf("unknown release %q, expected one of %s", name, strings.Join(releases, ", ")))
+			return nil, errs
+		}
+	}
+	return &canary.Rollout{Releases: parsed}, nil
+}
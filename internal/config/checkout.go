@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type CheckoutConfig struct {
+	// SessionTTL is how long a checkout stays resumable after its last completed step
+	SessionTTL time.Duration
+
+	// PurgeInterval is how often abandoned checkout sessions are deleted
+	PurgeInterval time.Duration
+}
+
+func loadCheckoutConfig(s *source) CheckoutConfig {
+	return CheckoutConfig{
+		SessionTTL:    s.Duration("CHECKOUT_SESSION_TTL", 30*time.Minute),
+		PurgeInterval: s.Duration("CHECKOUT_PURGE_INTERVAL", time.Hour),
+	}
+}
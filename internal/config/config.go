@@ -0,0 +1,195 @@
+// Package config loads the settings of the service into Config.
+//
+// Every setting is named by its environment variable, e.g. DB_HOST. The YAML file named by
+// CONFIG_FILE may set any of them, nested or not, and the profile in APP_PROFILE changes some
+// defaults for deployed environments. Load validates the result, so a misconfigured instance
+// refuses to start instead of failing on the first request that needs the setting.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tracing"
+)
+
+// Profile is the kind of environment the service runs in
+type Profile string
+
+const (
+	ProfileDev     Profile = "dev"
+	ProfileStaging Profile = "staging"
+	ProfileProd    Profile = "prod"
+)
+
+// deployedDefaults differ from the defaults in code, which suit a local run: deployed
+// environments reach the database over TLS, log less SQL and have no well-known password or
+// localhost tracking URL to fall back on
+var deployedDefaults = map[string]string{
+	"DB_SSLMODE":            "require",
+	"DB_LOG_LEVEL":          "warn",
+	"DB_PASSWORD":           "",
+	"CAMPAIGN_TRACKING_URL": "",
+}
+
+var profileDefaults = map[Profile]map[string]string{
+	ProfileDev:     {},
+	ProfileStaging: deployedDefaults,
+	ProfileProd:    deployedDefaults,
+}
+
+// Config is the configuration of the service, by the area it configures
+type Config struct {
+	Profile Profile
+
+	Server       ServerConfig
+	Logging      LoggingConfig
+	Tracing      tracing.Options
+	Database     DatabaseConfig
+	Lock         LockConfig
+	Bootstrap    BootstrapConfig
+	Audit        AuditConfig
+	Warmup       WarmupConfig
+	QueryCache   QueryCacheConfig
+	RBAC         RBACConfig
+	Credentials  CredentialsConfig
+	Payment      PaymentConfig
+	Mode         ModeConfig
+	Tenant       TenantConfig
+	Quota        QuotaConfig
+	Jobs         JobsConfig
+	Billing      BillingConfig
+	Inventory    InventoryConfig
+	Password     PasswordConfig
+	Login        LoginConfig
+	SMTP         SMTPConfig
+	Notification NotificationConfig
+	Webhook      WebhookConfig
+	Messaging    MessagingConfig
+	Delivery     DeliveryConfig
+	Shipping     ShippingConfig
+	Checkout     CheckoutConfig
+	Saga         SagaConfig
+	Currency     CurrencyConfig
+	Sync         SyncConfig
+	Account      AccountConfig
+	Async        AsyncConfig
+	Settings     SettingsConfig
+	Portability  PortabilityConfig
+	Pricing      PricingConfig
+	Canary       CanaryConfig
+	Storage      StorageConfig
+	Reporting    ReportingConfig
+	Search       SearchConfig
+
+	settings []setting
+}
+
+// Load reads the configuration from the environment and the file in CONFIG_FILE, if any, and
+// validates it. The error lists every invalid setting, as validation.Errors keyed by the name
+// of the setting.
+func Load() (*Config, error) {
+	s := newSource()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := s.readFile(path); err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	c := &Config{Profile: Profile(s.String("APP_PROFILE", string(ProfileDev)))}
+	defaults, ok := profileDefaults[c.Profile]
+	s.errs.Check(ok, "APP_PROFILE", fmt.Sprintf("must be one of dev, staging, prod, got %q", c.Profile))
+	s.profile = defaults
+
+	c.Server = loadServerConfig(s)
+	c.Logging = loadLoggingConfig(s)
+	c.Tracing = loadTracingConfig(s)
+	c.Database = loadDatabaseConfig(s)
+	c.Lock = loadLockConfig(s)
+	c.Bootstrap = loadBootstrapConfig(s)
+	c.Audit = loadAuditConfig(s)
+	c.Warmup = loadWarmupConfig(s)
+	c.QueryCache = loadQueryCacheConfig(s)
+	c.RBAC = loadRBACConfig(s)
+	c.Credentials = loadCredentialsConfig(s)
+	c.Payment = loadPaymentConfig(s)
+	c.Mode = loadModeConfig(s)
+	c.Tenant = loadTenantConfig(s)
+	c.Quota = loadQuotaConfig(s)
+	c.Jobs = loadJobsConfig(s)
+	c.Billing = loadBillingConfig(s)
+	c.Inventory = loadInventoryConfig(s)
+	c.Password = loadPasswordConfig(s)
+	c.Login = loadLoginConfig(s)
+	c.SMTP = loadSMTPConfig(s)
+	c.Notification = loadNotificationConfig(s)
+	c.Webhook = loadWebhookConfig(s)
+	c.Messaging = loadMessagingConfig(s)
+	c.Delivery = loadDeliveryConfig(s)
+	c.Shipping = loadShippingConfig(s)
+	c.Checkout = loadCheckoutConfig(s)
+	c.Saga = loadSagaConfig(s)
+	c.Currency = loadCurrencyConfig(s)
+	c.Sync = loadSyncConfig(s)
+	c.Account = loadAccountConfig(s)
+	c.Async = loadAsyncConfig(s)
+	c.Settings = loadSettingsConfig(s)
+	c.Portability = loadPortabilityConfig(s)
+	c.Pricing = loadPricingConfig(s, "", PricingConfig{})
+	c.Canary = loadCanaryConfig(s, c.Pricing)
+	c.Storage = loadStorageConfig(s)
+	c.Reporting = loadReportingConfig(s)
+	c.Search = loadSearchConfig(s)
+	c.settings = s.settings
+
+	for _, key := range s.unknown() {
+		s.errs.Add(key, "is not a known setting")
+	}
+	errs := s.errs
+	if ok {
+		errs.Merge("", c.Validate())
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Redacted lists every setting with its value and where the value came from, one per line,
+// for logging at startup. Secrets that are set read [redacted].
+func (c *Config) Redacted() string {
+	var b strings.Builder
+	for _, s := range c.settings {
+		value := s.value
+		if s.secret && value != "" {
+			value = "[redacted]"
+		}
+		fmt.Fprintf(&b, "%s=%s (%s)\n", s.key, value, s.origin)
+	}
+	return b.String()
+}
+
+// Features names the optional features the configuration enables, e.g. "rbac" and
+// "messaging:kafka", for reporting which build and setup an instance runs
+func (c *Config) Features() []string {
+	var features []string
+	add := func(enabled bool, name string) {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	add(c.RBAC.Enabled, "rbac")
+	add(c.Audit.Enabled, "audit")
+	add(c.Tracing.Endpoint != "", "tracing")
+	add(len(c.Database.ReplicaDSNs) > 0, "read_replicas")
+	add(c.QueryCache.TTL > 0, "query_cache")
+	add(c.Warmup.OnStartup, "cache_warmup")
+	add(c.Async.OrdersEnabled, "async_orders")
+	add(c.Tenant.Domain != "", "tenant_subdomains")
+	add(c.Login.StepUpOnAnomaly, "login_step_up")
+	add(c.Password.Policy.CheckBreached, "breached_passwords")
+	add(c.Messaging.Driver != "none", "messaging:"+c.Messaging.Driver)
+	add(c.Storage.Driver != "local", "storage:"+c.Storage.Driver)
+	return features
+}
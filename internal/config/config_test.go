@@ -0,0 +1,186 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	t.Setenv("CONFIG_FILE", path)
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, ProfileDev, cfg.Profile)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, "disable", cfg.Database.SSLMode)
+	assert.Equal(t, []string{"dhl", "ups", "fedex"}, cfg.Shipping.Carriers)
+}
+
+func TestLoad_FileUnderEnvironment(t *testing.T) {
+	writeConfigFile(t, `
+db:
+  host: db.internal
+  max_open_conns: 40
+messaging_driver: log
+kafka_brokers: [kafka-1:9092, kafka-2:9092]
+jobs:
+  poll_interval: 5s
+`)
+	t.Setenv("DB_HOST", "db.override")
+
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, "db.override", cfg.Database.Host, "the environment wins over the file")
+	assert.Equal(t, 40, cfg.Database.MaxOpenConns)
+	assert.Equal(t, "log", cfg.Messaging.Driver)
+	assert.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, cfg.Messaging.KafkaBrokers)
+	assert.Equal(t, 5*time.Second, cfg.Jobs.PollInterval)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	writeConfigFile(t, "db_hots: db.internal\n")
+	t.Setenv("HTTP_ADDR", ":99999")
+	t.Setenv("MESSAGING_DRIVER", "nats")
+	t.Setenv("LOCK_BACKEND", "redis")
+	t.Setenv("LOCK_REDIS_ADDR", "redis")
+	t.Setenv("JOBS_CONCURRENCY", "four")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("EXCHANGE_RATE_ROUNDING", "ceiling")
+	t.Setenv("SETTINGS_LOGO_URL", "http://cdn.example/logo.png")
+	t.Setenv("FREE_SHIPPING_THRESHOLD", "free")
+	t.Setenv("CANARY_RELEASES", "search=10")
+	t.Setenv("QUERY_CACHE_TTL", "-1s")
+
+	_, err := Load()
+
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.ElementsMatch(t, validation.Errors{
+		{Field: "JOBS_CONCURRENCY", Message: `must be an integer, got "four"`},
+		{Field: "DB_HOTS", Message: "is not a known setting"},
+		{Field: "HTTP_ADDR", Message: `must be a port between 1 and 65535, got "99999"`},
+		{Field: "MESSAGING_DRIVER", Message: `must be one of none, log, kafka, rabbitmq, got "nats"`},
+		{Field: "LOCK_REDIS_ADDR", Message: `must be host:port, got "redis"`},
+		{Field: "CORS_ALLOW_CREDENTIALS", Message: `cannot be combined with the "*" origin of CORS_ALLOWED_ORIGINS`},
+		{Field: "EXCHANGE_RATE_ROUNDING", Message: `must be one of half_up, half_even, down, got "ceiling"`},
+		{Field: "SETTINGS_LOGO_URL", Message: "must be an https URL"},
+		{Field: "FREE_SHIPPING_THRESHOLD", Message: "must be a positive amount in the base currency, e.g. 50.00"},
+		{Field: "CANARY_RELEASES", Message: `unknown release "search", expected one of pricing`},
+		{Field: "QUERY_CACHE_TTL", Message: "must not be negative"},
+	}, errs)
+}
+
+func TestLoad_CanaryPricing(t *testing.T) {
+	t.Setenv("TAX_RATES", "DE=19")
+	t.Setenv("SHIPPING_FEES", "*=9.90")
+	t.Setenv("CANARY_SHIPPING_FEES", "*=4.90")
+
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, "DE=19", cfg.Canary.Pricing.TaxRates, "the candidate falls back to the stable settings")
+	assert.Equal(t, "*=4.90", cfg.Canary.Pricing.ShippingFees)
+
+	t.Setenv("CANARY_TAX_RATES", "DE=lots")
+	_, err = Load()
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, "CANARY_TAX_RATES", errs[0].Field)
+}
+
+func TestLoad_Profile(t *testing.T) {
+	t.Setenv("APP_PROFILE", "prod")
+
+	_, err := Load()
+
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.ElementsMatch(t, validation.Errors{
+		{Field: "DB_PASSWORD", Message: "is required"},
+		{Field: "ENCRYPTION_KEYS", Message: "is required"},
+		{Field: "CAMPAIGN_TRACKING_URL", Message: "is required"},
+		{Field: "STORAGE_URL_SECRET", Message: "is required"},
+		{Field: "PAYMENT_WEBHOOK_SECRET", Message: "is required"},
+		{Field: "CARRIER_WEBHOOK_SECRET", Message: "is required"},
+	}, errs)
+
+	t.Setenv("DB_PASSWORD", "s3cret")
+	t.Setenv("ENCRYPTION_KEYS", "k1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	t.Setenv("CAMPAIGN_TRACKING_URL", "https://api.aiio.example")
+	t.Setenv("STORAGE_URL_SECRET", "signing-secret")
+	t.Setenv("PAYMENT_WEBHOOK_SECRET", "payment-secret")
+	t.Setenv("CARRIER_WEBHOOK_SECRET", "carrier-secret")
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, "require", cfg.Database.SSLMode)
+}
+
+func TestLoad_S3Storage(t *testing.T) {
+	t.Setenv("STORAGE_DRIVER", "s3")
+	t.Setenv("STORAGE_URL_TTL", "240h")
+
+	_, err := Load()
+
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.ElementsMatch(t, validation.Errors{
+		{Field: "S3_BUCKET", Message: "is required"},
+		{Field: "S3_ACCESS_KEY_ID", Message: "is required"},
+		{Field: "S3_SECRET_ACCESS_KEY", Message: "is required"},
+		{Field: "STORAGE_URL_TTL", Message: "must be at most 168h with S3, got 240h0m0s"},
+	}, errs)
+
+	t.Setenv("STORAGE_URL_TTL", "1h")
+	t.Setenv("S3_BUCKET", "aiio-images")
+	t.Setenv("S3_ACCESS_KEY_ID", "AKID")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "secret")
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", cfg.Storage.S3Region)
+	assert.Contains(t, cfg.Features(), "storage:s3")
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	writeConfigFile(t, "db_host: db.internal\n")
+	t.Setenv("DB_PASSWORD", "s3cret")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	redacted := cfg.Redacted()
+
+	assert.Contains(t, redacted, "DB_HOST=db.internal (file)\n")
+	assert.Contains(t, redacted, "DB_PASSWORD=[redacted] (env)\n")
+	assert.Contains(t, redacted, "DB_PORT=5432 (default)\n")
+	assert.Contains(t, redacted, "SMTP_PASSWORD= (default)\n", "unset secrets show as empty")
+	assert.NotContains(t, redacted, "s3cret")
+}
+
+func TestConfig_Features(t *testing.T) {
+	t.Setenv("RBAC_ENABLED", "true")
+	t.Setenv("QUERY_CACHE_TTL", "0s")
+	t.Setenv("MESSAGING_DRIVER", "log")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	features := cfg.Features()
+
+	assert.Contains(t, features, "rbac")
+	assert.Contains(t, features, "messaging:log")
+	assert.NotContains(t, features, "query_cache")
+	assert.NotContains(t, features, "tracing")
+}
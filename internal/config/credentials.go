@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type CredentialsConfig struct {
+	// EncryptionKeys encrypt stored credentials, as "id:base64key,..."; the first key encrypts new values
+	EncryptionKeys string
+	// CacheTTL is how long an instance uses a credential before reading it again, bounding how
+	// long other instances keep the old value after a rotation
+	CacheTTL time.Duration
+}
+
+func loadCredentialsConfig(s *source) CredentialsConfig {
+	return CredentialsConfig{
+		EncryptionKeys: s.Secret("ENCRYPTION_KEYS", ""),
+		CacheTTL:       s.Duration("CREDENTIALS_CACHE_TTL", time.Minute),
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+type CurrencyConfig struct {
+	// BaseCurrency is the currency product prices are stored in; prices given in another
+	// currency are converted into it when products are created or imported
+	BaseCurrency string
+
+	// RateProvider selects where exchange rates come from: ecb, fixer or none, which serves
+	// prices only in the currency they are stored in
+	RateProvider string
+	ECBURL       string
+	FixerAPIURL  string
+	FixerAPIKey  string
+
+	// RateCacheTTL is how long fetched rates are used before they are fetched again
+	RateCacheTTL time.Duration
+
+	// Rounding rounds converted amounts to the minor unit of their currency: half_up, half_even or down
+	Rounding string
+}
+
+func loadCurrencyConfig(s *source) CurrencyConfig {
+	return CurrencyConfig{
+		BaseCurrency: strings.ToUpper(s.String("BASE_CURRENCY", "EUR")),
+		RateProvider: s.String("EXCHANGE_RATE_PROVIDER", "ecb"),
+		ECBURL:       s.String("ECB_RATES_URL", ""),
+		FixerAPIURL:  s.String("FIXER_API_URL", ""),
+		FixerAPIKey:  s.Secret("FIXER_API_KEY", ""),
+		RateCacheTTL: s.Duration("EXCHANGE_RATE_TTL", time.Hour),
+		Rounding:     s.String("EXCHANGE_RATE_ROUNDING", "half_up"),
+	}
+}
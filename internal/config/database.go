@@ -1,36 +1,114 @@
 package config
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	auditDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	credentialDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
 	"log"
 	"os"
+	"time"
 
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
 
+	asyncDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+	billingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	checkoutDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	couponDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	notificationDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
 	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
 	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	reportingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+	reviewDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	settingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/migration"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/projection"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/repair"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/saga"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	shippingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	supportDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
 	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	webhookDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
 )
 
 type DatabaseConfig struct {
+	// Driver is postgres, or sqlite for local runs and end-to-end tests where DBName is the database file
+	Driver string
+
 	Host     string
 	Port     string
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// Connection pool tuning applied to the underlying *sql.DB
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// SlowQueryThreshold makes the GORM logger warn about queries slower than this (0 disables)
+	SlowQueryThreshold time.Duration
+
+	// LogLevel is one of silent, error, warn, info
+	LogLevel string
+
+	// PrepareStmt caches prepared statements for repeated queries
+	PrepareStmt bool
+
+	// ReplicaDSNs lists read replicas; reads are load balanced across them while
+	// writes and persistence.WithPrimary contexts stay on the primary
+	ReplicaDSNs []string
+
+	// AutoMigrate applies the schema when the database is behind SchemaVersion
+	AutoMigrate bool
+
+	// SchemaTolerance is how many versions binary and database may differ by and still serve traffic
+	SchemaTolerance int
+
+	// SchemaReadOnlyOnMismatch serves reads only instead of refusing to start beyond the tolerance
+	SchemaReadOnlyOnMismatch bool
 }
 
-func GetDatabaseConfig() *DatabaseConfig {
-	return &DatabaseConfig{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres"),
-		DBName:   getEnv("DB_NAME", "aiio_backend"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
+func loadDatabaseConfig(s *source) DatabaseConfig {
+	return DatabaseConfig{
+		Driver:   s.String("DB_DRIVER", "postgres"),
+		Host:     s.String("DB_HOST", "localhost"),
+		Port:     s.String("DB_PORT", "5432"),
+		User:     s.String("DB_USER", "postgres"),
+		Password: s.Secret("DB_PASSWORD", "postgres"),
+		DBName:   s.String("DB_NAME", "aiio_backend"),
+		SSLMode:  s.String("DB_SSLMODE", "disable"),
+
+		MaxOpenConns:    s.Int("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    s.Int("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: s.Duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: s.Duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		SlowQueryThreshold: s.Duration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		LogLevel:           s.String("DB_LOG_LEVEL", "info"),
+		PrepareStmt:        s.Bool("DB_PREPARE_STMT", true),
+		ReplicaDSNs:        s.SecretList("DB_REPLICA_DSNS", ";"),
+
+		AutoMigrate:              s.Bool("DB_AUTO_MIGRATE", true),
+		SchemaTolerance:          s.Int("DB_SCHEMA_TOLERANCE", 1),
+		SchemaReadOnlyOnMismatch: s.Bool("DB_SCHEMA_READ_ONLY_ON_MISMATCH", false),
 	}
 }
 
@@ -39,34 +117,335 @@ func (config *DatabaseConfig) BuildDSN() string {
 		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
 }
 
-func ConnectDatabase() (*gorm.DB, error) {
-	config := GetDatabaseConfig()
-	dsn := config.BuildDSN()
+// Dialector opens the configured database driver
+func (config *DatabaseConfig) Dialector() (gorm.Dialector, error) {
+	switch config.Driver {
+	case "postgres":
+		return postgres.Open(config.BuildDSN()), nil
+	case "sqlite":
+		// Enforce foreign keys like postgres does; sqlite leaves them off by default
+		return sqlite.Open(config.DBName + "?_foreign_keys=on&_busy_timeout=5000"), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", config.Driver)
+	}
+}
+
+// Locks coordinates the instances sharing the database: with advisory locks on postgres, and
+// within the process on sqlite, which a single instance uses
+func (config *DatabaseConfig) Locks(db *sql.DB) lock.DistributedLock {
+	if config.Driver == "postgres" {
+		return lock.NewPostgres(db)
+	}
+	return lock.NewLocal()
+}
+
+// GormLogger builds a logger reporting slow queries above SlowQueryThreshold at the configured level
+func (config *DatabaseConfig) GormLogger() logger.Interface {
+	return logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold:             config.SlowQueryThreshold,
+		LogLevel:                  parseLogLevel(config.LogLevel),
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  false,
+	})
+}
+
+// ApplyPool applies the connection pool settings to the underlying database handle
+func (config *DatabaseConfig) ApplyPool(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+}
+
+func ConnectDatabase(config *DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := config.Dialector()
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:         config.GormLogger(),
+		PrepareStmt:    config.PrepareStmt,
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto migrate the schemas
-	err = db.AutoMigrate(
-		&userDomain.User{},
-		&productDomain.Product{},
-		&orderDomain.Order{},
-	)
+	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+		return nil, fmt.Errorf("failed to get SQL DB: %w", err)
+	}
+	config.ApplyPool(sqlDB)
+
+	// Route reads to replicas when configured
+	if err := useReplicas(db, config); err != nil {
+		return nil, fmt.Errorf("failed to configure read replicas: %w", err)
+	}
+
+	// Emit a span per SQL statement; bound values are left out so traces carry no personal data
+	if err := db.Use(gormtracing.NewPlugin(
+		gormtracing.WithDBName(config.DBName),
+		gormtracing.WithoutQueryVariables(),
+		gormtracing.WithoutMetrics(),
+	)); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
 	}
 
-	log.Println("Database connected and migrated successfully")
 	return db, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// SchemaVersion is the database schema this binary is built for.
+// Bump it whenever the models migrated by MigrateDatabase change.
+const SchemaVersion = 46
+
+// MigrateDatabase brings a database that is behind SchemaVersion up to date and checks that the
+// applied version is compatible with this binary, returning whether it may write
+func MigrateDatabase(ctx context.Context, db *gorm.DB, config *DatabaseConfig) (migration.Mode, error) {
+	// Migrations backfill the rows of every tenant
+	ctx = tenant.Unscoped(ctx)
+	applied, err := migration.AppliedVersion(ctx, db)
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	// A newer database is left alone so old binaries never undo a rollout
+	if config.AutoMigrate && applied < SchemaVersion {
+		err = db.WithContext(ctx).AutoMigrate(
+			&userDomain.User{},
+			&userDomain.LoginAttempt{},
+			&userDomain.AccountToken{},
+			&userDomain.APIKey{},
+			&asyncDomain.Command{},
+			&userDomain.Permission{},
+			&userDomain.Role{},
+			&userDomain.UserRole{},
+			&userDomain.Address{},
+			&productDomain.Product{},
+			&productDomain.StockReservation{},
+			&productDomain.ProductVersion{},
+			&productDomain.ProductImage{},
+			&orderDomain.Order{},
+			&orderDomain.OrderStatusChange{},
+			&orderDomain.NumberSequence{},
+			&orderDomain.OrderVersion{},
+			&paymentDomain.Payment{},
+			&paymentDomain.Refund{},
+			&paymentDomain.PaymentEntry{},
+			&paymentDomain.ProcessedWebhook{},
+			&paymentDomain.Dispute{},
+			&paymentDomain.DisputeEvidence{},
+			&quotaDomain.TenantPlan{},
+			&quotaDomain.UsageCounter{},
+			&billingDomain.APIUsage{},
+			&billingDomain.BillingAccount{},
+			&jobs.Record{},
+			&checkoutDomain.Session{},
+			&auditDomain.Entry{},
+			&credentialDomain.Credential{},
+			&settingDomain.Setting{},
+			&couponDomain.Coupon{},
+			&couponDomain.Redemption{},
+			&reviewDomain.Review{},
+			&reviewDomain.ProductRating{},
+			&reportingDomain.DailySales{},
+			&reportingDomain.DailyProductSales{},
+			&messaging.OutboxMessage{},
+			&orderDomain.OrderSummary{},
+			&projection.Checkpoint{},
+			&notificationDomain.Campaign{},
+			&notificationDomain.Delivery{},
+			&notificationDomain.SendSlot{},
+			&deliveryDomain.Estimate{},
+			&deliveryDomain.Warehouse{},
+			&shippingDomain.Shipment{},
+			&shippingDomain.TrackingEvent{},
+			&repair.Run{},
+			&supportDomain.QueryLog{},
+			&webhookDomain.Endpoint{},
+			&webhookDomain.Delivery{},
+			&webhookDomain.Attempt{},
+			&saga.Instance{},
+			&saga.Marker{},
+		)
+		if err != nil {
+			return "", fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if err := dropGlobalUniqueIndexes(ctx, db); err != nil {
+			return "", err
+		}
+		if err := backfillPublicIDs(ctx, db); err != nil {
+			return "", err
+		}
+		if err := backfillUpdatedAt(ctx, db); err != nil {
+			return "", err
+		}
+		if err := backfillTenants(ctx, db); err != nil {
+			return "", err
+		}
+		if config.Driver == "postgres" {
+			if err := indexWarehouseLocations(ctx, db); err != nil {
+				return "", err
+			}
+		}
+		if err := migration.Record(ctx, db, SchemaVersion); err != nil {
+			return "", fmt.Errorf("failed to record schema version: %w", err)
+		}
+		log.Printf("Database migrated from schema version %d to %d", applied, SchemaVersion)
+		applied = SchemaVersion
+	}
+
+	gate := migration.Gate{
+		Expected:           SchemaVersion,
+		Tolerance:          config.SchemaTolerance,
+		ReadOnlyOnMismatch: config.SchemaReadOnlyOnMismatch,
+	}
+	return gate.Check(applied)
+}
+
+// backfillPublicIDs assigns public IDs to the users, products and orders created before schema version 15
+func backfillPublicIDs(ctx context.Context, db *gorm.DB) error {
+	tables := []struct{ table, prefix string }{
+		{"users", userDomain.PublicIDPrefix},
+		{"products", productDomain.PublicIDPrefix},
+		{"orders", orderDomain.PublicIDPrefix},
+	}
+	for _, t := range tables {
+		n, err := publicid.Backfill(ctx, db, t.table, t.prefix)
+		if err != nil {
+			return fmt.Errorf("failed to backfill public IDs: %w", err)
+		}
+		if n > 0 {
+			log.Printf("Assigned public IDs to %d %s", n, t.table)
+		}
+	}
+	return nil
+}
+
+// dropGlobalUniqueIndexes drops the unique indexes that schema version 41 replaced with ones
+// within each tenant, so tenants may reuse email addresses, SKUs and coupon codes
+func dropGlobalUniqueIndexes(ctx context.Context, db *gorm.DB) error {
+	indexes := []struct {
+		model any
+		name  string
+	}{
+		{&userDomain.User{}, "idx_users_email"},
+		{&productDomain.Product{}, "idx_products_sku"},
+		{&couponDomain.Coupon{}, "idx_coupons_code"},
+	}
+	migrator := db.WithContext(ctx).Migrator()
+	for _, index := range indexes {
+		if !migrator.HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := migrator.DropIndex(index.model, index.name); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", index.name, err)
+		}
+	}
+	return nil
+}
+
+// backfillUpdatedAt stamps the products and orders written before schema version 34 with the
+// migration time, so the first sync of offline clients returns them
+func backfillUpdatedAt(ctx context.Context, db *gorm.DB) error {
+	now := time.Now()
+	for _, table := range []string{"products", "orders"} {
+		result := db.WithContext(ctx).Table(table).Where("updated_at IS NULL").Update("updated_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill updated_at of %s: %w", table, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Stamped %d %s with updated_at", result.RowsAffected, table)
+		}
+	}
+	return nil
+}
+
+// backfillTenants gives the rows derived from orders and users, which have a tenant_id since schema
+// version 45, the tenant of their order or user; they were created with the default tenant
+func backfillTenants(ctx context.Context, db *gorm.DB) error {
+	derived := []struct{ table, key, parent, parentKey string }{
+		{"addresses", "user_id", "users", "id"},
+		{"order_summaries", "order_id", "orders", "public_id"},
+		{"payments", "order_id", "orders", "id"},
+		{"refunds", "order_id", "orders", "id"},
+		{"disputes", "order_id", "orders", "id"},
+		{"shipments", "order_id", "orders", "id"},
+		{"delivery_estimates", "order_id", "orders", "id"},
+	}
+	for _, d := range derived {
+		parent := fmt.Sprintf("SELECT p.tenant_id FROM %s p WHERE p.%s = %s.%s", d.parent, d.parentKey, d.table, d.key)
+		result := db.WithContext(ctx).Exec(fmt.Sprintf(
+			"UPDATE %s SET tenant_id = (%s) WHERE tenant_id = ? AND EXISTS (%s AND p.tenant_id <> ?)",
+			d.table, parent, parent,
+		), tenant.DefaultID, tenant.DefaultID)
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill tenant_id of %s: %w", d.table, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Moved %d %s to the tenant of their %s", result.RowsAffected, d.table, d.parent)
+		}
+	}
+
+	// Order summaries kept the tenant in a column of their own before
+	migrator := db.WithContext(ctx).Migrator()
+	if migrator.HasColumn(&orderDomain.OrderSummary{}, "tenant") {
+		if err := migrator.DropColumn(&orderDomain.OrderSummary{}, "tenant"); err != nil {
+			return fmt.Errorf("failed to drop order_summaries.tenant: %w", err)
+		}
+	}
+	return nil
+}
+
+// indexWarehouseLocations enables earthdistance, which finds the nearest warehouse since schema
+// version 46, and indexes the warehouses by their point on the earth
+func indexWarehouseLocations(ctx context.Context, db *gorm.DB) error {
+	for _, statement := range []string{
+		"CREATE EXTENSION IF NOT EXISTS cube",
+		"CREATE EXTENSION IF NOT EXISTS earthdistance",
+		"CREATE INDEX IF NOT EXISTS idx_warehouses_earth ON warehouses USING gist (ll_to_earth(latitude, longitude))",
+	} {
+		if err := db.WithContext(ctx).Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to index warehouse locations: %w", err)
+		}
+	}
+	return nil
+}
+
+func useReplicas(db *gorm.DB, config *DatabaseConfig) error {
+	if len(config.ReplicaDSNs) > 0 {
+		replicas := make([]gorm.Dialector, len(config.ReplicaDSNs))
+		for i, dsn := range config.ReplicaDSNs {
+			replicas[i] = postgres.Open(dsn)
+		}
+
+		resolver := dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		}).
+			SetMaxOpenConns(config.MaxOpenConns).
+			SetMaxIdleConns(config.MaxIdleConns).
+			SetConnMaxLifetime(config.ConnMaxLifetime).
+			SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+		if err := db.Use(resolver); err != nil {
+			return err
+		}
+		log.Printf("Read replicas configured: %d", len(replicas))
+	}
+
+	return persistence.RegisterPrimaryRouting(db)
+}
+
+func parseLogLevel(level string) logger.LogLevel {
+	switch level {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "info":
+		return logger.Info
+	default:
+		return logger.Warn
 	}
-	return defaultValue
 }
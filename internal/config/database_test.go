@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/logger"
+)
+
+func TestLoadDatabaseConfig_Defaults(t *testing.T) {
+	config := loadDatabaseConfig(newSource())
+
+	assert.Equal(t, 25, config.MaxOpenConns)
+	assert.Equal(t, 10, config.MaxIdleConns)
+	assert.Equal(t, 30*time.Minute, config.ConnMaxLifetime)
+	assert.Equal(t, 5*time.Minute, config.ConnMaxIdleTime)
+	assert.Equal(t, 200*time.Millisecond, config.SlowQueryThreshold)
+	assert.True(t, config.PrepareStmt)
+	assert.True(t, config.AutoMigrate)
+	assert.Equal(t, 1, config.SchemaTolerance)
+	assert.False(t, config.SchemaReadOnlyOnMismatch)
+}
+
+func TestLoadDatabaseConfig_FromEnv(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "50")
+	t.Setenv("DB_MAX_IDLE_CONNS", "not-a-number")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "1h")
+	t.Setenv("DB_SLOW_QUERY_THRESHOLD", "1s")
+	t.Setenv("DB_PREPARE_STMT", "false")
+
+	s := newSource()
+	config := loadDatabaseConfig(s)
+
+	assert.Equal(t, 50, config.MaxOpenConns)
+	assert.Equal(t, 10, config.MaxIdleConns)
+	assert.Equal(t, validation.Errors{{Field: "DB_MAX_IDLE_CONNS", Message: `must be an integer, got "not-a-number"`}}, s.errs)
+	assert.Equal(t, time.Hour, config.ConnMaxLifetime)
+	assert.Equal(t, time.Second, config.SlowQueryThreshold)
+	assert.False(t, config.PrepareStmt)
+}
+
+func TestParseLogLevel(t *testing.T) {
+	assert.Equal(t, logger.Silent, parseLogLevel("silent"))
+	assert.Equal(t, logger.Info, parseLogLevel("info"))
+	assert.Equal(t, logger.Warn, parseLogLevel("unknown"))
+}
+
+func TestDatabaseConfig_Dialector(t *testing.T) {
+	config := loadDatabaseConfig(newSource())
+	dialector, err := config.Dialector()
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres", dialector.Name())
+
+	config.Driver, config.DBName = "sqlite", "e2e.db"
+	dialector, err = config.Dialector()
+	assert.NoError(t, err)
+	assert.Equal(t, "sqlite", dialector.Name())
+
+	config.Driver = "mysql"
+	_, err = config.Dialector()
+	assert.Error(t, err)
+}
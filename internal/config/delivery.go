@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+type DeliveryConfig struct {
+	// ProcessingDays is how many business days the warehouse takes to hand an order to the carrier
+	ProcessingDays int
+	// Cutoff is the time of day, in TimeZone, orders must be placed by to start processing that day
+	Cutoff time.Duration
+	// TimeZone is the IANA time zone of the warehouse
+	TimeZone string
+
+	// StandardTransit and ExpressTransit are the carrier transit times in business days by
+	// destination country, e.g. "*=3-5,DE=1-2" where * is every other country
+	StandardTransit string
+	ExpressTransit  string
+
+	// Warehouses ship the orders to addresses with coordinates from the nearest of them, e.g.
+	// "BER=52.52,13.40,Europe/Berlin;NYC=40.71,-74.01,America/New_York"; other orders ship from TimeZone
+	Warehouses string
+}
+
+func loadDeliveryConfig(s *source) DeliveryConfig {
+	return DeliveryConfig{
+		ProcessingDays:  s.Int("DELIVERY_PROCESSING_DAYS", 1),
+		Cutoff:          s.Duration("DELIVERY_CUTOFF", 14*time.Hour),
+		TimeZone:        s.String("DELIVERY_TIMEZONE", "UTC"),
+		StandardTransit: s.String("DELIVERY_TRANSIT_STANDARD", "*=3-5"),
+		ExpressTransit:  s.String("DELIVERY_TRANSIT_EXPRESS", "*=1-2"),
+		Warehouses:      s.String("DELIVERY_WAREHOUSES", ""),
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+type InventoryConfig struct {
+	// ReservationTTL is how long an order that never completes holds stock
+	ReservationTTL time.Duration
+
+	// ReleaseInterval is how often expired reservations are released
+	ReleaseInterval time.Duration
+
+	// Locking is how concurrent orders of one product are serialized: optimistic or pessimistic
+	Locking string
+
+	// LowStockThreshold is the stock level at or below which a product is reported as running low
+	LowStockThreshold int
+}
+
+func loadInventoryConfig(s *source) InventoryConfig {
+	return InventoryConfig{
+		ReservationTTL:  s.Duration("STOCK_RESERVATION_TTL", 15*time.Minute),
+		ReleaseInterval: s.Duration("STOCK_RESERVATION_RELEASE_INTERVAL", time.Minute),
+		Locking:         s.String("STOCK_LOCKING", "optimistic"),
+
+		LowStockThreshold: s.Int("STOCK_LOW_THRESHOLD", 5),
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+type JobsConfig struct {
+	// Concurrency is the number of jobs a worker runs in parallel
+	Concurrency int
+
+	// PollInterval is how often an idle worker checks the queue
+	PollInterval time.Duration
+
+	// MaxAttempts is how often a failing job is tried before it is marked dead
+	MaxAttempts int
+
+	// BackoffBase and BackoffMax bound the exponential delay between attempts
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+
+	// Lease is how long a running job may take before another worker claims it again
+	Lease time.Duration
+}
+
+func loadJobsConfig(s *source) JobsConfig {
+	return JobsConfig{
+		Concurrency:  s.Int("JOBS_CONCURRENCY", 4),
+		PollInterval: s.Duration("JOBS_POLL_INTERVAL", time.Second),
+		MaxAttempts:  s.Int("JOBS_MAX_ATTEMPTS", 5),
+		BackoffBase:  s.Duration("JOBS_BACKOFF_BASE", 5*time.Second),
+		BackoffMax:   s.Duration("JOBS_BACKOFF_MAX", time.Hour),
+		Lease:        s.Duration("JOBS_LEASE", 5*time.Minute),
+	}
+}
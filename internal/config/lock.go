@@ -0,0 +1,38 @@
+package config
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+)
+
+type LockConfig struct {
+	// Backend holds the locks of singleton work: database, advisory locks on postgres and
+	// in-process locks on sqlite, or redis
+	Backend       string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	// TTL is how long a Redis lock outlives an instance that died holding it
+	TTL time.Duration
+}
+
+func loadLockConfig(s *source) LockConfig {
+	return LockConfig{
+		Backend:       s.String("LOCK_BACKEND", "database"),
+		RedisAddr:     s.String("LOCK_REDIS_ADDR", "localhost:6379"),
+		RedisPassword: s.Secret("LOCK_REDIS_PASSWORD", ""),
+		RedisDB:       s.Int("LOCK_REDIS_DB", 0),
+		TTL:           s.Duration("LOCK_TTL", 30*time.Second),
+	}
+}
+
+// Locks coordinates the instances: through Redis when configured, and through the database
+// otherwise
+func (config *LockConfig) Locks(database *DatabaseConfig, db *sql.DB) lock.DistributedLock {
+	if config.Backend == "redis" {
+		return lock.NewRedis(config.RedisAddr, config.RedisPassword, config.RedisDB, config.TTL)
+	}
+	return database.Locks(db)
+}
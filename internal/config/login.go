@@ -0,0 +1,28 @@
+package config
+
+import (
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+type LoginConfig struct {
+	Detector userDomain.LoginAnomalyDetector
+
+	// StepUpOnAnomaly requires an additional authentication factor when a login looks suspicious
+	StepUpOnAnomaly bool
+
+	// GeoIPAPIURL overrides the ip-api.com lookup endpoint
+	GeoIPAPIURL string
+}
+
+func loadLoginConfig(s *source) LoginConfig {
+	defaults := userDomain.DefaultLoginAnomalyDetector
+
+	return LoginConfig{
+		Detector: userDomain.LoginAnomalyDetector{
+			MaxTravelSpeedKmh:   float64(s.Int("LOGIN_MAX_TRAVEL_SPEED_KMH", int(defaults.MaxTravelSpeedKmh))),
+			MinTravelDistanceKm: float64(s.Int("LOGIN_MIN_TRAVEL_DISTANCE_KM", int(defaults.MinTravelDistanceKm))),
+		},
+		StepUpOnAnomaly: s.Bool("LOGIN_STEP_UP_ON_ANOMALY", false),
+		GeoIPAPIURL:     s.String("GEOIP_API_URL", ""),
+	}
+}
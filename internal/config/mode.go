@@ -0,0 +1,19 @@
+package config
+
+type ModeConfig struct {
+	// Default is the adapter mode of tenants not listed in SandboxTenants: live or sandbox
+	Default string
+	// SandboxTenants always use the sandbox endpoints, e.g. the tenants of integration partners
+	SandboxTenants []string
+
+	// StripeSandboxSecretKey is a Stripe test-mode key; without it sandbox payments use the fake gateway
+	StripeSandboxSecretKey string
+}
+
+func loadModeConfig(s *source) ModeConfig {
+	return ModeConfig{
+		Default:                s.String("ADAPTER_MODE", "live"),
+		SandboxTenants:         s.List("SANDBOX_TENANTS", ","),
+		StripeSandboxSecretKey: s.Secret("STRIPE_SANDBOX_SECRET_KEY", ""),
+	}
+}
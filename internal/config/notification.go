@@ -0,0 +1,45 @@
+package config
+
+type NotificationConfig struct {
+	// Provider selects the email adapter: smtp or sendgrid. SMTP without SMTP_HOST only logs emails.
+	Provider string
+
+	// From is the sender address of notification emails
+	From string
+
+	SendGridAPIKey string
+	SendGridAPIURL string
+
+	// TrackingURL is the public base URL of the API; campaign emails load their open tracking pixel from it
+	TrackingURL string
+	// CampaignBatchSize is how many recipients each campaign dispatch job adds
+	CampaignBatchSize int
+	// SendGridRateLimit and SMTPRateLimit are the campaign emails sent per second through the
+	// provider, shared by all campaigns and instances; 0 disables pacing
+	SendGridRateLimit float64
+	SMTPRateLimit     float64
+
+	// StockAlertRecipients are emailed when a product runs low on stock; empty sends no alerts
+	StockAlertRecipients []string
+}
+
+// RateLimits returns the campaign send rates per provider, keyed like Provider
+func (c *NotificationConfig) RateLimits() map[string]float64 {
+	return map[string]float64{"sendgrid": c.SendGridRateLimit, "smtp": c.SMTPRateLimit}
+}
+
+func loadNotificationConfig(s *source) NotificationConfig {
+	return NotificationConfig{
+		Provider:       s.String("EMAIL_PROVIDER", "smtp"),
+		From:           s.String("EMAIL_FROM", s.String("SMTP_FROM", "no-reply@aiio.local")),
+		SendGridAPIKey: s.Secret("SENDGRID_API_KEY", ""),
+		SendGridAPIURL: s.String("SENDGRID_API_URL", "https://api.sendgrid.com"),
+
+		TrackingURL:       s.String("CAMPAIGN_TRACKING_URL", "http://localhost:8080"),
+		CampaignBatchSize: s.Int("CAMPAIGN_BATCH_SIZE", 500),
+		SendGridRateLimit: s.Float("SENDGRID_RATE_LIMIT", 100),
+		SMTPRateLimit:     s.Float("SMTP_RATE_LIMIT", 10),
+
+		StockAlertRecipients: s.List("STOCK_LOW_ALERT_EMAILS", ","),
+	}
+}
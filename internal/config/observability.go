@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tracing"
+)
+
+type LoggingConfig struct {
+	// Format is "json" or "text"
+	Format string
+	// Level is one of debug, info, warn, error
+	Level string
+}
+
+func loadLoggingConfig(s *source) LoggingConfig {
+	return LoggingConfig{
+		Format: s.String("LOG_FORMAT", "json"),
+		Level:  s.String("LOG_LEVEL", "info"),
+	}
+}
+
+// loadTracingConfig reads the standard OpenTelemetry environment variables
+func loadTracingConfig(s *source) tracing.Options {
+	// A signal-specific endpoint is used as-is, the generic one is a base URL for all signals
+	endpoint := s.String("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if base := s.String("OTEL_EXPORTER_OTLP_ENDPOINT", ""); endpoint == "" && base != "" {
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+
+	return tracing.Options{
+		ServiceName: s.String("OTEL_SERVICE_NAME", "aiio-backend"),
+		Endpoint:    endpoint,
+		SampleRatio: s.Float("OTEL_TRACES_SAMPLER_ARG", 1.0),
+	}
+}
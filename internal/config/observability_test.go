@@ -0,0 +1,19 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTracingConfig_Endpoints(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	assert.Empty(t, loadTracingConfig(newSource()).Endpoint, "tracing is off by default")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	assert.Equal(t, "http://collector:4318/v1/traces", loadTracingConfig(newSource()).Endpoint)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "https://traces.example.com/otlp")
+	assert.Equal(t, "https://traces.example.com/otlp", loadTracingConfig(newSource()).Endpoint)
+}
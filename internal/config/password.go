@@ -0,0 +1,29 @@
+package config
+
+import (
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+type PasswordConfig struct {
+	Policy userDomain.PasswordPolicy
+
+	// BreachAPIURL overrides the pwned passwords range endpoint (e.g. a self-hosted mirror)
+	BreachAPIURL string
+}
+
+func loadPasswordConfig(s *source) PasswordConfig {
+	defaults := userDomain.DefaultPasswordPolicy
+
+	return PasswordConfig{
+		Policy: userDomain.PasswordPolicy{
+			MinLength:     s.Int("PASSWORD_MIN_LENGTH", defaults.MinLength),
+			RequireUpper:  s.Bool("PASSWORD_REQUIRE_UPPER", defaults.RequireUpper),
+			RequireLower:  s.Bool("PASSWORD_REQUIRE_LOWER", defaults.RequireLower),
+			RequireDigit:  s.Bool("PASSWORD_REQUIRE_DIGIT", defaults.RequireDigit),
+			RequireSymbol: s.Bool("PASSWORD_REQUIRE_SYMBOL", defaults.RequireSymbol),
+			DisallowEmail: s.Bool("PASSWORD_DISALLOW_EMAIL", defaults.DisallowEmail),
+			CheckBreached: s.Bool("PASSWORD_CHECK_BREACHED", defaults.CheckBreached),
+		},
+		BreachAPIURL: s.String("PASSWORD_BREACH_API_URL", ""),
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+type PaymentConfig struct {
+	// Gateway selects the payment adapter: fake or stripe
+	Gateway string
+
+	StripeSecretKey string
+	StripeAPIURL    string
+
+	// WebhookSecret signs the gateway callbacks on POST /webhooks/payments
+	WebhookSecret string
+	// ReconcileSchedule is the cron spec of the daily comparison with the gateway's report
+	ReconcileSchedule string
+	// EvidenceDir is where files uploaded as dispute evidence are kept
+	EvidenceDir string
+}
+
+func loadPaymentConfig(s *source) PaymentConfig {
+	return PaymentConfig{
+		Gateway:           s.String("PAYMENT_GATEWAY", "fake"),
+		StripeSecretKey:   s.Secret("STRIPE_SECRET_KEY", ""),
+		StripeAPIURL:      s.String("STRIPE_API_URL", "https://api.stripe.com"),
+		WebhookSecret:     s.Secret("PAYMENT_WEBHOOK_SECRET", ""),
+		ReconcileSchedule: s.String("PAYMENT_RECONCILE_SCHEDULE", "0 3 * * *"),
+		EvidenceDir:       s.String("DISPUTE_EVIDENCE_DIR", "data/dispute-evidence"),
+	}
+}
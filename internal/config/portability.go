@@ -0,0 +1,12 @@
+package config
+
+type PortabilityConfig struct {
+	// ExportDir is where the archives of GET /me/export are kept until ASYNC_COMMAND_RETENTION passes
+	ExportDir string
+}
+
+func loadPortabilityConfig(s *source) PortabilityConfig {
+	return PortabilityConfig{
+		ExportDir: s.String("DATA_EXPORT_DIR", "data/exports"),
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+type PricingConfig struct {
+	// TaxRates are comma-separated region[/class]=percent rates, e.g. "*=0,DE=19,DE/reduced=7,US-CA=7.25";
+	// the most specific rate for the destination and tax class of a product applies. Empty
+	// charges no tax.
+	TaxRates string
+	// ShippingFees are comma-separated region=amount fees in the base currency, e.g. "*=9.90,DE=4.90";
+	// empty ships for free
+	ShippingFees string
+	// FreeShippingThreshold waives the shipping fee of orders whose subtotal, in the base currency,
+	// is at least this much; empty never waives it
+	FreeShippingThreshold string
+
+	// prefix starts the names of the settings, e.g. "CANARY_" for the candidate pricing
+	prefix string
+}
+
+// loadPricingConfig reads the settings whose names start with prefix, falling back to those of
+// fallback
+func loadPricingConfig(s *source, prefix string, fallback PricingConfig) PricingConfig {
+	return PricingConfig{
+		TaxRates:              s.String(prefix+"TAX_RATES", fallback.TaxRates),
+		ShippingFees:          s.String(prefix+"SHIPPING_FEES", fallback.ShippingFees),
+		FreeShippingThreshold: s.String(prefix+"FREE_SHIPPING_THRESHOLD", fallback.FreeShippingThreshold),
+		prefix:                prefix,
+	}
+}
+
+// Engine parses the rates and fees, with the amounts in currency; the caller sets the rounding and
+// converter of the engine
+func (c PricingConfig) Engine(currency string) (*pricing.Engine, error) {
+	var errs validation.Errors
+	engine := &pricing.Engine{}
+
+	var err error
+	if engine.TaxRules, err = pricing.ParseTaxRules(c.TaxRates); err != nil {
+		errs.Add(c.prefix+"TAX_RATES", err.Error())
+	}
+	if engine.ShippingFees, err = pricing.ParseShippingFees(c.ShippingFees, currency); err != nil {
+		errs.Add(c.prefix+"SHIPPING_FEES", err.Error())
+	}
+	if c.FreeShippingThreshold != "" {
+		threshold, err := money.Parse(c.FreeShippingThreshold, currency)
+		errs.Check(err == nil && threshold.Amount > 0, c.prefix+"FREE_SHIPPING_THRESHOLD", "must be a positive amount in the base currency, e.g. 50.00")
+		engine.FreeShippingFrom = threshold
+	}
+	return engine, errs.Err()
+}
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type QuotaConfig struct {
+	// DefaultPlan applies to tenants without an entry in tenant_plans
+	DefaultPlan string
+
+	// PlanCacheTTL is how long plan assignments are cached per instance
+	PlanCacheTTL time.Duration
+}
+
+func loadQuotaConfig(s *source) QuotaConfig {
+	return QuotaConfig{
+		DefaultPlan:  s.String("QUOTA_DEFAULT_PLAN", "free"),
+		PlanCacheTTL: s.Duration("QUOTA_PLAN_CACHE_TTL", time.Minute),
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+type RBACConfig struct {
+	// Enabled enforces role permissions on the API. The caller is identified by the X-User-ID
+	// header, so only enable it behind a gateway that authenticates requests and sets the header.
+	Enabled bool
+
+	// MaskedData are the kinds of personal data, email and phone, responses mask for callers
+	// without pii:read; "none" masks nothing. Masking needs Enabled to know the caller.
+	MaskedData []string
+}
+
+func loadRBACConfig(s *source) RBACConfig {
+	masked := s.List("DATA_MASKING", ",")
+	switch {
+	case masked == nil:
+		masked = []string{"email", "phone"}
+	case len(masked) == 1 && masked[0] == "none":
+		masked = nil
+	}
+	return RBACConfig{
+		Enabled:    s.Bool("RBAC_ENABLED", false),
+		MaskedData: masked,
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+type ReportingConfig struct {
+	// Schedule is the cron spec of the nightly aggregation of orders into daily sales
+	Schedule string
+	// Days is how many days up to yesterday each run aggregates again, so orders cancelled or
+	// refunded after their day leave its sales
+	Days int
+}
+
+func loadReportingConfig(s *source) ReportingConfig {
+	return ReportingConfig{
+		Schedule: s.String("REPORTING_SCHEDULE", "30 0 * * *"),
+		Days:     s.Int("REPORTING_DAYS", 3),
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+type SagaConfig struct {
+	// ResumeInterval is how often unfinished sagas are looked for
+	ResumeInterval time.Duration
+
+	// ResumeAfter is how long a saga must have made no progress before it is resumed, long
+	// enough for the request that started it to have finished it
+	ResumeAfter time.Duration
+}
+
+func loadSagaConfig(s *source) SagaConfig {
+	return SagaConfig{
+		ResumeInterval: s.Duration("SAGA_RESUME_INTERVAL", time.Minute),
+		ResumeAfter:    s.Duration("SAGA_RESUME_AFTER", 5*time.Minute),
+	}
+}
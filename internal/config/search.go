@@ -0,0 +1,20 @@
+package config
+
+type SearchConfig struct {
+	// Backend is the search engine of GET /products/search: none, elasticsearch or meilisearch.
+	// Without one, products are searched by name in the database.
+	Backend string
+	URL     string
+	// Index is the name of the index, or the uid in Meilisearch, holding the products
+	Index  string
+	APIKey string
+}
+
+func loadSearchConfig(s *source) SearchConfig {
+	return SearchConfig{
+		Backend: s.String("SEARCH_BACKEND", "none"),
+		URL:     s.String("SEARCH_URL", ""),
+		Index:   s.String("SEARCH_INDEX", "products"),
+		APIKey:  s.Secret("SEARCH_API_KEY", ""),
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/middleware"
+)
+
+type ServerConfig struct {
+	Addr string
+	// ShutdownTimeout bounds how long in-flight requests and background work may drain on SIGINT or SIGTERM
+	ShutdownTimeout time.Duration
+	// WorkerDrainTimeout bounds how long the running jobs, and then the projection catch-up, may
+	// finish within ShutdownTimeout before they are checkpointed, keeping the rest for the relay
+	WorkerDrainTimeout time.Duration
+
+	CORS middleware.CORSOptions
+	// GzipMinSize is the size from which JSON responses are compressed
+	GzipMinSize int
+	// AccessLog logs every request
+	AccessLog bool
+}
+
+func loadServerConfig(s *source) ServerConfig {
+	return ServerConfig{
+		Addr:               s.String("HTTP_ADDR", ":8080"),
+		ShutdownTimeout:    s.Duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		WorkerDrainTimeout: s.Duration("SHUTDOWN_WORKER_TIMEOUT", 10*time.Second),
+		CORS: middleware.CORSOptions{
+			AllowedOrigins:   s.List("CORS_ALLOWED_ORIGINS", ","),
+			AllowedMethods:   s.List("CORS_ALLOWED_METHODS", ",", "GET", "POST", "PUT", "PATCH", "DELETE"),
+			AllowedHeaders:   s.List("CORS_ALLOWED_HEADERS", ",", "Content-Type", "X-Tenant-ID", "X-Tenant-Scope", "X-User-ID", "X-API-Key", middleware.RequestIDHeader),
+			ExposedHeaders:   s.List("CORS_EXPOSED_HEADERS", ",", middleware.RequestIDHeader, "Retry-After", "X-RateLimit-Limit"),
+			AllowCredentials: s.Bool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           s.Duration("CORS_MAX_AGE", 10*time.Minute),
+		},
+		GzipMinSize: s.Int("HTTP_GZIP_MIN_SIZE", 1024),
+		AccessLog:   s.Bool("HTTP_ACCESS_LOG", true),
+	}
+}
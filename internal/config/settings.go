@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"time"
+
+	settingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+type SettingsConfig struct {
+	// The defaults of the settings tenants have not set. An empty LogoURL shows no logo, empty
+	// Currencies allow every currency and an empty NotificationSender keeps the sender of the email
+	// provider.
+	StoreName          string
+	LogoURL            string
+	Currencies         []string
+	NotificationSender string
+
+	// CacheTTL is how long an instance uses the settings of a tenant before reading them again,
+	// bounding how long other instances keep the old values after a change
+	CacheTTL time.Duration
+}
+
+func loadSettingsConfig(s *source) SettingsConfig {
+	return SettingsConfig{
+		StoreName:          s.String("SETTINGS_STORE_NAME", "AIIO"),
+		LogoURL:            s.String("SETTINGS_LOGO_URL", ""),
+		Currencies:         s.List("SETTINGS_CURRENCIES", ","),
+		NotificationSender: s.String("SETTINGS_NOTIFICATION_SENDER", ""),
+		CacheTTL:           s.Duration("SETTINGS_CACHE_TTL", time.Minute),
+	}
+}
+
+// Defaults checks the defaults against the schemas tenants' values are held to, returning them
+// normalized, e.g. with upper-cased currencies
+func (c SettingsConfig) Defaults() (settingDomain.Settings, error) {
+	var errs validation.Errors
+	var settings settingDomain.Settings
+	apply := func(name string, key settingDomain.Key, value any) {
+		raw, _ := json.Marshal(value)
+		applied, err := settings.With(key, raw)
+		if err != nil {
+			errs.Add(name, err.Error())
+			return
+		}
+		settings = applied
+	}
+
+	apply("SETTINGS_STORE_NAME", settingDomain.KeyStoreName, c.StoreName)
+	if c.LogoURL != "" {
+		apply("SETTINGS_LOGO_URL", settingDomain.KeyLogoURL, c.LogoURL)
+	}
+	if len(c.Currencies) > 0 {
+		apply("SETTINGS_CURRENCIES", settingDomain.KeyCurrencies, c.Currencies)
+	}
+	if c.NotificationSender != "" {
+		apply("SETTINGS_NOTIFICATION_SENDER", settingDomain.KeyNotificationSender, c.NotificationSender)
+	}
+	return settings, errs.Err()
+}
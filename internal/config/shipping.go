@@ -0,0 +1,21 @@
+package config
+
+type ShippingConfig struct {
+	// Carriers are the codes of the carriers orders ship with; each gets a tracking callback endpoint
+	Carriers []string
+
+	// WebhookSecret signs the carrier callbacks on POST /webhooks/carriers/{carrier} until a
+	// carrier's own secret is stored with PUT /credentials/{carrier}/webhook_secret
+	WebhookSecret string
+}
+
+func loadShippingConfig(s *source) ShippingConfig {
+	carriers := s.List("SHIPPING_CARRIERS", ",")
+	if len(carriers) == 0 {
+		carriers = []string{"dhl", "ups", "fedex"}
+	}
+	return ShippingConfig{
+		Carriers:      carriers,
+		WebhookSecret: s.Secret("CARRIER_WEBHOOK_SECRET", ""),
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import "net/smtp"
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func loadSMTPConfig(s *source) SMTPConfig {
+	return SMTPConfig{
+		Host:     s.String("SMTP_HOST", ""),
+		Port:     s.String("SMTP_PORT", "587"),
+		Username: s.String("SMTP_USERNAME", ""),
+		Password: s.Secret("SMTP_PASSWORD", ""),
+		From:     s.String("SMTP_FROM", "no-reply@aiio.local"),
+	}
+}
+
+// Addr is the host:port to dial, or empty when SMTP is not configured
+func (c *SMTPConfig) Addr() string {
+	if c.Host == "" {
+		return ""
+	}
+	return c.Host + ":" + c.Port
+}
+
+// Auth returns PLAIN auth when credentials are configured
+func (c *SMTPConfig) Auth() smtp.Auth {
+	if c.Username == "" {
+		return nil
+	}
+	return smtp.PlainAuth("", c.Username, c.Password, c.Host)
+}
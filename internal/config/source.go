@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Origins of a setting, from the highest precedence to the lowest
+const (
+	originEnv     = "env"
+	originFile    = "file"
+	originProfile = "profile"
+	originDefault = "default"
+)
+
+// setting is a resolved value, kept for Config.Redacted
+type setting struct {
+	key    string
+	value  string
+	origin string
+	secret bool
+}
+
+// source resolves settings by the name of their environment variable. A variable set to a
+// non-empty value wins over the YAML file, the file over the defaults of the profile, and those
+// over the defaults in code. Values that do not parse are collected in errs.
+type source struct {
+	// file holds the settings of the YAML file: strings, or []string for sequences
+	file    map[string]any
+	profile map[string]string
+
+	settings []setting
+	read     map[string]bool
+	errs     validation.Errors
+}
+
+func newSource() *source {
+	return &source{read: make(map[string]bool)}
+}
+
+// readFile loads a YAML file of settings. Nested keys are joined with underscores, so
+// `db: {host: x}` and `db_host: x` both set DB_HOST.
+func (s *source) readFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	s.file = make(map[string]any)
+	flatten(s.file, "", doc)
+	return nil
+}
+
+func flatten(into map[string]any, prefix string, doc map[string]any) {
+	for key, value := range doc {
+		key = strings.ToUpper(key)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(into, key, value)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			into[key] = items
+		case nil:
+			into[key] = ""
+		default:
+			into[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// unknown reports the settings of the file that no section read, which are likely misspelt
+func (s *source) unknown() []string {
+	var keys []string
+	for key := range s.file {
+		if !s.read[key] {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// lookup finds the raw value of key and its origin; list is set for sequences of the file
+func (s *source) lookup(key string) (value string, list []string, origin string, ok bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, nil, originEnv, true
+	}
+	switch value := s.file[key].(type) {
+	case string:
+		return value, nil, originFile, true
+	case []string:
+		return strings.Join(value, ","), value, originFile, true
+	}
+	if value, ok := s.profile[key]; ok {
+		return value, nil, originProfile, true
+	}
+	return "", nil, "", false
+}
+
+func (s *source) record(key, value, origin string, secret bool) {
+	if s.read[key] {
+		return
+	}
+	s.read[key] = true
+	s.settings = append(s.settings, setting{key: key, value: value, origin: origin, secret: secret})
+}
+
+// scalar reads a single value; ok is false when the setting is not set and fallback applies
+func (s *source) scalar(key, fallback string, secret bool) (value string, ok bool) {
+	value, list, origin, ok := s.lookup(key)
+	if !ok {
+		s.record(key, fallback, originDefault, secret)
+		return fallback, false
+	}
+	if list != nil {
+		s.errs.Add(key, "must be a single value, not a list")
+	}
+	s.record(key, value, origin, secret)
+	return value, true
+}
+
+func (s *source) String(key, fallback string) string {
+	value, _ := s.scalar(key, fallback, false)
+	return value
+}
+
+// Secret reads a setting Config.Redacted must not print, such as a password or an API key
+func (s *source) Secret(key, fallback string) string {
+	value, _ := s.scalar(key, fallback, true)
+	return value
+}
+
+func (s *source) Int(key string, fallback int) int {
+	return parse(s, key, fallback, strconv.Atoi, "an integer")
+}
+
+func (s *source) Float(key string, fallback float64) float64 {
+	return parse(s, key, fallback, func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	}, "a number")
+}
+
+func (s *source) Duration(key string, fallback time.Duration) time.Duration {
+	return parse(s, key, fallback, time.ParseDuration, "a duration such as 30s or 5m")
+}
+
+func (s *source) Bool(key string, fallback bool) bool {
+	return parse(s, key, fallback, strconv.ParseBool, "true or false")
+}
+
+// List splits a setting on separator, or takes a sequence of the file as it is; fallback
+// applies when the setting is not set
+func (s *source) List(key, separator string, fallback ...string) []string {
+	return s.list(key, separator, false, fallback...)
+}
+
+// SecretList reads a List that Config.Redacted must not print, such as connection strings
+func (s *source) SecretList(key, separator string) []string {
+	return s.list(key, separator, true)
+}
+
+func (s *source) list(key, separator string, secret bool, fallback ...string) []string {
+	value, list, origin, ok := s.lookup(key)
+	if !ok {
+		s.record(key, strings.Join(fallback, separator), originDefault, secret)
+		return fallback
+	}
+	s.record(key, value, origin, secret)
+	if list == nil {
+		list = strings.Split(value, separator)
+	}
+	var values []string
+	for _, value := range list {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func parse[T any](s *source, key string, fallback T, parse func(string) (T, error), kind string) T {
+	raw, ok := s.scalar(key, fmt.Sprint(fallback), false)
+	if !ok {
+		return fallback
+	}
+	value, err := parse(raw)
+	if err != nil {
+		s.errs.Add(key, fmt.Sprintf("must be %s, got %q", kind, raw))
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,47 @@
+package config
+
+import "time"
+
+type StorageConfig struct {
+	// Driver selects the blob store: local keeps objects in Dir, s3 in an S3 bucket
+	Driver string
+	Dir    string
+	// PublicURL is where the local store serves signed URLs, the /blobs path of this service as
+	// clients reach it
+	PublicURL string
+	// URLSecret signs the URLs of the local store
+	URLSecret string
+	// URLTTL is how long signed URLs stay readable
+	URLTTL time.Duration
+
+	// S3Endpoint is left empty for AWS; set it with S3PathStyle for S3-compatible stores such as MinIO
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+
+	// MaxImageSize bounds product image uploads, in bytes
+	MaxImageSize int
+	// ThumbnailSize is the edge of the box product thumbnails fit in, in pixels
+	ThumbnailSize int
+}
+
+func loadStorageConfig(s *source) StorageConfig {
+	return StorageConfig{
+		Driver:            s.String("STORAGE_DRIVER", "local"),
+		Dir:               s.String("STORAGE_DIR", "data/blobs"),
+		PublicURL:         s.String("STORAGE_PUBLIC_URL", "http://localhost:8080/blobs"),
+		URLSecret:         s.Secret("STORAGE_URL_SECRET", ""),
+		URLTTL:            s.Duration("STORAGE_URL_TTL", 15*time.Minute),
+		S3Endpoint:        s.String("S3_ENDPOINT", ""),
+		S3Region:          s.String("S3_REGION", "us-east-1"),
+		S3Bucket:          s.String("S3_BUCKET", ""),
+		S3AccessKeyID:     s.String("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: s.Secret("S3_SECRET_ACCESS_KEY", ""),
+		S3PathStyle:       s.Bool("S3_PATH_STYLE", false),
+		MaxImageSize:      s.Int("PRODUCT_IMAGE_MAX_SIZE", 5<<20),
+		ThumbnailSize:     s.Int("PRODUCT_THUMBNAIL_SIZE", 256),
+	}
+}
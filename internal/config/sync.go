@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+type SyncConfig struct {
+	// SettleDelay holds back changes this recent from /sync, so that transactions still in
+	// flight commit before clients move their cursor past them; it should exceed the longest
+	// write transaction
+	SettleDelay time.Duration
+
+	// MaxBatchSize caps the records of each kind returned by one /sync call
+	MaxBatchSize int
+}
+
+func loadSyncConfig(s *source) SyncConfig {
+	return SyncConfig{
+		SettleDelay:  s.Duration("SYNC_SETTLE_DELAY", 5*time.Second),
+		MaxBatchSize: s.Int("SYNC_MAX_BATCH_SIZE", 500),
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+// TenantTables are the tables tenant.Plugin scopes to the tenant of the request. Rows derived from
+// an order or a user, such as payments and shipments, carry the tenant of their aggregate.
+var TenantTables = []string{
+	"users", "addresses", "products", "orders", "order_summaries", "payments", "refunds", "disputes",
+	"shipments", "delivery_estimates", "coupons", "checkout_sessions", "reviews", "daily_sales",
+	"daily_product_sales", "webhook_endpoints",
+}
+
+type TenantConfig struct {
+	// Domain serves each tenant at a subdomain of it, e.g. acme.shop.example.com for
+	// "shop.example.com"; empty resolves tenants by the X-Tenant-ID header only
+	Domain string
+}
+
+func loadTenantConfig(s *source) TenantConfig {
+	return TenantConfig{
+		Domain: s.String("TENANT_DOMAIN", ""),
+	}
+}
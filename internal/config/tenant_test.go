@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	deliveryAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/adapter"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	orderAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/order/adapter"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	shippingAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/adapter"
+	shippingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	supportAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/support/adapter"
+	supportDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTenantTables_KeepDerivedRowsOfOtherTenantsOut(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&orderDomain.OrderSummary{}, &shippingDomain.Shipment{}, &shippingDomain.TrackingEvent{}, &deliveryDomain.Estimate{}))
+	require.NoError(t, db.Use(tenant.NewPlugin(TenantTables...)))
+
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	summaries := orderAdapter.NewGormOrderSummaryRepository(db)
+	shipments := shippingAdapter.NewGormShipmentRepository(db)
+	estimates := deliveryAdapter.NewGormEstimateRepository(db)
+
+	placedAt := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, summaries.Save(globex, &orderDomain.OrderSummary{OrderID: "ord_globex", Status: orderDomain.StatusConfirmed, PlacedAt: &placedAt}))
+	shipment, err := shippingDomain.NewShipment(7, "dhl", "TRACK-7")
+	require.NoError(t, err)
+	require.NoError(t, shipments.Create(globex, shipment))
+	window := deliveryDomain.Window{Earliest: placedAt.Add(48 * time.Hour), Latest: placedAt.Add(72 * time.Hour)}
+	require.NoError(t, estimates.Create(globex, deliveryDomain.NewEstimate(7, "standard", "DE", placedAt, window)))
+
+	listed, err := summaries.List(acme, orderDomain.OrderSummaryFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, listed, "acme lists no summaries of globex")
+	listed, err = summaries.List(globex, orderDomain.OrderSummaryFilter{})
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+
+	_, err = shipments.GetByOrderID(acme, 7)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+	_, err = estimates.GetByOrderID(acme, 7)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+
+	sandbox, err := supportAdapter.NewGormSandbox(db,
+		supportAdapter.Dataset{Name: "order_summaries", Model: &orderDomain.OrderSummary{}},
+		supportAdapter.Dataset{Name: "shipments", Model: &shippingDomain.Shipment{}},
+		supportAdapter.Dataset{Name: "delivery_estimates", Model: &deliveryDomain.Estimate{}},
+	)
+	require.NoError(t, err)
+	for _, dataset := range []string{"order_summaries", "shipments", "delivery_estimates"} {
+		result, err := sandbox.Run(acme, supportDomain.SandboxQuery{Dataset: dataset, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, result.Rows, "acme sees no %s of globex in the sandbox", dataset)
+
+		result, err = sandbox.Run(globex, supportDomain.SandboxQuery{Dataset: dataset, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, result.Rows, 1, dataset)
+	}
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// Validate checks the settings the service cannot start without, the choices among adapters
+// and the ranges of numbers, returning validation.Errors keyed by the name of the setting
+func (c *Config) Validate() error {
+	var errs validation.Errors
+	deployed := c.Profile != ProfileDev
+
+	address(&errs, "HTTP_ADDR", c.Server.Addr)
+	positive(&errs, "SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout)
+	errs.Check(c.Server.WorkerDrainTimeout >= 0 && c.Server.WorkerDrainTimeout <= c.Server.ShutdownTimeout,
+		"SHUTDOWN_WORKER_TIMEOUT", fmt.Sprintf("must be between 0 and SHUTDOWN_TIMEOUT, got %s", c.Server.WorkerDrainTimeout))
+	errs.Check(!c.Server.CORS.AllowCredentials || !slices.Contains(c.Server.CORS.AllowedOrigins, "*"),
+		"CORS_ALLOW_CREDENTIALS", `cannot be combined with the "*" origin of CORS_ALLOWED_ORIGINS`)
+	atLeast(&errs, "HTTP_GZIP_MIN_SIZE", c.Server.GzipMinSize, 0)
+
+	oneOf(&errs, "LOG_FORMAT", c.Logging.Format, "json", "text")
+	oneOf(&errs, "LOG_LEVEL", c.Logging.Level, "debug", "info", "warn", "error")
+	errs.Check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"OTEL_TRACES_SAMPLER_ARG", fmt.Sprintf("must be between 0 and 1, got %g", c.Tracing.SampleRatio))
+
+	db := c.Database
+	oneOf(&errs, "DB_DRIVER", db.Driver, "postgres", "sqlite")
+	required(&errs, "DB_NAME", db.DBName)
+	if db.Driver == "postgres" {
+		required(&errs, "DB_HOST", db.Host)
+		port(&errs, "DB_PORT", db.Port)
+		required(&errs, "DB_USER", db.User)
+		if deployed {
+			required(&errs, "DB_PASSWORD", db.Password)
+		}
+		oneOf(&errs, "DB_SSLMODE", db.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+	oneOf(&errs, "DB_LOG_LEVEL", db.LogLevel, "silent", "error", "warn", "info")
+	oneOf(&errs, "LOCK_BACKEND", c.Lock.Backend, "database", "redis")
+	if c.Lock.Backend == "redis" {
+		address(&errs, "LOCK_REDIS_ADDR", c.Lock.RedisAddr)
+		atLeast(&errs, "LOCK_REDIS_DB", c.Lock.RedisDB, 0)
+		positive(&errs, "LOCK_TTL", c.Lock.TTL)
+	}
+	atLeast(&errs, "DB_MAX_OPEN_CONNS", db.MaxOpenConns, 1)
+	atLeast(&errs, "DB_MAX_IDLE_CONNS", db.MaxIdleConns, 0)
+	atLeast(&errs, "DB_SCHEMA_TOLERANCE", db.SchemaTolerance, 0)
+
+	atLeast(&errs, "CACHE_WARMUP_CONCURRENCY", c.Warmup.Concurrency, 1)
+	errs.Check(c.QueryCache.TTL >= 0, "QUERY_CACHE_TTL", "must not be negative")
+	atLeast(&errs, "QUERY_CACHE_MAX_ENTRIES", c.QueryCache.MaxEntries, 0)
+	if _, err := dto.ParseMaskKinds(c.RBAC.MaskedData); err != nil {
+		errs.Add("DATA_MASKING", err.Error())
+	}
+	if _, err := secret.NewKeyring(c.Credentials.EncryptionKeys); err != nil {
+		errs.Add("ENCRYPTION_KEYS", err.Error())
+	}
+	if deployed {
+		required(&errs, "ENCRYPTION_KEYS", c.Credentials.EncryptionKeys)
+	}
+
+	oneOf(&errs, "PAYMENT_GATEWAY", c.Payment.Gateway, "fake", "stripe")
+	// Anyone can compute an HMAC with an empty key, so the webhook verifiers reject every callback
+	// while their secret is empty; only development can live with that
+	if deployed {
+		required(&errs, "PAYMENT_WEBHOOK_SECRET", c.Payment.WebhookSecret)
+		required(&errs, "CARRIER_WEBHOOK_SECRET", c.Shipping.WebhookSecret)
+	}
+	required(&errs, "DISPUTE_EVIDENCE_DIR", c.Payment.EvidenceDir)
+	atLeast(&errs, "REPORTING_DAYS", c.Reporting.Days, 1)
+	oneOf(&errs, "SEARCH_BACKEND", c.Search.Backend, "none", "elasticsearch", "meilisearch")
+	if c.Search.Backend != "none" {
+		required(&errs, "SEARCH_URL", c.Search.URL)
+		required(&errs, "SEARCH_INDEX", c.Search.Index)
+	}
+	if _, err := mode.Parse(c.Mode.Default); err != nil {
+		errs.Add("ADAPTER_MODE", err.Error())
+	}
+	required(&errs, "QUOTA_DEFAULT_PLAN", c.Quota.DefaultPlan)
+
+	atLeast(&errs, "JOBS_CONCURRENCY", c.Jobs.Concurrency, 1)
+	atLeast(&errs, "JOBS_MAX_ATTEMPTS", c.Jobs.MaxAttempts, 1)
+	positive(&errs, "JOBS_POLL_INTERVAL", c.Jobs.PollInterval)
+	positive(&errs, "JOBS_LEASE", c.Jobs.Lease)
+	positive(&errs, "BILLING_FLUSH_INTERVAL", c.Billing.FlushInterval)
+	positive(&errs, "BILLING_EXPORT_INTERVAL", c.Billing.ExportInterval)
+
+	oneOf(&errs, "STOCK_LOCKING", c.Inventory.Locking, "optimistic", "pessimistic")
+	positive(&errs, "STOCK_RESERVATION_TTL", c.Inventory.ReservationTTL)
+	positive(&errs, "STOCK_RESERVATION_RELEASE_INTERVAL", c.Inventory.ReleaseInterval)
+	atLeast(&errs, "PASSWORD_MIN_LENGTH", c.Password.Policy.MinLength, 1)
+
+	if c.SMTP.Host != "" {
+		port(&errs, "SMTP_PORT", c.SMTP.Port)
+	}
+	oneOf(&errs, "EMAIL_PROVIDER", c.Notification.Provider, "smtp", "sendgrid")
+	required(&errs, "CAMPAIGN_TRACKING_URL", c.Notification.TrackingURL)
+	atLeast(&errs, "CAMPAIGN_BATCH_SIZE", c.Notification.CampaignBatchSize, 1)
+	errs.Check(c.Notification.SendGridRateLimit >= 0, "SENDGRID_RATE_LIMIT", "must not be negative")
+	errs.Check(c.Notification.SMTPRateLimit >= 0, "SMTP_RATE_LIMIT", "must not be negative")
+
+	atLeast(&errs, "WEBHOOK_MAX_ATTEMPTS", c.Webhook.MaxAttempts, 1)
+	positive(&errs, "WEBHOOK_TIMEOUT", c.Webhook.Timeout)
+
+	oneOf(&errs, "MESSAGING_DRIVER", c.Messaging.Driver, "none", "log", "kafka", "rabbitmq")
+	if c.Messaging.Driver == "kafka" {
+		errs.Check(len(c.Messaging.KafkaBrokers) > 0, "KAFKA_BROKERS", "is required")
+		atLeast(&errs, "KAFKA_PARTITIONS", c.Messaging.KafkaPartitions, 1)
+		atLeast(&errs, "KAFKA_REPLICATION_FACTOR", c.Messaging.KafkaReplicationFactor, 1)
+	}
+	positive(&errs, "MESSAGING_RELAY_INTERVAL", c.Messaging.RelayInterval)
+	atLeast(&errs, "MESSAGING_RELAY_BATCH_SIZE", c.Messaging.RelayBatchSize, 1)
+
+	atLeast(&errs, "DELIVERY_PROCESSING_DAYS", c.Delivery.ProcessingDays, 0)
+	errs.Check(c.Delivery.Cutoff >= 0 && c.Delivery.Cutoff < 24*time.Hour,
+		"DELIVERY_CUTOFF", fmt.Sprintf("must be a time of day between 0h and 24h, got %s", c.Delivery.Cutoff))
+	if _, err := time.LoadLocation(c.Delivery.TimeZone); err != nil {
+		errs.Add("DELIVERY_TIMEZONE", fmt.Sprintf("must be an IANA time zone, got %q", c.Delivery.TimeZone))
+	}
+	if _, err := deliveryDomain.ParseTransitTimes(c.Delivery.StandardTransit); err != nil {
+		errs.Add("DELIVERY_TRANSIT_STANDARD", err.Error())
+	}
+	if _, err := deliveryDomain.ParseTransitTimes(c.Delivery.ExpressTransit); err != nil {
+		errs.Add("DELIVERY_TRANSIT_EXPRESS", err.Error())
+	}
+	if _, err := deliveryDomain.ParseWarehouses(c.Delivery.Warehouses); err != nil {
+		errs.Add("DELIVERY_WAREHOUSES", err.Error())
+	}
+
+	positive(&errs, "CHECKOUT_SESSION_TTL", c.Checkout.SessionTTL)
+	positive(&errs, "CHECKOUT_PURGE_INTERVAL", c.Checkout.PurgeInterval)
+	positive(&errs, "SAGA_RESUME_INTERVAL", c.Saga.ResumeInterval)
+	positive(&errs, "SAGA_RESUME_AFTER", c.Saga.ResumeAfter)
+
+	if _, err := money.New(0, c.Currency.BaseCurrency); err != nil {
+		errs.Add("BASE_CURRENCY", fmt.Sprintf("must be an ISO 4217 currency code, got %q", c.Currency.BaseCurrency))
+	}
+	oneOf(&errs, "EXCHANGE_RATE_PROVIDER", c.Currency.RateProvider, "ecb", "fixer", "none")
+	positive(&errs, "EXCHANGE_RATE_TTL", c.Currency.RateCacheTTL)
+	if _, err := money.ParseRoundingMode(c.Currency.Rounding); err != nil {
+		errs.Add("EXCHANGE_RATE_ROUNDING", err.Error())
+	}
+
+	atLeast(&errs, "SYNC_MAX_BATCH_SIZE", c.Sync.MaxBatchSize, 1)
+	errs.Check(c.Sync.SettleDelay >= 0, "SYNC_SETTLE_DELAY", fmt.Sprintf("must not be negative, got %s", c.Sync.SettleDelay))
+
+	required(&errs, "PASSWORD_RESET_URL", c.Account.PasswordResetURL)
+	required(&errs, "EMAIL_VERIFICATION_URL", c.Account.EmailVerificationURL)
+	positive(&errs, "PASSWORD_RESET_TTL", c.Account.PasswordResetTTL)
+	positive(&errs, "EMAIL_VERIFICATION_TTL", c.Account.EmailVerificationTTL)
+	atLeast(&errs, "ACCOUNT_TOKEN_MAX_ATTEMPTS", c.Account.TokenMaxAttempts, 1)
+	atLeast(&errs, "ACCOUNT_TOKEN_REQUEST_LIMIT", c.Account.TokenRequestLimit, 1)
+	positive(&errs, "ACCOUNT_TOKEN_REQUEST_WINDOW", c.Account.TokenRequestWindow)
+
+	positive(&errs, "ASYNC_COMMAND_RETENTION", c.Async.Retention)
+	positive(&errs, "ASYNC_COMMAND_PURGE_INTERVAL", c.Async.PurgeInterval)
+	positive(&errs, "ASYNC_COMMAND_POLL_INTERVAL", c.Async.PollInterval)
+	positive(&errs, "ASYNC_COMMAND_STREAM_TIMEOUT", c.Async.StreamTimeout)
+
+	_, err := c.Settings.Defaults()
+	errs.Merge("", err)
+	positive(&errs, "SETTINGS_CACHE_TTL", c.Settings.CacheTTL)
+	required(&errs, "DATA_EXPORT_DIR", c.Portability.ExportDir)
+
+	oneOf(&errs, "STORAGE_DRIVER", c.Storage.Driver, "local", "s3")
+	switch c.Storage.Driver {
+	case "local":
+		required(&errs, "STORAGE_DIR", c.Storage.Dir)
+		required(&errs, "STORAGE_PUBLIC_URL", c.Storage.PublicURL)
+		if deployed {
+			required(&errs, "STORAGE_URL_SECRET", c.Storage.URLSecret)
+		}
+	case "s3":
+		required(&errs, "S3_REGION", c.Storage.S3Region)
+		required(&errs, "S3_BUCKET", c.Storage.S3Bucket)
+		required(&errs, "S3_ACCESS_KEY_ID", c.Storage.S3AccessKeyID)
+		required(&errs, "S3_SECRET_ACCESS_KEY", c.Storage.S3SecretAccessKey)
+		errs.Check(c.Storage.URLTTL <= 7*24*time.Hour, "STORAGE_URL_TTL", fmt.Sprintf("must be at most 168h with S3, got %s", c.Storage.URLTTL))
+	}
+	errs.Check(c.Storage.URLTTL >= time.Second, "STORAGE_URL_TTL", fmt.Sprintf("must be at least 1s, got %s", c.Storage.URLTTL))
+	atLeast(&errs, "PRODUCT_IMAGE_MAX_SIZE", c.Storage.MaxImageSize, 1)
+	atLeast(&errs, "PRODUCT_THUMBNAIL_SIZE", c.Storage.ThumbnailSize, 16)
+
+	_, err = c.Canary.Rollout()
+	errs.Merge("", err)
+	// The candidate pricing falls back to the stable settings, so it is only checked once they are valid
+	if _, err = c.Pricing.Engine(c.Currency.BaseCurrency); err != nil {
+		errs.Merge("", err)
+	} else {
+		_, err = c.Canary.Pricing.Engine(c.Currency.BaseCurrency)
+		errs.Merge("", err)
+	}
+	return errs.Err()
+}
+
+func required(errs *validation.Errors, key, value string) {
+	errs.Check(value != "", key, "is required")
+}
+
+func oneOf(errs *validation.Errors, key, value string, allowed ...string) {
+	errs.Check(slices.Contains(allowed, value), key, fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), value))
+}
+
+func atLeast(errs *validation.Errors, key string, value, minimum int) {
+	errs.Check(value >= minimum, key, fmt.Sprintf("must be at least %d, got %d", minimum, value))
+}
+
+func positive(errs *validation.Errors, key string, value time.Duration) {
+	errs.Check(value > 0, key, fmt.Sprintf("must be longer than 0s, got %s", value))
+}
+
+func port(errs *validation.Errors, key, value string) {
+	n, err := strconv.Atoi(value)
+	errs.Check(err == nil && n >= 1 && n <= 65535, key, fmt.Sprintf("must be a port between 1 and 65535, got %q", value))
+}
+
+// address checks a listen address such as ":8080" or "127.0.0.1:8080"
+func address(errs *validation.Errors, key, value string) {
+	_, p, err := net.SplitHostPort(value)
+	if err != nil {
+		errs.Add(key, fmt.Sprintf("must be host:port, got %q", value))
+		return
+	}
+	port(errs, key, p)
+}
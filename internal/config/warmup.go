@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+type WarmupConfig struct {
+	// OnStartup warms the caches in the background once the server starts
+	OnStartup bool
+
+	// Concurrency is the number of caches warmed at once
+	Concurrency int
+
+	// Timeout bounds the whole warm-up; caches not warmed by then fill on demand
+	Timeout time.Duration
+}
+
+func loadWarmupConfig(s *source) WarmupConfig {
+	return WarmupConfig{
+		OnStartup:   s.Bool("CACHE_WARMUP", true),
+		Concurrency: s.Int("CACHE_WARMUP_CONCURRENCY", 2),
+		Timeout:     s.Duration("CACHE_WARMUP_TIMEOUT", time.Minute),
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+type WebhookConfig struct {
+	// MaxAttempts is how often a delivery is tried before it is dead-lettered
+	MaxAttempts int
+	// BackoffBase is the wait before the second attempt, doubled for every further one up to BackoffMax
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Timeout bounds a single delivery attempt, including reading the response
+	Timeout time.Duration
+
+	// AllowHTTP accepts endpoint URLs without TLS, e.g. for local development
+	AllowHTTP bool
+}
+
+func loadWebhookConfig(s *source) WebhookConfig {
+	return WebhookConfig{
+		MaxAttempts: s.Int("WEBHOOK_MAX_ATTEMPTS", 8),
+		BackoffBase: s.Duration("WEBHOOK_BACKOFF_BASE", 30*time.Second),
+		BackoffMax:  s.Duration("WEBHOOK_BACKOFF_MAX", 6*time.Hour),
+		Timeout:     s.Duration("WEBHOOK_TIMEOUT", 10*time.Second),
+		AllowHTTP:   s.Bool("WEBHOOK_ALLOW_HTTP", false),
+	}
+}
@@ -0,0 +1,82 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormCouponRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCouponRepository(db *gorm.DB) domain.CouponRepository {
+	return &GormCouponRepository{db: db}
+}
+
+func (r *GormCouponRepository) Create(ctx context.Context, c *domain.Coupon) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(c).Error)
+}
+
+func (r *GormCouponRepository) GetByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	var c domain.Coupon
+	if err := persistence.Conn(ctx, r.db).Where("code = ?", code).First(&c).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &c, nil
+}
+
+func (r *GormCouponRepository) List(ctx context.Context, limit int) ([]domain.Coupon, error) {
+	var coupons []domain.Coupon
+	err := persistence.Conn(ctx, r.db).Order("created_at DESC, id DESC").Limit(limit).Find(&coupons).Error
+	return coupons, persistence.TranslateError(err)
+}
+
+func (r *GormCouponRepository) Redeem(ctx context.Context, c *domain.Coupon, userID int64, orderPublicID string) (*domain.Redemption, error) {
+	redemption := &domain.Redemption{CouponID: c.ID, UserID: userID, OrderPublicID: orderPublicID}
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// The guarded increment also locks the coupon until the transaction ends, so concurrent
+		// redemptions of one customer are counted one after the other
+		result := tx.Model(&domain.Coupon{}).
+			Where("id = ? AND (max_uses IS NULL OR uses < max_uses)", c.ID).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrCouponUsedUp
+		}
+
+		if c.MaxUsesPerUser != nil {
+			var used int64
+			if err := tx.Model(&domain.Redemption{}).Where("coupon_id = ? AND user_id = ?", c.ID, userID).Count(&used).Error; err != nil {
+				return err
+			}
+			if used >= int64(*c.MaxUsesPerUser) {
+				return domain.ErrCouponUsedUp
+			}
+		}
+		return tx.Create(redemption).Error
+	})
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return redemption, nil
+}
+
+func (r *GormCouponRepository) Release(ctx context.Context, redemptionID int64) error {
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var redemption domain.Redemption
+		if err := tx.First(&redemption, redemptionID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&redemption).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Coupon{}).Where("id = ?", redemption.CouponID).
+			Update("uses", gorm.Expr("uses - 1")).Error
+	})
+	return persistence.TranslateError(err)
+}
@@ -0,0 +1,68 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Coupon{}, &domain.Redemption{}))
+	return db
+}
+
+func limit(n int) *int {
+	return &n
+}
+
+func TestGormCouponRepository_CreateAndGet(t *testing.T) {
+	repo := adapter.NewGormCouponRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, &domain.Coupon{Code: "SUMMER10", Kind: domain.KindPercentage, Percent: 1000}))
+	assert.ErrorIs(t, repo.Create(ctx, &domain.Coupon{Code: "SUMMER10", Kind: domain.KindPercentage, Percent: 500}), persistence.ErrDuplicateKey)
+
+	c, err := repo.GetByCode(ctx, "SUMMER10")
+	require.NoError(t, err)
+	assert.Equal(t, domain.KindPercentage, c.Kind)
+	_, err = repo.GetByCode(ctx, "WINTER10")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGormCouponRepository_RedeemEnforcesLimits(t *testing.T) {
+	repo := adapter.NewGormCouponRepository(setupTestDB(t))
+	ctx := context.Background()
+	c := &domain.Coupon{Code: "LAUNCH", Kind: domain.KindPercentage, Percent: 1000, MaxUses: limit(2), MaxUsesPerUser: limit(1)}
+	require.NoError(t, repo.Create(ctx, c))
+
+	first, err := repo.Redeem(ctx, c, 1, "ord_1")
+	require.NoError(t, err)
+	_, err = repo.Redeem(ctx, c, 1, "ord_2")
+	assert.ErrorIs(t, err, domain.ErrCouponUsedUp, "one use per customer")
+	_, err = repo.Redeem(ctx, c, 2, "ord_3")
+	require.NoError(t, err)
+	_, err = repo.Redeem(ctx, c, 3, "ord_4")
+	assert.ErrorIs(t, err, domain.ErrCouponUsedUp, "two uses in total")
+
+	stored, err := repo.GetByCode(ctx, "LAUNCH")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Uses, "refused redemptions are not counted")
+
+	// A failed order gives its use back
+	require.NoError(t, repo.Release(ctx, first.ID))
+	assert.ErrorIs(t, repo.Release(ctx, first.ID), persistence.ErrNotFound)
+	_, err = repo.Redeem(ctx, c, 1, "ord_5")
+	require.NoError(t, err)
+	stored, err = repo.GetByCode(ctx, "LAUNCH")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Uses)
+}
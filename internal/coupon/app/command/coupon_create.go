@@ -0,0 +1,64 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// CreateCouponCommand adds a coupon; its amounts are in minor units of the base currency
+type CreateCouponCommand struct {
+	Code    string
+	Kind    domain.Kind
+	Percent pricing.Rate
+	// Amount is what a fixed coupon takes off and MinOrderValue the subtotal it needs, if any
+	Amount         int64
+	MinOrderValue  int64
+	MaxUses        *int
+	MaxUsesPerUser *int
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+}
+
+type CreateCouponHandler struct {
+	Coupons domain.CouponRepository
+	// Currency is the base currency products are priced in
+	Currency string
+}
+
+func (h *CreateCouponHandler) Handle(ctx context.Context, cmd CreateCouponCommand) (*domain.Coupon, error) {
+	c := &domain.Coupon{
+		Code:           domain.NormalizeCode(cmd.Code),
+		Kind:           cmd.Kind,
+		Percent:        cmd.Percent,
+		MaxUses:        cmd.MaxUses,
+		MaxUsesPerUser: cmd.MaxUsesPerUser,
+		StartsAt:       cmd.StartsAt,
+		EndsAt:         cmd.EndsAt,
+	}
+	if cmd.Amount != 0 {
+		c.Amount.Amount, c.Amount.Currency = cmd.Amount, h.Currency
+	}
+	if cmd.MinOrderValue != 0 {
+		c.MinOrderValue.Amount, c.MinOrderValue.Currency = cmd.MinOrderValue, h.Currency
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := h.Coupons.Create(ctx, c); err != nil {
+		if errors.Is(err, persistence.ErrDuplicateKey) {
+			var errs validation.Errors
+			errs.Add("code", "is already used by another coupon")
+			return nil, errs
+		}
+		return nil, fmt.Errorf("create coupon %s: %w", c.Code, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,157 @@
+// Package domain describes coupons, codes customers enter to take a discount off their order
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+var (
+	ErrCouponNotFound = errors.New("coupon not found")
+	// ErrCouponInactive is returned for coupons used before their validity window opens or after it closes
+	ErrCouponInactive = errors.New("coupon is not valid at this time")
+	// ErrCouponUsedUp is returned once a coupon, or the share of a customer, has no uses left
+	ErrCouponUsedUp = errors.New("coupon has no uses left")
+	// ErrCouponNotApplicable is returned for orders below the minimum order value of a coupon, and
+	// for orders of products priced in another currency or not priced at all
+	ErrCouponNotApplicable = errors.New("coupon does not apply to this order")
+)
+
+// ErrorCodeCouponRejected is returned with 422 for coupons an order cannot be placed with
+const ErrorCodeCouponRejected = "coupon_rejected"
+
+// Kind is how a coupon calculates its discount
+type Kind string
+
+const (
+	// KindPercentage takes Percent of the subtotal off
+	KindPercentage Kind = "percentage"
+	// KindFixed takes Amount off
+	KindFixed Kind = "fixed"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// NormalizeCode returns code as stored; codes are matched regardless of case and surrounding spaces
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+type Coupon struct {
+	ID int64 `gorm:"primaryKey"`
+	// TenantID is the tenant the coupon belongs to; see tenant.Plugin
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_coupons_tenant_code,priority:1"`
+	// Code is what customers enter, e.g. "SUMMER10", unique within the tenant; see NormalizeCode
+	Code string `gorm:"type:varchar(32);uniqueIndex:idx_coupons_tenant_code,priority:2;not null"`
+	Kind Kind   `gorm:"type:varchar(20);not null"`
+	// Percent is the share of the subtotal percentage coupons take off
+	Percent pricing.Rate `gorm:"not null;default:0"`
+	// Amount is what fixed coupons take off, never more than the subtotal
+	Amount money.Money `gorm:"type:varchar(32)"`
+	// MinOrderValue is the subtotal an order needs for the coupon to apply; zero applies to every order
+	MinOrderValue money.Money `gorm:"type:varchar(32)"`
+	// MaxUses bounds the orders placed with the coupon and MaxUsesPerUser those of each customer;
+	// nil is unlimited
+	MaxUses        *int
+	MaxUsesPerUser *int
+	// Uses counts the orders placed with the coupon
+	Uses int `gorm:"not null;default:0"`
+	// StartsAt and EndsAt bound when the coupon can be used; nil leaves that side open
+	StartsAt  *time.Time
+	EndsAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Validate checks the coupon invariants and returns validation.Errors describing every violation
+func (c *Coupon) Validate() error {
+	var errs validation.Errors
+
+	errs.Check(codePattern.MatchString(c.Code), "code", "must be 3 to 32 letters, digits, _ or -")
+	switch c.Kind {
+	case KindPercentage:
+		errs.Check(c.Percent > 0 && c.Percent <= pricing.MaxRate, "percent", "must be between 0 and 100")
+		errs.Check(c.Amount.IsZero(), "amount", "must be empty for percentage coupons")
+	case KindFixed:
+		errs.Check(c.Amount.Amount > 0 && c.Amount.Currency != "", "amount", "must be greater than 0")
+		errs.Check(c.Percent == 0, "percent", "must be empty for fixed coupons")
+	default:
+		errs.Add("kind", fmt.Sprintf("must be %q or %q", KindPercentage, KindFixed))
+	}
+	errs.Check(c.MinOrderValue.Amount >= 0, "min_order_value", "must not be negative")
+	errs.Check(c.MaxUses == nil || *c.MaxUses > 0, "max_uses", "must be greater than 0")
+	errs.Check(c.MaxUsesPerUser == nil || *c.MaxUsesPerUser > 0, "max_uses_per_user", "must be greater than 0")
+	errs.Check(c.StartsAt == nil || c.EndsAt == nil || c.EndsAt.After(*c.StartsAt), "ends_at", "must be after starts_at")
+
+	return errs.Err()
+}
+
+// Check reports why an order with subtotal cannot be placed with the coupon at now, if it cannot.
+// It leaves the limits per customer to CouponRepository.Redeem.
+func (c *Coupon) Check(subtotal money.Money, now time.Time) error {
+	if (c.StartsAt != nil && now.Before(*c.StartsAt)) || (c.EndsAt != nil && !now.Before(*c.EndsAt)) {
+		return ErrCouponInactive
+	}
+	if c.MaxUses != nil && c.Uses >= *c.MaxUses {
+		return ErrCouponUsedUp
+	}
+	if subtotal.Currency == "" {
+		return fmt.Errorf("%w: the product has no price", ErrCouponNotApplicable)
+	}
+	if !c.MinOrderValue.IsZero() && (subtotal.Currency != c.MinOrderValue.Currency || subtotal.Amount < c.MinOrderValue.Amount) {
+		return fmt.Errorf("%w: it requires a subtotal of at least %s", ErrCouponNotApplicable, c.MinOrderValue)
+	}
+	if c.Kind == KindFixed && subtotal.Currency != c.Amount.Currency {
+		return fmt.Errorf("%w: it applies to prices in %s", ErrCouponNotApplicable, c.Amount.Currency)
+	}
+	return nil
+}
+
+// Off returns the discount of the coupon on subtotal, which pricing.Engine caps at the subtotal.
+// Percentages are rounded down to the minor unit.
+func (c *Coupon) Off(subtotal money.Money) (money.Money, error) {
+	if c.Kind == KindPercentage {
+		return c.Percent.Of(subtotal, money.RoundDown), nil
+	}
+	if c.Amount.Currency != subtotal.Currency {
+		return money.Money{}, fmt.Errorf("%w: coupon in %s for prices in %s", money.ErrCurrencyMismatch, c.Amount.Currency, subtotal.Currency)
+	}
+	return c.Amount, nil
+}
+
+// Redemption is the use of a coupon by an order
+type Redemption struct {
+	ID       int64 `gorm:"primaryKey"`
+	CouponID int64 `gorm:"index;not null"`
+	UserID   int64 `gorm:"index;not null"`
+	// OrderPublicID is the order the coupon was used for
+	OrderPublicID string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	CreatedAt     time.Time
+}
+
+func (Redemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+type CouponRepository interface {
+	// Create returns persistence.ErrDuplicateKey when the code is taken
+	Create(ctx context.Context, c *Coupon) error
+	// GetByCode returns persistence.ErrNotFound for unknown codes; code must be normalized
+	GetByCode(ctx context.Context, code string) (*Coupon, error)
+	// List returns the coupons, newest first
+	List(ctx context.Context, limit int) ([]Coupon, error)
+	// Redeem counts a use of c by the order of userID in one statement, so concurrent orders
+	// cannot use the coupon more often than MaxUses, and records it. It returns ErrCouponUsedUp
+	// when the coupon, or the share of userID, has no uses left.
+	Redeem(ctx context.Context, c *Coupon, userID int64, orderPublicID string) (*Redemption, error)
+	// Release gives the use of a redemption back once its order failed; it returns
+	// persistence.ErrNotFound for redemptions released already
+	Release(ctx context.Context, redemptionID int64) error
+}
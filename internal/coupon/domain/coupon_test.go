@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+func eur(amount int64) money.Money {
+	return money.Money{Amount: amount, Currency: "EUR"}
+}
+
+func TestCoupon_Validate(t *testing.T) {
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+	zero := 0
+
+	tests := []struct {
+		name   string
+		coupon Coupon
+		field  string
+	}{
+		{"valid percentage", Coupon{Code: "SUMMER10", Kind: KindPercentage, Percent: 1000}, ""},
+		{"valid fixed", Coupon{Code: "WELCOME-5", Kind: KindFixed, Amount: eur(500), MinOrderValue: eur(2000)}, ""},
+		{"short code", Coupon{Code: "AB", Kind: KindPercentage, Percent: 1000}, "code"},
+		{"lowercase code", Coupon{Code: "summer10", Kind: KindPercentage, Percent: 1000}, "code"},
+		{"unknown kind", Coupon{Code: "SUMMER10", Kind: "bogo"}, "kind"},
+		{"percent over 100", Coupon{Code: "SUMMER10", Kind: KindPercentage, Percent: 10001}, "percent"},
+		{"fixed without amount", Coupon{Code: "SUMMER10", Kind: KindFixed}, "amount"},
+		{"no uses", Coupon{Code: "SUMMER10", Kind: KindPercentage, Percent: 1000, MaxUses: &zero}, "max_uses"},
+		{"ends before it starts", Coupon{Code: "SUMMER10", Kind: KindPercentage, Percent: 1000, StartsAt: &start, EndsAt: &end}, "ends_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.coupon.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			var errs validation.Errors
+			if !errors.As(err, &errs) || errs[0].Field != tt.field {
+				t.Errorf("Expected a validation error on %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestCoupon_Check(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	one := 1
+
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		subtotal money.Money
+		want     error
+	}{
+		{"applies", Coupon{Kind: KindFixed, Amount: eur(500), MinOrderValue: eur(2000)}, eur(2000), nil},
+		{"not started", Coupon{Kind: KindPercentage, Percent: 1000, StartsAt: &later}, eur(2000), ErrCouponInactive},
+		{"ended", Coupon{Kind: KindPercentage, Percent: 1000, EndsAt: &now}, eur(2000), ErrCouponInactive},
+		{"used up", Coupon{Kind: KindPercentage, Percent: 1000, MaxUses: &one, Uses: 1}, eur(2000), ErrCouponUsedUp},
+		{"below minimum", Coupon{Kind: KindFixed, Amount: eur(500), MinOrderValue: eur(2000)}, eur(1999), ErrCouponNotApplicable},
+		{"unpriced product", Coupon{Kind: KindPercentage, Percent: 1000}, money.Money{}, ErrCouponNotApplicable},
+		{"other currency", Coupon{Kind: KindFixed, Amount: eur(500)}, money.Money{Amount: 2000, Currency: "USD"}, ErrCouponNotApplicable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.coupon.Check(tt.subtotal, now); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCoupon_Off(t *testing.T) {
+	percentage := Coupon{Kind: KindPercentage, Percent: 1250}
+	if off, _ := percentage.Off(eur(1999)); off != eur(249) {
+		t.Errorf("Expected 12.5%% of 19.99 rounded down to 2.49, got %s", off)
+	}
+
+	fixed := Coupon{Kind: KindFixed, Amount: eur(500)}
+	if off, _ := fixed.Off(eur(1999)); off != eur(500) {
+		t.Errorf("Expected 5.00 off, got %s", off)
+	}
+	if _, err := fixed.Off(money.Money{Amount: 1999, Currency: "USD"}); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Expected a currency mismatch, got %v", err)
+	}
+}
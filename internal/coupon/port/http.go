@@ -0,0 +1,175 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// CreateCouponRequest is the body of POST /coupons; amounts are in minor units of the base currency
+type CreateCouponRequest struct {
+	Code string      `json:"code"`
+	Kind domain.Kind `json:"kind"`
+	// Percent is the share of the subtotal percentage coupons take off, e.g. "10" or "12.5"
+	Percent string `json:"percent,omitempty"`
+	// Amount is what fixed coupons take off
+	Amount        int64 `json:"amount,omitempty"`
+	MinOrderValue int64 `json:"min_order_value,omitempty"`
+	// MaxUses and MaxUsesPerUser are unlimited when omitted
+	MaxUses        *int       `json:"max_uses,omitempty"`
+	MaxUsesPerUser *int       `json:"max_uses_per_user,omitempty"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+}
+
+// CouponResponse is the public representation of a coupon
+type CouponResponse struct {
+	Code           string      `json:"code"`
+	Kind           domain.Kind `json:"kind"`
+	Percent        string      `json:"percent,omitempty"`
+	Amount         int64       `json:"amount,omitempty"`
+	MinOrderValue  int64       `json:"min_order_value,omitempty"`
+	Currency       string      `json:"currency,omitempty"`
+	MaxUses        *int        `json:"max_uses,omitempty"`
+	MaxUsesPerUser *int        `json:"max_uses_per_user,omitempty"`
+	Uses           int         `json:"uses"`
+	StartsAt       *time.Time  `json:"starts_at,omitempty"`
+	EndsAt         *time.Time  `json:"ends_at,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// CouponListResponse lists coupons, newest first
+type CouponListResponse struct {
+	Coupons []CouponResponse `json:"coupons"`
+}
+
+// HTTPServer lets staff manage coupons over HTTP
+type HTTPServer struct {
+	CreateCoupon decorator.CommandResultHandler[command.CreateCouponCommand, *domain.Coupon]
+	Coupons      domain.CouponRepository
+
+	// Auth requires coupon:manage; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the coupon endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/coupons",
+		Summary:  "Create a coupon taking a percentage or a fixed amount off orders",
+		Tags:     []string{"coupons"},
+		Request:  CreateCouponRequest{},
+		Response: CouponResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionCouponManage, s.createCoupon),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/coupons",
+		Summary:  "List coupons with their uses, newest first; ?limit= takes up to 200",
+		Tags:     []string{"coupons"},
+		Response: CouponListResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionCouponManage, s.listCoupons),
+	})
+}
+
+func (s *HTTPServer) createCoupon(w http.ResponseWriter, r *http.Request) {
+	var req CreateCouponRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	cmd := command.CreateCouponCommand{
+		Code:           req.Code,
+		Kind:           req.Kind,
+		Amount:         req.Amount,
+		MinOrderValue:  req.MinOrderValue,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+	}
+	if req.Percent != "" {
+		percent, err := pricing.ParseRate(req.Percent)
+		if err != nil {
+			var errs validation.Errors
+			errs.Add("percent", err.Error())
+			httpx.WriteError(w, errs)
+			return
+		}
+		cmd.Percent = percent
+	}
+
+	c, err := s.CreateCoupon.Handle(r.Context(), cmd)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, toCouponResponse(c))
+}
+
+func (s *HTTPServer) listCoupons(w http.ResponseWriter, r *http.Request) {
+	limit := defaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxLimit {
+			var errs validation.Errors
+			errs.Add("limit", fmt.Sprintf("must be between 1 and %d, got %q", maxLimit, value))
+			httpx.WriteError(w, errs)
+			return
+		}
+		limit = n
+	}
+
+	coupons, err := s.Coupons.List(r.Context(), limit)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := CouponListResponse{Coupons: make([]CouponResponse, len(coupons))}
+	for i := range coupons {
+		resp.Coupons[i] = toCouponResponse(&coupons[i])
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func toCouponResponse(c *domain.Coupon) CouponResponse {
+	resp := CouponResponse{
+		Code:           c.Code,
+		Kind:           c.Kind,
+		Amount:         c.Amount.Amount,
+		MinOrderValue:  c.MinOrderValue.Amount,
+		MaxUses:        c.MaxUses,
+		MaxUsesPerUser: c.MaxUsesPerUser,
+		Uses:           c.Uses,
+		StartsAt:       c.StartsAt,
+		EndsAt:         c.EndsAt,
+		CreatedAt:      c.CreatedAt,
+	}
+	if c.Kind == domain.KindPercentage {
+		resp.Percent = c.Percent.Percent()
+	}
+	if resp.Currency = c.Amount.Currency; resp.Currency == "" {
+		resp.Currency = c.MinOrderValue.Currency
+	}
+	return resp
+}
@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormCredentialRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCredentialRepository(db *gorm.DB) domain.CredentialRepository {
+	return &GormCredentialRepository{db: db}
+}
+
+// Add relies on the unique version index: a concurrent rotation of the same key fails with a duplicate error
+func (r *GormCredentialRepository) Add(ctx context.Context, c *domain.Credential) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		err := tx.Model(&domain.Credential{}).
+			Where("adapter = ? AND name = ?", c.Adapter, c.Name).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+		c.Version = latest + 1
+		return tx.Create(c).Error
+	})
+	return persistence.TranslateError(err)
+}
+
+func (r *GormCredentialRepository) Latest(ctx context.Context, key domain.Key) (*domain.Credential, error) {
+	var c domain.Credential
+	err := r.db.WithContext(ctx).
+		Where("adapter = ? AND name = ?", key.Adapter, key.Name).
+		Order("version DESC").
+		First(&c).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &c, nil
+}
+
+func (r *GormCredentialRepository) Versions(ctx context.Context) ([]domain.Credential, error) {
+	var credentials []domain.Credential
+	err := r.db.WithContext(ctx).
+		Omit("ciphertext").
+		Order("adapter, name, version DESC").
+		Find(&credentials).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return credentials, nil
+}
@@ -0,0 +1,43 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormCredentialRepository_AddVersions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Credential{}))
+	repo := adapter.NewGormCredentialRepository(db)
+	ctx := context.Background()
+	stripe := domain.Key{Adapter: "stripe", Name: "secret_key"}
+
+	_, err = repo.Latest(ctx, stripe)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+
+	for _, ciphertext := range []string{"k1:first", "k1:second"} {
+		require.NoError(t, repo.Add(ctx, &domain.Credential{Adapter: "stripe", Name: "secret_key", Ciphertext: ciphertext, RotatedBy: "user:1"}))
+	}
+	require.NoError(t, repo.Add(ctx, &domain.Credential{Adapter: "sendgrid", Name: "api_key", Ciphertext: "k1:sg", RotatedBy: "system"}))
+
+	latest, err := repo.Latest(ctx, stripe)
+	require.NoError(t, err)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, "k1:second", latest.Ciphertext)
+
+	versions, err := repo.Versions(ctx)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, "sendgrid", versions[0].Adapter)
+	assert.Equal(t, 2, versions[1].Version)
+	assert.Empty(t, versions[1].Ciphertext)
+}
@@ -0,0 +1,29 @@
+package command
+
+import (
+	"context"
+
+	auditDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// RotateCredentialCommand replaces the secret an adapter uses, e.g. after rolling a Stripe key
+type RotateCredentialCommand struct {
+	Adapter string `validate:"required,max=32"`
+	Name    string `validate:"required,max=64"`
+	Value   string `validate:"required,max=4096"`
+}
+
+type RotateCredentialHandler struct {
+	Store *domain.Store
+}
+
+// Handle stores the value as the next version; the adapter uses it on its next call
+func (h *RotateCredentialHandler) Handle(ctx context.Context, cmd RotateCredentialCommand) (*domain.Credential, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+	key := domain.Key{Adapter: cmd.Adapter, Name: cmd.Name}
+	return h.Store.Rotate(ctx, key, cmd.Value, auditDomain.ActorFromContext(ctx))
+}
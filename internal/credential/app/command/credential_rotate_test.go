@@ -0,0 +1,78 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+type MockCredentialRepository struct {
+	domain.CredentialRepository
+	added []domain.Credential
+}
+
+func (m *MockCredentialRepository) Add(ctx context.Context, c *domain.Credential) error {
+	c.Version = len(m.added) + 1
+	m.added = append(m.added, *c)
+	return nil
+}
+
+func (m *MockCredentialRepository) Latest(ctx context.Context, key domain.Key) (*domain.Credential, error) {
+	return nil, persistence.ErrNotFound
+}
+
+func newRotateHandler(t *testing.T) (*RotateCredentialHandler, *MockCredentialRepository) {
+	keyring, err := secret.NewKeyring("k1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials := &MockCredentialRepository{}
+	store := &domain.Store{Credentials: credentials, Keyring: keyring}
+	store.Source("stripe", "secret_key", "sk_env")
+	return &RotateCredentialHandler{Store: store}, credentials
+}
+
+func TestRotateCredentialHandler_Handle(t *testing.T) {
+	// Arrange
+	handler, credentials := newRotateHandler(t)
+	ctx := auth.WithUserID(context.Background(), 7)
+	cmd := RotateCredentialCommand{Adapter: "stripe", Name: "secret_key", Value: "sk_live_new"}
+
+	// Act
+	c, err := handler.Handle(ctx, cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if c.Version != 1 || c.RotatedBy != "user:7" {
+		t.Errorf("Expected version 1 rotated by user:7, got version %d by %q", c.Version, c.RotatedBy)
+	}
+	if len(credentials.added) != 1 || credentials.added[0].Ciphertext == "sk_live_new" {
+		t.Errorf("Expected the value to be stored encrypted, got %+v", credentials.added)
+	}
+}
+
+func TestRotateCredentialHandler_Handle_Invalid(t *testing.T) {
+	// Arrange
+	handler, credentials := newRotateHandler(t)
+	cmd := RotateCredentialCommand{Adapter: "stripe", Name: "secret_key"}
+
+	// Act
+	_, err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Errorf("Expected validation errors for an empty value, got %v", err)
+	}
+	if len(credentials.added) != 0 {
+		t.Errorf("Expected nothing to be stored, got %+v", credentials.added)
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrUnknownCredential is returned when rotating a credential no adapter reads
+var ErrUnknownCredential = errors.New("unknown credential")
+
+// Key names a secret of a third-party adapter, e.g. the "secret_key" of "stripe"
+type Key struct {
+	Adapter string
+	Name    string
+}
+
+func (k Key) String() string {
+	return k.Adapter + "/" + k.Name
+}
+
+// Credential is one version of an adapter secret, encrypted at rest. Rotating adds a version;
+// the highest version is the one in use and older ones are kept as a history.
+type Credential struct {
+	ID      int64  `gorm:"primaryKey"`
+	Adapter string `gorm:"type:varchar(32);not null;uniqueIndex:idx_credentials_version,priority:1"`
+	Name    string `gorm:"type:varchar(64);not null;uniqueIndex:idx_credentials_version,priority:2"`
+	Version int    `gorm:"not null;uniqueIndex:idx_credentials_version,priority:3"`
+	// Ciphertext is the value sealed by secret.Keyring; it is never returned by the API
+	Ciphertext string `gorm:"type:text;not null"`
+	RotatedBy  string `gorm:"type:varchar(64);not null"`
+	CreatedAt  time.Time
+}
+
+func (c *Credential) Key() Key {
+	return Key{Adapter: c.Adapter, Name: c.Name}
+}
+
+type CredentialRepository interface {
+	// Add stores c as the next version of its key and sets c.Version
+	Add(ctx context.Context, c *Credential) error
+	// Latest returns the current version of a key, persistence.ErrNotFound when it was never rotated
+	Latest(ctx context.Context, key Key) (*Credential, error)
+	// Versions lists every stored version without ciphertexts, newest first per key
+	Versions(ctx context.Context) ([]Credential, error)
+}
@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// Store resolves adapter secrets from their latest stored version, falling back to the value
+// configured in the environment until a credential is first rotated. Resolved values are cached
+// for TTL, so other instances pick up a rotation within TTL without a restart.
+type Store struct {
+	Credentials CredentialRepository
+	Keyring     *secret.Keyring
+	TTL         time.Duration
+	Now         func() time.Time
+
+	mu        sync.Mutex
+	fallbacks map[Key]string
+	cache     map[Key]cachedSecret
+}
+
+type cachedSecret struct {
+	value   string
+	version int
+	expires time.Time
+}
+
+// Status is the version of a credential in use; version 0 means the environment value is used
+type Status struct {
+	Key       Key
+	Version   int
+	RotatedAt *time.Time
+	RotatedBy string
+}
+
+// Source registers an adapter secret and returns it as a secret.Source for the adapter
+func (s *Store) Source(adapter, name, fallback string) secret.Source {
+	key := Key{Adapter: adapter, Name: name}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fallbacks == nil {
+		s.fallbacks = make(map[Key]string)
+	}
+	s.fallbacks[key] = fallback
+	return source{store: s, key: key}
+}
+
+type source struct {
+	store *Store
+	key   Key
+}
+
+func (src source) Secret(ctx context.Context) (string, error) {
+	value, _, err := src.store.Current(ctx, src.key)
+	return value, err
+}
+
+// Known reports whether an adapter reads the credential
+func (s *Store) Known(key Key) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.fallbacks[key]
+	return ok
+}
+
+// Current returns the value and version of a credential
+func (s *Store) Current(ctx context.Context, key Key) (string, int, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	fallback, known := s.fallbacks[key]
+	s.mu.Unlock()
+	if !known {
+		return "", 0, fmt.Errorf("%w %s", ErrUnknownCredential, key)
+	}
+	if ok && s.now().Before(cached.expires) {
+		return cached.value, cached.version, nil
+	}
+
+	value, version := fallback, 0
+	c, err := s.Credentials.Latest(ctx, key)
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+	case err != nil:
+		// Keep serving the last known value when the database is briefly unavailable
+		if ok {
+			slog.WarnContext(ctx, "serving cached credential after lookup failure", "credential", key.String(), "error", err)
+			return cached.value, cached.version, nil
+		}
+		return "", 0, fmt.Errorf("load credential %s: %w", key, err)
+	default:
+		if value, err = s.Keyring.Decrypt(c.Ciphertext); err != nil {
+			return "", 0, fmt.Errorf("decrypt credential %s version %d: %w", key, c.Version, err)
+		}
+		version = c.Version
+	}
+
+	s.remember(key, value, version)
+	return value, version, nil
+}
+
+// Rotate stores value as the next version of a credential and uses it from now on
+func (s *Store) Rotate(ctx context.Context, key Key, value, actor string) (*Credential, error) {
+	if !s.Known(key) {
+		return nil, fmt.Errorf("%w %s", ErrUnknownCredential, key)
+	}
+	ciphertext, err := s.Keyring.Encrypt(value)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt credential %s: %w", key, err)
+	}
+
+	c := &Credential{Adapter: key.Adapter, Name: key.Name, Ciphertext: ciphertext, RotatedBy: actor}
+	if err := s.Credentials.Add(ctx, c); err != nil {
+		return nil, fmt.Errorf("store credential %s: %w", key, err)
+	}
+	s.remember(key, value, c.Version)
+	return c, nil
+}
+
+// Statuses lists the credentials adapters read with the version in use, sorted by key
+func (s *Store) Statuses(ctx context.Context) ([]Status, error) {
+	versions, err := s.Credentials.Versions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[Key]Credential)
+	for _, c := range versions {
+		if c.Version > latest[c.Key()].Version {
+			latest[c.Key()] = c
+		}
+	}
+
+	s.mu.Lock()
+	statuses := make([]Status, 0, len(s.fallbacks))
+	for key := range s.fallbacks {
+		status := Status{Key: key}
+		if c, ok := latest[key]; ok {
+			status.Version, status.RotatedBy = c.Version, c.RotatedBy
+			rotatedAt := c.CreatedAt
+			status.RotatedAt = &rotatedAt
+		}
+		statuses = append(statuses, status)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Key.String() < statuses[j].Key.String()
+	})
+	return statuses, nil
+}
+
+func (s *Store) Describe() string {
+	return "credentials"
+}
+
+// Warm resolves every credential adapters read, so the first calls to payment providers and
+// mail servers after a start do not wait for the database and the keyring
+func (s *Store) Warm(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	keys := make([]Key, 0, len(s.fallbacks))
+	for key := range s.fallbacks {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+
+	warmed := 0
+	var errs []error
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return warmed, err
+		}
+		if _, _, err := s.Current(ctx, key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		warmed++
+	}
+	return warmed, errors.Join(errs...)
+}
+
+func (s *Store) remember(key Key, value string, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		s.cache = make(map[Key]cachedSecret)
+	}
+	s.cache[key] = cachedSecret{value: value, version: version, expires: s.now().Add(s.TTL)}
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,127 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCredentials keeps credential versions in memory; err fails every lookup
+type memoryCredentials struct {
+	versions []domain.Credential
+	err      error
+}
+
+func (m *memoryCredentials) Add(ctx context.Context, c *domain.Credential) error {
+	latest, err := m.Latest(ctx, c.Key())
+	c.Version = 1
+	if err == nil {
+		c.Version = latest.Version + 1
+	}
+	c.CreatedAt = time.Now()
+	m.versions = append(m.versions, *c)
+	return nil
+}
+
+func (m *memoryCredentials) Latest(ctx context.Context, key domain.Key) (*domain.Credential, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i := len(m.versions) - 1; i >= 0; i-- {
+		if m.versions[i].Key() == key {
+			return &m.versions[i], nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *memoryCredentials) Versions(ctx context.Context) ([]domain.Credential, error) {
+	return m.versions, m.err
+}
+
+func newStore(t *testing.T) (*domain.Store, *memoryCredentials, *time.Time) {
+	keyring, err := secret.NewKeyring("k1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	require.NoError(t, err)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	credentials := &memoryCredentials{}
+	store := &domain.Store{Credentials: credentials, Keyring: keyring, TTL: time.Minute, Now: func() time.Time { return now }}
+	return store, credentials, &now
+}
+
+func TestStore_FallsBackUntilRotated(t *testing.T) {
+	store, credentials, _ := newStore(t)
+	source := store.Source("stripe", "secret_key", "sk_env")
+	ctx := context.Background()
+
+	value, err := source.Secret(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_env", value)
+
+	c, err := store.Rotate(ctx, domain.Key{Adapter: "stripe", Name: "secret_key"}, "sk_rotated", "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Version)
+	assert.NotContains(t, credentials.versions[0].Ciphertext, "sk_rotated")
+
+	value, err = source.Secret(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_rotated", value)
+}
+
+func TestStore_PicksUpRotationsOfOtherInstancesAfterTTL(t *testing.T) {
+	store, credentials, now := newStore(t)
+	other := &domain.Store{Credentials: credentials, Keyring: store.Keyring, TTL: time.Minute}
+	source := store.Source("sendgrid", "api_key", "SG.env")
+	other.Source("sendgrid", "api_key", "SG.env")
+	ctx := context.Background()
+
+	_, err := source.Secret(ctx)
+	require.NoError(t, err)
+	_, err = other.Rotate(ctx, domain.Key{Adapter: "sendgrid", Name: "api_key"}, "SG.new", "user:1")
+	require.NoError(t, err)
+
+	value, _ := source.Secret(ctx)
+	assert.Equal(t, "SG.env", value, "cached until the TTL expires")
+
+	*now = now.Add(2 * time.Minute)
+	value, _ = source.Secret(ctx)
+	assert.Equal(t, "SG.new", value)
+
+	// A failing lookup keeps serving the last known value
+	credentials.err = errors.New("connection refused")
+	*now = now.Add(2 * time.Minute)
+	value, err = source.Secret(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "SG.new", value)
+}
+
+func TestStore_RotateUnknownCredential(t *testing.T) {
+	store, _, _ := newStore(t)
+
+	_, err := store.Rotate(context.Background(), domain.Key{Adapter: "stripe", Name: "typo"}, "value", "user:1")
+
+	assert.ErrorIs(t, err, domain.ErrUnknownCredential)
+}
+
+func TestStore_Warm(t *testing.T) {
+	store, credentials, _ := newStore(t)
+	source := store.Source("stripe", "secret_key", "sk_env")
+	store.Source("sendgrid", "api_key", "SG.env")
+	ctx := context.Background()
+
+	warmed, err := store.Warm(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, warmed)
+
+	// Warmed credentials are served from the cache
+	credentials.err = errors.New("connection refused")
+	value, err := source.Secret(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_env", value)
+}
@@ -0,0 +1,119 @@
+package port
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// RotateCredentialRequest is the body of PUT /credentials/{adapter}/{name}
+type RotateCredentialRequest struct {
+	Value string `json:"value"`
+}
+
+// CredentialResponse describes the version of a credential in use; values are never returned
+type CredentialResponse struct {
+	Adapter string `json:"adapter"`
+	Name    string `json:"name"`
+	// Version is 0 while the value configured in the environment is used
+	Version   int        `json:"version"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RotatedBy string     `json:"rotated_by,omitempty"`
+}
+
+// CredentialsResponse lists the credentials the adapters read
+type CredentialsResponse struct {
+	Credentials []CredentialResponse `json:"credentials"`
+}
+
+// HTTPServer exposes credential rotation over HTTP
+type HTTPServer struct {
+	RotateCredential decorator.CommandResultHandler[command.RotateCredentialCommand, *domain.Credential]
+	Store            *domain.Store
+
+	// Auth restricts credentials to admins; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the credential endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/credentials",
+		Summary:  "List the third-party credentials adapters use and their current version",
+		Tags:     []string{"credentials"},
+		Response: CredentialsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionCredentialManage, s.listCredentials),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/credentials/{adapter}/{name}",
+		Summary:  "Rotate a third-party credential; adapters use the new value without a restart",
+		Tags:     []string{"credentials"},
+		Request:  RotateCredentialRequest{},
+		Response: CredentialResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionCredentialManage, s.rotateCredential),
+	})
+}
+
+func (s *HTTPServer) listCredentials(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.Store.Statuses(r.Context())
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := CredentialsResponse{Credentials: make([]CredentialResponse, len(statuses))}
+	for i, status := range statuses {
+		resp.Credentials[i] = CredentialResponse{
+			Adapter:   status.Key.Adapter,
+			Name:      status.Key.Name,
+			Version:   status.Version,
+			RotatedAt: status.RotatedAt,
+			RotatedBy: status.RotatedBy,
+		}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) rotateCredential(w http.ResponseWriter, r *http.Request) {
+	var req RotateCredentialRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	c, err := s.RotateCredential.Handle(r.Context(), command.RotateCredentialCommand{
+		Adapter: r.PathValue("adapter"),
+		Name:    r.PathValue("name"),
+		Value:   req.Value,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnknownCredential):
+			httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+		case errors.Is(err, secret.ErrNoKeys):
+			httpx.WriteErrorStatus(w, http.StatusServiceUnavailable, errors.New("credential encryption is not configured; set ENCRYPTION_KEYS"))
+		default:
+			httpx.WriteError(w, err)
+		}
+		return
+	}
+
+	rotatedAt := c.CreatedAt
+	httpx.WriteJSON(w, http.StatusOK, CredentialResponse{
+		Adapter:   c.Adapter,
+		Name:      c.Name,
+		Version:   c.Version,
+		RotatedAt: &rotatedAt,
+		RotatedBy: c.RotatedBy,
+	})
+}
@@ -0,0 +1,44 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormEstimateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEstimateRepository(db *gorm.DB) domain.EstimateRepository {
+	return &GormEstimateRepository{db: db}
+}
+
+func (r *GormEstimateRepository) Create(ctx context.Context, e *domain.Estimate) error {
+	return persistence.TranslateError(r.db.WithContext(ctx).Create(e).Error)
+}
+
+func (r *GormEstimateRepository) Update(ctx context.Context, e *domain.Estimate) error {
+	err := r.db.WithContext(ctx).Model(e).Select("delivered_at", "updated_at").Updates(e).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormEstimateRepository) GetByOrderID(ctx context.Context, orderID int64) (*domain.Estimate, error) {
+	var e domain.Estimate
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&e).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &e, nil
+}
+
+func (r *GormEstimateRepository) ListDelivered(ctx context.Context, from, to time.Time) ([]domain.Estimate, error) {
+	var estimates []domain.Estimate
+	err := r.db.WithContext(ctx).
+		Where("delivered_at >= ? AND delivered_at < ?", from, to).
+		Order("delivered_at ASC, id ASC").
+		Find(&estimates).Error
+	return estimates, persistence.TranslateError(err)
+}
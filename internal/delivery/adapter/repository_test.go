@@ -0,0 +1,51 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Estimate{}))
+	return db
+}
+
+func TestGormEstimateRepository_RecordAndListDelivered(t *testing.T) {
+	repo := adapter.NewGormEstimateRepository(setupTestDB(t))
+	ctx := context.Background()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	placedAt := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	w := domain.Window{Earliest: time.Date(2024, 6, 5, 0, 0, 0, 0, berlin), Latest: time.Date(2024, 6, 6, 0, 0, 0, 0, berlin)}
+
+	delivered := domain.NewEstimate(1, "standard", "DE", placedAt, w)
+	require.NoError(t, repo.Create(ctx, delivered))
+	require.NoError(t, repo.Create(ctx, domain.NewEstimate(2, "standard", "DE", placedAt, w)))
+	assert.ErrorIs(t, repo.Create(ctx, domain.NewEstimate(1, "express", "DE", placedAt, w)), persistence.ErrDuplicateKey)
+
+	require.NoError(t, delivered.RecordDelivery(time.Date(2024, 6, 6, 15, 0, 0, 0, time.UTC)))
+	require.NoError(t, repo.Update(ctx, delivered))
+
+	got, err := repo.GetByOrderID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OutcomeOnTime, got.Outcome())
+	assert.Equal(t, "2024-06-05", got.Window().Earliest.Format("2006-01-02"), "window days are in the zone of the warehouse")
+	_, err = repo.GetByOrderID(ctx, 3)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+
+	estimates, err := repo.ListDelivered(ctx, time.Date(2024, 6, 6, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, estimates, 1)
+	assert.Equal(t, int64(1), estimates[0].OrderID)
+}
@@ -0,0 +1,66 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormWarehouseRepository ranks warehouses with the earthdistance extension on postgres, using
+// the GiST index of the migration, and by haversine distance in Go on other databases, which
+// hold the few warehouses of a single instance
+type GormWarehouseRepository struct {
+	db            *gorm.DB
+	earthdistance bool
+}
+
+func NewGormWarehouseRepository(db *gorm.DB) domain.WarehouseRepository {
+	return &GormWarehouseRepository{db: db, earthdistance: db.Dialector.Name() == "postgres"}
+}
+
+func (r *GormWarehouseRepository) Save(ctx context.Context, w *domain.Warehouse) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"latitude", "longitude", "time_zone", "updated_at"}),
+	}).Create(w).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormWarehouseRepository) NearestWarehouse(ctx context.Context, to geo.Point) (*domain.Warehouse, error) {
+	if !r.earthdistance {
+		return r.nearestInGo(ctx, to)
+	}
+
+	var warehouses []domain.Warehouse
+	err := r.db.WithContext(ctx).
+		Order(clause.Expr{SQL: "earth_distance(ll_to_earth(latitude, longitude), ll_to_earth(?, ?))", Vars: []any{to.Lat, to.Lng}}).
+		Limit(1).
+		Find(&warehouses).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	if len(warehouses) == 0 {
+		return nil, domain.ErrNoWarehouse
+	}
+	return &warehouses[0], nil
+}
+
+func (r *GormWarehouseRepository) nearestInGo(ctx context.Context, to geo.Point) (*domain.Warehouse, error) {
+	var warehouses []domain.Warehouse
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&warehouses).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	points := make([]geo.Point, len(warehouses))
+	for i := range warehouses {
+		points[i] = warehouses[i].Point()
+	}
+	i := geo.Nearest(to, points)
+	if i < 0 {
+		return nil, domain.ErrNoWarehouse
+	}
+	return &warehouses[i], nil
+}
@@ -0,0 +1,36 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormWarehouseRepository_NearestWarehouse(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&domain.Warehouse{}))
+	repo := adapter.NewGormWarehouseRepository(db)
+	ctx := context.Background()
+	paris := geo.Point{Lat: 48.86, Lng: 2.35}
+
+	_, err := repo.NearestWarehouse(ctx, paris)
+	assert.ErrorIs(t, err, domain.ErrNoWarehouse)
+
+	require.NoError(t, repo.Save(ctx, &domain.Warehouse{Code: "BER", Latitude: 52.52, Longitude: 13.40, TimeZone: "Europe/Berlin"}))
+	require.NoError(t, repo.Save(ctx, &domain.Warehouse{Code: "NYC", Latitude: 40.71, Longitude: -74.01, TimeZone: "America/New_York"}))
+	w, err := repo.NearestWarehouse(ctx, paris)
+	require.NoError(t, err)
+	assert.Equal(t, "BER", w.Code)
+
+	// Saving a warehouse again moves it
+	require.NoError(t, repo.Save(ctx, &domain.Warehouse{Code: "NYC", Latitude: 51.51, Longitude: -0.13, TimeZone: "Europe/London"}))
+	w, err = repo.NearestWarehouse(ctx, paris)
+	require.NoError(t, err)
+	assert.Equal(t, "NYC", w.Code)
+	assert.Equal(t, "Europe/London", w.TimeZone)
+}
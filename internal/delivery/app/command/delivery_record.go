@@ -0,0 +1,56 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// RecordDeliveryCommand records when an order actually arrived, for comparison with its estimate
+type RecordDeliveryCommand struct {
+	OrderID     int64 `validate:"required,gt=0"`
+	DeliveredAt time.Time
+}
+
+type RecordDeliveryHandler struct {
+	Estimates domain.EstimateRepository
+	Now       func() time.Time
+}
+
+func (h *RecordDeliveryHandler) Handle(ctx context.Context, cmd RecordDeliveryCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	e, err := h.Estimates.GetByOrderID(ctx, cmd.OrderID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return domain.ErrEstimateNotFound
+		}
+		return fmt.Errorf("get delivery estimate of order %d: %w", cmd.OrderID, err)
+	}
+
+	deliveredAt := cmd.DeliveredAt
+	if deliveredAt.IsZero() {
+		deliveredAt = nowFunc(h.Now)()
+	}
+	if err := e.RecordDelivery(deliveredAt); err != nil {
+		return err
+	}
+	if err := h.Estimates.Update(ctx, e); err != nil {
+		return fmt.Errorf("record delivery of order %d: %w", cmd.OrderID, err)
+	}
+	return nil
+}
+
+func nowFunc(now func() time.Time) func() time.Time {
+	if now != nil {
+		return now
+	}
+	return time.Now
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MockEstimateRepository keeps estimates in memory, keyed by order
+type MockEstimateRepository struct {
+	estimates map[int64]domain.Estimate
+}
+
+func (m *MockEstimateRepository) Create(ctx context.Context, e *domain.Estimate) error {
+	if m.estimates == nil {
+		m.estimates = make(map[int64]domain.Estimate)
+	}
+	if _, ok := m.estimates[e.OrderID]; ok {
+		return persistence.ErrDuplicateKey
+	}
+	m.estimates[e.OrderID] = *e
+	return nil
+}
+
+func (m *MockEstimateRepository) Update(ctx context.Context, e *domain.Estimate) error {
+	m.estimates[e.OrderID] = *e
+	return nil
+}
+
+func (m *MockEstimateRepository) GetByOrderID(ctx context.Context, orderID int64) (*domain.Estimate, error) {
+	e, ok := m.estimates[orderID]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+	return &e, nil
+}
+
+func (m *MockEstimateRepository) ListDelivered(ctx context.Context, from, to time.Time) ([]domain.Estimate, error) {
+	return nil, nil
+}
+
+func TestRecordEstimateAndDelivery(t *testing.T) {
+	// Arrange
+	estimates := &MockEstimateRepository{}
+	placedAt := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	record := &RecordEstimateHandler{
+		Estimator: &domain.Estimator{Cutoff: 12 * time.Hour, Transit: domain.TransitTable{"standard": {domain.AnyCountry: {MinDays: 2, MaxDays: 3}}}},
+		Estimates: estimates,
+	}
+	deliver := &RecordDeliveryHandler{Estimates: estimates, Now: func() time.Time { return placedAt.AddDate(0, 0, 4) }}
+
+	// Act
+	e, err := record.Handle(context.Background(), RecordEstimateCommand{OrderID: 42, Method: "standard", Country: "DE", PlacedAt: placedAt})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	err = deliver.Handle(context.Background(), RecordDeliveryCommand{OrderID: 42})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := time.Date(2024, 6, 6, 0, 0, 0, 0, time.UTC); !e.Latest.Equal(want) {
+		t.Errorf("Expected delivery by %s, got %s", want, e.Latest)
+	}
+	if stored, _ := estimates.GetByOrderID(context.Background(), 42); stored.Outcome() != domain.OutcomeLate {
+		t.Errorf("Expected a late delivery, got %q", stored.Outcome())
+	}
+	if err := deliver.Handle(context.Background(), RecordDeliveryCommand{OrderID: 42}); !errors.Is(err, domain.ErrAlreadyDelivered) {
+		t.Errorf("Expected ErrAlreadyDelivered, got %v", err)
+	}
+	if err := deliver.Handle(context.Background(), RecordDeliveryCommand{OrderID: 7}); !errors.Is(err, domain.ErrEstimateNotFound) {
+		t.Errorf("Expected ErrEstimateNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// RecordEstimateCommand stores the delivery window promised for a placed order
+type RecordEstimateCommand struct {
+	OrderID  int64     `validate:"required,gt=0"`
+	Method   string    `validate:"required"`
+	Country  string    `validate:"required"`
+	PlacedAt time.Time `validate:"required"`
+	// Coordinates of the shipping address, if it has them, pick the warehouse nearest to it
+	Coordinates *geo.Point
+}
+
+type RecordEstimateHandler struct {
+	Estimator *domain.Estimator
+	Estimates domain.EstimateRepository
+}
+
+func (h *RecordEstimateHandler) Handle(ctx context.Context, cmd RecordEstimateCommand) (*domain.Estimate, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	w, err := h.Estimator.Estimate(ctx, cmd.PlacedAt, cmd.Method, domain.Destination{Country: cmd.Country, Point: cmd.Coordinates})
+	if err != nil {
+		return nil, err
+	}
+
+	e := domain.NewEstimate(cmd.OrderID, cmd.Method, cmd.Country, cmd.PlacedAt, w)
+	if err := h.Estimates.Create(ctx, e); err != nil {
+		return nil, fmt.Errorf("record delivery estimate of order %d: %w", cmd.OrderID, err)
+	}
+	return e, nil
+}
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrEstimateNotFound = errors.New("delivery estimate not found")
+	ErrAlreadyDelivered = errors.New("order delivery is already recorded")
+)
+
+// Outcome compares an actual delivery with its estimated window
+type Outcome string
+
+const (
+	OutcomeEarly  Outcome = "EARLY"
+	OutcomeOnTime Outcome = "ON_TIME"
+	OutcomeLate   Outcome = "LATE"
+)
+
+// Estimate is the delivery window promised for an order when it was placed. Recording the actual
+// delivery next to it measures how accurate the estimates are.
+type Estimate struct {
+	ID int64 `gorm:"primaryKey"`
+	// TenantID is the tenant of the order estimated; see tenant.Plugin
+	TenantID string    `gorm:"type:varchar(64);not null;default:'default';index"`
+	OrderID  int64     `gorm:"not null;uniqueIndex"`
+	Method   string    `gorm:"type:varchar(20);not null"`
+	Country  string    `gorm:"type:varchar(2);not null"`
+	PlacedAt time.Time `gorm:"not null"`
+	Earliest time.Time `gorm:"not null"`
+	Latest   time.Time `gorm:"not null"`
+	// TimeZone is the zone of the warehouse the window days start in
+	TimeZone    string     `gorm:"type:varchar(64);not null"`
+	DeliveredAt *time.Time `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (Estimate) TableName() string {
+	return "delivery_estimates"
+}
+
+// NewEstimate records the window estimated for an order
+func NewEstimate(orderID int64, method, country string, placedAt time.Time, w Window) *Estimate {
+	return &Estimate{
+		OrderID:  orderID,
+		Method:   method,
+		Country:  country,
+		PlacedAt: placedAt,
+		Earliest: w.Earliest,
+		Latest:   w.Latest,
+		TimeZone: w.Earliest.Location().String(),
+	}
+}
+
+// Window returns the estimated delivery window in the time zone it was estimated in
+func (e *Estimate) Window() Window {
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return Window{Earliest: e.Earliest.In(loc), Latest: e.Latest.In(loc)}
+}
+
+// RecordDelivery stores when the order actually arrived
+func (e *Estimate) RecordDelivery(at time.Time) error {
+	if e.DeliveredAt != nil {
+		return ErrAlreadyDelivered
+	}
+	e.DeliveredAt = &at
+	return nil
+}
+
+// Outcome reports whether the order arrived before, within or after its window; it is empty until delivered
+func (e *Estimate) Outcome() Outcome {
+	switch days := e.ErrorDays(); {
+	case e.DeliveredAt == nil:
+		return ""
+	case days < 0:
+		return OutcomeEarly
+	case days > 0:
+		return OutcomeLate
+	default:
+		return OutcomeOnTime
+	}
+}
+
+// ErrorDays is how many days the delivery fell outside the window, counting a partial day as a
+// whole one: negative when early, positive when late and zero within the window or before delivery
+func (e *Estimate) ErrorDays() int {
+	if e.DeliveredAt == nil {
+		return 0
+	}
+	// The window closes at the end of its latest day
+	end := e.Latest.Add(24 * time.Hour)
+	switch {
+	case e.DeliveredAt.Before(e.Earliest):
+		return -int(math.Ceil(e.Earliest.Sub(*e.DeliveredAt).Hours() / 24))
+	case !e.DeliveredAt.Before(end):
+		return int(e.DeliveredAt.Sub(end).Hours()/24) + 1
+	}
+	return 0
+}
+
+// AccuracyReport summarizes how the deliveries of a period compare with their estimates
+type AccuracyReport struct {
+	Delivered int64
+	Early     int64
+	OnTime    int64
+	Late      int64
+	// MeanErrorDays is the mean distance in days of the deliveries from their window
+	MeanErrorDays float64
+}
+
+// OnTimeRate is the share of deliveries that arrived within their window
+func (r AccuracyReport) OnTimeRate() float64 {
+	if r.Delivered == 0 {
+		return 0
+	}
+	return float64(r.OnTime) / float64(r.Delivered)
+}
+
+// Accuracy compares delivered estimates with their actual delivery
+func Accuracy(estimates []Estimate) AccuracyReport {
+	var r AccuracyReport
+	var errorDays int
+	for i := range estimates {
+		e := &estimates[i]
+		switch e.Outcome() {
+		case OutcomeEarly:
+			r.Early++
+		case OutcomeOnTime:
+			r.OnTime++
+		case OutcomeLate:
+			r.Late++
+		default:
+			continue
+		}
+		r.Delivered++
+		if days := e.ErrorDays(); days < 0 {
+			errorDays -= days
+		} else {
+			errorDays += days
+		}
+	}
+	if r.Delivered > 0 {
+		r.MeanErrorDays = float64(errorDays) / float64(r.Delivered)
+	}
+	return r
+}
+
+type EstimateRepository interface {
+	Create(ctx context.Context, e *Estimate) error
+	Update(ctx context.Context, e *Estimate) error
+	GetByOrderID(ctx context.Context, orderID int64) (*Estimate, error)
+	// ListDelivered returns the estimates of the orders delivered in [from, to)
+	ListDelivered(ctx context.Context, from, to time.Time) ([]Estimate, error)
+}
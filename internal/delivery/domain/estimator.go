@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+)
+
+// ErrNoTransitTime is returned for destinations a shipping method has no transit time to
+var ErrNoTransitTime = errors.New("no transit time for the shipping method and destination")
+
+// AnyCountry is the transit table entry of a method for countries without their own entry
+const AnyCountry = "*"
+
+// TransitTime is how many business days a carrier takes from pick-up to delivery
+type TransitTime struct {
+	MinDays int
+	MaxDays int
+}
+
+// TransitTable holds the transit times of each shipping method by ISO destination country
+type TransitTable map[string]map[string]TransitTime
+
+// Lookup returns the transit time of a method to a country, falling back to its AnyCountry entry
+func (t TransitTable) Lookup(method, country string) (TransitTime, error) {
+	countries := t[method]
+	if transit, ok := countries[strings.ToUpper(country)]; ok {
+		return transit, nil
+	}
+	if transit, ok := countries[AnyCountry]; ok {
+		return transit, nil
+	}
+	return TransitTime{}, fmt.Errorf("%w: %s to %q", ErrNoTransitTime, method, country)
+}
+
+// ParseTransitTimes parses the transit times of one method, written as comma-separated
+// country=min-max entries such as "*=3-5,DE=1-2". A single number is a fixed transit time.
+func ParseTransitTimes(spec string) (map[string]TransitTime, error) {
+	times := map[string]TransitTime{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		country, days, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("transit time %q: expected country=min-max", entry)
+		}
+		minDays, maxDays, isRange := strings.Cut(days, "-")
+		if !isRange {
+			maxDays = minDays
+		}
+		transit := TransitTime{}
+		var err error
+		if transit.MinDays, err = strconv.Atoi(strings.TrimSpace(minDays)); err != nil {
+			return nil, fmt.Errorf("transit time %q: %w", entry, err)
+		}
+		if transit.MaxDays, err = strconv.Atoi(strings.TrimSpace(maxDays)); err != nil {
+			return nil, fmt.Errorf("transit time %q: %w", entry, err)
+		}
+		if transit.MinDays < 0 || transit.MaxDays < transit.MinDays {
+			return nil, fmt.Errorf("transit time %q: days must satisfy 0 <= min <= max", entry)
+		}
+		times[strings.ToUpper(strings.TrimSpace(country))] = transit
+	}
+	return times, nil
+}
+
+// Window is the range of days an order is expected to arrive in; both days are midnight in the
+// time zone of the warehouse
+type Window struct {
+	Earliest time.Time
+	Latest   time.Time
+}
+
+// Destination is where an order ships to; Point is nil for addresses without coordinates
+type Destination struct {
+	Country string
+	Point   *geo.Point
+}
+
+// Estimator predicts delivery windows from the processing time of the warehouse and the transit
+// times of the carriers. Warehouses and carriers work Monday to Friday.
+type Estimator struct {
+	// ProcessingDays is how many business days the warehouse takes to hand an order to the carrier
+	ProcessingDays int
+	// Cutoff is the time of day orders must be placed by to start processing that day
+	Cutoff time.Duration
+	// Location is the time zone of the warehouse shipping orders without a nearest warehouse;
+	// nil means UTC
+	Location *time.Location
+	Transit  TransitTable
+	// Warehouses finds the warehouse nearest to destinations with coordinates, whose time zone
+	// the days are counted in; nil ships every order from Location
+	Warehouses WarehouseRepository
+}
+
+// Estimate returns the delivery window of an order placed at placedAt
+func (e *Estimator) Estimate(ctx context.Context, placedAt time.Time, method string, to Destination) (Window, error) {
+	transit, err := e.Transit.Lookup(method, to.Country)
+	if err != nil {
+		return Window{}, err
+	}
+
+	loc, err := e.location(ctx, to)
+	if err != nil {
+		return Window{}, err
+	}
+	local := placedAt.In(loc)
+	start := midnight(local)
+	// Orders placed after the cutoff or outside business days start processing the next business day
+	if !isBusinessDay(start) || local.Sub(start) >= e.Cutoff {
+		start = addBusinessDays(start, 1)
+	}
+
+	shipped := addBusinessDays(start, e.ProcessingDays)
+	return Window{
+		Earliest: addBusinessDays(shipped, transit.MinDays),
+		Latest:   addBusinessDays(shipped, transit.MaxDays),
+	}, nil
+}
+
+// location returns the time zone of the warehouse shipping to a destination
+func (e *Estimator) location(ctx context.Context, to Destination) (*time.Location, error) {
+	if e.Warehouses != nil && to.Point != nil {
+		w, err := e.Warehouses.NearestWarehouse(ctx, *to.Point)
+		if err == nil {
+			return w.Location()
+		}
+		if !errors.Is(err, ErrNoWarehouse) {
+			return nil, fmt.Errorf("find nearest warehouse: %w", err)
+		}
+	}
+	if e.Location == nil {
+		return time.UTC, nil
+	}
+	return e.Location, nil
+}
+
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func isBusinessDay(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// addBusinessDays moves day forward by n business days, skipping weekends
+func addBusinessDays(day time.Time, n int) time.Time {
+	for n > 0 {
+		day = day.AddDate(0, 0, 1)
+		if isBusinessDay(day) {
+			n--
+		}
+	}
+	return day
+}
@@ -0,0 +1,154 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEstimator(t *testing.T) *domain.Estimator {
+	standard, err := domain.ParseTransitTimes("*=3-5, de=1-2")
+	require.NoError(t, err)
+	express, err := domain.ParseTransitTimes("*=1")
+	require.NoError(t, err)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	return &domain.Estimator{
+		ProcessingDays: 1,
+		Cutoff:         14 * time.Hour,
+		Location:       berlin,
+		Transit:        domain.TransitTable{"standard": standard, "express": express},
+	}
+}
+
+func day(t *testing.T, value string) time.Time {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	d, err := time.ParseInLocation("2006-01-02", value, berlin)
+	require.NoError(t, err)
+	return d
+}
+
+func TestEstimator_Estimate(t *testing.T) {
+	e := newEstimator(t)
+
+	tests := []struct {
+		name     string
+		placedAt time.Time
+		method   string
+		country  string
+		earliest string
+		latest   string
+	}{
+		// Monday 2024-06-03 13:00 in Berlin is before the cutoff, so processing ends Tuesday
+		{name: "before cutoff", placedAt: time.Date(2024, 6, 3, 11, 0, 0, 0, time.UTC), method: "standard", country: "DE", earliest: "2024-06-05", latest: "2024-06-06"},
+		{name: "after cutoff", placedAt: time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC), method: "standard", country: "de", earliest: "2024-06-06", latest: "2024-06-07"},
+		{name: "weekend", placedAt: time.Date(2024, 6, 8, 9, 0, 0, 0, time.UTC), method: "standard", country: "FR", earliest: "2024-06-14", latest: "2024-06-18"},
+		{name: "fixed transit", placedAt: time.Date(2024, 6, 6, 9, 0, 0, 0, time.UTC), method: "express", country: "US", earliest: "2024-06-10", latest: "2024-06-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := e.Estimate(context.Background(), tt.placedAt, tt.method, domain.Destination{Country: tt.country})
+			require.NoError(t, err)
+			assert.Equal(t, day(t, tt.earliest), w.Earliest)
+			assert.Equal(t, day(t, tt.latest), w.Latest)
+		})
+	}
+
+	_, err := e.Estimate(context.Background(), time.Now(), "freight", domain.Destination{Country: "DE"})
+	assert.True(t, errors.Is(err, domain.ErrNoTransitTime))
+}
+
+// nearestWarehouse finds the nearest of its warehouses by haversine distance
+type nearestWarehouse []domain.Warehouse
+
+func (n nearestWarehouse) Save(context.Context, *domain.Warehouse) error { return nil }
+
+func (n nearestWarehouse) NearestWarehouse(_ context.Context, to geo.Point) (*domain.Warehouse, error) {
+	points := make([]geo.Point, len(n))
+	for i := range n {
+		points[i] = n[i].Point()
+	}
+	if i := geo.Nearest(to, points); i >= 0 {
+		return &n[i], nil
+	}
+	return nil, domain.ErrNoWarehouse
+}
+
+func TestEstimator_Estimate_ShipsFromTheNearestWarehouse(t *testing.T) {
+	e := newEstimator(t)
+	e.Warehouses = nearestWarehouse{
+		{Code: "BER", Latitude: 52.52, Longitude: 13.40, TimeZone: "Europe/Berlin"},
+		{Code: "NYC", Latitude: 40.71, Longitude: -74.01, TimeZone: "America/New_York"},
+	}
+	ctx := context.Background()
+	// Monday 16:00 in Berlin is past the cutoff, 10:00 in New York is not
+	placedAt := time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)
+	boston := geo.Point{Lat: 42.36, Lng: -71.06}
+
+	w, err := e.Estimate(ctx, placedAt, "express", domain.Destination{Country: "US", Point: &boston})
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", w.Earliest.Location().String())
+	assert.Equal(t, "2024-06-05", w.Earliest.Format("2006-01-02"), "New York ships it Tuesday")
+
+	w, err = e.Estimate(ctx, placedAt, "express", domain.Destination{Country: "US"})
+	require.NoError(t, err)
+	assert.Equal(t, day(t, "2024-06-06"), w.Earliest, "without coordinates Berlin ships it, a day later after its cutoff")
+
+	e.Warehouses = nearestWarehouse{}
+	w, err = e.Estimate(ctx, placedAt, "express", domain.Destination{Country: "US", Point: &boston})
+	require.NoError(t, err)
+	assert.Equal(t, day(t, "2024-06-06"), w.Earliest, "without warehouses Location ships it")
+}
+
+func TestParseWarehouses(t *testing.T) {
+	warehouses, err := domain.ParseWarehouses(" ber=52.52,13.40,Europe/Berlin; NYC = 40.71 , -74.01 , America/New_York ;")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Warehouse{
+		{Code: "BER", Latitude: 52.52, Longitude: 13.40, TimeZone: "Europe/Berlin"},
+		{Code: "NYC", Latitude: 40.71, Longitude: -74.01, TimeZone: "America/New_York"},
+	}, warehouses)
+
+	for _, spec := range []string{"BER", "BER=52.52,13.40", "BER=x,13.40,UTC", "BER=95,13.40,UTC", "BER=52.52,13.40,Mars/Olympus"} {
+		_, err := domain.ParseWarehouses(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestParseTransitTimes_Invalid(t *testing.T) {
+	for _, spec := range []string{"DE", "DE=x", "DE=3-1", "DE=-1"} {
+		_, err := domain.ParseTransitTimes(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	w := domain.Window{Earliest: day(t, "2024-06-05"), Latest: day(t, "2024-06-06")}
+	delivered := func(at time.Time) domain.Estimate {
+		e := domain.NewEstimate(1, "standard", "DE", day(t, "2024-06-03"), w)
+		require.NoError(t, e.RecordDelivery(at))
+		return *e
+	}
+
+	early := delivered(day(t, "2024-06-04").Add(15 * time.Hour))
+	onTime := delivered(day(t, "2024-06-06").Add(23 * time.Hour))
+	late := delivered(day(t, "2024-06-09").Add(time.Hour))
+	pending := *domain.NewEstimate(2, "standard", "DE", day(t, "2024-06-03"), w)
+
+	assert.Equal(t, domain.OutcomeEarly, early.Outcome())
+	assert.Equal(t, -1, early.ErrorDays())
+	assert.Equal(t, domain.OutcomeOnTime, onTime.Outcome())
+	assert.Equal(t, 3, late.ErrorDays())
+	assert.ErrorIs(t, late.RecordDelivery(time.Now()), domain.ErrAlreadyDelivered)
+
+	report := domain.Accuracy([]domain.Estimate{early, onTime, late, pending})
+	assert.Equal(t, domain.AccuracyReport{Delivered: 3, Early: 1, OnTime: 1, Late: 1, MeanErrorDays: 4.0 / 3}, report)
+	assert.InDelta(t, 1.0/3, report.OnTimeRate(), 1e-9)
+}
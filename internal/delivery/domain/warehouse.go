@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+)
+
+// ErrNoWarehouse is returned when no warehouse is configured
+var ErrNoWarehouse = errors.New("no warehouse")
+
+// Warehouse ships orders; the one nearest to the destination of an order ships it
+type Warehouse struct {
+	ID        int64   `gorm:"primaryKey"`
+	Code      string  `gorm:"type:varchar(32);not null;uniqueIndex"`
+	Latitude  float64 `gorm:"not null"`
+	Longitude float64 `gorm:"not null"`
+	// TimeZone is the IANA time zone of the warehouse; its cutoff and business days are in it
+	TimeZone  string `gorm:"type:varchar(64);not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (w *Warehouse) Point() geo.Point {
+	return geo.Point{Lat: w.Latitude, Lng: w.Longitude}
+}
+
+// Location returns the time zone of the warehouse
+func (w *Warehouse) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(w.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("time zone of warehouse %s: %w", w.Code, err)
+	}
+	return loc, nil
+}
+
+type WarehouseRepository interface {
+	// Save creates the warehouse, or updates the location of the one with its code
+	Save(ctx context.Context, w *Warehouse) error
+	// NearestWarehouse returns the warehouse closest to a point, or ErrNoWarehouse when there are none
+	NearestWarehouse(ctx context.Context, to geo.Point) (*Warehouse, error)
+}
+
+// ParseWarehouses parses warehouses written as semicolon-separated code=lat,lng,zone entries such
+// as "BER=52.52,13.40,Europe/Berlin;NYC=40.71,-74.01,America/New_York"
+func ParseWarehouses(spec string) ([]Warehouse, error) {
+	var warehouses []Warehouse
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		code, location, ok := strings.Cut(entry, "=")
+		fields := strings.Split(location, ",")
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("warehouse %q: expected code=lat,lng,zone", entry)
+		}
+		lat, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("warehouse %q: %w", entry, err)
+		}
+		lng, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("warehouse %q: %w", entry, err)
+		}
+		p, err := geo.NewPoint(lat, lng)
+		if err != nil {
+			return nil, fmt.Errorf("warehouse %q: %w", entry, err)
+		}
+		w := Warehouse{Code: strings.ToUpper(strings.TrimSpace(code)), Latitude: p.Lat, Longitude: p.Lng, TimeZone: strings.TrimSpace(fields[2])}
+		if _, err := w.Location(); err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, w)
+	}
+	return warehouses, nil
+}
@@ -0,0 +1,131 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+const (
+	dayLayout = "2006-01-02"
+	// defaultReportDays is the period reported when from is not given
+	defaultReportDays = 30
+)
+
+// AccuracyResponse compares the deliveries of a shipping method, or of all methods, with their estimates
+type AccuracyResponse struct {
+	Method        string  `json:"method,omitempty"`
+	Delivered     int64   `json:"delivered"`
+	Early         int64   `json:"early"`
+	OnTime        int64   `json:"on_time"`
+	Late          int64   `json:"late"`
+	OnTimeRate    float64 `json:"on_time_rate"`
+	MeanErrorDays float64 `json:"mean_error_days"`
+}
+
+// AccuracyReportResponse reports the accuracy of the estimates of the orders delivered in a period
+type AccuracyReportResponse struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	Total   AccuracyResponse   `json:"total"`
+	Methods []AccuracyResponse `json:"methods"`
+}
+
+// HTTPServer exposes delivery estimate reporting over HTTP
+type HTTPServer struct {
+	Estimates domain.EstimateRepository
+	Now       func() time.Time
+
+	// Auth restricts the accuracy report to staff; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the delivery endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/delivery/accuracy",
+		Summary:  "Compare the delivery estimates of orders delivered between from and to (YYYY-MM-DD, default the last 30 days) with the actual deliveries",
+		Tags:     []string{"delivery"},
+		Response: AccuracyReportResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionDeliveryReport, s.accuracyReport),
+	})
+}
+
+func (s *HTTPServer) accuracyReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := s.reportParams(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	// to is inclusive, so report up to the start of the next day
+	estimates, err := s.Estimates.ListDelivered(r.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	byMethod := map[string][]domain.Estimate{}
+	for _, e := range estimates {
+		byMethod[e.Method] = append(byMethod[e.Method], e)
+	}
+	resp := AccuracyReportResponse{
+		From:    from.Format(dayLayout),
+		To:      to.Format(dayLayout),
+		Total:   toAccuracyResponse("", domain.Accuracy(estimates)),
+		Methods: make([]AccuracyResponse, 0, len(byMethod)),
+	}
+	for method, estimates := range byMethod {
+		resp.Methods = append(resp.Methods, toAccuracyResponse(method, domain.Accuracy(estimates)))
+	}
+	sort.Slice(resp.Methods, func(i, j int) bool { return resp.Methods[i].Method < resp.Methods[j].Method })
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// reportParams parses the from and to dates, defaulting to the last 30 days
+func (s *HTTPServer) reportParams(r *http.Request) (time.Time, time.Time, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	today := now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -defaultReportDays+1), today
+
+	var errs validation.Errors
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		day, err := time.Parse(dayLayout, value)
+		errs.Check(err == nil, "from", fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", value))
+		from = day
+	}
+	if value := query.Get("to"); value != "" {
+		day, err := time.Parse(dayLayout, value)
+		errs.Check(err == nil, "to", fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", value))
+		to = day
+	}
+	if err := errs.Err(); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	errs.Check(!to.Before(from), "to", "must not be before from")
+	return from, to, errs.Err()
+}
+
+func toAccuracyResponse(method string, r domain.AccuracyReport) AccuracyResponse {
+	return AccuracyResponse{
+		Method:        method,
+		Delivered:     r.Delivered,
+		Early:         r.Early,
+		OnTime:        r.OnTime,
+		Late:          r.Late,
+		OnTimeRate:    r.OnTimeRate(),
+		MeanErrorDays: r.MeanErrorDays,
+	}
+}
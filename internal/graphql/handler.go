@@ -0,0 +1,83 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// queryCacheSize bounds the number of parsed queries kept between requests
+const queryCacheSize = 1000
+
+// NewHandler serves the GraphQL API over GET (queries only) and POST, with per-request loaders
+func NewHandler(r *Resolver) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: r}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](queryCacheSize))
+	srv.Use(extension.Introspection{})
+	srv.SetErrorPresenter(presentError)
+
+	return LoadersMiddleware(r.UserRepo, r.ProductRepo, r.Masker, srv)
+}
+
+// presentError adds the error code of the REST API to the error extensions, e.g. {"code": "not_found"},
+// and hides the message of unexpected errors
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	// Errors raised while parsing and validating the query have no cause to map
+	if errors.As(err, new(*gqlerror.Error)) && errors.Unwrap(err) == nil {
+		return gqlErr
+	}
+
+	var fieldErrs validation.Errors
+	code := ""
+	switch {
+	case errors.As(err, &fieldErrs):
+		code = "validation_failed"
+		gqlErr.Message = "validation failed"
+		gqlErr.Extensions = map[string]any{"fields": fieldErrs}
+	case errors.Is(err, auth.ErrUnauthenticated):
+		code = "unauthorized"
+	case errors.Is(err, auth.ErrForbidden), errors.Is(err, userDomain.ErrUserDeactivated):
+		code = "forbidden"
+	case errors.Is(err, persistence.ErrNotFound), errors.Is(err, userDomain.ErrUserNotFound), errors.Is(err, productDomain.ErrProductNotFound):
+		code = "not_found"
+	case errors.Is(err, productDomain.ErrInsufficientStock), errors.Is(err, orderDomain.ErrInvalidTransition),
+		errors.Is(err, persistence.ErrDuplicateKey), errors.Is(err, persistence.ErrForeignKeyViolation):
+		code = "conflict"
+	case errors.Is(err, paymentDomain.ErrPaymentDeclined):
+		code = paymentDomain.ErrorCodePaymentDeclined
+	case errors.Is(err, quotaDomain.ErrQuotaExceeded):
+		code = quotaDomain.ErrorCodeQuotaExceeded
+	case errors.Is(err, orderDomain.ErrOrderRejected):
+		code = orderDomain.ErrorCodeOrderRejected
+	default:
+		log.Printf("internal error: %v", err)
+		code = "internal_error"
+		gqlErr.Message = http.StatusText(http.StatusInternalServerError)
+	}
+
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = make(map[string]any, 1)
+	}
+	gqlErr.Extensions["code"] = code
+	return gqlErr
+}
@@ -0,0 +1,266 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/graphql"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderRepo struct {
+	orderDomain.OrderRepository
+	orders map[string]*orderDomain.Order
+}
+
+func (r *orderRepo) GetByPublicID(ctx context.Context, publicID string) (*orderDomain.Order, error) {
+	if o, ok := r.orders[publicID]; ok {
+		return o, nil
+	}
+	return nil, persistence.ErrNotFound
+}
+
+// userRepo records every batch it is asked for
+type userRepo struct {
+	userDomain.UserRepository
+	mu      sync.Mutex
+	batches [][]int64
+}
+
+func (r *userRepo) GetByIDs(ctx context.Context, ids []int64) ([]userDomain.User, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, ids)
+	r.mu.Unlock()
+	users := make([]userDomain.User, len(ids))
+	for i, id := range ids {
+		users[i] = userDomain.User{ID: id, PublicID: fmt.Sprintf("usr_%d", id), Email: "customer@example.com", Active: true}
+	}
+	return users, nil
+}
+
+func (r *userRepo) GetByPublicID(ctx context.Context, publicID string) (*userDomain.User, error) {
+	var id int64
+	if _, err := fmt.Sscanf(publicID, "usr_%d", &id); err != nil {
+		return nil, persistence.ErrNotFound
+	}
+	return &userDomain.User{ID: id, PublicID: publicID, Active: true}, nil
+}
+
+type productRepo struct {
+	productDomain.ProductRepository
+	filters []productDomain.ProductFilter
+}
+
+func (r *productRepo) GetByIDs(ctx context.Context, ids []int64) ([]productDomain.Product, error) {
+	products := make([]productDomain.Product, len(ids))
+	for i, id := range ids {
+		products[i] = productDomain.Product{ID: id, PublicID: fmt.Sprintf("prd_%d", id), Name: "Widget", Stock: 3}
+	}
+	return products, nil
+}
+
+func (r *productRepo) GetByPublicID(ctx context.Context, publicID string) (*productDomain.Product, error) {
+	var id int64
+	if _, err := fmt.Sscanf(publicID, "prd_%d", &id); err != nil {
+		return nil, persistence.ErrNotFound
+	}
+	return &productDomain.Product{ID: id, PublicID: publicID, Name: "Widget", Stock: 3}, nil
+}
+
+func (r *productRepo) List(ctx context.Context, filter productDomain.ProductFilter) ([]productDomain.Product, error) {
+	r.filters = append(r.filters, filter)
+	return []productDomain.Product{{ID: 1, PublicID: "prd_1", Name: "Widget", Stock: 3, PriceAmount: 1999, PriceCurrency: "EUR"}}, nil
+}
+
+type addressRepo struct {
+	userDomain.AddressRepository
+}
+
+func (r *addressRepo) GetByPublicID(ctx context.Context, publicID string) (*userDomain.Address, error) {
+	var id int64
+	if _, err := fmt.Sscanf(publicID, "adr_%d", &id); err != nil {
+		return nil, persistence.ErrNotFound
+	}
+	return &userDomain.Address{ID: id, PublicID: publicID, UserID: 7}, nil
+}
+
+type placeOrderFunc func(ctx context.Context, cmd command.PlaceOrderCommand) (*orderDomain.Order, error)
+
+func (f placeOrderFunc) Handle(ctx context.Context, cmd command.PlaceOrderCommand) (*orderDomain.Order, error) {
+	return f(ctx, cmd)
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execute(t *testing.T, h http.Handler, query string, ctx context.Context) response {
+	body, err := json.Marshal(map[string]string{"query": query})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func newResolver() (*graphql.Resolver, *userRepo, *productRepo) {
+	users, products := &userRepo{}, &productRepo{}
+	return &graphql.Resolver{
+		OrderRepo: &orderRepo{orders: map[string]*orderDomain.Order{
+			"ord_1": {ID: 1, PublicID: "ord_1", UserID: 7, ProductID: 3, Quantity: 2, Status: orderDomain.StatusConfirmed},
+			"ord_2": {ID: 2, PublicID: "ord_2", UserID: 8, ProductID: 3, Quantity: 1, Status: orderDomain.StatusPending},
+		}},
+		ProductRepo:  products,
+		ListProducts: &productQuery.ListProductsHandler{ProductRepo: products},
+		UserRepo:     users,
+		Addresses:    &addressRepo{},
+	}, users, products
+}
+
+func TestHandler_BatchesUsersOfSeveralOrders(t *testing.T) {
+	resolver, users, _ := newResolver()
+
+	resp := execute(t, graphql.NewHandler(resolver),
+		`{ a: order(id: "ord_1") { id quantity user { id } product { name } } b: order(id: "ord_2") { user { id } } }`,
+		context.Background())
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"id":"ord_1","quantity":2,"user":{"id":"usr_7"},"product":{"name":"Widget"}}`, string(resp.Data["a"]))
+	assert.JSONEq(t, `{"user":{"id":"usr_8"}}`, string(resp.Data["b"]))
+	require.Len(t, users.batches, 1)
+	assert.ElementsMatch(t, []int64{7, 8}, users.batches[0])
+}
+
+func TestHandler_OrderNotFound(t *testing.T) {
+	resolver, _, _ := newResolver()
+
+	resp := execute(t, graphql.NewHandler(resolver), `{ order(id: "ord_42") { id } }`, context.Background())
+
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "not_found", resp.Errors[0].Extensions["code"])
+}
+
+func TestHandler_OrderOfAnotherCustomer(t *testing.T) {
+	resolver, _, _ := newResolver()
+	resolver.Auth = staticAuthorizer{8: {userDomain.PermissionOrderReadOwn}}
+
+	resp := execute(t, graphql.NewHandler(resolver), `{ order(id: "ord_1") { id } }`, auth.WithUserID(context.Background(), 8))
+
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "forbidden", resp.Errors[0].Extensions["code"])
+}
+
+func TestHandler_MasksEmailsOfOtherUsers(t *testing.T) {
+	resolver, _, _ := newResolver()
+	resolver.Auth = staticAuthorizer{
+		7: {userDomain.PermissionOrderReadOwn},
+		9: {userDomain.PermissionOrderReadAny},
+		1: {userDomain.PermissionOrderReadAny, userDomain.PermissionPIIRead},
+	}
+	resolver.Masker = &dto.Masker{Kinds: []dto.MaskKind{dto.MaskEmail}, Auth: resolver.Auth, Unmask: userDomain.PermissionPIIRead}
+	h := graphql.NewHandler(resolver)
+
+	for caller, email := range map[int64]string{7: "customer@example.com", 9: "c***@example.com", 1: "customer@example.com"} {
+		resp := execute(t, h, `{ order(id: "ord_1") { user { email } } }`, auth.WithUserID(context.Background(), caller))
+		assert.Empty(t, resp.Errors)
+		assert.JSONEq(t, fmt.Sprintf(`{"user":{"email":%q}}`, email), string(resp.Data["order"]), "caller %d", caller)
+	}
+}
+
+func TestHandler_Products(t *testing.T) {
+	resolver, _, products := newResolver()
+	h := graphql.NewHandler(resolver)
+
+	resp := execute(t, h, `{ products(filter: {name: "Wid", inStock: true}, pagination: {offset: 20}) { id name stock price { amount currency } } }`, context.Background())
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `[{"id":"prd_1","name":"Widget","stock":3,"price":{"amount":1999,"currency":"EUR"}}]`, string(resp.Data["products"]))
+	assert.Equal(t, []productDomain.ProductFilter{{Name: "Wid", InStock: true, Offset: 20, Limit: 20}}, products.filters)
+
+	resp = execute(t, h, `{ products(pagination: {limit: 500}) { id } }`, context.Background())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "validation_failed", resp.Errors[0].Extensions["code"])
+}
+
+func TestHandler_PricesInRequestedCurrency(t *testing.T) {
+	resolver, _, _ := newResolver()
+	rates := money.ExchangeRates{Base: "EUR", Rates: map[string]*big.Rat{"USD": big.NewRat(5, 4)}}
+	resolver.Converter = exchange.NewConverter(rateSource(rates), time.Hour, money.RoundHalfUp)
+	h := graphql.NewHandler(resolver)
+
+	resp := execute(t, h, `{ products { price(currency: "usd") { amount currency } } }`, context.Background())
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `[{"price":{"amount":2499,"currency":"USD"}}]`, string(resp.Data["products"]))
+
+	resp = execute(t, h, `{ products { price(currency: "CHF") { amount } } }`, context.Background())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "validation_failed", resp.Errors[0].Extensions["code"])
+}
+
+type rateSource money.ExchangeRates
+
+func (s rateSource) Latest(context.Context) (money.ExchangeRates, error) {
+	return money.ExchangeRates(s), nil
+}
+
+func TestHandler_PlaceOrder(t *testing.T) {
+	resolver, _, _ := newResolver()
+	var placed command.PlaceOrderCommand
+	resolver.PlaceOrder = placeOrderFunc(func(ctx context.Context, cmd command.PlaceOrderCommand) (*orderDomain.Order, error) {
+		placed = cmd
+		return &orderDomain.Order{ID: 9, PublicID: "ord_9", UserID: cmd.UserID, ProductID: cmd.ProductID, Quantity: 1, Status: orderDomain.StatusConfirmed}, nil
+	})
+
+	resp := execute(t, graphql.NewHandler(resolver), `mutation {
+		placeOrder(input: {userId: "usr_7", productId: "prd_3", shippingAddressId: "adr_4", quantity: 1, payments: [{method: "card", amount: 1999, currency: "EUR"}]}) {
+			id status user { id }
+		}
+	}`, context.Background())
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"id":"ord_9","status":"CONFIRMED","user":{"id":"usr_7"}}`, string(resp.Data["placeOrder"]))
+	assert.Equal(t, int64(7), placed.UserID)
+	assert.Equal(t, int64(3), placed.ProductID)
+	assert.Equal(t, int64(4), placed.ShippingAddressID)
+	require.Len(t, placed.Payments, 1)
+	assert.Equal(t, int64(1999), placed.Payments[0].Amount.Amount)
+}
+
+type staticAuthorizer map[int64][]auth.Permission
+
+func (a staticAuthorizer) Can(ctx context.Context, userID int64, p auth.Permission) (bool, error) {
+	for _, granted := range a[userID] {
+		if granted == p {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,83 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/vikstrous/dataloadgen"
+)
+
+// loaderWait is how long a loader collects keys before fetching them in one query
+const loaderWait = 2 * time.Millisecond
+
+// Loaders batch the users and products resolved for the orders of one request,
+// so a query over n orders reads each table once instead of n times
+type Loaders struct {
+	Users    *dataloadgen.Loader[int64, *userDomain.User]
+	Products *dataloadgen.Loader[int64, *productDomain.Product]
+	// MaskPolicy resolves the masking of the caller once, however many users the query returns
+	MaskPolicy func() (dto.MaskPolicy, error)
+}
+
+// NewLoaders creates loaders for a single request; they cache every value they load
+func NewLoaders(users userDomain.UserRepository, products productDomain.ProductRepository) *Loaders {
+	return &Loaders{
+		Users: dataloadgen.NewMappedLoader(func(ctx context.Context, ids []int64) (map[int64]*userDomain.User, error) {
+			found, err := users.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int64]*userDomain.User, len(found))
+			for i := range found {
+				byID[found[i].ID] = &found[i]
+			}
+			return byID, nil
+		}, dataloadgen.WithWait(loaderWait)),
+		Products: dataloadgen.NewMappedLoader(func(ctx context.Context, ids []int64) (map[int64]*productDomain.Product, error) {
+			found, err := products.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int64]*productDomain.Product, len(found))
+			for i := range found {
+				byID[found[i].ID] = &found[i]
+			}
+			return byID, nil
+		}, dataloadgen.WithWait(loaderWait)),
+	}
+}
+
+type loadersKey struct{}
+
+// LoadersMiddleware binds fresh loaders to every request, so cached values never outlive it
+func LoadersMiddleware(users userDomain.UserRepository, products productDomain.ProductRepository, masker *dto.Masker, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loaders := NewLoaders(users, products)
+		loaders.MaskPolicy = sync.OnceValues(func() (dto.MaskPolicy, error) {
+			return masker.Policy(r.Context())
+		})
+		ctx := context.WithValue(r.Context(), loadersKey{}, loaders)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loadersFrom returns the loaders of the request
+func loadersFrom(ctx context.Context) *Loaders {
+	return ctx.Value(loadersKey{}).(*Loaders)
+}
+
+// loadError reports keys the repository did not return as persistence.ErrNotFound
+func loadError(kind string, id int64, err error) error {
+	if errors.Is(err, dataloadgen.ErrNotFound) {
+		return fmt.Errorf("%s %d: %w", kind, id, persistence.ErrNotFound)
+	}
+	return fmt.Errorf("load %s %d: %w", kind, id, err)
+}
@@ -0,0 +1,40 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphql
+
+type Mutation struct {
+}
+
+type Pagination struct {
+	Offset *int `json:"offset,omitempty"`
+	// At most 100
+	Limit *int `json:"limit,omitempty"`
+}
+
+// A payment to authorize; amount is in minor units of currency
+type PaymentInput struct {
+	Method   string `json:"method"`
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type PlaceOrderInput struct {
+	// The public IDs of the user and product
+	UserID    string `json:"userId"`
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+	// The public ID of an address in the address book of the user
+	ShippingAddressID string          `json:"shippingAddressId"`
+	Payments          []*PaymentInput `json:"payments,omitempty"`
+	// A coupon to take off the order
+	CouponCode *string `json:"couponCode,omitempty"`
+}
+
+type ProductFilter struct {
+	// Matches products whose name contains it
+	Name    *string `json:"name,omitempty"`
+	InStock *bool   `json:"inStock,omitempty"`
+}
+
+type Query struct {
+}
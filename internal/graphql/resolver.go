@@ -0,0 +1,87 @@
+package graphql
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// Resolver serves the GraphQL schema from the same repositories and commands as the REST API
+type Resolver struct {
+	PlaceOrder   decorator.CommandResultHandler[command.PlaceOrderCommand, *orderDomain.Order]
+	ListProducts decorator.QueryHandler[productQuery.ListProductsQuery, []productDomain.Product]
+	OrderRepo    orderDomain.OrderRepository
+	ProductRepo  productDomain.ProductRepository
+	UserRepo     userDomain.UserRepository
+	Addresses    userDomain.AddressRepository
+
+	// Auth applies the permissions of the REST API; nil disables access control
+	Auth auth.Authorizer
+	// Masker hides emails and phones like the REST API; nil shows them
+	Masker *dto.Masker
+	// Converter converts prices into the currency argument of price fields; nil serves them as stored
+	Converter money.CurrencyConverter
+}
+
+const (
+	defaultProductLimit = 20
+	maxProductLimit     = 100
+)
+
+// priced returns nil for the zero Money of unpriced products and orders
+func priced(m money.Money) *money.Money {
+	if m.Currency == "" {
+		return nil
+	}
+	return &m
+}
+
+// convert converts m into the optional currency argument of a price field
+func (r *Resolver) convert(ctx context.Context, m money.Money, currency *string) (*money.Money, error) {
+	to := strings.ToUpper(strings.TrimSpace(ptrValue(currency)))
+	converted, err := exchange.Convert(ctx, r.Converter, m, to)
+	if err != nil {
+		return nil, err
+	}
+	return priced(converted), nil
+}
+
+// ptrValue returns the value of an optional argument, or its zero value when it was omitted
+func ptrValue[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+// masked masks value, personal data of kind owned by ownerID, for callers who may not see it
+func masked(ctx context.Context, kind dto.MaskKind, value string, ownerID int64) (string, error) {
+	policy, err := loadersFrom(ctx).MaskPolicy()
+	if err != nil {
+		return "", err
+	}
+	return policy.Value(kind, value, ownerID), nil
+}
+
+// notFound replaces persistence.ErrNotFound with the domain error of the missing entity
+func notFound(err, domainErr error) error {
+	if errors.Is(err, persistence.ErrNotFound) {
+		return domainErr
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// Phone is the resolver for the phone field.
+func (r *addressResolver) Phone(ctx context.Context, obj *userDomain.Address) (string, error) {
+	return masked(ctx, dto.MaskPhone, string(obj.Phone), obj.UserID)
+}
+
+// Lines is the resolver for the lines field.
+func (r *addressResolver) Lines(ctx context.Context, obj *userDomain.Address) ([]string, error) {
+	return obj.Lines(""), nil
+}
+
+// PlaceOrder is the resolver for the placeOrder field.
+func (r *mutationResolver) PlaceOrder(ctx context.Context, input PlaceOrderInput) (*orderDomain.Order, error) {
+	u, err := r.UserRepo.GetByPublicID(ctx, input.UserID)
+	if err != nil {
+		return nil, notFound(err, userDomain.ErrUserNotFound)
+	}
+	p, err := r.ProductRepo.GetByPublicID(ctx, input.ProductID)
+	if err != nil {
+		return nil, notFound(err, productDomain.ErrProductNotFound)
+	}
+	// The command checks the address belongs to the user and reports a missing one the same way
+	a, err := r.Addresses.GetByPublicID(ctx, input.ShippingAddressID)
+	if err != nil {
+		if !errors.Is(err, persistence.ErrNotFound) {
+			return nil, err
+		}
+		var errs validation.Errors
+		errs.Add("shipping_address_id", "must be an address of the user")
+		return nil, errs
+	}
+
+	if err := auth.CheckOwned(ctx, r.Auth, userDomain.PermissionOrderCreateAny, userDomain.PermissionOrderCreateOwn, u.ID); err != nil {
+		return nil, err
+	}
+
+	cmd := command.PlaceOrderCommand{
+		UserID:            u.ID,
+		ProductID:         p.ID,
+		Quantity:          input.Quantity,
+		ShippingAddressID: a.ID,
+		CouponCode:        ptrValue(input.CouponCode),
+	}
+	var errs validation.Errors
+	for i, p := range input.Payments {
+		amount, err := money.New(int64(p.Amount), p.Currency)
+		if err != nil {
+			errs.Add(fmt.Sprintf("payments[%d].currency", i), err.Error())
+			continue
+		}
+		cmd.Payments = append(cmd.Payments, command.PaymentDetails{Method: p.Method, Amount: amount})
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.Resolver.PlaceOrder.Handle(ctx, cmd)
+}
+
+// Status is the resolver for the status field.
+func (r *orderResolver) Status(ctx context.Context, obj *orderDomain.Order) (string, error) {
+	return string(obj.Status), nil
+}
+
+// Quantity is the resolver for the quantity field.
+func (r *orderResolver) Quantity(ctx context.Context, obj *orderDomain.Order) (int, error) {
+	return obj.Quantity.Int(), nil
+}
+
+// UnitPrice is the resolver for the unitPrice field.
+func (r *orderResolver) UnitPrice(ctx context.Context, obj *orderDomain.Order, currency *string) (*money.Money, error) {
+	return r.convert(ctx, obj.UnitPrice, currency)
+}
+
+// Total is the resolver for the total field.
+func (r *orderResolver) Total(ctx context.Context, obj *orderDomain.Order, currency *string) (*money.Money, error) {
+	if obj.Totals.IsZero() {
+		unitPrice, err := r.convert(ctx, obj.UnitPrice, currency)
+		if unitPrice == nil || err != nil {
+			return nil, err
+		}
+		return priced(unitPrice.Mul(int64(obj.Quantity))), nil
+	}
+	totals, err := obj.Totals.Convert(func(m money.Money) (money.Money, error) {
+		converted, err := r.convert(ctx, m, currency)
+		if converted == nil {
+			return money.Money{}, err
+		}
+		return *converted, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return priced(totals.Total), nil
+}
+
+// User is the resolver for the user field.
+func (r *orderResolver) User(ctx context.Context, obj *orderDomain.Order) (*userDomain.User, error) {
+	user, err := loadersFrom(ctx).Users.Load(ctx, obj.UserID)
+	if err != nil {
+		return nil, loadError("user", obj.UserID, err)
+	}
+	return user, nil
+}
+
+// Product is the resolver for the product field.
+func (r *orderResolver) Product(ctx context.Context, obj *orderDomain.Order) (*productDomain.Product, error) {
+	product, err := loadersFrom(ctx).Products.Load(ctx, obj.ProductID)
+	if err != nil {
+		return nil, loadError("product", obj.ProductID, err)
+	}
+	return product, nil
+}
+
+// Price is the resolver for the price field.
+func (r *productResolver) Price(ctx context.Context, obj *productDomain.Product, currency *string) (*money.Money, error) {
+	return r.convert(ctx, obj.Price(), currency)
+}
+
+// Order is the resolver for the order field.
+func (r *queryResolver) Order(ctx context.Context, id string) (*orderDomain.Order, error) {
+	o, err := r.OrderRepo.GetByPublicID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckOwned(ctx, r.Auth, userDomain.PermissionOrderReadAny, userDomain.PermissionOrderReadOwn, o.UserID); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, filter *ProductFilter, pagination *Pagination) ([]*productDomain.Product, error) {
+	var query productDomain.ProductFilter
+	if filter != nil {
+		query.Name = ptrValue(filter.Name)
+		query.InStock = ptrValue(filter.InStock)
+	}
+	query.Limit = defaultProductLimit
+	if pagination != nil {
+		query.Offset = ptrValue(pagination.Offset)
+		if pagination.Limit != nil {
+			query.Limit = *pagination.Limit
+		}
+	}
+
+	var errs validation.Errors
+	errs.Check(query.Offset >= 0, "pagination.offset", "must not be negative")
+	errs.Check(query.Limit >= 1 && query.Limit <= maxProductLimit, "pagination.limit", fmt.Sprintf("must be between 1 and %d", maxProductLimit))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	products, err := r.ListProducts.Handle(ctx, productQuery.ListProductsQuery{Filter: query})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*productDomain.Product, len(products))
+	for i := range products {
+		result[i] = &products[i]
+	}
+	return result, nil
+}
+
+// Email is the resolver for the email field.
+func (r *userResolver) Email(ctx context.Context, obj *userDomain.User) (string, error) {
+	return masked(ctx, dto.MaskEmail, string(obj.Email), obj.ID)
+}
+
+// Phone is the resolver for the phone field.
+func (r *userResolver) Phone(ctx context.Context, obj *userDomain.User) (string, error) {
+	return masked(ctx, dto.MaskPhone, string(obj.Phone), obj.ID)
+}
+
+// Address returns AddressResolver implementation.
+func (r *Resolver) Address() AddressResolver { return &addressResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Order returns OrderResolver implementation.
+func (r *Resolver) Order() OrderResolver { return &orderResolver{r} }
+
+// Product returns ProductResolver implementation.
+func (r *Resolver) Product() ProductResolver { return &productResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type addressResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type orderResolver struct{ *Resolver }
+type productResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,35 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// GormAudience finds the recipients of a segment in the users table
+type GormAudience struct {
+	db *gorm.DB
+}
+
+func NewGormAudience(db *gorm.DB) *GormAudience {
+	return &GormAudience{db: db}
+}
+
+func (a *GormAudience) Recipients(ctx context.Context, s domain.Segment, afterUserID int64, limit int) ([]domain.Recipient, error) {
+	q := persistence.Conn(ctx, a.db).Table("users").
+		Select("users.id AS user_id, users.email, users.first_name, users.last_name").
+		Where("users.active = ? AND users.id > ?", true, afterUserID)
+	if s.Role != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE user_roles.user_id = users.id AND roles.name = ?)", s.Role)
+	}
+	if s.EmailDomain != "" {
+		// Emails are stored lower-cased, like the normalized domain
+		q = q.Where("users.email LIKE ?", "%@"+s.EmailDomain)
+	}
+
+	var recipients []domain.Recipient
+	err := q.Order("users.id").Limit(limit).Scan(&recipients).Error
+	return recipients, persistence.TranslateError(err)
+}
@@ -0,0 +1,141 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormCampaignRepository struct {
+	*persistence.GenericRepository[domain.Campaign, int64]
+	db *gorm.DB
+}
+
+func NewGormCampaignRepository(db *gorm.DB) domain.CampaignRepository {
+	return &GormCampaignRepository{GenericRepository: persistence.NewGenericRepository[domain.Campaign, int64](db), db: db}
+}
+
+func (r *GormCampaignRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Campaign, error) {
+	var c domain.Campaign
+	if err := persistence.Conn(ctx, r.db).Where("public_id = ?", publicID).First(&c).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &c, nil
+}
+
+// MarkDispatched only writes dispatched_at, so it never undoes a concurrent cancellation
+func (r *GormCampaignRepository) MarkDispatched(ctx context.Context, id int64, at time.Time) error {
+	err := persistence.Conn(ctx, r.db).Model(&domain.Campaign{}).Where("id = ?", id).Update("dispatched_at", at).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormCampaignRepository) Cancel(ctx context.Context, id int64, at time.Time) error {
+	result := persistence.Conn(ctx, r.db).Model(&domain.Campaign{}).
+		Where("id = ? AND status = ?", id, domain.CampaignSending).
+		Updates(map[string]interface{}{"status": domain.CampaignCancelled, "cancelled_at": at})
+	if result.Error != nil {
+		return persistence.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrCampaignFinished
+	}
+	return nil
+}
+
+// Complete is a single conditional update, so the sends finishing last at the same time complete the campaign once
+func (r *GormCampaignRepository) Complete(ctx context.Context, id int64, at time.Time) (bool, error) {
+	pending := persistence.Conn(ctx, r.db).Model(&domain.Delivery{}).Select("1").
+		Where("campaign_id = ? AND status = ?", id, domain.DeliveryPending)
+	result := persistence.Conn(ctx, r.db).Model(&domain.Campaign{}).
+		Where("id = ? AND status = ? AND dispatched_at IS NOT NULL", id, domain.CampaignSending).
+		Where("NOT EXISTS (?)", pending).
+		Updates(map[string]interface{}{"status": domain.CampaignCompleted, "completed_at": at})
+	if result.Error != nil {
+		return false, persistence.TranslateError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+type GormDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDeliveryRepository(db *gorm.DB) domain.DeliveryRepository {
+	return &GormDeliveryRepository{db: db}
+}
+
+// Add skips recipients with a delivery, so a retried dispatch keeps their tokens and send state
+func (r *GormDeliveryRepository) Add(ctx context.Context, deliveries []domain.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := persistence.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "campaign_id"}, {Name: "user_id"}},
+		DoNothing: true,
+	}).Create(&deliveries).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormDeliveryRepository) Get(ctx context.Context, campaignID, userID int64) (*domain.Delivery, error) {
+	var d domain.Delivery
+	err := persistence.Conn(ctx, r.db).Where("campaign_id = ? AND user_id = ?", campaignID, userID).First(&d).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &d, nil
+}
+
+func (r *GormDeliveryRepository) Update(ctx context.Context, d *domain.Delivery) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Save(d).Error)
+}
+
+func (r *GormDeliveryRepository) CancelPending(ctx context.Context, campaignID int64) (int64, error) {
+	result := persistence.Conn(ctx, r.db).Model(&domain.Delivery{}).
+		Where("campaign_id = ? AND status = ?", campaignID, domain.DeliveryPending).
+		Update("status", domain.DeliveryCancelled)
+	return result.RowsAffected, persistence.TranslateError(result.Error)
+}
+
+func (r *GormDeliveryRepository) RecordOpen(ctx context.Context, token string, at time.Time) error {
+	err := persistence.Conn(ctx, r.db).Model(&domain.Delivery{}).
+		Where("token = ? AND opened_at IS NULL", token).
+		Update("opened_at", at).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormDeliveryRepository) Stats(ctx context.Context, campaignID int64) (domain.CampaignStats, error) {
+	var rows []struct {
+		Status domain.DeliveryStatus
+		Count  int64
+		Opened int64
+	}
+	err := persistence.Conn(ctx, r.db).Model(&domain.Delivery{}).
+		Select("status, COUNT(*) AS count, COUNT(opened_at) AS opened").
+		Where("campaign_id = ?", campaignID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return domain.CampaignStats{}, persistence.TranslateError(err)
+	}
+
+	var stats domain.CampaignStats
+	for _, row := range rows {
+		stats.Recipients += row.Count
+		stats.Opened += row.Opened
+		switch row.Status {
+		case domain.DeliveryPending:
+			stats.Pending = row.Count
+		case domain.DeliverySent:
+			stats.Sent = row.Count
+		case domain.DeliveryFailed:
+			stats.Failed = row.Count
+		case domain.DeliveryCancelled:
+			stats.Cancelled = row.Count
+		}
+	}
+	return stats, nil
+}
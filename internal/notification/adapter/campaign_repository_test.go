@@ -0,0 +1,156 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	userAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCampaignDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&userDomain.User{}, &userDomain.Permission{}, &userDomain.Role{}, &userDomain.UserRole{},
+		&domain.Campaign{}, &domain.Delivery{}, &domain.SendSlot{},
+	))
+	return db
+}
+
+func createCampaign(t *testing.T, repo domain.CampaignRepository) *domain.Campaign {
+	c, err := domain.NewCampaign("Spring sale", "Hello {{.FirstName}}", "<p>Hi</p>", domain.Segment{}, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(context.Background(), c))
+	return c
+}
+
+func TestGormCampaignRepository_Complete(t *testing.T) {
+	db := setupCampaignDB(t)
+	campaigns := adapter.NewGormCampaignRepository(db)
+	deliveries := adapter.NewGormDeliveryRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	c := createCampaign(t, campaigns)
+
+	jane, err := domain.NewDelivery(c.ID, domain.Recipient{UserID: 1, Email: "jane@example.com"})
+	require.NoError(t, err)
+	john, err := domain.NewDelivery(c.ID, domain.Recipient{UserID: 2, Email: "john@example.com"})
+	require.NoError(t, err)
+	require.NoError(t, deliveries.Add(ctx, []domain.Delivery{jane, john}))
+
+	completed, err := campaigns.Complete(ctx, c.ID, now)
+	require.NoError(t, err)
+	assert.False(t, completed, "a campaign still dispatching is not completed")
+
+	require.NoError(t, campaigns.MarkDispatched(ctx, c.ID, now))
+	sent, err := deliveries.Get(ctx, c.ID, 1)
+	require.NoError(t, err)
+	sent.MarkSent(now)
+	require.NoError(t, deliveries.Update(ctx, sent))
+	completed, err = campaigns.Complete(ctx, c.ID, now)
+	require.NoError(t, err)
+	assert.False(t, completed, "a campaign with pending deliveries is not completed")
+
+	failed, err := deliveries.Get(ctx, c.ID, 2)
+	require.NoError(t, err)
+	failed.MarkFailed(assert.AnError, 1)
+	require.NoError(t, deliveries.Update(ctx, failed))
+	completed, err = campaigns.Complete(ctx, c.ID, now)
+	require.NoError(t, err)
+	assert.True(t, completed)
+
+	completed, err = campaigns.Complete(ctx, c.ID, now)
+	require.NoError(t, err)
+	assert.False(t, completed, "a campaign completes once")
+	assert.ErrorIs(t, campaigns.Cancel(ctx, c.ID, now), domain.ErrCampaignFinished)
+
+	stored, err := campaigns.GetByPublicID(ctx, c.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CampaignCompleted, stored.Status)
+	assert.NotNil(t, stored.CompletedAt)
+}
+
+func TestGormDeliveryRepository(t *testing.T) {
+	db := setupCampaignDB(t)
+	campaigns := adapter.NewGormCampaignRepository(db)
+	deliveries := adapter.NewGormDeliveryRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	c := createCampaign(t, campaigns)
+
+	var added []domain.Delivery
+	for userID := int64(1); userID <= 3; userID++ {
+		d, err := domain.NewDelivery(c.ID, domain.Recipient{UserID: userID, Email: "user@example.com"})
+		require.NoError(t, err)
+		added = append(added, d)
+	}
+	require.NoError(t, deliveries.Add(ctx, added))
+	retried, err := domain.NewDelivery(c.ID, domain.Recipient{UserID: 1, Email: "user@example.com"})
+	require.NoError(t, err)
+	require.NoError(t, deliveries.Add(ctx, []domain.Delivery{retried}), "adding a recipient again is a no-op")
+
+	first, err := deliveries.Get(ctx, c.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, added[0].Token, first.Token, "a retried dispatch keeps the token")
+	first.MarkSent(now)
+	require.NoError(t, deliveries.Update(ctx, first))
+
+	require.NoError(t, deliveries.RecordOpen(ctx, first.Token, now))
+	require.NoError(t, deliveries.RecordOpen(ctx, first.Token, now.Add(time.Hour)), "later opens are ignored")
+	require.NoError(t, deliveries.RecordOpen(ctx, "unknown", now))
+	opened, err := deliveries.Get(ctx, c.ID, 1)
+	require.NoError(t, err)
+	assert.WithinDuration(t, now, *opened.OpenedAt, time.Second)
+
+	require.NoError(t, campaigns.Cancel(ctx, c.ID, now))
+	cancelled, err := deliveries.CancelPending(ctx, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cancelled)
+
+	stats, err := deliveries.Stats(ctx, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CampaignStats{Recipients: 3, Sent: 1, Cancelled: 2, Opened: 1}, stats)
+}
+
+func TestGormAudience_Recipients(t *testing.T) {
+	db := setupCampaignDB(t)
+	users := userAdapter.NewGormUserRepository(db)
+	roles := userAdapter.NewGormRoleRepository(db)
+	ctx := context.Background()
+	require.NoError(t, roles.Seed(ctx, userDomain.DefaultRoles()))
+
+	var ids []int64
+	for _, email := range []string{"jane@example.com", "john@example.com", "ann@other.org", "inactive@example.com"} {
+		u := userDomain.MustNewUser(email)
+		u.Active = email != "inactive@example.com"
+		require.NoError(t, users.Save(ctx, u))
+		ids = append(ids, u.ID)
+	}
+	require.NoError(t, roles.Assign(ctx, ids[1], userDomain.RoleCustomer))
+	require.NoError(t, roles.Assign(ctx, ids[2], userDomain.RoleCustomer))
+	audience := adapter.NewGormAudience(db)
+
+	all, err := audience.Recipients(ctx, domain.Segment{}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Recipient{
+		{UserID: ids[0], Email: "jane@example.com"},
+		{UserID: ids[1], Email: "john@example.com"},
+		{UserID: ids[2], Email: "ann@other.org"},
+	}, all, "inactive users are skipped")
+
+	page, err := audience.Recipients(ctx, domain.Segment{}, ids[0], 1)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Recipient{{UserID: ids[1], Email: "john@example.com"}}, page)
+
+	customers, err := audience.Recipients(ctx, domain.Segment{Role: userDomain.RoleCustomer, EmailDomain: "example.com"}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Recipient{{UserID: ids[1], Email: "john@example.com"}}, customers)
+}
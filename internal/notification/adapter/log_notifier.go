@@ -0,0 +1,16 @@
+package adapter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+)
+
+// LogNotifier only logs messages; used when no email provider is configured
+type LogNotifier struct{}
+
+func (LogNotifier) Send(ctx context.Context, msg domain.Message) error {
+	slog.InfoContext(ctx, "email skipped, no email provider configured", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
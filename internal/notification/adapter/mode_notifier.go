@@ -0,0 +1,20 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+)
+
+// ModeNotifier delivers sandbox messages through a sandbox notifier, so test orders never email real customers
+type ModeNotifier struct {
+	Live    domain.Notifier
+	Sandbox domain.Notifier
+}
+
+func (n ModeNotifier) Send(ctx context.Context, msg domain.Message) error {
+	if msg.Sandbox {
+		return n.Sandbox.Send(ctx, msg)
+	}
+	return n.Live.Send(ctx, msg)
+}
@@ -0,0 +1,115 @@
+package adapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+var message = domain.Message{To: "jane@example.com", Subject: "Your order #42 is confirmed", HTML: "<p>Thanks</p>"}
+
+func TestSMTPNotifier_Send(t *testing.T) {
+	var sentTo []string
+	var sender, sent string
+	notifier := adapter.NewSMTPNotifier("smtp.example.com:587", nil, "shop@example.com")
+	notifier.SendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo, sender = to, from
+		sent = string(msg)
+		return nil
+	}
+
+	assert.NoError(t, notifier.Send(context.Background(), message))
+	assert.Equal(t, []string{"jane@example.com"}, sentTo)
+	assert.Contains(t, sent, "From: shop@example.com\r\n")
+	assert.Contains(t, sent, "Subject: Your order #42 is confirmed\r\n")
+	assert.Contains(t, sent, "Content-Type: text/html; charset=UTF-8\r\n\r\n<p>Thanks</p>")
+
+	branded := message
+	branded.From = `"Acme" <shop@acme.example>`
+	assert.NoError(t, notifier.Send(context.Background(), branded))
+	assert.Contains(t, sent, "From: \"Acme\" <shop@acme.example>\r\n")
+	assert.Equal(t, "shop@example.com", sender, "bounces go to the relay's sender")
+
+	assert.ErrorIs(t, notifier.Send(context.Background(), domain.Message{}), domain.ErrNoRecipient)
+}
+
+func TestSendGridNotifier_Send(t *testing.T) {
+	var auth string
+	var mail map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&mail))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier := adapter.NewSendGridNotifier(server.URL, secret.Static("SG.key"), "shop@example.com", server.Client())
+
+	assert.NoError(t, notifier.Send(context.Background(), message))
+	assert.Equal(t, "Bearer SG.key", auth)
+	assert.Equal(t, "Your order #42 is confirmed", mail["subject"])
+	assert.Equal(t, map[string]any{"email": "shop@example.com"}, mail["from"])
+
+	branded := message
+	branded.From = `"Acme" <shop@acme.example>`
+	assert.NoError(t, notifier.Send(context.Background(), branded))
+	assert.Equal(t, map[string]any{"email": "shop@acme.example", "name": "Acme"}, mail["from"])
+}
+
+func TestSendGridNotifier_RejectedMail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"message":"invalid api key"}]}`))
+	}))
+	defer server.Close()
+
+	notifier := adapter.NewSendGridNotifier(server.URL, secret.Static("wrong"), "shop@example.com", server.Client())
+
+	assert.ErrorContains(t, notifier.Send(context.Background(), message), "invalid api key")
+}
+
+func TestSendGridNotifier_SandboxMode(t *testing.T) {
+	var mail map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&mail))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := adapter.NewSendGridNotifier(server.URL, secret.Static("SG.key"), "shop@example.com", server.Client())
+	notifier.SandboxMode = true
+
+	assert.NoError(t, notifier.Send(context.Background(), message))
+	assert.Equal(t, map[string]any{"sandbox_mode": map[string]any{"enable": true}}, mail["mail_settings"])
+}
+
+type recordingNotifier struct {
+	sent []domain.Message
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg domain.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestModeNotifier_RoutesSandboxMessages(t *testing.T) {
+	live, sandbox := &recordingNotifier{}, &recordingNotifier{}
+	notifier := adapter.ModeNotifier{Live: live, Sandbox: sandbox}
+	test := message
+	test.Sandbox = true
+
+	assert.NoError(t, notifier.Send(context.Background(), message))
+	assert.NoError(t, notifier.Send(context.Background(), test))
+
+	assert.Equal(t, []domain.Message{message}, live.sent)
+	assert.Equal(t, []domain.Message{test}, sandbox.sent)
+}
@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormPacer hands out send times from the email_send_slots table. The row of a provider is locked
+// while slots are reserved, so campaigns on every instance share the rate of the provider.
+type GormPacer struct {
+	db *gorm.DB
+	// rates are the sends per second allowed per provider; providers without a rate are not paced
+	rates map[string]float64
+}
+
+func NewGormPacer(db *gorm.DB, rates map[string]float64) *GormPacer {
+	return &GormPacer{db: db, rates: rates}
+}
+
+func (p *GormPacer) Reserve(ctx context.Context, provider string, n int, now time.Time) ([]time.Time, error) {
+	slots := make([]time.Time, n)
+	rate := p.rates[provider]
+	if rate <= 0 {
+		for i := range slots {
+			slots[i] = now
+		}
+		return slots, nil
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+
+	err := persistence.Conn(ctx, p.db).Transaction(func(tx *gorm.DB) error {
+		slot := domain.SendSlot{Provider: provider, NextAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&slot).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("provider = ?", provider).First(&slot).Error; err != nil {
+			return err
+		}
+
+		next := slot.NextAt
+		if next.Before(now) {
+			next = now
+		}
+		for i := range slots {
+			slots[i] = next
+			next = next.Add(interval)
+		}
+		return tx.Model(&slot).Update("next_at", next).Error
+	})
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return slots, nil
+}
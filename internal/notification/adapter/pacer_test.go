@@ -0,0 +1,42 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormPacer_Reserve(t *testing.T) {
+	pacer := adapter.NewGormPacer(setupCampaignDB(t), map[string]float64{"sendgrid": 10})
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	slots, err := pacer.Reserve(ctx, "sendgrid", 3, now)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{now, now.Add(100 * time.Millisecond), now.Add(200 * time.Millisecond)}, utc(slots))
+
+	slots, err = pacer.Reserve(ctx, "sendgrid", 1, now)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{now.Add(300 * time.Millisecond)}, utc(slots), "later reservations queue behind earlier ones")
+
+	later := now.Add(time.Minute)
+	slots, err = pacer.Reserve(ctx, "sendgrid", 1, later)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{later}, utc(slots), "idle time is not saved up")
+
+	slots, err = pacer.Reserve(ctx, "smtp", 2, now)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{now, now}, slots, "providers without a rate are not paced")
+}
+
+func utc(times []time.Time) []time.Time {
+	out := make([]time.Time, len(times))
+	for i, t := range times {
+		out[i] = t.UTC()
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	netmail "net/mail"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+)
+
+// DefaultSendGridURL is the SendGrid API base URL
+const DefaultSendGridURL = "https://api.sendgrid.com"
+
+// SendGridNotifier delivers emails through the SendGrid v3 mail send API
+type SendGridNotifier struct {
+	baseURL string
+	apiKey  secret.Source
+	from    string
+	client  *http.Client
+
+	// SandboxMode makes SendGrid validate mails without delivering them, for test data of sandbox tenants
+	SandboxMode bool
+}
+
+// NewSendGridNotifier reads the API key on every send so a rotated key is used without a restart
+func NewSendGridNotifier(baseURL string, apiKey secret.Source, from string, client *http.Client) *SendGridNotifier {
+	if baseURL == "" {
+		baseURL = DefaultSendGridURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &SendGridNotifier{baseURL: baseURL, apiKey: apiKey, from: from, client: client}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	MailSettings     *sendGridMailSettings     `json:"mail_settings,omitempty"`
+}
+
+type sendGridSetting struct {
+	Enable bool `json:"enable"`
+}
+
+type sendGridMailSettings struct {
+	SandboxMode sendGridSetting `json:"sandbox_mode"`
+}
+
+func (n *SendGridNotifier) Send(ctx context.Context, msg domain.Message) error {
+	if msg.To == "" {
+		return domain.ErrNoRecipient
+	}
+
+	mail := sendGridMail{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: msg.To}}}},
+		From:             sendGridAddress{Email: n.from},
+		Subject:          msg.Subject,
+		Content:          []sendGridContent{{Type: "text/html", Value: msg.HTML}},
+	}
+	if msg.From != "" {
+		from, err := netmail.ParseAddress(msg.From)
+		if err != nil {
+			return fmt.Errorf("sendgrid send: parse sender %q: %w", msg.From, err)
+		}
+		mail.From = sendGridAddress{Email: from.Address, Name: from.Name}
+	}
+	if n.SandboxMode {
+		mail.MailSettings = &sendGridMailSettings{SandboxMode: sendGridSetting{Enable: true}}
+	}
+	payload, err := json.Marshal(mail)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	apiKey, err := n.apiKey.Secret(ctx)
+	if err != nil {
+		return fmt.Errorf("sendgrid send: read api key: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sendgrid send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sendgrid send: unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+)
+
+// SendMailFunc has the signature of smtp.SendMail so tests can capture outgoing mail
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPNotifier delivers HTML emails through an SMTP relay
+type SMTPNotifier struct {
+	Addr     string
+	Auth     smtp.Auth
+	From     string
+	SendMail SendMailFunc
+}
+
+func NewSMTPNotifier(addr string, auth smtp.Auth, from string) *SMTPNotifier {
+	return &SMTPNotifier{Addr: addr, Auth: auth, From: from, SendMail: smtp.SendMail}
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg domain.Message) error {
+	if msg.To == "" {
+		return domain.ErrNoRecipient
+	}
+
+	// A sender of the message only changes the From header; bounces still go to the relay's sender
+	from := n.From
+	if msg.From != "" {
+		from = msg.From
+	}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&body, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&body, "Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	body.WriteString(msg.HTML)
+
+	if err := n.SendMail(n.Addr, n.Auth, n.From, []string{msg.To}, body.Bytes()); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
+
+// Describe and Check let the self-test verify the relay accepts the configured sender
+func (n *SMTPNotifier) Describe() string {
+	return "smtp relay " + n.Addr
+}
+
+// Check goes through the steps of sending, including STARTTLS and authentication when the
+// relay offers them and the sender address, and resets before any recipient is named
+func (n *SMTPNotifier) Check(ctx context.Context) error {
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if n.Auth != nil {
+		if err := c.Auth(n.Auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(n.From); err != nil {
+		return fmt.Errorf("sender %s: %w", n.From, err)
+	}
+	if err := c.Reset(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+)
+
+// CancelCampaignCommand stops a sending campaign; the emails already sent stay sent
+type CancelCampaignCommand struct {
+	CampaignID int64
+}
+
+type CancelCampaignHandler struct {
+	Campaigns  domain.CampaignRepository
+	Deliveries domain.DeliveryRepository
+	Now        func() time.Time
+}
+
+func (h *CancelCampaignHandler) Handle(ctx context.Context, cmd CancelCampaignCommand) (*domain.Campaign, error) {
+	c, err := getCampaign(ctx, h.Campaigns, cmd.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Finished() {
+		return nil, domain.ErrCampaignFinished
+	}
+
+	// Cancel only updates a sending campaign, so a campaign completing meanwhile stays completed
+	now := nowFunc(h.Now)()
+	if err := h.Campaigns.Cancel(ctx, c.ID, now); err != nil {
+		return nil, err
+	}
+	// Sends already queued see the cancelled campaign and cancel their delivery themselves, so a
+	// send racing this update is never lost
+	if _, err := h.Deliveries.CancelPending(ctx, c.ID); err != nil {
+		return nil, fmt.Errorf("cancel deliveries of campaign %d: %w", c.ID, err)
+	}
+
+	c.Status = domain.CampaignCancelled
+	c.CancelledAt = &now
+	return c, nil
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// CreateCampaignCommand starts emailing a segment; Subject and Body are templates of domain.Recipient
+type CreateCampaignCommand struct {
+	Name    string
+	Subject string
+	Body    string
+	Segment domain.Segment
+	// CreatedBy is the user starting the campaign, zero when access control is disabled
+	CreatedBy int64
+}
+
+type CreateCampaignHandler struct {
+	Campaigns domain.CampaignRepository
+	Queue     domain.CampaignQueue
+	Now       func() time.Time
+}
+
+func (h *CreateCampaignHandler) Handle(ctx context.Context, cmd CreateCampaignCommand) (*domain.Campaign, error) {
+	c, err := domain.NewCampaign(cmd.Name, cmd.Subject, cmd.Body, cmd.Segment, cmd.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Campaigns.Create(ctx, c); err != nil {
+		return nil, fmt.Errorf("create campaign: %w", err)
+	}
+
+	// The recipients are added in the background, one page per job, so large segments never hold up the request
+	if err := h.Queue.Dispatch(ctx, c.ID, 0, nowFunc(h.Now)()); err != nil {
+		return nil, fmt.Errorf("dispatch campaign %d: %w", c.ID, err)
+	}
+	return c, nil
+}
+
+// getCampaign loads a campaign, mapping a missing row onto ErrCampaignNotFound
+func getCampaign(ctx context.Context, campaigns domain.CampaignRepository, id int64) (*domain.Campaign, error) {
+	c, err := campaigns.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, domain.ErrCampaignNotFound
+		}
+		return nil, fmt.Errorf("get campaign %d: %w", id, err)
+	}
+	return c, nil
+}
+
+func nowFunc(now func() time.Time) func() time.Time {
+	if now != nil {
+		return now
+	}
+	return time.Now
+}
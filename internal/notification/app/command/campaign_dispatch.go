@@ -0,0 +1,92 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+)
+
+// DefaultCampaignBatchSize is how many recipients a dispatch job adds when no batch size is configured
+const DefaultCampaignBatchSize = 500
+
+// DispatchCampaignCommand adds the next page of recipients of a campaign, those with an ID above AfterUserID
+type DispatchCampaignCommand struct {
+	CampaignID  int64
+	AfterUserID int64
+}
+
+// DispatchCampaignHandler creates a delivery per recipient and schedules its send at the next free
+// slot of the provider. Each page schedules the next one at the time of its last send, so the
+// deliveries of a large segment are created as the sends catch up rather than all at once.
+type DispatchCampaignHandler struct {
+	Campaigns  domain.CampaignRepository
+	Deliveries domain.DeliveryRepository
+	Audience   domain.Audience
+	Queue      domain.CampaignQueue
+	Pacer      domain.Pacer
+	// Provider is the email provider whose rate limit the sends share
+	Provider  string
+	BatchSize int
+	Now       func() time.Time
+}
+
+func (h *DispatchCampaignHandler) Handle(ctx context.Context, cmd DispatchCampaignCommand) error {
+	c, err := getCampaign(ctx, h.Campaigns, cmd.CampaignID)
+	if err != nil {
+		return err
+	}
+	if c.Finished() {
+		return nil
+	}
+
+	batchSize := h.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultCampaignBatchSize
+	}
+	recipients, err := h.Audience.Recipients(ctx, c.Segment, cmd.AfterUserID, batchSize)
+	if err != nil {
+		return fmt.Errorf("find recipients of campaign %d: %w", c.ID, err)
+	}
+
+	now := nowFunc(h.Now)()
+	if len(recipients) > 0 {
+		deliveries := make([]domain.Delivery, len(recipients))
+		for i, r := range recipients {
+			if deliveries[i], err = domain.NewDelivery(c.ID, r); err != nil {
+				return err
+			}
+		}
+		if err := h.Deliveries.Add(ctx, deliveries); err != nil {
+			return fmt.Errorf("add deliveries of campaign %d: %w", c.ID, err)
+		}
+
+		slots, err := h.Pacer.Reserve(ctx, h.Provider, len(recipients), now)
+		if err != nil {
+			return fmt.Errorf("reserve send slots: %w", err)
+		}
+		for i, r := range recipients {
+			if err := h.Queue.Send(ctx, c.ID, r.UserID, slots[i]); err != nil {
+				return fmt.Errorf("schedule send of campaign %d to user %d: %w", c.ID, r.UserID, err)
+			}
+		}
+
+		if len(recipients) == batchSize {
+			last := recipients[len(recipients)-1].UserID
+			if err := h.Queue.Dispatch(ctx, c.ID, last, slots[len(slots)-1]); err != nil {
+				return fmt.Errorf("dispatch campaign %d: %w", c.ID, err)
+			}
+			return nil
+		}
+	}
+
+	if err := h.Campaigns.MarkDispatched(ctx, c.ID, now); err != nil {
+		return fmt.Errorf("mark campaign %d dispatched: %w", c.ID, err)
+	}
+	// A segment without recipients, or whose sends all finished already, completes here
+	if _, err := h.Campaigns.Complete(ctx, c.ID, now); err != nil {
+		return fmt.Errorf("complete campaign %d: %w", c.ID, err)
+	}
+	return nil
+}
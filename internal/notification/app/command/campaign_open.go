@@ -0,0 +1,31 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// RecordOpenCommand records that the email with the tracking token was opened
+type RecordOpenCommand struct {
+	Token string `validate:"required"`
+}
+
+type RecordOpenHandler struct {
+	Deliveries domain.DeliveryRepository
+	Now        func() time.Time
+}
+
+func (h *RecordOpenHandler) Handle(ctx context.Context, cmd RecordOpenCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	if err := h.Deliveries.RecordOpen(ctx, cmd.Token, nowFunc(h.Now)()); err != nil {
+		return fmt.Errorf("record open: %w", err)
+	}
+	return nil
+}
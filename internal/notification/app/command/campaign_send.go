@@ -0,0 +1,102 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// DefaultCampaignSendAttempts is how often a campaign email is tried when MaxAttempts is not set
+const DefaultCampaignSendAttempts = 5
+
+// SendCampaignEmailCommand emails a campaign to one of its recipients
+type SendCampaignEmailCommand struct {
+	CampaignID int64
+	UserID     int64
+}
+
+type SendCampaignEmailHandler struct {
+	Campaigns  domain.CampaignRepository
+	Deliveries domain.DeliveryRepository
+	Notifier   domain.Notifier
+	// TrackingURL is the public base URL of the API, which serves the open tracking pixels
+	TrackingURL string
+	// MaxAttempts should match the attempts of the job worker, so the delivery is marked failed
+	// on the attempt the worker gives up after
+	MaxAttempts int
+	Now         func() time.Time
+}
+
+func (h *SendCampaignEmailHandler) Handle(ctx context.Context, cmd SendCampaignEmailCommand) error {
+	d, err := h.Deliveries.Get(ctx, cmd.CampaignID, cmd.UserID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return fmt.Errorf("campaign %d has no delivery to user %d", cmd.CampaignID, cmd.UserID)
+		}
+		return fmt.Errorf("get delivery: %w", err)
+	}
+	// A duplicate job of a sent, failed or cancelled delivery has nothing left to do
+	if d.Status != domain.DeliveryPending {
+		return nil
+	}
+
+	c, err := getCampaign(ctx, h.Campaigns, cmd.CampaignID)
+	if err != nil {
+		return err
+	}
+	now := nowFunc(h.Now)()
+	if c.Status == domain.CampaignCancelled {
+		d.Cancel()
+		return h.update(ctx, d)
+	}
+
+	msg, err := domain.NewCampaignMessage(c, d.Recipient(), strings.TrimSuffix(h.TrackingURL, "/")+"/campaigns/opens/"+d.Token)
+	if err == nil {
+		err = h.Notifier.Send(ctx, msg)
+	}
+	if err != nil {
+		d.MarkFailed(err, h.maxAttempts())
+		if updateErr := h.update(ctx, d); updateErr != nil {
+			return updateErr
+		}
+		err = fmt.Errorf("send campaign %d to user %d: %w", c.ID, d.UserID, err)
+		// The worker gives up after the last attempt, so the campaign is completed now if this was its last delivery
+		if d.Status == domain.DeliveryFailed {
+			return errors.Join(err, h.complete(ctx, c.ID, now))
+		}
+		return err
+	}
+
+	d.MarkSent(now)
+	if err := h.update(ctx, d); err != nil {
+		return err
+	}
+	return h.complete(ctx, c.ID, now)
+}
+
+func (h *SendCampaignEmailHandler) update(ctx context.Context, d *domain.Delivery) error {
+	if err := h.Deliveries.Update(ctx, d); err != nil {
+		return fmt.Errorf("update delivery of campaign %d to user %d: %w", d.CampaignID, d.UserID, err)
+	}
+	return nil
+}
+
+// complete finishes the campaign once this was its last pending delivery
+func (h *SendCampaignEmailHandler) complete(ctx context.Context, campaignID int64, now time.Time) error {
+	if _, err := h.Campaigns.Complete(ctx, campaignID, now); err != nil {
+		return fmt.Errorf("complete campaign %d: %w", campaignID, err)
+	}
+	return nil
+}
+
+func (h *SendCampaignEmailHandler) maxAttempts() int {
+	if h.MaxAttempts > 0 {
+		return h.MaxAttempts
+	}
+	return DefaultCampaignSendAttempts
+}
@@ -0,0 +1,338 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MockCampaignRepository keeps campaigns in memory; Complete reads the pending deliveries of deliveries
+type MockCampaignRepository struct {
+	domain.CampaignRepository
+	campaigns  map[int64]*domain.Campaign
+	deliveries *MockDeliveryRepository
+}
+
+func (m *MockCampaignRepository) GetByID(ctx context.Context, id int64) (*domain.Campaign, error) {
+	c, ok := m.campaigns[id]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (m *MockCampaignRepository) MarkDispatched(ctx context.Context, id int64, at time.Time) error {
+	m.campaigns[id].DispatchedAt = &at
+	return nil
+}
+
+func (m *MockCampaignRepository) Cancel(ctx context.Context, id int64, at time.Time) error {
+	c := m.campaigns[id]
+	if c.Finished() {
+		return domain.ErrCampaignFinished
+	}
+	c.Status, c.CancelledAt = domain.CampaignCancelled, &at
+	return nil
+}
+
+func (m *MockCampaignRepository) Complete(ctx context.Context, id int64, at time.Time) (bool, error) {
+	c := m.campaigns[id]
+	if c.Finished() || c.DispatchedAt == nil {
+		return false, nil
+	}
+	for _, d := range m.deliveries.deliveries {
+		if d.CampaignID == id && d.Status == domain.DeliveryPending {
+			return false, nil
+		}
+	}
+	c.Status, c.CompletedAt = domain.CampaignCompleted, &at
+	return true, nil
+}
+
+// MockDeliveryRepository keeps deliveries in memory by user ID; the tests use a single campaign
+type MockDeliveryRepository struct {
+	domain.DeliveryRepository
+	deliveries map[int64]*domain.Delivery
+}
+
+func (m *MockDeliveryRepository) Add(ctx context.Context, deliveries []domain.Delivery) error {
+	for i := range deliveries {
+		if _, ok := m.deliveries[deliveries[i].UserID]; !ok {
+			d := deliveries[i]
+			m.deliveries[d.UserID] = &d
+		}
+	}
+	return nil
+}
+
+func (m *MockDeliveryRepository) Get(ctx context.Context, campaignID, userID int64) (*domain.Delivery, error) {
+	d, ok := m.deliveries[userID]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *MockDeliveryRepository) Update(ctx context.Context, d *domain.Delivery) error {
+	copied := *d
+	m.deliveries[d.UserID] = &copied
+	return nil
+}
+
+func (m *MockDeliveryRepository) CancelPending(ctx context.Context, campaignID int64) (int64, error) {
+	var n int64
+	for _, d := range m.deliveries {
+		if d.Status == domain.DeliveryPending {
+			d.Cancel()
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MockAudience returns the recipients above afterUserID from a fixed list in ID order
+type MockAudience struct {
+	recipients []domain.Recipient
+}
+
+func (m *MockAudience) Recipients(ctx context.Context, s domain.Segment, afterUserID int64, limit int) ([]domain.Recipient, error) {
+	var page []domain.Recipient
+	for _, r := range m.recipients {
+		if r.UserID > afterUserID && len(page) < limit {
+			page = append(page, r)
+		}
+	}
+	return page, nil
+}
+
+// MockCampaignQueue records the scheduled steps
+type MockCampaignQueue struct {
+	dispatches []scheduledStep
+	sends      []scheduledStep
+}
+
+type scheduledStep struct {
+	id int64
+	at time.Time
+}
+
+func (m *MockCampaignQueue) Dispatch(ctx context.Context, campaignID, afterUserID int64, at time.Time) error {
+	m.dispatches = append(m.dispatches, scheduledStep{id: afterUserID, at: at})
+	return nil
+}
+
+func (m *MockCampaignQueue) Send(ctx context.Context, campaignID, userID int64, at time.Time) error {
+	m.sends = append(m.sends, scheduledStep{id: userID, at: at})
+	return nil
+}
+
+// MockPacer spaces sends a second apart, continuing where the last reservation ended
+type MockPacer struct {
+	next time.Time
+}
+
+func (m *MockPacer) Reserve(ctx context.Context, provider string, n int, now time.Time) ([]time.Time, error) {
+	if m.next.Before(now) {
+		m.next = now
+	}
+	slots := make([]time.Time, n)
+	for i := range slots {
+		slots[i] = m.next
+		m.next = m.next.Add(time.Second)
+	}
+	return slots, nil
+}
+
+// MockNotifier records the sent messages and fails with err when set
+type MockNotifier struct {
+	sent []domain.Message
+	err  error
+}
+
+func (m *MockNotifier) Send(ctx context.Context, msg domain.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func newCampaignRepositories(t *testing.T) (*MockCampaignRepository, *MockDeliveryRepository, *domain.Campaign) {
+	t.Helper()
+	c, err := domain.NewCampaign("Spring sale", "Hello {{.FirstName}}", "<p>Hi {{.FirstName}}</p>", domain.Segment{}, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	c.ID = 1
+	deliveries := &MockDeliveryRepository{deliveries: map[int64]*domain.Delivery{}}
+	return &MockCampaignRepository{campaigns: map[int64]*domain.Campaign{1: c}, deliveries: deliveries}, deliveries, c
+}
+
+func TestDispatchCampaignHandler_Handle_Pages(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	campaigns, deliveries, c := newCampaignRepositories(t)
+	queue := &MockCampaignQueue{}
+	handler := &DispatchCampaignHandler{
+		Campaigns:  campaigns,
+		Deliveries: deliveries,
+		Audience: &MockAudience{recipients: []domain.Recipient{
+			{UserID: 3, Email: "jane@example.com"}, {UserID: 5, Email: "john@example.com"}, {UserID: 8, Email: "ann@example.com"},
+		}},
+		Queue:     queue,
+		Pacer:     &MockPacer{},
+		BatchSize: 2,
+		Now:       func() time.Time { return now },
+	}
+
+	// Act
+	err := handler.Handle(context.Background(), DispatchCampaignCommand{CampaignID: c.ID})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(deliveries.deliveries) != 2 || len(queue.sends) != 2 || !queue.sends[1].at.Equal(now.Add(time.Second)) {
+		t.Fatalf("Expected two paced sends, got %+v", queue.sends)
+	}
+	if len(queue.dispatches) != 1 || queue.dispatches[0].id != 5 || !queue.dispatches[0].at.Equal(now.Add(time.Second)) {
+		t.Fatalf("Expected the next page after user 5 at the last send, got %+v", queue.dispatches)
+	}
+	if campaigns.campaigns[c.ID].DispatchedAt != nil {
+		t.Error("Expected the campaign to be dispatching")
+	}
+
+	// Act
+	err = handler.Handle(context.Background(), DispatchCampaignCommand{CampaignID: c.ID, AfterUserID: 5})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(deliveries.deliveries) != 3 || len(queue.sends) != 3 || !queue.sends[2].at.Equal(now.Add(2*time.Second)) {
+		t.Errorf("Expected the last send queued behind the others, got %+v", queue.sends)
+	}
+	if len(queue.dispatches) != 1 {
+		t.Errorf("Expected no page after the last one, got %+v", queue.dispatches)
+	}
+	if campaigns.campaigns[c.ID].DispatchedAt == nil {
+		t.Error("Expected the campaign to be dispatched")
+	}
+}
+
+func TestDispatchCampaignHandler_Handle_EmptySegment(t *testing.T) {
+	campaigns, deliveries, c := newCampaignRepositories(t)
+	handler := &DispatchCampaignHandler{Campaigns: campaigns, Deliveries: deliveries, Audience: &MockAudience{}, Queue: &MockCampaignQueue{}, Pacer: &MockPacer{}}
+
+	if err := handler.Handle(context.Background(), DispatchCampaignCommand{CampaignID: c.ID}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status := campaigns.campaigns[c.ID].Status; status != domain.CampaignCompleted {
+		t.Errorf("Expected a campaign without recipients to complete, got %s", status)
+	}
+}
+
+func TestSendCampaignEmailHandler_Handle(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	campaigns, deliveries, c := newCampaignRepositories(t)
+	d, _ := domain.NewDelivery(c.ID, domain.Recipient{UserID: 7, Email: "jane@example.com", FirstName: "Jane"})
+	_ = deliveries.Add(context.Background(), []domain.Delivery{d})
+	_ = campaigns.MarkDispatched(context.Background(), c.ID, now)
+	notifier := &MockNotifier{err: errors.New("connection refused")}
+	handler := &SendCampaignEmailHandler{
+		Campaigns:   campaigns,
+		Deliveries:  deliveries,
+		Notifier:    notifier,
+		TrackingURL: "https://api.example.com/",
+		MaxAttempts: 3,
+		Now:         func() time.Time { return now },
+	}
+	cmd := SendCampaignEmailCommand{CampaignID: c.ID, UserID: 7}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the failed send to be retried")
+	}
+	if got := deliveries.deliveries[7]; got.Status != domain.DeliveryPending || got.Attempts != 1 {
+		t.Errorf("Expected a pending delivery after one attempt, got %s after %d", got.Status, got.Attempts)
+	}
+
+	// Act
+	notifier.err = nil
+	err = handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Subject != "Hello Jane" {
+		t.Fatalf("Expected the rendered campaign to be sent once, got %+v", notifier.sent)
+	}
+	if !strings.Contains(notifier.sent[0].HTML, "https://api.example.com/campaigns/opens/"+d.Token) {
+		t.Error("Expected the email to load the tracking pixel of the delivery")
+	}
+	if got := deliveries.deliveries[7]; got.Status != domain.DeliverySent {
+		t.Errorf("Expected the delivery to be sent, got %s", got.Status)
+	}
+	if status := campaigns.campaigns[c.ID].Status; status != domain.CampaignCompleted {
+		t.Errorf("Expected the campaign to complete with its last send, got %s", status)
+	}
+
+	// Act
+	err = handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil || len(notifier.sent) != 1 {
+		t.Errorf("Expected a duplicate job to send nothing, got %v and %d emails", err, len(notifier.sent))
+	}
+}
+
+func TestSendCampaignEmailHandler_Handle_Cancelled(t *testing.T) {
+	campaigns, deliveries, c := newCampaignRepositories(t)
+	d, _ := domain.NewDelivery(c.ID, domain.Recipient{UserID: 7, Email: "jane@example.com"})
+	_ = deliveries.Add(context.Background(), []domain.Delivery{d})
+	// The campaign was cancelled after this send was queued but before CancelPending reached the delivery
+	campaigns.campaigns[c.ID].Status = domain.CampaignCancelled
+	notifier := &MockNotifier{}
+	handler := &SendCampaignEmailHandler{Campaigns: campaigns, Deliveries: deliveries, Notifier: notifier}
+
+	if err := handler.Handle(context.Background(), SendCampaignEmailCommand{CampaignID: c.ID, UserID: 7}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(notifier.sent) != 0 || deliveries.deliveries[7].Status != domain.DeliveryCancelled {
+		t.Errorf("Expected the delivery to be cancelled without sending, got %s", deliveries.deliveries[7].Status)
+	}
+}
+
+func TestCancelCampaignHandler_Handle(t *testing.T) {
+	campaigns, deliveries, c := newCampaignRepositories(t)
+	d, _ := domain.NewDelivery(c.ID, domain.Recipient{UserID: 7, Email: "jane@example.com"})
+	_ = deliveries.Add(context.Background(), []domain.Delivery{d})
+	handler := &CancelCampaignHandler{Campaigns: campaigns, Deliveries: deliveries}
+
+	cancelled, err := handler.Handle(context.Background(), CancelCampaignCommand{CampaignID: c.ID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cancelled.Status != domain.CampaignCancelled || deliveries.deliveries[7].Status != domain.DeliveryCancelled {
+		t.Errorf("Expected the campaign and its pending delivery to be cancelled, got %s and %s", cancelled.Status, deliveries.deliveries[7].Status)
+	}
+
+	if _, err := handler.Handle(context.Background(), CancelCampaignCommand{CampaignID: c.ID}); !errors.Is(err, domain.ErrCampaignFinished) {
+		t.Errorf("Expected ErrCampaignFinished, got %v", err)
+	}
+	if _, err := handler.Handle(context.Background(), CancelCampaignCommand{CampaignID: 99}); !errors.Is(err, domain.ErrCampaignNotFound) {
+		t.Errorf("Expected ErrCampaignNotFound, got %v", err)
+	}
+}
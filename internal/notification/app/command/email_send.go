@@ -0,0 +1,34 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// SendEmailCommand delivers a rendered email
+type SendEmailCommand struct {
+	To string `validate:"required,email"`
+	// From overrides the sender of the notifier; empty keeps it
+	From    string
+	Subject string `validate:"required"`
+	HTML    string `validate:"required"`
+	Sandbox bool
+}
+
+type SendEmailHandler struct {
+	Notifier domain.Notifier
+}
+
+func (h *SendEmailHandler) Handle(ctx context.Context, cmd SendEmailCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	if err := h.Notifier.Send(ctx, domain.Message{To: cmd.To, From: cmd.From, Subject: cmd.Subject, HTML: cmd.HTML, Sandbox: cmd.Sandbox}); err != nil {
+		return fmt.Errorf("send %q to %s: %w", cmd.Subject, cmd.To, err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrCampaignFinished = errors.New("campaign has already finished")
+)
+
+// CampaignPublicIDPrefix starts the public IDs of campaigns, e.g. "cmp_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const CampaignPublicIDPrefix = "cmp"
+
+type CampaignStatus string
+
+const (
+	CampaignSending   CampaignStatus = "SENDING"
+	CampaignCompleted CampaignStatus = "COMPLETED"
+	CampaignCancelled CampaignStatus = "CANCELLED"
+)
+
+// Segment selects the recipients of a campaign among the active users; empty fields match everyone
+type Segment struct {
+	// Role limits the campaign to users holding the role, e.g. "customer"
+	Role string `gorm:"type:varchar(50)"`
+	// EmailDomain limits the campaign to addresses at the domain, e.g. "example.com"
+	EmailDomain string `gorm:"type:varchar(255)"`
+}
+
+func (s Segment) normalize() Segment {
+	s.Role = strings.TrimSpace(s.Role)
+	s.EmailDomain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s.EmailDomain), "@"))
+	return s
+}
+
+// Campaign is an email sent to every user of a segment. Sending starts when the campaign is
+// created and runs in the background until every recipient got the email or it is cancelled.
+type Campaign struct {
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the campaign in external APIs so the primary key never leaves the service
+	PublicID string  `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	Name     string  `gorm:"type:varchar(255);not null"`
+	Segment  Segment `gorm:"embedded;embeddedPrefix:segment_"`
+	// Subject and Body are templates rendered for each Recipient, e.g. "Hello {{.FirstName}}"
+	Subject string         `gorm:"type:varchar(255);not null"`
+	Body    string         `gorm:"type:text;not null"`
+	Status  CampaignStatus `gorm:"type:varchar(20);not null;index"`
+	// CreatedBy is the user who started the campaign; zero when access control is disabled
+	CreatedBy int64
+	// DispatchedAt is set once every recipient of the segment has a delivery
+	DispatchedAt *time.Time
+	CancelledAt  *time.Time
+	CompletedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// NewCampaign creates a campaign that is sending, after checking its templates render
+func NewCampaign(name, subject, body string, segment Segment, createdBy int64) (*Campaign, error) {
+	c := &Campaign{
+		PublicID:  publicid.New(CampaignPublicIDPrefix),
+		Name:      strings.TrimSpace(name),
+		Segment:   segment.normalize(),
+		Subject:   strings.TrimSpace(subject),
+		Body:      body,
+		Status:    CampaignSending,
+		CreatedBy: createdBy,
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the campaign and returns validation.Errors describing every violation
+func (c *Campaign) Validate() error {
+	var errs validation.Errors
+
+	errs.Check(c.Name != "", "name", "is required")
+	errs.Check(len(c.Name) <= 255, "name", "must be at most 255 characters")
+	errs.Check(c.Subject != "", "subject", "is required")
+	errs.Check(len(c.Subject) <= 255, "subject", "must be at most 255 characters")
+	errs.Check(strings.TrimSpace(c.Body) != "", "body", "is required")
+	// Rendering for a sample recipient catches syntax errors and fields a Recipient lacks
+	if c.Subject != "" {
+		if _, err := renderSubject(c.Subject, sampleRecipient); err != nil {
+			errs.Add("subject", err.Error())
+		}
+	}
+	if strings.TrimSpace(c.Body) != "" {
+		if _, err := renderCampaignBody(c.Body, campaignData{Subject: c.Subject, Recipient: sampleRecipient}); err != nil {
+			errs.Add("body", err.Error())
+		}
+	}
+
+	return errs.Err()
+}
+
+// Finished reports whether the campaign is no longer sending
+func (c *Campaign) Finished() bool {
+	return c.Status != CampaignSending
+}
+
+// Recipient is a user a campaign is sent to; its fields are the data of the campaign templates
+type Recipient struct {
+	UserID    int64
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+var sampleRecipient = Recipient{UserID: 1, Email: "jane@example.com", FirstName: "Jane", LastName: "Doe"}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliverySent      DeliveryStatus = "SENT"
+	DeliveryFailed    DeliveryStatus = "FAILED"
+	DeliveryCancelled DeliveryStatus = "CANCELLED"
+)
+
+// Delivery is the email of a campaign to one recipient
+type Delivery struct {
+	ID         int64          `gorm:"primaryKey"`
+	CampaignID int64          `gorm:"not null;uniqueIndex:idx_campaign_deliveries_recipient,priority:1;index:idx_campaign_deliveries_status,priority:1"`
+	UserID     int64          `gorm:"not null;uniqueIndex:idx_campaign_deliveries_recipient,priority:2"`
+	Email      string         `gorm:"type:varchar(255);not null"`
+	FirstName  string         `gorm:"type:varchar(100)"`
+	LastName   string         `gorm:"type:varchar(100)"`
+	Status     DeliveryStatus `gorm:"type:varchar(20);not null;index:idx_campaign_deliveries_status,priority:2"`
+	// Token identifies the delivery in the URL of its open tracking pixel
+	Token     string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	Attempts  int    `gorm:"not null"`
+	LastError string `gorm:"type:text"`
+	SentAt    *time.Time
+	// OpenedAt is when the tracking pixel was first loaded; clients blocking images never report opens
+	OpenedAt  *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Delivery) TableName() string {
+	return "campaign_deliveries"
+}
+
+// NewDelivery creates the pending delivery of a campaign to a recipient
+func NewDelivery(campaignID int64, r Recipient) (Delivery, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return Delivery{}, fmt.Errorf("generate delivery token: %w", err)
+	}
+	return Delivery{
+		CampaignID: campaignID,
+		UserID:     r.UserID,
+		Email:      r.Email,
+		FirstName:  r.FirstName,
+		LastName:   r.LastName,
+		Status:     DeliveryPending,
+		Token:      hex.EncodeToString(token),
+	}, nil
+}
+
+func (d *Delivery) Recipient() Recipient {
+	return Recipient{UserID: d.UserID, Email: d.Email, FirstName: d.FirstName, LastName: d.LastName}
+}
+
+// MarkSent records a successful send
+func (d *Delivery) MarkSent(at time.Time) {
+	d.Attempts++
+	d.Status = DeliverySent
+	d.SentAt = &at
+	d.LastError = ""
+}
+
+// MarkFailed records a failed send; the delivery stays pending for a retry until maxAttempts sends failed
+func (d *Delivery) MarkFailed(err error, maxAttempts int) {
+	d.Attempts++
+	d.LastError = err.Error()
+	if d.Attempts >= maxAttempts {
+		d.Status = DeliveryFailed
+	}
+}
+
+// Cancel stops a pending delivery of a cancelled campaign
+func (d *Delivery) Cancel() {
+	d.Status = DeliveryCancelled
+}
+
+// CampaignStats counts the deliveries of a campaign by status
+type CampaignStats struct {
+	Recipients int64
+	Pending    int64
+	Sent       int64
+	Failed     int64
+	Cancelled  int64
+	// Opened counts the sent deliveries whose tracking pixel was loaded
+	Opened int64
+}
+
+type CampaignRepository interface {
+	GetByID(ctx context.Context, id int64) (*Campaign, error)
+	GetByPublicID(ctx context.Context, publicID string) (*Campaign, error)
+	Create(ctx context.Context, c *Campaign) error
+	// MarkDispatched records that every recipient of the campaign has a delivery
+	MarkDispatched(ctx context.Context, id int64, at time.Time) error
+	// Cancel stops a sending campaign; it returns ErrCampaignFinished when the campaign is not sending
+	Cancel(ctx context.Context, id int64, at time.Time) error
+	// Complete marks a dispatched campaign completed once none of its deliveries is pending and
+	// reports whether it did
+	Complete(ctx context.Context, id int64, at time.Time) (bool, error)
+}
+
+type DeliveryRepository interface {
+	// Add stores the deliveries, skipping the recipients that already have one in their campaign
+	Add(ctx context.Context, deliveries []Delivery) error
+	Get(ctx context.Context, campaignID, userID int64) (*Delivery, error)
+	Update(ctx context.Context, d *Delivery) error
+	// CancelPending cancels the pending deliveries of a campaign and returns how many it cancelled
+	CancelPending(ctx context.Context, campaignID int64) (int64, error)
+	// RecordOpen sets OpenedAt of the delivery with the token unless it was opened before; unknown tokens are ignored
+	RecordOpen(ctx context.Context, token string, at time.Time) error
+	Stats(ctx context.Context, campaignID int64) (CampaignStats, error)
+}
+
+// Audience finds the recipients of a segment
+type Audience interface {
+	// Recipients returns up to limit active users of the segment with an ID above afterUserID, in ID order
+	Recipients(ctx context.Context, s Segment, afterUserID int64, limit int) ([]Recipient, error)
+}
+
+// CampaignQueue runs the steps of a campaign in the background
+type CampaignQueue interface {
+	// Dispatch adds the recipients of the campaign with an ID above afterUserID at at
+	Dispatch(ctx context.Context, campaignID, afterUserID int64, at time.Time) error
+	// Send emails the campaign to a recipient at at
+	Send(ctx context.Context, campaignID, userID int64, at time.Time) error
+}
+
+// Pacer spaces out the sends through an email provider to stay within its rate limit
+type Pacer interface {
+	// Reserve returns the times of n sends through the provider, the earliest not before now.
+	// Reservations of all campaigns and instances share the rate of the provider.
+	Reserve(ctx context.Context, provider string, n int, now time.Time) ([]time.Time, error)
+}
+
+// SendSlot is the next free send time of an email provider, kept by the Pacer
+type SendSlot struct {
+	Provider string    `gorm:"type:varchar(50);primaryKey"`
+	NextAt   time.Time `gorm:"not null"`
+}
+
+func (SendSlot) TableName() string {
+	return "email_send_slots"
+}
@@ -0,0 +1,44 @@
+package domain_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCampaign(t *testing.T) {
+	c, err := domain.NewCampaign(" Spring sale ", "Hello {{.FirstName}}", "<p>Hi</p>", domain.Segment{Role: " customer ", EmailDomain: "@Example.com "}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Spring sale", c.Name)
+	assert.Equal(t, domain.CampaignSending, c.Status)
+	assert.Equal(t, domain.Segment{Role: "customer", EmailDomain: "example.com"}, c.Segment)
+	assert.True(t, strings.HasPrefix(c.PublicID, domain.CampaignPublicIDPrefix+"_"))
+
+	for _, tt := range []struct{ name, subject, body, field string }{
+		{name: "", subject: "Hello", body: "<p>Hi</p>", field: "name"},
+		{name: "Sale", subject: "Hello {{.FirstName", body: "<p>Hi</p>", field: "subject"},
+		{name: "Sale", subject: "Hello", body: "<p>{{.Nickname}}</p>", field: "body"},
+		{name: "Sale", subject: "Hello", body: " ", field: "body"},
+	} {
+		var errs validation.Errors
+		if _, err := domain.NewCampaign(tt.name, tt.subject, tt.body, domain.Segment{}, 1); !errors.As(err, &errs) || errs[0].Field != tt.field {
+			t.Errorf("Expected a validation error on %s, got %v", tt.field, err)
+		}
+	}
+}
+
+func TestDelivery_MarkFailed(t *testing.T) {
+	d, err := domain.NewDelivery(1, domain.Recipient{UserID: 7, Email: "jane@example.com"})
+	assert.NoError(t, err)
+	assert.Len(t, d.Token, 32)
+
+	d.MarkFailed(errors.New("connection refused"), 2)
+	assert.Equal(t, domain.DeliveryPending, d.Status, "the delivery is retried")
+	d.MarkFailed(errors.New("connection refused"), 2)
+	assert.Equal(t, domain.DeliveryFailed, d.Status)
+	assert.Equal(t, "connection refused", d.LastError)
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoRecipient is returned when a message has no recipient address
+var ErrNoRecipient = errors.New("message has no recipient")
+
+// Message is a rendered email ready to be delivered
+type Message struct {
+	To string `json:"to"`
+	// From overrides the sender of the email provider, e.g. with the sender of a tenant
+	From    string `json:"from,omitempty"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	// Sandbox messages belong to test data and are delivered by the sandbox notifier
+	Sandbox bool `json:"sandbox,omitempty"`
+}
+
+// Notifier delivers messages through an email provider
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
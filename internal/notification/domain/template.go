@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// layout is parsed once; every email clones it, binds the brand function to the branding of its
+// store and adds its own "title" and "content" blocks
+var layout = template.Must(template.New("layout").Funcs(brandFuncs(Branding{})).ParseFS(templateFS, "templates/layout.html"))
+
+// DefaultStoreName signs emails of stores without a name of their own
+const DefaultStoreName = "AIIO"
+
+// Branding is how the store sending an email presents itself; the zero value signs emails as
+// DefaultStoreName, without a logo and from the sender of the email provider
+type Branding struct {
+	StoreName string
+	LogoURL   string
+	// Sender is the From of the email, e.g. "Acme <shop@acme.example>"
+	Sender string
+}
+
+// Name is the store name emails are signed with
+func (b Branding) Name() string {
+	if b.StoreName == "" {
+		return DefaultStoreName
+	}
+	return b.StoreName
+}
+
+func brandFuncs(b Branding) template.FuncMap {
+	return template.FuncMap{"brand": func() Branding { return b }}
+}
+
+// OrderConfirmation is the data of the order confirmation email; OrderID is the public ID of the order,
+// OrderNumber is empty for orders placed before numbers existed and Total is empty for unpaid orders
+type OrderConfirmation struct {
+	OrderID     string
+	OrderNumber string
+	ProductName string
+	Quantity    int
+	Total       string
+	PlacedAt    time.Time
+}
+
+// Reference names the order to the customer: its number, or its public ID when it has none
+func (d OrderConfirmation) Reference() string {
+	if d.OrderNumber != "" {
+		return d.OrderNumber
+	}
+	return d.OrderID
+}
+
+// StockAlert is the data of the email telling the staff a product runs low; ProductID is the
+// public ID of the product
+type StockAlert struct {
+	ProductID  string
+	Name       string
+	Stock      int
+	Threshold  int
+	DetectedAt time.Time
+}
+
+// Welcome is the data of the email sent after registration
+type Welcome struct {
+	Email string
+}
+
+// AccountLink is the data of the password reset and email verification emails; Link carries a
+// single-use token and must only be sent to Email
+type AccountLink struct {
+	Email     string
+	Link      string
+	ExpiresAt time.Time
+}
+
+func NewOrderConfirmationMessage(to string, b Branding, data OrderConfirmation) (Message, error) {
+	return render(to, fmt.Sprintf("Your order %s is confirmed", data.Reference()), "order_confirmation.html", b, data)
+}
+
+func NewWelcomeMessage(to string, b Branding, data Welcome) (Message, error) {
+	return render(to, "Welcome to "+b.Name(), "welcome.html", b, data)
+}
+
+func NewPasswordResetMessage(to string, b Branding, data AccountLink) (Message, error) {
+	return render(to, fmt.Sprintf("Reset your %s password", b.Name()), "password_reset.html", b, data)
+}
+
+func NewEmailVerificationMessage(to string, b Branding, data AccountLink) (Message, error) {
+	return render(to, "Verify your email address", "email_verification.html", b, data)
+}
+
+func NewStockAlertMessage(to string, b Branding, data StockAlert) (Message, error) {
+	return render(to, fmt.Sprintf("%s is running low on stock", data.Name), "stock_alert.html", b, data)
+}
+
+// NewCampaignMessage renders a campaign for a recipient. The email loads pixelURL as an invisible
+// image, which records when it is opened.
+func NewCampaignMessage(c *Campaign, r Recipient, pixelURL string) (Message, error) {
+	if r.Email == "" {
+		return Message{}, ErrNoRecipient
+	}
+
+	subject, err := renderSubject(c.Subject, r)
+	if err != nil {
+		return Message{}, err
+	}
+	html, err := renderCampaignBody(c.Body, campaignData{Subject: subject, Recipient: r, PixelURL: pixelURL})
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: r.Email, Subject: subject, HTML: html}, nil
+}
+
+// campaignData is the data of campaign.html; the body template of the campaign only sees Recipient
+type campaignData struct {
+	Subject   string
+	Recipient Recipient
+	PixelURL  string
+}
+
+// renderSubject renders a subject template; subjects are plain text, so nothing is escaped
+func renderSubject(subject string, r Recipient) (string, error) {
+	tmpl, err := texttemplate.New("subject").Parse(subject)
+	if err != nil {
+		return "", fmt.Errorf("parse subject template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, r); err != nil {
+		return "", fmt.Errorf("render subject template: %w", err)
+	}
+	return out.String(), nil
+}
+
+func renderCampaignBody(body string, data campaignData) (string, error) {
+	tmpl, err := layout.Clone()
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmpl.ParseFS(templateFS, "templates/campaign.html"); err != nil {
+		return "", fmt.Errorf("parse template campaign.html: %w", err)
+	}
+	if _, err := tmpl.New("body").Parse(body); err != nil {
+		return "", fmt.Errorf("parse body template: %w", err)
+	}
+
+	var html bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&html, "layout", data); err != nil {
+		return "", fmt.Errorf("render body template: %w", err)
+	}
+	return html.String(), nil
+}
+
+func render(to, subject, name string, b Branding, data any) (Message, error) {
+	if to == "" {
+		return Message{}, ErrNoRecipient
+	}
+
+	tmpl, err := layout.Clone()
+	if err != nil {
+		return Message{}, err
+	}
+	tmpl.Funcs(brandFuncs(b))
+	if _, err := tmpl.ParseFS(templateFS, "templates/"+name); err != nil {
+		return Message{}, fmt.Errorf("parse template %s: %w", name, err)
+	}
+
+	var html bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("render template %s: %w", name, err)
+	}
+	return Message{To: to, From: b.Sender, Subject: subject, HTML: html.String()}, nil
+}
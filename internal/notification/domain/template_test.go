@@ -0,0 +1,78 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrderConfirmationMessage(t *testing.T) {
+	msg, err := domain.NewOrderConfirmationMessage("jane@example.com", domain.Branding{}, domain.OrderConfirmation{
+		OrderID:     "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE",
+		ProductName: "Desk <Oak>",
+		Quantity:    2,
+		Total:       "25.00 EUR",
+		PlacedAt:    time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "jane@example.com", msg.To)
+	assert.Equal(t, "Your order ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE is confirmed", msg.Subject)
+	assert.Contains(t, msg.HTML, "<title>Order ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE confirmed</title>")
+	assert.Contains(t, msg.HTML, "May 18, 2024")
+	assert.Contains(t, msg.HTML, "Desk &lt;Oak&gt;", "product names are escaped")
+	assert.Contains(t, msg.HTML, "25.00 EUR")
+
+	msg, err = domain.NewOrderConfirmationMessage("jane@example.com", domain.Branding{}, domain.OrderConfirmation{
+		OrderID:     "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE",
+		OrderNumber: "20240518-000123",
+		PlacedAt:    time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Your order 20240518-000123 is confirmed", msg.Subject, "customers see the order number")
+	assert.Contains(t, msg.HTML, "We received your order 20240518-000123")
+}
+
+func TestNewWelcomeMessage(t *testing.T) {
+	msg, err := domain.NewWelcomeMessage("jane@example.com", domain.Branding{}, domain.Welcome{Email: "jane@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Welcome to AIIO", msg.Subject)
+	assert.Contains(t, msg.HTML, "Your account for jane@example.com has been created.")
+
+	_, err = domain.NewWelcomeMessage("", domain.Branding{}, domain.Welcome{})
+	assert.ErrorIs(t, err, domain.ErrNoRecipient)
+}
+
+func TestNewWelcomeMessage_Branding(t *testing.T) {
+	brand := domain.Branding{StoreName: "Acme & Co", LogoURL: "https://acme.example/logo.png", Sender: "Acme <shop@acme.example>"}
+	msg, err := domain.NewWelcomeMessage("jane@example.com", brand, domain.Welcome{Email: "jane@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Welcome to Acme & Co", msg.Subject)
+	assert.Equal(t, "Acme <shop@acme.example>", msg.From)
+	assert.Contains(t, msg.HTML, "<h1 style=\"font-size: 20px;\">Welcome to Acme &amp; Co</h1>")
+	assert.Contains(t, msg.HTML, `<img src="https://acme.example/logo.png" alt="Acme &amp; Co"`)
+	assert.NotContains(t, msg.HTML, "AIIO")
+
+	msg, err = domain.NewWelcomeMessage("jane@example.com", domain.Branding{}, domain.Welcome{Email: "jane@example.com"})
+	assert.NoError(t, err)
+	assert.NotContains(t, msg.HTML, "<img", "stores without a logo show none")
+}
+
+func TestNewCampaignMessage(t *testing.T) {
+	c, err := domain.NewCampaign("Spring sale", "{{.FirstName}}, spring is here", "<p>Hi {{.FirstName}} {{.LastName}}</p>", domain.Segment{}, 1)
+	assert.NoError(t, err)
+
+	msg, err := domain.NewCampaignMessage(c, domain.Recipient{UserID: 7, Email: "jane@example.com", FirstName: "Jane", LastName: "<Doe>"}, "https://api.example.com/campaigns/opens/abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane@example.com", msg.To)
+	assert.Equal(t, "Jane, spring is here", msg.Subject)
+	assert.Contains(t, msg.HTML, "<p>Hi Jane &lt;Doe&gt;</p>", "recipient data is escaped")
+	assert.Contains(t, msg.HTML, `<img src="https://api.example.com/campaigns/opens/abc"`)
+
+	_, err = domain.NewCampaignMessage(c, domain.Recipient{UserID: 7}, "")
+	assert.ErrorIs(t, err, domain.ErrNoRecipient)
+}
@@ -0,0 +1,193 @@
+package port
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// Enqueuer is the part of jobs.Queue the event subscribers need
+type Enqueuer interface {
+	Enqueue(ctx context.Context, job jobs.Job, opts ...jobs.EnqueueOption) error
+}
+
+// EventServer renders emails for domain events and enqueues their delivery,
+// so a slow or failing mail provider never holds up the command that published the event
+type EventServer struct {
+	Jobs Enqueuer
+	// StockAlertRecipients are alerted to products running low on stock; empty sends no alerts
+	StockAlertRecipients []string
+	// PasswordResetURL and EmailVerificationURL are the pages the account emails link to, with
+	// the token appended as ?token=
+	PasswordResetURL     string
+	EmailVerificationURL string
+	// Branding returns the branding of the tenant of ctx; nil signs every email as the default store
+	Branding func(ctx context.Context) (domain.Branding, error)
+}
+
+// branding returns the branding of the store the event happened in
+func (s *EventServer) branding(ctx context.Context) (domain.Branding, error) {
+	if s.Branding == nil {
+		return domain.Branding{}, nil
+	}
+	b, err := s.Branding(ctx)
+	if err != nil {
+		return domain.Branding{}, fmt.Errorf("resolve email branding: %w", err)
+	}
+	return b, nil
+}
+
+// Subscribe registers the email subscribers on the event bus
+func (s *EventServer) Subscribe(bus *event.Bus) {
+	bus.Subscribe(orderDomain.OrderPlacedEvent, s.orderPlaced)
+	bus.Subscribe(userDomain.UserRegisteredEvent, s.userRegistered)
+	bus.Subscribe(userDomain.PasswordResetRequestedEvent, s.passwordResetRequested)
+	bus.Subscribe(userDomain.EmailVerificationRequestedEvent, s.emailVerificationRequested)
+	if len(s.StockAlertRecipients) > 0 {
+		bus.Subscribe(productDomain.StockLowEvent, s.stockLow)
+	}
+}
+
+func (s *EventServer) orderPlaced(ctx context.Context, e event.Event) error {
+	placed, ok := e.(orderDomain.OrderPlaced)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	data := domain.OrderConfirmation{
+		OrderID:     placed.OrderPublicID,
+		OrderNumber: placed.OrderNumber,
+		ProductName: placed.ProductName,
+		Quantity:    placed.Quantity,
+		PlacedAt:    placed.PlacedAt,
+	}
+	if !placed.Amount.IsZero() {
+		data.Total = placed.Amount.String()
+	}
+
+	b, err := s.branding(ctx)
+	if err != nil {
+		return err
+	}
+	msg, err := domain.NewOrderConfirmationMessage(placed.Email.String(), b, data)
+	if err != nil {
+		return err
+	}
+	msg.Sandbox = placed.Sandbox
+	return s.Jobs.Enqueue(ctx, SendEmailJob{Message: msg}, jobs.WithUniqueKey("order-confirmation-"+placed.EventID()))
+}
+
+func (s *EventServer) userRegistered(ctx context.Context, e event.Event) error {
+	registered, ok := e.(userDomain.UserRegistered)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	b, err := s.branding(ctx)
+	if err != nil {
+		return err
+	}
+	msg, err := domain.NewWelcomeMessage(registered.Email.String(), b, domain.Welcome{Email: registered.Email.String()})
+	if err != nil {
+		return err
+	}
+	msg.Sandbox = mode.FromContext(ctx).IsSandbox()
+	return s.Jobs.Enqueue(ctx, SendEmailJob{Message: msg}, jobs.WithUniqueKey("welcome-"+registered.EventID()))
+}
+
+func (s *EventServer) passwordResetRequested(ctx context.Context, e event.Event) error {
+	requested, ok := e.(userDomain.PasswordResetRequested)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	data, err := accountLink(s.PasswordResetURL, requested.Email, requested.Token, requested.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	b, err := s.branding(ctx)
+	if err != nil {
+		return err
+	}
+	msg, err := domain.NewPasswordResetMessage(requested.Email.String(), b, data)
+	if err != nil {
+		return err
+	}
+	msg.Sandbox = mode.FromContext(ctx).IsSandbox()
+	return s.Jobs.Enqueue(ctx, SendEmailJob{Message: msg}, jobs.WithUniqueKey("password-reset-"+requested.EventID()))
+}
+
+func (s *EventServer) emailVerificationRequested(ctx context.Context, e event.Event) error {
+	requested, ok := e.(userDomain.EmailVerificationRequested)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	data, err := accountLink(s.EmailVerificationURL, requested.Email, requested.Token, requested.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	b, err := s.branding(ctx)
+	if err != nil {
+		return err
+	}
+	msg, err := domain.NewEmailVerificationMessage(requested.Email.String(), b, data)
+	if err != nil {
+		return err
+	}
+	msg.Sandbox = mode.FromContext(ctx).IsSandbox()
+	return s.Jobs.Enqueue(ctx, SendEmailJob{Message: msg}, jobs.WithUniqueKey("email-verification-"+requested.EventID()))
+}
+
+// accountLink appends token to the query of page
+func accountLink(page string, email userDomain.Email, token string, expiresAt time.Time) (domain.AccountLink, error) {
+	u, err := url.Parse(page)
+	if err != nil {
+		return domain.AccountLink{}, fmt.Errorf("parse account link %q: %w", page, err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return domain.AccountLink{Email: email.String(), Link: u.String(), ExpiresAt: expiresAt}, nil
+}
+
+// stockLow alerts every recipient to a product running low, one email job each so a bad address
+// does not hold up the others
+func (s *EventServer) stockLow(ctx context.Context, e event.Event) error {
+	low, ok := e.(productDomain.StockLow)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	data := domain.StockAlert{
+		ProductID:  low.ProductPublicID,
+		Name:       low.Name,
+		Stock:      low.Stock,
+		Threshold:  low.Threshold,
+		DetectedAt: low.DetectedAt,
+	}
+	b, err := s.branding(ctx)
+	if err != nil {
+		return err
+	}
+	for _, to := range s.StockAlertRecipients {
+		msg, err := domain.NewStockAlertMessage(to, b, data)
+		if err != nil {
+			return err
+		}
+		msg.Sandbox = mode.FromContext(ctx).IsSandbox()
+		if err := s.Jobs.Enqueue(ctx, SendEmailJob{Message: msg}, jobs.WithUniqueKey("stock-alert-"+to+"-"+low.EventID())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package port_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/port"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingEnqueuer struct {
+	jobs []jobs.Job
+	// uniqueKeys holds the deduplication key of every job, empty when it has none
+	uniqueKeys []string
+}
+
+func (e *recordingEnqueuer) Enqueue(ctx context.Context, job jobs.Job, opts ...jobs.EnqueueOption) error {
+	var record jobs.Record
+	for _, opt := range opts {
+		opt(&record)
+	}
+	e.jobs = append(e.jobs, job)
+	e.uniqueKeys = append(e.uniqueKeys, "")
+	if record.UniqueKey != nil {
+		e.uniqueKeys[len(e.uniqueKeys)-1] = *record.UniqueKey
+	}
+	return nil
+}
+
+func TestEventServer_EnqueuesEmails(t *testing.T) {
+	enqueuer := &recordingEnqueuer{}
+	bus := event.NewBus()
+	(&port.EventServer{Jobs: enqueuer}).Subscribe(bus)
+
+	err := bus.Publish(context.Background(),
+		orderDomain.OrderPlaced{
+			OrderID:       42,
+			OrderPublicID: "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE",
+			Email:         "jane@example.com",
+			ProductName:   "Desk",
+			Quantity:      1,
+			Amount:        money.Money{Amount: 2500, Currency: "EUR"},
+			PlacedAt:      time.Now(),
+		},
+		userDomain.UserRegistered{UserID: 7, UserPublicID: "usr_01HXM3Q6Z9V4S8T2K7N1B5C0DE", Email: "john@example.com"},
+	)
+
+	assert.NoError(t, err)
+	if assert.Len(t, enqueuer.jobs, 2) {
+		assert.Equal(t, []string{
+			"order-confirmation-" + event.NewID(orderDomain.OrderPlacedEvent, "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE", 0),
+			"welcome-" + event.NewID(userDomain.UserRegisteredEvent, "usr_01HXM3Q6Z9V4S8T2K7N1B5C0DE", 1),
+		}, enqueuer.uniqueKeys, "emails are deduplicated by event ID")
+
+		confirmation := enqueuer.jobs[0].(port.SendEmailJob).Message
+		assert.Equal(t, "jane@example.com", confirmation.To)
+		assert.Contains(t, confirmation.HTML, "25.00 EUR")
+		assert.Equal(t, "Your order ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE is confirmed", confirmation.Subject)
+
+		welcome := enqueuer.jobs[1].(port.SendEmailJob).Message
+		assert.Equal(t, "john@example.com", welcome.To)
+		assert.Equal(t, "Welcome to AIIO", welcome.Subject)
+	}
+}
+
+func TestEventServer_BrandsEmailsPerTenant(t *testing.T) {
+	enqueuer := &recordingEnqueuer{}
+	bus := event.NewBus()
+	(&port.EventServer{
+		Jobs: enqueuer,
+		Branding: func(ctx context.Context) (domain.Branding, error) {
+			if tenant.FromContext(ctx) == "acme" {
+				return domain.Branding{StoreName: "Acme", Sender: "Acme <shop@acme.example>"}, nil
+			}
+			return domain.Branding{}, nil
+		},
+	}).Subscribe(bus)
+
+	registered := userDomain.UserRegistered{UserID: 7, UserPublicID: "usr_01HXM3Q6Z9V4S8T2K7N1B5C0DE", Email: "john@example.com"}
+	assert.NoError(t, bus.Publish(tenant.WithID(context.Background(), "acme"), registered))
+	assert.NoError(t, bus.Publish(context.Background(), userDomain.UserRegistered{UserID: 8, UserPublicID: "usr_01HXM3Q6Z9V4S8T2K7N1B5C0DF", Email: "jane@example.com"}))
+
+	if assert.Len(t, enqueuer.jobs, 2) {
+		acme := enqueuer.jobs[0].(port.SendEmailJob).Message
+		assert.Equal(t, "Welcome to Acme", acme.Subject)
+		assert.Equal(t, "Acme <shop@acme.example>", acme.From)
+
+		other := enqueuer.jobs[1].(port.SendEmailJob).Message
+		assert.Equal(t, "Welcome to AIIO", other.Subject)
+		assert.Empty(t, other.From)
+	}
+}
+
+func TestEventServer_TagsSandboxEmails(t *testing.T) {
+	enqueuer := &recordingEnqueuer{}
+	bus := event.NewBus()
+	(&port.EventServer{Jobs: enqueuer}).Subscribe(bus)
+
+	err := bus.Publish(mode.With(context.Background(), mode.Sandbox),
+		orderDomain.OrderPlaced{OrderID: 42, Email: "jane@example.com", ProductName: "Desk", Quantity: 1, Sandbox: true},
+		userDomain.UserRegistered{UserID: 7, Email: "john@example.com"},
+	)
+
+	assert.NoError(t, err)
+	if assert.Len(t, enqueuer.jobs, 2) {
+		assert.True(t, enqueuer.jobs[0].(port.SendEmailJob).Message.Sandbox)
+		assert.True(t, enqueuer.jobs[1].(port.SendEmailJob).Message.Sandbox)
+	}
+}
+
+func TestEventServer_AlertsToLowStock(t *testing.T) {
+	enqueuer := &recordingEnqueuer{}
+	bus := event.NewBus()
+	(&port.EventServer{Jobs: enqueuer, StockAlertRecipients: []string{"buyer@example.com", "ops@example.com"}}).Subscribe(bus)
+
+	low := productDomain.StockLow{ProductID: 3, ProductPublicID: "prd_01HXM3Q6Z9V4S8T2K7N1B5C0DE", Name: "Desk", Stock: 2, Threshold: 5, DetectedAt: time.Now()}
+	err := bus.Publish(context.Background(), low)
+
+	assert.NoError(t, err)
+	if assert.Len(t, enqueuer.jobs, 2) {
+		alert := enqueuer.jobs[0].(port.SendEmailJob).Message
+		assert.Equal(t, "buyer@example.com", alert.To)
+		assert.Equal(t, "Desk is running low on stock", alert.Subject)
+		assert.Contains(t, alert.HTML, "reorder threshold of 5")
+		assert.Equal(t, "ops@example.com", enqueuer.jobs[1].(port.SendEmailJob).Message.To)
+		assert.Equal(t, "stock-alert-buyer@example.com-"+low.EventID(), enqueuer.uniqueKeys[0])
+	}
+}
+
+func TestEventServer_SendsNoStockAlertsWithoutRecipients(t *testing.T) {
+	enqueuer := &recordingEnqueuer{}
+	bus := event.NewBus()
+	(&port.EventServer{Jobs: enqueuer}).Subscribe(bus)
+
+	assert.NoError(t, bus.Publish(context.Background(), productDomain.StockLow{ProductID: 3, Name: "Desk"}))
+	assert.Empty(t, enqueuer.jobs)
+}
+
+func TestEventServer_EmailsAccountLinks(t *testing.T) {
+	enqueuer := &recordingEnqueuer{}
+	bus := event.NewBus()
+	(&port.EventServer{
+		Jobs:                 enqueuer,
+		PasswordResetURL:     "https://shop.example.com/password/reset",
+		EmailVerificationURL: "https://shop.example.com/verify?lang=en",
+	}).Subscribe(bus)
+
+	reset := userDomain.PasswordResetRequested{UserID: 7, Email: "jane@example.com", Token: "0a1b.2c3d", ExpiresAt: time.Now().Add(time.Hour)}
+	verification := userDomain.EmailVerificationRequested{UserID: 7, Email: "jane@example.com", Token: "4e5f.6a7b", ExpiresAt: time.Now().Add(time.Hour)}
+	err := bus.Publish(context.Background(), reset, verification)
+
+	assert.NoError(t, err)
+	if assert.Len(t, enqueuer.jobs, 2) {
+		resetMsg := enqueuer.jobs[0].(port.SendEmailJob).Message
+		assert.Equal(t, "jane@example.com", resetMsg.To)
+		assert.Equal(t, "Reset your AIIO password", resetMsg.Subject)
+		assert.Contains(t, resetMsg.HTML, `href="https://shop.example.com/password/reset?token=0a1b.2c3d"`)
+		assert.Equal(t, "password-reset-"+reset.EventID(), enqueuer.uniqueKeys[0])
+
+		verificationMsg := enqueuer.jobs[1].(port.SendEmailJob).Message
+		assert.Equal(t, "Verify your email address", verificationMsg.Subject)
+		assert.Contains(t, verificationMsg.HTML, `href="https://shop.example.com/verify?lang=en&amp;token=4e5f.6a7b"`)
+		assert.Equal(t, "email-verification-"+verification.EventID(), enqueuer.uniqueKeys[1])
+	}
+}
@@ -0,0 +1,224 @@
+package port
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// ErrorCodeCampaignFinished is returned with 409 when cancelling a campaign that is no longer sending
+const ErrorCodeCampaignFinished = "campaign_finished"
+
+// pixel is a transparent 1x1 GIF, the smallest image every email client renders
+var pixel = []byte("GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\x00\x00\x00!\xf9\x04\x01\x00\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;")
+
+// CreateCampaignRequest is the body of POST /campaigns. Subject and Body are Go templates of the
+// recipient, e.g. "Hello {{.FirstName}}"; Body is HTML.
+type CreateCampaignRequest struct {
+	Name    string         `json:"name"`
+	Subject string         `json:"subject"`
+	Body    string         `json:"body"`
+	Segment SegmentRequest `json:"segment"`
+}
+
+// SegmentRequest selects the active users a campaign is sent to; omitted fields match everyone
+type SegmentRequest struct {
+	Role        string `json:"role,omitempty"`
+	EmailDomain string `json:"email_domain,omitempty"`
+}
+
+// CampaignResponse is a campaign and the progress of its sends; ID is the public "cmp_" ID
+type CampaignResponse struct {
+	ID           string                `json:"id"`
+	Name         string                `json:"name"`
+	Subject      string                `json:"subject"`
+	Segment      SegmentRequest        `json:"segment"`
+	Status       domain.CampaignStatus `json:"status"`
+	Stats        CampaignStatsResponse `json:"stats"`
+	CreatedAt    time.Time             `json:"created_at"`
+	DispatchedAt *time.Time            `json:"dispatched_at,omitempty"`
+	CancelledAt  *time.Time            `json:"cancelled_at,omitempty"`
+	CompletedAt  *time.Time            `json:"completed_at,omitempty"`
+}
+
+// CampaignStatsResponse counts the deliveries of a campaign; Recipients grows while the segment is dispatched
+type CampaignStatsResponse struct {
+	Recipients int64 `json:"recipients"`
+	Pending    int64 `json:"pending"`
+	Sent       int64 `json:"sent"`
+	Failed     int64 `json:"failed"`
+	Cancelled  int64 `json:"cancelled"`
+	Opened     int64 `json:"opened"`
+}
+
+// HTTPServer exposes email campaigns over HTTP
+type HTTPServer struct {
+	CreateCampaign decorator.CommandResultHandler[command.CreateCampaignCommand, *domain.Campaign]
+	CancelCampaign decorator.CommandResultHandler[command.CancelCampaignCommand, *domain.Campaign]
+	RecordOpen     decorator.CommandHandler[command.RecordOpenCommand]
+	Campaigns      domain.CampaignRepository
+	Deliveries     domain.DeliveryRepository
+
+	// Auth restricts campaigns to campaign:manage; the tracking pixel is public. nil disables access control.
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the campaign endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/campaigns",
+		Summary:  "Email a templated message to every active user of a segment",
+		Tags:     []string{"campaigns"},
+		Request:  CreateCampaignRequest{},
+		Response: CampaignResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionCampaignManage, s.createCampaign),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/campaigns/{id}",
+		Summary:  "Get a campaign with its send and open counts",
+		Tags:     []string{"campaigns"},
+		Response: CampaignResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionCampaignManage, s.getCampaign),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/campaigns/{id}/cancel",
+		Summary:  "Stop a sending campaign; emails already sent stay sent",
+		Tags:     []string{"campaigns"},
+		Response: CampaignResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionCampaignManage, s.cancelCampaign),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:  http.MethodGet,
+		Path:    "/campaigns/opens/{token}",
+		Summary: "Tracking pixel of a campaign email; records the open and returns a 1x1 GIF",
+		Tags:    []string{"campaigns"},
+		Handler: s.recordOpen,
+	})
+}
+
+func (s *HTTPServer) createCampaign(w http.ResponseWriter, r *http.Request) {
+	var req CreateCampaignRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	createdBy, _ := auth.UserID(r.Context())
+	c, err := s.CreateCampaign.Handle(r.Context(), command.CreateCampaignCommand{
+		Name:      req.Name,
+		Subject:   req.Subject,
+		Body:      req.Body,
+		Segment:   domain.Segment{Role: req.Segment.Role, EmailDomain: req.Segment.EmailDomain},
+		CreatedBy: createdBy,
+	})
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	s.writeCampaign(w, r, http.StatusCreated, c)
+}
+
+func (s *HTTPServer) getCampaign(w http.ResponseWriter, r *http.Request) {
+	c, err := s.campaign(r)
+	if err != nil {
+		writeCampaignError(w, err)
+		return
+	}
+	s.writeCampaign(w, r, http.StatusOK, c)
+}
+
+func (s *HTTPServer) cancelCampaign(w http.ResponseWriter, r *http.Request) {
+	c, err := s.campaign(r)
+	if err != nil {
+		writeCampaignError(w, err)
+		return
+	}
+
+	c, err = s.CancelCampaign.Handle(r.Context(), command.CancelCampaignCommand{CampaignID: c.ID})
+	if err != nil {
+		writeCampaignError(w, err)
+		return
+	}
+	s.writeCampaign(w, r, http.StatusOK, c)
+}
+
+// recordOpen always returns the pixel, so a broken image never hints at an unknown token
+func (s *HTTPServer) recordOpen(w http.ResponseWriter, r *http.Request) {
+	if err := s.RecordOpen.Handle(r.Context(), command.RecordOpenCommand{Token: r.PathValue("token")}); err != nil {
+		slog.ErrorContext(r.Context(), "record campaign open", "error", err)
+	}
+
+	// Proxies and clients must not cache the pixel, or later opens are never requested
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pixel)
+}
+
+// campaign looks up the campaign of the {id} path parameter
+func (s *HTTPServer) campaign(r *http.Request) (*domain.Campaign, error) {
+	c, err := s.Campaigns.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, domain.ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *HTTPServer) writeCampaign(w http.ResponseWriter, r *http.Request, status int, c *domain.Campaign) {
+	stats, err := s.Deliveries.Stats(r.Context(), c.ID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, status, CampaignResponse{
+		ID:      c.PublicID,
+		Name:    c.Name,
+		Subject: c.Subject,
+		Segment: SegmentRequest{Role: c.Segment.Role, EmailDomain: c.Segment.EmailDomain},
+		Status:  c.Status,
+		Stats: CampaignStatsResponse{
+			Recipients: stats.Recipients,
+			Pending:    stats.Pending,
+			Sent:       stats.Sent,
+			Failed:     stats.Failed,
+			Cancelled:  stats.Cancelled,
+			Opened:     stats.Opened,
+		},
+		CreatedAt:    c.CreatedAt,
+		DispatchedAt: c.DispatchedAt,
+		CancelledAt:  c.CancelledAt,
+		CompletedAt:  c.CompletedAt,
+	})
+}
+
+// writeCampaignError maps campaign domain errors onto HTTP status codes
+func writeCampaignError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCampaignNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrCampaignFinished):
+		httpx.WriteErrorCode(w, http.StatusConflict, ErrorCodeCampaignFinished, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
@@ -0,0 +1,83 @@
+package port
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// SendEmailJob delivers a rendered email; failed deliveries are retried by the worker
+type SendEmailJob struct {
+	Message domain.Message `json:"message"`
+}
+
+func (SendEmailJob) Kind() string {
+	return "notification.send_email"
+}
+
+// DispatchCampaignJob adds the recipients of a campaign with an ID above AfterUserID
+type DispatchCampaignJob struct {
+	CampaignID  int64 `json:"campaign_id"`
+	AfterUserID int64 `json:"after_user_id"`
+}
+
+func (DispatchCampaignJob) Kind() string {
+	return "notification.dispatch_campaign"
+}
+
+// SendCampaignEmailJob emails a campaign to one recipient; failed sends are retried by the worker
+type SendCampaignEmailJob struct {
+	CampaignID int64 `json:"campaign_id"`
+	UserID     int64 `json:"user_id"`
+}
+
+func (SendCampaignEmailJob) Kind() string {
+	return "notification.send_campaign_email"
+}
+
+// JobCampaignQueue runs the steps of campaigns as jobs. The jobs are deduplicated, so a retried
+// dispatch never schedules a page or a send twice.
+type JobCampaignQueue struct {
+	Jobs Enqueuer
+}
+
+func (q JobCampaignQueue) Dispatch(ctx context.Context, campaignID, afterUserID int64, at time.Time) error {
+	return q.Jobs.Enqueue(ctx, DispatchCampaignJob{CampaignID: campaignID, AfterUserID: afterUserID},
+		jobs.RunAt(at), jobs.WithUniqueKey(fmt.Sprintf("campaign-%d-after-%d", campaignID, afterUserID)))
+}
+
+func (q JobCampaignQueue) Send(ctx context.Context, campaignID, userID int64, at time.Time) error {
+	return q.Jobs.Enqueue(ctx, SendCampaignEmailJob{CampaignID: campaignID, UserID: userID},
+		jobs.RunAt(at), jobs.WithUniqueKey(fmt.Sprintf("campaign-%d-user-%d", campaignID, userID)))
+}
+
+// JobServer runs the notification use cases triggered by background jobs
+type JobServer struct {
+	SendEmail         decorator.CommandHandler[command.SendEmailCommand]
+	DispatchCampaign  decorator.CommandHandler[command.DispatchCampaignCommand]
+	SendCampaignEmail decorator.CommandHandler[command.SendCampaignEmailCommand]
+}
+
+// RegisterJobs adds the notification job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, job SendEmailJob) error {
+		return s.SendEmail.Handle(ctx, command.SendEmailCommand{
+			To:      job.Message.To,
+			From:    job.Message.From,
+			Subject: job.Message.Subject,
+			HTML:    job.Message.HTML,
+			Sandbox: job.Message.Sandbox,
+		})
+	})
+	jobs.Register(w, func(ctx context.Context, job DispatchCampaignJob) error {
+		return s.DispatchCampaign.Handle(ctx, command.DispatchCampaignCommand{CampaignID: job.CampaignID, AfterUserID: job.AfterUserID})
+	})
+	jobs.Register(w, func(ctx context.Context, job SendCampaignEmailJob) error {
+		return s.SendCampaignEmail.Handle(ctx, command.SendCampaignEmailCommand{CampaignID: job.CampaignID, UserID: job.UserID})
+	})
+}
@@ -0,0 +1,23 @@
+package port_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/notification/port"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobCampaignQueue(t *testing.T) {
+	enqueuer := &recordingEnqueuer{}
+	queue := port.JobCampaignQueue{Jobs: enqueuer}
+	at := time.Now().Add(time.Minute)
+
+	assert.NoError(t, queue.Dispatch(context.Background(), 3, 500, at))
+	assert.NoError(t, queue.Send(context.Background(), 3, 7, at))
+
+	assert.Equal(t, []string{"campaign-3-after-500", "campaign-3-user-7"}, enqueuer.uniqueKeys, "steps are deduplicated")
+	assert.Equal(t, port.DispatchCampaignJob{CampaignID: 3, AfterUserID: 500}, enqueuer.jobs[0])
+	assert.Equal(t, port.SendCampaignEmailJob{CampaignID: 3, UserID: 7}, enqueuer.jobs[1])
+}
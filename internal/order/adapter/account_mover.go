@@ -0,0 +1,52 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// GormAccountMover moves the orders of merged users along with their summaries
+type GormAccountMover struct {
+	db *gorm.DB
+}
+
+func NewGormAccountMover(db *gorm.DB) userDomain.AccountMover {
+	return &GormAccountMover{db: db}
+}
+
+// MoveAccount rewrites the user of the summaries too, they are keyed by public ID and email
+func (m *GormAccountMover) MoveAccount(ctx context.Context, fromUserID, toUserID int64) ([]userDomain.MergeItem, error) {
+	db := persistence.Conn(ctx, m.db)
+	var users []userDomain.User
+	if err := db.Where("id IN ?", []int64{fromUserID, toUserID}).Find(&users).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	var from, to *userDomain.User
+	for i := range users {
+		switch users[i].ID {
+		case fromUserID:
+			from = &users[i]
+		case toUserID:
+			to = &users[i]
+		}
+	}
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("%w: merging user %d into %d", userDomain.ErrUserNotFound, fromUserID, toUserID)
+	}
+
+	result := db.Model(&domain.Order{}).Where("user_id = ?", fromUserID).Update("user_id", toUserID)
+	if result.Error != nil {
+		return nil, persistence.TranslateError(result.Error)
+	}
+	err := db.Model(&domain.OrderSummary{}).Where("user_id = ?", from.PublicID).
+		Updates(map[string]any{"user_id": to.PublicID, "user_email": to.Email.String()}).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return []userDomain.MergeItem{{Kind: "orders", Moved: int(result.RowsAffected)}}, nil
+}
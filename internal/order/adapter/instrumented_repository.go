@@ -0,0 +1,70 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/metrics"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// InstrumentedOrderRepository records the duration of every call to the wrapped repository
+type InstrumentedOrderRepository struct {
+	next    domain.OrderRepository
+	observe metrics.RepositoryObserver
+}
+
+func NewInstrumentedOrderRepository(next domain.OrderRepository, m *metrics.Metrics) domain.OrderRepository {
+	return &InstrumentedOrderRepository{next: next, observe: m.Repository("order")}
+}
+
+func (r *InstrumentedOrderRepository) Save(ctx context.Context, o *domain.Order) error {
+	defer r.observe.Since("Save", time.Now())
+	return r.next.Save(ctx, o)
+}
+
+func (r *InstrumentedOrderRepository) GetByID(ctx context.Context, id int64) (*domain.Order, error) {
+	defer r.observe.Since("GetByID", time.Now())
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *InstrumentedOrderRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Order, error) {
+	defer r.observe.Since("GetByPublicID", time.Now())
+	return r.next.GetByPublicID(ctx, publicID)
+}
+
+func (r *InstrumentedOrderRepository) ListChangedForUser(ctx context.Context, userID int64, after persistence.ChangePosition, until time.Time, limit int) ([]domain.Order, error) {
+	defer r.observe.Since("ListChangedForUser", time.Now())
+	return r.next.ListChangedForUser(ctx, userID, after, until, limit)
+}
+
+func (r *InstrumentedOrderRepository) GetByIDs(ctx context.Context, ids []int64) ([]domain.Order, error) {
+	defer r.observe.Since("GetByIDs", time.Now())
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r *InstrumentedOrderRepository) GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*domain.Order, error) {
+	defer r.observe.Since("GetByPublicIDAsOf", time.Now())
+	return r.next.GetByPublicIDAsOf(ctx, publicID, at)
+}
+
+func (r *InstrumentedOrderRepository) PublicIDs(ctx context.Context, ids []int64) (map[int64]string, error) {
+	defer r.observe.Since("PublicIDs", time.Now())
+	return r.next.PublicIDs(ctx, ids)
+}
+
+func (r *InstrumentedOrderRepository) UpdateStatus(ctx context.Context, o *domain.Order) error {
+	defer r.observe.Since("UpdateStatus", time.Now())
+	return r.next.UpdateStatus(ctx, o)
+}
+
+func (r *InstrumentedOrderRepository) UpdateFlag(ctx context.Context, o *domain.Order) error {
+	defer r.observe.Since("UpdateFlag", time.Now())
+	return r.next.UpdateFlag(ctx, o)
+}
+
+func (r *InstrumentedOrderRepository) FindInBatches(ctx context.Context, filter domain.OrderFilter, size int, fn func([]domain.Order) error) error {
+	defer r.observe.Since("FindInBatches", time.Now())
+	return r.next.FindInBatches(ctx, filter, size, fn)
+}
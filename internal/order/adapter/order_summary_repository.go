@@ -0,0 +1,44 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormOrderSummaryRepository struct {
+	*persistence.GenericRepository[domain.OrderSummary, string]
+	db *gorm.DB
+}
+
+func NewGormOrderSummaryRepository(db *gorm.DB) domain.OrderSummaryRepository {
+	return &GormOrderSummaryRepository{GenericRepository: persistence.NewGenericRepository[domain.OrderSummary, string](db), db: db}
+}
+
+func (r *GormOrderSummaryRepository) Save(ctx context.Context, s *domain.OrderSummary) error {
+	err := persistence.Conn(ctx, r.db).Clauses(clause.OnConflict{UpdateAll: true}).Create(s).Error
+	return persistence.TranslateError(err)
+}
+
+// List reads the summary table alone; everything a listing shows is denormalized into it
+func (r *GormOrderSummaryRepository) List(ctx context.Context, filter domain.OrderSummaryFilter) ([]domain.OrderSummary, error) {
+	query := r.db.WithContext(ctx).Order("placed_at DESC, order_id DESC")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var summaries []domain.OrderSummary
+	if err := query.Find(&summaries).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return summaries, nil
+}
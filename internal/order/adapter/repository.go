@@ -2,9 +2,15 @@ package adapter
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormOrderRepository struct {
@@ -15,15 +21,161 @@ func NewGormOrderRepository(db *gorm.DB) domain.OrderRepository {
 	return &GormOrderRepository{db: db}
 }
 
+// Save creates the order, numbering it with the next number of the day unless it has one
 func (r *GormOrderRepository) Save(ctx context.Context, o *domain.Order) error {
-	return r.db.WithContext(ctx).Create(o).Error
+	o.AssignPublicID()
+	if o.Number == "" {
+		now := time.Now()
+		n, err := r.nextNumber(ctx, now)
+		if err != nil {
+			return err
+		}
+		o.Number = domain.FormatNumber(now, n)
+	}
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(o).Error)
+}
+
+// nextNumber increments the order number sequence of the day of now in one upsert, which the
+// database serializes on the row of the day, and returns the incremented value
+func (r *GormOrderRepository) nextNumber(ctx context.Context, now time.Time) (int64, error) {
+	seq := domain.NumberSequence{Day: domain.NumberDay(now), LastNumber: 1}
+	err := persistence.Conn(ctx, r.db).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]any{"last_number": gorm.Expr("order_number_sequences.last_number + 1")}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "last_number"}}},
+	).Create(&seq).Error
+	if err != nil {
+		return 0, fmt.Errorf("next order number of %s: %w", seq.Day, persistence.TranslateError(err))
+	}
+	return seq.LastNumber, nil
 }
 
 func (r *GormOrderRepository) GetByID(ctx context.Context, id int64) (*domain.Order, error) {
-	var order domain.Order
-	err := r.db.WithContext(ctx).Preload("User").Preload("Product").First(&order, id).Error
+	return r.get(ctx, r.db.Where("orders.id = ?", id))
+}
+
+func (r *GormOrderRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Order, error) {
+	return r.get(ctx, r.db.Where("orders.public_id = ?", publicID))
+}
+
+// GetByPublicIDAsOf reads the order from orders_history. Its user, product and shipping address
+// are the current ones, which is enough to name them by public ID.
+func (r *GormOrderRepository) GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*domain.Order, error) {
+	current, err := r.GetByPublicID(ctx, publicID)
 	if err != nil {
 		return nil, err
 	}
+	o, err := temporal.AsOf[domain.Order](ctx, r.db, current.ID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	o.User, o.Product, o.ShippingAddress = current.User, current.Product, current.ShippingAddress
+	for _, c := range current.History {
+		if !c.ChangedAt.After(at) {
+			o.History = append(o.History, c)
+		}
+	}
+	return o, nil
+}
+
+// GetByIDs loads the orders like GetByID, with one query per preloaded relation whatever the
+// number of orders
+func (r *GormOrderRepository) GetByIDs(ctx context.Context, ids []int64) ([]domain.Order, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var orders []domain.Order
+	if err := r.preloaded(ctx).Where("orders.id IN ?", ids).Find(&orders).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return persistence.InKeyOrder(ids, orders, func(o *domain.Order) int64 { return o.ID }), nil
+}
+
+// get loads the order matching cond with its user, product, shipping address and status history
+func (r *GormOrderRepository) get(ctx context.Context, cond *gorm.DB) (*domain.Order, error) {
+	var order domain.Order
+	if err := r.preloaded(ctx).Where(cond).First(&order).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
 	return &order, nil
 }
+
+func (r *GormOrderRepository) preloaded(ctx context.Context) *gorm.DB {
+	return persistence.Conn(ctx, r.db).
+		Preload("User").
+		Preload("Product", unscoped).
+		Preload("ShippingAddress").
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("changed_at ASC, id ASC")
+		})
+}
+
+// unscoped preloads deleted products too, since orders keep referring to them
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+func (r *GormOrderRepository) ListChangedForUser(ctx context.Context, userID int64, after persistence.ChangePosition, until time.Time, limit int) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := persistence.ChangedAfter(r.preloaded(ctx).Where("orders.user_id = ?", userID), after, until, limit).Find(&orders).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return orders, nil
+}
+
+func (r *GormOrderRepository) PublicIDs(ctx context.Context, ids []int64) (map[int64]string, error) {
+	if len(ids) == 0 {
+		return map[int64]string{}, nil
+	}
+
+	var rows []struct {
+		ID       int64
+		PublicID string
+	}
+	err := persistence.Conn(ctx, r.db).Model(&domain.Order{}).Select("id, public_id").Where("id IN ?", ids).Scan(&rows).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	publicIDs := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		publicIDs[row.ID] = row.PublicID
+	}
+	return publicIDs, nil
+}
+
+func (r *GormOrderRepository) UpdateStatus(ctx context.Context, o *domain.Order) error {
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Order{}).Where("id = ?", o.ID).Update("status", o.Status).Error; err != nil {
+			return err
+		}
+		for i := range o.History {
+			if o.History[i].ID == 0 {
+				if err := tx.Create(&o.History[i]).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return persistence.TranslateError(err)
+}
+
+func (r *GormOrderRepository) UpdateFlag(ctx context.Context, o *domain.Order) error {
+	err := persistence.Conn(ctx, r.db).Model(&domain.Order{}).Where("id = ?", o.ID).
+		Updates(map[string]any{"flag_reason": o.FlagReason, "flagged_at": o.FlaggedAt}).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormOrderRepository) FindInBatches(ctx context.Context, filter domain.OrderFilter, size int, fn func([]domain.Order) error) error {
+	db := query.NewQueryBuilder(persistence.Conn(ctx, r.db).Model(&domain.Order{})).
+		ApplyFilters(filter).
+		AddPreload("User").
+		AddPreload("Product", unscoped).
+		Build()
+	return persistence.InBatches(db, size, fn)
+}
@@ -0,0 +1,279 @@
+package adapter_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+
+	err = db.AutoMigrate(&userDomain.User{}, &userDomain.Address{}, &productDomain.Product{}, &domain.Order{}, &domain.OrderStatusChange{}, &domain.NumberSequence{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Create(&userDomain.User{ID: 1, Email: "test@example.com", Active: true}).Error)
+	assert.NoError(t, db.Create(&productDomain.Product{ID: 1, Name: "Test Product", Stock: 10}).Error)
+
+	return db
+}
+
+func TestGormOrderRepository_ShippingAddress(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormOrderRepository(db)
+	ctx := context.Background()
+
+	shipTo, err := userDomain.NewAddress(1, userDomain.AddressDetails{
+		Address: address.Address{Name: "Test", Line1: "1 Main St", City: "Berlin", PostalCode: "10115", Country: "DE"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Create(shipTo).Error)
+
+	o := domain.MustNewOrder(1, 1, 2)
+	o.ShippingAddressID = &shipTo.ID
+	assert.NoError(t, o.Confirm())
+	assert.NoError(t, repo.Save(ctx, o))
+
+	found, err := repo.GetByPublicID(ctx, o.PublicID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found.ShippingAddress) {
+		assert.Equal(t, shipTo.PublicID, found.ShippingAddress.PublicID)
+	}
+
+	err = persistence.TranslateError(db.Delete(&userDomain.Address{}, shipTo.ID).Error)
+	assert.ErrorIs(t, err, persistence.ErrForeignKeyViolation, "an address an order ships to stays")
+}
+
+func TestGormOrderRepository_SaveAndGetByID(t *testing.T) {
+	repo := adapter.NewGormOrderRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	o := domain.MustNewOrder(1, 1, 2)
+	assert.NoError(t, o.Confirm())
+	assert.NoError(t, repo.Save(ctx, o))
+
+	found, err := repo.GetByID(ctx, o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusConfirmed, found.Status)
+	assert.Equal(t, userDomain.Email("test@example.com"), found.User.Email)
+	assert.Len(t, found.History, 1)
+}
+
+func TestGormOrderRepository_GetByIDs(t *testing.T) {
+	repo := adapter.NewGormOrderRepository(setupTestDB(t))
+	ctx := context.Background()
+	first, second := domain.MustNewOrder(1, 1, 1), domain.MustNewOrder(1, 1, 2)
+	assert.NoError(t, repo.Save(ctx, first))
+	assert.NoError(t, repo.Save(ctx, second))
+
+	orders, err := repo.GetByIDs(ctx, []int64{second.ID, 99, first.ID})
+
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, []int64{second.ID, first.ID}, []int64{orders[0].ID, orders[1].ID})
+		assert.Equal(t, userDomain.Email("test@example.com"), orders[0].User.Email)
+		assert.Equal(t, "Test Product", orders[1].Product.Name)
+	}
+}
+
+func TestGormOrderRepository_GetByID_NotFound(t *testing.T) {
+	repo := adapter.NewGormOrderRepository(setupTestDB(t))
+
+	_, err := repo.GetByID(context.Background(), 42)
+
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGormOrderRepository_Save_UnknownUser(t *testing.T) {
+	repo := adapter.NewGormOrderRepository(setupTestDB(t))
+
+	err := repo.Save(context.Background(), domain.MustNewOrder(999, 1, 1))
+
+	assert.ErrorIs(t, err, persistence.ErrForeignKeyViolation)
+}
+
+func TestGormOrderRepository_UpdateStatus(t *testing.T) {
+	repo := adapter.NewGormOrderRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	o := domain.MustNewOrder(1, 1, 2)
+	assert.NoError(t, o.Confirm())
+	assert.NoError(t, repo.Save(ctx, o))
+
+	found, err := repo.GetByID(ctx, o.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, found.Cancel())
+	assert.NoError(t, repo.UpdateStatus(ctx, found))
+
+	updated, err := repo.GetByID(ctx, o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusCancelled, updated.Status)
+	if assert.Len(t, updated.History, 2) {
+		assert.Equal(t, domain.StatusConfirmed, updated.History[1].FromStatus)
+		assert.Equal(t, domain.StatusCancelled, updated.History[1].ToStatus)
+	}
+}
+
+func TestGormOrderRepository_GetByPublicIDAsOf(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&domain.OrderVersion{}))
+	assert.NoError(t, db.Use(temporal.NewPlugin("orders")))
+	repo := adapter.NewGormOrderRepository(db)
+	ctx := context.Background()
+
+	beforePlaced := time.Now().UTC()
+	o := domain.MustNewOrder(1, 1, 2)
+	assert.NoError(t, o.Confirm())
+	assert.NoError(t, repo.Save(ctx, o))
+	placed := time.Now().UTC()
+	assert.NoError(t, o.Cancel())
+	assert.NoError(t, repo.UpdateStatus(ctx, o))
+
+	_, err := repo.GetByPublicIDAsOf(ctx, o.PublicID, beforePlaced)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+
+	then, err := repo.GetByPublicIDAsOf(ctx, o.PublicID, placed)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusConfirmed, then.Status)
+	assert.Equal(t, o.Number, then.Number)
+	assert.Equal(t, "test@example.com", then.User.Email.String())
+	assert.Len(t, then.History, 1, "the cancellation came later")
+
+	now, err := repo.GetByPublicIDAsOf(ctx, o.PublicID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusCancelled, now.Status)
+}
+
+func TestGormOrderRepository_UpdateFlag(t *testing.T) {
+	repo := adapter.NewGormOrderRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	o := domain.MustNewOrder(1, 1, 2)
+	assert.NoError(t, repo.Save(ctx, o))
+
+	assert.True(t, o.Flag(domain.FlagDisputed, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)))
+	assert.NoError(t, repo.UpdateFlag(ctx, o))
+
+	found, err := repo.GetByID(ctx, o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FlagDisputed, found.FlagReason)
+	if assert.NotNil(t, found.FlaggedAt) {
+		assert.True(t, found.FlaggedAt.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)))
+	}
+}
+
+func TestGormOrderRepository_Save_NumbersOrdersPerDay(t *testing.T) {
+	db := setupTestDB(t)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	repo := adapter.NewGormOrderRepository(db)
+	ctx := context.Background()
+
+	const orders = 20
+	numbers := make(chan string, orders)
+	var wg sync.WaitGroup
+	for i := 0; i < orders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o := domain.MustNewOrder(1, 1, 1)
+			if assert.NoError(t, repo.Save(ctx, o)) {
+				numbers <- o.Number
+			}
+		}()
+	}
+	wg.Wait()
+	close(numbers)
+
+	seen := make(map[string]bool)
+	for n := range numbers {
+		seen[n] = true
+	}
+	assert.Len(t, seen, orders, "every order gets its own number")
+	assert.True(t, seen[domain.FormatNumber(time.Now(), 1)])
+	assert.True(t, seen[domain.FormatNumber(time.Now(), orders)])
+
+	numbered := domain.MustNewOrder(1, 1, 1)
+	numbered.Number = "20240518-000001"
+	assert.NoError(t, repo.Save(ctx, numbered), "an order keeps the number it has")
+	duplicate := domain.MustNewOrder(1, 1, 1)
+	duplicate.Number = numbered.Number
+	assert.ErrorIs(t, repo.Save(ctx, duplicate), persistence.ErrDuplicateKey)
+}
+
+func TestGormOrderRepository_FindInBatches(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormOrderRepository(db)
+	ctx := context.Background()
+	assert.NoError(t, db.Create(&userDomain.User{ID: 2, Email: "other@example.org", Active: true}).Error)
+	for _, userID := range []int64{1, 2, 1, 1, 1} {
+		o := domain.MustNewOrder(userID, 1, 1)
+		assert.NoError(t, o.Confirm())
+		assert.NoError(t, repo.Save(ctx, o))
+	}
+	after := time.Now().Add(-time.Minute)
+
+	var sizes []int
+	var emails []userDomain.Email
+	err := repo.FindInBatches(ctx, domain.OrderFilter{UserEmail: "example.com", CreatedAfter: &after}, 2, func(batch []domain.Order) error {
+		sizes = append(sizes, len(batch))
+		for _, o := range batch {
+			emails = append(emails, o.User.Email)
+			assert.Equal(t, "Test Product", o.Product.Name)
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 2}, sizes)
+	assert.Len(t, emails, 4)
+	assert.NotContains(t, emails, userDomain.Email("other@example.org"))
+}
+
+func TestGormOrderRepository_ListChangedForUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormOrderRepository(db)
+	ctx := context.Background()
+	assert.NoError(t, db.Create(&userDomain.User{ID: 2, Email: "other@example.org", Active: true}).Error)
+	var orders []*domain.Order
+	for _, userID := range []int64{1, 2, 1} {
+		o := domain.MustNewOrder(userID, 1, 1)
+		assert.NoError(t, repo.Save(ctx, o))
+		orders = append(orders, o)
+	}
+	first, mine := orders[0], orders[2]
+
+	// A status change moves the first order after the last one
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, first.Confirm())
+	assert.NoError(t, repo.UpdateStatus(ctx, first))
+	assert.NoError(t, db.Delete(&productDomain.Product{}, 1).Error)
+
+	changed, err := repo.ListChangedForUser(ctx, 1, persistence.ChangePosition{}, time.Now().Add(time.Second), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, changed, 2) {
+		assert.Equal(t, mine.ID, changed[0].ID)
+		assert.Equal(t, first.ID, changed[1].ID)
+		assert.Equal(t, domain.StatusConfirmed, changed[1].Status)
+		assert.Equal(t, "Test Product", changed[1].Product.Name, "deleted products are still loaded")
+	}
+
+	last := changed[1]
+	changed, err = repo.ListChangedForUser(ctx, 1, persistence.ChangePosition{UpdatedAt: last.UpdatedAt, ID: last.ID}, time.Now().Add(time.Second), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+}
@@ -0,0 +1,23 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+)
+
+// ValidatingOrderRepository validates orders before delegating writes to the wrapped repository
+type ValidatingOrderRepository struct {
+	domain.OrderRepository
+}
+
+func NewValidatingOrderRepository(next domain.OrderRepository) domain.OrderRepository {
+	return &ValidatingOrderRepository{OrderRepository: next}
+}
+
+func (r *ValidatingOrderRepository) Save(ctx context.Context, o *domain.Order) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	return r.OrderRepository.Save(ctx, o)
+}
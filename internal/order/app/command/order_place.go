@@ -3,51 +3,483 @@ package command
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
+	couponDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
 	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
 	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
 	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
 )
 
 type PlaceOrderCommand struct {
-	UserID    int64
-	ProductID int64
-	Quantity  int
+	UserID    int64 `validate:"required,gt=0"`
+	ProductID int64 `validate:"required,gt=0"`
+	Quantity  int   `validate:"required,gt=0"`
+	// ShippingAddressID must be an address in the address book of the user
+	ShippingAddressID int64 `validate:"required,gt=0"`
+
+	// Payments are required when the handler has a payment gateway. Several instruments,
+	// e.g. a gift card and a card, each authorize their share of the order.
+	Payments []PaymentDetails `validate:"dive"`
+
+	// CouponCode takes the discount of a coupon off the order of a priced product; empty places
+	// the order without one
+	CouponCode string
+}
+
+// PaymentDetails is a payment authorized before an order is confirmed.
+// For priced products the amounts of all payments must add up to the order total, including tax
+// and shipping.
+type PaymentDetails struct {
+	Method string `validate:"required"`
+	Amount money.Money
 }
 
+// DefaultReservationTTL bounds how long an order that never completes holds stock
+const DefaultReservationTTL = 15 * time.Minute
+
 type PlaceOrderHandler struct {
 	OrderRepo   orderDomain.OrderRepository
 	UserRepo    userDomain.UserRepository
 	ProductRepo productDomain.ProductRepository
+	Addresses   userDomain.AddressRepository
+
+	// Reservations holds stock while the order is placed; stock only decreases once it is confirmed
+	Reservations   productDomain.StockReservationRepository
+	ReservationTTL time.Duration
+
+	// Pricing calculates the tax and shipping of orders of priced products; nil charges neither
+	Pricing *pricing.Engine
+	// Coupons redeems the coupons of orders; nil rejects orders with a coupon
+	Coupons couponDomain.CouponRepository
+
+	// Quota enforces the monthly order limit of the tenant's plan; nil disables it
+	Quota quotaDomain.Limiter
+
+	// Payments authorizes the payment before the order is confirmed; nil places orders without payment
+	Payments    paymentDomain.PaymentGateway
+	PaymentRepo paymentDomain.PaymentRepository
+
+	// Events receives OrderPlaced once the order is saved, and StockLow when the order takes the
+	// product's stock to its low-stock threshold or below; nil disables publishing
+	Events event.Publisher
+	// Outbox records OrderPlaced in the transaction that saves the order, so other services hear of
+	// every order that commits; a failed write fails the order. nil disables it.
+	Outbox event.Publisher
+	// LowStockThreshold applies to products without a reorder threshold of their own
+	LowStockThreshold int
+
+	// Locking selects how concurrent orders of one product are kept from overselling; empty is optimistic
+	Locking StockLocking
+	// Tx commits the stock decrement together with the order and its payments, so a failed save
+	// puts the stock back; with StockLockingPessimistic the whole placement runs in it. It is required.
+	Tx persistence.Transactor
+
+	// Hooks add the business rules of a deployment to placement
+	Hooks orderDomain.Hooks
 }
 
-func (h *PlaceOrderHandler) Handle(ctx context.Context, cmd PlaceOrderCommand) error {
-	// Get user by ID using repository
+// StockLocking selects how PlaceOrder keeps concurrent orders of one product from overselling
+type StockLocking string
+
+const (
+	// StockLockingOptimistic holds no lock across the order. Stock is reserved up front and the decrement at
+	// confirmation is guarded, so an order that loses a race fails after its payment was authorized and voided.
+	StockLockingOptimistic StockLocking = "optimistic"
+	// StockLockingPessimistic places the order in one transaction that starts with SELECT ... FOR UPDATE on the
+	// product, so concurrent orders of the same product wait for each other at the database. The row stays
+	// locked while the payment is authorized.
+	StockLockingPessimistic StockLocking = "pessimistic"
+)
+
+func (h *PlaceOrderHandler) Handle(ctx context.Context, cmd PlaceOrderCommand) (_ *orderDomain.Order, err error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+	if h.Tx == nil {
+		return nil, errors.New("placing orders needs a transactor")
+	}
+	if h.Payments != nil {
+		if err := validatePayments(cmd.Payments); err != nil {
+			return nil, err
+		}
+	}
+
+	// Count the order up front so concurrent requests can't overshoot the limit; give it back on failure
+	if h.Quota != nil {
+		if err := h.Quota.Consume(ctx, quotaDomain.MetricOrdersPerMonth, 1); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				if releaseErr := h.Quota.Release(ctx, quotaDomain.MetricOrdersPerMonth, 1); releaseErr != nil {
+					slog.ErrorContext(ctx, "releasing order quota failed", "error", releaseErr)
+				}
+			}
+		}()
+	}
+
+	qty, err := orderDomain.NewQuantity(cmd.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Release the authorizations of a failed order, including one whose transaction did not commit
+	var auths []*paymentDomain.Authorization
+	defer func() {
+		if err != nil {
+			for _, auth := range auths {
+				if voidErr := h.Payments.Void(ctx, auth.Reference); voidErr != nil {
+					slog.ErrorContext(ctx, "voiding payment authorization failed", "reference", auth.Reference, "error", voidErr)
+				}
+			}
+		}
+	}()
+
+	var o *orderDomain.Order
+	var placed orderDomain.OrderPlaced
+	place := func(ctx context.Context) (err error) {
+		o, placed, err = h.place(ctx, cmd, qty, &auths)
+		return err
+	}
+	if h.Locking == StockLockingPessimistic {
+		err = h.Tx.InTransaction(ctx, place)
+	} else {
+		err = place(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscribers such as the confirmation mail must not fail an order that is already placed
+	if h.Events != nil {
+		if err := h.Events.Publish(ctx, placed); err != nil {
+			slog.WarnContext(ctx, "publishing order placement failed", "order_id", o.ID, "error", err)
+		}
+		// The product was read before the reservation was confirmed, so its stock is the level before
+		// the order; the copy keeps the product of the order as it was read
+		product := o.Product
+		if low, err := product.Reserve(o.Quantity.Int(), h.LowStockThreshold, placed.PlacedAt); err == nil && low != nil {
+			if err := h.Events.Publish(ctx, *low); err != nil {
+				slog.WarnContext(ctx, "publishing low stock failed", "product_id", o.ProductID, "error", err)
+			}
+		}
+	}
+	for _, hook := range h.Hooks.PostConfirmation {
+		if err := hook.AfterConfirmation(ctx, o); err != nil {
+			slog.WarnContext(ctx, "post-confirmation hook failed", "order_id", o.ID, "hook", fmt.Sprintf("%T", hook), "error", err)
+		}
+	}
+	return o, nil
+}
+
+// place reserves stock, authorizes the payments and saves the confirmed order. Authorizations are
+// appended to auths as they are granted so Handle can void them when the order fails.
+func (h *PlaceOrderHandler) place(ctx context.Context, cmd PlaceOrderCommand, qty orderDomain.Quantity, auths *[]*paymentDomain.Authorization) (_ *orderDomain.Order, _ orderDomain.OrderPlaced, err error) {
+	var placed orderDomain.OrderPlaced
+
+	// Get user by ID using repository; only a missing record is reported as not found
 	u, err := h.UserRepo.GetByID(ctx, cmd.UserID)
 	if err != nil {
-		return errors.New("user not found")
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, placed, userDomain.ErrUserNotFound
+		}
+		return nil, placed, fmt.Errorf("get user %d: %w", cmd.UserID, err)
+	}
+	if u.Deactivated() {
+		return nil, placed, userDomain.ErrUserDeactivated
+	}
+	address, err := h.shippingAddress(ctx, u.ID, cmd.ShippingAddressID)
+	if err != nil {
+		return nil, placed, err
 	}
 
-	// Get product by ID using repository
-	p, err := h.ProductRepo.GetByID(ctx, cmd.ProductID)
+	// Get product by ID using repository; with pessimistic locking the row stays locked until the order is saved
+	getProduct := h.ProductRepo.GetByID
+	if h.Locking == StockLockingPessimistic {
+		getProduct = h.ProductRepo.GetByIDForUpdate
+	}
+	p, err := getProduct(ctx, cmd.ProductID)
 	if err != nil {
-		return errors.New("product not found")
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, placed, productDomain.ErrProductNotFound
+		}
+		return nil, placed, fmt.Errorf("get product %d: %w", cmd.ProductID, err)
 	}
 
-	// Reserve product stock (domain business logic)
-	if err := p.Reserve(cmd.Quantity); err != nil {
-		return err
+	placement := orderDomain.Placement{User: u, Product: p, Quantity: qty, ShippingAddress: address}
+	for _, hook := range h.Hooks.PreOrderPlacement {
+		if err := hook.BeforePlacement(ctx, placement); err != nil {
+			return nil, placed, err
+		}
+	}
+
+	// Hold the stock until payment succeeds; on failure the hold is released here or by the expiry job
+	reservation, err := h.Reservations.Reserve(ctx, p.ID, qty.Int(), time.Now().Add(h.reservationTTL()))
+	if err != nil {
+		if errors.Is(err, productDomain.ErrInsufficientStock) {
+			return nil, placed, err
+		}
+		return nil, placed, fmt.Errorf("reserve stock of product %d: %w", p.ID, err)
 	}
+	defer func() {
+		if err != nil {
+			if releaseErr := h.Reservations.Release(ctx, reservation.ID); releaseErr != nil && !errors.Is(releaseErr, productDomain.ErrReservationNotActive) {
+				slog.ErrorContext(ctx, "releasing stock reservation failed", "reservation_id", reservation.ID, "error", releaseErr)
+			}
+		}
+	}()
 
 	// Create and confirm order
-	o := orderDomain.NewOrder(u.ID, p.ID, cmd.Quantity)
-	o.Confirm()
+	o, err := orderDomain.NewOrder(u.ID, p.ID, qty)
+	if err != nil {
+		return nil, placed, err
+	}
+	o.ShippingAddressID = &address.ID
+	if o.UnitPrice, err = h.unitPrice(ctx, placement, p.Price()); err != nil {
+		return nil, placed, err
+	}
+	coupon, err := h.coupon(ctx, cmd.CouponCode, o.UnitPrice.Mul(int64(o.Quantity)))
+	if err != nil {
+		return nil, placed, err
+	}
+	if err := h.price(ctx, o, p, address, coupon); err != nil {
+		return nil, placed, err
+	}
+	// Count the use of the coupon before payment so concurrent orders can't overshoot its limits;
+	// give it back when the order fails
+	if coupon != nil {
+		var redemption *couponDomain.Redemption
+		if redemption, err = h.Coupons.Redeem(ctx, coupon, u.ID, o.PublicID); err != nil {
+			if errors.Is(err, couponDomain.ErrCouponUsedUp) {
+				return nil, placed, err
+			}
+			return nil, placed, fmt.Errorf("redeem coupon %s: %w", coupon.Code, err)
+		}
+		defer func() {
+			if err != nil {
+				if releaseErr := h.Coupons.Release(ctx, redemption.ID); releaseErr != nil && !errors.Is(releaseErr, persistence.ErrNotFound) {
+					slog.ErrorContext(ctx, "releasing coupon redemption failed", "redemption_id", redemption.ID, "error", releaseErr)
+				}
+			}
+		}()
+	}
+	o.Sandbox = mode.FromContext(ctx).IsSandbox()
+	if h.Payments != nil && o.UnitPrice.Currency != "" {
+		if err := validatePaymentTotal(cmd.Payments, o.Total()); err != nil {
+			return nil, placed, err
+		}
+	}
 
-	// Update product stock using repository
-	if err := h.ProductRepo.UpdateStock(ctx, p); err != nil {
-		return err
+	// Authorize before confirming so a declined payment never takes stock
+	if h.Payments != nil {
+		for i := range cmd.Payments {
+			auth, err := h.authorizePayment(ctx, &cmd.Payments[i], o)
+			if err != nil {
+				return nil, placed, err
+			}
+			*auths = append(*auths, auth)
+		}
+	}
+
+	if err := o.Confirm(); err != nil {
+		return nil, placed, err
+	}
+
+	// The stock decrement commits with the order, its payments and its outbox message, so a failed
+	// save puts the stock back and the deferred release frees the reservation again
+	err = h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		total, err := h.save(ctx, o, reservation.ID, *auths)
+		if err != nil {
+			return err
+		}
+		placed = orderDomain.OrderPlaced{
+			OrderID:     o.ID,
+			UserID:      u.ID,
+			Email:       u.Email,
+			ProductID:   p.ID,
+			ProductName: p.Name,
+			Quantity:    o.Quantity.Int(),
+			Amount:      total,
+			PlacedAt:    time.Now().UTC(),
+			Sandbox:     o.Sandbox,
+
+			OrderPublicID:   o.PublicID,
+			OrderNumber:     o.Number,
+			UserPublicID:    u.PublicID,
+			ProductPublicID: p.PublicID,
+			OrderVersion:    o.Version(),
+		}
+		if h.Outbox != nil {
+			if err := h.Outbox.Publish(ctx, placed); err != nil {
+				return fmt.Errorf("record placement of order %d in the outbox: %w", o.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, placed, err
+	}
+	// Attach the user, product and address after saving so GORM doesn't write them back, as GetByID would load them
+	o.User, o.Product, o.ShippingAddress = *u, *p, address
+	return o, placed, nil
+}
+
+// save turns the reservation into a stock decrement, then saves the confirmed order and a payment
+// per authorization, and returns the amount authorized
+func (h *PlaceOrderHandler) save(ctx context.Context, o *orderDomain.Order, reservationID int64, auths []*paymentDomain.Authorization) (money.Money, error) {
+	var total money.Money
+	if err := h.Reservations.Confirm(ctx, reservationID); err != nil {
+		if errors.Is(err, productDomain.ErrInsufficientStock) {
+			return total, err
+		}
+		return total, fmt.Errorf("confirm stock reservation %d: %w", reservationID, err)
+	}
+
+	if err := h.OrderRepo.Save(ctx, o); err != nil {
+		return total, fmt.Errorf("save order: %w", err)
+	}
+
+	for _, auth := range auths {
+		payment := paymentDomain.NewAuthorizedPayment(o.ID, h.Payments.Name(), auth)
+		payment.TenantID, payment.Sandbox = o.TenantID, o.Sandbox
+		if err := h.PaymentRepo.Save(ctx, payment); err != nil {
+			return total, fmt.Errorf("save payment of order %d: %w", o.ID, err)
+		}
+		total = money.Money{Amount: total.Amount + auth.Amount.Amount, Currency: auth.Amount.Currency}
+	}
+	return total, nil
+}
+
+// shippingAddress returns the address the order ships to. An address of another user is reported
+// like a missing one, so its existence is not revealed.
+func (h *PlaceOrderHandler) shippingAddress(ctx context.Context, userID, addressID int64) (*userDomain.Address, error) {
+	a, err := h.Addresses.GetByID(ctx, addressID)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return nil, fmt.Errorf("get address %d: %w", addressID, err)
+	}
+
+	var errs validation.Errors
+	errs.Check(err == nil && a.BelongsTo(userID), "shipping_address_id", "must be an address of the user")
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// unitPrice runs the pricing hooks over the list price of the product
+func (h *PlaceOrderHandler) unitPrice(ctx context.Context, placement orderDomain.Placement, price money.Money) (money.Money, error) {
+	for _, hook := range h.Hooks.Pricing {
+		next, err := hook.UnitPrice(ctx, placement, price)
+		if err != nil {
+			return money.Money{}, err
+		}
+		if next.Amount < 0 || next.Currency != price.Currency {
+			return money.Money{}, fmt.Errorf("pricing hook %T turned %s into %s, expected a price of at least 0 %s", hook, price, next, price.Currency)
+		}
+		price = next
+	}
+	return price, nil
+}
+
+// coupon returns the coupon of code if an order with subtotal can be placed with it now, and nil
+// for an empty code
+func (h *PlaceOrderHandler) coupon(ctx context.Context, code string, subtotal money.Money) (*couponDomain.Coupon, error) {
+	if code = couponDomain.NormalizeCode(code); code == "" {
+		return nil, nil
+	}
+	if h.Coupons == nil {
+		return nil, couponDomain.ErrCouponNotFound
+	}
+	c, err := h.Coupons.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, couponDomain.ErrCouponNotFound
+		}
+		return nil, fmt.Errorf("get coupon %s: %w", code, err)
 	}
+	if err := c.Check(subtotal, time.Now()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// price calculates the totals of an order of a priced product shipping to address, less the
+// discount of coupon unless it is nil
+func (h *PlaceOrderHandler) price(ctx context.Context, o *orderDomain.Order, p *productDomain.Product, address *userDomain.Address, coupon *couponDomain.Coupon) error {
+	if o.UnitPrice.Currency == "" {
+		return nil
+	}
+	engine := h.Pricing
+	if engine == nil {
+		engine = &pricing.Engine{}
+	}
+	var discount pricing.Discount
+	if coupon != nil {
+		discount = coupon
+		o.CouponCode = coupon.Code
+	}
+	q, err := engine.Quote(ctx, pricing.Destination{Country: address.Country, Region: address.Region}, discount,
+		pricing.Line{UnitPrice: o.UnitPrice, Quantity: int64(o.Quantity), Class: p.TaxClass})
+	if err != nil {
+		return fmt.Errorf("price order: %w", err)
+	}
+	o.Totals, o.TaxRate = q.Totals, q.Lines[0].TaxRate
+	return nil
+}
 
-	// Save order using repository
-	return h.OrderRepo.Save(ctx, o)
+func (h *PlaceOrderHandler) reservationTTL() time.Duration {
+	if h.ReservationTTL > 0 {
+		return h.ReservationTTL
+	}
+	return DefaultReservationTTL
+}
+
+// validatePayments requires at least one payment and a single currency across all of them
+func validatePayments(payments []PaymentDetails) error {
+	var errs validation.Errors
+	errs.Check(len(payments) > 0, "payments", "is required")
+	for i, p := range payments {
+		errs.Check(p.Amount.Amount > 0, fmt.Sprintf("payments[%d].amount", i), "must be greater than 0")
+		errs.Check(p.Amount.Currency == payments[0].Amount.Currency, fmt.Sprintf("payments[%d].currency", i), "must match the other payments")
+	}
+	return errs.Err()
+}
+
+// validatePaymentTotal requires the payments to add up to the total of an order of a priced product
+func validatePaymentTotal(payments []PaymentDetails, total money.Money) error {
+	amounts := make([]money.Money, len(payments))
+	for i, p := range payments {
+		amounts[i] = p.Amount
+	}
+	paid, err := money.Sum(amounts...)
+
+	var errs validation.Errors
+	errs.Check(err == nil && paid == total, "payments", fmt.Sprintf("must add up to the order total of %s", total))
+	return errs.Err()
+}
+
+func (h *PlaceOrderHandler) authorizePayment(ctx context.Context, details *PaymentDetails, o *orderDomain.Order) (*paymentDomain.Authorization, error) {
+	auth, err := h.Payments.Authorize(ctx, paymentDomain.AuthorizeRequest{
+		Amount:      details.Amount,
+		Method:      details.Method,
+		Description: fmt.Sprintf("Order of %d x product %d", o.Quantity.Int(), o.ProductID),
+	})
+	if err != nil {
+		if errors.Is(err, paymentDomain.ErrPaymentDeclined) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("authorize payment: %w", err)
+	}
+	return auth, nil
 }
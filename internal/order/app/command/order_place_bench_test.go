@@ -10,9 +10,10 @@ import (
 
 func BenchmarkPlaceOrderHandler_Handle(b *testing.B) {
 	cmd := PlaceOrderCommand{
-		UserID:    1,
-		ProductID: 1,
-		Quantity:  1,
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          1,
 	}
 
 	b.ResetTimer()
@@ -38,7 +39,9 @@ func BenchmarkPlaceOrderHandler_Handle(b *testing.B) {
 		handler := &PlaceOrderHandler{
 			UserRepo:    userRepo,
 			ProductRepo: productRepo,
+			Addresses:   newMockAddressRepository(),
 			OrderRepo:   orderRepo,
+			Tx:          &MockTransactor{},
 		}
 		b.StartTimer()
 
@@ -66,13 +69,16 @@ func BenchmarkPlaceOrderHandler_Handle_WithReusableMocks(b *testing.B) {
 	handler := &PlaceOrderHandler{
 		UserRepo:    userRepo,
 		ProductRepo: productRepo,
+		Addresses:   newMockAddressRepository(),
 		OrderRepo:   orderRepo,
+		Tx:          &MockTransactor{},
 	}
 
 	cmd := PlaceOrderCommand{
-		UserID:    1,
-		ProductID: 1,
-		Quantity:  1,
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          1,
 	}
 
 	b.ResetTimer()
@@ -87,9 +93,10 @@ func BenchmarkPlaceOrderHandler_Handle_WithReusableMocks(b *testing.B) {
 // Benchmark error path performance
 func BenchmarkPlaceOrderHandler_Handle_UserNotFound(b *testing.B) {
 	cmd := PlaceOrderCommand{
-		UserID:    999, // Non-existent user
-		ProductID: 1,
-		Quantity:  1,
+		UserID:            999, // Non-existent user
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          1,
 	}
 
 	b.ResetTimer()
@@ -103,7 +110,9 @@ func BenchmarkPlaceOrderHandler_Handle_UserNotFound(b *testing.B) {
 		handler := &PlaceOrderHandler{
 			UserRepo:    userRepo,
 			ProductRepo: productRepo,
+			Addresses:   newMockAddressRepository(),
 			OrderRepo:   orderRepo,
+			Tx:          &MockTransactor{},
 		}
 		b.StartTimer()
 
@@ -119,9 +128,10 @@ func BenchmarkPlaceOrderHandler_Handle_MemoryOptimized(b *testing.B) {
 	}
 
 	cmd := PlaceOrderCommand{
-		UserID:    1,
-		ProductID: 1,
-		Quantity:  1,
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          1,
 	}
 
 	b.ReportAllocs()
@@ -139,7 +149,9 @@ func BenchmarkPlaceOrderHandler_Handle_MemoryOptimized(b *testing.B) {
 		handler := &PlaceOrderHandler{
 			UserRepo:    userRepo,
 			ProductRepo: productRepo,
+			Addresses:   newMockAddressRepository(),
 			OrderRepo:   orderRepo,
+			Tx:          &MockTransactor{},
 		}
 
 		handler.Handle(context.Background(), cmd)
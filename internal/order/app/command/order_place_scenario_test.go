@@ -0,0 +1,72 @@
+package command
+
+import (
+	"testing"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+func TestScenario_OrderInStockIsConfirmed(t *testing.T) {
+	newScenario(t).
+		GivenUser().
+		GivenProductWithStock(5).
+		WhenPlaceOrder(2).
+		ThenOrderStatus(orderDomain.StatusConfirmed).
+		ThenStock(3).
+		ThenPublished(orderDomain.OrderPlacedEvent)
+}
+
+func TestScenario_OrderBeyondStockIsRejected(t *testing.T) {
+	newScenario(t).
+		GivenUser().
+		GivenProductWithStock(1).
+		WhenPlaceOrder(2).
+		ThenFailsWith(productDomain.ErrInsufficientStock).
+		ThenStock(1).
+		ThenPublished()
+}
+
+func TestScenario_OrderOfUnknownUserIsRejected(t *testing.T) {
+	s := newScenario(t).GivenProductWithStock(5)
+	s.user = &userDomain.User{ID: 42}
+
+	s.WhenPlaceOrder(1).
+		ThenFailsWith(userDomain.ErrUserNotFound).
+		ThenStock(5)
+}
+
+func TestScenario_PricedOrderIsPaidInFull(t *testing.T) {
+	newScenario(t).
+		GivenUser().
+		GivenProductWithStock(5).GivenPrice(1250, "EUR").
+		GivenPaymentGateway().
+		WhenPlaceOrder(2, Pay(2500, "EUR")).
+		ThenOrderStatus(orderDomain.StatusConfirmed).
+		ThenOrderTotal(2500, "EUR").
+		ThenPaymentsAuthorized(1, 0)
+}
+
+func TestScenario_SplitPaymentIsVoidedWhenOneInstrumentIsDeclined(t *testing.T) {
+	newScenario(t).
+		GivenUser().
+		GivenProductWithStock(5).GivenPrice(1250, "EUR").
+		GivenPaymentGateway("pm_card_declined").
+		WhenPlaceOrder(2, PayWith("gift_card", 1000, "EUR"), PayWith("pm_card_declined", 1500, "EUR")).
+		ThenFailsWith(paymentDomain.ErrPaymentDeclined).
+		ThenPaymentsAuthorized(1, 1).
+		ThenStock(5)
+}
+
+func TestScenario_SandboxOrderIsTagged(t *testing.T) {
+	newScenario(t).
+		GivenSandboxTenant().
+		GivenUser().
+		GivenProductWithStock(5).
+		GivenPaymentGateway().
+		WhenPlaceOrder(1, Pay(1000, "EUR")).
+		ThenOrderStatus(orderDomain.StatusConfirmed).
+		ThenSandboxOrder()
+}
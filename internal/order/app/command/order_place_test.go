@@ -3,11 +3,28 @@ package command
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 
+	couponDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	orderAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/order/adapter"
 	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	productAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
 	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
 	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 // Mock implementations for testing
@@ -23,7 +40,32 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*userDomain
 	if user, exists := m.users[id]; exists {
 		return user, nil
 	}
-	return nil, errors.New("user not found")
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockUserRepository) GetByPublicID(ctx context.Context, publicID string) (*userDomain.User, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, user := range m.users {
+		if user.PublicID == publicID {
+			return user, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockUserRepository) GetByIDs(ctx context.Context, ids []int64) ([]userDomain.User, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var users []userDomain.User
+	for _, id := range ids {
+		if user, exists := m.users[id]; exists {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
 }
 
 func (m *MockUserRepository) Save(ctx context.Context, u *userDomain.User) error {
@@ -37,9 +79,38 @@ func (m *MockUserRepository) Save(ctx context.Context, u *userDomain.User) error
 	return nil
 }
 
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email userDomain.Email) (*userDomain.User, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockUserRepository) ExistsByEmail(ctx context.Context, email userDomain.Email) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	for _, user := range m.users {
+		if user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockUserRepository) Search(ctx context.Context, filter userDomain.UserSearchFilter, page, pageSize int) ([]userDomain.User, int64, error) {
+	return nil, 0, m.err
+}
+
 type MockProductRepository struct {
-	products map[int64]*productDomain.Product
-	err      error
+	products        map[int64]*productDomain.Product
+	err             error
+	lockedForUpdate int
 }
 
 func (m *MockProductRepository) GetByID(ctx context.Context, id int64) (*productDomain.Product, error) {
@@ -49,7 +120,99 @@ func (m *MockProductRepository) GetByID(ctx context.Context, id int64) (*product
 	if product, exists := m.products[id]; exists {
 		return product, nil
 	}
-	return nil, errors.New("product not found")
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockProductRepository) GetByPublicID(ctx context.Context, publicID string) (*productDomain.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, product := range m.products {
+		if product.PublicID == publicID {
+			return product, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+// GetByPublicIDAsOf returns the current product; the mock keeps no history
+func (m *MockProductRepository) GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*productDomain.Product, error) {
+	return m.GetByPublicID(ctx, publicID)
+}
+
+func (m *MockProductRepository) GetByIDForUpdate(ctx context.Context, id int64) (*productDomain.Product, error) {
+	m.lockedForUpdate++
+	return m.GetByID(ctx, id)
+}
+
+func (m *MockProductRepository) GetByIDs(ctx context.Context, ids []int64) ([]productDomain.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var products []productDomain.Product
+	for _, id := range ids {
+		if product, exists := m.products[id]; exists {
+			products = append(products, *product)
+		}
+	}
+	return products, nil
+}
+
+func (m *MockProductRepository) GetByPublicIDs(ctx context.Context, publicIDs []string) ([]productDomain.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var products []productDomain.Product
+	for _, product := range m.products {
+		if slices.Contains(publicIDs, product.PublicID) {
+			products = append(products, *product)
+		}
+	}
+	return products, nil
+}
+
+func (m *MockProductRepository) GetBySKUs(ctx context.Context, skus []string) ([]productDomain.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var products []productDomain.Product
+	for _, product := range m.products {
+		if slices.Contains(skus, product.SKUValue()) {
+			products = append(products, *product)
+		}
+	}
+	return products, nil
+}
+
+func (m *MockProductRepository) List(ctx context.Context, filter productDomain.ProductFilter) ([]productDomain.Product, error) {
+	return nil, m.err
+}
+
+func (m *MockProductRepository) Count(ctx context.Context, filter productDomain.ProductFilter) (int64, error) {
+	return 0, m.err
+}
+
+func (m *MockProductRepository) ListLowStock(ctx context.Context, fallbackThreshold, limit int) ([]productDomain.Product, error) {
+	return nil, m.err
+}
+
+func (m *MockProductRepository) ListChanged(ctx context.Context, after persistence.ChangePosition, until time.Time, limit int) ([]productDomain.Product, error) {
+	return nil, m.err
+}
+
+func (m *MockProductRepository) Delete(ctx context.Context, id int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, exists := m.products[id]; !exists {
+		return persistence.ErrNotFound
+	}
+	delete(m.products, id)
+	return nil
+}
+
+func (m *MockProductRepository) FindInBatches(ctx context.Context, filter productDomain.ProductFilter, size int, fn func([]productDomain.Product) error) error {
+	return errors.New("not implemented")
 }
 
 func (m *MockProductRepository) Save(ctx context.Context, p *productDomain.Product) error {
@@ -63,17 +226,122 @@ func (m *MockProductRepository) Save(ctx context.Context, p *productDomain.Produ
 	return nil
 }
 
-func (m *MockProductRepository) UpdateStock(ctx context.Context, p *productDomain.Product) error {
+func (m *MockProductRepository) DecrementStock(ctx context.Context, productID int64, qty int) error {
 	if m.err != nil {
 		return m.err
 	}
-	if m.products == nil {
-		m.products = make(map[int64]*productDomain.Product)
+	p, ok := m.products[productID]
+	if !ok {
+		return persistence.ErrNotFound
 	}
-	m.products[p.ID] = p
+	if err := p.CheckStock(qty); err != nil {
+		return err
+	}
+	p.Stock -= qty
+	return nil
+}
+
+func (m *MockProductRepository) UpsertBySKU(ctx context.Context, products []productDomain.Product) error {
+	return m.err
+}
+
+func (m *MockProductRepository) GetStockLevels(ctx context.Context, ids []int64) (map[int64]int, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	levels := make(map[int64]int, len(ids))
+	for _, id := range ids {
+		if product, exists := m.products[id]; exists {
+			levels[id] = product.Stock
+		}
+	}
+	return levels, nil
+}
+
+func (m *MockProductRepository) BulkUpdateStock(ctx context.Context, adjustments []productDomain.StockAdjustment) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, adj := range adjustments {
+		if product, exists := m.products[adj.ProductID]; exists {
+			product.Stock += adj.Delta
+		}
+	}
+	return nil
+}
+
+// MockStockReservationRepository holds stock of a MockProductRepository and decrements it on confirmation
+type MockStockReservationRepository struct {
+	productDomain.StockReservationRepository
+	products     *MockProductRepository
+	reservations []*productDomain.StockReservation
+}
+
+func (m *MockStockReservationRepository) Reserve(ctx context.Context, productID int64, qty int, expiresAt time.Time) (*productDomain.StockReservation, error) {
+	product, err := m.products.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	available := product.Stock
+	for _, r := range m.reservations {
+		if r.ProductID == productID && r.IsActive(time.Now()) {
+			available -= r.Quantity
+		}
+	}
+	if available < qty {
+		return nil, productDomain.ErrInsufficientStock
+	}
+
+	reservation := &productDomain.StockReservation{
+		ID:        int64(len(m.reservations) + 1),
+		ProductID: productID,
+		Quantity:  qty,
+		Status:    productDomain.ReservationActive,
+		ExpiresAt: expiresAt,
+	}
+	m.reservations = append(m.reservations, reservation)
+	return reservation, nil
+}
+
+func (m *MockStockReservationRepository) Confirm(ctx context.Context, id int64) error {
+	reservation := m.reservations[id-1]
+	if !reservation.IsActive(time.Now()) {
+		return productDomain.ErrReservationNotActive
+	}
+	reservation.Status = productDomain.ReservationConfirmed
+	m.products.products[reservation.ProductID].Stock -= reservation.Quantity
+	return nil
+}
+
+func (m *MockStockReservationRepository) Release(ctx context.Context, id int64) error {
+	reservation := m.reservations[id-1]
+	if !reservation.IsActive(time.Now()) {
+		return productDomain.ErrReservationNotActive
+	}
+	reservation.Status = productDomain.ReservationReleased
 	return nil
 }
 
+// MockAddressRepository keeps addresses in memory; only GetByID is used by PlaceOrder
+type MockAddressRepository struct {
+	userDomain.AddressRepository
+	addresses map[int64]*userDomain.Address
+}
+
+// newMockAddressRepository holds address 1 of user 1
+func newMockAddressRepository() *MockAddressRepository {
+	return &MockAddressRepository{addresses: map[int64]*userDomain.Address{
+		1: {ID: 1, UserID: 1, AddressDetails: userDomain.AddressDetails{Address: address.Address{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", PostalCode: "10115", Country: "DE"}}},
+	}}
+}
+
+func (m *MockAddressRepository) GetByID(ctx context.Context, id int64) (*userDomain.Address, error) {
+	if a, ok := m.addresses[id]; ok {
+		return a, nil
+	}
+	return nil, persistence.ErrNotFound
+}
+
 type MockOrderRepository struct {
 	orders map[int64]*orderDomain.Order
 	err    error
@@ -99,7 +367,47 @@ func (m *MockOrderRepository) GetByID(ctx context.Context, id int64) (*orderDoma
 	if order, exists := m.orders[id]; exists {
 		return order, nil
 	}
-	return nil, errors.New("order not found")
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockOrderRepository) GetByPublicID(ctx context.Context, publicID string) (*orderDomain.Order, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, order := range m.orders {
+		if order.PublicID == publicID {
+			return order, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockOrderRepository) GetByIDs(ctx context.Context, ids []int64) ([]orderDomain.Order, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var orders []orderDomain.Order
+	for _, id := range ids {
+		if order, ok := m.orders[id]; ok && !slices.ContainsFunc(orders, func(o orderDomain.Order) bool { return o.ID == id }) {
+			orders = append(orders, *order)
+		}
+	}
+	return orders, nil
+}
+
+// GetByPublicIDAsOf returns the current order; the mock keeps no history
+func (m *MockOrderRepository) GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*orderDomain.Order, error) {
+	return m.GetByPublicID(ctx, publicID)
+}
+
+func (m *MockOrderRepository) PublicIDs(ctx context.Context, ids []int64) (map[int64]string, error) {
+	publicIDs := make(map[int64]string)
+	for _, id := range ids {
+		if order, ok := m.orders[id]; ok {
+			publicIDs[id] = order.PublicID
+		}
+	}
+	return publicIDs, nil
 }
 
 func TestPlaceOrderHandler_Handle_Success(t *testing.T) {
@@ -109,46 +417,50 @@ func TestPlaceOrderHandler_Handle_Success(t *testing.T) {
 			1: {ID: 1, Email: "test@example.com", Active: true},
 		},
 	}
-	
+
 	productRepo := &MockProductRepository{
 		products: map[int64]*productDomain.Product{
 			1: {ID: 1, Name: "Test Product", Stock: 10},
 		},
 	}
-	
+
 	orderRepo := &MockOrderRepository{}
-	
+
 	handler := &PlaceOrderHandler{
-		UserRepo:    userRepo,
-		ProductRepo: productRepo,
-		OrderRepo:   orderRepo,
+		UserRepo:     userRepo,
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Tx:           &MockTransactor{},
 	}
-	
+
 	cmd := PlaceOrderCommand{
-		UserID:    1,
-		ProductID: 1,
-		Quantity:  2,
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          2,
 	}
-	
+
 	// Act
-	err := handler.Handle(context.Background(), cmd)
-	
+	_, err := handler.Handle(context.Background(), cmd)
+
 	// Assert
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	
+
 	// Verify product stock was updated
 	product, _ := productRepo.GetByID(context.Background(), 1)
 	if product.Stock != 8 {
 		t.Errorf("Expected product stock to be 8, got %d", product.Stock)
 	}
-	
+
 	// Verify order was saved
 	if len(orderRepo.orders) != 1 {
 		t.Errorf("Expected 1 order to be saved, got %d", len(orderRepo.orders))
 	}
-	
+
 	// Verify order details
 	for _, order := range orderRepo.orders {
 		if order.UserID != 1 {
@@ -163,6 +475,43 @@ func TestPlaceOrderHandler_Handle_Success(t *testing.T) {
 		if order.Status != "CONFIRMED" {
 			t.Errorf("Expected order Status to be 'CONFIRMED', got %s", order.Status)
 		}
+		if order.ShippingAddressID == nil || *order.ShippingAddressID != 1 {
+			t.Errorf("Expected order to ship to address 1, got %v", order.ShippingAddressID)
+		}
+	}
+}
+
+func TestPlaceOrderHandler_Handle_ShippingAddressOfOtherUser(t *testing.T) {
+	// Arrange
+	productRepo := &MockProductRepository{products: map[int64]*productDomain.Product{
+		1: {ID: 1, Name: "Test Product", Stock: 10},
+	}}
+	addresses := newMockAddressRepository()
+	addresses.addresses[2] = &userDomain.Address{ID: 2, UserID: 2}
+	orderRepo := &MockOrderRepository{}
+	handler := &PlaceOrderHandler{
+		UserRepo: &MockUserRepository{users: map[int64]*userDomain.User{
+			1: {ID: 1, Email: "test@example.com", Active: true},
+		}},
+		ProductRepo:  productRepo,
+		Addresses:    addresses,
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Tx:           &MockTransactor{},
+	}
+
+	for _, addressID := range []int64{2, 999} {
+		// Act
+		_, err := handler.Handle(context.Background(), PlaceOrderCommand{UserID: 1, ShippingAddressID: addressID, ProductID: 1, Quantity: 1})
+
+		// Assert
+		var errs validation.Errors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "shipping_address_id" {
+			t.Errorf("Expected a validation error on shipping_address_id for address %d, got %v", addressID, err)
+		}
+	}
+	if len(orderRepo.orders) != 0 {
+		t.Errorf("Expected no orders to be saved, got %d", len(orderRepo.orders))
 	}
 }
 
@@ -171,22 +520,26 @@ func TestPlaceOrderHandler_Handle_UserNotFound(t *testing.T) {
 	userRepo := &MockUserRepository{users: map[int64]*userDomain.User{}}
 	productRepo := &MockProductRepository{}
 	orderRepo := &MockOrderRepository{}
-	
+
 	handler := &PlaceOrderHandler{
-		UserRepo:    userRepo,
-		ProductRepo: productRepo,
-		OrderRepo:   orderRepo,
+		UserRepo:     userRepo,
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Tx:           &MockTransactor{},
 	}
-	
+
 	cmd := PlaceOrderCommand{
-		UserID:    999,
-		ProductID: 1,
-		Quantity:  2,
+		UserID:            999,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          2,
 	}
-	
+
 	// Act
-	err := handler.Handle(context.Background(), cmd)
-	
+	_, err := handler.Handle(context.Background(), cmd)
+
 	// Assert
 	if err == nil {
 		t.Error("Expected error for user not found, got nil")
@@ -196,6 +549,36 @@ func TestPlaceOrderHandler_Handle_UserNotFound(t *testing.T) {
 	}
 }
 
+func TestPlaceOrderHandler_Handle_UserDeactivated(t *testing.T) {
+	// Arrange
+	deactivatedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	productRepo := &MockProductRepository{products: map[int64]*productDomain.Product{
+		1: {ID: 1, Name: "Test Product", Stock: 10},
+	}}
+	orderRepo := &MockOrderRepository{}
+	handler := &PlaceOrderHandler{
+		UserRepo: &MockUserRepository{users: map[int64]*userDomain.User{
+			1: {ID: 1, Email: "test@example.com", Active: true, DeactivatedAt: &deactivatedAt},
+		}},
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Tx:           &MockTransactor{},
+	}
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 1})
+
+	// Assert
+	if !errors.Is(err, userDomain.ErrUserDeactivated) {
+		t.Errorf("Expected ErrUserDeactivated, got %v", err)
+	}
+	if len(orderRepo.orders) != 0 || productRepo.products[1].Stock != 10 {
+		t.Errorf("Expected no order and untouched stock, got %d orders and stock %d", len(orderRepo.orders), productRepo.products[1].Stock)
+	}
+}
+
 func TestPlaceOrderHandler_Handle_ProductNotFound(t *testing.T) {
 	// Arrange
 	userRepo := &MockUserRepository{
@@ -205,22 +588,26 @@ func TestPlaceOrderHandler_Handle_ProductNotFound(t *testing.T) {
 	}
 	productRepo := &MockProductRepository{products: map[int64]*productDomain.Product{}}
 	orderRepo := &MockOrderRepository{}
-	
+
 	handler := &PlaceOrderHandler{
-		UserRepo:    userRepo,
-		ProductRepo: productRepo,
-		OrderRepo:   orderRepo,
+		UserRepo:     userRepo,
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Tx:           &MockTransactor{},
 	}
-	
+
 	cmd := PlaceOrderCommand{
-		UserID:    1,
-		ProductID: 999,
-		Quantity:  2,
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         999,
+		Quantity:          2,
 	}
-	
+
 	// Act
-	err := handler.Handle(context.Background(), cmd)
-	
+	_, err := handler.Handle(context.Background(), cmd)
+
 	// Assert
 	if err == nil {
 		t.Error("Expected error for product not found, got nil")
@@ -237,30 +624,34 @@ func TestPlaceOrderHandler_Handle_InsufficientStock(t *testing.T) {
 			1: {ID: 1, Email: "test@example.com", Active: true},
 		},
 	}
-	
+
 	productRepo := &MockProductRepository{
 		products: map[int64]*productDomain.Product{
 			1: {ID: 1, Name: "Test Product", Stock: 1}, // Only 1 in stock
 		},
 	}
-	
+
 	orderRepo := &MockOrderRepository{}
-	
+
 	handler := &PlaceOrderHandler{
-		UserRepo:    userRepo,
-		ProductRepo: productRepo,
-		OrderRepo:   orderRepo,
+		UserRepo:     userRepo,
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Tx:           &MockTransactor{},
 	}
-	
+
 	cmd := PlaceOrderCommand{
-		UserID:    1,
-		ProductID: 1,
-		Quantity:  5, // Requesting more than available
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          5, // Requesting more than available
 	}
-	
+
 	// Act
-	err := handler.Handle(context.Background(), cmd)
-	
+	_, err := handler.Handle(context.Background(), cmd)
+
 	// Assert
 	if err == nil {
 		t.Error("Expected error for insufficient stock, got nil")
@@ -268,7 +659,7 @@ func TestPlaceOrderHandler_Handle_InsufficientStock(t *testing.T) {
 	if err.Error() != "insufficient stock" {
 		t.Errorf("Expected error message 'insufficient stock', got %s", err.Error())
 	}
-	
+
 	// Verify no order was saved
 	if len(orderRepo.orders) != 0 {
 		t.Errorf("Expected no orders to be saved, got %d", len(orderRepo.orders))
@@ -282,37 +673,1048 @@ func TestPlaceOrderHandler_Handle_RepositoryError(t *testing.T) {
 			1: {ID: 1, Email: "test@example.com", Active: true},
 		},
 	}
-	
+
 	productRepo := &MockProductRepository{
 		products: map[int64]*productDomain.Product{
 			1: {ID: 1, Name: "Test Product", Stock: 10},
 		},
 	}
-	
+
 	orderRepo := &MockOrderRepository{
 		err: errors.New("database connection failed"), // Simulate DB error
 	}
-	
+
 	handler := &PlaceOrderHandler{
-		UserRepo:    userRepo,
-		ProductRepo: productRepo,
-		OrderRepo:   orderRepo,
+		UserRepo:     userRepo,
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Tx:           &MockTransactor{},
 	}
-	
+
 	cmd := PlaceOrderCommand{
-		UserID:    1,
-		ProductID: 1,
-		Quantity:  2,
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          2,
 	}
-	
+
 	// Act
-	err := handler.Handle(context.Background(), cmd)
-	
+	_, err := handler.Handle(context.Background(), cmd)
+
 	// Assert
 	if err == nil {
-		t.Error("Expected error from repository, got nil")
+		t.Fatal("Expected error from repository, got nil")
+	}
+	if !errors.Is(err, orderRepo.err) {
+		t.Errorf("Expected error to wrap 'database connection failed', got %s", err.Error())
+	}
+}
+
+func TestPlaceOrderHandler_Handle_InvalidQuantity(t *testing.T) {
+	// Arrange
+	orderRepo := &MockOrderRepository{}
+
+	handler := &PlaceOrderHandler{
+		UserRepo:    &MockUserRepository{},
+		ProductRepo: &MockProductRepository{},
+		Addresses:   newMockAddressRepository(),
+		OrderRepo:   orderRepo,
+		Tx:          &MockTransactor{},
+	}
+
+	cmd := PlaceOrderCommand{
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          0,
+	}
+
+	// Act
+	_, err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+	if len(errs) != 1 || errs[0].Field != "quantity" {
+		t.Errorf("Expected a single error on quantity, got %v", errs)
+	}
+	if len(orderRepo.orders) != 0 {
+		t.Errorf("Expected no orders to be saved, got %d", len(orderRepo.orders))
+	}
+}
+
+func TestPlaceOrderHandler_Handle_UserLookupInfrastructureError(t *testing.T) {
+	// Arrange
+	dbErr := errors.New("database connection failed")
+	handler := &PlaceOrderHandler{
+		UserRepo:    &MockUserRepository{err: dbErr},
+		ProductRepo: &MockProductRepository{},
+		Addresses:   newMockAddressRepository(),
+		OrderRepo:   &MockOrderRepository{},
+		Tx:          &MockTransactor{},
+	}
+
+	cmd := PlaceOrderCommand{
+		UserID:            1,
+		ShippingAddressID: 1,
+		ProductID:         1,
+		Quantity:          1,
+	}
+
+	// Act
+	_, err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if errors.Is(err, userDomain.ErrUserNotFound) {
+		t.Fatal("Expected infrastructure error not to be reported as user not found")
+	}
+	if !errors.Is(err, dbErr) {
+		t.Errorf("Expected error to wrap the repository error, got %v", err)
+	}
+}
+
+// MockLimiter allows up to limit units and tracks the consumed amount
+type MockLimiter struct {
+	limit int64
+	used  int64
+}
+
+func (m *MockLimiter) Consume(ctx context.Context, metric quotaDomain.Metric, n int64) error {
+	if m.used+n > m.limit {
+		return &quotaDomain.ExceededError{Metric: metric, Limit: m.limit, Plan: "free"}
 	}
-	if err.Error() != "database connection failed" {
-		t.Errorf("Expected error message 'database connection failed', got %s", err.Error())
+	m.used += n
+	return nil
+}
+
+func (m *MockLimiter) Release(ctx context.Context, metric quotaDomain.Metric, n int64) error {
+	m.used -= n
+	return nil
+}
+
+func TestPlaceOrderHandler_Handle_MonthlyOrderQuota(t *testing.T) {
+	// Arrange
+	quota := &MockLimiter{limit: 1}
+	orderRepo := &MockOrderRepository{}
+	productRepo := &MockProductRepository{products: map[int64]*productDomain.Product{
+		1: {ID: 1, Name: "Test Product", Stock: 10},
+	}}
+	handler := &PlaceOrderHandler{
+		UserRepo: &MockUserRepository{users: map[int64]*userDomain.User{
+			1: {ID: 1, Email: "test@example.com", Active: true},
+		}},
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Quota:        quota,
+		Tx:           &MockTransactor{},
+	}
+	cmd := PlaceOrderCommand{UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 1}
+
+	// Act
+	_, first := handler.Handle(context.Background(), cmd)
+	_, second := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if first != nil {
+		t.Fatalf("Expected first order to succeed, got %v", first)
+	}
+	if !errors.Is(second, quotaDomain.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", second)
+	}
+	if len(orderRepo.orders) != 1 {
+		t.Errorf("Expected 1 order to be saved, got %d", len(orderRepo.orders))
+	}
+}
+
+func TestPlaceOrderHandler_Handle_ReleasesQuotaOnFailure(t *testing.T) {
+	// Arrange
+	quota := &MockLimiter{limit: 5}
+	handler := &PlaceOrderHandler{
+		UserRepo:    &MockUserRepository{users: map[int64]*userDomain.User{}},
+		ProductRepo: &MockProductRepository{},
+		Addresses:   newMockAddressRepository(),
+		OrderRepo:   &MockOrderRepository{},
+		Quota:       quota,
+		Tx:          &MockTransactor{},
+	}
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{UserID: 999, ShippingAddressID: 1, ProductID: 1, Quantity: 1})
+
+	// Assert
+	if !errors.Is(err, userDomain.ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+	if quota.used != 0 {
+		t.Errorf("Expected failed order to release its quota, got %d used", quota.used)
+	}
+}
+
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, o *orderDomain.Order) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.orders[o.ID] = o
+	return nil
+}
+
+func (m *MockOrderRepository) UpdateFlag(ctx context.Context, o *orderDomain.Order) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.orders[o.ID] = o
+	return nil
+}
+
+func (m *MockOrderRepository) ListChangedForUser(ctx context.Context, userID int64, after persistence.ChangePosition, until time.Time, limit int) ([]orderDomain.Order, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrderRepository) FindInBatches(ctx context.Context, filter orderDomain.OrderFilter, size int, fn func([]orderDomain.Order) error) error {
+	return errors.New("not implemented")
+}
+
+// MockPaymentGateway authorizes every request unless decline is set or the method is declineMethod
+type MockPaymentGateway struct {
+	paymentDomain.PaymentGateway
+	decline       bool
+	declineMethod string
+	authorized    []paymentDomain.AuthorizeRequest
+	voided        []string
+}
+
+func (m *MockPaymentGateway) Name() string {
+	return "mock"
+}
+
+func (m *MockPaymentGateway) Authorize(ctx context.Context, req paymentDomain.AuthorizeRequest) (*paymentDomain.Authorization, error) {
+	if m.decline {
+		return nil, paymentDomain.ErrPaymentDeclined
+	}
+	if m.declineMethod != "" && req.Method == m.declineMethod {
+		return nil, paymentDomain.ErrPaymentDeclined
+	}
+	m.authorized = append(m.authorized, req)
+	return &paymentDomain.Authorization{Reference: fmt.Sprintf("auth_%d", len(m.authorized)), Amount: req.Amount}, nil
+}
+
+func (m *MockPaymentGateway) Void(ctx context.Context, reference string) error {
+	m.voided = append(m.voided, reference)
+	return nil
+}
+
+type MockPaymentRepository struct {
+	paymentDomain.PaymentRepository
+	payments []*paymentDomain.Payment
+	err      error
+}
+
+func (m *MockPaymentRepository) Save(ctx context.Context, p *paymentDomain.Payment) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.payments = append(m.payments, p)
+	return nil
+}
+
+func newPaidOrderHandler(gateway *MockPaymentGateway, orderRepo *MockOrderRepository, payments *MockPaymentRepository) *PlaceOrderHandler {
+	productRepo := &MockProductRepository{products: map[int64]*productDomain.Product{
+		1: {ID: 1, Name: "Test Product", Stock: 10},
+	}}
+	return &PlaceOrderHandler{
+		UserRepo: &MockUserRepository{users: map[int64]*userDomain.User{
+			1: {ID: 1, Email: "test@example.com", Active: true},
+		}},
+		ProductRepo:  productRepo,
+		Addresses:    newMockAddressRepository(),
+		OrderRepo:    orderRepo,
+		Reservations: &MockStockReservationRepository{products: productRepo},
+		Payments:     gateway,
+		PaymentRepo:  payments,
+		Tx:           &MockTransactor{},
+	}
+}
+
+func TestPlaceOrderHandler_Handle_AuthorizesPayment(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{}
+	payments := &MockPaymentRepository{}
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{}, payments)
+	amount := money.Money{Amount: 2500, Currency: "EUR"}
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: amount}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(gateway.authorized) != 1 || gateway.authorized[0].Amount != amount {
+		t.Errorf("Expected one authorization of %s, got %+v", amount, gateway.authorized)
+	}
+	if len(payments.payments) != 1 {
+		t.Fatalf("Expected 1 payment to be saved, got %d", len(payments.payments))
+	}
+	payment := payments.payments[0]
+	if payment.OrderID != 1 || payment.Status != paymentDomain.StatusAuthorized || payment.Reference != "auth_1" {
+		t.Errorf("Unexpected payment: %+v", payment)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_PaymentDeclined(t *testing.T) {
+	// Arrange
+	orderRepo := &MockOrderRepository{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{decline: true}, orderRepo, &MockPaymentRepository{})
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_declined", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if !errors.Is(err, paymentDomain.ErrPaymentDeclined) {
+		t.Fatalf("Expected ErrPaymentDeclined, got %v", err)
+	}
+	if len(orderRepo.orders) != 0 {
+		t.Errorf("Expected no order to be saved, got %d", len(orderRepo.orders))
+	}
+	reservations := handler.Reservations.(*MockStockReservationRepository)
+	if len(reservations.reservations) != 1 || reservations.reservations[0].Status != productDomain.ReservationReleased {
+		t.Errorf("Expected the stock reservation to be released, got %+v", reservations.reservations)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_VoidsPaymentOnFailure(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{}
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{err: errors.New("connection reset")}, &MockPaymentRepository{})
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the save failure to be returned")
+	}
+	if len(gateway.voided) != 1 || gateway.voided[0] != "auth_1" {
+		t.Errorf("Expected the authorization to be voided, got %v", gateway.voided)
+	}
+}
+
+// newStoredStockHandler is newPaidOrderHandler with product 1, its reservations and the orders of
+// an in-memory database, so a unit of work that fails rolls its changes back
+func newStoredStockHandler(t *testing.T, payments paymentDomain.PaymentRepository) (*PlaceOrderHandler, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&productDomain.Product{}, &productDomain.StockReservation{}, &orderDomain.Order{}, &orderDomain.OrderStatusChange{}, &orderDomain.NumberSequence{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&productDomain.Product{ID: 1, Name: "Test Product", Stock: 10}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+	products := productAdapter.NewGormProductRepository(db)
+	handler.ProductRepo, handler.Reservations = products, productAdapter.NewGormStockReservationRepository(db, products)
+	handler.OrderRepo, handler.PaymentRepo, handler.Tx = orderAdapter.NewGormOrderRepository(db), payments, persistence.NewGormTransactor(db)
+	return handler, db
+}
+
+func storedStock(t *testing.T, db *gorm.DB) int {
+	var p productDomain.Product
+	if err := db.First(&p, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	return p.Stock
+}
+
+func TestPlaceOrderHandler_Handle_FailedSaveKeepsStock(t *testing.T) {
+	// Arrange
+	handler, db := newStoredStockHandler(t, &MockPaymentRepository{})
+	handler.OrderRepo = &MockOrderRepository{err: errors.New("connection reset")}
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the save failure to be returned")
+	}
+	if stock := storedStock(t, db); stock != 10 {
+		t.Errorf("Expected the stock decrement to be rolled back, got a stock of %d", stock)
+	}
+	var reservation productDomain.StockReservation
+	if err := db.First(&reservation).Error; err != nil || reservation.Status != productDomain.ReservationReleased {
+		t.Errorf("Expected the reservation to be released, got %+v (%v)", reservation, err)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_FailedPaymentSaveDiscardsOrder(t *testing.T) {
+	// Arrange
+	handler, db := newStoredStockHandler(t, &MockPaymentRepository{err: errors.New("connection reset")})
+	gateway := handler.Payments.(*MockPaymentGateway)
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the payment save failure to be returned")
+	}
+	var orders int64
+	if err := db.Model(&orderDomain.Order{}).Count(&orders).Error; err != nil || orders != 0 {
+		t.Errorf("Expected the order to be rolled back with its payments, got %d orders (%v)", orders, err)
+	}
+	if stock := storedStock(t, db); stock != 10 {
+		t.Errorf("Expected the stock decrement to be rolled back, got a stock of %d", stock)
+	}
+	if len(gateway.voided) != 1 || gateway.voided[0] != "auth_1" {
+		t.Errorf("Expected the authorization to be voided, got %v", gateway.voided)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_FailedOutboxWriteDiscardsOrder(t *testing.T) {
+	// Arrange
+	handler, db := newStoredStockHandler(t, &MockPaymentRepository{})
+	handler.Outbox = &MockPublisher{err: errors.New("connection reset")}
+	events := &MockPublisher{}
+	handler.Events = events
+	gateway := handler.Payments.(*MockPaymentGateway)
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the outbox failure to be returned")
+	}
+	var orders int64
+	if err := db.Model(&orderDomain.Order{}).Count(&orders).Error; err != nil || orders != 0 {
+		t.Errorf("Expected the order to be rolled back with its outbox message, got %d orders (%v)", orders, err)
+	}
+	if stock := storedStock(t, db); stock != 10 {
+		t.Errorf("Expected the stock decrement to be rolled back, got a stock of %d", stock)
+	}
+	if len(gateway.voided) != 1 || gateway.voided[0] != "auth_1" {
+		t.Errorf("Expected the authorization to be voided, got %v", gateway.voided)
+	}
+	if len(events.events) != 0 {
+		t.Errorf("Expected no event for a discarded order, got %+v", events.events)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_RecordsOrderPlacedInOutbox(t *testing.T) {
+	// Arrange
+	outbox := &MockPublisher{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+	handler.Outbox = outbox
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(outbox.events) != 1 || outbox.events[0].EventName() != orderDomain.OrderPlacedEvent {
+		t.Errorf("Expected OrderPlaced in the outbox, got %+v", outbox.events)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_RequiresPayment(t *testing.T) {
+	// Arrange
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2})
+
+	// Assert
+	if !validation.IsValidationError(err) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_RecordsUnitPriceOfPricedProduct(t *testing.T) {
+	// Arrange
+	orderRepo := &MockOrderRepository{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, orderRepo, &MockPaymentRepository{})
+	product, _ := handler.ProductRepo.GetByID(context.Background(), 1)
+	product.SetPrice(money.Money{Amount: 1250, Currency: "EUR"})
+
+	// Act
+	o, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := (money.Money{Amount: 2500, Currency: "EUR"}); o.Total() != want {
+		t.Errorf("Expected a total of %s, got %s", want, o.Total())
+	}
+}
+
+func TestPlaceOrderHandler_Handle_ChargesTaxAndShipping(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{}
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{}, &MockPaymentRepository{})
+	handler.Pricing = &pricing.Engine{
+		TaxRules:     []pricing.TaxRule{{Region: "DE", Rate: 1900}, {Region: "DE", Class: "reduced", Rate: 700}},
+		ShippingFees: []pricing.ShippingFee{{Region: "*", Fee: money.Money{Amount: 490, Currency: "EUR"}}},
+	}
+	product, _ := handler.ProductRepo.GetByID(context.Background(), 1)
+	product.SetPrice(money.Money{Amount: 1250, Currency: "EUR"})
+	product.TaxClass = "reduced"
+
+	// Act
+	o, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 3165, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := pricing.Totals{
+		Subtotal: money.Money{Amount: 2500, Currency: "EUR"},
+		Discount: money.Money{Amount: 0, Currency: "EUR"},
+		Tax:      money.Money{Amount: 175, Currency: "EUR"},
+		Shipping: money.Money{Amount: 490, Currency: "EUR"},
+		Total:    money.Money{Amount: 3165, Currency: "EUR"},
+	}
+	if o.Totals != want || o.TaxRate != 700 {
+		t.Errorf("Expected totals %+v at 7%%, got %+v at %s%%", want, o.Totals, o.TaxRate.Percent())
+	}
+	if o.Total() != want.Total {
+		t.Errorf("Expected a total of %s, got %s", want.Total, o.Total())
+	}
+	if len(gateway.authorized) != 1 || gateway.authorized[0].Amount != want.Total {
+		t.Errorf("Expected one authorization of %s, got %+v", want.Total, gateway.authorized)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_RejectsPaymentsNotMatchingTotal(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{}
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{}, &MockPaymentRepository{})
+	product, _ := handler.ProductRepo.GetByID(context.Background(), 1)
+	product.SetPrice(money.Money{Amount: 1250, Currency: "EUR"})
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 1250, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if !validation.IsValidationError(err) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+	if len(gateway.authorized) != 0 {
+		t.Errorf("Expected no authorization, got %+v", gateway.authorized)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_SplitsPaymentAcrossInstruments(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{}
+	payments := &MockPaymentRepository{}
+	publisher := &MockPublisher{}
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{}, payments)
+	handler.Events = publisher
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{
+			{Method: "gift_card_123", Amount: money.Money{Amount: 1000, Currency: "EUR"}},
+			{Method: "pm_card_visa", Amount: money.Money{Amount: 1500, Currency: "EUR"}},
+		},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(payments.payments) != 2 || payments.payments[0].Reference != "auth_1" || payments.payments[1].Reference != "auth_2" {
+		t.Fatalf("Expected a payment per instrument, got %+v", payments.payments)
+	}
+	if len(payments.payments[1].Entries) != 1 || payments.payments[1].Entries[0].Kind != paymentDomain.EntryAuthorization {
+		t.Errorf("Expected an authorization entry, got %+v", payments.payments[1].Entries)
+	}
+	placed := publisher.events[0].(orderDomain.OrderPlaced)
+	if placed.Amount != (money.Money{Amount: 2500, Currency: "EUR"}) {
+		t.Errorf("Expected the order amount to be the sum of the payments, got %s", placed.Amount)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_VoidsOtherInstrumentsOnDecline(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{declineMethod: "pm_card_declined"}
+	payments := &MockPaymentRepository{}
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{}, payments)
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{
+			{Method: "gift_card_123", Amount: money.Money{Amount: 1000, Currency: "EUR"}},
+			{Method: "pm_card_declined", Amount: money.Money{Amount: 1500, Currency: "EUR"}},
+		},
+	})
+
+	// Assert
+	if !errors.Is(err, paymentDomain.ErrPaymentDeclined) {
+		t.Fatalf("Expected ErrPaymentDeclined, got %v", err)
+	}
+	if len(gateway.voided) != 1 || gateway.voided[0] != "auth_1" {
+		t.Errorf("Expected the gift card authorization to be voided, got %v", gateway.voided)
+	}
+	if len(payments.payments) != 0 {
+		t.Errorf("Expected no payment to be saved, got %d", len(payments.payments))
+	}
+}
+
+func TestPlaceOrderHandler_Handle_RejectsMixedCurrencies(t *testing.T) {
+	// Arrange
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{
+			{Method: "gift_card_123", Amount: money.Money{Amount: 1000, Currency: "EUR"}},
+			{Method: "pm_card_visa", Amount: money.Money{Amount: 1500, Currency: "USD"}},
+		},
+	})
+
+	// Assert
+	if !validation.IsValidationError(err) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+// MockPublisher collects published events
+type MockPublisher struct {
+	events []event.Event
+	err    error
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, events ...event.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func TestPlaceOrderHandler_Handle_PublishesOrderPlaced(t *testing.T) {
+	// Arrange
+	publisher := &MockPublisher{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+	handler.Events = publisher
+	amount := money.Money{Amount: 2500, Currency: "EUR"}
+
+	// Act
+	o, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: amount}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(publisher.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(publisher.events))
+	}
+	placed, ok := publisher.events[0].(orderDomain.OrderPlaced)
+	if !ok {
+		t.Fatalf("Expected OrderPlaced, got %T", publisher.events[0])
+	}
+	if placed.OrderID != o.ID || placed.Email != "test@example.com" || placed.ProductName != "Test Product" || placed.Amount != amount {
+		t.Errorf("Unexpected event: %+v", placed)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_PublishesStockLowAtReorderThreshold(t *testing.T) {
+	// Arrange
+	publisher := &MockPublisher{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+	handler.Events, handler.LowStockThreshold = publisher, 2
+	product, err := handler.ProductRepo.GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected the product, got %v", err)
+	}
+	threshold := 8
+	product.ReorderThreshold = &threshold
+	// Confirming the reservation must not change the product the handler read, as with a database
+	handler.Reservations = &MockStockReservationRepository{products: &MockProductRepository{products: map[int64]*productDomain.Product{
+		1: {ID: 1, Name: "Test Product", Stock: 10},
+	}}}
+
+	// Act
+	_, err = handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 3,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 3000, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(publisher.events) != 2 {
+		t.Fatalf("Expected OrderPlaced and StockLow, got %d events", len(publisher.events))
+	}
+	low, ok := publisher.events[1].(productDomain.StockLow)
+	if !ok {
+		t.Fatalf("Expected StockLow, got %T", publisher.events[1])
+	}
+	if low.Stock != 7 || low.Threshold != 8 {
+		t.Errorf("Expected stock 7 below the reorder threshold 8, got %+v", low)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_TagsSandboxOrders(t *testing.T) {
+	// Arrange
+	orderRepo := &MockOrderRepository{}
+	payments := &MockPaymentRepository{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, orderRepo, payments)
+	ctx := mode.With(context.Background(), mode.Sandbox)
+
+	// Act
+	order, err := handler.Handle(ctx, PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 1,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 1000, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !order.Sandbox {
+		t.Error("Expected the order to be tagged as sandbox")
+	}
+	if len(payments.payments) != 1 || !payments.payments[0].Sandbox {
+		t.Errorf("Expected the payment to be tagged as sandbox, got %+v", payments.payments)
+	}
+}
+
+// MockTransactor runs units of work directly and fails the commit when commitErr is set. Like
+// GormTransactor, a unit of work started inside another joins it.
+type MockTransactor struct {
+	calls     int
+	commitErr error
+}
+
+type mockTransactionKey struct{}
+
+func (m *MockTransactor) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(mockTransactionKey{}) != nil {
+		return fn(ctx)
+	}
+	m.calls++
+	if err := fn(context.WithValue(ctx, mockTransactionKey{}, true)); err != nil {
+		return err
+	}
+	return m.commitErr
+}
+
+func TestPlaceOrderHandler_Handle_PessimisticLockingLocksProductInTransaction(t *testing.T) {
+	// Arrange
+	tx := &MockTransactor{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+	handler.Locking, handler.Tx = StockLockingPessimistic, tx
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 1,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 1000, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tx.calls != 1 {
+		t.Errorf("Expected the order to be placed in 1 transaction, got %d", tx.calls)
+	}
+	if products := handler.ProductRepo.(*MockProductRepository); products.lockedForUpdate != 1 {
+		t.Errorf("Expected the product to be read for update once, got %d", products.lockedForUpdate)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_VoidsPaymentWhenTransactionFails(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{}
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{}, &MockPaymentRepository{})
+	handler.Locking, handler.Tx = StockLockingPessimistic, &MockTransactor{commitErr: errors.New("serialization failure")}
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 1,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 1000, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the failed commit to fail the order")
+	}
+	if len(gateway.voided) != 1 || gateway.voided[0] != "auth_1" {
+		t.Errorf("Expected the authorization to be voided, got %v", gateway.voided)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_PreOrderPlacementHookRejects(t *testing.T) {
+	// Arrange
+	orderRepo := &MockOrderRepository{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, orderRepo, &MockPaymentRepository{})
+	handler.Hooks.PreOrderPlacement = append(handler.Hooks.PreOrderPlacement, orderDomain.PreOrderPlacementFunc(
+		func(ctx context.Context, p orderDomain.Placement) error {
+			if p.Quantity.Int() > 1 {
+				return fmt.Errorf("%w: one per customer", orderDomain.ErrOrderRejected)
+			}
+			return nil
+		}))
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if !errors.Is(err, orderDomain.ErrOrderRejected) {
+		t.Fatalf("Expected ErrOrderRejected, got %v", err)
+	}
+	if len(orderRepo.orders) != 0 {
+		t.Errorf("Expected no order to be saved, got %d", len(orderRepo.orders))
+	}
+	if reservations := handler.Reservations.(*MockStockReservationRepository); len(reservations.reservations) != 0 {
+		t.Errorf("Expected no stock to be reserved, got %+v", reservations.reservations)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_PricingHooks(t *testing.T) {
+	// Arrange
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, &MockOrderRepository{}, &MockPaymentRepository{})
+	product, _ := handler.ProductRepo.GetByID(context.Background(), 1)
+	product.SetPrice(money.Money{Amount: 1250, Currency: "EUR"})
+	discount := func(percent int64) orderDomain.PricingFunc {
+		return func(ctx context.Context, p orderDomain.Placement, price money.Money) (money.Money, error) {
+			return money.Money{Amount: price.Amount * (100 - percent) / 100, Currency: price.Currency}, nil
+		}
+	}
+	handler.Hooks.Pricing = append(handler.Hooks.Pricing, discount(20), discount(50))
+
+	// Act
+	o, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 1000, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := (money.Money{Amount: 500, Currency: "EUR"}); o.UnitPrice != want {
+		t.Errorf("Expected a unit price of %s after both hooks, got %s", want, o.UnitPrice)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_PricingHookMustKeepCurrency(t *testing.T) {
+	// Arrange
+	orderRepo := &MockOrderRepository{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, orderRepo, &MockPaymentRepository{})
+	product, _ := handler.ProductRepo.GetByID(context.Background(), 1)
+	product.SetPrice(money.Money{Amount: 1250, Currency: "EUR"})
+	handler.Hooks.Pricing = append(handler.Hooks.Pricing, orderDomain.PricingFunc(
+		func(ctx context.Context, p orderDomain.Placement, price money.Money) (money.Money, error) {
+			return money.Money{Amount: 1300, Currency: "USD"}, nil
+		}))
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2600, Currency: "USD"}}},
+	})
+
+	// Assert
+	if err == nil || validation.IsValidationError(err) {
+		t.Fatalf("Expected the hook to fail the order, got %v", err)
+	}
+	if len(orderRepo.orders) != 0 {
+		t.Errorf("Expected no order to be saved, got %d", len(orderRepo.orders))
+	}
+}
+
+func TestPlaceOrderHandler_Handle_PostConfirmationHookDoesNotFailOrder(t *testing.T) {
+	// Arrange
+	orderRepo := &MockOrderRepository{}
+	handler := newPaidOrderHandler(&MockPaymentGateway{}, orderRepo, &MockPaymentRepository{})
+	var confirmed []int64
+	handler.Hooks.PostConfirmation = append(handler.Hooks.PostConfirmation,
+		orderDomain.PostConfirmationFunc(func(ctx context.Context, o *orderDomain.Order) error {
+			confirmed = append(confirmed, o.ID)
+			return errors.New("crm unavailable")
+		}),
+		orderDomain.PostConfirmationFunc(func(ctx context.Context, o *orderDomain.Order) error {
+			confirmed = append(confirmed, o.ID)
+			return nil
+		}),
+	)
+
+	// Act
+	o, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2,
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(confirmed, []int64{o.ID, o.ID}) {
+		t.Errorf("Expected both hooks to see order %d, got %v", o.ID, confirmed)
+	}
+}
+
+type MockCouponRepository struct {
+	couponDomain.CouponRepository
+	coupons     map[string]*couponDomain.Coupon
+	redemptions map[int64]*couponDomain.Redemption
+}
+
+func (m *MockCouponRepository) GetByCode(ctx context.Context, code string) (*couponDomain.Coupon, error) {
+	if c, ok := m.coupons[code]; ok {
+		return c, nil
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockCouponRepository) Redeem(ctx context.Context, c *couponDomain.Coupon, userID int64, orderPublicID string) (*couponDomain.Redemption, error) {
+	if c.MaxUses != nil && c.Uses >= *c.MaxUses {
+		return nil, couponDomain.ErrCouponUsedUp
+	}
+	c.Uses++
+	r := &couponDomain.Redemption{ID: int64(len(m.redemptions) + 1), CouponID: c.ID, UserID: userID, OrderPublicID: orderPublicID}
+	m.redemptions[r.ID] = r
+	return r, nil
+}
+
+func (m *MockCouponRepository) Release(ctx context.Context, redemptionID int64) error {
+	r, ok := m.redemptions[redemptionID]
+	if !ok {
+		return persistence.ErrNotFound
+	}
+	delete(m.redemptions, redemptionID)
+	for _, c := range m.coupons {
+		if c.ID == r.CouponID {
+			c.Uses--
+		}
+	}
+	return nil
+}
+
+func newCouponOrderHandler(gateway *MockPaymentGateway) (*PlaceOrderHandler, *couponDomain.Coupon) {
+	handler := newPaidOrderHandler(gateway, &MockOrderRepository{}, &MockPaymentRepository{})
+	product, _ := handler.ProductRepo.GetByID(context.Background(), 1)
+	product.SetPrice(money.Money{Amount: 1250, Currency: "EUR"})
+	coupon := &couponDomain.Coupon{ID: 1, Code: "SUMMER10", Kind: couponDomain.KindPercentage, Percent: 1000}
+	handler.Coupons = &MockCouponRepository{
+		coupons:     map[string]*couponDomain.Coupon{coupon.Code: coupon},
+		redemptions: map[int64]*couponDomain.Redemption{},
+	}
+	return handler, coupon
+}
+
+func TestPlaceOrderHandler_Handle_TakesCouponOff(t *testing.T) {
+	// Arrange
+	gateway := &MockPaymentGateway{}
+	handler, coupon := newCouponOrderHandler(gateway)
+
+	// Act
+	o, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2, CouponCode: "summer10",
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2250, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := (money.Money{Amount: 250, Currency: "EUR"}); o.Totals.Discount != want || o.CouponCode != "SUMMER10" {
+		t.Errorf("Expected a discount of %s from SUMMER10, got %s from %q", want, o.Totals.Discount, o.CouponCode)
+	}
+	if want := (money.Money{Amount: 2250, Currency: "EUR"}); o.Total() != want {
+		t.Errorf("Expected a total of %s, got %s", want, o.Total())
+	}
+	if coupon.Uses != 1 {
+		t.Errorf("Expected the coupon to be used once, got %d", coupon.Uses)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_ReleasesCouponOnFailure(t *testing.T) {
+	// Arrange
+	handler, coupon := newCouponOrderHandler(&MockPaymentGateway{decline: true})
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2, CouponCode: "SUMMER10",
+		Payments: []PaymentDetails{{Method: "pm_card_declined", Amount: money.Money{Amount: 2250, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if !errors.Is(err, paymentDomain.ErrPaymentDeclined) {
+		t.Fatalf("Expected ErrPaymentDeclined, got %v", err)
+	}
+	if coupon.Uses != 0 {
+		t.Errorf("Expected the use of the coupon to be given back, got %d uses", coupon.Uses)
+	}
+}
+
+func TestPlaceOrderHandler_Handle_RejectsUnknownCoupon(t *testing.T) {
+	// Arrange
+	handler, _ := newCouponOrderHandler(&MockPaymentGateway{})
+
+	// Act
+	_, err := handler.Handle(context.Background(), PlaceOrderCommand{
+		UserID: 1, ShippingAddressID: 1, ProductID: 1, Quantity: 2, CouponCode: "WINTER10",
+		Payments: []PaymentDetails{{Method: "pm_card_visa", Amount: money.Money{Amount: 2500, Currency: "EUR"}}},
+	})
+
+	// Assert
+	if !errors.Is(err, couponDomain.ErrCouponNotFound) {
+		t.Errorf("Expected ErrCouponNotFound, got %v", err)
 	}
 }
@@ -0,0 +1,220 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// scenario is a Given/When/Then acceptance test of the order flow:
+//
+//	newScenario(t).
+//		GivenUser().
+//		GivenProductWithStock(5).
+//		WhenPlaceOrder(2).
+//		ThenOrderStatus(orderDomain.StatusConfirmed).
+//		ThenStock(3)
+//
+// Givens seed in-memory repositories, When dispatches the command through the same decorators as
+// production and Thens check the outcome, failing the test with the step that did not hold.
+type scenario struct {
+	t   *testing.T
+	ctx context.Context
+
+	users     *MockUserRepository
+	addresses *MockAddressRepository
+	products  *MockProductRepository
+	orders    *MockOrderRepository
+	gateway   *MockPaymentGateway
+	payments  *MockPaymentRepository
+	events    *MockPublisher
+	handler   *PlaceOrderHandler
+
+	// user and product are the last ones given; When steps act on them
+	user    *userDomain.User
+	product *productDomain.Product
+
+	order *orderDomain.Order
+	err   error
+}
+
+func newScenario(t *testing.T) *scenario {
+	s := &scenario{
+		t:         t,
+		ctx:       context.Background(),
+		users:     &MockUserRepository{users: map[int64]*userDomain.User{}},
+		addresses: &MockAddressRepository{addresses: map[int64]*userDomain.Address{}},
+		products:  &MockProductRepository{products: map[int64]*productDomain.Product{}},
+		orders:    &MockOrderRepository{},
+		events:    &MockPublisher{},
+	}
+	s.handler = &PlaceOrderHandler{
+		UserRepo:     s.users,
+		ProductRepo:  s.products,
+		Addresses:    s.addresses,
+		OrderRepo:    s.orders,
+		Reservations: &MockStockReservationRepository{products: s.products},
+		Events:       s.events,
+		Tx:           &MockTransactor{},
+	}
+	return s
+}
+
+// GivenUser registers an active customer with one address, which has the ID of the customer
+func (s *scenario) GivenUser() *scenario {
+	id := int64(len(s.users.users) + 1)
+	s.user = &userDomain.User{ID: id, Email: userDomain.Email(fmt.Sprintf("customer%d@example.com", id)), Active: true}
+	s.users.users[id] = s.user
+	s.addresses.addresses[id] = &userDomain.Address{ID: id, UserID: id, AddressDetails: userDomain.AddressDetails{
+		Address: address.Address{Name: "Customer", Line1: fmt.Sprintf("Main St %d", id), City: "Berlin", PostalCode: "10115", Country: "DE"},
+	}}
+	return s
+}
+
+// GivenProductWithStock adds an unpriced product
+func (s *scenario) GivenProductWithStock(stock int) *scenario {
+	id := int64(len(s.products.products) + 1)
+	s.product = &productDomain.Product{ID: id, Name: fmt.Sprintf("Product %d", id), Stock: stock}
+	s.products.products[id] = s.product
+	return s
+}
+
+// GivenPrice prices the last given product
+func (s *scenario) GivenPrice(amount int64, currency string) *scenario {
+	s.product.SetPrice(money.Money{Amount: amount, Currency: currency})
+	return s
+}
+
+// GivenPaymentGateway makes orders pay through a gateway authorizing every method but declined
+func (s *scenario) GivenPaymentGateway(declined ...string) *scenario {
+	s.gateway = &MockPaymentGateway{}
+	if len(declined) > 0 {
+		s.gateway.declineMethod = declined[0]
+	}
+	s.payments = &MockPaymentRepository{}
+	s.handler.Payments, s.handler.PaymentRepo = s.gateway, s.payments
+	return s
+}
+
+// GivenSandboxTenant places the following orders for a tenant in sandbox mode
+func (s *scenario) GivenSandboxTenant() *scenario {
+	s.ctx = mode.With(s.ctx, mode.Sandbox)
+	return s
+}
+
+// WhenPlaceOrder orders quantity of the last given product for the last given user
+func (s *scenario) WhenPlaceOrder(quantity int, payments ...PaymentDetails) *scenario {
+	s.t.Helper()
+	if s.user == nil || s.product == nil {
+		s.t.Fatal("WhenPlaceOrder needs GivenUser and GivenProductWithStock first")
+	}
+	placeOrder := decorator.ApplyCommandResultDecorators[PlaceOrderCommand, *orderDomain.Order](s.handler)
+	s.order, s.err = placeOrder.Handle(s.ctx, PlaceOrderCommand{
+		UserID:            s.user.ID,
+		ShippingAddressID: s.user.ID,
+		ProductID:         s.product.ID,
+		Quantity:          quantity,
+		Payments:          payments,
+	})
+	return s
+}
+
+// Pay is a card payment of amount for WhenPlaceOrder
+func Pay(amount int64, currency string) PaymentDetails {
+	return PaymentDetails{Method: "pm_card_visa", Amount: money.Money{Amount: amount, Currency: currency}}
+}
+
+// PayWith is a payment of amount with a given method for WhenPlaceOrder
+func PayWith(method string, amount int64, currency string) PaymentDetails {
+	return PaymentDetails{Method: method, Amount: money.Money{Amount: amount, Currency: currency}}
+}
+
+// ThenOrderStatus checks the command succeeded and the stored order has status
+func (s *scenario) ThenOrderStatus(status orderDomain.OrderStatus) *scenario {
+	s.t.Helper()
+	if s.err != nil {
+		s.t.Fatalf("Then order status %s: the order failed with %v", status, s.err)
+	}
+	stored, err := s.orders.GetByID(s.ctx, s.order.ID)
+	if err != nil {
+		s.t.Fatalf("Then order status %s: order %d was not saved: %v", status, s.order.ID, err)
+	}
+	if stored.Status != status {
+		s.t.Errorf("Then order status %s: got %s", status, stored.Status)
+	}
+	return s
+}
+
+// ThenFailsWith checks the command failed with target, matched with errors.Is, and saved no order
+func (s *scenario) ThenFailsWith(target error) *scenario {
+	s.t.Helper()
+	if !errors.Is(s.err, target) {
+		s.t.Errorf("Then fails with %v: got %v", target, s.err)
+	}
+	if len(s.orders.orders) != 0 {
+		s.t.Errorf("Then fails with %v: %d orders were saved", target, len(s.orders.orders))
+	}
+	return s
+}
+
+// ThenStock checks the stock of the last given product
+func (s *scenario) ThenStock(stock int) *scenario {
+	s.t.Helper()
+	if s.product.Stock != stock {
+		s.t.Errorf("Then stock %d: got %d", stock, s.product.Stock)
+	}
+	return s
+}
+
+// ThenOrderTotal checks the total of the placed order
+func (s *scenario) ThenOrderTotal(amount int64, currency string) *scenario {
+	s.t.Helper()
+	if want := (money.Money{Amount: amount, Currency: currency}); s.order == nil || s.order.Total() != want {
+		s.t.Errorf("Then order total %s: got order %+v", want, s.order)
+	}
+	return s
+}
+
+// ThenPaymentsAuthorized checks how many payments were authorized and how many of them were voided again
+func (s *scenario) ThenPaymentsAuthorized(authorized, voided int) *scenario {
+	s.t.Helper()
+	if s.gateway == nil {
+		s.t.Fatal("ThenPaymentsAuthorized needs GivenPaymentGateway first")
+	}
+	if len(s.gateway.authorized) != authorized || len(s.gateway.voided) != voided {
+		s.t.Errorf("Then %d payments authorized and %d voided: got %d and %d",
+			authorized, voided, len(s.gateway.authorized), len(s.gateway.voided))
+	}
+	return s
+}
+
+// ThenSandboxOrder checks the placed order was tagged as sandbox test data
+func (s *scenario) ThenSandboxOrder() *scenario {
+	s.t.Helper()
+	if s.order == nil || !s.order.Sandbox {
+		s.t.Errorf("Then sandbox order: got %+v", s.order)
+	}
+	return s
+}
+
+// ThenPublished checks the events published by the command, by name
+func (s *scenario) ThenPublished(names ...string) *scenario {
+	s.t.Helper()
+	var published []string
+	for _, e := range s.events.events {
+		published = append(published, e.EventName())
+	}
+	if fmt.Sprint(published) != fmt.Sprint(names) {
+		s.t.Errorf("Then published %v: got %v", names, published)
+	}
+	return s
+}
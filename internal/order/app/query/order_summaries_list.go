@@ -0,0 +1,44 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+const (
+	DefaultSummaryLimit = 50
+	MaxSummaryLimit     = 200
+)
+
+// ListOrderSummariesQuery lists order summaries for the admin dashboard; zero fields match every order
+type ListOrderSummariesQuery struct {
+	Status domain.OrderStatus
+	// UserID is the public ID of the user who placed the orders
+	UserID string
+	// Limit defaults to DefaultSummaryLimit
+	Limit int
+}
+
+// ListOrderSummariesHandler reads the order_summaries read model only, so a listing costs a single
+// query without joins however many orders it shows. Summaries follow the order messages at the
+// pace of the projections and can lag behind the orders by a relay interval.
+type ListOrderSummariesHandler struct {
+	Summaries domain.OrderSummaryRepository
+}
+
+func (h *ListOrderSummariesHandler) Handle(ctx context.Context, q ListOrderSummariesQuery) ([]domain.OrderSummary, error) {
+	var errs validation.Errors
+	errs.Check(q.Status == "" || q.Status.IsValid(), "status", fmt.Sprintf("is not a known order status, got %q", q.Status))
+	errs.Check(q.Limit >= 0 && q.Limit <= MaxSummaryLimit, "limit", fmt.Sprintf("must be between 1 and %d, got %d", MaxSummaryLimit, q.Limit))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultSummaryLimit
+	}
+
+	return h.Summaries.List(ctx, domain.OrderSummaryFilter{Status: q.Status, UserID: q.UserID, Limit: q.Limit})
+}
@@ -0,0 +1,37 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubSummaryRepository struct {
+	domain.OrderSummaryRepository
+	filter domain.OrderSummaryFilter
+}
+
+func (r *stubSummaryRepository) List(ctx context.Context, filter domain.OrderSummaryFilter) ([]domain.OrderSummary, error) {
+	r.filter = filter
+	return []domain.OrderSummary{{OrderID: "ord_1"}}, nil
+}
+
+func TestListOrderSummariesHandler_Handle(t *testing.T) {
+	repo := &stubSummaryRepository{}
+	h := &ListOrderSummariesHandler{Summaries: repo}
+
+	summaries, err := h.Handle(context.Background(), ListOrderSummariesQuery{Status: domain.StatusConfirmed, UserID: "usr_1"})
+	assert.NoError(t, err)
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, domain.OrderSummaryFilter{Status: domain.StatusConfirmed, UserID: "usr_1", Limit: DefaultSummaryLimit}, repo.filter)
+
+	_, err = h.Handle(context.Background(), ListOrderSummariesQuery{Status: "LOST", Limit: MaxSummaryLimit + 1})
+	var errs validation.Errors
+	if assert.True(t, errors.As(err, &errs)) {
+		assert.Len(t, errs, 2)
+	}
+}
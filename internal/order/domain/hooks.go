@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// ErrOrderRejected is wrapped by a PreOrderPlacementHook that turns an order down for a business
+// rule, e.g. fmt.Errorf("%w: orders above 50 units need a sales contract", ErrOrderRejected)
+var ErrOrderRejected = errors.New("order rejected")
+
+// ErrorCodeOrderRejected is the stable API error code of ErrOrderRejected
+const ErrorCodeOrderRejected = "order_rejected"
+
+// Placement is an order being placed, as hooks see it. The tenant and adapter mode of the
+// request are in the context.
+type Placement struct {
+	User            *userDomain.User
+	Product         *productDomain.Product
+	Quantity        Quantity
+	ShippingAddress *userDomain.Address
+}
+
+// Hooks add the business logic of a deployment to order placement without changing the
+// handler. Each kind runs in the order it was registered.
+type Hooks struct {
+	PreOrderPlacement []PreOrderPlacementHook
+	Pricing           []PricingHook
+	PostConfirmation  []PostConfirmationHook
+}
+
+// PreOrderPlacementHook vets an order before stock is reserved and payment authorized. An error
+// rejects the order and reaches the caller: validation.Errors or ErrOrderRejected for the
+// customer to act on, anything else as an internal error.
+type PreOrderPlacementHook interface {
+	BeforePlacement(ctx context.Context, p Placement) error
+}
+
+// PricingHook sets the unit price of an order, e.g. for a customer discount. It receives the
+// list price of the product, or what earlier hooks made of it; the price it returns must not be
+// negative and must keep the currency. Payments must add up to the resulting total.
+type PricingHook interface {
+	UnitPrice(ctx context.Context, p Placement, price money.Money) (money.Money, error)
+}
+
+// PostConfirmationHook runs once a confirmed order is saved and its events are published. The
+// order stands whatever the hook does, so an error is only logged.
+type PostConfirmationHook interface {
+	AfterConfirmation(ctx context.Context, o *Order) error
+}
+
+// PreOrderPlacementFunc, PricingFunc and PostConfirmationFunc make hooks of functions
+type (
+	PreOrderPlacementFunc func(ctx context.Context, p Placement) error
+	PricingFunc           func(ctx context.Context, p Placement, price money.Money) (money.Money, error)
+	PostConfirmationFunc  func(ctx context.Context, o *Order) error
+)
+
+func (f PreOrderPlacementFunc) BeforePlacement(ctx context.Context, p Placement) error {
+	return f(ctx, p)
+}
+
+func (f PricingFunc) UnitPrice(ctx context.Context, p Placement, price money.Money) (money.Money, error) {
+	return f(ctx, p, price)
+}
+
+func (f PostConfirmationFunc) AfterConfirmation(ctx context.Context, o *Order) error {
+	return f(ctx, o)
+}
@@ -1,29 +1,255 @@
 package domain
 
 import (
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
 	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
 	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
 )
 
+// PublicIDPrefix starts the public IDs of orders, e.g. "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const PublicIDPrefix = "ord"
+
 type Order struct {
-	ID        int64 `gorm:"primaryKey"`
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the order in external APIs so the primary key never leaves the service
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	// TenantID is the tenant the order belongs to; see tenant.Plugin
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';index"`
+	// Number is the order number shown to customers, e.g. 20240518-000123; it is empty for orders
+	// placed before numbers existed
+	Number    string `gorm:"type:varchar(20);uniqueIndex;default:null"`
 	UserID    int64
 	User      userDomain.User `gorm:"foreignKey:UserID"`
 	ProductID int64
 	Product   productDomain.Product `gorm:"foreignKey:ProductID"`
-	Quantity  int
-	Status    string `gorm:"type:varchar(20);not null"`
+	// ShippingAddressID is the address in the user's address book the order ships to; nil for
+	// orders placed before addresses existed
+	ShippingAddressID *int64              `gorm:"index"`
+	ShippingAddress   *userDomain.Address `gorm:"foreignKey:ShippingAddressID"`
+	Quantity          Quantity
+	// UnitPrice is the product price when the order was placed; zero for orders of unpriced products
+	UnitPrice money.Money `gorm:"type:varchar(32)"`
+	// Totals break down what the order costs into subtotal, tax and shipping; zero for orders of
+	// unpriced products and orders placed before totals were calculated
+	Totals pricing.Totals `gorm:"embedded;embeddedPrefix:totals_"`
+	// TaxRate is the rate the tax in Totals was calculated at
+	TaxRate pricing.Rate `gorm:"not null;default:0"`
+	// CouponCode is the coupon whose discount is in Totals; empty for orders without one
+	CouponCode string              `gorm:"type:varchar(32);index"`
+	Status     OrderStatus         `gorm:"type:varchar(20);not null"`
+	History    []OrderStatusChange `gorm:"foreignKey:OrderID"`
+	// CreatedAt is when the order was placed; zero for orders placed before it was recorded
+	CreatedAt time.Time `gorm:"index"`
+	// UpdatedAt is when the order last changed, set by gorm on every save; offline clients sync
+	// orders by it
+	UpdatedAt time.Time `gorm:"index"`
+
+	// FlagReason marks an order for manual review, e.g. after a chargeback; empty when not flagged
+	FlagReason string `gorm:"type:varchar(32);index"`
+	FlaggedAt  *time.Time
+
+	// Sandbox marks test orders placed by a sandbox tenant; their payments never reach a live gateway
+	Sandbox bool `gorm:"not null;default:false;index"`
+}
+
+// FlagDisputed flags orders whose payment the customer disputed with their bank
+const FlagDisputed = "disputed"
+
+// OrderPlacedEvent is the event name of OrderPlaced
+const OrderPlacedEvent = "order.placed"
+
+// OrderPlaced is emitted once an order is confirmed and saved. Amount is zero when no payment was taken.
+type OrderPlaced struct {
+	OrderID     int64
+	UserID      int64
+	Email       userDomain.Email
+	ProductID   int64
+	ProductName string
+	Quantity    int
+	Amount      money.Money
+	PlacedAt    time.Time
+	// OrderPublicID, UserPublicID and ProductPublicID are the IDs shown to other services
+	OrderPublicID   string
+	OrderNumber     string
+	UserPublicID    string
+	ProductPublicID string
+	// OrderVersion is the version of the order once placed, see Order.Version
+	OrderVersion int
+	// Sandbox is set for test orders, whose emails go to the sandbox notifier
+	Sandbox bool
+}
+
+func (OrderPlaced) EventName() string {
+	return OrderPlacedEvent
+}
+
+func (e OrderPlaced) EventID() string {
+	return event.NewID(OrderPlacedEvent, e.OrderPublicID, e.OrderVersion)
+}
+
+// OrderCancelledEvent is the event name of OrderCancelled
+const OrderCancelledEvent = "order.cancelled"
+
+// CancelReasonPaymentFailed cancels an order whose payment failed after it was placed
+const CancelReasonPaymentFailed = "payment_failed"
+
+// OrderCancelled is emitted once a cancelled order is saved. Restocked is set when its stock was put back.
+type OrderCancelled struct {
+	OrderID     int64
+	UserID      int64
+	ProductID   int64
+	Quantity    int
+	Reason      string
+	Restocked   bool
+	CancelledAt time.Time
+	Sandbox     bool
+
+	OrderPublicID   string
+	UserPublicID    string
+	ProductPublicID string
+	OrderVersion    int
+}
+
+func (OrderCancelled) EventName() string {
+	return OrderCancelledEvent
+}
+
+func (e OrderCancelled) EventID() string {
+	return event.NewID(OrderCancelledEvent, e.OrderPublicID, e.OrderVersion)
+}
+
+// OrderRefundedEvent is the event name of OrderRefunded
+const OrderRefundedEvent = "order.refunded"
+
+// OrderRefunded is emitted for every refund of an order once it is saved. An order can be refunded
+// in parts; FullyRefunded is set by the refund that moved it to REFUNDED.
+type OrderRefunded struct {
+	OrderID       int64
+	UserID        int64
+	ProductID     int64
+	Quantity      int
+	Amount        money.Money
+	Reason        string
+	Restocked     bool
+	FullyRefunded bool
+	RefundedAt    time.Time
+	Sandbox       bool
+
+	OrderPublicID   string
+	UserPublicID    string
+	ProductPublicID string
+	// RefundNumber counts the refunds of the order from 1; it identifies the event as partial
+	// refunds leave the order version unchanged
+	RefundNumber int
+}
+
+func (OrderRefunded) EventName() string {
+	return OrderRefundedEvent
+}
+
+func (e OrderRefunded) EventID() string {
+	return event.NewID(OrderRefundedEvent, e.OrderPublicID, e.RefundNumber)
 }
 
-func NewOrder(userID, productID int64, quantity int) *Order {
-	return &Order{
+// NewOrder creates a pending order, returning validation.Errors when the invariants are not met
+func NewOrder(userID, productID int64, quantity Quantity) (*Order, error) {
+	o := &Order{
 		UserID:    userID,
 		ProductID: productID,
 		Quantity:  quantity,
-		Status:    "PENDING",
+		Status:    StatusPending,
+	}
+	o.AssignPublicID()
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// MustNewOrder is like NewOrder but panics on invalid input; intended for tests and fixtures
+func MustNewOrder(userID, productID int64, quantity Quantity) *Order {
+	o, err := NewOrder(userID, productID, quantity)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// AssignPublicID gives the order a public ID unless it already has one
+func (o *Order) AssignPublicID() {
+	if o.PublicID == "" {
+		o.PublicID = publicid.New(PublicIDPrefix)
+	}
+}
+
+// Transition moves the order to the given status and records the change in its history
+func (o *Order) Transition(to OrderStatus) error {
+	if !o.Status.CanTransitionTo(to) {
+		return newStatusChangeError(o.Status, to)
+	}
+
+	o.History = append(o.History, OrderStatusChange{
+		OrderID:    o.ID,
+		FromStatus: o.Status,
+		ToStatus:   to,
+		ChangedAt:  time.Now().UTC(),
+	})
+	o.Status = to
+	return nil
+}
+
+// Version counts the status changes of the order, so every event of the order has its own version.
+// It requires the full History, as loaded by the repository.
+func (o *Order) Version() int {
+	return len(o.History)
+}
+
+// Total is the amount charged for the order including tax and shipping. Orders placed before
+// totals were calculated charged the unit price times the quantity.
+func (o *Order) Total() money.Money {
+	if !o.Totals.IsZero() {
+		return o.Totals.Total
+	}
+	return o.UnitPrice.Mul(int64(o.Quantity))
+}
+
+func (o *Order) Confirm() error {
+	return o.Transition(StatusConfirmed)
+}
+
+func (o *Order) Cancel() error {
+	return o.Transition(StatusCancelled)
+}
+
+// Refund moves the order to REFUNDED once everything captured for it is refunded
+func (o *Order) Refund() error {
+	return o.Transition(StatusRefunded)
+}
+
+// Flag marks the order for review; the first reason is kept when it is flagged again
+func (o *Order) Flag(reason string, at time.Time) bool {
+	if o.FlagReason != "" {
+		return false
 	}
+	o.FlagReason = reason
+	o.FlaggedAt = &at
+	return true
 }
 
-func (o *Order) Confirm() {
-	o.Status = "CONFIRMED"
+// Validate checks the order invariants and returns validation.Errors describing every violation
+func (o *Order) Validate() error {
+	var errs validation.Errors
+
+	errs.Check(o.UserID > 0, "user_id", "is required")
+	errs.Check(o.ProductID > 0, "product_id", "is required")
+	errs.Check(o.Quantity.IsValid(), "quantity", "must be greater than zero")
+	errs.Check(o.Status.IsValid(), "status", "is not a known order status")
+
+	return errs.Err()
 }
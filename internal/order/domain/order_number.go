@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// numberDayLayout is the day prefix of order numbers
+const numberDayLayout = "20060102"
+
+// NumberSequence counts the orders numbered on a day. The counter of a day is incremented by a
+// single upsert, so concurrent orders never get the same number; a number taken by an order that
+// then fails to save is not reused.
+type NumberSequence struct {
+	// Day is the UTC day the sequence numbers, as YYYYMMDD
+	Day        string `gorm:"primaryKey;type:varchar(8)"`
+	LastNumber int64  `gorm:"not null"`
+}
+
+func (NumberSequence) TableName() string {
+	return "order_number_sequences"
+}
+
+// FormatNumber returns the order number of the n-th order of a day, e.g. 20240518-000123. Days
+// with more than 999999 orders get longer numbers.
+func FormatNumber(day time.Time, n int64) string {
+	return fmt.Sprintf("%s-%06d", NumberDay(day), n)
+}
+
+// NumberDay is the day of t that order numbers start with
+func NumberDay(t time.Time) string {
+	return t.UTC().Format(numberDayLayout)
+}
@@ -2,9 +2,49 @@ package domain
 
 import (
 	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
 )
 
+// OrderFilter selects orders, applied with query.QueryBuilder.ApplyFilters
+type OrderFilter struct {
+	ID            int64      `filter:"id"`
+	UserID        int64      `filter:"user_id"`
+	ProductID     int64      `filter:"product_id"`
+	Status        string     `filter:"status"`
+	MinQuantity   int        `filter:"quantity,>="`
+	MaxQuantity   int        `filter:"quantity,<="`
+	UserIDs       []int64    `filter:"user_id,IN"`
+	ProductIDs    []int64    `filter:"product_id,IN"`
+	Statuses      []string   `filter:"status,IN"`
+	CreatedAfter  *time.Time `filter:"created_at,>="`
+	CreatedBefore *time.Time `filter:"created_at,<="`
+	// UserEmail filters on the email of the user through the User relation of the order
+	UserEmail string `filter:"User.email,CONTAINS"`
+}
+
 type OrderRepository interface {
 	Save(ctx context.Context, o *Order) error
 	GetByID(ctx context.Context, id int64) (*Order, error)
+	// GetByPublicID looks an order up by the ID shown in external APIs, loading it like GetByID
+	GetByPublicID(ctx context.Context, publicID string) (*Order, error)
+	// GetByIDs returns the orders with the given IDs in one query, loaded like GetByID and in the
+	// order of ids; unknown and repeated IDs are omitted
+	GetByIDs(ctx context.Context, ids []int64) ([]Order, error)
+	// GetByPublicIDAsOf returns the order as it was at the given moment, with the status history up
+	// to then; it returns persistence.ErrNotFound for orders placed later
+	GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*Order, error)
+	// PublicIDs maps order IDs to their public IDs; unknown IDs are omitted
+	PublicIDs(ctx context.Context, ids []int64) (map[int64]string, error)
+	// UpdateStatus stores the current status of an order together with its new history entries
+	UpdateStatus(ctx context.Context, o *Order) error
+	// UpdateFlag stores the flag of an order
+	UpdateFlag(ctx context.Context, o *Order) error
+	// ListChangedForUser returns up to limit orders of the user changed after the position and
+	// before until, loaded like GetByID in the order they changed; see persistence.ChangedAfter
+	ListChangedForUser(ctx context.Context, userID int64, after persistence.ChangePosition, until time.Time, limit int) ([]Order, error)
+	// FindInBatches calls fn with the orders matching filter, with their user and product, size
+	// orders at a time in ID order; fn must not keep the slice
+	FindInBatches(ctx context.Context, filter OrderFilter, size int, fn func([]Order) error) error
 }
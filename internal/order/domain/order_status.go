@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTransition is returned when an order is moved to a status that is not reachable from its current one
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// OrderStatus is the lifecycle state of an order
+type OrderStatus string
+
+const (
+	StatusPending   OrderStatus = "PENDING"
+	StatusConfirmed OrderStatus = "CONFIRMED"
+	StatusShipped   OrderStatus = "SHIPPED"
+	StatusDelivered OrderStatus = "DELIVERED"
+	StatusCancelled OrderStatus = "CANCELLED"
+	StatusRefunded  OrderStatus = "REFUNDED"
+)
+
+// transitions lists the statuses reachable from each status
+var transitions = map[OrderStatus][]OrderStatus{
+	StatusPending:   {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusShipped, StatusCancelled, StatusRefunded},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusRefunded},
+	StatusCancelled: {},
+	StatusRefunded:  {},
+}
+
+// IsValid reports whether the status is part of the order lifecycle
+func (s OrderStatus) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an order in status s may move to status to
+func (s OrderStatus) CanTransitionTo(to OrderStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusChange is a single entry of the order status history
+type OrderStatusChange struct {
+	ID         int64       `gorm:"primaryKey"`
+	OrderID    int64       `gorm:"index;not null"`
+	FromStatus OrderStatus `gorm:"type:varchar(20);not null"`
+	ToStatus   OrderStatus `gorm:"type:varchar(20);not null"`
+	ChangedAt  time.Time   `gorm:"not null"`
+}
+
+func newStatusChangeError(from, to OrderStatus) error {
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
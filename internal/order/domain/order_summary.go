@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// OrderSummary is the read model of an order for reporting, built from order messages by the
+// order_summary projection. Orders, users and products are named by their public IDs, and the
+// email of the user and the name of the product are copied in so listings need no joins.
+type OrderSummary struct {
+	OrderID string `gorm:"primaryKey;type:varchar(32)"`
+	// OrderNumber is the number customers quote, e.g. 20240518-000123
+	OrderNumber string `gorm:"type:varchar(20);index"`
+	// TenantID is the tenant of the order; see tenant.Plugin
+	TenantID  string `gorm:"type:varchar(64);not null;default:'default';index"`
+	UserID    string `gorm:"type:varchar(32);index"`
+	UserEmail string `gorm:"type:varchar(255)"`
+	ProductID string `gorm:"type:varchar(32);index"`
+	// ProductName is the name of the product when the order was placed
+	ProductName string `gorm:"type:varchar(255)"`
+	Quantity    int    `gorm:"not null"`
+	// Amount is the order total; it is zero for orders of unpriced products
+	Amount       money.Money `gorm:"type:varchar(32)"`
+	Status       OrderStatus `gorm:"type:varchar(20);not null;index"`
+	PlacedAt     *time.Time  `gorm:"index"`
+	CancelledAt  *time.Time
+	CancelReason string `gorm:"type:varchar(32)"`
+	// RefundedAt is set once everything captured for the order is refunded
+	RefundedAt *time.Time
+	Sandbox    bool `gorm:"not null;default:false"`
+	UpdatedAt  time.Time
+}
+
+// ApplyPlaced records the placement; a cancellation or refund handled before it, e.g. when a replay
+// started between the two, is kept
+func (s *OrderSummary) ApplyPlaced(placedAt time.Time, amount money.Money) {
+	s.PlacedAt, s.Amount = &placedAt, amount
+	if s.CancelledAt == nil && s.RefundedAt == nil {
+		s.Status = StatusConfirmed
+	}
+}
+
+func (s *OrderSummary) ApplyCancelled(cancelledAt time.Time, reason string) {
+	s.CancelledAt, s.CancelReason = &cancelledAt, reason
+	s.Status = StatusCancelled
+}
+
+// ApplyRefunded records the refund that returned the last of the order's money
+func (s *OrderSummary) ApplyRefunded(refundedAt time.Time) {
+	s.RefundedAt = &refundedAt
+	s.Status = StatusRefunded
+}
+
+// OrderSummaryFilter narrows the summaries returned by List; zero fields match every summary
+type OrderSummaryFilter struct {
+	Status OrderStatus
+	// UserID is the public ID of the user who placed the orders
+	UserID string
+	Limit  int
+}
+
+type OrderSummaryRepository interface {
+	// GetByID returns persistence.ErrNotFound for orders without a summary yet
+	GetByID(ctx context.Context, orderID string) (*OrderSummary, error)
+	// Save creates or replaces the summary
+	Save(ctx context.Context, s *OrderSummary) error
+	// List returns the newest placed summaries first
+	List(ctx context.Context, filter OrderSummaryFilter) ([]OrderSummary, error)
+}
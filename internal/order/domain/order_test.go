@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+func TestOrder_Validate(t *testing.T) {
+	tests := []struct {
+		name           string
+		order          Order
+		expectedFields []string
+	}{
+		{
+			name:  "valid order",
+			order: Order{UserID: 1, ProductID: 1, Quantity: 2, Status: StatusPending},
+		},
+		{
+			name:           "missing references and non-positive quantity",
+			order:          Order{Quantity: 0, Status: StatusConfirmed},
+			expectedFields: []string{"user_id", "product_id", "quantity"},
+		},
+		{
+			name:           "unknown status",
+			order:          Order{UserID: 1, ProductID: 1, Quantity: 1, Status: "LOST"},
+			expectedFields: []string{"status"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.order.Validate()
+			if len(tt.expectedFields) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			errs, ok := err.(validation.Errors)
+			if !ok {
+				t.Fatalf("Expected validation.Errors, got %T", err)
+			}
+			if len(errs) != len(tt.expectedFields) {
+				t.Fatalf("Expected %d errors, got %d (%v)", len(tt.expectedFields), len(errs), errs)
+			}
+			for i, field := range tt.expectedFields {
+				if errs[i].Field != field {
+					t.Errorf("Expected error %d on field %s, got %s", i, field, errs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestOrder_Transition(t *testing.T) {
+	o := MustNewOrder(1, 1, 1)
+
+	if err := o.Confirm(); err != nil {
+		t.Fatalf("Expected PENDING -> CONFIRMED to succeed, got %v", err)
+	}
+	if err := o.Transition(StatusShipped); err != nil {
+		t.Fatalf("Expected CONFIRMED -> SHIPPED to succeed, got %v", err)
+	}
+	if err := o.Transition(StatusPending); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition for SHIPPED -> PENDING, got %v", err)
+	}
+
+	if o.Status != StatusShipped {
+		t.Errorf("Expected status SHIPPED, got %s", o.Status)
+	}
+	if len(o.History) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(o.History))
+	}
+	if o.History[1].FromStatus != StatusConfirmed || o.History[1].ToStatus != StatusShipped {
+		t.Errorf("Unexpected history entry %+v", o.History[1])
+	}
+}
+
+func TestOrderStatus_TerminalStatuses(t *testing.T) {
+	for _, status := range []OrderStatus{StatusCancelled, StatusRefunded} {
+		for to := range transitions {
+			if status.CanTransitionTo(to) {
+				t.Errorf("Expected %s to be terminal, but it can move to %s", status, to)
+			}
+		}
+	}
+}
+
+func TestNewOrder_EnforcesInvariants(t *testing.T) {
+	_, err := NewOrder(0, 1, 0)
+	if !validation.IsValidationError(err) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustNewOrder to panic on invalid input")
+		}
+	}()
+	MustNewOrder(1, 1, 0)
+}
+
+func TestOrder_Total(t *testing.T) {
+	o := Order{Quantity: 3, UnitPrice: money.Money{Amount: 1999, Currency: "USD"}}
+
+	if want := (money.Money{Amount: 5997, Currency: "USD"}); o.Total() != want {
+		t.Errorf("Expected %s, got %s", want, o.Total())
+	}
+	if total := (&Order{Quantity: 3}).Total(); !total.IsZero() {
+		t.Errorf("Expected a zero total without unit price, got %s", total)
+	}
+
+	o.Totals = pricing.Totals{Total: money.Money{Amount: 7626, Currency: "USD"}}
+	if want := o.Totals.Total; o.Total() != want {
+		t.Errorf("Expected the calculated total %s, got %s", want, o.Total())
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	// 23:30 in New York is already the next day in UTC
+	day := time.Date(2024, 5, 17, 23, 30, 0, 0, time.FixedZone("EDT", -4*60*60))
+
+	if got := FormatNumber(day, 123); got != "20240518-000123" {
+		t.Errorf("Expected 20240518-000123, got %s", got)
+	}
+	if got := FormatNumber(day, 1234567); got != "20240518-1234567" {
+		t.Errorf("Expected the sequence to grow past six digits, got %s", got)
+	}
+}
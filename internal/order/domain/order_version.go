@@ -0,0 +1,13 @@
+package domain
+
+import "github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+
+// OrderVersion is an order as it was over a period of time, kept so support can look at an order
+// as of a past moment; see temporal.AsOf
+type OrderVersion struct {
+	temporal.Version
+}
+
+func (OrderVersion) TableName() string {
+	return "orders_history"
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidQuantity = errors.New("quantity must be greater than zero")
+
+// Quantity is a strictly positive number of ordered units
+type Quantity int
+
+// NewQuantity returns ErrInvalidQuantity for zero or negative values
+func NewQuantity(n int) (Quantity, error) {
+	q := Quantity(n)
+	if !q.IsValid() {
+		return 0, ErrInvalidQuantity
+	}
+	return q, nil
+}
+
+func (q Quantity) IsValid() bool {
+	return q > 0
+}
+
+func (q Quantity) Int() int {
+	return int(q)
+}
+
+// Value implements driver.Valuer
+func (q Quantity) Value() (driver.Value, error) {
+	return int64(q), nil
+}
+
+// Scan implements sql.Scanner
+func (q *Quantity) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case int64:
+		*q = Quantity(v)
+	case int32:
+		*q = Quantity(v)
+	case nil:
+		*q = 0
+	default:
+		return fmt.Errorf("cannot scan %T into Quantity", value)
+	}
+	return nil
+}
+
+// UnmarshalJSON rejects non-positive quantities while decoding
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	quantity, err := NewQuantity(n)
+	if err != nil {
+		return err
+	}
+	*q = quantity
+	return nil
+}
@@ -0,0 +1,158 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/export"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// exportBatchSize is the number of orders read, written and flushed to the client at a time
+const exportBatchSize = 500
+
+// OrderExportRow is a line of GET /orders/export; IDs are public IDs
+type OrderExportRow struct {
+	ID          string             `json:"id"`
+	Number      string             `json:"number"`
+	UserID      string             `json:"user_id"`
+	UserEmail   string             `json:"user_email" mask:"email"`
+	ProductID   string             `json:"product_id"`
+	ProductName string             `json:"product_name"`
+	Quantity    int                `json:"quantity"`
+	Status      domain.OrderStatus `json:"status"`
+	// UnitPrice and Total are in minor units of Currency
+	UnitPrice int64  `json:"unit_price"`
+	Total     int64  `json:"total"`
+	Currency  string `json:"currency"`
+	Flag      string `json:"flag"`
+	Sandbox   bool   `json:"sandbox"`
+	// CreatedAt is omitted for orders placed before it was recorded
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// exportOrders streams the orders matching the filter of the query string. The filter takes
+// status (repeatable), user_id, product_id, min_quantity, max_quantity, user_email, and
+// created_after and created_before in RFC 3339.
+func (s *HTTPServer) exportOrders(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	filter, err := s.exportFilter(r.Context(), r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	policy, err := s.Masker.Policy(r.Context())
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	download := export.NewDownload(w, format, "orders")
+	rows := export.NewWriter[OrderExportRow](download, format)
+	err = s.OrderRepo.FindInBatches(r.Context(), filter, exportBatchSize, func(orders []domain.Order) error {
+		for i := range orders {
+			row := toOrderExportRow(&orders[i])
+			policy.Apply(&row, orders[i].UserID)
+			if err := rows.Write(row); err != nil {
+				return err
+			}
+		}
+		return rows.Flush()
+	})
+	if err == nil {
+		err = rows.Flush()
+	}
+	if err != nil {
+		download.Fail(r, err)
+	}
+}
+
+// exportFilter reads the OrderFilter of an export, resolving the public IDs it names
+func (s *HTTPServer) exportFilter(ctx context.Context, r *http.Request) (domain.OrderFilter, error) {
+	values := r.URL.Query()
+	filter := domain.OrderFilter{UserEmail: values.Get("user_email")}
+
+	var errs validation.Errors
+	for _, value := range values["status"] {
+		errs.Check(domain.OrderStatus(value).IsValid(), "status", fmt.Sprintf("is not a known order status, got %q", value))
+		filter.Statuses = append(filter.Statuses, value)
+	}
+	quantity := func(param string) int {
+		value := values.Get(param)
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(value)
+		errs.Check(err == nil && n >= 1, param, fmt.Sprintf("must be a positive number, got %q", value))
+		return n
+	}
+	filter.MinQuantity, filter.MaxQuantity = quantity("min_quantity"), quantity("max_quantity")
+	moment := func(param string) *time.Time {
+		value := values.Get(param)
+		if value == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		errs.Check(err == nil, param, fmt.Sprintf("must be an RFC 3339 time, got %q", value))
+		return &t
+	}
+	filter.CreatedAfter, filter.CreatedBefore = moment("created_after"), moment("created_before")
+	if err := errs.Err(); err != nil {
+		return filter, err
+	}
+
+	if id := values.Get("user_id"); id != "" {
+		u, err := s.UserRepo.GetByPublicID(ctx, id)
+		if errors.Is(err, persistence.ErrNotFound) {
+			errs.Add("user_id", fmt.Sprintf("is not a known user, got %q", id))
+		} else if err != nil {
+			return filter, fmt.Errorf("get user %s: %w", id, err)
+		} else {
+			filter.UserID = u.ID
+		}
+	}
+	if id := values.Get("product_id"); id != "" {
+		p, err := s.ProductRepo.GetByPublicID(ctx, id)
+		if errors.Is(err, persistence.ErrNotFound) {
+			errs.Add("product_id", fmt.Sprintf("is not a known product, got %q", id))
+		} else if err != nil {
+			return filter, fmt.Errorf("get product %s: %w", id, err)
+		} else {
+			filter.ProductID = p.ID
+		}
+	}
+	return filter, errs.Err()
+}
+
+func toOrderExportRow(o *domain.Order) OrderExportRow {
+	row := OrderExportRow{
+		ID:          o.PublicID,
+		Number:      o.Number,
+		UserID:      o.User.PublicID,
+		UserEmail:   string(o.User.Email),
+		ProductID:   o.Product.PublicID,
+		ProductName: o.Product.Name,
+		Quantity:    o.Quantity.Int(),
+		Status:      o.Status,
+		UnitPrice:   o.UnitPrice.Amount,
+		Total:       o.Total().Amount,
+		Currency:    o.UnitPrice.Currency,
+		Flag:        o.FlagReason,
+		Sandbox:     o.Sandbox,
+	}
+	if !o.CreatedAt.IsZero() {
+		row.CreatedAt = &o.CreatedAt
+	}
+	return row
+}
@@ -0,0 +1,482 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	asyncPort "github.com/mohsenjafari-aiio/aiiobackend/internal/async/port"
+	couponDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/domain"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+const dayLayout = "2006-01-02"
+
+// PlaceOrderRequest is the body of POST /orders; UserID, ProductID and ShippingAddressID are public IDs
+type PlaceOrderRequest struct {
+	UserID    string `json:"user_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	// ShippingAddressID is an address of the user's address book, see POST /users/{id}/addresses
+	ShippingAddressID string `json:"shipping_address_id"`
+
+	// Payment is a single payment; Payments splits the order across several instruments, e.g. a gift card and a card
+	Payment  *PaymentRequest  `json:"payment,omitempty"`
+	Payments []PaymentRequest `json:"payments,omitempty"`
+
+	// CouponCode takes the discount of a coupon off the order, see POST /coupons
+	CouponCode string `json:"coupon_code,omitempty"`
+}
+
+// PaymentRequest is a payment to authorize for an order; Amount is in minor units of Currency
+type PaymentRequest struct {
+	Method   string `json:"method"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// OrderResponse is the public representation of an order; ID is the public "ord_" ID
+type OrderResponse struct {
+	ID string `json:"id"`
+	// Number is the order number shown to customers, e.g. 20240518-000123; it is omitted for
+	// orders placed before numbers existed
+	Number    string             `json:"number,omitempty"`
+	UserID    string             `json:"user_id"`
+	ProductID string             `json:"product_id"`
+	Quantity  int                `json:"quantity"`
+	Status    domain.OrderStatus `json:"status"`
+	// ShippingAddressID is omitted for orders placed before addresses existed
+	ShippingAddressID string `json:"shipping_address_id,omitempty"`
+	// UnitPrice and Total are in minor units of Currency; they are omitted for orders of unpriced
+	// products. With ?currency= every amount is converted and rounded first, and the total is
+	// the sum of the converted amounts.
+	UnitPrice int64  `json:"unit_price,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	// Totals break the total down into subtotal, discount, tax and shipping; they are omitted for
+	// orders placed before totals were calculated, whose total is the unit price times the quantity
+	Totals *TotalsResponse `json:"totals,omitempty"`
+	// CouponCode is the coupon the discount in Totals came from
+	CouponCode string `json:"coupon_code,omitempty"`
+	// BaseUnitPrice, BaseTotal and BaseCurrency are the prices as charged; they are only reported
+	// when the prices above were converted
+	BaseUnitPrice int64  `json:"base_unit_price,omitempty"`
+	BaseTotal     int64  `json:"base_total,omitempty"`
+	BaseCurrency  string `json:"base_currency,omitempty"`
+	// Flag is the reason the order awaits review, e.g. "disputed"
+	Flag string `json:"flag,omitempty"`
+	// Sandbox marks test orders of sandbox tenants
+	Sandbox bool `json:"sandbox,omitempty"`
+	// EstimatedDelivery is the delivery window promised at checkout; only GET /orders/{id} reports it
+	EstimatedDelivery *DeliveryEstimateResponse `json:"estimated_delivery,omitempty"`
+}
+
+// TotalsResponse is what an order costs; amounts are in minor units of the currency of the order
+type TotalsResponse struct {
+	Subtotal int64 `json:"subtotal"`
+	// Discount is what the coupon of the order took off the subtotal
+	Discount int64 `json:"discount"`
+	// TaxRate is the percentage the tax was calculated at, e.g. "19" or "7.25"
+	TaxRate  string `json:"tax_rate"`
+	Tax      int64  `json:"tax"`
+	Shipping int64  `json:"shipping"`
+	Total    int64  `json:"total"`
+}
+
+// DeliveryEstimateResponse is the range of days, in YYYY-MM-DD format, an order is expected to
+// arrive in, and how the actual delivery compared once it arrived
+type DeliveryEstimateResponse struct {
+	Earliest    string                 `json:"earliest"`
+	Latest      string                 `json:"latest"`
+	DeliveredAt *time.Time             `json:"delivered_at,omitempty"`
+	Outcome     deliveryDomain.Outcome `json:"outcome,omitempty"`
+}
+
+// HTTPServer exposes the order use cases over HTTP
+type HTTPServer struct {
+	PlaceOrder decorator.CommandResultHandler[command.PlaceOrderCommand, *domain.Order]
+	// ListOrderSummaries lists orders from the order_summaries read model
+	ListOrderSummaries decorator.QueryHandler[query.ListOrderSummariesQuery, []domain.OrderSummary]
+	OrderRepo          domain.OrderRepository
+	// UserRepo, ProductRepo and Addresses resolve the public IDs of placed orders
+	UserRepo    userDomain.UserRepository
+	ProductRepo productDomain.ProductRepository
+	Addresses   userDomain.AddressRepository
+	// Estimates holds the delivery windows promised for orders; nil leaves them out
+	Estimates deliveryDomain.EstimateRepository
+
+	// Auth limits customers to their own orders; nil disables access control
+	Auth auth.Authorizer
+	// Masker hides the emails of users in order summaries from callers without pii:read; nil shows them
+	Masker *dto.Masker
+	// Converter returns prices in the currency of ?currency=; nil serves them as charged
+	Converter money.CurrencyConverter
+	// Async places the orders of requests sent with "Prefer: respond-async" in a background job,
+	// answering 202 with a command to poll; nil places every order right away
+	Async *asyncPort.Runner
+}
+
+// RegisterRoutes adds the order endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/orders",
+		Summary:  "Place an order; ?currency=USD converts the prices of the response. With \"Prefer: respond-async\" the order is placed in the background and the request answers 202 with a command whose result is this response, see GET /commands/{id}",
+		Tags:     []string{"orders"},
+		Request:  PlaceOrderRequest{},
+		Response: OrderResponse{},
+		Status:   http.StatusCreated,
+		Handler:  s.placeOrder,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/orders/{id}",
+		Summary:  "Get an order by ID, or as it was at a past moment with ?as_of=2024-05-18T12:00:00Z (support only); ?currency=USD converts its prices",
+		Tags:     []string{"orders"},
+		Response: OrderResponse{},
+		Handler:  s.getOrder,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/order-summaries",
+		Summary:  "List order summaries for the admin dashboard",
+		Tags:     []string{"orders"},
+		Response: OrderSummariesResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionOrderReadAny, s.listOrderSummaries),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/orders/export",
+		Summary:  "Export the orders matching a filter as CSV, or as JSON Lines with ?format=ndjson",
+		Tags:     []string{"orders"},
+		Response: OrderExportRow{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionOrderReadAny, s.exportOrders),
+	})
+}
+
+func (s *HTTPServer) placeOrder(w http.ResponseWriter, r *http.Request) {
+	var req PlaceOrderRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	currency, err := exchange.ParseCurrency(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	cmd, err := s.resolve(r.Context(), req)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	if err := auth.CheckOwned(r.Context(), s.Auth, userDomain.PermissionOrderCreateAny, userDomain.PermissionOrderCreateOwn, cmd.UserID); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+
+	cmd.Quantity = req.Quantity
+	payments, err := toPaymentDetails(req)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	cmd.Payments = payments
+
+	if s.Async != nil && asyncPort.Preferred(r) {
+		if err := validation.Struct(cmd); err != nil {
+			httpx.WriteError(w, err)
+			return
+		}
+		c, err := s.Async.Accept(r.Context(), cmd.UserID, func(commandID int64) jobs.Job {
+			return PlaceOrderJob{CommandID: commandID, Order: cmd, Currency: currency}
+		})
+		if err != nil {
+			httpx.WriteError(w, err)
+			return
+		}
+		asyncPort.WriteAccepted(w, c)
+		return
+	}
+	s.writePlacedOrder(r.Context(), w, cmd, currency)
+}
+
+// writePlacedOrder places the order and answers with it, or with why it was not placed
+func (s *HTTPServer) writePlacedOrder(ctx context.Context, w http.ResponseWriter, cmd command.PlaceOrderCommand, currency string) {
+	o, err := s.PlaceOrder.Handle(ctx, cmd)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	// The order is placed by now, so prices that cannot be converted are reported as charged
+	resp, err := s.pricedOrderResponse(ctx, o, currency)
+	if err != nil {
+		slog.WarnContext(ctx, "converting order prices failed", "order_id", o.ID, "currency", currency, "error", err)
+		resp = toOrderResponse(o)
+	}
+	httpx.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (s *HTTPServer) getOrder(w http.ResponseWriter, r *http.Request) {
+	asOf, err := temporal.ParseAsOf(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	currency, err := exchange.ParseCurrency(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	if asOf != nil {
+		s.getOrderAsOf(w, r, *asOf, currency)
+		return
+	}
+
+	o, err := s.OrderRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	if err := auth.CheckOwned(r.Context(), s.Auth, userDomain.PermissionOrderReadAny, userDomain.PermissionOrderReadOwn, o.UserID); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+
+	resp, err := s.pricedOrderResponse(r.Context(), o, currency)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	resp.EstimatedDelivery = s.deliveryEstimate(r.Context(), o.ID)
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// getOrderAsOf shows support staff an order as it was at a past moment, e.g. to explain what a
+// customer saw before a status change
+func (s *HTTPServer) getOrderAsOf(w http.ResponseWriter, r *http.Request, at time.Time, currency string) {
+	if err := auth.Check(r.Context(), s.Auth, userDomain.PermissionSupportQuery); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+
+	o, err := s.OrderRepo.GetByPublicIDAsOf(r.Context(), r.PathValue("id"), at)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	resp, err := s.pricedOrderResponse(r.Context(), o, currency)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// deliveryEstimate returns the delivery window of an order, or nil for orders placed without one.
+// The estimate only adds to the order, so failing to load it does not fail the request.
+func (s *HTTPServer) deliveryEstimate(ctx context.Context, orderID int64) *DeliveryEstimateResponse {
+	if s.Estimates == nil {
+		return nil
+	}
+	e, err := s.Estimates.GetByOrderID(ctx, orderID)
+	if err != nil {
+		if !errors.Is(err, persistence.ErrNotFound) {
+			slog.WarnContext(ctx, "loading delivery estimate failed", "order_id", orderID, "error", err)
+		}
+		return nil
+	}
+
+	w := e.Window()
+	return &DeliveryEstimateResponse{
+		Earliest:    w.Earliest.Format(dayLayout),
+		Latest:      w.Latest.Format(dayLayout),
+		DeliveredAt: e.DeliveredAt,
+		Outcome:     e.Outcome(),
+	}
+}
+
+// resolve maps the public user, product and address IDs of the request to the primary keys of the command
+func (s *HTTPServer) resolve(ctx context.Context, req PlaceOrderRequest) (command.PlaceOrderCommand, error) {
+	var cmd command.PlaceOrderCommand
+	var errs validation.Errors
+	errs.Check(req.UserID != "", "user_id", "is required")
+	errs.Check(req.ProductID != "", "product_id", "is required")
+	errs.Check(req.ShippingAddressID != "", "shipping_address_id", "is required")
+	if err := errs.Err(); err != nil {
+		return cmd, err
+	}
+
+	u, err := s.UserRepo.GetByPublicID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return cmd, userDomain.ErrUserNotFound
+		}
+		return cmd, fmt.Errorf("get user %s: %w", req.UserID, err)
+	}
+	p, err := s.ProductRepo.GetByPublicID(ctx, req.ProductID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return cmd, productDomain.ErrProductNotFound
+		}
+		return cmd, fmt.Errorf("get product %s: %w", req.ProductID, err)
+	}
+	// The command checks the address belongs to the user and reports a missing one the same way
+	a, err := s.Addresses.GetByPublicID(ctx, req.ShippingAddressID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			errs.Add("shipping_address_id", "must be an address of the user")
+			return cmd, errs
+		}
+		return cmd, fmt.Errorf("get address %s: %w", req.ShippingAddressID, err)
+	}
+
+	cmd.UserID, cmd.ProductID, cmd.ShippingAddressID = u.ID, p.ID, a.ID
+	cmd.CouponCode = req.CouponCode
+	return cmd, nil
+}
+
+// toPaymentDetails accepts either the single payment or the list of payments of the request
+func toPaymentDetails(req PlaceOrderRequest) ([]command.PaymentDetails, error) {
+	var errs validation.Errors
+	if req.Payment != nil {
+		errs.Check(len(req.Payments) == 0, "payments", "cannot be combined with payment")
+		if err := errs.Err(); err != nil {
+			return nil, err
+		}
+		amount, err := money.New(req.Payment.Amount, req.Payment.Currency)
+		if err != nil {
+			errs.Add("payment.currency", err.Error())
+			return nil, errs
+		}
+		return []command.PaymentDetails{{Method: req.Payment.Method, Amount: amount}}, nil
+	}
+
+	details := make([]command.PaymentDetails, len(req.Payments))
+	for i, p := range req.Payments {
+		amount, err := money.New(p.Amount, p.Currency)
+		if err != nil {
+			errs.Add(fmt.Sprintf("payments[%d].currency", i), err.Error())
+			continue
+		}
+		details[i] = command.PaymentDetails{Method: p.Method, Amount: amount}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+func toOrderResponse(o *domain.Order) OrderResponse {
+	var shippingAddressID string
+	if o.ShippingAddress != nil {
+		shippingAddressID = o.ShippingAddress.PublicID
+	}
+	resp := OrderResponse{
+		ID:                o.PublicID,
+		Number:            o.Number,
+		UserID:            o.User.PublicID,
+		ProductID:         o.Product.PublicID,
+		Quantity:          o.Quantity.Int(),
+		Status:            o.Status,
+		ShippingAddressID: shippingAddressID,
+		UnitPrice:         o.UnitPrice.Amount,
+		Total:             o.Total().Amount,
+		Currency:          o.UnitPrice.Currency,
+		Flag:              o.FlagReason,
+		Sandbox:           o.Sandbox,
+		CouponCode:        o.CouponCode,
+	}
+	if !o.Totals.IsZero() {
+		resp.Totals = toTotalsResponse(o.Totals, o.TaxRate)
+	}
+	return resp
+}
+
+func toTotalsResponse(t pricing.Totals, rate pricing.Rate) *TotalsResponse {
+	return &TotalsResponse{
+		Subtotal: t.Subtotal.Amount,
+		Discount: t.Discount.Amount,
+		TaxRate:  rate.Percent(),
+		Tax:      t.Tax.Amount,
+		Shipping: t.Shipping.Amount,
+		Total:    t.Total.Amount,
+	}
+}
+
+// pricedOrderResponse is like toOrderResponse with the prices converted into currency at the
+// current rate; an empty currency keeps them as charged
+func (s *HTTPServer) pricedOrderResponse(ctx context.Context, o *domain.Order, currency string) (OrderResponse, error) {
+	resp := toOrderResponse(o)
+	unitPrice, err := exchange.Convert(ctx, s.Converter, o.UnitPrice, currency)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	if unitPrice == o.UnitPrice {
+		return resp, nil
+	}
+	resp.BaseUnitPrice, resp.BaseTotal, resp.BaseCurrency = resp.UnitPrice, resp.Total, resp.Currency
+	resp.UnitPrice, resp.Total, resp.Currency = unitPrice.Amount, unitPrice.Mul(int64(o.Quantity)).Amount, unitPrice.Currency
+	if o.Totals.IsZero() {
+		return resp, nil
+	}
+
+	// Convert each part so the converted total still adds up
+	totals, err := o.Totals.Convert(func(m money.Money) (money.Money, error) {
+		return exchange.Convert(ctx, s.Converter, m, currency)
+	})
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	resp.Total = totals.Total.Amount
+	resp.Totals = toTotalsResponse(totals, o.TaxRate)
+	return resp, nil
+}
+
+// writeOrderError maps order domain errors onto HTTP status codes
+func writeOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, userDomain.ErrUserNotFound), errors.Is(err, productDomain.ErrProductNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, userDomain.ErrUserDeactivated):
+		httpx.WriteErrorStatus(w, http.StatusForbidden, err)
+	case errors.Is(err, productDomain.ErrInsufficientStock), errors.Is(err, domain.ErrInvalidTransition):
+		httpx.WriteErrorStatus(w, http.StatusConflict, err)
+	case errors.Is(err, paymentDomain.ErrPaymentDeclined):
+		httpx.WriteErrorCode(w, http.StatusPaymentRequired, paymentDomain.ErrorCodePaymentDeclined, err)
+	case errors.Is(err, quotaDomain.ErrQuotaExceeded):
+		httpx.WriteErrorCode(w, http.StatusTooManyRequests, quotaDomain.ErrorCodeQuotaExceeded, err)
+	case errors.Is(err, couponDomain.ErrCouponNotFound), errors.Is(err, couponDomain.ErrCouponInactive),
+		errors.Is(err, couponDomain.ErrCouponUsedUp), errors.Is(err, couponDomain.ErrCouponNotApplicable):
+		httpx.WriteErrorCode(w, http.StatusUnprocessableEntity, couponDomain.ErrorCodeCouponRejected, err)
+	case errors.Is(err, domain.ErrOrderRejected):
+		httpx.WriteErrorCode(w, http.StatusUnprocessableEntity, domain.ErrorCodeOrderRejected, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
@@ -0,0 +1,30 @@
+package port
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+)
+
+// PlaceOrderJob places an order accepted with 202; the command with CommandID receives the response
+type PlaceOrderJob struct {
+	CommandID int64                     `json:"command_id"`
+	Order     command.PlaceOrderCommand `json:"order"`
+	// Currency is the ?currency= of the request
+	Currency string `json:"currency,omitempty"`
+}
+
+func (PlaceOrderJob) Kind() string {
+	return "order.place"
+}
+
+// RegisterJobs adds the handler of orders accepted with 202 to the worker; it needs Async
+func (s *HTTPServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, job PlaceOrderJob) error {
+		return s.Async.Run(ctx, job.CommandID, func(ctx context.Context, w http.ResponseWriter) {
+			s.writePlacedOrder(ctx, w, job.Order, job.Currency)
+		})
+	})
+}
@@ -0,0 +1,172 @@
+package port
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+)
+
+// OrderPlacedMessage is the payload of order.placed messages, the contract with consuming services.
+// Orders, users and products are named by their public IDs.
+type OrderPlacedMessage struct {
+	OrderID string `json:"order_id"`
+	// OrderNumber is the number shown to the customer; it is omitted for orders placed before numbers existed
+	OrderNumber string `json:"order_number,omitempty"`
+	UserID      string `json:"user_id"`
+	ProductID   string `json:"product_id"`
+	// UserEmail and ProductName are omitted by messages published before they were added
+	UserEmail   string `json:"user_email,omitempty"`
+	ProductName string `json:"product_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	// Amount is omitted for orders of unpriced products
+	Amount   *money.Money `json:"amount,omitempty"`
+	PlacedAt time.Time    `json:"placed_at"`
+	Sandbox  bool         `json:"sandbox,omitempty"`
+}
+
+// OrderCancelledMessage is the payload of order.cancelled messages
+type OrderCancelledMessage struct {
+	OrderID     string    `json:"order_id"`
+	UserID      string    `json:"user_id"`
+	ProductID   string    `json:"product_id"`
+	Quantity    int       `json:"quantity"`
+	Reason      string    `json:"reason"`
+	Restocked   bool      `json:"restocked"`
+	CancelledAt time.Time `json:"cancelled_at"`
+	Sandbox     bool      `json:"sandbox,omitempty"`
+}
+
+// OrderRefundedMessage is the payload of order.refunded messages, published for every refund of an
+// order. FullyRefunded is set by the refund that returned the last of the money captured.
+type OrderRefundedMessage struct {
+	OrderID       string      `json:"order_id"`
+	UserID        string      `json:"user_id"`
+	ProductID     string      `json:"product_id"`
+	Quantity      int         `json:"quantity"`
+	RefundNumber  int         `json:"refund_number"`
+	Amount        money.Money `json:"amount"`
+	Reason        string      `json:"reason,omitempty"`
+	Restocked     bool        `json:"restocked"`
+	FullyRefunded bool        `json:"fully_refunded"`
+	RefundedAt    time.Time   `json:"refunded_at"`
+	Sandbox       bool        `json:"sandbox,omitempty"`
+}
+
+// HeaderTenant carries the tenant the order belongs to on order messages
+const HeaderTenant = "tenant"
+
+// MessagingServer announces order events to other services on a topic. Messages are keyed by
+// order, so consumers see the events of an order in the order they happened.
+//
+// It is the event.Publisher commands hand order events to in the transaction that saves the
+// order, so with the outbox as Publisher the message commits with the order or not at all.
+// Events other than order placements, cancellations and refunds are ignored.
+type MessagingServer struct {
+	Publisher messaging.Publisher
+	Topic     string
+}
+
+// Publish writes the message of each order event
+func (s *MessagingServer) Publish(ctx context.Context, events ...event.Event) error {
+	for _, e := range events {
+		var err error
+		switch e.EventName() {
+		case domain.OrderPlacedEvent:
+			err = s.orderPlaced(ctx, e)
+		case domain.OrderCancelledEvent:
+			err = s.orderCancelled(ctx, e)
+		case domain.OrderRefundedEvent:
+			err = s.orderRefunded(ctx, e)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MessagingServer) orderPlaced(ctx context.Context, e event.Event) error {
+	placed, ok := e.(domain.OrderPlaced)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	payload := OrderPlacedMessage{
+		OrderID:     placed.OrderPublicID,
+		OrderNumber: placed.OrderNumber,
+		UserID:      placed.UserPublicID,
+		ProductID:   placed.ProductPublicID,
+		UserEmail:   placed.Email.String(),
+		ProductName: placed.ProductName,
+		Quantity:    placed.Quantity,
+		PlacedAt:    placed.PlacedAt,
+		Sandbox:     placed.Sandbox,
+	}
+	if !placed.Amount.IsZero() {
+		payload.Amount = &placed.Amount
+	}
+	return s.publish(ctx, placed, placed.OrderPublicID, placed.PlacedAt, payload)
+}
+
+func (s *MessagingServer) orderCancelled(ctx context.Context, e event.Event) error {
+	cancelled, ok := e.(domain.OrderCancelled)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	return s.publish(ctx, cancelled, cancelled.OrderPublicID, cancelled.CancelledAt, OrderCancelledMessage{
+		OrderID:     cancelled.OrderPublicID,
+		UserID:      cancelled.UserPublicID,
+		ProductID:   cancelled.ProductPublicID,
+		Quantity:    cancelled.Quantity,
+		Reason:      cancelled.Reason,
+		Restocked:   cancelled.Restocked,
+		CancelledAt: cancelled.CancelledAt,
+		Sandbox:     cancelled.Sandbox,
+	})
+}
+
+func (s *MessagingServer) orderRefunded(ctx context.Context, e event.Event) error {
+	refunded, ok := e.(domain.OrderRefunded)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	return s.publish(ctx, refunded, refunded.OrderPublicID, refunded.RefundedAt, OrderRefundedMessage{
+		OrderID:       refunded.OrderPublicID,
+		UserID:        refunded.UserPublicID,
+		ProductID:     refunded.ProductPublicID,
+		Quantity:      refunded.Quantity,
+		RefundNumber:  refunded.RefundNumber,
+		Amount:        refunded.Amount,
+		Reason:        refunded.Reason,
+		Restocked:     refunded.Restocked,
+		FullyRefunded: refunded.FullyRefunded,
+		RefundedAt:    refunded.RefundedAt,
+		Sandbox:       refunded.Sandbox,
+	})
+}
+
+// publish sends the message of e, identified by the event ID so consumers can deduplicate replays
+func (s *MessagingServer) publish(ctx context.Context, e event.Event, orderID string, at time.Time, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", e.EventName(), err)
+	}
+
+	return s.Publisher.Publish(ctx, s.Topic, messaging.Message{
+		ID:      e.EventID(),
+		Type:    e.EventName(),
+		Key:     orderID,
+		Payload: body,
+		Headers: map[string]string{HeaderTenant: tenant.FromContext(ctx)},
+		Time:    at,
+	})
+}
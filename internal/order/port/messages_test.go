@@ -0,0 +1,74 @@
+package port_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagingServer_PublishesOrderEvents(t *testing.T) {
+	broker := messaging.NewMemoryBroker()
+	server := &port.MessagingServer{Publisher: broker, Topic: "order-events"}
+	ctx := tenant.WithID(context.Background(), "acme")
+	at := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+
+	err := server.Publish(ctx,
+		domain.OrderPlaced{OrderID: 42, UserID: 7, ProductID: 3, OrderPublicID: "ord_1", UserPublicID: "usr_7", ProductPublicID: "prd_3", OrderVersion: 1, Quantity: 2, Amount: money.Money{Amount: 2500, Currency: "EUR"}, PlacedAt: at},
+		domain.OrderCancelled{OrderID: 42, UserID: 7, ProductID: 3, OrderPublicID: "ord_1", UserPublicID: "usr_7", ProductPublicID: "prd_3", OrderVersion: 2, Quantity: 2, Reason: domain.CancelReasonPaymentFailed, Restocked: true, CancelledAt: at},
+	)
+
+	assert.NoError(t, err)
+	msgs := broker.Messages("order-events")
+	if !assert.Len(t, msgs, 2) {
+		return
+	}
+
+	assert.Equal(t, domain.OrderPlacedEvent, msgs[0].Type)
+	assert.Equal(t, event.NewID(domain.OrderPlacedEvent, "ord_1", 1), msgs[0].ID, "messages are identified by their event")
+	assert.Equal(t, "ord_1", msgs[0].Key)
+	assert.Equal(t, "acme", msgs[0].Headers[port.HeaderTenant])
+	var placed port.OrderPlacedMessage
+	assert.NoError(t, json.Unmarshal(msgs[0].Payload, &placed))
+	assert.Equal(t, &money.Money{Amount: 2500, Currency: "EUR"}, placed.Amount)
+	assert.Equal(t, 2, placed.Quantity)
+
+	assert.Equal(t, domain.OrderCancelledEvent, msgs[1].Type)
+	assert.Equal(t, event.NewID(domain.OrderCancelledEvent, "ord_1", 2), msgs[1].ID)
+	assert.Equal(t, "ord_1", msgs[1].Key, "events of an order share its key so they stay in order")
+	assert.JSONEq(t, `{"order_id":"ord_1","user_id":"usr_7","product_id":"prd_3","quantity":2,"reason":"payment_failed","restocked":true,"cancelled_at":"2024-05-18T12:00:00Z"}`, string(msgs[1].Payload))
+}
+
+func TestMessagingServer_OmitsAmountOfUnpricedOrders(t *testing.T) {
+	broker := messaging.NewMemoryBroker()
+	server := &port.MessagingServer{Publisher: broker, Topic: "order-events"}
+
+	assert.NoError(t, server.Publish(context.Background(), domain.OrderPlaced{OrderID: 1, Quantity: 1}))
+
+	msgs := broker.Messages("order-events")
+	if assert.Len(t, msgs, 1) {
+		assert.NotContains(t, string(msgs[0].Payload), "amount")
+		assert.Equal(t, tenant.DefaultID, msgs[0].Headers[port.HeaderTenant])
+	}
+}
+
+type stockLow struct{}
+
+func (stockLow) EventName() string { return "product.stock_low" }
+func (stockLow) EventID() string   { return "evt_1" }
+
+func TestMessagingServer_IgnoresOtherEvents(t *testing.T) {
+	broker := messaging.NewMemoryBroker()
+	server := &port.MessagingServer{Publisher: broker, Topic: "order-events"}
+
+	assert.NoError(t, server.Publish(context.Background(), stockLow{}))
+	assert.Empty(t, broker.Messages("order-events"))
+}
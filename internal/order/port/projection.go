@@ -0,0 +1,106 @@
+package port
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// OrderSummaryProjectionName names the projection on the command line, e.g. `projections replay --projection order_summary`
+const OrderSummaryProjectionName = "order_summary"
+
+// OrderSummaryProjection maintains the order summaries from the messages of the order topic
+type OrderSummaryProjection struct {
+	Summaries domain.OrderSummaryRepository
+	Topic     string
+}
+
+func (p *OrderSummaryProjection) Name() string {
+	return OrderSummaryProjectionName
+}
+
+// Handle applies order.placed and order.cancelled. A payload that does not decode can never be
+// applied, so it is logged and skipped instead of holding back the projection.
+func (p *OrderSummaryProjection) Handle(ctx context.Context, topic string, msg messaging.Message) error {
+	if topic != p.Topic {
+		return nil
+	}
+
+	switch msg.Type {
+	case domain.OrderPlacedEvent:
+		var placed OrderPlacedMessage
+		if err := json.Unmarshal(msg.Payload, &placed); err != nil {
+			slog.ErrorContext(ctx, "skipping undecodable message", "projection", p.Name(), "type", msg.Type, "id", msg.ID, "error", err)
+			return nil
+		}
+		var amount money.Money
+		if placed.Amount != nil {
+			amount = *placed.Amount
+		}
+		return p.apply(ctx, msg, placed.OrderID, placed.UserID, placed.ProductID, placed.Quantity, placed.Sandbox, func(s *domain.OrderSummary) {
+			s.ApplyPlaced(placed.PlacedAt, amount)
+			// Replays of messages published before the names were added keep the ones already known
+			if placed.UserEmail != "" {
+				s.UserEmail = placed.UserEmail
+			}
+			if placed.ProductName != "" {
+				s.ProductName = placed.ProductName
+			}
+			if placed.OrderNumber != "" {
+				s.OrderNumber = placed.OrderNumber
+			}
+		})
+	case domain.OrderCancelledEvent:
+		var cancelled OrderCancelledMessage
+		if err := json.Unmarshal(msg.Payload, &cancelled); err != nil {
+			slog.ErrorContext(ctx, "skipping undecodable message", "projection", p.Name(), "type", msg.Type, "id", msg.ID, "error", err)
+			return nil
+		}
+		return p.apply(ctx, msg, cancelled.OrderID, cancelled.UserID, cancelled.ProductID, cancelled.Quantity, cancelled.Sandbox, func(s *domain.OrderSummary) {
+			s.ApplyCancelled(cancelled.CancelledAt, cancelled.Reason)
+		})
+	case domain.OrderRefundedEvent:
+		var refunded OrderRefundedMessage
+		if err := json.Unmarshal(msg.Payload, &refunded); err != nil {
+			slog.ErrorContext(ctx, "skipping undecodable message", "projection", p.Name(), "type", msg.Type, "id", msg.ID, "error", err)
+			return nil
+		}
+		// Partial refunds leave the order as it is
+		if !refunded.FullyRefunded {
+			return nil
+		}
+		return p.apply(ctx, msg, refunded.OrderID, refunded.UserID, refunded.ProductID, refunded.Quantity, refunded.Sandbox, func(s *domain.OrderSummary) {
+			s.ApplyRefunded(refunded.RefundedAt)
+		})
+	}
+	return nil
+}
+
+// apply loads the summary of the order, or starts one, fills in the fields every order message
+// carries and saves it after change
+func (p *OrderSummaryProjection) apply(ctx context.Context, msg messaging.Message, orderID, userID, productID string, quantity int, sandbox bool, change func(s *domain.OrderSummary)) error {
+	// Summaries belong to the tenant of the order; messages published before the header existed
+	// are of the default tenant
+	if id := msg.Headers[HeaderTenant]; id != "" {
+		ctx = tenant.WithID(ctx, id)
+	}
+	s, err := p.Summaries.GetByID(ctx, orderID)
+	if errors.Is(err, persistence.ErrNotFound) {
+		s, err = &domain.OrderSummary{OrderID: orderID}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.TenantID = msg.Headers[HeaderTenant]
+	s.UserID, s.ProductID, s.Quantity, s.Sandbox = userID, productID, quantity, sandbox
+	change(s)
+	return p.Summaries.Save(ctx, s)
+}
@@ -0,0 +1,136 @@
+package port_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSummaries(t *testing.T) (*port.OrderSummaryProjection, domain.OrderSummaryRepository) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.OrderSummary{}))
+
+	summaries := adapter.NewGormOrderSummaryRepository(db)
+	return &port.OrderSummaryProjection{Summaries: summaries, Topic: "order-events"}, summaries
+}
+
+func orderMessage(t *testing.T, msgType string, payload interface{}) messaging.Message {
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return messaging.Message{Type: msgType, Payload: body, Headers: map[string]string{port.HeaderTenant: "acme"}}
+}
+
+func TestOrderSummaryProjection_PlacedThenCancelled(t *testing.T) {
+	p, summaries := setupSummaries(t)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	placed := orderMessage(t, domain.OrderPlacedEvent, port.OrderPlacedMessage{
+		OrderID: "ord_1", OrderNumber: "20240518-000001", UserID: "usr_7", ProductID: "prd_3", UserEmail: "ann@example.com", ProductName: "Lamp",
+		Quantity: 2, Amount: &money.Money{Amount: 2500, Currency: "EUR"}, PlacedAt: at,
+	})
+	cancelled := orderMessage(t, domain.OrderCancelledEvent, port.OrderCancelledMessage{
+		OrderID: "ord_1", UserID: "usr_7", ProductID: "prd_3", Quantity: 2, Reason: domain.CancelReasonPaymentFailed, CancelledAt: at.Add(time.Minute),
+	})
+
+	require.NoError(t, p.Handle(ctx, "order-events", placed))
+	s, err := summaries.GetByID(ctx, "ord_1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusConfirmed, s.Status)
+	assert.Equal(t, "acme", s.TenantID)
+	assert.Equal(t, money.Money{Amount: 2500, Currency: "EUR"}, s.Amount)
+	assert.Equal(t, "ann@example.com", s.UserEmail)
+	assert.Equal(t, "Lamp", s.ProductName)
+	assert.Equal(t, "20240518-000001", s.OrderNumber)
+
+	require.NoError(t, p.Handle(ctx, "order-events", cancelled))
+	require.NoError(t, p.Handle(ctx, "order-events", placed), "a replayed placement keeps the cancellation")
+	s, err = summaries.GetByID(ctx, "ord_1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusCancelled, s.Status)
+	assert.Equal(t, domain.CancelReasonPaymentFailed, s.CancelReason)
+	assert.Equal(t, at, s.PlacedAt.UTC())
+}
+
+func TestOrderSummaryProjection_RefundedOnceFullyRefunded(t *testing.T) {
+	p, summaries := setupSummaries(t)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	placed := orderMessage(t, domain.OrderPlacedEvent, port.OrderPlacedMessage{
+		OrderID: "ord_1", UserID: "usr_7", ProductID: "prd_3", Quantity: 2, Amount: &money.Money{Amount: 2500, Currency: "EUR"}, PlacedAt: at,
+	})
+	refund := func(number int, full bool) messaging.Message {
+		return orderMessage(t, domain.OrderRefundedEvent, port.OrderRefundedMessage{
+			OrderID: "ord_1", UserID: "usr_7", ProductID: "prd_3", Quantity: 2, RefundNumber: number,
+			Amount: money.Money{Amount: 1000, Currency: "EUR"}, FullyRefunded: full, RefundedAt: at.Add(time.Duration(number) * time.Hour),
+		})
+	}
+
+	require.NoError(t, p.Handle(ctx, "order-events", placed))
+	require.NoError(t, p.Handle(ctx, "order-events", refund(1, false)))
+	s, err := summaries.GetByID(ctx, "ord_1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusConfirmed, s.Status, "a partial refund keeps the order status")
+
+	require.NoError(t, p.Handle(ctx, "order-events", refund(2, true)))
+	require.NoError(t, p.Handle(ctx, "order-events", placed), "a replayed placement keeps the refund")
+	s, err = summaries.GetByID(ctx, "ord_1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusRefunded, s.Status)
+	assert.Equal(t, at.Add(2*time.Hour), s.RefundedAt.UTC())
+}
+
+func TestOrderSummaryRepository_List(t *testing.T) {
+	p, summaries := setupSummaries(t)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	for i, userID := range []string{"usr_1", "usr_2", "usr_1"} {
+		orderID := fmt.Sprintf("ord_%d", i+1)
+		require.NoError(t, p.Handle(ctx, "order-events", orderMessage(t, domain.OrderPlacedEvent, port.OrderPlacedMessage{
+			OrderID: orderID, UserID: userID, ProductID: "prd_3", Quantity: 1, PlacedAt: at.Add(time.Duration(i) * time.Hour),
+		})))
+	}
+	require.NoError(t, p.Handle(ctx, "order-events", orderMessage(t, domain.OrderCancelledEvent, port.OrderCancelledMessage{
+		OrderID: "ord_3", UserID: "usr_1", ProductID: "prd_3", Quantity: 1, CancelledAt: at.Add(3 * time.Hour),
+	})))
+
+	list, err := summaries.List(ctx, domain.OrderSummaryFilter{})
+	require.NoError(t, err)
+	ids := make([]string, len(list))
+	for i, s := range list {
+		ids[i] = s.OrderID
+	}
+	assert.Equal(t, []string{"ord_3", "ord_2", "ord_1"}, ids, "newest placed first")
+
+	list, err = summaries.List(ctx, domain.OrderSummaryFilter{UserID: "usr_1", Status: domain.StatusConfirmed, Limit: 5})
+	require.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "ord_1", list[0].OrderID)
+	}
+}
+
+func TestOrderSummaryProjection_IgnoresOtherMessages(t *testing.T) {
+	p, summaries := setupSummaries(t)
+	ctx := context.Background()
+
+	assert.NoError(t, p.Handle(ctx, "user-events", orderMessage(t, domain.OrderPlacedEvent, port.OrderPlacedMessage{OrderID: "ord_1"})))
+	assert.NoError(t, p.Handle(ctx, "order-events", orderMessage(t, "order.shipped", port.OrderPlacedMessage{OrderID: "ord_2"})))
+	assert.NoError(t, p.Handle(ctx, "order-events", messaging.Message{Type: domain.OrderPlacedEvent, Payload: []byte(`not json`)}))
+
+	for _, id := range []string{"ord_1", "ord_2"} {
+		_, err := summaries.GetByID(ctx, id)
+		assert.Error(t, err, id)
+	}
+}
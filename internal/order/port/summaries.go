@@ -0,0 +1,86 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// OrderSummaryResponse is an order as listed on the admin dashboard; IDs are public IDs
+type OrderSummaryResponse struct {
+	ID          string             `json:"id"`
+	UserID      string             `json:"user_id"`
+	UserEmail   string             `json:"user_email,omitempty" mask:"email"`
+	ProductID   string             `json:"product_id"`
+	ProductName string             `json:"product_name,omitempty"`
+	Quantity    int                `json:"quantity"`
+	Status      domain.OrderStatus `json:"status"`
+	// Total is in minor units of Currency; it is omitted for orders of unpriced products
+	Total        int64      `json:"total,omitempty"`
+	Currency     string     `json:"currency,omitempty"`
+	PlacedAt     *time.Time `json:"placed_at,omitempty"`
+	CancelReason string     `json:"cancel_reason,omitempty"`
+	Sandbox      bool       `json:"sandbox,omitempty"`
+}
+
+// OrderSummariesResponse lists order summaries, newest first
+type OrderSummariesResponse struct {
+	Orders []dto.Sparse[OrderSummaryResponse] `json:"orders"`
+}
+
+func (s *HTTPServer) listOrderSummaries(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	q := query.ListOrderSummariesQuery{Status: domain.OrderStatus(values.Get("status")), UserID: values.Get("user_id")}
+
+	var errs validation.Errors
+	if value := values.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		errs.Check(err == nil && n >= 1, "limit", fmt.Sprintf("must be between 1 and %d, got %q", query.MaxSummaryLimit, value))
+		q.Limit = n
+	}
+	fields, err := dto.ParseFields[OrderSummaryResponse](r)
+	errs.Merge("", err)
+	if err := errs.Err(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	summaries, err := s.ListOrderSummaries.Handle(r.Context(), q)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	policy, err := s.Masker.Policy(r.Context())
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	// Summaries only know the public ID of the user, so the caller's own orders are masked too
+	resp := OrderSummariesResponse{Orders: dto.Map(summaries, toOrderSummaryResponse, fields)}
+	policy.Apply(&resp, 0)
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func toOrderSummaryResponse(s *domain.OrderSummary) OrderSummaryResponse {
+	return OrderSummaryResponse{
+		ID:           s.OrderID,
+		UserID:       s.UserID,
+		UserEmail:    s.UserEmail,
+		ProductID:    s.ProductID,
+		ProductName:  s.ProductName,
+		Quantity:     s.Quantity,
+		Status:       s.Status,
+		Total:        s.Amount.Amount,
+		Currency:     s.Amount.Currency,
+		PlacedAt:     s.PlacedAt,
+		CancelReason: s.CancelReason,
+		Sandbox:      s.Sandbox,
+	}
+}
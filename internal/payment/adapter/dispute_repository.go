@@ -0,0 +1,115 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// disputeGroupExpressions are the SQL expressions orders are grouped by in dispute rate reports
+var disputeGroupExpressions = map[domain.DisputeGrouping]string{
+	domain.GroupByProduct: "CAST(o.product_id AS TEXT)",
+	domain.GroupBySegment: fmt.Sprintf(
+		"CASE WHEN EXISTS (SELECT 1 FROM orders prev WHERE prev.user_id = o.user_id AND prev.id < o.id) THEN '%s' ELSE '%s' END",
+		domain.SegmentReturning, domain.SegmentNew,
+	),
+}
+
+type GormDisputeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDisputeRepository(db *gorm.DB) domain.DisputeRepository {
+	return &GormDisputeRepository{db: db}
+}
+
+func (r *GormDisputeRepository) Create(ctx context.Context, d *domain.Dispute) error {
+	return persistence.TranslateError(r.db.WithContext(ctx).Create(d).Error)
+}
+
+func (r *GormDisputeRepository) Update(ctx context.Context, d *domain.Dispute) error {
+	err := r.db.WithContext(ctx).Model(d).
+		Select("status", "reason", "evidence_due_by", "closed_at", "updated_at").
+		Updates(d).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormDisputeRepository) GetByID(ctx context.Context, id int64) (*domain.Dispute, error) {
+	var d domain.Dispute
+	err := r.db.WithContext(ctx).
+		Preload("Evidence", func(db *gorm.DB) *gorm.DB {
+			return db.Order("uploaded_at ASC, id ASC")
+		}).
+		First(&d, id).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &d, nil
+}
+
+func (r *GormDisputeRepository) GetByReference(ctx context.Context, gateway, reference string) (*domain.Dispute, error) {
+	var d domain.Dispute
+	err := r.db.WithContext(ctx).Where("gateway = ? AND reference = ?", gateway, reference).First(&d).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &d, nil
+}
+
+func (r *GormDisputeRepository) List(ctx context.Context, filter domain.DisputeFilter) ([]domain.Dispute, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC, id DESC")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.OrderID != 0 {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.WithEvidence {
+		query = query.Preload("Evidence", func(db *gorm.DB) *gorm.DB {
+			return db.Order("uploaded_at ASC, id ASC")
+		})
+	}
+
+	var disputes []domain.Dispute
+	if err := query.Find(&disputes).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return disputes, nil
+}
+
+func (r *GormDisputeRepository) AddEvidence(ctx context.Context, e *domain.DisputeEvidence) error {
+	return persistence.TranslateError(r.db.WithContext(ctx).Create(e).Error)
+}
+
+// Rates counts an order once however many payments or disputes it has
+func (r *GormDisputeRepository) Rates(ctx context.Context, from, to time.Time, groupBy domain.DisputeGrouping) ([]domain.DisputeRate, error) {
+	group, ok := disputeGroupExpressions[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown dispute grouping %q", groupBy)
+	}
+
+	paid := r.db.Table("orders o").
+		Distinct("o.id AS order_id", group+" AS group_key").
+		Joins("JOIN payments p ON p.order_id = o.id").
+		Where("p.created_at >= ? AND p.created_at < ?", from, to)
+
+	var rates []domain.DisputeRate
+	err := r.db.WithContext(ctx).
+		Table("(?) AS paid", paid).
+		Select("paid.group_key AS \"group\", COUNT(DISTINCT paid.order_id) AS orders, COUNT(DISTINCT d.order_id) AS disputed").
+		Joins("LEFT JOIN disputes d ON d.order_id = paid.order_id").
+		Group("paid.group_key").
+		Order("paid.group_key").
+		Scan(&rates).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,96 @@
+package adapter_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupDisputeDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&orderDomain.Order{}, &domain.Payment{}, &domain.Dispute{}, &domain.DisputeEvidence{}))
+	return db
+}
+
+func TestGormDisputeRepository_CreateUpdateAndGet(t *testing.T) {
+	repo := adapter.NewGormDisputeRepository(setupDisputeDB(t))
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	p := &domain.Payment{ID: 1, OrderID: 42, Gateway: "stripe", Reference: "pi_1"}
+	d := domain.NewDispute(p, money.Money{Amount: 3000, Currency: "EUR"}, domain.DisputeDetails{Reference: "dp_1", Reason: "fraudulent"}, now)
+	require.NoError(t, repo.Create(ctx, d))
+
+	require.True(t, d.Apply(domain.DisputeDetails{Reference: "dp_1", Status: domain.DisputeLost}, now.Add(time.Hour)))
+	require.NoError(t, repo.Update(ctx, d))
+	require.NoError(t, repo.AddEvidence(ctx, &domain.DisputeEvidence{DisputeID: d.ID, FileName: "receipt.pdf", ContentType: "application/pdf", Size: 10, StorageKey: "disputes/1/receipt.pdf", UploadedAt: now}))
+
+	found, err := repo.GetByReference(ctx, "stripe", "dp_1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DisputeLost, found.Status)
+	assert.Equal(t, "fraudulent", found.Reason)
+	assert.NotNil(t, found.ClosedAt)
+
+	withEvidence, err := repo.GetByID(ctx, d.ID)
+	require.NoError(t, err)
+	require.Len(t, withEvidence.Evidence, 1)
+	assert.Equal(t, "receipt.pdf", withEvidence.Evidence[0].FileName)
+
+	open, err := repo.List(ctx, domain.DisputeFilter{Status: domain.DisputeNeedsResponse})
+	require.NoError(t, err)
+	assert.Empty(t, open)
+}
+
+func TestGormDisputeRepository_Rates(t *testing.T) {
+	db := setupDisputeDB(t)
+	repo := adapter.NewGormDisputeRepository(db)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	amount := money.Money{Amount: 1000, Currency: "EUR"}
+
+	// User 1 orders product 7 twice, user 2 orders product 7 and product 8 once each
+	orders := []orderDomain.Order{
+		{ID: 1, UserID: 1, ProductID: 7, Quantity: 1, Status: orderDomain.StatusConfirmed},
+		{ID: 2, UserID: 1, ProductID: 7, Quantity: 1, Status: orderDomain.StatusConfirmed},
+		{ID: 3, UserID: 2, ProductID: 7, Quantity: 1, Status: orderDomain.StatusConfirmed},
+		{ID: 4, UserID: 2, ProductID: 8, Quantity: 1, Status: orderDomain.StatusConfirmed},
+	}
+	require.NoError(t, db.Omit("User", "Product").Create(&orders).Error)
+	for _, o := range orders {
+		require.NoError(t, db.Create(&domain.Payment{OrderID: o.ID, Gateway: "stripe", Reference: fmt.Sprintf("pi_%d", o.ID), Amount: amount, Status: domain.StatusCaptured, CreatedAt: day.Add(time.Hour)}).Error)
+	}
+	// Order 2 was paid with two payments and both were disputed
+	require.NoError(t, db.Create(&domain.Payment{OrderID: 2, Gateway: "stripe", Reference: "pi_gift", Amount: amount, Status: domain.StatusCaptured, CreatedAt: day.Add(time.Hour)}).Error)
+	require.NoError(t, db.Create(&domain.Dispute{OrderID: 2, PaymentID: 2, Gateway: "stripe", Reference: "dp_1", Amount: amount, Status: domain.DisputeNeedsResponse}).Error)
+	require.NoError(t, db.Create(&domain.Dispute{OrderID: 2, PaymentID: 5, Gateway: "stripe", Reference: "dp_2", Amount: amount, Status: domain.DisputeNeedsResponse}).Error)
+	require.NoError(t, db.Create(&domain.Dispute{OrderID: 4, PaymentID: 4, Gateway: "stripe", Reference: "dp_3", Amount: amount, Status: domain.DisputeLost}).Error)
+
+	byProduct, err := repo.Rates(ctx, day, day.AddDate(0, 0, 1), domain.GroupByProduct)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.DisputeRate{
+		{Group: "7", Orders: 3, Disputed: 1},
+		{Group: "8", Orders: 1, Disputed: 1},
+	}, byProduct)
+
+	bySegment, err := repo.Rates(ctx, day, day.AddDate(0, 0, 1), domain.GroupBySegment)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.DisputeRate{
+		{Group: domain.SegmentNew, Orders: 2, Disputed: 0},
+		{Group: domain.SegmentReturning, Orders: 2, Disputed: 2},
+	}, bySegment)
+
+	outside, err := repo.Rates(ctx, day.AddDate(0, 0, 1), day.AddDate(0, 0, 2), domain.GroupByProduct)
+	require.NoError(t, err)
+	assert.Empty(t, outside)
+}
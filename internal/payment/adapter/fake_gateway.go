@@ -0,0 +1,137 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// FakeDeclinedMethod is the payment method the fake gateway always declines
+const FakeDeclinedMethod = "pm_card_declined"
+
+type fakePayment struct {
+	createdAt  time.Time
+	authorized money.Money
+	captured   int64
+	refunded   int64
+	voided     bool
+}
+
+// FakeGateway is an in-memory gateway for local development and tests. It authorizes every
+// method except FakeDeclinedMethod and enforces the same amount rules as a real provider.
+type FakeGateway struct {
+	mu       sync.Mutex
+	nextID   int
+	payments map[string]*fakePayment
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{payments: make(map[string]*fakePayment)}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.Authorization, error) {
+	if req.Method == FakeDeclinedMethod {
+		return nil, fmt.Errorf("%w: card declined", domain.ErrPaymentDeclined)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nextID++
+	reference := "fake_" + strconv.Itoa(g.nextID)
+	g.payments[reference] = &fakePayment{createdAt: time.Now(), authorized: req.Amount}
+	return &domain.Authorization{Reference: reference, Amount: req.Amount}, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amount money.Money) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, err := g.payment(reference, amount)
+	if err != nil {
+		return err
+	}
+	if p.voided || p.captured+amount.Amount > p.authorized.Amount {
+		return fmt.Errorf("cannot capture %s of %s", amount, reference)
+	}
+	p.captured += amount.Amount
+	return nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, reference string, amount money.Money) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, err := g.payment(reference, amount)
+	if err != nil {
+		return err
+	}
+	if p.refunded+amount.Amount > p.captured {
+		return fmt.Errorf("cannot refund %s of %s", amount, reference)
+	}
+	p.refunded += amount.Amount
+	return nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, reference string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[reference]
+	if !ok {
+		return domain.ErrUnknownPayment
+	}
+	if p.captured > 0 {
+		return fmt.Errorf("cannot void captured payment %s", reference)
+	}
+	p.voided = true
+	return nil
+}
+
+func (g *FakeGateway) ListPayments(ctx context.Context, from, to time.Time) ([]domain.GatewayPayment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var payments []domain.GatewayPayment
+	for reference, p := range g.payments {
+		if p.createdAt.Before(from) || !p.createdAt.Before(to) {
+			continue
+		}
+		status := domain.StatusAuthorized
+		switch {
+		case p.voided:
+			status = domain.StatusVoided
+		case p.captured == p.authorized.Amount:
+			status = domain.StatusCaptured
+		case p.captured > 0:
+			status = domain.StatusPartiallyCaptured
+		}
+		payments = append(payments, domain.GatewayPayment{
+			Reference: reference,
+			Status:    status,
+			Amount:    p.authorized,
+			Refunded:  money.Money{Amount: p.refunded, Currency: p.authorized.Currency},
+		})
+	}
+	return payments, nil
+}
+
+// payment looks up an authorization and checks the currency of amount against it
+func (g *FakeGateway) payment(reference string, amount money.Money) (*fakePayment, error) {
+	p, ok := g.payments[reference]
+	if !ok {
+		return nil, domain.ErrUnknownPayment
+	}
+	if amount.Currency != p.authorized.Currency {
+		return nil, fmt.Errorf("currency %s does not match authorization in %s", amount.Currency, p.authorized.Currency)
+	}
+	return p, nil
+}
@@ -0,0 +1,36 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeGateway_Lifecycle(t *testing.T) {
+	gateway := adapter.NewFakeGateway()
+	ctx := context.Background()
+	amount := money.Money{Amount: 1000, Currency: "USD"}
+
+	auth, err := gateway.Authorize(ctx, domain.AuthorizeRequest{Amount: amount, Method: "pm_card_visa"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, gateway.Capture(ctx, auth.Reference, money.Money{Amount: 600, Currency: "USD"}))
+	assert.Error(t, gateway.Capture(ctx, auth.Reference, money.Money{Amount: 600, Currency: "USD"}), "capture beyond the authorization")
+	assert.NoError(t, gateway.Refund(ctx, auth.Reference, money.Money{Amount: 600, Currency: "USD"}))
+	assert.Error(t, gateway.Refund(ctx, auth.Reference, money.Money{Amount: 1, Currency: "USD"}), "refund beyond the capture")
+	assert.Error(t, gateway.Void(ctx, auth.Reference), "captured payments can't be voided")
+
+	assert.ErrorIs(t, gateway.Void(ctx, "missing"), domain.ErrUnknownPayment)
+}
+
+func TestFakeGateway_DeclinesTestMethod(t *testing.T) {
+	_, err := adapter.NewFakeGateway().Authorize(context.Background(), domain.AuthorizeRequest{
+		Amount: money.Money{Amount: 1000, Currency: "USD"},
+		Method: adapter.FakeDeclinedMethod,
+	})
+	assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+}
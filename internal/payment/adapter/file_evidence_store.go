@@ -0,0 +1,119 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// FileEvidenceStore keeps dispute evidence on the local filesystem, e.g. a mounted volume
+type FileEvidenceStore struct {
+	dir string
+}
+
+func NewFileEvidenceStore(dir string) *FileEvidenceStore {
+	return &FileEvidenceStore{dir: dir}
+}
+
+var _ domain.EvidenceStore = (*FileEvidenceStore)(nil)
+
+func (s *FileEvidenceStore) Put(ctx context.Context, key string, content io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, fmt.Errorf("create evidence directory: %w", err)
+	}
+
+	// Write to a temporary file first so a failed upload never leaves a truncated file behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("create evidence file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("write evidence %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("store evidence %s: %w", key, err)
+	}
+	return size, nil
+}
+
+func (s *FileEvidenceStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, persistence.ErrNotFound
+	}
+	return f, err
+}
+
+// Describe and Ensure let the bootstrap registry create the evidence directory
+func (s *FileEvidenceStore) Describe() string {
+	return "dispute evidence directory " + s.dir
+}
+
+func (s *FileEvidenceStore) Ensure(ctx context.Context) (bool, error) {
+	if _, err := os.Stat(s.dir); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Check stores a probe file and reads it back, for selftest.Suite
+func (s *FileEvidenceStore) Check(ctx context.Context) error {
+	key := fmt.Sprintf(".selftest/probe-%d", time.Now().UnixNano())
+	probe := []byte("selftest")
+	if _, err := s.Put(ctx, key, bytes.NewReader(probe)); err != nil {
+		return err
+	}
+	path, _ := s.path(key)
+	defer os.Remove(path)
+
+	f, err := s.Open(ctx, key)
+	if err != nil {
+		return fmt.Errorf("read back %s: %w", key, err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("read back %s: %w", key, err)
+	}
+	if !bytes.Equal(content, probe) {
+		return fmt.Errorf("read back %s: got %d bytes, stored %d", key, len(content), len(probe))
+	}
+	return nil
+}
+
+// path resolves key inside the store directory, rejecting keys that escape it
+func (s *FileEvidenceStore) path(key string) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("invalid evidence key %q", key)
+	}
+	return path, nil
+}
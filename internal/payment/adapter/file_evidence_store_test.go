@@ -0,0 +1,52 @@
+package adapter_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileEvidenceStore_PutAndOpen(t *testing.T) {
+	store := adapter.NewFileEvidenceStore(t.TempDir())
+	ctx := context.Background()
+
+	size, err := store.Put(ctx, "disputes/1/receipt.txt", strings.NewReader("delivered"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), size)
+
+	f, err := store.Open(ctx, "disputes/1/receipt.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "delivered", string(content))
+
+	_, err = store.Open(ctx, "disputes/1/missing.txt")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestFileEvidenceStore_RejectsKeysOutsideDirectory(t *testing.T) {
+	store := adapter.NewFileEvidenceStore(t.TempDir())
+
+	_, err := store.Put(context.Background(), "../escape.txt", strings.NewReader("x"))
+
+	assert.Error(t, err)
+}
+
+func TestFileEvidenceStore_Check(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, adapter.NewFileEvidenceStore(dir).Check(context.Background()))
+
+	probes, err := os.ReadDir(filepath.Join(dir, ".selftest"))
+	require.NoError(t, err)
+	assert.Empty(t, probes, "the probe is removed")
+}
@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+)
+
+// HMACWebhookVerifier verifies webhooks in the gateway independent format used with the fake gateway.
+// The X-Webhook-Signature header holds "sha256=<hex HMAC-SHA256 of the payload>".
+type HMACWebhookVerifier struct {
+	secret secret.Source
+}
+
+func NewHMACWebhookVerifier(webhookSecret secret.Source) *HMACWebhookVerifier {
+	return &HMACWebhookVerifier{secret: webhookSecret}
+}
+
+// hmacWebhookPayload is the body of a webhook in the gateway independent format
+type hmacWebhookPayload struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Reference string `json:"reference"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	// Dispute is required for the disputed, dispute_updated and dispute_closed types
+	Dispute *struct {
+		ID            string     `json:"id"`
+		Reason        string     `json:"reason"`
+		Status        string     `json:"status"`
+		EvidenceDueBy *time.Time `json:"evidence_due_by"`
+	} `json:"dispute"`
+}
+
+func (v *HMACWebhookVerifier) SignatureHeader() string {
+	return "X-Webhook-Signature"
+}
+
+func (v *HMACWebhookVerifier) Verify(ctx context.Context, payload []byte, signature string) (*domain.WebhookEvent, error) {
+	key, err := v.secret.Secret(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read webhook secret: %w", err)
+	}
+	// PAYMENT_WEBHOOK_SECRET may be left empty in development, see config.Config.Validate
+	if key == "" {
+		return nil, fmt.Errorf("%w: no webhook secret is configured", domain.ErrInvalidSignature)
+	}
+	expected := "sha256=" + hmacSHA256(key, string(payload))
+	if !hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected)) {
+		return nil, domain.ErrInvalidSignature
+	}
+
+	var body hmacWebhookPayload
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("decode webhook: %w", err)
+	}
+
+	eventType := domain.WebhookEventType(body.Type)
+	switch eventType {
+	case domain.WebhookAuthorized, domain.WebhookCaptured, domain.WebhookFailed:
+	case domain.WebhookDisputed, domain.WebhookDisputeUpdated, domain.WebhookDisputeClosed:
+		if body.Dispute == nil || body.Dispute.ID == "" {
+			return nil, fmt.Errorf("webhook %s: %s event without dispute id", body.ID, eventType)
+		}
+	default:
+		return nil, nil
+	}
+
+	amount, err := money.New(body.Amount, body.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: %w", body.ID, err)
+	}
+	event := &domain.WebhookEvent{ID: body.ID, Type: eventType, Reference: body.Reference, Amount: amount}
+	if eventType.IsDispute() {
+		event.Dispute = &domain.DisputeDetails{
+			Reference:     body.Dispute.ID,
+			Reason:        body.Dispute.Reason,
+			Status:        domain.DisputeStatus(strings.ToUpper(body.Dispute.Status)),
+			EvidenceDueBy: body.Dispute.EvidenceDueBy,
+		}
+	}
+	return event, nil
+}
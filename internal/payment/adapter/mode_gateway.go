@@ -0,0 +1,57 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// ModeGateway sends the payments of sandbox requests to a sandbox gateway, e.g. Stripe in test mode,
+// and all others to the live one. Both are required so a sandbox payment never falls back to live.
+type ModeGateway struct {
+	live    domain.PaymentGateway
+	sandbox domain.PaymentGateway
+}
+
+func NewModeGateway(live, sandbox domain.PaymentGateway) *ModeGateway {
+	if live == nil || sandbox == nil {
+		panic("payment: ModeGateway needs a live and a sandbox gateway")
+	}
+	return &ModeGateway{live: live, sandbox: sandbox}
+}
+
+// Name is the live gateway's; sandbox payments are told apart by Payment.Sandbox
+func (g *ModeGateway) Name() string {
+	return g.live.Name()
+}
+
+func (g *ModeGateway) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.Authorization, error) {
+	return g.gateway(ctx).Authorize(ctx, req)
+}
+
+func (g *ModeGateway) Capture(ctx context.Context, reference string, amount money.Money) error {
+	return g.gateway(ctx).Capture(ctx, reference, amount)
+}
+
+func (g *ModeGateway) Refund(ctx context.Context, reference string, amount money.Money) error {
+	return g.gateway(ctx).Refund(ctx, reference, amount)
+}
+
+func (g *ModeGateway) Void(ctx context.Context, reference string) error {
+	return g.gateway(ctx).Void(ctx, reference)
+}
+
+// ListPayments reports the live gateway's payments, which reconciliation compares with live payments only
+func (g *ModeGateway) ListPayments(ctx context.Context, from, to time.Time) ([]domain.GatewayPayment, error) {
+	return g.live.ListPayments(ctx, from, to)
+}
+
+func (g *ModeGateway) gateway(ctx context.Context) domain.PaymentGateway {
+	if mode.FromContext(ctx).IsSandbox() {
+		return g.sandbox
+	}
+	return g.live
+}
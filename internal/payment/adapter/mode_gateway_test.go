@@ -0,0 +1,32 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModeGateway_RoutesSandboxPayments(t *testing.T) {
+	live, sandbox := adapter.NewFakeGateway(), adapter.NewFakeGateway()
+	gateway := adapter.NewModeGateway(live, sandbox)
+	sandboxCtx := mode.With(context.Background(), mode.Sandbox)
+	amount := money.Money{Amount: 1000, Currency: "EUR"}
+
+	auth, err := gateway.Authorize(sandboxCtx, domain.AuthorizeRequest{Amount: amount, Method: "pm_card_visa"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, gateway.Capture(context.Background(), auth.Reference, amount), domain.ErrUnknownPayment,
+		"a sandbox authorization is unknown to the live gateway")
+	assert.NoError(t, gateway.Capture(sandboxCtx, auth.Reference, amount))
+
+	reported, err := gateway.ListPayments(context.Background(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, reported, "reconciliation only sees live payments")
+}
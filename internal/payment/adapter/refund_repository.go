@@ -0,0 +1,29 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormRefundRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRefundRepository(db *gorm.DB) domain.RefundRepository {
+	return &GormRefundRepository{db: db}
+}
+
+func (r *GormRefundRepository) Create(ctx context.Context, refund *domain.Refund) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(refund).Error)
+}
+
+func (r *GormRefundRepository) ListByOrder(ctx context.Context, orderID int64) ([]domain.Refund, error) {
+	var refunds []domain.Refund
+	if err := persistence.Conn(ctx, r.db).Where("order_id = ?", orderID).Order("number").Find(&refunds).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return refunds, nil
+}
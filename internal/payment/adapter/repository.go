@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormPaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPaymentRepository(db *gorm.DB) domain.PaymentRepository {
+	return &GormPaymentRepository{db: db}
+}
+
+func (r *GormPaymentRepository) Save(ctx context.Context, p *domain.Payment) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Save(p).Error)
+}
+
+func (r *GormPaymentRepository) ListByOrder(ctx context.Context, orderID int64) ([]domain.Payment, error) {
+	return r.listByOrder(persistence.Conn(ctx, r.db), orderID)
+}
+
+func (r *GormPaymentRepository) ListByOrderForUpdate(ctx context.Context, orderID int64) ([]domain.Payment, error) {
+	return r.listByOrder(persistence.Conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}), orderID)
+}
+
+func (r *GormPaymentRepository) listByOrder(db *gorm.DB, orderID int64) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := db.
+		Preload("Entries", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		Where("order_id = ?", orderID).
+		Order("id").
+		Find(&payments).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return payments, nil
+}
+
+func (r *GormPaymentRepository) GetByReference(ctx context.Context, gateway, reference string) (*domain.Payment, error) {
+	var p domain.Payment
+	err := persistence.Conn(ctx, r.db).Where("gateway = ? AND reference = ?", gateway, reference).First(&p).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &p, nil
+}
+
+func (r *GormPaymentRepository) ListCreatedBetween(ctx context.Context, gateway string, from, to time.Time) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := persistence.Conn(ctx, r.db).
+		Where("gateway = ? AND created_at >= ? AND created_at < ? AND sandbox = ?", gateway, from, to, false).
+		Order("id").
+		Find(&payments).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return payments, nil
+}
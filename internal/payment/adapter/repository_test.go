@@ -0,0 +1,40 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormPaymentRepository_SaveRecordsEntries(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Payment{}, &domain.PaymentEntry{}))
+	repo := adapter.NewGormPaymentRepository(db)
+	ctx := context.Background()
+
+	amount := money.Money{Amount: 3000, Currency: "EUR"}
+	p := domain.NewAuthorizedPayment(42, "fake", &domain.Authorization{Reference: "fake_1", Amount: amount})
+	require.NoError(t, repo.Save(ctx, p))
+
+	require.NoError(t, p.Capture(money.Money{Amount: 1000, Currency: "EUR"}, time.Now().UTC()))
+	require.NoError(t, repo.Save(ctx, p))
+
+	payments, err := repo.ListByOrder(ctx, 42)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, domain.StatusPartiallyCaptured, payments[0].Status)
+	assert.Equal(t, int64(1000), payments[0].Captured.Amount)
+	require.Len(t, payments[0].Entries, 2)
+	assert.Equal(t, domain.EntryAuthorization, payments[0].Entries[0].Kind)
+	assert.Equal(t, domain.EntryCapture, payments[0].Entries[1].Kind)
+	assert.Equal(t, p.ID, payments[0].Entries[1].PaymentID)
+}
@@ -0,0 +1,218 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+)
+
+// DefaultStripeURL is the Stripe API base URL
+const DefaultStripeURL = "https://api.stripe.com"
+
+// StripeGateway authorizes payments as manually captured Stripe PaymentIntents
+type StripeGateway struct {
+	baseURL   string
+	secretKey secret.Source
+	client    *http.Client
+}
+
+// NewStripeGateway reads the secret key on every request so a rotated key is used without a restart
+func NewStripeGateway(baseURL string, secretKey secret.Source, client *http.Client) *StripeGateway {
+	if baseURL == "" {
+		baseURL = DefaultStripeURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &StripeGateway{baseURL: baseURL, secretKey: secretKey, client: client}
+}
+
+// stripePaymentIntent is the subset of the Stripe PaymentIntent object we read
+type stripePaymentIntent struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	AmountReceived int64  `json:"amount_received"`
+	Currency       string `json:"currency"`
+	// LatestCharge is only an object when expanded, as ListPayments does
+	LatestCharge *stripeCharge `json:"latest_charge,omitempty"`
+}
+
+type stripeCharge struct {
+	AmountRefunded int64 `json:"amount_refunded"`
+}
+
+type stripePaymentIntentList struct {
+	Data    []stripePaymentIntent `json:"data"`
+	HasMore bool                  `json:"has_more"`
+}
+
+// stripeStatuses maps PaymentIntent statuses onto payment statuses; intents still waiting
+// for the customer (requires_action, processing) are not payments yet and are left out
+var stripeStatuses = map[string]domain.PaymentStatus{
+	"requires_capture":        domain.StatusAuthorized,
+	"succeeded":               domain.StatusCaptured,
+	"canceled":                domain.StatusVoided,
+	"requires_payment_method": domain.StatusFailed,
+}
+
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (g *StripeGateway) Name() string {
+	return "stripe"
+}
+
+func (g *StripeGateway) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.Authorization, error) {
+	form := url.Values{
+		"amount":                 {strconv.FormatInt(req.Amount.Amount, 10)},
+		"currency":               {strings.ToLower(req.Amount.Currency)},
+		"payment_method":         {req.Method},
+		"payment_method_types[]": {"card"},
+		"capture_method":         {"manual"},
+		"confirm":                {"true"},
+		// Partial shipments capture an authorization several times
+		"payment_method_options[card][request_multicapture]": {"if_available"},
+	}
+	if req.Description != "" {
+		form.Set("description", req.Description)
+	}
+
+	var intent stripePaymentIntent
+	if err := g.post(ctx, "/v1/payment_intents", form, &intent); err != nil {
+		return nil, err
+	}
+	// Anything but a hold ready for capture (e.g. 3-D Secure) can't complete without the customer
+	if intent.Status != "requires_capture" {
+		return nil, fmt.Errorf("%w: payment intent %s is %s", domain.ErrPaymentDeclined, intent.ID, intent.Status)
+	}
+	return &domain.Authorization{Reference: intent.ID, Amount: req.Amount}, nil
+}
+
+func (g *StripeGateway) Capture(ctx context.Context, reference string, amount money.Money) error {
+	// The intent stays capturable until the whole amount is captured or it is cancelled
+	form := url.Values{
+		"amount_to_capture": {strconv.FormatInt(amount.Amount, 10)},
+		"final_capture":     {"false"},
+	}
+	return g.post(ctx, "/v1/payment_intents/"+url.PathEscape(reference)+"/capture", form, nil)
+}
+
+func (g *StripeGateway) Refund(ctx context.Context, reference string, amount money.Money) error {
+	form := url.Values{
+		"payment_intent": {reference},
+		"amount":         {strconv.FormatInt(amount.Amount, 10)},
+	}
+	return g.post(ctx, "/v1/refunds", form, nil)
+}
+
+func (g *StripeGateway) Void(ctx context.Context, reference string) error {
+	return g.post(ctx, "/v1/payment_intents/"+url.PathEscape(reference)+"/cancel", url.Values{}, nil)
+}
+
+func (g *StripeGateway) ListPayments(ctx context.Context, from, to time.Time) ([]domain.GatewayPayment, error) {
+	query := url.Values{
+		"created[gte]": {strconv.FormatInt(from.Unix(), 10)},
+		"created[lt]":  {strconv.FormatInt(to.Unix(), 10)},
+		"limit":        {"100"},
+		"expand[]":     {"data.latest_charge"},
+	}
+
+	var payments []domain.GatewayPayment
+	for {
+		var page stripePaymentIntentList
+		if err := g.get(ctx, "/v1/payment_intents", query, &page); err != nil {
+			return nil, err
+		}
+		for _, intent := range page.Data {
+			status, ok := stripeStatuses[intent.Status]
+			if !ok {
+				continue
+			}
+			if status == domain.StatusAuthorized && intent.AmountReceived > 0 {
+				status = domain.StatusPartiallyCaptured
+			}
+			amount, err := money.New(intent.Amount, intent.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("payment intent %s: %w", intent.ID, err)
+			}
+			payment := domain.GatewayPayment{Reference: intent.ID, Status: status, Amount: amount}
+			if intent.LatestCharge != nil && intent.LatestCharge.AmountRefunded > 0 {
+				payment.Refunded = money.Money{Amount: intent.LatestCharge.AmountRefunded, Currency: amount.Currency}
+			}
+			payments = append(payments, payment)
+		}
+		if !page.HasMore || len(page.Data) == 0 {
+			return payments, nil
+		}
+		query.Set("starting_after", page.Data[len(page.Data)-1].ID)
+	}
+}
+
+// post sends a form encoded Stripe request and decodes the response into out when it is not nil
+func (g *StripeGateway) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return g.do(req, path, out)
+}
+
+func (g *StripeGateway) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return g.do(req, path, out)
+}
+
+func (g *StripeGateway) do(req *http.Request, path string, out interface{}) error {
+	key, err := g.secretKey.Secret(req.Context())
+	if err != nil {
+		return fmt.Errorf("stripe %s: read secret key: %w", path, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body stripeError
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body)
+		switch {
+		case body.Error.Type == "card_error":
+			return fmt.Errorf("%w: %s", domain.ErrPaymentDeclined, body.Error.Message)
+		case resp.StatusCode == http.StatusNotFound:
+			return domain.ErrUnknownPayment
+		default:
+			return fmt.Errorf("stripe %s: unexpected status %d: %s", path, resp.StatusCode, body.Error.Message)
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("stripe %s: decode response: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package adapter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripeGateway_Authorize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/payment_intents", r.URL.Path)
+		assert.Equal(t, "Bearer sk_test", r.Header.Get("Authorization"))
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "2500", r.PostForm.Get("amount"))
+		assert.Equal(t, "eur", r.PostForm.Get("currency"))
+		assert.Equal(t, "manual", r.PostForm.Get("capture_method"))
+		fmt.Fprint(w, `{"id":"pi_123","status":"requires_capture"}`)
+	}))
+	defer server.Close()
+
+	gateway := adapter.NewStripeGateway(server.URL, secret.Static("sk_test"), server.Client())
+
+	auth, err := gateway.Authorize(context.Background(), domain.AuthorizeRequest{
+		Amount: money.Money{Amount: 2500, Currency: "EUR"},
+		Method: "pm_card_visa",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "pi_123", auth.Reference)
+}
+
+func TestStripeGateway_AuthorizeDeclined(t *testing.T) {
+	tests := map[string]func(w http.ResponseWriter){
+		"card error": func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusPaymentRequired)
+			fmt.Fprint(w, `{"error":{"type":"card_error","code":"card_declined","message":"Your card was declined."}}`)
+		},
+		"requires action": func(w http.ResponseWriter) {
+			fmt.Fprint(w, `{"id":"pi_123","status":"requires_action"}`)
+		},
+	}
+
+	for name, respond := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respond(w)
+			}))
+			defer server.Close()
+
+			gateway := adapter.NewStripeGateway(server.URL, secret.Static("sk_test"), server.Client())
+
+			_, err := gateway.Authorize(context.Background(), domain.AuthorizeRequest{
+				Amount: money.Money{Amount: 2500, Currency: "EUR"},
+				Method: "pm_card_chargeDeclined",
+			})
+			assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+		})
+	}
+}
+
+func TestStripeGateway_CaptureAndVoid(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	gateway := adapter.NewStripeGateway(server.URL, secret.Static("sk_test"), server.Client())
+
+	assert.NoError(t, gateway.Capture(context.Background(), "pi_123", money.Money{Amount: 100, Currency: "EUR"}))
+	assert.NoError(t, gateway.Void(context.Background(), "pi_456"))
+	assert.Equal(t, []string{"/v1/payment_intents/pi_123/capture", "/v1/payment_intents/pi_456/cancel"}, paths)
+}
+
+func TestStripeGateway_ListPaymentsPaginates(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "1715990400", r.URL.Query().Get("created[gte]"))
+		assert.Equal(t, "data.latest_charge", r.URL.Query().Get("expand[]"))
+		pages = append(pages, r.URL.Query().Get("starting_after"))
+		if r.URL.Query().Get("starting_after") == "" {
+			fmt.Fprint(w, `{"data":[{"id":"pi_1","status":"requires_capture","amount":2500,"currency":"eur"},{"id":"pi_2","status":"requires_action","amount":100,"currency":"eur"}],"has_more":true}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"id":"pi_3","status":"succeeded","amount":990,"currency":"usd","latest_charge":{"amount_refunded":300}}],"has_more":false}`)
+	}))
+	defer server.Close()
+
+	gateway := adapter.NewStripeGateway(server.URL, secret.Static("sk_test"), server.Client())
+	from := time.Date(2024, 5, 18, 0, 0, 0, 0, time.UTC)
+
+	payments, err := gateway.ListPayments(context.Background(), from, from.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "pi_2"}, pages)
+	assert.Equal(t, []domain.GatewayPayment{
+		{Reference: "pi_1", Status: domain.StatusAuthorized, Amount: money.Money{Amount: 2500, Currency: "EUR"}},
+		{Reference: "pi_3", Status: domain.StatusCaptured, Amount: money.Money{Amount: 990, Currency: "USD"}, Refunded: money.Money{Amount: 300, Currency: "USD"}},
+	}, payments)
+}
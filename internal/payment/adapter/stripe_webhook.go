@@ -0,0 +1,165 @@
+package adapter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+)
+
+// DefaultWebhookTolerance is how old a signed webhook may be before it is treated as a replay
+const DefaultWebhookTolerance = 5 * time.Minute
+
+var stripeDisputeEvents = map[string]domain.WebhookEventType{
+	"charge.dispute.created": domain.WebhookDisputed,
+	"charge.dispute.updated": domain.WebhookDisputeUpdated,
+	"charge.dispute.closed":  domain.WebhookDisputeClosed,
+}
+
+// stripeDisputeStatuses maps Stripe dispute statuses; warning_* statuses are inquiries that may
+// still escalate, and a warning_closed inquiry ended without a chargeback
+var stripeDisputeStatuses = map[string]domain.DisputeStatus{
+	"warning_needs_response": domain.DisputeNeedsResponse,
+	"needs_response":         domain.DisputeNeedsResponse,
+	"warning_under_review":   domain.DisputeUnderReview,
+	"under_review":           domain.DisputeUnderReview,
+	"warning_closed":         domain.DisputeWon,
+	"won":                    domain.DisputeWon,
+	"lost":                   domain.DisputeLost,
+}
+
+// StripeWebhookVerifier verifies the Stripe-Signature header of Stripe webhook events:
+// an HMAC-SHA256 over "<timestamp>.<payload>" keyed with the endpoint's signing secret
+type StripeWebhookVerifier struct {
+	secret    secret.Source
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func NewStripeWebhookVerifier(signingSecret secret.Source) *StripeWebhookVerifier {
+	return &StripeWebhookVerifier{secret: signingSecret, tolerance: DefaultWebhookTolerance, now: time.Now}
+}
+
+// stripeEvent is the subset of the Stripe Event object we read. Object is a PaymentIntent for
+// payment_intent.* events and a Dispute, which references its PaymentIntent, for charge.dispute.*.
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID             string `json:"id"`
+			Amount         int64  `json:"amount"`
+			Currency       string `json:"currency"`
+			PaymentIntent  string `json:"payment_intent"`
+			AmountReceived int64  `json:"amount_received"`
+			// Reason, Status and EvidenceDetails are only set on disputes
+			Reason          string `json:"reason"`
+			Status          string `json:"status"`
+			EvidenceDetails struct {
+				DueBy int64 `json:"due_by"`
+			} `json:"evidence_details"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+func (v *StripeWebhookVerifier) SignatureHeader() string {
+	return "Stripe-Signature"
+}
+
+func (v *StripeWebhookVerifier) Verify(ctx context.Context, payload []byte, signature string) (*domain.WebhookEvent, error) {
+	key, err := v.secret.Secret(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read webhook secret: %w", err)
+	}
+	// Stripe gives every endpoint a whsec_ signing secret, so an empty one was never configured
+	if key == "" {
+		return nil, fmt.Errorf("%w: no webhook secret is configured", domain.ErrInvalidSignature)
+	}
+	if err := v.verifySignature(key, payload, signature); err != nil {
+		return nil, err
+	}
+
+	var e stripeEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, fmt.Errorf("decode stripe event: %w", err)
+	}
+
+	object := e.Data.Object
+	event := &domain.WebhookEvent{ID: e.ID, Reference: object.ID}
+	amount := object.Amount
+	switch e.Type {
+	case "payment_intent.amount_capturable_updated":
+		event.Type = domain.WebhookAuthorized
+	case "payment_intent.succeeded":
+		event.Type = domain.WebhookCaptured
+		amount = object.AmountReceived
+	case "payment_intent.payment_failed":
+		event.Type = domain.WebhookFailed
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed":
+		event.Type = stripeDisputeEvents[e.Type]
+		event.Reference = object.PaymentIntent
+		event.Dispute = &domain.DisputeDetails{
+			Reference: object.ID,
+			Reason:    object.Reason,
+			Status:    stripeDisputeStatuses[object.Status],
+		}
+		if object.EvidenceDetails.DueBy > 0 {
+			dueBy := time.Unix(object.EvidenceDetails.DueBy, 0).UTC()
+			event.Dispute.EvidenceDueBy = &dueBy
+		}
+	default:
+		return nil, nil
+	}
+
+	parsed, err := money.New(amount, object.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("stripe event %s: %w", e.ID, err)
+	}
+	event.Amount = parsed
+	return event, nil
+}
+
+func (v *StripeWebhookVerifier) verifySignature(key string, payload []byte, header string) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed Stripe-Signature header", domain.ErrInvalidSignature)
+	}
+	if age := v.now().Sub(time.Unix(unix, 0)); age > v.tolerance || age < -v.tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", domain.ErrInvalidSignature)
+	}
+
+	expected := hmacSHA256(key, timestamp+"."+string(payload))
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return domain.ErrInvalidSignature
+}
+
+func hmacSHA256(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
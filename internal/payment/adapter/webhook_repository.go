@@ -0,0 +1,32 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWebhookRepository(db *gorm.DB) domain.WebhookRepository {
+	return &GormWebhookRepository{db: db}
+}
+
+func (r *GormWebhookRepository) IsProcessed(ctx context.Context, gateway, eventID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.ProcessedWebhook{}).
+		Where("gateway = ? AND event_id = ?", gateway, eventID).
+		Count(&count).Error
+	return count > 0, persistence.TranslateError(err)
+}
+
+// MarkProcessed ignores events recorded by a concurrent delivery of the same event
+func (r *GormWebhookRepository) MarkProcessed(ctx context.Context, w *domain.ProcessedWebhook) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(w).Error
+	return persistence.TranslateError(err)
+}
@@ -0,0 +1,126 @@
+package adapter_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func stripeSignature(secret, payload string, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, sign(secret, timestamp+"."+payload))
+}
+
+func TestStripeWebhookVerifier_Verify(t *testing.T) {
+	verifier := adapter.NewStripeWebhookVerifier(secret.Static("whsec_test"))
+	dueBy := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected *domain.WebhookEvent
+	}{
+		{
+			name:     "captured",
+			payload:  `{"id":"evt_1","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","amount":2500,"amount_received":2000,"currency":"eur"}}}`,
+			expected: &domain.WebhookEvent{ID: "evt_1", Type: domain.WebhookCaptured, Reference: "pi_1", Amount: money.Money{Amount: 2000, Currency: "EUR"}},
+		},
+		{
+			name:    "dispute references its payment intent",
+			payload: `{"id":"evt_2","type":"charge.dispute.created","data":{"object":{"id":"dp_1","amount":2500,"currency":"eur","payment_intent":"pi_1","reason":"fraudulent","status":"needs_response","evidence_details":{"due_by":1772366400}}}}`,
+			expected: &domain.WebhookEvent{
+				ID: "evt_2", Type: domain.WebhookDisputed, Reference: "pi_1", Amount: money.Money{Amount: 2500, Currency: "EUR"},
+				Dispute: &domain.DisputeDetails{Reference: "dp_1", Reason: "fraudulent", Status: domain.DisputeNeedsResponse, EvidenceDueBy: &dueBy},
+			},
+		},
+		{
+			name:    "closed dispute",
+			payload: `{"id":"evt_4","type":"charge.dispute.closed","data":{"object":{"id":"dp_1","amount":2500,"currency":"eur","payment_intent":"pi_1","reason":"fraudulent","status":"lost"}}}`,
+			expected: &domain.WebhookEvent{
+				ID: "evt_4", Type: domain.WebhookDisputeClosed, Reference: "pi_1", Amount: money.Money{Amount: 2500, Currency: "EUR"},
+				Dispute: &domain.DisputeDetails{Reference: "dp_1", Reason: "fraudulent", Status: domain.DisputeLost},
+			},
+		},
+		{
+			name:    "unhandled event type",
+			payload: `{"id":"evt_3","type":"customer.created","data":{"object":{"id":"cus_1"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := verifier.Verify(context.Background(), []byte(tt.payload), stripeSignature("whsec_test", tt.payload, time.Now()))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, event)
+		})
+	}
+}
+
+func TestStripeWebhookVerifier_RejectsInvalidSignatures(t *testing.T) {
+	verifier := adapter.NewStripeWebhookVerifier(secret.Static("whsec_test"))
+	payload := `{"id":"evt_1","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1"}}}`
+
+	signatures := map[string]string{
+		"wrong secret":   stripeSignature("whsec_other", payload, time.Now()),
+		"replayed":       stripeSignature("whsec_test", payload, time.Now().Add(-time.Hour)),
+		"missing header": "",
+		"tampered":       stripeSignature("whsec_test", payload+" ", time.Now()),
+	}
+	for name, signature := range signatures {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), []byte(payload), signature)
+			assert.ErrorIs(t, err, domain.ErrInvalidSignature)
+		})
+	}
+}
+
+func TestHMACWebhookVerifier_Verify(t *testing.T) {
+	verifier := adapter.NewHMACWebhookVerifier(secret.Static("secret"))
+	payload := `{"id":"evt_1","type":"failed","reference":"fake_1","amount":2500,"currency":"EUR"}`
+
+	event, err := verifier.Verify(context.Background(), []byte(payload), "sha256="+sign("secret", payload))
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.WebhookEvent{ID: "evt_1", Type: domain.WebhookFailed, Reference: "fake_1", Amount: money.Money{Amount: 2500, Currency: "EUR"}}, event)
+
+	_, err = verifier.Verify(context.Background(), []byte(payload), "sha256="+sign("other", payload))
+	assert.ErrorIs(t, err, domain.ErrInvalidSignature)
+}
+
+func TestWebhookVerifiers_RejectEverythingWithoutASecret(t *testing.T) {
+	payload := `{"id":"evt_1","type":"failed","reference":"fake_1","amount":2500,"currency":"EUR"}`
+
+	_, err := adapter.NewHMACWebhookVerifier(secret.Static("")).Verify(context.Background(), []byte(payload), "sha256="+sign("", payload))
+	assert.ErrorIs(t, err, domain.ErrInvalidSignature)
+	_, err = adapter.NewStripeWebhookVerifier(secret.Static("")).Verify(context.Background(), []byte(payload), stripeSignature("", payload, time.Now()))
+	assert.ErrorIs(t, err, domain.ErrInvalidSignature)
+}
+
+func TestHMACWebhookVerifier_VerifyDispute(t *testing.T) {
+	verifier := adapter.NewHMACWebhookVerifier(secret.Static("secret"))
+	payload := `{"id":"evt_2","type":"dispute_updated","reference":"fake_1","amount":2500,"currency":"EUR","dispute":{"id":"dp_1","status":"under_review"}}`
+
+	event, err := verifier.Verify(context.Background(), []byte(payload), "sha256="+sign("secret", payload))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WebhookDisputeUpdated, event.Type)
+	assert.Equal(t, &domain.DisputeDetails{Reference: "dp_1", Status: domain.DisputeUnderReview}, event.Dispute)
+
+	missing := `{"id":"evt_3","type":"disputed","reference":"fake_1","amount":2500,"currency":"EUR"}`
+	_, err = verifier.Verify(context.Background(), []byte(missing), "sha256="+sign("secret", missing))
+	assert.Error(t, err)
+}
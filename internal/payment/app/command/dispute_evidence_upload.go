@@ -0,0 +1,82 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// unsafeFileNameChars are replaced in uploaded file names before they become part of a storage key
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// UploadDisputeEvidenceCommand attaches a file contesting a dispute, e.g. a signed delivery receipt
+type UploadDisputeEvidenceCommand struct {
+	DisputeID   int64  `validate:"required,gt=0"`
+	FileName    string `validate:"required,max=255"`
+	ContentType string `validate:"required"`
+	Description string `validate:"max=1000"`
+	Content     io.Reader
+}
+
+type UploadDisputeEvidenceHandler struct {
+	Disputes domain.DisputeRepository
+	Store    domain.EvidenceStore
+	Now      func() time.Time
+}
+
+// Handle stores the file and records it on the dispute; evidence can only be added while the bank has not decided
+func (h *UploadDisputeEvidenceHandler) Handle(ctx context.Context, cmd UploadDisputeEvidenceCommand) (*domain.DisputeEvidence, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(domain.EvidenceContentTypes, cmd.ContentType) {
+		var errs validation.Errors
+		errs.Add("content_type", fmt.Sprintf("must be one of %s", strings.Join(domain.EvidenceContentTypes, ", ")))
+		return nil, errs
+	}
+
+	d, err := h.Disputes.GetByID(ctx, cmd.DisputeID)
+	if err != nil {
+		return nil, fmt.Errorf("get dispute %d: %w", cmd.DisputeID, err)
+	}
+	if d.Status.IsClosed() {
+		return nil, fmt.Errorf("%w: dispute %d was %s", domain.ErrDisputeClosed, d.ID, d.Status)
+	}
+
+	now := h.now()
+	name := unsafeFileNameChars.ReplaceAllString(filepath.Base(cmd.FileName), "_")
+	key := fmt.Sprintf("disputes/%d/%d-%s", d.ID, now.UnixNano(), name)
+	size, err := h.Store.Put(ctx, key, cmd.Content)
+	if err != nil {
+		return nil, fmt.Errorf("store evidence for dispute %d: %w", d.ID, err)
+	}
+
+	evidence := &domain.DisputeEvidence{
+		DisputeID:   d.ID,
+		FileName:    cmd.FileName,
+		ContentType: cmd.ContentType,
+		Size:        size,
+		StorageKey:  key,
+		Description: cmd.Description,
+		UploadedAt:  now,
+	}
+	if err := h.Disputes.AddEvidence(ctx, evidence); err != nil {
+		return nil, fmt.Errorf("record evidence for dispute %d: %w", d.ID, err)
+	}
+	return evidence, nil
+}
+
+func (h *UploadDisputeEvidenceHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// MockEvidenceStore keeps stored files in memory
+type MockEvidenceStore struct {
+	domain.EvidenceStore
+	files map[string]string
+}
+
+func (m *MockEvidenceStore) Put(ctx context.Context, key string, content io.Reader) (int64, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, err
+	}
+	if m.files == nil {
+		m.files = make(map[string]string)
+	}
+	m.files[key] = string(data)
+	return int64(len(data)), nil
+}
+
+func newUploadFixture(status domain.DisputeStatus) (*UploadDisputeEvidenceHandler, *MockDisputeRepository, *MockEvidenceStore) {
+	disputes := &MockDisputeRepository{disputes: map[string]*domain.Dispute{
+		"dp_1": {ID: 5, Reference: "dp_1", Status: status},
+	}}
+	store := &MockEvidenceStore{}
+	return &UploadDisputeEvidenceHandler{Disputes: disputes, Store: store}, disputes, store
+}
+
+func TestUploadDisputeEvidenceHandler_Handle(t *testing.T) {
+	// Arrange
+	handler, disputes, store := newUploadFixture(domain.DisputeNeedsResponse)
+	cmd := UploadDisputeEvidenceCommand{DisputeID: 5, FileName: "../delivery receipt.txt", ContentType: "text/plain", Content: strings.NewReader("signed by J. Doe")}
+
+	// Act
+	evidence, err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if evidence.Size != 16 || len(disputes.evidence) != 1 {
+		t.Errorf("Expected 16 bytes of evidence to be recorded, got %+v", disputes.evidence)
+	}
+	if !strings.HasPrefix(evidence.StorageKey, "disputes/5/") || !strings.HasSuffix(evidence.StorageKey, "-delivery_receipt.txt") {
+		t.Errorf("Expected a sanitized storage key under disputes/5/, got %q", evidence.StorageKey)
+	}
+	if store.files[evidence.StorageKey] != "signed by J. Doe" {
+		t.Errorf("Expected the file to be stored, got %v", store.files)
+	}
+}
+
+func TestUploadDisputeEvidenceHandler_Handle_ClosedDispute(t *testing.T) {
+	// Arrange
+	handler, _, store := newUploadFixture(domain.DisputeLost)
+	cmd := UploadDisputeEvidenceCommand{DisputeID: 5, FileName: "receipt.pdf", ContentType: "application/pdf", Content: strings.NewReader("%PDF")}
+
+	// Act
+	_, err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if !errors.Is(err, domain.ErrDisputeClosed) {
+		t.Errorf("Expected ErrDisputeClosed, got %v", err)
+	}
+	if len(store.files) != 0 {
+		t.Errorf("Expected nothing to be stored, got %v", store.files)
+	}
+}
+
+func TestUploadDisputeEvidenceHandler_Handle_UnsupportedContentType(t *testing.T) {
+	// Arrange
+	handler, _, _ := newUploadFixture(domain.DisputeNeedsResponse)
+	cmd := UploadDisputeEvidenceCommand{DisputeID: 5, FileName: "movie.mp4", ContentType: "video/mp4", Content: strings.NewReader("")}
+
+	// Act
+	_, err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Errorf("Expected validation errors, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// RefundOrderCommand returns Amount of what was captured for an order to its customer; a zero
+// Amount refunds everything still refundable. Restock puts the ordered quantity back in stock.
+type RefundOrderCommand struct {
+	OrderID int64 `validate:"required,gt=0"`
+	Amount  money.Money
+	Reason  string `validate:"max=255"`
+	Restock bool
+}
+
+type RefundOrderHandler struct {
+	Gateway  domain.PaymentGateway
+	Payments domain.PaymentRepository
+	Refunds  domain.RefundRepository
+	Orders   orderDomain.OrderRepository
+	Products productDomain.ProductRepository
+	// Events receives OrderRefunded for every refund; nil disables publishing
+	Events event.Publisher
+	// Outbox records OrderRefunded in the transaction that records the refund; a failed write fails
+	// the refund. nil disables it.
+	Outbox event.Publisher
+	Tx     persistence.Transactor
+	Now    func() time.Time
+}
+
+// Handle refunds from the order's payments, newest first, and records the refund. The order moves
+// to REFUNDED once everything captured is refunded; partial refunds leave its status unchanged.
+// The payments of the order stay locked from allocating the refund until it is recorded, so
+// concurrent refunds of an order run one after the other and can't refund a capture twice.
+// A gateway failure part way leaves the refunds made so far recorded on their payments.
+func (h *RefundOrderHandler) Handle(ctx context.Context, cmd RefundOrderCommand) (*domain.Refund, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	var refund *domain.Refund
+	var refunded orderDomain.OrderRefunded
+	var gatewayErr error
+	err := h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		refund, refunded, err = h.refund(ctx, cmd)
+		// The gateway keeps what it refunded, so the payments saved before it failed commit
+		if errors.As(err, new(refundGatewayError)) {
+			gatewayErr = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if gatewayErr != nil {
+		return nil, gatewayErr
+	}
+
+	if h.Events != nil {
+		if err := h.Events.Publish(ctx, refunded); err != nil {
+			slog.WarnContext(ctx, "publishing order refund failed", "order_id", refunded.OrderID, "error", err)
+		}
+	}
+	return refund, nil
+}
+
+// refundGatewayError is a refund the gateway rejected after the allocation was locked
+type refundGatewayError struct {
+	error
+}
+
+func (e refundGatewayError) Unwrap() error {
+	return e.error
+}
+
+// refund allocates and records the refund in the transaction of ctx
+func (h *RefundOrderHandler) refund(ctx context.Context, cmd RefundOrderCommand) (*domain.Refund, orderDomain.OrderRefunded, error) {
+	var refunded orderDomain.OrderRefunded
+	o, err := h.Orders.GetByID(ctx, cmd.OrderID)
+	if err != nil {
+		return nil, refunded, fmt.Errorf("get order %d: %w", cmd.OrderID, err)
+	}
+	if o.Status != orderDomain.StatusConfirmed && o.Status != orderDomain.StatusDelivered {
+		return nil, refunded, fmt.Errorf("%w: order %d is %s", domain.ErrOrderNotRefundable, o.ID, o.Status)
+	}
+
+	payments, err := h.Payments.ListByOrderForUpdate(ctx, o.ID)
+	if err != nil {
+		return nil, refunded, fmt.Errorf("list payments of order %d: %w", o.ID, err)
+	}
+	amount := cmd.Amount
+	if amount.Amount == 0 {
+		if amount = domain.TotalRefundable(payments); amount.Amount == 0 {
+			return nil, refunded, fmt.Errorf("%w: nothing left to refund of order %d", domain.ErrRefundExceedsCaptured, o.ID)
+		}
+	}
+
+	allocations, err := domain.AllocateRefund(payments, amount)
+	if err != nil {
+		return nil, refunded, err
+	}
+	for _, a := range allocations {
+		// Refund where the payment was made, whatever the mode of the caller
+		paymentCtx := mode.With(ctx, mode.Of(a.Payment.Sandbox))
+		if err := h.Gateway.Refund(paymentCtx, a.Payment.Reference, a.Amount); err != nil {
+			return nil, refunded, refundGatewayError{fmt.Errorf("refund %s of payment %s: %w", a.Amount, a.Payment.Reference, err)}
+		}
+		if err := a.Payment.Refund(a.Amount, h.now()); err != nil {
+			return nil, refunded, err
+		}
+		if err := h.Payments.Save(ctx, a.Payment); err != nil {
+			return nil, refunded, fmt.Errorf("save payment %s: %w", a.Payment.Reference, err)
+		}
+	}
+
+	refund := &domain.Refund{TenantID: o.TenantID, OrderID: o.ID, Amount: amount, Reason: cmd.Reason, CreatedAt: h.now()}
+	fullyRefunded := domain.TotalRefundable(payments).Amount == 0
+	previous, err := h.Refunds.ListByOrder(ctx, o.ID)
+	if err != nil {
+		return nil, refunded, fmt.Errorf("list refunds of order %d: %w", o.ID, err)
+	}
+	refund.Number = len(previous) + 1
+
+	// The ordered quantity goes back in stock at most once, however many refunds the order gets
+	refund.Restocked = cmd.Restock && !anyRestocked(previous)
+	if refund.Restocked {
+		restock := []productDomain.StockAdjustment{{ProductID: o.ProductID, Delta: o.Quantity.Int()}}
+		if err := h.Products.BulkUpdateStock(ctx, restock); err != nil {
+			return nil, refunded, fmt.Errorf("restock product %d of order %d: %w", o.ProductID, o.ID, err)
+		}
+	}
+	if err := h.Refunds.Create(ctx, refund); err != nil {
+		return nil, refunded, fmt.Errorf("create refund of order %d: %w", o.ID, err)
+	}
+
+	if fullyRefunded {
+		if err := o.Refund(); err != nil {
+			return nil, refunded, err
+		}
+		if err := h.Orders.UpdateStatus(ctx, o); err != nil {
+			return nil, refunded, fmt.Errorf("refund order %d: %w", o.ID, err)
+		}
+	}
+
+	refunded = orderDomain.OrderRefunded{
+		OrderID:       o.ID,
+		UserID:        o.UserID,
+		ProductID:     o.ProductID,
+		Quantity:      o.Quantity.Int(),
+		Amount:        refund.Amount,
+		Reason:        refund.Reason,
+		Restocked:     refund.Restocked,
+		FullyRefunded: fullyRefunded,
+		RefundedAt:    refund.CreatedAt,
+		Sandbox:       o.Sandbox,
+
+		OrderPublicID:   o.PublicID,
+		UserPublicID:    o.User.PublicID,
+		ProductPublicID: o.Product.PublicID,
+		RefundNumber:    refund.Number,
+	}
+	if h.Outbox != nil {
+		if err := h.Outbox.Publish(ctx, refunded); err != nil {
+			return nil, refunded, fmt.Errorf("record refund of order %d in the outbox: %w", o.ID, err)
+		}
+	}
+	return refund, refunded, nil
+}
+
+func anyRestocked(refunds []domain.Refund) bool {
+	for _, r := range refunds {
+		if r.Restocked {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *RefundOrderHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now().UTC()
+}
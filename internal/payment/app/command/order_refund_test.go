@@ -0,0 +1,275 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// MockRefundGateway records refunds by payment reference
+type MockRefundGateway struct {
+	domain.PaymentGateway
+	refunded map[string]int64
+}
+
+func (m *MockRefundGateway) Refund(ctx context.Context, reference string, amount money.Money) error {
+	if m.refunded == nil {
+		m.refunded = make(map[string]int64)
+	}
+	m.refunded[reference] += amount.Amount
+	return nil
+}
+
+type MockRefundRepository struct {
+	refunds []domain.Refund
+}
+
+func (m *MockRefundRepository) Create(ctx context.Context, r *domain.Refund) error {
+	m.refunds = append(m.refunds, *r)
+	return nil
+}
+
+func (m *MockRefundRepository) ListByOrder(ctx context.Context, orderID int64) ([]domain.Refund, error) {
+	return m.refunds, nil
+}
+
+type noTx struct{}
+
+func (noTx) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newRefundFixture(status orderDomain.OrderStatus) (*RefundOrderHandler, *MockRefundGateway, *MockOrderRepository, *MockProductRepository, *MockRefundRepository, *MockPublisher) {
+	order := orderDomain.MustNewOrder(1, 7, 3)
+	order.ID = 42
+	order.Status = status
+
+	eur := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "EUR"} }
+	gateway := &MockRefundGateway{}
+	orders := &MockOrderRepository{orders: map[int64]*orderDomain.Order{42: order}}
+	products := &MockProductRepository{}
+	refunds := &MockRefundRepository{}
+	events := &MockPublisher{}
+	handler := &RefundOrderHandler{
+		Gateway: gateway,
+		Payments: &MockOrderPayments{payments: []domain.Payment{
+			{ID: 1, OrderID: 42, Reference: "gift_1", Amount: eur(1000), Captured: eur(1000), Status: domain.StatusCaptured},
+			{ID: 2, OrderID: 42, Reference: "pi_2", Amount: eur(2000), Captured: eur(2000), Status: domain.StatusCaptured},
+		}},
+		Refunds:  refunds,
+		Orders:   orders,
+		Products: products,
+		Events:   events,
+		Tx:       noTx{},
+	}
+	return handler, gateway, orders, products, refunds, events
+}
+
+func TestRefundOrderHandler_Handle_Partial(t *testing.T) {
+	// Arrange
+	handler, gateway, orders, products, refunds, events := newRefundFixture(orderDomain.StatusDelivered)
+
+	// Act
+	refund, err := handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42, Amount: money.Money{Amount: 2500, Currency: "EUR"}, Restock: true})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gateway.refunded["pi_2"] != 2000 || gateway.refunded["gift_1"] != 500 {
+		t.Errorf("Expected the card to be refunded before the gift card, got %v", gateway.refunded)
+	}
+	if refund.Number != 1 || !refund.Restocked || len(refunds.refunds) != 1 {
+		t.Errorf("Expected the first refund to be recorded and restocked, got %+v", refund)
+	}
+	if len(products.adjustments) != 1 || products.adjustments[0].Delta != 3 {
+		t.Errorf("Expected the 3 ordered items to be restocked, got %v", products.adjustments)
+	}
+	if len(orders.updated) != 0 {
+		t.Errorf("Expected a partial refund to keep the order status, got %v", orders.updated)
+	}
+	if e, ok := events.events[0].(orderDomain.OrderRefunded); !ok || e.FullyRefunded || e.RefundNumber != 1 {
+		t.Errorf("Expected a partial OrderRefunded event, got %+v", events.events)
+	}
+}
+
+func TestRefundOrderHandler_Handle_RestOfOrder(t *testing.T) {
+	// Arrange
+	handler, gateway, orders, products, _, events := newRefundFixture(orderDomain.StatusConfirmed)
+	if _, err := handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42, Amount: money.Money{Amount: 1000, Currency: "EUR"}, Restock: true}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Act: a zero amount refunds what is left
+	refund, err := handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42, Restock: true})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if refund.Number != 2 || refund.Amount.Amount != 2000 || refund.Restocked {
+		t.Errorf("Expected a second refund of the remaining 2000 without restocking again, got %+v", refund)
+	}
+	if gateway.refunded["pi_2"] != 2000 || gateway.refunded["gift_1"] != 1000 {
+		t.Errorf("Expected everything captured to be refunded, got %v", gateway.refunded)
+	}
+	if len(products.adjustments) != 1 {
+		t.Errorf("Expected the order to be restocked once, got %v", products.adjustments)
+	}
+	if len(orders.updated) != 1 || orders.updated[0] != orderDomain.StatusRefunded {
+		t.Errorf("Expected the order to be refunded, got %v", orders.updated)
+	}
+	if e := events.events[1].(orderDomain.OrderRefunded); !e.FullyRefunded {
+		t.Errorf("Expected the last refund to be reported as full, got %+v", e)
+	}
+
+	_, err = handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42})
+	if !errors.Is(err, domain.ErrOrderNotRefundable) {
+		t.Errorf("Expected ErrOrderNotRefundable for a refunded order, got %v", err)
+	}
+}
+
+func TestRefundOrderHandler_Handle_Rejected(t *testing.T) {
+	// Arrange
+	handler, gateway, _, _, refunds, _ := newRefundFixture(orderDomain.StatusPending)
+
+	// Act
+	_, err := handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42})
+
+	// Assert
+	if !errors.Is(err, domain.ErrOrderNotRefundable) {
+		t.Errorf("Expected ErrOrderNotRefundable for a pending order, got %v", err)
+	}
+
+	handler, gateway, _, _, refunds, _ = newRefundFixture(orderDomain.StatusDelivered)
+	_, err = handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42, Amount: money.Money{Amount: 3001, Currency: "EUR"}})
+	if !errors.Is(err, domain.ErrRefundExceedsCaptured) {
+		t.Errorf("Expected ErrRefundExceedsCaptured, got %v", err)
+	}
+	if len(gateway.refunded) != 0 || len(refunds.refunds) != 0 {
+		t.Errorf("Expected nothing to be refunded, got %v", gateway.refunded)
+	}
+}
+
+func TestRefundOrderHandler_Handle_RecordsOrderRefundedInOutbox(t *testing.T) {
+	// Arrange
+	handler, _, _, _, _, events := newRefundFixture(orderDomain.StatusDelivered)
+	outbox := &MockPublisher{}
+	handler.Outbox = outbox
+
+	// Act
+	_, err := handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42, Amount: money.Money{Amount: 500, Currency: "EUR"}})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(outbox.events) != 1 || len(events.events) != 1 {
+		t.Fatalf("Expected OrderRefunded in the outbox and on the bus, got %v and %v", outbox.events, events.events)
+	}
+	if _, ok := outbox.events[0].(orderDomain.OrderRefunded); !ok {
+		t.Errorf("Expected OrderRefunded, got %T", outbox.events[0])
+	}
+
+	// A refund that can't be recorded in the outbox fails
+	handler.Outbox = &MockPublisher{err: errors.New("connection reset")}
+	_, err = handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42, Amount: money.Money{Amount: 500, Currency: "EUR"}})
+	if err == nil {
+		t.Fatal("Expected the outbox failure to be returned")
+	}
+	if len(events.events) != 1 {
+		t.Errorf("Expected no event for a refund that didn't commit, got %v", events.events)
+	}
+}
+
+// SlowRefundGateway takes a while to refund, so concurrent refunds overlap at the gateway
+type SlowRefundGateway struct {
+	domain.PaymentGateway
+	mu       sync.Mutex
+	refunded int64
+}
+
+func (m *SlowRefundGateway) Refund(ctx context.Context, reference string, amount money.Money) error {
+	time.Sleep(20 * time.Millisecond)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refunded += amount.Amount
+	return nil
+}
+
+func TestRefundOrderHandler_Handle_ConcurrentRefundsDontRefundTwice(t *testing.T) {
+	// Arrange
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection opens a database of its own; one keeps them all on the same
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&domain.Payment{}, &domain.PaymentEntry{}, &domain.Refund{}); err != nil {
+		t.Fatal(err)
+	}
+	payments := adapter.NewGormPaymentRepository(db)
+	eur := money.Money{Amount: 3000, Currency: "EUR"}
+	p := domain.NewAuthorizedPayment(42, "stripe", &domain.Authorization{Reference: "pi_1", Amount: eur})
+	if err := p.Capture(eur, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if err := payments.Save(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+
+	handler, _, _, _, _, _ := newRefundFixture(orderDomain.StatusDelivered)
+	gateway := &SlowRefundGateway{}
+	handler.Gateway, handler.Payments = gateway, payments
+	handler.Refunds, handler.Tx = adapter.NewGormRefundRepository(db), persistence.NewGormTransactor(db)
+
+	// Act: two refunds of 2000 race for the 3000 captured
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = handler.Handle(context.Background(), RefundOrderCommand{OrderID: 42, Amount: money.Money{Amount: 2000, Currency: "EUR"}})
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, domain.ErrRefundExceedsCaptured):
+			t.Errorf("Expected ErrRefundExceedsCaptured for the refund that lost, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one refund to succeed, got %d (%v)", succeeded, errs)
+	}
+	if gateway.refunded != 2000 {
+		t.Errorf("Expected 2000 to be refunded at the gateway, got %d", gateway.refunded)
+	}
+	stored, err := payments.ListByOrder(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refundable := domain.TotalRefundable(stored); refundable.Amount != 1000 {
+		t.Errorf("Expected 1000 to stay refundable, got %s", refundable)
+	}
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// CaptureOrderPaymentCommand collects Amount of an order's authorizations, e.g. the value of a partial shipment
+type CaptureOrderPaymentCommand struct {
+	OrderID int64 `validate:"required,gt=0"`
+	Amount  money.Money
+}
+
+type CaptureOrderPaymentHandler struct {
+	Gateway  domain.PaymentGateway
+	Payments domain.PaymentRepository
+	Now      func() time.Time
+}
+
+// Handle captures from the order's payments in the order they were authorized and returns the updated payments.
+// A gateway failure part way leaves the captures made so far recorded; retrying captures only what is left.
+func (h *CaptureOrderPaymentHandler) Handle(ctx context.Context, cmd CaptureOrderPaymentCommand) ([]domain.Payment, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	payments, err := h.Payments.ListByOrder(ctx, cmd.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("list payments of order %d: %w", cmd.OrderID, err)
+	}
+
+	allocations, err := domain.AllocateCapture(payments, cmd.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range allocations {
+		// Capture where the payment was authorized, whatever the mode of the caller, e.g. a live shipping job
+		paymentCtx := mode.With(ctx, mode.Of(a.Payment.Sandbox))
+		if err := h.Gateway.Capture(paymentCtx, a.Payment.Reference, a.Amount); err != nil {
+			return nil, fmt.Errorf("capture %s of payment %s: %w", a.Amount, a.Payment.Reference, err)
+		}
+		if err := a.Payment.Capture(a.Amount, h.now()); err != nil {
+			return nil, err
+		}
+		if err := h.Payments.Save(ctx, a.Payment); err != nil {
+			return nil, fmt.Errorf("save payment %s: %w", a.Payment.Reference, err)
+		}
+	}
+	return payments, nil
+}
+
+func (h *CaptureOrderPaymentHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now().UTC()
+}
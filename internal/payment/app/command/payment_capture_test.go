@@ -0,0 +1,114 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// MockCaptureGateway records captures and the mode each was made in
+type MockCaptureGateway struct {
+	domain.PaymentGateway
+	captured map[string]int64
+	modes    map[string]mode.Mode
+}
+
+func (m *MockCaptureGateway) Capture(ctx context.Context, reference string, amount money.Money) error {
+	if m.captured == nil {
+		m.captured = make(map[string]int64)
+		m.modes = make(map[string]mode.Mode)
+	}
+	m.captured[reference] += amount.Amount
+	m.modes[reference] = mode.FromContext(ctx)
+	return nil
+}
+
+type MockOrderPayments struct {
+	domain.PaymentRepository
+	payments []domain.Payment
+	saved    int
+}
+
+func (m *MockOrderPayments) ListByOrder(ctx context.Context, orderID int64) ([]domain.Payment, error) {
+	return m.payments, nil
+}
+
+func (m *MockOrderPayments) ListByOrderForUpdate(ctx context.Context, orderID int64) ([]domain.Payment, error) {
+	return m.payments, nil
+}
+
+func (m *MockOrderPayments) Save(ctx context.Context, p *domain.Payment) error {
+	m.saved++
+	return nil
+}
+
+func TestCaptureOrderPaymentHandler_Handle_PartialShipment(t *testing.T) {
+	// Arrange
+	gateway := &MockCaptureGateway{}
+	payments := &MockOrderPayments{payments: []domain.Payment{
+		{ID: 1, OrderID: 42, Reference: "gift_1", Amount: money.Money{Amount: 1000, Currency: "EUR"}, Status: domain.StatusAuthorized},
+		{ID: 2, OrderID: 42, Reference: "pi_2", Amount: money.Money{Amount: 2000, Currency: "EUR"}, Status: domain.StatusAuthorized},
+	}}
+	handler := &CaptureOrderPaymentHandler{Gateway: gateway, Payments: payments}
+
+	// Act
+	updated, err := handler.Handle(context.Background(), CaptureOrderPaymentCommand{OrderID: 42, Amount: money.Money{Amount: 1500, Currency: "EUR"}})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gateway.captured["gift_1"] != 1000 || gateway.captured["pi_2"] != 500 {
+		t.Errorf("Expected the gift card to be used up first, got %v", gateway.captured)
+	}
+	if updated[0].Status != domain.StatusCaptured || updated[1].Status != domain.StatusPartiallyCaptured {
+		t.Errorf("Unexpected statuses %s and %s", updated[0].Status, updated[1].Status)
+	}
+	if payments.saved != 2 {
+		t.Errorf("Expected 2 payments to be saved, got %d", payments.saved)
+	}
+}
+
+func TestCaptureOrderPaymentHandler_Handle_ExceedsAuthorization(t *testing.T) {
+	// Arrange
+	gateway := &MockCaptureGateway{}
+	payments := &MockOrderPayments{payments: []domain.Payment{
+		{ID: 1, OrderID: 42, Reference: "pi_1", Amount: money.Money{Amount: 1000, Currency: "EUR"}, Status: domain.StatusAuthorized},
+	}}
+	handler := &CaptureOrderPaymentHandler{Gateway: gateway, Payments: payments}
+
+	// Act
+	_, err := handler.Handle(context.Background(), CaptureOrderPaymentCommand{OrderID: 42, Amount: money.Money{Amount: 1001, Currency: "EUR"}})
+
+	// Assert
+	if !errors.Is(err, domain.ErrCaptureExceedsAuthorization) {
+		t.Errorf("Expected ErrCaptureExceedsAuthorization, got %v", err)
+	}
+	if len(gateway.captured) != 0 {
+		t.Errorf("Expected nothing to be captured, got %v", gateway.captured)
+	}
+}
+
+func TestCaptureOrderPaymentHandler_Handle_SandboxPaymentNeverCapturedLive(t *testing.T) {
+	// Arrange
+	gateway := &MockCaptureGateway{}
+	payments := &MockOrderPayments{payments: []domain.Payment{
+		{ID: 1, OrderID: 42, Reference: "pi_test_1", Amount: money.Money{Amount: 1000, Currency: "EUR"}, Status: domain.StatusAuthorized, Sandbox: true},
+	}}
+	handler := &CaptureOrderPaymentHandler{Gateway: gateway, Payments: payments}
+
+	// Act: a job without a tenant runs in live mode
+	_, err := handler.Handle(context.Background(), CaptureOrderPaymentCommand{OrderID: 42, Amount: money.Money{Amount: 1000, Currency: "EUR"}})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gateway.modes["pi_test_1"] != mode.Sandbox {
+		t.Errorf("Expected the sandbox payment to be captured in sandbox mode, got %q", gateway.modes["pi_test_1"])
+	}
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+)
+
+// reconcileSlack widens the local window: a payment is saved shortly after the gateway created it,
+// so a payment authorized just before midnight can be stored just after it
+const reconcileSlack = 5 * time.Minute
+
+// ReconcilePaymentsCommand compares one UTC day of local payments with the gateway's report
+type ReconcilePaymentsCommand struct {
+	Day time.Time
+}
+
+type ReconcilePaymentsHandler struct {
+	Gateway  domain.PaymentGateway
+	Payments domain.PaymentRepository
+}
+
+func (h *ReconcilePaymentsHandler) Handle(ctx context.Context, cmd ReconcilePaymentsCommand) error {
+	from := time.Date(cmd.Day.Year(), cmd.Day.Month(), cmd.Day.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	remote, err := h.Gateway.ListPayments(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("list %s payments: %w", h.Gateway.Name(), err)
+	}
+	local, err := h.Payments.ListCreatedBetween(ctx, h.Gateway.Name(), from, to.Add(reconcileSlack))
+	if err != nil {
+		return fmt.Errorf("list local payments: %w", err)
+	}
+
+	createdAt := make(map[string]time.Time, len(local))
+	for _, p := range local {
+		createdAt[p.Reference] = p.CreatedAt
+	}
+
+	found := 0
+	for _, d := range domain.Reconcile(local, remote) {
+		// Payments of the slack window belong to the next day's report
+		if d.Kind == domain.DiscrepancyMissingAtGateway && !createdAt[d.Reference].Before(to) {
+			continue
+		}
+		found++
+		slog.WarnContext(ctx, "payment discrepancy", "gateway", h.Gateway.Name(), "day", from.Format("2006-01-02"),
+			"reference", d.Reference, "kind", d.Kind, "detail", d.Detail)
+	}
+
+	slog.InfoContext(ctx, "payments reconciled", "gateway", h.Gateway.Name(), "day", from.Format("2006-01-02"),
+		"gateway_payments", len(remote), "discrepancies", found)
+	return nil
+}
@@ -0,0 +1,207 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// HandleWebhookCommand applies a verified gateway callback to the payment, its disputes and its order
+type HandleWebhookCommand struct {
+	Gateway string `validate:"required"`
+	Event   domain.WebhookEvent
+}
+
+type HandleWebhookHandler struct {
+	Payments domain.PaymentRepository
+	Webhooks domain.WebhookRepository
+	Disputes domain.DisputeRepository
+	Orders   orderDomain.OrderRepository
+	Products productDomain.ProductRepository
+	// Events receives OrderCancelled for orders cancelled by a failed payment; nil disables publishing
+	Events event.Publisher
+	// Outbox records OrderCancelled in the transaction that cancels the order; a failed write fails
+	// the callback, which the gateway retries. nil disables it.
+	Outbox event.Publisher
+	// Tx saves a payment together with the cancellation of its order
+	Tx  persistence.Transactor
+	Now func() time.Time
+}
+
+func (h *HandleWebhookHandler) Handle(ctx context.Context, cmd HandleWebhookCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+	e := cmd.Event
+
+	processed, err := h.Webhooks.IsProcessed(ctx, cmd.Gateway, e.ID)
+	if err != nil {
+		return fmt.Errorf("check webhook %s: %w", e.ID, err)
+	}
+	if processed {
+		return nil
+	}
+
+	// The callback can overtake the order that saves the payment; ErrUnknownPayment makes the gateway retry
+	p, err := h.Payments.GetByReference(ctx, cmd.Gateway, e.Reference)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return fmt.Errorf("%w: %s", domain.ErrUnknownPayment, e.Reference)
+		}
+		return fmt.Errorf("get payment %s: %w", e.Reference, err)
+	}
+
+	if to, ok := e.Type.PaymentStatus(); ok {
+		if err := h.transition(ctx, p, e, to); err != nil {
+			return err
+		}
+	}
+	if e.Type.IsDispute() && e.Dispute != nil {
+		if err := h.recordDispute(ctx, p, e); err != nil {
+			return err
+		}
+	}
+
+	return h.Webhooks.MarkProcessed(ctx, &domain.ProcessedWebhook{
+		Gateway:     cmd.Gateway,
+		EventID:     e.ID,
+		Type:        e.Type,
+		ProcessedAt: h.now(),
+	})
+}
+
+// transition moves the payment to the status of the event, cancelling the order of a failed payment
+func (h *HandleWebhookHandler) transition(ctx context.Context, p *domain.Payment, e domain.WebhookEvent, to domain.PaymentStatus) error {
+	changed, err := p.Transition(to)
+	switch {
+	case errors.Is(err, domain.ErrInvalidPaymentTransition):
+		// Gateways don't guarantee ordering; a stale callback must not move the payment back
+		slog.WarnContext(ctx, "ignoring out of order payment webhook", "event_id", e.ID, "reference", e.Reference, "error", err)
+	case err != nil:
+		return err
+	case changed:
+		var cancelled *orderDomain.OrderCancelled
+		err := h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+			if err := h.Payments.Save(ctx, p); err != nil {
+				return fmt.Errorf("save payment %s: %w", p.Reference, err)
+			}
+			if p.Status != domain.StatusFailed {
+				return nil
+			}
+			var err error
+			cancelled, err = h.cancelOrder(ctx, p.OrderID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if cancelled != nil && h.Events != nil {
+			if err := h.Events.Publish(ctx, *cancelled); err != nil {
+				slog.WarnContext(ctx, "publishing order cancellation failed", "order_id", cancelled.OrderID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// recordDispute opens the dispute of the event, flagging its order for review, or updates its status
+func (h *HandleWebhookHandler) recordDispute(ctx context.Context, p *domain.Payment, e domain.WebhookEvent) error {
+	d, err := h.Disputes.GetByReference(ctx, p.Gateway, e.Dispute.Reference)
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		d = domain.NewDispute(p, e.Amount, *e.Dispute, h.now())
+		if err := h.Disputes.Create(ctx, d); err != nil {
+			return fmt.Errorf("create dispute %s: %w", d.Reference, err)
+		}
+		slog.WarnContext(ctx, "payment disputed", "order_id", p.OrderID, "dispute", d.Reference, "reason", d.Reason)
+		return h.flagOrder(ctx, p.OrderID)
+	case err != nil:
+		return fmt.Errorf("get dispute %s: %w", e.Dispute.Reference, err)
+	}
+
+	if d.Apply(*e.Dispute, h.now()) {
+		if err := h.Disputes.Update(ctx, d); err != nil {
+			return fmt.Errorf("update dispute %s: %w", d.Reference, err)
+		}
+	}
+	return nil
+}
+
+// flagOrder marks a disputed order for review
+func (h *HandleWebhookHandler) flagOrder(ctx context.Context, orderID int64) error {
+	o, err := h.Orders.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("get order %d: %w", orderID, err)
+	}
+	if !o.Flag(orderDomain.FlagDisputed, h.now()) {
+		return nil
+	}
+	if err := h.Orders.UpdateFlag(ctx, o); err != nil {
+		return fmt.Errorf("flag order %d: %w", orderID, err)
+	}
+	return nil
+}
+
+// cancelOrder cancels the order of a failed payment, puts its stock back and records the
+// cancellation in the outbox. It returns the cancellation, or nil when the order was left alone.
+func (h *HandleWebhookHandler) cancelOrder(ctx context.Context, orderID int64) (*orderDomain.OrderCancelled, error) {
+	o, err := h.Orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get order %d: %w", orderID, err)
+	}
+
+	wasConfirmed := o.Status == orderDomain.StatusConfirmed
+	if err := o.Cancel(); err != nil {
+		slog.WarnContext(ctx, "payment failed for an order that can no longer be cancelled", "order_id", orderID, "status", o.Status)
+		return nil, nil
+	}
+	if err := h.Orders.UpdateStatus(ctx, o); err != nil {
+		return nil, fmt.Errorf("cancel order %d: %w", orderID, err)
+	}
+
+	// Stock is only taken once an order is confirmed
+	if wasConfirmed {
+		restock := []productDomain.StockAdjustment{{ProductID: o.ProductID, Delta: o.Quantity.Int()}}
+		if err := h.Products.BulkUpdateStock(ctx, restock); err != nil {
+			return nil, fmt.Errorf("restock product %d of order %d: %w", o.ProductID, orderID, err)
+		}
+	}
+
+	cancelled := &orderDomain.OrderCancelled{
+		OrderID:     o.ID,
+		UserID:      o.UserID,
+		ProductID:   o.ProductID,
+		Quantity:    o.Quantity.Int(),
+		Reason:      orderDomain.CancelReasonPaymentFailed,
+		Restocked:   wasConfirmed,
+		CancelledAt: h.now(),
+		Sandbox:     o.Sandbox,
+
+		OrderPublicID:   o.PublicID,
+		UserPublicID:    o.User.PublicID,
+		ProductPublicID: o.Product.PublicID,
+		OrderVersion:    o.Version(),
+	}
+	if h.Outbox != nil {
+		if err := h.Outbox.Publish(ctx, *cancelled); err != nil {
+			return nil, fmt.Errorf("record cancellation of order %d in the outbox: %w", orderID, err)
+		}
+	}
+	return cancelled, nil
+}
+
+func (h *HandleWebhookHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,391 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+type MockPaymentRepository struct {
+	domain.PaymentRepository
+	payments map[string]*domain.Payment
+	saved    []domain.Payment
+}
+
+func (m *MockPaymentRepository) GetByReference(ctx context.Context, gateway, reference string) (*domain.Payment, error) {
+	if p, ok := m.payments[reference]; ok {
+		return p, nil
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockPaymentRepository) Save(ctx context.Context, p *domain.Payment) error {
+	m.saved = append(m.saved, *p)
+	return nil
+}
+
+type MockWebhookRepository struct {
+	processed map[string]bool
+}
+
+func (m *MockWebhookRepository) IsProcessed(ctx context.Context, gateway, eventID string) (bool, error) {
+	return m.processed[eventID], nil
+}
+
+func (m *MockWebhookRepository) MarkProcessed(ctx context.Context, w *domain.ProcessedWebhook) error {
+	if m.processed == nil {
+		m.processed = make(map[string]bool)
+	}
+	m.processed[w.EventID] = true
+	return nil
+}
+
+type MockOrderRepository struct {
+	orderDomain.OrderRepository
+	orders  map[int64]*orderDomain.Order
+	updated []orderDomain.OrderStatus
+	flagged []string
+}
+
+func (m *MockOrderRepository) GetByID(ctx context.Context, id int64) (*orderDomain.Order, error) {
+	if o, ok := m.orders[id]; ok {
+		return o, nil
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, o *orderDomain.Order) error {
+	m.updated = append(m.updated, o.Status)
+	return nil
+}
+
+func (m *MockOrderRepository) UpdateFlag(ctx context.Context, o *orderDomain.Order) error {
+	m.flagged = append(m.flagged, o.FlagReason)
+	return nil
+}
+
+type MockDisputeRepository struct {
+	domain.DisputeRepository
+	disputes map[string]*domain.Dispute
+	evidence []domain.DisputeEvidence
+	updates  int
+}
+
+func (m *MockDisputeRepository) GetByReference(ctx context.Context, gateway, reference string) (*domain.Dispute, error) {
+	if d, ok := m.disputes[reference]; ok {
+		return d, nil
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockDisputeRepository) GetByID(ctx context.Context, id int64) (*domain.Dispute, error) {
+	for _, d := range m.disputes {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockDisputeRepository) Create(ctx context.Context, d *domain.Dispute) error {
+	if m.disputes == nil {
+		m.disputes = make(map[string]*domain.Dispute)
+	}
+	d.ID = int64(len(m.disputes) + 1)
+	m.disputes[d.Reference] = d
+	return nil
+}
+
+func (m *MockDisputeRepository) Update(ctx context.Context, d *domain.Dispute) error {
+	m.updates++
+	return nil
+}
+
+func (m *MockDisputeRepository) AddEvidence(ctx context.Context, e *domain.DisputeEvidence) error {
+	m.evidence = append(m.evidence, *e)
+	return nil
+}
+
+type MockProductRepository struct {
+	productDomain.ProductRepository
+	adjustments []productDomain.StockAdjustment
+}
+
+func (m *MockProductRepository) BulkUpdateStock(ctx context.Context, adjustments []productDomain.StockAdjustment) error {
+	m.adjustments = append(m.adjustments, adjustments...)
+	return nil
+}
+
+// MockPublisher collects published events
+type MockPublisher struct {
+	events []event.Event
+	err    error
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, events ...event.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func newWebhookFixture(status domain.PaymentStatus) (*HandleWebhookHandler, *MockPaymentRepository, *MockWebhookRepository, *MockOrderRepository, *MockProductRepository) {
+	order := orderDomain.MustNewOrder(1, 7, 3)
+	order.ID = 42
+	_ = order.Confirm()
+
+	payments := &MockPaymentRepository{payments: map[string]*domain.Payment{
+		"pi_1": {ID: 1, OrderID: 42, Gateway: "stripe", Reference: "pi_1", Amount: money.Money{Amount: 3000, Currency: "EUR"}, Status: status},
+	}}
+	webhooks := &MockWebhookRepository{}
+	orders := &MockOrderRepository{orders: map[int64]*orderDomain.Order{42: order}}
+	products := &MockProductRepository{}
+
+	handler := &HandleWebhookHandler{Payments: payments, Webhooks: webhooks, Orders: orders, Products: products, Tx: noTx{}}
+	return handler, payments, webhooks, orders, products
+}
+
+func TestHandleWebhookHandler_Handle_Captured(t *testing.T) {
+	// Arrange
+	handler, payments, webhooks, orders, _ := newWebhookFixture(domain.StatusAuthorized)
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_1", Type: domain.WebhookCaptured, Reference: "pi_1"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(payments.saved) != 1 || payments.saved[0].Status != domain.StatusCaptured {
+		t.Errorf("Expected payment to be saved as CAPTURED, got %+v", payments.saved)
+	}
+	if !webhooks.processed["evt_1"] {
+		t.Error("Expected event to be marked as processed")
+	}
+	if len(orders.updated) != 0 {
+		t.Errorf("Expected order to be left alone, got updates %v", orders.updated)
+	}
+}
+
+func TestHandleWebhookHandler_Handle_FailedCancelsOrderAndRestocks(t *testing.T) {
+	// Arrange
+	handler, payments, _, orders, products := newWebhookFixture(domain.StatusAuthorized)
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_2", Type: domain.WebhookFailed, Reference: "pi_1"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if payments.payments["pi_1"].Status != domain.StatusFailed {
+		t.Errorf("Expected payment status FAILED, got %s", payments.payments["pi_1"].Status)
+	}
+	if len(orders.updated) != 1 || orders.updated[0] != orderDomain.StatusCancelled {
+		t.Errorf("Expected order to be cancelled, got updates %v", orders.updated)
+	}
+	if len(products.adjustments) != 1 || products.adjustments[0] != (productDomain.StockAdjustment{ProductID: 7, Delta: 3}) {
+		t.Errorf("Expected 3 units of product 7 to be restocked, got %v", products.adjustments)
+	}
+}
+
+func TestHandleWebhookHandler_Handle_FailedPublishesOrderCancelled(t *testing.T) {
+	// Arrange
+	handler, _, _, _, _ := newWebhookFixture(domain.StatusAuthorized)
+	publisher := &MockPublisher{}
+	handler.Events = publisher
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_2", Type: domain.WebhookFailed, Reference: "pi_1"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(publisher.events) != 1 {
+		t.Fatalf("Expected one event, got %v", publisher.events)
+	}
+	cancelled, ok := publisher.events[0].(orderDomain.OrderCancelled)
+	if !ok {
+		t.Fatalf("Expected OrderCancelled, got %T", publisher.events[0])
+	}
+	if cancelled.OrderID != 42 || cancelled.Quantity != 3 || !cancelled.Restocked || cancelled.Reason != orderDomain.CancelReasonPaymentFailed {
+		t.Errorf("Unexpected event %+v", cancelled)
+	}
+	if cancelled.OrderVersion != 2 {
+		t.Errorf("Expected the cancellation to be version 2 of the confirmed order, got %d", cancelled.OrderVersion)
+	}
+}
+
+func TestHandleWebhookHandler_Handle_FailedRecordsOrderCancelledInOutbox(t *testing.T) {
+	// Arrange
+	handler, _, _, _, _ := newWebhookFixture(domain.StatusAuthorized)
+	outbox := &MockPublisher{}
+	handler.Outbox = outbox
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_2", Type: domain.WebhookFailed, Reference: "pi_1"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(outbox.events) != 1 {
+		t.Fatalf("Expected OrderCancelled in the outbox, got %v", outbox.events)
+	}
+	if _, ok := outbox.events[0].(orderDomain.OrderCancelled); !ok {
+		t.Errorf("Expected OrderCancelled, got %T", outbox.events[0])
+	}
+}
+
+func TestHandleWebhookHandler_Handle_FailedOutboxWriteFailsCallback(t *testing.T) {
+	// Arrange
+	handler, _, webhooks, _, _ := newWebhookFixture(domain.StatusAuthorized)
+	handler.Outbox = &MockPublisher{err: errors.New("connection reset")}
+	events := &MockPublisher{}
+	handler.Events = events
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_2", Type: domain.WebhookFailed, Reference: "pi_1"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the outbox failure to be returned so the gateway retries")
+	}
+	if len(webhooks.processed) != 0 {
+		t.Errorf("Expected the webhook to stay unprocessed, got %v", webhooks.processed)
+	}
+	if len(events.events) != 0 {
+		t.Errorf("Expected no event for a cancellation that didn't commit, got %v", events.events)
+	}
+}
+
+func TestHandleWebhookHandler_Handle_DuplicateEvent(t *testing.T) {
+	// Arrange
+	handler, payments, webhooks, _, _ := newWebhookFixture(domain.StatusAuthorized)
+	webhooks.processed = map[string]bool{"evt_1": true}
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_1", Type: domain.WebhookCaptured, Reference: "pi_1"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(payments.saved) != 0 {
+		t.Errorf("Expected a processed event to be skipped, got saves %+v", payments.saved)
+	}
+}
+
+func TestHandleWebhookHandler_Handle_OutOfOrderEvent(t *testing.T) {
+	// Arrange
+	handler, payments, webhooks, _, _ := newWebhookFixture(domain.StatusCaptured)
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_3", Type: domain.WebhookAuthorized, Reference: "pi_1"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(payments.saved) != 0 {
+		t.Errorf("Expected payment to stay CAPTURED, got saves %+v", payments.saved)
+	}
+	if !webhooks.processed["evt_3"] {
+		t.Error("Expected stale event to be marked as processed")
+	}
+}
+
+func TestHandleWebhookHandler_Handle_UnknownPayment(t *testing.T) {
+	// Arrange
+	handler, _, webhooks, _, _ := newWebhookFixture(domain.StatusAuthorized)
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{ID: "evt_4", Type: domain.WebhookCaptured, Reference: "pi_unknown"}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if !errors.Is(err, domain.ErrUnknownPayment) {
+		t.Errorf("Expected ErrUnknownPayment, got %v", err)
+	}
+	if webhooks.processed["evt_4"] {
+		t.Error("Expected event to stay unprocessed so the gateway retries it")
+	}
+}
+
+func TestHandleWebhookHandler_Handle_DisputeOpensDisputeAndFlagsOrder(t *testing.T) {
+	// Arrange
+	handler, payments, _, orders, _ := newWebhookFixture(domain.StatusCaptured)
+	disputes := &MockDisputeRepository{}
+	handler.Disputes = disputes
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{
+		ID: "evt_5", Type: domain.WebhookDisputed, Reference: "pi_1", Amount: money.Money{Amount: 3000, Currency: "EUR"},
+		Dispute: &domain.DisputeDetails{Reference: "dp_1", Reason: "fraudulent", Status: domain.DisputeNeedsResponse},
+	}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if payments.payments["pi_1"].Status != domain.StatusDisputed {
+		t.Errorf("Expected payment status DISPUTED, got %s", payments.payments["pi_1"].Status)
+	}
+	d, ok := disputes.disputes["dp_1"]
+	if !ok {
+		t.Fatal("Expected dispute dp_1 to be recorded")
+	}
+	if d.OrderID != 42 || d.PaymentID != 1 || d.Reason != "fraudulent" || d.Status != domain.DisputeNeedsResponse {
+		t.Errorf("Expected dispute of order 42 needing a response, got %+v", d)
+	}
+	if len(orders.flagged) != 1 || orders.flagged[0] != orderDomain.FlagDisputed {
+		t.Errorf("Expected order to be flagged as disputed, got %v", orders.flagged)
+	}
+}
+
+func TestHandleWebhookHandler_Handle_DisputeClosed(t *testing.T) {
+	// Arrange
+	handler, payments, _, orders, _ := newWebhookFixture(domain.StatusDisputed)
+	disputes := &MockDisputeRepository{disputes: map[string]*domain.Dispute{
+		"dp_1": {ID: 1, PaymentID: 1, OrderID: 42, Reference: "dp_1", Status: domain.DisputeUnderReview},
+	}}
+	handler.Disputes = disputes
+	cmd := HandleWebhookCommand{Gateway: "stripe", Event: domain.WebhookEvent{
+		ID: "evt_6", Type: domain.WebhookDisputeClosed, Reference: "pi_1",
+		Dispute: &domain.DisputeDetails{Reference: "dp_1", Status: domain.DisputeWon},
+	}}
+
+	// Act
+	err := handler.Handle(context.Background(), cmd)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if d := disputes.disputes["dp_1"]; d.Status != domain.DisputeWon || d.ClosedAt == nil || disputes.updates != 1 {
+		t.Errorf("Expected dispute to be stored as WON, got %+v after %d updates", d, disputes.updates)
+	}
+	if len(payments.saved) != 0 {
+		t.Errorf("Expected payment to be left alone, got saves %+v", payments.saved)
+	}
+	if len(orders.flagged) != 0 {
+		t.Errorf("Expected no new flag, got %v", orders.flagged)
+	}
+}
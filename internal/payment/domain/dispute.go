@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// ErrDisputeClosed is returned when evidence is attached to a dispute the bank has decided
+var ErrDisputeClosed = errors.New("dispute is closed")
+
+// MaxEvidenceSize bounds a single evidence file; gateways reject larger uploads
+const MaxEvidenceSize = 5 << 20
+
+// EvidenceContentTypes are the file types gateways accept as dispute evidence
+var EvidenceContentTypes = []string{"application/pdf", "image/jpeg", "image/png", "text/plain"}
+
+// DisputeStatus is where a chargeback stands at the customer's bank
+type DisputeStatus string
+
+const (
+	DisputeNeedsResponse DisputeStatus = "NEEDS_RESPONSE"
+	DisputeUnderReview   DisputeStatus = "UNDER_REVIEW"
+	DisputeWon           DisputeStatus = "WON"
+	DisputeLost          DisputeStatus = "LOST"
+)
+
+// IsClosed reports whether the bank has decided the dispute
+func (s DisputeStatus) IsClosed() bool {
+	return s == DisputeWon || s == DisputeLost
+}
+
+// IsValid reports whether s is a known dispute status
+func (s DisputeStatus) IsValid() bool {
+	switch s {
+	case DisputeNeedsResponse, DisputeUnderReview, DisputeWon, DisputeLost:
+		return true
+	}
+	return false
+}
+
+// DisputeDetails is the state of a dispute as reported by a gateway callback
+type DisputeDetails struct {
+	// Reference is the gateway's dispute identifier
+	Reference     string
+	Reason        string
+	Status        DisputeStatus
+	EvidenceDueBy *time.Time
+}
+
+// Dispute is a chargeback the customer raised with their bank against a payment
+type Dispute struct {
+	ID int64 `gorm:"primaryKey"`
+	// TenantID is the tenant of the disputed payment; see tenant.Plugin
+	TenantID      string        `gorm:"type:varchar(64);not null;default:'default';index"`
+	PaymentID     int64         `gorm:"index;not null"`
+	OrderID       int64         `gorm:"index;not null"`
+	Gateway       string        `gorm:"type:varchar(32);not null;uniqueIndex:idx_disputes_reference,priority:1"`
+	Reference     string        `gorm:"type:varchar(255);not null;uniqueIndex:idx_disputes_reference,priority:2"`
+	Reason        string        `gorm:"type:varchar(64)"`
+	Amount        money.Money   `gorm:"type:varchar(32);not null"`
+	Status        DisputeStatus `gorm:"type:varchar(20);not null;index"`
+	EvidenceDueBy *time.Time
+	ClosedAt      *time.Time
+	Evidence      []DisputeEvidence `gorm:"foreignKey:DisputeID"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// NewDispute opens a dispute against payment p from the first callback about it
+func NewDispute(p *Payment, amount money.Money, details DisputeDetails, now time.Time) *Dispute {
+	d := &Dispute{
+		TenantID:  p.TenantID,
+		PaymentID: p.ID,
+		OrderID:   p.OrderID,
+		Gateway:   p.Gateway,
+		Reference: details.Reference,
+		Amount:    amount,
+		Status:    DisputeNeedsResponse,
+	}
+	d.Apply(details, now)
+	return d
+}
+
+// Apply updates the dispute from a gateway callback and reports whether anything changed.
+// A decided dispute is final, so stale callbacks arriving after the decision are ignored.
+func (d *Dispute) Apply(details DisputeDetails, now time.Time) bool {
+	if d.Status.IsClosed() {
+		return false
+	}
+
+	changed := false
+	if details.Status.IsValid() && details.Status != d.Status {
+		d.Status = details.Status
+		changed = true
+		if d.Status.IsClosed() {
+			d.ClosedAt = &now
+		}
+	}
+	if details.Reason != "" && details.Reason != d.Reason {
+		d.Reason = details.Reason
+		changed = true
+	}
+	if details.EvidenceDueBy != nil && (d.EvidenceDueBy == nil || !d.EvidenceDueBy.Equal(*details.EvidenceDueBy)) {
+		d.EvidenceDueBy = details.EvidenceDueBy
+		changed = true
+	}
+	return changed
+}
+
+// DisputeEvidence is a file submitted to contest a dispute, e.g. a delivery confirmation
+type DisputeEvidence struct {
+	ID          int64  `gorm:"primaryKey"`
+	DisputeID   int64  `gorm:"index;not null"`
+	FileName    string `gorm:"type:varchar(255);not null"`
+	ContentType string `gorm:"type:varchar(100);not null"`
+	Size        int64  `gorm:"not null"`
+	// StorageKey locates the file in the EvidenceStore
+	StorageKey  string `gorm:"type:varchar(512);not null"`
+	Description string `gorm:"type:text"`
+	UploadedAt  time.Time
+}
+
+// DisputeFilter narrows the disputes returned by List; zero fields match every dispute
+type DisputeFilter struct {
+	Status  DisputeStatus
+	OrderID int64
+	Limit   int
+	// WithEvidence loads the evidence of each dispute, which List leaves out otherwise
+	WithEvidence bool
+}
+
+// DisputeGrouping is the dimension dispute rates are reported by
+type DisputeGrouping string
+
+const (
+	GroupByProduct DisputeGrouping = "product"
+	// GroupBySegment splits orders into the customer's first order ("new") and later ones ("returning")
+	GroupBySegment DisputeGrouping = "segment"
+)
+
+const (
+	SegmentNew       = "new"
+	SegmentReturning = "returning"
+)
+
+// DisputeRate is the share of paid orders in a group that were disputed
+type DisputeRate struct {
+	Group    string
+	Orders   int64
+	Disputed int64
+}
+
+// Rate is Disputed over Orders, 0 for a group without orders
+func (r DisputeRate) Rate() float64 {
+	if r.Orders == 0 {
+		return 0
+	}
+	return float64(r.Disputed) / float64(r.Orders)
+}
+
+type DisputeRepository interface {
+	Create(ctx context.Context, d *Dispute) error
+	// Update stores the status, reason and deadlines of a dispute
+	Update(ctx context.Context, d *Dispute) error
+	GetByID(ctx context.Context, id int64) (*Dispute, error)
+	GetByReference(ctx context.Context, gateway, reference string) (*Dispute, error)
+	// List returns the matching disputes, newest first
+	List(ctx context.Context, filter DisputeFilter) ([]Dispute, error)
+	AddEvidence(ctx context.Context, e *DisputeEvidence) error
+	// Rates reports dispute rates of the orders paid in [from, to)
+	Rates(ctx context.Context, from, to time.Time, groupBy DisputeGrouping) ([]DisputeRate, error)
+}
+
+// EvidenceStore keeps the files attached to disputes
+type EvidenceStore interface {
+	// Put stores the content under key and returns its size in bytes
+	Put(ctx context.Context, key string, content io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
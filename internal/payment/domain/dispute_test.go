@@ -0,0 +1,28 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispute_Apply(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	dueBy := now.AddDate(0, 0, 7)
+	p := &domain.Payment{ID: 1, OrderID: 42, Gateway: "stripe"}
+
+	d := domain.NewDispute(p, eur(3000), domain.DisputeDetails{Reference: "dp_1", Reason: "fraudulent", EvidenceDueBy: &dueBy}, now)
+	assert.Equal(t, domain.DisputeNeedsResponse, d.Status)
+	assert.Equal(t, int64(42), d.OrderID)
+	assert.Equal(t, &dueBy, d.EvidenceDueBy)
+
+	assert.False(t, d.Apply(domain.DisputeDetails{Reference: "dp_1", Reason: "fraudulent"}, now), "unchanged details")
+	assert.True(t, d.Apply(domain.DisputeDetails{Reference: "dp_1", Status: domain.DisputeLost}, now))
+	assert.Equal(t, &now, d.ClosedAt)
+
+	// A stale update delivered after the decision must not reopen it
+	assert.False(t, d.Apply(domain.DisputeDetails{Reference: "dp_1", Status: domain.DisputeUnderReview}, now))
+	assert.Equal(t, domain.DisputeLost, d.Status)
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// AuthorizeRequest asks the gateway to hold Amount on the payment method
+type AuthorizeRequest struct {
+	Amount money.Money
+	// Method is the gateway's token for the customer's payment method, e.g. a Stripe PaymentMethod ID
+	Method      string
+	Description string
+}
+
+// Authorization is a hold placed by the gateway; Reference identifies it in later calls
+type Authorization struct {
+	Reference string
+	Amount    money.Money
+}
+
+// PaymentGateway is the port to an external payment provider.
+// Authorize returns an error wrapping ErrPaymentDeclined when the provider refuses the payment.
+type PaymentGateway interface {
+	// Name identifies the gateway on stored payments, e.g. "stripe"
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error)
+	// Capture collects amount of an authorization
+	Capture(ctx context.Context, reference string, amount money.Money) error
+	// Refund returns amount of a captured payment to the customer
+	Refund(ctx context.Context, reference string, amount money.Money) error
+	// Void releases an authorization that will not be captured
+	Void(ctx context.Context, reference string) error
+	// ListPayments reports the payments created in [from, to) as the gateway sees them
+	ListPayments(ctx context.Context, from, to time.Time) ([]GatewayPayment, error)
+}
+
+// GatewayPayment is a payment as reported by the gateway, used for reconciliation
+type GatewayPayment struct {
+	Reference string
+	Status    PaymentStatus
+	Amount    money.Money
+	// Refunded is how much of the payment the gateway returned to the customer
+	Refunded money.Money
+}
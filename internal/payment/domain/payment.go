@@ -0,0 +1,276 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+var (
+	// ErrPaymentDeclined is returned when the gateway refuses to authorize a payment
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrUnknownPayment is returned for references the gateway did not issue or no payment is stored for
+	ErrUnknownPayment = errors.New("unknown payment reference")
+	// ErrInvalidPaymentTransition is returned when a payment is moved to a status not reachable from its current one
+	ErrInvalidPaymentTransition = errors.New("invalid payment status transition")
+	// ErrCaptureExceedsAuthorization is returned when more is captured than is still authorized
+	ErrCaptureExceedsAuthorization = errors.New("capture exceeds the authorized amount")
+	// ErrRefundExceedsCaptured is returned when more is refunded than was captured and not refunded yet
+	ErrRefundExceedsCaptured = errors.New("refund exceeds the captured amount")
+)
+
+// ErrorCodePaymentDeclined is returned with 402 when a payment is declined
+const ErrorCodePaymentDeclined = "payment_declined"
+
+// PaymentStatus is the lifecycle state of a payment at the gateway
+type PaymentStatus string
+
+const (
+	StatusAuthorized        PaymentStatus = "AUTHORIZED"
+	StatusPartiallyCaptured PaymentStatus = "PARTIALLY_CAPTURED"
+	StatusCaptured          PaymentStatus = "CAPTURED"
+	StatusRefunded          PaymentStatus = "REFUNDED"
+	StatusVoided            PaymentStatus = "VOIDED"
+	StatusFailed            PaymentStatus = "FAILED"
+	StatusDisputed          PaymentStatus = "DISPUTED"
+)
+
+// paymentTransitions lists the statuses reachable from each status
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	StatusAuthorized:        {StatusPartiallyCaptured, StatusCaptured, StatusVoided, StatusFailed, StatusDisputed},
+	StatusPartiallyCaptured: {StatusCaptured, StatusRefunded, StatusDisputed},
+	StatusCaptured:          {StatusRefunded, StatusDisputed},
+	StatusRefunded:          {},
+	StatusVoided:            {},
+	StatusFailed:            {},
+	StatusDisputed:          {},
+}
+
+// CanTransitionTo reports whether a payment in status s may move to status to
+func (s PaymentStatus) CanTransitionTo(to PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// EntryKind is the kind of money movement recorded on a payment
+type EntryKind string
+
+const (
+	EntryAuthorization EntryKind = "AUTHORIZATION"
+	EntryCapture       EntryKind = "CAPTURE"
+	EntryRefund        EntryKind = "REFUND"
+)
+
+// PaymentEntry is a single money movement of a payment, e.g. one partial capture per shipment
+type PaymentEntry struct {
+	ID        int64       `gorm:"primaryKey"`
+	PaymentID int64       `gorm:"index;not null"`
+	OrderID   int64       `gorm:"index;not null"`
+	Kind      EntryKind   `gorm:"type:varchar(20);not null"`
+	Amount    money.Money `gorm:"type:varchar(32);not null"`
+	CreatedAt time.Time   `gorm:"not null"`
+}
+
+// Payment is the money authorized for an order at a payment gateway.
+// An order may be paid with several payments, e.g. a gift card and a card.
+type Payment struct {
+	ID int64 `gorm:"primaryKey"`
+	// TenantID is the tenant of the order paid for; see tenant.Plugin
+	TenantID  string      `gorm:"type:varchar(64);not null;default:'default';index"`
+	OrderID   int64       `gorm:"index;not null"`
+	Gateway   string      `gorm:"type:varchar(32);not null"`
+	Reference string      `gorm:"type:varchar(255);not null"`
+	Amount    money.Money `gorm:"type:varchar(32);not null"`
+	Captured  money.Money `gorm:"type:varchar(32)"`
+	// Refunded is the part of Captured returned to the customer
+	Refunded money.Money    `gorm:"type:varchar(32)"`
+	Status   PaymentStatus  `gorm:"type:varchar(20);not null"`
+	Entries  []PaymentEntry `gorm:"foreignKey:PaymentID"`
+	// Sandbox payments were authorized at the gateway's sandbox and are only ever captured there
+	Sandbox   bool `gorm:"not null;default:false"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewAuthorizedPayment records an authorization granted by gateway for an order
+func NewAuthorizedPayment(orderID int64, gateway string, auth *Authorization) *Payment {
+	return &Payment{
+		OrderID:   orderID,
+		Gateway:   gateway,
+		Reference: auth.Reference,
+		Amount:    auth.Amount,
+		Status:    StatusAuthorized,
+		Entries: []PaymentEntry{
+			{OrderID: orderID, Kind: EntryAuthorization, Amount: auth.Amount, CreatedAt: time.Now().UTC()},
+		},
+	}
+}
+
+// Capturable is the authorized amount that has not been captured yet
+func (p *Payment) Capturable() money.Money {
+	if p.Status != StatusAuthorized && p.Status != StatusPartiallyCaptured {
+		return money.Money{Currency: p.Amount.Currency}
+	}
+	return money.Money{Amount: p.Amount.Amount - p.Captured.Amount, Currency: p.Amount.Currency}
+}
+
+// Capture records that amount of the authorization was collected; the payment stays
+// PARTIALLY_CAPTURED until the whole authorized amount is captured
+func (p *Payment) Capture(amount money.Money, now time.Time) error {
+	if amount.Amount <= 0 || amount.Currency != p.Amount.Currency {
+		return fmt.Errorf("%w: cannot capture %s of a %s payment", ErrCaptureExceedsAuthorization, amount, p.Amount.Currency)
+	}
+	if amount.Amount > p.Capturable().Amount {
+		return fmt.Errorf("%w: %s requested, %s capturable", ErrCaptureExceedsAuthorization, amount, p.Capturable())
+	}
+
+	to := StatusPartiallyCaptured
+	if p.Captured.Amount+amount.Amount == p.Amount.Amount {
+		to = StatusCaptured
+	}
+	if _, err := p.Transition(to); err != nil {
+		return err
+	}
+	p.Captured = money.Money{Amount: p.Captured.Amount + amount.Amount, Currency: p.Amount.Currency}
+	p.Entries = append(p.Entries, PaymentEntry{PaymentID: p.ID, OrderID: p.OrderID, Kind: EntryCapture, Amount: amount, CreatedAt: now})
+	return nil
+}
+
+// Refundable is the captured amount that has not been refunded yet
+func (p *Payment) Refundable() money.Money {
+	if p.Status != StatusPartiallyCaptured && p.Status != StatusCaptured {
+		return money.Money{Currency: p.Amount.Currency}
+	}
+	return money.Money{Amount: p.Captured.Amount - p.Refunded.Amount, Currency: p.Amount.Currency}
+}
+
+// Refund records that amount of the captured money was returned to the customer; the payment
+// moves to REFUNDED once everything captured is refunded
+func (p *Payment) Refund(amount money.Money, now time.Time) error {
+	if amount.Amount <= 0 || amount.Currency != p.Amount.Currency {
+		return fmt.Errorf("%w: cannot refund %s of a %s payment", ErrRefundExceedsCaptured, amount, p.Amount.Currency)
+	}
+	if amount.Amount > p.Refundable().Amount {
+		return fmt.Errorf("%w: %s requested, %s refundable", ErrRefundExceedsCaptured, amount, p.Refundable())
+	}
+
+	if p.Refunded.Amount+amount.Amount == p.Captured.Amount {
+		if _, err := p.Transition(StatusRefunded); err != nil {
+			return err
+		}
+	}
+	p.Refunded = money.Money{Amount: p.Refunded.Amount + amount.Amount, Currency: p.Amount.Currency}
+	p.Entries = append(p.Entries, PaymentEntry{PaymentID: p.ID, OrderID: p.OrderID, Kind: EntryRefund, Amount: amount, CreatedAt: now})
+	return nil
+}
+
+// CaptureAllocation is the part of an order capture taken from one payment
+type CaptureAllocation struct {
+	Payment *Payment
+	Amount  money.Money
+}
+
+// AllocateCapture splits amount across the payments of an order in the order they were authorized,
+// so instruments given first, such as gift cards, are used up first
+func AllocateCapture(payments []Payment, amount money.Money) ([]CaptureAllocation, error) {
+	if amount.Amount <= 0 {
+		return nil, fmt.Errorf("%w: capture amount must be greater than 0", ErrCaptureExceedsAuthorization)
+	}
+
+	var allocations []CaptureAllocation
+	remaining := amount.Amount
+	for i := range payments {
+		p := &payments[i]
+		capturable := p.Capturable()
+		if remaining == 0 || capturable.Amount == 0 || capturable.Currency != amount.Currency {
+			continue
+		}
+		take := min(remaining, capturable.Amount)
+		allocations = append(allocations, CaptureAllocation{Payment: p, Amount: money.Money{Amount: take, Currency: amount.Currency}})
+		remaining -= take
+	}
+	if remaining > 0 {
+		return nil, fmt.Errorf("%w: %s requested, %s not covered by any payment", ErrCaptureExceedsAuthorization,
+			amount, money.Money{Amount: remaining, Currency: amount.Currency})
+	}
+	return allocations, nil
+}
+
+// RefundAllocation is the part of an order refund returned through one payment
+type RefundAllocation struct {
+	Payment *Payment
+	Amount  money.Money
+}
+
+// AllocateRefund splits amount across the payments of an order, newest first, so instruments
+// used up first, such as gift cards, are the last to get money back
+func AllocateRefund(payments []Payment, amount money.Money) ([]RefundAllocation, error) {
+	if amount.Amount <= 0 {
+		return nil, fmt.Errorf("%w: refund amount must be greater than 0", ErrRefundExceedsCaptured)
+	}
+
+	var allocations []RefundAllocation
+	remaining := amount.Amount
+	for i := len(payments) - 1; i >= 0; i-- {
+		p := &payments[i]
+		refundable := p.Refundable()
+		if remaining == 0 || refundable.Amount == 0 || refundable.Currency != amount.Currency {
+			continue
+		}
+		take := min(remaining, refundable.Amount)
+		allocations = append(allocations, RefundAllocation{Payment: p, Amount: money.Money{Amount: take, Currency: amount.Currency}})
+		remaining -= take
+	}
+	if remaining > 0 {
+		return nil, fmt.Errorf("%w: %s requested, %s not covered by any payment", ErrRefundExceedsCaptured,
+			amount, money.Money{Amount: remaining, Currency: amount.Currency})
+	}
+	return allocations, nil
+}
+
+// TotalRefundable adds up what can still be refunded of the payments of an order. Orders are
+// paid in one currency; payments in another one than the first refundable payment are left out.
+func TotalRefundable(payments []Payment) money.Money {
+	var total money.Money
+	for i := range payments {
+		refundable := payments[i].Refundable()
+		if refundable.Amount == 0 || (total.Currency != "" && refundable.Currency != total.Currency) {
+			continue
+		}
+		total = money.Money{Amount: total.Amount + refundable.Amount, Currency: refundable.Currency}
+	}
+	return total
+}
+
+// Transition moves the payment to status to. Gateways deliver callbacks at least once,
+// so moving to the current status is a no-op reported with changed false.
+func (p *Payment) Transition(to PaymentStatus) (changed bool, err error) {
+	if p.Status == to {
+		return false, nil
+	}
+	if !p.Status.CanTransitionTo(to) {
+		return false, fmt.Errorf("%w: %s -> %s", ErrInvalidPaymentTransition, p.Status, to)
+	}
+	p.Status = to
+	return true, nil
+}
+
+type PaymentRepository interface {
+	// Save stores the payment together with its new entries
+	Save(ctx context.Context, p *Payment) error
+	// ListByOrder returns the payments of an order with their entries, oldest first
+	ListByOrder(ctx context.Context, orderID int64) ([]Payment, error)
+	// ListByOrderForUpdate is ListByOrder with SELECT ... FOR UPDATE; the payments stay locked until
+	// the transaction of ctx ends, so it must be called inside persistence.Transactor.InTransaction
+	ListByOrderForUpdate(ctx context.Context, orderID int64) ([]Payment, error)
+	GetByReference(ctx context.Context, gateway, reference string) (*Payment, error)
+	// ListCreatedBetween returns the live payments of gateway created in [from, to)
+	ListCreatedBetween(ctx context.Context, gateway string, from, to time.Time) ([]Payment, error)
+}
@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eur(amount int64) money.Money {
+	return money.Money{Amount: amount, Currency: "EUR"}
+}
+
+func TestPayment_Transition(t *testing.T) {
+	p := &domain.Payment{Status: domain.StatusAuthorized}
+
+	changed, err := p.Transition(domain.StatusCaptured)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = p.Transition(domain.StatusCaptured)
+	assert.NoError(t, err)
+	assert.False(t, changed, "redelivered callbacks are no-ops")
+
+	_, err = p.Transition(domain.StatusAuthorized)
+	assert.ErrorIs(t, err, domain.ErrInvalidPaymentTransition)
+	assert.Equal(t, domain.StatusCaptured, p.Status)
+}
+
+func TestPayment_Capture(t *testing.T) {
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	p := &domain.Payment{ID: 1, OrderID: 7, Amount: eur(3000), Status: domain.StatusAuthorized}
+
+	require.NoError(t, p.Capture(eur(1000), now))
+	assert.Equal(t, domain.StatusPartiallyCaptured, p.Status)
+	assert.Equal(t, eur(2000), p.Capturable())
+
+	assert.ErrorIs(t, p.Capture(eur(2500), now), domain.ErrCaptureExceedsAuthorization)
+	assert.ErrorIs(t, p.Capture(money.Money{Amount: 500, Currency: "USD"}, now), domain.ErrCaptureExceedsAuthorization)
+
+	require.NoError(t, p.Capture(eur(2000), now))
+	assert.Equal(t, domain.StatusCaptured, p.Status)
+	assert.Equal(t, eur(3000), p.Captured)
+	assert.Equal(t, eur(0), p.Capturable())
+
+	require.Len(t, p.Entries, 2)
+	assert.Equal(t, domain.PaymentEntry{PaymentID: 1, OrderID: 7, Kind: domain.EntryCapture, Amount: eur(1000), CreatedAt: now}, p.Entries[0])
+}
+
+func TestAllocateCapture(t *testing.T) {
+	payments := []domain.Payment{
+		{Reference: "gift_card", Amount: eur(1000), Captured: eur(400), Status: domain.StatusPartiallyCaptured},
+		{Reference: "voided", Amount: eur(5000), Status: domain.StatusVoided},
+		{Reference: "card", Amount: eur(2000), Status: domain.StatusAuthorized},
+	}
+
+	allocations, err := domain.AllocateCapture(payments, eur(1500))
+	require.NoError(t, err)
+	require.Len(t, allocations, 2)
+	assert.Equal(t, "gift_card", allocations[0].Payment.Reference)
+	assert.Equal(t, eur(600), allocations[0].Amount, "instruments given first are used up first")
+	assert.Equal(t, "card", allocations[1].Payment.Reference)
+	assert.Equal(t, eur(900), allocations[1].Amount)
+
+	_, err = domain.AllocateCapture(payments, eur(2601))
+	assert.ErrorIs(t, err, domain.ErrCaptureExceedsAuthorization)
+}
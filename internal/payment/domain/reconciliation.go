@@ -0,0 +1,87 @@
+package domain
+
+import "fmt"
+
+// DiscrepancyKind classifies a difference between local payments and the gateway report
+type DiscrepancyKind string
+
+const (
+	DiscrepancyMissingLocally   DiscrepancyKind = "missing_locally"
+	DiscrepancyMissingAtGateway DiscrepancyKind = "missing_at_gateway"
+	DiscrepancyStatus           DiscrepancyKind = "status_mismatch"
+	DiscrepancyAmount           DiscrepancyKind = "amount_mismatch"
+	DiscrepancyRefund           DiscrepancyKind = "refund_mismatch"
+)
+
+// Discrepancy is a payment whose local record disagrees with the gateway
+type Discrepancy struct {
+	Reference string
+	Kind      DiscrepancyKind
+	Detail    string
+}
+
+// Reconcile compares local payments with the gateway's report of the same period
+func Reconcile(local []Payment, remote []GatewayPayment) []Discrepancy {
+	byReference := make(map[string]Payment, len(local))
+	for _, p := range local {
+		byReference[p.Reference] = p
+	}
+
+	var discrepancies []Discrepancy
+	for _, r := range remote {
+		p, ok := byReference[r.Reference]
+		if !ok {
+			discrepancies = append(discrepancies, Discrepancy{
+				Reference: r.Reference,
+				Kind:      DiscrepancyMissingLocally,
+				Detail:    fmt.Sprintf("%s payment of %s has no local record", r.Status, r.Amount),
+			})
+			continue
+		}
+		delete(byReference, r.Reference)
+
+		if settledStatus(p.Status) != r.Status {
+			discrepancies = append(discrepancies, Discrepancy{
+				Reference: r.Reference,
+				Kind:      DiscrepancyStatus,
+				Detail:    fmt.Sprintf("local %s, gateway %s", p.Status, r.Status),
+			})
+		}
+		if p.Amount != r.Amount {
+			discrepancies = append(discrepancies, Discrepancy{
+				Reference: r.Reference,
+				Kind:      DiscrepancyAmount,
+				Detail:    fmt.Sprintf("local %s, gateway %s", p.Amount, r.Amount),
+			})
+		}
+		if p.Refunded.Amount != r.Refunded.Amount {
+			discrepancies = append(discrepancies, Discrepancy{
+				Reference: r.Reference,
+				Kind:      DiscrepancyRefund,
+				Detail:    fmt.Sprintf("local refunded %d, gateway refunded %d", p.Refunded.Amount, r.Refunded.Amount),
+			})
+		}
+	}
+
+	for _, p := range local {
+		if _, missing := byReference[p.Reference]; missing {
+			discrepancies = append(discrepancies, Discrepancy{
+				Reference: p.Reference,
+				Kind:      DiscrepancyMissingAtGateway,
+				Detail:    fmt.Sprintf("%s payment of order %d is unknown to the gateway", p.Status, p.OrderID),
+			})
+		}
+	}
+	return discrepancies
+}
+
+// settledStatus maps local statuses onto what gateways report for the underlying charge:
+// refunds and disputes don't change the status of a captured payment at the gateway
+func settledStatus(s PaymentStatus) PaymentStatus {
+	switch s {
+	case StatusRefunded, StatusDisputed:
+		return StatusCaptured
+	default:
+		return s
+	}
+}
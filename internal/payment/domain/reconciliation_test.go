@@ -0,0 +1,38 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+	local := []domain.Payment{
+		{OrderID: 1, Reference: "pi_ok", Amount: eur(1000), Status: domain.StatusCaptured},
+		{OrderID: 2, Reference: "pi_refunded", Amount: eur(1000), Status: domain.StatusRefunded},
+		{OrderID: 3, Reference: "pi_status", Amount: eur(1000), Status: domain.StatusAuthorized},
+		{OrderID: 4, Reference: "pi_amount", Amount: eur(1000), Status: domain.StatusAuthorized},
+		{OrderID: 5, Reference: "pi_local_only", Amount: eur(1000), Status: domain.StatusAuthorized},
+	}
+	remote := []domain.GatewayPayment{
+		{Reference: "pi_ok", Amount: eur(1000), Status: domain.StatusCaptured},
+		{Reference: "pi_refunded", Amount: eur(1000), Status: domain.StatusCaptured},
+		{Reference: "pi_status", Amount: eur(1000), Status: domain.StatusVoided},
+		{Reference: "pi_amount", Amount: eur(900), Status: domain.StatusAuthorized},
+		{Reference: "pi_remote_only", Amount: eur(500), Status: domain.StatusCaptured},
+	}
+
+	discrepancies := domain.Reconcile(local, remote)
+
+	kinds := make(map[string]domain.DiscrepancyKind)
+	for _, d := range discrepancies {
+		kinds[d.Reference] = d.Kind
+	}
+	assert.Equal(t, map[string]domain.DiscrepancyKind{
+		"pi_status":      domain.DiscrepancyStatus,
+		"pi_amount":      domain.DiscrepancyAmount,
+		"pi_remote_only": domain.DiscrepancyMissingLocally,
+		"pi_local_only":  domain.DiscrepancyMissingAtGateway,
+	}, kinds)
+}
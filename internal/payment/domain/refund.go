@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// ErrOrderNotRefundable is returned when refunding an order that is neither confirmed nor delivered
+var ErrOrderNotRefundable = errors.New("order cannot be refunded")
+
+// Refund is money returned to the customer of an order, possibly part of what was paid. The money
+// movements are recorded on the payments it was taken from as REFUND entries.
+type Refund struct {
+	ID int64 `gorm:"primaryKey"`
+	// TenantID is the tenant of the order refunded; see tenant.Plugin
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';index"`
+	OrderID  int64  `gorm:"not null;uniqueIndex:idx_refunds_order_number"`
+	// Number counts the refunds of the order from 1
+	Number int         `gorm:"not null;uniqueIndex:idx_refunds_order_number"`
+	Amount money.Money `gorm:"type:varchar(32);not null"`
+	Reason string      `gorm:"type:varchar(255)"`
+	// Restocked is set when the refund put the ordered quantity back in stock
+	Restocked bool `gorm:"not null;default:false"`
+	CreatedAt time.Time
+}
+
+type RefundRepository interface {
+	Create(ctx context.Context, r *Refund) error
+	// ListByOrder returns the refunds of an order, oldest first
+	ListByOrder(ctx context.Context, orderID int64) ([]Refund, error)
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// ErrInvalidSignature is returned when a webhook payload is not signed by the gateway
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// ErrorCodeInvalidSignature is returned with 400 for webhooks failing signature verification
+const ErrorCodeInvalidSignature = "invalid_signature"
+
+// WebhookEventType is the gateway independent kind of an asynchronous gateway callback
+type WebhookEventType string
+
+const (
+	WebhookAuthorized WebhookEventType = "authorized"
+	WebhookCaptured   WebhookEventType = "captured"
+	WebhookFailed     WebhookEventType = "failed"
+	WebhookDisputed   WebhookEventType = "disputed"
+	// WebhookDisputeUpdated and WebhookDisputeClosed follow a dispute opened by WebhookDisputed
+	WebhookDisputeUpdated WebhookEventType = "dispute_updated"
+	WebhookDisputeClosed  WebhookEventType = "dispute_closed"
+)
+
+// PaymentStatus is the status the event moves a payment to; false for events that leave it unchanged
+func (t WebhookEventType) PaymentStatus() (PaymentStatus, bool) {
+	switch t {
+	case WebhookAuthorized:
+		return StatusAuthorized, true
+	case WebhookCaptured:
+		return StatusCaptured, true
+	case WebhookFailed:
+		return StatusFailed, true
+	case WebhookDisputed:
+		return StatusDisputed, true
+	default:
+		return "", false
+	}
+}
+
+// IsDispute reports whether the event is about a dispute and carries its details
+func (t WebhookEventType) IsDispute() bool {
+	return t == WebhookDisputed || t == WebhookDisputeUpdated || t == WebhookDisputeClosed
+}
+
+// WebhookEvent is a verified gateway callback about the payment identified by Reference
+type WebhookEvent struct {
+	// ID is the gateway's event identifier; redeliveries of an event share it
+	ID        string
+	Type      WebhookEventType
+	Reference string
+	Amount    money.Money
+	// Dispute is set for dispute events
+	Dispute *DisputeDetails
+}
+
+// WebhookVerifier authenticates and decodes the callbacks of one gateway
+type WebhookVerifier interface {
+	// SignatureHeader is the HTTP header carrying the payload signature
+	SignatureHeader() string
+	// Verify checks the signature and decodes the payload. It returns ErrInvalidSignature for
+	// forged payloads and a nil event for event types the application does not handle.
+	Verify(ctx context.Context, payload []byte, signature string) (*WebhookEvent, error)
+}
+
+// ProcessedWebhook records a handled gateway event so redeliveries are skipped
+type ProcessedWebhook struct {
+	Gateway     string           `gorm:"primaryKey;type:varchar(32)"`
+	EventID     string           `gorm:"primaryKey;type:varchar(255)"`
+	Type        WebhookEventType `gorm:"type:varchar(20);not null"`
+	ProcessedAt time.Time        `gorm:"not null"`
+}
+
+type WebhookRepository interface {
+	IsProcessed(ctx context.Context, gateway, eventID string) (bool, error)
+	MarkProcessed(ctx context.Context, w *ProcessedWebhook) error
+}
@@ -0,0 +1,333 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+const (
+	defaultDisputeLimit = 50
+	maxDisputeLimit     = 200
+	// defaultReportDays is the period reported when from is not given
+	defaultReportDays = 30
+	// multipartOverhead allows for the form fields and boundaries around an evidence file
+	multipartOverhead = 64 << 10
+)
+
+// DisputeEvidenceResponse describes an evidence file attached to a dispute
+type DisputeEvidenceResponse struct {
+	ID          int64     `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Description string    `json:"description,omitempty"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// DisputeResponse is the public representation of a chargeback; OrderID is the public ID of the order
+type DisputeResponse struct {
+	ID            int64                     `json:"id"`
+	OrderID       string                    `json:"order_id"`
+	PaymentID     int64                     `json:"payment_id"`
+	Reference     string                    `json:"reference"`
+	Reason        string                    `json:"reason,omitempty"`
+	Amount        int64                     `json:"amount"`
+	Currency      string                    `json:"currency"`
+	Status        domain.DisputeStatus      `json:"status"`
+	EvidenceDueBy *time.Time                `json:"evidence_due_by,omitempty"`
+	ClosedAt      *time.Time                `json:"closed_at,omitempty"`
+	CreatedAt     time.Time                 `json:"created_at"`
+	Evidence      []DisputeEvidenceResponse `json:"evidence,omitempty"`
+}
+
+// DisputesResponse lists disputes, newest first. The evidence of each dispute is only listed when
+// named in ?fields=.
+type DisputesResponse struct {
+	Disputes []dto.Sparse[DisputeResponse] `json:"disputes"`
+}
+
+// DisputeRateResponse is the dispute rate of one product or customer segment
+type DisputeRateResponse struct {
+	Group    string  `json:"group"`
+	Orders   int64   `json:"orders"`
+	Disputed int64   `json:"disputed"`
+	Rate     float64 `json:"rate"`
+}
+
+// DisputeReportResponse reports dispute rates of the orders paid in a period
+type DisputeReportResponse struct {
+	From    string                 `json:"from"`
+	To      string                 `json:"to"`
+	GroupBy domain.DisputeGrouping `json:"group_by"`
+	Rates   []DisputeRateResponse  `json:"rates"`
+}
+
+func (s *HTTPServer) listDisputes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.DisputeFilter{Status: domain.DisputeStatus(query.Get("status")), Limit: defaultDisputeLimit}
+
+	var errs validation.Errors
+	errs.Check(filter.Status == "" || filter.Status.IsValid(), "status", fmt.Sprintf("is not a known dispute status, got %q", filter.Status))
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		errs.Check(err == nil && n >= 1 && n <= maxDisputeLimit, "limit", fmt.Sprintf("must be between 1 and %d, got %q", maxDisputeLimit, value))
+		filter.Limit = n
+	}
+	fields, err := dto.ParseFields[DisputeResponse](r)
+	errs.Merge("", err)
+	if err := errs.Err(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	filter.WithEvidence = fields.Named("evidence")
+
+	if value := query.Get("order_id"); value != "" {
+		id, err := s.orderID(r.Context(), value)
+		if errors.Is(err, persistence.ErrNotFound) {
+			// An unknown order has no disputes
+			httpx.WriteJSON(w, http.StatusOK, DisputesResponse{Disputes: []dto.Sparse[DisputeResponse]{}})
+			return
+		}
+		if err != nil {
+			httpx.WriteError(w, err)
+			return
+		}
+		filter.OrderID = id
+	}
+
+	disputes, err := s.Disputes.List(r.Context(), filter)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	// Public order IDs cost a query, so they are only looked up when selected
+	var orderIDs []int64
+	if fields.Has("order_id") {
+		for i := range disputes {
+			orderIDs = append(orderIDs, disputes[i].OrderID)
+		}
+	}
+	publicIDs, err := s.Orders.PublicIDs(r.Context(), orderIDs)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	toResponse := func(d *domain.Dispute) DisputeResponse {
+		return toDisputeResponse(d, publicIDs[d.OrderID])
+	}
+	httpx.WriteJSON(w, http.StatusOK, DisputesResponse{Disputes: dto.Map(disputes, toResponse, fields)})
+}
+
+func (s *HTTPServer) getDispute(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	d, err := s.Disputes.GetByID(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	publicIDs, err := s.Orders.PublicIDs(r.Context(), []int64{d.OrderID})
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, toDisputeResponse(d, publicIDs[d.OrderID]))
+}
+
+func (s *HTTPServer) uploadDisputeEvidence(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, domain.MaxEvidenceSize+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("evidence files are limited to %d bytes", domain.MaxEvidenceSize))
+			return
+		}
+		var errs validation.Errors
+		errs.Add("file", "is required as a multipart/form-data file")
+		httpx.WriteError(w, errs)
+		return
+	}
+	defer file.Close()
+	if header.Size > domain.MaxEvidenceSize {
+		httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("evidence files are limited to %d bytes", domain.MaxEvidenceSize))
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+
+	evidence, err := s.UploadDisputeEvidence.Handle(r.Context(), command.UploadDisputeEvidenceCommand{
+		DisputeID:   id,
+		FileName:    header.Filename,
+		ContentType: contentType,
+		Description: r.FormValue("description"),
+		Content:     file,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrDisputeClosed) {
+			httpx.WriteErrorStatus(w, http.StatusConflict, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, toDisputeEvidenceResponse(evidence))
+}
+
+func (s *HTTPServer) downloadDisputeEvidence(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.PathInt64(r, "id")
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	evidenceID, err := httpx.PathInt64(r, "evidenceID")
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	d, err := s.Disputes.GetByID(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	var evidence *domain.DisputeEvidence
+	for i := range d.Evidence {
+		if d.Evidence[i].ID == evidenceID {
+			evidence = &d.Evidence[i]
+		}
+	}
+	if evidence == nil {
+		httpx.WriteError(w, persistence.ErrNotFound)
+		return
+	}
+
+	content, err := s.Evidence.Open(r.Context(), evidence.StorageKey)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", evidence.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(evidence.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": evidence.FileName}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, content)
+}
+
+func (s *HTTPServer) disputeReport(w http.ResponseWriter, r *http.Request) {
+	from, to, groupBy, err := s.reportParams(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	// to is inclusive, so report up to the start of the next day
+	rates, err := s.Disputes.Rates(r.Context(), from, to.AddDate(0, 0, 1), groupBy)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := DisputeReportResponse{
+		From:    from.Format(dayLayout),
+		To:      to.Format(dayLayout),
+		GroupBy: groupBy,
+		Rates:   make([]DisputeRateResponse, len(rates)),
+	}
+	for i, rate := range rates {
+		resp.Rates[i] = DisputeRateResponse{Group: rate.Group, Orders: rate.Orders, Disputed: rate.Disputed, Rate: rate.Rate()}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// reportParams parses the from and to dates (YYYY-MM-DD, defaulting to the last 30 days) and group_by
+func (s *HTTPServer) reportParams(r *http.Request) (time.Time, time.Time, domain.DisputeGrouping, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	today := now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -defaultReportDays+1), today
+
+	var errs validation.Errors
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		day, err := time.Parse(dayLayout, value)
+		errs.Check(err == nil, "from", fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", value))
+		from = day
+	}
+	if value := query.Get("to"); value != "" {
+		day, err := time.Parse(dayLayout, value)
+		errs.Check(err == nil, "to", fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", value))
+		to = day
+	}
+	groupBy := domain.DisputeGrouping(query.Get("group_by"))
+	if groupBy == "" {
+		groupBy = domain.GroupByProduct
+	}
+	errs.Check(groupBy == domain.GroupByProduct || groupBy == domain.GroupBySegment, "group_by",
+		fmt.Sprintf("must be %s or %s, got %q", domain.GroupByProduct, domain.GroupBySegment, groupBy))
+	if err := errs.Err(); err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+	errs.Check(!to.Before(from), "to", "must not be before from")
+	return from, to, groupBy, errs.Err()
+}
+
+func toDisputeResponse(d *domain.Dispute, orderPublicID string) DisputeResponse {
+	resp := DisputeResponse{
+		ID:            d.ID,
+		OrderID:       orderPublicID,
+		PaymentID:     d.PaymentID,
+		Reference:     d.Reference,
+		Reason:        d.Reason,
+		Amount:        d.Amount.Amount,
+		Currency:      d.Amount.Currency,
+		Status:        d.Status,
+		EvidenceDueBy: d.EvidenceDueBy,
+		ClosedAt:      d.ClosedAt,
+		CreatedAt:     d.CreatedAt,
+	}
+	for i := range d.Evidence {
+		resp.Evidence = append(resp.Evidence, toDisputeEvidenceResponse(&d.Evidence[i]))
+	}
+	return resp
+}
+
+func toDisputeEvidenceResponse(e *domain.DisputeEvidence) DisputeEvidenceResponse {
+	return DisputeEvidenceResponse{
+		ID:          e.ID,
+		FileName:    e.FileName,
+		ContentType: e.ContentType,
+		Size:        e.Size,
+		Description: e.Description,
+		UploadedAt:  e.UploadedAt,
+	}
+}
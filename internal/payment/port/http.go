@@ -0,0 +1,341 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// maxWebhookBody bounds the callback payload read before the signature is checked
+const maxWebhookBody = 64 << 10
+
+// CaptureRequest is the body of POST /orders/{id}/captures; Amount is in minor units of Currency
+type CaptureRequest struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// RefundRequest is the body of POST /orders/{id}/refunds; Amount is in minor units of Currency and
+// an absent amount refunds everything still refundable
+type RefundRequest struct {
+	Amount   int64  `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// Restock puts the ordered quantity back in stock; an order is restocked at most once
+	Restock bool `json:"restock"`
+}
+
+// RefundResponse is a refund of an order
+type RefundResponse struct {
+	Number    int       `json:"number"`
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	Reason    string    `json:"reason,omitempty"`
+	Restocked bool      `json:"restocked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaymentEntryResponse is a single money movement of a payment
+type PaymentEntryResponse struct {
+	Kind      domain.EntryKind `json:"kind"`
+	Amount    int64            `json:"amount"`
+	Currency  string           `json:"currency"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// PaymentResponse is the public representation of a payment of an order
+type PaymentResponse struct {
+	ID        int64                  `json:"id"`
+	Gateway   string                 `json:"gateway"`
+	Reference string                 `json:"reference"`
+	Amount    int64                  `json:"amount"`
+	Captured  int64                  `json:"captured"`
+	Refunded  int64                  `json:"refunded"`
+	Currency  string                 `json:"currency"`
+	Status    domain.PaymentStatus   `json:"status"`
+	Entries   []PaymentEntryResponse `json:"entries"`
+}
+
+// OrderPaymentsResponse lists the payments of an order
+type OrderPaymentsResponse struct {
+	Payments []PaymentResponse `json:"payments"`
+}
+
+// HTTPServer exposes order payments and the payment gateway callbacks over HTTP
+type HTTPServer struct {
+	CaptureOrderPayment decorator.CommandResultHandler[command.CaptureOrderPaymentCommand, []domain.Payment]
+	RefundOrder         decorator.CommandResultHandler[command.RefundOrderCommand, *domain.Refund]
+	HandleWebhook       decorator.CommandHandler[command.HandleWebhookCommand]
+	Payments            domain.PaymentRepository
+	// Orders resolves the public order IDs of the order routes and dispute filters
+	Orders orderDomain.OrderRepository
+
+	UploadDisputeEvidence decorator.CommandResultHandler[command.UploadDisputeEvidenceCommand, *domain.DisputeEvidence]
+	Disputes              domain.DisputeRepository
+	Evidence              domain.EvidenceStore
+	Now                   func() time.Time
+
+	// Auth restricts order payments and disputes to staff; nil disables access control
+	Auth auth.Authorizer
+
+	// Gateway is the name of the configured gateway the callbacks come from
+	Gateway  string
+	Verifier domain.WebhookVerifier
+}
+
+// RegisterRoutes adds the payment endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/orders/{id}/payments",
+		Summary:  "List the payments of an order with their authorization and capture entries",
+		Tags:     []string{"payments"},
+		Response: OrderPaymentsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionOrderReadAny, s.listOrderPayments),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/orders/{id}/captures",
+		Summary:  "Capture part of an order's authorized payments, e.g. for a partial shipment",
+		Tags:     []string{"payments"},
+		Request:  CaptureRequest{},
+		Response: OrderPaymentsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionPaymentCapture, s.captureOrderPayment),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/orders/{id}/refunds",
+		Summary:  "Refund all or part of a confirmed or delivered order, optionally restocking it",
+		Tags:     []string{"payments"},
+		Request:  RefundRequest{},
+		Response: RefundResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionPaymentRefund, s.refundOrder),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/disputes",
+		Summary:  "List disputes, newest first, optionally by status or order_id; ?fields=id,status,evidence selects fields",
+		Tags:     []string{"disputes"},
+		Response: DisputesResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionDisputeManage, s.listDisputes),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/disputes/report",
+		Summary:  "Report dispute rates of orders paid between from and to, grouped by product or customer segment",
+		Tags:     []string{"disputes"},
+		Response: DisputeReportResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionDisputeManage, s.disputeReport),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/disputes/{id}",
+		Summary:  "Get a dispute with its evidence",
+		Tags:     []string{"disputes"},
+		Response: DisputeResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionDisputeManage, s.getDispute),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/disputes/{id}/evidence",
+		Summary:  "Attach an evidence file to an open dispute as multipart/form-data with file and description fields",
+		Tags:     []string{"disputes"},
+		Response: DisputeEvidenceResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionDisputeManage, s.uploadDisputeEvidence),
+	})
+	r.Handle(httpx.Route{
+		Method:  http.MethodGet,
+		Path:    "/disputes/{id}/evidence/{evidenceID}",
+		Summary: "Download an evidence file of a dispute",
+		Tags:    []string{"disputes"},
+		Handler: auth.Require(s.Auth, userDomain.PermissionDisputeManage, s.downloadDisputeEvidence),
+	})
+	r.Handle(httpx.Route{
+		Method:  http.MethodPost,
+		Path:    "/webhooks/payments",
+		Summary: "Receive a signed payment gateway callback",
+		Tags:    []string{"payments"},
+		Handler: s.handleWebhook,
+	})
+}
+
+func (s *HTTPServer) listOrderPayments(w http.ResponseWriter, r *http.Request) {
+	id, err := s.orderID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	payments, err := s.Payments.ListByOrder(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, toOrderPaymentsResponse(payments))
+}
+
+func (s *HTTPServer) captureOrderPayment(w http.ResponseWriter, r *http.Request) {
+	id, err := s.orderID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	var req CaptureRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	amount, err := money.New(req.Amount, req.Currency)
+	if err != nil {
+		var errs validation.Errors
+		errs.Add("currency", err.Error())
+		httpx.WriteError(w, errs)
+		return
+	}
+
+	payments, err := s.CaptureOrderPayment.Handle(r.Context(), command.CaptureOrderPaymentCommand{OrderID: id, Amount: amount})
+	if err != nil {
+		if errors.Is(err, domain.ErrCaptureExceedsAuthorization) {
+			httpx.WriteErrorStatus(w, http.StatusConflict, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, toOrderPaymentsResponse(payments))
+}
+
+func (s *HTTPServer) refundOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := s.orderID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	var req RefundRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	var amount money.Money
+	if req.Amount != 0 || req.Currency != "" {
+		if amount, err = money.New(req.Amount, req.Currency); err != nil {
+			var errs validation.Errors
+			errs.Add("currency", err.Error())
+			httpx.WriteError(w, errs)
+			return
+		}
+	}
+
+	refund, err := s.RefundOrder.Handle(r.Context(), command.RefundOrderCommand{OrderID: id, Amount: amount, Reason: req.Reason, Restock: req.Restock})
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotRefundable) || errors.Is(err, domain.ErrRefundExceedsCaptured) {
+			httpx.WriteErrorStatus(w, http.StatusConflict, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, RefundResponse{
+		Number:    refund.Number,
+		Amount:    refund.Amount.Amount,
+		Currency:  refund.Amount.Currency,
+		Reason:    refund.Reason,
+		Restocked: refund.Restocked,
+		CreatedAt: refund.CreatedAt,
+	})
+}
+
+// orderID resolves the public ID of an order to its primary key
+func (s *HTTPServer) orderID(ctx context.Context, publicID string) (int64, error) {
+	o, err := s.Orders.GetByPublicID(ctx, publicID)
+	if err != nil {
+		return 0, err
+	}
+	return o.ID, nil
+}
+
+func toOrderPaymentsResponse(payments []domain.Payment) OrderPaymentsResponse {
+	resp := OrderPaymentsResponse{Payments: make([]PaymentResponse, len(payments))}
+	for i, p := range payments {
+		entries := make([]PaymentEntryResponse, len(p.Entries))
+		for j, e := range p.Entries {
+			entries[j] = PaymentEntryResponse{Kind: e.Kind, Amount: e.Amount.Amount, Currency: e.Amount.Currency, CreatedAt: e.CreatedAt}
+		}
+		resp.Payments[i] = PaymentResponse{
+			ID:        p.ID,
+			Gateway:   p.Gateway,
+			Reference: p.Reference,
+			Amount:    p.Amount.Amount,
+			Captured:  p.Captured.Amount,
+			Refunded:  p.Refunded.Amount,
+			Currency:  p.Amount.Currency,
+			Status:    p.Status,
+			Entries:   entries,
+		}
+	}
+	return resp
+}
+
+func (s *HTTPServer) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		httpx.WriteError(w, fmt.Errorf("read webhook body: %w", err))
+		return
+	}
+	if len(payload) > maxWebhookBody {
+		httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, errors.New("webhook body too large"))
+		return
+	}
+
+	event, err := s.Verifier.Verify(r.Context(), payload, r.Header.Get(s.Verifier.SignatureHeader()))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSignature) {
+			httpx.WriteErrorCode(w, http.StatusBadRequest, domain.ErrorCodeInvalidSignature, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+	// Acknowledge event types we don't handle so the gateway stops sending them
+	if event == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Gateways don't know the tenant of the payment, which the signature already vouches for
+	ctx := tenant.Unscoped(r.Context())
+	if err := s.HandleWebhook.Handle(ctx, command.HandleWebhookCommand{Gateway: s.Gateway, Event: *event}); err != nil {
+		if errors.Is(err, domain.ErrUnknownPayment) {
+			httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
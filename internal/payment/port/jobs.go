@@ -0,0 +1,45 @@
+package port
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/payment/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+const dayLayout = "2006-01-02"
+
+// ReconcilePaymentsJob compares one day of payments with the gateway's report.
+// Day is YYYY-MM-DD; an empty Day reconciles the day before the job runs.
+type ReconcilePaymentsJob struct {
+	Day string `json:"day,omitempty"`
+}
+
+func (ReconcilePaymentsJob) Kind() string {
+	return "payment.reconcile"
+}
+
+// JobServer runs the payment use cases triggered by background jobs
+type JobServer struct {
+	ReconcilePayments decorator.CommandHandler[command.ReconcilePaymentsCommand]
+}
+
+// RegisterJobs adds the payment job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, s.reconcilePayments)
+}
+
+func (s *JobServer) reconcilePayments(ctx context.Context, job ReconcilePaymentsJob) error {
+	day := time.Now().UTC().AddDate(0, 0, -1)
+	if job.Day != "" {
+		parsed, err := time.Parse(dayLayout, job.Day)
+		if err != nil {
+			return fmt.Errorf("parse day %q: %w", job.Day, err)
+		}
+		day = parsed
+	}
+	return s.ReconcilePayments.Handle(ctx, command.ReconcilePaymentsCommand{Day: day})
+}
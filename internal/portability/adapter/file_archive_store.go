@@ -0,0 +1,118 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/portability/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// FileArchiveStore keeps the archives of data exports on the local filesystem, e.g. a mounted volume
+type FileArchiveStore struct {
+	dir string
+}
+
+func NewFileArchiveStore(dir string) *FileArchiveStore {
+	return &FileArchiveStore{dir: dir}
+}
+
+var _ domain.ArchiveStore = (*FileArchiveStore)(nil)
+
+func (s *FileArchiveStore) Put(ctx context.Context, key string, content io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return 0, fmt.Errorf("create archive directory: %w", err)
+	}
+
+	// Write to a temporary file first so a failed export never leaves a truncated archive behind
+	tmp, err := os.CreateTemp(s.dir, ".export-*")
+	if err != nil {
+		return 0, fmt.Errorf("create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("write archive %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("store archive %s: %w", key, err)
+	}
+	return size, nil
+}
+
+func (s *FileArchiveStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, persistence.ErrNotFound
+	}
+	return f, err
+}
+
+// DeleteBefore removes the archives last written before t, leftover temporary files included
+func (s *FileArchiveStore) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("list archives: %w", err)
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(t) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return deleted, fmt.Errorf("delete archive %s: %w", entry.Name(), err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Describe and Ensure let the bootstrap registry create the archive directory
+func (s *FileArchiveStore) Describe() string {
+	return "data export directory " + s.dir
+}
+
+func (s *FileArchiveStore) Ensure(ctx context.Context) (bool, error) {
+	if _, err := os.Stat(s.dir); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// path resolves key to a file directly inside the store directory
+func (s *FileArchiveStore) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid archive key %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
@@ -0,0 +1,67 @@
+package adapter_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/portability/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileArchiveStore_PutAndOpen(t *testing.T) {
+	store := adapter.NewFileArchiveStore(filepath.Join(t.TempDir(), "exports"))
+	ctx := context.Background()
+
+	size, err := store.Put(ctx, "cmd_1.zip", strings.NewReader("archive"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), size)
+
+	f, err := store.Open(ctx, "cmd_1.zip")
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(content))
+
+	_, err = store.Open(ctx, "cmd_2.zip")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestFileArchiveStore_RejectsKeysOutsideDirectory(t *testing.T) {
+	store := adapter.NewFileArchiveStore(t.TempDir())
+
+	for _, key := range []string{"../escape.zip", "nested/cmd_1.zip", ".hidden", ""} {
+		_, err := store.Put(context.Background(), key, strings.NewReader("x"))
+		assert.Error(t, err, key)
+	}
+}
+
+func TestFileArchiveStore_DeleteBefore(t *testing.T) {
+	dir := t.TempDir()
+	store := adapter.NewFileArchiveStore(dir)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, key := range []string{"cmd_old.zip", "cmd_new.zip"} {
+		_, err := store.Put(ctx, key, strings.NewReader("archive"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "cmd_old.zip"), now.Add(-48*time.Hour), now.Add(-48*time.Hour)))
+
+	deleted, err := store.DeleteBefore(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = store.Open(ctx, "cmd_old.zip")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+	f, err := store.Open(ctx, "cmd_new.zip")
+	require.NoError(t, err)
+	f.Close()
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/portability/domain"
+)
+
+// PurgeArchivesCommand deletes the archives of data exports kept long enough for users to download them
+type PurgeArchivesCommand struct{}
+
+type PurgeArchivesHandler struct {
+	Archives domain.ArchiveStore
+	// Retention is how long an archive stays available after it was written
+	Retention time.Duration
+	Now       func() time.Time
+}
+
+func (h *PurgeArchivesHandler) Handle(ctx context.Context, cmd PurgeArchivesCommand) error {
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	deleted, err := h.Archives.DeleteBefore(ctx, now().Add(-h.Retention))
+	if err != nil {
+		return fmt.Errorf("delete data export archives: %w", err)
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "purged data export archives", "count", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package command
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/portability/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	reviewDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/export"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// exportBatchSize is the number of orders or reviews read and written into the archive at a time
+const exportBatchSize = 500
+
+// ExportUserDataCommand assembles everything stored about a user into a zip archive kept under Key
+type ExportUserDataCommand struct {
+	UserID int64         `validate:"required"`
+	Key    string        `validate:"required"`
+	Format export.Format `validate:"required"`
+}
+
+// ExportUserDataHandler writes profile, addresses, orders and reviews files in the format of the
+// command. The archive is streamed into the store as it is written, so memory stays flat however
+// many orders the user placed.
+type ExportUserDataHandler struct {
+	Users     userDomain.UserRepository
+	Addresses userDomain.AddressRepository
+	Orders    orderDomain.OrderRepository
+	Reviews   reviewDomain.ReviewRepository
+	// Products name the reviewed products
+	Products productDomain.ProductRepository
+	Archives domain.ArchiveStore
+	Now      func() time.Time
+}
+
+func (h *ExportUserDataHandler) Handle(ctx context.Context, cmd ExportUserDataCommand) (*domain.Archive, error) {
+	u, err := h.Users.GetByID(ctx, cmd.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("get user %d: %w", cmd.UserID, err)
+	}
+	addresses, err := h.Addresses.ListByUser(ctx, u.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list addresses of user %d: %w", u.ID, err)
+	}
+
+	archive := &domain.Archive{Key: cmd.Key}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(h.writeArchive(ctx, pw, cmd.Format, u, addresses, archive))
+	}()
+	size, err := h.Archives.Put(ctx, cmd.Key, pr)
+	// Unblock the writer when the store gave up before reading everything
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return nil, fmt.Errorf("store data export %s: %w", cmd.Key, err)
+	}
+	archive.Size = size
+	return archive, nil
+}
+
+// writeArchive writes the zip archive to w, recording the names of its files in archive
+func (h *ExportUserDataHandler) writeArchive(ctx context.Context, w io.Writer, format export.Format, u *userDomain.User, addresses []userDomain.Address, archive *domain.Archive) error {
+	zw := zip.NewWriter(w)
+	modified := h.now()
+	create := func(name string) (io.Writer, error) {
+		name += "." + format.Extension()
+		archive.Files = append(archive.Files, name)
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	}
+
+	f, err := create("profile")
+	if err != nil {
+		return err
+	}
+	profile := export.NewWriter[domain.ProfileRecord](f, format)
+	if err := profile.Write(toProfileRecord(u)); err != nil {
+		return err
+	}
+	if err := profile.Flush(); err != nil {
+		return err
+	}
+
+	f, err = create("addresses")
+	if err != nil {
+		return err
+	}
+	addressRows := export.NewWriter[domain.AddressRecord](f, format)
+	addressIDs := make(map[int64]string, len(addresses))
+	for i := range addresses {
+		addressIDs[addresses[i].ID] = addresses[i].PublicID
+		if err := addressRows.Write(toAddressRecord(&addresses[i])); err != nil {
+			return err
+		}
+	}
+	if err := addressRows.Flush(); err != nil {
+		return err
+	}
+
+	f, err = create("orders")
+	if err != nil {
+		return err
+	}
+	orderRows := export.NewWriter[domain.OrderRecord](f, format)
+	err = h.Orders.FindInBatches(ctx, orderDomain.OrderFilter{UserID: u.ID}, exportBatchSize, func(orders []orderDomain.Order) error {
+		for i := range orders {
+			if err := orderRows.Write(toOrderRecord(&orders[i], addressIDs)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("export orders of user %d: %w", u.ID, err)
+	}
+	if err := orderRows.Flush(); err != nil {
+		return err
+	}
+
+	f, err = create("reviews")
+	if err != nil {
+		return err
+	}
+	if err := h.writeReviews(ctx, export.NewWriter[domain.ReviewRecord](f, format), u.ID); err != nil {
+		return fmt.Errorf("export reviews of user %d: %w", u.ID, err)
+	}
+	return zw.Close()
+}
+
+// writeReviews writes every review of the user, whatever its moderation status, a page at a time
+func (h *ExportUserDataHandler) writeReviews(ctx context.Context, rows *export.Writer[domain.ReviewRecord], userID int64) error {
+	for page := 1; ; page++ {
+		reviews, _, err := h.Reviews.Search(ctx, reviewDomain.ReviewFilter{UserID: userID}, page, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		productIDs := make([]int64, len(reviews))
+		for i := range reviews {
+			productIDs[i] = reviews[i].ProductID
+		}
+		products, err := h.Products.GetByIDs(ctx, productIDs)
+		if err != nil {
+			return err
+		}
+		byID := make(map[int64]*productDomain.Product, len(products))
+		for i := range products {
+			byID[products[i].ID] = &products[i]
+		}
+		for i := range reviews {
+			if err := rows.Write(toReviewRecord(&reviews[i], byID[reviews[i].ProductID])); err != nil {
+				return err
+			}
+		}
+		if len(reviews) < exportBatchSize {
+			return rows.Flush()
+		}
+	}
+}
+
+func (h *ExportUserDataHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+func toProfileRecord(u *userDomain.User) domain.ProfileRecord {
+	return domain.ProfileRecord{
+		ID:              u.PublicID,
+		Email:           u.Email.String(),
+		FirstName:       u.FirstName,
+		LastName:        u.LastName,
+		Phone:           u.Phone.String(),
+		Active:          u.Active,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		DeactivatedAt:   u.DeactivatedAt,
+	}
+}
+
+func toAddressRecord(a *userDomain.Address) domain.AddressRecord {
+	record := domain.AddressRecord{
+		ID:         a.PublicID,
+		Label:      a.Label,
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone.String(),
+		CreatedAt:  a.CreatedAt,
+	}
+	if p := a.Point(); p != nil {
+		record.Latitude, record.Longitude = &p.Lat, &p.Lng
+	}
+	return record
+}
+
+func toReviewRecord(r *reviewDomain.Review, p *productDomain.Product) domain.ReviewRecord {
+	record := domain.ReviewRecord{
+		ID:               r.PublicID,
+		Rating:           r.Rating,
+		Text:             r.Text,
+		VerifiedPurchase: r.VerifiedPurchase,
+		Status:           r.Status,
+		CreatedAt:        r.CreatedAt,
+	}
+	if p != nil {
+		record.ProductID, record.ProductName = p.PublicID, p.Name
+	}
+	return record
+}
+
+func toOrderRecord(o *orderDomain.Order, addressIDs map[int64]string) domain.OrderRecord {
+	record := domain.OrderRecord{
+		ID:          o.PublicID,
+		Number:      o.Number,
+		ProductID:   o.Product.PublicID,
+		ProductName: o.Product.Name,
+		Quantity:    o.Quantity.Int(),
+		Status:      o.Status,
+		UnitPrice:   o.UnitPrice.Amount,
+		Total:       o.Total().Amount,
+		Currency:    o.UnitPrice.Currency,
+	}
+	if o.ShippingAddressID != nil {
+		record.ShippingAddressID = addressIDs[*o.ShippingAddressID]
+	}
+	if !o.CreatedAt.IsZero() {
+		record.CreatedAt = &o.CreatedAt
+	}
+	return record
+}
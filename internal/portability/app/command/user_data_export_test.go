@@ -0,0 +1,211 @@
+package command
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	reviewDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/export"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUserRepository struct {
+	userDomain.UserRepository
+	user *userDomain.User
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*userDomain.User, error) {
+	return m.user, nil
+}
+
+type MockAddressRepository struct {
+	userDomain.AddressRepository
+	addresses []userDomain.Address
+}
+
+func (m *MockAddressRepository) ListByUser(ctx context.Context, userID int64) ([]userDomain.Address, error) {
+	return m.addresses, nil
+}
+
+// MockOrderRepository returns its orders in batches; filter records the filter of the last call
+type MockOrderRepository struct {
+	orderDomain.OrderRepository
+	orders []orderDomain.Order
+	filter orderDomain.OrderFilter
+	err    error
+}
+
+func (m *MockOrderRepository) FindInBatches(ctx context.Context, filter orderDomain.OrderFilter, size int, fn func([]orderDomain.Order) error) error {
+	m.filter = filter
+	if m.err != nil {
+		return m.err
+	}
+	for i := 0; i < len(m.orders); i += size {
+		if err := fn(m.orders[i:min(i+size, len(m.orders))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MockReviewRepository pages through its reviews; filter records the filter of the last call
+type MockReviewRepository struct {
+	reviewDomain.ReviewRepository
+	reviews []reviewDomain.Review
+	filter  reviewDomain.ReviewFilter
+}
+
+func (m *MockReviewRepository) Search(ctx context.Context, filter reviewDomain.ReviewFilter, page, pageSize int) ([]reviewDomain.Review, int64, error) {
+	m.filter = filter
+	from := min((page-1)*pageSize, len(m.reviews))
+	return m.reviews[from:min(from+pageSize, len(m.reviews))], int64(len(m.reviews)), nil
+}
+
+type MockProductRepository struct {
+	productDomain.ProductRepository
+	products []productDomain.Product
+}
+
+func (m *MockProductRepository) GetByIDs(ctx context.Context, ids []int64) ([]productDomain.Product, error) {
+	var products []productDomain.Product
+	for _, p := range m.products {
+		for _, id := range ids {
+			if p.ID == id {
+				products = append(products, p)
+				break
+			}
+		}
+	}
+	return products, nil
+}
+
+// MockArchiveStore keeps stored archives in memory
+type MockArchiveStore struct {
+	archives map[string][]byte
+}
+
+func (m *MockArchiveStore) Put(ctx context.Context, key string, content io.Reader) (int64, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, err
+	}
+	if m.archives == nil {
+		m.archives = make(map[string][]byte)
+	}
+	m.archives[key] = data
+	return int64(len(data)), nil
+}
+
+func (m *MockArchiveStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.archives[key])), nil
+}
+
+func (m *MockArchiveStore) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	return 0, nil
+}
+
+func newExportFixture() (*ExportUserDataHandler, *MockOrderRepository, *MockArchiveStore) {
+	placed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	addressID := int64(7)
+	orders := &MockOrderRepository{orders: []orderDomain.Order{{
+		PublicID:          "ord_1",
+		Number:            "20260301-000001",
+		UserID:            1,
+		Product:           productDomain.Product{PublicID: "prd_1", Name: "=Widget"},
+		Quantity:          2,
+		UnitPrice:         money.Money{Amount: 1250, Currency: "EUR"},
+		Status:            orderDomain.StatusDelivered,
+		ShippingAddressID: &addressID,
+		CreatedAt:         placed,
+	}}}
+	archives := &MockArchiveStore{}
+	handler := &ExportUserDataHandler{
+		Users: &MockUserRepository{user: &userDomain.User{
+			ID: 1, PublicID: "usr_1", Active: true, Email: "jane@example.com", FirstName: "Jane", LastName: "Doe",
+		}},
+		Addresses: &MockAddressRepository{addresses: []userDomain.Address{{
+			ID: addressID, PublicID: "adr_1", UserID: 1, CreatedAt: placed,
+			AddressDetails: userDomain.AddressDetails{Label: "Home", Address: address.Address{Line1: "1 Main St", City: "Berlin", PostalCode: "10115", Country: "DE"}},
+		}}},
+		Orders: orders,
+		Reviews: &MockReviewRepository{reviews: []reviewDomain.Review{{
+			PublicID: "rev_1", ProductID: 3, UserID: 1, Rating: 4, Text: "Sturdy", VerifiedPurchase: true,
+			Status: reviewDomain.StatusPending, CreatedAt: placed,
+		}}},
+		Products: &MockProductRepository{products: []productDomain.Product{{ID: 3, PublicID: "prd_1", Name: "=Widget"}}},
+		Archives: archives,
+		Now:      func() time.Time { return placed },
+	}
+	return handler, orders, archives
+}
+
+// readArchive returns the files of a zip archive by name
+func readArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestExportUserData_CSV(t *testing.T) {
+	handler, orders, archives := newExportFixture()
+
+	archive, err := handler.Handle(context.Background(), ExportUserDataCommand{UserID: 1, Key: "cmd_1.zip", Format: export.FormatCSV})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"profile.csv", "addresses.csv", "orders.csv", "reviews.csv"}, archive.Files)
+	assert.Equal(t, int64(len(archives.archives["cmd_1.zip"])), archive.Size)
+	assert.Equal(t, int64(1), orders.filter.UserID, "only the orders of the user are exported")
+	assert.Equal(t, int64(1), handler.Reviews.(*MockReviewRepository).filter.UserID, "only the reviews of the user are exported")
+
+	files := readArchive(t, archives.archives["cmd_1.zip"])
+	assert.Equal(t, "id,email,first_name,last_name,phone,active,email_verified_at,deactivated_at\n"+
+		"usr_1,jane@example.com,Jane,Doe,,true,,\n", files["profile.csv"])
+	assert.Equal(t, "id,label,name,line1,line2,city,region,postal_code,country,latitude,longitude,phone,created_at\n"+
+		"adr_1,Home,,1 Main St,,Berlin,,10115,DE,,,,2026-03-01T12:00:00Z\n", files["addresses.csv"])
+	assert.Equal(t, "id,number,product_id,product_name,quantity,status,unit_price,total,currency,shipping_address_id,created_at\n"+
+		"ord_1,20260301-000001,prd_1,'=Widget,2,DELIVERED,1250,2500,EUR,adr_1,2026-03-01T12:00:00Z\n", files["orders.csv"])
+	assert.Equal(t, "id,product_id,product_name,rating,text,verified_purchase,status,created_at\n"+
+		"rev_1,prd_1,'=Widget,4,Sturdy,true,pending,2026-03-01T12:00:00Z\n", files["reviews.csv"], "pending reviews are exported too")
+}
+
+func TestExportUserData_NDJSON(t *testing.T) {
+	handler, _, archives := newExportFixture()
+
+	archive, err := handler.Handle(context.Background(), ExportUserDataCommand{UserID: 1, Key: "cmd_1.zip", Format: export.FormatNDJSON})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"profile.ndjson", "addresses.ndjson", "orders.ndjson", "reviews.ndjson"}, archive.Files)
+	files := readArchive(t, archives.archives["cmd_1.zip"])
+	assert.JSONEq(t, `{"id":"usr_1","email":"jane@example.com","first_name":"Jane","last_name":"Doe","phone":"","active":true}`, files["profile.ndjson"])
+	assert.Contains(t, files["orders.ndjson"], `"product_name":"=Widget"`)
+}
+
+func TestExportUserData_OrderFailure(t *testing.T) {
+	handler, orders, _ := newExportFixture()
+	orders.err = errors.New("database unavailable")
+
+	_, err := handler.Handle(context.Background(), ExportUserDataCommand{UserID: 1, Key: "cmd_1.zip", Format: export.FormatCSV})
+
+	assert.ErrorContains(t, err, "database unavailable")
+}
@@ -0,0 +1,94 @@
+// Package domain describes the archives users download to take their data elsewhere
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	reviewDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+)
+
+var (
+	ErrExportNotFound = errors.New("data export not found")
+	// ErrExportNotReady is returned when downloading an export that is still running or failed
+	ErrExportNotReady = errors.New("data export is not ready")
+)
+
+// ArchiveStore keeps the archives of data exports until they expire
+type ArchiveStore interface {
+	// Put stores the content under key and returns its size in bytes
+	Put(ctx context.Context, key string, content io.Reader) (int64, error)
+	// Open returns persistence.ErrNotFound for missing and purged archives
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// DeleteBefore removes the archives stored before t and returns how many it removed
+	DeleteBefore(ctx context.Context, t time.Time) (int, error)
+}
+
+// Archive describes a stored export
+type Archive struct {
+	Key string
+	// Files are the names of the files in the archive, e.g. "orders.csv"
+	Files []string
+	Size  int64
+}
+
+// ProfileRecord is the line of profile.csv; IDs are public IDs
+type ProfileRecord struct {
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Phone           string     `json:"phone"`
+	Active          bool       `json:"active"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	DeactivatedAt   *time.Time `json:"deactivated_at,omitempty"`
+}
+
+// AddressRecord is a line of addresses.csv
+type AddressRecord struct {
+	ID         string    `json:"id"`
+	Label      string    `json:"label"`
+	Name       string    `json:"name"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2"`
+	City       string    `json:"city"`
+	Region     string    `json:"region"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country"`
+	Latitude   *float64  `json:"latitude,omitempty"`
+	Longitude  *float64  `json:"longitude,omitempty"`
+	Phone      string    `json:"phone"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrderRecord is a line of orders.csv
+type OrderRecord struct {
+	ID          string                  `json:"id"`
+	Number      string                  `json:"number"`
+	ProductID   string                  `json:"product_id"`
+	ProductName string                  `json:"product_name"`
+	Quantity    int                     `json:"quantity"`
+	Status      orderDomain.OrderStatus `json:"status"`
+	// UnitPrice and Total are in minor units of Currency
+	UnitPrice         int64  `json:"unit_price"`
+	Total             int64  `json:"total"`
+	Currency          string `json:"currency"`
+	ShippingAddressID string `json:"shipping_address_id"`
+	// CreatedAt is omitted for orders placed before it was recorded
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// ReviewRecord is a line of reviews.csv; pending and rejected reviews are included
+type ReviewRecord struct {
+	ID               string              `json:"id"`
+	ProductID        string              `json:"product_id"`
+	ProductName      string              `json:"product_name"`
+	Rating           int                 `json:"rating"`
+	Text             string              `json:"text"`
+	VerifiedPurchase bool                `json:"verified_purchase"`
+	Status           reviewDomain.Status `json:"status"`
+	CreatedAt        time.Time           `json:"created_at"`
+}
@@ -0,0 +1,178 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	asyncDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/async/domain"
+	asyncPort "github.com/mohsenjafari-aiio/aiiobackend/internal/async/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/portability/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/portability/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/export"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// DataExportResponse is the result of a data export command once the archive is ready
+type DataExportResponse struct {
+	// DownloadURL serves the zip archive until ExpiresAt
+	DownloadURL string        `json:"download_url"`
+	Format      export.Format `json:"format"`
+	Files       []string      `json:"files"`
+	Size        int64         `json:"size"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+}
+
+// HTTPServer lets users export the data stored about them
+type HTTPServer struct {
+	ExportUserData decorator.CommandResultHandler[command.ExportUserDataCommand, *domain.Archive]
+	// Async accepts exports as commands clients poll at /commands/{id}
+	Async    *asyncPort.Runner
+	Commands asyncDomain.CommandRepository
+	Archives domain.ArchiveStore
+	// Retention is how long an archive can be downloaded after it was written
+	Retention time.Duration
+
+	// Auth requires user:read:own, or user:read:any to download the exports of others; nil
+	// disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the data export endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/me/export",
+		Summary:  "Start exporting the profile, addresses and orders of the caller as a zip archive of CSV (default) or ?format=ndjson files",
+		Tags:     []string{"users"},
+		Response: asyncPort.CommandResponse{},
+		Status:   http.StatusAccepted,
+		Handler:  s.startExport,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/me/exports/{id}",
+		Summary:  "Download the zip archive of a finished data export",
+		Tags:     []string{"users"},
+		Handler:  s.downloadExport,
+		StringID: true,
+	})
+}
+
+func (s *HTTPServer) startExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		auth.WriteError(w, auth.ErrUnauthenticated)
+		return
+	}
+	if err := auth.Check(r.Context(), s.Auth, userDomain.PermissionUserReadOwn); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+	format, err := export.ParseFormat(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	c, err := s.Async.Accept(r.Context(), userID, func(commandID int64) jobs.Job {
+		return ExportUserDataJob{CommandID: commandID, Format: format}
+	})
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	asyncPort.WriteAccepted(w, c)
+}
+
+// writeExport assembles the archive of the export command of job, keyed by the public ID of the
+// command, and answers with where to download it
+func (s *HTTPServer) writeExport(ctx context.Context, w http.ResponseWriter, job ExportUserDataJob) {
+	c, err := s.Commands.GetByID(ctx, job.CommandID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	archive, err := s.ExportUserData.Handle(ctx, command.ExportUserDataCommand{
+		UserID: c.OwnerID,
+		Key:    archiveKey(c),
+		Format: job.Format,
+	})
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, DataExportResponse{
+		DownloadURL: "/me/exports/" + c.PublicID,
+		Format:      job.Format,
+		Files:       archive.Files,
+		Size:        archive.Size,
+		ExpiresAt:   time.Now().Add(s.Retention).UTC(),
+	})
+}
+
+func (s *HTTPServer) downloadExport(w http.ResponseWriter, r *http.Request) {
+	c, err := s.Commands.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err == nil && (c.TenantID != tenant.FromContext(r.Context()) || c.Kind != (ExportUserDataJob{}).Kind()) {
+		err = domain.ErrExportNotFound
+	}
+	if errors.Is(err, asyncDomain.ErrCommandNotFound) {
+		err = domain.ErrExportNotFound
+	}
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	if err := auth.CheckOwned(r.Context(), s.Auth, userDomain.PermissionUserReadAny, userDomain.PermissionUserReadOwn, c.OwnerID); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+	if c.Status != asyncDomain.CommandSucceeded {
+		writeExportError(w, domain.ErrExportNotReady)
+		return
+	}
+
+	f, err := s.Archives.Open(r.Context(), archiveKey(c))
+	if errors.Is(err, persistence.ErrNotFound) {
+		err = domain.ErrExportNotFound
+	}
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "user-data-" + c.PublicID + ".zip"}))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, f); err != nil {
+		slog.WarnContext(r.Context(), "data export download aborted", "command_id", c.PublicID, "error", err)
+	}
+}
+
+// writeExportError maps the errors of data exports onto HTTP status codes
+func writeExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrExportNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrExportNotReady):
+		httpx.WriteErrorStatus(w, http.StatusConflict, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
+
+// archiveKey is where the archive of an export command is stored
+func archiveKey(c *asyncDomain.Command) string {
+	return c.PublicID + ".zip"
+}
@@ -0,0 +1,50 @@
+package port
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/portability/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/export"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// ExportUserDataJob assembles the archive of an export accepted with 202; the command with
+// CommandID receives the DataExportResponse
+type ExportUserDataJob struct {
+	CommandID int64         `json:"command_id"`
+	Format    export.Format `json:"format"`
+}
+
+func (ExportUserDataJob) Kind() string {
+	return "portability.export_user_data"
+}
+
+// RegisterJobs adds the handler of exports accepted with 202 to the worker; it needs Async
+func (s *HTTPServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, job ExportUserDataJob) error {
+		return s.Async.Run(ctx, job.CommandID, func(ctx context.Context, w http.ResponseWriter) {
+			s.writeExport(ctx, w, job)
+		})
+	})
+}
+
+// PurgeArchivesJob deletes the archives of data exports past their retention
+type PurgeArchivesJob struct{}
+
+func (PurgeArchivesJob) Kind() string {
+	return "portability.purge_archives"
+}
+
+// JobServer runs the data export use cases triggered by scheduled jobs
+type JobServer struct {
+	PurgeArchives decorator.CommandHandler[command.PurgeArchivesCommand]
+}
+
+// RegisterJobs adds the data export job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, _ PurgeArchivesJob) error {
+		return s.PurgeArchives.Handle(ctx, command.PurgeArchivesCommand{})
+	})
+}
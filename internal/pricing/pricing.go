@@ -0,0 +1,376 @@
+// Package pricing computes what an order costs: the subtotal of its lines less any discount, the
+// tax due at the rate of its destination and of the class of each product, and the shipping fee
+// of the destination. Prices are net of tax, tax is due on the discounted amount and shipping is
+// not taxed.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// AnyRegion is the region of rules applying to every destination without a rule of its own
+const AnyRegion = "*"
+
+// MaxRate is 100%
+const MaxRate Rate = 10000
+
+// Rate is a tax rate in basis points, hundredths of a percent: 1900 is 19% and 725 is 7.25%
+type Rate int64
+
+// ParseRate reads a percentage with up to two decimal places, e.g. "19" or "7.25"
+func ParseRate(percent string) (Rate, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(percent), ".")
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("rate %q has more than 2 decimal places", percent)
+	}
+	digits := whole + fraction + strings.Repeat("0", 2-len(fraction))
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || whole == "" || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") || Rate(n) > MaxRate {
+		return 0, fmt.Errorf("rate %q must be a percentage between 0 and 100", percent)
+	}
+	return Rate(n), nil
+}
+
+// Percent formats the rate as a percentage without trailing zeros, e.g. "19" or "7.25"
+func (r Rate) Percent() string {
+	s := fmt.Sprintf("%d.%02d", r/100, r%100)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// Of returns the tax at rate r on amount, rounded to the minor unit by mode
+func (r Rate) Of(amount money.Money, mode money.RoundingMode) money.Money {
+	return amount.MulRatio(int64(r), int64(MaxRate), mode)
+}
+
+// Destination is where an order ships
+type Destination struct {
+	// Country is an ISO 3166-1 alpha-2 code, e.g. "US"
+	Country string
+	// Region is the state or province within Country, e.g. "CA"; empty when unknown
+	Region string
+}
+
+// matches returns how closely a rule for region applies to d: 2 for its country and region,
+// e.g. "US-CA", 1 for its country, 0 for AnyRegion and -1 when it does not apply
+func (d Destination) matches(region string) int {
+	country := strings.ToUpper(d.Country)
+	switch {
+	case region == AnyRegion:
+		return 0
+	case region == country:
+		return 1
+	case d.Region != "" && region == country+"-"+strings.ToUpper(d.Region):
+		return 2
+	default:
+		return -1
+	}
+}
+
+// TaxRule is the rate of a region, e.g. "DE" or "US-CA", for products of Class; rules without
+// a class apply to every product of the region
+type TaxRule struct {
+	Region string
+	Class  string
+	Rate   Rate
+}
+
+// ShippingFee is the fee of orders shipping to Region
+type ShippingFee struct {
+	Region string
+	Fee    money.Money
+}
+
+// Line is a product of an order
+type Line struct {
+	UnitPrice money.Money
+	Quantity  int64
+	// Class is the tax class of the product, e.g. "reduced"; empty is the standard class
+	Class string
+}
+
+// Discount takes an amount off the subtotal of an order, e.g. a coupon
+type Discount interface {
+	// Off returns the amount taken off subtotal; more than subtotal takes off all of it
+	Off(subtotal money.Money) (money.Money, error)
+}
+
+// LineTotals is what a line costs before shipping
+type LineTotals struct {
+	Subtotal money.Money
+	// Discount is the share of the discount of the order taken off this line
+	Discount money.Money
+	TaxRate  Rate
+	Tax      money.Money
+}
+
+// Totals is what an order costs; all amounts are in the currency of its prices
+type Totals struct {
+	Subtotal money.Money `gorm:"type:varchar(32)"`
+	Discount money.Money `gorm:"type:varchar(32)"`
+	Tax      money.Money `gorm:"type:varchar(32)"`
+	Shipping money.Money `gorm:"type:varchar(32)"`
+	// Total is Subtotal - Discount + Tax + Shipping, the amount charged
+	Total money.Money `gorm:"type:varchar(32)"`
+}
+
+// IsZero reports whether no totals were calculated, as for orders of unpriced products
+func (t Totals) IsZero() bool {
+	return t.Total.Currency == ""
+}
+
+// Convert converts every part of the totals with convert, e.g. into another currency, and adds
+// the converted parts up again, so the converted total always matches its breakdown
+func (t Totals) Convert(convert func(money.Money) (money.Money, error)) (Totals, error) {
+	var converted Totals
+	for _, part := range []struct{ from, to *money.Money }{
+		{&t.Subtotal, &converted.Subtotal},
+		{&t.Discount, &converted.Discount},
+		{&t.Tax, &converted.Tax},
+		{&t.Shipping, &converted.Shipping},
+	} {
+		var err error
+		if *part.to, err = convert(*part.from); err != nil {
+			return Totals{}, err
+		}
+	}
+	net, err := converted.Subtotal.Sub(converted.Discount)
+	if err != nil {
+		return Totals{}, err
+	}
+	if converted.Total, err = money.Sum(net, converted.Tax, converted.Shipping); err != nil {
+		return Totals{}, err
+	}
+	return converted, nil
+}
+
+// Quote is the breakdown of what an order costs
+type Quote struct {
+	Lines  []LineTotals
+	Totals Totals
+}
+
+// Engine prices orders by its rules. The zero Engine charges neither tax nor shipping.
+type Engine struct {
+	TaxRules     []TaxRule
+	ShippingFees []ShippingFee
+	// FreeShippingFrom waives the shipping fee of orders whose subtotal is at least this much;
+	// the zero Money never waives it
+	FreeShippingFrom money.Money
+	// Rounding rounds the tax of each line to the minor unit of its currency
+	Rounding money.RoundingMode
+	// Converter converts the fees into the currency of orders priced in another one; without
+	// it such orders fail with money.ErrCurrencyMismatch
+	Converter money.CurrencyConverter
+}
+
+// Quote prices the lines of an order shipping to dest, less discount unless it is nil. The lines
+// must share a currency.
+func (e *Engine) Quote(ctx context.Context, dest Destination, discount Discount, lines ...Line) (Quote, error) {
+	var q Quote
+	var subtotal money.Money
+	var err error
+	for _, line := range lines {
+		lt := LineTotals{Subtotal: line.UnitPrice.Mul(line.Quantity), TaxRate: e.TaxRate(dest, line.Class)}
+		if subtotal, err = subtotal.Add(lt.Subtotal); err != nil {
+			return Quote{}, err
+		}
+		q.Lines = append(q.Lines, lt)
+	}
+
+	off := money.Money{Currency: subtotal.Currency}
+	if discount != nil && subtotal.Amount > 0 {
+		if off, err = discount.Off(subtotal); err != nil {
+			return Quote{}, err
+		}
+		if off.Currency != subtotal.Currency {
+			return Quote{}, fmt.Errorf("%w: discount in %s for prices in %s", money.ErrCurrencyMismatch, off.Currency, subtotal.Currency)
+		}
+		off.Amount = min(max(off.Amount, 0), subtotal.Amount)
+	}
+	spread(q.Lines, off, subtotal)
+
+	var tax money.Money
+	for i := range q.Lines {
+		lt := &q.Lines[i]
+		lt.Tax = lt.TaxRate.Of(money.Money{Amount: lt.Subtotal.Amount - lt.Discount.Amount, Currency: lt.Subtotal.Currency}, e.Rounding)
+		if tax, err = tax.Add(lt.Tax); err != nil {
+			return Quote{}, err
+		}
+	}
+
+	net := money.Money{Amount: subtotal.Amount - off.Amount, Currency: subtotal.Currency}
+	shipping, err := e.shipping(ctx, dest, net)
+	if err != nil {
+		return Quote{}, err
+	}
+	total, err := money.Sum(net, tax, shipping)
+	if err != nil {
+		return Quote{}, err
+	}
+	q.Totals = Totals{Subtotal: subtotal, Discount: off, Tax: tax, Shipping: shipping, Total: total}
+	return q, nil
+}
+
+// spread shares the discount off out over lines by their share of subtotal, so each line is taxed
+// on what is charged for it. The largest line takes what rounding leaves over.
+func spread(lines []LineTotals, off, subtotal money.Money) {
+	largest, left := 0, off.Amount
+	for i := range lines {
+		lines[i].Discount = money.Money{Currency: off.Currency}
+		if subtotal.Amount > 0 {
+			lines[i].Discount = off.MulRatio(lines[i].Subtotal.Amount, subtotal.Amount, money.RoundDown)
+		}
+		left -= lines[i].Discount.Amount
+		if lines[i].Subtotal.Amount > lines[largest].Subtotal.Amount {
+			largest = i
+		}
+	}
+	if len(lines) > 0 {
+		lines[largest].Discount.Amount += left
+	}
+}
+
+// TaxRate returns the rate of the most specific rule for dest and class: a rule for the region
+// of dest before one for its country before one for AnyRegion, and within those a rule for class
+// before one for every class. Destinations without a rule are not taxed.
+func (e *Engine) TaxRate(dest Destination, class string) Rate {
+	best, rate := -1, Rate(0)
+	for _, rule := range e.TaxRules {
+		score := dest.matches(rule.Region) * 2
+		if score < 0 || (rule.Class != "" && rule.Class != class) {
+			continue
+		}
+		if rule.Class != "" {
+			score++
+		}
+		if score > best {
+			best, rate = score, rule.Rate
+		}
+	}
+	return rate
+}
+
+// shipping returns the fee of the most specific rule for dest in the currency of subtotal, the
+// amount charged for the lines after discounts
+func (e *Engine) shipping(ctx context.Context, dest Destination, subtotal money.Money) (money.Money, error) {
+	best := -1
+	var fee money.Money
+	for _, rule := range e.ShippingFees {
+		if score := dest.matches(rule.Region); score > best {
+			best, fee = score, rule.Fee
+		}
+	}
+	zero := money.Money{Currency: subtotal.Currency}
+	if fee.IsZero() {
+		return zero, nil
+	}
+
+	fee, err := e.convert(ctx, fee, subtotal.Currency)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("shipping fee: %w", err)
+	}
+	if !e.FreeShippingFrom.IsZero() {
+		threshold, err := e.convert(ctx, e.FreeShippingFrom, subtotal.Currency)
+		if err != nil {
+			return money.Money{}, fmt.Errorf("free shipping threshold: %w", err)
+		}
+		if subtotal.Amount >= threshold.Amount {
+			return zero, nil
+		}
+	}
+	return fee, nil
+}
+
+func (e *Engine) convert(ctx context.Context, m money.Money, currency string) (money.Money, error) {
+	if m.Currency == currency {
+		return m, nil
+	}
+	if e.Converter == nil {
+		return money.Money{}, fmt.Errorf("%w: %s for prices in %s", money.ErrCurrencyMismatch, m.Currency, currency)
+	}
+	return e.Converter.Convert(ctx, m, currency)
+}
+
+var regionPattern = regexp.MustCompile(`^(\*|[A-Z]{2}(-[A-Z0-9]{1,3})?)$`)
+
+// ErrInvalidClass is returned for tax class names that are not lowercase letters, digits, "_" and "-"
+var ErrInvalidClass = errors.New("tax class must be 1 to 32 lowercase letters, digits, _ or -")
+
+var classPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// ValidateClass checks the name of a tax class; the empty standard class is valid
+func ValidateClass(class string) error {
+	if class != "" && !classPattern.MatchString(class) {
+		return ErrInvalidClass
+	}
+	return nil
+}
+
+// ParseTaxRules parses comma-separated region[/class]=percent entries such as
+// "*=0,DE=19,DE/reduced=7,US-CA=7.25"
+func ParseTaxRules(spec string) ([]TaxRule, error) {
+	var rules []TaxRule
+	for _, entry := range entries(spec) {
+		key, percent, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("tax rate %q: expected region[/class]=percent", entry)
+		}
+		region, class, _ := strings.Cut(strings.TrimSpace(key), "/")
+		rule := TaxRule{Region: strings.ToUpper(region), Class: class}
+		if !regionPattern.MatchString(rule.Region) {
+			return nil, fmt.Errorf("tax rate %q: region must be *, a country such as DE or a country and region such as US-CA", entry)
+		}
+		if err := ValidateClass(rule.Class); err != nil {
+			return nil, fmt.Errorf("tax rate %q: %w", entry, err)
+		}
+		rate, err := ParseRate(percent)
+		if err != nil {
+			return nil, fmt.Errorf("tax rate %q: %w", entry, err)
+		}
+		rule.Rate = rate
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseShippingFees parses comma-separated region=amount entries such as "*=9.90,DE=4.90",
+// with the amounts in the major unit of currency
+func ParseShippingFees(spec, currency string) ([]ShippingFee, error) {
+	var fees []ShippingFee
+	for _, entry := range entries(spec) {
+		region, amount, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("shipping fee %q: expected region=amount", entry)
+		}
+		fee := ShippingFee{Region: strings.ToUpper(strings.TrimSpace(region))}
+		if !regionPattern.MatchString(fee.Region) {
+			return nil, fmt.Errorf("shipping fee %q: region must be *, a country such as DE or a country and region such as US-CA", entry)
+		}
+		var err error
+		if fee.Fee, err = money.Parse(amount, currency); err != nil {
+			return nil, fmt.Errorf("shipping fee %q: %w", entry, err)
+		}
+		if fee.Fee.Amount < 0 {
+			return nil, fmt.Errorf("shipping fee %q: must not be negative", entry)
+		}
+		fees = append(fees, fee)
+	}
+	return fees, nil
+}
+
+func entries(spec string) []string {
+	var entries []string
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,179 @@
+package pricing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eur(amount int64) money.Money {
+	return money.Money{Amount: amount, Currency: "EUR"}
+}
+
+// fixedConverter converts at a fixed ratio
+type fixedConverter struct{ num, den int64 }
+
+func (c fixedConverter) Convert(ctx context.Context, m money.Money, to string) (money.Money, error) {
+	converted := m.MulRatio(c.num, c.den, money.RoundHalfUp)
+	converted.Currency = to
+	return converted, nil
+}
+
+func newEngine(t *testing.T) *pricing.Engine {
+	t.Helper()
+	rules, err := pricing.ParseTaxRules("*=0, DE=19, DE/reduced=7, US=0, US-CA=7.25, us/books=1")
+	require.NoError(t, err)
+	fees, err := pricing.ParseShippingFees("*=9.90,DE=4.90,US-CA=0", "EUR")
+	require.NoError(t, err)
+	return &pricing.Engine{TaxRules: rules, ShippingFees: fees, FreeShippingFrom: eur(5000)}
+}
+
+func TestEngine_TaxRate(t *testing.T) {
+	engine := newEngine(t)
+
+	tests := []struct {
+		dest  pricing.Destination
+		class string
+		want  pricing.Rate
+	}{
+		{pricing.Destination{Country: "DE"}, "", 1900},
+		{pricing.Destination{Country: "DE", Region: "BY"}, "reduced", 700},
+		{pricing.Destination{Country: "DE"}, "books", 1900},
+		{pricing.Destination{Country: "US", Region: "CA"}, "", 725},
+		{pricing.Destination{Country: "us", Region: "ca"}, "books", 725},
+		{pricing.Destination{Country: "US", Region: "NY"}, "books", 100},
+		{pricing.Destination{Country: "FR"}, "", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, engine.TaxRate(tt.dest, tt.class), "%+v %q", tt.dest, tt.class)
+	}
+}
+
+func TestEngine_Quote(t *testing.T) {
+	engine := newEngine(t)
+
+	q, err := engine.Quote(context.Background(), pricing.Destination{Country: "DE"}, nil,
+		pricing.Line{UnitPrice: eur(999), Quantity: 2},
+		pricing.Line{UnitPrice: eur(1050), Quantity: 1, Class: "reduced"},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, []pricing.LineTotals{
+		{Subtotal: eur(1998), Discount: eur(0), TaxRate: 1900, Tax: eur(380)},
+		{Subtotal: eur(1050), Discount: eur(0), TaxRate: 700, Tax: eur(74)},
+	}, q.Lines)
+	assert.Equal(t, pricing.Totals{Subtotal: eur(3048), Discount: eur(0), Tax: eur(454), Shipping: eur(490), Total: eur(3992)}, q.Totals)
+}
+
+// fixedDiscount takes a fixed amount off
+type fixedDiscount money.Money
+
+func (d fixedDiscount) Off(subtotal money.Money) (money.Money, error) {
+	return money.Money(d), nil
+}
+
+func TestEngine_QuoteDiscount(t *testing.T) {
+	engine := newEngine(t)
+	ctx := context.Background()
+
+	q, err := engine.Quote(ctx, pricing.Destination{Country: "DE"}, fixedDiscount(eur(1001)),
+		pricing.Line{UnitPrice: eur(999), Quantity: 2},
+		pricing.Line{UnitPrice: eur(1050), Quantity: 1, Class: "reduced"},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, []pricing.LineTotals{
+		{Subtotal: eur(1998), Discount: eur(657), TaxRate: 1900, Tax: eur(255)},
+		{Subtotal: eur(1050), Discount: eur(344), TaxRate: 700, Tax: eur(49)},
+	}, q.Lines, "the discount is shared by the lines and taxed at their rates")
+	assert.Equal(t, pricing.Totals{Subtotal: eur(3048), Discount: eur(1001), Tax: eur(304), Shipping: eur(490), Total: eur(2841)}, q.Totals)
+
+	q, err = engine.Quote(ctx, pricing.Destination{Country: "FR"}, fixedDiscount(eur(2000)), pricing.Line{UnitPrice: eur(1500), Quantity: 1})
+	require.NoError(t, err)
+	assert.Equal(t, pricing.Totals{Subtotal: eur(1500), Discount: eur(1500), Tax: eur(0), Shipping: eur(990), Total: eur(990)}, q.Totals,
+		"the discount takes off at most the subtotal")
+
+	_, err = engine.Quote(ctx, pricing.Destination{Country: "FR"}, fixedDiscount(money.Money{Amount: 100, Currency: "USD"}), pricing.Line{UnitPrice: eur(1500), Quantity: 1})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestTotals_Convert(t *testing.T) {
+	totals := pricing.Totals{Subtotal: eur(3048), Discount: eur(1001), Tax: eur(304), Shipping: eur(490), Total: eur(2841)}
+
+	converted, err := totals.Convert(func(m money.Money) (money.Money, error) {
+		return fixedConverter{num: 11, den: 10}.Convert(context.Background(), m, "USD")
+	})
+
+	require.NoError(t, err)
+	usd := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "USD"} }
+	assert.Equal(t, pricing.Totals{Subtotal: usd(3353), Discount: usd(1101), Tax: usd(334), Shipping: usd(539), Total: usd(3125)}, converted)
+}
+
+func TestEngine_QuoteShipping(t *testing.T) {
+	engine := newEngine(t)
+	ctx := context.Background()
+
+	q, err := engine.Quote(ctx, pricing.Destination{Country: "DE"}, nil, pricing.Line{UnitPrice: eur(5000), Quantity: 1})
+	require.NoError(t, err)
+	assert.Equal(t, eur(0), q.Totals.Shipping, "free from FreeShippingFrom")
+
+	q, err = engine.Quote(ctx, pricing.Destination{Country: "US", Region: "CA"}, nil, pricing.Line{UnitPrice: eur(1000), Quantity: 1})
+	require.NoError(t, err)
+	assert.Equal(t, eur(0), q.Totals.Shipping, "a zero fee overrides the fee of everywhere else")
+	assert.Equal(t, eur(1073), q.Totals.Total)
+
+	q, err = engine.Quote(ctx, pricing.Destination{Country: "FR"}, nil, pricing.Line{UnitPrice: eur(1000), Quantity: 1})
+	require.NoError(t, err)
+	assert.Equal(t, eur(990), q.Totals.Shipping)
+}
+
+func TestEngine_QuoteConvertsFees(t *testing.T) {
+	engine := newEngine(t)
+	usd := money.Money{Amount: 1000, Currency: "USD"}
+
+	_, err := engine.Quote(context.Background(), pricing.Destination{Country: "FR"}, nil, pricing.Line{UnitPrice: usd, Quantity: 1})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	engine.Converter = fixedConverter{num: 11, den: 10}
+	q, err := engine.Quote(context.Background(), pricing.Destination{Country: "FR"}, nil, pricing.Line{UnitPrice: usd, Quantity: 1})
+	require.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 1089, Currency: "USD"}, q.Totals.Shipping)
+}
+
+func TestZeroEngine(t *testing.T) {
+	q, err := (&pricing.Engine{}).Quote(context.Background(), pricing.Destination{Country: "DE"}, nil, pricing.Line{UnitPrice: eur(1250), Quantity: 3})
+
+	require.NoError(t, err)
+	assert.Equal(t, pricing.Totals{Subtotal: eur(3750), Discount: eur(0), Tax: eur(0), Shipping: eur(0), Total: eur(3750)}, q.Totals)
+}
+
+func TestParseRate(t *testing.T) {
+	for percent, want := range map[string]pricing.Rate{"19": 1900, "7.25": 725, "0": 0, "100": 10000, "0.5": 50} {
+		rate, err := pricing.ParseRate(percent)
+		require.NoError(t, err, percent)
+		assert.Equal(t, want, rate, percent)
+		assert.Equal(t, percent, rate.Percent())
+	}
+	for _, percent := range []string{"", "-1", "100.01", "7.255", "abc", ".5"} {
+		_, err := pricing.ParseRate(percent)
+		assert.Error(t, err, percent)
+	}
+}
+
+func TestParseTaxRules_Invalid(t *testing.T) {
+	for _, spec := range []string{"DE", "Germany=19", "DE/Reduced=7", "DE=120"} {
+		_, err := pricing.ParseTaxRules(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestParseShippingFees_Invalid(t *testing.T) {
+	for _, spec := range []string{"DE", "DE=4.999", "DE=-1", "Europe=4"} {
+		_, err := pricing.ParseShippingFees(spec, "EUR")
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,245 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+)
+
+// ElasticsearchIndex keeps the product documents in an Elasticsearch index, or an OpenSearch one,
+// through its REST API
+type ElasticsearchIndex struct {
+	baseURL string
+	index   string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewElasticsearchIndex uses the index named index at baseURL, e.g. http://localhost:9200; an
+// empty apiKey sends requests without authentication
+func NewElasticsearchIndex(baseURL, index, apiKey string, client *http.Client) *ElasticsearchIndex {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ElasticsearchIndex{baseURL: strings.TrimSuffix(baseURL, "/"), index: index, apiKey: apiKey, client: client}
+}
+
+// elasticsearchMappings types the fields searches filter on as keywords, so tenants and SKUs
+// match as a whole
+var elasticsearchMappings = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			"tenant_id":      map[string]any{"type": "keyword"},
+			"name":           map[string]any{"type": "text"},
+			"sku":            map[string]any{"type": "keyword"},
+			"price_amount":   map[string]any{"type": "long"},
+			"price_currency": map[string]any{"type": "keyword"},
+		},
+	},
+}
+
+// Describe and Ensure let the bootstrap registry create the index
+func (x *ElasticsearchIndex) Describe() string {
+	return "elasticsearch index " + x.index
+}
+
+// Ensure creates the index with its mappings unless it exists
+func (x *ElasticsearchIndex) Ensure(ctx context.Context) (bool, error) {
+	body, err := json.Marshal(elasticsearchMappings)
+	if err != nil {
+		return false, err
+	}
+	status, resp, err := x.do(ctx, http.MethodPut, "/"+url.PathEscape(x.index), "application/json", body)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusBadRequest && strings.Contains(string(resp), "resource_already_exists_exception") {
+		return false, nil
+	}
+	if err := x.check("create index", status, resp); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (x *ElasticsearchIndex) Index(ctx context.Context, docs []domain.SearchDocument) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		if err := enc.Encode(map[string]any{"index": map[string]string{"_id": doc.ID}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return x.bulk(ctx, "index", body.Bytes())
+}
+
+func (x *ElasticsearchIndex) Update(ctx context.Context, docs []domain.SearchDocument) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		if err := enc.Encode(map[string]any{"update": map[string]string{"_id": doc.ID}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(map[string]any{"doc": doc, "doc_as_upsert": true}); err != nil {
+			return err
+		}
+	}
+	return x.bulk(ctx, "update", body.Bytes())
+}
+
+func (x *ElasticsearchIndex) Delete(ctx context.Context, ids []string) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, id := range ids {
+		if err := enc.Encode(map[string]any{"delete": map[string]string{"_id": id}}); err != nil {
+			return err
+		}
+	}
+	return x.bulk(ctx, "delete", body.Bytes())
+}
+
+type elasticsearchBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk sends NDJSON actions to the _bulk API, which answers 200 even when actions fail
+func (x *ElasticsearchIndex) bulk(ctx context.Context, action string, body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+	status, resp, err := x.do(ctx, http.MethodPost, "/"+url.PathEscape(x.index)+"/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	if err := x.check(action, status, resp); err != nil {
+		return err
+	}
+
+	var result elasticsearchBulkResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("elasticsearch %s: decode response: %w", action, err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	for _, item := range result.Items {
+		for _, r := range item {
+			// Deleting a document that is not indexed is not an error
+			if r.Error == nil || (action == "delete" && r.Status == http.StatusNotFound) {
+				continue
+			}
+			return fmt.Errorf("elasticsearch %s %s: %s: %s", action, r.ID, r.Error.Type, r.Error.Reason)
+		}
+	}
+	return nil
+}
+
+type elasticsearchSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID string `json:"_id"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func (x *ElasticsearchIndex) Search(ctx context.Context, req domain.SearchRequest) (domain.SearchResult, error) {
+	match := map[string]any{"match_all": map[string]any{}}
+	if req.Text != "" {
+		match = map[string]any{"multi_match": map[string]any{
+			"query":     req.Text,
+			"fields":    []string{"name^2", "sku"},
+			"fuzziness": "AUTO",
+		}}
+	}
+	boolQuery := map[string]any{"must": []any{match}}
+	if req.TenantID != "" {
+		boolQuery["filter"] = []any{map[string]any{"term": map[string]any{"tenant_id": req.TenantID}}}
+	}
+	body, err := json.Marshal(map[string]any{
+		"from":             req.Offset,
+		"size":             req.Limit,
+		"query":            map[string]any{"bool": boolQuery},
+		"_source":          false,
+		"track_total_hits": true,
+	})
+	if err != nil {
+		return domain.SearchResult{}, err
+	}
+
+	status, resp, err := x.do(ctx, http.MethodPost, "/"+url.PathEscape(x.index)+"/_search", "application/json", body)
+	if err != nil {
+		return domain.SearchResult{}, err
+	}
+	if err := x.check("search", status, resp); err != nil {
+		return domain.SearchResult{}, err
+	}
+	var result elasticsearchSearchResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return domain.SearchResult{}, fmt.Errorf("elasticsearch search: decode response: %w", err)
+	}
+
+	ids := make([]string, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		ids[i] = hit.ID
+	}
+	return domain.SearchResult{IDs: ids, Total: result.Hits.Total.Value}, nil
+}
+
+func (x *ElasticsearchIndex) do(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, x.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if x.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+x.apiKey)
+	}
+
+	resp, err := x.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("elasticsearch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return 0, nil, fmt.Errorf("elasticsearch %s: read response: %w", path, err)
+	}
+	return resp.StatusCode, data, nil
+}
+
+func (x *ElasticsearchIndex) check(action string, status int, body []byte) error {
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	return fmt.Errorf("elasticsearch %s: status %d: %s", action, status, truncate(body, 512))
+}
+
+// truncate shortens error bodies of search engines for error messages
+func truncate(body []byte, n int) string {
+	if len(body) > n {
+		return string(body[:n]) + "..."
+	}
+	return string(body)
+}
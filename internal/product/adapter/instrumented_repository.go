@@ -0,0 +1,145 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/metrics"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// InstrumentedProductRepository records the duration of every call to the wrapped repository
+type InstrumentedProductRepository struct {
+	next    domain.ProductRepository
+	observe metrics.RepositoryObserver
+}
+
+func NewInstrumentedProductRepository(next domain.ProductRepository, m *metrics.Metrics) domain.ProductRepository {
+	return &InstrumentedProductRepository{next: next, observe: m.Repository("product")}
+}
+
+func (r *InstrumentedProductRepository) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
+	defer r.observe.Since("GetByID", time.Now())
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *InstrumentedProductRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Product, error) {
+	defer r.observe.Since("GetByPublicID", time.Now())
+	return r.next.GetByPublicID(ctx, publicID)
+}
+
+func (r *InstrumentedProductRepository) GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*domain.Product, error) {
+	defer r.observe.Since("GetByPublicIDAsOf", time.Now())
+	return r.next.GetByPublicIDAsOf(ctx, publicID, at)
+}
+
+func (r *InstrumentedProductRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Product, error) {
+	defer r.observe.Since("GetByIDForUpdate", time.Now())
+	return r.next.GetByIDForUpdate(ctx, id)
+}
+
+func (r *InstrumentedProductRepository) GetByIDs(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	defer r.observe.Since("GetByIDs", time.Now())
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r *InstrumentedProductRepository) GetByPublicIDs(ctx context.Context, publicIDs []string) ([]domain.Product, error) {
+	defer r.observe.Since("GetByPublicIDs", time.Now())
+	return r.next.GetByPublicIDs(ctx, publicIDs)
+}
+
+func (r *InstrumentedProductRepository) GetBySKUs(ctx context.Context, skus []string) ([]domain.Product, error) {
+	defer r.observe.Since("GetBySKUs", time.Now())
+	return r.next.GetBySKUs(ctx, skus)
+}
+
+func (r *InstrumentedProductRepository) FindInBatches(ctx context.Context, filter domain.ProductFilter, size int, fn func([]domain.Product) error) error {
+	defer r.observe.Since("FindInBatches", time.Now())
+	return r.next.FindInBatches(ctx, filter, size, fn)
+}
+
+func (r *InstrumentedProductRepository) List(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+	defer r.observe.Since("List", time.Now())
+	return r.next.List(ctx, filter)
+}
+
+func (r *InstrumentedProductRepository) Count(ctx context.Context, filter domain.ProductFilter) (int64, error) {
+	defer r.observe.Since("Count", time.Now())
+	return r.next.Count(ctx, filter)
+}
+
+func (r *InstrumentedProductRepository) ListLowStock(ctx context.Context, fallbackThreshold, limit int) ([]domain.Product, error) {
+	defer r.observe.Since("ListLowStock", time.Now())
+	return r.next.ListLowStock(ctx, fallbackThreshold, limit)
+}
+
+func (r *InstrumentedProductRepository) ListChanged(ctx context.Context, after persistence.ChangePosition, until time.Time, limit int) ([]domain.Product, error) {
+	defer r.observe.Since("ListChanged", time.Now())
+	return r.next.ListChanged(ctx, after, until, limit)
+}
+
+func (r *InstrumentedProductRepository) Delete(ctx context.Context, id int64) error {
+	defer r.observe.Since("Delete", time.Now())
+	return r.next.Delete(ctx, id)
+}
+
+func (r *InstrumentedProductRepository) Save(ctx context.Context, p *domain.Product) error {
+	defer r.observe.Since("Save", time.Now())
+	return r.next.Save(ctx, p)
+}
+
+func (r *InstrumentedProductRepository) DecrementStock(ctx context.Context, productID int64, qty int) error {
+	defer r.observe.Since("DecrementStock", time.Now())
+	return r.next.DecrementStock(ctx, productID, qty)
+}
+
+func (r *InstrumentedProductRepository) UpsertBySKU(ctx context.Context, products []domain.Product) error {
+	defer r.observe.Since("UpsertBySKU", time.Now())
+	return r.next.UpsertBySKU(ctx, products)
+}
+
+func (r *InstrumentedProductRepository) GetStockLevels(ctx context.Context, ids []int64) (map[int64]int, error) {
+	defer r.observe.Since("GetStockLevels", time.Now())
+	return r.next.GetStockLevels(ctx, ids)
+}
+
+func (r *InstrumentedProductRepository) BulkUpdateStock(ctx context.Context, adjustments []domain.StockAdjustment) error {
+	defer r.observe.Since("BulkUpdateStock", time.Now())
+	return r.next.BulkUpdateStock(ctx, adjustments)
+}
+
+// InstrumentedStockReservationRepository records the duration of every call to the wrapped repository
+type InstrumentedStockReservationRepository struct {
+	next    domain.StockReservationRepository
+	observe metrics.RepositoryObserver
+}
+
+func NewInstrumentedStockReservationRepository(next domain.StockReservationRepository, m *metrics.Metrics) domain.StockReservationRepository {
+	return &InstrumentedStockReservationRepository{next: next, observe: m.Repository("stock_reservation")}
+}
+
+func (r *InstrumentedStockReservationRepository) Reserve(ctx context.Context, productID int64, qty int, expiresAt time.Time) (*domain.StockReservation, error) {
+	defer r.observe.Since("Reserve", time.Now())
+	return r.next.Reserve(ctx, productID, qty, expiresAt)
+}
+
+func (r *InstrumentedStockReservationRepository) Confirm(ctx context.Context, id int64) error {
+	defer r.observe.Since("Confirm", time.Now())
+	return r.next.Confirm(ctx, id)
+}
+
+func (r *InstrumentedStockReservationRepository) Release(ctx context.Context, id int64) error {
+	defer r.observe.Since("Release", time.Now())
+	return r.next.Release(ctx, id)
+}
+
+func (r *InstrumentedStockReservationRepository) ReleaseExpired(ctx context.Context, now time.Time) (int64, error) {
+	defer r.observe.Since("ReleaseExpired", time.Now())
+	return r.next.ReleaseExpired(ctx, now)
+}
+
+func (r *InstrumentedStockReservationRepository) AvailableStock(ctx context.Context, productID int64) (int, error) {
+	defer r.observe.Since("AvailableStock", time.Now())
+	return r.next.AvailableStock(ctx, productID)
+}
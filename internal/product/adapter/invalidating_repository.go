@@ -0,0 +1,62 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/cache"
+)
+
+// InvalidatingProductRepository drops the cached product queries after every write to the
+// wrapped repository, failed ones included since they may have changed rows before failing.
+// Writes inside a transaction invalidate before it commits, so a query racing the commit may
+// cache the old rows until they expire.
+type InvalidatingProductRepository struct {
+	domain.ProductRepository
+	store *cache.Store
+}
+
+func NewInvalidatingProductRepository(next domain.ProductRepository, store *cache.Store) domain.ProductRepository {
+	return &InvalidatingProductRepository{ProductRepository: next, store: store}
+}
+
+func (r *InvalidatingProductRepository) Save(ctx context.Context, p *domain.Product) error {
+	defer r.store.Invalidate(domain.CacheTag)
+	return r.ProductRepository.Save(ctx, p)
+}
+
+func (r *InvalidatingProductRepository) Delete(ctx context.Context, id int64) error {
+	defer r.store.Invalidate(domain.CacheTag)
+	return r.ProductRepository.Delete(ctx, id)
+}
+
+func (r *InvalidatingProductRepository) DecrementStock(ctx context.Context, productID int64, qty int) error {
+	defer r.store.Invalidate(domain.CacheTag)
+	return r.ProductRepository.DecrementStock(ctx, productID, qty)
+}
+
+func (r *InvalidatingProductRepository) UpsertBySKU(ctx context.Context, products []domain.Product) error {
+	defer r.store.Invalidate(domain.CacheTag)
+	return r.ProductRepository.UpsertBySKU(ctx, products)
+}
+
+func (r *InvalidatingProductRepository) BulkUpdateStock(ctx context.Context, adjustments []domain.StockAdjustment) error {
+	defer r.store.Invalidate(domain.CacheTag)
+	return r.ProductRepository.BulkUpdateStock(ctx, adjustments)
+}
+
+// InvalidatingStockReservationRepository drops the cached product queries once a reservation is
+// confirmed, since confirming decrements the stock of the product
+type InvalidatingStockReservationRepository struct {
+	domain.StockReservationRepository
+	store *cache.Store
+}
+
+func NewInvalidatingStockReservationRepository(next domain.StockReservationRepository, store *cache.Store) domain.StockReservationRepository {
+	return &InvalidatingStockReservationRepository{StockReservationRepository: next, store: store}
+}
+
+func (r *InvalidatingStockReservationRepository) Confirm(ctx context.Context, id int64) error {
+	defer r.store.Invalidate(domain.CacheTag)
+	return r.StockReservationRepository.Confirm(ctx, id)
+}
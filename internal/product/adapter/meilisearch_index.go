@@ -0,0 +1,167 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+)
+
+// MeilisearchIndex keeps the product documents in a Meilisearch index through its REST API.
+// Meilisearch applies writes in the background, so they are searchable shortly after they return.
+type MeilisearchIndex struct {
+	baseURL string
+	index   string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewMeilisearchIndex uses the index with the uid index at baseURL, e.g. http://localhost:7700; an
+// empty apiKey sends requests without authentication
+func NewMeilisearchIndex(baseURL, index, apiKey string, client *http.Client) *MeilisearchIndex {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &MeilisearchIndex{baseURL: strings.TrimSuffix(baseURL, "/"), index: index, apiKey: apiKey, client: client}
+}
+
+// Describe and Ensure let the bootstrap registry create the index
+func (x *MeilisearchIndex) Describe() string {
+	return "meilisearch index " + x.index
+}
+
+// Ensure creates the index unless it exists and makes tenant_id filterable, which searches need
+func (x *MeilisearchIndex) Ensure(ctx context.Context) (bool, error) {
+	err := x.send(ctx, http.MethodGet, x.path(""), nil, nil)
+	created := errors.Is(err, errMeilisearchNotFound)
+	if created {
+		err = x.send(ctx, http.MethodPost, "/indexes", map[string]string{"uid": x.index, "primaryKey": "id"}, nil)
+	}
+	if err != nil {
+		return false, err
+	}
+	// Meilisearch queues the settings after the creation, so they apply to the new index
+	return created, x.send(ctx, http.MethodPatch, x.path("/settings"), map[string]any{
+		"searchableAttributes": []string{"name", "sku"},
+		"filterableAttributes": []string{"tenant_id"},
+	}, nil)
+}
+
+func (x *MeilisearchIndex) Index(ctx context.Context, docs []domain.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return x.send(ctx, http.MethodPost, x.path("/documents"), docs, nil)
+}
+
+func (x *MeilisearchIndex) Update(ctx context.Context, docs []domain.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return x.send(ctx, http.MethodPut, x.path("/documents"), docs, nil)
+}
+
+func (x *MeilisearchIndex) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return x.send(ctx, http.MethodPost, x.path("/documents/delete-batch"), ids, nil)
+}
+
+type meilisearchSearchResponse struct {
+	Hits []struct {
+		ID string `json:"id"`
+	} `json:"hits"`
+	EstimatedTotalHits int64 `json:"estimatedTotalHits"`
+}
+
+func (x *MeilisearchIndex) Search(ctx context.Context, req domain.SearchRequest) (domain.SearchResult, error) {
+	body := map[string]any{
+		"q":                    req.Text,
+		"offset":               req.Offset,
+		"limit":                req.Limit,
+		"attributesToRetrieve": []string{"id"},
+	}
+	if req.TenantID != "" {
+		body["filter"] = "tenant_id = " + strconv.Quote(req.TenantID)
+	}
+
+	var resp meilisearchSearchResponse
+	if err := x.send(ctx, http.MethodPost, x.path("/search"), body, &resp); err != nil {
+		return domain.SearchResult{}, err
+	}
+	ids := make([]string, len(resp.Hits))
+	for i, hit := range resp.Hits {
+		ids[i] = hit.ID
+	}
+	return domain.SearchResult{IDs: ids, Total: resp.EstimatedTotalHits}, nil
+}
+
+func (x *MeilisearchIndex) path(suffix string) string {
+	return "/indexes/" + url.PathEscape(x.index) + suffix
+}
+
+// errMeilisearchNotFound wraps the errors of requests answered with 404 Not Found
+var errMeilisearchNotFound = errors.New("not found")
+
+type meilisearchError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// send encodes in as the JSON body and decodes the response into out when it is not nil
+func (x *MeilisearchIndex) send(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, x.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if x.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+x.apiKey)
+	}
+
+	resp, err := x.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("meilisearch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		cause := fmt.Errorf("status %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusNotFound {
+			cause = fmt.Errorf("%w: status %d", errMeilisearchNotFound, resp.StatusCode)
+		}
+		var e meilisearchError
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &e) == nil && e.Message != "" {
+			return fmt.Errorf("meilisearch %s: %w: %s (%s)", path, cause, e.Message, e.Code)
+		}
+		return fmt.Errorf("meilisearch %s: %w: %s", path, cause, truncate(data, 512))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("meilisearch %s: decode response: %w", path, err)
+	}
+	return nil
+}
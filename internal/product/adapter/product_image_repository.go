@@ -0,0 +1,50 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormProductImageRepository struct {
+	db *gorm.DB
+}
+
+func NewGormProductImageRepository(db *gorm.DB) domain.ProductImageRepository {
+	return &GormProductImageRepository{db: db}
+}
+
+func (r *GormProductImageRepository) Create(ctx context.Context, img *domain.ProductImage) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(img).Error)
+}
+
+func (r *GormProductImageRepository) ListByProduct(ctx context.Context, productID int64) ([]domain.ProductImage, error) {
+	var images []domain.ProductImage
+	err := persistence.Conn(ctx, r.db).Where("product_id = ?", productID).Order("id").Find(&images).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return images, nil
+}
+
+func (r *GormProductImageRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.ProductImage, error) {
+	var img domain.ProductImage
+	err := persistence.Conn(ctx, r.db).Where("public_id = ?", publicID).First(&img).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &img, nil
+}
+
+func (r *GormProductImageRepository) Delete(ctx context.Context, id int64) error {
+	result := persistence.Conn(ctx, r.db).Delete(&domain.ProductImage{}, id)
+	if result.Error != nil {
+		return persistence.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
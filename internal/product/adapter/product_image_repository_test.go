@@ -0,0 +1,39 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormProductImageRepository(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&domain.ProductImage{}))
+	repo := adapter.NewGormProductImageRepository(db)
+	ctx := context.Background()
+
+	first := &domain.ProductImage{PublicID: "img_1", ProductID: 1, ContentType: "image/png", Size: 10, Width: 4, Height: 4, Key: "products/prd_1/img_1.png", ThumbnailKey: "products/prd_1/img_1-thumb.png"}
+	second := &domain.ProductImage{PublicID: "img_2", ProductID: 1, ContentType: "image/jpeg", Size: 20, Width: 8, Height: 8, Key: "products/prd_1/img_2.jpg", ThumbnailKey: "products/prd_1/img_2-thumb.jpg"}
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, second))
+	require.NoError(t, repo.Create(ctx, &domain.ProductImage{PublicID: "img_3", ProductID: 2, ContentType: "image/gif", Key: "a", ThumbnailKey: "b"}))
+
+	images, err := repo.ListByProduct(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, "img_1", images[0].PublicID, "oldest first")
+
+	got, err := repo.GetByPublicID(ctx, "img_2")
+	require.NoError(t, err)
+	assert.Equal(t, second.Key, got.Key)
+
+	require.NoError(t, repo.Delete(ctx, first.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, first.ID), persistence.ErrNotFound)
+	_, err = repo.GetByPublicID(ctx, "img_1")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
@@ -2,32 +2,288 @@ package adapter
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// bulkStockBatchSize bounds the number of products updated by a single statement
+const bulkStockBatchSize = 500
+
 type GormProductRepository struct {
+	*persistence.GenericRepository[domain.Product, int64]
 	db *gorm.DB
 }
 
 func NewGormProductRepository(db *gorm.DB) domain.ProductRepository {
-	return &GormProductRepository{db: db}
+	return &GormProductRepository{GenericRepository: persistence.NewGenericRepository[domain.Product, int64](db), db: db}
 }
 
-func (r *GormProductRepository) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
+func (r *GormProductRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Product, error) {
 	var product domain.Product
-	err := r.db.WithContext(ctx).First(&product, id).Error
+	err := persistence.Conn(ctx, r.db).Where("public_id = ?", publicID).First(&product).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &product, nil
+}
+
+// GetByPublicIDAsOf reads the product from products_history
+func (r *GormProductRepository) GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*domain.Product, error) {
+	current, err := r.GetByPublicID(ctx, publicID)
 	if err != nil {
 		return nil, err
 	}
+	return temporal.AsOf[domain.Product](ctx, r.db, current.ID, at)
+}
+
+func (r *GormProductRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Product, error) {
+	var product domain.Product
+	err := persistence.Conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
 	return &product, nil
 }
 
+func (r *GormProductRepository) GetByIDs(ctx context.Context, ids []int64) ([]domain.Product, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// Deleted products are included, since orders keep referring to them
+	var products []domain.Product
+	if err := persistence.Conn(ctx, r.db).Unscoped().Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return persistence.InKeyOrder(ids, products, func(p *domain.Product) int64 { return p.ID }), nil
+}
+
+func (r *GormProductRepository) GetByPublicIDs(ctx context.Context, publicIDs []string) ([]domain.Product, error) {
+	if len(publicIDs) == 0 {
+		return nil, nil
+	}
+
+	var products []domain.Product
+	if err := persistence.Conn(ctx, r.db).Where("public_id IN ?", publicIDs).Find(&products).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return persistence.InKeyOrder(publicIDs, products, func(p *domain.Product) string { return p.PublicID }), nil
+}
+
+func (r *GormProductRepository) GetBySKUs(ctx context.Context, skus []string) ([]domain.Product, error) {
+	if len(skus) == 0 {
+		return nil, nil
+	}
+
+	var products []domain.Product
+	if err := persistence.Conn(ctx, r.db).Where("sku IN ?", skus).Find(&products).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return persistence.InKeyOrder(skus, products, func(p *domain.Product) string { return p.SKUValue() }), nil
+}
+
+func (r *GormProductRepository) List(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+	db := filter.Apply(query.NewQueryBuilder(persistence.Conn(ctx, r.db).Model(&domain.Product{}))).Build()
+
+	var products []domain.Product
+	if err := db.Find(&products).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return products, nil
+}
+
+func (r *GormProductRepository) Count(ctx context.Context, filter domain.ProductFilter) (int64, error) {
+	var n int64
+	err := filter.Apply(query.NewQueryBuilder(persistence.Conn(ctx, r.db).Model(&domain.Product{}))).Build().Offset(-1).Limit(-1).Count(&n).Error
+	return n, persistence.TranslateError(err)
+}
+
+func (r *GormProductRepository) ListLowStock(ctx context.Context, fallbackThreshold, limit int) ([]domain.Product, error) {
+	var products []domain.Product
+	err := persistence.Conn(ctx, r.db).
+		Where("stock <= COALESCE(reorder_threshold, ?)", fallbackThreshold).
+		Order("stock, id").
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return products, nil
+}
+
+func (r *GormProductRepository) ListChanged(ctx context.Context, after persistence.ChangePosition, until time.Time, limit int) ([]domain.Product, error) {
+	var products []domain.Product
+	err := persistence.ChangedAfter(persistence.Conn(ctx, r.db).Unscoped(), after, until, limit).Find(&products).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return products, nil
+}
+
+func (r *GormProductRepository) FindInBatches(ctx context.Context, filter domain.ProductFilter, size int, fn func([]domain.Product) error) error {
+	q := func(qb *query.QueryBuilder) *query.QueryBuilder {
+		if filter.Name != "" {
+			qb.AddFilter("name", query.OperatorContains, filter.Name)
+		}
+		if filter.InStock {
+			qb.AddFilter("stock", query.OperatorGreaterThan, 0)
+		}
+		return qb
+	}
+	return r.InBatches(ctx, q, size, fn)
+}
+
+// Delete soft-deletes the product, changing its updated_at so offline clients sync the removal
+func (r *GormProductRepository) Delete(ctx context.Context, id int64) error {
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Product{}).Where("id = ?", id).Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return persistence.ErrNotFound
+		}
+		return tx.Delete(&domain.Product{}, id).Error
+	})
+	return persistence.TranslateError(err)
+}
+
 func (r *GormProductRepository) Save(ctx context.Context, p *domain.Product) error {
-	return r.db.WithContext(ctx).Save(p).Error
+	p.AssignPublicID()
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Save(p).Error)
 }
 
-func (r *GormProductRepository) UpdateStock(ctx context.Context, p *domain.Product) error {
-	return r.db.WithContext(ctx).Model(p).Update("stock", p.Stock).Error
+func (r *GormProductRepository) DecrementStock(ctx context.Context, productID int64, qty int) error {
+	for retried := false; ; retried = true {
+		result := persistence.Conn(ctx, r.db).Model(&domain.Product{}).
+			Where("id = ? AND stock >= ?", productID, qty).
+			Update("stock", gorm.Expr("stock - ?", qty))
+		if result.Error != nil {
+			return persistence.TranslateError(result.Error)
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		// Nothing matched, so the product is unknown or short; reading it tells which and how short
+		p, err := r.GetByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if err := p.CheckStock(qty); err != nil {
+			return err
+		}
+		// The stock was replenished after the update missed it, so the update is tried once more
+		if retried {
+			return fmt.Errorf("%w: the stock of product %d kept changing", domain.ErrInsufficientStock, productID)
+		}
+	}
+}
+
+// UpsertBySKU issues a single INSERT ... ON CONFLICT (tenant_id, sku) DO UPDATE; public IDs given to
+// products whose SKU is taken are discarded, and deleted products whose SKU is imported again
+// are restored
+func (r *GormProductRepository) UpsertBySKU(ctx context.Context, products []domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	for i := range products {
+		products[i].AssignPublicID()
+	}
+
+	err := persistence.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "sku"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "stock", "price_amount", "price_currency", "updated_at", "deleted_at"}),
+	}).Create(&products).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormProductRepository) GetStockLevels(ctx context.Context, ids []int64) (map[int64]int, error) {
+	levels := make(map[int64]int, len(ids))
+	if len(ids) == 0 {
+		return levels, nil
+	}
+
+	var rows []domain.Product
+	err := persistence.Conn(ctx, r.db).Select("id", "stock").Where("id IN ?", ids).Find(&rows).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	for _, row := range rows {
+		levels[row.ID] = row.Stock
+	}
+	return levels, nil
+}
+
+// BulkUpdateStock issues one UPDATE ... SET stock = stock + CASE id ... END per batch inside a
+// single transaction, then verifies no product went negative so concurrent writers cannot oversell
+func (r *GormProductRepository) BulkUpdateStock(ctx context.Context, adjustments []domain.StockAdjustment) error {
+	if len(adjustments) == 0 {
+		return nil
+	}
+
+	// Deleted products are restocked too, e.g. when an order of one is refunded
+	err := persistence.Conn(ctx, r.db).Unscoped().Transaction(func(tx *gorm.DB) error {
+		ids := make([]int64, 0, len(adjustments))
+
+		for start := 0; start < len(adjustments); start += bulkStockBatchSize {
+			end := min(start+bulkStockBatchSize, len(adjustments))
+			batch := adjustments[start:end]
+
+			expr, batchIDs := stockDeltaExpr(batch)
+			ids = append(ids, batchIDs...)
+
+			result := tx.Model(&domain.Product{}).
+				Where("id IN ?", batchIDs).
+				Update("stock", expr)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(batchIDs)) {
+				return fmt.Errorf("bulk stock update: expected %d products, updated %d", len(batchIDs), result.RowsAffected)
+			}
+		}
+
+		var negative int64
+		if err := tx.Model(&domain.Product{}).Where("id IN ? AND stock < 0", ids).Count(&negative).Error; err != nil {
+			return err
+		}
+		if negative > 0 {
+			return domain.ErrNegativeStock
+		}
+		return nil
+	})
+	return persistence.TranslateError(err)
+}
+
+// stockDeltaExpr builds "stock + CASE id WHEN ? THEN ? ... ELSE 0 END" for a batch,
+// summing deltas for products that appear more than once
+func stockDeltaExpr(batch []domain.StockAdjustment) (expr clause.Expr, ids []int64) {
+	deltas := make(map[int64]int, len(batch))
+	for _, adj := range batch {
+		if _, seen := deltas[adj.ProductID]; !seen {
+			ids = append(ids, adj.ProductID)
+		}
+		deltas[adj.ProductID] += adj.Delta
+	}
+
+	var sql strings.Builder
+	args := make([]interface{}, 0, len(ids)*2)
+	sql.WriteString("stock + CASE id")
+	for _, id := range ids {
+		sql.WriteString(" WHEN ? THEN ?")
+		args = append(args, id, deltas[id])
+	}
+	sql.WriteString(" ELSE 0 END")
+
+	return gorm.Expr(sql.String(), args...), ids
 }
@@ -0,0 +1,312 @@
+package adapter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+
+	err = db.AutoMigrate(&domain.Product{}, &domain.StockReservation{})
+	assert.NoError(t, err)
+
+	products := []domain.Product{
+		{ID: 1, Name: "Product One", Stock: 10},
+		{ID: 2, Name: "Product Two", Stock: 5},
+		{ID: 3, Name: "Product Three", Stock: 0},
+	}
+	assert.NoError(t, db.Create(&products).Error)
+
+	return db
+}
+
+func stockOf(t *testing.T, db *gorm.DB, id int64) int {
+	var p domain.Product
+	assert.NoError(t, db.First(&p, id).Error)
+	return p.Stock
+}
+
+func TestGormProductRepository_BulkUpdateStock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+
+	err := repo.BulkUpdateStock(context.Background(), []domain.StockAdjustment{
+		{ProductID: 1, Delta: -4},
+		{ProductID: 2, Delta: 10},
+		{ProductID: 1, Delta: 1},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, stockOf(t, db, 1))
+	assert.Equal(t, 15, stockOf(t, db, 2))
+	assert.Equal(t, 0, stockOf(t, db, 3))
+}
+
+func TestGormProductRepository_BulkUpdateStock_RollsBackOnNegativeStock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+
+	err := repo.BulkUpdateStock(context.Background(), []domain.StockAdjustment{
+		{ProductID: 1, Delta: 5},
+		{ProductID: 3, Delta: -1},
+	})
+
+	assert.ErrorIs(t, err, domain.ErrNegativeStock)
+	assert.Equal(t, 10, stockOf(t, db, 1))
+	assert.Equal(t, 0, stockOf(t, db, 3))
+}
+
+func TestGormProductRepository_DecrementStock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.DecrementStock(ctx, 2, 3))
+	assert.Equal(t, 2, stockOf(t, db, 2))
+
+	err := repo.DecrementStock(ctx, 2, 3)
+	assert.ErrorIs(t, err, domain.ErrInsufficientStock)
+	assert.ErrorContains(t, err, "2 left, 3 requested")
+	assert.Equal(t, 2, stockOf(t, db, 2))
+
+	assert.ErrorIs(t, repo.DecrementStock(ctx, 99, 1), persistence.ErrNotFound)
+}
+
+func TestGormProductRepository_DecrementStock_RetriesWhenReplenishedMeanwhile(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+	// Restock product 3 right after the first update misses it, before the repository reads why
+	restocked := false
+	assert.NoError(t, db.Callback().Update().After("gorm:update").Register("test:restock", func(tx *gorm.DB) {
+		if !restocked && tx.Statement.RowsAffected == 0 {
+			restocked = true
+			assert.NoError(t, tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE products SET stock = 4 WHERE id = 3").Error)
+		}
+	}))
+
+	assert.NoError(t, repo.DecrementStock(context.Background(), 3, 3))
+	assert.True(t, restocked)
+	assert.Equal(t, 1, stockOf(t, db, 3))
+}
+
+func TestGormProductRepository_GetStockLevels(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+
+	levels, err := repo.GetStockLevels(context.Background(), []int64{1, 3, 99})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int{1: 10, 3: 0}, levels)
+}
+
+func TestGormProductRepository_GetByID_NotFound(t *testing.T) {
+	repo := adapter.NewGormProductRepository(setupTestDB(t))
+
+	_, err := repo.GetByID(context.Background(), 42)
+
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGormProductRepository_GetByIDs(t *testing.T) {
+	repo := adapter.NewGormProductRepository(setupTestDB(t))
+
+	products, err := repo.GetByIDs(context.Background(), []int64{3, 1, 99, 3})
+
+	assert.NoError(t, err)
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	assert.Equal(t, []int64{3, 1}, ids, "in the order asked for, without unknown or repeated IDs")
+}
+
+func TestGormProductRepository_List(t *testing.T) {
+	repo := adapter.NewGormProductRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	products, err := repo.List(ctx, domain.ProductFilter{Name: "Product T", InStock: true})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, int64(2), products[0].ID)
+
+	products, err = repo.List(ctx, domain.ProductFilter{Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, int64(2), products[0].ID)
+
+	count, err := repo.Count(ctx, domain.ProductFilter{Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count, "offset and limit are ignored")
+}
+
+func TestGormProductRepository_ListLowStock(t *testing.T) {
+	db := setupTestDB(t)
+	threshold := 12
+	assert.NoError(t, db.Model(&domain.Product{}).Where("id = ?", 1).Update("reorder_threshold", threshold).Error)
+	repo := adapter.NewGormProductRepository(db)
+
+	products, err := repo.ListLowStock(context.Background(), 5, 10)
+
+	assert.NoError(t, err)
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	assert.Equal(t, []int64{3, 2, 1}, ids, "lowest stock first, product 1 by its own threshold")
+
+	products, err = repo.ListLowStock(context.Background(), 4, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, int64(3), products[0].ID)
+	}
+}
+
+func TestGormProductRepository_FindInBatches(t *testing.T) {
+	repo := adapter.NewGormProductRepository(setupTestDB(t))
+
+	var ids [][]int64
+	err := repo.FindInBatches(context.Background(), domain.ProductFilter{Name: "Product", InStock: true, Limit: 1}, 1, func(batch []domain.Product) error {
+		var batchIDs []int64
+		for _, p := range batch {
+			batchIDs = append(batchIDs, p.ID)
+		}
+		ids = append(ids, batchIDs)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{1}, {2}}, ids, "the limit is ignored")
+}
+
+func TestGormProductRepository_GetByIDForUpdate_JoinsTransaction(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+	reservations := adapter.NewGormStockReservationRepository(db, repo)
+	errDeclined := errors.New("payment declined")
+
+	err := persistence.NewGormTransactor(db).InTransaction(context.Background(), func(ctx context.Context) error {
+		p, err := repo.GetByIDForUpdate(ctx, 2)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, 5, p.Stock)
+
+		reservation, err := reservations.Reserve(ctx, p.ID, 2, time.Now().Add(time.Minute))
+		if err != nil {
+			return err
+		}
+		if err := reservations.Confirm(ctx, reservation.ID); err != nil {
+			return err
+		}
+		return errDeclined
+	})
+
+	assert.ErrorIs(t, err, errDeclined)
+	assert.Equal(t, 5, stockOf(t, db, 2), "the stock decrement is rolled back with the order")
+	var count int64
+	assert.NoError(t, db.Model(&domain.StockReservation{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	_, err = repo.GetByIDForUpdate(context.Background(), 99)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGormProductRepository_UpsertBySKU(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+	ctx := context.Background()
+
+	existing := domain.MustNewProduct("Desk", 1)
+	existing.SetSKU("DESK-1")
+	assert.NoError(t, repo.Save(ctx, existing))
+
+	desk, lamp := domain.Product{Name: "Oak desk", Stock: 4}, domain.Product{Name: "Lamp", Stock: 2}
+	desk.SetSKU("DESK-1")
+	lamp.SetSKU("LAMP-1")
+	assert.NoError(t, repo.UpsertBySKU(ctx, []domain.Product{desk, lamp}))
+
+	products, err := repo.GetBySKUs(ctx, []string{"DESK-1", "LAMP-1", "UNKNOWN"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	for _, p := range products {
+		switch p.SKUValue() {
+		case "DESK-1":
+			assert.Equal(t, existing.ID, p.ID)
+			assert.Equal(t, existing.PublicID, p.PublicID, "upserts must keep the public ID")
+			assert.Equal(t, "Oak desk", p.Name)
+			assert.Equal(t, 4, p.Stock)
+		case "LAMP-1":
+			assert.NotEmpty(t, p.PublicID)
+		}
+	}
+
+	// Products without a SKU do not collide on the unique index
+	assert.NoError(t, repo.Save(ctx, domain.MustNewProduct("Chair", 1)))
+	assert.NoError(t, repo.Save(ctx, domain.MustNewProduct("Rug", 1)))
+}
+
+func TestGormProductRepository_Delete(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Delete(ctx, 2))
+
+	_, err := repo.GetByID(ctx, 2)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+	products, err := repo.GetByIDs(ctx, []int64{2})
+	assert.NoError(t, err)
+	if assert.Len(t, products, 1, "orders keep referring to deleted products") {
+		assert.True(t, products[0].DeletedAt.Valid)
+	}
+	assert.ErrorIs(t, repo.Delete(ctx, 2), persistence.ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, 99), persistence.ErrNotFound)
+}
+
+func TestGormProductRepository_ListChanged(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormProductRepository(db)
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for id, at := range map[int64]time.Time{1: base.Add(time.Minute), 2: base, 3: base} {
+		assert.NoError(t, db.Model(&domain.Product{}).Where("id = ?", id).UpdateColumn("updated_at", at).Error)
+	}
+	assert.NoError(t, db.Model(&domain.Product{}).Where("id = ?", 3).UpdateColumn("deleted_at", base).Error)
+
+	ids := func(products []domain.Product) []int64 {
+		ids := make([]int64, len(products))
+		for i, p := range products {
+			ids[i] = p.ID
+		}
+		return ids
+	}
+	until := base.Add(time.Hour)
+
+	changed, err := repo.ListChanged(ctx, persistence.ChangePosition{}, until, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3, 1}, ids(changed), "by updated_at then ID, with deleted products")
+	assert.True(t, changed[1].DeletedAt.Valid)
+
+	changed, err = repo.ListChanged(ctx, persistence.ChangePosition{UpdatedAt: base, ID: 2}, until, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 1}, ids(changed), "after the position")
+
+	changed, err = repo.ListChanged(ctx, persistence.ChangePosition{}, until, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids(changed))
+
+	changed, err = repo.ListChanged(ctx, persistence.ChangePosition{}, base.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, ids(changed), "changes from until on are left for later")
+}
@@ -0,0 +1,111 @@
+package adapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var kettle = domain.SearchDocument{ID: "prd_kettle", TenantID: "acme", Name: "Kettle", SKU: "KT-1", PriceAmount: 2999, PriceCurrency: "EUR"}
+
+func TestElasticsearchIndex_Update(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/products/_bulk", r.URL.Path)
+		assert.Equal(t, "ApiKey secret", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		require.Len(t, lines, 2)
+		assert.JSONEq(t, `{"update":{"_id":"prd_kettle"}}`, lines[0])
+		assert.Contains(t, lines[1], `"doc_as_upsert":true`)
+		fmt.Fprint(w, `{"errors":true,"items":[{"update":{"_id":"prd_kettle","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`)
+	}))
+	defer server.Close()
+
+	index := adapter.NewElasticsearchIndex(server.URL, "products", "secret", server.Client())
+	err := index.Update(context.Background(), []domain.SearchDocument{kettle})
+	assert.ErrorContains(t, err, "mapper_parsing_exception", "failed bulk actions fail the call")
+}
+
+func TestElasticsearchIndex_DeleteIgnoresMissing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errors":true,"items":[{"delete":{"_id":"prd_gone","status":404,"error":{"type":"not_found","reason":"missing"}}}]}`)
+	}))
+	defer server.Close()
+
+	index := adapter.NewElasticsearchIndex(server.URL, "products", "", server.Client())
+	assert.NoError(t, index.Delete(context.Background(), []string{"prd_gone"}))
+}
+
+func TestElasticsearchIndex_Search(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/products/_search", r.URL.Path)
+		var body struct {
+			From  int `json:"from"`
+			Size  int `json:"size"`
+			Query struct {
+				Bool struct {
+					Filter []map[string]map[string]string `json:"filter"`
+				} `json:"bool"`
+			} `json:"query"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, 20, body.From)
+		assert.Equal(t, 10, body.Size)
+		assert.Equal(t, "acme", body.Query.Bool.Filter[0]["term"]["tenant_id"])
+		fmt.Fprint(w, `{"hits":{"total":{"value":21},"hits":[{"_id":"prd_kettle"}]}}`)
+	}))
+	defer server.Close()
+
+	index := adapter.NewElasticsearchIndex(server.URL, "products", "", server.Client())
+	result, err := index.Search(context.Background(), domain.SearchRequest{Text: "ketle", TenantID: "acme", Offset: 20, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, domain.SearchResult{IDs: []string{"prd_kettle"}, Total: 21}, result)
+}
+
+func TestMeilisearchIndex(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/indexes/products/search":
+			var body map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, `tenant_id = "acme"`, body["filter"])
+			fmt.Fprint(w, `{"hits":[{"id":"prd_kettle"}],"estimatedTotalHits":1}`)
+		case "/indexes/products", "/indexes/products/documents/delete-batch":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Index products not found.","code":"index_not_found"}`)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{"taskUid":1}`)
+		}
+	}))
+	defer server.Close()
+	index := adapter.NewMeilisearchIndex(server.URL, "products", "secret", server.Client())
+	ctx := context.Background()
+
+	created, err := index.Ensure(ctx)
+	require.NoError(t, err)
+	assert.True(t, created)
+	require.NoError(t, index.Update(ctx, []domain.SearchDocument{kettle}))
+	result, err := index.Search(ctx, domain.SearchRequest{Text: "kettle", TenantID: "acme", Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, domain.SearchResult{IDs: []string{"prd_kettle"}, Total: 1}, result)
+	assert.ErrorContains(t, index.Delete(ctx, []string{"prd_kettle"}), "index_not_found")
+
+	assert.Equal(t, []string{
+		"GET /indexes/products", "POST /indexes", "PATCH /indexes/products/settings", "PUT /indexes/products/documents",
+		"POST /indexes/products/search", "POST /indexes/products/documents/delete-batch",
+	}, requests)
+}
@@ -0,0 +1,125 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormStockReservationRepository struct {
+	db         *gorm.DB
+	transactor *persistence.GormTransactor
+	// products takes confirmed reservations off the stock
+	products domain.ProductRepository
+	now      func() time.Time
+}
+
+func NewGormStockReservationRepository(db *gorm.DB, products domain.ProductRepository) domain.StockReservationRepository {
+	return &GormStockReservationRepository{db: db, transactor: persistence.NewGormTransactor(db), products: products, now: time.Now}
+}
+
+// Reserve locks the product row so concurrent reservations of the same product are checked one at a time
+func (r *GormStockReservationRepository) Reserve(ctx context.Context, productID int64, qty int, expiresAt time.Time) (*domain.StockReservation, error) {
+	reservation := &domain.StockReservation{
+		ProductID: productID,
+		Quantity:  qty,
+		Status:    domain.ReservationActive,
+		ExpiresAt: expiresAt,
+	}
+
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var product domain.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&product, productID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrProductNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		reserved, err := activeReservations(tx, productID, r.now())
+		if err != nil {
+			return err
+		}
+		if product.Stock-reserved < qty {
+			return domain.ErrInsufficientStock
+		}
+		return tx.Create(reservation).Error
+	})
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return reservation, nil
+}
+
+// Confirm settles the reservation and decrements stock in one transaction, joining the one of ctx
+// if any; the guard of DecrementStock catches manual stock adjustments made while the reservation
+// was held
+func (r *GormStockReservationRepository) Confirm(ctx context.Context, id int64) error {
+	return r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		tx := persistence.Conn(ctx, r.db)
+		var reservation domain.StockReservation
+		if err := tx.First(&reservation, id).Error; err != nil {
+			return persistence.TranslateError(err)
+		}
+
+		if err := settle(tx, id, domain.ReservationConfirmed, r.now()); err != nil {
+			return err
+		}
+		return r.products.DecrementStock(ctx, reservation.ProductID, reservation.Quantity)
+	})
+}
+
+func (r *GormStockReservationRepository) Release(ctx context.Context, id int64) error {
+	return persistence.TranslateError(settle(persistence.Conn(ctx, r.db), id, domain.ReservationReleased, r.now()))
+}
+
+func (r *GormStockReservationRepository) ReleaseExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := persistence.Conn(ctx, r.db).Model(&domain.StockReservation{}).
+		Where("status = ? AND expires_at <= ?", domain.ReservationActive, now).
+		Update("status", domain.ReservationReleased)
+	return result.RowsAffected, persistence.TranslateError(result.Error)
+}
+
+func (r *GormStockReservationRepository) AvailableStock(ctx context.Context, productID int64) (int, error) {
+	db := persistence.Conn(ctx, r.db)
+
+	var product domain.Product
+	if err := db.Select("id", "stock").First(&product, productID).Error; err != nil {
+		return 0, persistence.TranslateError(err)
+	}
+	reserved, err := activeReservations(db, productID, r.now())
+	if err != nil {
+		return 0, persistence.TranslateError(err)
+	}
+	return product.Stock - reserved, nil
+}
+
+// activeReservations sums the quantity held by unexpired active reservations of a product
+func activeReservations(db *gorm.DB, productID int64, now time.Time) (int, error) {
+	var reserved int
+	err := db.Model(&domain.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND status = ? AND expires_at > ?", productID, domain.ReservationActive, now).
+		Scan(&reserved).Error
+	return reserved, err
+}
+
+// settle moves an active, unexpired reservation to status, failing with ErrReservationNotActive otherwise
+func settle(db *gorm.DB, id int64, status domain.ReservationStatus, now time.Time) error {
+	result := db.Model(&domain.StockReservation{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, domain.ReservationActive, now).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrReservationNotActive
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGormStockReservationRepository_ReserveHoldsAvailableStock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormStockReservationRepository(db, adapter.NewGormProductRepository(db))
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	_, err := repo.Reserve(ctx, 2, 3, expiresAt)
+	assert.NoError(t, err)
+
+	available, err := repo.AvailableStock(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, available)
+	assert.Equal(t, 5, stockOf(t, db, 2), "reserving leaves stock untouched")
+
+	_, err = repo.Reserve(ctx, 2, 3, expiresAt)
+	assert.ErrorIs(t, err, domain.ErrInsufficientStock)
+
+	_, err = repo.Reserve(ctx, 99, 1, expiresAt)
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+}
+
+func TestGormStockReservationRepository_ConfirmDecrementsStock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormStockReservationRepository(db, adapter.NewGormProductRepository(db))
+	ctx := context.Background()
+
+	reservation, err := repo.Reserve(ctx, 1, 4, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.Confirm(ctx, reservation.ID))
+	assert.Equal(t, 6, stockOf(t, db, 1))
+
+	available, err := repo.AvailableStock(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, available, "confirmed reservations no longer hold stock")
+
+	assert.ErrorIs(t, repo.Confirm(ctx, reservation.ID), domain.ErrReservationNotActive)
+	assert.ErrorIs(t, repo.Release(ctx, reservation.ID), domain.ErrReservationNotActive)
+}
+
+func TestGormStockReservationRepository_ExpiredReservationsFreeStock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := adapter.NewGormStockReservationRepository(db, adapter.NewGormProductRepository(db))
+	ctx := context.Background()
+
+	expired := domain.StockReservation{ProductID: 2, Quantity: 5, Status: domain.ReservationActive, ExpiresAt: time.Now().Add(-time.Minute)}
+	assert.NoError(t, db.Create(&expired).Error)
+
+	available, err := repo.AvailableStock(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, available, "expired reservations don't count even before they are released")
+	assert.ErrorIs(t, repo.Confirm(ctx, expired.ID), domain.ErrReservationNotActive)
+
+	released, err := repo.ReleaseExpired(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), released)
+
+	var reloaded domain.StockReservation
+	assert.NoError(t, db.First(&reloaded, expired.ID).Error)
+	assert.Equal(t, domain.ReservationReleased, reloaded.Status)
+}
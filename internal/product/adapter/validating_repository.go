@@ -0,0 +1,47 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// ValidatingProductRepository validates products before delegating writes to the wrapped repository
+type ValidatingProductRepository struct {
+	domain.ProductRepository
+}
+
+func NewValidatingProductRepository(next domain.ProductRepository) domain.ProductRepository {
+	return &ValidatingProductRepository{ProductRepository: next}
+}
+
+func (r *ValidatingProductRepository) Save(ctx context.Context, p *domain.Product) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	return r.ProductRepository.Save(ctx, p)
+}
+
+func (r *ValidatingProductRepository) UpsertBySKU(ctx context.Context, products []domain.Product) error {
+	var errs validation.Errors
+	for i := range products {
+		prefix := fmt.Sprintf("products[%d]", i)
+		errs.Check(products[i].SKU != nil, prefix+".sku", "is required")
+		errs.Merge(prefix, products[i].Validate())
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	return r.ProductRepository.UpsertBySKU(ctx, products)
+}
+
+func (r *ValidatingProductRepository) DecrementStock(ctx context.Context, productID int64, qty int) error {
+	var errs validation.Errors
+	errs.Check(qty > 0, "quantity", "must be positive")
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	return r.ProductRepository.DecrementStock(ctx, productID, qty)
+}
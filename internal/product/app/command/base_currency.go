@@ -0,0 +1,40 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// BaseCurrency is the currency the catalogue stores prices in. Prices given in another currency
+// are converted into it at the current rate, rounded by the rules of the converter; the zero
+// value keeps prices in the currency they were given in.
+type BaseCurrency struct {
+	Currency  string
+	Converter money.CurrencyConverter
+}
+
+// convert returns price in the base currency; currencies without a rate are reported as
+// validation.Errors of field
+func (b BaseCurrency) convert(ctx context.Context, price money.Money, field string) (money.Money, error) {
+	if b.Currency == "" || price.Currency == "" || price.Currency == b.Currency {
+		return price, nil
+	}
+	var errs validation.Errors
+	if b.Converter == nil {
+		errs.Add(field, fmt.Sprintf("must be %s", b.Currency))
+		return money.Money{}, errs
+	}
+	converted, err := b.Converter.Convert(ctx, price, b.Currency)
+	if errors.Is(err, money.ErrUnknownRate) {
+		errs.Add(field, fmt.Sprintf("cannot be converted to %s: %s", b.Currency, err))
+		return money.Money{}, errs
+	}
+	if err != nil {
+		return money.Money{}, fmt.Errorf("convert price to %s: %w", b.Currency, err)
+	}
+	return converted, nil
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+type CreateProductCommand struct {
+	Name  string `validate:"required"`
+	Stock int    `validate:"gte=0"`
+	// SKU is optional and must not be taken by another product
+	SKU string
+	// Price is optional; unpriced products are paid for with amounts given by the client. It is
+	// stored in the base currency.
+	Price money.Money
+	// ReorderThreshold is optional; products without one run low at STOCK_LOW_THRESHOLD
+	ReorderThreshold *int
+	// TaxClass selects the TAX_RATES of the product; empty is the standard class
+	TaxClass string
+}
+
+type CreateProductHandler struct {
+	ProductRepo productDomain.ProductRepository
+	Base        BaseCurrency
+
+	// Quota enforces the product limit of the tenant's plan; nil disables it
+	Quota quotaDomain.Limiter
+	// Events receives ProductsChanged for the created product; nil disables publishing
+	Events event.Publisher
+}
+
+func (h *CreateProductHandler) Handle(ctx context.Context, cmd CreateProductCommand) (*productDomain.Product, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	p, err := productDomain.NewProduct(cmd.Name, cmd.Stock)
+	if err != nil {
+		return nil, err
+	}
+	price, err := h.Base.convert(ctx, cmd.Price, "price.currency")
+	if err != nil {
+		return nil, err
+	}
+	p.SetPrice(price)
+	p.SetSKU(cmd.SKU)
+	p.ReorderThreshold = cmd.ReorderThreshold
+	p.TaxClass = cmd.TaxClass
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if h.Quota != nil {
+		if err := h.Quota.Consume(ctx, quotaDomain.MetricProducts, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.ProductRepo.Save(ctx, p); err != nil {
+		if h.Quota != nil {
+			if releaseErr := h.Quota.Release(ctx, quotaDomain.MetricProducts, 1); releaseErr != nil {
+				slog.ErrorContext(ctx, "releasing product quota failed", "error", releaseErr)
+			}
+		}
+		return nil, fmt.Errorf("save product: %w", err)
+	}
+
+	publishProductsChanged(ctx, h.Events, []int64{p.ID})
+	return p, nil
+}
@@ -0,0 +1,158 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// MockProductRepository stores products in memory; saveErr simulates a failing insert
+type MockProductRepository struct {
+	productDomain.ProductRepository
+	products []*productDomain.Product
+	saveErr  error
+	batches  []int
+}
+
+func (m *MockProductRepository) Save(ctx context.Context, p *productDomain.Product) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	p.ID = int64(len(m.products) + 1)
+	m.products = append(m.products, p)
+	return nil
+}
+
+// MockLimiter allows up to limit units and tracks the consumed amount
+type MockLimiter struct {
+	limit int64
+	used  int64
+}
+
+func (m *MockLimiter) Consume(ctx context.Context, metric quotaDomain.Metric, n int64) error {
+	if m.used+n > m.limit {
+		return &quotaDomain.ExceededError{Metric: metric, Limit: m.limit, Plan: "free"}
+	}
+	m.used += n
+	return nil
+}
+
+func (m *MockLimiter) Release(ctx context.Context, metric quotaDomain.Metric, n int64) error {
+	m.used -= n
+	return nil
+}
+
+func TestCreateProductHandler_Handle_Success(t *testing.T) {
+	repo := &MockProductRepository{}
+	quota := &MockLimiter{limit: 1}
+	handler := &CreateProductHandler{ProductRepo: repo, Quota: quota}
+
+	p, err := handler.Handle(context.Background(), CreateProductCommand{Name: " Lamp ", Stock: 3})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if p.ID == 0 || p.Name != "Lamp" {
+		t.Errorf("Expected saved product named Lamp, got %+v", p)
+	}
+	if quota.used != 1 {
+		t.Errorf("Expected 1 product counted against the quota, got %d", quota.used)
+	}
+}
+
+func TestCreateProductHandler_Handle_QuotaExceeded(t *testing.T) {
+	repo := &MockProductRepository{}
+	handler := &CreateProductHandler{ProductRepo: repo, Quota: &MockLimiter{limit: 0}}
+
+	_, err := handler.Handle(context.Background(), CreateProductCommand{Name: "Lamp"})
+
+	if !errors.Is(err, quotaDomain.ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if len(repo.products) != 0 {
+		t.Error("Expected no product to be saved")
+	}
+}
+
+func TestCreateProductHandler_Handle_ReleasesQuotaOnFailure(t *testing.T) {
+	quota := &MockLimiter{limit: 5}
+	handler := &CreateProductHandler{
+		ProductRepo: &MockProductRepository{saveErr: errors.New("database connection failed")},
+		Quota:       quota,
+	}
+
+	_, err := handler.Handle(context.Background(), CreateProductCommand{Name: "Lamp"})
+
+	if err == nil {
+		t.Fatal("Expected error from repository, got nil")
+	}
+	if quota.used != 0 {
+		t.Errorf("Expected quota to be released, got %d used", quota.used)
+	}
+}
+
+func TestCreateProductHandler_Handle_Invalid(t *testing.T) {
+	quota := &MockLimiter{limit: 5}
+	handler := &CreateProductHandler{ProductRepo: &MockProductRepository{}, Quota: quota}
+
+	_, err := handler.Handle(context.Background(), CreateProductCommand{Name: "", Stock: -1})
+
+	if !validation.IsValidationError(err) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+	if quota.used != 0 {
+		t.Error("Expected invalid products not to count against the quota")
+	}
+}
+
+func TestCreateProductHandler_Handle_Price(t *testing.T) {
+	handler := &CreateProductHandler{ProductRepo: &MockProductRepository{}}
+	price := money.Money{Amount: 1999, Currency: "EUR"}
+
+	p, err := handler.Handle(context.Background(), CreateProductCommand{Name: "Lamp", Price: price})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if p.Price() != price {
+		t.Errorf("Expected price %s, got %s", price, p.Price())
+	}
+
+	_, err = handler.Handle(context.Background(), CreateProductCommand{Name: "Lamp", Price: money.Money{Amount: -1, Currency: "EUR"}})
+	if !validation.IsValidationError(err) {
+		t.Errorf("Expected a validation error for a negative price, got %v", err)
+	}
+}
+
+// MockCurrencyConverter converts at fixed rates against EUR
+type MockCurrencyConverter struct{}
+
+func (MockCurrencyConverter) Convert(ctx context.Context, m money.Money, to string) (money.Money, error) {
+	rates := money.ExchangeRates{Base: "EUR", Rates: map[string]*big.Rat{"USD": big.NewRat(5, 4)}}
+	return rates.Convert(m, to, money.RoundHalfUp)
+}
+
+func TestCreateProductHandler_Handle_StoresBaseCurrency(t *testing.T) {
+	handler := &CreateProductHandler{
+		ProductRepo: &MockProductRepository{},
+		Base:        BaseCurrency{Currency: "EUR", Converter: MockCurrencyConverter{}},
+	}
+
+	p, err := handler.Handle(context.Background(), CreateProductCommand{Name: "Lamp", Price: money.Money{Amount: 2500, Currency: "USD"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := (money.Money{Amount: 2000, Currency: "EUR"}); p.Price() != want {
+		t.Errorf("Expected price %s, got %s", want, p.Price())
+	}
+
+	_, err = handler.Handle(context.Background(), CreateProductCommand{Name: "Lamp", Price: money.Money{Amount: 2500, Currency: "CHF"}})
+	if !validation.IsValidationError(err) {
+		t.Errorf("Expected a validation error for a currency without a rate, got %v", err)
+	}
+}
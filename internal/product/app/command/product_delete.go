@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// DeleteProductCommand removes a product from the catalogue. The product is soft-deleted, so the
+// orders that refer to it keep it and offline clients learn of the removal on their next sync.
+type DeleteProductCommand struct {
+	PublicID string `validate:"required"`
+}
+
+type DeleteProductHandler struct {
+	ProductRepo productDomain.ProductRepository
+
+	// Quota gives the product back to the limit of the tenant's plan; nil disables it
+	Quota quotaDomain.Limiter
+	// Events receives ProductsChanged for the deleted product; nil disables publishing
+	Events event.Publisher
+}
+
+func (h *DeleteProductHandler) Handle(ctx context.Context, cmd DeleteProductCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	p, err := h.ProductRepo.GetByPublicID(ctx, cmd.PublicID)
+	if err != nil {
+		return err
+	}
+	if err := h.ProductRepo.Delete(ctx, p.ID); err != nil {
+		return err
+	}
+
+	// The product is gone either way, so a failed release only leaves the quota counting it
+	if h.Quota != nil {
+		if err := h.Quota.Release(ctx, quotaDomain.MetricProducts, 1); err != nil {
+			slog.ErrorContext(ctx, "releasing product quota failed", "product_id", p.ID, "error", err)
+		}
+	}
+	publishProductsChanged(ctx, h.Events, []int64{p.ID})
+	return nil
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/storage"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// DeleteProductImageCommand removes a picture from a product
+type DeleteProductImageCommand struct {
+	ProductID string `validate:"required"`
+	ImageID   string `validate:"required"`
+}
+
+type DeleteProductImageHandler struct {
+	ProductRepo productDomain.ProductRepository
+	Images      productDomain.ProductImageRepository
+	Store       storage.BlobStore
+}
+
+// Handle returns persistence.ErrNotFound for images of other products, so the product in the path
+// decides whether the caller may reach the image
+func (h *DeleteProductImageHandler) Handle(ctx context.Context, cmd DeleteProductImageCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+	p, err := h.ProductRepo.GetByPublicID(ctx, cmd.ProductID)
+	if err != nil {
+		return err
+	}
+	img, err := h.Images.GetByPublicID(ctx, cmd.ImageID)
+	if err != nil {
+		return err
+	}
+	if img.ProductID != p.ID {
+		return persistence.ErrNotFound
+	}
+
+	if err := h.Images.Delete(ctx, img.ID); err != nil {
+		return fmt.Errorf("delete image %s: %w", img.PublicID, err)
+	}
+	// The row is gone, so objects that fail to delete are only orphans no URL is signed for
+	for _, key := range []string{img.Key, img.ThumbnailKey} {
+		if err := h.Store.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "deleting product image object failed", "key", key, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // registers GIF with image.Decode, like the jpeg and png imports
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/storage"
+)
+
+const (
+	DefaultMaxImageSize  = 5 << 20
+	DefaultThumbnailSize = 256
+	// MaxImagePixels bounds the dimensions of uploads, so a small file cannot decode into an
+	// image that exhausts memory
+	MaxImagePixels = 40_000_000
+)
+
+// UploadProductImageCommand adds a picture to a product
+type UploadProductImageCommand struct {
+	ProductID string `validate:"required"`
+	Content   io.Reader
+}
+
+// UploadProductImageHandler stores an image and a thumbnail of it. The format is sniffed from the
+// content rather than trusted from the client, and must be one of productDomain.ImageContentTypes.
+type UploadProductImageHandler struct {
+	ProductRepo productDomain.ProductRepository
+	Images      productDomain.ProductImageRepository
+	Store       storage.BlobStore
+	// MaxSize defaults to DefaultMaxImageSize bytes
+	MaxSize int64
+	// ThumbnailSize is the edge of the box thumbnails fit in, DefaultThumbnailSize by default
+	ThumbnailSize int
+}
+
+func (h *UploadProductImageHandler) Handle(ctx context.Context, cmd UploadProductImageCommand) (*productDomain.ProductImage, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+	p, err := h.ProductRepo.GetByPublicID(ctx, cmd.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := h.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxImageSize
+	}
+	content, err := io.ReadAll(io.LimitReader(cmd.Content, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", productDomain.ErrImageTooLarge, maxSize)
+	}
+
+	contentType := http.DetectContentType(content)
+	ext, ok := productDomain.ImageContentTypes[contentType]
+	if !ok {
+		return nil, imageError(fmt.Sprintf("must be one of %s, got %s", strings.Join(imageContentTypes(), ", "), contentType))
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, imageError("is not a readable image")
+	}
+	if config.Width*config.Height > MaxImagePixels {
+		return nil, imageError(fmt.Sprintf("must have at most %d pixels, got %dx%d", MaxImagePixels, config.Width, config.Height))
+	}
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, imageError("is not a readable image")
+	}
+
+	thumbnail, thumbnailType, thumbnailExt, err := h.thumbnail(src, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &productDomain.ProductImage{
+		PublicID:    publicid.New(productDomain.ImagePublicIDPrefix),
+		ProductID:   p.ID,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+	img.Key = fmt.Sprintf("products/%s/%s%s", p.PublicID, img.PublicID, ext)
+	img.ThumbnailKey = fmt.Sprintf("products/%s/%s-thumb%s", p.PublicID, img.PublicID, thumbnailExt)
+
+	if _, err := h.Store.Put(ctx, img.Key, contentType, bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("store image of product %d: %w", p.ID, err)
+	}
+	if _, err := h.Store.Put(ctx, img.ThumbnailKey, thumbnailType, bytes.NewReader(thumbnail)); err != nil {
+		h.discard(ctx, img.Key)
+		return nil, fmt.Errorf("store thumbnail of product %d: %w", p.ID, err)
+	}
+	if err := h.Images.Create(ctx, img); err != nil {
+		h.discard(ctx, img.Key, img.ThumbnailKey)
+		return nil, fmt.Errorf("record image of product %d: %w", p.ID, err)
+	}
+	return img, nil
+}
+
+// thumbnail encodes a scaled down copy of src; photos become JPEGs while PNGs and GIFs, which are
+// mostly drawings and may be transparent, stay PNGs
+func (h *UploadProductImageHandler) thumbnail(src image.Image, contentType string) (content []byte, thumbnailType, ext string, err error) {
+	size := h.ThumbnailSize
+	if size <= 0 {
+		size = DefaultThumbnailSize
+	}
+	thumb := productDomain.Thumbnail(src, size)
+
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+		thumbnailType, ext = "image/jpeg", ".jpg"
+	} else {
+		err = png.Encode(&buf, thumb)
+		thumbnailType, ext = "image/png", ".png"
+	}
+	if err != nil {
+		return nil, "", "", fmt.Errorf("encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), thumbnailType, ext, nil
+}
+
+// discard removes the objects of an upload that failed halfway; a failure only leaves orphans behind
+func (h *UploadProductImageHandler) discard(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := h.Store.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "discarding product image failed", "key", key, "error", err)
+		}
+	}
+}
+
+func imageError(message string) error {
+	var errs validation.Errors
+	errs.Add("file", message)
+	return errs
+}
+
+func imageContentTypes() []string {
+	types := make([]string, 0, len(productDomain.ImageContentTypes))
+	for t := range productDomain.ImageContentTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,139 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+func (m *MockProductRepository) GetByPublicID(ctx context.Context, publicID string) (*productDomain.Product, error) {
+	for _, p := range m.products {
+		if p.PublicID == publicID {
+			return p, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+// MockProductImageRepository keeps images in memory
+type MockProductImageRepository struct {
+	productDomain.ProductImageRepository
+	images []*productDomain.ProductImage
+}
+
+func (m *MockProductImageRepository) Create(ctx context.Context, img *productDomain.ProductImage) error {
+	img.ID = int64(len(m.images) + 1)
+	m.images = append(m.images, img)
+	return nil
+}
+
+// MockBlobStore keeps objects in memory
+type MockBlobStore struct {
+	objects map[string][]byte
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key, contentType string, content io.Reader) (int64, error) {
+	b, err := io.ReadAll(content)
+	if m.objects == nil {
+		m.objects = map[string][]byte{}
+	}
+	m.objects[key] = b
+	return int64(len(b)), err
+}
+
+func (m *MockBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	b, ok := m.objects[key]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *MockBlobStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return "https://blobs.example.com/" + key, nil
+}
+
+func pngImage(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadProductImageHandler_Handle_StoresImageAndThumbnail(t *testing.T) {
+	p := productDomain.MustNewProduct("Lamp", 1)
+	p.ID = 1
+	images := &MockProductImageRepository{}
+	store := &MockBlobStore{}
+	handler := &UploadProductImageHandler{ProductRepo: &MockProductRepository{products: []*productDomain.Product{p}}, Images: images, Store: store, ThumbnailSize: 32}
+
+	img, err := handler.Handle(context.Background(), UploadProductImageCommand{ProductID: p.PublicID, Content: bytes.NewReader(pngImage(t, 128, 64))})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if img.ContentType != "image/png" || img.Width != 128 || img.Height != 64 || img.ProductID != p.ID {
+		t.Errorf("Unexpected image %+v", img)
+	}
+	if !strings.HasPrefix(img.Key, "products/"+p.PublicID+"/img_") || !strings.HasSuffix(img.ThumbnailKey, "-thumb.png") {
+		t.Errorf("Unexpected keys %q and %q", img.Key, img.ThumbnailKey)
+	}
+	thumb, _, err := image.DecodeConfig(bytes.NewReader(store.objects[img.ThumbnailKey]))
+	if err != nil {
+		t.Fatalf("Expected a readable thumbnail, got %v", err)
+	}
+	if thumb.Width != 32 || thumb.Height != 16 {
+		t.Errorf("Expected a 32x16 thumbnail, got %dx%d", thumb.Width, thumb.Height)
+	}
+	if len(images.images) != 1 {
+		t.Errorf("Expected the image to be recorded, got %d images", len(images.images))
+	}
+}
+
+func TestUploadProductImageHandler_Handle_RejectsInvalidUploads(t *testing.T) {
+	p := productDomain.MustNewProduct("Lamp", 1)
+	store := &MockBlobStore{}
+	handler := &UploadProductImageHandler{ProductRepo: &MockProductRepository{products: []*productDomain.Product{p}}, Images: &MockProductImageRepository{}, Store: store, MaxSize: 1 << 10}
+
+	_, err := handler.Handle(context.Background(), UploadProductImageCommand{ProductID: p.PublicID, Content: strings.NewReader("%PDF-1.4 not an image")})
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Errorf("Expected validation errors for a PDF, got %v", err)
+	}
+
+	_, err = handler.Handle(context.Background(), UploadProductImageCommand{ProductID: p.PublicID, Content: bytes.NewReader(pngImage(t, 256, 256))})
+	if !errors.Is(err, productDomain.ErrImageTooLarge) {
+		t.Errorf("Expected ErrImageTooLarge, got %v", err)
+	}
+
+	_, err = handler.Handle(context.Background(), UploadProductImageCommand{ProductID: "prd_missing", Content: bytes.NewReader(pngImage(t, 8, 8))})
+	if !errors.Is(err, persistence.ErrNotFound) {
+		t.Errorf("Expected persistence.ErrNotFound for an unknown product, got %v", err)
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("Expected nothing stored, got %d objects", len(store.objects))
+	}
+}
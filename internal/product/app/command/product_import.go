@@ -0,0 +1,289 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/spreadsheet"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// importColumns are the columns an import file may have; the others are ignored
+var importColumns = []string{"sku", "name", "stock", "price", "currency"}
+
+// requiredImportColumns must be present in the header of an import file
+var requiredImportColumns = []string{"sku", "name", "stock"}
+
+// ImportProductsCommand imports the rows of a spreadsheet whose first row names its columns:
+// sku, name and stock, and optionally price (a decimal such as 12.99) and currency. Rows whose
+// SKU exists update that product, the others create one. Prices are stored in the base currency.
+type ImportProductsCommand struct {
+	Rows spreadsheet.Reader
+	// OnBatch is called with the report so far after each batch is written; it may be nil
+	OnBatch func(productDomain.ImportReport)
+}
+
+// ImportProductsHandler validates every row and upserts the valid ones in batches of
+// productDomain.ImportBatchSize. Invalid rows are reported with their line and skipped. Each
+// batch is written on its own, so when the import fails, batches written before stay.
+type ImportProductsHandler struct {
+	ProductRepo productDomain.ProductRepository
+	Base        BaseCurrency
+
+	// Quota enforces the product limit of the tenant's plan on created products; nil disables it
+	Quota quotaDomain.Limiter
+	// Events receives ProductsChanged for every batch written; nil disables publishing
+	Events event.Publisher
+}
+
+func (h *ImportProductsHandler) Handle(ctx context.Context, cmd ImportProductsCommand) (*productDomain.ImportReport, error) {
+	header, err := nextRow(cmd.Rows)
+	if errors.Is(err, io.EOF) {
+		return nil, fileError("is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns, err := importColumnsOf(header)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &productDomain.ImportReport{}
+	lines := make(map[string]int)
+	batch := make([]importRow, 0, productDomain.ImportBatchSize)
+	for {
+		row, err := nextRow(cmd.Rows)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.Rows++
+
+		p, err := columns.product(row)
+		if err == nil {
+			var price money.Money
+			price, err = h.Base.convert(ctx, p.Price(), "currency")
+			p.SetPrice(price)
+		}
+		if err != nil && !validation.IsValidationError(err) {
+			return nil, err
+		}
+		if err != nil {
+			report.Fail(row.Line, columns.cell(row, "sku"), err)
+			continue
+		}
+		if first, seen := lines[p.SKUValue()]; seen {
+			report.Fail(row.Line, p.SKUValue(), validation.Errors{{Field: "sku", Message: fmt.Sprintf("duplicates line %d", first)}})
+			continue
+		}
+		lines[p.SKUValue()] = row.Line
+
+		batch = append(batch, importRow{line: row.Line, product: p})
+		if len(batch) == productDomain.ImportBatchSize {
+			if err := h.writeBatch(ctx, batch, report, cmd.OnBatch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := h.writeBatch(ctx, batch, report, cmd.OnBatch); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+type importRow struct {
+	line    int
+	product productDomain.Product
+}
+
+// writeBatch upserts the rows, failing the new products of the batch when they exceed the quota
+func (h *ImportProductsHandler) writeBatch(ctx context.Context, batch []importRow, report *productDomain.ImportReport, onBatch func(productDomain.ImportReport)) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	skus := make([]string, len(batch))
+	for i, row := range batch {
+		skus[i] = row.product.SKUValue()
+	}
+	existing, err := h.ProductRepo.GetBySKUs(ctx, skus)
+	if err != nil {
+		return fmt.Errorf("import products: %w", err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		taken[p.SKUValue()] = true
+	}
+
+	created := int64(len(batch) - len(existing))
+	if h.Quota != nil && created > 0 {
+		err := h.Quota.Consume(ctx, quotaDomain.MetricProducts, created)
+		switch {
+		case errors.Is(err, quotaDomain.ErrQuotaExceeded):
+			batch = failNew(batch, taken, report, err)
+			created = 0
+		case err != nil:
+			return fmt.Errorf("import products: %w", err)
+		}
+	}
+
+	products := make([]productDomain.Product, len(batch))
+	for i, row := range batch {
+		products[i] = row.product
+	}
+	if err := h.upsert(ctx, products); err != nil {
+		if h.Quota != nil && created > 0 {
+			if releaseErr := h.Quota.Release(ctx, quotaDomain.MetricProducts, created); releaseErr != nil {
+				slog.ErrorContext(ctx, "releasing product quota failed", "error", releaseErr)
+			}
+		}
+		return fmt.Errorf("import products: %w", err)
+	}
+
+	h.publishBatch(ctx, batch)
+
+	report.Created += int(created)
+	report.Updated += len(batch) - int(created)
+	slog.InfoContext(ctx, "product import progress", "rows", report.Rows, "created", report.Created, "updated", report.Updated, "failed", len(report.Failed))
+	if onBatch != nil {
+		onBatch(*report)
+	}
+	return nil
+}
+
+// publishBatch reports the products of a written batch, which the upsert leaves without IDs
+func (h *ImportProductsHandler) publishBatch(ctx context.Context, batch []importRow) {
+	if h.Events == nil || len(batch) == 0 {
+		return
+	}
+	skus := make([]string, len(batch))
+	for i, row := range batch {
+		skus[i] = row.product.SKUValue()
+	}
+	written, err := h.ProductRepo.GetBySKUs(ctx, skus)
+	if err != nil {
+		slog.WarnContext(ctx, "loading imported products failed", "error", err)
+		return
+	}
+	ids := make([]int64, len(written))
+	for i, p := range written {
+		ids[i] = p.ID
+	}
+	publishProductsChanged(ctx, h.Events, ids)
+}
+
+func (h *ImportProductsHandler) upsert(ctx context.Context, products []productDomain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	return h.ProductRepo.UpsertBySKU(ctx, products)
+}
+
+// failNew reports the rows that would create a product as failed and returns the others
+func failNew(batch []importRow, taken map[string]bool, report *productDomain.ImportReport, err error) []importRow {
+	kept := batch[:0]
+	for _, row := range batch {
+		if taken[row.product.SKUValue()] {
+			kept = append(kept, row)
+			continue
+		}
+		report.Fail(row.line, row.product.SKUValue(), err)
+	}
+	return kept
+}
+
+// nextRow skips blank rows, which spreadsheets often end with
+func nextRow(rows spreadsheet.Reader) (spreadsheet.Row, error) {
+	for {
+		row, err := rows.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return row, err
+			}
+			return row, fileError(err.Error())
+		}
+		if !row.Empty() {
+			return row, nil
+		}
+	}
+}
+
+func fileError(message string) error {
+	var errs validation.Errors
+	errs.Add("file", message)
+	return errs
+}
+
+// importColumnIndex maps the import columns present in the header to their index
+type importColumnIndex map[string]int
+
+func importColumnsOf(header spreadsheet.Row) (importColumnIndex, error) {
+	columns := make(importColumnIndex, len(importColumns))
+	for i, cell := range header.Cells {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		for _, known := range importColumns {
+			if name == known {
+				columns[name] = i
+			}
+		}
+	}
+
+	var errs validation.Errors
+	for _, name := range requiredImportColumns {
+		_, ok := columns[name]
+		errs.Check(ok, "file", fmt.Sprintf("is missing the %s column", name))
+	}
+	return columns, errs.Err()
+}
+
+func (c importColumnIndex) cell(row spreadsheet.Row, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(row.Cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.Cells[i])
+}
+
+// product reads a row into a product, returning validation.Errors for invalid cells
+func (c importColumnIndex) product(row spreadsheet.Row) (productDomain.Product, error) {
+	var errs validation.Errors
+	p := productDomain.Product{Name: c.cell(row, "name")}
+
+	p.SetSKU(c.cell(row, "sku"))
+	errs.Check(p.SKU != nil, "sku", "is required")
+
+	stock, err := strconv.Atoi(c.cell(row, "stock"))
+	errs.Check(err == nil, "stock", "must be a whole number")
+	p.Stock = stock
+
+	if amount := c.cell(row, "price"); amount != "" {
+		currency := c.cell(row, "currency")
+		price, err := money.Parse(amount, currency)
+		switch {
+		case currency == "":
+			errs.Add("currency", "is required with a price")
+		case err != nil:
+			errs.Add("price", err.Error())
+		default:
+			p.SetPrice(price)
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return p, err
+	}
+	return p, p.Validate()
+}
@@ -0,0 +1,147 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/spreadsheet"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+func (m *MockProductRepository) GetBySKUs(ctx context.Context, skus []string) ([]productDomain.Product, error) {
+	var products []productDomain.Product
+	for _, p := range m.products {
+		if slices.Contains(skus, p.SKUValue()) {
+			products = append(products, *p)
+		}
+	}
+	return products, nil
+}
+
+// UpsertBySKU records the size of every batch
+func (m *MockProductRepository) UpsertBySKU(ctx context.Context, products []productDomain.Product) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.batches = append(m.batches, len(products))
+	for _, p := range products {
+		i := slices.IndexFunc(m.products, func(existing *productDomain.Product) bool {
+			return existing.SKUValue() == p.SKUValue()
+		})
+		if i >= 0 {
+			m.products[i].Name, m.products[i].Stock = p.Name, p.Stock
+			continue
+		}
+		p.ID = int64(len(m.products) + 1)
+		m.products = append(m.products, &p)
+	}
+	return nil
+}
+
+func csvRows(lines ...string) spreadsheet.Reader {
+	return spreadsheet.NewCSVReader(strings.NewReader(strings.Join(lines, "\n")))
+}
+
+func TestImportProductsHandler_Handle_ReportsFailedRows(t *testing.T) {
+	existing := productDomain.MustNewProduct("Old desk", 1)
+	existing.SetSKU("DESK-1")
+	repo := &MockProductRepository{products: []*productDomain.Product{existing}}
+	handler := &ImportProductsHandler{ProductRepo: repo}
+
+	report, err := handler.Handle(context.Background(), ImportProductsCommand{Rows: csvRows(
+		"Name,SKU,Stock,Price,Currency,Notes",
+		"Desk,DESK-1,4,199.00,EUR,",
+		"Lamp,LAMP-1,10,12.5,EUR,new",
+		",CHAIR-1,-1,,,",
+		"",
+		"Lamp again,LAMP-1,3,,,",
+		"Rug,RUG-1,2,12.999,EUR,",
+	)})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Rows != 5 || report.Created != 1 || report.Updated != 1 {
+		t.Errorf("Expected 5 rows, 1 created and 1 updated, got %+v", report)
+	}
+	if existing.Name != "Desk" || existing.Stock != 4 {
+		t.Errorf("Expected the existing product updated, got %+v", existing)
+	}
+
+	want := []struct {
+		line   int
+		sku    string
+		fields []string
+	}{
+		{4, "CHAIR-1", []string{"stock", "name"}},
+		{6, "LAMP-1", []string{"sku"}},
+		{7, "RUG-1", []string{"price"}},
+	}
+	if len(report.Failed) != len(want) {
+		t.Fatalf("Expected %d failed rows, got %+v", len(want), report.Failed)
+	}
+	for i, w := range want {
+		got := report.Failed[i]
+		var fields []string
+		for _, fieldErr := range got.Errors {
+			fields = append(fields, fieldErr.Field)
+		}
+		if got.Line != w.line || got.SKU != w.sku || !slices.Equal(slices.Sorted(slices.Values(fields)), slices.Sorted(slices.Values(w.fields))) {
+			t.Errorf("Expected line %d (%s) to fail on %v, got %+v", w.line, w.sku, w.fields, got)
+		}
+	}
+}
+
+func TestImportProductsHandler_Handle_WritesBatches(t *testing.T) {
+	lines := []string{"sku,name,stock"}
+	for i := 0; i < 1203; i++ {
+		lines = append(lines, fmt.Sprintf("SKU-%d,Product %d,1", i, i))
+	}
+	repo := &MockProductRepository{}
+	handler := &ImportProductsHandler{ProductRepo: repo, Quota: &MockLimiter{limit: 1100}}
+
+	var progress []int
+	report, err := handler.Handle(context.Background(), ImportProductsCommand{
+		Rows:    csvRows(lines...),
+		OnBatch: func(r productDomain.ImportReport) { progress = append(progress, r.Rows) },
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(repo.batches, []int{500, 500}) {
+		t.Errorf("Expected two full batches with the last over the quota, got %v", repo.batches)
+	}
+	if !slices.Equal(progress, []int{500, 1000, 1203}) {
+		t.Errorf("Expected progress after every batch, got %v", progress)
+	}
+	if report.Created != 1000 || len(report.Failed) != 203 {
+		t.Errorf("Expected 1000 created and 203 over the quota, got %d created, %d failed", report.Created, len(report.Failed))
+	}
+}
+
+func TestImportProductsHandler_Handle_RejectsBadFiles(t *testing.T) {
+	handler := &ImportProductsHandler{ProductRepo: &MockProductRepository{}}
+
+	for name, rows := range map[string]spreadsheet.Reader{
+		"empty":          csvRows("", ""),
+		"missing column": csvRows("sku,name", "A,Desk"),
+		"malformed":      csvRows("sku,name,stock", `A,"Desk,1`),
+	} {
+		_, err := handler.Handle(context.Background(), ImportProductsCommand{Rows: rows})
+		if !validation.IsValidationError(err) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+
+	handler.ProductRepo = &MockProductRepository{saveErr: errors.New("db down")}
+	_, err := handler.Handle(context.Background(), ImportProductsCommand{Rows: csvRows("sku,name,stock", "A,Desk,1")})
+	if err == nil || validation.IsValidationError(err) {
+		t.Errorf("Expected the write error, got %v", err)
+	}
+}
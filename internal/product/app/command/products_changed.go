@@ -0,0 +1,21 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+)
+
+// publishProductsChanged reports products that were written to events, which may be nil; the
+// products are saved already, so failures are only logged
+func publishProductsChanged(ctx context.Context, events event.Publisher, ids []int64) {
+	if events == nil || len(ids) == 0 {
+		return
+	}
+	if err := events.Publish(ctx, productDomain.ProductsChanged{ProductIDs: ids, ChangedAt: time.Now().UTC()}); err != nil {
+		slog.WarnContext(ctx, "publishing product changes failed", "product_ids", ids, "error", err)
+	}
+}
@@ -0,0 +1,34 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+)
+
+// ReleaseExpiredReservationsCommand frees the stock held by reservations whose orders never completed
+type ReleaseExpiredReservationsCommand struct{}
+
+type ReleaseExpiredReservationsHandler struct {
+	Reservations productDomain.StockReservationRepository
+	Now          func() time.Time
+}
+
+func (h *ReleaseExpiredReservationsHandler) Handle(ctx context.Context, cmd ReleaseExpiredReservationsCommand) error {
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+
+	released, err := h.Reservations.ReleaseExpired(ctx, now())
+	if err != nil {
+		return fmt.Errorf("release expired reservations: %w", err)
+	}
+	if released > 0 {
+		slog.InfoContext(ctx, "released expired stock reservations", "count", released)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+)
+
+// MockStockReservationRepository records the cut-off passed to ReleaseExpired
+type MockStockReservationRepository struct {
+	productDomain.StockReservationRepository
+	releasedBefore time.Time
+}
+
+func (m *MockStockReservationRepository) ReleaseExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.releasedBefore = now
+	return 2, nil
+}
+
+func TestReleaseExpiredReservationsHandler_Handle(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	reservations := &MockStockReservationRepository{}
+	handler := &ReleaseExpiredReservationsHandler{Reservations: reservations, Now: func() time.Time { return now }}
+
+	// Act
+	err := handler.Handle(context.Background(), ReleaseExpiredReservationsCommand{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reservations.releasedBefore.Equal(now) {
+		t.Errorf("Expected reservations expired by %s to be released, got %s", now, reservations.releasedBefore)
+	}
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+)
+
+// SyncSearchIndexCommand brings the documents of changed products up to date in the search index
+type SyncSearchIndexCommand struct {
+	ProductIDs []int64
+}
+
+// SyncSearchIndexHandler updates the documents of the products still in the catalogue and
+// deletes those of deleted products
+type SyncSearchIndexHandler struct {
+	ProductRepo productDomain.ProductRepository
+	Index       productDomain.SearchIndex
+}
+
+func (h *SyncSearchIndexHandler) Handle(ctx context.Context, cmd SyncSearchIndexCommand) error {
+	products, err := h.ProductRepo.GetByIDs(ctx, cmd.ProductIDs)
+	if err != nil {
+		return fmt.Errorf("load changed products: %w", err)
+	}
+
+	var docs []productDomain.SearchDocument
+	var deleted []string
+	for i := range products {
+		if products[i].DeletedAt.Valid {
+			deleted = append(deleted, products[i].PublicID)
+			continue
+		}
+		docs = append(docs, productDomain.NewSearchDocument(&products[i]))
+	}
+	if len(docs) > 0 {
+		if err := h.Index.Update(ctx, docs); err != nil {
+			return fmt.Errorf("update search index: %w", err)
+		}
+	}
+	if len(deleted) > 0 {
+		if err := h.Index.Delete(ctx, deleted); err != nil {
+			return fmt.Errorf("delete from search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReindexSearchCommand writes the documents of every product of the catalogue to the search
+// index, e.g. to fill a new index or to catch up after the search engine was unreachable
+type ReindexSearchCommand struct{}
+
+type ReindexSearchHandler struct {
+	ProductRepo productDomain.ProductRepository
+	Index       productDomain.SearchIndex
+	// BatchSize is how many documents are sent at once; it defaults to ReindexBatchSize
+	BatchSize int
+}
+
+// ReindexBatchSize is the default ReindexSearchHandler.BatchSize
+const ReindexBatchSize = 500
+
+func (h *ReindexSearchHandler) Handle(ctx context.Context, cmd ReindexSearchCommand) error {
+	size := h.BatchSize
+	if size <= 0 {
+		size = ReindexBatchSize
+	}
+
+	indexed := 0
+	err := h.ProductRepo.FindInBatches(ctx, productDomain.ProductFilter{}, size, func(products []productDomain.Product) error {
+		docs := make([]productDomain.SearchDocument, len(products))
+		for i := range products {
+			docs[i] = productDomain.NewSearchDocument(&products[i])
+		}
+		if err := h.Index.Index(ctx, docs); err != nil {
+			return err
+		}
+		indexed += len(docs)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reindex products: %w", err)
+	}
+	slog.InfoContext(ctx, "products reindexed", "count", indexed)
+	return nil
+}
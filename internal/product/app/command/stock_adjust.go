@@ -0,0 +1,104 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+type AdjustStockCommand struct {
+	Adjustments []productDomain.StockAdjustment `validate:"required,min=1,dive"`
+}
+
+type AdjustStockHandler struct {
+	ProductRepo productDomain.ProductRepository
+
+	// Events receives StockLow for every product the adjustments take to its low-stock threshold
+	// or below; nil disables publishing
+	Events event.Publisher
+	// LowStockThreshold applies to products without a reorder threshold of their own
+	LowStockThreshold int
+}
+
+func (h *AdjustStockHandler) Handle(ctx context.Context, cmd AdjustStockCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	// Load current stock levels for every product in the batch
+	ids := make([]int64, 0, len(cmd.Adjustments))
+	for _, adj := range cmd.Adjustments {
+		ids = append(ids, adj.ProductID)
+	}
+	levels, err := h.ProductRepo.GetStockLevels(ctx, ids)
+	if err != nil {
+		return err
+	}
+	before := maps.Clone(levels)
+
+	// Validate that every product exists and no adjustment drives stock below zero
+	var errs validation.Errors
+	for i, adj := range cmd.Adjustments {
+		field := fmt.Sprintf("adjustments[%d]", i)
+
+		stock, exists := levels[adj.ProductID]
+		if !exists {
+			errs.Add(field+".product_id", "product not found")
+			continue
+		}
+
+		levels[adj.ProductID] = stock + adj.Delta
+		errs.Check(levels[adj.ProductID] >= 0, field+".delta", "would result in negative stock")
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	// Apply all adjustments in bulk using repository
+	if err := h.ProductRepo.BulkUpdateStock(ctx, cmd.Adjustments); err != nil {
+		return err
+	}
+	h.publishStockLow(ctx, before, levels)
+	return nil
+}
+
+// publishStockLow reports the products whose stock dropped to their threshold or below; the stock
+// is already adjusted, so failures are only logged
+func (h *AdjustStockHandler) publishStockLow(ctx context.Context, before, after map[int64]int) {
+	if h.Events == nil {
+		return
+	}
+	// Thresholds differ by product, so every product whose stock went down is loaded to compare
+	var dropped []int64
+	for id, stock := range after {
+		if stock < before[id] {
+			dropped = append(dropped, id)
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+
+	products, err := h.ProductRepo.GetByIDs(ctx, dropped)
+	if err != nil {
+		slog.WarnContext(ctx, "loading low stock products failed", "product_ids", dropped, "error", err)
+		return
+	}
+	now := time.Now().UTC()
+	for i := range products {
+		p := &products[i]
+		e := productDomain.NewStockLow(p, before[p.ID], after[p.ID], h.LowStockThreshold, now)
+		if e == nil {
+			continue
+		}
+		if err := h.Events.Publish(ctx, *e); err != nil {
+			slog.WarnContext(ctx, "publishing low stock failed", "product_id", p.ID, "error", err)
+		}
+	}
+}
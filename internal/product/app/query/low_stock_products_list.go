@@ -0,0 +1,41 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+const (
+	DefaultLowStockLimit = 50
+	MaxLowStockLimit     = 200
+)
+
+// ListLowStockProductsQuery lists the products to replenish
+type ListLowStockProductsQuery struct {
+	// Limit defaults to DefaultLowStockLimit
+	Limit int
+}
+
+// ListLowStockProductsHandler lists the products whose stock is at or below their low-stock
+// threshold, lowest stock first
+type ListLowStockProductsHandler struct {
+	ProductRepo domain.ProductRepository
+	// LowStockThreshold applies to products without a reorder threshold of their own
+	LowStockThreshold int
+}
+
+func (h *ListLowStockProductsHandler) Handle(ctx context.Context, q ListLowStockProductsQuery) ([]domain.Product, error) {
+	var errs validation.Errors
+	errs.Check(q.Limit >= 0 && q.Limit <= MaxLowStockLimit, "limit", fmt.Sprintf("must be between 1 and %d, got %d", MaxLowStockLimit, q.Limit))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultLowStockLimit
+	}
+
+	return h.ProductRepo.ListLowStock(ctx, h.LowStockThreshold, q.Limit)
+}
@@ -0,0 +1,67 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/storage"
+)
+
+// DefaultImageURLTTL is how long the URLs of listed images stay readable by default
+const DefaultImageURLTTL = 15 * time.Minute
+
+// ListProductImagesQuery lists the pictures of a product
+type ListProductImagesQuery struct {
+	ProductID string `validate:"required"`
+}
+
+// ProductImageView is an image with URLs that read it and its thumbnail until ExpiresAt
+type ProductImageView struct {
+	domain.ProductImage
+	URL          string
+	ThumbnailURL string
+	ExpiresAt    time.Time
+}
+
+// ListProductImagesHandler signs a fresh pair of URLs for every image, oldest image first
+type ListProductImagesHandler struct {
+	ProductRepo domain.ProductRepository
+	Images      domain.ProductImageRepository
+	Store       storage.BlobStore
+	// URLTTL defaults to DefaultImageURLTTL
+	URLTTL time.Duration
+}
+
+func (h *ListProductImagesHandler) Handle(ctx context.Context, q ListProductImagesQuery) ([]ProductImageView, error) {
+	if err := validation.Struct(q); err != nil {
+		return nil, err
+	}
+	p, err := h.ProductRepo.GetByPublicID(ctx, q.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	images, err := h.Images.ListByProduct(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := h.URLTTL
+	if ttl <= 0 {
+		ttl = DefaultImageURLTTL
+	}
+	expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+	views := make([]ProductImageView, len(images))
+	for i, img := range images {
+		views[i] = ProductImageView{ProductImage: img, ExpiresAt: expiresAt}
+		if views[i].URL, err = h.Store.SignedURL(ctx, img.Key, ttl); err != nil {
+			return nil, fmt.Errorf("sign URL of image %s: %w", img.PublicID, err)
+		}
+		if views[i].ThumbnailURL, err = h.Store.SignedURL(ctx, img.ThumbnailKey, ttl); err != nil {
+			return nil, fmt.Errorf("sign URL of image %s: %w", img.PublicID, err)
+		}
+	}
+	return views, nil
+}
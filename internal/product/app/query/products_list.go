@@ -0,0 +1,26 @@
+package query
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+// ListProductsQuery lists the products of the catalogue matching Filter, ordered by ID
+type ListProductsQuery struct {
+	Filter domain.ProductFilter
+}
+
+// CacheKey describes the products the query reads, see cache.CachedQuery
+func (q ListProductsQuery) CacheKey() *query.QueryBuilder {
+	return q.Filter.Apply(query.NewQueryBuilder(nil))
+}
+
+type ListProductsHandler struct {
+	ProductRepo domain.ProductRepository
+}
+
+func (h *ListProductsHandler) Handle(ctx context.Context, q ListProductsQuery) ([]domain.Product, error) {
+	return h.ProductRepo.List(ctx, q.Filter)
+}
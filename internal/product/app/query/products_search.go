@@ -0,0 +1,94 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+const (
+	DefaultSearchPageSize = 20
+	MaxSearchPageSize     = 100
+)
+
+// SearchProductsQuery pages through the products matching Text, best matches first
+type SearchProductsQuery struct {
+	Text string
+	// Page starts at 1; PageSize defaults to DefaultSearchPageSize
+	Page     int
+	PageSize int
+}
+
+// ProductSearchPage is a page of the products matching a search
+type ProductSearchPage struct {
+	Products []domain.Product
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// SearchProductsHandler searches the search index, or the names of the products in the database
+// when no index is configured
+type SearchProductsHandler struct {
+	ProductRepo domain.ProductRepository
+	// Index is the search engine; nil falls back to matching names with SQL, ordered by ID
+	Index domain.SearchIndex
+}
+
+func (h *SearchProductsHandler) Handle(ctx context.Context, q SearchProductsQuery) (*ProductSearchPage, error) {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.PageSize == 0 {
+		q.PageSize = DefaultSearchPageSize
+	}
+	var errs validation.Errors
+	errs.Check(q.Page >= 1, "page", fmt.Sprintf("must be a positive number, got %d", q.Page))
+	errs.Check(q.PageSize >= 1 && q.PageSize <= MaxSearchPageSize, "page_size", fmt.Sprintf("must be between 1 and %d, got %d", MaxSearchPageSize, q.PageSize))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &ProductSearchPage{Page: q.Page, PageSize: q.PageSize}
+	offset := (q.Page - 1) * q.PageSize
+	if h.Index == nil {
+		filter := domain.ProductFilter{Name: q.Text, Offset: offset, Limit: q.PageSize}
+		var err error
+		if page.Total, err = h.ProductRepo.Count(ctx, filter); err != nil {
+			return nil, err
+		}
+		if page.Products, err = h.ProductRepo.List(ctx, filter); err != nil {
+			return nil, err
+		}
+		return page, nil
+	}
+
+	// The index holds every tenant, so searches are narrowed to the one the database would scope to
+	req := domain.SearchRequest{Text: q.Text, Offset: offset, Limit: q.PageSize}
+	if !tenant.IsUnscoped(ctx) {
+		req.TenantID = tenant.FromContext(ctx)
+	}
+	result, err := h.Index.Search(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("search products: %w", err)
+	}
+	page.Total = result.Total
+	if len(result.IDs) == 0 {
+		return page, nil
+	}
+
+	products, err := h.ProductRepo.GetByPublicIDs(ctx, result.IDs)
+	if err != nil {
+		return nil, err
+	}
+	// The index may still list products deleted a moment ago
+	for _, p := range products {
+		if !p.DeletedAt.Valid {
+			page.Products = append(page.Products, p)
+		}
+	}
+	return page, nil
+}
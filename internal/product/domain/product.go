@@ -1,17 +1,161 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+// PublicIDPrefix starts the public IDs of products, e.g. "prd_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const PublicIDPrefix = "prd"
+
+// CacheTag tags the cached query results that read products; product writes invalidate it
+const CacheTag = "product"
+
+// MaxSKULength bounds stock keeping units to the size of their column
+const MaxSKULength = 64
 
 type Product struct {
-	ID    int64  `gorm:"primaryKey"`
-	Name  string `gorm:"not null"`
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the product in external APIs so the primary key never leaves the service
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	// TenantID is the tenant the product belongs to; see tenant.Plugin
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_products_tenant_sku,priority:1"`
+	// SKU is the merchant's own stock keeping unit, unique within the tenant; imports match
+	// products by it. Products without one are stored with NULL so any number of them fit the
+	// unique index.
+	SKU   *string `gorm:"type:varchar(64);uniqueIndex:idx_products_tenant_sku,priority:2"`
+	Name  string  `gorm:"not null"`
 	Stock int
+	// PriceAmount and PriceCurrency hold the unit price in separate columns, unlike other money
+	// values, so the catalogue can be filtered and sorted by amount; use Price and SetPrice
+	PriceAmount   int64  `gorm:"not null;default:0"`
+	PriceCurrency string `gorm:"type:char(3)"`
+	// TaxClass selects the tax rates of the product, e.g. "reduced"; empty is the standard class
+	TaxClass string `gorm:"type:varchar(32);not null;default:''"`
+	// ReorderThreshold is the stock level at or below which the product runs low and is to be
+	// replenished; nil leaves it to the threshold of the deployment
+	ReorderThreshold *int
+
+	CreatedAt time.Time
+	// UpdatedAt orders the changes offline clients sync; deleting a product changes it too
+	UpdatedAt time.Time `gorm:"index"`
+	// DeletedAt is set once the product is removed from the catalogue. The row stays so orders
+	// keep their product and offline clients learn about the removal; GORM leaves deleted
+	// products out of queries that don't ask for them with Unscoped.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
-func (p *Product) Reserve(qty int) error {
-	if p.Stock < qty {
-		return errors.New("insufficient stock")
+// NewProduct creates a product, returning validation.Errors when the invariants are not met
+func NewProduct(name string, stock int) (*Product, error) {
+	p := &Product{
+		Name:  strings.TrimSpace(name),
+		Stock: stock,
+	}
+	p.AssignPublicID()
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MustNewProduct is like NewProduct but panics on invalid input; intended for tests and fixtures
+func MustNewProduct(name string, stock int) *Product {
+	p, err := NewProduct(name, stock)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// AssignPublicID gives the product a public ID unless it already has one
+func (p *Product) AssignPublicID() {
+	if p.PublicID == "" {
+		p.PublicID = publicid.New(PublicIDPrefix)
+	}
+}
+
+// SetSKU sets the stock keeping unit; a blank one removes it
+func (p *Product) SetSKU(sku string) {
+	if sku = strings.TrimSpace(sku); sku != "" {
+		p.SKU = &sku
+		return
+	}
+	p.SKU = nil
+}
+
+// SKUValue is the stock keeping unit, or "" when the product has none
+func (p *Product) SKUValue() string {
+	if p.SKU == nil {
+		return ""
 	}
+	return *p.SKU
+}
+
+// Price is the unit price; the zero Money when the product is not priced
+func (p *Product) Price() money.Money {
+	if p.PriceCurrency == "" {
+		return money.Money{}
+	}
+	return money.Money{Amount: p.PriceAmount, Currency: p.PriceCurrency}
+}
+
+func (p *Product) SetPrice(price money.Money) {
+	p.PriceAmount, p.PriceCurrency = price.Amount, price.Currency
+}
+
+// LowStockThreshold is the ReorderThreshold of the product, or fallback when it has none
+func (p *Product) LowStockThreshold(fallback int) int {
+	if p.ReorderThreshold == nil {
+		return fallback
+	}
+	return *p.ReorderThreshold
+}
+
+// Reserve takes qty off the stock. It returns StockLow when that takes the stock to the
+// LowStockThreshold of the product or below, and nil otherwise.
+func (p *Product) Reserve(qty, fallbackThreshold int, at time.Time) (*StockLow, error) {
+	if err := p.CheckStock(qty); err != nil {
+		return nil, err
+	}
+	before := p.Stock
 	p.Stock -= qty
+	return NewStockLow(p, before, p.Stock, fallbackThreshold, at), nil
+}
+
+// CheckStock returns ErrInsufficientStock, with the stock left, when less than qty is in stock
+func (p *Product) CheckStock(qty int) error {
+	if p.Stock < qty {
+		return fmt.Errorf("%w: %d left, %d requested", ErrInsufficientStock, p.Stock, qty)
+	}
 	return nil
 }
+
+// Validate checks the product invariants and returns validation.Errors describing every violation
+func (p *Product) Validate() error {
+	var errs validation.Errors
+
+	errs.Check(strings.TrimSpace(p.Name) != "", "name", "is required")
+	errs.Check(p.SKU == nil || len(*p.SKU) <= MaxSKULength, "sku", fmt.Sprintf("must be at most %d characters", MaxSKULength))
+	errs.Check(p.Stock >= 0, "stock", "must not be negative")
+	errs.Check(p.PriceAmount >= 0, "price.amount", "must not be negative")
+	errs.Check(p.ReorderThreshold == nil || *p.ReorderThreshold >= 0, "reorder_threshold", "must not be negative")
+	errs.Check(p.PriceCurrency != "" || p.PriceAmount == 0, "price.currency", "is required")
+	if err := pricing.ValidateClass(p.TaxClass); err != nil {
+		errs.Add("tax_class", err.Error())
+	}
+
+	return errs.Err()
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrImageTooLarge is returned for uploads over the size limit of the deployment
+var ErrImageTooLarge = errors.New("image too large")
+
+// ImagePublicIDPrefix starts the public IDs of product images, e.g. "img_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const ImagePublicIDPrefix = "img"
+
+// ImageContentTypes are the image formats products accept, with the extension their objects are
+// stored under
+var ImageContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProductImage is a picture of a product kept in a storage.BlobStore along with a thumbnail of
+// it; the row only records where the objects are. Images belong to the tenant of their product.
+type ProductImage struct {
+	ID        int64  `gorm:"primaryKey"`
+	PublicID  string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	ProductID int64  `gorm:"index;not null"`
+	// ContentType is the sniffed format of the upload, one of ImageContentTypes
+	ContentType string `gorm:"type:varchar(32);not null"`
+	// Size is the size of the original in bytes; Width and Height are its dimensions in pixels
+	Size   int64 `gorm:"not null"`
+	Width  int   `gorm:"not null"`
+	Height int   `gorm:"not null"`
+	// Key and ThumbnailKey locate the original and the thumbnail in the blob store
+	Key          string `gorm:"type:varchar(255);not null"`
+	ThumbnailKey string `gorm:"type:varchar(255);not null"`
+	CreatedAt    time.Time
+}
+
+type ProductImageRepository interface {
+	Create(ctx context.Context, img *ProductImage) error
+	// ListByProduct returns the images of a product, oldest first
+	ListByProduct(ctx context.Context, productID int64) ([]ProductImage, error)
+	// GetByPublicID returns persistence.ErrNotFound for unknown images
+	GetByPublicID(ctx context.Context, publicID string) (*ProductImage, error)
+	Delete(ctx context.Context, id int64) error
+}
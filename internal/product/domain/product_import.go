@@ -0,0 +1,32 @@
+package domain
+
+import "github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+
+// ImportBatchSize is the number of products an import writes with a single upsert
+const ImportBatchSize = 500
+
+// MaxImportSize bounds the size of an uploaded import file
+const MaxImportSize = 20 << 20
+
+// ImportRowError describes why a line of an import file was not imported
+type ImportRowError struct {
+	Line   int
+	SKU    string
+	Errors validation.Errors
+}
+
+// ImportReport is the outcome of an import so far. Rows counts the non-empty lines after the
+// header; each of them was created, updated or failed.
+type ImportReport struct {
+	Rows    int
+	Created int
+	Updated int
+	Failed  []ImportRowError
+}
+
+// Fail records the errors of a line
+func (r *ImportReport) Fail(line int, sku string, err error) {
+	var errs validation.Errors
+	errs.Merge("", err)
+	r.Failed = append(r.Failed, ImportRowError{Line: line, SKU: sku, Errors: errs})
+}
@@ -1,9 +1,83 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+// ProductFilter narrows the products returned by List; zero fields match every product
+type ProductFilter struct {
+	// Name matches products whose name contains it
+	Name    string
+	InStock bool
+	Offset  int
+	Limit   int
+}
+
+// Apply narrows qb to the products matching the filter, ordered by ID
+func (f ProductFilter) Apply(qb *query.QueryBuilder) *query.QueryBuilder {
+	if f.Name != "" {
+		qb.AddFilter("name", query.OperatorContains, f.Name)
+	}
+	if f.InStock {
+		qb.AddFilter("stock", query.OperatorGreaterThan, 0)
+	}
+	if f.Offset > 0 || f.Limit > 0 {
+		qb.SetOffset(f.Offset, f.Limit)
+	}
+	return qb.AddSort("id", query.SortOrderAsc)
+}
 
 type ProductRepository interface {
 	GetByID(ctx context.Context, id int64) (*Product, error)
+	// GetByPublicID looks a product up by the ID shown in external APIs
+	GetByPublicID(ctx context.Context, publicID string) (*Product, error)
+	// GetByPublicIDAsOf returns the product as it was at the given moment; it returns
+	// persistence.ErrNotFound for products created later
+	GetByPublicIDAsOf(ctx context.Context, publicID string, at time.Time) (*Product, error)
+	// GetByIDForUpdate reads a product with SELECT ... FOR UPDATE; the row stays locked until the
+	// transaction of ctx ends, so it must be called inside persistence.Transactor.InTransaction
+	GetByIDForUpdate(ctx context.Context, id int64) (*Product, error)
+	// GetByIDs returns the products with the given IDs in one query, in the order of ids; unknown
+	// and repeated IDs are omitted. Deleted products are included, since orders refer to them.
+	GetByIDs(ctx context.Context, ids []int64) ([]Product, error)
+	// GetByPublicIDs is GetByIDs for public IDs
+	GetByPublicIDs(ctx context.Context, publicIDs []string) ([]Product, error)
+	// GetBySKUs is GetByIDs for stock keeping units
+	GetBySKUs(ctx context.Context, skus []string) ([]Product, error)
+	// List returns the products matching filter ordered by ID
+	List(ctx context.Context, filter ProductFilter) ([]Product, error)
+	// Count returns how many products match filter, ignoring its offset and limit
+	Count(ctx context.Context, filter ProductFilter) (int64, error)
+	// ListLowStock returns up to limit products whose stock is at or below their
+	// LowStockThreshold, fallbackThreshold for products without a ReorderThreshold, lowest
+	// stock first
+	ListLowStock(ctx context.Context, fallbackThreshold, limit int) ([]Product, error)
+	// ListChanged returns up to limit products, deleted ones included, that changed after the
+	// position and before until, in the order they changed
+	ListChanged(ctx context.Context, after persistence.ChangePosition, until time.Time, limit int) ([]Product, error)
+	// FindInBatches calls fn with the products matching filter, ignoring its offset and limit,
+	// size products at a time in ID order; fn must not keep the slice
+	FindInBatches(ctx context.Context, filter ProductFilter, size int, fn func([]Product) error) error
 	Save(ctx context.Context, p *Product) error
-	UpdateStock(ctx context.Context, p *Product) error
+	// Delete removes the product from the catalogue, keeping its row for the orders of it; it
+	// returns persistence.ErrNotFound for unknown and deleted products
+	Delete(ctx context.Context, id int64) error
+	// DecrementStock takes qty off the stock of a product in one UPDATE that only matches while
+	// enough is left, so concurrent decrements can't oversell without locking the row. It returns
+	// ErrInsufficientStock when less is left and persistence.ErrNotFound for unknown products.
+	DecrementStock(ctx context.Context, productID int64, qty int) error
+	// UpsertBySKU creates the products in one statement, updating the name, stock and price of
+	// those whose SKU is taken instead; every product must have a SKU
+	UpsertBySKU(ctx context.Context, products []Product) error
+
+	// GetStockLevels returns the current stock keyed by product ID; unknown IDs are omitted
+	GetStockLevels(ctx context.Context, ids []int64) (map[int64]int, error)
+
+	// BulkUpdateStock applies all adjustments atomically, failing with ErrNegativeStock
+	// if any product would end up below zero
+	BulkUpdateStock(ctx context.Context, adjustments []StockAdjustment) error
 }
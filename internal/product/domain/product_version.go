@@ -0,0 +1,13 @@
+package domain
+
+import "github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+
+// ProductVersion is a product as it was over a period of time, e.g. its stock and price before a
+// change; see temporal.AsOf
+type ProductVersion struct {
+	temporal.Version
+}
+
+func (ProductVersion) TableName() string {
+	return "products_history"
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+)
+
+// ProductsChangedEvent is the event name of ProductsChanged
+const ProductsChangedEvent = "product.changed"
+
+// ProductsChanged is emitted once products are created, imported or deleted, so copies of the
+// catalogue such as the search index catch up. Stock changes are not reported; they happen with
+// every order and copies don't hold the stock.
+type ProductsChanged struct {
+	ProductIDs []int64
+	ChangedAt  time.Time
+}
+
+func (ProductsChanged) EventName() string {
+	return ProductsChangedEvent
+}
+
+// EventID treats the change of the products at a moment as an aggregate that is changed once
+func (e ProductsChanged) EventID() string {
+	return event.NewID(ProductsChangedEvent, fmt.Sprintf("%v_%d", e.ProductIDs, e.ChangedAt.UnixMilli()), len(e.ProductIDs))
+}
+
+// SearchDocument is what the search index holds of a product. ID is the public ID of the product.
+type SearchDocument struct {
+	ID            string `json:"id"`
+	TenantID      string `json:"tenant_id"`
+	Name          string `json:"name"`
+	SKU           string `json:"sku,omitempty"`
+	PriceAmount   int64  `json:"price_amount"`
+	PriceCurrency string `json:"price_currency,omitempty"`
+}
+
+// NewSearchDocument returns the document of a product
+func NewSearchDocument(p *Product) SearchDocument {
+	return SearchDocument{
+		ID:            p.PublicID,
+		TenantID:      p.TenantID,
+		Name:          p.Name,
+		SKU:           p.SKUValue(),
+		PriceAmount:   p.PriceAmount,
+		PriceCurrency: p.PriceCurrency,
+	}
+}
+
+// SearchRequest asks the index for a page of the products matching Text, best matches first
+type SearchRequest struct {
+	Text string
+	// TenantID restricts the search to the products of a tenant; empty searches every tenant
+	TenantID string
+	Offset   int
+	Limit    int
+}
+
+// SearchResult is a page of matches by public ID and how many products match in all
+type SearchResult struct {
+	IDs   []string
+	Total int64
+}
+
+// SearchIndex is a full-text index of the catalogue kept by a search engine. Writes may become
+// visible to searches with a delay.
+type SearchIndex interface {
+	// Index adds the documents, replacing those with the same ID as a whole
+	Index(ctx context.Context, docs []SearchDocument) error
+	// Update sets the fields of indexed documents to those of docs, adding the missing ones
+	Update(ctx context.Context, docs []SearchDocument) error
+	// Delete removes the documents with the given IDs; unknown IDs are ignored
+	Delete(ctx context.Context, ids []string) error
+	Search(ctx context.Context, req SearchRequest) (SearchResult, error)
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "errors"
+
+var ErrNegativeStock = errors.New("stock adjustment would result in negative stock")
+
+// StockAdjustment describes a relative stock change for a single product
+type StockAdjustment struct {
+	ProductID int64 `validate:"required,gt=0"`
+	Delta     int   `validate:"ne=0"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+)
+
+// StockLowEvent is the event name of StockLow
+const StockLowEvent = "product.stock_low"
+
+// StockLow is emitted when a sale or an adjustment takes the stock of a product from above its
+// Product.LowStockThreshold to or below it. A product is reported again only after being
+// restocked above the threshold.
+type StockLow struct {
+	ProductID       int64
+	ProductPublicID string
+	Name            string
+	Stock           int
+	Threshold       int
+	DetectedAt      time.Time
+}
+
+func (StockLow) EventName() string {
+	return StockLowEvent
+}
+
+// EventID treats each drop of a product to a stock level as an aggregate that is detected once
+func (e StockLow) EventID() string {
+	return event.NewID(StockLowEvent, fmt.Sprintf("%s_%d", e.ProductPublicID, e.DetectedAt.UnixMilli()), e.Stock)
+}
+
+// NewStockLow returns the StockLow event of a stock change from before to after, or nil when the
+// change does not cross the threshold of the product; fallbackThreshold applies to products
+// without a ReorderThreshold
+func NewStockLow(p *Product, before, after, fallbackThreshold int, at time.Time) *StockLow {
+	threshold := p.LowStockThreshold(fallbackThreshold)
+	if before <= threshold || after > threshold {
+		return nil
+	}
+	return &StockLow{
+		ProductID:       p.ID,
+		ProductPublicID: p.PublicID,
+		Name:            p.Name,
+		Stock:           after,
+		Threshold:       threshold,
+		DetectedAt:      at,
+	}
+}
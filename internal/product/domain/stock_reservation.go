@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrReservationNotActive is returned when confirming or releasing a reservation that expired or was already settled
+var ErrReservationNotActive = errors.New("stock reservation is not active")
+
+// ReservationStatus is the lifecycle state of a stock reservation
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "ACTIVE"
+	ReservationConfirmed ReservationStatus = "CONFIRMED"
+	ReservationReleased  ReservationStatus = "RELEASED"
+)
+
+// StockReservation holds Quantity of a product for a pending purchase until ExpiresAt.
+// Available stock is the product stock minus active, unexpired reservations; stock itself
+// only decreases when a reservation is confirmed.
+type StockReservation struct {
+	ID        int64             `gorm:"primaryKey"`
+	ProductID int64             `gorm:"index:idx_stock_reservations_product_status;not null"`
+	Quantity  int               `gorm:"not null"`
+	Status    ReservationStatus `gorm:"index:idx_stock_reservations_product_status;type:varchar(20);not null"`
+	ExpiresAt time.Time         `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+// IsActive reports whether the reservation still holds stock at now
+func (r *StockReservation) IsActive(now time.Time) bool {
+	return r.Status == ReservationActive && now.Before(r.ExpiresAt)
+}
+
+type StockReservationRepository interface {
+	// Reserve holds qty of a product until expiresAt, failing with ErrInsufficientStock
+	// when less is available and ErrProductNotFound for unknown products
+	Reserve(ctx context.Context, productID int64, qty int, expiresAt time.Time) (*StockReservation, error)
+
+	// Confirm decrements the product stock by an active reservation and settles it
+	Confirm(ctx context.Context, id int64) error
+
+	// Release gives an active reservation back without touching stock
+	Release(ctx context.Context, id int64) error
+
+	// ReleaseExpired releases every active reservation that expired at or before now, returning how many
+	ReleaseExpired(ctx context.Context, now time.Time) (int64, error)
+
+	// AvailableStock returns the stock of a product minus its active reservations
+	AvailableStock(ctx context.Context, productID int64) (int, error)
+}
@@ -0,0 +1,29 @@
+package port
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// EventServer keeps the search index in step with the catalogue as products change
+type EventServer struct {
+	SyncSearchIndex decorator.CommandHandler[command.SyncSearchIndexCommand]
+}
+
+// Subscribe registers the product subscribers on the event bus
+func (s *EventServer) Subscribe(bus *event.Bus) {
+	bus.Subscribe(domain.ProductsChangedEvent, s.productsChanged)
+}
+
+func (s *EventServer) productsChanged(ctx context.Context, e event.Event) error {
+	changed, ok := e.(domain.ProductsChanged)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+	return s.SyncSearchIndex.Handle(ctx, command.SyncSearchIndexCommand{ProductIDs: changed.ProductIDs})
+}
@@ -0,0 +1,59 @@
+package port
+
+import (
+	"net/http"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/export"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+)
+
+// exportBatchSize is the number of products read, written and flushed to the client at a time
+const exportBatchSize = 500
+
+// ProductExportRow is a line of GET /products/export. Its sku, name, stock, price and currency
+// columns are those of POST /products/import, so an edited export can be imported back.
+type ProductExportRow struct {
+	ID    string `json:"id"`
+	SKU   string `json:"sku"`
+	Name  string `json:"name"`
+	Stock int    `json:"stock"`
+	// Price is in major units of Currency, e.g. 12.99; both are empty for unpriced products
+	Price    string `json:"price"`
+	Currency string `json:"currency"`
+}
+
+// exportProducts streams the products matching ?name= and ?in_stock=true
+func (s *HTTPServer) exportProducts(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	filter := domain.ProductFilter{Name: r.URL.Query().Get("name"), InStock: r.URL.Query().Get("in_stock") == "true"}
+
+	download := export.NewDownload(w, format, "products")
+	rows := export.NewWriter[ProductExportRow](download, format)
+	err = s.ProductRepo.FindInBatches(r.Context(), filter, exportBatchSize, func(products []domain.Product) error {
+		for i := range products {
+			if err := rows.Write(toProductExportRow(&products[i])); err != nil {
+				return err
+			}
+		}
+		return rows.Flush()
+	})
+	if err == nil {
+		err = rows.Flush()
+	}
+	if err != nil {
+		download.Fail(r, err)
+	}
+}
+
+func toProductExportRow(p *domain.Product) ProductExportRow {
+	row := ProductExportRow{ID: p.PublicID, SKU: p.SKUValue(), Name: p.Name, Stock: p.Stock}
+	if price := p.Price(); price.Currency != "" {
+		row.Price, row.Currency = price.Major(), price.Currency
+	}
+	return row
+}
@@ -0,0 +1,374 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// CreateProductRequest is the body of POST /products
+type CreateProductRequest struct {
+	Name  string `json:"name"`
+	SKU   string `json:"sku,omitempty"`
+	Stock int    `json:"stock"`
+	Price *Price `json:"price,omitempty"`
+	// ReorderThreshold is the stock level at or below which the product runs low; without it
+	// STOCK_LOW_THRESHOLD applies
+	ReorderThreshold *int `json:"reorder_threshold,omitempty"`
+	// TaxClass selects the TAX_RATES of the product, e.g. "reduced"; without it the standard
+	// rates apply
+	TaxClass string `json:"tax_class,omitempty"`
+}
+
+// Price is a unit price; Amount is in minor units of Currency, e.g. cents
+type Price struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// StockAdjustmentRequest is a single entry of POST /products/stock-adjustments
+type StockAdjustmentRequest struct {
+	ProductID string `json:"product_id"`
+	Delta     int    `json:"delta"`
+}
+
+// AdjustStockRequest is the body of POST /products/stock-adjustments
+type AdjustStockRequest struct {
+	Adjustments []StockAdjustmentRequest `json:"adjustments"`
+}
+
+// ProductResponse is the public representation of a product.
+// ID is the public "prd_" ID. Available is the stock not held by pending orders; it is only reported by GET /products/{id} without as_of.
+type ProductResponse struct {
+	ID    string `json:"id"`
+	SKU   string `json:"sku,omitempty"`
+	Name  string `json:"name"`
+	Stock int    `json:"stock"`
+	// Price is in the currency requested with ?currency=, or else in the one it is stored in
+	Price *Price `json:"price,omitempty"`
+	// BasePrice is the price as stored; it is only reported when Price was converted
+	BasePrice *Price `json:"base_price,omitempty"`
+	Available *int   `json:"available,omitempty"`
+	// ReorderThreshold is omitted for products running low at STOCK_LOW_THRESHOLD
+	ReorderThreshold *int `json:"reorder_threshold,omitempty"`
+	// TaxClass is omitted for products of the standard class
+	TaxClass string `json:"tax_class,omitempty"`
+}
+
+// HTTPServer exposes the product use cases over HTTP
+type HTTPServer struct {
+	CreateProduct  decorator.CommandResultHandler[command.CreateProductCommand, *domain.Product]
+	AdjustStock    decorator.CommandHandler[command.AdjustStockCommand]
+	DeleteProduct  decorator.CommandHandler[command.DeleteProductCommand]
+	ImportProducts decorator.CommandResultHandler[command.ImportProductsCommand, *domain.ImportReport]
+	// ListLowStockProducts lists the products to replenish
+	ListLowStockProducts decorator.QueryHandler[query.ListLowStockProductsQuery, []domain.Product]
+	SearchProducts       decorator.QueryHandler[query.SearchProductsQuery, *query.ProductSearchPage]
+	UploadProductImage   decorator.CommandResultHandler[command.UploadProductImageCommand, *domain.ProductImage]
+	DeleteProductImage   decorator.CommandHandler[command.DeleteProductImageCommand]
+	ListProductImages    decorator.QueryHandler[query.ListProductImagesQuery, []query.ProductImageView]
+	ProductRepo          domain.ProductRepository
+	Reservations         domain.StockReservationRepository
+	// Converter returns prices in the currency of ?currency=; nil serves them as stored
+	Converter money.CurrencyConverter
+	// MaxImageSize is the PRODUCT_IMAGE_MAX_SIZE of uploads, command.DefaultMaxImageSize by default
+	MaxImageSize int64
+
+	// Auth restricts catalogue changes to product:write; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the product endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/products",
+		Summary:  "Create a product",
+		Tags:     []string{"products"},
+		Request:  CreateProductRequest{},
+		Response: ProductResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionProductWrite, s.createProduct),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/products/{id}",
+		Summary:  "Get a product by ID, or as it was at a past moment with ?as_of=2024-05-18T12:00:00Z (support only); ?currency=USD converts its price",
+		Tags:     []string{"products"},
+		Response: ProductResponse{},
+		Handler:  s.getProduct,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodDelete,
+		Path:     "/products/{id}",
+		Summary:  "Delete a product; orders keep referring to it and clients syncing with /sync see it removed",
+		Tags:     []string{"products"},
+		Status:   http.StatusNoContent,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionProductWrite, s.deleteProduct),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:  http.MethodPost,
+		Path:    "/products/stock-adjustments",
+		Summary: "Apply relative stock adjustments to many products at once",
+		Tags:    []string{"products"},
+		Request: AdjustStockRequest{},
+		Status:  http.StatusNoContent,
+		Handler: auth.Require(s.Auth, userDomain.PermissionProductWrite, s.adjustStock),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/products/low-stock",
+		Summary:  "List the products whose stock is at or below their reorder threshold, lowest stock first; ?currency=USD converts their prices",
+		Tags:     []string{"products"},
+		Response: LowStockProductsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionProductWrite, s.listLowStockProducts),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/products/import",
+		Summary:  "Create or update products by SKU from a CSV or XLSX file sent as multipart/form-data in a file field; lines that fail are reported",
+		Tags:     []string{"products"},
+		Response: ImportProductsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionProductWrite, s.importProducts),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/products/search",
+		Summary:  "Search the catalogue, best matches first, e.g. ?q=kettle&page=1&page_size=20; without a search backend names containing q match, in ID order",
+		Tags:     []string{"products"},
+		Response: ProductSearchResponse{},
+		Handler:  s.searchProducts,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/products/export",
+		Summary:  "Export the catalogue as CSV, or as JSON Lines with ?format=ndjson, in the columns of the import; ?name= and ?in_stock=true narrow it",
+		Tags:     []string{"products"},
+		Response: ProductExportRow{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionProductWrite, s.exportProducts),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/products/{id}/images",
+		Summary:  "Add a JPEG, PNG or GIF image sent as multipart/form-data in a file field to a product; a thumbnail is generated and oversized files are rejected with 413",
+		Tags:     []string{"products"},
+		Response: ProductImageResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionProductWrite, s.uploadProductImage),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/products/{id}/images",
+		Summary:  "List the images of a product, oldest first, with signed URLs reading them and their thumbnails for a limited time",
+		Tags:     []string{"products"},
+		Response: ProductImagesResponse{},
+		Handler:  s.listProductImages,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodDelete,
+		Path:     "/products/{id}/images/{imageID}",
+		Summary:  "Delete an image of a product along with its thumbnail",
+		Tags:     []string{"products"},
+		Status:   http.StatusNoContent,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionProductWrite, s.deleteProductImage),
+		StringID: true,
+	})
+}
+
+func (s *HTTPServer) getProduct(w http.ResponseWriter, r *http.Request) {
+	asOf, err := temporal.ParseAsOf(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	currency, err := exchange.ParseCurrency(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	if asOf != nil {
+		s.getProductAsOf(w, r, *asOf, currency)
+		return
+	}
+
+	p, err := s.ProductRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	available, err := s.Reservations.AvailableStock(r.Context(), p.ID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp, err := s.pricedProductResponse(r.Context(), p, currency)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	resp.Available = &available
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// getProductAsOf shows support staff a product as it was at a past moment; the available stock
+// is only known for the present, so it is left out
+func (s *HTTPServer) getProductAsOf(w http.ResponseWriter, r *http.Request, at time.Time, currency string) {
+	if err := auth.Check(r.Context(), s.Auth, userDomain.PermissionSupportQuery); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+
+	p, err := s.ProductRepo.GetByPublicIDAsOf(r.Context(), r.PathValue("id"), at)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	resp, err := s.pricedProductResponse(r.Context(), p, currency)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) createProduct(w http.ResponseWriter, r *http.Request) {
+	var req CreateProductRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	cmd := command.CreateProductCommand{Name: req.Name, SKU: req.SKU, Stock: req.Stock, ReorderThreshold: req.ReorderThreshold, TaxClass: req.TaxClass}
+	if req.Price != nil {
+		price, err := money.New(req.Price.Amount, req.Price.Currency)
+		if err != nil {
+			var errs validation.Errors
+			errs.Add("price.currency", err.Error())
+			httpx.WriteError(w, errs)
+			return
+		}
+		cmd.Price = price
+	}
+
+	p, err := s.CreateProduct.Handle(r.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, quotaDomain.ErrQuotaExceeded) {
+			httpx.WriteErrorCode(w, http.StatusTooManyRequests, quotaDomain.ErrorCodeQuotaExceeded, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, toProductResponse(p))
+}
+
+func toProductResponse(p *domain.Product) ProductResponse {
+	resp := ProductResponse{ID: p.PublicID, SKU: p.SKUValue(), Name: p.Name, Stock: p.Stock, ReorderThreshold: p.ReorderThreshold, TaxClass: p.TaxClass}
+	if price := p.Price(); price.Currency != "" {
+		resp.Price = &Price{Amount: price.Amount, Currency: price.Currency}
+	}
+	return resp
+}
+
+// pricedProductResponse is like toProductResponse with the price converted into currency, at the
+// current rate rather than the one at the time of an as_of read; an empty currency keeps it as stored
+func (s *HTTPServer) pricedProductResponse(ctx context.Context, p *domain.Product, currency string) (ProductResponse, error) {
+	resp := toProductResponse(p)
+	price := p.Price()
+	converted, err := exchange.Convert(ctx, s.Converter, price, currency)
+	if err != nil {
+		return ProductResponse{}, err
+	}
+	if converted != price {
+		resp.BasePrice = resp.Price
+		resp.Price = &Price{Amount: converted.Amount, Currency: converted.Currency}
+	}
+	return resp, nil
+}
+
+func (s *HTTPServer) deleteProduct(w http.ResponseWriter, r *http.Request) {
+	if err := s.DeleteProduct.Handle(r.Context(), command.DeleteProductCommand{PublicID: r.PathValue("id")}); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *HTTPServer) adjustStock(w http.ResponseWriter, r *http.Request) {
+	var req AdjustStockRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	ids, err := s.resolveProductIDs(r.Context(), req.Adjustments)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	// Unknown products are reported like the command reports them
+	var errs validation.Errors
+	cmd := command.AdjustStockCommand{Adjustments: make([]domain.StockAdjustment, len(req.Adjustments))}
+	for i, adj := range req.Adjustments {
+		id, ok := ids[adj.ProductID]
+		errs.Check(ok, fmt.Sprintf("adjustments[%d].product_id", i), "product not found")
+		cmd.Adjustments[i] = domain.StockAdjustment{ProductID: id, Delta: adj.Delta}
+	}
+	if err := errs.Err(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	if err := s.AdjustStock.Handle(r.Context(), cmd); err != nil {
+		if errors.Is(err, domain.ErrNegativeStock) {
+			httpx.WriteErrorStatus(w, http.StatusConflict, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resolveProductIDs maps the public product IDs of the adjustments to primary keys in one query;
+// unknown IDs are left out
+func (s *HTTPServer) resolveProductIDs(ctx context.Context, adjustments []StockAdjustmentRequest) (map[string]int64, error) {
+	publicIDs := make([]string, 0, len(adjustments))
+	for _, adj := range adjustments {
+		publicIDs = append(publicIDs, adj.ProductID)
+	}
+
+	products, err := s.ProductRepo.GetByPublicIDs(ctx, publicIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(products))
+	for _, p := range products {
+		ids[p.PublicID] = p.ID
+	}
+	return ids, nil
+}
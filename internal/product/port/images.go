@@ -0,0 +1,113 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// ProductImageResponse describes a picture of a product. URL and ThumbnailURL read the image and
+// its thumbnail without credentials until ExpiresAt; they are only reported by GET
+// /products/{id}/images, which signs fresh ones on every call.
+type ProductImageResponse struct {
+	ID           string     `json:"id"`
+	ContentType  string     `json:"content_type"`
+	Size         int64      `json:"size"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	URL          string     `json:"url,omitempty"`
+	ThumbnailURL string     `json:"thumbnail_url,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ProductImagesResponse is the body of GET /products/{id}/images
+type ProductImagesResponse struct {
+	Images []ProductImageResponse `json:"images"`
+}
+
+func (s *HTTPServer) uploadProductImage(w http.ResponseWriter, r *http.Request) {
+	maxSize := s.MaxImageSize
+	if maxSize <= 0 {
+		maxSize = command.DefaultMaxImageSize
+	}
+	tooLarge := fmt.Errorf("product images are limited to %d bytes", maxSize)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		var errs validation.Errors
+		errs.Add("file", "is required as a multipart/form-data file")
+		httpx.WriteError(w, errs)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxSize {
+		httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+
+	img, err := s.UploadProductImage.Handle(r.Context(), command.UploadProductImageCommand{ProductID: r.PathValue("id"), Content: file})
+	if err != nil {
+		if errors.Is(err, domain.ErrImageTooLarge) {
+			httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, toProductImageResponse(query.ProductImageView{ProductImage: *img}))
+}
+
+func (s *HTTPServer) listProductImages(w http.ResponseWriter, r *http.Request) {
+	views, err := s.ListProductImages.Handle(r.Context(), query.ListProductImagesQuery{ProductID: r.PathValue("id")})
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := ProductImagesResponse{Images: make([]ProductImageResponse, len(views))}
+	for i, v := range views {
+		resp.Images[i] = toProductImageResponse(v)
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) deleteProductImage(w http.ResponseWriter, r *http.Request) {
+	cmd := command.DeleteProductImageCommand{ProductID: r.PathValue("id"), ImageID: r.PathValue("imageID")}
+	if err := s.DeleteProductImage.Handle(r.Context(), cmd); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toProductImageResponse(v query.ProductImageView) ProductImageResponse {
+	resp := ProductImageResponse{
+		ID:           v.PublicID,
+		ContentType:  v.ContentType,
+		Size:         v.Size,
+		Width:        v.Width,
+		Height:       v.Height,
+		URL:          v.URL,
+		ThumbnailURL: v.ThumbnailURL,
+		CreatedAt:    v.CreatedAt,
+	}
+	if v.URL != "" {
+		resp.ExpiresAt = &v.ExpiresAt
+	}
+	return resp
+}
@@ -0,0 +1,90 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/spreadsheet"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// multipartOverhead allows for the boundaries around an import file
+const multipartOverhead = 64 << 10
+
+// ImportRowErrorResponse tells why a line of an import file was not imported
+type ImportRowErrorResponse struct {
+	Line   int                     `json:"line"`
+	SKU    string                  `json:"sku,omitempty"`
+	Errors []validation.FieldError `json:"errors"`
+}
+
+// ImportProductsResponse is the outcome of POST /products/import. Rows counts the non-empty lines
+// after the header; each was created, updated or failed.
+type ImportProductsResponse struct {
+	Rows    int                      `json:"rows"`
+	Created int                      `json:"created"`
+	Updated int                      `json:"updated"`
+	Failed  []ImportRowErrorResponse `json:"failed"`
+}
+
+func (s *HTTPServer) importProducts(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, domain.MaxImportSize+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("import files are limited to %d bytes", domain.MaxImportSize))
+			return
+		}
+		var errs validation.Errors
+		errs.Add("file", "is required as a multipart/form-data file")
+		httpx.WriteError(w, errs)
+		return
+	}
+	defer file.Close()
+	if header.Size > domain.MaxImportSize {
+		httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("import files are limited to %d bytes", domain.MaxImportSize))
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	format, err := spreadsheet.DetectFormat(contentType, header.Filename)
+	if err != nil {
+		httpx.WriteErrorStatus(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+	rows, err := spreadsheet.Open(format, file, header.Size)
+	if err != nil {
+		var errs validation.Errors
+		errs.Add("file", err.Error())
+		httpx.WriteError(w, errs)
+		return
+	}
+
+	report, err := s.ImportProducts.Handle(r.Context(), command.ImportProductsCommand{Rows: rows})
+	if err != nil {
+		if errors.Is(err, quotaDomain.ErrQuotaExceeded) {
+			httpx.WriteErrorCode(w, http.StatusTooManyRequests, quotaDomain.ErrorCodeQuotaExceeded, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := ImportProductsResponse{
+		Rows:    report.Rows,
+		Created: report.Created,
+		Updated: report.Updated,
+		Failed:  make([]ImportRowErrorResponse, len(report.Failed)),
+	}
+	for i, failed := range report.Failed {
+		resp.Failed[i] = ImportRowErrorResponse{Line: failed.Line, SKU: failed.SKU, Errors: failed.Errors}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,28 @@
+package port
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// ReleaseExpiredReservationsJob frees the stock held by reservations of abandoned orders
+type ReleaseExpiredReservationsJob struct{}
+
+func (ReleaseExpiredReservationsJob) Kind() string {
+	return "product.release_expired_reservations"
+}
+
+// JobServer runs the product use cases triggered by background jobs
+type JobServer struct {
+	ReleaseExpiredReservations decorator.CommandHandler[command.ReleaseExpiredReservationsCommand]
+}
+
+// RegisterJobs adds the product job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, _ ReleaseExpiredReservationsJob) error {
+		return s.ReleaseExpiredReservations.Handle(ctx, command.ReleaseExpiredReservationsCommand{})
+	})
+}
@@ -0,0 +1,51 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// LowStockProductsResponse lists the products to replenish, lowest stock first
+type LowStockProductsResponse struct {
+	Products []ProductResponse `json:"products"`
+}
+
+func (s *HTTPServer) listLowStockProducts(w http.ResponseWriter, r *http.Request) {
+	currency, err := exchange.ParseCurrency(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	var q query.ListLowStockProductsQuery
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			var errs validation.Errors
+			errs.Add("limit", fmt.Sprintf("must be between 1 and %d, got %q", query.MaxLowStockLimit, value))
+			httpx.WriteError(w, errs)
+			return
+		}
+		q.Limit = n
+	}
+
+	products, err := s.ListLowStockProducts.Handle(r.Context(), q)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	resp := LowStockProductsResponse{Products: make([]ProductResponse, len(products))}
+	for i := range products {
+		if resp.Products[i], err = s.pricedProductResponse(r.Context(), &products[i], currency); err != nil {
+			httpx.WriteError(w, err)
+			return
+		}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
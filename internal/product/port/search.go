@@ -0,0 +1,49 @@
+package port
+
+import (
+	"net/http"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	sharedQuery "github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+// ProductSearchResponse is a page of the products matching a search, best matches first
+type ProductSearchResponse struct {
+	Products []ProductResponse `json:"products"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+func (s *HTTPServer) searchProducts(w http.ResponseWriter, r *http.Request) {
+	currency, err := exchange.ParseCurrency(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	q := query.SearchProductsQuery{Text: r.URL.Query().Get("q")}
+	var errs validation.Errors
+	q.Page, q.PageSize = sharedQuery.ParsePage(r.URL.Query(), query.MaxSearchPageSize, errs.Add)
+	if err := errs.Err(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	page, err := s.SearchProducts.Handle(r.Context(), q)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	resp := ProductSearchResponse{Products: make([]ProductResponse, len(page.Products)), Total: page.Total, Page: page.Page, PageSize: page.PageSize}
+	for i := range page.Products {
+		if resp.Products[i], err = s.pricedProductResponse(r.Context(), &page.Products[i], currency); err != nil {
+			httpx.WriteError(w, err)
+			return
+		}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
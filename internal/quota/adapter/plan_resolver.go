@@ -0,0 +1,109 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// GormPlanResolver reads plan assignments from tenant_plans and caches them for ttl,
+// since the API rate limit resolves the plan on every request
+type GormPlanResolver struct {
+	db          *gorm.DB
+	defaultPlan domain.Plan
+	ttl         time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedPlan
+}
+
+type cachedPlan struct {
+	plan    domain.Plan
+	expires time.Time
+}
+
+// warmPlanLimit bounds the plan assignments loaded by Warm to the most recently changed ones
+const warmPlanLimit = 5000
+
+func NewGormPlanResolver(db *gorm.DB, defaultPlan string, ttl time.Duration) (*GormPlanResolver, error) {
+	plan, ok := domain.Plans[defaultPlan]
+	if !ok {
+		return nil, fmt.Errorf("unknown default plan %q", defaultPlan)
+	}
+	return &GormPlanResolver{db: db, defaultPlan: plan, ttl: ttl, cache: make(map[string]cachedPlan)}, nil
+}
+
+func (r *GormPlanResolver) PlanFor(ctx context.Context, tenantID string) (domain.Plan, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[tenantID]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.plan, nil
+	}
+
+	plan, err := r.load(ctx, tenantID)
+	if err != nil {
+		return domain.Plan{}, err
+	}
+
+	r.remember(tenantID, plan)
+	return plan, nil
+}
+
+func (r *GormPlanResolver) remember(tenantID string, plan domain.Plan) {
+	r.mu.Lock()
+	r.cache[tenantID] = cachedPlan{plan: plan, expires: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+}
+
+func (r *GormPlanResolver) Describe() string {
+	return "quota plans"
+}
+
+// Warm caches the default tenant and the tenants whose plan changed last, up to warmPlanLimit;
+// assignments to unknown plans are left to fail on use
+func (r *GormPlanResolver) Warm(ctx context.Context) (int, error) {
+	var assignments []domain.TenantPlan
+	err := r.db.WithContext(ctx).Order("updated_at DESC").Limit(warmPlanLimit).Find(&assignments).Error
+	if err != nil {
+		return 0, persistence.TranslateError(err)
+	}
+
+	warmed := 0
+	for _, assignment := range assignments {
+		if plan, ok := domain.Plans[assignment.Plan]; ok {
+			r.remember(assignment.TenantID, plan)
+			warmed++
+		}
+	}
+	if !slices.ContainsFunc(assignments, func(a domain.TenantPlan) bool { return a.TenantID == tenant.DefaultID }) {
+		r.remember(tenant.DefaultID, r.defaultPlan)
+		warmed++
+	}
+	return warmed, nil
+}
+
+func (r *GormPlanResolver) load(ctx context.Context, tenantID string) (domain.Plan, error) {
+	var assignment domain.TenantPlan
+	err := r.db.WithContext(ctx).First(&assignment, "tenant_id = ?", tenantID).Error
+	if err != nil {
+		if err = persistence.TranslateError(err); errors.Is(err, persistence.ErrNotFound) {
+			return r.defaultPlan, nil
+		}
+		return domain.Plan{}, err
+	}
+
+	plan, ok := domain.Plans[assignment.Plan]
+	if !ok {
+		return domain.Plan{}, fmt.Errorf("tenant %s is assigned to unknown plan %q", tenantID, assignment.Plan)
+	}
+	return plan, nil
+}
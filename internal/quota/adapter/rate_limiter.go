@@ -0,0 +1,68 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+)
+
+// RateLimiter enforces APIRequestsPerMinute with an in-memory fixed window per tenant.
+// Limits apply per instance; a deployment with N instances allows up to N times the plan rate.
+type RateLimiter struct {
+	plans domain.PlanResolver
+	now   func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int64
+}
+
+func NewRateLimiter(plans domain.PlanResolver) *RateLimiter {
+	return &RateLimiter{plans: plans, now: time.Now, windows: make(map[string]*rateWindow)}
+}
+
+// Allow counts a request of the tenant and reports whether it is within the plan rate
+func (l *RateLimiter) Allow(ctx context.Context, tenantID string) (bool, domain.Plan, error) {
+	plan, err := l.plans.PlanFor(ctx, tenantID)
+	if err != nil {
+		return false, domain.Plan{}, err
+	}
+	limit := plan.Limit(domain.MetricAPIRequestsPerMinute)
+	if limit <= 0 {
+		return true, plan, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window := l.window(tenantID)
+	if window.count >= limit {
+		return false, plan, nil
+	}
+	window.count++
+	return true, plan, nil
+}
+
+// Current returns the number of requests counted in the tenant's current window
+func (l *RateLimiter) Current(tenantID string) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.window(tenantID).count
+}
+
+// window returns the tenant's window, starting a new one once a minute has passed; callers hold mu
+func (l *RateLimiter) window(tenantID string) *rateWindow {
+	now := l.now().Truncate(time.Minute)
+	window, ok := l.windows[tenantID]
+	if !ok || !window.start.Equal(now) {
+		window = &rateWindow{start: now}
+		l.windows[tenantID] = window
+	}
+	return window
+}
@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticPlans map[string]domain.Plan
+
+func (p staticPlans) PlanFor(ctx context.Context, tenantID string) (domain.Plan, error) {
+	return p[tenantID], nil
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 5, 18, 10, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(staticPlans{
+		"acme":   {Name: "free", APIRequestsPerMinute: 2},
+		"globex": {Name: "enterprise"},
+	})
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, expected := range []bool{true, true, false} {
+		allowed, _, err := limiter.Allow(ctx, "acme")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, allowed)
+	}
+	assert.Equal(t, int64(2), limiter.Current("acme"))
+
+	// Unlimited plans are never throttled
+	for i := 0; i < 5; i++ {
+		allowed, _, _ := limiter.Allow(ctx, "globex")
+		assert.True(t, allowed)
+	}
+
+	// A new minute starts a new window
+	now = now.Add(time.Minute)
+	allowed, _, err := limiter.Allow(ctx, "acme")
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,61 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUsageRepository(db *gorm.DB) domain.UsageRepository {
+	return &GormUsageRepository{db: db}
+}
+
+// Increment is a single upsert; the limit check lives in the conflict clause so concurrent
+// callers cannot push the counter past the limit
+func (r *GormUsageRepository) Increment(ctx context.Context, tenantID string, metric domain.Metric, period string, n, limit int64) (bool, error) {
+	if limit > 0 && n > limit {
+		return false, nil
+	}
+
+	onConflict := clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "metric"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("usage_counters.count + ?", n),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}
+	if limit > 0 {
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{
+			gorm.Expr("usage_counters.count + ? <= ?", n, limit),
+		}}
+	}
+
+	counter := &domain.UsageCounter{TenantID: tenantID, Metric: metric, Period: period, Count: n}
+	result := r.db.WithContext(ctx).Clauses(onConflict).Create(counter)
+	if result.Error != nil {
+		return false, persistence.TranslateError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GormUsageRepository) Get(ctx context.Context, tenantID string, metric domain.Metric, period string) (int64, error) {
+	var counter domain.UsageCounter
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND metric = ? AND period = ?", tenantID, metric, period).
+		First(&counter).Error
+	if err != nil {
+		if err = persistence.TranslateError(err); errors.Is(err, persistence.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return counter.Count, nil
+}
@@ -0,0 +1,138 @@
+package adapter_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+
+	// A single connection keeps every goroutine on the same in-memory database
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	assert.NoError(t, db.AutoMigrate(&domain.UsageCounter{}, &domain.TenantPlan{}))
+	return db
+}
+
+func TestGormUsageRepository_IncrementRespectsLimit(t *testing.T) {
+	repo := adapter.NewGormUsageRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		applied, err := repo.Increment(ctx, "acme", domain.MetricOrdersPerMonth, "2024-05", 1, 3)
+		assert.NoError(t, err)
+		assert.True(t, applied)
+	}
+
+	applied, err := repo.Increment(ctx, "acme", domain.MetricOrdersPerMonth, "2024-05", 1, 3)
+	assert.NoError(t, err)
+	assert.False(t, applied, "fourth order exceeds the limit")
+
+	// Other tenants and periods count separately
+	applied, err = repo.Increment(ctx, "acme", domain.MetricOrdersPerMonth, "2024-06", 1, 3)
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	used, err := repo.Get(ctx, "acme", domain.MetricOrdersPerMonth, "2024-05")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), used)
+
+	used, err = repo.Get(ctx, "globex", domain.MetricOrdersPerMonth, "2024-05")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), used)
+}
+
+func TestGormUsageRepository_ConcurrentIncrements(t *testing.T) {
+	repo := adapter.NewGormUsageRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	applied := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := repo.Increment(ctx, "acme", domain.MetricProducts, "", 1, 5)
+			assert.NoError(t, err)
+			if ok {
+				mu.Lock()
+				applied++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, applied)
+	used, err := repo.Get(ctx, "acme", domain.MetricProducts, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), used)
+}
+
+func TestGormUsageRepository_Release(t *testing.T) {
+	repo := adapter.NewGormUsageRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	_, err := repo.Increment(ctx, "acme", domain.MetricProducts, "", 2, 0)
+	assert.NoError(t, err)
+	_, err = repo.Increment(ctx, "acme", domain.MetricProducts, "", -1, 0)
+	assert.NoError(t, err)
+
+	used, err := repo.Get(ctx, "acme", domain.MetricProducts, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), used)
+}
+
+func TestGormPlanResolver_PlanFor(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.Create(&domain.TenantPlan{TenantID: "acme", Plan: "pro"}).Error)
+	assert.NoError(t, db.Create(&domain.TenantPlan{TenantID: "broken", Plan: "platinum"}).Error)
+
+	resolver, err := adapter.NewGormPlanResolver(db, "free", 0)
+	assert.NoError(t, err)
+
+	plan, err := resolver.PlanFor(context.Background(), "acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "pro", plan.Name)
+
+	plan, err = resolver.PlanFor(context.Background(), "globex")
+	assert.NoError(t, err)
+	assert.Equal(t, "free", plan.Name, "unassigned tenants get the default plan")
+
+	_, err = resolver.PlanFor(context.Background(), "broken")
+	assert.Error(t, err)
+
+	_, err = adapter.NewGormPlanResolver(db, "platinum", 0)
+	assert.Error(t, err)
+}
+
+func TestGormPlanResolver_Warm(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.Create(&domain.TenantPlan{TenantID: "acme", Plan: "pro"}).Error)
+	assert.NoError(t, db.Create(&domain.TenantPlan{TenantID: "broken", Plan: "platinum"}).Error)
+
+	resolver, err := adapter.NewGormPlanResolver(db, "free", time.Hour)
+	assert.NoError(t, err)
+	warmed, err := resolver.Warm(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, warmed, "acme and the default tenant")
+
+	// Warmed plans are served without reading tenant_plans
+	assert.NoError(t, db.Exec("DELETE FROM tenant_plans").Error)
+	plan, err := resolver.PlanFor(context.Background(), "acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "pro", plan.Name)
+}
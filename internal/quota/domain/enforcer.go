@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+)
+
+// Enforcer implements Limiter on top of the tenant plans and usage counters
+type Enforcer struct {
+	Plans PlanResolver
+	Usage UsageRepository
+	Now   func() time.Time
+}
+
+func (e *Enforcer) Consume(ctx context.Context, metric Metric, n int64) error {
+	tenantID := tenant.FromContext(ctx)
+	plan, err := e.Plans.PlanFor(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("resolve plan of tenant %s: %w", tenantID, err)
+	}
+
+	limit := plan.Limit(metric)
+	applied, err := e.Usage.Increment(ctx, tenantID, metric, PeriodFor(metric, e.now()), n, limit)
+	if err != nil {
+		return fmt.Errorf("count %s usage: %w", metric, err)
+	}
+	if !applied {
+		return &ExceededError{Metric: metric, Limit: limit, Plan: plan.Name}
+	}
+	return nil
+}
+
+func (e *Enforcer) Release(ctx context.Context, metric Metric, n int64) error {
+	_, err := e.Usage.Increment(ctx, tenant.FromContext(ctx), metric, PeriodFor(metric, e.now()), -n, 0)
+	return err
+}
+
+// MetricUsage is the usage of a metric against its plan limit (0 = unlimited)
+type MetricUsage struct {
+	Metric Metric
+	Used   int64
+	Limit  int64
+}
+
+// UsageReport summarizes the counted usage of the tenant in ctx
+type UsageReport struct {
+	TenantID string
+	Plan     string
+	Period   string
+	Metrics  []MetricUsage
+}
+
+// Report returns the usage of the counted metrics for the current period
+func (e *Enforcer) Report(ctx context.Context) (*UsageReport, error) {
+	tenantID := tenant.FromContext(ctx)
+	plan, err := e.Plans.PlanFor(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve plan of tenant %s: %w", tenantID, err)
+	}
+
+	now := e.now()
+	report := &UsageReport{TenantID: tenantID, Plan: plan.Name, Period: PeriodFor(MetricOrdersPerMonth, now)}
+	for _, metric := range []Metric{MetricProducts, MetricOrdersPerMonth} {
+		used, err := e.Usage.Get(ctx, tenantID, metric, PeriodFor(metric, now))
+		if err != nil {
+			return nil, fmt.Errorf("get %s usage: %w", metric, err)
+		}
+		report.Metrics = append(report.Metrics, MetricUsage{Metric: metric, Used: used, Limit: plan.Limit(metric)})
+	}
+	return report, nil
+}
+
+func (e *Enforcer) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,80 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticPlans map[string]domain.Plan
+
+func (p staticPlans) PlanFor(ctx context.Context, tenantID string) (domain.Plan, error) {
+	return p[tenantID], nil
+}
+
+// memoryUsage is an in-memory domain.UsageRepository
+type memoryUsage map[string]int64
+
+func (m memoryUsage) key(tenantID string, metric domain.Metric, period string) string {
+	return tenantID + "/" + string(metric) + "/" + period
+}
+
+func (m memoryUsage) Increment(ctx context.Context, tenantID string, metric domain.Metric, period string, n, limit int64) (bool, error) {
+	key := m.key(tenantID, metric, period)
+	if limit > 0 && m[key]+n > limit {
+		return false, nil
+	}
+	m[key] += n
+	return true, nil
+}
+
+func (m memoryUsage) Get(ctx context.Context, tenantID string, metric domain.Metric, period string) (int64, error) {
+	return m[m.key(tenantID, metric, period)], nil
+}
+
+func TestEnforcer_ConsumeAndReport(t *testing.T) {
+	usage := memoryUsage{}
+	enforcer := &domain.Enforcer{
+		Plans: staticPlans{"acme": {Name: "free", MaxProducts: 1, MaxOrdersPerMonth: 2}},
+		Usage: usage,
+		Now:   func() time.Time { return time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC) },
+	}
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	assert.NoError(t, enforcer.Consume(ctx, domain.MetricProducts, 1))
+	err := enforcer.Consume(ctx, domain.MetricProducts, 1)
+
+	var exceeded *domain.ExceededError
+	assert.ErrorAs(t, err, &exceeded)
+	assert.True(t, errors.Is(err, domain.ErrQuotaExceeded))
+	assert.Equal(t, domain.MetricProducts, exceeded.Metric)
+	assert.Equal(t, int64(1), exceeded.Limit)
+
+	assert.NoError(t, enforcer.Consume(ctx, domain.MetricOrdersPerMonth, 1))
+	assert.NoError(t, enforcer.Release(ctx, domain.MetricOrdersPerMonth, 1))
+	assert.NoError(t, enforcer.Consume(ctx, domain.MetricOrdersPerMonth, 1))
+
+	report, err := enforcer.Report(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.UsageReport{
+		TenantID: "acme",
+		Plan:     "free",
+		Period:   "2024-05",
+		Metrics: []domain.MetricUsage{
+			{Metric: domain.MetricProducts, Used: 1, Limit: 1},
+			{Metric: domain.MetricOrdersPerMonth, Used: 1, Limit: 2},
+		},
+	}, report)
+}
+
+func TestPeriodFor(t *testing.T) {
+	at := time.Date(2024, 6, 1, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	assert.Equal(t, "2024-05", domain.PeriodFor(domain.MetricOrdersPerMonth, at), "months are counted in UTC")
+	assert.Equal(t, "", domain.PeriodFor(domain.MetricProducts, at))
+}
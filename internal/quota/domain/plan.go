@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type Metric string
+
+const (
+	MetricProducts             Metric = "products"
+	MetricOrdersPerMonth       Metric = "orders_per_month"
+	MetricAPIRequestsPerMinute Metric = "api_requests_per_minute"
+)
+
+// Plan limits what a tenant may do; a zero limit means unlimited
+type Plan struct {
+	Name                 string
+	MaxProducts          int64
+	MaxOrdersPerMonth    int64
+	APIRequestsPerMinute int64
+}
+
+// Limit returns the plan limit for metric, 0 when unlimited
+func (p Plan) Limit(metric Metric) int64 {
+	switch metric {
+	case MetricProducts:
+		return p.MaxProducts
+	case MetricOrdersPerMonth:
+		return p.MaxOrdersPerMonth
+	case MetricAPIRequestsPerMinute:
+		return p.APIRequestsPerMinute
+	}
+	return 0
+}
+
+// Plans are the plans tenants can be assigned to
+var Plans = map[string]Plan{
+	"free":       {Name: "free", MaxProducts: 100, MaxOrdersPerMonth: 1000, APIRequestsPerMinute: 120},
+	"pro":        {Name: "pro", MaxProducts: 10000, MaxOrdersPerMonth: 100000, APIRequestsPerMinute: 1200},
+	"enterprise": {Name: "enterprise"},
+}
+
+// TenantPlan assigns a plan to a tenant; tenants without a row get the default plan
+type TenantPlan struct {
+	TenantID  string `gorm:"primaryKey;type:varchar(64)"`
+	Plan      string `gorm:"type:varchar(32);not null"`
+	UpdatedAt time.Time
+}
+
+// PlanResolver returns the plan of a tenant
+type PlanResolver interface {
+	PlanFor(ctx context.Context, tenantID string) (Plan, error)
+}
+
+// PeriodFor is the usage period a metric is counted in: calendar months (UTC) for monthly
+// metrics, a single lifetime period ("") for totals
+func PeriodFor(metric Metric, t time.Time) string {
+	if metric == MetricOrdersPerMonth {
+		return t.UTC().Format("2006-01")
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQuotaExceeded is matched by every ExceededError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrorCodeQuotaExceeded is the stable API error code for ErrQuotaExceeded
+const ErrorCodeQuotaExceeded = "quota_exceeded"
+
+// ExceededError reports which plan limit an operation ran into
+type ExceededError struct {
+	Metric Metric
+	Limit  int64
+	Plan   string
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s limit of %d reached on the %s plan", e.Metric, e.Limit, e.Plan)
+}
+
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// UsageCounter is the usage of one metric by a tenant within a period
+type UsageCounter struct {
+	TenantID  string `gorm:"primaryKey;type:varchar(64)"`
+	Metric    Metric `gorm:"primaryKey;type:varchar(64)"`
+	Period    string `gorm:"primaryKey;type:varchar(16)"`
+	Count     int64  `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+type UsageRepository interface {
+	// Increment atomically adds n to the counter unless the result would exceed limit
+	// (limit <= 0 means unlimited) and reports whether it was applied
+	Increment(ctx context.Context, tenantID string, metric Metric, period string, n, limit int64) (bool, error)
+	// Get returns the counter value, 0 when nothing was counted yet
+	Get(ctx context.Context, tenantID string, metric Metric, period string) (int64, error)
+}
+
+// Limiter is the port commands use to enforce plan limits
+type Limiter interface {
+	// Consume counts n units of metric for the tenant in ctx, failing with ExceededError above the plan limit
+	Consume(ctx context.Context, metric Metric, n int64) error
+	// Release gives back units of a consumed operation that did not complete
+	Release(ctx context.Context, metric Metric, n int64) error
+}
@@ -0,0 +1,113 @@
+package port
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+)
+
+// ErrorCodeRateLimited is returned with 429 when a tenant exceeds its API request rate
+const ErrorCodeRateLimited = "rate_limited"
+
+// RateLimiter counts API requests per tenant
+type RateLimiter interface {
+	Allow(ctx context.Context, tenantID string) (bool, domain.Plan, error)
+	Current(tenantID string) int64
+}
+
+// UsageReporter reports the counted usage of the tenant in ctx
+type UsageReporter interface {
+	Report(ctx context.Context) (*domain.UsageReport, error)
+}
+
+// MetricUsageResponse is the usage of a single metric; Limit is null when unlimited
+type MetricUsageResponse struct {
+	Metric string `json:"metric"`
+	Used   int64  `json:"used"`
+	Limit  *int64 `json:"limit"`
+}
+
+// UsageResponse is the body of GET /usage
+type UsageResponse struct {
+	TenantID string                `json:"tenant_id"`
+	Plan     string                `json:"plan"`
+	Period   string                `json:"period"`
+	Metrics  []MetricUsageResponse `json:"metrics"`
+}
+
+// HTTPServer exposes plan usage to tenants
+type HTTPServer struct {
+	Usage       UsageReporter
+	RateLimiter RateLimiter
+}
+
+// RegisterRoutes adds the quota endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/usage",
+		Summary:  "Get the plan usage of the current tenant",
+		Tags:     []string{"quota"},
+		Response: UsageResponse{},
+		Handler:  s.getUsage,
+	})
+}
+
+func (s *HTTPServer) getUsage(w http.ResponseWriter, r *http.Request) {
+	report, err := s.Usage.Report(r.Context())
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := UsageResponse{TenantID: report.TenantID, Plan: report.Plan, Period: report.Period}
+	for _, usage := range report.Metrics {
+		resp.Metrics = append(resp.Metrics, toMetricUsageResponse(usage))
+	}
+
+	plan := domain.Plans[report.Plan]
+	resp.Metrics = append(resp.Metrics, toMetricUsageResponse(domain.MetricUsage{
+		Metric: domain.MetricAPIRequestsPerMinute,
+		Used:   s.RateLimiter.Current(report.TenantID),
+		Limit:  plan.Limit(domain.MetricAPIRequestsPerMinute),
+	}))
+
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func toMetricUsageResponse(usage domain.MetricUsage) MetricUsageResponse {
+	resp := MetricUsageResponse{Metric: string(usage.Metric), Used: usage.Used}
+	if usage.Limit > 0 {
+		limit := usage.Limit
+		resp.Limit = &limit
+	}
+	return resp
+}
+
+// RateLimitMiddleware rejects requests above the tenant's APIRequestsPerMinute with 429.
+// It must run after tenant.Middleware.
+func RateLimitMiddleware(limiter RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, plan, err := limiter.Allow(r.Context(), tenant.FromContext(r.Context()))
+			if err != nil {
+				httpx.WriteError(w, err)
+				return
+			}
+			if !allowed {
+				limit := plan.Limit(domain.MetricAPIRequestsPerMinute)
+				w.Header().Set("Retry-After", "60")
+				w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+				httpx.WriteErrorCode(w, http.StatusTooManyRequests, ErrorCodeRateLimited,
+					fmt.Errorf("rate limit of %d requests per minute exceeded", limit))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// soldStatuses are the statuses of orders that were confirmed and neither cancelled nor refunded
+var soldStatuses = []orderDomain.OrderStatus{orderDomain.StatusConfirmed, orderDomain.StatusShipped, orderDomain.StatusDelivered}
+
+// GormSaleSource reads sales from the orders table. Sandbox orders are test orders and are not sales.
+type GormSaleSource struct {
+	db *gorm.DB
+}
+
+func NewGormSaleSource(db *gorm.DB) domain.SaleSource {
+	return &GormSaleSource{db: db}
+}
+
+func (s *GormSaleSource) SalesBetween(ctx context.Context, from, to time.Time) ([]domain.Sale, error) {
+	var rows []struct {
+		TenantID        string
+		ProductID       int64
+		ProductPublicID string
+		Quantity        int
+		Total           money.Money
+	}
+	err := persistence.Conn(ctx, s.db).Model(&orderDomain.Order{}).
+		Select("orders.tenant_id, orders.product_id, products.public_id AS product_public_id, orders.quantity, orders.totals_total AS total").
+		Joins("JOIN products ON products.id = orders.product_id").
+		Where("orders.created_at >= ? AND orders.created_at < ?", from, to).
+		Where("orders.status IN ? AND orders.sandbox = ?", soldStatuses, false).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	sales := make([]domain.Sale, len(rows))
+	for i, r := range rows {
+		sales[i] = domain.Sale{TenantID: r.TenantID, ProductID: r.ProductID, ProductPublicID: r.ProductPublicID, Units: r.Quantity, Revenue: r.Total}
+	}
+	return sales, nil
+}
@@ -0,0 +1,51 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormSalesRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSalesRepository(db *gorm.DB) domain.SalesRepository {
+	return &GormSalesRepository{db: db}
+}
+
+func (r *GormSalesRepository) ReplaceDay(ctx context.Context, day time.Time, daily []domain.DailySales, products []domain.DailyProductSales) error {
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day = ?", day).Delete(&domain.DailyProductSales{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("day = ?", day).Delete(&domain.DailySales{}).Error; err != nil {
+			return err
+		}
+		if len(daily) > 0 {
+			if err := tx.Create(&daily).Error; err != nil {
+				return err
+			}
+		}
+		if len(products) > 0 {
+			return tx.Create(&products).Error
+		}
+		return nil
+	})
+	return persistence.TranslateError(err)
+}
+
+func (r *GormSalesRepository) ListDaily(ctx context.Context, from, to time.Time) ([]domain.DailySales, error) {
+	var daily []domain.DailySales
+	err := persistence.Conn(ctx, r.db).Where("day >= ? AND day < ?", from, to).Order("day, currency").Find(&daily).Error
+	return daily, persistence.TranslateError(err)
+}
+
+func (r *GormSalesRepository) ListProducts(ctx context.Context, from, to time.Time) ([]domain.DailyProductSales, error) {
+	var products []domain.DailyProductSales
+	err := persistence.Conn(ctx, r.db).Where("day >= ? AND day < ?", from, to).Order("day, currency, product_id").Find(&products).Error
+	return products, persistence.TranslateError(err)
+}
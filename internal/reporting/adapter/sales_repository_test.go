@@ -0,0 +1,79 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/pricing"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&productDomain.Product{}, &orderDomain.Order{}, &domain.DailySales{}, &domain.DailyProductSales{}))
+	return db
+}
+
+func TestGormSaleSource_SalesBetween(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&productDomain.Product{ID: 1, PublicID: "prd_kettle", Name: "Kettle"}).Error)
+	day := time.Date(2024, 5, 18, 0, 0, 0, 0, time.UTC)
+	total := money.Money{Amount: 2999, Currency: "EUR"}
+
+	for _, o := range []orderDomain.Order{
+		{ProductID: 1, Quantity: 2, Status: orderDomain.StatusConfirmed, CreatedAt: day.Add(time.Hour)},
+		{ProductID: 1, Quantity: 1, Status: orderDomain.StatusDelivered, CreatedAt: day.Add(23 * time.Hour)},
+		{ProductID: 1, Quantity: 1, Status: orderDomain.StatusCancelled, CreatedAt: day.Add(time.Hour)},
+		{ProductID: 1, Quantity: 1, Status: orderDomain.StatusConfirmed, CreatedAt: day.Add(time.Hour), Sandbox: true},
+		{ProductID: 1, Quantity: 1, Status: orderDomain.StatusConfirmed, CreatedAt: day.AddDate(0, 0, 1)},
+	} {
+		o.TenantID, o.Totals = "default", pricing.Totals{Total: total}
+		require.NoError(t, db.Omit("User", "Product").Create(&o).Error)
+	}
+
+	sales, err := adapter.NewGormSaleSource(db).SalesBetween(context.Background(), day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, sales, 2, "cancelled, sandbox and next day's orders are no sales")
+	assert.Equal(t, domain.Sale{TenantID: "default", ProductID: 1, ProductPublicID: "prd_kettle", Units: 2, Revenue: total}, sales[0])
+}
+
+func TestGormSalesRepository_ReplaceDay(t *testing.T) {
+	repo := adapter.NewGormSalesRepository(setupTestDB(t))
+	ctx := context.Background()
+	day := time.Date(2024, 5, 18, 0, 0, 0, 0, time.UTC)
+	sales := []domain.Sale{{TenantID: "default", ProductID: 1, ProductPublicID: "prd_a", Units: 2, Revenue: money.Money{Amount: 2000, Currency: "EUR"}}}
+
+	daily, products, err := domain.Aggregate(day, sales, day)
+	require.NoError(t, err)
+	require.NoError(t, repo.ReplaceDay(ctx, day, daily, products))
+	next, _, err := domain.Aggregate(day.AddDate(0, 0, 1), sales, day)
+	require.NoError(t, err)
+	require.NoError(t, repo.ReplaceDay(ctx, day.AddDate(0, 0, 1), next, nil))
+
+	sales = append(sales, sales[0])
+	daily, products, err = domain.Aggregate(day, sales, day)
+	require.NoError(t, err)
+	require.NoError(t, repo.ReplaceDay(ctx, day, daily, products), "aggregating a day again replaces its rows")
+
+	found, err := repo.ListDaily(ctx, day, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, int64(2), found[0].Orders)
+	assert.Equal(t, money.Money{Amount: 4000, Currency: "EUR"}, found[0].Revenue)
+	assert.True(t, found[1].Day.Equal(day.AddDate(0, 0, 1)), "oldest first")
+
+	breakdown, err := repo.ListProducts(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, breakdown, 1)
+	assert.Equal(t, int64(4), breakdown[0].Units)
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+)
+
+// AggregateDailySalesCommand rolls the orders placed on one UTC day up into its daily sales,
+// replacing what an earlier run of the same day saved
+type AggregateDailySalesCommand struct {
+	Day time.Time
+}
+
+type AggregateDailySalesHandler struct {
+	Sales   domain.SaleSource
+	Reports domain.SalesRepository
+	Now     func() time.Time
+}
+
+func (h *AggregateDailySalesHandler) Handle(ctx context.Context, cmd AggregateDailySalesCommand) error {
+	from := domain.StartOfDay(cmd.Day)
+	to := from.AddDate(0, 0, 1)
+
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	sales, err := h.Sales.SalesBetween(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("read sales of %s: %w", from.Format(domain.DayLayout), err)
+	}
+	daily, products, err := domain.Aggregate(from, sales, now())
+	if err != nil {
+		return fmt.Errorf("aggregate sales of %s: %w", from.Format(domain.DayLayout), err)
+	}
+	if err := h.Reports.ReplaceDay(ctx, from, daily, products); err != nil {
+		return fmt.Errorf("save sales of %s: %w", from.Format(domain.DayLayout), err)
+	}
+
+	slog.InfoContext(ctx, "daily sales aggregated", "day", from.Format(domain.DayLayout), "orders", len(sales), "rows", len(daily))
+	return nil
+}
@@ -0,0 +1,82 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+const (
+	// DefaultReportDays is the period of reports without dates: the days up to yesterday
+	DefaultReportDays = 30
+	// MaxReportDays bounds the period of a report
+	MaxReportDays      = 366
+	DefaultTopProducts = 10
+	MaxTopProducts     = 100
+)
+
+// SalesReportQuery reports the daily sales of the UTC days from From to To, both included
+type SalesReportQuery struct {
+	// From defaults to DefaultReportDays days before To, and To to yesterday, the last day
+	// the nightly job aggregated
+	From time.Time
+	To   time.Time
+	// TopProducts is how many products each series breaks down; it defaults to DefaultTopProducts
+	TopProducts int
+}
+
+// SalesReport is the sales of a period, one series per currency
+type SalesReport struct {
+	From   time.Time
+	To     time.Time
+	Series []domain.Series
+}
+
+type SalesReportHandler struct {
+	Reports domain.SalesRepository
+	Now     func() time.Time
+}
+
+func (h *SalesReportHandler) Handle(ctx context.Context, q SalesReportQuery) (*SalesReport, error) {
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	to := domain.StartOfDay(now()).AddDate(0, 0, -1)
+	if !q.To.IsZero() {
+		to = domain.StartOfDay(q.To)
+	}
+	from := to.AddDate(0, 0, 1-DefaultReportDays)
+	if !q.From.IsZero() {
+		from = domain.StartOfDay(q.From)
+	}
+	if q.TopProducts == 0 {
+		q.TopProducts = DefaultTopProducts
+	}
+
+	var errs validation.Errors
+	errs.Check(!from.After(to), "from", "must not be after to")
+	errs.Check(!to.After(from.AddDate(0, 0, MaxReportDays-1)), "to", fmt.Sprintf("must be at most %d days after from", MaxReportDays))
+	errs.Check(q.TopProducts >= 1 && q.TopProducts <= MaxTopProducts, "top_products", fmt.Sprintf("must be between 1 and %d, got %d", MaxTopProducts, q.TopProducts))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	end := to.AddDate(0, 0, 1)
+	daily, err := h.Reports.ListDaily(ctx, from, end)
+	if err != nil {
+		return nil, fmt.Errorf("list daily sales: %w", err)
+	}
+	products, err := h.Reports.ListProducts(ctx, from, end)
+	if err != nil {
+		return nil, fmt.Errorf("list product sales: %w", err)
+	}
+	series, err := domain.BuildReport(from, end, daily, products, q.TopProducts)
+	if err != nil {
+		return nil, err
+	}
+	return &SalesReport{From: from, To: to, Series: series}, nil
+}
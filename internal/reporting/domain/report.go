@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// SalesTotals is how many orders, units and how much revenue a day, product or period had
+type SalesTotals struct {
+	Orders  int64
+	Units   int64
+	Revenue money.Money
+}
+
+func (t *SalesTotals) add(orders, units int64, revenue money.Money) error {
+	sum, err := t.Revenue.Add(revenue)
+	if err != nil {
+		return err
+	}
+	t.Orders, t.Units, t.Revenue = t.Orders+orders, t.Units+units, sum
+	return nil
+}
+
+// DayPoint is the sales of one day of a series
+type DayPoint struct {
+	Day time.Time
+	SalesTotals
+}
+
+// ProductTotals is the sales of a product over the period of a report
+type ProductTotals struct {
+	ProductPublicID string
+	SalesTotals
+}
+
+// Series is the sales of a period in one currency: a point for every day, days without sales
+// included, the total of the period and the products that brought in the most revenue
+type Series struct {
+	Currency string
+	Days     []DayPoint
+	Total    SalesTotals
+	Products []ProductTotals
+}
+
+// BuildReport turns the rows of the days in [from, to) into one series per currency, sorted by
+// currency, with at most top products each, highest revenue first. Rows of several tenants, as
+// unscoped callers see them, are added up.
+func BuildReport(from, to time.Time, daily []DailySales, products []DailyProductSales, top int) ([]Series, error) {
+	from, to = StartOfDay(from), StartOfDay(to)
+	byCurrency := make(map[string]*Series)
+	series := func(currency string) *Series {
+		s, ok := byCurrency[currency]
+		if !ok {
+			s = &Series{Currency: currency, Total: SalesTotals{Revenue: money.Money{Currency: currency}}}
+			for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+				s.Days = append(s.Days, DayPoint{Day: day, SalesTotals: SalesTotals{Revenue: money.Money{Currency: currency}}})
+			}
+			byCurrency[currency] = s
+		}
+		return s
+	}
+
+	for _, d := range daily {
+		s := series(d.Currency)
+		i := int(StartOfDay(d.Day).Sub(from) / (24 * time.Hour))
+		if i < 0 || i >= len(s.Days) {
+			continue
+		}
+		if err := s.Days[i].add(d.Orders, d.Units, d.Revenue); err != nil {
+			return nil, err
+		}
+		if err := s.Total.add(d.Orders, d.Units, d.Revenue); err != nil {
+			return nil, err
+		}
+	}
+
+	type productKey struct{ currency, product string }
+	productTotals := make(map[productKey]*ProductTotals)
+	for _, p := range products {
+		key := productKey{p.Currency, p.ProductPublicID}
+		t, ok := productTotals[key]
+		if !ok {
+			t = &ProductTotals{ProductPublicID: p.ProductPublicID, SalesTotals: SalesTotals{Revenue: money.Money{Currency: p.Currency}}}
+			productTotals[key] = t
+		}
+		if err := t.add(p.Orders, p.Units, p.Revenue); err != nil {
+			return nil, err
+		}
+	}
+	for key, t := range productTotals {
+		s := series(key.currency)
+		s.Products = append(s.Products, *t)
+	}
+
+	report := make([]Series, 0, len(byCurrency))
+	for _, s := range byCurrency {
+		slices.SortFunc(s.Products, func(a, b ProductTotals) int {
+			return cmp.Or(cmp.Compare(b.Revenue.Amount, a.Revenue.Amount), cmp.Compare(a.ProductPublicID, b.ProductPublicID))
+		})
+		if len(s.Products) > top {
+			s.Products = s.Products[:top]
+		}
+		report = append(report, *s)
+	}
+	slices.SortFunc(report, func(a, b Series) int { return cmp.Compare(a.Currency, b.Currency) })
+	return report, nil
+}
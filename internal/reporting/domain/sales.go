@@ -0,0 +1,148 @@
+// Package domain describes the sales reports of the dashboards: orders rolled up into one row per
+// day and currency, with a breakdown by product, so reports over months never scan the orders
+package domain
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// DayLayout is how days are written in reports and job payloads, e.g. 2024-05-18
+const DayLayout = "2006-01-02"
+
+// Sale is an order that counts towards the sales of the day it was placed on: one that was
+// confirmed and neither cancelled nor refunded
+type Sale struct {
+	TenantID string
+	// ProductID and ProductPublicID are the primary key and public ID of the ordered product
+	ProductID       int64
+	ProductPublicID string
+	Units           int
+	// Revenue is the total charged for the order; it is zero for orders without totals
+	Revenue money.Money
+}
+
+// DailySales is the sales of a tenant on a UTC day in one currency. Rows are replaced whenever the
+// day is aggregated again, see SalesRepository.ReplaceDay. Daily sales belong to a tenant; see
+// tenant.Plugin.
+type DailySales struct {
+	ID       int64  `gorm:"primaryKey"`
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_daily_sales_day,priority:1"`
+	// Day is the midnight UTC starting the day
+	Day      time.Time   `gorm:"not null;uniqueIndex:idx_daily_sales_day,priority:2"`
+	Currency string      `gorm:"type:varchar(3);not null;uniqueIndex:idx_daily_sales_day,priority:3"`
+	Orders   int64       `gorm:"not null;default:0"`
+	Units    int64       `gorm:"not null;default:0"`
+	Revenue  money.Money `gorm:"type:varchar(32)"`
+	// AggregatedAt is when the day was last aggregated
+	AggregatedAt time.Time
+}
+
+func (DailySales) TableName() string {
+	return "daily_sales"
+}
+
+// DailyProductSales breaks the DailySales of the same tenant, day and currency down by product
+type DailyProductSales struct {
+	ID              int64       `gorm:"primaryKey"`
+	TenantID        string      `gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_daily_product_sales_day,priority:1"`
+	Day             time.Time   `gorm:"not null;uniqueIndex:idx_daily_product_sales_day,priority:2"`
+	Currency        string      `gorm:"type:varchar(3);not null;uniqueIndex:idx_daily_product_sales_day,priority:3"`
+	ProductID       int64       `gorm:"not null;uniqueIndex:idx_daily_product_sales_day,priority:4;index"`
+	ProductPublicID string      `gorm:"type:varchar(32)"`
+	Orders          int64       `gorm:"not null;default:0"`
+	Units           int64       `gorm:"not null;default:0"`
+	Revenue         money.Money `gorm:"type:varchar(32)"`
+	AggregatedAt    time.Time
+}
+
+func (DailyProductSales) TableName() string {
+	return "daily_product_sales"
+}
+
+// StartOfDay returns the midnight UTC starting the day of t
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Aggregate rolls the sales of a day up by tenant and currency, and by product within them.
+// Sales without revenue, such as orders of unpriced products, have no currency to be reported
+// in and are left out. Rows come sorted by tenant, currency and product.
+func Aggregate(day time.Time, sales []Sale, at time.Time) ([]DailySales, []DailyProductSales, error) {
+	type dayKey struct{ tenant, currency string }
+	type productKey struct {
+		dayKey
+		product int64
+	}
+	day = StartOfDay(day)
+	days := make(map[dayKey]*DailySales)
+	products := make(map[productKey]*DailyProductSales)
+
+	for _, s := range sales {
+		if s.Revenue.Currency == "" {
+			continue
+		}
+		dk := dayKey{s.TenantID, s.Revenue.Currency}
+		d, ok := days[dk]
+		if !ok {
+			d = &DailySales{TenantID: s.TenantID, Day: day, Currency: dk.currency, Revenue: money.Money{Currency: dk.currency}, AggregatedAt: at}
+			days[dk] = d
+		}
+		pk := productKey{dk, s.ProductID}
+		p, ok := products[pk]
+		if !ok {
+			p = &DailyProductSales{TenantID: s.TenantID, Day: day, Currency: dk.currency, ProductID: s.ProductID,
+				ProductPublicID: s.ProductPublicID, Revenue: money.Money{Currency: dk.currency}, AggregatedAt: at}
+			products[pk] = p
+		}
+
+		var err error
+		if d.Revenue, err = d.Revenue.Add(s.Revenue); err != nil {
+			return nil, nil, err
+		}
+		if p.Revenue, err = p.Revenue.Add(s.Revenue); err != nil {
+			return nil, nil, err
+		}
+		d.Orders++
+		d.Units += int64(s.Units)
+		p.Orders++
+		p.Units += int64(s.Units)
+	}
+
+	dailies := make([]DailySales, 0, len(days))
+	for _, d := range days {
+		dailies = append(dailies, *d)
+	}
+	slices.SortFunc(dailies, func(a, b DailySales) int {
+		return cmp.Or(cmp.Compare(a.TenantID, b.TenantID), cmp.Compare(a.Currency, b.Currency))
+	})
+	breakdown := make([]DailyProductSales, 0, len(products))
+	for _, p := range products {
+		breakdown = append(breakdown, *p)
+	}
+	slices.SortFunc(breakdown, func(a, b DailyProductSales) int {
+		return cmp.Or(cmp.Compare(a.TenantID, b.TenantID), cmp.Compare(a.Currency, b.Currency), cmp.Compare(a.ProductID, b.ProductID))
+	})
+	return dailies, breakdown, nil
+}
+
+// SaleSource reads the sales of a period from the orders
+type SaleSource interface {
+	// SalesBetween returns the sales placed in [from, to)
+	SalesBetween(ctx context.Context, from, to time.Time) ([]Sale, error)
+}
+
+type SalesRepository interface {
+	// ReplaceDay deletes the rows of day and saves daily and products in their place, in one
+	// transaction, so aggregating a day again never counts an order twice
+	ReplaceDay(ctx context.Context, day time.Time, daily []DailySales, products []DailyProductSales) error
+	// ListDaily returns the daily sales of the days in [from, to), oldest first
+	ListDaily(ctx context.Context, from, to time.Time) ([]DailySales, error)
+	// ListProducts returns the product breakdown of the days in [from, to)
+	ListProducts(ctx context.Context, from, to time.Time) ([]DailyProductSales, error)
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+func eur(cents int64) money.Money {
+	return money.Money{Amount: cents, Currency: "EUR"}
+}
+
+func TestAggregate(t *testing.T) {
+	day := time.Date(2024, 5, 18, 15, 4, 0, 0, time.UTC)
+	sales := []Sale{
+		{TenantID: "default", ProductID: 1, ProductPublicID: "prd_a", Units: 2, Revenue: eur(2000)},
+		{TenantID: "default", ProductID: 2, ProductPublicID: "prd_b", Units: 1, Revenue: eur(550)},
+		{TenantID: "default", ProductID: 1, ProductPublicID: "prd_a", Units: 1, Revenue: eur(1000)},
+		{TenantID: "default", ProductID: 1, ProductPublicID: "prd_a", Units: 3, Revenue: money.Money{Amount: 4500, Currency: "USD"}},
+		{TenantID: "acme", ProductID: 3, ProductPublicID: "prd_c", Units: 1, Revenue: eur(100)},
+		{TenantID: "default", ProductID: 4, ProductPublicID: "prd_d", Units: 5},
+	}
+
+	daily, products, err := Aggregate(day, sales, day)
+	if err != nil {
+		t.Fatalf("Aggregate() = %v", err)
+	}
+	if len(daily) != 3 || len(products) != 4 {
+		t.Fatalf("Aggregate() = %d days and %d products, want 3 and 4", len(daily), len(products))
+	}
+	want := DailySales{TenantID: "default", Currency: "EUR", Orders: 3, Units: 4, Revenue: eur(3550)}
+	got := daily[1]
+	if got.TenantID != want.TenantID || got.Currency != want.Currency || got.Orders != want.Orders || got.Units != want.Units || got.Revenue != want.Revenue {
+		t.Errorf("Aggregate() default EUR = %+v, want %+v", got, want)
+	}
+	if !got.Day.Equal(time.Date(2024, 5, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Aggregate() day = %v, want the start of the day", got.Day)
+	}
+	if p := products[1]; p.ProductPublicID != "prd_a" || p.Currency != "EUR" || p.Orders != 2 || p.Units != 3 || p.Revenue != eur(3000) {
+		t.Errorf("Aggregate() default EUR prd_a = %+v", p)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 3)
+	daily := []DailySales{
+		{TenantID: "default", Day: from, Currency: "EUR", Orders: 2, Units: 3, Revenue: eur(3000)},
+		{TenantID: "acme", Day: from, Currency: "EUR", Orders: 1, Units: 1, Revenue: eur(500)},
+		{TenantID: "default", Day: from.AddDate(0, 0, 2), Currency: "EUR", Orders: 1, Units: 1, Revenue: eur(1000)},
+	}
+	products := []DailyProductSales{
+		{Day: from, Currency: "EUR", ProductPublicID: "prd_a", Orders: 2, Units: 3, Revenue: eur(3000)},
+		{Day: from, Currency: "EUR", ProductPublicID: "prd_b", Orders: 1, Units: 1, Revenue: eur(500)},
+		{Day: from.AddDate(0, 0, 2), Currency: "EUR", ProductPublicID: "prd_b", Orders: 1, Units: 1, Revenue: eur(1000)},
+	}
+
+	report, err := BuildReport(from, to, daily, products, 1)
+	if err != nil {
+		t.Fatalf("BuildReport() = %v", err)
+	}
+	if len(report) != 1 || len(report[0].Days) != 3 {
+		t.Fatalf("BuildReport() = %+v, want one series of 3 days", report)
+	}
+	s := report[0]
+	if s.Days[0].Orders != 3 || s.Days[0].Revenue != eur(3500) {
+		t.Errorf("BuildReport() first day = %+v, want the tenants added up", s.Days[0])
+	}
+	if s.Days[1].Orders != 0 || s.Days[1].Revenue != eur(0) {
+		t.Errorf("BuildReport() second day = %+v, want an empty point", s.Days[1])
+	}
+	if s.Total.Orders != 4 || s.Total.Units != 5 || s.Total.Revenue != eur(4500) {
+		t.Errorf("BuildReport() total = %+v", s.Total)
+	}
+	if len(s.Products) != 1 || s.Products[0].ProductPublicID != "prd_a" {
+		t.Errorf("BuildReport() products = %+v, want prd_a with the most revenue", s.Products)
+	}
+}
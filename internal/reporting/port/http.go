@@ -0,0 +1,127 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// SalesPointResponse is the sales of one day, or of a product or the whole period; Revenue is in
+// minor units of the currency of its series
+type SalesPointResponse struct {
+	Day       string `json:"day,omitempty"`
+	ProductID string `json:"product_id,omitempty"`
+	Orders    int64  `json:"orders"`
+	Units     int64  `json:"units"`
+	Revenue   int64  `json:"revenue"`
+}
+
+// SalesSeriesResponse is the sales of the period in one currency, with a point for every day
+type SalesSeriesResponse struct {
+	Currency string               `json:"currency"`
+	Points   []SalesPointResponse `json:"points"`
+	Total    SalesPointResponse   `json:"total"`
+	// Products are the products with the most revenue, highest first
+	Products []SalesPointResponse `json:"products"`
+}
+
+// SalesReportResponse is the body of GET /reports/sales
+type SalesReportResponse struct {
+	From   string                `json:"from"`
+	To     string                `json:"to"`
+	Series []SalesSeriesResponse `json:"series"`
+}
+
+// HTTPServer serves the sales reports of the dashboards over HTTP
+type HTTPServer struct {
+	SalesReport decorator.QueryHandler[query.SalesReportQuery, *query.SalesReport]
+
+	// Auth requires report:read; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the reporting endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/reports/sales",
+		Summary:  "Get the daily sales of a period as one time series per currency, e.g. ?from=2024-05-01&to=2024-05-31&top_products=10; the period defaults to the 30 days up to yesterday",
+		Tags:     []string{"reports"},
+		Response: SalesReportResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionReportRead, s.getSales),
+	})
+}
+
+func (s *HTTPServer) getSales(w http.ResponseWriter, r *http.Request) {
+	q, err := parseSalesReportQuery(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	report, err := s.SalesReport.Handle(r.Context(), q)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := SalesReportResponse{
+		From:   report.From.Format(domain.DayLayout),
+		To:     report.To.Format(domain.DayLayout),
+		Series: make([]SalesSeriesResponse, len(report.Series)),
+	}
+	for i, series := range report.Series {
+		out := SalesSeriesResponse{
+			Currency: series.Currency,
+			Points:   make([]SalesPointResponse, len(series.Days)),
+			Total:    toSalesPoint(series.Total),
+			Products: make([]SalesPointResponse, len(series.Products)),
+		}
+		for j, day := range series.Days {
+			out.Points[j] = toSalesPoint(day.SalesTotals)
+			out.Points[j].Day = day.Day.Format(domain.DayLayout)
+		}
+		for j, product := range series.Products {
+			out.Products[j] = toSalesPoint(product.SalesTotals)
+			out.Products[j].ProductID = product.ProductPublicID
+		}
+		resp.Series[i] = out
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// parseSalesReportQuery reads the from and to parameters as YYYY-MM-DD and top_products, leaving
+// defaults and bounds to the query
+func parseSalesReportQuery(r *http.Request) (query.SalesReportQuery, error) {
+	var q query.SalesReportQuery
+	var errs validation.Errors
+	params := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		day  *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if value := params.Get(p.name); value != "" {
+			day, err := time.Parse(domain.DayLayout, value)
+			errs.Check(err == nil, p.name, fmt.Sprintf("must be a date in YYYY-MM-DD format, got %q", value))
+			*p.day = day
+		}
+	}
+	if value := params.Get("top_products"); value != "" {
+		n, err := strconv.Atoi(value)
+		errs.Check(err == nil && n >= 1, "top_products", fmt.Sprintf("must be a positive number, got %q", value))
+		q.TopProducts = n
+	}
+	return q, errs.Err()
+}
+
+func toSalesPoint(t domain.SalesTotals) SalesPointResponse {
+	return SalesPointResponse{Orders: t.Orders, Units: t.Units, Revenue: t.Revenue.Amount}
+}
@@ -0,0 +1,53 @@
+package port
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// AggregateDailySalesJob rolls the orders of a day up into its daily sales. Day is YYYY-MM-DD; an
+// empty Day aggregates the days before the job runs again, see JobServer.Days.
+type AggregateDailySalesJob struct {
+	Day string `json:"day,omitempty"`
+}
+
+func (AggregateDailySalesJob) Kind() string {
+	return "reporting.aggregate_daily_sales"
+}
+
+// JobServer runs the reporting use cases triggered by background jobs
+type JobServer struct {
+	AggregateDailySales decorator.CommandHandler[command.AggregateDailySalesCommand]
+	// Days is how many days up to yesterday a scheduled run aggregates, so orders cancelled or
+	// refunded after their day leave its sales
+	Days int
+}
+
+// RegisterJobs adds the reporting job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, s.aggregateDailySales)
+}
+
+func (s *JobServer) aggregateDailySales(ctx context.Context, job AggregateDailySalesJob) error {
+	if job.Day != "" {
+		day, err := time.Parse(domain.DayLayout, job.Day)
+		if err != nil {
+			return fmt.Errorf("parse day %q: %w", job.Day, err)
+		}
+		return s.AggregateDailySales.Handle(ctx, command.AggregateDailySalesCommand{Day: day})
+	}
+
+	yesterday := domain.StartOfDay(time.Now()).AddDate(0, 0, -1)
+	for i := max(s.Days, 1) - 1; i >= 0; i-- {
+		if err := s.AggregateDailySales.Handle(ctx, command.AggregateDailySalesCommand{Day: yesterday.AddDate(0, 0, -i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// GormAccountMover moves the reviews of merged users
+type GormAccountMover struct {
+	db      *gorm.DB
+	ratings domain.RatingRepository
+}
+
+func NewGormAccountMover(db *gorm.DB) userDomain.AccountMover {
+	return &GormAccountMover{db: db, ratings: NewGormRatingRepository(db)}
+}
+
+// MoveAccount keeps one review per product: where both users reviewed a product, the review of
+// the target stays and that of the source is deleted, leaving the rating of the product if it
+// was approved
+func (m *GormAccountMover) MoveAccount(ctx context.Context, fromUserID, toUserID int64) ([]userDomain.MergeItem, error) {
+	db := persistence.Conn(ctx, m.db)
+	item := userDomain.MergeItem{Kind: "reviews"}
+	var source []domain.Review
+	if err := db.Where("user_id = ?", fromUserID).Order("id").Find(&source).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	var reviewed []domain.Review
+	if err := db.Select("product_id", "public_id").Where("user_id = ?", toUserID).Find(&reviewed).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	target := make(map[int64]string, len(reviewed))
+	for _, r := range reviewed {
+		target[r.ProductID] = r.PublicID
+	}
+
+	for _, r := range source {
+		kept, ok := target[r.ProductID]
+		if !ok {
+			if err := db.Model(&domain.Review{}).Where("id = ?", r.ID).Update("user_id", toUserID).Error; err != nil {
+				return nil, persistence.TranslateError(err)
+			}
+			item.Moved++
+			continue
+		}
+
+		if err := db.Delete(&domain.Review{}, r.ID).Error; err != nil {
+			return nil, persistence.TranslateError(err)
+		}
+		if r.Status == domain.StatusApproved {
+			if err := m.ratings.Adjust(ctx, r.ProductID, domain.RatingDelta{Count: -1, Sum: -int64(r.Rating)}); err != nil {
+				return nil, err
+			}
+		}
+		item.Conflicts = append(item.Conflicts, fmt.Sprintf("%s: dropped as a duplicate of %s", r.PublicID, kept))
+	}
+	return []userDomain.MergeItem{item}, nil
+}
@@ -0,0 +1,51 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormAccountMover_KeepsOneReviewPerProduct(t *testing.T) {
+	db := setupTestDB(t)
+	reviews := adapter.NewGormReviewRepository(db)
+	ratings := adapter.NewGormRatingRepository(db)
+	ctx := context.Background()
+
+	review := func(productID, userID int64, rating int) *domain.Review {
+		r, err := domain.NewReview(productID, userID, rating, "", true)
+		require.NoError(t, err)
+		delta, err := r.Moderate(domain.StatusApproved, 9, "", time.Now())
+		require.NoError(t, err)
+		require.NoError(t, reviews.Create(ctx, r))
+		require.NoError(t, ratings.Adjust(ctx, productID, delta))
+		return r
+	}
+	kept := review(10, 2, 5)
+	duplicate := review(10, 1, 1)
+	review(20, 1, 4)
+
+	items, err := adapter.NewGormAccountMover(db).MoveAccount(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []userDomain.MergeItem{{
+		Kind:      "reviews",
+		Moved:     1,
+		Conflicts: []string{duplicate.PublicID + ": dropped as a duplicate of " + kept.PublicID},
+	}}, items)
+
+	moved, total, err := reviews.Search(ctx, domain.ReviewFilter{UserID: 2}, 1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.ElementsMatch(t, []int64{10, 20}, []int64{moved[0].ProductID, moved[1].ProductID})
+
+	rating, err := ratings.Get(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, rating.Average(), "the dropped review leaves the rating")
+	assert.EqualValues(t, 1, rating.Count)
+}
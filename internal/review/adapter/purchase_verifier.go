@@ -0,0 +1,34 @@
+package adapter
+
+import (
+	"context"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// purchasedStatuses are the statuses of orders whose customer got, or is getting, the product
+var purchasedStatuses = []orderDomain.OrderStatus{orderDomain.StatusConfirmed, orderDomain.StatusShipped, orderDomain.StatusDelivered}
+
+// GormPurchaseVerifier looks for an order of the product by the user that was confirmed and
+// neither cancelled nor refunded
+type GormPurchaseVerifier struct {
+	db *gorm.DB
+}
+
+func NewGormPurchaseVerifier(db *gorm.DB) domain.PurchaseVerifier {
+	return &GormPurchaseVerifier{db: db}
+}
+
+func (v *GormPurchaseVerifier) HasPurchased(ctx context.Context, userID, productID int64) (bool, error) {
+	var count int64
+	err := persistence.Conn(ctx, v.db).Model(&orderDomain.Order{}).
+		Where("user_id = ? AND product_id = ? AND status IN ?", userID, productID, purchasedStatuses).
+		Limit(1).Count(&count).Error
+	if err != nil {
+		return false, persistence.TranslateError(err)
+	}
+	return count > 0, nil
+}
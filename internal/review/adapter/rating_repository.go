@@ -0,0 +1,43 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormRatingRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRatingRepository(db *gorm.DB) domain.RatingRepository {
+	return &GormRatingRepository{db: db}
+}
+
+func (r *GormRatingRepository) Get(ctx context.Context, productID int64) (domain.ProductRating, error) {
+	var rating domain.ProductRating
+	err := persistence.TranslateError(persistence.Conn(ctx, r.db).Where("product_id = ?", productID).First(&rating).Error)
+	if errors.Is(err, persistence.ErrNotFound) {
+		return domain.ProductRating{ProductID: productID}, nil
+	}
+	return rating, err
+}
+
+// Adjust adds to the stored values in the database rather than writing back ones read before, so
+// concurrent moderations of reviews of the same product never lose an update
+func (r *GormRatingRepository) Adjust(ctx context.Context, productID int64, delta domain.RatingDelta) error {
+	rating := domain.ProductRating{ProductID: productID, Count: delta.Count, Sum: delta.Sum}
+	err := persistence.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "product_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"review_count": gorm.Expr("product_ratings.review_count + ?", delta.Count),
+			"rating_sum":   gorm.Expr("product_ratings.rating_sum + ?", delta.Sum),
+			"updated_at":   gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&rating).Error
+	return persistence.TranslateError(err)
+}
@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormReviewRepository struct {
+	*persistence.GenericRepository[domain.Review, int64]
+	db *gorm.DB
+}
+
+func NewGormReviewRepository(db *gorm.DB) domain.ReviewRepository {
+	return &GormReviewRepository{GenericRepository: persistence.NewGenericRepository[domain.Review, int64](db), db: db}
+}
+
+func (r *GormReviewRepository) Create(ctx context.Context, review *domain.Review) error {
+	err := persistence.TranslateError(persistence.Conn(ctx, r.db).Create(review).Error)
+	if errors.Is(err, persistence.ErrDuplicateKey) {
+		return domain.ErrAlreadyReviewed
+	}
+	return err
+}
+
+func (r *GormReviewRepository) GetByPublicIDForUpdate(ctx context.Context, publicID string) (*domain.Review, error) {
+	var review domain.Review
+	err := persistence.Conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where("public_id = ?", publicID).First(&review).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &review, nil
+}
+
+func (r *GormReviewRepository) UpdateModeration(ctx context.Context, review *domain.Review) error {
+	result := persistence.Conn(ctx, r.db).Model(review).Select("status", "moderated_by", "moderated_at", "moderation_note").Updates(review)
+	if result.Error != nil {
+		return persistence.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormReviewRepository) Search(ctx context.Context, filter domain.ReviewFilter, page, pageSize int) ([]domain.Review, int64, error) {
+	q := func(qb *query.QueryBuilder) *query.QueryBuilder {
+		return qb.ApplyFilters(filter).
+			AddSort("created_at", query.SortOrderDesc).
+			AddSort("id", query.SortOrderDesc).
+			SetPagination(page, pageSize)
+	}
+	total, err := r.Count(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	reviews, err := r.List(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
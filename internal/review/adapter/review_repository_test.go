@@ -0,0 +1,89 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Review{}, &domain.ProductRating{}))
+	return db
+}
+
+func TestGormReviewRepository(t *testing.T) {
+	repo := adapter.NewGormReviewRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	var created []*domain.Review
+	for i, userID := range []int64{1, 2, 3} {
+		r, err := domain.NewReview(10, userID, i+3, "", false)
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(ctx, r))
+		created = append(created, r)
+	}
+	again, err := domain.NewReview(10, 1, 1, "changed my mind", false)
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Create(ctx, again), domain.ErrAlreadyReviewed)
+
+	r, err := repo.GetByPublicIDForUpdate(ctx, created[1].PublicID)
+	require.NoError(t, err)
+	_, err = r.Moderate(domain.StatusApproved, 9, "", r.CreatedAt)
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateModeration(ctx, r))
+
+	reviews, total, err := repo.Search(ctx, domain.ReviewFilter{ProductID: 10}, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, reviews, 2)
+	assert.Equal(t, created[2].PublicID, reviews[0].PublicID, "newest first")
+
+	reviews, total, err = repo.Search(ctx, domain.ReviewFilter{ProductID: 10, Status: domain.StatusApproved}, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, created[1].PublicID, reviews[0].PublicID)
+}
+
+func TestGormRatingRepository_Adjust(t *testing.T) {
+	repo := adapter.NewGormRatingRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	rating, err := repo.Get(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProductRating{ProductID: 10}, rating, "products without approved reviews have no rating")
+
+	require.NoError(t, repo.Adjust(ctx, 10, domain.RatingDelta{Count: 1, Sum: 5}))
+	require.NoError(t, repo.Adjust(ctx, 10, domain.RatingDelta{Count: 1, Sum: 4}))
+	require.NoError(t, repo.Adjust(ctx, 10, domain.RatingDelta{Count: 1, Sum: 2}))
+	require.NoError(t, repo.Adjust(ctx, 10, domain.RatingDelta{Count: -1, Sum: -2}))
+
+	rating, err = repo.Get(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rating.Count)
+	assert.Equal(t, int64(9), rating.Sum)
+	assert.Equal(t, 4.5, rating.Average())
+}
+
+func TestGormPurchaseVerifier(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, product_id INTEGER, status TEXT)").Error)
+	require.NoError(t, db.Exec("INSERT INTO orders (user_id, product_id, status) VALUES (1, 10, ?), (2, 10, ?), (3, 10, ?)",
+		orderDomain.StatusDelivered, orderDomain.StatusCancelled, orderDomain.StatusPending).Error)
+	verifier := adapter.NewGormPurchaseVerifier(db)
+
+	for userID, want := range map[int64]bool{1: true, 2: false, 3: false, 4: false} {
+		got, err := verifier.HasPurchased(context.Background(), userID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "user %d", userID)
+	}
+}
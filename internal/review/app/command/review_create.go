@@ -0,0 +1,50 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// CreateReviewCommand reviews a product on behalf of the user who bought it, or meant to
+type CreateReviewCommand struct {
+	UserID int64 `validate:"required,gt=0"`
+	// ProductID is the public ID of the product
+	ProductID string `validate:"required"`
+	Rating    int
+	Text      string
+}
+
+// CreateReviewHandler records a pending review, marked verified when the user has an order of
+// the product. Reviews only count towards the rating of their product once approved.
+type CreateReviewHandler struct {
+	Reviews   domain.ReviewRepository
+	Products  productDomain.ProductRepository
+	Purchases domain.PurchaseVerifier
+}
+
+func (h *CreateReviewHandler) Handle(ctx context.Context, cmd CreateReviewCommand) (*domain.Review, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+	p, err := h.Products.GetByPublicID(ctx, cmd.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	verified, err := h.Purchases.HasPurchased(ctx, cmd.UserID, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("check purchases of product %s: %w", p.PublicID, err)
+	}
+
+	r, err := domain.NewReview(p.ID, cmd.UserID, cmd.Rating, cmd.Text, verified)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Reviews.Create(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
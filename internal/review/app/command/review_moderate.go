@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// ModerateReviewCommand approves or rejects a review
+type ModerateReviewCommand struct {
+	// ReviewID is the public ID of the review
+	ReviewID    string `validate:"required"`
+	Status      domain.Status
+	ModeratorID int64
+	Note        string `validate:"max=255"`
+}
+
+// ModerateReviewHandler saves the decision and adjusts the rating of the product in one
+// transaction. The review is locked first, so two moderators deciding at once cannot both count it.
+type ModerateReviewHandler struct {
+	Reviews domain.ReviewRepository
+	Ratings domain.RatingRepository
+	Tx      persistence.Transactor
+}
+
+func (h *ModerateReviewHandler) Handle(ctx context.Context, cmd ModerateReviewCommand) (*domain.Review, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	var r *domain.Review
+	err := h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if r, err = h.Reviews.GetByPublicIDForUpdate(ctx, cmd.ReviewID); err != nil {
+			return err
+		}
+		delta, err := r.Moderate(cmd.Status, cmd.ModeratorID, cmd.Note, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if err := h.Reviews.UpdateModeration(ctx, r); err != nil {
+			return fmt.Errorf("moderate review %s: %w", r.PublicID, err)
+		}
+		if delta.IsZero() {
+			return nil
+		}
+		if err := h.Ratings.Adjust(ctx, r.ProductID, delta); err != nil {
+			return fmt.Errorf("adjust rating of product %d: %w", r.ProductID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,76 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+const (
+	DefaultReviewPageSize = 20
+	MaxReviewPageSize     = 100
+)
+
+// ListReviewsQuery pages through reviews, newest first; zero fields match every review
+type ListReviewsQuery struct {
+	// ProductID is the public ID of the product whose reviews to list
+	ProductID string
+	Status    domain.Status
+	// Page starts at 1; PageSize defaults to DefaultReviewPageSize
+	Page     int
+	PageSize int
+}
+
+// ReviewPage is a page of reviews. Rating is the rating of the product for queries naming one.
+type ReviewPage struct {
+	Reviews  []domain.Review
+	Total    int64
+	Page     int
+	PageSize int
+	Rating   *domain.ProductRating
+}
+
+type ListReviewsHandler struct {
+	Reviews  domain.ReviewRepository
+	Ratings  domain.RatingRepository
+	Products productDomain.ProductRepository
+}
+
+func (h *ListReviewsHandler) Handle(ctx context.Context, q ListReviewsQuery) (*ReviewPage, error) {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.PageSize == 0 {
+		q.PageSize = DefaultReviewPageSize
+	}
+	var errs validation.Errors
+	errs.Check(q.Status == "" || q.Status.IsValid(), "status", fmt.Sprintf("must be %s, %s or %s, got %q", domain.StatusPending, domain.StatusApproved, domain.StatusRejected, q.Status))
+	errs.Check(q.Page >= 1, "page", fmt.Sprintf("must be a positive number, got %d", q.Page))
+	errs.Check(q.PageSize >= 1 && q.PageSize <= MaxReviewPageSize, "page_size", fmt.Sprintf("must be between 1 and %d, got %d", MaxReviewPageSize, q.PageSize))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	filter := domain.ReviewFilter{Status: q.Status}
+	page := &ReviewPage{Page: q.Page, PageSize: q.PageSize}
+	if q.ProductID != "" {
+		p, err := h.Products.GetByPublicID(ctx, q.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		rating, err := h.Ratings.Get(ctx, p.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get rating of product %s: %w", p.PublicID, err)
+		}
+		filter.ProductID, page.Rating = p.ID, &rating
+	}
+
+	var err error
+	if page.Reviews, page.Total, err = h.Reviews.Search(ctx, filter, q.Page, q.PageSize); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// ProductRating is the projection of the approved reviews of a product onto their count and the
+// sum of their ratings. It is adjusted by a RatingDelta whenever a review is approved or stops
+// being approved, in the transaction of the moderation, so it never needs to read the reviews.
+type ProductRating struct {
+	ProductID int64 `gorm:"primaryKey;autoIncrement:false"`
+	Count     int64 `gorm:"column:review_count;not null;default:0"`
+	Sum       int64 `gorm:"column:rating_sum;not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// Average is the mean rating rounded to one decimal, e.g. 4.3, or 0 for products without
+// approved reviews
+func (r ProductRating) Average() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return math.Round(float64(r.Sum)/float64(r.Count)*10) / 10
+}
+
+// RatingDelta is how a moderation changes the count and rating sum of a product
+type RatingDelta struct {
+	Count int64
+	Sum   int64
+}
+
+func (d RatingDelta) IsZero() bool {
+	return d == RatingDelta{}
+}
+
+type RatingRepository interface {
+	// Get returns the rating of a product, which is zero for products without approved reviews
+	Get(ctx context.Context, productID int64) (ProductRating, error)
+	// Adjust adds delta to the rating of a product in one statement, creating the rating if needed
+	Adjust(ctx context.Context, productID int64, delta RatingDelta) error
+}
@@ -0,0 +1,149 @@
+// Package domain describes product reviews, the ratings customers give the products they bought,
+// and the average rating of each product kept up to date as reviews are moderated
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// ErrAlreadyReviewed is returned when a customer reviews a product a second time
+var ErrAlreadyReviewed = errors.New("product already reviewed")
+
+// ErrorCodeAlreadyReviewed is returned with 409 for ErrAlreadyReviewed
+const ErrorCodeAlreadyReviewed = "already_reviewed"
+
+// PublicIDPrefix starts the public IDs of reviews, e.g. "rev_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const PublicIDPrefix = "rev"
+
+const (
+	MinRating = 1
+	MaxRating = 5
+	// MaxTextLength bounds the text of a review in characters
+	MaxTextLength = 5000
+)
+
+// Status is where a review stands in moderation
+type Status string
+
+const (
+	// StatusPending reviews wait for a moderator and are not shown or counted yet
+	StatusPending Status = "pending"
+	// StatusApproved reviews are listed with their product and count towards its rating
+	StatusApproved Status = "approved"
+	// StatusRejected reviews are hidden
+	StatusRejected Status = "rejected"
+)
+
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusPending, StatusApproved, StatusRejected:
+		return true
+	}
+	return false
+}
+
+// Review is the rating a customer gives a product, with an optional text. Each customer reviews
+// a product once. Reviews belong to a tenant; see tenant.Plugin.
+type Review struct {
+	ID       int64  `gorm:"primaryKey"`
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';index"`
+	// ProductID and UserID are the primary keys of the product and its reviewer; a customer has
+	// one review per product
+	ProductID int64  `gorm:"not null;uniqueIndex:idx_reviews_product_user,priority:1"`
+	UserID    int64  `gorm:"not null;uniqueIndex:idx_reviews_product_user,priority:2;index"`
+	Rating    int    `gorm:"not null"`
+	Text      string `gorm:"type:text"`
+	// VerifiedPurchase is set when the customer had an order of the product that was not
+	// cancelled or refunded at the time of the review
+	VerifiedPurchase bool   `gorm:"not null;default:false"`
+	Status           Status `gorm:"type:varchar(20);not null;index"`
+	// ModeratedBy is the user who last approved or rejected the review, and ModerationNote why
+	ModeratedBy    *int64
+	ModeratedAt    *time.Time
+	ModerationNote string `gorm:"type:varchar(255)"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// NewReview starts a pending review of a product by a user
+func NewReview(productID, userID int64, rating int, text string, verifiedPurchase bool) (*Review, error) {
+	r := &Review{
+		PublicID:         publicid.New(PublicIDPrefix),
+		ProductID:        productID,
+		UserID:           userID,
+		Rating:           rating,
+		Text:             strings.TrimSpace(text),
+		VerifiedPurchase: verifiedPurchase,
+		Status:           StatusPending,
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Validate checks the review invariants and returns validation.Errors describing every violation
+func (r *Review) Validate() error {
+	var errs validation.Errors
+	errs.Check(r.Rating >= MinRating && r.Rating <= MaxRating, "rating", fmt.Sprintf("must be between %d and %d", MinRating, MaxRating))
+	errs.Check(utf8.RuneCountInString(r.Text) <= MaxTextLength, "text", fmt.Sprintf("must be at most %d characters", MaxTextLength))
+	return errs.Err()
+}
+
+// Moderate moves the review to status on behalf of moderatorID, zero when unknown, and returns how
+// the rating of its product changes: approving adds the review to it, rejecting an approved review
+// takes it out
+func (r *Review) Moderate(status Status, moderatorID int64, note string, at time.Time) (RatingDelta, error) {
+	if status != StatusApproved && status != StatusRejected {
+		var errs validation.Errors
+		errs.Add("status", fmt.Sprintf("must be %q or %q", StatusApproved, StatusRejected))
+		return RatingDelta{}, errs
+	}
+
+	var delta RatingDelta
+	switch {
+	case r.Status != StatusApproved && status == StatusApproved:
+		delta = RatingDelta{Count: 1, Sum: int64(r.Rating)}
+	case r.Status == StatusApproved && status != StatusApproved:
+		delta = RatingDelta{Count: -1, Sum: -int64(r.Rating)}
+	}
+	r.Status, r.ModeratedAt, r.ModerationNote = status, &at, strings.TrimSpace(note)
+	r.ModeratedBy = nil
+	if moderatorID != 0 {
+		r.ModeratedBy = &moderatorID
+	}
+	return delta, nil
+}
+
+// ReviewFilter narrows the reviews returned by Search; zero fields match every review
+type ReviewFilter struct {
+	ProductID int64  `filter:"product_id"`
+	UserID    int64  `filter:"user_id"`
+	Status    Status `filter:"status"`
+}
+
+type ReviewRepository interface {
+	// Create returns ErrAlreadyReviewed when the user reviewed the product before
+	Create(ctx context.Context, r *Review) error
+	// GetByPublicIDForUpdate locks the review until the transaction of ctx ends; it returns
+	// persistence.ErrNotFound for unknown reviews
+	GetByPublicIDForUpdate(ctx context.Context, publicID string) (*Review, error)
+	// UpdateModeration saves the status and moderation fields of the review
+	UpdateModeration(ctx context.Context, r *Review) error
+	// Search returns a page of the reviews matching filter, newest first, and how many match in all
+	Search(ctx context.Context, filter ReviewFilter, page, pageSize int) ([]Review, int64, error)
+}
+
+// PurchaseVerifier tells whether a user bought a product, which marks their review verified
+type PurchaseVerifier interface {
+	HasPurchased(ctx context.Context, userID, productID int64) (bool, error)
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+func TestNewReview_Validates(t *testing.T) {
+	tests := []struct {
+		name   string
+		rating int
+		text   string
+		field  string
+	}{
+		{"valid", 5, "Great kettle", ""},
+		{"without text", 1, "", ""},
+		{"no stars", 0, "", "rating"},
+		{"six stars", 6, "", "rating"},
+		{"text too long", 3, strings.Repeat("ä", MaxTextLength+1), "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReview(1, 2, tt.rating, tt.text, false)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("NewReview() = %v", err)
+				}
+				if r.Status != StatusPending || !strings.HasPrefix(r.PublicID, PublicIDPrefix+"_") {
+					t.Errorf("NewReview() = status %q, public ID %q", r.Status, r.PublicID)
+				}
+				return
+			}
+			var errs validation.Errors
+			if !errors.As(err, &errs) || errs[0].Field != tt.field {
+				t.Errorf("NewReview() = %v, want an error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestReview_Moderate(t *testing.T) {
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	r := &Review{Rating: 4, Status: StatusPending}
+
+	steps := []struct {
+		status Status
+		want   RatingDelta
+	}{
+		{StatusApproved, RatingDelta{Count: 1, Sum: 4}},
+		{StatusApproved, RatingDelta{}},
+		{StatusRejected, RatingDelta{Count: -1, Sum: -4}},
+		{StatusRejected, RatingDelta{}},
+		{StatusApproved, RatingDelta{Count: 1, Sum: 4}},
+	}
+	for i, step := range steps {
+		delta, err := r.Moderate(step.status, 9, "checked", at)
+		if err != nil {
+			t.Fatalf("step %d: Moderate(%s) = %v", i, step.status, err)
+		}
+		if delta != step.want {
+			t.Errorf("step %d: Moderate(%s) = %+v, want %+v", i, step.status, delta, step.want)
+		}
+	}
+	if r.ModeratedBy == nil || *r.ModeratedBy != 9 || r.ModerationNote != "checked" {
+		t.Errorf("moderation = %v %q", r.ModeratedBy, r.ModerationNote)
+	}
+
+	if _, err := r.Moderate(StatusPending, 9, "", at); err == nil {
+		t.Error("Moderate(pending) = nil, want an error")
+	}
+	if r.Status != StatusApproved {
+		t.Errorf("status = %s after a refused moderation", r.Status)
+	}
+}
+
+func TestProductRating_Average(t *testing.T) {
+	if got := (ProductRating{}).Average(); got != 0 {
+		t.Errorf("Average() = %v without reviews", got)
+	}
+	if got := (ProductRating{Count: 3, Sum: 13}).Average(); got != 4.3 {
+		t.Errorf("Average() = %v, want 4.3", got)
+	}
+}
@@ -0,0 +1,221 @@
+package port
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/review/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	sharedQuery "github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+// CreateReviewRequest is the body of POST /products/{id}/reviews
+type CreateReviewRequest struct {
+	// Rating is from 1 to 5 stars
+	Rating int    `json:"rating"`
+	Text   string `json:"text,omitempty"`
+}
+
+// ModerateReviewRequest is the body of POST /reviews/{id}/moderation
+type ModerateReviewRequest struct {
+	// Status is approved or rejected
+	Status domain.Status `json:"status"`
+	Note   string        `json:"note,omitempty"`
+}
+
+// ReviewResponse is a review; moderation fields are only filled in for moderators
+type ReviewResponse struct {
+	ID               string        `json:"id"`
+	Rating           int           `json:"rating"`
+	Text             string        `json:"text,omitempty"`
+	VerifiedPurchase bool          `json:"verified_purchase"`
+	Status           domain.Status `json:"status"`
+	ModeratedAt      *time.Time    `json:"moderated_at,omitempty"`
+	ModerationNote   string        `json:"moderation_note,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+// RatingResponse is the average of the approved reviews of a product, rounded to one decimal
+type RatingResponse struct {
+	Average float64 `json:"average"`
+	Count   int64   `json:"count"`
+}
+
+// ReviewsResponse is a page of reviews, newest first. Rating is reported for the reviews of a product.
+type ReviewsResponse struct {
+	Reviews  []ReviewResponse `json:"reviews"`
+	Rating   *RatingResponse  `json:"rating,omitempty"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
+
+// HTTPServer lets customers review products and staff moderate the reviews over HTTP
+type HTTPServer struct {
+	CreateReview   decorator.CommandResultHandler[command.CreateReviewCommand, *domain.Review]
+	ModerateReview decorator.CommandResultHandler[command.ModerateReviewCommand, *domain.Review]
+	ListReviews    decorator.QueryHandler[query.ListReviewsQuery, *query.ReviewPage]
+
+	// Auth requires review:write to review and review:moderate to moderate; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the review endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/products/{id}/reviews",
+		Summary:  "List the approved reviews of a product with its average rating, newest first; ?page=2&page_size=20",
+		Tags:     []string{"reviews"},
+		Response: ReviewsResponse{},
+		Handler:  s.listProductReviews,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/products/{id}/reviews",
+		Summary:  "Review a product once with 1 to 5 stars; the review is listed once a moderator approves it",
+		Tags:     []string{"reviews"},
+		Request:  CreateReviewRequest{},
+		Response: ReviewResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionReviewWrite, s.createReview),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/reviews",
+		Summary:  "List reviews for moderation, newest first, e.g. ?status=pending&product_id=prd_...&page=1&page_size=20",
+		Tags:     []string{"reviews"},
+		Response: ReviewsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionReviewModerate, s.listReviews),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/reviews/{id}/moderation",
+		Summary:  "Approve or reject a review; approved reviews count towards the rating of their product",
+		Tags:     []string{"reviews"},
+		Request:  ModerateReviewRequest{},
+		Response: ReviewResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionReviewModerate, s.moderateReview),
+		StringID: true,
+	})
+}
+
+func (s *HTTPServer) createReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		auth.WriteError(w, auth.ErrUnauthenticated)
+		return
+	}
+	var req CreateReviewRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	review, err := s.CreateReview.Handle(r.Context(), command.CreateReviewCommand{
+		UserID:    userID,
+		ProductID: r.PathValue("id"),
+		Rating:    req.Rating,
+		Text:      req.Text,
+	})
+	if errors.Is(err, domain.ErrAlreadyReviewed) {
+		httpx.WriteErrorCode(w, http.StatusConflict, domain.ErrorCodeAlreadyReviewed, err)
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, toReviewResponse(review, false))
+}
+
+func (s *HTTPServer) moderateReview(w http.ResponseWriter, r *http.Request) {
+	var req ModerateReviewRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	moderatorID, _ := auth.UserID(r.Context())
+
+	review, err := s.ModerateReview.Handle(r.Context(), command.ModerateReviewCommand{
+		ReviewID:    r.PathValue("id"),
+		Status:      req.Status,
+		ModeratorID: moderatorID,
+		Note:        req.Note,
+	})
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, toReviewResponse(review, true))
+}
+
+func (s *HTTPServer) listProductReviews(w http.ResponseWriter, r *http.Request) {
+	q, err := parsePage(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	// Customers only see what moderators approved
+	q.ProductID, q.Status = r.PathValue("id"), domain.StatusApproved
+	s.writeReviews(w, r, q, false)
+}
+
+func (s *HTTPServer) listReviews(w http.ResponseWriter, r *http.Request) {
+	q, err := parsePage(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	q.ProductID, q.Status = r.URL.Query().Get("product_id"), domain.Status(r.URL.Query().Get("status"))
+	s.writeReviews(w, r, q, true)
+}
+
+func (s *HTTPServer) writeReviews(w http.ResponseWriter, r *http.Request, q query.ListReviewsQuery, moderation bool) {
+	page, err := s.ListReviews.Handle(r.Context(), q)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := ReviewsResponse{Reviews: make([]ReviewResponse, len(page.Reviews)), Total: page.Total, Page: page.Page, PageSize: page.PageSize}
+	for i := range page.Reviews {
+		resp.Reviews[i] = toReviewResponse(&page.Reviews[i], moderation)
+	}
+	if page.Rating != nil {
+		resp.Rating = &RatingResponse{Average: page.Rating.Average(), Count: page.Rating.Count}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// parsePage reads the page and page_size parameters, leaving defaults and bounds to the query
+func parsePage(r *http.Request) (query.ListReviewsQuery, error) {
+	var q query.ListReviewsQuery
+	var errs validation.Errors
+	q.Page, q.PageSize = sharedQuery.ParsePage(r.URL.Query(), query.MaxReviewPageSize, errs.Add)
+	return q, errs.Err()
+}
+
+func toReviewResponse(r *domain.Review, moderation bool) ReviewResponse {
+	resp := ReviewResponse{
+		ID:               r.PublicID,
+		Rating:           r.Rating,
+		Text:             r.Text,
+		VerifiedPurchase: r.VerifiedPurchase,
+		Status:           r.Status,
+		CreatedAt:        r.CreatedAt,
+	}
+	if moderation {
+		resp.ModeratedAt, resp.ModerationNote = r.ModeratedAt, r.ModerationNote
+	}
+	return resp
+}
@@ -0,0 +1,107 @@
+// Package seed loads fixture files of users, products and orders into the database for local
+// development and integration test environments.
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures is the content of a fixture file. Records are identified by their key, which also
+// derives their public ID, so loading a file again updates the records it created before.
+type Fixtures struct {
+	Users    []UserFixture    `json:"users" yaml:"users" validate:"dive"`
+	Products []ProductFixture `json:"products" yaml:"products" validate:"dive"`
+	Orders   []OrderFixture   `json:"orders" yaml:"orders" validate:"dive"`
+}
+
+// UserFixture is a user; Password is only set when given and Roles are granted in addition to any the user has
+type UserFixture struct {
+	Key      string   `json:"key" yaml:"key" validate:"required"`
+	Email    string   `json:"email" yaml:"email" validate:"required"`
+	Password string   `json:"password" yaml:"password"`
+	Active   bool     `json:"active" yaml:"active"`
+	Roles    []string `json:"roles" yaml:"roles"`
+}
+
+// ProductFixture is a product; products without a price are not for sale
+type ProductFixture struct {
+	Key   string        `json:"key" yaml:"key" validate:"required"`
+	Name  string        `json:"name" yaml:"name" validate:"required"`
+	Stock int           `json:"stock" yaml:"stock" validate:"gte=0"`
+	Price *PriceFixture `json:"price" yaml:"price"`
+}
+
+// PriceFixture is an amount in minor units of Currency
+type PriceFixture struct {
+	Amount   int64  `json:"amount" yaml:"amount"`
+	Currency string `json:"currency" yaml:"currency"`
+}
+
+// OrderFixture is an order of a user and a product of the same or an earlier fixture file, named by key.
+// Orders are stored as they are: no stock is reserved and no payment is taken.
+type OrderFixture struct {
+	Key      string `json:"key" yaml:"key" validate:"required"`
+	User     string `json:"user" yaml:"user" validate:"required"`
+	Product  string `json:"product" yaml:"product" validate:"required"`
+	Quantity int    `json:"quantity" yaml:"quantity" validate:"gt=0"`
+	// Status defaults to PENDING
+	Status string `json:"status" yaml:"status"`
+}
+
+// Load reads a fixture file, choosing JSON or YAML by its extension
+func Load(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f Fixtures
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&f)
+	default:
+		return nil, fmt.Errorf("unsupported fixture file extension %q, use .json, .yaml or .yml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fixtures in %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Validate checks the fixtures for missing fields and keys used twice
+func (f *Fixtures) Validate() error {
+	if err := validation.Struct(f); err != nil {
+		return err
+	}
+
+	var errs validation.Errors
+	checkUnique(&errs, "users", len(f.Users), func(i int) string { return f.Users[i].Key })
+	checkUnique(&errs, "products", len(f.Products), func(i int) string { return f.Products[i].Key })
+	checkUnique(&errs, "orders", len(f.Orders), func(i int) string { return f.Orders[i].Key })
+	return errs.Err()
+}
+
+func checkUnique(errs *validation.Errors, field string, n int, key func(int) string) {
+	seen := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		errs.Check(!seen[key(i)], fmt.Sprintf("%s[%d].key", field, i), "is used by another record")
+		seen[key(i)] = true
+	}
+}
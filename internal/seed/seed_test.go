@@ -0,0 +1,152 @@
+package seed_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	orderAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/order/adapter"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/seed"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	userAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const fixturesYAML = `
+users:
+  - key: alice
+    email: Alice@Example.com
+    password: correct-horse-battery
+    active: true
+    roles: [admin]
+products:
+  - key: desk
+    name: Standing Desk
+    stock: 5
+    price: {amount: 12900, currency: EUR}
+orders:
+  - key: alice-desk
+    user: alice
+    product: desk
+    quantity: 2
+    status: CONFIRMED
+`
+
+func setupSeeder(t *testing.T) (*seed.Seeder, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&userDomain.User{}, &userDomain.Permission{}, &userDomain.Role{}, &userDomain.UserRole{},
+		&productDomain.Product{}, &orderDomain.Order{}, &orderDomain.OrderStatusChange{}, &orderDomain.NumberSequence{},
+	))
+
+	return &seed.Seeder{
+		Users:    userAdapter.NewGormUserRepository(db),
+		Roles:    userAdapter.NewGormRoleRepository(db),
+		Products: productAdapter.NewGormProductRepository(db),
+		Orders:   orderAdapter.NewGormOrderRepository(db),
+	}, db
+}
+
+func writeFixtures(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestSeeder_Seed(t *testing.T) {
+	s, _ := setupSeeder(t)
+	ctx := context.Background()
+	f, err := seed.Load(writeFixtures(t, "dev.yaml", fixturesYAML))
+	require.NoError(t, err)
+
+	res, err := s.Seed(ctx, f)
+	require.NoError(t, err)
+	assert.Equal(t, seed.Result{Users: 1, Products: 1, Orders: 1}, res)
+
+	u, err := s.Users.GetByPublicID(ctx, publicid.Derive(userDomain.PublicIDPrefix, "alice"))
+	require.NoError(t, err)
+	assert.Equal(t, userDomain.Email("alice@example.com"), u.Email)
+	assert.True(t, u.Active)
+	assert.True(t, u.CheckPassword("correct-horse-battery"))
+	perms, err := s.Roles.PermissionsOf(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Contains(t, perms, userDomain.PermissionOrderReadAny)
+
+	o, err := s.Orders.GetByPublicID(ctx, publicid.Derive(orderDomain.PublicIDPrefix, "alice-desk"))
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, o.UserID)
+	assert.Equal(t, orderDomain.StatusConfirmed, o.Status)
+	assert.Equal(t, int64(25800), o.Total().Amount)
+	assert.Equal(t, 5, o.Product.Stock, "fixtures reserve no stock")
+}
+
+func TestSeeder_Seed_Idempotent(t *testing.T) {
+	s, db := setupSeeder(t)
+	ctx := context.Background()
+	f, err := seed.Load(writeFixtures(t, "dev.yaml", fixturesYAML))
+	require.NoError(t, err)
+	_, err = s.Seed(ctx, f)
+	require.NoError(t, err)
+
+	f.Products[0].Stock = 3
+	f.Orders[0].Status = string(orderDomain.StatusShipped)
+	_, err = s.Seed(ctx, f)
+	require.NoError(t, err)
+
+	for table, want := range map[string]int64{"users": 1, "products": 1, "orders": 1, "user_roles": 1} {
+		var count int64
+		require.NoError(t, db.Table(table).Count(&count).Error)
+		assert.Equal(t, want, count, table)
+	}
+	o, err := s.Orders.GetByPublicID(ctx, publicid.Derive(orderDomain.PublicIDPrefix, "alice-desk"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, o.Product.Stock)
+	assert.Equal(t, orderDomain.StatusShipped, o.Status)
+	assert.Len(t, o.History, 1)
+}
+
+func TestSeeder_Seed_UnknownOrderUser(t *testing.T) {
+	s, _ := setupSeeder(t)
+
+	_, err := s.Seed(context.Background(), &seed.Fixtures{
+		Orders: []seed.OrderFixture{{Key: "o", User: "bob", Product: "desk", Quantity: 1}},
+	})
+
+	assert.ErrorContains(t, err, `order "o": user "bob"`)
+}
+
+func TestLoad_JSON(t *testing.T) {
+	f, err := seed.Load(writeFixtures(t, "dev.json", `{"products":[{"key":"lamp","name":"Desk Lamp","stock":0}]}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, []seed.ProductFixture{{Key: "lamp", Name: "Desk Lamp"}}, f.Products)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		"unknown field":    {"dev.yaml", "products:\n  - key: desk\n    title: Desk\n", "field title not found"},
+		"missing field":    {"dev.json", `{"users":[{"key":"alice"}]}`, "users[0].email"},
+		"duplicate key":    {"dev.yml", "products:\n  - {key: desk, name: A}\n  - {key: desk, name: B}\n", "products[1].key: is used by another record"},
+		"unsupported file": {"dev.toml", "", "unsupported fixture file extension"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := seed.Load(writeFixtures(t, tt.name, tt.content))
+
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
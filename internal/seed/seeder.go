@@ -0,0 +1,180 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// Seeder upserts fixtures through the repositories. It does not publish events, so loading
+// fixtures sends no emails and reaches no broker.
+type Seeder struct {
+	Users    userDomain.UserRepository
+	Roles    userDomain.RoleRepository
+	Products productDomain.ProductRepository
+	Orders   orderDomain.OrderRepository
+}
+
+// Result counts the records a Seed call created or updated
+type Result struct {
+	Users    int
+	Products int
+	Orders   int
+}
+
+// Seed creates the fixtures that don't exist yet and updates the others to match. It is not
+// transactional; since it is idempotent, a failed run is repaired by running it again.
+func (s *Seeder) Seed(ctx context.Context, f *Fixtures) (Result, error) {
+	var res Result
+
+	if len(f.Users) > 0 {
+		if err := s.Roles.Seed(ctx, userDomain.DefaultRoles()); err != nil {
+			return res, fmt.Errorf("seed roles: %w", err)
+		}
+	}
+	for _, uf := range f.Users {
+		if err := s.seedUser(ctx, uf); err != nil {
+			return res, fmt.Errorf("user %q: %w", uf.Key, err)
+		}
+		res.Users++
+	}
+	for _, pf := range f.Products {
+		if err := s.seedProduct(ctx, pf); err != nil {
+			return res, fmt.Errorf("product %q: %w", pf.Key, err)
+		}
+		res.Products++
+	}
+	for _, of := range f.Orders {
+		if err := s.seedOrder(ctx, of); err != nil {
+			return res, fmt.Errorf("order %q: %w", of.Key, err)
+		}
+		res.Orders++
+	}
+	return res, nil
+}
+
+func (s *Seeder) seedUser(ctx context.Context, f UserFixture) error {
+	u, err := s.Users.GetByPublicID(ctx, publicid.Derive(userDomain.PublicIDPrefix, f.Key))
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		if u, err = userDomain.NewUser(f.Email); err != nil {
+			return err
+		}
+		u.PublicID = publicid.Derive(userDomain.PublicIDPrefix, f.Key)
+	case err != nil:
+		return err
+	default:
+		email, err := userDomain.NewEmail(f.Email)
+		if err != nil {
+			return err
+		}
+		u.Email = email
+	}
+
+	u.Active = f.Active
+	if f.Password != "" && !u.CheckPassword(f.Password) {
+		if err := u.SetPassword(f.Password); err != nil {
+			return err
+		}
+	}
+	if err := s.Users.Save(ctx, u); err != nil {
+		return err
+	}
+
+	for _, role := range f.Roles {
+		if err := s.Roles.Assign(ctx, u.ID, role); err != nil {
+			return fmt.Errorf("assign role %s: %w", role, err)
+		}
+	}
+	return nil
+}
+
+func (s *Seeder) seedProduct(ctx context.Context, f ProductFixture) error {
+	p, err := s.Products.GetByPublicID(ctx, publicid.Derive(productDomain.PublicIDPrefix, f.Key))
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		if p, err = productDomain.NewProduct(f.Name, f.Stock); err != nil {
+			return err
+		}
+		p.PublicID = publicid.Derive(productDomain.PublicIDPrefix, f.Key)
+	case err != nil:
+		return err
+	default:
+		p.Name, p.Stock = f.Name, f.Stock
+	}
+
+	p.SetPrice(money.Money{})
+	if f.Price != nil {
+		price, err := money.New(f.Price.Amount, f.Price.Currency)
+		if err != nil {
+			return err
+		}
+		p.SetPrice(price)
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	return s.Products.Save(ctx, p)
+}
+
+func (s *Seeder) seedOrder(ctx context.Context, f OrderFixture) error {
+	status := orderDomain.StatusPending
+	if f.Status != "" {
+		status = orderDomain.OrderStatus(f.Status)
+	}
+
+	o, err := s.Orders.GetByPublicID(ctx, publicid.Derive(orderDomain.PublicIDPrefix, f.Key))
+	if err == nil {
+		// Orders are immutable apart from their status, which is set as given rather than transitioned
+		if o.Status == status {
+			return nil
+		}
+		o.History = append(o.History, orderDomain.OrderStatusChange{
+			OrderID:    o.ID,
+			FromStatus: o.Status,
+			ToStatus:   status,
+			ChangedAt:  time.Now().UTC(),
+		})
+		o.Status = status
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		return s.Orders.UpdateStatus(ctx, o)
+	}
+	if !errors.Is(err, persistence.ErrNotFound) {
+		return err
+	}
+
+	u, err := s.Users.GetByPublicID(ctx, publicid.Derive(userDomain.PublicIDPrefix, f.User))
+	if err != nil {
+		return fmt.Errorf("user %q: %w", f.User, err)
+	}
+	p, err := s.Products.GetByPublicID(ctx, publicid.Derive(productDomain.PublicIDPrefix, f.Product))
+	if err != nil {
+		return fmt.Errorf("product %q: %w", f.Product, err)
+	}
+
+	quantity, err := orderDomain.NewQuantity(f.Quantity)
+	if err != nil {
+		return err
+	}
+	o, err = orderDomain.NewOrder(u.ID, p.ID, quantity)
+	if err != nil {
+		return err
+	}
+	o.PublicID = publicid.Derive(orderDomain.PublicIDPrefix, f.Key)
+	o.UnitPrice = p.Price()
+	o.Status = status
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	return s.Orders.Save(ctx, o)
+}
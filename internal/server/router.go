@@ -0,0 +1,147 @@
+package server
+
+import (
+	"net/http"
+
+	asyncPort "github.com/mohsenjafari-aiio/aiiobackend/internal/async/port"
+	auditPort "github.com/mohsenjafari-aiio/aiiobackend/internal/audit/port"
+	billingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/port"
+	checkoutPort "github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/port"
+	couponPort "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/port"
+	credentialPort "github.com/mohsenjafari-aiio/aiiobackend/internal/credential/port"
+	deliveryPort "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/port"
+	notificationPort "github.com/mohsenjafari-aiio/aiiobackend/internal/notification/port"
+	orderPort "github.com/mohsenjafari-aiio/aiiobackend/internal/order/port"
+	paymentPort "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/port"
+	portabilityPort "github.com/mohsenjafari-aiio/aiiobackend/internal/portability/port"
+	productPort "github.com/mohsenjafari-aiio/aiiobackend/internal/product/port"
+	quotaPort "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/port"
+	reportingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/port"
+	reviewPort "github.com/mohsenjafari-aiio/aiiobackend/internal/review/port"
+	settingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/setting/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/buildinfo"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	shippingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/port"
+	supportPort "github.com/mohsenjafari-aiio/aiiobackend/internal/support/port"
+	syncPort "github.com/mohsenjafari-aiio/aiiobackend/internal/sync/port"
+	userPort "github.com/mohsenjafari-aiio/aiiobackend/internal/user/port"
+	webhookPort "github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/port"
+)
+
+// APIInfo describes the public API in the generated OpenAPI document
+var APIInfo = httpx.Info{
+	Title:   "AIIO Backend API",
+	Version: "1.0.0",
+}
+
+// Handlers groups the module HTTP servers mounted on the API router
+type Handlers struct {
+	Orders      *orderPort.HTTPServer
+	Products    *productPort.HTTPServer
+	Users       *userPort.HTTPServer
+	Quota       *quotaPort.HTTPServer
+	Billing     *billingPort.HTTPServer
+	Checkout    *checkoutPort.HTTPServer
+	Payments    *paymentPort.HTTPServer
+	Audit       *auditPort.HTTPServer
+	Credentials *credentialPort.HTTPServer
+	Campaigns   *notificationPort.HTTPServer
+	Delivery    *deliveryPort.HTTPServer
+	Shipping    *shippingPort.HTTPServer
+	Support     *supportPort.HTTPServer
+	Webhooks    *webhookPort.HTTPServer
+	Sync        *syncPort.HTTPServer
+	Commands    *asyncPort.HTTPServer
+	Settings    *settingPort.HTTPServer
+	DataExports *portabilityPort.HTTPServer
+	Coupons     *couponPort.HTTPServer
+	Reviews     *reviewPort.HTTPServer
+	Reports     *reportingPort.HTTPServer
+
+	// Build is served at GET /version
+	Build buildinfo.Info
+
+	// Blobs serves the signed URLs of the local blob store at GET /blobs/{key...} when set
+	Blobs http.Handler
+	// GraphQL serves /graphql when set
+	GraphQL http.Handler
+	// Metrics serves GET /metrics when set
+	Metrics http.Handler
+}
+
+// NewRouter registers every module route plus the /version, /openapi.json, /docs, /blobs, /graphql
+// and /metrics endpoints
+func NewRouter(h Handlers) *httpx.Router {
+	r := httpx.NewRouter()
+
+	h.Orders.RegisterRoutes(r)
+	h.Products.RegisterRoutes(r)
+	h.Users.RegisterRoutes(r)
+	h.Quota.RegisterRoutes(r)
+	h.Billing.RegisterRoutes(r)
+	h.Checkout.RegisterRoutes(r)
+	h.Payments.RegisterRoutes(r)
+	h.Audit.RegisterRoutes(r)
+	h.Credentials.RegisterRoutes(r)
+	h.Campaigns.RegisterRoutes(r)
+	h.Delivery.RegisterRoutes(r)
+	h.Shipping.RegisterRoutes(r)
+	h.Support.RegisterRoutes(r)
+	h.Webhooks.RegisterRoutes(r)
+	h.Sync.RegisterRoutes(r)
+	h.Commands.RegisterRoutes(r)
+	h.Settings.RegisterRoutes(r)
+	h.DataExports.RegisterRoutes(r)
+	h.Coupons.RegisterRoutes(r)
+	h.Reviews.RegisterRoutes(r)
+	h.Reports.RegisterRoutes(r)
+
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/version",
+		Summary:  "Get the build the instance runs and the features it enables",
+		Tags:     []string{"meta"},
+		Response: buildinfo.Info{},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			httpx.WriteJSON(w, http.StatusOK, h.Build)
+		},
+	})
+	r.MountDocs(APIInfo)
+	if h.Blobs != nil {
+		r.Mount("GET /blobs/{key...}", h.Blobs)
+	}
+	if h.GraphQL != nil {
+		r.Mount("/graphql", h.GraphQL)
+	}
+	if h.Metrics != nil {
+		r.Mount("GET /metrics", h.Metrics)
+	}
+	return r
+}
+
+// DocumentationRouter registers the routes without dependencies, for spec generation
+func DocumentationRouter() *httpx.Router {
+	return NewRouter(Handlers{
+		Orders:      &orderPort.HTTPServer{},
+		Products:    &productPort.HTTPServer{},
+		Users:       &userPort.HTTPServer{},
+		Quota:       &quotaPort.HTTPServer{},
+		Billing:     &billingPort.HTTPServer{},
+		Checkout:    &checkoutPort.HTTPServer{},
+		Payments:    &paymentPort.HTTPServer{},
+		Audit:       &auditPort.HTTPServer{},
+		Credentials: &credentialPort.HTTPServer{},
+		Campaigns:   &notificationPort.HTTPServer{},
+		Delivery:    &deliveryPort.HTTPServer{},
+		Shipping:    &shippingPort.HTTPServer{},
+		Support:     &supportPort.HTTPServer{},
+		Webhooks:    &webhookPort.HTTPServer{},
+		Sync:        &syncPort.HTTPServer{},
+		Commands:    &asyncPort.HTTPServer{},
+		Settings:    &settingPort.HTTPServer{},
+		DataExports: &portabilityPort.HTTPServer{},
+		Coupons:     &couponPort.HTTPServer{},
+		Reviews:     &reviewPort.HTTPServer{},
+		Reports:     &reportingPort.HTTPServer{},
+	})
+}
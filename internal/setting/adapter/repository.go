@@ -0,0 +1,42 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormSettingRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSettingRepository(db *gorm.DB) domain.SettingRepository {
+	return &GormSettingRepository{db: db}
+}
+
+func (r *GormSettingRepository) ListByTenant(ctx context.Context, tenantID string) ([]domain.Setting, error) {
+	var settings []domain.Setting
+	err := persistence.Conn(ctx, r.db).Where("tenant_id = ?", tenantID).Order("key").Find(&settings).Error
+	return settings, persistence.TranslateError(err)
+}
+
+func (r *GormSettingRepository) Update(ctx context.Context, tenantID string, set []domain.Setting, reset []domain.Key) error {
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if len(reset) > 0 {
+			if err := tx.Where("tenant_id = ? AND key IN ?", tenantID, reset).Delete(&domain.Setting{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+		}).Create(&set).Error
+	})
+	return persistence.TranslateError(err)
+}
@@ -0,0 +1,44 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/setting/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormSettingRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Setting{}))
+	repo := adapter.NewGormSettingRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Update(ctx, "acme", []domain.Setting{
+		{TenantID: "acme", Key: domain.KeyStoreName, Value: `"Acme"`, UpdatedBy: "user:1"},
+		{TenantID: "acme", Key: domain.KeyLogoURL, Value: `"https://acme.example/logo.png"`, UpdatedBy: "user:1"},
+	}, nil))
+	require.NoError(t, repo.Update(ctx, "other", []domain.Setting{
+		{TenantID: "other", Key: domain.KeyStoreName, Value: `"Other"`, UpdatedBy: "user:2"},
+	}, nil))
+
+	require.NoError(t, repo.Update(ctx, "acme", []domain.Setting{
+		{TenantID: "acme", Key: domain.KeyStoreName, Value: `"Acme Corp"`, UpdatedBy: "user:3"},
+	}, []domain.Key{domain.KeyLogoURL}))
+
+	settings, err := repo.ListByTenant(ctx, "acme")
+	require.NoError(t, err)
+	if assert.Len(t, settings, 1) {
+		assert.Equal(t, `"Acme Corp"`, settings[0].Value)
+		assert.Equal(t, "user:3", settings[0].UpdatedBy)
+	}
+
+	settings, err = repo.ListByTenant(ctx, "other")
+	require.NoError(t, err)
+	assert.Len(t, settings, 1, "other tenants are untouched")
+}
@@ -0,0 +1,32 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+
+	auditDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// UpdateSettingsCommand changes settings of the tenant of the request; a null value resets a
+// setting to the deployment default
+type UpdateSettingsCommand struct {
+	Values map[domain.Key]json.RawMessage `validate:"required,min=1"`
+}
+
+type UpdateSettingsHandler struct {
+	Store *domain.Store
+}
+
+func (h *UpdateSettingsHandler) Handle(ctx context.Context, cmd UpdateSettingsCommand) (*domain.Settings, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+	settings, err := h.Store.Update(ctx, tenant.FromContext(ctx), cmd.Values, auditDomain.ActorFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// ErrUnknownSetting is returned when changing a setting without a schema
+var ErrUnknownSetting = errors.New("unknown setting")
+
+// Key names a tenant setting
+type Key string
+
+const (
+	KeyStoreName          Key = "store_name"
+	KeyLogoURL            Key = "logo_url"
+	KeyCurrencies         Key = "currencies"
+	KeyNotificationSender Key = "notification_sender"
+)
+
+// Type is the kind of value a setting holds
+type Type string
+
+const (
+	TypeString       Type = "string"
+	TypeURL          Type = "url"
+	TypeEmail        Type = "email"
+	TypeCurrencyList Type = "currency_list"
+)
+
+// MaxStoreNameLength bounds the store name
+const MaxStoreNameLength = 100
+
+// Settings are how a tenant presents itself. Unset settings take the deployment defaults.
+type Settings struct {
+	StoreName string `json:"store_name"`
+	// LogoURL is shown in the header of emails; empty shows none
+	LogoURL string `json:"logo_url,omitempty"`
+	// Currencies are the currencies prices may be requested in, the first being the one prices are
+	// shown in by default; empty allows every currency and shows prices as stored
+	Currencies []string `json:"currencies,omitempty"`
+	// NotificationSender is the From of emails, e.g. "Acme <shop@acme.example>"; empty keeps the
+	// sender of the email provider
+	NotificationSender string `json:"notification_sender,omitempty"`
+}
+
+// Schema describes a setting and decodes its values onto Settings
+type Schema struct {
+	Key         Key
+	Type        Type
+	Description string
+	apply       func(s *Settings, raw json.RawMessage) error
+}
+
+// Schemas lists the settings a tenant may change
+var Schemas = []Schema{
+	{Key: KeyStoreName, Type: TypeString, Description: "Name of the store shown in emails", apply: applyStoreName},
+	{Key: KeyLogoURL, Type: TypeURL, Description: "HTTPS URL of the logo shown in emails", apply: applyLogoURL},
+	{Key: KeyCurrencies, Type: TypeCurrencyList, Description: "ISO 4217 codes prices may be requested in, the first is the default", apply: applyCurrencies},
+	{Key: KeyNotificationSender, Type: TypeEmail, Description: "From address of emails, optionally with a name", apply: applyNotificationSender},
+}
+
+// SchemaOf returns the schema of key, failing with ErrUnknownSetting
+func SchemaOf(key Key) (Schema, error) {
+	i := slices.IndexFunc(Schemas, func(s Schema) bool { return s.Key == key })
+	if i < 0 {
+		return Schema{}, fmt.Errorf("%w %q", ErrUnknownSetting, key)
+	}
+	return Schemas[i], nil
+}
+
+// With returns s with the setting of key set to raw, failing when raw does not fit its schema
+func (s Settings) With(key Key, raw json.RawMessage) (Settings, error) {
+	schema, err := SchemaOf(key)
+	if err != nil {
+		return s, err
+	}
+	if err := schema.apply(&s, raw); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// AllowsCurrency reports whether prices may be requested in currency
+func (s Settings) AllowsCurrency(currency string) bool {
+	return len(s.Currencies) == 0 || slices.Contains(s.Currencies, currency)
+}
+
+// DefaultCurrency is the currency prices are shown in when none is requested, "" to show them as stored
+func (s Settings) DefaultCurrency() string {
+	if len(s.Currencies) == 0 {
+		return ""
+	}
+	return s.Currencies[0]
+}
+
+func applyStoreName(s *Settings, raw json.RawMessage) error {
+	var name string
+	if err := json.Unmarshal(raw, &name); err != nil {
+		return errors.New("must be a string")
+	}
+	if name == "" || len(name) > MaxStoreNameLength {
+		return fmt.Errorf("must be 1 to %d characters", MaxStoreNameLength)
+	}
+	s.StoreName = name
+	return nil
+}
+
+func applyLogoURL(s *Settings, raw json.RawMessage) error {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return errors.New("must be a string")
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("must be an https URL")
+	}
+	s.LogoURL = value
+	return nil
+}
+
+func applyCurrencies(s *Settings, raw json.RawMessage) error {
+	var codes []string
+	if err := json.Unmarshal(raw, &codes); err != nil {
+		return errors.New("must be a list of currency codes")
+	}
+	currencies := make([]string, 0, len(codes))
+	for _, code := range codes {
+		m, err := money.New(0, code)
+		if err != nil {
+			return fmt.Errorf("must be ISO 4217 currency codes, got %q", code)
+		}
+		if !slices.Contains(currencies, m.Currency) {
+			currencies = append(currencies, m.Currency)
+		}
+	}
+	s.Currencies = currencies
+	return nil
+}
+
+func applyNotificationSender(s *Settings, raw json.RawMessage) error {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return errors.New("must be a string")
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return errors.New("must be an email address, optionally with a name")
+	}
+	s.NotificationSender = addr.String()
+	return nil
+}
+
+// Setting is a value a tenant set, stored as JSON
+type Setting struct {
+	TenantID  string `gorm:"type:varchar(64);primaryKey"`
+	Key       Key    `gorm:"type:varchar(64);primaryKey"`
+	Value     string `gorm:"type:text;not null"`
+	UpdatedBy string `gorm:"type:varchar(64);not null"`
+	UpdatedAt time.Time
+}
+
+func (Setting) TableName() string {
+	return "tenant_settings"
+}
+
+type SettingRepository interface {
+	// ListByTenant returns the settings a tenant set
+	ListByTenant(ctx context.Context, tenantID string) ([]Setting, error)
+	// Update inserts or replaces set and removes the settings of reset, so they take the defaults
+	// again, all at once
+	Update(ctx context.Context, tenantID string, set []Setting, reset []Key) error
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// Store resolves the settings of tenants from the values they set over the deployment Defaults.
+// Resolved settings are cached for TTL, since emails and price responses read them on every use;
+// other instances pick up a change within TTL.
+type Store struct {
+	Settings SettingRepository
+	Defaults Settings
+	TTL      time.Duration
+	Now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedSettings
+}
+
+type cachedSettings struct {
+	settings Settings
+	expires  time.Time
+}
+
+// Current returns the settings of the tenant of ctx
+func (s *Store) Current(ctx context.Context) (Settings, error) {
+	return s.For(ctx, tenant.FromContext(ctx))
+}
+
+// For returns the settings of a tenant
+func (s *Store) For(ctx context.Context, tenantID string) (Settings, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[tenantID]
+	s.mu.Unlock()
+	if ok && s.now().Before(cached.expires) {
+		return cached.settings, nil
+	}
+
+	stored, err := s.Settings.ListByTenant(ctx, tenantID)
+	if err != nil {
+		// Keep serving the last known settings when the database is briefly unavailable
+		if ok {
+			slog.WarnContext(ctx, "serving cached tenant settings after lookup failure", "tenant", tenantID, "error", err)
+			return cached.settings, nil
+		}
+		return Settings{}, fmt.Errorf("load settings of tenant %s: %w", tenantID, err)
+	}
+
+	settings := s.resolve(ctx, tenantID, stored)
+	s.remember(tenantID, settings)
+	return settings, nil
+}
+
+// resolve applies the stored values over the defaults; values that no longer fit their schema,
+// e.g. after a setting was removed, are logged and left at the default
+func (s *Store) resolve(ctx context.Context, tenantID string, stored []Setting) Settings {
+	settings := s.Defaults
+	settings.Currencies = append([]string(nil), s.Defaults.Currencies...)
+	for _, setting := range stored {
+		applied, err := settings.With(setting.Key, json.RawMessage(setting.Value))
+		if err != nil {
+			slog.WarnContext(ctx, "ignoring invalid tenant setting", "tenant", tenantID, "key", setting.Key, "error", err)
+			continue
+		}
+		settings = applied
+	}
+	return settings
+}
+
+// Update sets the settings of a tenant to values; a null value resets a setting to its default.
+// Values that do not fit their schema are reported as validation.Errors and nothing is changed.
+func (s *Store) Update(ctx context.Context, tenantID string, values map[Key]json.RawMessage, actor string) (Settings, error) {
+	var errs validation.Errors
+	var set []Setting
+	var reset []Key
+	check := s.Defaults
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		raw := values[key]
+		if string(raw) == "null" {
+			if _, err := SchemaOf(key); err != nil {
+				errs.Add(string(key), err.Error())
+			}
+			reset = append(reset, key)
+			continue
+		}
+		applied, err := check.With(key, raw)
+		if err != nil {
+			errs.Add(string(key), err.Error())
+			continue
+		}
+		check = applied
+		set = append(set, Setting{TenantID: tenantID, Key: key, Value: string(raw), UpdatedBy: actor, UpdatedAt: s.now()})
+	}
+	if err := errs.Err(); err != nil {
+		return Settings{}, err
+	}
+
+	if err := s.Settings.Update(ctx, tenantID, set, reset); err != nil {
+		return Settings{}, fmt.Errorf("update settings of tenant %s: %w", tenantID, err)
+	}
+	s.forget(tenantID)
+	return s.For(ctx, tenantID)
+}
+
+func (s *Store) Describe() string {
+	return "tenant settings"
+}
+
+// Warm resolves the settings of the default tenant, which serves every request without a tenant
+func (s *Store) Warm(ctx context.Context) (int, error) {
+	if _, err := s.For(ctx, tenant.DefaultID); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func (s *Store) remember(tenantID string, settings Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		s.cache = make(map[string]cachedSettings)
+	}
+	s.cache[tenantID] = cachedSettings{settings: settings, expires: s.now().Add(s.TTL)}
+}
+
+func (s *Store) forget(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, tenantID)
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,136 @@
+package domain_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySettings keeps settings in memory; err fails every call
+type memorySettings struct {
+	settings []domain.Setting
+	err      error
+}
+
+func (m *memorySettings) ListByTenant(ctx context.Context, tenantID string) ([]domain.Setting, error) {
+	var settings []domain.Setting
+	for _, s := range m.settings {
+		if s.TenantID == tenantID {
+			settings = append(settings, s)
+		}
+	}
+	return settings, m.err
+}
+
+func (m *memorySettings) Update(ctx context.Context, tenantID string, set []domain.Setting, reset []domain.Key) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.settings = slices.DeleteFunc(m.settings, func(s domain.Setting) bool {
+		return s.TenantID == tenantID && (slices.Contains(reset, s.Key) || slices.ContainsFunc(set, func(n domain.Setting) bool { return n.Key == s.Key }))
+	})
+	m.settings = append(m.settings, set...)
+	return nil
+}
+
+func newStore() (*domain.Store, *memorySettings, *time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	settings := &memorySettings{}
+	store := &domain.Store{
+		Settings: settings,
+		Defaults: domain.Settings{StoreName: "AIIO"},
+		TTL:      time.Minute,
+		Now:      func() time.Time { return now },
+	}
+	return store, settings, &now
+}
+
+func TestStore_Update(t *testing.T) {
+	store, _, _ := newStore()
+	ctx := context.Background()
+
+	settings, err := store.Update(ctx, "acme", map[domain.Key]json.RawMessage{
+		domain.KeyStoreName:          json.RawMessage(`"Acme"`),
+		domain.KeyCurrencies:         json.RawMessage(`["usd","EUR","USD"]`),
+		domain.KeyNotificationSender: json.RawMessage(`"Acme Shop <shop@acme.example>"`),
+	}, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", settings.StoreName)
+	assert.Equal(t, []string{"USD", "EUR"}, settings.Currencies)
+	assert.Equal(t, "USD", settings.DefaultCurrency())
+	assert.True(t, settings.AllowsCurrency("EUR"))
+	assert.False(t, settings.AllowsCurrency("GBP"))
+	assert.Equal(t, `"Acme Shop" <shop@acme.example>`, settings.NotificationSender)
+
+	other, err := store.For(ctx, tenant.DefaultID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Settings{StoreName: "AIIO"}, other, "other tenants keep the defaults")
+	assert.True(t, other.AllowsCurrency("GBP"))
+
+	settings, err = store.Update(ctx, "acme", map[domain.Key]json.RawMessage{domain.KeyStoreName: json.RawMessage(`null`)}, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, "AIIO", settings.StoreName, "null resets to the default")
+	assert.Equal(t, []string{"USD", "EUR"}, settings.Currencies)
+}
+
+func TestStore_UpdateRejectsInvalidValues(t *testing.T) {
+	store, settings, _ := newStore()
+
+	_, err := store.Update(context.Background(), "acme", map[domain.Key]json.RawMessage{
+		domain.KeyStoreName:  json.RawMessage(`"Acme"`),
+		domain.KeyLogoURL:    json.RawMessage(`"http://acme.example/logo.png"`),
+		domain.KeyCurrencies: json.RawMessage(`["EURO"]`),
+		"theme":              json.RawMessage(`"dark"`),
+	}, "user:1")
+
+	var errs validation.Errors
+	require.True(t, errors.As(err, &errs), "got %v", err)
+	assert.Len(t, errs, 3)
+	assert.Empty(t, settings.settings, "nothing is stored when a value is invalid")
+}
+
+func TestStore_CachesForTTL(t *testing.T) {
+	store, settings, now := newStore()
+	ctx := context.Background()
+	settings.settings = []domain.Setting{{TenantID: "acme", Key: domain.KeyStoreName, Value: `"Acme"`}}
+
+	got, err := store.For(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", got.StoreName)
+
+	settings.settings[0].Value = `"Acme Corp"`
+	got, _ = store.For(ctx, "acme")
+	assert.Equal(t, "Acme", got.StoreName, "served from the cache")
+
+	*now = now.Add(time.Minute)
+	settings.err = errors.New("database unavailable")
+	got, err = store.For(ctx, "acme")
+	require.NoError(t, err, "expired settings are served while the database is unavailable")
+	assert.Equal(t, "Acme", got.StoreName)
+
+	settings.err = nil
+	got, _ = store.For(ctx, "acme")
+	assert.Equal(t, "Acme Corp", got.StoreName)
+}
+
+func TestStore_IgnoresInvalidStoredValues(t *testing.T) {
+	store, settings, _ := newStore()
+	settings.settings = []domain.Setting{
+		{TenantID: "acme", Key: domain.KeyStoreName, Value: `42`},
+		{TenantID: "acme", Key: "theme", Value: `"dark"`},
+		{TenantID: "acme", Key: domain.KeyLogoURL, Value: `"https://acme.example/logo.png"`},
+	}
+
+	got, err := store.For(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Settings{StoreName: "AIIO", LogoURL: "https://acme.example/logo.png"}, got)
+}
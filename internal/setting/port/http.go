@@ -0,0 +1,182 @@
+package port
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/setting/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// SettingRequest is the body of PUT /settings/{key}; Value has the type of the setting's schema
+type SettingRequest struct {
+	Value any `json:"value"`
+}
+
+// SettingsResponse is how the tenant of the request presents itself
+type SettingsResponse struct {
+	StoreName          string   `json:"store_name"`
+	LogoURL            string   `json:"logo_url,omitempty"`
+	Currencies         []string `json:"currencies"`
+	NotificationSender string   `json:"notification_sender,omitempty"`
+}
+
+// SettingSchemaResponse describes a setting tenants may change
+type SettingSchemaResponse struct {
+	Key         domain.Key  `json:"key"`
+	Type        domain.Type `json:"type"`
+	Description string      `json:"description"`
+}
+
+// SettingSchemasResponse lists the settings tenants may change
+type SettingSchemasResponse struct {
+	Settings []SettingSchemaResponse `json:"settings"`
+}
+
+// HTTPServer exposes the settings of tenants over HTTP
+type HTTPServer struct {
+	UpdateSettings decorator.CommandResultHandler[command.UpdateSettingsCommand, *domain.Settings]
+	Store          *domain.Store
+
+	// Auth restricts changes to setting:manage; reading is open, since storefronts show the
+	// settings to anonymous visitors. nil disables access control.
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the setting endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/settings",
+		Summary:  "Get the store name, logo, currencies and email sender of the tenant of the request",
+		Tags:     []string{"settings"},
+		Response: SettingsResponse{},
+		Handler:  s.getSettings,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/settings/schema",
+		Summary:  "List the settings tenants may change and the type of their values",
+		Tags:     []string{"settings"},
+		Response: SettingSchemasResponse{},
+		Handler:  s.listSchemas,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/settings/{key}",
+		Summary:  "Change a setting of the tenant of the request",
+		Tags:     []string{"settings"},
+		Request:  SettingRequest{},
+		Response: SettingsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionSettingManage, s.putSetting),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodDelete,
+		Path:     "/settings/{key}",
+		Summary:  "Reset a setting of the tenant of the request to the deployment default",
+		Tags:     []string{"settings"},
+		Response: SettingsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionSettingManage, s.resetSetting),
+	})
+}
+
+// Middleware limits the currencies prices are requested in to those of the tenant of the
+// request. It must run after tenant.Middleware.
+func Middleware(store *domain.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			settings, err := store.Current(r.Context())
+			if err != nil {
+				httpx.WriteError(w, err)
+				return
+			}
+			if len(settings.Currencies) > 0 {
+				r = r.WithContext(exchange.WithCurrencies(r.Context(), settings.Currencies))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (s *HTTPServer) getSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.Store.Current(r.Context())
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, toSettingsResponse(settings))
+}
+
+func (s *HTTPServer) listSchemas(w http.ResponseWriter, r *http.Request) {
+	resp := SettingSchemasResponse{Settings: make([]SettingSchemaResponse, len(domain.Schemas))}
+	for i, schema := range domain.Schemas {
+		resp.Settings[i] = SettingSchemaResponse{Key: schema.Key, Type: schema.Type, Description: schema.Description}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) putSetting(w http.ResponseWriter, r *http.Request) {
+	var req SettingRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	if req.Value == nil {
+		var errs validation.Errors
+		errs.Add("value", "is required; DELETE the setting to reset it")
+		httpx.WriteError(w, errs)
+		return
+	}
+	value, err := json.Marshal(req.Value)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	s.updateSetting(w, r, value)
+}
+
+func (s *HTTPServer) resetSetting(w http.ResponseWriter, r *http.Request) {
+	s.updateSetting(w, r, json.RawMessage("null"))
+}
+
+// updateSetting sets the setting of the {key} path parameter to value
+func (s *HTTPServer) updateSetting(w http.ResponseWriter, r *http.Request, value json.RawMessage) {
+	key := domain.Key(r.PathValue("key"))
+	if _, err := domain.SchemaOf(key); err != nil {
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+		return
+	}
+
+	settings, err := s.UpdateSettings.Handle(r.Context(), command.UpdateSettingsCommand{
+		Values: map[domain.Key]json.RawMessage{key: value},
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUnknownSetting) {
+			httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, toSettingsResponse(*settings))
+}
+
+func toSettingsResponse(s domain.Settings) SettingsResponse {
+	currencies := s.Currencies
+	if currencies == nil {
+		currencies = []string{}
+	}
+	return SettingsResponse{
+		StoreName:          s.StoreName,
+		LogoURL:            s.LogoURL,
+		Currencies:         currencies,
+		NotificationSender: s.NotificationSender,
+	}
+}
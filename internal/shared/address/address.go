@@ -0,0 +1,169 @@
+// Package address holds postal addresses and the rules countries have for writing and validating them
+package address
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// placeholder is a {field} of a layout line
+var placeholder = regexp.MustCompile(`\{[a-z0-9_]+\}`)
+
+// Address is a postal address; Country is an ISO 3166-1 alpha-2 code and decides which fields are
+// required and how the address is printed
+type Address struct {
+	Name  string `gorm:"type:varchar(255)"`
+	Line1 string `gorm:"type:varchar(255)"`
+	Line2 string `gorm:"type:varchar(255)"`
+	City  string `gorm:"type:varchar(255)"`
+	// Region is the state, province or county, e.g. "CA"; only some countries require it
+	Region     string `gorm:"type:varchar(100)"`
+	PostalCode string `gorm:"type:varchar(32)"`
+	Country    string `gorm:"type:varchar(2)"`
+	// Latitude and Longitude optionally place the address, e.g. to ship from the nearest warehouse;
+	// both zero means it is not placed
+	Latitude  float64
+	Longitude float64
+}
+
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// Point returns the coordinates of the address, or nil when it has none
+func (a Address) Point() *geo.Point {
+	if a.Latitude == 0 && a.Longitude == 0 {
+		return nil
+	}
+	return &geo.Point{Lat: a.Latitude, Lng: a.Longitude}
+}
+
+// Format returns the format of the country of the address
+func (a Address) Format() Format {
+	return FormatOf(a.Country)
+}
+
+// Normalize trims the fields, upper-cases the country, region and postal code and adds the
+// separator a postal code was typed without, e.g. "sw1a1aa" becomes "SW1A 1AA"
+func (a Address) Normalize() Address {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.Region = strings.ToUpper(strings.TrimSpace(a.Region))
+
+	f := a.Format()
+	postalCode := strings.ToUpper(strings.Join(strings.Fields(a.PostalCode), " "))
+	if f.separator != "" && !strings.Contains(postalCode, f.separator) {
+		compact := strings.ReplaceAll(postalCode, " ", "")
+		if len(compact) > f.separatorAt {
+			postalCode = compact[:len(compact)-f.separatorAt] + f.separator + compact[len(compact)-f.separatorAt:]
+		}
+	}
+	a.PostalCode = postalCode
+	return a
+}
+
+// Validate checks the address against the rules of its country and returns validation.Errors
+// describing every violation. Call it on a normalized address.
+func (a Address) Validate() error {
+	var errs validation.Errors
+
+	// An unknown country is checked against the default format, so the other fields are still reported
+	f := a.Format()
+	for _, field := range f.Required {
+		if a.value(field) == "" {
+			errs.Add(string(field), "is required")
+		}
+	}
+	if a.PostalCode != "" && f.PostalCode != nil && !f.PostalCode.MatchString(a.PostalCode) {
+		errs.Add(string(FieldPostalCode), fmt.Sprintf("is not a valid %s of %s, e.g. %s", strings.ToLower(f.PostalCodeLabel), f.CountryName, f.PostalCodeExample))
+	}
+	if a.Region != "" && !f.hasRegion(a.Region) {
+		errs.Add(string(FieldRegion), fmt.Sprintf("is not a %s of %s", strings.ToLower(f.RegionLabel), f.CountryName))
+	}
+	errs.Check(len(a.Country) == 2, string(FieldCountry), "must be a two letter country code")
+	if p := a.Point(); p != nil {
+		if _, err := geo.NewPoint(p.Lat, p.Lng); err != nil {
+			errs.Add("coordinates", err.Error())
+		}
+	}
+
+	return errs.Err()
+}
+
+// Lines returns the address as printed on an invoice, in the order of its country. The country
+// name is added for addresses abroad, i.e. outside the from country; an empty from always adds it.
+func (a Address) Lines(from string) []string {
+	lines, _, _ := a.lines(from)
+	return lines
+}
+
+// Label returns the lines of a shipping label. Postal services sort by the city and country lines,
+// so those are upper-cased as the UPU recommends.
+func (a Address) Label(from string) []string {
+	lines, city, country := a.lines(from)
+	for _, i := range []int{city, country} {
+		if i >= 0 {
+			lines[i] = strings.ToUpper(lines[i])
+		}
+	}
+	return lines
+}
+
+// lines renders the layout of the country and returns the indexes of the city and country lines, -1 when absent
+func (a Address) lines(from string) (lines []string, city, country int) {
+	f := a.Format()
+	city, country = -1, -1
+	for _, layout := range f.Layout {
+		var filled bool
+		line := placeholder.ReplaceAllStringFunc(layout, func(p string) string {
+			value := a.value(Field(p[1 : len(p)-1]))
+			filled = filled || value != ""
+			return value
+		})
+		if !filled {
+			continue
+		}
+		if strings.Contains(layout, "{city}") {
+			city = len(lines)
+		}
+		// Separators next to an empty field are dropped with it, e.g. "Springfield, " without a region
+		lines = append(lines, strings.Trim(strings.Join(strings.Fields(line), " "), " ,-"))
+	}
+
+	if !strings.EqualFold(a.Country, from) {
+		name := f.CountryName
+		if name == "" {
+			name = a.Country
+		}
+		country = len(lines)
+		lines = append(lines, name)
+	}
+	return lines, city, country
+}
+
+func (a Address) value(f Field) string {
+	switch f {
+	case FieldName:
+		return a.Name
+	case FieldLine1:
+		return a.Line1
+	case FieldLine2:
+		return a.Line2
+	case FieldCity:
+		return a.City
+	case FieldRegion:
+		return a.Region
+	case FieldPostalCode:
+		return a.PostalCode
+	case FieldCountry:
+		return a.Country
+	}
+	return ""
+}
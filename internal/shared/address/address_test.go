@@ -0,0 +1,94 @@
+package address_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddress_Normalize(t *testing.T) {
+	a := address.Address{Name: " Jane Doe ", Line1: "10 Downing St", City: "London", PostalCode: " sw1a2aa ", Country: "gb"}.Normalize()
+	assert.Equal(t, "SW1A 2AA", a.PostalCode)
+	assert.Equal(t, "GB", a.Country)
+	assert.Equal(t, "Jane Doe", a.Name)
+
+	assert.Equal(t, "1012 JS", address.Address{PostalCode: "1012js", Country: "NL"}.Normalize().PostalCode)
+	assert.Equal(t, "154-0023", address.Address{PostalCode: "1540023", Country: "JP"}.Normalize().PostalCode)
+	assert.Equal(t, "10115", address.Address{PostalCode: "10115", Country: "DE"}.Normalize().PostalCode)
+}
+
+func TestAddress_Validate(t *testing.T) {
+	valid := []address.Address{
+		{Name: "Jane Doe", Line1: "1 Infinite Loop", City: "Cupertino", Region: "CA", PostalCode: "95014", Country: "US"},
+		{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", PostalCode: "10115", Country: "DE"},
+		{Name: "Jane Doe", Line1: "1 Main St", City: "Dublin", Country: "IE"},
+		{Name: "Jane Doe", Line1: "1 Main St", City: "Reykjavik", Country: "IS"},
+		{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", PostalCode: "10115", Country: "DE", Latitude: 52.53, Longitude: 13.38},
+	}
+	for _, a := range valid {
+		assert.NoError(t, a.Normalize().Validate(), a.Country)
+	}
+
+	tests := []struct {
+		name    string
+		address address.Address
+		fields  []string
+	}{
+		{
+			name:    "US without state and with a malformed ZIP code",
+			address: address.Address{Name: "Jane Doe", Line1: "1 Infinite Loop", City: "Cupertino", PostalCode: "9501", Country: "US"},
+			fields:  []string{"region", "postal_code"},
+		},
+		{
+			name:    "unknown state",
+			address: address.Address{Name: "Jane Doe", Line1: "1 Infinite Loop", City: "Cupertino", Region: "XX", PostalCode: "95014", Country: "US"},
+			fields:  []string{"region"},
+		},
+		{
+			name:    "German address without a postal code",
+			address: address.Address{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", Country: "DE"},
+			fields:  []string{"postal_code"},
+		},
+		{
+			name:    "missing country",
+			address: address.Address{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin"},
+			fields:  []string{"country"},
+		},
+		{
+			name:    "coordinates off the globe",
+			address: address.Address{Name: "Jane Doe", Line1: "Main St 1", City: "Berlin", PostalCode: "10115", Country: "DE", Latitude: 152.53, Longitude: 13.38},
+			fields:  []string{"coordinates"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs validation.Errors
+			if !errors.As(tt.address.Normalize().Validate(), &errs) {
+				t.Fatalf("Expected validation errors on %v", tt.fields)
+			}
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestAddress_Lines(t *testing.T) {
+	us := address.Address{Name: "Jane Doe", Line1: "1 Infinite Loop", City: "Cupertino", Region: "CA", PostalCode: "95014", Country: "US"}
+	assert.Equal(t, []string{"Jane Doe", "1 Infinite Loop", "Cupertino, CA 95014"}, us.Lines("US"))
+	assert.Equal(t, []string{"Jane Doe", "1 Infinite Loop", "CUPERTINO, CA 95014", "UNITED STATES"}, us.Label("DE"))
+
+	de := address.Address{Name: "Jane Doe", Line1: "Main St 1", Line2: "3rd floor", City: "Berlin", PostalCode: "10115", Country: "DE"}
+	assert.Equal(t, []string{"Jane Doe", "Main St 1", "3rd floor", "10115 Berlin", "Germany"}, de.Lines(""))
+
+	jp := address.Address{Name: "Taro Yamada", Line1: "1-2-3 Kamiuma", City: "Setagaya-ku", Region: "Tokyo", PostalCode: "154-0023", Country: "JP"}
+	assert.Equal(t, []string{"〒154-0023", "TokyoSetagaya-ku", "1-2-3 Kamiuma", "Taro Yamada"}, jp.Lines("JP"))
+
+	unknown := address.Address{Name: "Jane Doe", Line1: "1 Main St", City: "Reykjavik", Country: "IS"}
+	assert.Equal(t, []string{"Jane Doe", "1 Main St", "Reykjavik", "IS"}, unknown.Lines("DE"))
+}
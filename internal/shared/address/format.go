@@ -0,0 +1,254 @@
+package address
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Field names an address field; the names are the JSON and validation field names
+type Field string
+
+const (
+	FieldName       Field = "name"
+	FieldLine1      Field = "line1"
+	FieldLine2      Field = "line2"
+	FieldCity       Field = "city"
+	FieldRegion     Field = "region"
+	FieldPostalCode Field = "postal_code"
+	FieldCountry    Field = "country"
+)
+
+// Format is how addresses of a country are written and which of their fields are required
+type Format struct {
+	// Country is the ISO 3166-1 alpha-2 code; CountryName is printed on international labels
+	Country     string
+	CountryName string
+	// Layout lists the lines of the address in print order. Each line holds {field} placeholders;
+	// lines left empty are dropped.
+	Layout   []string
+	Required []Field
+	// PostalCode matches the normalized postal codes of the country; nil accepts any
+	PostalCode        *regexp.Regexp
+	PostalCodeExample string
+	// PostalCodeLabel and RegionLabel are what the fields are called in the country, e.g. "ZIP code" and "State"
+	PostalCodeLabel string
+	RegionLabel     string
+	// Regions are the accepted region codes, e.g. "CA" for California; empty accepts any region
+	Regions []string
+
+	// separator is inserted into postal codes typed without it, separatorAt characters from the end
+	separator   string
+	separatorAt int
+}
+
+// Requires reports whether f is required in the country
+func (f Format) Requires(field Field) bool {
+	for _, r := range f.Required {
+		if r == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Format) hasRegion(region string) bool {
+	if len(f.Regions) == 0 {
+		return true
+	}
+	for _, r := range f.Regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultFormat is used for countries without a format; it asks for what most postal services need
+var defaultFormat = Format{
+	Layout:          []string{"{name}", "{line1}", "{line2}", "{postal_code} {city}", "{region}"},
+	Required:        []Field{FieldName, FieldLine1, FieldCity},
+	PostalCodeLabel: "Postal code",
+	RegionLabel:     "Region",
+}
+
+var usStates = []string{
+	"AK", "AL", "AR", "AS", "AZ", "CA", "CO", "CT", "DC", "DE", "FL", "GA", "GU", "HI", "IA", "ID", "IL", "IN",
+	"KS", "KY", "LA", "MA", "MD", "ME", "MI", "MN", "MO", "MP", "MS", "MT", "NC", "ND", "NE", "NH", "NJ", "NM",
+	"NV", "NY", "OH", "OK", "OR", "PA", "PR", "RI", "SC", "SD", "TN", "TX", "UT", "VA", "VI", "VT", "WA", "WI",
+	"WV", "WY", "AA", "AE", "AP",
+}
+
+var formats = map[string]Format{
+	"AT": {
+		CountryName:       "Austria",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{postal_code} {city}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{4}$`),
+		PostalCodeExample: "1010",
+	},
+	"AU": {
+		CountryName:       "Australia",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{city} {region} {postal_code}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldRegion, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{4}$`),
+		PostalCodeExample: "2000",
+		RegionLabel:       "State",
+		Regions:           []string{"ACT", "JBT", "NSW", "NT", "QLD", "SA", "TAS", "VIC", "WA"},
+	},
+	"BR": {
+		CountryName:       "Brazil",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{city}-{region}", "{postal_code}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldRegion, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{5}-\d{3}$`),
+		PostalCodeExample: "01310-100",
+		PostalCodeLabel:   "CEP",
+		RegionLabel:       "State",
+		separator:         "-",
+		separatorAt:       3,
+	},
+	"CA": {
+		CountryName:       "Canada",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{city} {region} {postal_code}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldRegion, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] \d[ABCEGHJ-NPRSTV-Z]\d$`),
+		PostalCodeExample: "K1A 0B1",
+		RegionLabel:       "Province",
+		Regions:           []string{"AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT"},
+		separator:         " ",
+		separatorAt:       3,
+	},
+	"CH": {
+		CountryName:       "Switzerland",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{postal_code} {city}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{4}$`),
+		PostalCodeExample: "8001",
+	},
+	"DE": {
+		CountryName:       "Germany",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{postal_code} {city}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{5}$`),
+		PostalCodeExample: "10115",
+	},
+	"ES": {
+		CountryName:       "Spain",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{postal_code} {city}", "{region}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{5}$`),
+		PostalCodeExample: "28013",
+		RegionLabel:       "Province",
+	},
+	"FR": {
+		CountryName:       "France",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{postal_code} {city}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{5}$`),
+		PostalCodeExample: "75001",
+	},
+	"GB": {
+		CountryName:       "United Kingdom",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{city}", "{region}", "{postal_code}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^(GIR 0AA|[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2})$`),
+		PostalCodeExample: "SW1A 1AA",
+		PostalCodeLabel:   "Postcode",
+		RegionLabel:       "County",
+		separator:         " ",
+		separatorAt:       3,
+	},
+	// Eircodes are optional in Ireland, which is why the postal code is not required
+	"IE": {
+		CountryName:       "Ireland",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{city}", "{region}", "{postal_code}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity},
+		PostalCode:        regexp.MustCompile(`^([AC-FHKNPRTV-Y]\d{2}|D6W) [0-9AC-FHKNPRTV-Y]{4}$`),
+		PostalCodeExample: "D02 X285",
+		PostalCodeLabel:   "Eircode",
+		RegionLabel:       "County",
+		separator:         " ",
+		separatorAt:       4,
+	},
+	"IN": {
+		CountryName:       "India",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{city} {postal_code}", "{region}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldRegion, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^[1-9]\d{5}$`),
+		PostalCodeExample: "110001",
+		PostalCodeLabel:   "PIN code",
+		RegionLabel:       "State",
+	},
+	"IT": {
+		CountryName:       "Italy",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{postal_code} {city} {region}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldRegion, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{5}$`),
+		PostalCodeExample: "00144",
+		PostalCodeLabel:   "CAP",
+		RegionLabel:       "Province",
+	},
+	"JP": {
+		CountryName:       "Japan",
+		Layout:            []string{"〒{postal_code}", "{region}{city}", "{line1}", "{line2}", "{name}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldRegion, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{3}-\d{4}$`),
+		PostalCodeExample: "154-0023",
+		RegionLabel:       "Prefecture",
+		separator:         "-",
+		separatorAt:       4,
+	},
+	"NL": {
+		CountryName:       "Netherlands",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{postal_code} {city}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^[1-9]\d{3} [A-Z]{2}$`),
+		PostalCodeExample: "1012 JS",
+		separator:         " ",
+		separatorAt:       2,
+	},
+	"US": {
+		CountryName:       "United States",
+		Layout:            []string{"{name}", "{line1}", "{line2}", "{city}, {region} {postal_code}"},
+		Required:          []Field{FieldName, FieldLine1, FieldCity, FieldRegion, FieldPostalCode},
+		PostalCode:        regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		PostalCodeExample: "95014",
+		PostalCodeLabel:   "ZIP code",
+		RegionLabel:       "State",
+		Regions:           usStates,
+	},
+}
+
+func init() {
+	for code, f := range formats {
+		f.Country = code
+		if f.PostalCodeLabel == "" {
+			f.PostalCodeLabel = defaultFormat.PostalCodeLabel
+		}
+		if f.RegionLabel == "" {
+			f.RegionLabel = defaultFormat.RegionLabel
+		}
+		formats[code] = f
+	}
+}
+
+// FormatOf returns the format of a country, or a lenient default format for countries without one
+func FormatOf(country string) Format {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if f, ok := formats[country]; ok {
+		return f
+	}
+	f := defaultFormat
+	f.Country = country
+	return f
+}
+
+// Formats returns the formats of the countries with specific rules, ordered by country code
+func Formats() []Format {
+	all := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		all = append(all, f)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Country < all[j].Country })
+	return all
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("permission denied")
+)
+
+// Header carries the authenticated user of a request. It is set by the API gateway
+// after it verified the caller's credentials and must be stripped from client requests.
+const Header = "X-User-ID"
+
+// Permission names an action as resource:action[:scope], e.g. order:read:own
+type Permission string
+
+// Authorizer reports whether a user holds a permission
+type Authorizer interface {
+	Can(ctx context.Context, userID int64, p Permission) (bool, error)
+}
+
+type contextKey struct{}
+
+type scopesKey struct{}
+
+// WithUserID returns a context bound to the authenticated user
+func WithUserID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// UserID returns the authenticated user bound to ctx; ok is false for anonymous requests and background jobs
+func UserID(ctx context.Context) (id int64, ok bool) {
+	id, ok = ctx.Value(contextKey{}).(int64)
+	return id, ok
+}
+
+// WithScopes returns a context limited to the scopes, e.g. those of the API key a request was
+// made with; Authorizers deny every permission outside them
+func WithScopes(ctx context.Context, scopes []Permission) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// InScope reports whether p is among the scopes bound to ctx; it is true when none are bound
+func InScope(ctx context.Context, p Permission) bool {
+	scopes, ok := ctx.Value(scopesKey{}).([]Permission)
+	if !ok {
+		return true
+	}
+	for _, s := range scopes {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware binds the user named in the X-User-ID header to the request context
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(Header)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			var errs validation.Errors
+			errs.Add(Header, fmt.Sprintf("invalid user ID %q", value))
+			httpx.WriteError(w, errs)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), id)))
+	})
+}
+
+// Check returns nil when the user of ctx holds p. A nil Authorizer allows everything,
+// which keeps deployments without RBAC and the documentation router working.
+func Check(ctx context.Context, a Authorizer, p Permission) error {
+	if a == nil {
+		return nil
+	}
+	userID, ok := UserID(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	allowed, err := a.Can(ctx, userID, p)
+	if err != nil {
+		return fmt.Errorf("check permission %s: %w", p, err)
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s", ErrForbidden, p)
+	}
+	return nil
+}
+
+// CheckOwned allows users holding anyPermission, and users holding ownPermission on resources they own
+func CheckOwned(ctx context.Context, a Authorizer, anyPermission, ownPermission Permission, ownerID int64) error {
+	err := Check(ctx, a, anyPermission)
+	if !errors.Is(err, ErrForbidden) {
+		return err
+	}
+	if userID, _ := UserID(ctx); userID != ownerID {
+		return err
+	}
+	return Check(ctx, a, ownPermission)
+}
+
+// Require wraps a handler so it only runs for users holding p
+func Require(a Authorizer, p Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := Check(r.Context(), a, p); err != nil {
+			WriteError(w, err)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// WriteError writes 401 for anonymous requests, 403 for missing permissions and defers anything else to httpx
+func WriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		httpx.WriteErrorStatus(w, http.StatusUnauthorized, err)
+	case errors.Is(err, ErrForbidden):
+		httpx.WriteErrorStatus(w, http.StatusForbidden, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
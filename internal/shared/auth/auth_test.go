@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticAuthorizer map[int64][]auth.Permission
+
+func (a staticAuthorizer) Can(ctx context.Context, userID int64, p auth.Permission) (bool, error) {
+	for _, granted := range a[userID] {
+		if granted == p {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	var seen int64
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = auth.UserID(r.Context())
+	}))
+
+	tests := []struct {
+		header string
+		status int
+		userID int64
+	}{
+		{header: "", status: http.StatusOK},
+		{header: "42", status: http.StatusOK, userID: 42},
+		{header: "admin", status: http.StatusUnprocessableEntity},
+		{header: "-1", status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		seen = 0
+		req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		if tt.header != "" {
+			req.Header.Set(auth.Header, tt.header)
+		}
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code, tt.header)
+		assert.Equal(t, tt.userID, seen, tt.header)
+	}
+}
+
+func TestCheckOwned(t *testing.T) {
+	authorizer := staticAuthorizer{
+		1: {"order:read:any"},
+		2: {"order:read:own"},
+	}
+	as := func(id int64) context.Context { return auth.WithUserID(context.Background(), id) }
+
+	assert.NoError(t, auth.CheckOwned(as(1), authorizer, "order:read:any", "order:read:own", 2))
+	assert.NoError(t, auth.CheckOwned(as(2), authorizer, "order:read:any", "order:read:own", 2))
+	assert.ErrorIs(t, auth.CheckOwned(as(2), authorizer, "order:read:any", "order:read:own", 3), auth.ErrForbidden)
+	assert.ErrorIs(t, auth.CheckOwned(as(3), authorizer, "order:read:any", "order:read:own", 3), auth.ErrForbidden)
+	assert.ErrorIs(t, auth.CheckOwned(context.Background(), authorizer, "order:read:any", "order:read:own", 3), auth.ErrUnauthenticated)
+	assert.NoError(t, auth.CheckOwned(context.Background(), nil, "order:read:any", "order:read:own", 3), "a nil authorizer allows everything")
+}
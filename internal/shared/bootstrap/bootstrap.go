@@ -0,0 +1,57 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Resource is a piece of external infrastructure the service depends on, such as a broker topic,
+// a Redis keyspace or a storage bucket. Adapters owning the resource implement it.
+type Resource interface {
+	// Describe names the resource in logs, e.g. "kafka topic order-events"
+	Describe() string
+	// Ensure creates the resource when it is missing; it must succeed without changes when it exists
+	Ensure(ctx context.Context) (created bool, err error)
+}
+
+// Registry collects the resources adapters need so a new environment can be set up in one step
+type Registry struct {
+	mu        sync.Mutex
+	resources []Resource
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds resources to be ensured by EnsureAll
+func (r *Registry) Register(resources ...Resource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resources = append(r.resources, resources...)
+}
+
+// EnsureAll ensures every registered resource in registration order; a failing resource does not stop the others
+func (r *Registry) EnsureAll(ctx context.Context) error {
+	r.mu.Lock()
+	resources := append([]Resource(nil), r.resources...)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, resource := range resources {
+		created, err := resource.Ensure(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ensure %s: %w", resource.Describe(), err))
+			continue
+		}
+		if created {
+			slog.InfoContext(ctx, "infrastructure resource created", "resource", resource.Describe())
+		} else {
+			slog.DebugContext(ctx, "infrastructure resource already exists", "resource", resource.Describe())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package bootstrap_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/bootstrap"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResource struct {
+	name   string
+	exists bool
+	err    error
+	calls  int
+}
+
+func (r *fakeResource) Describe() string {
+	return r.name
+}
+
+func (r *fakeResource) Ensure(ctx context.Context) (bool, error) {
+	r.calls++
+	if r.err != nil {
+		return false, r.err
+	}
+	created := !r.exists
+	r.exists = true
+	return created, nil
+}
+
+func TestRegistry_EnsureAllIsIdempotent(t *testing.T) {
+	topic := &fakeResource{name: "topic orders"}
+	bucket := &fakeResource{name: "bucket images", exists: true}
+	registry := bootstrap.NewRegistry()
+	registry.Register(topic, bucket)
+
+	assert.NoError(t, registry.EnsureAll(context.Background()))
+	assert.NoError(t, registry.EnsureAll(context.Background()))
+
+	assert.True(t, topic.exists)
+	assert.Equal(t, 2, topic.calls)
+	assert.Equal(t, 2, bucket.calls)
+}
+
+func TestRegistry_EnsureAllContinuesAfterFailure(t *testing.T) {
+	broken := &fakeResource{name: "topic orders", err: errors.New("broker unavailable")}
+	bucket := &fakeResource{name: "bucket images"}
+	registry := bootstrap.NewRegistry()
+	registry.Register(broken, bucket)
+
+	err := registry.EnsureAll(context.Background())
+
+	assert.ErrorContains(t, err, "ensure topic orders: broker unavailable")
+	assert.True(t, bucket.exists, "later resources are still ensured")
+}
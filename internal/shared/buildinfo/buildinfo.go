@@ -0,0 +1,79 @@
+// Package buildinfo describes the build that is running, so operators can tell which one served a
+// request. Release builds stamp Version, Commit and BuildTime with the linker:
+//
+//	go build -ldflags "-X github.com/mohsenjafari-aiio/aiiobackend/internal/shared/buildinfo.Version=1.4.0 \
+//	  -X github.com/mohsenjafari-aiio/aiiobackend/internal/shared/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/mohsenjafari-aiio/aiiobackend/internal/shared/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// `make build` does this. Other builds fall back to the revision and commit time go build records
+// from git.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags "-X ..."; see the package documentation
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info is the build of the running binary and the optional features its configuration enables
+type Info struct {
+	Version string `json:"version"`
+	// Commit is the git revision, suffixed with "-dirty" for builds of uncommitted changes
+	Commit string `json:"commit,omitempty"`
+	// BuildTime is when the binary was built in RFC 3339, or the time of its commit for builds not
+	// stamped by the linker
+	BuildTime string   `json:"build_time,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Features  []string `json:"features"`
+}
+
+// Read returns the build of the running binary with the given enabled features
+func Read(features ...string) Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Features:  append([]string{}, features...),
+	}
+	if info.Commit != "" && info.BuildTime != "" {
+		return info
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	var revision, modified string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	if info.Commit == "" && revision != "" {
+		info.Commit = revision
+		if modified == "true" {
+			info.Commit += "-dirty"
+		}
+	}
+	return info
+}
+
+// LogAttrs returns the version and commit as slog key-value pairs, for loggers whose records
+// should name the build, e.g. slog.Default().With(info.LogAttrs()...)
+func (i Info) LogAttrs() []any {
+	return []any{"version", i.Version, "commit", i.Commit}
+}
@@ -0,0 +1,33 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	Version, Commit, BuildTime = "1.4.0", "abc123", "2024-05-18T12:00:00Z"
+	t.Cleanup(func() { Version, Commit, BuildTime = "dev", "", "" })
+
+	features := []string{"rbac"}
+	info := Read(features...)
+	features[0] = "audit"
+
+	assert.Equal(t, Info{
+		Version:   "1.4.0",
+		Commit:    "abc123",
+		BuildTime: "2024-05-18T12:00:00Z",
+		GoVersion: runtime.Version(),
+		Features:  []string{"rbac"},
+	}, info)
+	assert.Equal(t, []any{"version", "1.4.0", "commit", "abc123"}, info.LogAttrs())
+}
+
+func TestRead_Unstamped(t *testing.T) {
+	info := Read()
+
+	assert.Equal(t, "dev", info.Version)
+	assert.NotNil(t, info.Features, "no features encode as an empty list")
+}
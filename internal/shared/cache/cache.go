@@ -0,0 +1,170 @@
+// Package cache keeps the results of list and aggregate queries in memory. Results are keyed by
+// a hash of the QueryBuilder describing what the query reads and tagged with the entity types it
+// reads, e.g. "product"; repositories invalidate a tag when they write an entity of its type, so
+// a product list cached before a stock update is dropped as soon as the update is written.
+//
+// Each instance keeps its own cache, so a write only invalidates the instance it ran on; the
+// others serve their cached results until the TTL expires.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+// Store holds cached query results for TTL, dropping every result of a tag when it is invalidated
+type Store struct {
+	// TTL bounds how long a result is served; zero disables caching
+	TTL time.Duration
+	// MaxEntries bounds the number of results kept; zero leaves it unbounded
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]entry
+	// tags lists the keys of the results cached under each tag
+	tags map[string]map[string]struct{}
+	// generation counts invalidations, so results read before one are not cached after it
+	generation uint64
+}
+
+type entry struct {
+	value   any
+	tags    []string
+	expires time.Time
+}
+
+// Get returns the result cached under key
+func (s *Store) Get(key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(e.expires) {
+		s.remove(key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Generation returns the current generation of the store, to pass to Set once the result is read
+func (s *Store) Generation() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation
+}
+
+// Set caches value under key and tags, unless the store was invalidated since generation: the
+// value may have been read before the write that invalidated it
+func (s *Store) Set(key string, value any, generation uint64, tags ...string) {
+	if s.TTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if generation != s.generation {
+		return
+	}
+	if s.MaxEntries > 0 && len(s.entries) >= s.MaxEntries {
+		s.removeExpired()
+		if len(s.entries) >= s.MaxEntries {
+			return
+		}
+	}
+
+	if s.entries == nil {
+		s.entries, s.tags = map[string]entry{}, map[string]map[string]struct{}{}
+	}
+	s.remove(key)
+	s.entries[key] = entry{value: value, tags: tags, expires: time.Now().Add(s.TTL)}
+	for _, tag := range tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = map[string]struct{}{}
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+}
+
+// Invalidate drops the results cached under any of tags
+func (s *Store) Invalidate(tags ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			s.remove(key)
+		}
+	}
+}
+
+// Len returns the number of cached results, expired ones included until they are dropped
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *Store) remove(key string) {
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+	for _, tag := range e.tags {
+		delete(s.tags[tag], key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+func (s *Store) removeExpired() {
+	now := time.Now()
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			s.remove(key)
+		}
+	}
+}
+
+// Query is a query whose results can be cached
+type Query interface {
+	// CacheKey describes the rows the query reads; queries of a type with equal builders share
+	// their results
+	CacheKey() *query.QueryBuilder
+}
+
+// CachedQuery serves the results of handler from s, reading them again once they expire or a
+// repository invalidates one of tags. The key also holds the type of the query and the tenant of
+// ctx. Callers share the cached results, so they must not modify them; failures are not cached.
+func CachedQuery[Q Query, R any](s *Store, handler decorator.QueryHandler[Q, R], tags ...string) decorator.QueryHandler[Q, R] {
+	return cachedQuery[Q, R]{store: s, base: handler, tags: tags}
+}
+
+type cachedQuery[Q Query, R any] struct {
+	store *Store
+	base  decorator.QueryHandler[Q, R]
+	tags  []string
+}
+
+func (d cachedQuery[Q, R]) Handle(ctx context.Context, q Q) (R, error) {
+	key := fmt.Sprintf("%T/%s/%s", q, tenant.FromContext(ctx), q.CacheKey().Key())
+	if cached, ok := d.store.Get(key); ok {
+		return cached.(R), nil
+	}
+
+	generation := d.store.Generation()
+	result, err := d.base.Handle(ctx, q)
+	if err != nil {
+		return result, err
+	}
+	d.store.Set(key, result, generation, d.tags...)
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/cache"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listQuery struct {
+	Name string
+}
+
+func (q listQuery) CacheKey() *query.QueryBuilder {
+	return query.NewQueryBuilder(nil).AddFilter("name", query.OperatorContains, q.Name)
+}
+
+// countingHandler returns how often it was called, and runs during before returning when set
+type countingHandler struct {
+	calls  int
+	during func()
+}
+
+func (h *countingHandler) Handle(ctx context.Context, q listQuery) (int, error) {
+	h.calls++
+	if h.during != nil {
+		h.during()
+	}
+	return h.calls, nil
+}
+
+func TestCachedQuery(t *testing.T) {
+	store := &cache.Store{TTL: time.Minute}
+	base := &countingHandler{}
+	handler := cache.CachedQuery[listQuery, int](store, base, "product")
+	ctx := context.Background()
+
+	first, err := handler.Handle(ctx, listQuery{Name: "Wid"})
+	require.NoError(t, err)
+	again, _ := handler.Handle(ctx, listQuery{Name: "Wid"})
+	assert.Equal(t, first, again, "equal queries share the cached result")
+
+	other, _ := handler.Handle(ctx, listQuery{Name: "Gad"})
+	assert.NotEqual(t, first, other)
+	otherTenant, _ := handler.Handle(tenant.WithID(ctx, "acme"), listQuery{Name: "Wid"})
+	assert.NotEqual(t, first, otherTenant, "tenants do not share results")
+	assert.Equal(t, 3, base.calls)
+
+	store.Invalidate("order")
+	cached, _ := handler.Handle(ctx, listQuery{Name: "Wid"})
+	assert.Equal(t, first, cached, "other tags keep the result")
+
+	store.Invalidate("product")
+	fresh, _ := handler.Handle(ctx, listQuery{Name: "Wid"})
+	assert.Equal(t, 4, fresh, "the tag of the query drops the result")
+	assert.Equal(t, 1, store.Len(), "the other results of the tag are dropped too")
+}
+
+func TestCachedQuery_DropsResultsReadBeforeAnInvalidation(t *testing.T) {
+	store := &cache.Store{TTL: time.Minute}
+	base := &countingHandler{}
+	handler := cache.CachedQuery[listQuery, int](store, base, "product")
+	// A write lands while the query reads the old rows
+	base.during = func() { store.Invalidate("product") }
+
+	_, err := handler.Handle(context.Background(), listQuery{})
+	require.NoError(t, err)
+
+	assert.Zero(t, store.Len())
+}
+
+func TestStore_Expiry(t *testing.T) {
+	store := &cache.Store{TTL: time.Millisecond}
+	store.Set("a", 1, store.Generation(), "product")
+	time.Sleep(2 * time.Millisecond)
+
+	_, ok := store.Get("a")
+	assert.False(t, ok)
+
+	full := &cache.Store{TTL: time.Minute, MaxEntries: 1}
+	full.Set("b", 2, full.Generation())
+	full.Set("c", 3, full.Generation())
+	_, ok = full.Get("c")
+	assert.False(t, ok, "a full store caches no more results")
+
+	disabled := &cache.Store{}
+	disabled.Set("a", 1, disabled.Generation())
+	assert.Zero(t, disabled.Len())
+}
@@ -0,0 +1,145 @@
+// Package canary soft-launches new implementations to a share of users. A release registers the
+// stable and the candidate implementation of a command side by side, and Rollout sends the
+// configured percentage of users to the candidate. Users are bucketed by a hash of their ID, so
+// each of them sticks to one implementation across requests and instances.
+package canary
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// Header lists the releases a request was routed to the candidate of, e.g. "pricing"
+const Header = "X-Canary"
+
+// Variant is the implementation of a release a user gets
+type Variant string
+
+const (
+	Stable    Variant = "stable"
+	Candidate Variant = "candidate"
+)
+
+// Releases are the percentages of users, from 0 to 100, that get the candidate of each release
+type Releases map[string]int
+
+// ParseReleases reads comma-separated name=percent pairs, e.g. "pricing=10,search=50"
+func ParseReleases(s string) (Releases, error) {
+	releases := Releases{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected name=percent, got %q", entry)
+		}
+		percent, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("percent of %s must be between 0 and 100, got %q", name, value)
+		}
+		if _, ok := releases[name]; ok {
+			return nil, fmt.Errorf("release %s is listed twice", name)
+		}
+		releases[name] = percent
+	}
+	return releases, nil
+}
+
+// Observer counts how often each variant of a release is used, to compare their error rates and
+// latencies while the candidate is rolled out
+type Observer interface {
+	Exposed(release string, v Variant)
+}
+
+// Rollout routes users to the variants of its releases. The zero Rollout keeps everyone on the
+// stable implementations.
+type Rollout struct {
+	Releases Releases
+	Observer Observer
+}
+
+// Variant returns the variant of release user userID gets. Anonymous callers and background jobs
+// without a user (userID 0) always get the stable implementation.
+func (r *Rollout) Variant(release string, userID int64) Variant {
+	percent := r.Releases[release]
+	if percent <= 0 || userID <= 0 {
+		return Stable
+	}
+	if bucket(release, userID) < percent {
+		return Candidate
+	}
+	return Stable
+}
+
+// bucket hashes the user into one of 100 buckets; the release name is part of the hash so the
+// candidates of different releases are not tried on the same users
+func bucket(release string, userID int64) int {
+	h := fnv.New32a()
+	h.Write([]byte(release))
+	h.Write(binary.BigEndian.AppendUint64([]byte{0}, uint64(userID)))
+	return int(h.Sum32() % 100)
+}
+
+// choose returns the variant of release for the user bound to ctx and counts the exposure
+func (r *Rollout) choose(ctx context.Context, release string) Variant {
+	userID, _ := auth.UserID(ctx)
+	v := r.Variant(release, userID)
+	if r.Observer != nil {
+		r.Observer.Exposed(release, v)
+	}
+	return v
+}
+
+// Middleware reports the releases the authenticated user gets the candidate of in the X-Canary
+// response header, so support can tell which implementation served a request; it must run
+// inside auth.Middleware
+func (r *Rollout) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if userID, ok := auth.UserID(req.Context()); ok {
+			var candidates []string
+			for release := range r.Releases {
+				if r.Variant(release, userID) == Candidate {
+					candidates = append(candidates, release)
+				}
+			}
+			if len(candidates) > 0 {
+				sort.Strings(candidates)
+				w.Header().Set(Header, strings.Join(candidates, ","))
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Split registers the stable and candidate implementations of a command side by side and routes
+// each call to the variant of release the caller gets; a nil candidate always uses stable
+func Split[C any, R any](r *Rollout, release string, stable, candidate decorator.CommandResultHandler[C, R]) decorator.CommandResultHandler[C, R] {
+	if candidate == nil {
+		return stable
+	}
+	return splitHandler[C, R]{rollout: r, release: release, stable: stable, candidate: candidate}
+}
+
+type splitHandler[C any, R any] struct {
+	rollout           *Rollout
+	release           string
+	stable, candidate decorator.CommandResultHandler[C, R]
+}
+
+func (h splitHandler[C, R]) Handle(ctx context.Context, cmd C) (R, error) {
+	if h.rollout.choose(ctx, h.release) == Candidate {
+		return h.candidate.Handle(ctx, cmd)
+	}
+	return h.stable.Handle(ctx, cmd)
+}
@@ -0,0 +1,86 @@
+package canary_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/canary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReleases(t *testing.T) {
+	releases, err := canary.ParseReleases(" pricing=10, search = 50 ,")
+	require.NoError(t, err)
+	assert.Equal(t, canary.Releases{"pricing": 10, "search": 50}, releases)
+
+	for _, invalid := range []string{"pricing", "=10", "pricing=ten", "pricing=101", "pricing=-1", "pricing=10,pricing=20"} {
+		_, err := canary.ParseReleases(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRollout_Variant(t *testing.T) {
+	r := &canary.Rollout{Releases: canary.Releases{"pricing": 20, "off": 0, "all": 100}}
+
+	candidates := 0
+	for id := int64(1); id <= 10000; id++ {
+		v := r.Variant("pricing", id)
+		assert.Equal(t, v, r.Variant("pricing", id), "users stick to their variant")
+		if v == canary.Candidate {
+			candidates++
+		}
+	}
+	assert.InDelta(t, 2000, candidates, 200, "about a fifth of the users get the candidate")
+
+	assert.Equal(t, canary.Stable, r.Variant("off", 1))
+	assert.Equal(t, canary.Stable, r.Variant("unknown", 1))
+	assert.Equal(t, canary.Candidate, r.Variant("all", 1))
+	assert.Equal(t, canary.Stable, r.Variant("all", 0), "anonymous callers get the stable implementation")
+}
+
+type recorder map[canary.Variant]int
+
+func (r recorder) Exposed(release string, v canary.Variant) {
+	r[v]++
+}
+
+type constHandler string
+
+func (h constHandler) Handle(ctx context.Context, cmd struct{}) (string, error) {
+	return string(h), nil
+}
+
+func TestSplit(t *testing.T) {
+	exposures := recorder{}
+	r := &canary.Rollout{Releases: canary.Releases{"pricing": 50}, Observer: exposures}
+	handler := canary.Split[struct{}, string](r, "pricing", constHandler("stable"), constHandler("candidate"))
+
+	for id := int64(1); id <= 100; id++ {
+		got, err := handler.Handle(auth.WithUserID(context.Background(), id), struct{}{})
+		require.NoError(t, err)
+		assert.Equal(t, string(r.Variant("pricing", id)), got)
+	}
+	got, _ := handler.Handle(context.Background(), struct{}{})
+	assert.Equal(t, "stable", got)
+	assert.Equal(t, 101, exposures[canary.Stable]+exposures[canary.Candidate], "every call is counted")
+	assert.Positive(t, exposures[canary.Candidate])
+}
+
+func TestRollout_Middleware(t *testing.T) {
+	r := &canary.Rollout{Releases: canary.Releases{"pricing": 100, "search": 0}}
+	handler := auth.Middleware(r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(auth.Header, "7")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "pricing", rec.Header().Get(canary.Header))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Empty(t, rec.Header().Get(canary.Header))
+}
@@ -0,0 +1,190 @@
+// Package dto maps domain entities to API responses and trims responses to the fields a client
+// selected. Modules keep their own toXResponse mappers; Map applies one to a list and Select keeps
+// the fields named by the fields query parameter, e.g. GET /disputes?fields=id,status,amount.
+// MaskPolicy hides personal data such as emails from callers who may not see it.
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// Fields is a sparse fieldset: the JSON fields of a response a client asked for. A nil Fields
+// selects every field.
+type Fields map[string]bool
+
+// ParseFields reads the comma-separated fields query parameter of r. It returns nil when the
+// parameter is absent, and validation.Errors when it names a field response R does not have.
+func ParseFields[R any](r *http.Request) (Fields, error) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+
+	known := fieldsOf(reflect.TypeOf((*R)(nil)).Elem())
+	fields := Fields{}
+	var unknown []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(known, func(f field) bool { return f.name == name }) {
+			unknown = append(unknown, name)
+			continue
+		}
+		fields[name] = true
+	}
+
+	var errs validation.Errors
+	if len(unknown) > 0 {
+		names := make([]string, len(known))
+		for i, f := range known {
+			names[i] = f.name
+		}
+		errs.Add("fields", fmt.Sprintf("has unknown fields %s; known fields are %s", strings.Join(unknown, ", "), strings.Join(names, ", ")))
+	}
+	errs.Check(len(fields) > 0 || len(unknown) > 0, "fields", "must name at least one field")
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Has reports whether the field is selected, which every field is when no fields were given
+func (f Fields) Has(name string) bool {
+	return f == nil || f[name]
+}
+
+// Named reports whether the client asked for the field by name. Responses use it for expensive
+// fields left out by default, such as relations a list would otherwise have to preload.
+func (f Fields) Named(name string) bool {
+	return f[name]
+}
+
+// Sparse is a response encoded with only its selected fields, in declaration order
+type Sparse[R any] struct {
+	Value  R
+	Fields Fields
+}
+
+// Select keeps the selected fields of a response
+func Select[R any](v R, fields Fields) Sparse[R] {
+	return Sparse[R]{Value: v, Fields: fields}
+}
+
+// Map converts entities to responses with mapper and keeps the selected fields of each
+func Map[E, R any](entities []E, mapper func(*E) R, fields Fields) []Sparse[R] {
+	out := make([]Sparse[R], len(entities))
+	for i := range entities {
+		out[i] = Select(mapper(&entities[i]), fields)
+	}
+	return out
+}
+
+// SchemaType makes the OpenAPI document describe a Sparse response as the full response
+func (Sparse[R]) SchemaType() reflect.Type {
+	return reflect.TypeOf((*R)(nil)).Elem()
+}
+
+func (s Sparse[R]) MarshalJSON() ([]byte, error) {
+	if s.Fields == nil {
+		return json.Marshal(s.Value)
+	}
+
+	v := reflect.ValueOf(s.Value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+		v = v.Elem()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, f := range fieldsOf(v.Type()) {
+		value := v.Field(f.index)
+		if !s.Fields[f.name] || (f.omitEmpty && isEmpty(value)) {
+			continue
+		}
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(encoded)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// field is a top-level JSON field of a response struct
+type field struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// fieldCache holds the fields of each response type, which never change
+var fieldCache sync.Map
+
+// fieldsOf lists the JSON fields of a struct type the way encoding/json names them. Responses
+// do not embed structs, so embedded fields are not flattened.
+func fieldsOf(t reflect.Type) []field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			tag := strings.Split(sf.Tag.Get("json"), ",")
+			if tag[0] == "-" {
+				continue
+			}
+			f := field{name: tag[0], index: i, omitEmpty: slices.Contains(tag[1:], "omitempty")}
+			if f.name == "" {
+				f.name = sf.Name
+			}
+			fields = append(fields, f)
+		}
+	}
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.([]field)
+}
+
+// isEmpty matches the values encoding/json leaves out of omitempty fields
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return v.IsZero()
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+type testResponse struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Total     int64     `json:"total,omitempty"`
+	Items     []string  `json:"items,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	internal  string
+}
+
+type testEntity struct {
+	ID    string
+	Items []string
+}
+
+func toTestResponse(e *testEntity) testResponse {
+	return testResponse{ID: e.ID, Status: "PAID", Items: e.Items}
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields[testResponse](httptest.NewRequest("GET", "/orders?fields=id,+total,,status", nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(fields) != 3 || !fields.Has("total") || fields.Has("items") {
+		t.Errorf("Expected id, total and status, got %v", fields)
+	}
+
+	fields, err = ParseFields[testResponse](httptest.NewRequest("GET", "/orders", nil))
+	if err != nil || fields != nil {
+		t.Fatalf("Expected every field without the parameter, got %v and %v", fields, err)
+	}
+	if !fields.Has("items") || fields.Named("items") {
+		t.Error("Expected every field to be selected but none named")
+	}
+
+	for _, query := range []string{"fields=id,internal,secret", "fields=,"} {
+		_, err := ParseFields[testResponse](httptest.NewRequest("GET", "/orders?"+query, nil))
+		var errs validation.Errors
+		if !errors.As(err, &errs) || errs[0].Field != "fields" {
+			t.Errorf("Expected a validation error on fields for %q, got %v", query, err)
+		}
+	}
+}
+
+func TestMap_KeepsSelectedFields(t *testing.T) {
+	entities := []testEntity{{ID: "ord_1", Items: []string{"a"}}, {ID: "ord_2"}}
+
+	body, err := json.Marshal(Map(entities, toTestResponse, Fields{"items": true, "id": true}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Fields keep their declaration order and omitempty still applies
+	if want := `[{"id":"ord_1","items":["a"]},{"id":"ord_2"}]`; string(body) != want {
+		t.Errorf("Expected %s, got %s", want, body)
+	}
+
+	full, _ := json.Marshal(toTestResponse(&entities[0]))
+	body, _ = json.Marshal(Select(toTestResponse(&entities[0]), nil))
+	if string(body) != string(full) {
+		t.Errorf("Expected every field without a selection, got %s", body)
+	}
+}
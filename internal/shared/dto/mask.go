@@ -0,0 +1,161 @@
+package dto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+)
+
+// MaskKind is a kind of personal data hidden from callers who may not see it. Response fields
+// name theirs with the mask tag, e.g. `json:"email" mask:"email"`.
+type MaskKind string
+
+const (
+	// MaskEmail keeps the first letter and the domain: j***@example.com
+	MaskEmail MaskKind = "email"
+	// MaskPhone keeps the last four digits: +*******0123
+	MaskPhone MaskKind = "phone"
+)
+
+var maskers = map[MaskKind]func(string) string{
+	MaskEmail: maskEmail,
+	MaskPhone: maskPhone,
+}
+
+// ParseMaskKinds checks the kinds named in configuration
+func ParseMaskKinds(names []string) ([]MaskKind, error) {
+	kinds := make([]MaskKind, 0, len(names))
+	for _, name := range names {
+		kind := MaskKind(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := maskers[kind]; !ok {
+			return nil, fmt.Errorf("unknown mask kind %q, expected %s or %s", name, MaskEmail, MaskPhone)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// Masker decides which personal data the responses of a request hide. Callers holding Unmask see
+// everything; a nil Masker or Auth masks nothing, the way auth.Check allows everything without RBAC.
+type Masker struct {
+	Kinds  []MaskKind
+	Auth   auth.Authorizer
+	Unmask auth.Permission
+}
+
+// Policy resolves the masking for the caller of ctx. Resolve it once per request; it checks a
+// permission.
+func (m *Masker) Policy(ctx context.Context) (MaskPolicy, error) {
+	if m == nil || m.Auth == nil || len(m.Kinds) == 0 {
+		return MaskPolicy{}, nil
+	}
+	err := auth.Check(ctx, m.Auth, m.Unmask)
+	switch {
+	case err == nil:
+		return MaskPolicy{}, nil
+	case errors.Is(err, auth.ErrForbidden), errors.Is(err, auth.ErrUnauthenticated):
+		caller, _ := auth.UserID(ctx)
+		return MaskPolicy{kinds: m.Kinds, caller: caller}, nil
+	default:
+		return MaskPolicy{}, err
+	}
+}
+
+// MaskPolicy masks personal data for one caller. The zero MaskPolicy masks nothing.
+type MaskPolicy struct {
+	kinds  []MaskKind
+	caller int64
+}
+
+// Masks reports whether data of kind owned by the user ownerID is hidden. Users always see their
+// own data; pass 0 when the owner is unknown.
+func (p MaskPolicy) Masks(kind MaskKind, ownerID int64) bool {
+	return slices.Contains(p.kinds, kind) && (p.caller == 0 || p.caller != ownerID)
+}
+
+// Value returns value masked when the policy hides its kind
+func (p MaskPolicy) Value(kind MaskKind, value string, ownerID int64) string {
+	if value == "" || !p.Masks(kind, ownerID) {
+		return value
+	}
+	return maskers[kind](value)
+}
+
+// Apply masks the tagged string fields of the response v points to, including those of nested
+// structs, pointers and slices such as a []Sparse[AddressResponse]. v may point to an interface
+// holding the response.
+func (p MaskPolicy) Apply(v any, ownerID int64) {
+	if len(p.kinds) == 0 {
+		return
+	}
+	p.apply(reflect.ValueOf(v), ownerID)
+}
+
+func (p MaskPolicy) apply(v reflect.Value, ownerID int64) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			p.apply(v.Elem(), ownerID)
+		}
+	case reflect.Interface:
+		// The value in an interface cannot be changed in place, so a masked copy replaces it
+		if !v.IsNil() && v.CanSet() {
+			masked := reflect.New(v.Elem().Type()).Elem()
+			masked.Set(v.Elem())
+			p.apply(masked, ownerID)
+			v.Set(masked)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			p.apply(v.Index(i), ownerID)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf, field := t.Field(i), v.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if kind, ok := sf.Tag.Lookup("mask"); ok && field.Kind() == reflect.String && field.CanSet() {
+				field.SetString(p.Value(MaskKind(kind), field.String(), ownerID))
+				continue
+			}
+			p.apply(field, ownerID)
+		}
+	}
+}
+
+// maskEmail keeps the first character of the local part and the domain
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return "***"
+	}
+	first := ""
+	for _, r := range local {
+		first = string(r)
+		break
+	}
+	return first + "***@" + domain
+}
+
+// maskPhone keeps the leading + and the last four digits
+func maskPhone(phone string) string {
+	runes := []rune(phone)
+	keep := min(4, len(runes)/2)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case i == 0 && r == '+', i >= len(runes)-keep:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('*')
+		}
+	}
+	return b.String()
+}
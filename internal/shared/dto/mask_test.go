@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+)
+
+type maskedResponse struct {
+	Email    string                   `json:"email" mask:"email"`
+	Phone    string                   `json:"phone,omitempty" mask:"phone"`
+	Name     string                   `json:"name"`
+	Contacts []Sparse[maskedResponse] `json:"contacts,omitempty"`
+}
+
+// grants allows the users in the map the permissions listed for them
+type grants map[int64][]auth.Permission
+
+func (g grants) Can(ctx context.Context, userID int64, p auth.Permission) (bool, error) {
+	for _, granted := range g[userID] {
+		if granted == p {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestMaskValues(t *testing.T) {
+	tests := []struct {
+		kind  MaskKind
+		value string
+		want  string
+	}{
+		{MaskEmail, "jane@example.com", "j***@example.com"},
+		{MaskEmail, "ünal@example.de", "ü***@example.de"},
+		{MaskEmail, "not-an-email", "***"},
+		{MaskPhone, "+14155550123", "+*******0123"},
+		{MaskPhone, "555", "**5"},
+		{MaskEmail, "", ""},
+	}
+	policy := MaskPolicy{kinds: []MaskKind{MaskEmail, MaskPhone}}
+	for _, tt := range tests {
+		if got := policy.Value(tt.kind, tt.value, 0); got != tt.want {
+			t.Errorf("Value(%s, %q) = %q, want %q", tt.kind, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMasker_Policy(t *testing.T) {
+	masker := &Masker{Kinds: []MaskKind{MaskEmail}, Auth: grants{1: {"pii:read"}}, Unmask: "pii:read"}
+	response := func() maskedResponse {
+		return maskedResponse{
+			Email:    "jane@example.com",
+			Phone:    "+14155550123",
+			Name:     "Jane",
+			Contacts: []Sparse[maskedResponse]{Select(maskedResponse{Email: "bob@example.com"}, nil)},
+		}
+	}
+
+	policy, err := masker.Policy(auth.WithUserID(context.Background(), 1))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp := response()
+	policy.Apply(&resp, 7)
+	if resp.Email != "jane@example.com" || resp.Contacts[0].Value.Email != "bob@example.com" {
+		t.Errorf("Expected callers with the permission to see everything, got %+v", resp)
+	}
+
+	policy, err = masker.Policy(auth.WithUserID(context.Background(), 2))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp = response()
+	policy.Apply(&resp, 7)
+	if resp.Email != "j***@example.com" || resp.Contacts[0].Value.Email != "b***@example.com" {
+		t.Errorf("Expected nested emails masked, got %+v", resp)
+	}
+	if resp.Phone != "+14155550123" || resp.Name != "Jane" {
+		t.Errorf("Expected kinds that are not configured left alone, got %+v", resp)
+	}
+
+	var boxed any = response()
+	policy.Apply(&boxed, 7)
+	if got := boxed.(maskedResponse).Email; got != "j***@example.com" {
+		t.Errorf("Expected responses held in an interface masked, got %q", got)
+	}
+
+	resp = response()
+	policy.Apply(&resp, 2)
+	if resp.Email != "jane@example.com" {
+		t.Errorf("Expected owners to see their own data, got %q", resp.Email)
+	}
+
+	policy, err = (&Masker{Kinds: []MaskKind{MaskEmail}, Unmask: "pii:read"}).Policy(context.Background())
+	if err != nil || policy.Masks(MaskEmail, 0) {
+		t.Errorf("Expected nothing masked without access control, got %+v, %v", policy, err)
+	}
+}
+
+func TestParseMaskKinds(t *testing.T) {
+	kinds, err := ParseMaskKinds([]string{"email", " Phone"})
+	if err != nil || len(kinds) != 2 || kinds[1] != MaskPhone {
+		t.Errorf("Expected email and phone, got %v, %v", kinds, err)
+	}
+	if _, err := ParseMaskKinds([]string{"ssn"}); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+}
@@ -0,0 +1,69 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+)
+
+// Event is a domain event; EventName identifies it for subscribers
+type Event interface {
+	EventName() string
+	// EventID identifies this occurrence of the event. It is derived with NewID rather than
+	// generated, so an event that is replayed or rebuilt from stored state keeps its ID.
+	EventID() string
+}
+
+// IDPrefix starts event IDs, e.g. "evt_0BFR2002Z44G2XBJBBYXGAVC9Z"
+const IDPrefix = "evt"
+
+// NewID derives the ID of the event name emitted by an aggregate at version, the number of changes
+// the aggregate went through up to and including this one. The same inputs always give the same ID.
+func NewID(name, aggregateID string, version int) string {
+	return publicid.Derive(IDPrefix, fmt.Sprintf("%s/%s/%d", name, aggregateID, version))
+}
+
+// Handler reacts to a published event
+type Handler func(ctx context.Context, e Event) error
+
+// Publisher is the port commands use to emit domain events
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// Bus is an in-process publisher dispatching events synchronously to their subscribers
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers handler for events with the given name
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish delivers every event to all of its subscribers; a failing handler does not stop the others
+func (b *Bus) Publish(ctx context.Context, events ...Event) error {
+	var errs []error
+	for _, e := range events {
+		b.mu.RLock()
+		handlers := b.handlers[e.EventName()]
+		b.mu.RUnlock()
+
+		for _, handler := range handlers {
+			if err := handler(ctx, e); err != nil {
+				errs = append(errs, fmt.Errorf("handle %s: %w", e.EventName(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type userRenamed struct {
+	Name string
+}
+
+func (userRenamed) EventName() string { return "user.renamed" }
+func (e userRenamed) EventID() string { return event.NewID("user.renamed", e.Name, 1) }
+
+func TestNewID(t *testing.T) {
+	id := event.NewID("order.placed", "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE", 1)
+
+	assert.Equal(t, id, event.NewID("order.placed", "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE", 1))
+	assert.Regexp(t, `^evt_[0-9A-Z]{26}$`, id)
+	assert.NotEqual(t, id, event.NewID("order.placed", "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE", 2))
+	assert.NotEqual(t, id, event.NewID("order.cancelled", "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE", 1))
+	assert.NotEqual(t, id, event.NewID("order.placed", "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DF", 1))
+}
+
+func TestBus_PublishDeliversToSubscribers(t *testing.T) {
+	bus := event.NewBus()
+	var received []string
+	bus.Subscribe("user.renamed", func(ctx context.Context, e event.Event) error {
+		received = append(received, e.(userRenamed).Name)
+		return nil
+	})
+	bus.Subscribe("user.deleted", func(ctx context.Context, e event.Event) error {
+		t.Fatal("unexpected delivery")
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), userRenamed{Name: "a"}, userRenamed{Name: "b"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, received)
+}
+
+func TestBus_PublishContinuesAfterHandlerError(t *testing.T) {
+	bus := event.NewBus()
+	failure := errors.New("smtp down")
+	delivered := false
+	bus.Subscribe("user.renamed", func(ctx context.Context, e event.Event) error { return failure })
+	bus.Subscribe("user.renamed", func(ctx context.Context, e event.Event) error {
+		delivered = true
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), userRenamed{})
+
+	assert.ErrorIs(t, err, failure)
+	assert.True(t, delivered, "later subscribers must still run")
+}
@@ -0,0 +1,80 @@
+package exchange
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+)
+
+// DefaultECBURL is the daily reference rates of the European Central Bank, published around
+// 16:00 CET on working days
+const DefaultECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECBSource reads the euro reference rates of the European Central Bank; it needs no API key
+type ECBSource struct {
+	url    string
+	client *http.Client
+}
+
+func NewECBSource(url string, client *http.Client) *ECBSource {
+	if url == "" {
+		url = DefaultECBURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ECBSource{url: url, client: client}
+}
+
+// ecbEnvelope is the gesmes envelope of the daily file: Cube > Cube time > Cube currency rate
+type ecbEnvelope struct {
+	Day struct {
+		Date  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+func (s *ECBSource) Latest(ctx context.Context) (money.ExchangeRates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return money.ExchangeRates{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return money.ExchangeRates{}, fmt.Errorf("ecb rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return money.ExchangeRates{}, fmt.Errorf("ecb rates: unexpected status %d", resp.StatusCode)
+	}
+
+	var body ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return money.ExchangeRates{}, fmt.Errorf("ecb rates: %w", err)
+	}
+	date, err := time.Parse(time.DateOnly, body.Day.Date)
+	if err != nil {
+		return money.ExchangeRates{}, fmt.Errorf("ecb rates: invalid date %q", body.Day.Date)
+	}
+
+	rates := money.ExchangeRates{Base: "EUR", Rates: make(map[string]*big.Rat, len(body.Day.Rates)), Date: date}
+	for _, r := range body.Day.Rates {
+		rate, ok := new(big.Rat).SetString(r.Rate)
+		if !ok || rate.Sign() <= 0 {
+			return money.ExchangeRates{}, fmt.Errorf("ecb rates: invalid rate %q for %s", r.Rate, r.Currency)
+		}
+		rates.Rates[r.Currency] = rate
+	}
+	if len(rates.Rates) == 0 {
+		return money.ExchangeRates{}, fmt.Errorf("ecb rates: no rates for %s", body.Day.Date)
+	}
+	return rates, nil
+}
@@ -0,0 +1,147 @@
+// Package exchange converts money between currencies at the rates of a provider such as the
+// European Central Bank or fixer.io. Rates are cached for a TTL, since providers publish them
+// once a day and limit how often they may be fetched.
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/warmup"
+)
+
+// CurrencyParam is the query parameter naming the currency prices are returned in, e.g. ?currency=EUR
+const CurrencyParam = "currency"
+
+// RateSource fetches the latest exchange rates of a provider
+type RateSource interface {
+	Latest(ctx context.Context) (money.ExchangeRates, error)
+}
+
+// Converter implements money.CurrencyConverter with the rates of a RateSource, cached for ttl.
+// When the source fails, the rates fetched last keep being used, so an outage of the provider
+// serves slightly dated prices instead of failing every request that asks for a currency.
+type Converter struct {
+	source   RateSource
+	ttl      time.Duration
+	rounding money.RoundingMode
+	now      func() time.Time
+
+	mu      sync.Mutex
+	rates   *money.ExchangeRates
+	expires time.Time
+}
+
+func NewConverter(source RateSource, ttl time.Duration, rounding money.RoundingMode) *Converter {
+	return &Converter{source: source, ttl: ttl, rounding: rounding, now: time.Now}
+}
+
+var _ warmup.Cache = (*Converter)(nil)
+
+func (c *Converter) Convert(ctx context.Context, m money.Money, to string) (money.Money, error) {
+	if m.Currency == "" || m.Currency == to {
+		return m, nil
+	}
+	rates, err := c.Rates(ctx)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return rates.Convert(m, to, c.rounding)
+}
+
+// Rates returns the cached rates, fetching them first when they expired
+func (c *Converter) Rates(ctx context.Context) (money.ExchangeRates, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rates != nil && c.now().Before(c.expires) {
+		return *c.rates, nil
+	}
+
+	rates, err := c.source.Latest(ctx)
+	if err != nil {
+		if c.rates == nil {
+			return money.ExchangeRates{}, fmt.Errorf("fetch exchange rates: %w", err)
+		}
+		slog.WarnContext(ctx, "fetching exchange rates failed, using the previous ones",
+			"error", err, "rates_date", c.rates.Date.Format(time.DateOnly))
+		return *c.rates, nil
+	}
+	c.rates, c.expires = &rates, c.now().Add(c.ttl)
+	return rates, nil
+}
+
+// Describe and Warm let the rates be fetched at startup by the cache warmer
+func (c *Converter) Describe() string {
+	return "exchange rates"
+}
+
+func (c *Converter) Warm(ctx context.Context) (int, error) {
+	rates, err := c.Rates(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(rates.Rates), nil
+}
+
+// Convert converts m into the currency a caller requested with CurrencyParam. Currencies without
+// a rate, and any currency when c is nil, are reported as validation.Errors of the parameter. An
+// empty to leaves m as it is.
+func Convert(ctx context.Context, c money.CurrencyConverter, m money.Money, to string) (money.Money, error) {
+	if to == "" || m.Currency == "" || m.Currency == to {
+		return m, nil
+	}
+	var errs validation.Errors
+	if c == nil {
+		errs.Add(CurrencyParam, fmt.Sprintf("cannot be %s, prices are only available in %s", to, m.Currency))
+		return money.Money{}, errs
+	}
+	converted, err := c.Convert(ctx, m, to)
+	if errors.Is(err, money.ErrUnknownRate) {
+		errs.Add(CurrencyParam, err.Error())
+		return money.Money{}, errs
+	}
+	return converted, err
+}
+
+type currenciesKey struct{}
+
+// WithCurrencies returns a context limiting the currencies prices may be requested in, e.g. to
+// those a tenant sells in; the first is used when a request names none. An empty list allows
+// every currency.
+func WithCurrencies(ctx context.Context, currencies []string) context.Context {
+	return context.WithValue(ctx, currenciesKey{}, currencies)
+}
+
+// ParseCurrency returns the currency of the currency parameter of r, upper-cased. Without one it
+// returns the first currency bound with WithCurrencies, or "" when none are.
+func ParseCurrency(r *http.Request) (string, error) {
+	allowed, _ := r.Context().Value(currenciesKey{}).([]string)
+	value := r.URL.Query().Get(CurrencyParam)
+	if value == "" {
+		if len(allowed) == 0 {
+			return "", nil
+		}
+		return allowed[0], nil
+	}
+
+	var errs validation.Errors
+	m, err := money.New(0, value)
+	if err != nil {
+		errs.Add(CurrencyParam, fmt.Sprintf("must be an ISO 4217 currency code, got %q", value))
+		return "", errs
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, m.Currency) {
+		errs.Add(CurrencyParam, fmt.Sprintf("must be one of %s, got %s", strings.Join(allowed, ", "), m.Currency))
+		return "", errs
+	}
+	return m.Currency, nil
+}
@@ -0,0 +1,172 @@
+package exchange_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-05-17">
+			<Cube currency="USD" rate="1.0844"/>
+			<Cube currency="JPY" rate="168.86"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBSource_Latest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ecbDaily)
+	}))
+	defer server.Close()
+
+	rates, err := exchange.NewECBSource(server.URL, server.Client()).Latest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", rates.Base)
+	assert.Equal(t, time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC), rates.Date)
+	assert.Equal(t, "1.0844", rates.Rates["USD"].FloatString(4))
+	assert.Equal(t, "168.86", rates.Rates["JPY"].FloatString(2))
+
+	usd, err := rates.Convert(money.Money{Amount: 1000, Currency: "EUR"}, "USD", money.RoundHalfUp)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 1084, Currency: "USD"}, usd)
+}
+
+func TestFixerSource_Latest(t *testing.T) {
+	var key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest", r.URL.Path)
+		key = r.URL.Query().Get("access_key")
+		fmt.Fprint(w, `{"success":true,"base":"EUR","date":"2024-05-17","rates":{"USD":1.0844,"GBP":0.85513}}`)
+	}))
+	defer server.Close()
+
+	rates, err := exchange.NewFixerSource(server.URL, secret.Static("fixer-key"), server.Client()).Latest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fixer-key", key)
+	assert.Equal(t, "EUR", rates.Base)
+	assert.Equal(t, "0.85513", rates.Rates["GBP"].FloatString(5))
+}
+
+func TestFixerSource_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"success":false,"error":{"code":101,"type":"invalid_access_key","info":"You have not supplied a valid API Access Key."}}`)
+	}))
+	defer server.Close()
+
+	_, err := exchange.NewFixerSource(server.URL, secret.Static("wrong"), server.Client()).Latest(context.Background())
+	assert.ErrorContains(t, err, "invalid_access_key")
+}
+
+type fakeSource struct {
+	calls int
+	err   error
+}
+
+func (s *fakeSource) Latest(context.Context) (money.ExchangeRates, error) {
+	s.calls++
+	if s.err != nil {
+		return money.ExchangeRates{}, s.err
+	}
+	return money.ExchangeRates{Base: "EUR", Rates: map[string]*big.Rat{"USD": big.NewRat(5, 4)}}, nil
+}
+
+func TestConverter_CachesRates(t *testing.T) {
+	source := &fakeSource{}
+	converter := exchange.NewConverter(source, time.Hour, money.RoundHalfUp)
+	ctx := context.Background()
+
+	for range 3 {
+		usd, err := converter.Convert(ctx, money.Money{Amount: 1000, Currency: "EUR"}, "USD")
+		assert.NoError(t, err)
+		assert.Equal(t, money.Money{Amount: 1250, Currency: "USD"}, usd)
+	}
+	assert.Equal(t, 1, source.calls)
+
+	// Amounts that need no conversion don't fetch rates
+	same, err := exchange.NewConverter(&fakeSource{err: errors.New("down")}, time.Hour, money.RoundHalfUp).
+		Convert(ctx, money.Money{Amount: 5, Currency: "USD"}, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 5, Currency: "USD"}, same)
+}
+
+func TestConverter_WarmFetchesRatesAhead(t *testing.T) {
+	source := &fakeSource{}
+	converter := exchange.NewConverter(source, time.Hour, money.RoundHalfUp)
+	ctx := context.Background()
+
+	entries, err := converter.Warm(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, entries)
+
+	_, err = converter.Convert(ctx, money.Money{Amount: 1000, Currency: "EUR"}, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, source.calls, "the first conversion uses the warmed rates")
+}
+
+func TestConverter_KeepsRatesWhenSourceFails(t *testing.T) {
+	source := &fakeSource{}
+	converter := exchange.NewConverter(source, time.Nanosecond, money.RoundHalfUp)
+	ctx := context.Background()
+
+	_, err := converter.Rates(ctx)
+	require.NoError(t, err)
+
+	source.err = errors.New("provider down")
+	time.Sleep(time.Millisecond)
+	usd, err := converter.Convert(ctx, money.Money{Amount: 100, Currency: "EUR"}, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 125, Currency: "USD"}, usd)
+	assert.Equal(t, 2, source.calls)
+
+	_, err = exchange.NewConverter(source, time.Hour, money.RoundHalfUp).Rates(ctx)
+	assert.ErrorContains(t, err, "provider down")
+}
+
+func TestParseCurrency(t *testing.T) {
+	currency, err := exchange.ParseCurrency(httptest.NewRequest(http.MethodGet, "/products/prd_1?currency=eur", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", currency)
+
+	currency, err = exchange.ParseCurrency(httptest.NewRequest(http.MethodGet, "/products/prd_1", nil))
+	assert.NoError(t, err)
+	assert.Empty(t, currency)
+
+	_, err = exchange.ParseCurrency(httptest.NewRequest(http.MethodGet, "/products/prd_1?currency=euro", nil))
+	var errs validation.Errors
+	assert.ErrorAs(t, err, &errs)
+}
+
+func TestParseCurrency_WithCurrencies(t *testing.T) {
+	request := func(target string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		return r.WithContext(exchange.WithCurrencies(r.Context(), []string{"USD", "EUR"}))
+	}
+
+	currency, err := exchange.ParseCurrency(request("/products/prd_1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", currency, "the first currency is the default")
+
+	currency, err = exchange.ParseCurrency(request("/products/prd_1?currency=eur"))
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", currency)
+
+	_, err = exchange.ParseCurrency(request("/products/prd_1?currency=GBP"))
+	var errs validation.Errors
+	assert.ErrorAs(t, err, &errs)
+}
@@ -0,0 +1,98 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+)
+
+// DefaultFixerURL is the fixer.io API
+const DefaultFixerURL = "https://data.fixer.io/api"
+
+// FixerSource reads the latest rates of fixer.io; free plans only quote against EUR
+type FixerSource struct {
+	baseURL string
+	apiKey  secret.Source
+	client  *http.Client
+}
+
+func NewFixerSource(baseURL string, apiKey secret.Source, client *http.Client) *FixerSource {
+	if baseURL == "" {
+		baseURL = DefaultFixerURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &FixerSource{baseURL: baseURL, apiKey: apiKey, client: client}
+}
+
+type fixerResponse struct {
+	Success bool                   `json:"success"`
+	Base    string                 `json:"base"`
+	Date    string                 `json:"date"`
+	Rates   map[string]json.Number `json:"rates"`
+	Error   struct {
+		Code int    `json:"code"`
+		Type string `json:"type"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+func (s *FixerSource) Latest(ctx context.Context) (money.ExchangeRates, error) {
+	key, err := s.apiKey.Secret(ctx)
+	if err != nil {
+		return money.ExchangeRates{}, fmt.Errorf("fixer rates: read api key: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/latest?"+url.Values{"access_key": {key}}.Encode(), nil)
+	if err != nil {
+		return money.ExchangeRates{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// The URL carries the key, so it is left out of the error
+		return money.ExchangeRates{}, fmt.Errorf("fixer rates: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return money.ExchangeRates{}, fmt.Errorf("fixer rates: unexpected status %d", resp.StatusCode)
+	}
+
+	// fixer.io reports errors with status 200 and success false
+	var body fixerResponse
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return money.ExchangeRates{}, fmt.Errorf("fixer rates: %w", err)
+	}
+	if !body.Success {
+		return money.ExchangeRates{}, fmt.Errorf("fixer rates: %s (%d): %s", body.Error.Type, body.Error.Code, body.Error.Info)
+	}
+	date, err := time.Parse(time.DateOnly, body.Date)
+	if err != nil {
+		return money.ExchangeRates{}, fmt.Errorf("fixer rates: invalid date %q", body.Date)
+	}
+
+	rates := money.ExchangeRates{Base: body.Base, Rates: make(map[string]*big.Rat, len(body.Rates)), Date: date}
+	for currency, value := range body.Rates {
+		rate, ok := new(big.Rat).SetString(value.String())
+		if !ok || rate.Sign() <= 0 {
+			return money.ExchangeRates{}, fmt.Errorf("fixer rates: invalid rate %q for %s", value, currency)
+		}
+		rates.Rates[currency] = rate
+	}
+	return rates, nil
+}
+
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
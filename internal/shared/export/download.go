@@ -0,0 +1,52 @@
+package export
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+)
+
+// Download is the response of an export. The status and headers are only sent with the first
+// bytes of the file, so an error found before, e.g. by the first query, is still answered with a
+// normal error response.
+type Download struct {
+	w        http.ResponseWriter
+	format   Format
+	filename string
+	started  bool
+}
+
+// NewDownload answers r with a file named name plus the extension of format, e.g. orders.csv
+func NewDownload(w http.ResponseWriter, format Format, name string) *Download {
+	return &Download{w: w, format: format, filename: name + "." + format.Extension()}
+}
+
+func (d *Download) Write(p []byte) (int, error) {
+	if !d.started {
+		d.started = true
+		d.w.Header().Set("Content-Type", d.format.ContentType())
+		d.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.filename}))
+		d.w.WriteHeader(http.StatusOK)
+	}
+	return d.w.Write(p)
+}
+
+// Flush implements http.Flusher
+func (d *Download) Flush() {
+	if f, ok := d.w.(http.Flusher); d.started && ok {
+		f.Flush()
+	}
+}
+
+// Fail answers with err unless the file has started, in which case the download is cut short:
+// clients can tell from the connection closing before the end of the chunked response
+func (d *Download) Fail(r *http.Request, err error) {
+	if !d.started {
+		httpx.WriteError(d.w, err)
+		return
+	}
+	slog.ErrorContext(r.Context(), "export aborted", "file", d.filename, "error", err)
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,202 @@
+// Package export streams large result sets, such as every order of a year, as CSV or JSON Lines
+// downloads. Rows are response structs written as they are read in batches, so memory stays flat
+// however many rows there are.
+//
+// Columns come from the json tags of the row type, so the CSV header matches the keys of the
+// JSON Lines objects. Row types must be flat: their fields are strings, numbers, booleans, times
+// or pointers to them.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// FormatParam is the query parameter choosing the format of an export, e.g. ?format=ndjson
+const FormatParam = "format"
+
+// Format is a file format rows are exported in
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	// FormatNDJSON writes one JSON object per line, also known as JSON Lines
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat reads the format parameter of r; CSV is the default and "jsonl" names JSON Lines too
+func ParseFormat(r *http.Request) (Format, error) {
+	switch value := r.URL.Query().Get(FormatParam); strings.ToLower(value) {
+	case "", "csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	default:
+		var errs validation.Errors
+		errs.Add(FormatParam, fmt.Sprintf("must be csv or ndjson, got %q", value))
+		return "", errs
+	}
+}
+
+// ContentType is the media type of files of the format
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension is the file name extension of the format, without the dot
+func (f Format) Extension() string {
+	if f == FormatNDJSON {
+		return "ndjson"
+	}
+	return "csv"
+}
+
+// Writer encodes rows of T in a format. Rows are buffered until Flush, which also flushes an
+// underlying http.Flusher so the client receives every batch as it is written.
+type Writer[T any] struct {
+	format  Format
+	out     *bufio.Writer
+	dest    io.Writer
+	csv     *csv.Writer
+	json    *json.Encoder
+	columns []column
+	header  bool
+}
+
+// column is a field of the row type and its name in the export
+type column struct {
+	name  string
+	index int
+}
+
+func NewWriter[T any](w io.Writer, format Format) *Writer[T] {
+	out := bufio.NewWriter(w)
+	ew := &Writer[T]{format: format, out: out, dest: w}
+	if format == FormatNDJSON {
+		ew.json = json.NewEncoder(out)
+		return ew
+	}
+	ew.csv = csv.NewWriter(out)
+	ew.columns = columnsOf(reflect.TypeOf((*T)(nil)).Elem())
+	return ew
+}
+
+// columnsOf lists the exported fields of t by json name, skipping those tagged "-"
+func columnsOf(t reflect.Type) []column {
+	var columns []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		columns = append(columns, column{name: name, index: i})
+	}
+	return columns
+}
+
+func (w *Writer[T]) Write(row T) error {
+	if w.json != nil {
+		return w.json.Encode(row)
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(row)
+	record := make([]string, len(w.columns))
+	for i, c := range w.columns {
+		record[i] = cell(v.Field(c.index))
+	}
+	return w.csv.Write(record)
+}
+
+// writeHeader writes the header row once, before the first row or on the first Flush
+func (w *Writer[T]) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	names := make([]string, len(w.columns))
+	for i, c := range w.columns {
+		names[i] = c.name
+	}
+	return w.csv.Write(names)
+}
+
+// Flush sends the rows written so far
+func (w *Writer[T]) Flush() error {
+	if w.csv != nil {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := w.out.Flush(); err != nil {
+		return err
+	}
+	if f, ok := w.dest.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// cell formats a field for CSV; nil pointers and zero times are empty
+func cell(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return neutralize(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return neutralize(fmt.Sprint(v.Interface()))
+	}
+}
+
+// neutralize keeps spreadsheet programs from running text such as a product name "=HYPERLINK(...)"
+// as a formula by prefixing it with an apostrophe
+func neutralize(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package export_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type row struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name,omitempty"`
+	Quantity int        `json:"quantity"`
+	Sandbox  bool       `json:"sandbox"`
+	PlacedAt *time.Time `json:"placed_at,omitempty"`
+	internal string
+	Secret   string `json:"-"`
+}
+
+func TestWriter_CSV(t *testing.T) {
+	var out strings.Builder
+	w := export.NewWriter[row](&out, export.FormatCSV)
+	placed := time.Date(2026, 5, 18, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, w.Write(row{ID: "ord_1", Name: "Desk, oak", Quantity: 2, PlacedAt: &placed, Secret: "x"}))
+	require.NoError(t, w.Write(row{ID: "ord_2", Name: "=HYPERLINK(\"http://evil\")", Quantity: -1}))
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, "id,name,quantity,sandbox,placed_at\n"+
+		"ord_1,\"Desk, oak\",2,false,2026-05-18T12:00:00Z\n"+
+		"ord_2,\"'=HYPERLINK(\"\"http://evil\"\")\",-1,false,\n", out.String())
+}
+
+func TestWriter_CSVWithoutRowsHasHeader(t *testing.T) {
+	var out strings.Builder
+	w := export.NewWriter[row](&out, export.FormatCSV)
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "id,name,quantity,sandbox,placed_at\n", out.String())
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	var out strings.Builder
+	w := export.NewWriter[row](&out, export.FormatNDJSON)
+
+	require.NoError(t, w.Write(row{ID: "ord_1", Quantity: 2}))
+	require.NoError(t, w.Write(row{ID: "ord_2", Name: "Lamp", Quantity: 1, Sandbox: true}))
+	assert.Empty(t, out.String(), "rows are buffered until Flush")
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, `{"id":"ord_1","quantity":2,"sandbox":false}`+"\n"+
+		`{"id":"ord_2","name":"Lamp","quantity":1,"sandbox":true}`+"\n", out.String())
+}
+
+func TestParseFormat(t *testing.T) {
+	for query, want := range map[string]export.Format{"": export.FormatCSV, "?format=jsonl": export.FormatNDJSON, "?format=NDJSON": export.FormatNDJSON} {
+		format, err := export.ParseFormat(httptest.NewRequest(http.MethodGet, "/orders/export"+query, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, want, format, query)
+	}
+	_, err := export.ParseFormat(httptest.NewRequest(http.MethodGet, "/orders/export?format=xlsx", nil))
+	assert.Error(t, err)
+}
+
+func TestDownload(t *testing.T) {
+	rec := httptest.NewRecorder()
+	download := export.NewDownload(rec, export.FormatCSV, "orders")
+	w := export.NewWriter[row](download, export.FormatCSV)
+	require.NoError(t, w.Write(row{ID: "ord_1"}))
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=orders.csv`, rec.Header().Get("Content-Disposition"))
+	assert.True(t, rec.Flushed)
+
+	// Errors before the first bytes are answered like other errors
+	rec = httptest.NewRecorder()
+	download = export.NewDownload(rec, export.FormatNDJSON, "orders")
+	download.Fail(httptest.NewRequest(http.MethodGet, "/orders/export", nil), errors.New("db down"))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Disposition"))
+}
@@ -0,0 +1,57 @@
+// Package geo measures great-circle distances between coordinates, the fallback for databases
+// without earthdistance or PostGIS
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalidPoint = errors.New("invalid coordinates")
+
+// EarthRadiusKm is the mean radius of the earth
+const EarthRadiusKm = 6371.0088
+
+// Point is a WGS 84 coordinate in decimal degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// NewPoint checks the latitude is within ±90 and the longitude within ±180 degrees
+func NewPoint(lat, lng float64) (Point, error) {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return Point{}, fmt.Errorf("%w: latitude %v is not between -90 and 90", ErrInvalidPoint, lat)
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return Point{}, fmt.Errorf("%w: longitude %v is not between -180 and 180", ErrInvalidPoint, lng)
+	}
+	return Point{Lat: lat, Lng: lng}, nil
+}
+
+// Distance returns the haversine distance between a and b in kilometers. Treating the earth as
+// a sphere errs by up to 0.5%, which is fine for ranking locations by distance.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat, dLng := lat2-lat1, radians(b.Lng-a.Lng)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Nearest returns the index of the point closest to origin, or -1 when points is empty; ties go
+// to the earlier point
+func Nearest(origin Point, points []Point) int {
+	nearest, best := -1, math.Inf(1)
+	for i, p := range points {
+		if d := Distance(origin, p); d < best {
+			nearest, best = i, d
+		}
+	}
+	return nearest
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
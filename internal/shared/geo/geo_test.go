@@ -0,0 +1,46 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var (
+	berlin = Point{Lat: 52.5200, Lng: 13.4050}
+	paris  = Point{Lat: 48.8566, Lng: 2.3522}
+	munich = Point{Lat: 48.1351, Lng: 11.5820}
+)
+
+func TestDistance(t *testing.T) {
+	if d := Distance(berlin, paris); math.Abs(d-878) > 5 {
+		t.Errorf("Expected Berlin to Paris to be about 878 km, got %.1f", d)
+	}
+	if d := Distance(berlin, berlin); d != 0 {
+		t.Errorf("Expected no distance to itself, got %v", d)
+	}
+	// Antipodes must not produce NaN from rounding past 1
+	if d := Distance(Point{Lat: 0, Lng: 0}, Point{Lat: 0, Lng: 180}); math.Abs(d-math.Pi*EarthRadiusKm) > 0.001 {
+		t.Errorf("Expected half the circumference, got %v", d)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	if i := Nearest(Point{Lat: 50.1109, Lng: 8.6821}, []Point{berlin, paris, munich}); i != 2 {
+		t.Errorf("Expected Munich to be nearest to Frankfurt, got %d", i)
+	}
+	if i := Nearest(berlin, nil); i != -1 {
+		t.Errorf("Expected -1 without points, got %d", i)
+	}
+}
+
+func TestNewPoint(t *testing.T) {
+	if _, err := NewPoint(52.52, 13.405); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	for _, p := range [][2]float64{{91, 0}, {0, -181}, {math.NaN(), 0}} {
+		if _, err := NewPoint(p[0], p[1]); !errors.Is(err, ErrInvalidPoint) {
+			t.Errorf("Expected ErrInvalidPoint for %v, got %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package httpx
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Document is the subset of the OpenAPI 3 document model produced from the route registry
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)\.{0,3}\}`)
+
+// OpenAPI builds an OpenAPI 3 document describing every registered route
+func (r *Router) OpenAPI(info Info) *Document {
+	gen := &schemaGenerator{schemas: map[string]*Schema{}}
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      map[string]map[string]*Operation{},
+		Components: Components{Schemas: gen.schemas},
+	}
+
+	errorSchema := gen.schemaFor(reflect.TypeOf(ErrorResponse{}))
+
+	for _, route := range r.routes {
+		op := &Operation{
+			Summary:     route.Summary,
+			Tags:        route.Tags,
+			OperationID: operationID(route),
+			Responses:   map[string]*Response{},
+		}
+
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   pathParamSchema(route, match[1]),
+			})
+		}
+
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(gen.schemaFor(reflect.TypeOf(route.Request))),
+			}
+			op.Responses["422"] = &Response{Description: "Validation failed", Content: jsonContent(errorSchema)}
+		}
+
+		success := &Response{Description: http.StatusText(route.Status)}
+		if route.Response != nil {
+			success.Content = jsonContent(gen.schemaFor(reflect.TypeOf(route.Response)))
+		}
+		op.Responses[strconv.Itoa(route.Status)] = success
+		op.Responses["default"] = &Response{Description: "Error", Content: jsonContent(errorSchema)}
+
+		path := pathParamPattern.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return doc
+}
+
+// MountDocs serves the generated document at /openapi.json and a Swagger UI page at /docs
+func (r *Router) MountDocs(info Info) {
+	spec, err := json.MarshalIndent(r.OpenAPI(info), "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal OpenAPI document: %v", err))
+	}
+
+	r.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	})
+	r.mux.HandleFunc("GET /docs", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, swaggerUIPage, info.Title)
+	})
+}
+
+// pathParamSchema documents identifiers ("id", "order_id") as integers, unless the route has string IDs,
+// and everything else as strings
+func pathParamSchema(route Route, name string) *Schema {
+	if name == "id" && route.StringID {
+		return &Schema{Type: "string"}
+	}
+	if name == "id" || strings.HasSuffix(name, "_id") {
+		return &Schema{Type: "integer", Format: "int64"}
+	}
+	return &Schema{Type: "string"}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// operationID derives a stable identifier such as "get_orders_id" from the route
+func operationID(route Route) string {
+	path := pathParamPattern.ReplaceAllString(route.Path, "$1")
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' })
+	return strings.ToLower(route.Method) + "_" + strings.Join(parts, "_")
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	schemaTyperType   = reflect.TypeOf((*schemaTyper)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaTyper is implemented by types encoded as a view of another type, such as the sparse
+// responses of dto.Select, so the document describes the other type
+type schemaTyper interface {
+	SchemaType() reflect.Type
+}
+
+// schemaGenerator converts Go types into JSON schemas, registering named structs as components
+type schemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := g.baseSchema(t)
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+func (g *schemaGenerator) baseSchema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(schemaTyperType):
+		return g.schemaFor(reflect.Zero(t).Interface().(schemaTyper).SchemaType())
+	case t.Kind() == reflect.Struct && t.Implements(jsonMarshalerType):
+		return g.structSchema(t)
+	case t.Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	name := t.Name()
+	if name == "" {
+		return g.objectSchema(t)
+	}
+
+	if _, exists := g.schemas[name]; !exists {
+		// Register a placeholder first so recursive types terminate
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.objectSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *schemaGenerator) objectSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if t.Implements(jsonMarshalerType) {
+		// Custom JSON encoding: properties cannot be inferred from the Go fields
+		return &Schema{Type: "object"}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := g.objectSchema(indirect(field.Type))
+			for prop, propSchema := range embedded.Properties {
+				schema.Properties[prop] = propSchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = g.schemaFor(field.Type)
+		if !omitEmpty && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool) {
+	tag := field.Tag.Get("json")
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
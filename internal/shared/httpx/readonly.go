@@ -0,0 +1,23 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorCodeReadOnly is returned with 503 for writes while the service runs in read-only mode
+const ErrorCodeReadOnly = "read_only"
+
+// ReadOnly rejects every request that may change state, letting GET, HEAD and OPTIONS through
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Retry-After", "60")
+			WriteErrorCode(w, http.StatusServiceUnavailable, ErrorCodeReadOnly,
+				errors.New("the service is temporarily read-only"))
+		}
+	})
+}
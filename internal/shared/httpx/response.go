@@ -0,0 +1,103 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// ErrorResponse is the JSON body returned for every failed request.
+// Code is a stable machine readable identifier clients can branch on, e.g. "email_taken".
+type ErrorResponse struct {
+	Error  string                  `json:"error"`
+	Code   string                  `json:"code"`
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
+
+// Default error codes per status, used when a port does not provide a more specific one
+var defaultCodes = map[int]string{
+	http.StatusBadRequest:          "bad_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusConflict:            "conflict",
+	http.StatusUnprocessableEntity: "validation_failed",
+	http.StatusTooManyRequests:     "too_many_requests",
+	http.StatusInternalServerError: "internal_error",
+}
+
+// WriteJSON serializes v as the JSON response body
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v == nil {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
+
+// WriteError maps shared application errors onto HTTP status codes:
+// validation errors -> 422, not found -> 404, duplicate/foreign key -> 409, anything else -> 500
+func WriteError(w http.ResponseWriter, err error) {
+	var fieldErrs validation.Errors
+	switch {
+	case errors.As(err, &fieldErrs):
+		WriteJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+			Error:  "validation failed",
+			Code:   defaultCodes[http.StatusUnprocessableEntity],
+			Fields: fieldErrs,
+		})
+	case errors.Is(err, persistence.ErrNotFound):
+		WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, persistence.ErrDuplicateKey), errors.Is(err, persistence.ErrForeignKeyViolation):
+		WriteErrorStatus(w, http.StatusConflict, err)
+	default:
+		log.Printf("internal error: %v", err)
+		WriteJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: http.StatusText(http.StatusInternalServerError),
+			Code:  defaultCodes[http.StatusInternalServerError],
+		})
+	}
+}
+
+// WriteErrorStatus writes err with an explicit status code, used by ports for domain specific errors
+func WriteErrorStatus(w http.ResponseWriter, status int, err error) {
+	WriteErrorCode(w, status, defaultCodes[status], err)
+}
+
+// WriteErrorCode writes err with an explicit status code and stable error code
+func WriteErrorCode(w http.ResponseWriter, status int, code string, err error) {
+	WriteJSON(w, status, ErrorResponse{Error: err.Error(), Code: code})
+}
+
+// DecodeJSON decodes the request body into v, rejecting unknown fields.
+// Decoding failures are returned as validation errors so they render as 422.
+func DecodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var errs validation.Errors
+		errs.Add("body", err.Error())
+		return errs
+	}
+	return nil
+}
+
+// PathInt64 parses a positive integer path parameter such as {id}
+func PathInt64(r *http.Request, name string) (int64, error) {
+	value, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || value <= 0 {
+		var errs validation.Errors
+		errs.Add(name, fmt.Sprintf("must be a positive integer, got %q", r.PathValue(name)))
+		return 0, errs
+	}
+	return value, nil
+}
@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Route describes an HTTP endpoint together with the metadata used to generate the OpenAPI document
+type Route struct {
+	Method  string
+	Path    string // net/http pattern path, e.g. "/orders/{id}"
+	Summary string
+	Tags    []string
+
+	// Request is a sample of the JSON request body type; nil when the route has no body
+	Request interface{}
+
+	// Response is a sample of the JSON response body type; nil when the route returns no body
+	Response interface{}
+
+	// Status is the success status code, defaults to 200
+	Status int
+
+	// StringID documents the {id} path parameter as a string, for resources addressed by public or random IDs
+	StringID bool
+
+	// Handler may be nil when routes are registered only to generate documentation
+	Handler http.HandlerFunc
+}
+
+// Router is a thin wrapper over http.ServeMux that keeps a typed registry of its routes
+type Router struct {
+	mux    *http.ServeMux
+	routes []Route
+}
+
+// NewRouter creates an empty router
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// Handle registers a route
+func (r *Router) Handle(route Route) {
+	if route.Status == 0 {
+		route.Status = http.StatusOK
+	}
+	r.routes = append(r.routes, route)
+
+	if route.Handler != nil {
+		r.mux.HandleFunc(route.Method+" "+route.Path, withRouteSpanName(route))
+	}
+}
+
+// Mount serves an operational endpoint (e.g. "GET /metrics") that is not part of the documented API
+func (r *Router) Mount(pattern string, handler http.Handler) {
+	r.mux.Handle(pattern, handler)
+}
+
+// Routes returns the registered routes in registration order
+func (r *Router) Routes() []Route {
+	return append([]Route(nil), r.routes...)
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
+
+// withRouteSpanName names the request span after the route pattern, keeping span names low-cardinality
+func withRouteSpanName(route Route) http.HandlerFunc {
+	name := route.Method + " " + route.Path
+	return func(w http.ResponseWriter, req *http.Request) {
+		span := trace.SpanFromContext(req.Context())
+		span.SetName(name)
+		span.SetAttributes(attribute.String("http.route", route.Path))
+		route.Handler(w, req)
+	}
+}
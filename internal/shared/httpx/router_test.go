@@ -0,0 +1,165 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+type createThingRequest struct {
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags,omitempty"`
+	Owner *int64   `json:"owner_id"`
+}
+
+type thingResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestRouter() *httpx.Router {
+	r := httpx.NewRouter()
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/things",
+		Summary:  "Create a thing",
+		Tags:     []string{"things"},
+		Request:  createThingRequest{},
+		Response: thingResponse{},
+		Status:   http.StatusCreated,
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			var req createThingRequest
+			if err := httpx.DecodeJSON(r, &req); err != nil {
+				httpx.WriteError(w, err)
+				return
+			}
+			httpx.WriteJSON(w, http.StatusCreated, thingResponse{ID: 1, Name: req.Name})
+		},
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/things/{id}",
+		Response: thingResponse{},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			if _, err := httpx.PathInt64(r, "id"); err != nil {
+				httpx.WriteError(w, err)
+				return
+			}
+			httpx.WriteError(w, persistence.TranslateError(errors.New("boom")))
+		},
+	})
+	return r
+}
+
+func TestRouter_ServesRegisteredRoutes(t *testing.T) {
+	router := newTestRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(`{"name":"lamp"}`)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"id":1,"name":"lamp"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(`{"unknown":true}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/things/abc", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/things/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "boom", "internal errors must not leak")
+}
+
+func TestWriteError_StatusMapping(t *testing.T) {
+	var fieldErrs validation.Errors
+	fieldErrs.Add("quantity", "must be greater than 0")
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: fieldErrs, status: http.StatusUnprocessableEntity},
+		{err: persistence.ErrNotFound, status: http.StatusNotFound},
+		{err: persistence.ErrDuplicateKey, status: http.StatusConflict},
+		{err: errors.New("boom"), status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		httpx.WriteError(rec, tt.err)
+		assert.Equal(t, tt.status, rec.Code, tt.err.Error())
+	}
+}
+
+func TestRouter_OpenAPI(t *testing.T) {
+	router := newTestRouter()
+	router.Handle(httpx.Route{Method: http.MethodGet, Path: "/tags/{id}", StringID: true})
+	router.MountDocs(httpx.Info{Title: "Test API", Version: "0.1.0"})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var doc httpx.Document
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	create := doc.Paths["/things"]["post"]
+	assert.NotNil(t, create)
+	assert.Equal(t, "#/components/schemas/createThingRequest", create.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Contains(t, create.Responses, "201")
+	assert.Contains(t, create.Responses, "422")
+
+	get := doc.Paths["/things/{id}"]["get"]
+	assert.Len(t, get.Parameters, 1)
+	assert.Equal(t, "integer", get.Parameters[0].Schema.Type)
+	assert.Equal(t, "string", doc.Paths["/tags/{id}"]["get"].Parameters[0].Schema.Type)
+
+	schema := doc.Components.Schemas["createThingRequest"]
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.Equal(t, "array", schema.Properties["tags"].Type)
+	assert.True(t, schema.Properties["owner_id"].Nullable)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Contains(t, rec.Body.String(), "swagger-ui")
+}
+
+func TestRouter_MountIsNotDocumented(t *testing.T) {
+	r := newTestRouter()
+	r.Mount("GET /metrics", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("up 1"))
+	}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, "up 1", rec.Body.String())
+	for _, route := range r.Routes() {
+		assert.NotEqual(t, "/metrics", route.Path)
+	}
+}
+
+func TestReadOnly_RejectsWrites(t *testing.T) {
+	handler := httpx.ReadOnly(newTestRouter())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(`{"name":"lamp"}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"error":"the service is temporarily read-only","code":"read_only"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/things/abc", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "reads reach the router")
+}
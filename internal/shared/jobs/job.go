@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"time"
+)
+
+// Job is a unit of background work. Jobs are stored as JSON, so implementations should be
+// plain structs; Kind must work on the zero value because handlers are registered per kind.
+type Job interface {
+	// Kind names the job type, e.g. "billing.export_usage"
+	Kind() string
+}
+
+// Status is the state of a persisted job
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	// StatusDead marks jobs that failed on every attempt; they stay in the table for inspection
+	StatusDead Status = "dead"
+)
+
+// Record is a job stored in the queue table
+type Record struct {
+	ID          int64     `gorm:"primaryKey"`
+	Kind        string    `gorm:"type:varchar(100);not null"`
+	Payload     string    `gorm:"type:text;not null"`
+	Status      Status    `gorm:"type:varchar(20);not null;index:idx_jobs_due,priority:1"`
+	RunAt       time.Time `gorm:"not null;index:idx_jobs_due,priority:2"`
+	Attempts    int       `gorm:"not null"`
+	MaxAttempts int       `gorm:"not null"`
+	LastError   string    `gorm:"type:text"`
+	LockedAt    *time.Time
+	// UniqueKey deduplicates enqueues, e.g. of the same scheduled run from several instances
+	UniqueKey *string `gorm:"type:varchar(255);uniqueIndex"`
+	// TenantID is the tenant the job was enqueued for; jobs enqueued outside of a tenant, such as
+	// scheduled ones, leave it empty and run across all tenants
+	TenantID  string `gorm:"type:varchar(64)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Record) TableName() string {
+	return "jobs"
+}
+
+// Backoff computes the delay before retrying a failed job: Base doubled per attempt, capped at Max
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the wait before the next attempt after attempt failures
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := b.Base
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
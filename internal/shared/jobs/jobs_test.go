@@ -0,0 +1,262 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type sendReportJob struct {
+	Email string `json:"email"`
+}
+
+func (sendReportJob) Kind() string {
+	return "test.send_report"
+}
+
+// clock is a settable time source shared by queue, worker and scheduler
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func setupQueue(t *testing.T, maxAttempts int) (*Queue, *clock) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+
+	// A single connection keeps every goroutine on the same in-memory database
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&Record{}))
+
+	c := &clock{now: time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)}
+	queue := NewQueue(db, maxAttempts, 5*time.Minute)
+	queue.now = c.Now
+	return queue, c
+}
+
+func TestQueue_ClaimsDueJobsOnce(t *testing.T) {
+	queue, c := setupQueue(t, 3)
+	ctx := context.Background()
+
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "a@example.com"}))
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "b@example.com"}, RunAt(c.now.Add(time.Hour))))
+
+	claimed, err := queue.Claim(ctx, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, claimed, 1, "only due jobs are claimed") {
+		assert.JSONEq(t, `{"email":"a@example.com"}`, claimed[0].Payload)
+		assert.Equal(t, 1, claimed[0].Attempts)
+	}
+
+	again, err := queue.Claim(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, again, "running jobs are not claimed twice")
+
+	c.now = c.now.Add(6 * time.Minute)
+	reclaimed, err := queue.Claim(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, reclaimed, 1, "jobs abandoned beyond the lease are claimed again")
+}
+
+func TestQueue_UniqueKeyDeduplicates(t *testing.T) {
+	queue, _ := setupQueue(t, 3)
+	ctx := context.Background()
+
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{}, WithUniqueKey("daily@1")))
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{}, WithUniqueKey("daily@1")))
+
+	var count int64
+	assert.NoError(t, queue.db.Model(&Record{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestWorker_RetriesWithBackoffUntilDead(t *testing.T) {
+	queue, c := setupQueue(t, 2)
+	ctx := context.Background()
+	worker := NewWorker(queue, 2, time.Second, Backoff{Base: time.Minute, Max: time.Hour})
+	worker.now = c.Now
+
+	var calls atomic.Int32
+	Register(worker, func(ctx context.Context, job sendReportJob) error {
+		calls.Add(1)
+		return errors.New("smtp unavailable")
+	})
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "a@example.com"}))
+
+	n, err := worker.RunBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var record Record
+	assert.NoError(t, queue.db.First(&record).Error)
+	assert.Equal(t, StatusPending, record.Status)
+	assert.Equal(t, "smtp unavailable", record.LastError)
+	assert.True(t, record.RunAt.Equal(c.now.Add(time.Minute)), "retried after the backoff")
+
+	n, _ = worker.RunBatch(ctx)
+	assert.Equal(t, 0, n, "not due before the backoff elapsed")
+
+	c.now = c.now.Add(time.Minute)
+	_, err = worker.RunBatch(ctx)
+	assert.NoError(t, err)
+
+	assert.NoError(t, queue.db.First(&record).Error)
+	assert.Equal(t, StatusDead, record.Status)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestWorker_CompletesJobsAndRecoversPanics(t *testing.T) {
+	queue, _ := setupQueue(t, 1)
+	ctx := context.Background()
+	worker := NewWorker(queue, 4, time.Second, Backoff{Base: time.Second, Max: time.Minute})
+
+	var sent []string
+	Register(worker, func(ctx context.Context, job sendReportJob) error {
+		if job.Email == "" {
+			panic("missing email")
+		}
+		sent = append(sent, job.Email)
+		return nil
+	})
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "a@example.com"}))
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{}))
+
+	n, err := worker.RunBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"a@example.com"}, sent)
+
+	var statuses []Status
+	assert.NoError(t, queue.db.Model(&Record{}).Order("id").Pluck("status", &statuses).Error)
+	assert.Equal(t, []Status{StatusDone, StatusDead}, statuses)
+}
+
+func TestWorker_RunsJobsInTheTenantTheyWereEnqueuedFor(t *testing.T) {
+	queue, _ := setupQueue(t, 1)
+	ctx := context.Background()
+	worker := NewWorker(queue, 1, time.Second, Backoff{Base: time.Second, Max: time.Minute})
+
+	tenants := map[string]string{}
+	Register(worker, func(ctx context.Context, job sendReportJob) error {
+		if tenant.IsUnscoped(ctx) {
+			tenants[job.Email] = "*"
+		} else {
+			tenants[job.Email] = tenant.FromContext(ctx)
+		}
+		return nil
+	})
+	assert.NoError(t, queue.Enqueue(tenant.WithID(ctx, "acme"), sendReportJob{Email: "a@example.com"}))
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "b@example.com"}))
+
+	for range 2 {
+		_, err := worker.RunBatch(ctx)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, map[string]string{"a@example.com": "acme", "b@example.com": "*"}, tenants, "jobs enqueued outside of a tenant run unscoped")
+}
+
+func TestWorker_DrainReturnsUnfinishedJobsToQueue(t *testing.T) {
+	queue, c := setupQueue(t, 3)
+	ctx := context.Background()
+	worker := NewWorker(queue, 2, time.Hour, Backoff{Base: time.Minute, Max: time.Hour})
+
+	started := make(chan struct{})
+	Register(worker, func(ctx context.Context, job sendReportJob) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "a@example.com"}))
+	worker.Start()
+	<-started
+
+	drainCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, worker.Drain(drainCtx))
+
+	var record Record
+	assert.NoError(t, queue.db.First(&record).Error)
+	assert.Equal(t, StatusPending, record.Status)
+	assert.Equal(t, 0, record.Attempts, "the cut short attempt is not counted")
+	assert.Empty(t, record.LastError)
+	assert.True(t, record.RunAt.Equal(c.now), "due right away")
+}
+
+func TestExclusive_SkipsJobRunningElsewhere(t *testing.T) {
+	queue, _ := setupQueue(t, 3)
+	ctx := context.Background()
+	worker := NewWorker(queue, 1, time.Second, Backoff{Base: time.Second, Max: time.Minute})
+	locks := lock.NewLocal()
+
+	var calls atomic.Int32
+	Register(worker, func(ctx context.Context, job sendReportJob) error {
+		calls.Add(1)
+		return nil
+	})
+	Exclusive[sendReportJob](worker, locks)
+
+	// Another instance runs the job
+	lease, _, err := locks.TryLock(ctx, "job:"+sendReportJob{}.Kind())
+	assert.NoError(t, err)
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "a@example.com"}))
+	_, err = worker.RunBatch(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, calls.Load())
+
+	var record Record
+	assert.NoError(t, queue.db.First(&record).Error)
+	assert.Equal(t, StatusDone, record.Status, "the run elsewhere covers it")
+
+	assert.NoError(t, lease.Release(ctx))
+	assert.NoError(t, queue.Enqueue(ctx, sendReportJob{Email: "b@example.com"}))
+	_, err = worker.RunBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestScheduler_EnqueuesEachRunOnce(t *testing.T) {
+	queue, c := setupQueue(t, 3)
+	ctx := context.Background()
+
+	// Two instances started at different times share the aligned run times
+	first := NewScheduler(queue)
+	first.now = c.Now
+	assert.NoError(t, first.Add("report", "@every 10m", sendReportJob{}))
+	c.now = c.now.Add(3 * time.Minute)
+	second := NewScheduler(queue)
+	second.now = c.Now
+	assert.NoError(t, second.Add("report", "@every 10m", sendReportJob{}))
+
+	c.now = time.Date(2024, 5, 18, 12, 10, 0, 0, time.UTC)
+	first.enqueueDue(ctx, c.now)
+	second.enqueueDue(ctx, c.now)
+
+	var records []Record
+	assert.NoError(t, queue.db.Find(&records).Error)
+	if assert.Len(t, records, 1) {
+		assert.True(t, records[0].RunAt.Equal(c.now))
+	}
+
+	assert.Error(t, first.Add("broken", "not a schedule", sendReportJob{}))
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	assert.Equal(t, time.Second, backoff.Delay(1))
+	assert.Equal(t, 4*time.Second, backoff.Delay(3))
+	assert.Equal(t, 10*time.Second, backoff.Delay(20))
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Queue persists jobs in the jobs table. Workers on any number of instances claim due jobs
+// with SELECT ... FOR UPDATE SKIP LOCKED, so each job runs once at a time.
+type Queue struct {
+	db          *gorm.DB
+	maxAttempts int
+	lease       time.Duration
+	now         func() time.Time
+}
+
+// NewQueue creates a queue retrying jobs up to maxAttempts times. A running job whose worker
+// has not finished it within lease is considered abandoned and claimed again.
+func NewQueue(db *gorm.DB, maxAttempts int, lease time.Duration) *Queue {
+	return &Queue{db: db, maxAttempts: maxAttempts, lease: lease, now: time.Now}
+}
+
+// EnqueueOption customizes a job before it is stored
+type EnqueueOption func(*Record)
+
+// RunAt delays the job until t
+func RunAt(t time.Time) EnqueueOption {
+	return func(r *Record) {
+		r.RunAt = t
+	}
+}
+
+// WithMaxAttempts overrides the queue's attempt limit for this job
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(r *Record) {
+		r.MaxAttempts = n
+	}
+}
+
+// WithUniqueKey drops the enqueue when a job with the same key was enqueued before
+func WithUniqueKey(key string) EnqueueOption {
+	return func(r *Record) {
+		r.UniqueKey = &key
+	}
+}
+
+// Enqueue stores job to be run as soon as it is due
+func (q *Queue) Enqueue(ctx context.Context, job Job, opts ...EnqueueOption) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encode %s job: %w", job.Kind(), err)
+	}
+
+	record := &Record{
+		Kind:        job.Kind(),
+		Payload:     string(payload),
+		Status:      StatusPending,
+		RunAt:       q.now(),
+		MaxAttempts: q.maxAttempts,
+	}
+	if id, ok := tenant.Bound(ctx); ok {
+		record.TenantID = id
+	}
+	for _, opt := range opts {
+		opt(record)
+	}
+
+	err = q.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error
+	return persistence.TranslateError(err)
+}
+
+// Claim marks up to limit due jobs as running and returns them
+func (q *Queue) Claim(ctx context.Context, limit int) ([]Record, error) {
+	now := q.now()
+	var records []Record
+
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				StatusPending, now, StatusRunning, now.Add(-q.lease)).
+			Order("run_at").
+			Limit(limit).
+			Find(&records).Error
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(records))
+		for i := range records {
+			ids[i] = records[i].ID
+			records[i].Status = StatusRunning
+			records[i].Attempts++
+			records[i].LockedAt = &now
+		}
+		return tx.Model(&Record{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":    StatusRunning,
+			"attempts":  gorm.Expr("attempts + 1"),
+			"locked_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return records, nil
+}
+
+// Complete marks a claimed job as done
+func (q *Queue) Complete(ctx context.Context, record Record) error {
+	err := q.db.WithContext(ctx).Model(&Record{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+		"status":    StatusDone,
+		"locked_at": nil,
+	}).Error
+	return persistence.TranslateError(err)
+}
+
+// Release hands claimed jobs that did not finish back to the queue, due now and without
+// counting their attempt, and returns how many it released. Jobs finished or claimed again in
+// the meantime are left alone.
+func (q *Queue) Release(ctx context.Context, records []Record) (int, error) {
+	released := 0
+	for _, record := range records {
+		result := q.db.WithContext(ctx).Model(&Record{}).
+			Where("id = ? AND status = ? AND locked_at = ?", record.ID, StatusRunning, record.LockedAt).
+			Updates(map[string]interface{}{
+				"status":    StatusPending,
+				"run_at":    q.now(),
+				"attempts":  gorm.Expr("attempts - 1"),
+				"locked_at": nil,
+			})
+		if result.Error != nil {
+			return released, persistence.TranslateError(result.Error)
+		}
+		released += int(result.RowsAffected)
+	}
+	return released, nil
+}
+
+// Fail records jobErr and schedules the job again at retryAt, or marks it dead when it has no attempts left
+func (q *Queue) Fail(ctx context.Context, record Record, jobErr error, retryAt time.Time) error {
+	updates := map[string]interface{}{
+		"status":     StatusPending,
+		"run_at":     retryAt,
+		"last_error": jobErr.Error(),
+		"locked_at":  nil,
+	}
+	if record.Attempts >= record.MaxAttempts {
+		updates["status"] = StatusDead
+	}
+
+	err := q.db.WithContext(ctx).Model(&Record{}).Where("id = ?", record.ID).Updates(updates).Error
+	return persistence.TranslateError(err)
+}
@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Scheduler enqueues recurring jobs on cron schedules. Every run is enqueued with a unique key
+// derived from its scheduled time, so instances running the same schedules enqueue it once.
+type Scheduler struct {
+	queue *Queue
+	now   func() time.Time
+
+	mu      sync.Mutex
+	entries []*scheduleEntry
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type scheduleEntry struct {
+	name     string
+	schedule cron.Schedule
+	job      Job
+	next     time.Time
+}
+
+func NewScheduler(queue *Queue) *Scheduler {
+	return &Scheduler{queue: queue, now: time.Now}
+}
+
+// Add schedules job under a unique name. spec is a five field cron expression, a descriptor
+// such as "@hourly", or "@every <duration>"; intervals are aligned to the clock, not to startup.
+func (s *Scheduler) Add(name, spec string, job Job) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", name, err)
+	}
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		schedule = alignedEvery(every.Delay)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, &scheduleEntry{name: name, schedule: schedule, job: job, next: schedule.Next(s.now())})
+	return nil
+}
+
+// Start checks the schedules every second until Stop
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.enqueueDue(context.Background(), s.now())
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
+
+// enqueueDue enqueues the jobs whose next run is at or before now. Runs missed while the
+// scheduler was down are not caught up.
+func (s *Scheduler) enqueueDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		if now.Before(entry.next) {
+			continue
+		}
+
+		key := fmt.Sprintf("%s@%d", entry.name, entry.next.Unix())
+		if err := s.queue.Enqueue(ctx, entry.job, RunAt(entry.next), WithUniqueKey(key)); err != nil {
+			slog.ErrorContext(ctx, "enqueueing scheduled job failed", "schedule", entry.name, "error", err)
+			continue
+		}
+		entry.next = entry.schedule.Next(now)
+	}
+}
+
+// alignedEvery fires at multiples of the interval since the zero time, so every instance
+// computes the same run times
+type alignedEvery time.Duration
+
+func (e alignedEvery) Next(t time.Time) time.Time {
+	interval := time.Duration(e)
+	return t.Truncate(interval).Add(interval)
+}
@@ -0,0 +1,235 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+)
+
+// HandlerFunc runs a job from its stored JSON payload
+type HandlerFunc func(ctx context.Context, payload []byte) error
+
+// Worker runs due jobs from a queue on a bounded pool of goroutines. Jobs run at least once:
+// a job whose worker dies is claimed again after the queue lease, so handlers must be idempotent.
+type Worker struct {
+	queue        *Queue
+	concurrency  int
+	pollInterval time.Duration
+	backoff      Backoff
+	now          func() time.Time
+
+	handlers map[string]HandlerFunc
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// abandoned ends once a drain runs out of time, cancelling the context of the running jobs
+	abandoned context.Context
+	abandon   context.CancelFunc
+
+	mu      sync.Mutex
+	running map[int64]Record
+}
+
+func NewWorker(queue *Queue, concurrency int, pollInterval time.Duration, backoff Backoff) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	abandoned, abandon := context.WithCancel(context.Background())
+	return &Worker{
+		queue:        queue,
+		concurrency:  concurrency,
+		pollInterval: pollInterval,
+		backoff:      backoff,
+		now:          time.Now,
+		handlers:     make(map[string]HandlerFunc),
+		abandoned:    abandoned,
+		abandon:      abandon,
+		running:      make(map[int64]Record),
+	}
+}
+
+// Register adds the handler of jobs of type J; J must be a struct whose Kind has a value receiver
+func Register[J Job](w *Worker, handle func(ctx context.Context, job J) error) {
+	var zero J
+	w.handlers[zero.Kind()] = func(ctx context.Context, payload []byte) error {
+		var job J
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return fmt.Errorf("decode %s job: %w", zero.Kind(), err)
+		}
+		return handle(ctx, job)
+	}
+}
+
+// Exclusive runs jobs of type J, registered before, on one instance at a time. A job claimed
+// while another instance runs one is done right away, so it only suits sweeps such as releasing
+// expired reservations, where the run in progress covers the skipped one.
+func Exclusive[J Job](w *Worker, locks lock.DistributedLock) {
+	var zero J
+	kind := zero.Kind()
+	handle, ok := w.handlers[kind]
+	if !ok {
+		panic(fmt.Sprintf("jobs: no handler registered for job kind %q", kind))
+	}
+	w.handlers[kind] = func(ctx context.Context, payload []byte) error {
+		ran, err := lock.Do(ctx, locks, "job:"+kind, func(ctx context.Context) error {
+			return handle(ctx, payload)
+		})
+		if err == nil && !ran {
+			slog.InfoContext(ctx, "skipping job running on another instance", "kind", kind)
+		}
+		return err
+	}
+}
+
+// Start polls the queue in the background until Stop
+func (w *Worker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			// Keep going without waiting while the queue has a backlog
+			n, err := w.RunBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("claiming jobs failed", "error", err)
+			}
+			if n == w.concurrency {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops polling and waits for the running batch to finish
+func (w *Worker) Stop() {
+	_ = w.Drain(context.Background())
+}
+
+// Drain stops polling and waits for the running batch to finish. When ctx ends first, the
+// context of the running jobs is cancelled and they go back to the queue, due right away and
+// without counting the attempt, so another worker picks them up instead of waiting for the lease.
+// Jobs handed back are not failures; Drain only fails when the queue cannot take them back.
+func (w *Worker) Drain(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.abandon()
+	w.mu.Lock()
+	records := make([]Record, 0, len(w.running))
+	for _, record := range w.running {
+		records = append(records, record)
+	}
+	w.mu.Unlock()
+
+	released, err := w.queue.Release(context.WithoutCancel(ctx), records)
+	if err != nil {
+		return fmt.Errorf("return %d running jobs to the queue: %w", len(records), err)
+	}
+	slog.WarnContext(ctx, "job drain timed out, running jobs returned to the queue", "jobs", released)
+	return nil
+}
+
+// RunBatch claims up to the worker's concurrency of due jobs, runs them in parallel and
+// returns once all of them finished. Running jobs are not cancelled with ctx.
+func (w *Worker) RunBatch(ctx context.Context) (int, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	records, err := w.queue.Claim(ctx, w.concurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	defer context.AfterFunc(w.abandoned, cancel)()
+
+	var wg sync.WaitGroup
+	for _, record := range records {
+		w.mu.Lock()
+		w.running[record.ID] = record
+		w.mu.Unlock()
+
+		wg.Add(1)
+		go func(record Record) {
+			defer wg.Done()
+			defer func() {
+				w.mu.Lock()
+				delete(w.running, record.ID)
+				w.mu.Unlock()
+			}()
+			w.run(jobCtx, record)
+		}(record)
+	}
+	wg.Wait()
+	return len(records), nil
+}
+
+func (w *Worker) run(ctx context.Context, record Record) {
+	err := w.handle(ctx, record)
+	// The outcome is stored even when a drain cancelled the job
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		if err := w.queue.Complete(ctx, record); err != nil {
+			slog.ErrorContext(ctx, "completing job failed", "job_id", record.ID, "kind", record.Kind, "error", err)
+		}
+		return
+	}
+	if w.abandoned.Err() != nil {
+		// The drain handed the job back to the queue
+		return
+	}
+
+	slog.ErrorContext(ctx, "job failed", "job_id", record.ID, "kind", record.Kind,
+		"attempt", record.Attempts, "max_attempts", record.MaxAttempts, "error", err)
+	retryAt := w.now().Add(w.backoff.Delay(record.Attempts))
+	if err := w.queue.Fail(ctx, record, err, retryAt); err != nil {
+		slog.ErrorContext(ctx, "recording job failure failed", "job_id", record.ID, "kind", record.Kind, "error", err)
+	}
+}
+
+// handle dispatches the job to its handler, turning panics into errors so the job is retried
+func (w *Worker) handle(ctx context.Context, record Record) (err error) {
+	handler, ok := w.handlers[record.Kind]
+	if !ok {
+		return fmt.Errorf("no handler registered for job kind %q", record.Kind)
+	}
+
+	if record.TenantID != "" {
+		ctx = tenant.WithID(ctx, record.TenantID)
+	} else {
+		ctx = tenant.Unscoped(ctx)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, []byte(record.Payload))
+}
@@ -0,0 +1,108 @@
+// Package lifecycle shuts the components of the process down in order once it is asked to stop.
+// Components register a stop function as they are started; Shutdown runs them last-registered
+// first, like deferred calls, so a component stops before the ones it was built on.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Manager holds the stop functions of the running components
+type Manager struct {
+	// Observer, when set, is told how long every component took to stop
+	Observer Observer
+
+	mu    sync.Mutex
+	hooks []hook
+}
+
+// Observer records the progress of a shutdown, e.g. metrics.Shutdown
+type Observer interface {
+	// Stopped is called once a component stopped, or with the error it failed with
+	Stopped(component string, duration time.Duration, err error)
+}
+
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+// OnShutdown registers the stop function of a component. The function should return once the
+// component finished its in-flight work, or when ctx ends.
+func (m *Manager) OnShutdown(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, stop: stop})
+}
+
+// Shutdown stops the registered components in reverse order. A component that fails or runs
+// out of time does not keep the others from stopping; once ctx ends the remaining ones get no
+// time to drain. The errors are joined, and later calls do nothing.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		start := time.Now()
+		err := h.stop(ctx)
+		duration := time.Since(start)
+		if m.Observer != nil {
+			m.Observer.Stopped(h.name, duration, err)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "stopping component failed", "component", h.name, "duration", duration, "error", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.name, err))
+			continue
+		}
+		slog.InfoContext(ctx, "component stopped", "component", h.name, "duration", duration)
+	}
+	return errors.Join(errs...)
+}
+
+// Within gives stop at most d of the shutdown, keeping the rest for the components stopping
+// after it, e.g. for the outbox relay to flush what the job worker stored
+func Within(d time.Duration, stop func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return stop(ctx)
+	}
+}
+
+// Wait adapts a blocking stop function without a context. It returns ctx.Err() when ctx ends
+// first, leaving stop to finish in the background.
+func Wait(stop func()) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			stop()
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close adapts a Close method such as the one of a database handle or a broker connection
+func Close(close func() error) func(ctx context.Context) error {
+	return func(context.Context) error {
+		return close()
+	}
+}
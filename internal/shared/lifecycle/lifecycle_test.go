@@ -0,0 +1,109 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdown_StopsInReverseOrder(t *testing.T) {
+	m := New()
+	var stopped []string
+	for _, name := range []string{"database", "worker", "http"} {
+		m.OnShutdown(name, func(context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		})
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := "http,worker,database"; strings.Join(stopped, ",") != want {
+		t.Errorf("Expected %s, got %v", want, stopped)
+	}
+
+	// A second shutdown, such as a deferred one, does nothing
+	if err := m.Shutdown(context.Background()); err != nil || len(stopped) != 3 {
+		t.Errorf("Expected the components to stop once, got %v and %v", stopped, err)
+	}
+}
+
+func TestShutdown_KeepsStoppingAfterFailure(t *testing.T) {
+	m := New()
+	closed := false
+	m.OnShutdown("database", Close(func() error {
+		closed = true
+		return nil
+	}))
+	boom := errors.New("boom")
+	m.OnShutdown("relay", func(context.Context) error { return boom })
+
+	err := m.Shutdown(context.Background())
+	if !errors.Is(err, boom) {
+		t.Errorf("Expected the relay error, got %v", err)
+	}
+	if !closed {
+		t.Error("Expected the database to close after the relay failed")
+	}
+}
+
+func TestWait_GivesUpAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	m := New()
+	m.OnShutdown("worker", Wait(func() { <-release }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the drain timeout, got %v", err)
+	}
+}
+
+type recordingObserver struct {
+	stopped map[string]error
+}
+
+func (o *recordingObserver) Stopped(component string, duration time.Duration, err error) {
+	o.stopped[component] = err
+}
+
+func TestShutdown_ReportsEveryComponent(t *testing.T) {
+	observer := &recordingObserver{stopped: make(map[string]error)}
+	m := New()
+	m.Observer = observer
+	boom := errors.New("boom")
+	m.OnShutdown("database", func(context.Context) error { return nil })
+	m.OnShutdown("relay", func(context.Context) error { return boom })
+
+	_ = m.Shutdown(context.Background())
+
+	if len(observer.stopped) != 2 || observer.stopped["database"] != nil || observer.stopped["relay"] != boom {
+		t.Errorf("Expected both components reported, got %v", observer.stopped)
+	}
+}
+
+func TestWithin_LeavesTimeForLaterComponents(t *testing.T) {
+	m := New()
+	var relayDeadline time.Time
+	m.OnShutdown("relay", func(ctx context.Context) error {
+		relayDeadline, _ = ctx.Deadline()
+		return ctx.Err()
+	})
+	m.OnShutdown("worker", Within(10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected the relay to get time after the worker, got %v", err)
+	}
+	if time.Until(relayDeadline) < 50*time.Second {
+		t.Errorf("Expected the relay to keep the shutdown deadline, got %s", relayDeadline)
+	}
+}
@@ -0,0 +1,106 @@
+// Package lock coordinates work that must run on one instance at a time, such as the outbox
+// relay or a sweep of expired reservations, when several instances of the service run.
+//
+// DistributedLock is the port; Postgres implements it with advisory locks, which need nothing
+// beyond the database every instance already shares, Redis with expiring keys for deployments
+// that keep locks off the database, and Local for a single process, e.g. with SQLite. Locks are tried, not waited for: work that finds its lock taken is skipped because
+// another instance is already doing it.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// DistributedLock hands out named locks held by at most one holder across all instances
+type DistributedLock interface {
+	// TryLock takes the lock called name without waiting; ok is false when another holder has it
+	TryLock(ctx context.Context, name string) (lease Lease, ok bool, err error)
+}
+
+// Lease is a held lock
+type Lease interface {
+	// Release gives the lock up; it must be called once the work is done
+	Release(ctx context.Context) error
+	// Lost is closed when the lock is lost before it is released, after which another holder may
+	// take it; it is nil for locks that can't be lost
+	Lost() <-chan struct{}
+}
+
+// ErrLost is the cause of the ctx Do cancels when the lock is lost while fn runs
+var ErrLost = errors.New("lock lost")
+
+// Do runs fn while holding the lock called name and reports whether it ran; it does not run
+// when another holder has the lock. The ctx of fn is cancelled with ErrLost should the lock be
+// lost, so the work stops before another holder starts it again.
+func Do(ctx context.Context, l DistributedLock, name string, fn func(ctx context.Context) error) (bool, error) {
+	lease, ok, err := l.TryLock(ctx, name)
+	if err != nil {
+		return false, fmt.Errorf("lock %s: %w", name, err)
+	}
+	if !ok {
+		return false, nil
+	}
+	defer func() {
+		// The lock outlives a cancelled ctx unless released anyway
+		if err := lease.Release(context.WithoutCancel(ctx)); err != nil {
+			slog.ErrorContext(ctx, "releasing lock failed", "lock", name, "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-lease.Lost():
+			cancel(ErrLost)
+		case <-ctx.Done():
+		}
+	}()
+	if err := fn(ctx); err != nil {
+		if errors.Is(context.Cause(ctx), ErrLost) {
+			return true, fmt.Errorf("%w: %s: %w", ErrLost, name, err)
+		}
+		return true, err
+	}
+	return true, nil
+}
+
+// Local is a DistributedLock for a single process, such as local runs on SQLite and tests
+type Local struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func NewLocal() *Local {
+	return &Local{held: make(map[string]bool)}
+}
+
+func (l *Local) TryLock(ctx context.Context, name string) (Lease, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return localLease{local: l, name: name}, true, nil
+}
+
+type localLease struct {
+	local *Local
+	name  string
+}
+
+func (localLease) Lost() <-chan struct{} {
+	return nil
+}
+
+func (l localLease) Release(context.Context) error {
+	l.local.mu.Lock()
+	defer l.local.mu.Unlock()
+	delete(l.local.held, l.name)
+	return nil
+}
@@ -0,0 +1,74 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo_SkipsWhileHeld(t *testing.T) {
+	locks := lock.NewLocal()
+	ctx := context.Background()
+
+	var nested bool
+	ran, err := lock.Do(ctx, locks, "relay", func(ctx context.Context) error {
+		// A second holder, e.g. another instance, finds the lock taken
+		nested, _ = lock.Do(ctx, locks, "relay", func(context.Context) error { return nil })
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.False(t, nested)
+
+	ran, err = lock.Do(ctx, locks, "relay", func(context.Context) error { return errors.New("broker down") })
+	assert.True(t, ran, "the lock is released after each run")
+	assert.EqualError(t, err, "broker down")
+	ran, _ = lock.Do(ctx, locks, "relay", func(context.Context) error { return nil })
+	assert.True(t, ran, "the lock is released after a failed run too")
+}
+
+func TestLocal_LocksAreNamed(t *testing.T) {
+	locks := lock.NewLocal()
+	ctx := context.Background()
+
+	_, ok, _ := locks.TryLock(ctx, "job:a")
+	assert.True(t, ok)
+	_, ok, _ = locks.TryLock(ctx, "job:b")
+	assert.True(t, ok, "other names are free")
+	_, ok, _ = locks.TryLock(ctx, "job:a")
+	assert.False(t, ok)
+}
+
+// losableLock hands out leases the test can lose, as an expired Redis key would be
+type losableLock struct {
+	lost chan struct{}
+}
+
+func (l losableLock) TryLock(context.Context, string) (lock.Lease, bool, error) {
+	return l, true, nil
+}
+
+func (l losableLock) Release(context.Context) error {
+	return nil
+}
+
+func (l losableLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func TestDo_CancelsWorkWhenTheLockIsLost(t *testing.T) {
+	locks := losableLock{lost: make(chan struct{})}
+
+	ran, err := lock.Do(context.Background(), locks, "relay", func(ctx context.Context) error {
+		close(locks.lost)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.True(t, ran)
+	assert.ErrorIs(t, err, lock.ErrLost)
+	assert.ErrorIs(t, err, context.Canceled)
+}
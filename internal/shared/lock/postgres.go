@@ -0,0 +1,68 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+)
+
+// Postgres takes session-level advisory locks. A lease keeps a connection of the pool for as
+// long as it is held, so the lock is released by the database should the process die.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) TryLock(ctx context.Context, name string) (Lease, bool, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	key := advisoryKey(name)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+	return &postgresLease{conn: conn, key: key}, true, nil
+}
+
+type postgresLease struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// Lost is nil: the session keeps the lock for as long as the connection of the lease is open
+func (l *postgresLease) Lost() <-chan struct{} {
+	return nil
+}
+
+func (l *postgresLease) Release(ctx context.Context) error {
+	defer l.conn.Close()
+	var ok bool
+	if err := l.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&ok); err != nil {
+		// Discard the session rather than return it to the pool still holding the lock
+		_ = l.conn.Raw(func(any) error { return driver.ErrBadConn })
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("advisory lock %d was not held", l.key)
+	}
+	return nil
+}
+
+// advisoryKey maps a lock name onto the 64-bit key space of advisory locks
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,188 @@
+package lock
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis takes locks with SET NX on a single Redis instance, the redsync algorithm without the
+// quorum over several masters. Each lock holds a random token and expires after the TTL, so a
+// lock whose holder died frees itself; a held lease extends it every third of the TTL and reports
+// it lost once it can't, see keepAlive.
+//
+// Locks are taken a few times a minute, so each command dials its own connection rather than
+// keeping a pool.
+type Redis struct {
+	addr     string
+	password string
+	db       int
+	ttl      time.Duration
+	dialer   net.Dialer
+}
+
+func NewRedis(addr, password string, db int, ttl time.Duration) *Redis {
+	return &Redis{addr: addr, password: password, db: db, ttl: ttl, dialer: net.Dialer{Timeout: 5 * time.Second}}
+}
+
+// The scripts only touch the key while it still holds the token of the lease, so a lease whose
+// lock expired and was taken by another holder can't release or extend that holder's lock
+const (
+	releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+	extendScript  = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+)
+
+func (r *Redis) TryLock(ctx context.Context, name string) (Lease, bool, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, false, err
+	}
+	lease := &redisLease{redis: r, key: "lock:" + name, token: hex.EncodeToString(token), stop: make(chan struct{}), done: make(chan struct{}), lost: make(chan struct{})}
+
+	reply, err := r.do(ctx, "SET", lease.key, lease.token, "NX", "PX", r.millis())
+	if err != nil {
+		return nil, false, err
+	}
+	// SET NX replies nil when the key is taken
+	if reply == nil {
+		return nil, false, nil
+	}
+	go lease.keepAlive()
+	return lease, true, nil
+}
+
+func (r *Redis) millis() string {
+	return strconv.FormatInt(r.ttl.Milliseconds(), 10)
+}
+
+type redisLease struct {
+	redis *Redis
+	key   string
+	token string
+	stop  chan struct{}
+	done  chan struct{}
+	lost  chan struct{}
+}
+
+// keepAlive extends the lock until the lease is released. It closes lost and gives up once the
+// key no longer holds the token, or once Redis was unreachable for the TTL, by when the key has
+// expired.
+func (l *redisLease) keepAlive() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.redis.ttl / 3)
+	defer ticker.Stop()
+	extended := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), l.redis.ttl/3)
+		reply, err := l.redis.do(ctx, "EVAL", extendScript, "1", l.key, l.token, l.redis.millis())
+		cancel()
+		if err != nil {
+			slog.Error("extending lock failed", "lock", l.key, "error", err)
+			if time.Since(extended) >= l.redis.ttl {
+				close(l.lost)
+				return
+			}
+			continue
+		}
+		if reply == int64(0) {
+			slog.Error("lock expired while held", "lock", l.key)
+			close(l.lost)
+			return
+		}
+		extended = time.Now()
+	}
+}
+
+func (l *redisLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *redisLease) Release(ctx context.Context) error {
+	close(l.stop)
+	<-l.done
+	_, err := l.redis.do(ctx, "EVAL", releaseScript, "1", l.key, l.token)
+	return err
+}
+
+// do runs one command on a new connection and returns its reply: a string, an int64, or nil
+func (r *Redis) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := r.dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if r.password != "" {
+		if _, err := command(rw, "AUTH", r.password); err != nil {
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := command(rw, "SELECT", strconv.Itoa(r.db)); err != nil {
+			return nil, err
+		}
+	}
+	return command(rw, args...)
+}
+
+// command writes args as a RESP array of bulk strings and reads the reply
+func command(rw *bufio.ReadWriter, args ...string) (any, error) {
+	fmt.Fprintf(rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+
+	line, err := rw.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch payload := line[1:]; line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rw, buf); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return string(buf[:n]), nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,153 @@
+package lock_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis answers the commands of lock.Redis from a map, ignoring expiry
+type fakeRedis struct {
+	mu       sync.Mutex
+	keys     map[string]string
+	extended int
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeRedis{keys: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		fmt.Fprint(conn, f.reply(args))
+	}
+}
+
+func (f *fakeRedis) reply(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch args[0] {
+	case "SET":
+		if _, taken := f.keys[args[1]]; taken {
+			return "$-1\r\n"
+		}
+		f.keys[args[1]] = args[2]
+		return "+OK\r\n"
+	case "EVAL":
+		key, token := args[3], args[4]
+		if f.keys[key] != token {
+			return ":0\r\n"
+		}
+		if strings.Contains(args[1], "pexpire") {
+			f.extended++
+		} else {
+			delete(f.keys, key)
+		}
+		return ":1\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedis_LocksAreHeldByOneLeaseAndExtended(t *testing.T) {
+	server, addr := startFakeRedis(t)
+	locks := lock.NewRedis(addr, "", 0, 30*time.Millisecond)
+	ctx := context.Background()
+
+	lease, ok, err := locks.TryLock(ctx, "relay")
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = lock.NewRedis(addr, "", 0, time.Second).TryLock(ctx, "relay")
+	require.NoError(t, err)
+	assert.False(t, ok, "another instance finds the lock taken")
+
+	assert.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return server.extended >= 2
+	}, time.Second, 5*time.Millisecond, "the held lock is extended before it expires")
+
+	require.NoError(t, lease.Release(ctx))
+	_, ok, err = locks.TryLock(ctx, "relay")
+	require.NoError(t, err)
+	assert.True(t, ok, "the released lock is free")
+}
+
+func TestRedis_LeaseReportsAnExpiredLockLost(t *testing.T) {
+	server, addr := startFakeRedis(t)
+	lease, ok, err := lock.NewRedis(addr, "", 0, 30*time.Millisecond).TryLock(context.Background(), "relay")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The key expires, e.g. while the holder was paused for longer than the TTL
+	server.mu.Lock()
+	delete(server.keys, "lock:relay")
+	server.mu.Unlock()
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("the lease was not reported lost")
+	}
+}
+
+func TestRedis_ReportsUnreachableServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, ok, err := lock.NewRedis(addr, "", 0, time.Second).TryLock(context.Background(), "relay")
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "redis:")
+}
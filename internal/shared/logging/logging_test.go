@@ -0,0 +1,56 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/logging"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew_InjectsTraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "json", "info").With("component", "test")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.InfoContext(ctx, "order placed")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+	assert.Equal(t, "test", record["component"])
+}
+
+func TestNew_WithoutSpan(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "json", "warn")
+
+	logger.Info("dropped")
+	logger.WarnContext(context.Background(), "kept")
+
+	assert.NotContains(t, buf.String(), "dropped")
+	assert.Contains(t, buf.String(), `"msg":"kept"`)
+	assert.NotContains(t, buf.String(), "trace_id")
+}
+
+func TestNew_InjectsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "json", "info")
+
+	logger.InfoContext(logging.WithRequestID(context.Background(), "req-42"), "order placed")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "req-42", record["request_id"])
+}
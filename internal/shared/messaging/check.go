@@ -0,0 +1,31 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PingType is the type of the message PublishCheck publishes; consumers skip it like every type
+// they have no handler for
+const PingType = "selftest.ping"
+
+// PublishCheck verifies the broker accepts messages on Topic, for selftest.Suite
+type PublishCheck struct {
+	Publisher Publisher
+	Topic     string
+}
+
+func (c PublishCheck) Describe() string {
+	return "broker publish to " + c.Topic
+}
+
+func (c PublishCheck) Check(ctx context.Context) error {
+	now := time.Now()
+	return c.Publisher.Publish(ctx, c.Topic, Message{
+		ID:      fmt.Sprintf("selftest-%d", now.UnixNano()),
+		Type:    PingType,
+		Payload: []byte(`{}`),
+		Time:    now,
+	})
+}
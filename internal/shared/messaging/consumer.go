@@ -0,0 +1,118 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+)
+
+// Consumer is the harness services use to react to the events of a topic: it subscribes as a
+// group, dispatches every message to the handler registered for its type and retries failing
+// handlers with backoff. Messages without a handler are acknowledged and skipped. With
+// Parallelism set, messages of different keys are handled concurrently.
+//
+//	c := messaging.NewConsumer(subscriber, "order-events", "shipping")
+//	messaging.On(c, "order.placed", func(ctx context.Context, placed orderPort.OrderPlacedMessage, msg messaging.Message) error {
+//		return shipping.Prepare(ctx, placed.OrderID)
+//	})
+//	err := c.Run(ctx)
+type Consumer struct {
+	subscriber Subscriber
+	topic      string
+	group      string
+	handlers   map[string]Handler
+
+	// MaxAttempts is how often a message is tried before it is logged and dropped; zero retries
+	// until the handler succeeds, holding back the messages after it on the same worker
+	MaxAttempts int
+	Backoff     jobs.Backoff
+	// Parallelism handles the messages of different keys concurrently, see Parallelism
+	Parallelism Parallelism
+	// Observer, when set, is told about every message handled, e.g. metrics.Consumer
+	Observer ConsumerObserver
+}
+
+// ConsumerObserver records how far a consumer lags behind its topic
+type ConsumerObserver interface {
+	// Handled is called once a message published at published, whose handling started at
+	// started, is handled, skipped or dropped, or with the error that ended its retries
+	Handled(published, started time.Time, err error)
+}
+
+func NewConsumer(subscriber Subscriber, topic, group string) *Consumer {
+	return &Consumer{
+		subscriber: subscriber,
+		topic:      topic,
+		group:      group,
+		handlers:   make(map[string]Handler),
+		Backoff:    jobs.Backoff{Base: time.Second, Max: time.Minute},
+	}
+}
+
+// Handle registers the handler of a message type, replacing an earlier one
+func (c *Consumer) Handle(msgType string, handler Handler) {
+	c.handlers[msgType] = handler
+}
+
+// On registers a handler receiving the payload of msgType decoded into T. A payload that does not
+// decode can never succeed, so it is logged and dropped instead of retried.
+func On[T any](c *Consumer, msgType string, handle func(ctx context.Context, payload T, msg Message) error) {
+	c.Handle(msgType, func(ctx context.Context, msg Message) error {
+		var payload T
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			slog.ErrorContext(ctx, "dropping undecodable message", "topic", c.topic, "type", msg.Type, "id", msg.ID, "error", err)
+			return nil
+		}
+		return handle(ctx, payload, msg)
+	})
+}
+
+// Run consumes until ctx is done or the subscription fails
+func (c *Consumer) Run(ctx context.Context) error {
+	err := c.subscriber.SubscribeParallel(ctx, c.topic, c.group, c.Parallelism, c.dispatch)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("consume %s as %s: %w", c.topic, c.group, err)
+	}
+	return nil
+}
+
+func (c *Consumer) dispatch(ctx context.Context, msg Message) error {
+	if c.Observer == nil {
+		return c.handle(ctx, msg)
+	}
+	started := time.Now()
+	err := c.handle(ctx, msg)
+	c.Observer.Handled(msg.Time, started, err)
+	return err
+}
+
+func (c *Consumer) handle(ctx context.Context, msg Message) error {
+	handler, ok := c.handlers[msg.Type]
+	if !ok {
+		slog.DebugContext(ctx, "skipping message without handler", "topic", c.topic, "type", msg.Type, "id", msg.ID)
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		if c.MaxAttempts > 0 && attempt >= c.MaxAttempts {
+			slog.ErrorContext(ctx, "dropping message after failed attempts", "topic", c.topic, "type", msg.Type, "id", msg.ID, "attempts", attempt, "error", err)
+			return nil
+		}
+
+		delay := c.Backoff.Delay(attempt)
+		slog.WarnContext(ctx, "handling message failed, retrying", "topic", c.topic, "type", msg.Type, "id", msg.ID, "attempt", attempt, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
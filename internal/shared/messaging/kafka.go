@@ -0,0 +1,146 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher publishes to Kafka topics, partitioned by Message.Key so the messages of a key stay in order
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string) *KafkaPublisher {
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// Topics are created with their partitions by the bootstrap, see KafkaTopic
+		AllowAutoTopicCreation: false,
+	}}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, topic string, msgs ...Message) error {
+	records := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		headers := []kafka.Header{
+			{Key: HeaderID, Value: []byte(msg.ID)},
+			{Key: HeaderType, Value: []byte(msg.Type)},
+		}
+		for k, v := range msg.Headers {
+			headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		records[i] = kafka.Message{Topic: topic, Key: []byte(msg.Key), Value: msg.Payload, Headers: headers, Time: msg.Time}
+	}
+	return p.writer.WriteMessages(ctx, records...)
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// KafkaSubscriber consumes Kafka topics as a consumer group, committing the offset of each handled
+// message once the messages before it in its partition are handled too
+type KafkaSubscriber struct {
+	brokers []string
+}
+
+func NewKafkaSubscriber(brokers []string) *KafkaSubscriber {
+	return &KafkaSubscriber{brokers: brokers}
+}
+
+func (s *KafkaSubscriber) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	return s.SubscribeParallel(ctx, topic, group, Parallelism{}, handler)
+}
+
+func (s *KafkaSubscriber) SubscribeParallel(ctx context.Context, topic, group string, p Parallelism, handler Handler) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{Brokers: s.brokers, Topic: topic, GroupID: group})
+	defer reader.Close()
+
+	// Offsets are committed per partition, so each partition gets its own window
+	windows := make(map[int]*ackWindow[kafka.Message])
+	return consume(ctx, p, handler, func(fetchCtx context.Context) (delivery, error) {
+		record, err := reader.FetchMessage(fetchCtx)
+		if err != nil {
+			return delivery{}, err
+		}
+		window, ok := windows[record.Partition]
+		if !ok {
+			window = newAckWindow(func(record kafka.Message) error {
+				return reader.CommitMessages(ctx, record)
+			})
+			windows[record.Partition] = window
+		}
+		return delivery{msg: kafkaMessage(record), ack: window.add(record)}, nil
+	})
+}
+
+func kafkaMessage(record kafka.Message) Message {
+	msg := Message{Key: string(record.Key), Payload: record.Value, Time: record.Time, Headers: make(map[string]string)}
+	for _, h := range record.Headers {
+		switch h.Key {
+		case HeaderID:
+			msg.ID = string(h.Value)
+		case HeaderType:
+			msg.Type = string(h.Value)
+		default:
+			msg.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return msg
+}
+
+// KafkaTopic is the bootstrap resource creating a topic the service publishes to
+type KafkaTopic struct {
+	Brokers           []string
+	Topic             string
+	Partitions        int
+	ReplicationFactor int
+}
+
+func (t KafkaTopic) Describe() string {
+	return "kafka topic " + t.Topic
+}
+
+func (t KafkaTopic) Ensure(ctx context.Context) (bool, error) {
+	if len(t.Brokers) == 0 {
+		return false, errors.New("no kafka brokers configured")
+	}
+	conn, err := kafka.DialContext(ctx, "tcp", t.Brokers[0])
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(t.Topic)
+	if err == nil && len(partitions) > 0 {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, kafka.UnknownTopicOrPartition) {
+		return false, err
+	}
+
+	// Topics can only be created through the controller broker
+	controller, err := conn.Controller()
+	if err != nil {
+		return false, err
+	}
+	dialer := &kafka.Dialer{Timeout: 10 * time.Second}
+	controllerConn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return false, err
+	}
+	defer controllerConn.Close()
+
+	err = controllerConn.CreateTopics(kafka.TopicConfig{
+		Topic:             t.Topic,
+		NumPartitions:     t.Partitions,
+		ReplicationFactor: t.ReplicationFactor,
+	})
+	return err == nil, err
+}
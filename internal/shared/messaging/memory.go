@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// LogPublisher only logs messages; it stands in for a broker in development
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(ctx context.Context, topic string, msgs ...Message) error {
+	for _, msg := range msgs {
+		slog.InfoContext(ctx, "message published", "topic", topic, "type", msg.Type, "id", msg.ID, "key", msg.Key, "payload", string(msg.Payload))
+	}
+	return nil
+}
+
+// MemoryBroker is an in-process Publisher and Subscriber for tests. Every published message is
+// kept; a subscription replays the topic from the first message its group has not acknowledged.
+type MemoryBroker struct {
+	mu      sync.Mutex
+	topics  map[string][]Message
+	offsets map[string]int
+	// changed is closed and replaced whenever a message is published
+	changed chan struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		topics:  make(map[string][]Message),
+		offsets: make(map[string]int),
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, topic string, msgs ...Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topics[topic] = append(b.topics[topic], msgs...)
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	return b.SubscribeParallel(ctx, topic, group, Parallelism{}, handler)
+}
+
+func (b *MemoryBroker) SubscribeParallel(ctx context.Context, topic, group string, p Parallelism, handler Handler) error {
+	offsetKey := topic + "/" + group
+	b.mu.Lock()
+	next := b.offsets[offsetKey]
+	b.mu.Unlock()
+
+	window := newAckWindow(func(offset int) error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.offsets[offsetKey] = offset + 1
+		return nil
+	})
+	return consume(ctx, p, handler, func(ctx context.Context) (delivery, error) {
+		for {
+			b.mu.Lock()
+			pending := b.topics[topic][next:]
+			changed := b.changed
+			b.mu.Unlock()
+
+			if len(pending) > 0 {
+				offset := next
+				next++
+				return delivery{msg: pending[0], ack: window.add(offset)}, nil
+			}
+			select {
+			case <-ctx.Done():
+				return delivery{}, ctx.Err()
+			case <-changed:
+			}
+		}
+	})
+}
+
+// Messages returns what was published to topic so far
+func (b *MemoryBroker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.topics[topic]...)
+}
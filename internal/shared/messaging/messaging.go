@@ -0,0 +1,56 @@
+// Package messaging publishes integration events to a message broker for other services.
+//
+// Domain events stay in process on the event bus; the subscribers that announce them to other
+// services write Messages to the Outbox, and the Relay forwards stored messages to Kafka or
+// RabbitMQ. Delivery is at least once, so consumers must tolerate duplicates; Message.ID
+// identifies a message across redeliveries.
+package messaging
+
+import (
+	"context"
+	"time"
+)
+
+// Message is an integration event as it travels through the broker
+type Message struct {
+	// ID is unique per message and stable across redeliveries; the outbox assigns it when empty.
+	// Publishers of domain events use the event ID, so replaying an event yields the same message.
+	ID string
+	// Type names the event, e.g. "order.placed"
+	Type string
+	// Key orders messages: brokers deliver the messages with the same key in publish order
+	Key string
+	// Payload is the JSON encoded event
+	Payload []byte
+	// Headers carry metadata such as the tenant
+	Headers map[string]string
+	Time    time.Time
+}
+
+// Handler reacts to a delivered message
+type Handler func(ctx context.Context, msg Message) error
+
+// Publisher sends messages to a topic; it returns once the broker accepted them
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msgs ...Message) error
+}
+
+// Subscriber delivers the messages of a topic to a handler
+type Subscriber interface {
+	// Subscribe hands every message of topic to handler until ctx is done. Subscribers of one group
+	// share the messages between them. A message is acknowledged once handler returns nil; a handler
+	// error ends the subscription without acknowledging, so the message is delivered again on the
+	// next Subscribe. See Consumer for retries.
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+	// SubscribeParallel is Subscribe with up to p.Workers messages handled at once. The messages
+	// of a key are still handled in order, and a message only counts as acknowledged once handled.
+	SubscribeParallel(ctx context.Context, topic, group string, p Parallelism, handler Handler) error
+}
+
+// Header names the broker adapters use for the Message fields brokers have no native property for
+const (
+	HeaderID   = "message-id"
+	HeaderType = "message-type"
+	// HeaderKey carries Message.Key on RabbitMQ, which has no message key of its own
+	HeaderKey = "message-key"
+)
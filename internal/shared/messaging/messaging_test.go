@@ -0,0 +1,375 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupOutbox(t *testing.T) (*Outbox, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&OutboxMessage{}))
+	return NewOutbox(db), db
+}
+
+// flakyPublisher fails while failures is positive, then forwards to the broker
+type flakyPublisher struct {
+	broker   *MemoryBroker
+	failures int
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, topic string, msgs ...Message) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	return p.broker.Publish(ctx, topic, msgs...)
+}
+
+func TestRelay_PublishesOutboxInOrder(t *testing.T) {
+	outbox, _ := setupOutbox(t)
+	broker := NewMemoryBroker()
+	relay := NewRelay(outbox, broker, time.Second, 10, time.Hour)
+	ctx := context.Background()
+
+	assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.placed", Key: "1", Payload: []byte(`{"order_id":1}`), Headers: map[string]string{"tenant": "acme"}}))
+	assert.NoError(t, outbox.Publish(ctx, "users", Message{ID: "evt-7", Type: "user.registered", Key: "7", Payload: []byte(`{}`)}))
+	assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.cancelled", Key: "1", Payload: []byte(`{"order_id":1}`)}))
+
+	n, err := relay.RelayBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	orders := broker.Messages("orders")
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, "order.placed", orders[0].Type)
+		assert.Equal(t, "1", orders[0].ID, "the outbox row ID identifies messages without one")
+		assert.Equal(t, map[string]string{"tenant": "acme"}, orders[0].Headers)
+		assert.Equal(t, "order.cancelled", orders[1].Type)
+	}
+	users := broker.Messages("users")
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "evt-7", users[0].ID)
+		assert.Empty(t, users[0].Headers)
+	}
+
+	pending, err := outbox.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, pending)
+	n, err = relay.RelayBatch(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, n, "published messages are not sent again")
+}
+
+func TestRelay_RetriesFailedMessageBeforeLaterOnes(t *testing.T) {
+	outbox, db := setupOutbox(t)
+	publisher := &flakyPublisher{broker: NewMemoryBroker(), failures: 1}
+	relay := NewRelay(outbox, publisher, time.Second, 10, time.Hour)
+	ctx := context.Background()
+
+	assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.placed", Key: "1", Payload: []byte(`{}`)}))
+	assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.cancelled", Key: "1", Payload: []byte(`{}`)}))
+
+	n, err := relay.RelayBatch(ctx)
+	assert.Error(t, err)
+	assert.Zero(t, n)
+	var first OutboxMessage
+	assert.NoError(t, db.Order("id").First(&first).Error)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, "broker unavailable", first.LastError)
+
+	n, err = relay.RelayBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	msgs := publisher.broker.Messages("orders")
+	if assert.Len(t, msgs, 2) {
+		assert.Equal(t, "order.placed", msgs[0].Type)
+		assert.Equal(t, "order.cancelled", msgs[1].Type)
+	}
+}
+
+func TestRelay_FlushDrainsOutbox(t *testing.T) {
+	outbox, _ := setupOutbox(t)
+	broker := NewMemoryBroker()
+	relay := NewRelay(outbox, broker, time.Hour, 2, time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.placed", Payload: []byte(`{}`)}))
+	}
+
+	assert.NoError(t, relay.Flush(ctx))
+	assert.Len(t, broker.Messages("orders"), 5)
+	pending, err := outbox.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, pending)
+}
+
+func TestRelay_FlushLeavesOutboxToLockHolder(t *testing.T) {
+	outbox, _ := setupOutbox(t)
+	broker := NewMemoryBroker()
+	locks := lock.NewLocal()
+	relay := NewRelay(outbox, broker, time.Hour, 10, time.Hour)
+	relay.Lock = locks
+	ctx := context.Background()
+	assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.placed", Payload: []byte(`{}`)}))
+
+	// Another instance is relaying
+	lease, ok, err := locks.TryLock(ctx, relayLock)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, relay.Flush(ctx))
+	assert.Empty(t, broker.Messages("orders"))
+
+	assert.NoError(t, lease.Release(ctx))
+	assert.NoError(t, relay.Flush(ctx))
+	assert.Len(t, broker.Messages("orders"), 1)
+}
+
+func TestOutbox_PublishSkipsKnownIDs(t *testing.T) {
+	outbox, _ := setupOutbox(t)
+	broker := NewMemoryBroker()
+	relay := NewRelay(outbox, broker, time.Second, 10, time.Hour)
+	ctx := context.Background()
+
+	placed := Message{ID: "evt-1", Type: "order.placed", Key: "1", Payload: []byte(`{}`)}
+	assert.NoError(t, outbox.Publish(ctx, "orders", placed))
+	assert.NoError(t, outbox.Publish(ctx, "orders", placed, Message{ID: "evt-2", Type: "order.cancelled", Key: "1", Payload: []byte(`{}`)}))
+	_, err := relay.RelayBatch(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, outbox.Publish(ctx, "orders", placed), "a replay after relaying is skipped too")
+
+	pending, err := outbox.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, pending)
+	msgs := broker.Messages("orders")
+	if assert.Len(t, msgs, 2) {
+		assert.Equal(t, "evt-1", msgs[0].ID)
+		assert.Equal(t, "evt-2", msgs[1].ID)
+	}
+}
+
+func TestOutbox_PublishJoinsTransaction(t *testing.T) {
+	outbox, db := setupOutbox(t)
+	ctx := context.Background()
+
+	err := persistence.NewGormTransactor(db).InTransaction(ctx, func(ctx context.Context) error {
+		if err := outbox.Publish(ctx, "orders", Message{Type: "order.placed", Payload: []byte(`{}`)}); err != nil {
+			return err
+		}
+		return errors.New("order failed")
+	})
+	assert.Error(t, err)
+
+	pending, err := outbox.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, pending, "messages of a rolled back unit of work are never published")
+}
+
+func TestOutbox_PurgeKeepsPendingMessages(t *testing.T) {
+	outbox, _ := setupOutbox(t)
+	relay := NewRelay(outbox, NewMemoryBroker(), time.Second, 1, time.Hour)
+	ctx := context.Background()
+
+	assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.placed", Payload: []byte(`{}`)}))
+	assert.NoError(t, outbox.Publish(ctx, "orders", Message{Type: "order.cancelled", Payload: []byte(`{}`)}))
+	_, err := relay.RelayBatch(ctx)
+	assert.NoError(t, err)
+
+	purged, err := outbox.Purge(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	pending, err := outbox.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), pending)
+}
+
+type orderPlaced struct {
+	OrderID int64 `json:"order_id"`
+}
+
+func TestConsumer_DispatchesByTypeAndRetries(t *testing.T) {
+	broker := NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, broker.Publish(ctx, "orders",
+		Message{ID: "1", Type: "order.placed", Payload: []byte(`{"order_id":42}`)},
+		Message{ID: "2", Type: "order.shipped", Payload: []byte(`{}`)},
+		Message{ID: "3", Type: "order.placed", Payload: []byte(`not json`)},
+		Message{ID: "4", Type: "order.placed", Payload: []byte(`{"order_id":43}`)},
+	))
+
+	consumer := NewConsumer(broker, "orders", "shipping")
+	consumer.Backoff = jobs.Backoff{Base: time.Millisecond, Max: time.Millisecond}
+	var received []int64
+	failures := 1
+	On(consumer, "order.placed", func(ctx context.Context, placed orderPlaced, msg Message) error {
+		if failures > 0 {
+			failures--
+			return errors.New("warehouse unavailable")
+		}
+		received = append(received, placed.OrderID)
+		if len(received) == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.NoError(t, consumer.Run(ctx))
+	assert.Equal(t, []int64{42, 43}, received, "failed messages are retried, undecodable and unhandled ones skipped")
+}
+
+func TestConsumer_DropsMessageAfterMaxAttempts(t *testing.T) {
+	broker := NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, broker.Publish(ctx, "orders",
+		Message{ID: "1", Type: "order.placed", Payload: []byte(`{}`)},
+		Message{ID: "2", Type: "order.cancelled", Payload: []byte(`{}`)},
+	))
+
+	consumer := NewConsumer(broker, "orders", "shipping")
+	consumer.MaxAttempts = 3
+	consumer.Backoff = jobs.Backoff{Base: time.Millisecond, Max: time.Millisecond}
+	attempts := 0
+	consumer.Handle("order.placed", func(ctx context.Context, msg Message) error {
+		attempts++
+		return errors.New("poison message")
+	})
+	consumer.Handle("order.cancelled", func(ctx context.Context, msg Message) error {
+		cancel()
+		return nil
+	})
+
+	assert.NoError(t, consumer.Run(ctx))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestMemoryBroker_GroupsResumeFromTheirOffset(t *testing.T) {
+	broker := NewMemoryBroker()
+	ctx := context.Background()
+	assert.NoError(t, broker.Publish(ctx, "orders", Message{ID: "1"}, Message{ID: "2"}))
+
+	failing := errors.New("handler failed")
+	err := broker.Subscribe(ctx, "orders", "billing", func(ctx context.Context, msg Message) error {
+		if msg.ID == "2" {
+			return failing
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, failing)
+
+	var redelivered []string
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	err = broker.Subscribe(subCtx, "orders", "billing", func(ctx context.Context, msg Message) error {
+		redelivered = append(redelivered, msg.ID)
+		cancel()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, redelivered, "the unacknowledged message is delivered again")
+}
+
+// waitFor fails the test instead of hanging when ch is not closed in time. Handlers run on worker
+// goroutines, so it cannot stop the test with t.Fatal.
+func waitFor(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Error("timed out waiting for the other worker")
+	}
+}
+
+func TestConsumer_ParallelKeepsOrderPerKey(t *testing.T) {
+	broker := NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// With two workers, keys "a" and "b" hash to different workers
+	assert.NoError(t, broker.Publish(ctx, "orders",
+		Message{ID: "a1", Key: "a", Type: "order.placed"},
+		Message{ID: "b1", Key: "b", Type: "order.placed"},
+		Message{ID: "b2", Key: "b", Type: "order.placed"},
+		Message{ID: "a2", Key: "a", Type: "order.placed"},
+	))
+
+	consumer := NewConsumer(broker, "orders", "shipping")
+	consumer.Parallelism = Parallelism{Workers: 2, Buffer: 1}
+	var mu sync.Mutex
+	var handled []string
+	bDone := make(chan struct{})
+	consumer.Handle("order.placed", func(ctx context.Context, msg Message) error {
+		if msg.ID == "a1" {
+			waitFor(t, bDone)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, msg.ID)
+		switch msg.ID {
+		case "b2":
+			close(bDone)
+		case "a2":
+			cancel()
+		}
+		return nil
+	})
+
+	assert.NoError(t, consumer.Run(ctx))
+	assert.Equal(t, []string{"b1", "b2", "a1", "a2"}, handled, "a slow key does not hold back the others")
+}
+
+func TestMemoryBroker_ParallelAcknowledgesInFetchOrder(t *testing.T) {
+	broker := NewMemoryBroker()
+	ctx := context.Background()
+	assert.NoError(t, broker.Publish(ctx, "orders", Message{ID: "1"}, Message{ID: "2"}, Message{ID: "3"}))
+
+	// Messages without a key are spread over the workers in turn
+	failing := errors.New("handler failed")
+	var others sync.WaitGroup
+	others.Add(2)
+	othersDone := make(chan struct{})
+	go func() {
+		others.Wait()
+		close(othersDone)
+	}()
+	err := broker.SubscribeParallel(ctx, "orders", "billing", Parallelism{Workers: 3}, func(ctx context.Context, msg Message) error {
+		if msg.ID == "1" {
+			waitFor(t, othersDone)
+			return failing
+		}
+		others.Done()
+		return nil
+	})
+	assert.ErrorIs(t, err, failing)
+
+	var redelivered []string
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	err = broker.Subscribe(subCtx, "orders", "billing", func(ctx context.Context, msg Message) error {
+		redelivered = append(redelivered, msg.ID)
+		if len(redelivered) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, redelivered, "messages handled after the failed one are not acknowledged before it")
+}
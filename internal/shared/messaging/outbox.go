@@ -0,0 +1,196 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxMessage is a message stored in the outbox table until the Relay published it
+type OutboxMessage struct {
+	ID int64 `gorm:"primaryKey"`
+	// MessageID is Message.ID when the publisher set one; storing the same ID again is a no-op
+	MessageID *string `gorm:"type:varchar(64);uniqueIndex"`
+	Topic     string  `gorm:"type:varchar(255);not null"`
+	Type      string  `gorm:"type:varchar(100);not null"`
+	Key       string  `gorm:"type:varchar(255)"`
+	Payload   string  `gorm:"type:text;not null"`
+	// Headers is the JSON encoded Message.Headers
+	Headers   string `gorm:"type:text"`
+	CreatedAt time.Time
+	// PublishedAt is nil until the broker accepted the message
+	PublishedAt *time.Time `gorm:"index"`
+	Attempts    int        `gorm:"not null"`
+	LastError   string     `gorm:"type:text"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// message converts the stored row back, using its ID when the publisher left Message.ID empty
+func (m OutboxMessage) message() (Message, error) {
+	msg := Message{ID: strconv.FormatInt(m.ID, 10), Type: m.Type, Key: m.Key, Payload: []byte(m.Payload), Time: m.CreatedAt}
+	if m.MessageID != nil {
+		msg.ID = *m.MessageID
+	}
+	if m.Headers != "" {
+		if err := json.Unmarshal([]byte(m.Headers), &msg.Headers); err != nil {
+			return Message{}, fmt.Errorf("decode headers of outbox message %d: %w", m.ID, err)
+		}
+	}
+	// Rows stored before MessageID existed carry the ID in their headers
+	if id := msg.Headers[HeaderID]; id != "" {
+		msg.ID = id
+		delete(msg.Headers, HeaderID)
+	}
+	return msg, nil
+}
+
+// Outbox is a Publisher storing messages in the database for the Relay. Publishing joins the
+// transaction bound to ctx, so the messages of a unit of work are only sent if it commits.
+// Messages with an ID are stored once: publishing an ID again, e.g. when an event is replayed,
+// is skipped as long as the first message has not been purged.
+type Outbox struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewOutbox(db *gorm.DB) *Outbox {
+	return &Outbox{db: db, now: time.Now}
+}
+
+func (o *Outbox) Publish(ctx context.Context, topic string, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	rows := make([]OutboxMessage, len(msgs))
+	for i, msg := range msgs {
+		rows[i] = OutboxMessage{Topic: topic, Type: msg.Type, Key: msg.Key, Payload: string(msg.Payload), CreatedAt: msg.Time}
+		if msg.ID != "" {
+			id := msg.ID
+			rows[i].MessageID = &id
+		}
+		if rows[i].CreatedAt.IsZero() {
+			rows[i].CreatedAt = o.now()
+		}
+		if len(msg.Headers) > 0 {
+			encoded, err := json.Marshal(msg.Headers)
+			if err != nil {
+				return fmt.Errorf("encode headers of %s: %w", msg.Type, err)
+			}
+			rows[i].Headers = string(encoded)
+		}
+	}
+	err := persistence.Conn(ctx, o.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+	return persistence.TranslateError(err)
+}
+
+// publishPending hands up to limit unpublished messages, oldest first, to publish and marks the
+// ones it accepted as published. Concurrent callers wait for each other so messages keep their order.
+func (o *Outbox) publishPending(ctx context.Context, limit int, publish func(ctx context.Context, batch []OutboxMessage) (int, error)) (int, error) {
+	published := 0
+	var publishErr error
+
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pending []OutboxMessage
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("published_at IS NULL").
+			Order("id").
+			Limit(limit).
+			Find(&pending).Error
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+
+		published, publishErr = publish(ctx, pending)
+		if published > 0 {
+			ids := make([]int64, published)
+			for i := range ids {
+				ids[i] = pending[i].ID
+			}
+			if err := tx.Model(&OutboxMessage{}).Where("id IN ?", ids).Update("published_at", o.now()).Error; err != nil {
+				return err
+			}
+		}
+		if publishErr != nil && published < len(pending) {
+			return tx.Model(&OutboxMessage{}).Where("id = ?", pending[published].ID).Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": publishErr.Error(),
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return published, persistence.TranslateError(err)
+	}
+	return published, publishErr
+}
+
+// LogEntry is a message of the outbox log; Position orders the entries and is stable, unlike the time
+type LogEntry struct {
+	Position int64
+	Topic    string
+	Message  Message
+}
+
+// Log returns up to limit stored messages after position, oldest first, whether published or not.
+// It is how projections read the history of events; purged messages are gone from it.
+func (o *Outbox) Log(ctx context.Context, after int64, limit int) ([]LogEntry, error) {
+	var rows []OutboxMessage
+	err := o.db.WithContext(ctx).Where("id > ?", after).Order("id").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	entries := make([]LogEntry, len(rows))
+	for i, row := range rows {
+		msg, err := row.message()
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = LogEntry{Position: row.ID, Topic: row.Topic, Message: msg}
+	}
+	return entries, nil
+}
+
+// PositionAt returns the position right before the first message stored at or after t, so
+// reading the log after it starts at t
+func (o *Outbox) PositionAt(ctx context.Context, t time.Time) (int64, error) {
+	var first OutboxMessage
+	err := o.db.WithContext(ctx).Select("id").Where("created_at >= ?", t).Order("id").Limit(1).Find(&first).Error
+	if err != nil {
+		return 0, persistence.TranslateError(err)
+	}
+	if first.ID == 0 {
+		// Nothing was stored since t, so only messages yet to come are after it
+		return o.lastPosition(ctx)
+	}
+	return first.ID - 1, nil
+}
+
+func (o *Outbox) lastPosition(ctx context.Context) (int64, error) {
+	var last int64
+	err := o.db.WithContext(ctx).Model(&OutboxMessage{}).Select("COALESCE(MAX(id), 0)").Scan(&last).Error
+	return last, persistence.TranslateError(err)
+}
+
+// Purge deletes the messages published before cutoff and returns how many it deleted
+func (o *Outbox) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := o.db.WithContext(ctx).Where("published_at < ?", cutoff).Delete(&OutboxMessage{})
+	return result.RowsAffected, persistence.TranslateError(result.Error)
+}
+
+// Pending counts the messages not published yet
+func (o *Outbox) Pending(ctx context.Context) (int64, error) {
+	var n int64
+	err := o.db.WithContext(ctx).Model(&OutboxMessage{}).Where("published_at IS NULL").Count(&n).Error
+	return n, persistence.TranslateError(err)
+}
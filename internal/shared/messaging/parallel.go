@@ -0,0 +1,179 @@
+package messaging
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+)
+
+// Parallelism spreads the messages of a subscription over concurrent handler calls
+type Parallelism struct {
+	// Workers is how many messages are handled at once; zero means one. The messages of a key
+	// always go to the same worker, so they are still handled one at a time in delivery order.
+	Workers int
+	// Buffer is how many fetched messages may wait for each worker. Fetching blocks while the
+	// worker of the next message is full, so a slow handler holds back the broker instead of
+	// piling messages up in memory.
+	Buffer int
+}
+
+func (p Parallelism) workers() int {
+	if p.Workers < 1 {
+		return 1
+	}
+	return p.Workers
+}
+
+// delivery is a fetched message and the func acknowledging it to the broker
+type delivery struct {
+	msg Message
+	ack func() error
+}
+
+// consume hands what fetch returns to the workers of p until ctx is done, fetch fails or a handler
+// fails. Messages still queued after a failure are left unacknowledged for redelivery.
+func consume(ctx context.Context, p Parallelism, handler Handler, fetch func(ctx context.Context) (delivery, error)) error {
+	workers := startWorkers(ctx, p, handler)
+
+	var fetchErr error
+	for {
+		d, err := fetch(workers.ctx)
+		if err != nil {
+			fetchErr = err
+			break
+		}
+		if !workers.submit(d) {
+			break
+		}
+	}
+
+	err := workers.stop()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fetchErr
+}
+
+// workerPool runs a handler on a fixed set of workers, each with its own queue
+type workerPool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	queues []chan delivery
+	wg     sync.WaitGroup
+	// next is the worker of the next message without a key, which has no order to keep
+	next int
+
+	failOnce sync.Once
+	err      error
+}
+
+func startWorkers(ctx context.Context, p Parallelism, handler Handler) *workerPool {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &workerPool{ctx: ctx, cancel: cancel, queues: make([]chan delivery, p.workers())}
+	for i := range w.queues {
+		queue := make(chan delivery, p.Buffer)
+		w.queues[i] = queue
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for d := range queue {
+				// After a failure the queue is drained without handling, so nothing past it is acknowledged
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := handler(ctx, d.msg); err != nil {
+					w.fail(err)
+					continue
+				}
+				if err := d.ack(); err != nil {
+					w.fail(err)
+				}
+			}
+		}()
+	}
+	return w
+}
+
+// submit queues d for the worker of its key, blocking while that worker is full. It returns false
+// once the pool stopped because a handler failed or ctx is done.
+func (w *workerPool) submit(d delivery) bool {
+	var i int
+	if d.msg.Key == "" {
+		i = w.next
+		w.next = (w.next + 1) % len(w.queues)
+	} else {
+		h := fnv.New32a()
+		h.Write([]byte(d.msg.Key))
+		i = int(h.Sum32() % uint32(len(w.queues)))
+	}
+
+	select {
+	case w.queues[i] <- d:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+func (w *workerPool) fail(err error) {
+	w.failOnce.Do(func() {
+		w.err = err
+		w.cancel()
+	})
+}
+
+// stop waits until the workers are through their queues and returns the first failure
+func (w *workerPool) stop() error {
+	for _, queue := range w.queues {
+		close(queue)
+	}
+	w.wg.Wait()
+	w.cancel()
+	return w.err
+}
+
+// ackWindow acknowledges messages in fetch order for brokers that track a group by offset:
+// committing an offset acknowledges every message before it, so a handled message is committed
+// only once all messages fetched before it are handled too.
+type ackWindow[T any] struct {
+	mu      sync.Mutex
+	pending []*windowSlot[T]
+	commit  func(T) error
+}
+
+type windowSlot[T any] struct {
+	value T
+	done  bool
+}
+
+func newAckWindow[T any](commit func(T) error) *ackWindow[T] {
+	return &ackWindow[T]{commit: commit}
+}
+
+// add appends a fetched message and returns the func marking it handled
+func (w *ackWindow[T]) add(value T) func() error {
+	slot := &windowSlot[T]{value: value}
+	w.mu.Lock()
+	w.pending = append(w.pending, slot)
+	w.mu.Unlock()
+
+	return func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		slot.done = true
+
+		n := 0
+		for n < len(w.pending) && w.pending[n].done {
+			n++
+		}
+		if n == 0 {
+			return nil
+		}
+		last := w.pending[n-1].value
+		w.pending = w.pending[n:]
+		return w.commit(last)
+	}
+}
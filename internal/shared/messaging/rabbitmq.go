@@ -0,0 +1,206 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// In RabbitMQ a topic is a durable topic exchange; messages are routed by their type, and every
+// consumer group reads from its own durable queue "<topic>.<group>" bound to all of them.
+const rabbitMQExchangeKind = "topic"
+
+// RabbitMQPublisher publishes persistent messages and waits for the broker to confirm them
+type RabbitMQPublisher struct {
+	url string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+func NewRabbitMQPublisher(url string) *RabbitMQPublisher {
+	return &RabbitMQPublisher{url: url}
+}
+
+func (p *RabbitMQPublisher) Publish(ctx context.Context, topic string, msgs ...Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, err := p.open()
+	if err != nil {
+		return err
+	}
+
+	confirms := make([]*amqp.DeferredConfirmation, 0, len(msgs))
+	for _, msg := range msgs {
+		headers := amqp.Table{HeaderKey: msg.Key}
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, topic, msg.Type, false, false, amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    msg.ID,
+			Type:         msg.Type,
+			Timestamp:    msg.Time,
+			Headers:      headers,
+			Body:         msg.Payload,
+		})
+		if err != nil {
+			return fmt.Errorf("publish %s to %s: %w", msg.Type, topic, err)
+		}
+		confirms = append(confirms, confirm)
+	}
+
+	for i, confirm := range confirms {
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !acked {
+			return fmt.Errorf("broker rejected %s %s", msgs[i].Type, msgs[i].ID)
+		}
+	}
+	return nil
+}
+
+// open returns the confirming channel, reconnecting after the connection or channel was closed
+func (p *RabbitMQPublisher) open() (*amqp.Channel, error) {
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, nil
+	}
+	if p.conn == nil || p.conn.IsClosed() {
+		conn, err := amqp.Dial(p.url)
+		if err != nil {
+			return nil, err
+		}
+		p.conn = conn
+	}
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	p.channel = ch
+	return ch, nil
+}
+
+func (p *RabbitMQPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.Close()
+}
+
+// RabbitMQSubscriber consumes a topic from the group's queue, acknowledging each message once handled
+type RabbitMQSubscriber struct {
+	url string
+}
+
+func NewRabbitMQSubscriber(url string) *RabbitMQSubscriber {
+	return &RabbitMQSubscriber{url: url}
+}
+
+func (s *RabbitMQSubscriber) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	return s.SubscribeParallel(ctx, topic, group, Parallelism{}, handler)
+}
+
+func (s *RabbitMQSubscriber) SubscribeParallel(ctx context.Context, topic, group string, p Parallelism, handler Handler) error {
+	conn, err := amqp.Dial(s.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	// Declaring is idempotent, so consumers can start before the publisher ever ran
+	queue := topic + "." + group
+	if err := ch.ExchangeDeclare(topic, rabbitMQExchangeKind, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare exchange %s: %w", topic, err)
+	}
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare queue %s: %w", queue, err)
+	}
+	if err := ch.QueueBind(queue, "#", topic, false, nil); err != nil {
+		return fmt.Errorf("bind queue %s: %w", queue, err)
+	}
+	// The broker sends no more messages than the workers can hold, so a full worker holds them back
+	// in the queue. Messages left unacknowledged when the connection closes are requeued.
+	if err := ch.Qos(p.workers()*(p.Buffer+1), 0, false); err != nil {
+		return err
+	}
+	deliveries, err := ch.ConsumeWithContext(ctx, queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", queue, err)
+	}
+
+	return consume(ctx, p, handler, func(ctx context.Context) (delivery, error) {
+		var d amqp.Delivery
+		select {
+		case <-ctx.Done():
+			return delivery{}, ctx.Err()
+		case next, ok := <-deliveries:
+			if !ok {
+				return delivery{}, errors.New("rabbitmq closed the delivery channel")
+			}
+			d = next
+		}
+
+		msg := Message{ID: d.MessageId, Type: d.Type, Payload: d.Body, Time: d.Timestamp, Headers: make(map[string]string)}
+		for k, v := range d.Headers {
+			if k == HeaderKey {
+				msg.Key = fmt.Sprint(v)
+				continue
+			}
+			msg.Headers[k] = fmt.Sprint(v)
+		}
+		return delivery{msg: msg, ack: func() error { return d.Ack(false) }}, nil
+	})
+}
+
+// RabbitMQExchange is the bootstrap resource declaring the exchange of a topic the service publishes to
+type RabbitMQExchange struct {
+	URL      string
+	Exchange string
+}
+
+func (e RabbitMQExchange) Describe() string {
+	return "rabbitmq exchange " + e.Exchange
+}
+
+func (e RabbitMQExchange) Ensure(ctx context.Context) (bool, error) {
+	conn, err := amqp.Dial(e.URL)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// A failed passive declare closes the channel, so the declare needs a fresh one
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	if err := ch.ExchangeDeclarePassive(e.Exchange, rabbitMQExchangeKind, true, false, false, false, nil); err == nil {
+		return false, nil
+	}
+	ch, err = conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	if err := ch.ExchangeDeclare(e.Exchange, rabbitMQExchangeKind, true, false, false, false, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,148 @@
+package messaging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lock"
+)
+
+// relayLock is the lock the instances relaying the same outbox take turns on
+const relayLock = "outbox-relay"
+
+// Relay forwards the messages stored in the Outbox to the broker and purges them once they are
+// older than the retention. A message the broker rejects holds back the ones after it, so
+// consumers see the messages of a key in order; it is retried on the next poll.
+type Relay struct {
+	outbox    *Outbox
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+	retention time.Duration
+	now       func() time.Time
+
+	// Lock, when set, lets one instance at a time relay, so instances do not wait on each
+	// other's rows; a poll finding it taken is skipped
+	Lock lock.DistributedLock
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewRelay(outbox *Outbox, publisher Publisher, interval time.Duration, batchSize int, retention time.Duration) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// Start relays in the background every interval until Stop; a full batch is followed by the next one right away
+func (r *Relay) Start() {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+
+			if err := r.exclusive(context.Background(), r.poll); err != nil {
+				slog.Error("taking the outbox relay lock failed", "error", err)
+			}
+		}
+	}()
+}
+
+// poll relays the pending messages and purges those past the retention
+func (r *Relay) poll(ctx context.Context) error {
+	for {
+		n, err := r.RelayBatch(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "relaying outbox messages failed", "published", n, "error", err)
+		}
+		if err != nil || n < r.batchSize {
+			break
+		}
+	}
+	if _, err := r.outbox.Purge(ctx, r.now().Add(-r.retention)); err != nil {
+		slog.ErrorContext(ctx, "purging published outbox messages failed", "error", err)
+	}
+	return nil
+}
+
+// exclusive runs fn under the relay lock, if any; fn is skipped while another instance holds it
+func (r *Relay) exclusive(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.Lock == nil {
+		return fn(ctx)
+	}
+	_, err := lock.Do(ctx, r.Lock, relayLock, fn)
+	return err
+}
+
+func (r *Relay) Stop() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+}
+
+// Flush publishes the pending messages batch by batch until the outbox is drained, a batch fails
+// or ctx ends. It is called on shutdown after Stop so no stored message waits for the next start.
+// While another instance holds the relay lock, that instance relays them instead.
+func (r *Relay) Flush(ctx context.Context) error {
+	return r.exclusive(ctx, func(ctx context.Context) error {
+		for ctx.Err() == nil {
+			n, err := r.RelayBatch(ctx)
+			if err != nil {
+				return err
+			}
+			if n < r.batchSize {
+				return nil
+			}
+		}
+		return ctx.Err()
+	})
+}
+
+// RelayBatch publishes up to one batch of pending messages and returns how many were published
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	return r.outbox.publishPending(ctx, r.batchSize, r.publish)
+}
+
+// publish sends the batch one run of messages for the same topic at a time, stopping at the first failure
+func (r *Relay) publish(ctx context.Context, batch []OutboxMessage) (int, error) {
+	published := 0
+	for published < len(batch) {
+		topic := batch[published].Topic
+		var msgs []Message
+		for _, row := range batch[published:] {
+			if row.Topic != topic {
+				break
+			}
+			msg, err := row.message()
+			if err != nil {
+				return published, err
+			}
+			msgs = append(msgs, msg)
+		}
+
+		if err := r.publisher.Publish(ctx, topic, msgs...); err != nil {
+			return published, err
+		}
+		published += len(msgs)
+	}
+	return published, nil
+}
@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/buildinfo"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/canary"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Result label values
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Metrics holds the application collectors registered on a single registry
+type Metrics struct {
+	registry *prometheus.Registry
+
+	OrdersPlaced       prometheus.Counter
+	OrderPlaceDuration *prometheus.HistogramVec
+	DBQueryDuration    *prometheus.HistogramVec
+	ConsumerLag        *prometheus.GaugeVec
+	ConsumerDuration   *prometheus.HistogramVec
+	WarmupDuration     *prometheus.HistogramVec
+	WarmupEntries      *prometheus.GaugeVec
+	WarmupPending      prometheus.Gauge
+	ShutdownDuration   *prometheus.HistogramVec
+	CanaryExposures    *prometheus.CounterVec
+	BuildInfo          *prometheus.GaugeVec
+}
+
+// New creates a registry with the application collectors plus the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		OrdersPlaced: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_placed_total",
+			Help: "Number of orders placed successfully.",
+		}),
+		OrderPlaceDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "order_place_duration_seconds",
+			Help:    "Duration of the place order command.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"result"}),
+		DBQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of repository calls.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "method"}),
+		ConsumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "messaging_consumer_lag_seconds",
+			Help: "Age of the last message a consumer started handling.",
+		}, []string{"topic", "group"}),
+		ConsumerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "messaging_consumer_handle_duration_seconds",
+			Help:    "Duration of handling a message, retries included.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"topic", "group", "result"}),
+		WarmupDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_warmup_duration_seconds",
+			Help:    "Duration of warming a cache on startup.",
+			Buckets: []float64{.01, .05, .1, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"cache", "result"}),
+		WarmupEntries: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cache_warmup_entries",
+			Help: "Number of entries loaded into a cache on startup.",
+		}, []string{"cache"}),
+		WarmupPending: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_warmup_pending",
+			Help: "Number of caches still to be warmed on startup.",
+		}),
+		ShutdownDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "shutdown_component_duration_seconds",
+			Help:    "Duration of stopping a component on shutdown.",
+			Buckets: []float64{.01, .05, .1, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"component", "result"}),
+		CanaryExposures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "canary_exposures_total",
+			Help: "Number of calls served by the stable or candidate implementation of a release.",
+		}, []string{"release", "variant"}),
+		BuildInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "Always 1; the labels name the build of the instance.",
+		}, []string{"version", "commit", "go_version"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.OrdersPlaced,
+		m.OrderPlaceDuration,
+		m.DBQueryDuration,
+		m.ConsumerLag,
+		m.ConsumerDuration,
+		m.WarmupDuration,
+		m.WarmupEntries,
+		m.WarmupPending,
+		m.ShutdownDuration,
+		m.CanaryExposures,
+		m.BuildInfo,
+	)
+	return m
+}
+
+// Build labels build_info with the build of the instance, so dashboards can join other series on it
+func (m *Metrics) Build(info buildinfo.Info) {
+	m.BuildInfo.WithLabelValues(info.Version, info.Commit, info.GoVersion).Set(1)
+}
+
+// Registry exposes the registry so other packages can add their own collectors
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Repository returns an observer recording db_query_duration_seconds for the named repository
+func (m *Metrics) Repository(name string) RepositoryObserver {
+	return RepositoryObserver{histogram: m.DBQueryDuration, repository: name}
+}
+
+// RepositoryObserver times repository methods, e.g. `defer observer.Since("GetByID", time.Now())`
+type RepositoryObserver struct {
+	histogram  *prometheus.HistogramVec
+	repository string
+}
+
+func (o RepositoryObserver) Since(method string, start time.Time) {
+	o.histogram.WithLabelValues(o.repository, method).Observe(time.Since(start).Seconds())
+}
+
+// Consumer returns an observer recording the messaging_consumer metrics of a consumer group, for
+// messaging.Consumer.Observer
+func (m *Metrics) Consumer(topic, group string) ConsumerObserver {
+	return ConsumerObserver{
+		lag:      m.ConsumerLag.WithLabelValues(topic, group),
+		duration: m.ConsumerDuration,
+		topic:    topic,
+		group:    group,
+	}
+}
+
+// ConsumerObserver sets the lag of a consumer group to the age of each message when its handling
+// starts; a lag that keeps growing means the group cannot keep up with its topic
+type ConsumerObserver struct {
+	lag          prometheus.Gauge
+	duration     *prometheus.HistogramVec
+	topic, group string
+}
+
+func (o ConsumerObserver) Handled(published, started time.Time, err error) {
+	if !published.IsZero() {
+		o.lag.Set(started.Sub(published).Seconds())
+	}
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	}
+	o.duration.WithLabelValues(o.topic, o.group, result).Observe(time.Since(started).Seconds())
+}
+
+// CacheWarmup returns an observer recording the cache_warmup metrics, for warmup.Warmer.Observer
+func (m *Metrics) CacheWarmup() WarmupObserver {
+	return WarmupObserver{duration: m.WarmupDuration, entries: m.WarmupEntries, pending: m.WarmupPending}
+}
+
+// WarmupObserver reports how far the warm-up of the caches of an instance got
+type WarmupObserver struct {
+	duration *prometheus.HistogramVec
+	entries  *prometheus.GaugeVec
+	pending  prometheus.Gauge
+}
+
+func (o WarmupObserver) Warmed(cache string, entries int, duration time.Duration, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	} else {
+		o.entries.WithLabelValues(cache).Set(float64(entries))
+	}
+	o.duration.WithLabelValues(cache, result).Observe(duration.Seconds())
+}
+
+func (o WarmupObserver) Pending(n int) {
+	o.pending.Set(float64(n))
+}
+
+// Shutdown returns an observer recording shutdown_component_duration_seconds, for
+// lifecycle.Manager.Observer
+func (m *Metrics) Shutdown() ShutdownObserver {
+	return ShutdownObserver{duration: m.ShutdownDuration}
+}
+
+// ShutdownObserver records how long the components of an instance took to stop
+type ShutdownObserver struct {
+	duration *prometheus.HistogramVec
+}
+
+func (o ShutdownObserver) Stopped(component string, duration time.Duration, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	}
+	o.duration.WithLabelValues(component, result).Observe(duration.Seconds())
+}
+
+// Canary returns an observer recording canary_exposures_total, for canary.Rollout.Observer
+func (m *Metrics) Canary() CanaryObserver {
+	return CanaryObserver{exposures: m.CanaryExposures}
+}
+
+// CanaryObserver counts the calls each variant of a release served; compared with the command
+// metrics it shows whether the candidate can be rolled out further
+type CanaryObserver struct {
+	exposures *prometheus.CounterVec
+}
+
+func (o CanaryObserver) Exposed(release string, v canary.Variant) {
+	o.exposures.WithLabelValues(release, string(v)).Inc()
+}
+
+// InstrumentPlaceOrder wraps the place order command with orders_placed_total and order_place_duration_seconds
+func InstrumentPlaceOrder[C any, R any](handler decorator.CommandResultHandler[C, R], m *Metrics) decorator.CommandResultHandler[C, R] {
+	return placeOrderDecorator[C, R]{base: handler, metrics: m}
+}
+
+type placeOrderDecorator[C any, R any] struct {
+	base    decorator.CommandResultHandler[C, R]
+	metrics *Metrics
+}
+
+func (d placeOrderDecorator[C, R]) Handle(ctx context.Context, cmd C) (R, error) {
+	start := time.Now()
+	res, err := d.base.Handle(ctx, cmd)
+
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	} else {
+		d.metrics.OrdersPlaced.Inc()
+	}
+	d.metrics.OrderPlaceDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	return res, err
+}
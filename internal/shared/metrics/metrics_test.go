@@ -0,0 +1,120 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/buildinfo"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/canary"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type placeOrder struct{}
+
+type placeOrderHandler struct {
+	err error
+}
+
+func (h placeOrderHandler) Handle(ctx context.Context, cmd placeOrder) (int64, error) {
+	return 1, h.err
+}
+
+func TestInstrumentPlaceOrder(t *testing.T) {
+	m := metrics.New()
+
+	ok := metrics.InstrumentPlaceOrder[placeOrder, int64](placeOrderHandler{}, m)
+	failing := metrics.InstrumentPlaceOrder[placeOrder, int64](placeOrderHandler{err: errors.New("insufficient stock")}, m)
+
+	_, err := ok.Handle(context.Background(), placeOrder{})
+	assert.NoError(t, err)
+	_, err = ok.Handle(context.Background(), placeOrder{})
+	assert.NoError(t, err)
+	_, err = failing.Handle(context.Background(), placeOrder{})
+	assert.Error(t, err)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.OrdersPlaced), "only successful orders are counted")
+	assert.Equal(t, 2, testutil.CollectAndCount(m.OrderPlaceDuration), "one series per result")
+}
+
+func TestRepositoryObserver_Since(t *testing.T) {
+	m := metrics.New()
+
+	m.Repository("order").Since("GetByID", time.Now())
+	m.Repository("order").Since("Save", time.Now())
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.DBQueryDuration))
+}
+
+func TestConsumerObserver_Handled(t *testing.T) {
+	m := metrics.New()
+	started := time.Now()
+
+	m.Consumer("order-events", "shipping").Handled(started.Add(-90*time.Second), started, nil)
+	m.Consumer("order-events", "shipping").Handled(time.Time{}, started, errors.New("warehouse unavailable"))
+
+	assert.InDelta(t, 90.0, testutil.ToFloat64(m.ConsumerLag.WithLabelValues("order-events", "shipping")), 0.001, "messages without a publish time leave the lag alone")
+	assert.Equal(t, 2, testutil.CollectAndCount(m.ConsumerDuration), "one series per result")
+}
+
+func TestWarmupObserver(t *testing.T) {
+	m := metrics.New()
+	observer := m.CacheWarmup()
+
+	observer.Pending(2)
+	observer.Warmed("quota plans", 12, 40*time.Millisecond, nil)
+	observer.Warmed("credentials", 5, time.Second, errors.New("db down"))
+	observer.Pending(0)
+
+	assert.Equal(t, 12.0, testutil.ToFloat64(m.WarmupEntries.WithLabelValues("quota plans")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.WarmupEntries), "failed caches report no entries")
+	assert.Equal(t, 2, testutil.CollectAndCount(m.WarmupDuration))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.WarmupPending))
+}
+
+func TestShutdownObserver(t *testing.T) {
+	m := metrics.New()
+	observer := m.Shutdown()
+
+	observer.Stopped("http server", 2*time.Second, nil)
+	observer.Stopped("job worker", 20*time.Second, context.DeadlineExceeded)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.ShutdownDuration), "one series per component and result")
+}
+
+func TestCanaryObserver(t *testing.T) {
+	m := metrics.New()
+	observer := m.Canary()
+
+	observer.Exposed("pricing", canary.Stable)
+	observer.Exposed("pricing", canary.Stable)
+	observer.Exposed("pricing", canary.Candidate)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.CanaryExposures.WithLabelValues("pricing", "stable")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.CanaryExposures.WithLabelValues("pricing", "candidate")))
+}
+
+func TestBuild(t *testing.T) {
+	m := metrics.New()
+
+	m.Build(buildinfo.Info{Version: "1.4.0", Commit: "abc123", GoVersion: "go1.23.0"})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.BuildInfo.WithLabelValues("1.4.0", "abc123", "go1.23.0")))
+}
+
+func TestHandler_ExposesMetrics(t *testing.T) {
+	m := metrics.New()
+	m.OrdersPlaced.Inc()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(body), "orders_placed_total 1")
+	assert.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,83 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzip(t *testing.T) {
+	large := `{"items":"` + strings.Repeat("a", 2048) + `"}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		accept      string
+		compressed  bool
+	}{
+		{name: "large JSON", contentType: "application/json", body: large, accept: "gzip, deflate", compressed: true},
+		{name: "small JSON", contentType: "application/json", body: `{"ok":true}`, accept: "gzip"},
+		{name: "not accepted", contentType: "application/json", body: large, accept: "gzip;q=0"},
+		{name: "CSV export", contentType: "text/csv", body: strings.Repeat("a,b\n", 1024), accept: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := middleware.Gzip(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.accept)
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Contains(t, rec.Header().Values("Vary"), "Accept-Encoding")
+			if !tt.compressed {
+				assert.Empty(t, rec.Header().Get("Content-Encoding"))
+				assert.Equal(t, tt.body, rec.Body.String())
+				return
+			}
+			assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+			assert.Less(t, rec.Body.Len(), len(tt.body))
+			body := decompress(t, rec.Body.Bytes())
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+func TestGzip_FlushSendsUncompressed(t *testing.T) {
+	h := middleware.Gzip(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"first":1}`))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte(strings.Repeat(" ", 2048)))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	assert.True(t, rec.Flushed)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"first":1}`+strings.Repeat(" ", 2048), rec.Body.String())
+}
+
+func decompress(t *testing.T, body []byte) string {
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(content)
+}
@@ -0,0 +1,60 @@
+// Package middleware holds the request and response handling every API request goes through:
+// request IDs, access logging, panic recovery, CORS and gzip compression. Each is a Middleware,
+// and Chain stacks them around the router.
+package middleware
+
+import (
+	"net/http"
+)
+
+// Middleware wraps a handler with behaviour of its own
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h in middlewares, the first of them outermost: Chain(h, a, b) serves a(b(h))
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// recorder remembers the status and size of a response for the middleware wrapping it
+type recorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 && status >= 200 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher, which streaming responses such as exports assert
+func (r *recorder) Flush() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap gives http.ResponseController access to the wrapped writer
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// started reports whether the status line has been sent, after which the response cannot change
+func (r *recorder) started() bool {
+	return r.status != 0
+}
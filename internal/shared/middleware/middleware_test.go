@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/logging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_FirstIsOutermost(t *testing.T) {
+	var order []string
+	mark := func(name string) middleware.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := middleware.Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		order = append(order, "handler")
+	}), mark("a"), mark("b"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"a", "b", "handler"}, order)
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		kept   bool
+	}{
+		{name: "propagated", header: "0f8fad5b-d9cb-469f-a165-70867728950e", kept: true},
+		{name: "generated", header: ""},
+		{name: "malformed replaced", header: "id with\nnewline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(middleware.RequestIDHeader, tt.header)
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rec.Header().Get(middleware.RequestIDHeader))
+			if tt.kept {
+				assert.Equal(t, tt.header, seen)
+			} else {
+				assert.NotEqual(t, tt.header, seen)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, "json", "info"))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	h := middleware.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}), middleware.RequestID, middleware.AccessLog)
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "http request", record["msg"])
+	assert.Equal(t, "POST", record["method"])
+	assert.Equal(t, "/orders", record["path"])
+	assert.Equal(t, float64(http.StatusCreated), record["status"])
+	assert.Equal(t, float64(5), record["bytes"])
+	assert.Equal(t, "req-1", record["request_id"])
+}
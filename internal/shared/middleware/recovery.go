@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+)
+
+// Recovery turns a panicking handler into a 500 with the usual ErrorResponse body, logging the
+// panic and its stack. A panic after the response started cannot be answered any more; the
+// connection is then aborted so the client does not take a truncated body for a complete one.
+// http.ErrAbortHandler, which handlers panic with to abort on purpose, is passed on unlogged.
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &recorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+			slog.ErrorContext(r.Context(), "panic serving request",
+				"method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			if rec.started() {
+				panic(http.ErrAbortHandler)
+			}
+			httpx.WriteErrorStatus(w, http.StatusInternalServerError, errors.New(http.StatusText(http.StatusInternalServerError)))
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/logging"
+)
+
+// RequestIDHeader carries the ID of a request from the caller, and back in the response
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern accepts the IDs proxies and clients commonly generate, e.g. UUIDs, while
+// keeping arbitrary text out of the logs
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID binds the ID of the request to its context, where logging adds it to every record,
+// and returns it in the X-Request-ID response header. The ID the caller sent is kept when it is
+// well-formed, so a request can be followed across services; otherwise a new one is generated.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,76 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// ErrIncompatibleSchema is returned when the database schema is too far from the one the binary was built for
+var ErrIncompatibleSchema = errors.New("incompatible database schema")
+
+// SchemaMigration records every schema version applied to the database
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	AppliedAt time.Time
+}
+
+// AppliedVersion returns the highest recorded schema version, 0 for a database never migrated
+func AppliedVersion(ctx context.Context, db *gorm.DB) (int, error) {
+	if err := db.WithContext(ctx).AutoMigrate(&SchemaMigration{}); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := db.WithContext(persistence.WithPrimary(ctx)).Model(&SchemaMigration{}).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, persistence.TranslateError(err)
+}
+
+// Record marks version as applied; recording it again is a no-op
+func Record(ctx context.Context, db *gorm.DB, version int) error {
+	err := db.WithContext(ctx).
+		Where(SchemaMigration{Version: version}).
+		Attrs(SchemaMigration{AppliedAt: time.Now()}).
+		FirstOrCreate(&SchemaMigration{}).Error
+	return persistence.TranslateError(err)
+}
+
+// Mode is how the service may use the database once the schema has been checked
+type Mode string
+
+const (
+	ModeReadWrite Mode = "read_write"
+	ModeReadOnly  Mode = "read_only"
+)
+
+// Gate compares the schema version a binary expects with the applied one. During a rolling deploy
+// old and new binaries run side by side, so versions within Tolerance of each other must stay
+// compatible (expand/contract migrations); anything further apart is refused or served read-only.
+type Gate struct {
+	Expected  int
+	Tolerance int
+	// ReadOnlyOnMismatch serves reads instead of refusing to start when the versions are too far apart
+	ReadOnlyOnMismatch bool
+}
+
+// Check returns the mode for the applied version, or ErrIncompatibleSchema when the service must not serve
+func (g Gate) Check(applied int) (Mode, error) {
+	drift := applied - g.Expected
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift <= g.Tolerance {
+		return ModeReadWrite, nil
+	}
+	if g.ReadOnlyOnMismatch {
+		return ModeReadOnly, nil
+	}
+	return "", fmt.Errorf("%w: binary expects version %d, database is at %d (tolerance %d)",
+		ErrIncompatibleSchema, g.Expected, applied, g.Tolerance)
+}
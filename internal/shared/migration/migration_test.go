@@ -0,0 +1,57 @@
+package migration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/migration"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGate_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		gate    migration.Gate
+		applied int
+		mode    migration.Mode
+		wantErr bool
+	}{
+		{name: "same version", gate: migration.Gate{Expected: 3, Tolerance: 1}, applied: 3, mode: migration.ModeReadWrite},
+		{name: "old binary during rollout", gate: migration.Gate{Expected: 3, Tolerance: 1}, applied: 4, mode: migration.ModeReadWrite},
+		{name: "database two versions ahead", gate: migration.Gate{Expected: 3, Tolerance: 1}, applied: 5, wantErr: true},
+		{name: "database behind without migrating", gate: migration.Gate{Expected: 3}, applied: 2, wantErr: true},
+		{name: "read-only fallback", gate: migration.Gate{Expected: 3, Tolerance: 1, ReadOnlyOnMismatch: true}, applied: 5, mode: migration.ModeReadOnly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := tt.gate.Check(tt.applied)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, migration.ErrIncompatibleSchema)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mode, mode)
+		})
+	}
+}
+
+func TestRecordAndAppliedVersion(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	version, err := migration.AppliedVersion(ctx, db)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version, "a fresh database has no version")
+
+	assert.NoError(t, migration.Record(ctx, db, 1))
+	assert.NoError(t, migration.Record(ctx, db, 2))
+	assert.NoError(t, migration.Record(ctx, db, 2), "recording twice is idempotent")
+
+	version, err = migration.AppliedVersion(ctx, db)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+}
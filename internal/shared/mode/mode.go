@@ -0,0 +1,84 @@
+// Package mode separates test traffic from real traffic. Requests of sandbox tenants are routed to the
+// sandbox endpoints of external providers and the data they create is tagged, so test orders never
+// charge a real card or email a real customer.
+package mode
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+)
+
+// Mode selects the live or sandbox endpoints of external adapters
+type Mode string
+
+const (
+	Live    Mode = "live"
+	Sandbox Mode = "sandbox"
+)
+
+// Of returns the mode of data tagged as sandbox or not
+func Of(sandbox bool) Mode {
+	if sandbox {
+		return Sandbox
+	}
+	return Live
+}
+
+func (m Mode) IsSandbox() bool {
+	return m == Sandbox
+}
+
+// Parse accepts "live" and "sandbox"
+func Parse(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case Live, Sandbox:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown mode %q, expected %s or %s", s, Live, Sandbox)
+	}
+}
+
+// Resolver decides the mode of a tenant: Default, unless the tenant is one of SandboxTenants
+type Resolver struct {
+	Default        Mode
+	SandboxTenants []string
+}
+
+func (r Resolver) Of(tenantID string) Mode {
+	if slices.Contains(r.SandboxTenants, tenantID) {
+		return Sandbox
+	}
+	if r.Default == "" {
+		return Live
+	}
+	return r.Default
+}
+
+type contextKey struct{}
+
+// With binds a mode to ctx, e.g. the mode of a stored payment before it is captured by a job
+func With(ctx context.Context, m Mode) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the mode bound to ctx; contexts without one, such as background jobs, are live
+func FromContext(ctx context.Context) Mode {
+	if m, ok := ctx.Value(contextKey{}).(Mode); ok {
+		return m
+	}
+	return Live
+}
+
+// Middleware binds the mode of the request's tenant; it must run inside tenant.Middleware
+func Middleware(r Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := With(req.Context(), r.Of(tenant.FromContext(req.Context())))
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
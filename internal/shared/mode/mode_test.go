@@ -0,0 +1,44 @@
+package mode_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolver_Of(t *testing.T) {
+	r := mode.Resolver{Default: mode.Live, SandboxTenants: []string{"acme-test"}}
+
+	assert.Equal(t, mode.Sandbox, r.Of("acme-test"))
+	assert.Equal(t, mode.Live, r.Of("acme"))
+	assert.Equal(t, mode.Sandbox, mode.Resolver{Default: mode.Sandbox}.Of("acme"))
+	assert.Equal(t, mode.Live, mode.Resolver{}.Of("acme"))
+}
+
+func TestFromContext_DefaultsToLive(t *testing.T) {
+	assert.Equal(t, mode.Live, mode.FromContext(context.Background()))
+	assert.Equal(t, mode.Sandbox, mode.FromContext(mode.With(context.Background(), mode.Sandbox)))
+}
+
+func TestMiddleware(t *testing.T) {
+	var seen mode.Mode
+	handler := tenant.Middleware(mode.Middleware(mode.Resolver{SandboxTenants: []string{"acme-test"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = mode.FromContext(r.Context())
+		}),
+	))
+
+	for header, want := range map[string]mode.Mode{"acme-test": mode.Sandbox, "acme": mode.Live} {
+		req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		req.Header.Set(tenant.Header, header)
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, want, seen, header)
+	}
+}
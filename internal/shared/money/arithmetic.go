@@ -0,0 +1,105 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// RoundingMode decides how a result between two minor units is rounded
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero, e.g. 2.5 -> 3 and -2.5 -> -3
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even neighbour, e.g. 2.5 -> 2 and 3.5 -> 4
+	RoundHalfEven
+	// RoundDown truncates towards zero
+	RoundDown
+)
+
+// Add returns m + o. The zero Money has no currency and adopts the currency of the other operand,
+// so it can start a running total.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := commonCurrency(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}, nil
+}
+
+// Sub returns m - o under the same currency rules as Add
+func (m Money) Sub(o Money) (Money, error) {
+	currency, err := commonCurrency(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: currency}, nil
+}
+
+// Mul returns m times n, e.g. the total of n units priced at m
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// MulRatio returns m * num / den rounded to the minor unit, e.g. MulRatio(19, 100, RoundHalfUp) for 19% tax
+func (m Money) MulRatio(num, den int64, mode RoundingMode) Money {
+	if den == 0 {
+		panic("money: MulRatio with zero denominator")
+	}
+
+	n := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	return Money{Amount: round(n, big.NewInt(den), mode).Int64(), Currency: m.Currency}
+}
+
+// round returns n / d rounded to an integer by mode
+func round(n, d *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+
+	// Compare the remainder with half the denominator to decide whether to move q away from zero
+	twiceRemainder := new(big.Int).Abs(r)
+	twiceRemainder.Lsh(twiceRemainder, 1)
+	cmp := twiceRemainder.Cmp(new(big.Int).Abs(d))
+	awayFromZero := false
+	switch mode {
+	case RoundHalfUp:
+		awayFromZero = cmp >= 0
+	case RoundHalfEven:
+		awayFromZero = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	}
+	if awayFromZero && r.Sign() != 0 {
+		if n.Sign() == d.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Sum adds amounts of the same currency; the sum of no amounts is the zero Money
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, m := range amounts {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func commonCurrency(a, b Money) (string, error) {
+	switch {
+	case a.Currency == b.Currency:
+		return a.Currency, nil
+	case a.Currency == "" && a.Amount == 0:
+		return b.Currency, nil
+	case b.Currency == "" && b.Amount == 0:
+		return a.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+}
@@ -0,0 +1,72 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney_AddAndSub(t *testing.T) {
+	a := money.Money{Amount: 1250, Currency: "EUR"}
+	b := money.Money{Amount: 250, Currency: "EUR"}
+
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 1500, Currency: "EUR"}, sum)
+
+	diff, err := b.Sub(a)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: -1000, Currency: "EUR"}, diff)
+
+	_, err = a.Add(money.Money{Amount: 1, Currency: "USD"})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestMoney_ZeroAdoptsCurrency(t *testing.T) {
+	sum, err := money.Money{}.Add(money.Money{Amount: 5, Currency: "GBP"})
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 5, Currency: "GBP"}, sum)
+
+	total, err := money.Sum(
+		money.Money{Amount: 100, Currency: "USD"},
+		money.Money{Amount: 250, Currency: "USD"},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 350, Currency: "USD"}, total)
+
+	_, err = money.Sum(money.Money{Amount: 1, Currency: "USD"}, money.Money{Amount: 1, Currency: "EUR"})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestMoney_Mul(t *testing.T) {
+	assert.Equal(t, money.Money{Amount: 5997, Currency: "USD"}, money.Money{Amount: 1999, Currency: "USD"}.Mul(3))
+}
+
+func TestMoney_MulRatio(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		num    int64
+		den    int64
+		mode   money.RoundingMode
+		want   int64
+	}{
+		{"exact", 1000, 19, 100, money.RoundHalfUp, 190},
+		{"half up", 250, 1, 100, money.RoundHalfUp, 3},
+		{"half up negative", -250, 1, 100, money.RoundHalfUp, -3},
+		{"half even rounds down to even", 250, 1, 100, money.RoundHalfEven, 2},
+		{"half even rounds up to even", 350, 1, 100, money.RoundHalfEven, 4},
+		{"half even above half", 251, 1, 100, money.RoundHalfEven, 3},
+		{"down", 199, 1, 100, money.RoundDown, 1},
+		{"down negative", -199, 1, 100, money.RoundDown, -1},
+		{"one third", 1000, 1, 3, money.RoundHalfUp, 333},
+		{"no overflow", 1 << 60, 8, 16, money.RoundHalfUp, 1 << 59},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := money.Money{Amount: tt.amount, Currency: "EUR"}.MulRatio(tt.num, tt.den, tt.mode)
+			assert.Equal(t, money.Money{Amount: tt.want, Currency: "EUR"}, got)
+		})
+	}
+}
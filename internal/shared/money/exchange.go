@@ -0,0 +1,89 @@
+package money
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var ErrUnknownRate = errors.New("no exchange rate")
+
+// CurrencyConverter converts amounts into other currencies at current exchange rates
+type CurrencyConverter interface {
+	// Convert returns m in the currency to; the zero Money and amounts already in to are returned
+	// as they are
+	Convert(ctx context.Context, m Money, to string) (Money, error)
+}
+
+// ExchangeRates are the rates of currencies against Base as published on Date
+type ExchangeRates struct {
+	Base string
+	// Rates are the units of each currency one unit of Base buys, e.g. "USD": 1.0842 for base EUR
+	Rates map[string]*big.Rat
+	Date  time.Time
+}
+
+// Rate returns the units of currency one unit of Base buys
+func (r ExchangeRates) Rate(currency string) (*big.Rat, bool) {
+	if currency == r.Base {
+		return big.NewRat(1, 1), true
+	}
+	rate, ok := r.Rates[currency]
+	return rate, ok && rate.Sign() > 0
+}
+
+// Convert converts m into the currency to. Rates between two currencies other than Base are
+// crossed through Base. The amount is converted exactly and rounded once, by mode, to the minor
+// unit of to, so converting 1.00 USD into JPY yields whole yen and into BHD thousandths of a dinar.
+func (r ExchangeRates) Convert(m Money, to string, mode RoundingMode) (Money, error) {
+	target, err := New(0, to)
+	if err != nil {
+		return Money{}, err
+	}
+	if m.Currency == "" || m.Currency == target.Currency {
+		return m, nil
+	}
+
+	fromRate, ok := r.Rate(m.Currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w for %s", ErrUnknownRate, m.Currency)
+	}
+	toRate, ok := r.Rate(target.Currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w for %s", ErrUnknownRate, target.Currency)
+	}
+
+	// amount / 10^from exponent / fromRate * toRate * 10^to exponent
+	n := new(big.Int).Mul(big.NewInt(m.Amount), toRate.Num())
+	n.Mul(n, fromRate.Denom())
+	n.Mul(n, pow10(minorUnitExponent(target.Currency)))
+	d := new(big.Int).Mul(toRate.Denom(), fromRate.Num())
+	d.Mul(d, pow10(minorUnitExponent(m.Currency)))
+
+	amount := round(n, d, mode)
+	if !amount.IsInt64() {
+		return Money{}, fmt.Errorf("%s in %s is out of range", m, target.Currency)
+	}
+	target.Amount = amount.Int64()
+	return target, nil
+}
+
+// ParseRoundingMode reads the name of a rounding mode: half_up, half_even or down
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	switch name {
+	case "half_up":
+		return RoundHalfUp, nil
+	case "half_even":
+		return RoundHalfEven, nil
+	case "down":
+		return RoundDown, nil
+	default:
+		return 0, fmt.Errorf("must be one of half_up, half_even, down, got %q", name)
+	}
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
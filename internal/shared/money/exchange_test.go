@@ -0,0 +1,63 @@
+package money_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func rates() money.ExchangeRates {
+	rate := func(s string) *big.Rat {
+		r, _ := new(big.Rat).SetString(s)
+		return r
+	}
+	return money.ExchangeRates{
+		Base: "EUR",
+		Rates: map[string]*big.Rat{
+			"USD": rate("1.25"),
+			"JPY": rate("160"),
+			"BHD": rate("0.4"),
+		},
+	}
+}
+
+func TestExchangeRates_Convert(t *testing.T) {
+	tests := []struct {
+		name string
+		in   money.Money
+		to   string
+		mode money.RoundingMode
+		want money.Money
+	}{
+		{"from base", money.Money{Amount: 1000, Currency: "EUR"}, "USD", money.RoundHalfUp, money.Money{Amount: 1250, Currency: "USD"}},
+		{"into base", money.Money{Amount: 1250, Currency: "USD"}, "eur", money.RoundHalfUp, money.Money{Amount: 1000, Currency: "EUR"}},
+		{"crossed through base", money.Money{Amount: 125, Currency: "USD"}, "JPY", money.RoundHalfUp, money.Money{Amount: 160, Currency: "JPY"}},
+		{"into three decimals", money.Money{Amount: 100, Currency: "EUR"}, "BHD", money.RoundHalfUp, money.Money{Amount: 400, Currency: "BHD"}},
+		{"half up", money.Money{Amount: 2, Currency: "EUR"}, "USD", money.RoundHalfUp, money.Money{Amount: 3, Currency: "USD"}},
+		{"half even", money.Money{Amount: 2, Currency: "EUR"}, "USD", money.RoundHalfEven, money.Money{Amount: 2, Currency: "USD"}},
+		{"down", money.Money{Amount: 3, Currency: "EUR"}, "USD", money.RoundDown, money.Money{Amount: 3, Currency: "USD"}},
+		{"negative half up", money.Money{Amount: -2, Currency: "EUR"}, "USD", money.RoundHalfUp, money.Money{Amount: -3, Currency: "USD"}},
+		{"same currency", money.Money{Amount: 7, Currency: "USD"}, "USD", money.RoundHalfUp, money.Money{Amount: 7, Currency: "USD"}},
+		{"unpriced", money.Money{}, "USD", money.RoundHalfUp, money.Money{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates().Convert(tt.in, tt.to, tt.mode)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExchangeRates_ConvertUnknownCurrency(t *testing.T) {
+	_, err := rates().Convert(money.Money{Amount: 100, Currency: "EUR"}, "CHF", money.RoundHalfUp)
+	assert.ErrorIs(t, err, money.ErrUnknownRate)
+
+	_, err = rates().Convert(money.Money{Amount: 100, Currency: "CHF"}, "EUR", money.RoundHalfUp)
+	assert.ErrorIs(t, err, money.ErrUnknownRate)
+
+	_, err = rates().Convert(money.Money{Amount: 100, Currency: "EUR"}, "dollars", money.RoundHalfUp)
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+}
@@ -0,0 +1,171 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCurrency = errors.New("invalid currency code")
+
+// Money is an amount expressed in the minor unit of an ISO 4217 currency (e.g. cents for USD)
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New validates the currency code and returns a Money value
+func New(amount int64, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !isCurrencyCode(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Parse reads a decimal amount such as "12.99" in the major unit of the currency, e.g. dollars,
+// rejecting more decimal places than the currency's minor unit has
+func Parse(amount, currency string) (Money, error) {
+	m, err := New(0, currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	amount = strings.TrimSpace(amount)
+	whole, fraction, _ := strings.Cut(amount, ".")
+	exponent := minorUnitExponent(m.Currency)
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places", amount, exponent)
+	}
+	negative := strings.HasPrefix(whole, "-")
+	digits := strings.TrimPrefix(whole, "-") + fraction
+	if digits == "" || strings.ContainsFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	minor, err := strconv.ParseInt(digits+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	if negative {
+		minor = -minor
+	}
+	m.Amount = minor
+	return m, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String formats the amount using its minor unit exponent, e.g. "12.34 USD"
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", formatMinorUnits(m.Amount, minorUnitExponent(m.Currency)), m.Currency)
+}
+
+// Major formats the amount in major units without the currency, e.g. "12.34"; it is the
+// inverse of Parse
+func (m Money) Major() string {
+	return formatMinorUnits(m.Amount, minorUnitExponent(m.Currency))
+}
+
+// Value implements driver.Valuer, persisting money as "<minor units> <currency>"
+func (m Money) Value() (driver.Value, error) {
+	if m.Currency == "" {
+		return nil, nil
+	}
+	return fmt.Sprintf("%d %s", m.Amount, m.Currency), nil
+}
+
+// Scan implements sql.Scanner for values written by Value
+func (m *Money) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+		*m = Money{}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+
+	parts := strings.Fields(raw)
+	if len(parts) != 2 {
+		return fmt.Errorf("cannot scan %q into Money", raw)
+	}
+	amount, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Money: %w", raw, err)
+	}
+	parsed, err := New(amount, parts[1])
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := New(raw.Amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// minorUnitExponent returns the number of decimal places of the currency's minor unit
+func minorUnitExponent(currency string) int {
+	switch currency {
+	case "JPY", "KRW", "VND", "CLP", "ISK":
+		return 0
+	case "BHD", "KWD", "OMR", "JOD", "TND":
+		return 3
+	default:
+		return 2
+	}
+}
+
+func formatMinorUnits(amount int64, exponent int) string {
+	if exponent == 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := fmt.Sprintf("%0*d", exponent+1, amount)
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package money_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_RejectsInvalidCurrency(t *testing.T) {
+	_, err := money.New(100, "dollars")
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+
+	m, err := money.New(100, " usd ")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", m.Currency)
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "12.34 USD", money.Money{Amount: 1234, Currency: "USD"}.String())
+	assert.Equal(t, "-0.05 EUR", money.Money{Amount: -5, Currency: "EUR"}.String())
+	assert.Equal(t, "500 JPY", money.Money{Amount: 500, Currency: "JPY"}.String())
+	assert.Equal(t, "1.250 KWD", money.Money{Amount: 1250, Currency: "KWD"}.String())
+}
+
+func TestMoney_ValueScanRoundTrip(t *testing.T) {
+	original := money.Money{Amount: 1999, Currency: "EUR"}
+
+	value, err := original.Value()
+	assert.NoError(t, err)
+
+	var scanned money.Money
+	assert.NoError(t, scanned.Scan(value))
+	assert.Equal(t, original, scanned)
+
+	assert.Error(t, scanned.Scan("garbage"))
+}
+
+func TestMoney_JSONRoundTrip(t *testing.T) {
+	data, err := json.Marshal(money.Money{Amount: 250, Currency: "GBP"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":250,"currency":"GBP"}`, string(data))
+
+	var decoded money.Money
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, money.Money{Amount: 250, Currency: "GBP"}, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":1,"currency":"X"}`), &decoded))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		want             money.Money
+	}{
+		{"12.99", "usd", money.Money{Amount: 1299, Currency: "USD"}},
+		{"12.5", "EUR", money.Money{Amount: 1250, Currency: "EUR"}},
+		{"-0.05", "EUR", money.Money{Amount: -5, Currency: "EUR"}},
+		{" 500 ", "JPY", money.Money{Amount: 500, Currency: "JPY"}},
+		{"1.250", "KWD", money.Money{Amount: 1250, Currency: "KWD"}},
+	}
+	for _, tt := range tests {
+		got, err := money.Parse(tt.amount, tt.currency)
+		assert.NoError(t, err, tt.amount)
+		assert.Equal(t, tt.want, got, tt.amount)
+	}
+
+	for _, invalid := range []string{"", "1.999", "12,99", "1e3", "-", "."} {
+		_, err := money.Parse(invalid, "USD")
+		assert.Error(t, err, invalid)
+	}
+	_, err := money.Parse("1", "dollars")
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+}
+
+func TestMoney_Major(t *testing.T) {
+	assert.Equal(t, "12.50", money.Money{Amount: 1250, Currency: "EUR"}.Major())
+	assert.Equal(t, "-0.05", money.Money{Amount: -5, Currency: "EUR"}.Major())
+	assert.Equal(t, "500", money.Money{Amount: 500, Currency: "JPY"}.Major())
+	assert.Equal(t, "1.250", money.Money{Amount: 1250, Currency: "KWD"}.Major())
+}
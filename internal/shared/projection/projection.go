@@ -0,0 +1,64 @@
+// Package projection keeps read models up to date from the outbox log of integration events.
+//
+// A Projection handles messages like a broker consumer would, but reads them from the log, so it
+// can be rebuilt after a bug fix by replaying the history still in the outbox. The Runner applies
+// new messages as they are stored and records a checkpoint per projection, the log position of
+// the last message it handled; Replay moves the checkpoint back and streams the history again.
+package projection
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Projection builds a read model from messages. Messages can be handled more than once, e.g. after
+// a replay, so Handle must be idempotent; messages it has no use for are ignored.
+type Projection interface {
+	// Name identifies the projection on the command line and in its checkpoint, e.g. "order_summary"
+	Name() string
+	Handle(ctx context.Context, topic string, msg messaging.Message) error
+}
+
+// Log is the part of messaging.Outbox projections read from
+type Log interface {
+	Log(ctx context.Context, after int64, limit int) ([]messaging.LogEntry, error)
+	PositionAt(ctx context.Context, t time.Time) (int64, error)
+}
+
+// Checkpoint is the log position up to which a projection handled every message
+type Checkpoint struct {
+	Projection string `gorm:"primaryKey;type:varchar(100)"`
+	Position   int64  `gorm:"not null"`
+	UpdatedAt  time.Time
+}
+
+func (Checkpoint) TableName() string {
+	return "projection_checkpoints"
+}
+
+// Checkpoints stores the checkpoint of each projection
+type Checkpoints struct {
+	db *gorm.DB
+}
+
+func NewCheckpoints(db *gorm.DB) *Checkpoints {
+	return &Checkpoints{db: db}
+}
+
+// Get returns the position of a projection, or 0, the start of the log, when it has none yet
+func (c *Checkpoints) Get(ctx context.Context, name string) (int64, error) {
+	var cp Checkpoint
+	err := c.db.WithContext(ctx).Where("projection = ?", name).Limit(1).Find(&cp).Error
+	return cp.Position, persistence.TranslateError(err)
+}
+
+func (c *Checkpoints) Set(ctx context.Context, name string, position int64) error {
+	err := c.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&Checkpoint{Projection: name, Position: position, UpdatedAt: time.Now()}).Error
+	return persistence.TranslateError(err)
+}
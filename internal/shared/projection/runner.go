@@ -0,0 +1,186 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Runner feeds the registered projections from the log, each from its own checkpoint
+type Runner struct {
+	log         Log
+	checkpoints *Checkpoints
+	projections map[string]Projection
+	interval    time.Duration
+	batchSize   int
+
+	stop   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewRunner(log Log, checkpoints *Checkpoints, interval time.Duration, batchSize int, projections ...Projection) *Runner {
+	r := &Runner{
+		log:         log,
+		checkpoints: checkpoints,
+		projections: make(map[string]Projection, len(projections)),
+		interval:    interval,
+		batchSize:   batchSize,
+	}
+	for _, p := range projections {
+		r.projections[p.Name()] = p
+	}
+	return r
+}
+
+// Names lists the registered projections in alphabetical order
+func (r *Runner) Names() []string {
+	names := make([]string, 0, len(r.projections))
+	for name := range r.projections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start catches every projection up every interval until Stop
+func (r *Runner) Start() {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+
+			for name, p := range r.projections {
+				if n, err := r.run(ctx, p, 0); err != nil {
+					slog.ErrorContext(ctx, "updating projection failed", "projection", name, "handled", n, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (r *Runner) Stop() {
+	_ = r.Drain(context.Background())
+}
+
+// Drain stops the runner once the catch-up in progress is done. When ctx ends first, the
+// catch-up stops after the message it is handling and saves its checkpoint there, so the next
+// start resumes from it; a projection that ignores the cancellation is still waited for.
+func (r *Runner) Drain(ctx context.Context) error {
+	if r.stop == nil {
+		return nil
+	}
+	close(r.stop)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+	}
+	r.cancel()
+	<-r.done
+	slog.WarnContext(ctx, "projection drain timed out, stopped at the last checkpoint")
+	return nil
+}
+
+// CatchUp handles the messages stored after the checkpoint of the projection, at most rate per
+// second when rate is positive, and returns how many it handled
+func (r *Runner) CatchUp(ctx context.Context, name string, rate int) (int, error) {
+	p, ok := r.projections[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown projection %q", name)
+	}
+	return r.run(ctx, p, rate)
+}
+
+// Replay moves the checkpoint of the projection back to the first message stored at or after from
+// and handles every message since, at most rate per second when rate is positive. An interrupted
+// replay has saved its checkpoint, so CatchUp resumes it where it stopped.
+func (r *Runner) Replay(ctx context.Context, name string, from time.Time, rate int) (int, error) {
+	p, ok := r.projections[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown projection %q", name)
+	}
+
+	position, err := r.log.PositionAt(ctx, from)
+	if err != nil {
+		return 0, fmt.Errorf("find log position at %s: %w", from.Format(time.RFC3339), err)
+	}
+	if err := r.checkpoints.Set(ctx, name, position); err != nil {
+		return 0, fmt.Errorf("reset checkpoint of %s: %w", name, err)
+	}
+	return r.run(ctx, p, rate)
+}
+
+// run handles the log after the checkpoint of p until its end, saving the checkpoint after every
+// batch and after the last message handled before a failure
+func (r *Runner) run(ctx context.Context, p Projection, rate int) (int, error) {
+	position, err := r.checkpoints.Get(ctx, p.Name())
+	if err != nil {
+		return 0, fmt.Errorf("get checkpoint of %s: %w", p.Name(), err)
+	}
+
+	var pace <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		pace = ticker.C
+	}
+
+	handled := 0
+	for {
+		entries, err := r.log.Log(ctx, position, r.batchSize)
+		if err != nil {
+			return handled, fmt.Errorf("read log after %d: %w", position, err)
+		}
+
+		for _, entry := range entries {
+			if pace != nil {
+				select {
+				case <-ctx.Done():
+				case <-pace:
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return handled, r.save(ctx, p.Name(), position, err)
+			}
+			if err := p.Handle(ctx, entry.Topic, entry.Message); err != nil {
+				err = fmt.Errorf("handle %s %s at position %d: %w", entry.Message.Type, entry.Message.ID, entry.Position, err)
+				return handled, r.save(ctx, p.Name(), position, err)
+			}
+			position = entry.Position
+			handled++
+		}
+
+		if len(entries) > 0 {
+			if err := r.save(ctx, p.Name(), position, nil); err != nil {
+				return handled, err
+			}
+		}
+		if len(entries) < r.batchSize {
+			return handled, nil
+		}
+	}
+}
+
+// save stores the checkpoint and returns cause, or the failure to store it
+func (r *Runner) save(ctx context.Context, name string, position int64, cause error) error {
+	// A cancelled ctx must not keep the progress made so far from being saved
+	if err := r.checkpoints.Set(context.WithoutCancel(ctx), name, position); err != nil {
+		return fmt.Errorf("save checkpoint of %s: %w", name, err)
+	}
+	return cause
+}
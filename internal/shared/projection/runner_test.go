@@ -0,0 +1,156 @@
+package projection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/projection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// recordingProjection remembers the IDs it handled and fails on the ID in failOn
+type recordingProjection struct {
+	handled []string
+	failOn  string
+}
+
+func (p *recordingProjection) Name() string { return "recording" }
+
+func (p *recordingProjection) Handle(ctx context.Context, topic string, msg messaging.Message) error {
+	if msg.ID == p.failOn {
+		return errors.New("projection bug")
+	}
+	p.handled = append(p.handled, msg.ID)
+	return nil
+}
+
+var start = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// setupLog stores messages m1..m5, one hour apart from start
+func setupLog(t *testing.T) (*messaging.Outbox, *projection.Checkpoints) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&messaging.OutboxMessage{}, &projection.Checkpoint{}))
+
+	outbox := messaging.NewOutbox(db)
+	for i, id := range []string{"m1", "m2", "m3", "m4", "m5"} {
+		msg := messaging.Message{ID: id, Type: "order.placed", Payload: []byte(`{}`), Time: start.Add(time.Duration(i) * time.Hour)}
+		require.NoError(t, outbox.Publish(context.Background(), "orders", msg))
+	}
+	return outbox, projection.NewCheckpoints(db)
+}
+
+func TestRunner_CatchUpResumesFromCheckpoint(t *testing.T) {
+	outbox, checkpoints := setupLog(t)
+	p := &recordingProjection{}
+	runner := projection.NewRunner(outbox, checkpoints, time.Second, 2, p)
+	ctx := context.Background()
+
+	n, err := runner.CatchUp(ctx, "recording", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	require.NoError(t, outbox.Publish(ctx, "orders", messaging.Message{ID: "m6", Type: "order.placed", Payload: []byte(`{}`)}))
+	n, err = runner.CatchUp(ctx, "recording", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"m1", "m2", "m3", "m4", "m5", "m6"}, p.handled)
+}
+
+func TestRunner_ReplayFrom(t *testing.T) {
+	outbox, checkpoints := setupLog(t)
+	p := &recordingProjection{}
+	runner := projection.NewRunner(outbox, checkpoints, time.Second, 2, p)
+	ctx := context.Background()
+	_, err := runner.CatchUp(ctx, "recording", 0)
+	require.NoError(t, err)
+	p.handled = nil
+
+	n, err := runner.Replay(ctx, "recording", start.Add(150*time.Minute), 1000)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"m4", "m5"}, p.handled)
+}
+
+func TestRunner_FailureKeepsCheckpointOfLastHandledMessage(t *testing.T) {
+	outbox, checkpoints := setupLog(t)
+	p := &recordingProjection{failOn: "m4"}
+	runner := projection.NewRunner(outbox, checkpoints, time.Second, 10, p)
+	ctx := context.Background()
+
+	n, err := runner.Replay(ctx, "recording", start, 0)
+	assert.ErrorContains(t, err, "projection bug")
+	assert.Equal(t, 3, n)
+
+	// Once the bug is fixed, catching up resumes the replay at the failed message
+	p.failOn = ""
+	n, err = runner.CatchUp(ctx, "recording", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"m1", "m2", "m3", "m4", "m5"}, p.handled)
+}
+
+func TestRunner_ReplayStopsWhenCancelled(t *testing.T) {
+	outbox, checkpoints := setupLog(t)
+	p := &recordingProjection{}
+	runner := projection.NewRunner(outbox, checkpoints, time.Second, 10, p)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	n, err := runner.Replay(ctx, "recording", start, 5)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, n, "the rate allows one message per 200ms")
+	position, err := checkpoints.Get(context.Background(), "recording")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), position)
+}
+
+// slowProjection handles m1 and then blocks on m2 until its context ends
+type slowProjection struct {
+	started chan struct{}
+}
+
+func (p *slowProjection) Name() string { return "slow" }
+
+func (p *slowProjection) Handle(ctx context.Context, topic string, msg messaging.Message) error {
+	if msg.ID == "m1" {
+		return nil
+	}
+	close(p.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRunner_DrainKeepsCheckpointWhenOutOfTime(t *testing.T) {
+	outbox, checkpoints := setupLog(t)
+	p := &slowProjection{started: make(chan struct{})}
+	runner := projection.NewRunner(outbox, checkpoints, 10*time.Millisecond, 10, p)
+	runner.Start()
+	<-p.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, runner.Drain(ctx))
+
+	position, err := checkpoints.Get(context.Background(), "slow")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), position, "the next start resumes after m1")
+}
+
+func TestRunner_UnknownProjection(t *testing.T) {
+	outbox, checkpoints := setupLog(t)
+	runner := projection.NewRunner(outbox, checkpoints, time.Second, 10, &recordingProjection{})
+
+	_, err := runner.Replay(context.Background(), "order_summary", start, 0)
+
+	assert.ErrorContains(t, err, `unknown projection "order_summary"`)
+	assert.Equal(t, []string{"recording"}, runner.Names())
+}
@@ -0,0 +1,110 @@
+// Package publicid generates the opaque identifiers used for users, products and orders in
+// external APIs, so auto-increment primary keys never leak and cannot be enumerated.
+package publicid
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// encoding is Crockford's base32 alphabet, which sorts like the bytes it encodes
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// length is the size of a ULID in base32 characters
+const length = 26
+
+// New returns a prefixed ULID such as "ord_01HXM3Q6Z9V4S8T2K7N1B5C0DE". ULIDs start with their
+// creation time, so IDs of the same kind sort roughly by age while the 80 random bits stay unguessable.
+func New(prefix string) string {
+	var id [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic(fmt.Sprintf("publicid: read random bytes: %v", err))
+	}
+	return prefix + "_" + encode(id)
+}
+
+// Derive returns the public ID that key always maps to, so fixtures keep their IDs across databases.
+// Derived IDs carry no creation time and must not be used for records created through the API.
+func Derive(prefix, key string) string {
+	sum := sha256.Sum256([]byte(prefix + "_" + key))
+	var id [16]byte
+	copy(id[:], sum[:])
+	return prefix + "_" + encode(id)
+}
+
+// encode renders the 128 bits as 26 base32 characters, the first holding the top 3 bits
+func encode(id [16]byte) string {
+	var out [length]byte
+	var acc uint64
+	bits := 2 // pad the 128 bits to 130 so they split evenly into groups of five
+	n := 0
+	for _, b := range id {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[n] = encoding[(acc>>bits)&31]
+			n++
+		}
+	}
+	return string(out[:])
+}
+
+// Valid reports whether s is a public ID with the given prefix
+func Valid(prefix, s string) bool {
+	id, ok := strings.CutPrefix(s, prefix+"_")
+	if !ok || len(id) != length || id[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(encoding, id[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// backfillBatch is how many rows Backfill updates per statement batch
+const backfillBatch = 500
+
+// Backfill assigns public IDs to the rows of table created before the public_id column existed,
+// returning how many were updated
+func Backfill(ctx context.Context, db *gorm.DB, table, prefix string) (int, error) {
+	total := 0
+	for {
+		var ids []int64
+		err := db.WithContext(ctx).Table(table).
+			Where("public_id IS NULL OR public_id = ''").
+			Order("id").Limit(backfillBatch).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, fmt.Errorf("list %s without public ID: %w", table, err)
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, id := range ids {
+				if err := tx.Table(table).Where("id = ?", id).Update("public_id", New(prefix)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, fmt.Errorf("backfill %s public IDs: %w", table, err)
+		}
+		total += len(ids)
+	}
+}
@@ -0,0 +1,89 @@
+package publicid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestNew(t *testing.T) {
+	first := New("ord")
+	time.Sleep(2 * time.Millisecond)
+	second := New("ord")
+
+	assert.Len(t, first, len("ord_")+length)
+	assert.True(t, Valid("ord", first))
+	assert.NotEqual(t, first, second)
+	assert.Less(t, first, second, "IDs sort by creation time")
+}
+
+func TestDerive(t *testing.T) {
+	id := Derive("prd", "desk")
+
+	assert.True(t, Valid("prd", id))
+	assert.Equal(t, id, Derive("prd", "desk"))
+	assert.NotEqual(t, id, Derive("prd", "lamp"))
+	assert.NotEqual(t, id[len("prd_"):], Derive("usr", "desk")[len("usr_"):], "the prefix is part of the hash")
+}
+
+func TestEncode(t *testing.T) {
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+
+	assert.Equal(t, "00000000000000000000000000", encode([16]byte{}))
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encode(max))
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"usr_01HXM3Q6Z9V4S8T2K7N1B5C0DE", true},
+		{"prd_01HXM3Q6Z9V4S8T2K7N1B5C0DE", false},
+		{"usr_01HXM3Q6Z9V4S8T2K7N1B5C0D", false},
+		{"usr_81HXM3Q6Z9V4S8T2K7N1B5C0DE", false},
+		{"usr_01HXM3Q6Z9V4S8T2K7N1B5C0DU", false},
+		{"42", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, Valid("usr", tt.id), tt.id)
+	}
+}
+
+type widget struct {
+	ID       int64   `gorm:"primaryKey"`
+	PublicID *string `gorm:"type:varchar(32);uniqueIndex"`
+}
+
+func TestBackfill(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&widget{}))
+	existing := New("wdg")
+	assert.NoError(t, db.Create(&[]widget{{}, {PublicID: &existing}, {}}).Error)
+
+	n, err := Backfill(context.Background(), db, "widgets", "wdg")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	var widgets []widget
+	assert.NoError(t, db.Order("id").Find(&widgets).Error)
+	for _, w := range widgets {
+		if assert.NotNil(t, w.PublicID) {
+			assert.True(t, Valid("wdg", *w.PublicID))
+		}
+	}
+	assert.Equal(t, existing, *widgets[1].PublicID, "assigned IDs are kept")
+
+	n, err = Backfill(context.Background(), db, "widgets", "wdg")
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,84 @@
+// Package repair runs one-off fixes of stored data, such as normalizing values written before a
+// validation existed, as reviewed code instead of ad-hoc SQL.
+//
+// A Script repairs rows in batches, walking a table in primary key order. The Runner applies each
+// batch in its own transaction and stores the progress of the run in repair_runs, so an
+// interrupted or failed run resumes after the last committed batch. A dry run rolls every batch
+// back and only reports what would change. Model writes made by a script are recorded in the audit
+// log with the actor "repair:<script>".
+package repair
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownScript    = errors.New("unknown repair script")
+	ErrAlreadyCompleted = errors.New("repair script already completed")
+)
+
+// Script is a data repair applied in batches of rows after a cursor, usually the primary key
+type Script interface {
+	// Name identifies the script on the command line and in repair_runs, e.g. "normalize-user-emails"
+	Name() string
+	// Description says what the script fixes
+	Description() string
+	// Batch repairs up to limit rows after the cursor in cursor order. It runs in a transaction
+	// bound to ctx, so writes must use persistence.Conn; returning an error rolls the batch back.
+	Batch(ctx context.Context, after int64, limit int) (Batch, error)
+}
+
+// Batch is the outcome of one batch of a script
+type Batch struct {
+	// Last is the cursor of the last row looked at; the next batch starts after it
+	Last int64
+	// Scanned counts the rows looked at; fewer than the limit ends the run
+	Scanned int
+	// Changed counts the rows repaired
+	Changed int
+}
+
+// RunStatus is where a run of a script is
+type RunStatus string
+
+const (
+	StatusRunning     RunStatus = "RUNNING"
+	StatusInterrupted RunStatus = "INTERRUPTED"
+	StatusFailed      RunStatus = "FAILED"
+	StatusCompleted   RunStatus = "COMPLETED"
+)
+
+// Run records a run of a script: who started it, how far it got and what it changed
+type Run struct {
+	ID     int64  `gorm:"primaryKey"`
+	Script string `gorm:"type:varchar(100);not null;index"`
+	DryRun bool   `gorm:"not null"`
+	// Operator is the OS user who started the run
+	Operator string    `gorm:"type:varchar(100);not null"`
+	Status   RunStatus `gorm:"type:varchar(20);not null"`
+	Cursor   int64     `gorm:"not null"`
+	Scanned  int64     `gorm:"not null"`
+	Changed  int64     `gorm:"not null"`
+	// Error is why the run failed; a resumed run clears it
+	Error      string `gorm:"type:text"`
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	UpdatedAt  time.Time
+}
+
+func (Run) TableName() string {
+	return "repair_runs"
+}
+
+type runKey struct{}
+
+// Actor names the script ctx runs in as "repair:<script>"; ok is false outside a repair run
+func Actor(ctx context.Context) (actor string, ok bool) {
+	name, ok := ctx.Value(runKey{}).(string)
+	if !ok {
+		return "", false
+	}
+	return "repair:" + name, true
+}
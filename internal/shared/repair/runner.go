@@ -0,0 +1,174 @@
+package repair
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// errDryRun rolls back the transaction of a dry run batch
+var errDryRun = errors.New("dry run")
+
+// Options tune a run
+type Options struct {
+	// DryRun rolls every batch back; dry runs are recorded but never resumed
+	DryRun bool
+	// BatchSize is how many rows a batch looks at at most
+	BatchSize int
+	// Again starts a new run of a script whose last run completed
+	Again bool
+	// Operator is recorded as who started the run
+	Operator string
+}
+
+// Runner runs the registered scripts and records their runs
+type Runner struct {
+	db      *gorm.DB
+	tx      persistence.Transactor
+	scripts map[string]Script
+	now     func() time.Time
+}
+
+func NewRunner(db *gorm.DB, scripts ...Script) *Runner {
+	r := &Runner{
+		db:      db,
+		tx:      persistence.NewGormTransactor(db),
+		scripts: make(map[string]Script, len(scripts)),
+		now:     time.Now,
+	}
+	for _, s := range scripts {
+		r.scripts[s.Name()] = s
+	}
+	return r
+}
+
+// Scripts lists the registered scripts in alphabetical order
+func (r *Runner) Scripts() []Script {
+	scripts := make([]Script, 0, len(r.scripts))
+	for _, s := range r.scripts {
+		scripts = append(scripts, s)
+	}
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].Name() < scripts[j].Name() })
+	return scripts
+}
+
+// Run applies a script batch by batch until it scans a partial batch, ctx ends or a batch fails.
+// A real run resumes the last unfinished real run of the script, and refuses to repeat a
+// completed one unless opts.Again is set. The returned run is recorded even when err is not nil.
+func (r *Runner) Run(ctx context.Context, name string, opts Options) (*Run, error) {
+	s, ok := r.scripts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownScript, name)
+	}
+	if opts.BatchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be positive, got %d", opts.BatchSize)
+	}
+
+	run, err := r.start(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "repair run started", "script", name, "run", run.ID, "dry_run", run.DryRun, "cursor", run.Cursor)
+
+	ctx = context.WithValue(ctx, runKey{}, name)
+	for {
+		if err := ctx.Err(); err != nil {
+			return run, r.finish(ctx, run, StatusInterrupted, err)
+		}
+
+		// A started batch finishes even when ctx ends, so an interrupted run stops between batches
+		var batch Batch
+		err := r.tx.InTransaction(context.WithoutCancel(ctx), func(ctx context.Context) error {
+			var err error
+			if batch, err = s.Batch(ctx, run.Cursor, opts.BatchSize); err != nil {
+				return err
+			}
+			if opts.DryRun {
+				return errDryRun
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errDryRun) {
+			return run, r.finish(ctx, run, StatusFailed, fmt.Errorf("batch after %d: %w", run.Cursor, err))
+		}
+
+		if batch.Scanned > 0 {
+			run.Cursor = batch.Last
+		}
+		run.Scanned += int64(batch.Scanned)
+		run.Changed += int64(batch.Changed)
+		if batch.Scanned < opts.BatchSize {
+			return run, r.finish(ctx, run, StatusCompleted, nil)
+		}
+		if err := r.save(ctx, run); err != nil {
+			return run, err
+		}
+		slog.InfoContext(ctx, "repair progress", "script", name, "run", run.ID, "cursor", run.Cursor, "scanned", run.Scanned, "changed", run.Changed)
+	}
+}
+
+// Runs returns the runs of a script, newest first
+func (r *Runner) Runs(ctx context.Context, name string) ([]Run, error) {
+	var runs []Run
+	err := r.db.WithContext(ctx).Where("script = ?", name).Order("id DESC").Find(&runs).Error
+	return runs, persistence.TranslateError(err)
+}
+
+// start resumes the unfinished run of a real run or records a new one
+func (r *Runner) start(ctx context.Context, name string, opts Options) (*Run, error) {
+	if !opts.DryRun {
+		var last Run
+		err := r.db.WithContext(ctx).Where("script = ? AND dry_run = ?", name, false).
+			Order("id DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			return nil, fmt.Errorf("find last run of %s: %w", name, persistence.TranslateError(err))
+		}
+		switch {
+		case last.ID != 0 && last.Status != StatusCompleted:
+			last.Status = StatusRunning
+			last.Error = ""
+			return &last, r.save(ctx, &last)
+		case last.ID != 0 && !opts.Again:
+			return nil, fmt.Errorf("%w: %s finished run %d at %s", ErrAlreadyCompleted, name, last.ID, last.FinishedAt.Format(time.RFC3339))
+		}
+	}
+
+	run := &Run{Script: name, DryRun: opts.DryRun, Operator: opts.Operator, Status: StatusRunning, StartedAt: r.now()}
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, fmt.Errorf("record run of %s: %w", name, persistence.TranslateError(err))
+	}
+	return run, nil
+}
+
+// finish records how the run ended and returns cause, or the failure to record it
+func (r *Runner) finish(ctx context.Context, run *Run, status RunStatus, cause error) error {
+	run.Status = status
+	if cause != nil {
+		run.Error = cause.Error()
+	}
+	if status == StatusCompleted {
+		finishedAt := r.now()
+		run.FinishedAt = &finishedAt
+	}
+	if err := r.save(ctx, run); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "repair run stopped", "script", run.Script, "run", run.ID, "status", run.Status,
+		"scanned", run.Scanned, "changed", run.Changed, "error", run.Error)
+	return cause
+}
+
+func (r *Runner) save(ctx context.Context, run *Run) error {
+	// A cancelled ctx must not keep the progress made so far from being saved
+	err := r.db.WithContext(context.WithoutCancel(ctx)).Save(run).Error
+	if err != nil {
+		return fmt.Errorf("save run %d of %s: %w", run.ID, run.Script, persistence.TranslateError(err))
+	}
+	return nil
+}
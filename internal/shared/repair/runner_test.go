@@ -0,0 +1,149 @@
+package repair
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   int64 `gorm:"primaryKey"`
+	Name string
+}
+
+// upperCaseScript upper-cases widget names and fails once it reaches failAt
+type upperCaseScript struct {
+	db     *gorm.DB
+	failAt int64
+	actors []string
+	// afterBatch runs once a batch is done, e.g. to interrupt the run
+	afterBatch func()
+}
+
+func (s *upperCaseScript) Name() string        { return "upper-case-widgets" }
+func (s *upperCaseScript) Description() string { return "upper-case widget names" }
+
+func (s *upperCaseScript) Batch(ctx context.Context, after int64, limit int) (Batch, error) {
+	actor, _ := Actor(ctx)
+	s.actors = append(s.actors, actor)
+
+	conn := persistence.Conn(ctx, s.db)
+	var widgets []widget
+	if err := conn.Where("id > ?", after).Order("id").Limit(limit).Find(&widgets).Error; err != nil {
+		return Batch{}, err
+	}
+	batch := Batch{Scanned: len(widgets)}
+	for _, w := range widgets {
+		if w.ID == s.failAt {
+			return batch, errors.New("boom")
+		}
+		batch.Last = w.ID
+		if upper := strings.ToUpper(w.Name); upper != w.Name {
+			if err := conn.Model(&widget{ID: w.ID}).Update("name", upper).Error; err != nil {
+				return batch, err
+			}
+			batch.Changed++
+		}
+	}
+	if s.afterBatch != nil {
+		s.afterBatch()
+	}
+	return batch, nil
+}
+
+func setupRunner(t *testing.T, widgets int) (*Runner, *upperCaseScript, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&Run{}, &widget{}))
+	for i := 0; i < widgets; i++ {
+		require.NoError(t, db.Create(&widget{Name: "widget"}).Error)
+	}
+
+	script := &upperCaseScript{db: db}
+	return NewRunner(db, script), script, db
+}
+
+func names(t *testing.T, db *gorm.DB) []string {
+	var names []string
+	require.NoError(t, db.Model(&widget{}).Order("id").Pluck("name", &names).Error)
+	return names
+}
+
+func TestRunner_DryRunRollsBack(t *testing.T) {
+	runner, script, db := setupRunner(t, 5)
+
+	run, err := runner.Run(context.Background(), script.Name(), Options{DryRun: true, BatchSize: 2, Operator: "ops"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, run.Status)
+	assert.EqualValues(t, 5, run.Scanned)
+	assert.EqualValues(t, 5, run.Changed, "a dry run reports what would change")
+	assert.Equal(t, []string{"widget", "widget", "widget", "widget", "widget"}, names(t, db))
+	assert.Equal(t, "repair:upper-case-widgets", script.actors[0])
+
+	// Dry runs don't count as completing the script
+	run, err = runner.Run(context.Background(), script.Name(), Options{BatchSize: 2})
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, run.Changed)
+	assert.Equal(t, []string{"WIDGET", "WIDGET", "WIDGET", "WIDGET", "WIDGET"}, names(t, db))
+}
+
+func TestRunner_ResumesAfterFailure(t *testing.T) {
+	runner, script, db := setupRunner(t, 5)
+	ctx := context.Background()
+
+	script.failAt = 4
+	run, err := runner.Run(ctx, script.Name(), Options{BatchSize: 2})
+	assert.EqualError(t, err, "batch after 2: boom")
+	assert.Equal(t, StatusFailed, run.Status)
+	assert.EqualValues(t, 2, run.Cursor, "the failed batch is rolled back")
+	assert.Equal(t, []string{"WIDGET", "WIDGET", "widget", "widget", "widget"}, names(t, db))
+
+	script.failAt = 0
+	resumed, err := runner.Run(ctx, script.Name(), Options{BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, run.ID, resumed.ID)
+	assert.Equal(t, StatusCompleted, resumed.Status)
+	assert.Empty(t, resumed.Error)
+	assert.EqualValues(t, 5, resumed.Changed)
+	assert.Equal(t, []string{"WIDGET", "WIDGET", "WIDGET", "WIDGET", "WIDGET"}, names(t, db))
+
+	_, err = runner.Run(ctx, script.Name(), Options{BatchSize: 2})
+	assert.ErrorIs(t, err, ErrAlreadyCompleted)
+	again, err := runner.Run(ctx, script.Name(), Options{BatchSize: 2, Again: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, run.ID, again.ID)
+	assert.Zero(t, again.Changed)
+
+	runs, err := runner.Runs(ctx, script.Name())
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+}
+
+func TestRunner_InterruptedRunKeepsProgress(t *testing.T) {
+	runner, script, db := setupRunner(t, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	script.afterBatch = cancel
+
+	run, err := runner.Run(ctx, script.Name(), Options{BatchSize: 2})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, StatusInterrupted, run.Status)
+	assert.EqualValues(t, 2, run.Cursor)
+
+	var stored Run
+	require.NoError(t, db.First(&stored, run.ID).Error)
+	assert.Equal(t, StatusInterrupted, stored.Status)
+	assert.EqualValues(t, 2, stored.Changed)
+
+	_, err = runner.Run(context.Background(), "missing", Options{BatchSize: 2})
+	assert.ErrorIs(t, err, ErrUnknownScript)
+}
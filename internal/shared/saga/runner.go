@@ -0,0 +1,189 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// Runner runs the registered sagas and records them
+type Runner struct {
+	db     *gorm.DB
+	stores map[string]*gorm.DB
+	sagas  map[string]Definition
+	now    func() time.Time
+}
+
+// NewRunner records sagas in db and runs their steps on stores
+func NewRunner(db *gorm.DB, stores ...Store) *Runner {
+	r := &Runner{
+		db:     db,
+		stores: make(map[string]*gorm.DB, len(stores)),
+		sagas:  make(map[string]Definition),
+		now:    time.Now,
+	}
+	for _, s := range stores {
+		r.stores[s.Name] = s.DB
+	}
+	return r
+}
+
+// Register adds a saga, checking that the stores of its steps are known
+func (r *Runner) Register(def Definition) error {
+	if len(def.Steps) == 0 {
+		return fmt.Errorf("saga %s has no steps", def.Name)
+	}
+	for _, step := range def.Steps {
+		if _, ok := r.stores[step.Store]; !ok {
+			return fmt.Errorf("step %s of saga %s changes unknown store %q", step.Name, def.Name, step.Store)
+		}
+		if step.Do == nil {
+			return fmt.Errorf("step %s of saga %s does nothing", step.Name, def.Name)
+		}
+	}
+	r.sagas[def.Name] = def
+	return nil
+}
+
+// Start records a saga and runs it. When a step fails, the steps before it are compensated and
+// the error of the step is returned; a saga whose compensation fails too is left COMPENSATING for
+// Resume. The returned instance is recorded even when err is not nil. A started saga runs to the
+// end even when ctx ends, since leaving it halfway would only hand it to Resume.
+func (r *Runner) Start(ctx context.Context, name string, payload any) (*Instance, error) {
+	def, ok := r.sagas[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSaga, name)
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload of saga %s: %w", name, err)
+	}
+
+	inst := &Instance{Saga: name, Payload: string(encoded), Status: StatusRunning}
+	if err := r.db.WithContext(ctx).Create(inst).Error; err != nil {
+		return nil, fmt.Errorf("record saga %s: %w", name, persistence.TranslateError(err))
+	}
+	return inst, r.run(context.WithoutCancel(ctx), def, inst)
+}
+
+// Resume continues the RUNNING and COMPENSATING sagas not updated for stale, long enough for a
+// saga still being started to have moved on. It returns how many sagas it continued and the
+// errors of those that failed again.
+func (r *Runner) Resume(ctx context.Context, stale time.Duration) (int, error) {
+	var insts []Instance
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", []Status{StatusRunning, StatusCompensating}, r.now().Add(-stale)).
+		Order("id").Find(&insts).Error
+	if err != nil {
+		return 0, fmt.Errorf("find unfinished sagas: %w", persistence.TranslateError(err))
+	}
+
+	var errs []error
+	for i := range insts {
+		inst := &insts[i]
+		def, ok := r.sagas[inst.Saga]
+		if !ok {
+			errs = append(errs, fmt.Errorf("saga %d: %w: %q", inst.ID, ErrUnknownSaga, inst.Saga))
+			continue
+		}
+		slog.InfoContext(ctx, "resuming saga", "saga", inst.Saga, "id", inst.ID, "status", inst.Status, "step", inst.Step)
+		if err := r.run(ctx, def, inst); err != nil {
+			errs = append(errs, fmt.Errorf("saga %d: %w", inst.ID, err))
+		}
+	}
+	return len(insts), errors.Join(errs...)
+}
+
+// run takes a saga from where its instance is to COMPLETED or COMPENSATED
+func (r *Runner) run(ctx context.Context, def Definition, inst *Instance) error {
+	payload := []byte(inst.Payload)
+
+	var failure error
+	for inst.Status == StatusRunning && inst.Step < len(def.Steps) {
+		step := def.Steps[inst.Step]
+		// The failed step counts as done: its store may have committed before the error, e.g. when
+		// the connection dropped on commit, and its marker tells
+		inst.Step++
+		if err := r.apply(ctx, inst, step, PhaseDo, step.Do, payload); err != nil {
+			failure = fmt.Errorf("step %s: %w", step.Name, err)
+			inst.Status = StatusCompensating
+			inst.Error = failure.Error()
+			slog.WarnContext(ctx, "saga step failed, compensating", "saga", def.Name, "id", inst.ID, "step", step.Name, "error", err)
+		}
+		if err := r.save(ctx, inst); err != nil {
+			return errors.Join(failure, err)
+		}
+	}
+	if inst.Status == StatusRunning {
+		inst.Status = StatusCompleted
+		return r.save(ctx, inst)
+	}
+
+	for inst.Step > 0 {
+		step := def.Steps[inst.Step-1]
+		if step.Compensate != nil {
+			if err := r.apply(ctx, inst, step, PhaseCompensate, step.Compensate, payload); err != nil {
+				inst.Error = fmt.Sprintf("compensate step %s: %v", step.Name, err)
+				slog.ErrorContext(ctx, "saga compensation failed", "saga", def.Name, "id", inst.ID, "step", step.Name, "error", err)
+				return errors.Join(failure, r.save(ctx, inst), fmt.Errorf("compensate step %s: %w", step.Name, err))
+			}
+		}
+		inst.Step--
+		if err := r.save(ctx, inst); err != nil {
+			return errors.Join(failure, err)
+		}
+	}
+	inst.Status = StatusCompensated
+	return errors.Join(failure, r.save(ctx, inst))
+}
+
+// apply runs a phase of a step in a transaction of its store together with the marker of the
+// phase. A phase already marked committed before and is skipped, as is the compensation of a
+// step whose Do never committed.
+func (r *Runner) apply(ctx context.Context, inst *Instance, step Step, phase string, fn func(context.Context, []byte) error, payload []byte) error {
+	db := r.stores[step.Store]
+	return persistence.NewGormTransactor(db).InTransaction(ctx, func(ctx context.Context) error {
+		conn := persistence.Conn(ctx, db)
+		var markers []Marker
+		if err := conn.Where("saga_id = ? AND step = ?", inst.ID, step.Name).Find(&markers).Error; err != nil {
+			return fmt.Errorf("read markers: %w", err)
+		}
+		done := make(map[string]bool, len(markers))
+		for _, m := range markers {
+			done[m.Phase] = true
+		}
+		if done[phase] || (phase == PhaseCompensate && !done[PhaseDo]) {
+			return nil
+		}
+
+		if err := fn(ctx, payload); err != nil {
+			return err
+		}
+		return conn.Create(&Marker{SagaID: inst.ID, Step: step.Name, Phase: phase, CreatedAt: r.now()}).Error
+	})
+}
+
+// RegisterJobs adds the handler of ResumeJob to the worker, resuming the sagas not updated for stale
+func (r *Runner) RegisterJobs(w *jobs.Worker, stale time.Duration) {
+	jobs.Register(w, func(ctx context.Context, _ ResumeJob) error {
+		n, err := r.Resume(ctx, stale)
+		if n > 0 {
+			slog.InfoContext(ctx, "resumed unfinished sagas", "count", n)
+		}
+		return err
+	})
+}
+
+func (r *Runner) save(ctx context.Context, inst *Instance) error {
+	if err := r.db.WithContext(ctx).Save(inst).Error; err != nil {
+		return fmt.Errorf("save saga %d: %w", inst.ID, persistence.TranslateError(err))
+	}
+	return nil
+}
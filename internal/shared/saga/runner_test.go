@@ -0,0 +1,199 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stock and charge stand for the tables of an order database and of a payment database
+type stock struct {
+	SKU       string `gorm:"primaryKey"`
+	Available int
+}
+
+type charge struct {
+	ID     int64 `gorm:"primaryKey"`
+	Amount int64
+	Status string
+}
+
+type purchase struct {
+	SKU    string
+	Amount int64
+}
+
+func openStore(t *testing.T, models ...any) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true, Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(append(models, &Marker{})...))
+	return db
+}
+
+// purchaseSaga reserves stock in the orders store, then charges in the payments store, where it
+// also stores a payment event in the outbox
+type purchaseSaga struct {
+	orders, payments *gorm.DB
+	outbox           *messaging.Outbox
+	chargeErr        error
+	releaseErr       error
+	reserved         int
+}
+
+func newPurchaseSaga(t *testing.T) (*purchaseSaga, *Runner) {
+	coordinator := openStore(t, &Instance{})
+	s := &purchaseSaga{orders: openStore(t, &stock{}), payments: openStore(t, &charge{}, &messaging.OutboxMessage{})}
+	s.outbox = messaging.NewOutbox(s.payments)
+	require.NoError(t, s.orders.Create(&stock{SKU: "mug", Available: 5}).Error)
+
+	r := NewRunner(coordinator, Store{Name: "orders", DB: s.orders}, Store{Name: "payments", DB: s.payments})
+	require.NoError(t, r.Register(s.definition()))
+	return s, r
+}
+
+func (s *purchaseSaga) definition() Definition {
+	return Definition{Name: "purchase", Steps: []Step{
+		{Name: "reserve-stock", Store: "orders", Do: s.reserve, Compensate: s.release},
+		{Name: "charge", Store: "payments", Do: s.charge},
+	}}
+}
+
+func (s *purchaseSaga) reserve(ctx context.Context, payload []byte) error {
+	var p purchase
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	s.reserved++
+	return persistence.Conn(ctx, s.orders).Model(&stock{SKU: p.SKU}).Update("available", gorm.Expr("available - 1")).Error
+}
+
+func (s *purchaseSaga) release(ctx context.Context, payload []byte) error {
+	if s.releaseErr != nil {
+		return s.releaseErr
+	}
+	var p purchase
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	return persistence.Conn(ctx, s.orders).Model(&stock{SKU: p.SKU}).Update("available", gorm.Expr("available + 1")).Error
+}
+
+func (s *purchaseSaga) charge(ctx context.Context, payload []byte) error {
+	var p purchase
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	if err := persistence.Conn(ctx, s.payments).Create(&charge{Amount: p.Amount, Status: "CAPTURED"}).Error; err != nil {
+		return err
+	}
+	if err := s.outbox.Publish(ctx, "payment-events", messaging.Message{Type: "payment.captured", Payload: payload}); err != nil {
+		return err
+	}
+	return s.chargeErr
+}
+
+func (s *purchaseSaga) available(t *testing.T) int {
+	var st stock
+	require.NoError(t, s.orders.First(&st, "sku = ?", "mug").Error)
+	return st.Available
+}
+
+func (s *purchaseSaga) counts(t *testing.T) (charges, messages int64) {
+	require.NoError(t, s.payments.Model(&charge{}).Count(&charges).Error)
+	require.NoError(t, s.payments.Model(&messaging.OutboxMessage{}).Count(&messages).Error)
+	return charges, messages
+}
+
+func TestRunner_Start(t *testing.T) {
+	s, r := newPurchaseSaga(t)
+
+	inst, err := r.Start(context.Background(), "purchase", purchase{SKU: "mug", Amount: 1200})
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, inst.Status)
+	assert.Equal(t, 2, inst.Step)
+	assert.Equal(t, 4, s.available(t))
+	charges, messages := s.counts(t)
+	assert.Equal(t, int64(1), charges)
+	assert.Equal(t, int64(1), messages)
+}
+
+func TestRunner_Start_CompensatesFailedStep(t *testing.T) {
+	s, r := newPurchaseSaga(t)
+	s.chargeErr = errors.New("card declined")
+
+	inst, err := r.Start(context.Background(), "purchase", purchase{SKU: "mug", Amount: 1200})
+
+	assert.ErrorIs(t, err, s.chargeErr)
+	assert.Equal(t, StatusCompensated, inst.Status)
+	assert.Equal(t, 0, inst.Step)
+	assert.Contains(t, inst.Error, "step charge: card declined")
+	assert.Equal(t, 5, s.available(t), "the reservation is released")
+	charges, messages := s.counts(t)
+	assert.Zero(t, charges, "the charge rolled back")
+	assert.Zero(t, messages, "the outbox message rolled back with it")
+}
+
+func TestRunner_Resume_SkipsStepCommittedBeforeCrash(t *testing.T) {
+	s, r := newPurchaseSaga(t)
+	payload, _ := json.Marshal(purchase{SKU: "mug", Amount: 1200})
+	// The orders store committed the reservation, then the process stopped before recording it
+	inst := &Instance{Saga: "purchase", Payload: string(payload), Status: StatusRunning}
+	require.NoError(t, r.db.Create(inst).Error)
+	require.NoError(t, r.apply(context.Background(), inst, s.definition().Steps[0], PhaseDo, s.reserve, payload))
+
+	n, err := r.Resume(context.Background(), 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, r.db.First(inst, inst.ID).Error)
+	assert.Equal(t, StatusCompleted, inst.Status)
+	assert.Equal(t, 1, s.reserved, "the reservation is not made twice")
+	assert.Equal(t, 4, s.available(t))
+}
+
+func TestRunner_Resume_RetriesFailedCompensation(t *testing.T) {
+	s, r := newPurchaseSaga(t)
+	s.chargeErr = errors.New("card declined")
+	s.releaseErr = errors.New("orders database unavailable")
+
+	inst, err := r.Start(context.Background(), "purchase", purchase{SKU: "mug", Amount: 1200})
+
+	assert.ErrorIs(t, err, s.releaseErr)
+	assert.Equal(t, StatusCompensating, inst.Status)
+	assert.Equal(t, 1, inst.Step)
+	assert.Equal(t, 4, s.available(t))
+
+	s.releaseErr = nil
+	n, err := r.Resume(context.Background(), 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, r.db.First(inst, inst.ID).Error)
+	assert.Equal(t, StatusCompensated, inst.Status)
+	assert.Equal(t, 5, s.available(t))
+
+	n, err = r.Resume(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Zero(t, n, "finished sagas are left alone")
+}
+
+func TestRunner_Register_UnknownStore(t *testing.T) {
+	r := NewRunner(nil, Store{Name: "orders"})
+
+	err := r.Register(Definition{Name: "purchase", Steps: []Step{{Name: "charge", Store: "payments", Do: func(context.Context, []byte) error { return nil }}}})
+
+	assert.ErrorContains(t, err, `unknown store "payments"`)
+}
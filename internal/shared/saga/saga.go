@@ -0,0 +1,106 @@
+// Package saga coordinates units of work spanning several stores, e.g. an order database and a
+// payment database, which no single transaction covers.
+//
+// A Definition lists the steps of a saga, each changing one Store. The Runner records every saga
+// in saga_instances of its own database and runs the steps in order, each in a transaction of its
+// store that also writes a Marker to saga_markers of that store. That is the first phase: the
+// writes of the step, the messages it stores in the outbox of the store and the marker commit
+// together, or not at all. The Runner then records the step as done in saga_instances, the second
+// phase. A step found marked, because the process stopped between the phases, is not run again.
+//
+// When a step fails, the steps before it are compensated in reverse order, in two phases the same
+// way. A saga interrupted by a crash, or whose compensation failed, stays RUNNING or COMPENSATING
+// until Resume continues it.
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUnknownSaga = errors.New("unknown saga")
+
+// Step is a change of one store
+type Step struct {
+	// Name identifies the step in its markers, e.g. "capture-payment"
+	Name string
+	// Store names the Store the step changes
+	Store string
+	// Do makes the change. It runs in a transaction of the store bound to ctx, so writes must use
+	// persistence.Conn on the database of the store, and must not reach other stores.
+	Do func(ctx context.Context, payload []byte) error
+	// Compensate undoes Do once a later step failed, in a transaction like Do; nil when there is
+	// nothing to undo
+	Compensate func(ctx context.Context, payload []byte) error
+}
+
+// Definition is a saga the Runner can run
+type Definition struct {
+	// Name identifies the saga in saga_instances, e.g. "order-payment"
+	Name  string
+	Steps []Step
+}
+
+// Store is a database the steps of sagas change; it needs the saga_markers table
+type Store struct {
+	Name string
+	DB   *gorm.DB
+}
+
+// Status is where a saga is
+type Status string
+
+const (
+	StatusRunning      Status = "RUNNING"
+	StatusCompleted    Status = "COMPLETED"
+	StatusCompensating Status = "COMPENSATING"
+	StatusCompensated  Status = "COMPENSATED"
+)
+
+// Instance records a saga in the database of the Runner
+type Instance struct {
+	ID   int64  `gorm:"primaryKey"`
+	Saga string `gorm:"type:varchar(100);not null;index"`
+	// Payload is the JSON encoded payload the steps receive
+	Payload string `gorm:"type:text;not null"`
+	Status  Status `gorm:"type:varchar(20);not null;index"`
+	// Step counts the steps that may have changed their store: while running, the steps done;
+	// while compensating, the steps not compensated yet
+	Step int `gorm:"not null"`
+	// Error is why the saga is compensated, and then why its compensation failed
+	Error     string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Instance) TableName() string {
+	return "saga_instances"
+}
+
+// Phases of a Marker
+const (
+	PhaseDo         = "do"
+	PhaseCompensate = "compensate"
+)
+
+// Marker records in the database of a store that a phase of a step committed there
+type Marker struct {
+	SagaID    int64  `gorm:"primaryKey;autoIncrement:false"`
+	Step      string `gorm:"primaryKey;type:varchar(100)"`
+	Phase     string `gorm:"primaryKey;type:varchar(20)"`
+	CreatedAt time.Time
+}
+
+func (Marker) TableName() string {
+	return "saga_markers"
+}
+
+// ResumeJob continues the sagas a crash or a failed compensation left unfinished
+type ResumeJob struct{}
+
+func (ResumeJob) Kind() string {
+	return "saga.resume"
+}
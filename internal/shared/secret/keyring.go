@@ -0,0 +1,110 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNoKeys is returned when encrypting without a configured key
+	ErrNoKeys = errors.New("no encryption key configured")
+	// ErrUnknownKey is returned when a value was encrypted with a key the keyring no longer has
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrMalformed is returned for values that were not produced by Encrypt or were tampered with
+	ErrMalformed = errors.New("malformed encrypted value")
+)
+
+// Keyring encrypts fields at rest with AES-256-GCM. Values are written with the primary key and
+// carry its ID, so keys can be rotated by adding a new primary while older keys still decrypt.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring parses keys in the form "id:base64key,id:base64key"; the first key is the primary.
+// Each key must decode to 32 bytes, e.g. the output of `openssl rand -base64 32`.
+func NewKeyring(spec string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("encryption key %q must be written as id:base64key", entry)
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("encryption key %q is listed twice", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("encryption key %q must be 32 bytes encoded as base64", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+		if k.primary == "" {
+			k.primary = id
+		}
+	}
+	return k, nil
+}
+
+// Encrypt seals plaintext with the primary key as "<key id>:<base64 nonce and ciphertext>"
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if k == nil || k.primary == "" {
+		return "", ErrNoKeys
+	}
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	// The key ID is authenticated so a value cannot be moved to another key's namespace
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.primary))
+	return k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with any key of the keyring
+func (k *Keyring) Decrypt(value string) (string, error) {
+	id, encoded, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	if k == nil {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
+
+// PrimaryKeyID is the ID of the key new values are encrypted with, empty without keys
+func (k *Keyring) PrimaryKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.primary
+}
@@ -0,0 +1,72 @@
+package secret_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oldKey = "old:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	newKey = "new:ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	keyring, err := secret.NewKeyring(oldKey)
+	require.NoError(t, err)
+
+	encrypted, err := keyring.Encrypt("sk_live_123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "old:"))
+	assert.NotContains(t, encrypted, "sk_live_123")
+
+	decrypted, err := keyring.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_live_123", decrypted)
+}
+
+func TestKeyring_RotationKeepsOldValuesReadable(t *testing.T) {
+	before, err := secret.NewKeyring(oldKey)
+	require.NoError(t, err)
+	encrypted, err := before.Encrypt("sk_live_123")
+	require.NoError(t, err)
+
+	after, err := secret.NewKeyring(newKey + "," + oldKey)
+	require.NoError(t, err)
+	assert.Equal(t, "new", after.PrimaryKeyID())
+
+	decrypted, err := after.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_live_123", decrypted)
+
+	retired, err := secret.NewKeyring(newKey)
+	require.NoError(t, err)
+	_, err = retired.Decrypt(encrypted)
+	assert.ErrorIs(t, err, secret.ErrUnknownKey)
+}
+
+func TestKeyring_RejectsTamperedValues(t *testing.T) {
+	keyring, err := secret.NewKeyring(oldKey)
+	require.NoError(t, err)
+	encrypted, err := keyring.Encrypt("sk_live_123")
+	require.NoError(t, err)
+
+	tampered := encrypted[:len(encrypted)-4] + "AAAA"
+	_, err = keyring.Decrypt(tampered)
+	assert.ErrorIs(t, err, secret.ErrMalformed)
+}
+
+func TestNewKeyring_InvalidKeys(t *testing.T) {
+	for _, spec := range []string{"nokey", "short:c2hvcnQ=", oldKey + "," + oldKey} {
+		_, err := secret.NewKeyring(spec)
+		assert.Error(t, err, spec)
+	}
+
+	empty, err := secret.NewKeyring("")
+	require.NoError(t, err)
+	_, err = empty.Encrypt("value")
+	assert.ErrorIs(t, err, secret.ErrNoKeys)
+}
@@ -0,0 +1,15 @@
+package secret
+
+import "context"
+
+// Source returns the current value of a secret, such as a gateway API key that may be rotated at runtime
+type Source interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// Static is a secret that never changes, e.g. one read from the environment at startup
+type Static string
+
+func (s Static) Secret(context.Context) (string, error) {
+	return string(s), nil
+}
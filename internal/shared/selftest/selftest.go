@@ -0,0 +1,126 @@
+// Package selftest verifies the dependencies of a deployment before it takes traffic:
+// `aiiobackend serve --selftest` wires the service as for serving, runs every registered check
+// and exits with a report instead of listening.
+//
+// Adapters owning a dependency implement Check, exercising what the service needs of it rather
+// than only connecting: the database takes a write, the broker a publish, storage a put and get.
+// Checks must leave no trace beyond what consumers already ignore.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Check verifies a dependency
+type Check interface {
+	// Describe names the dependency in the report, e.g. "kafka topic order-events"
+	Describe() string
+	// Check exercises the dependency; it returns an error made by Skip when it is not configured
+	Check(ctx context.Context) error
+}
+
+// Func is a Check made of a function
+type Func struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+func (f Func) Describe() string {
+	return f.Name
+}
+
+func (f Func) Check(ctx context.Context) error {
+	return f.Fn(ctx)
+}
+
+// skipped is the error of a check of a dependency that is not configured
+type skipped struct {
+	reason string
+}
+
+func (s skipped) Error() string {
+	return "skipped: " + s.reason
+}
+
+// Skip reports a dependency as not configured, e.g. Skip("SMTP_HOST is not set")
+func Skip(reason string) error {
+	return skipped{reason: reason}
+}
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Check      string `json:"check"`
+	Status     Status `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	// Error tells why the check failed, or why it was skipped
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the outcome of a self-test; OK is false when any check failed
+type Report struct {
+	OK      bool     `json:"ok"`
+	Results []Result `json:"results"`
+}
+
+// Failed reports a self-test that could not get to its checks, e.g. because the database
+// refused the connection the service is wired with
+func Failed(check string, err error) Report {
+	return Report{Results: []Result{{Check: check, Status: StatusFailed, Error: err.Error()}}}
+}
+
+// Suite runs the registered checks one after the other
+type Suite struct {
+	// Timeout bounds every check; zero leaves it to ctx
+	Timeout time.Duration
+
+	checks []Check
+}
+
+// Register adds checks to be run by Run
+func (s *Suite) Register(checks ...Check) {
+	s.checks = append(s.checks, checks...)
+}
+
+// Run runs every check in registration order; a failing check does not stop the others
+func (s *Suite) Run(ctx context.Context) Report {
+	report := Report{OK: true, Results: make([]Result, 0, len(s.checks))}
+	for _, check := range s.checks {
+		result := s.run(ctx, check)
+		if result.Status == StatusFailed {
+			report.OK = false
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func (s *Suite) run(ctx context.Context, check Check) Result {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := check.Check(ctx)
+	result := Result{Check: check.Describe(), Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+	var skip skipped
+	switch {
+	case errors.As(err, &skip):
+		result.Status, result.Error = StatusSkipped, skip.reason
+	case err != nil:
+		result.Status, result.Error = StatusFailed, err.Error()
+	}
+	return result
+}
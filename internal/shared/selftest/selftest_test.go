@@ -0,0 +1,42 @@
+package selftest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/selftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuite_Run(t *testing.T) {
+	suite := selftest.Suite{Timeout: 20 * time.Millisecond}
+	suite.Register(
+		selftest.Func{Name: "database", Fn: func(context.Context) error { return nil }},
+		selftest.Func{Name: "broker", Fn: func(context.Context) error { return errors.New("connection refused") }},
+		selftest.Func{Name: "smtp", Fn: func(context.Context) error { return selftest.Skip("SMTP_HOST is not set") }},
+		selftest.Func{Name: "storage", Fn: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	report := suite.Run(context.Background())
+
+	assert.False(t, report.OK)
+	require.Len(t, report.Results, 4)
+	assert.Equal(t, selftest.Result{Check: "database", Status: selftest.StatusOK}, report.Results[0])
+	assert.Equal(t, selftest.Result{Check: "broker", Status: selftest.StatusFailed, Error: "connection refused"}, report.Results[1])
+	assert.Equal(t, selftest.Result{Check: "smtp", Status: selftest.StatusSkipped, Error: "SMTP_HOST is not set"}, report.Results[2])
+	assert.Equal(t, selftest.StatusFailed, report.Results[3].Status, "a hanging check is cut off by the timeout")
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Results[3].Error)
+}
+
+func TestSuite_RunSkippedIsOK(t *testing.T) {
+	var suite selftest.Suite
+	suite.Register(selftest.Func{Name: "smtp", Fn: func(context.Context) error { return selftest.Skip("SMTP_HOST is not set") }})
+
+	assert.True(t, suite.Run(context.Background()).OK)
+}
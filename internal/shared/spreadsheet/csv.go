@@ -0,0 +1,36 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM is the byte order mark spreadsheet programs put before CSV exports
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+type csvReader struct {
+	r *csv.Reader
+}
+
+// NewCSVReader streams the rows of a comma separated file; rows may have different numbers of
+// cells and a leading byte order mark is skipped
+func NewCSVReader(r io.Reader) Reader {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(utf8BOM)); bytes.Equal(head, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	return &csvReader{r: cr}
+}
+
+func (c *csvReader) Next() (Row, error) {
+	cells, err := c.r.Read()
+	if err != nil {
+		return Row{}, err
+	}
+	line, _ := c.r.FieldPos(0)
+	return Row{Line: line, Cells: cells}, nil
+}
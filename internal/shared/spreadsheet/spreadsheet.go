@@ -0,0 +1,76 @@
+// Package spreadsheet reads the rows of uploaded CSV and XLSX files, such as product imports.
+//
+// Both formats are read as plain text cells: the CSV reader streams its input, while the XLSX
+// reader needs random access to the zip archive and reads the first worksheet only. Formulas
+// are read as their cached values and styles, including number formats, are ignored.
+package spreadsheet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Format is a file format rows can be read from
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format, expected CSV or XLSX")
+
+// Row is a row of cells; Line is its 1-based line in a CSV file or row number in a worksheet
+type Row struct {
+	Line  int
+	Cells []string
+}
+
+// Empty reports whether every cell of the row is blank
+func (r Row) Empty() bool {
+	for _, cell := range r.Cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader returns the rows of a file in order, then io.EOF
+type Reader interface {
+	Next() (Row, error)
+}
+
+// Open reads the rows of a file of the given format; XLSX needs the size of the file
+func Open(format Format, r io.ReaderAt, size int64) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(io.NewSectionReader(r, 0, size)), nil
+	case FormatXLSX:
+		return NewXLSXReader(r, size)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// DetectFormat tells the format of an upload from its content type, falling back to the
+// extension of its file name
+func DetectFormat(contentType, fileName string) (Format, error) {
+	switch contentType {
+	case "text/csv", "application/csv":
+		return FormatCSV, nil
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return FormatXLSX, nil
+	}
+	switch ext := strings.ToLower(path.Ext(fileName)); ext {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, fileName)
+	}
+}
@@ -0,0 +1,119 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, r Reader) []Row {
+	t.Helper()
+	var rows []Row
+	for {
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	input := "\xEF\xBB\xBFsku,name\nA-1,\"Desk, oak\"\n\nB-2\n"
+	rows := readAll(t, NewCSVReader(strings.NewReader(input)))
+
+	want := []Row{
+		{Line: 1, Cells: []string{"sku", "name"}},
+		{Line: 2, Cells: []string{"A-1", "Desk, oak"}},
+		{Line: 4, Cells: []string{"B-2"}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+}
+
+// buildXLSX writes a workbook with the given parts next to the ones every workbook has
+func buildXLSX(t *testing.T, sheet, sharedStrings string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Products" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+		"xl/worksheets/sheet1.xml": sheet,
+	}
+	if sharedStrings != "" {
+		parts["xl/sharedStrings.xml"] = sharedStrings
+	}
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestXLSXReader(t *testing.T) {
+	data := buildXLSX(t, `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>price</t></is></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="C3"><v>12.99</v></c></row>
+</sheetData></worksheet>`, `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>sku</t></si><si><t>name</t></si><si><r><t>A-</t></r><r><t>1</t></r></si></sst>`)
+
+	r, err := NewXLSXReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	rows := readAll(t, r)
+
+	want := []Row{
+		{Line: 1, Cells: []string{"sku", "name", "price"}},
+		{Line: 3, Cells: []string{"A-1", "", "12.99"}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+}
+
+func TestXLSXReader_RejectsOtherFiles(t *testing.T) {
+	data := []byte("sku,name\n")
+	if _, err := NewXLSXReader(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrInvalidXLSX) {
+		t.Errorf("Expected ErrInvalidXLSX, got %v", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		contentType, fileName string
+		want                  Format
+		wantErr               bool
+	}{
+		{"text/csv", "export", FormatCSV, false},
+		{"application/octet-stream", "Products.XLSX", FormatXLSX, false},
+		{"application/octet-stream", "products.xls", "", true},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.contentType, tt.fileName)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("DetectFormat(%q, %q) = %q, %v", tt.contentType, tt.fileName, got, err)
+		}
+	}
+}
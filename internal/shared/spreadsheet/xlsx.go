@@ -0,0 +1,237 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidXLSX = errors.New("invalid XLSX file")
+
+type xlsxReader struct {
+	sheet   io.Closer
+	decoder *xml.Decoder
+	strings []string
+}
+
+// NewXLSXReader reads the rows of the first worksheet of an Office Open XML workbook. Rows are
+// decoded one at a time; the shared strings table is held in memory.
+func NewXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := sharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidXLSX, sheetPath)
+	}
+	rc, err := sheet.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	return &xlsxReader{sheet: rc, decoder: xml.NewDecoder(rc), strings: shared}, nil
+}
+
+type xlsxRow struct {
+	R     int        `xml:"r,attr"`
+	Cells []xlsxCell `xml:"c"`
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+// xlsxText is a string item, either plain or split into runs of rich text
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+func (x *xlsxReader) Next() (Row, error) {
+	for {
+		tok, err := x.decoder.Token()
+		if err == io.EOF {
+			x.sheet.Close()
+			return Row{}, io.EOF
+		}
+		if err != nil {
+			x.sheet.Close()
+			return Row{}, fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := x.decoder.DecodeElement(&row, &start); err != nil {
+			x.sheet.Close()
+			return Row{}, fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+		}
+		return x.toRow(row)
+	}
+}
+
+// toRow places the cells at their columns; empty cells are often left out of the sheet
+func (x *xlsxReader) toRow(row xlsxRow) (Row, error) {
+	var cells []string
+	for i, c := range row.Cells {
+		col := i
+		if c.Ref != "" {
+			col = columnIndex(c.Ref)
+			if col < 0 {
+				return Row{}, fmt.Errorf("%w: invalid cell reference %q", ErrInvalidXLSX, c.Ref)
+			}
+		}
+		value, err := x.cellValue(c)
+		if err != nil {
+			return Row{}, err
+		}
+		for len(cells) <= col {
+			cells = append(cells, "")
+		}
+		cells[col] = value
+	}
+	return Row{Line: row.R, Cells: cells}, nil
+}
+
+func (x *xlsxReader) cellValue(c xlsxCell) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(x.strings) {
+			return "", fmt.Errorf("%w: cell %s refers to unknown shared string %q", ErrInvalidXLSX, c.Ref, c.Value)
+		}
+		return x.strings[i], nil
+	case "inlineStr":
+		return c.Inline.String(), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return c.Value, nil
+	}
+}
+
+// columnIndex turns the letters of a cell reference such as "AB12" into a 0-based column
+func columnIndex(ref string) int {
+	col := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return -1
+	}
+	return col - 1
+}
+
+// firstSheetPath follows the workbook's first sheet to its part through the workbook relationships
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: the workbook has no sheets", ErrInvalidXLSX)
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		// Targets are relative to xl/ unless they start at the root of the package
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("%w: the first sheet has no part", ErrInvalidXLSX)
+}
+
+// sharedStrings reads the strings table cells of type "s" index into; workbooks without text have none
+func sharedStrings(files map[string]*zip.File) ([]string, error) {
+	if _, ok := files["xl/sharedStrings.xml"]; !ok {
+		return nil, nil
+	}
+	var table struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := decodePart(files, "xl/sharedStrings.xml", &table); err != nil {
+		return nil, err
+	}
+	values := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		values[i] = item.String()
+	}
+	return values, nil
+}
+
+func decodePart(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: missing %s", ErrInvalidXLSX, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidXLSX, name, err)
+	}
+	return nil
+}
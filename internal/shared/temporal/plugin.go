@@ -0,0 +1,259 @@
+package temporal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idsKey holds the primary keys of the rows an update or delete is about to change
+const idsKey = "temporal:ids"
+
+// Plugin keeps the history tables of the tracked tables. Every create, update and delete made
+// through GORM models closes the current version of the rows it touched and, unless they were
+// deleted, adds their new version read back from the table. Versions are written on the
+// connection of the write, which puts them in the same transaction. Raw SQL is not tracked.
+type Plugin struct {
+	tables map[string]bool
+	now    func() time.Time
+}
+
+// NewPlugin tracks the given tables, whose models must have a single int64 primary key
+func NewPlugin(tables ...string) *Plugin {
+	p := &Plugin{
+		tables: make(map[string]bool, len(tables)),
+		now:    func() time.Time { return time.Now().UTC() },
+	}
+	for _, t := range tables {
+		p.tables[t] = true
+	}
+	return p
+}
+
+func (p *Plugin) Name() string {
+	return "temporal"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().After("gorm:create").Register("temporal:after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("temporal:before_update", p.captureIDs); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("temporal:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("temporal:before_delete", p.captureIDs); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("temporal:after_delete", p.afterDelete)
+}
+
+func (p *Plugin) tracked(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && stmt.Schema != nil && len(stmt.Schema.PrimaryFields) == 1 && p.tables[stmt.Table]
+}
+
+func (p *Plugin) afterCreate(db *gorm.DB) {
+	if !p.tracked(db) || db.RowsAffected == 0 {
+		return
+	}
+	// Upserts go through here too, so created rows are versioned like updated ones
+	p.recordVersions(db, primaryKeysOf(db.Statement))
+}
+
+// captureIDs stores the rows an update or delete matches; writes matching nothing are not tracked
+func (p *Plugin) captureIDs(db *gorm.DB) {
+	if !p.tracked(db) {
+		return
+	}
+	conditions := conditionsOf(db.Statement)
+	if len(conditions) == 0 {
+		return
+	}
+
+	var ids []int64
+	err := p.session(db).
+		Table(db.Statement.Table).
+		Clauses(clause.Where{Exprs: conditions}).
+		Pluck(db.Statement.Schema.PrimaryFields[0].DBName, &ids).Error
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("temporal: load rows before write: %w", err))
+		return
+	}
+	db.InstanceSet(idsKey, ids)
+}
+
+func (p *Plugin) afterUpdate(db *gorm.DB) {
+	if !p.tracked(db) {
+		return
+	}
+	p.recordVersions(db, p.capturedIDs(db))
+}
+
+func (p *Plugin) afterDelete(db *gorm.DB) {
+	if !p.tracked(db) {
+		return
+	}
+
+	now := p.now()
+	for _, id := range p.capturedIDs(db) {
+		if err := p.closeVersion(db, id, now); err != nil {
+			_ = db.AddError(fmt.Errorf("temporal: close version of deleted row %d: %w", id, err))
+			return
+		}
+	}
+}
+
+func (p *Plugin) capturedIDs(db *gorm.DB) []int64 {
+	value, _ := db.InstanceGet(idsKey)
+	ids, _ := value.([]int64)
+	return ids
+}
+
+// recordVersions reads the rows back and versions each one that differs from its current version,
+// so writes that change nothing add no version
+func (p *Plugin) recordVersions(db *gorm.DB, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+	stmt := db.Statement
+	pk := stmt.Schema.PrimaryFields[0]
+
+	var rows []map[string]any
+	err := p.session(db).
+		Table(stmt.Table).
+		Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: toValues(ids)}).
+		Find(&rows).Error
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("temporal: load written rows: %w", err))
+		return
+	}
+
+	now := p.now()
+	for _, row := range rows {
+		id, err := toInt64(row[pk.DBName])
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("temporal: read primary key: %w", err))
+			return
+		}
+		data, err := encode(row)
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("temporal: encode row %d: %w", id, err))
+			return
+		}
+		if err := p.recordVersion(db, id, data, now); err != nil {
+			_ = db.AddError(fmt.Errorf("temporal: record version of row %d: %w", id, err))
+			return
+		}
+	}
+}
+
+func (p *Plugin) recordVersion(db *gorm.DB, id int64, data string, now time.Time) error {
+	history := HistoryTable(db.Statement.Table)
+
+	var current Version
+	err := p.session(db).Table(history).
+		Where("record_id = ? AND valid_to IS NULL", id).
+		Order("id DESC").
+		Limit(1).
+		Find(&current).Error
+	if err != nil {
+		return err
+	}
+	if current.ID != 0 && current.Data == data {
+		return nil
+	}
+
+	if err := p.closeVersion(db, id, now); err != nil {
+		return err
+	}
+	return p.session(db).Table(history).Create(&Version{RecordID: id, Data: data, ValidFrom: now}).Error
+}
+
+func (p *Plugin) closeVersion(db *gorm.DB, id int64, now time.Time) error {
+	return p.session(db).Table(HistoryTable(db.Statement.Table)).
+		Where("record_id = ? AND valid_to IS NULL", id).
+		Update("valid_to", now).Error
+}
+
+// session runs on the connection of the write, so versions join its transaction and reads see
+// its uncommitted changes; outside a transaction reads go to the primary
+func (p *Plugin) session(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true, Context: persistence.WithPrimary(db.Statement.Context)})
+}
+
+// encode marshals the columns of a row to JSON, keyed by column name
+func encode(row map[string]any) (string, error) {
+	for column, value := range row {
+		if b, ok := value.([]byte); ok {
+			row[column] = string(b)
+		}
+	}
+	b, err := json.Marshal(row)
+	return string(b), err
+}
+
+func toInt64(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("unsupported primary key %T", value)
+	}
+}
+
+func toValues(ids []int64) []any {
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	return values
+}
+
+// primaryKeysOf returns the primary key of every model in the statement whose key is set
+func primaryKeysOf(stmt *gorm.Statement) []int64 {
+	pk := stmt.Schema.PrimaryFields[0]
+	var ids []int64
+	addModel := func(rv reflect.Value) {
+		if value, zero := pk.ValueOf(stmt.Context, rv); !zero {
+			ids = append(ids, reflect.ValueOf(value).Int())
+		}
+	}
+
+	rv := reflect.Indirect(stmt.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			addModel(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		addModel(rv)
+	}
+	return ids
+}
+
+// conditionsOf combines the WHERE clause of an update or delete with the primary key of its model
+func conditionsOf(stmt *gorm.Statement) []clause.Expression {
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+	if ids := primaryKeysOf(stmt); len(ids) == 1 {
+		pk := stmt.Schema.PrimaryFields[0]
+		conditions = append(conditions, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Value: ids[0]})
+	}
+	return conditions
+}
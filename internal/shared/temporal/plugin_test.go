@@ -0,0 +1,126 @@
+package temporal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID        int64 `gorm:"primaryKey"`
+	Name      string
+	Stock     int
+	Active    bool
+	CheckedAt *time.Time
+	OwnerID   int64
+	Owner     *owner `gorm:"foreignKey:OwnerID"`
+}
+
+type owner struct {
+	ID   int64 `gorm:"primaryKey"`
+	Name string
+}
+
+type widgetVersion struct{ Version }
+
+func (widgetVersion) TableName() string {
+	return "widgets_history"
+}
+
+// clock advances by a minute on every read, so each write gets its own moment
+type clock struct{ now time.Time }
+
+func (c *clock) tick() time.Time {
+	c.now = c.now.Add(time.Minute)
+	return c.now
+}
+
+func setupTemporalDB(t *testing.T) (*gorm.DB, *clock) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&widget{}, &owner{}, &widgetVersion{}))
+
+	c := &clock{now: time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)}
+	p := NewPlugin("widgets")
+	p.now = c.tick
+	require.NoError(t, db.Use(p))
+	return db, c
+}
+
+func TestPlugin_RecordsVersions(t *testing.T) {
+	db, c := setupTemporalDB(t)
+	ctx := context.Background()
+
+	checkedAt := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	w := &widget{Name: "Bolt", Stock: 10, Active: true, CheckedAt: &checkedAt, Owner: &owner{Name: "Ann"}}
+	require.NoError(t, db.Create(w).Error)
+	created := c.now
+	require.NoError(t, db.Model(&widget{}).Where("id = ?", w.ID).Update("stock", gorm.Expr("stock - ?", 3)).Error)
+	require.NoError(t, db.Model(w).Update("stock", 7).Error, "writes that change nothing add no version")
+	w.Name, w.Stock = "Nut", 5
+	require.NoError(t, db.Save(w).Error)
+	renamed := c.now
+
+	var versions []widgetVersion
+	require.NoError(t, db.Order("id").Find(&versions).Error)
+	require.Len(t, versions, 3)
+	assert.Contains(t, versions[0].Data, `"owner_id":`, "versions hold columns, not associations")
+
+	before, err := AsOf[widget](ctx, db, w.ID, created.Add(-time.Second))
+	assert.ErrorIs(t, err, persistence.ErrNotFound, "got %+v", before)
+
+	first, err := AsOf[widget](ctx, db, w.ID, created)
+	require.NoError(t, err)
+	assert.Equal(t, widget{ID: w.ID, Name: "Bolt", Stock: 10, Active: true, CheckedAt: first.CheckedAt, OwnerID: w.OwnerID}, *first)
+	if assert.NotNil(t, first.CheckedAt) {
+		assert.True(t, checkedAt.Equal(*first.CheckedAt), "got %v", first.CheckedAt)
+	}
+
+	second, err := AsOf[widget](ctx, db, w.ID, renamed.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 7, second.Stock)
+	assert.Equal(t, "Bolt", second.Name)
+
+	current, err := AsOf[widget](ctx, db, w.ID, renamed.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Nut", current.Name)
+	assert.Equal(t, 5, current.Stock)
+}
+
+func TestPlugin_ClosesVersionsOfDeletedRows(t *testing.T) {
+	db, c := setupTemporalDB(t)
+	ctx := context.Background()
+
+	w := &widget{Name: "Bolt", Stock: 10}
+	require.NoError(t, db.Create(w).Error)
+	created := c.now
+	require.NoError(t, db.Delete(w).Error)
+	deleted := c.now
+
+	_, err := AsOf[widget](ctx, db, w.ID, created)
+	assert.NoError(t, err)
+	_, err = AsOf[widget](ctx, db, w.ID, deleted)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestPlugin_RollsBackWithTheWrite(t *testing.T) {
+	db, _ := setupTemporalDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&widget{Name: "Bolt"}).Error)
+		return assert.AnError
+	})
+
+	var n int64
+	require.NoError(t, db.Model(&widgetVersion{}).Count(&n).Error)
+	assert.Zero(t, n)
+}
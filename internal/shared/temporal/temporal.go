@@ -0,0 +1,124 @@
+package temporal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// AsOfParam is the query parameter asking for a record as of an RFC 3339 time, e.g.
+// ?as_of=2024-05-18T12:00:00Z
+const AsOfParam = "as_of"
+
+// Version is a row of a history table: a record as it was from ValidFrom until ValidTo. Models
+// keep their history by embedding it in a type named after HistoryTable of their table, e.g.
+//
+//	type OrderVersion struct{ temporal.Version }
+//	func (OrderVersion) TableName() string { return "orders_history" }
+type Version struct {
+	ID       int64 `gorm:"primaryKey"`
+	RecordID int64 `gorm:"not null;index"`
+	// Data holds the columns of the record as JSON, keyed by column name
+	Data      string    `gorm:"type:text;not null"`
+	ValidFrom time.Time `gorm:"not null"`
+	// ValidTo is when the record changed again or was deleted; nil for its current version
+	ValidTo *time.Time `gorm:"index"`
+}
+
+// HistoryTable names the history table of a table, e.g. "orders_history"
+func HistoryTable(table string) string {
+	return table + "_history"
+}
+
+// ParseAsOf returns the time of the as_of parameter of r, or nil when r has none
+func ParseAsOf(r *http.Request) (*time.Time, error) {
+	value := r.URL.Query().Get(AsOfParam)
+	if value == "" {
+		return nil, nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		var errs validation.Errors
+		errs.Add(AsOfParam, fmt.Sprintf("must be a time in RFC 3339 format, got %q", value))
+		return nil, errs
+	}
+	return &at, nil
+}
+
+// AsOf returns the record of T with primary key id as it was at the given moment. It returns
+// persistence.ErrNotFound when the record did not exist then, was deleted by then, or was not
+// written through the plugin since its history started.
+func AsOf[T any](ctx context.Context, db *gorm.DB, id int64, at time.Time) (*T, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, fmt.Errorf("parse model: %w", err)
+	}
+
+	at = at.UTC()
+	var v Version
+	err := persistence.Conn(ctx, db).Table(HistoryTable(stmt.Table)).
+		Where("record_id = ? AND valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", id, at, at).
+		Order("valid_from DESC, id DESC").
+		First(&v).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	var record T
+	if err := decode(ctx, stmt.Schema, v.Data, reflect.ValueOf(&record).Elem()); err != nil {
+		return nil, fmt.Errorf("decode version %d of %s %d: %w", v.ID, stmt.Table, id, err)
+	}
+	return &record, nil
+}
+
+// decode sets the fields of record from the columns of a version. Columns added after the version
+// was written keep their zero value.
+func decode(ctx context.Context, s *schema.Schema, data string, record reflect.Value) error {
+	var columns map[string]any
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&columns); err != nil {
+		return err
+	}
+
+	for _, f := range s.Fields {
+		value, ok := columns[f.DBName]
+		if f.DBName == "" || !ok {
+			continue
+		}
+		value, err := columnValue(f, value)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", f.DBName, err)
+		}
+		if err := f.Set(ctx, record, value); err != nil {
+			return fmt.Errorf("column %s: %w", f.DBName, err)
+		}
+	}
+	return nil
+}
+
+// columnValue turns a JSON value back into the value the database driver returned for the column
+func columnValue(f *schema.Field, value any) (any, error) {
+	kind := f.IndirectFieldType.Kind()
+	switch v := value.(type) {
+	case json.Number:
+		if kind == reflect.Float32 || kind == reflect.Float64 {
+			return v.Float64()
+		}
+		return v.Int64()
+	case string:
+		if f.IndirectFieldType == reflect.TypeOf(time.Time{}) {
+			return time.Parse(time.RFC3339Nano, v)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,112 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Column holds the tenant of each row of a tenant-owned table
+const Column = "tenant_id"
+
+// ErrCrossTenant is returned for rows created for a tenant other than the one of the context
+var ErrCrossTenant = errors.New("row belongs to another tenant")
+
+// Plugin scopes the tenant-owned tables to the tenant of the context of each statement: queries,
+// updates and deletes only match its rows, created rows are stamped with it and upserts leave the
+// rows of other tenants alone. Statements of Unscoped contexts see every tenant. Contexts without
+// a tenant, such as those of command line tools, are scoped to DefaultID. Raw SQL and rows
+// created from maps are not scoped.
+type Plugin struct {
+	tables map[string]bool
+}
+
+// NewPlugin scopes the given tables, which must have a tenant_id column
+func NewPlugin(tables ...string) *Plugin {
+	p := &Plugin{tables: make(map[string]bool, len(tables))}
+	for _, t := range tables {
+		p.tables[t] = true
+	}
+	return p
+}
+
+func (p *Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize registers the scope before the callbacks of other plugins registered later, so the
+// audit log and history tables only see the rows of the tenant too
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("tenant:query", p.scope); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("tenant:row", p.scope); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tenant:update", p.scope); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", p.scope); err != nil {
+		return err
+	}
+	return db.Callback().Create().Before("gorm:create").Register("tenant:create", p.stamp)
+}
+
+func (p *Plugin) scoped(db *gorm.DB) bool {
+	return db.Error == nil && p.tables[db.Statement.Table] && !IsUnscoped(db.Statement.Context)
+}
+
+// condition matches the rows of the tenant of the statement
+func condition(stmt *gorm.Statement) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: Column}, Value: FromContext(stmt.Context)}
+}
+
+func (p *Plugin) scope(db *gorm.DB) {
+	if !p.scoped(db) {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition(db.Statement)}})
+}
+
+func (p *Plugin) stamp(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || !p.tables[stmt.Table] || stmt.Schema == nil {
+		return
+	}
+	field := stmt.Schema.LookUpField(Column)
+	if field == nil {
+		return
+	}
+
+	id, unscoped := FromContext(stmt.Context), IsUnscoped(stmt.Context)
+	stampRow := func(row reflect.Value) {
+		value, zero := field.ValueOf(stmt.Context, row)
+		if zero {
+			_ = db.AddError(field.Set(stmt.Context, row, id))
+		} else if !unscoped && value != id {
+			_ = db.AddError(fmt.Errorf("%w: %s %v", ErrCrossTenant, stmt.Table, value))
+		}
+	}
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			stampRow(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		stampRow(rv)
+	}
+
+	// An upsert conflicting with a row of another tenant must not take it over
+	if c, ok := stmt.Clauses[clause.OnConflict{}.Name()]; ok && !unscoped {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+				Column: clause.Column{Table: stmt.Table, Name: Column},
+				Value:  id,
+			})
+			stmt.AddClause(onConflict)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package tenant_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type widget struct {
+	ID       int64  `gorm:"primaryKey"`
+	TenantID string `gorm:"uniqueIndex:idx_widgets_tenant_sku,priority:1"`
+	SKU      string `gorm:"uniqueIndex:idx_widgets_tenant_sku,priority:2"`
+	Stock    int
+}
+
+func setupTenantDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&widget{}))
+	require.NoError(t, db.Use(tenant.NewPlugin("widgets")))
+	return db
+}
+
+func TestPlugin_ScopesToTheTenant(t *testing.T) {
+	db := setupTenantDB(t)
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	mine := &widget{SKU: "W-1", Stock: 5}
+	require.NoError(t, db.WithContext(acme).Create(mine).Error)
+	assert.Equal(t, "acme", mine.TenantID, "created rows get the tenant of the context")
+	require.NoError(t, db.WithContext(globex).Create(&[]widget{{SKU: "W-1"}, {SKU: "W-2"}}).Error)
+
+	var widgets []widget
+	require.NoError(t, db.WithContext(acme).Find(&widgets).Error)
+	assert.Len(t, widgets, 1)
+	assert.ErrorIs(t, db.WithContext(globex).First(&widget{}, mine.ID).Error, gorm.ErrRecordNotFound)
+
+	var count int64
+	require.NoError(t, db.WithContext(globex).Model(&widget{}).Count(&count).Error)
+	assert.EqualValues(t, 2, count)
+
+	result := db.WithContext(globex).Model(&widget{}).Where("id = ?", mine.ID).Update("stock", 0)
+	require.NoError(t, result.Error)
+	assert.Zero(t, result.RowsAffected, "updates skip the rows of other tenants")
+	result = db.WithContext(globex).Delete(&widget{}, mine.ID)
+	require.NoError(t, result.Error)
+	assert.Zero(t, result.RowsAffected, "deletes skip the rows of other tenants")
+
+	err := db.WithContext(globex).Create(&widget{TenantID: "acme", SKU: "W-3"}).Error
+	assert.ErrorIs(t, err, tenant.ErrCrossTenant)
+
+	require.NoError(t, db.WithContext(tenant.Unscoped(acme)).Find(&widgets).Error)
+	assert.Len(t, widgets, 3, "unscoped contexts see every tenant")
+}
+
+func TestPlugin_DefaultsToTheDefaultTenant(t *testing.T) {
+	db := setupTenantDB(t)
+
+	w := &widget{SKU: "W-1"}
+	require.NoError(t, db.Create(w).Error)
+	assert.Equal(t, tenant.DefaultID, w.TenantID)
+
+	var widgets []widget
+	require.NoError(t, db.WithContext(tenant.WithID(context.Background(), "acme")).Find(&widgets).Error)
+	assert.Empty(t, widgets)
+}
+
+func TestPlugin_UpsertsKeepOtherTenantsRows(t *testing.T) {
+	db := setupTenantDB(t)
+	acme := tenant.WithID(context.Background(), "acme")
+	require.NoError(t, db.WithContext(acme).Create(&widget{ID: 1, SKU: "W-1", Stock: 5}).Error)
+
+	// A conflict on the primary key of a row of another tenant leaves it alone
+	upsert := clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoUpdates: clause.AssignmentColumns([]string{"stock"})}
+	globex := tenant.WithID(context.Background(), "globex")
+	require.NoError(t, db.WithContext(globex).Clauses(upsert).Create(&widget{ID: 1, SKU: "W-1", Stock: 9}).Error)
+
+	var w widget
+	require.NoError(t, db.WithContext(acme).First(&w, 1).Error)
+	assert.Equal(t, 5, w.Stock)
+
+	require.NoError(t, db.WithContext(acme).Clauses(upsert).Create(&widget{ID: 1, SKU: "W-1", Stock: 7}).Error)
+	require.NoError(t, db.WithContext(acme).First(&w, 1).Error)
+	assert.Equal(t, 7, w.Stock)
+}
@@ -0,0 +1,149 @@
+// Package tenant binds the tenant of a request to its context. Requests name their tenant with the
+// X-Tenant-ID header or the subdomain they are sent to; Plugin then scopes every query of the
+// tenant-owned tables to it, so a tenant never reads or writes the rows of another. Superadmins
+// and system work such as scheduled jobs and migrations opt out with Unscoped.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// DefaultID is the tenant of requests that don't name one
+const DefaultID = "default"
+
+// Header carries the tenant of a request
+const Header = "X-Tenant-ID"
+
+// ScopeHeader set to "all" asks for the rows of every tenant; only superadmins may send it
+const ScopeHeader = "X-Tenant-Scope"
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type contextKey struct{}
+
+type unscopedKey struct{}
+
+type namedKey struct{}
+
+// WithID returns a context bound to the tenant
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant bound to ctx, or DefaultID
+func FromContext(ctx context.Context) string {
+	if id, ok := Bound(ctx); ok {
+		return id
+	}
+	return DefaultID
+}
+
+// Bound returns the tenant bound to ctx; ok is false when none is, e.g. in scheduled jobs
+func Bound(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(contextKey{}).(string)
+	return id, ok
+}
+
+// Named reports whether the request of ctx named its tenant, by header or subdomain, rather than
+// getting DefaultID
+func Named(ctx context.Context) bool {
+	named, _ := ctx.Value(namedKey{}).(bool)
+	return named
+}
+
+// Unscoped returns a context whose queries see the rows of every tenant. Rows created with it keep
+// the tenant they name, or get the tenant of ctx.
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// IsUnscoped reports whether the queries of ctx see the rows of every tenant
+func IsUnscoped(ctx context.Context) bool {
+	unscoped, _ := ctx.Value(unscopedKey{}).(bool)
+	return unscoped
+}
+
+// Resolver binds the tenant of each request to its context
+type Resolver struct {
+	// Domain is the domain tenants are subdomains of, e.g. "shop.example.com" serves the acme
+	// tenant at acme.shop.example.com; empty resolves tenants by header only
+	Domain string
+}
+
+// Middleware binds the tenant named in the X-Tenant-ID header, or else by the subdomain of the
+// request, to the request context; requests naming neither get DefaultID
+func (res *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, field := r.Header.Get(Header), Header
+		if id == "" {
+			id, field = res.subdomain(r.Host), "Host"
+		}
+		ctx := r.Context()
+		if id == "" {
+			id = DefaultID
+		} else {
+			ctx = context.WithValue(ctx, namedKey{}, true)
+		}
+		if !idPattern.MatchString(id) {
+			var errs validation.Errors
+			errs.Add(field, fmt.Sprintf("invalid tenant ID %q", id))
+			httpx.WriteError(w, errs)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithID(ctx, id)))
+	})
+}
+
+// Middleware resolves the tenant of each request by the X-Tenant-ID header alone, see Resolver
+func Middleware(next http.Handler) http.Handler {
+	return (&Resolver{}).Middleware(next)
+}
+
+// subdomain returns the label in front of Domain in host, or "" for hosts outside of it
+func (res *Resolver) subdomain(host string) string {
+	if res.Domain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(res.Domain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// BypassMiddleware unscopes requests sending X-Tenant-Scope: all, after checking that their user
+// holds p in any tenant; it must run inside auth.Middleware
+func BypassMiddleware(a auth.Authorizer, p auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch scope := r.Header.Get(ScopeHeader); scope {
+			case "":
+			case "all":
+				ctx := Unscoped(r.Context())
+				if err := auth.Check(ctx, a, p); err != nil {
+					auth.WriteError(w, err)
+					return
+				}
+				r = r.WithContext(ctx)
+			default:
+				var errs validation.Errors
+				errs.Add(ScopeHeader, fmt.Sprintf("must be all, got %q", scope))
+				httpx.WriteError(w, errs)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package tenant_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	handler := tenant.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = tenant.FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		header string
+		status int
+		tenant string
+	}{
+		{header: "", status: http.StatusOK, tenant: tenant.DefaultID},
+		{header: "acme-shop", status: http.StatusOK, tenant: "acme-shop"},
+		{header: "Acme Shop", status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		seen = ""
+		req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		if tt.header != "" {
+			req.Header.Set(tenant.Header, tt.header)
+		}
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code, tt.header)
+		assert.Equal(t, tt.tenant, seen, tt.header)
+	}
+}
+
+func TestResolver_Subdomain(t *testing.T) {
+	var seen string
+	var named bool
+	resolver := &tenant.Resolver{Domain: "shop.example.com"}
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, named = tenant.FromContext(r.Context()), tenant.Named(r.Context())
+	}))
+
+	tests := []struct {
+		host   string
+		header string
+		tenant string
+	}{
+		{host: "acme.shop.example.com", tenant: "acme"},
+		{host: "ACME.shop.example.com:8080", tenant: "acme"},
+		{host: "acme.shop.example.com", header: "globex", tenant: "globex"},
+		{host: "shop.example.com", tenant: tenant.DefaultID},
+		{host: "a.b.shop.example.com", tenant: tenant.DefaultID},
+		{host: "acme.other.com", tenant: tenant.DefaultID},
+	}
+
+	for _, tt := range tests {
+		seen = ""
+		req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		req.Host = tt.host
+		if tt.header != "" {
+			req.Header.Set(tenant.Header, tt.header)
+		}
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, tt.host)
+		assert.Equal(t, tt.tenant, seen, tt.host)
+		assert.Equal(t, tt.tenant != tenant.DefaultID, named, tt.host)
+	}
+}
+
+type bypassAuthorizer map[int64]bool
+
+func (a bypassAuthorizer) Can(ctx context.Context, userID int64, p auth.Permission) (bool, error) {
+	return a[userID], nil
+}
+
+func TestBypassMiddleware(t *testing.T) {
+	var unscoped bool
+	handler := tenant.BypassMiddleware(bypassAuthorizer{1: true}, "tenant:bypass")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unscoped = tenant.IsUnscoped(r.Context())
+	}))
+
+	tests := []struct {
+		scope    string
+		userID   int64
+		status   int
+		unscoped bool
+	}{
+		{scope: "", userID: 2, status: http.StatusOK},
+		{scope: "all", userID: 1, status: http.StatusOK, unscoped: true},
+		{scope: "all", userID: 2, status: http.StatusForbidden},
+		{scope: "acme", userID: 1, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		unscoped = false
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req = req.WithContext(auth.WithUserID(req.Context(), tt.userID))
+		if tt.scope != "" {
+			req.Header.Set(tenant.ScopeHeader, tt.scope)
+		}
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code, tt.scope)
+		assert.Equal(t, tt.unscoped, unscoped, tt.scope)
+	}
+}
@@ -0,0 +1,124 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+// engine lazily configures the shared validator so field paths use json names (or snake_case)
+func engine() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		validate.RegisterTagNameFunc(fieldName)
+	})
+	return validate
+}
+
+// Struct validates a command or DTO using its `validate` struct tags and returns
+// validation.Errors with one entry per failed rule, e.g. "quantity: must be greater than 0"
+func Struct(s interface{}) error {
+	err := engine().Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	errs := make(Errors, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		errs.Add(fieldPath(fieldErr.Namespace()), message(fieldErr))
+	}
+	return errs
+}
+
+// fieldPath drops the root struct name from a validator namespace ("PlaceOrderCommand.user_id" -> "user_id")
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldName reports the json name of a field, falling back to snake_case of the Go name
+func fieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return toSnakeCase(field.Name)
+	default:
+		return name
+	}
+}
+
+// message renders a human readable description of a failed rule
+func message(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", param)
+	case "lt":
+		return fmt.Sprintf("must be less than %s", param)
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", param)
+	case "min":
+		if isCollection(fieldErr.Kind()) {
+			return fmt.Sprintf("must contain at least %s items", param)
+		}
+		return fmt.Sprintf("must be at least %s", param)
+	case "max":
+		if isCollection(fieldErr.Kind()) {
+			return fmt.Sprintf("must contain at most %s items", param)
+		}
+		return fmt.Sprintf("must be at most %s", param)
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(param), ", "))
+	case "ne":
+		return fmt.Sprintf("must not be %s", param)
+	default:
+		return fmt.Sprintf("failed %q validation", fieldErr.Tag())
+	}
+}
+
+func isCollection(kind reflect.Kind) bool {
+	return kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+}
+
+// toSnakeCase converts CamelCase to snake_case, keeping acronyms together ("UserID" -> "user_id")
+func toSnakeCase(str string) string {
+	runes := []rune(str)
+	var result strings.Builder
+	for i, r := range runes {
+		isUpper := r >= 'A' && r <= 'Z'
+		if i > 0 && isUpper {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || nextLower {
+				result.WriteRune('_')
+			}
+		}
+		result.WriteRune(r)
+	}
+	return strings.ToLower(result.String())
+}
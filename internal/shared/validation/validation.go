@@ -0,0 +1,89 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldError describes a single rule violation for a field path (e.g. "email", "items[0].quantity")
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Errors collects every rule violation found while validating a value
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Add records a rule violation for the given field path
+func (e *Errors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Check records a rule violation when ok is false
+func (e *Errors) Check(ok bool, field, message string) {
+	if !ok {
+		e.Add(field, message)
+	}
+}
+
+// Merge appends the violations of a nested validation result under the given prefix.
+// Errors that are not validation errors are recorded against the prefix itself.
+func (e *Errors) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+
+	var nested Errors
+	if !errors.As(err, &nested) {
+		e.Add(prefix, err.Error())
+		return
+	}
+
+	for _, fieldErr := range nested {
+		*e = append(*e, FieldError{Field: joinPath(prefix, fieldErr.Field), Message: fieldErr.Message})
+	}
+}
+
+// Err returns nil when no violations were recorded, so callers can `return errs.Err()`
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// IsValidationError reports whether err carries field-level validation errors
+func IsValidationError(err error) bool {
+	var errs Errors
+	return errors.As(err, &errs)
+}
+
+// joinPath joins a prefix and a field path with a dot, omitting empty segments
+func joinPath(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	case strings.HasPrefix(field, "["):
+		return prefix + field
+	default:
+		return prefix + "." + field
+	}
+}
@@ -0,0 +1,83 @@
+package validation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors_ErrIsNilWhenEmpty(t *testing.T) {
+	var errs validation.Errors
+	errs.Check(true, "email", "is required")
+
+	assert.NoError(t, errs.Err())
+}
+
+func TestErrors_CollectsEveryViolation(t *testing.T) {
+	var errs validation.Errors
+	errs.Check(false, "email", "is required")
+	errs.Add("stock", "must not be negative")
+
+	err := errs.Err()
+	assert.Error(t, err)
+	assert.True(t, validation.IsValidationError(err))
+	assert.Equal(t, "validation failed: email: is required; stock: must not be negative", err.Error())
+}
+
+func TestErrors_MergePrefixesFieldPaths(t *testing.T) {
+	var nested validation.Errors
+	nested.Add("quantity", "must be greater than zero")
+
+	var errs validation.Errors
+	errs.Merge("items[0]", nested.Err())
+	errs.Merge("order", errors.New("boom"))
+	errs.Merge("ignored", nil)
+
+	assert.Equal(t, validation.Errors{
+		{Field: "items[0].quantity", Message: "must be greater than zero"},
+		{Field: "order", Message: "boom"},
+	}, errs)
+}
+
+type testItem struct {
+	SKU      string `json:"sku" validate:"required"`
+	Quantity int    `validate:"gt=0"`
+}
+
+type testCommand struct {
+	UserID int64      `validate:"required"`
+	Email  string     `json:"email_address" validate:"required,email"`
+	Status string     `validate:"oneof=PENDING CONFIRMED"`
+	Items  []testItem `validate:"min=1,dive"`
+}
+
+func TestStruct_ReturnsFieldLevelErrors(t *testing.T) {
+	err := validation.Struct(testCommand{
+		Email:  "nope",
+		Status: "LOST",
+		Items:  []testItem{{SKU: "A", Quantity: 1}, {Quantity: 0}},
+	})
+
+	var errs validation.Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, validation.Errors{
+		{Field: "user_id", Message: "is required"},
+		{Field: "email_address", Message: "must be a valid email address"},
+		{Field: "status", Message: "must be one of PENDING, CONFIRMED"},
+		{Field: "items[1].sku", Message: "is required"},
+		{Field: "items[1].quantity", Message: "must be greater than 0"},
+	}, errs)
+}
+
+func TestStruct_Valid(t *testing.T) {
+	err := validation.Struct(testCommand{
+		UserID: 1,
+		Email:  "user@example.com",
+		Status: "PENDING",
+		Items:  []testItem{{SKU: "A", Quantity: 1}},
+	})
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,140 @@
+// Package warmup fills the caches of an instance in the background once it starts, so the first
+// requests after a deploy do not all miss at once and pile onto the database.
+//
+// Adapters owning a cache implement Cache and register it on a Warmer. Run warms at most
+// Concurrency caches at a time so a rollout of many instances does not flood the database with
+// the queries it meant to spare. A cache that fails to warm is logged and left cold; it fills
+// on demand as before.
+package warmup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Cache is a cache that can be filled ahead of its first use
+type Cache interface {
+	// Describe names the cache in logs and metrics, e.g. "quota plans"
+	Describe() string
+	// Warm loads the entries likely to be read soon and returns how many it loaded
+	Warm(ctx context.Context) (entries int, err error)
+}
+
+// Observer records the progress of a warm-up, e.g. metrics.CacheWarmup
+type Observer interface {
+	// Warmed is called once a cache is warmed, or with the error that stopped it
+	Warmed(cache string, entries int, duration time.Duration, err error)
+	// Pending is called with the number of caches left whenever it changes
+	Pending(n int)
+}
+
+// Warmer warms the registered caches with bounded concurrency
+type Warmer struct {
+	// Concurrency is the number of caches warmed at once; values below 1 mean 1
+	Concurrency int
+	// Timeout bounds the whole warm-up; zero leaves it to ctx
+	Timeout time.Duration
+	// Observer, when set, is told about every cache warmed
+	Observer Observer
+
+	mu     sync.Mutex
+	caches []Cache
+}
+
+// Register adds caches to be warmed by Run
+func (w *Warmer) Register(caches ...Cache) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.caches = append(w.caches, caches...)
+}
+
+// Run warms every registered cache in registration order and returns once all are done or ctx
+// ends. A failing cache does not stop the others; the errors are joined.
+func (w *Warmer) Run(ctx context.Context) error {
+	w.mu.Lock()
+	caches := append([]Cache(nil), w.caches...)
+	w.mu.Unlock()
+	if len(caches) == 0 {
+		return nil
+	}
+
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    []error
+		pending = len(caches)
+		slots   = make(chan struct{}, max(w.Concurrency, 1))
+	)
+	w.pending(pending)
+	start := time.Now()
+	for _, cache := range caches {
+		// Caches not started by the time ctx ends are skipped
+		var acquired bool
+		if ctx.Err() == nil {
+			select {
+			case slots <- struct{}{}:
+				acquired = true
+			case <-ctx.Done():
+			}
+		}
+		if !acquired {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("warm %s: %w", cache.Describe(), ctx.Err()))
+			pending--
+			w.pending(pending)
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(cache Cache) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := w.warm(ctx, cache)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			pending--
+			w.pending(pending)
+		}(cache)
+	}
+	wg.Wait()
+
+	slog.InfoContext(ctx, "cache warm-up finished", "caches", len(caches), "failed", len(errs), "duration", time.Since(start))
+	return errors.Join(errs...)
+}
+
+func (w *Warmer) warm(ctx context.Context, cache Cache) error {
+	start := time.Now()
+	entries, err := cache.Warm(ctx)
+	duration := time.Since(start)
+	if w.Observer != nil {
+		w.Observer.Warmed(cache.Describe(), entries, duration, err)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "cache warm-up failed", "cache", cache.Describe(), "error", err)
+		return fmt.Errorf("warm %s: %w", cache.Describe(), err)
+	}
+	slog.InfoContext(ctx, "cache warmed", "cache", cache.Describe(), "entries", entries, "duration", duration)
+	return nil
+}
+
+func (w *Warmer) pending(n int) {
+	if w.Observer != nil {
+		w.Observer.Pending(n)
+	}
+}
@@ -0,0 +1,100 @@
+package warmup_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/warmup"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCache takes a moment to warm and tracks how many caches warm at once
+type fakeCache struct {
+	name    string
+	entries int
+	err     error
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (c *fakeCache) Describe() string {
+	return c.name
+}
+
+func (c *fakeCache) Warm(ctx context.Context) (int, error) {
+	n := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return c.entries, c.err
+}
+
+type recordingObserver struct {
+	mu      sync.Mutex
+	warmed  map[string]int
+	pending []int
+}
+
+func (o *recordingObserver) Warmed(cache string, entries int, duration time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err == nil {
+		o.warmed[cache] = entries
+	}
+}
+
+func (o *recordingObserver) Pending(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending = append(o.pending, n)
+}
+
+func TestWarmer_RunBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	observer := &recordingObserver{warmed: make(map[string]int)}
+	warmer := &warmup.Warmer{Concurrency: 2, Observer: observer}
+	for _, name := range []string{"plans", "credentials", "products", "rates"} {
+		warmer.Register(&fakeCache{name: name, entries: 3, running: &running, peak: &peak})
+	}
+
+	assert.NoError(t, warmer.Run(context.Background()))
+
+	assert.Equal(t, int32(2), peak.Load(), "expected at most two caches warmed at once")
+	assert.Len(t, observer.warmed, 4)
+	assert.Equal(t, []int{4, 3, 2, 1, 0}, observer.pending)
+}
+
+func TestWarmer_RunContinuesAfterFailure(t *testing.T) {
+	var running, peak atomic.Int32
+	failing := &fakeCache{name: "plans", err: errors.New("db down"), running: &running, peak: &peak}
+	healthy := &fakeCache{name: "credentials", entries: 1, running: &running, peak: &peak}
+	observer := &recordingObserver{warmed: make(map[string]int)}
+	warmer := &warmup.Warmer{Observer: observer}
+	warmer.Register(failing, healthy)
+
+	err := warmer.Run(context.Background())
+
+	assert.ErrorContains(t, err, "warm plans: db down")
+	assert.Equal(t, map[string]int{"credentials": 1}, observer.warmed)
+}
+
+func TestWarmer_RunStopsWhenContextEnds(t *testing.T) {
+	var running, peak atomic.Int32
+	warmer := &warmup.Warmer{}
+	warmer.Register(&fakeCache{name: "plans", running: &running, peak: &peak})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := warmer.Run(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
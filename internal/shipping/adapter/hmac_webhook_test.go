@@ -0,0 +1,51 @@
+package adapter_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte("whsec_carrier"))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHMACTrackingVerifier_Verify(t *testing.T) {
+	verifier := adapter.NewHMACTrackingVerifier(secret.Static("whsec_carrier"))
+	ctx := context.Background()
+
+	payload := `{"id":"evt_1","tracking_number":"JD0001","status":"out_for_delivery","location":"Berlin","occurred_at":"2024-06-05T07:30:00Z"}`
+	callback, err := verifier.Verify(ctx, []byte(payload), sign(payload))
+	require.NoError(t, err)
+	assert.Equal(t, "JD0001", callback.TrackingNumber)
+	assert.Equal(t, domain.StatusOutForDelivery, callback.Update.Status)
+	assert.Equal(t, "Berlin", callback.Update.Location)
+
+	_, err = verifier.Verify(ctx, []byte(payload), sign(payload+" "))
+	assert.ErrorIs(t, err, domain.ErrInvalidSignature)
+
+	unknown := `{"id":"evt_2","tracking_number":"JD0001","status":"customs_cleared","occurred_at":"2024-06-05T07:30:00Z"}`
+	callback, err = verifier.Verify(ctx, []byte(unknown), sign(unknown))
+	assert.NoError(t, err)
+	assert.Nil(t, callback, "untracked statuses are acknowledged")
+}
+
+func TestHMACTrackingVerifier_RejectsEverythingWithoutASecret(t *testing.T) {
+	verifier := adapter.NewHMACTrackingVerifier(secret.Static(""))
+	payload := `{"id":"evt_1","tracking_number":"JD0001","status":"delivered","occurred_at":"2024-06-05T07:30:00Z"}`
+	mac := hmac.New(sha256.New, nil)
+	mac.Write([]byte(payload))
+
+	_, err := verifier.Verify(context.Background(), []byte(payload), "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	assert.ErrorIs(t, err, domain.ErrInvalidSignature)
+}
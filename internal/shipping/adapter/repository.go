@@ -0,0 +1,63 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormShipmentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormShipmentRepository(db *gorm.DB) domain.ShipmentRepository {
+	return &GormShipmentRepository{db: db}
+}
+
+func (r *GormShipmentRepository) Create(ctx context.Context, s *domain.Shipment) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(s).Error)
+}
+
+func (r *GormShipmentRepository) Update(ctx context.Context, s *domain.Shipment) error {
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(s).Select("status", "delivered_at", "updated_at").Updates(s).Error
+		if err != nil {
+			return err
+		}
+		for i := range s.Events {
+			if s.Events[i].ID == 0 {
+				s.Events[i].ShipmentID = s.ID
+				if err := tx.Create(&s.Events[i]).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return persistence.TranslateError(err)
+}
+
+func (r *GormShipmentRepository) GetByOrderID(ctx context.Context, orderID int64) (*domain.Shipment, error) {
+	return r.get(ctx, r.db.Where("order_id = ?", orderID))
+}
+
+func (r *GormShipmentRepository) GetByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*domain.Shipment, error) {
+	return r.get(ctx, r.db.Where("carrier = ? AND tracking_number = ?", carrier, trackingNumber))
+}
+
+// get loads the shipment matching cond with its tracking events
+func (r *GormShipmentRepository) get(ctx context.Context, cond *gorm.DB) (*domain.Shipment, error) {
+	var s domain.Shipment
+	err := persistence.Conn(ctx, r.db).
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("occurred_at ASC, id ASC")
+		}).
+		Where(cond).
+		First(&s).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &s, nil
+}
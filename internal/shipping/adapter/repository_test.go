@@ -0,0 +1,52 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Shipment{}, &domain.TrackingEvent{}))
+	return db
+}
+
+func TestGormShipmentRepository_TrackAndReload(t *testing.T) {
+	repo := adapter.NewGormShipmentRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	s, err := domain.NewShipment(1, "dhl", "JD0001")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, s))
+
+	other, err := domain.NewShipment(2, "dhl", "JD0001")
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Create(ctx, other), persistence.ErrDuplicateKey)
+
+	at := time.Date(2024, 6, 4, 8, 0, 0, 0, time.UTC)
+	_, err = s.Track(domain.TrackingUpdate{EventID: "e2", Status: domain.StatusDelivered, OccurredAt: at.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = s.Track(domain.TrackingUpdate{EventID: "e1", Status: domain.StatusInTransit, Location: "Leipzig", OccurredAt: at})
+	require.NoError(t, err)
+	require.NoError(t, repo.Update(ctx, s))
+	require.NoError(t, repo.Update(ctx, s), "saved events are not inserted again")
+
+	got, err := repo.GetByTrackingNumber(ctx, "dhl", "JD0001")
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusDelivered, got.Status)
+	require.Len(t, got.Events, 2)
+	assert.Equal(t, "e1", got.Events[0].ExternalID, "events are loaded oldest first")
+
+	_, err = repo.GetByOrderID(ctx, 2)
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
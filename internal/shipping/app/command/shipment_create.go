@@ -0,0 +1,73 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// CreateShipmentCommand hands a confirmed order to a carrier and marks it SHIPPED
+type CreateShipmentCommand struct {
+	OrderID        int64  `validate:"required,gt=0"`
+	Carrier        string `validate:"required"`
+	TrackingNumber string `validate:"required"`
+}
+
+type CreateShipmentHandler struct {
+	Shipments domain.ShipmentRepository
+	Orders    orderDomain.OrderRepository
+	// Carriers are the codes of the carriers orders ship with, e.g. "dhl"
+	Carriers []string
+	Tx       persistence.Transactor
+}
+
+func (h *CreateShipmentHandler) Handle(ctx context.Context, cmd CreateShipmentCommand) (*domain.Shipment, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	s, err := domain.NewShipment(cmd.OrderID, cmd.Carrier, cmd.TrackingNumber)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(h.Carriers, s.Carrier) {
+		var errs validation.Errors
+		errs.Add("carrier", fmt.Sprintf("must be one of %s", strings.Join(h.Carriers, ", ")))
+		return nil, errs
+	}
+
+	err = h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		o, err := h.Orders.GetByID(ctx, cmd.OrderID)
+		if err != nil {
+			return fmt.Errorf("get order %d: %w", cmd.OrderID, err)
+		}
+		// Only confirmed orders ship, and a shipped order has its shipment already
+		if err := o.Transition(orderDomain.StatusShipped); err != nil {
+			return err
+		}
+		if err := h.Orders.UpdateStatus(ctx, o); err != nil {
+			return fmt.Errorf("ship order %d: %w", o.ID, err)
+		}
+
+		if err := h.Shipments.Create(ctx, s); err != nil {
+			if errors.Is(err, persistence.ErrDuplicateKey) {
+				var errs validation.Errors
+				errs.Add("tracking_number", "is already used by another shipment")
+				return errs
+			}
+			return fmt.Errorf("create shipment of order %d: %w", o.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,166 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	deliveryCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/app/command"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// noTx runs units of work without a transaction
+type noTx struct{}
+
+func (noTx) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// MockOrderRepository keeps orders in memory; only the methods shipping uses are implemented
+type MockOrderRepository struct {
+	orderDomain.OrderRepository
+	orders map[int64]orderDomain.Order
+}
+
+func (m *MockOrderRepository) GetByID(ctx context.Context, id int64) (*orderDomain.Order, error) {
+	o, ok := m.orders[id]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+	return &o, nil
+}
+
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, o *orderDomain.Order) error {
+	m.orders[o.ID] = *o
+	return nil
+}
+
+// MockShipmentRepository keeps shipments in memory, keyed by order
+type MockShipmentRepository struct {
+	shipments map[int64]domain.Shipment
+}
+
+func (m *MockShipmentRepository) Create(ctx context.Context, s *domain.Shipment) error {
+	for _, other := range m.shipments {
+		if other.Carrier == s.Carrier && other.TrackingNumber == s.TrackingNumber {
+			return persistence.ErrDuplicateKey
+		}
+	}
+	s.ID = int64(len(m.shipments) + 1)
+	m.shipments[s.OrderID] = *s
+	return nil
+}
+
+func (m *MockShipmentRepository) Update(ctx context.Context, s *domain.Shipment) error {
+	m.shipments[s.OrderID] = *s
+	return nil
+}
+
+func (m *MockShipmentRepository) GetByOrderID(ctx context.Context, orderID int64) (*domain.Shipment, error) {
+	s, ok := m.shipments[orderID]
+	if !ok {
+		return nil, persistence.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (m *MockShipmentRepository) GetByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*domain.Shipment, error) {
+	for _, s := range m.shipments {
+		if s.Carrier == carrier && s.TrackingNumber == trackingNumber {
+			return &s, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+// MockRecordDeliveryHandler records the delivered orders
+type MockRecordDeliveryHandler struct {
+	recorded []deliveryCommand.RecordDeliveryCommand
+}
+
+func (m *MockRecordDeliveryHandler) Handle(ctx context.Context, cmd deliveryCommand.RecordDeliveryCommand) error {
+	m.recorded = append(m.recorded, cmd)
+	return deliveryDomain.ErrAlreadyDelivered
+}
+
+func TestShipAndDeliverOrder(t *testing.T) {
+	// Arrange
+	orders := &MockOrderRepository{orders: map[int64]orderDomain.Order{
+		1: {ID: 1, Status: orderDomain.StatusConfirmed},
+		2: {ID: 2, Status: orderDomain.StatusConfirmed},
+	}}
+	shipments := &MockShipmentRepository{shipments: map[int64]domain.Shipment{}}
+	deliveries := &MockRecordDeliveryHandler{}
+	create := &CreateShipmentHandler{Shipments: shipments, Orders: orders, Carriers: []string{"dhl", "ups"}, Tx: noTx{}}
+	track := &UpdateTrackingStatusHandler{Shipments: shipments, Orders: orders, RecordDelivery: deliveries, Tx: noTx{}}
+	ctx := context.Background()
+	deliveredAt := time.Date(2024, 6, 6, 10, 0, 0, 0, time.UTC)
+
+	// Act
+	s, err := create.Handle(ctx, CreateShipmentCommand{OrderID: 1, Carrier: "DHL", TrackingNumber: "jd 0001"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, status := range []domain.ShipmentStatus{domain.StatusInTransit, domain.StatusDelivered, domain.StatusDelivered} {
+		err := track.Handle(ctx, UpdateTrackingStatusCommand{
+			Carrier:        "dhl",
+			TrackingNumber: "JD0001",
+			Update:         domain.TrackingUpdate{EventID: string(status), Status: status, OccurredAt: deliveredAt.Add(time.Duration(i-1) * time.Hour)},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", status, err)
+		}
+	}
+
+	// Assert
+	if s.TrackingNumber != "JD0001" || orders.orders[1].Status != orderDomain.StatusDelivered {
+		t.Errorf("Expected order 1 to be delivered with tracking number JD0001, got %s and %s", orders.orders[1].Status, s.TrackingNumber)
+	}
+	if len(orders.orders[1].History) != 2 {
+		t.Errorf("Expected SHIPPED and DELIVERED in the order history, got %+v", orders.orders[1].History)
+	}
+	if len(deliveries.recorded) != 1 || !deliveries.recorded[0].DeliveredAt.Equal(deliveredAt) {
+		t.Errorf("Expected the delivery to be recorded once at %s, got %+v", deliveredAt, deliveries.recorded)
+	}
+}
+
+func TestCreateShipmentHandler_Rejects(t *testing.T) {
+	orders := &MockOrderRepository{orders: map[int64]orderDomain.Order{
+		1: {ID: 1, Status: orderDomain.StatusConfirmed},
+		2: {ID: 2, Status: orderDomain.StatusPending},
+		3: {ID: 3, Status: orderDomain.StatusConfirmed},
+	}}
+	shipments := &MockShipmentRepository{shipments: map[int64]domain.Shipment{}}
+	handler := &CreateShipmentHandler{Shipments: shipments, Orders: orders, Carriers: []string{"dhl"}, Tx: noTx{}}
+	ctx := context.Background()
+	if _, err := handler.Handle(ctx, CreateShipmentCommand{OrderID: 1, Carrier: "dhl", TrackingNumber: "JD0001"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		cmd      CreateShipmentCommand
+		expected error
+	}{
+		{name: "already shipped", cmd: CreateShipmentCommand{OrderID: 1, Carrier: "dhl", TrackingNumber: "JD0002"}, expected: orderDomain.ErrInvalidTransition},
+		{name: "pending order", cmd: CreateShipmentCommand{OrderID: 2, Carrier: "dhl", TrackingNumber: "JD0002"}, expected: orderDomain.ErrInvalidTransition},
+		{name: "unknown carrier", cmd: CreateShipmentCommand{OrderID: 3, Carrier: "pigeon", TrackingNumber: "JD0002"}},
+		{name: "tracking number in use", cmd: CreateShipmentCommand{OrderID: 3, Carrier: "dhl", TrackingNumber: "JD0001"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.Handle(ctx, tt.cmd)
+			var errs validation.Errors
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			} else if tt.expected == nil && !errors.As(err, &errs) {
+				t.Errorf("Expected a validation error, got %v", err)
+			}
+		})
+	}
+}
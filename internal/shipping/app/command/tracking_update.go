@@ -0,0 +1,91 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	deliveryCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/app/command"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// UpdateTrackingStatusCommand applies a carrier status update to the shipment with the tracking number
+type UpdateTrackingStatusCommand struct {
+	Carrier        string `validate:"required"`
+	TrackingNumber string `validate:"required"`
+	Update         domain.TrackingUpdate
+}
+
+type UpdateTrackingStatusHandler struct {
+	Shipments domain.ShipmentRepository
+	Orders    orderDomain.OrderRepository
+	// RecordDelivery compares delivered orders with their delivery estimate; nil skips it
+	RecordDelivery decorator.CommandHandler[deliveryCommand.RecordDeliveryCommand]
+	Tx             persistence.Transactor
+}
+
+func (h *UpdateTrackingStatusHandler) Handle(ctx context.Context, cmd UpdateTrackingStatusCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	var delivered *domain.Shipment
+	err := h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		carrier, trackingNumber := domain.NormalizeCarrier(cmd.Carrier), domain.NormalizeTrackingNumber(cmd.TrackingNumber)
+		s, err := h.Shipments.GetByTrackingNumber(ctx, carrier, trackingNumber)
+		if err != nil {
+			if errors.Is(err, persistence.ErrNotFound) {
+				return fmt.Errorf("%w: %s %s", domain.ErrShipmentNotFound, carrier, trackingNumber)
+			}
+			return fmt.Errorf("get shipment %s %s: %w", carrier, trackingNumber, err)
+		}
+
+		changed, err := s.Track(cmd.Update)
+		if err != nil {
+			return err
+		}
+		if err := h.Shipments.Update(ctx, s); err != nil {
+			return fmt.Errorf("update shipment %s: %w", s.PublicID, err)
+		}
+		if changed && s.Status == domain.StatusDelivered {
+			delivered = s
+			return h.deliverOrder(ctx, s.OrderID)
+		}
+		return nil
+	})
+	if err != nil || delivered == nil {
+		return err
+	}
+
+	// The accuracy report misses the order when this fails, but the delivery itself is recorded
+	if h.RecordDelivery != nil {
+		err := h.RecordDelivery.Handle(ctx, deliveryCommand.RecordDeliveryCommand{OrderID: delivered.OrderID, DeliveredAt: *delivered.DeliveredAt})
+		if err != nil && !errors.Is(err, deliveryDomain.ErrEstimateNotFound) {
+			slog.WarnContext(ctx, "recording order delivery failed", "order_id", delivered.OrderID, "error", err)
+		}
+	}
+	return nil
+}
+
+// deliverOrder marks the order of a delivered shipment DELIVERED
+func (h *UpdateTrackingStatusHandler) deliverOrder(ctx context.Context, orderID int64) error {
+	o, err := h.Orders.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("get order %d: %w", orderID, err)
+	}
+	if err := o.Transition(orderDomain.StatusDelivered); err != nil {
+		// The parcel arrived either way; an order refunded in the meantime keeps its status
+		slog.WarnContext(ctx, "delivered shipment for an order that is no longer shipped", "order_id", orderID, "status", o.Status)
+		return nil
+	}
+	if err := h.Orders.UpdateStatus(ctx, o); err != nil {
+		return fmt.Errorf("deliver order %d: %w", orderID, err)
+	}
+	return nil
+}
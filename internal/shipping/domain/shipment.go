@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+var (
+	ErrShipmentNotFound = errors.New("shipment not found")
+	ErrUnknownCarrier   = errors.New("unknown carrier")
+)
+
+// PublicIDPrefix starts the public IDs of shipments, e.g. "shp_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const PublicIDPrefix = "shp"
+
+// ShipmentStatus is where a shipment is on its way to the customer, as reported by the carrier
+type ShipmentStatus string
+
+const (
+	StatusLabelCreated   ShipmentStatus = "LABEL_CREATED"
+	StatusInTransit      ShipmentStatus = "IN_TRANSIT"
+	StatusOutForDelivery ShipmentStatus = "OUT_FOR_DELIVERY"
+	StatusDelivered      ShipmentStatus = "DELIVERED"
+	// StatusException is a failed delivery attempt or a hold; the carrier reports the next step later
+	StatusException ShipmentStatus = "EXCEPTION"
+)
+
+// progress orders the statuses along the way of a shipment
+var progress = map[ShipmentStatus]int{
+	StatusLabelCreated:   0,
+	StatusInTransit:      1,
+	StatusOutForDelivery: 2,
+	StatusDelivered:      3,
+}
+
+// IsValid reports whether the status is known
+func (s ShipmentStatus) IsValid() bool {
+	_, ok := progress[s]
+	return ok || s == StatusException
+}
+
+// CanMoveTo reports whether a shipment in status s moves on to status to. Carriers don't guarantee
+// the order of their callbacks, so a shipment never moves back; an exception may happen at any
+// point before delivery and is followed by any status but LABEL_CREATED.
+func (s ShipmentStatus) CanMoveTo(to ShipmentStatus) bool {
+	switch {
+	case s == StatusDelivered || s == to || !to.IsValid():
+		return false
+	case to == StatusException:
+		return true
+	case s == StatusException:
+		return to != StatusLabelCreated
+	default:
+		return progress[to] > progress[s]
+	}
+}
+
+// carrierPattern matches carrier codes such as "dhl" or "royal-mail"
+var carrierPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,31}$`)
+
+// TrackingEvent is a status update of a shipment reported by its carrier
+type TrackingEvent struct {
+	ID         int64 `gorm:"primaryKey"`
+	ShipmentID int64 `gorm:"not null;uniqueIndex:idx_tracking_events_external"`
+	// ExternalID is the carrier's event identifier; redeliveries of an event share it
+	ExternalID  string         `gorm:"type:varchar(255);not null;uniqueIndex:idx_tracking_events_external"`
+	Status      ShipmentStatus `gorm:"type:varchar(20);not null"`
+	Location    string         `gorm:"type:varchar(255)"`
+	Description string         `gorm:"type:varchar(255)"`
+	OccurredAt  time.Time      `gorm:"not null"`
+}
+
+// Shipment is the parcel an order is sent in and how its carrier tracks it
+type Shipment struct {
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the shipment in external APIs so the primary key never leaves the service
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	// TenantID is the tenant of the order shipped; see tenant.Plugin
+	TenantID       string         `gorm:"type:varchar(64);not null;default:'default';index"`
+	OrderID        int64          `gorm:"not null;uniqueIndex"`
+	Carrier        string         `gorm:"type:varchar(32);not null;uniqueIndex:idx_shipments_tracking"`
+	TrackingNumber string         `gorm:"type:varchar(64);not null;uniqueIndex:idx_shipments_tracking"`
+	Status         ShipmentStatus `gorm:"type:varchar(20);not null"`
+	// Events are the tracking updates of the carrier, oldest first
+	Events      []TrackingEvent `gorm:"foreignKey:ShipmentID"`
+	DeliveredAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NormalizeCarrier lower-cases a carrier code
+func NormalizeCarrier(carrier string) string {
+	return strings.ToLower(strings.TrimSpace(carrier))
+}
+
+// NormalizeTrackingNumber upper-cases a tracking number and drops the spaces carriers print it with
+func NormalizeTrackingNumber(trackingNumber string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(trackingNumber), ""))
+}
+
+// NewShipment hands an order to a carrier under the tracking number of its label
+func NewShipment(orderID int64, carrier, trackingNumber string) (*Shipment, error) {
+	carrier = NormalizeCarrier(carrier)
+	trackingNumber = NormalizeTrackingNumber(trackingNumber)
+
+	var errs validation.Errors
+	errs.Check(orderID > 0, "order_id", "is required")
+	errs.Check(carrierPattern.MatchString(carrier), "carrier", "must be a carrier code such as dhl")
+	errs.Check(trackingNumber != "", "tracking_number", "is required")
+	errs.Check(len(trackingNumber) <= 64, "tracking_number", "must be at most 64 characters")
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Shipment{
+		PublicID:       publicid.New(PublicIDPrefix),
+		OrderID:        orderID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		Status:         StatusLabelCreated,
+	}, nil
+}
+
+// TrackingUpdate is a status change reported by a carrier for a tracking number
+type TrackingUpdate struct {
+	EventID     string
+	Status      ShipmentStatus
+	Location    string
+	Description string
+	OccurredAt  time.Time
+}
+
+// Track records a tracking update and reports whether it moved the shipment to a new status.
+// Redelivered updates are ignored, and stale ones are kept in the history without moving the
+// shipment back.
+func (s *Shipment) Track(u TrackingUpdate) (bool, error) {
+	if !u.Status.IsValid() {
+		return false, fmt.Errorf("tracking event %s: unknown status %q", u.EventID, u.Status)
+	}
+	for _, e := range s.Events {
+		if e.ExternalID == u.EventID {
+			return false, nil
+		}
+	}
+
+	s.Events = append(s.Events, TrackingEvent{
+		ShipmentID:  s.ID,
+		ExternalID:  u.EventID,
+		Status:      u.Status,
+		Location:    u.Location,
+		Description: u.Description,
+		OccurredAt:  u.OccurredAt,
+	})
+
+	if !s.Status.CanMoveTo(u.Status) {
+		return false, nil
+	}
+	s.Status = u.Status
+	if u.Status == StatusDelivered {
+		deliveredAt := u.OccurredAt
+		s.DeliveredAt = &deliveredAt
+	}
+	return true, nil
+}
+
+type ShipmentRepository interface {
+	Create(ctx context.Context, s *Shipment) error
+	// Update stores the status of a shipment together with its new tracking events
+	Update(ctx context.Context, s *Shipment) error
+	GetByOrderID(ctx context.Context, orderID int64) (*Shipment, error)
+	GetByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*Shipment, error)
+}
@@ -0,0 +1,59 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShipment_NormalizesAndValidates(t *testing.T) {
+	s, err := domain.NewShipment(1, " DHL ", "00340 43412 3456")
+	require.NoError(t, err)
+	assert.Equal(t, "dhl", s.Carrier)
+	assert.Equal(t, "00340434123456", s.TrackingNumber)
+	assert.Equal(t, domain.StatusLabelCreated, s.Status)
+	assert.NotEmpty(t, s.PublicID)
+
+	_, err = domain.NewShipment(0, "d", " ")
+	var errs validation.Errors
+	require.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 3)
+}
+
+func TestShipment_Track(t *testing.T) {
+	s, err := domain.NewShipment(1, "dhl", "JD0001")
+	require.NoError(t, err)
+	at := time.Date(2024, 6, 4, 8, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		update  domain.TrackingUpdate
+		changed bool
+		status  domain.ShipmentStatus
+	}{
+		{update: domain.TrackingUpdate{EventID: "e1", Status: domain.StatusInTransit, OccurredAt: at}, changed: true, status: domain.StatusInTransit},
+		{update: domain.TrackingUpdate{EventID: "e1", Status: domain.StatusInTransit, OccurredAt: at}, status: domain.StatusInTransit},
+		{update: domain.TrackingUpdate{EventID: "e2", Status: domain.StatusOutForDelivery, OccurredAt: at.Add(24 * time.Hour)}, changed: true, status: domain.StatusOutForDelivery},
+		// A late callback is kept in the history without moving the shipment back
+		{update: domain.TrackingUpdate{EventID: "e0", Status: domain.StatusLabelCreated, OccurredAt: at.Add(-time.Hour)}, status: domain.StatusOutForDelivery},
+		{update: domain.TrackingUpdate{EventID: "e3", Status: domain.StatusException, Description: "Recipient absent", OccurredAt: at.Add(26 * time.Hour)}, changed: true, status: domain.StatusException},
+		{update: domain.TrackingUpdate{EventID: "e4", Status: domain.StatusOutForDelivery, OccurredAt: at.Add(48 * time.Hour)}, changed: true, status: domain.StatusOutForDelivery},
+		{update: domain.TrackingUpdate{EventID: "e5", Status: domain.StatusDelivered, OccurredAt: at.Add(50 * time.Hour)}, changed: true, status: domain.StatusDelivered},
+		{update: domain.TrackingUpdate{EventID: "e6", Status: domain.StatusException, OccurredAt: at.Add(51 * time.Hour)}, status: domain.StatusDelivered},
+	}
+	for _, step := range steps {
+		changed, err := s.Track(step.update)
+		require.NoError(t, err)
+		assert.Equal(t, step.changed, changed, step.update.EventID)
+		assert.Equal(t, step.status, s.Status, step.update.EventID)
+	}
+	assert.Len(t, s.Events, 7)
+	assert.Equal(t, at.Add(50*time.Hour), *s.DeliveredAt)
+
+	_, err = s.Track(domain.TrackingUpdate{EventID: "e7", Status: "LOST"})
+	assert.Error(t, err)
+}
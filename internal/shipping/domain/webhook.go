@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidSignature is returned when a tracking callback is not signed by the carrier
+var ErrInvalidSignature = errors.New("invalid tracking webhook signature")
+
+// ErrorCodeInvalidSignature is returned with 400 for callbacks failing signature verification
+const ErrorCodeInvalidSignature = "invalid_signature"
+
+// TrackingCallback is a verified carrier callback about the shipment with TrackingNumber
+type TrackingCallback struct {
+	TrackingNumber string
+	Update         TrackingUpdate
+}
+
+// TrackingVerifier authenticates and decodes the tracking callbacks of one carrier
+type TrackingVerifier interface {
+	// SignatureHeader is the HTTP header carrying the payload signature
+	SignatureHeader() string
+	// Verify checks the signature and decodes the payload. It returns ErrInvalidSignature for
+	// forged payloads and a nil callback for statuses the application does not track.
+	Verify(ctx context.Context, payload []byte, signature string) (*TrackingCallback, error)
+}
@@ -0,0 +1,215 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// maxWebhookBody bounds the callback payload read before the signature is checked
+const maxWebhookBody = 64 << 10
+
+// CreateShipmentRequest is the body of POST /orders/{id}/shipments
+type CreateShipmentRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+// TrackingEventResponse is a status update reported by the carrier
+type TrackingEventResponse struct {
+	Status      domain.ShipmentStatus `json:"status"`
+	Location    string                `json:"location,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OccurredAt  time.Time             `json:"occurred_at"`
+}
+
+// ShipmentResponse is the public representation of a shipment; OrderID is the public ID of the order
+type ShipmentResponse struct {
+	ID             string                  `json:"id"`
+	OrderID        string                  `json:"order_id"`
+	Carrier        string                  `json:"carrier"`
+	TrackingNumber string                  `json:"tracking_number"`
+	Status         domain.ShipmentStatus   `json:"status"`
+	DeliveredAt    *time.Time              `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	Events         []TrackingEventResponse `json:"events"`
+}
+
+// HTTPServer exposes the shipping use cases over HTTP
+type HTTPServer struct {
+	CreateShipment       decorator.CommandResultHandler[command.CreateShipmentCommand, *domain.Shipment]
+	UpdateTrackingStatus decorator.CommandHandler[command.UpdateTrackingStatusCommand]
+	Shipments            domain.ShipmentRepository
+	// Orders resolves the public order IDs of the order routes
+	Orders orderDomain.OrderRepository
+
+	// Auth limits shipping orders to staff and tracking to the owner of the order; nil disables access control
+	Auth auth.Authorizer
+
+	// Verifiers authenticate the tracking callbacks of each carrier, keyed by carrier code
+	Verifiers map[string]domain.TrackingVerifier
+}
+
+// RegisterRoutes adds the shipping endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/orders/{id}/shipments",
+		Summary:  "Hand a confirmed order to a carrier and mark it shipped",
+		Tags:     []string{"shipping"},
+		Request:  CreateShipmentRequest{},
+		Response: ShipmentResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionShipmentManage, s.createShipment),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/orders/{id}/shipment",
+		Summary:  "Track the shipment of an order",
+		Tags:     []string{"shipping"},
+		Response: ShipmentResponse{},
+		Handler:  s.getShipment,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:  http.MethodPost,
+		Path:    "/webhooks/carriers/{carrier}",
+		Summary: "Receive a signed carrier tracking callback",
+		Tags:    []string{"shipping"},
+		Handler: s.handleWebhook,
+	})
+}
+
+func (s *HTTPServer) createShipment(w http.ResponseWriter, r *http.Request) {
+	var req CreateShipmentRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	o, err := s.Orders.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	shipment, err := s.CreateShipment.Handle(r.Context(), command.CreateShipmentCommand{
+		OrderID:        o.ID,
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+	})
+	if err != nil {
+		writeShippingError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, toShipmentResponse(shipment, o.PublicID))
+}
+
+func (s *HTTPServer) getShipment(w http.ResponseWriter, r *http.Request) {
+	o, err := s.Orders.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	if err := auth.CheckOwned(r.Context(), s.Auth, userDomain.PermissionOrderReadAny, userDomain.PermissionOrderReadOwn, o.UserID); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+
+	shipment, err := s.Shipments.GetByOrderID(r.Context(), o.ID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, toShipmentResponse(shipment, o.PublicID))
+}
+
+func (s *HTTPServer) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	carrier := domain.NormalizeCarrier(r.PathValue("carrier"))
+	verifier, ok := s.Verifiers[carrier]
+	if !ok {
+		httpx.WriteErrorStatus(w, http.StatusNotFound, fmt.Errorf("%w: %s", domain.ErrUnknownCarrier, carrier))
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		httpx.WriteError(w, fmt.Errorf("read webhook body: %w", err))
+		return
+	}
+	if len(payload) > maxWebhookBody {
+		httpx.WriteErrorStatus(w, http.StatusRequestEntityTooLarge, errors.New("webhook body too large"))
+		return
+	}
+
+	callback, err := verifier.Verify(r.Context(), payload, r.Header.Get(verifier.SignatureHeader()))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSignature) {
+			httpx.WriteErrorCode(w, http.StatusBadRequest, domain.ErrorCodeInvalidSignature, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+	// Acknowledge statuses we don't track so the carrier stops sending them
+	if callback == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Carriers don't know the tenant of the shipment, which the signature already vouches for
+	err = s.UpdateTrackingStatus.Handle(tenant.Unscoped(r.Context()), command.UpdateTrackingStatusCommand{
+		Carrier:        carrier,
+		TrackingNumber: callback.TrackingNumber,
+		Update:         callback.Update,
+	})
+	if err != nil {
+		writeShippingError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func toShipmentResponse(s *domain.Shipment, orderPublicID string) ShipmentResponse {
+	resp := ShipmentResponse{
+		ID:             s.PublicID,
+		OrderID:        orderPublicID,
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		Status:         s.Status,
+		DeliveredAt:    s.DeliveredAt,
+		CreatedAt:      s.CreatedAt,
+		Events:         make([]TrackingEventResponse, len(s.Events)),
+	}
+	for i, e := range s.Events {
+		resp.Events[i] = TrackingEventResponse{Status: e.Status, Location: e.Location, Description: e.Description, OccurredAt: e.OccurredAt}
+	}
+	return resp
+}
+
+// writeShippingError maps shipping and order domain errors onto HTTP status codes
+func writeShippingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrShipmentNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, orderDomain.ErrInvalidTransition):
+		httpx.WriteErrorStatus(w, http.StatusConflict, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// FileStore keeps objects on the local filesystem, e.g. a mounted volume, and serves them itself:
+// its signed URLs point at Handler, which checks the signature and expiry before sending the file
+type FileStore struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+// NewFileStore stores objects under dir; signed URLs are built on baseURL, where Handler must be
+// mounted, and signed with secret
+func NewFileStore(dir, baseURL, secret string) *FileStore {
+	return &FileStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), secret: []byte(secret)}
+}
+
+var _ BlobStore = (*FileStore)(nil)
+
+func (s *FileStore) Put(ctx context.Context, key, contentType string, content io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, fmt.Errorf("create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first so a failed upload never leaves a truncated file behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("create blob file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("write blob %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("store blob %s: %w", key, err)
+	}
+	return size, nil
+}
+
+func (s *FileStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, persistence.ErrNotFound
+	}
+	return f, err
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// SignedURL returns <baseURL>/<key>?expires=<unix seconds>&signature=<hex HMAC-SHA256>
+func (s *FileStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return s.baseURL + "/" + escapeKey(key) + "?" + q.Encode(), nil
+}
+
+// Handler serves the objects of signed URLs; it must be mounted with a {key...} wildcard, e.g.
+// "GET /blobs/{key...}". Unsigned, tampered and expired URLs are answered with 403.
+func (s *FileStore) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+		expires := r.URL.Query().Get("expires")
+		signature, err := hex.DecodeString(r.URL.Query().Get("signature"))
+		if err != nil || !hmac.Equal(signature, s.mac(key, expires)) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		deadline, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > deadline {
+			http.Error(w, "url expired", http.StatusForbidden)
+			return
+		}
+
+		path, err := s.path(key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(max(deadline-time.Now().Unix(), 0), 10))
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	})
+}
+
+// Describe and Ensure let the bootstrap registry create the blob directory
+func (s *FileStore) Describe() string {
+	return "blob directory " + s.dir
+}
+
+func (s *FileStore) Ensure(ctx context.Context) (bool, error) {
+	if _, err := os.Stat(s.dir); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Check stores a probe object and reads it back, for selftest.Suite
+func (s *FileStore) Check(ctx context.Context) error {
+	key := fmt.Sprintf(".selftest/probe-%d", time.Now().UnixNano())
+	probe := []byte("selftest")
+	if _, err := s.Put(ctx, key, "text/plain", bytes.NewReader(probe)); err != nil {
+		return err
+	}
+	defer s.Delete(ctx, key)
+
+	f, err := s.Open(ctx, key)
+	if err != nil {
+		return fmt.Errorf("read back %s: %w", key, err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("read back %s: %w", key, err)
+	}
+	if !bytes.Equal(content, probe) {
+		return fmt.Errorf("read back %s: got %d bytes, stored %d", key, len(content), len(probe))
+	}
+	return nil
+}
+
+func (s *FileStore) sign(key, expires string) string {
+	return hex.EncodeToString(s.mac(key, expires))
+}
+
+func (s *FileStore) mac(key, expires string) []byte {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(key + "\n" + expires))
+	return m.Sum(nil)
+}
+
+// path resolves key inside the store directory, rejecting keys that escape it
+func (s *FileStore) path(key string) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return path, nil
+}
+
+// escapeKey escapes each segment of key for use in a URL path, keeping the slashes between them
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,97 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/storage"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_PutOpenDelete(t *testing.T) {
+	store := storage.NewFileStore(t.TempDir(), "http://localhost/blobs", "secret")
+	ctx := context.Background()
+
+	size, err := store.Put(ctx, "products/prd_1/img_1.png", "image/png", strings.NewReader("png bytes"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), size)
+
+	f, err := store.Open(ctx, "products/prd_1/img_1.png")
+	require.NoError(t, err)
+	content, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "png bytes", string(content))
+
+	require.NoError(t, store.Delete(ctx, "products/prd_1/img_1.png"))
+	require.NoError(t, store.Delete(ctx, "products/prd_1/img_1.png"), "deleting a missing object is not an error")
+	_, err = store.Open(ctx, "products/prd_1/img_1.png")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+
+	_, err = store.Put(ctx, "../escape.txt", "text/plain", strings.NewReader("x"))
+	assert.Error(t, err, "keys cannot escape the directory")
+}
+
+func TestFileStore_SignedURLs(t *testing.T) {
+	store := storage.NewFileStore(t.TempDir(), "http://localhost/blobs/", "secret")
+	ctx := context.Background()
+	_, err := store.Put(ctx, "products/prd_1/img 1.png", "image/png", strings.NewReader("png bytes"))
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /blobs/{key...}", store.Handler())
+	get := func(rawURL string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, rawURL, nil))
+		return rec
+	}
+
+	signed, err := store.SignedURL(ctx, "products/prd_1/img 1.png", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, "http://localhost/blobs/products/prd_1/img%201.png?"), signed)
+
+	rec := get(signed)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "png bytes", rec.Body.String())
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	q := u.Query()
+	q.Set("expires", "9999999999")
+	u.RawQuery = q.Encode()
+	assert.Equal(t, http.StatusForbidden, get(u.String()).Code, "a tampered expiry is rejected")
+
+	u.Path = "/blobs/products/prd_1/other.png"
+	u.RawQuery = q.Encode()
+	assert.Equal(t, http.StatusForbidden, get(u.String()).Code, "a signature only reads its key")
+
+	expired, err := store.SignedURL(ctx, "products/prd_1/img 1.png", -time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, get(expired).Code)
+
+	other := storage.NewFileStore(t.TempDir(), "http://localhost/blobs", "another secret")
+	forged, err := other.SignedURL(ctx, "products/prd_1/img 1.png", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, get(forged).Code, "URLs signed with another secret are rejected")
+}
+
+func TestFileStore_Check(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, storage.NewFileStore(dir, "http://localhost/blobs", "secret").Check(context.Background()))
+
+	probes, err := os.ReadDir(filepath.Join(dir, ".selftest"))
+	require.NoError(t, err)
+	assert.Empty(t, probes, "the probe is removed")
+}
@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3TimeFormat      = "20060102T150405Z"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// s3MaxURLTTL is the longest lifetime S3 accepts for a presigned URL
+	s3MaxURLTTL = 7 * 24 * time.Hour
+)
+
+// S3Options configures an S3Store
+type S3Options struct {
+	// Endpoint defaults to https://s3.<Region>.amazonaws.com; set it for S3-compatible stores such
+	// as MinIO
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses objects as <Endpoint>/<Bucket>/<key> instead of on a bucket subdomain,
+	// which most S3-compatible stores need
+	PathStyle bool
+
+	// Client defaults to one with a 30 second timeout
+	Client *http.Client
+	// Now defaults to time.Now; requests are signed with it
+	Now func() time.Time
+}
+
+// S3Store keeps objects in an S3 bucket, signing requests with AWS Signature Version 4. Reads
+// go straight to the bucket through presigned URLs.
+type S3Store struct {
+	opts     S3Options
+	endpoint *url.URL
+}
+
+func NewS3Store(opts S3Options) (*S3Store, error) {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(opts.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", opts.Endpoint)
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &S3Store{opts: opts, endpoint: endpoint}, nil
+}
+
+var _ BlobStore = (*S3Store)(nil)
+
+// Put buffers content to sign its hash, so it suits objects that fit in memory such as images
+func (s *S3Store) Put(ctx context.Context, key, contentType string, content io.Reader) (int64, error) {
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return 0, fmt.Errorf("read blob %s: %w", key, err)
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return 0, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, sha256Hex(body))
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("put blob %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("put blob %s: %w", key, s3Error(resp))
+	}
+	return int64(len(body)), nil
+}
+
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, sha256Hex(nil))
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get blob %s: %w", key, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, persistence.ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("get blob %s: %w", key, s3Error(resp))
+	}
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, sha256Hex(nil))
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete blob %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	// S3 answers 204 whether or not the object existed; other stores may answer 404
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete blob %s: %w", key, s3Error(resp))
+	}
+	return nil
+}
+
+// SignedURL presigns a GET of key; S3 caps the lifetime of presigned URLs at 7 days
+func (s *S3Store) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if ttl < time.Second || ttl > s3MaxURLTTL {
+		return "", fmt.Errorf("signed URL lifetime must be between 1s and %s, got %s", s3MaxURLTTL, ttl)
+	}
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.opts.Now().UTC()
+	q := url.Values{
+		"X-Amz-Algorithm":     {s3Algorithm},
+		"X-Amz-Credential":    {s.opts.AccessKeyID + "/" + s.scope(now)},
+		"X-Amz-Date":          {now.Format(s3TimeFormat)},
+		"X-Amz-Expires":       {strconv.FormatInt(int64(ttl/time.Second), 10)},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	q.Set("X-Amz-Signature", s.signature(now, canonical))
+	u.RawQuery = canonicalQuery(q)
+	return u.String(), nil
+}
+
+// Describe names the bucket in selftest reports
+func (s *S3Store) Describe() string {
+	return "s3 bucket " + s.opts.Bucket
+}
+
+// Check stores a probe object, reads it back and deletes it, for selftest.Suite
+func (s *S3Store) Check(ctx context.Context) error {
+	key := fmt.Sprintf(".selftest/probe-%d", time.Now().UnixNano())
+	probe := []byte("selftest")
+	if _, err := s.Put(ctx, key, "text/plain", bytes.NewReader(probe)); err != nil {
+		return err
+	}
+	defer s.Delete(ctx, key)
+
+	f, err := s.Open(ctx, key)
+	if err != nil {
+		return fmt.Errorf("read back %s: %w", key, err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("read back %s: %w", key, err)
+	}
+	if !bytes.Equal(content, probe) {
+		return fmt.Errorf("read back %s: got %d bytes, stored %d", key, len(content), len(probe))
+	}
+	return nil
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return http.NewRequestWithContext(ctx, method, u.String(), reader)
+}
+
+// objectURL addresses key on the bucket subdomain, or under the bucket path with PathStyle
+func (s *S3Store) objectURL(key string) (*url.URL, error) {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return nil, fmt.Errorf("invalid blob key %q", key)
+	}
+	u := *s.endpoint
+	path := "/" + key
+	if s.opts.PathStyle {
+		path = "/" + s.opts.Bucket + path
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	u.Path = u.Path + path
+	u.RawPath = s.endpoint.EscapedPath() + awsEscape(path, false)
+	return &u, nil
+}
+
+// sign adds the Authorization header of a request whose body hashes to payloadHash, signing the
+// host, x-amz-content-sha256 and x-amz-date headers
+func (s *S3Store) sign(req *http.Request, payloadHash string) {
+	now := s.opts.Now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + now.Format(s3TimeFormat) + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.opts.AccessKeyID, s.scope(now), signedHeaders, s.signature(now, canonical)))
+}
+
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.opts.Region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request made at now with the key derived for its day and region
+func (s *S3Store) signature(now time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalQuery sorts the parameters by name and escapes them the way Signature Version 4 does
+func canonicalQuery(q url.Values) string {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := append([]string{}, q[name]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, awsEscape(name, true)+"="+awsEscape(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes every byte but the unreserved characters A-Z a-z 0-9 - _ . ~, and
+// slashes too when escapeSlash is set
+func awsEscape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// s3Error reads the code and message of an S3 error response
+func s3Error(resp *http.Response) error {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if xml.Unmarshal(raw, &body) == nil && body.Code != "" {
+		return fmt.Errorf("s3 status %d: %s: %s", resp.StatusCode, body.Code, body.Message)
+	}
+	return fmt.Errorf("s3 status %d", resp.StatusCode)
+}
@@ -0,0 +1,22 @@
+// Package storage keeps binary objects such as product images outside the database. Objects are
+// addressed by slash-separated keys, e.g. "products/prd_123/img_456.jpg", and clients read them
+// through short-lived signed URLs rather than through the API.
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// BlobStore stores objects under keys
+type BlobStore interface {
+	// Put stores content under key, replacing any object already there, and returns its size
+	Put(ctx context.Context, key, contentType string, content io.Reader) (int64, error)
+	// Open returns the content of key, or persistence.ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that reads key without credentials until ttl has passed
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
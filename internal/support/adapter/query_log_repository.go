@@ -0,0 +1,34 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormQueryLogRepository struct {
+	db *gorm.DB
+}
+
+func NewGormQueryLogRepository(db *gorm.DB) domain.QueryLogRepository {
+	return &GormQueryLogRepository{db: db}
+}
+
+func (r *GormQueryLogRepository) Create(ctx context.Context, l *domain.QueryLog) error {
+	return persistence.TranslateError(r.db.WithContext(ctx).Create(l).Error)
+}
+
+func (r *GormQueryLogRepository) List(ctx context.Context, actor string, limit int) ([]domain.QueryLog, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Limit(limit)
+	if actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+
+	var logs []domain.QueryLog
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return logs, nil
+}
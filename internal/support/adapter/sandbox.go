@@ -0,0 +1,148 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"gorm.io/gorm"
+)
+
+// Dataset puts a read model on the sandbox allowlist under a name
+type Dataset struct {
+	Name string
+	// Model is a pointer to the GORM model of the read model, e.g. &orderDomain.OrderSummary{}
+	Model any
+	// Redacted are the PII columns of the model
+	Redacted []string
+}
+
+type dataset struct {
+	model   any
+	info    domain.Dataset
+	columns *query.Columns
+}
+
+// GormSandbox runs sandbox queries through the query builder, which checks every column against
+// the model before it reaches the SQL
+type GormSandbox struct {
+	db       *gorm.DB
+	datasets map[string]dataset
+}
+
+// NewGormSandbox allowlists datasets; it fails for models GORM cannot parse and for redacted
+// columns the model does not have
+func NewGormSandbox(db *gorm.DB, datasets ...Dataset) (*GormSandbox, error) {
+	s := &GormSandbox{db: db, datasets: make(map[string]dataset, len(datasets))}
+	for _, d := range datasets {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(d.Model); err != nil {
+			return nil, fmt.Errorf("dataset %s: %w", d.Name, err)
+		}
+		columns, err := query.ColumnsOf(db, d.Model)
+		if err != nil {
+			return nil, fmt.Errorf("dataset %s: %w", d.Name, err)
+		}
+		for _, c := range d.Redacted {
+			if !slices.Contains(stmt.Schema.DBNames, c) {
+				return nil, fmt.Errorf("dataset %s: redacted column %q is not a column of %s", d.Name, c, stmt.Schema.Table)
+			}
+		}
+		s.datasets[d.Name] = dataset{
+			model:   d.Model,
+			info:    domain.Dataset{Name: d.Name, Columns: slices.Clone(stmt.Schema.DBNames), Redacted: d.Redacted},
+			columns: columns,
+		}
+	}
+	return s, nil
+}
+
+func (s *GormSandbox) Datasets() []domain.Dataset {
+	datasets := make([]domain.Dataset, 0, len(s.datasets))
+	for _, d := range s.datasets {
+		datasets = append(datasets, d.info)
+	}
+	sort.Slice(datasets, func(i, j int) bool { return datasets[i].Name < datasets[j].Name })
+	return datasets
+}
+
+func (s *GormSandbox) Run(ctx context.Context, q domain.SandboxQuery) (*domain.SandboxResult, error) {
+	d, ok := s.datasets[q.Dataset]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownDataset, q.Dataset)
+	}
+
+	qb := query.NewQueryBuilder(s.db.WithContext(ctx).Model(d.model))
+	var errs validation.Errors
+	for i, c := range q.Filters {
+		field := fmt.Sprintf("filters[%d]", i)
+		filter, err := c.Field()
+		var invalid *query.InvalidConditionError
+		if errors.As(err, &invalid) {
+			errs.Add(field, invalid.Reason)
+			continue
+		}
+		if reason := d.check(c.Column); reason != "" {
+			errs.Add(field+".column", reason)
+			continue
+		}
+		// A value the column cannot hold, e.g. "soon" for a timestamp, is the caller's mistake
+		var badValue *query.InvalidFilterValueError
+		if filter, err = d.columns.Coerce(filter); errors.As(err, &badValue) {
+			errs.Add(field+".value", badValue.Reason)
+			continue
+		}
+		qb.AddFilters([]query.FilterField{filter})
+	}
+	for i, o := range q.Sort {
+		field := fmt.Sprintf("sort[%d]", i)
+		config := o.Config()
+		errs.Check(config.Order == query.SortOrderAsc || config.Order == query.SortOrderDesc, field+".order", "must be ASC or DESC")
+		errs.Check(config.Nulls == "" || config.Nulls == query.NullsFirst || config.Nulls == query.NullsLast, field+".nulls", "must be FIRST or LAST")
+		if reason := d.check(o.Column); reason != "" {
+			errs.Add(field+".column", reason)
+			continue
+		}
+		qb.AddSorts(config)
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	// One row more than the limit tells whether the result is truncated
+	var rows []map[string]any
+	if err := qb.Build().Limit(q.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	result := &domain.SandboxResult{Columns: d.info.Columns, Rows: rows}
+	if len(rows) > q.Limit {
+		result.Rows, result.Truncated = rows[:q.Limit], true
+	}
+	for _, row := range result.Rows {
+		for _, c := range d.info.Redacted {
+			if row[c] != nil {
+				row[c] = domain.RedactedValue
+			}
+		}
+	}
+	return result, nil
+}
+
+// check returns why a column cannot be filtered or sorted on, or "" when it can
+func (d dataset) check(column string) string {
+	c, err := d.columns.Lookup(column)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("is not a column of %s", d.info.Name)
+	case slices.Contains(d.info.Redacted, c.Name):
+		return "is redacted"
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package adapter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/support/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSandbox(t *testing.T) *adapter.GormSandbox {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&orderDomain.OrderSummary{}))
+
+	at := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	for i, status := range []orderDomain.OrderStatus{orderDomain.StatusConfirmed, orderDomain.StatusCancelled, orderDomain.StatusCancelled, orderDomain.StatusCancelled} {
+		placedAt := at.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, db.Create(&orderDomain.OrderSummary{
+			OrderID: "ord_" + string(rune('a'+i)), UserID: "usr_7", UserEmail: "ann@example.com", Status: status, PlacedAt: &placedAt,
+		}).Error)
+	}
+
+	sandbox, err := adapter.NewGormSandbox(db, adapter.Dataset{Name: "order_summaries", Model: &orderDomain.OrderSummary{}, Redacted: []string{"user_email"}})
+	require.NoError(t, err)
+	return sandbox
+}
+
+func TestGormSandbox_Run(t *testing.T) {
+	sandbox := setupSandbox(t)
+
+	result, err := sandbox.Run(context.Background(), domain.SandboxQuery{
+		Dataset: "order_summaries",
+		Filters: []query.Condition{
+			{Column: "status", Value: "CANCELLED"},
+			{Column: "placed_at", Op: query.OperatorGreaterOrEqual, Value: "2024-05-18T13:00:00Z"},
+		},
+		Sort:  []query.Sort{{Column: "placed_at", Order: "desc"}},
+		Limit: 2,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Rows, 2)
+	assert.True(t, result.Truncated, "a third cancelled order matched")
+	assert.Equal(t, "ord_d", result.Rows[0]["order_id"])
+	assert.Equal(t, "ord_c", result.Rows[1]["order_id"])
+	assert.Equal(t, domain.RedactedValue, result.Rows[0]["user_email"])
+	assert.Equal(t, "usr_7", result.Rows[0]["user_id"])
+	assert.Contains(t, result.Columns, "user_email")
+}
+
+func TestGormSandbox_Run_RejectsRedactedAndUnknownColumns(t *testing.T) {
+	sandbox := setupSandbox(t)
+
+	_, err := sandbox.Run(context.Background(), domain.SandboxQuery{
+		Dataset: "order_summaries",
+		Filters: []query.Condition{
+			{Column: "user_email", Op: query.OperatorStartsWith, Value: "a"},
+			{Column: "status; DROP TABLE order_summaries", Value: "x"},
+			{Column: "User.email", Value: "x"},
+			{Column: "placed_at", Op: query.OperatorGreaterOrEqual, Value: "yesterday"},
+		},
+		Sort:  []query.Sort{{Column: "user_email"}},
+		Limit: 10,
+	})
+
+	var errs validation.Errors
+	require.True(t, errors.As(err, &errs), "got %v", err)
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	assert.Equal(t, []string{"filters[0].column", "filters[1].column", "filters[2]", "filters[3].value", "sort[0].column"}, fields)
+
+	_, err = sandbox.Run(context.Background(), domain.SandboxQuery{Dataset: "users", Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrUnknownDataset)
+}
+
+func TestNewGormSandbox_RejectsUnknownRedactedColumns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	_, err = adapter.NewGormSandbox(db, adapter.Dataset{Name: "order_summaries", Model: &orderDomain.OrderSummary{}, Redacted: []string{"email"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	auditDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/audit/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+const (
+	DefaultSandboxLimit = 100
+	MaxSandboxLimit     = 500
+	// maxLoggedError bounds the error stored on a query log
+	maxLoggedError = 255
+)
+
+// RunSandboxQueryHandler answers the queries of support staff from the allowlisted read models.
+// Every query is logged with its actor before the result is returned, including the ones denied
+// by validation; a query whose log cannot be written fails rather than going unrecorded.
+type RunSandboxQueryHandler struct {
+	Sandbox domain.Sandbox
+	Logs    domain.QueryLogRepository
+	Now     func() time.Time
+}
+
+func (h *RunSandboxQueryHandler) Handle(ctx context.Context, q domain.SandboxQuery) (*domain.SandboxResult, error) {
+	if q.Limit == 0 {
+		q.Limit = DefaultSandboxLimit
+	}
+	started := h.now()
+
+	var result *domain.SandboxResult
+	var errs validation.Errors
+	errs.Check(q.Dataset != "", "dataset", "is required")
+	errs.Check(q.Limit >= 1 && q.Limit <= MaxSandboxLimit, "limit", fmt.Sprintf("must be between 1 and %d, got %d", MaxSandboxLimit, q.Limit))
+	err := errs.Err()
+	if err == nil {
+		result, err = h.Sandbox.Run(ctx, q)
+	}
+
+	if logErr := h.log(ctx, q, result, err, started); logErr != nil {
+		return nil, logErr
+	}
+	return result, err
+}
+
+func (h *RunSandboxQueryHandler) log(ctx context.Context, q domain.SandboxQuery, result *domain.SandboxResult, queryErr error, started time.Time) error {
+	spec, err := json.Marshal(struct {
+		Filters []query.Condition `json:"filters,omitempty"`
+		Sort    []query.Sort      `json:"sort,omitempty"`
+		Limit   int               `json:"limit"`
+	}{q.Filters, q.Sort, q.Limit})
+	if err != nil {
+		return fmt.Errorf("encode sandbox query: %w", err)
+	}
+
+	l := &domain.QueryLog{
+		Actor:      auditDomain.ActorFromContext(ctx),
+		TenantID:   tenant.FromContext(ctx),
+		Dataset:    q.Dataset,
+		Query:      string(spec),
+		DurationMs: h.now().Sub(started).Milliseconds(),
+		CreatedAt:  started,
+	}
+	if result != nil {
+		l.Rows, l.Truncated = len(result.Rows), result.Truncated
+	}
+	if queryErr != nil {
+		l.Error = queryErr.Error()
+		if len(l.Error) > maxLoggedError {
+			// Cut at the start of a rune so the stored error stays valid UTF-8
+			cut := maxLoggedError
+			for cut > 0 && !utf8.RuneStart(l.Error[cut]) {
+				cut--
+			}
+			l.Error = l.Error[:cut]
+		}
+	}
+	// A query must not run unrecorded, so the log is written even when the request was cancelled
+	if err := h.Logs.Create(context.WithoutCancel(ctx), l); err != nil {
+		return fmt.Errorf("log sandbox query: %w", err)
+	}
+	slog.InfoContext(ctx, "support sandbox query", "actor", l.Actor, "dataset", l.Dataset, "rows", l.Rows, "error", l.Error)
+	return nil
+}
+
+func (h *RunSandboxQueryHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,107 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
+	sharedQuery "github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+type MockSandbox struct {
+	domain.Sandbox
+	runs int
+	err  error
+}
+
+func (m *MockSandbox) Run(ctx context.Context, q domain.SandboxQuery) (*domain.SandboxResult, error) {
+	m.runs++
+	if m.err != nil {
+		return nil, m.err
+	}
+	if q.Dataset != "order_summaries" {
+		return nil, domain.ErrUnknownDataset
+	}
+	return &domain.SandboxResult{Columns: []string{"order_id"}, Rows: []map[string]any{{"order_id": "ord_1"}}}, nil
+}
+
+type MockQueryLogs struct {
+	domain.QueryLogRepository
+	logs []domain.QueryLog
+}
+
+func (m *MockQueryLogs) Create(ctx context.Context, l *domain.QueryLog) error {
+	m.logs = append(m.logs, *l)
+	return nil
+}
+
+func TestRunSandboxQueryHandler_LogsEveryQuery(t *testing.T) {
+	// Arrange
+	sandbox, logs := &MockSandbox{}, &MockQueryLogs{}
+	handler := &RunSandboxQueryHandler{Sandbox: sandbox, Logs: logs}
+	ctx := auth.WithUserID(context.Background(), 42)
+
+	// Act
+	result, err := handler.Handle(ctx, domain.SandboxQuery{
+		Dataset: "order_summaries",
+		Filters: []sharedQuery.Condition{{Column: "status", Value: "CANCELLED"}},
+	})
+	_, unknownErr := handler.Handle(ctx, domain.SandboxQuery{Dataset: "users"})
+	_, limitErr := handler.Handle(ctx, domain.SandboxQuery{Dataset: "order_summaries", Limit: MaxSandboxLimit + 1})
+
+	// Assert
+	if err != nil || len(result.Rows) != 1 {
+		t.Fatalf("Expected one row, got %v and %v", result, err)
+	}
+	if !errors.Is(unknownErr, domain.ErrUnknownDataset) {
+		t.Errorf("Expected ErrUnknownDataset, got %v", unknownErr)
+	}
+	var errs validation.Errors
+	if !errors.As(limitErr, &errs) || errs[0].Field != "limit" {
+		t.Errorf("Expected a validation error on limit, got %v", limitErr)
+	}
+	if sandbox.runs != 2 {
+		t.Errorf("Expected the query over the limit not to run, got %d runs", sandbox.runs)
+	}
+
+	if len(logs.logs) != 3 {
+		t.Fatalf("Expected every query to be logged, got %d logs", len(logs.logs))
+	}
+	first := logs.logs[0]
+	if first.Actor != "user:42" || first.Rows != 1 || first.Error != "" {
+		t.Errorf("Unexpected log of the answered query %+v", first)
+	}
+	if want := `{"filters":[{"column":"status","value":"CANCELLED"}],"limit":100}`; first.Query != want {
+		t.Errorf("Expected query %s, got %s", want, first.Query)
+	}
+	if logs.logs[1].Error == "" || logs.logs[2].Error == "" {
+		t.Errorf("Expected the denied queries to be logged with their error, got %+v", logs.logs[1:])
+	}
+}
+
+func TestRunSandboxQueryHandler_TruncatesLoggedErrorsAtRuneBoundaries(t *testing.T) {
+	// Arrange: "é" takes two bytes, so an odd byte limit falls inside a rune
+	sandbox := &MockSandbox{err: errors.New(strings.Repeat("é", maxLoggedError))}
+	logs := &MockQueryLogs{}
+	handler := &RunSandboxQueryHandler{Sandbox: sandbox, Logs: logs}
+
+	// Act
+	_, _ = handler.Handle(context.Background(), domain.SandboxQuery{Dataset: "order_summaries"})
+
+	// Assert
+	if len(logs.logs) != 1 {
+		t.Fatalf("Expected the failed query to be logged, got %d logs", len(logs.logs))
+	}
+	logged := logs.logs[0].Error
+	if len(logged) > maxLoggedError || !utf8.ValidString(logged) {
+		t.Errorf("Expected at most %d bytes of valid UTF-8, got %d bytes %q", maxLoggedError, len(logged), logged)
+	}
+	if want := strings.Repeat("é", maxLoggedError/2); logged != want {
+		t.Errorf("Expected the error cut before the split rune, got %q", logged)
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+// ErrUnknownDataset is returned for datasets that are not on the sandbox allowlist
+var ErrUnknownDataset = errors.New("unknown dataset")
+
+// RedactedValue replaces the values of PII columns in sandbox results
+const RedactedValue = "[redacted]"
+
+// Dataset describes a read model support staff may query through the sandbox
+type Dataset struct {
+	Name    string
+	Columns []string
+	// Redacted are the PII columns whose values are never returned; they cannot be filtered or sorted on
+	Redacted []string
+}
+
+// SandboxQuery filters a dataset with the JSON filter DSL of the query package
+type SandboxQuery struct {
+	Dataset string
+	Filters []query.Condition
+	Sort    []query.Sort
+	Limit   int
+}
+
+// SandboxResult holds the rows of a sandbox query keyed by column, PII columns redacted
+type SandboxResult struct {
+	Columns []string
+	Rows    []map[string]any
+	// Truncated is set when more rows matched than the limit of the query
+	Truncated bool
+}
+
+// Sandbox runs queries against an allowlist of datasets. It never runs raw SQL: filters and
+// sorts name columns of the dataset, checked before the query reaches the database.
+type Sandbox interface {
+	// Datasets lists the allowlisted datasets by name
+	Datasets() []Dataset
+	// Run returns ErrUnknownDataset for datasets off the allowlist and validation.Errors for
+	// filters or sorts on unknown or redacted columns
+	Run(ctx context.Context, q SandboxQuery) (*SandboxResult, error)
+}
+
+// QueryLog records a sandbox query: who ran it, what it asked for and how many rows it returned.
+// Denied and failed queries are recorded too.
+type QueryLog struct {
+	ID       int64  `gorm:"primaryKey"`
+	Actor    string `gorm:"type:varchar(64);not null;index"`
+	TenantID string `gorm:"type:varchar(64);not null"`
+	Dataset  string `gorm:"type:varchar(64);not null"`
+	// Query is the filters, sorts and limit as JSON
+	Query     string `gorm:"type:text;not null"`
+	Rows      int    `gorm:"not null"`
+	Truncated bool   `gorm:"not null;default:false"`
+	// Error is why the query was denied or failed; it is empty for answered queries
+	Error      string    `gorm:"type:varchar(255)"`
+	DurationMs int64     `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"not null;index"`
+}
+
+func (QueryLog) TableName() string {
+	return "support_query_logs"
+}
+
+type QueryLogRepository interface {
+	Create(ctx context.Context, l *QueryLog) error
+	// List returns the newest logs first, of one actor when actor is not empty
+	List(ctx context.Context, actor string, limit int) ([]QueryLog, error)
+}
@@ -0,0 +1,194 @@
+package port
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/support/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+const (
+	defaultLogLimit = 50
+	maxLogLimit     = 200
+)
+
+// DatasetResponse is a read model that can be queried through the sandbox
+type DatasetResponse struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	// Redacted columns are returned as "[redacted]" and cannot be filtered or sorted on
+	Redacted []string `json:"redacted"`
+}
+
+// DatasetsResponse lists the datasets of the sandbox
+type DatasetsResponse struct {
+	Datasets []DatasetResponse `json:"datasets"`
+}
+
+// SandboxQueryRequest is the body of POST /support/queries, e.g.
+// {"dataset": "order_summaries", "filters": [{"column": "status", "op": "=", "value": "CANCELLED"}], "limit": 20}
+type SandboxQueryRequest struct {
+	Dataset string            `json:"dataset"`
+	Filters []query.Condition `json:"filters,omitempty"`
+	Sort    []query.Sort      `json:"sort,omitempty"`
+	// Limit defaults to 100 rows and may be at most 500
+	Limit int `json:"limit,omitempty"`
+}
+
+// SandboxQueryResponse holds the rows of a sandbox query keyed by column
+type SandboxQueryResponse struct {
+	Columns []string         `json:"columns"`
+	Rows    []map[string]any `json:"rows"`
+	// Truncated is set when more rows matched than the limit
+	Truncated bool `json:"truncated"`
+}
+
+// QueryLogResponse is a recorded sandbox query
+type QueryLogResponse struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	TenantID   string          `json:"tenant_id"`
+	Dataset    string          `json:"dataset"`
+	Query      json.RawMessage `json:"query"`
+	Rows       int             `json:"rows"`
+	Truncated  bool            `json:"truncated"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// QueryLogsResponse lists sandbox queries, newest first
+type QueryLogsResponse struct {
+	Queries []QueryLogResponse `json:"queries"`
+}
+
+// HTTPServer exposes the support query sandbox over HTTP
+type HTTPServer struct {
+	RunSandboxQuery decorator.QueryHandler[domain.SandboxQuery, *domain.SandboxResult]
+	Sandbox         domain.Sandbox
+	Logs            domain.QueryLogRepository
+
+	// Auth restricts the sandbox to support staff and its log to auditors; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the support endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/support/datasets",
+		Summary:  "List the read models support staff can query, with their columns and redacted PII columns",
+		Tags:     []string{"support"},
+		Response: DatasetsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionSupportQuery, s.listDatasets),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/support/queries",
+		Summary:  "Query a read model with the JSON filter DSL; PII is redacted and every query is logged",
+		Tags:     []string{"support"},
+		Request:  SandboxQueryRequest{},
+		Response: SandboxQueryResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionSupportQuery, s.runQuery),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/support/queries",
+		Summary:  "List the logged sandbox queries, newest first, optionally of one actor such as ?actor=user:42",
+		Tags:     []string{"support"},
+		Response: QueryLogsResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionAuditRead, s.listQueries),
+	})
+}
+
+func (s *HTTPServer) listDatasets(w http.ResponseWriter, r *http.Request) {
+	datasets := s.Sandbox.Datasets()
+	resp := DatasetsResponse{Datasets: make([]DatasetResponse, len(datasets))}
+	for i, d := range datasets {
+		redacted := d.Redacted
+		if redacted == nil {
+			redacted = []string{}
+		}
+		resp.Datasets[i] = DatasetResponse{Name: d.Name, Columns: d.Columns, Redacted: redacted}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) runQuery(w http.ResponseWriter, r *http.Request) {
+	var req SandboxQueryRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	result, err := s.RunSandboxQuery.Handle(r.Context(), domain.SandboxQuery{
+		Dataset: req.Dataset,
+		Filters: req.Filters,
+		Sort:    req.Sort,
+		Limit:   req.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUnknownDataset) {
+			var errs validation.Errors
+			errs.Add("dataset", fmt.Sprintf("is not a queryable dataset, got %q", req.Dataset))
+			httpx.WriteError(w, errs)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	rows := result.Rows
+	if rows == nil {
+		rows = []map[string]any{}
+	}
+	httpx.WriteJSON(w, http.StatusOK, SandboxQueryResponse{Columns: result.Columns, Rows: rows, Truncated: result.Truncated})
+}
+
+func (s *HTTPServer) listQueries(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit := defaultLogLimit
+	if value := values.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxLogLimit {
+			var errs validation.Errors
+			errs.Add("limit", fmt.Sprintf("must be between 1 and %d, got %q", maxLogLimit, value))
+			httpx.WriteError(w, errs)
+			return
+		}
+		limit = n
+	}
+
+	logs, err := s.Logs.List(r.Context(), values.Get("actor"), limit)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := QueryLogsResponse{Queries: make([]QueryLogResponse, len(logs))}
+	for i, l := range logs {
+		resp.Queries[i] = QueryLogResponse{
+			ID:         l.ID,
+			Actor:      l.Actor,
+			TenantID:   l.TenantID,
+			Dataset:    l.Dataset,
+			Query:      json.RawMessage(l.Query),
+			Rows:       l.Rows,
+			Truncated:  l.Truncated,
+			Error:      l.Error,
+			DurationMs: l.DurationMs,
+			CreatedAt:  l.CreatedAt,
+		}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
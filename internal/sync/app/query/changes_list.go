@@ -0,0 +1,87 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/sync/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+const (
+	DefaultChangesLimit = 100
+	DefaultMaxLimit     = 500
+)
+
+// ListChangesQuery asks for the products and the orders of a user changed after Cursor, up to
+// Limit of each
+type ListChangesQuery struct {
+	UserID int64
+	Cursor domain.Cursor
+	Limit  int
+}
+
+type ListChangesHandler struct {
+	ProductRepo productDomain.ProductRepository
+	OrderRepo   orderDomain.OrderRepository
+	// SettleDelay leaves the changes of the last moments for a later sync, so that records
+	// written by transactions still in flight, which may commit with an earlier updated_at than
+	// the ones already returned, are not skipped
+	SettleDelay time.Duration
+	// MaxLimit caps Limit; zero means DefaultMaxLimit
+	MaxLimit int
+	Now      func() time.Time
+}
+
+func (h *ListChangesHandler) Handle(ctx context.Context, q ListChangesQuery) (*domain.Changes, error) {
+	if q.Limit == 0 {
+		q.Limit = DefaultChangesLimit
+	}
+	maxLimit := h.MaxLimit
+	if maxLimit == 0 {
+		maxLimit = DefaultMaxLimit
+	}
+	var errs validation.Errors
+	errs.Check(q.Limit >= 1 && q.Limit <= maxLimit, "limit", fmt.Sprintf("must be between 1 and %d, got %d", maxLimit, q.Limit))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	// One more record than asked for tells whether more are waiting
+	until := h.now().Add(-h.SettleDelay)
+	products, err := h.ProductRepo.ListChanged(ctx, q.Cursor.Products, until, q.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("list changed products: %w", err)
+	}
+	orders, err := h.OrderRepo.ListChangedForUser(ctx, q.UserID, q.Cursor.Orders, until, q.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("list changed orders: %w", err)
+	}
+
+	changes := &domain.Changes{Next: q.Cursor}
+	if len(products) > q.Limit || len(orders) > q.Limit {
+		changes.HasMore = true
+	}
+	changes.Products = products[:min(len(products), q.Limit)]
+	changes.Orders = orders[:min(len(orders), q.Limit)]
+	if n := len(changes.Products); n > 0 {
+		last := changes.Products[n-1]
+		changes.Next.Products = persistence.ChangePosition{UpdatedAt: last.UpdatedAt, ID: last.ID}
+	}
+	if n := len(changes.Orders); n > 0 {
+		last := changes.Orders[n-1]
+		changes.Next.Orders = persistence.ChangePosition{UpdatedAt: last.UpdatedAt, ID: last.ID}
+	}
+	return changes, nil
+}
+
+func (h *ListChangesHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,117 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/sync/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+var base = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type MockProductRepository struct {
+	productDomain.ProductRepository
+	products []productDomain.Product
+	until    time.Time
+}
+
+func (m *MockProductRepository) ListChanged(ctx context.Context, after persistence.ChangePosition, until time.Time, limit int) ([]productDomain.Product, error) {
+	m.until = until
+	return m.products[:min(len(m.products), limit)], nil
+}
+
+type MockOrderRepository struct {
+	orderDomain.OrderRepository
+	orders []orderDomain.Order
+	userID int64
+}
+
+func (m *MockOrderRepository) ListChangedForUser(ctx context.Context, userID int64, after persistence.ChangePosition, until time.Time, limit int) ([]orderDomain.Order, error) {
+	m.userID = userID
+	return m.orders[:min(len(m.orders), limit)], nil
+}
+
+func TestListChangesHandler_Handle(t *testing.T) {
+	// Arrange
+	products := &MockProductRepository{products: []productDomain.Product{
+		{ID: 3, UpdatedAt: base},
+		{ID: 1, UpdatedAt: base.Add(time.Second)},
+		{ID: 2, UpdatedAt: base.Add(2 * time.Second)},
+	}}
+	orders := &MockOrderRepository{orders: []orderDomain.Order{{ID: 7, UpdatedAt: base}}}
+	handler := &ListChangesHandler{
+		ProductRepo: products,
+		OrderRepo:   orders,
+		SettleDelay: 5 * time.Second,
+		Now:         func() time.Time { return base.Add(time.Hour) },
+	}
+	cursor := domain.Cursor{Orders: persistence.ChangePosition{UpdatedAt: base.Add(-time.Hour), ID: 4}}
+
+	// Act
+	changes, err := handler.Handle(context.Background(), ListChangesQuery{UserID: 42, Cursor: cursor, Limit: 2})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(changes.Products) != 2 || len(changes.Orders) != 1 || !changes.HasMore {
+		t.Errorf("Expected 2 products, 1 order and more to come, got %+v", changes)
+	}
+	if want := (persistence.ChangePosition{UpdatedAt: base.Add(time.Second), ID: 1}); changes.Next.Products != want {
+		t.Errorf("Expected the products cursor at the last product returned, got %+v", changes.Next.Products)
+	}
+	if want := (persistence.ChangePosition{UpdatedAt: base, ID: 7}); changes.Next.Orders != want {
+		t.Errorf("Expected the orders cursor at the last order, got %+v", changes.Next.Orders)
+	}
+	if !products.until.Equal(base.Add(time.Hour - 5*time.Second)) {
+		t.Errorf("Expected changes of the settle delay to be held back, got until %s", products.until)
+	}
+	if orders.userID != 42 {
+		t.Errorf("Expected the orders of user 42, got %d", orders.userID)
+	}
+}
+
+func TestListChangesHandler_KeepsCursorWithoutChanges(t *testing.T) {
+	handler := &ListChangesHandler{ProductRepo: &MockProductRepository{}, OrderRepo: &MockOrderRepository{}}
+	cursor := domain.CursorSince(base)
+
+	changes, err := handler.Handle(context.Background(), ListChangesQuery{UserID: 42, Cursor: cursor})
+
+	if err != nil || changes.HasMore || changes.Next != cursor {
+		t.Errorf("Expected the cursor back and nothing more, got %+v and %v", changes, err)
+	}
+}
+
+func TestListChangesHandler_ValidatesLimit(t *testing.T) {
+	handler := &ListChangesHandler{ProductRepo: &MockProductRepository{}, OrderRepo: &MockOrderRepository{}, MaxLimit: 50}
+
+	_, err := handler.Handle(context.Background(), ListChangesQuery{UserID: 42, Limit: 51})
+
+	var errs validation.Errors
+	if !errors.As(err, &errs) || errs[0].Field != "limit" {
+		t.Errorf("Expected a validation error on limit, got %v", err)
+	}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := domain.Cursor{
+		Products: persistence.ChangePosition{UpdatedAt: base, ID: 3},
+		Orders:   persistence.ChangePosition{UpdatedAt: base.Add(time.Minute), ID: 9},
+	}
+
+	parsed, err := domain.ParseCursor(cursor.Encode())
+	_, invalidErr := domain.ParseCursor("not-a-cursor")
+
+	if err != nil || !parsed.Products.UpdatedAt.Equal(cursor.Products.UpdatedAt) || parsed.Orders.ID != 9 {
+		t.Errorf("Expected %+v back, got %+v and %v", cursor, parsed, err)
+	}
+	if !validation.IsValidationError(invalidErr) {
+		t.Errorf("Expected a validation error, got %v", invalidErr)
+	}
+}
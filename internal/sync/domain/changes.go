@@ -0,0 +1,61 @@
+// Package domain describes the changes offline clients download to bring their copy of the
+// catalogue and of their orders up to date
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// Cursor is how far a client synced each kind of record. The zero Cursor starts from the
+// beginning, so a first sync downloads everything.
+type Cursor struct {
+	Products persistence.ChangePosition `json:"p"`
+	Orders   persistence.ChangePosition `json:"o"`
+}
+
+// CursorSince starts both kinds of records at t, for clients that only kept the time they last synced
+func CursorSince(t time.Time) Cursor {
+	return Cursor{Products: persistence.ChangePosition{UpdatedAt: t}, Orders: persistence.ChangePosition{UpdatedAt: t}}
+}
+
+// Encode returns the cursor as an opaque token for clients to send back
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor reads a token of Cursor.Encode; an empty token is the zero Cursor
+func ParseCursor(token string) (Cursor, error) {
+	var c Cursor
+	if token == "" {
+		return c, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		var errs validation.Errors
+		errs.Add("cursor", "is not a cursor returned by /sync")
+		return Cursor{}, errs
+	}
+	return c, nil
+}
+
+// Changes are the records changed after a cursor, oldest change first. Products include the
+// deleted ones, with DeletedAt set, so clients drop them.
+type Changes struct {
+	Products []productDomain.Product
+	Orders   []orderDomain.Order
+	// Next is the cursor to send on the next sync
+	Next Cursor
+	// HasMore is set when more changes are waiting, which clients fetch right away with Next
+	HasMore bool
+}
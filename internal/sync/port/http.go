@@ -0,0 +1,190 @@
+package port
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/sync/app/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/sync/domain"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// SyncProductResponse is a product as stored by offline clients
+type SyncProductResponse struct {
+	ID    string `json:"id"`
+	SKU   string `json:"sku,omitempty"`
+	Name  string `json:"name"`
+	Stock int    `json:"stock"`
+	// PriceAmount is in minor units of PriceCurrency; both are omitted for unpriced products
+	PriceAmount      int64     `json:"price_amount,omitempty"`
+	PriceCurrency    string    `json:"price_currency,omitempty"`
+	ReorderThreshold *int      `json:"reorder_threshold,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// SyncOrderResponse is an order of the caller as stored by offline clients
+type SyncOrderResponse struct {
+	ID                string                  `json:"id"`
+	Number            string                  `json:"number,omitempty"`
+	ProductID         string                  `json:"product_id"`
+	Quantity          int                     `json:"quantity"`
+	Status            orderDomain.OrderStatus `json:"status"`
+	ShippingAddressID string                  `json:"shipping_address_id,omitempty"`
+	// UnitPrice and Total are in minor units of Currency; they are omitted for unpriced products
+	UnitPrice int64     `json:"unit_price,omitempty"`
+	Total     int64     `json:"total,omitempty"`
+	Currency  string    `json:"currency,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductChangesResponse lists the products to store and the IDs of the products to drop
+type ProductChangesResponse struct {
+	Upserted []SyncProductResponse `json:"upserted"`
+	Deleted  []string              `json:"deleted"`
+}
+
+// OrderChangesResponse lists the orders to store; orders are never deleted
+type OrderChangesResponse struct {
+	Upserted []SyncOrderResponse `json:"upserted"`
+}
+
+// ChangesResponse holds the changes since the cursor of the request, oldest first
+type ChangesResponse struct {
+	Products ProductChangesResponse `json:"products"`
+	Orders   OrderChangesResponse   `json:"orders"`
+	// Cursor is sent back as ?cursor= on the next sync
+	Cursor string `json:"cursor"`
+	// HasMore is set when more changes are waiting; clients sync again right away with Cursor
+	HasMore bool `json:"has_more"`
+}
+
+// HTTPServer exposes the differential sync of offline clients over HTTP
+type HTTPServer struct {
+	ListChanges decorator.QueryHandler[query.ListChangesQuery, *domain.Changes]
+
+	// Auth requires order:read:own, since the caller's orders are synced; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the sync endpoint to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/sync",
+		Summary:  "List the products and the caller's orders changed since ?cursor= (or ?since=2024-05-18T12:00:00Z), oldest change first, up to ?limit= of each (default 100); without either it lists everything",
+		Tags:     []string{"sync"},
+		Response: ChangesResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionOrderReadOwn, s.listChanges),
+	})
+}
+
+func (s *HTTPServer) listChanges(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		auth.WriteError(w, auth.ErrUnauthenticated)
+		return
+	}
+	q, err := parseChangesQuery(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	q.UserID = userID
+
+	changes, err := s.ListChanges.Handle(r.Context(), q)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, toChangesResponse(changes))
+}
+
+func parseChangesQuery(r *http.Request) (query.ListChangesQuery, error) {
+	values := r.URL.Query()
+	var q query.ListChangesQuery
+	var errs validation.Errors
+	if value := values.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		errs.Check(err == nil, "limit", fmt.Sprintf("must be a number, got %q", value))
+		q.Limit = n
+	}
+
+	cursor, since := values.Get("cursor"), values.Get("since")
+	switch {
+	case cursor != "" && since != "":
+		errs.Add("since", "cannot be combined with cursor")
+	case since != "":
+		t, err := time.Parse(time.RFC3339, since)
+		errs.Check(err == nil, "since", fmt.Sprintf("must be an RFC 3339 time, got %q", since))
+		q.Cursor = domain.CursorSince(t)
+	default:
+		c, err := domain.ParseCursor(cursor)
+		errs.Merge("", err)
+		q.Cursor = c
+	}
+	return q, errs.Err()
+}
+
+func toChangesResponse(c *domain.Changes) ChangesResponse {
+	resp := ChangesResponse{
+		Products: ProductChangesResponse{Upserted: []SyncProductResponse{}, Deleted: []string{}},
+		Orders:   OrderChangesResponse{Upserted: make([]SyncOrderResponse, len(c.Orders))},
+		Cursor:   c.Next.Encode(),
+		HasMore:  c.HasMore,
+	}
+	for i := range c.Products {
+		p := &c.Products[i]
+		if p.DeletedAt.Valid {
+			resp.Products.Deleted = append(resp.Products.Deleted, p.PublicID)
+			continue
+		}
+		resp.Products.Upserted = append(resp.Products.Upserted, toSyncProductResponse(p))
+	}
+	for i := range c.Orders {
+		resp.Orders.Upserted[i] = toSyncOrderResponse(&c.Orders[i])
+	}
+	return resp
+}
+
+func toSyncProductResponse(p *productDomain.Product) SyncProductResponse {
+	price := p.Price()
+	return SyncProductResponse{
+		ID:               p.PublicID,
+		SKU:              p.SKUValue(),
+		Name:             p.Name,
+		Stock:            p.Stock,
+		PriceAmount:      price.Amount,
+		PriceCurrency:    price.Currency,
+		ReorderThreshold: p.ReorderThreshold,
+		UpdatedAt:        p.UpdatedAt,
+	}
+}
+
+func toSyncOrderResponse(o *orderDomain.Order) SyncOrderResponse {
+	var shippingAddressID string
+	if o.ShippingAddress != nil {
+		shippingAddressID = o.ShippingAddress.PublicID
+	}
+	return SyncOrderResponse{
+		ID:                o.PublicID,
+		Number:            o.Number,
+		ProductID:         o.Product.PublicID,
+		Quantity:          o.Quantity.Int(),
+		Status:            o.Status,
+		ShippingAddressID: shippingAddressID,
+		UnitPrice:         o.UnitPrice.Amount,
+		Total:             o.Total().Amount,
+		Currency:          o.UnitPrice.Currency,
+		CreatedAt:         o.CreatedAt,
+		UpdatedAt:         o.UpdatedAt,
+	}
+}
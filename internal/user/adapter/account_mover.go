@@ -0,0 +1,92 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormAccountMover moves the address book and roles of merged users
+type GormAccountMover struct {
+	db *gorm.DB
+}
+
+func NewGormAccountMover(db *gorm.DB) domain.AccountMover {
+	return &GormAccountMover{db: db}
+}
+
+// MoveAccount gives the target every role of the source; roles both users have are kept once
+func (m *GormAccountMover) MoveAccount(ctx context.Context, fromUserID, toUserID int64) ([]domain.MergeItem, error) {
+	db := persistence.Conn(ctx, m.db)
+	addresses, err := m.moveAddresses(db, fromUserID, toUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := domain.MergeItem{Kind: "roles"}
+	var roleIDs []int64
+	if err := db.Model(&domain.UserRole{}).Where("user_id = ?", fromUserID).Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	for _, roleID := range roleIDs {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.UserRole{UserID: toUserID, RoleID: roleID})
+		if result.Error != nil {
+			return nil, persistence.TranslateError(result.Error)
+		}
+		roles.Moved += int(result.RowsAffected)
+	}
+	if err := db.Where("user_id = ?", fromUserID).Delete(&domain.UserRole{}).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return []domain.MergeItem{addresses, roles}, nil
+}
+
+// moveAddresses hands the address book of the source to the target. An address the target has
+// too, down to the phone, is dropped unless orders ship to it, as orders keep their address.
+func (m *GormAccountMover) moveAddresses(db *gorm.DB, fromUserID, toUserID int64) (domain.MergeItem, error) {
+	item := domain.MergeItem{Kind: "addresses"}
+	var source, target []domain.Address
+	if err := db.Where("user_id = ?", fromUserID).Order("id").Find(&source).Error; err != nil {
+		return item, persistence.TranslateError(err)
+	}
+	if err := db.Where("user_id = ?", toUserID).Find(&target).Error; err != nil {
+		return item, persistence.TranslateError(err)
+	}
+
+	for _, a := range source {
+		if same := sameAddress(target, a); same != nil {
+			// The delete runs in a savepoint, so an address orders refer to fails it alone
+			err := persistence.TranslateError(db.Transaction(func(tx *gorm.DB) error {
+				return tx.Delete(&domain.Address{}, a.ID).Error
+			}))
+			if err == nil {
+				item.Conflicts = append(item.Conflicts, fmt.Sprintf("%s: dropped as a duplicate of %s", a.PublicID, same.PublicID))
+				continue
+			}
+			if !errors.Is(err, persistence.ErrForeignKeyViolation) {
+				return item, err
+			}
+			item.Conflicts = append(item.Conflicts, fmt.Sprintf("%s: kept next to its duplicate %s as orders ship to it", a.PublicID, same.PublicID))
+		}
+		if err := db.Model(&domain.Address{}).Where("id = ?", a.ID).Update("user_id", toUserID).Error; err != nil {
+			return item, persistence.TranslateError(err)
+		}
+		item.Moved++
+	}
+	return item, nil
+}
+
+// sameAddress finds the address of addresses a duplicates; labels may differ
+func sameAddress(addresses []domain.Address, a domain.Address) *domain.Address {
+	for i := range addresses {
+		if addresses[i].Address == a.Address && addresses[i].Phone == a.Phone {
+			return &addresses[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormAccountTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAccountTokenRepository(db *gorm.DB) domain.AccountTokenRepository {
+	return &GormAccountTokenRepository{db: db}
+}
+
+func (r *GormAccountTokenRepository) Create(ctx context.Context, t *domain.AccountToken) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(t).Error)
+}
+
+func (r *GormAccountTokenRepository) GetBySelector(ctx context.Context, selector string) (*domain.AccountToken, error) {
+	var t domain.AccountToken
+	if err := persistence.Conn(ctx, r.db).Where("selector = ?", selector).First(&t).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &t, nil
+}
+
+// RecordFailure increments the counter in the database, so concurrent guesses are all counted
+func (r *GormAccountTokenRepository) RecordFailure(ctx context.Context, id int64) error {
+	err := persistence.Conn(ctx, r.db).Model(&domain.AccountToken{}).Where("id = ?", id).
+		Update("failed_attempts", gorm.Expr("failed_attempts + 1")).Error
+	return persistence.TranslateError(err)
+}
+
+func (r *GormAccountTokenRepository) MarkUsed(ctx context.Context, id int64, at time.Time) error {
+	result := persistence.Conn(ctx, r.db).Model(&domain.AccountToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return persistence.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormAccountTokenRepository) CountCreatedSince(ctx context.Context, userID int64, purpose domain.TokenPurpose, since time.Time) (int64, error) {
+	var n int64
+	err := persistence.Conn(ctx, r.db).Model(&domain.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at >= ?", userID, purpose, since).
+		Count(&n).Error
+	return n, persistence.TranslateError(err)
+}
+
+func (r *GormAccountTokenRepository) Revoke(ctx context.Context, userID int64, purpose domain.TokenPurpose, at time.Time) error {
+	err := persistence.Conn(ctx, r.db).Model(&domain.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", at).Error
+	return persistence.TranslateError(err)
+}
@@ -0,0 +1,54 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormAccountTokenRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.AccountToken{}))
+
+	repo := adapter.NewGormAccountTokenRepository(db)
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	u := &domain.User{ID: 7, Email: "jane@example.com"}
+
+	first, _, err := domain.NewAccountToken(u, domain.TokenPasswordReset, time.Hour, now.Add(-2*time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Create(ctx, first))
+	second, token, err := domain.NewAccountToken(u, domain.TokenPasswordReset, time.Hour, now)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Create(ctx, second))
+
+	count, err := repo.CountCreatedSince(ctx, u.ID, domain.TokenPasswordReset, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	selector, _, _ := domain.SplitToken(token)
+	assert.NoError(t, repo.RecordFailure(ctx, second.ID))
+	assert.NoError(t, repo.RecordFailure(ctx, second.ID))
+	stored, err := repo.GetBySelector(ctx, selector)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stored.FailedAttempts)
+
+	assert.NoError(t, repo.MarkUsed(ctx, second.ID, now))
+	assert.ErrorIs(t, repo.MarkUsed(ctx, second.ID, now), persistence.ErrNotFound, "tokens are single use")
+
+	assert.NoError(t, repo.Revoke(ctx, u.ID, domain.TokenPasswordReset, now))
+	revoked, err := repo.GetBySelector(ctx, first.Selector)
+	assert.NoError(t, err)
+	assert.NotNil(t, revoked.UsedAt)
+
+	_, err = repo.GetBySelector(ctx, "unknown")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
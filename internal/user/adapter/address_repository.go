@@ -0,0 +1,38 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormAddressRepository struct {
+	*persistence.GenericRepository[domain.Address, int64]
+	db *gorm.DB
+}
+
+func NewGormAddressRepository(db *gorm.DB) domain.AddressRepository {
+	return &GormAddressRepository{GenericRepository: persistence.NewGenericRepository[domain.Address, int64](db), db: db}
+}
+
+func (r *GormAddressRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Address, error) {
+	var address domain.Address
+	err := persistence.Conn(ctx, r.db).Where("public_id = ?", publicID).First(&address).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &address, nil
+}
+
+func (r *GormAddressRepository) ListByUser(ctx context.Context, userID int64) ([]domain.Address, error) {
+	var addresses []domain.Address
+	err := persistence.Conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&addresses).Error
+	return addresses, persistence.TranslateError(err)
+}
+
+func (r *GormAddressRepository) Create(ctx context.Context, a *domain.Address) error {
+	a.AssignPublicID()
+	return r.GenericRepository.Create(ctx, a)
+}
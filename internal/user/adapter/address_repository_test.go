@@ -0,0 +1,48 @@
+package adapter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormAddressRepository_AddressBook(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&domain.Address{}))
+	users := adapter.NewGormUserRepository(db)
+	repo := adapter.NewGormAddressRepository(db)
+	ctx := context.Background()
+
+	alice, bob := domain.MustNewUser("alice@example.com"), domain.MustNewUser("bob@example.com")
+	require.NoError(t, users.Save(ctx, alice))
+	require.NoError(t, users.Save(ctx, bob))
+
+	details := domain.AddressDetails{Address: address.Address{Name: "Alice", Line1: "1 Main St", City: "Berlin", PostalCode: "10115", Country: "DE"}}
+	home := &domain.Address{UserID: alice.ID, AddressDetails: details}
+	office := &domain.Address{UserID: alice.ID, AddressDetails: details}
+	require.NoError(t, repo.Create(ctx, home))
+	require.NoError(t, repo.Create(ctx, office))
+	require.NoError(t, repo.Create(ctx, &domain.Address{UserID: bob.ID, AddressDetails: details}))
+	assert.True(t, strings.HasPrefix(home.PublicID, domain.AddressPublicIDPrefix+"_"))
+
+	office.Label = "Office"
+	require.NoError(t, repo.Update(ctx, office))
+	found, err := repo.GetByPublicID(ctx, office.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, "Office", found.Label)
+
+	require.NoError(t, repo.Delete(ctx, home.ID))
+	book, err := repo.ListByUser(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, book, 1)
+	assert.Equal(t, office.ID, book[0].ID)
+
+	assert.ErrorIs(t, repo.Delete(ctx, home.ID), persistence.ErrNotFound)
+}
@@ -0,0 +1,64 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAPIKeyRepository(db *gorm.DB) domain.APIKeyRepository {
+	return &GormAPIKeyRepository{db: db}
+}
+
+func (r *GormAPIKeyRepository) Create(ctx context.Context, k *domain.APIKey) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(k).Error)
+}
+
+func (r *GormAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var k domain.APIKey
+	if err := persistence.Conn(ctx, r.db).Where("prefix = ?", prefix).First(&k).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &k, nil
+}
+
+func (r *GormAPIKeyRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.APIKey, error) {
+	var k domain.APIKey
+	if err := persistence.Conn(ctx, r.db).Where("public_id = ?", publicID).First(&k).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &k, nil
+}
+
+func (r *GormAPIKeyRepository) ListByUser(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	err := persistence.Conn(ctx, r.db).Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, persistence.TranslateError(err)
+}
+
+func (r *GormAPIKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	result := persistence.Conn(ctx, r.db).Model(&domain.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return persistence.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return persistence.ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormAPIKeyRepository) Touch(ctx context.Context, id int64, at time.Time) error {
+	err := persistence.Conn(ctx, r.db).Model(&domain.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at).
+		Update("last_used_at", at).Error
+	return persistence.TranslateError(err)
+}
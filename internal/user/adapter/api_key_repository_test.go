@@ -0,0 +1,56 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormAPIKeyRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.APIKey{}))
+
+	repo := adapter.NewGormAPIKeyRepository(db)
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	older, _, err := domain.NewAPIKey(7, "default", "erp", []auth.Permission{domain.PermissionOrderReadAny}, nil, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Create(ctx, older))
+	newer, key, err := domain.NewAPIKey(7, "default", "warehouse", []auth.Permission{domain.PermissionShipmentManage}, nil, now)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Create(ctx, newer))
+
+	prefix, _, _ := domain.SplitAPIKey(key)
+	stored, err := repo.GetByPrefix(ctx, prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, newer.PublicID, stored.PublicID)
+	assert.Equal(t, []auth.Permission{domain.PermissionShipmentManage}, stored.Scopes)
+
+	keys, err := repo.ListByUser(ctx, 7)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2) {
+		assert.Equal(t, newer.ID, keys[0].ID, "newest first")
+	}
+
+	assert.NoError(t, repo.Touch(ctx, newer.ID, now))
+	assert.NoError(t, repo.Touch(ctx, newer.ID, now.Add(-time.Second)))
+	stored, err = repo.GetByPublicID(ctx, newer.PublicID)
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(*stored.LastUsedAt), "an older touch does not move LastUsedAt back")
+
+	assert.NoError(t, repo.Revoke(ctx, newer.ID, now))
+	assert.ErrorIs(t, repo.Revoke(ctx, newer.ID, now), persistence.ErrNotFound)
+
+	_, err = repo.GetByPrefix(ctx, "aiio_unknown")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
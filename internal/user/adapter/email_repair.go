@@ -0,0 +1,62 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/repair"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+// NormalizeEmailsScript trims and lower-cases the emails of users stored before registration
+// normalized them, which kept those users from logging in with their address as typed
+type NormalizeEmailsScript struct {
+	db *gorm.DB
+}
+
+func NewNormalizeEmailsScript(db *gorm.DB) *NormalizeEmailsScript {
+	return &NormalizeEmailsScript{db: db}
+}
+
+func (s *NormalizeEmailsScript) Name() string {
+	return "normalize-user-emails"
+}
+
+func (s *NormalizeEmailsScript) Description() string {
+	return "trim and lower-case user emails; users whose normalized email is taken are left for a manual merge"
+}
+
+func (s *NormalizeEmailsScript) Batch(ctx context.Context, after int64, limit int) (repair.Batch, error) {
+	conn := persistence.Conn(ctx, s.db)
+	var users []domain.User
+	if err := conn.Select("id", "email").Where("id > ?", after).Order("id").Limit(limit).Find(&users).Error; err != nil {
+		return repair.Batch{}, persistence.TranslateError(err)
+	}
+
+	batch := repair.Batch{Scanned: len(users)}
+	for _, u := range users {
+		batch.Last = u.ID
+		normalized := domain.Email(strings.ToLower(strings.TrimSpace(string(u.Email))))
+		if normalized == u.Email {
+			continue
+		}
+
+		var taken int64
+		if err := conn.Model(&domain.User{}).Where("email = ? AND id <> ?", normalized, u.ID).Count(&taken).Error; err != nil {
+			return batch, persistence.TranslateError(err)
+		}
+		if taken > 0 {
+			slog.WarnContext(ctx, "normalized email belongs to another user, skipping", "user_id", u.ID, "email", normalized)
+			continue
+		}
+		if err := conn.Model(&domain.User{ID: u.ID}).Update("email", normalized).Error; err != nil {
+			return batch, fmt.Errorf("update email of user %d: %w", u.ID, persistence.TranslateError(err))
+		}
+		batch.Changed++
+	}
+	return batch, nil
+}
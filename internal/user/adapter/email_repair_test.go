@@ -0,0 +1,35 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmailsScript_Batch(t *testing.T) {
+	db := setupTestDB(t)
+	for _, email := range []domain.Email{" Ann@Example.com", "bob@example.com", "Carl@example.com", "carl@example.com"} {
+		require.NoError(t, db.Create(&domain.User{Email: email}).Error)
+	}
+	script := adapter.NewNormalizeEmailsScript(db)
+
+	batch, err := script.Batch(context.Background(), 0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, batch.Scanned)
+	assert.EqualValues(t, 3, batch.Last)
+	// Carl's normalized email belongs to user 4, so user 3 is left for a manual merge
+	assert.Equal(t, 1, batch.Changed)
+
+	var emails []string
+	require.NoError(t, db.Model(&domain.User{}).Order("id").Pluck("email", &emails).Error)
+	assert.Equal(t, []string{"ann@example.com", "bob@example.com", "Carl@example.com", "carl@example.com"}, emails)
+
+	batch, err = script.Batch(context.Background(), batch.Last, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, batch.Scanned)
+	assert.Zero(t, batch.Changed)
+}
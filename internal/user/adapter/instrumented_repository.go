@@ -0,0 +1,74 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/metrics"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// InstrumentedUserRepository records the duration of every call to the wrapped repository
+type InstrumentedUserRepository struct {
+	next    domain.UserRepository
+	observe metrics.RepositoryObserver
+}
+
+func NewInstrumentedUserRepository(next domain.UserRepository, m *metrics.Metrics) domain.UserRepository {
+	return &InstrumentedUserRepository{next: next, observe: m.Repository("user")}
+}
+
+func (r *InstrumentedUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	defer r.observe.Since("GetByID", time.Now())
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *InstrumentedUserRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.User, error) {
+	defer r.observe.Since("GetByPublicID", time.Now())
+	return r.next.GetByPublicID(ctx, publicID)
+}
+
+func (r *InstrumentedUserRepository) GetByIDs(ctx context.Context, ids []int64) ([]domain.User, error) {
+	defer r.observe.Since("GetByIDs", time.Now())
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r *InstrumentedUserRepository) Save(ctx context.Context, u *domain.User) error {
+	defer r.observe.Since("Save", time.Now())
+	return r.next.Save(ctx, u)
+}
+
+func (r *InstrumentedUserRepository) GetByEmail(ctx context.Context, email domain.Email) (*domain.User, error) {
+	defer r.observe.Since("GetByEmail", time.Now())
+	return r.next.GetByEmail(ctx, email)
+}
+
+func (r *InstrumentedUserRepository) ExistsByEmail(ctx context.Context, email domain.Email) (bool, error) {
+	defer r.observe.Since("ExistsByEmail", time.Now())
+	return r.next.ExistsByEmail(ctx, email)
+}
+
+func (r *InstrumentedUserRepository) Search(ctx context.Context, filter domain.UserSearchFilter, page, pageSize int) ([]domain.User, int64, error) {
+	defer r.observe.Since("Search", time.Now())
+	return r.next.Search(ctx, filter, page, pageSize)
+}
+
+// InstrumentedLoginAttemptRepository records the duration of every call to the wrapped repository
+type InstrumentedLoginAttemptRepository struct {
+	next    domain.LoginAttemptRepository
+	observe metrics.RepositoryObserver
+}
+
+func NewInstrumentedLoginAttemptRepository(next domain.LoginAttemptRepository, m *metrics.Metrics) domain.LoginAttemptRepository {
+	return &InstrumentedLoginAttemptRepository{next: next, observe: m.Repository("login_attempt")}
+}
+
+func (r *InstrumentedLoginAttemptRepository) Save(ctx context.Context, a *domain.LoginAttempt) error {
+	defer r.observe.Since("Save", time.Now())
+	return r.next.Save(ctx, a)
+}
+
+func (r *InstrumentedLoginAttemptRepository) RecentSuccessful(ctx context.Context, userID int64, limit int) ([]*domain.LoginAttempt, error) {
+	defer r.observe.Since("RecentSuccessful", time.Now())
+	return r.next.RecentSuccessful(ctx, userID, limit)
+}
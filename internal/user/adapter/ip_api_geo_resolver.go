@@ -0,0 +1,80 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// DefaultIPAPIURL is the ip-api.com JSON endpoint
+const DefaultIPAPIURL = "http://ip-api.com/json/"
+
+// IPAPIGeoResolver implements domain.GeoResolver with the ip-api.com lookup API
+type IPAPIGeoResolver struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewIPAPIGeoResolver(baseURL string, client *http.Client) domain.GeoResolver {
+	if baseURL == "" {
+		baseURL = DefaultIPAPIURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Second}
+	}
+	return &IPAPIGeoResolver{baseURL: baseURL, client: client}
+}
+
+type ipAPIResponse struct {
+	Status      string  `json:"status"`
+	Message     string  `json:"message"`
+	CountryCode string  `json:"countryCode"`
+	City        string  `json:"city"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+}
+
+func (g *IPAPIGeoResolver) Resolve(ctx context.Context, ip string) (domain.GeoLocation, error) {
+	// Private and loopback addresses have no public location; don't leak them to the API
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsUnspecified() {
+		return domain.GeoLocation{}, nil
+	}
+
+	endpoint := g.baseURL + url.PathEscape(ip) + "?fields=status,message,countryCode,city,lat,lon"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return domain.GeoLocation{}, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return domain.GeoLocation{}, fmt.Errorf("geo lookup: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domain.GeoLocation{}, fmt.Errorf("geo lookup: unexpected status %d", resp.StatusCode)
+	}
+
+	var body ipAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return domain.GeoLocation{}, fmt.Errorf("geo lookup: %w", err)
+	}
+	if body.Status != "success" {
+		return domain.GeoLocation{}, fmt.Errorf("geo lookup: %s", body.Message)
+	}
+
+	return domain.GeoLocation{
+		Country:   body.CountryCode,
+		City:      body.City,
+		Latitude:  body.Lat,
+		Longitude: body.Lon,
+	}, nil
+}
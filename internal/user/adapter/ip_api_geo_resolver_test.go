@@ -0,0 +1,56 @@
+package adapter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPAPIGeoResolver_Resolve(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		fmt.Fprint(w, `{"status":"success","countryCode":"DE","city":"Berlin","lat":52.52,"lon":13.405}`)
+	}))
+	defer server.Close()
+
+	resolver := adapter.NewIPAPIGeoResolver(server.URL+"/json/", server.Client())
+
+	location, err := resolver.Resolve(context.Background(), "203.0.113.7")
+	assert.NoError(t, err)
+	assert.Equal(t, "/json/203.0.113.7", requestedPath)
+	assert.Equal(t, domain.GeoLocation{Country: "DE", City: "Berlin", Latitude: 52.52, Longitude: 13.405}, location)
+}
+
+func TestIPAPIGeoResolver_SkipsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("private addresses must not be sent to the API")
+	}))
+	defer server.Close()
+
+	resolver := adapter.NewIPAPIGeoResolver(server.URL+"/json/", server.Client())
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "::1", "not-an-ip"} {
+		location, err := resolver.Resolve(context.Background(), ip)
+		assert.NoError(t, err)
+		assert.False(t, location.IsKnown(), ip)
+	}
+}
+
+func TestIPAPIGeoResolver_FailedLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"fail","message":"reserved range"}`)
+	}))
+	defer server.Close()
+
+	resolver := adapter.NewIPAPIGeoResolver(server.URL+"/json/", server.Client())
+
+	_, err := resolver.Resolve(context.Background(), "203.0.113.7")
+	assert.ErrorContains(t, err, "reserved range")
+}
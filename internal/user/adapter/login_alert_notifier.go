@@ -0,0 +1,69 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// SendMailFunc has the signature of smtp.SendMail so tests can capture outgoing mail
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// LoginAlertNotifier emails users when a login to their account looks suspicious.
+// Without an SMTP address the alerts are only logged.
+type LoginAlertNotifier struct {
+	Addr     string
+	Auth     smtp.Auth
+	From     string
+	SendMail SendMailFunc
+}
+
+func NewLoginAlertNotifier(addr string, auth smtp.Auth, from string) *LoginAlertNotifier {
+	return &LoginAlertNotifier{Addr: addr, Auth: auth, From: from, SendMail: smtp.SendMail}
+}
+
+// Handle is an event.Handler for domain.LoginAnomalyDetected
+func (n *LoginAlertNotifier) Handle(ctx context.Context, e event.Event) error {
+	anomaly, ok := e.(domain.LoginAnomalyDetected)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	msg := n.message(anomaly)
+	if n.Addr == "" {
+		log.Printf("Login alert for user %d (%s): SMTP not configured, skipping email", anomaly.UserID, anomaly.Kind)
+		return nil
+	}
+	return n.SendMail(n.Addr, n.Auth, n.From, []string{anomaly.Email.String()}, msg)
+}
+
+func (n *LoginAlertNotifier) message(anomaly domain.LoginAnomalyDetected) []byte {
+	attempt := anomaly.Attempt
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", n.From)
+	fmt.Fprintf(&body, "To: %s\r\n", anomaly.Email)
+	fmt.Fprintf(&body, "Subject: New sign-in to your account\r\n")
+	fmt.Fprintf(&body, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+
+	switch anomaly.Kind {
+	case domain.LoginAnomalyNewCountry:
+		fmt.Fprintf(&body, "Your account was signed in to from a country you have not used before.\r\n\r\n")
+	case domain.LoginAnomalyImpossibleTravel:
+		fmt.Fprintf(&body, "Your account was signed in to from a location far away from your previous sign-in.\r\n\r\n")
+	}
+
+	fmt.Fprintf(&body, "Time: %s\r\n", attempt.AttemptedAt.UTC().Format(time.RFC1123))
+	fmt.Fprintf(&body, "Location: %s %s\r\n", attempt.Location.City, attempt.Location.Country)
+	fmt.Fprintf(&body, "IP address: %s\r\n", attempt.IP)
+	fmt.Fprintf(&body, "Device: %s\r\n\r\n", attempt.Device)
+	fmt.Fprintf(&body, "If this was not you, change your password immediately.\r\n")
+
+	return body.Bytes()
+}
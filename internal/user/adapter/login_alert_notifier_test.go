@@ -0,0 +1,56 @@
+package adapter_test
+
+import (
+	"context"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAlertNotifier_SendsEmail(t *testing.T) {
+	var sentTo []string
+	var sentMsg string
+	notifier := adapter.NewLoginAlertNotifier("smtp.example.com:587", nil, "security@example.com")
+	notifier.SendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo = to
+		sentMsg = string(msg)
+		return nil
+	}
+
+	userID := int64(7)
+	err := notifier.Handle(context.Background(), domain.LoginAnomalyDetected{
+		UserID: userID,
+		Email:  "jane@example.com",
+		Kind:   domain.LoginAnomalyNewCountry,
+		Attempt: domain.LoginAttempt{
+			UserID:      &userID,
+			IP:          "203.0.113.7",
+			Device:      "Firefox",
+			Location:    domain.GeoLocation{Country: "BR", City: "Recife"},
+			AttemptedAt: time.Date(2024, 5, 18, 10, 0, 0, 0, time.UTC),
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jane@example.com"}, sentTo)
+	assert.Contains(t, sentMsg, "Subject: New sign-in to your account")
+	assert.Contains(t, sentMsg, "a country you have not used before")
+	assert.Contains(t, sentMsg, "Recife BR")
+	assert.Contains(t, sentMsg, "203.0.113.7")
+}
+
+func TestLoginAlertNotifier_WithoutSMTPOnlyLogs(t *testing.T) {
+	notifier := adapter.NewLoginAlertNotifier("", nil, "security@example.com")
+	notifier.SendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		t.Fatal("no mail must be sent without an SMTP address")
+		return nil
+	}
+
+	err := notifier.Handle(context.Background(), domain.LoginAnomalyDetected{UserID: 1, Kind: domain.LoginAnomalyImpossibleTravel})
+
+	assert.NoError(t, err)
+}
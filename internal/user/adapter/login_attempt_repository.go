@@ -0,0 +1,34 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+)
+
+type GormLoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLoginAttemptRepository(db *gorm.DB) domain.LoginAttemptRepository {
+	return &GormLoginAttemptRepository{db: db}
+}
+
+func (r *GormLoginAttemptRepository) Save(ctx context.Context, a *domain.LoginAttempt) error {
+	return persistence.TranslateError(r.db.WithContext(ctx).Create(a).Error)
+}
+
+func (r *GormLoginAttemptRepository) RecentSuccessful(ctx context.Context, userID int64, limit int) ([]*domain.LoginAttempt, error) {
+	var attempts []*domain.LoginAttempt
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND success = ?", userID, true).
+		Order("attempted_at DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return attempts, nil
+}
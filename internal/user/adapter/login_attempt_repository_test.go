@@ -0,0 +1,42 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormLoginAttemptRepository_RecentSuccessful(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.LoginAttempt{}))
+
+	repo := adapter.NewGormLoginAttemptRepository(db)
+	ctx := context.Background()
+	userID, otherID := int64(1), int64(2)
+	start := time.Date(2024, 5, 18, 8, 0, 0, 0, time.UTC)
+
+	attempts := []*domain.LoginAttempt{
+		{UserID: &userID, Email: "jane@example.com", Success: true, Location: domain.GeoLocation{Country: "DE"}, AttemptedAt: start},
+		{UserID: &userID, Email: "jane@example.com", FailureReason: domain.LoginFailureWrongPassword, AttemptedAt: start.Add(time.Minute)},
+		{UserID: &otherID, Email: "john@example.com", Success: true, AttemptedAt: start.Add(2 * time.Minute)},
+		{UserID: &userID, Email: "jane@example.com", Success: true, Location: domain.GeoLocation{Country: "FR"}, AttemptedAt: start.Add(3 * time.Minute)},
+	}
+	for _, a := range attempts {
+		assert.NoError(t, repo.Save(ctx, a))
+	}
+
+	recent, err := repo.RecentSuccessful(ctx, userID, 10)
+
+	assert.NoError(t, err)
+	if assert.Len(t, recent, 2) {
+		assert.Equal(t, "FR", recent[0].Location.Country, "newest first")
+		assert.Equal(t, "DE", recent[1].Location.Country)
+	}
+}
@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// DefaultPwnedPasswordsURL is the Have I Been Pwned range API
+const DefaultPwnedPasswordsURL = "https://api.pwnedpasswords.com/range/"
+
+// PwnedPasswordsChecker implements domain.BreachedPasswordChecker using the k-anonymity range API:
+// only the first five characters of the SHA-1 hash leave the process, the match happens locally
+type PwnedPasswordsChecker struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewPwnedPasswordsChecker(baseURL string, client *http.Client) domain.BreachedPasswordChecker {
+	if baseURL == "" {
+		baseURL = DefaultPwnedPasswordsURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 3 * time.Second}
+	}
+	return &PwnedPasswordsChecker{baseURL: baseURL, client: client}
+}
+
+func (c *PwnedPasswordsChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// Padding hides the real number of matches from observers of the response size
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("pwned passwords request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("pwned passwords request: unexpected status %d", resp.StatusCode)
+	}
+
+	// Each line is "<HASH SUFFIX>:<COUNT>"; padded entries have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if found && candidate == suffix && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,46 @@
+package adapter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPwnedPasswordsChecker_IsBreached(t *testing.T) {
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		fmt.Fprintln(w, "0018A45C4D1DEF81644B54AB7F969B88D65:0")
+		fmt.Fprintln(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493")
+	}))
+	defer server.Close()
+
+	checker := adapter.NewPwnedPasswordsChecker(server.URL+"/range/", server.Client())
+
+	breached, err := checker.IsBreached(context.Background(), "password")
+	assert.NoError(t, err)
+	assert.True(t, breached)
+	assert.Equal(t, "/range/5BAA6", requestedPath, "only the hash prefix may be sent")
+
+	breached, err = checker.IsBreached(context.Background(), "a much better passphrase")
+	assert.NoError(t, err)
+	assert.False(t, breached)
+}
+
+func TestPwnedPasswordsChecker_UnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	checker := adapter.NewPwnedPasswordsChecker(server.URL+"/", server.Client())
+
+	_, err := checker.IsBreached(context.Background(), "password")
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRoleRepository(db *gorm.DB) domain.RoleRepository {
+	return &GormRoleRepository{db: db}
+}
+
+func (r *GormRoleRepository) Seed(ctx context.Context, roles []domain.Role) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, seed := range roles {
+			role := domain.Role{Name: seed.Name}
+			if err := tx.Where("name = ?", seed.Name).FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+			permissions := make([]domain.Permission, len(seed.Permissions))
+			for i, p := range seed.Permissions {
+				permissions[i] = domain.Permission{Name: p.Name}
+				if err := tx.Where("name = ?", p.Name).FirstOrCreate(&permissions[i]).Error; err != nil {
+					return err
+				}
+			}
+			// Grants are only added; permissions revoked by hand stay revoked until they are seeded again
+			if err := tx.Model(&role).Association("Permissions").Append(permissions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return persistence.TranslateError(err)
+}
+
+func (r *GormRoleRepository) Assign(ctx context.Context, userID int64, name string) error {
+	var role domain.Role
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		err = persistence.TranslateError(err)
+		if errors.Is(err, persistence.ErrNotFound) {
+			return domain.ErrRoleNotFound
+		}
+		return err
+	}
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.UserRole{UserID: userID, RoleID: role.ID}).Error
+	return persistence.TranslateError(err)
+}
+
+// PermissionsOf only grants the roles of users of the tenant of ctx, so a user signed in to another
+// tenant holds none; unscoped callers see the roles of every user
+func (r *GormRoleRepository) PermissionsOf(ctx context.Context, userID int64) ([]auth.Permission, error) {
+	var names []auth.Permission
+	q := r.db.WithContext(ctx).
+		Model(&domain.Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID)
+	if !tenant.IsUnscoped(ctx) {
+		q = q.Joins("JOIN users ON users.id = user_roles.user_id").
+			Where("users.tenant_id = ?", tenant.FromContext(ctx))
+	}
+	err := q.Pluck("permissions.name", &names).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return names, nil
+}
@@ -0,0 +1,65 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormRoleRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.User{}, &domain.Permission{}, &domain.Role{}, &domain.UserRole{}))
+	repo := adapter.NewGormRoleRepository(db)
+	ctx := context.Background()
+	require.NoError(t, db.Create(&domain.User{ID: 7, Email: "ann@example.com", TenantID: tenant.DefaultID}).Error)
+
+	require.NoError(t, repo.Seed(ctx, domain.DefaultRoles()))
+	require.NoError(t, repo.Seed(ctx, domain.DefaultRoles()), "seeding is idempotent")
+
+	require.NoError(t, repo.Assign(ctx, 7, domain.RoleCustomer))
+	require.NoError(t, repo.Assign(ctx, 7, domain.RoleCustomer), "assigning twice is a no-op")
+	assert.ErrorIs(t, repo.Assign(ctx, 7, "superuser"), domain.ErrRoleNotFound)
+
+	permissions, err := repo.PermissionsOf(ctx, 7)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []auth.Permission{domain.PermissionOrderCreateOwn, domain.PermissionOrderReadOwn, domain.PermissionUserReadOwn, domain.PermissionUserUpdateOwn, domain.PermissionReviewWrite}, permissions)
+
+	checker := &domain.PermissionChecker{Roles: repo}
+	allowed, err := checker.Can(ctx, 7, domain.PermissionOrderReadAny)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	require.NoError(t, repo.Assign(ctx, 7, domain.RoleAdmin))
+	allowed, err = checker.Can(ctx, 7, domain.PermissionOrderReadAny)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	scoped := auth.WithScopes(ctx, []auth.Permission{domain.PermissionOrderReadAny, domain.PermissionAuditRead})
+	allowed, err = checker.Can(scoped, 7, domain.PermissionOrderReadAny)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = checker.Can(scoped, 7, domain.PermissionProductWrite)
+	require.NoError(t, err)
+	assert.False(t, allowed, "permissions outside the scopes are denied")
+
+	allowed, err = checker.Can(tenant.WithID(ctx, "acme"), 7, domain.PermissionOrderReadAny)
+	require.NoError(t, err)
+	assert.False(t, allowed, "roles only apply within the tenant of the user")
+	allowed, err = checker.Can(ctx, 7, domain.PermissionTenantBypass)
+	require.NoError(t, err)
+	assert.False(t, allowed, "admins stay within their tenant")
+
+	require.NoError(t, repo.Assign(ctx, 7, domain.RoleSuperadmin))
+	allowed, err = checker.Can(tenant.Unscoped(tenant.WithID(ctx, "acme")), 7, domain.PermissionTenantBypass)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
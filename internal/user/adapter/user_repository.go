@@ -2,28 +2,83 @@ package adapter
 
 import (
 	"context"
+	"slices"
 
 	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
 	"gorm.io/gorm"
 )
 
 type GormUserRepository struct {
+	*persistence.GenericRepository[domain.User, int64]
 	db *gorm.DB
 }
 
 func NewGormUserRepository(db *gorm.DB) domain.UserRepository {
-	return &GormUserRepository{db: db}
+	return &GormUserRepository{GenericRepository: persistence.NewGenericRepository[domain.User, int64](db), db: db}
 }
 
-func (r *GormUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+func (r *GormUserRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
+	err := r.db.WithContext(ctx).Where("public_id = ?", publicID).First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, persistence.TranslateError(err)
 	}
 	return &user, nil
 }
 
+func (r *GormUserRepository) GetByIDs(ctx context.Context, ids []int64) ([]domain.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var users []domain.User
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return persistence.InKeyOrder(ids, users, func(u *domain.User) int64 { return u.ID }), nil
+}
+
 func (r *GormUserRepository) Save(ctx context.Context, u *domain.User) error {
-	return r.db.WithContext(ctx).Save(u).Error
+	u.AssignPublicID()
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Save(u).Error)
+}
+
+func (r *GormUserRepository) GetByEmail(ctx context.Context, email domain.Email) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) Search(ctx context.Context, filter domain.UserSearchFilter, page, pageSize int) ([]domain.User, int64, error) {
+	q := func(qb *query.QueryBuilder) *query.QueryBuilder {
+		qb = qb.ApplyFilters(filter).AddSorts(filter.Sort...)
+		// Sorting by ID last keeps the pages of equal sort values stable
+		if !slices.ContainsFunc(filter.Sort, func(s query.SortConfig) bool { return s.Field == "id" }) {
+			qb = qb.AddSort("id", query.SortOrderAsc)
+		}
+		return qb.SetPagination(page, pageSize)
+	}
+	total, err := r.Count(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	users, err := r.List(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *GormUserRepository) ExistsByEmail(ctx context.Context, email domain.Email) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.User{}).Where("email = ?", email).Limit(1).Count(&count).Error
+	if err != nil {
+		return false, persistence.TranslateError(err)
+	}
+	return count > 0, nil
 }
@@ -0,0 +1,174 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	assert.NoError(t, err)
+
+	err = db.AutoMigrate(&domain.User{})
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestGormUserRepository_GetByID_NotFound(t *testing.T) {
+	repo := adapter.NewGormUserRepository(setupTestDB(t))
+
+	_, err := repo.GetByID(context.Background(), 42)
+
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGormUserRepository_Save_DuplicateEmail(t *testing.T) {
+	repo := adapter.NewGormUserRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Save(ctx, domain.MustNewUser("dup@example.com")))
+	err := repo.Save(ctx, domain.MustNewUser("dup@example.com"))
+
+	assert.ErrorIs(t, err, persistence.ErrDuplicateKey)
+}
+
+func TestGormUserRepository_ExistsByEmail(t *testing.T) {
+	repo := adapter.NewGormUserRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Save(ctx, domain.MustNewUser("exists@example.com")))
+
+	exists, err := repo.ExistsByEmail(ctx, "exists@example.com")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.ExistsByEmail(ctx, "missing@example.com")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestGormUserRepository_GetByEmail(t *testing.T) {
+	repo := adapter.NewGormUserRepository(setupTestDB(t))
+	ctx := context.Background()
+	assert.NoError(t, repo.Save(ctx, domain.MustNewUser("jane@example.com")))
+
+	u, err := repo.GetByEmail(ctx, "jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Email("jane@example.com"), u.Email)
+
+	_, err = repo.GetByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGormUserRepository_GetByIDs(t *testing.T) {
+	repo := adapter.NewGormUserRepository(setupTestDB(t))
+	ctx := context.Background()
+	jane, john := domain.MustNewUser("jane@example.com"), domain.MustNewUser("john@example.com")
+	assert.NoError(t, repo.Save(ctx, jane))
+	assert.NoError(t, repo.Save(ctx, john))
+
+	users, err := repo.GetByIDs(ctx, []int64{john.ID, 99, jane.ID})
+
+	assert.NoError(t, err)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, john.Email, users[0].Email)
+		assert.Equal(t, jane.Email, users[1].Email)
+	}
+}
+
+func TestGormUserRepository_Search(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&domain.Permission{}, &domain.Role{}, &domain.UserRole{}))
+	repo := adapter.NewGormUserRepository(db)
+	roles := adapter.NewGormRoleRepository(db)
+	ctx := context.Background()
+	require.NoError(t, roles.Seed(ctx, domain.DefaultRoles()))
+
+	var users []*domain.User
+	for _, email := range []string{"ann@acme.com", "bob@acme.com", "cat@globex.com", "dan@acme.com"} {
+		u := domain.MustNewUser(email)
+		require.NoError(t, repo.Save(ctx, u))
+		require.NoError(t, roles.Assign(ctx, u.ID, domain.RoleCustomer))
+		users = append(users, u)
+	}
+	require.NoError(t, roles.Assign(ctx, users[1].ID, domain.RoleAdmin))
+	users[3].Deactivate(time.Now())
+	require.NoError(t, repo.Save(ctx, users[3]))
+
+	found, total, err := repo.Search(ctx, domain.UserSearchFilter{SearchTerm: "acme"}, 2, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	require.Len(t, found, 1)
+	assert.Equal(t, users[3].ID, found[0].ID, "pages are ordered by ID")
+
+	found, total, err = repo.Search(ctx, domain.UserSearchFilter{Roles: []string{domain.RoleAdmin}}, 1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, found, 1)
+	assert.Equal(t, users[1].ID, found[0].ID)
+
+	found, _, err = repo.Search(ctx, domain.UserSearchFilter{Deactivated: true}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, users[3].ID, found[0].ID)
+
+	sort, err := query.ParseSort("-deactivated_at:nulls_last,-email", domain.UserSortColumns...)
+	require.NoError(t, err)
+	found, _, err = repo.Search(ctx, domain.UserSearchFilter{Sort: sort}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 4)
+	assert.Equal(t, []int64{users[3].ID, users[2].ID, users[1].ID, users[0].ID}, []int64{found[0].ID, found[1].ID, found[2].ID, found[3].ID})
+}
+
+func TestGormAccountMover_MoveAccount(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&domain.Address{}, &domain.Permission{}, &domain.Role{}, &domain.UserRole{}))
+	users := adapter.NewGormUserRepository(db)
+	roles := adapter.NewGormRoleRepository(db)
+	ctx := context.Background()
+	require.NoError(t, roles.Seed(ctx, domain.DefaultRoles()))
+
+	source, target := domain.MustNewUser("jane@example.com"), domain.MustNewUser("jane.doe@example.com")
+	require.NoError(t, users.Save(ctx, source))
+	require.NoError(t, users.Save(ctx, target))
+	require.NoError(t, roles.Assign(ctx, source.ID, domain.RoleCustomer))
+	require.NoError(t, roles.Assign(ctx, source.ID, domain.RoleAdmin))
+	require.NoError(t, roles.Assign(ctx, target.ID, domain.RoleCustomer))
+	home := address.Address{Name: "Jane Doe", Line1: "1 Main St", City: "Berlin", PostalCode: "10115", Country: "DE"}
+	office := home
+	office.Line1 = "2 Side St"
+	duplicate := domain.Address{PublicID: "adr_source_home", UserID: source.ID, AddressDetails: domain.AddressDetails{Label: "Home", Address: home}}
+	require.NoError(t, db.Create(&duplicate).Error)
+	require.NoError(t, db.Create(&domain.Address{PublicID: "adr_source_office", UserID: source.ID, AddressDetails: domain.AddressDetails{Address: office}}).Error)
+	require.NoError(t, db.Create(&domain.Address{PublicID: "adr_target_home", UserID: target.ID, AddressDetails: domain.AddressDetails{Label: "At home", Address: home}}).Error)
+
+	items, err := adapter.NewGormAccountMover(db).MoveAccount(ctx, source.ID, target.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.MergeItem{
+		{Kind: "addresses", Moved: 1, Conflicts: []string{"adr_source_home: dropped as a duplicate of adr_target_home"}},
+		{Kind: "roles", Moved: 1},
+	}, items, "the customer role both users hold is kept once")
+	var addressOwners []int64
+	require.NoError(t, db.Model(&domain.Address{}).Order("id").Pluck("user_id", &addressOwners).Error)
+	assert.Equal(t, []int64{target.ID, target.ID}, addressOwners)
+
+	allowed, err := (&domain.PermissionChecker{Roles: roles}).Can(ctx, target.ID, domain.PermissionUserManage)
+	require.NoError(t, err)
+	assert.True(t, allowed, "the target gets the roles of the source")
+	left, err := roles.PermissionsOf(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Empty(t, left)
+}
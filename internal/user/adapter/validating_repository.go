@@ -0,0 +1,23 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// ValidatingUserRepository validates users before delegating writes to the wrapped repository
+type ValidatingUserRepository struct {
+	domain.UserRepository
+}
+
+func NewValidatingUserRepository(next domain.UserRepository) domain.UserRepository {
+	return &ValidatingUserRepository{UserRepository: next}
+}
+
+func (r *ValidatingUserRepository) Save(ctx context.Context, u *domain.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	return r.UserRepository.Save(ctx, u)
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// TokenPolicy bounds the account tokens of one purpose
+type TokenPolicy struct {
+	// TTL is how long a token stays valid
+	TTL time.Duration
+	// MaxAttempts locks a token after that many wrong verifiers
+	MaxAttempts int
+	// RequestLimit is how many tokens a user may request per RequestWindow; further requests are
+	// ignored so the endpoints cannot be used to flood a mailbox
+	RequestLimit  int
+	RequestWindow time.Duration
+}
+
+// issueToken revokes the earlier tokens of u for purpose and creates a new one, returned with the
+// token to email. It returns a nil token when u reached the request limit of policy.
+func issueToken(ctx context.Context, tokens userDomain.AccountTokenRepository, policy TokenPolicy, u *userDomain.User, purpose userDomain.TokenPurpose, now time.Time) (*userDomain.AccountToken, string, error) {
+	requested, err := tokens.CountCreatedSince(ctx, u.ID, purpose, now.Add(-policy.RequestWindow))
+	if err != nil {
+		return nil, "", fmt.Errorf("count %s tokens of user %d: %w", purpose, u.ID, err)
+	}
+	if requested >= int64(policy.RequestLimit) {
+		slog.WarnContext(ctx, "account token request limit reached", "user_id", u.ID, "purpose", purpose)
+		return nil, "", nil
+	}
+
+	t, token, err := userDomain.NewAccountToken(u, purpose, policy.TTL, now)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate %s token: %w", purpose, err)
+	}
+	// Only the newest link works, so an older email that leaked is of no use
+	if err := tokens.Revoke(ctx, u.ID, purpose, now); err != nil {
+		return nil, "", fmt.Errorf("revoke %s tokens of user %d: %w", purpose, u.ID, err)
+	}
+	if err := tokens.Create(ctx, t); err != nil {
+		return nil, "", fmt.Errorf("save %s token: %w", purpose, err)
+	}
+	return t, token, nil
+}
+
+// checkToken returns the usable token of purpose matching token. A wrong verifier counts as a
+// failed attempt on the token; every failure is userDomain.ErrInvalidToken.
+func checkToken(ctx context.Context, tokens userDomain.AccountTokenRepository, policy TokenPolicy, token string, purpose userDomain.TokenPurpose, now time.Time) (*userDomain.AccountToken, error) {
+	selector, verifier, ok := userDomain.SplitToken(token)
+	if !ok {
+		return nil, userDomain.ErrInvalidToken
+	}
+	t, err := tokens.GetBySelector(ctx, selector)
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		return nil, userDomain.ErrInvalidToken
+	case err != nil:
+		return nil, fmt.Errorf("get token: %w", err)
+	}
+	if t.Purpose != purpose || !t.Usable(policy.MaxAttempts, now) {
+		return nil, userDomain.ErrInvalidToken
+	}
+	if !t.Matches(verifier) {
+		if err := tokens.RecordFailure(ctx, t.ID); err != nil {
+			return nil, fmt.Errorf("record failed attempt on token %d: %w", t.ID, err)
+		}
+		slog.WarnContext(ctx, "wrong account token verifier", "user_id", t.UserID, "purpose", purpose, "failed_attempts", t.FailedAttempts+1)
+		return nil, userDomain.ErrInvalidToken
+	}
+	return t, nil
+}
+
+// useToken marks t as used, failing with userDomain.ErrInvalidToken when a concurrent request used it first
+func useToken(ctx context.Context, tokens userDomain.AccountTokenRepository, t *userDomain.AccountToken, now time.Time) error {
+	err := tokens.MarkUsed(ctx, t.ID, now)
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		return userDomain.ErrInvalidToken
+	case err != nil:
+		return fmt.Errorf("mark token %d used: %w", t.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MockAccountTokenRepository keeps tokens in memory
+type MockAccountTokenRepository struct {
+	tokens []*userDomain.AccountToken
+}
+
+func (m *MockAccountTokenRepository) Create(ctx context.Context, t *userDomain.AccountToken) error {
+	t.ID = int64(len(m.tokens) + 1)
+	m.tokens = append(m.tokens, t)
+	return nil
+}
+
+func (m *MockAccountTokenRepository) GetBySelector(ctx context.Context, selector string) (*userDomain.AccountToken, error) {
+	for _, t := range m.tokens {
+		if t.Selector == selector {
+			clone := *t
+			return &clone, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockAccountTokenRepository) RecordFailure(ctx context.Context, id int64) error {
+	m.tokens[id-1].FailedAttempts++
+	return nil
+}
+
+func (m *MockAccountTokenRepository) MarkUsed(ctx context.Context, id int64, at time.Time) error {
+	t := m.tokens[id-1]
+	if t.UsedAt != nil {
+		return persistence.ErrNotFound
+	}
+	t.UsedAt = &at
+	return nil
+}
+
+func (m *MockAccountTokenRepository) CountCreatedSince(ctx context.Context, userID int64, purpose userDomain.TokenPurpose, since time.Time) (int64, error) {
+	var n int64
+	for _, t := range m.tokens {
+		if t.UserID == userID && t.Purpose == purpose && !t.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MockAccountTokenRepository) Revoke(ctx context.Context, userID int64, purpose userDomain.TokenPurpose, at time.Time) error {
+	for _, t := range m.tokens {
+		if t.UserID == userID && t.Purpose == purpose && t.UsedAt == nil {
+			t.UsedAt = &at
+		}
+	}
+	return nil
+}
+
+var testTokenPolicy = TokenPolicy{TTL: time.Hour, MaxAttempts: 3, RequestLimit: 2, RequestWindow: time.Hour}
+
+type passwordResetFixture struct {
+	users     *MockUserRepository
+	tokens    *MockAccountTokenRepository
+	publisher *RecordingPublisher
+	request   *RequestPasswordResetHandler
+	reset     *ResetPasswordHandler
+	now       time.Time
+}
+
+func newPasswordResetFixture(t *testing.T) *passwordResetFixture {
+	f := &passwordResetFixture{
+		users:     newUsers(t, "jane@example.com"),
+		tokens:    &MockAccountTokenRepository{},
+		publisher: &RecordingPublisher{},
+		now:       time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	now := func() time.Time { return f.now }
+	f.request = &RequestPasswordResetHandler{UserRepo: f.users, Tokens: f.tokens, Policy: testTokenPolicy, Events: f.publisher, Now: now}
+	f.reset = &ResetPasswordHandler{UserRepo: f.users, Tokens: f.tokens, Policy: testTokenPolicy, PasswordValidator: newPasswordValidator(), Tx: noTx{}, Now: now}
+	return f
+}
+
+// requestToken requests a password reset for jane@example.com and returns the emailed token
+func (f *passwordResetFixture) requestToken(t *testing.T) string {
+	t.Helper()
+	sent := len(f.publisher.events)
+	if err := f.request.Handle(context.Background(), RequestPasswordResetCommand{Email: "jane@example.com"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.publisher.events) != sent+1 {
+		t.Fatalf("Expected a PasswordResetRequested event, got %v", f.publisher.events[sent:])
+	}
+	return f.publisher.events[sent].(userDomain.PasswordResetRequested).Token
+}
+
+func TestResetPassword(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	token := f.requestToken(t)
+	if stored := f.tokens.tokens[0].VerifierHash; strings.Contains(token, stored) {
+		t.Error("Expected only a hash of the verifier to be stored")
+	}
+
+	u, err := f.reset.Handle(context.Background(), ResetPasswordCommand{Token: token, NewPassword: "Battery-Staple-7"})
+	if err != nil || !u.CheckPassword("Battery-Staple-7") || !u.EmailVerified() {
+		t.Fatalf("Expected the password reset and the email verified, got %+v, %v", u, err)
+	}
+
+	_, err = f.reset.Handle(context.Background(), ResetPasswordCommand{Token: token, NewPassword: "Another-Staple-8"})
+	if !errors.Is(err, userDomain.ErrInvalidToken) {
+		t.Errorf("Expected a used token to be rejected, got %v", err)
+	}
+}
+
+func TestResetPassword_RejectsTokens(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	older := f.requestToken(t)
+	token := f.requestToken(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "malformed", token: "not-a-token"},
+		{name: "unknown selector", token: "0123.4567"},
+		{name: "superseded", token: older},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.reset.Handle(context.Background(), ResetPasswordCommand{Token: tt.token, NewPassword: "Battery-Staple-7"})
+			if !errors.Is(err, userDomain.ErrInvalidToken) {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+
+	f.now = f.now.Add(2 * time.Hour)
+	if _, err := f.reset.Handle(context.Background(), ResetPasswordCommand{Token: token, NewPassword: "Battery-Staple-7"}); !errors.Is(err, userDomain.ErrInvalidToken) {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+}
+
+func TestResetPassword_LocksTokenAfterFailedAttempts(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	token := f.requestToken(t)
+	selector, _, _ := userDomain.SplitToken(token)
+
+	for i := 0; i < testTokenPolicy.MaxAttempts; i++ {
+		_, err := f.reset.Handle(context.Background(), ResetPasswordCommand{Token: selector + ".guess", NewPassword: "Battery-Staple-7"})
+		if !errors.Is(err, userDomain.ErrInvalidToken) {
+			t.Fatalf("Expected ErrInvalidToken, got %v", err)
+		}
+	}
+
+	_, err := f.reset.Handle(context.Background(), ResetPasswordCommand{Token: token, NewPassword: "Battery-Staple-7"})
+	if !errors.Is(err, userDomain.ErrInvalidToken) {
+		t.Errorf("Expected the locked token to be rejected even with the right verifier, got %v", err)
+	}
+}
+
+func TestResetPassword_KeepsTokenForRejectedPassword(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	token := f.requestToken(t)
+
+	if _, err := f.reset.Handle(context.Background(), ResetPasswordCommand{Token: token, NewPassword: "short"}); err == nil {
+		t.Fatal("Expected the weak password to be rejected")
+	}
+	if _, err := f.reset.Handle(context.Background(), ResetPasswordCommand{Token: token, NewPassword: "Battery-Staple-7"}); err != nil {
+		t.Errorf("Expected the token to stay usable, got %v", err)
+	}
+}
+
+func TestRequestPasswordReset_LimitsRequestsAndHidesAccounts(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	for i := 0; i < testTokenPolicy.RequestLimit+1; i++ {
+		if err := f.request.Handle(context.Background(), RequestPasswordResetCommand{Email: "jane@example.com"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(f.publisher.events) != testTokenPolicy.RequestLimit {
+		t.Errorf("Expected %d emails, got %d", testTokenPolicy.RequestLimit, len(f.publisher.events))
+	}
+
+	f.now = f.now.Add(testTokenPolicy.RequestWindow + time.Minute)
+	f.requestToken(t)
+
+	if err := f.request.Handle(context.Background(), RequestPasswordResetCommand{Email: "nobody@example.com"}); err != nil {
+		t.Errorf("Expected unknown emails to be accepted, got %v", err)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	users := newUsers(t, "jane@example.com")
+	tokens := &MockAccountTokenRepository{}
+	publisher := &RecordingPublisher{}
+	request := &RequestEmailVerificationHandler{UserRepo: users, Tokens: tokens, Policy: testTokenPolicy, Events: publisher}
+	verify := &VerifyEmailHandler{UserRepo: users, Tokens: tokens, Policy: testTokenPolicy, Tx: noTx{}}
+
+	if err := request.Handle(context.Background(), RequestEmailVerificationCommand{UserID: 1}); err != nil || len(publisher.events) != 1 {
+		t.Fatalf("Expected an EmailVerificationRequested event, got %v, %v", publisher.events, err)
+	}
+	token := publisher.events[0].(userDomain.EmailVerificationRequested).Token
+
+	resetHandler := &ResetPasswordHandler{UserRepo: users, Tokens: tokens, Policy: testTokenPolicy, PasswordValidator: newPasswordValidator(), Tx: noTx{}}
+	if _, err := resetHandler.Handle(context.Background(), ResetPasswordCommand{Token: token, NewPassword: "Battery-Staple-7"}); !errors.Is(err, userDomain.ErrInvalidToken) {
+		t.Errorf("Expected a verification token not to reset passwords, got %v", err)
+	}
+
+	u, err := verify.Handle(context.Background(), VerifyEmailCommand{Token: token})
+	if err != nil || !u.EmailVerified() {
+		t.Fatalf("Expected the email verified, got %+v, %v", u, err)
+	}
+	if err := request.Handle(context.Background(), RequestEmailVerificationCommand{UserID: 1}); !errors.Is(err, userDomain.ErrEmailAlreadyVerified) {
+		t.Errorf("Expected ErrEmailAlreadyVerified, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// AddAddressCommand adds a shipping address to the address book of a user
+type AddAddressCommand struct {
+	UserID  int64 `validate:"required,gt=0"`
+	Address userDomain.AddressDetails
+}
+
+type AddAddressHandler struct {
+	UserRepo  userDomain.UserRepository
+	Addresses userDomain.AddressRepository
+}
+
+func (h *AddAddressHandler) Handle(ctx context.Context, cmd AddAddressCommand) (*userDomain.Address, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	if _, err := getUser(ctx, h.UserRepo, cmd.UserID); err != nil {
+		return nil, err
+	}
+	a, err := userDomain.NewAddress(cmd.UserID, cmd.Address)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Addresses.Create(ctx, a); err != nil {
+		return nil, fmt.Errorf("create address of user %d: %w", cmd.UserID, err)
+	}
+	return a, nil
+}
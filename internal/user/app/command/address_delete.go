@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// DeleteAddressCommand removes an address from the address book of a user
+type DeleteAddressCommand struct {
+	UserID    int64 `validate:"required,gt=0"`
+	AddressID int64 `validate:"required,gt=0"`
+}
+
+type DeleteAddressHandler struct {
+	Addresses userDomain.AddressRepository
+}
+
+func (h *DeleteAddressHandler) Handle(ctx context.Context, cmd DeleteAddressCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	if _, err := getOwnAddress(ctx, h.Addresses, cmd.UserID, cmd.AddressID); err != nil {
+		return err
+	}
+	// Orders keep their shipping address, so an address that was shipped to stays in the book
+	if err := h.Addresses.Delete(ctx, cmd.AddressID); err != nil {
+		if errors.Is(err, persistence.ErrForeignKeyViolation) {
+			return userDomain.ErrAddressInUse
+		}
+		return fmt.Errorf("delete address %d: %w", cmd.AddressID, err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MockAddressRepository keeps addresses in memory; inUse holds the IDs orders ship to
+type MockAddressRepository struct {
+	addresses map[int64]*userDomain.Address
+	inUse     map[int64]bool
+}
+
+func (m *MockAddressRepository) GetByID(ctx context.Context, id int64) (*userDomain.Address, error) {
+	if a, ok := m.addresses[id]; ok {
+		return a, nil
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockAddressRepository) GetByPublicID(ctx context.Context, publicID string) (*userDomain.Address, error) {
+	for _, a := range m.addresses {
+		if a.PublicID == publicID {
+			return a, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockAddressRepository) ListByUser(ctx context.Context, userID int64) ([]userDomain.Address, error) {
+	var addresses []userDomain.Address
+	for _, a := range m.addresses {
+		if a.UserID == userID {
+			addresses = append(addresses, *a)
+		}
+	}
+	return addresses, nil
+}
+
+func (m *MockAddressRepository) Create(ctx context.Context, a *userDomain.Address) error {
+	if m.addresses == nil {
+		m.addresses = make(map[int64]*userDomain.Address)
+	}
+	a.ID = int64(len(m.addresses) + 1)
+	m.addresses[a.ID] = a
+	return nil
+}
+
+func (m *MockAddressRepository) Update(ctx context.Context, a *userDomain.Address) error {
+	m.addresses[a.ID] = a
+	return nil
+}
+
+func (m *MockAddressRepository) Delete(ctx context.Context, id int64) error {
+	if m.inUse[id] {
+		return persistence.ErrForeignKeyViolation
+	}
+	delete(m.addresses, id)
+	return nil
+}
+
+func hasFieldError(errs validation.Errors, field string) bool {
+	for _, e := range errs {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+var homeAddress = userDomain.AddressDetails{
+	Label:   " Home ",
+	Address: address.Address{Name: "Jane Doe", Line1: "1 Main St", City: "Berlin", PostalCode: "10115", Country: "de"},
+	Phone:   "+49 30 1234567",
+}
+
+func TestAddAddressHandler_Handle_NormalizesDetails(t *testing.T) {
+	users := &MockUserRepository{users: []*userDomain.User{{ID: 1, Email: "jane@example.com"}}}
+	handler := &AddAddressHandler{UserRepo: users, Addresses: &MockAddressRepository{}}
+
+	a, err := handler.Handle(context.Background(), AddAddressCommand{UserID: 1, Address: homeAddress})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if a.ID == 0 || a.PublicID == "" || a.UserID != 1 {
+		t.Errorf("Expected a saved address of user 1, got %+v", a)
+	}
+	if a.Label != "Home" || a.Country != "DE" || a.Phone != "+49301234567" {
+		t.Errorf("Expected normalized details, got %+v", a.AddressDetails)
+	}
+}
+
+func TestAddAddressHandler_Handle_Rejects(t *testing.T) {
+	users := &MockUserRepository{users: []*userDomain.User{{ID: 1, Email: "jane@example.com"}}}
+	handler := &AddAddressHandler{UserRepo: users, Addresses: &MockAddressRepository{}}
+
+	_, err := handler.Handle(context.Background(), AddAddressCommand{UserID: 2, Address: homeAddress})
+	if !errors.Is(err, userDomain.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	_, err = handler.Handle(context.Background(), AddAddressCommand{UserID: 1, Address: userDomain.AddressDetails{Address: address.Address{Country: "Germany"}, Phone: "030 1234567"}})
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+	for _, field := range []string{"name", "line1", "city", "country", "phone"} {
+		if !hasFieldError(errs, field) {
+			t.Errorf("Expected an error for %s, got %v", field, errs)
+		}
+	}
+}
+
+func TestUpdateAddressHandler_Handle_OnlyOwnAddresses(t *testing.T) {
+	addresses := &MockAddressRepository{}
+	own, _ := userDomain.NewAddress(1, homeAddress)
+	other, _ := userDomain.NewAddress(2, homeAddress)
+	addresses.Create(context.Background(), own)
+	addresses.Create(context.Background(), other)
+	handler := &UpdateAddressHandler{Addresses: addresses}
+
+	office := homeAddress
+	office.Label = "Office"
+	a, err := handler.Handle(context.Background(), UpdateAddressCommand{UserID: 1, AddressID: own.ID, Address: office})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if a.Label != "Office" {
+		t.Errorf("Expected the label to be updated, got %q", a.Label)
+	}
+
+	_, err = handler.Handle(context.Background(), UpdateAddressCommand{UserID: 1, AddressID: other.ID, Address: office})
+	if !errors.Is(err, userDomain.ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound for the address of another user, got %v", err)
+	}
+	if other.Label != "Home" {
+		t.Errorf("Expected the address of another user to be left alone, got %q", other.Label)
+	}
+}
+
+func TestDeleteAddressHandler_Handle_AddressInUse(t *testing.T) {
+	addresses := &MockAddressRepository{}
+	shipped, _ := userDomain.NewAddress(1, homeAddress)
+	unused, _ := userDomain.NewAddress(1, homeAddress)
+	addresses.Create(context.Background(), shipped)
+	addresses.Create(context.Background(), unused)
+	addresses.inUse = map[int64]bool{shipped.ID: true}
+	handler := &DeleteAddressHandler{Addresses: addresses}
+
+	if err := handler.Handle(context.Background(), DeleteAddressCommand{UserID: 1, AddressID: shipped.ID}); !errors.Is(err, userDomain.ErrAddressInUse) {
+		t.Errorf("Expected ErrAddressInUse, got %v", err)
+	}
+	if err := handler.Handle(context.Background(), DeleteAddressCommand{UserID: 2, AddressID: unused.ID}); !errors.Is(err, userDomain.ErrAddressNotFound) {
+		t.Errorf("Expected ErrAddressNotFound for another user, got %v", err)
+	}
+	if err := handler.Handle(context.Background(), DeleteAddressCommand{UserID: 1, AddressID: unused.ID}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(addresses.addresses) != 1 {
+		t.Errorf("Expected 1 address left, got %d", len(addresses.addresses))
+	}
+}
+
+func TestUpdateProfileHandler_Handle(t *testing.T) {
+	users := &MockUserRepository{users: []*userDomain.User{{ID: 1, Email: "jane@example.com"}}}
+	handler := &UpdateProfileHandler{UserRepo: users}
+
+	u, err := handler.Handle(context.Background(), UpdateProfileCommand{UserID: 1, FirstName: " Jane ", LastName: "Doe", Phone: "+1 (415) 555-0123"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if u.FirstName != "Jane" || u.LastName != "Doe" || u.Phone != "+14155550123" {
+		t.Errorf("Expected the normalized profile, got %q %q %q", u.FirstName, u.LastName, u.Phone)
+	}
+
+	_, err = handler.Handle(context.Background(), UpdateProfileCommand{UserID: 1, Phone: "555-0123"})
+	var errs validation.Errors
+	if !errors.As(err, &errs) || !hasFieldError(errs, "phone") {
+		t.Errorf("Expected a validation error for phone, got %v", err)
+	}
+	if u.Phone != "+14155550123" {
+		t.Errorf("Expected a rejected update to keep the phone, got %q", u.Phone)
+	}
+}
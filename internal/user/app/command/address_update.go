@@ -0,0 +1,56 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// UpdateAddressCommand replaces the details of an address in the address book of a user. Orders
+// already shipping to the address see the change.
+type UpdateAddressCommand struct {
+	UserID    int64 `validate:"required,gt=0"`
+	AddressID int64 `validate:"required,gt=0"`
+	Address   userDomain.AddressDetails
+}
+
+type UpdateAddressHandler struct {
+	Addresses userDomain.AddressRepository
+}
+
+func (h *UpdateAddressHandler) Handle(ctx context.Context, cmd UpdateAddressCommand) (*userDomain.Address, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	a, err := getOwnAddress(ctx, h.Addresses, cmd.UserID, cmd.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.Update(cmd.Address); err != nil {
+		return nil, err
+	}
+	if err := h.Addresses.Update(ctx, a); err != nil {
+		return nil, fmt.Errorf("update address %d: %w", a.ID, err)
+	}
+	return a, nil
+}
+
+// getOwnAddress reports an address of another user as ErrAddressNotFound, so its existence is not revealed
+func getOwnAddress(ctx context.Context, addresses userDomain.AddressRepository, userID, addressID int64) (*userDomain.Address, error) {
+	a, err := addresses.GetByID(ctx, addressID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, userDomain.ErrAddressNotFound
+		}
+		return nil, fmt.Errorf("get address %d: %w", addressID, err)
+	}
+	if !a.BelongsTo(userID) {
+		return nil, userDomain.ErrAddressNotFound
+	}
+	return a, nil
+}
@@ -0,0 +1,195 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MockAPIKeyRepository keeps API keys in memory
+type MockAPIKeyRepository struct {
+	keys []*userDomain.APIKey
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, k *userDomain.APIKey) error {
+	k.ID = int64(len(m.keys) + 1)
+	m.keys = append(m.keys, k)
+	return nil
+}
+
+func (m *MockAPIKeyRepository) find(match func(k *userDomain.APIKey) bool) (*userDomain.APIKey, error) {
+	for _, k := range m.keys {
+		if match(k) {
+			clone := *k
+			return &clone, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*userDomain.APIKey, error) {
+	return m.find(func(k *userDomain.APIKey) bool { return k.Prefix == prefix })
+}
+
+func (m *MockAPIKeyRepository) GetByPublicID(ctx context.Context, publicID string) (*userDomain.APIKey, error) {
+	return m.find(func(k *userDomain.APIKey) bool { return k.PublicID == publicID })
+}
+
+func (m *MockAPIKeyRepository) ListByUser(ctx context.Context, userID int64) ([]userDomain.APIKey, error) {
+	var keys []userDomain.APIKey
+	for _, k := range m.keys {
+		if k.UserID == userID {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	k := m.keys[id-1]
+	if k.RevokedAt != nil {
+		return persistence.ErrNotFound
+	}
+	k.RevokedAt = &at
+	return nil
+}
+
+func (m *MockAPIKeyRepository) Touch(ctx context.Context, id int64, at time.Time) error {
+	m.keys[id-1].LastUsedAt = &at
+	return nil
+}
+
+type apiKeyFixture struct {
+	users        *MockUserRepository
+	keys         *MockAPIKeyRepository
+	issue        *IssueAPIKeyHandler
+	revoke       *RevokeAPIKeyHandler
+	authenticate *AuthenticateAPIKeyHandler
+	now          time.Time
+}
+
+func newAPIKeyFixture(t *testing.T) *apiKeyFixture {
+	f := &apiKeyFixture{
+		users: newUsers(t, "erp@example.com"),
+		keys:  &MockAPIKeyRepository{},
+		now:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	now := func() time.Time { return f.now }
+	f.issue = &IssueAPIKeyHandler{UserRepo: f.users, Keys: f.keys, Now: now}
+	f.revoke = &RevokeAPIKeyHandler{Keys: f.keys, Now: now}
+	f.authenticate = &AuthenticateAPIKeyHandler{UserRepo: f.users, Keys: f.keys, Now: now}
+	return f
+}
+
+func (f *apiKeyFixture) issueKey(t *testing.T, expiresAt *time.Time) *IssuedAPIKey {
+	t.Helper()
+	issued, err := f.issue.Handle(context.Background(), IssueAPIKeyCommand{
+		UserID:    1,
+		Name:      "ERP",
+		Scopes:    []auth.Permission{userDomain.PermissionOrderReadAny, userDomain.PermissionOrderReadAny},
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return issued
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	issued := f.issueKey(t, nil)
+	if !strings.HasPrefix(issued.Key, userDomain.APIKeyPrefix) || strings.Contains(issued.Key, issued.APIKey.SecretHash) {
+		t.Fatalf("Expected a prefixed key of which only a hash is stored, got %q", issued.Key)
+	}
+	if len(issued.APIKey.Scopes) != 1 {
+		t.Errorf("Expected duplicate scopes dropped, got %v", issued.APIKey.Scopes)
+	}
+
+	k, err := f.authenticate.Handle(context.Background(), AuthenticateAPIKeyCommand{Key: issued.Key})
+	if err != nil || k.UserID != 1 || k.LastUsedAt == nil || !k.LastUsedAt.Equal(f.now) {
+		t.Fatalf("Expected the key of user 1 marked used, got %+v, %v", k, err)
+	}
+
+	f.now = f.now.Add(time.Second)
+	if _, err := f.authenticate.Handle(context.Background(), AuthenticateAPIKeyCommand{Key: issued.Key}); err != nil {
+		t.Fatal(err)
+	}
+	if used := f.keys.keys[0].LastUsedAt; !used.Equal(f.now.Add(-time.Second)) {
+		t.Errorf("Expected LastUsedAt kept within the touch interval, got %v", used)
+	}
+}
+
+func TestAuthenticateAPIKey_RejectsKeys(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	expiresAt := f.now.Add(time.Hour)
+	expiring := f.issueKey(t, &expiresAt)
+	revoked := f.issueKey(t, nil)
+	if err := f.revoke.Handle(context.Background(), RevokeAPIKeyCommand{UserID: 1, PublicID: revoked.APIKey.PublicID}); err != nil {
+		t.Fatal(err)
+	}
+	f.now = expiresAt
+	prefix, _, _ := userDomain.SplitAPIKey(revoked.Key)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "malformed", key: "not-a-key"},
+		{name: "unknown prefix", key: userDomain.APIKeyPrefix + "0123.4567"},
+		{name: "wrong secret", key: prefix + ".4567"},
+		{name: "expired", key: expiring.Key},
+		{name: "revoked", key: revoked.Key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.authenticate.Handle(context.Background(), AuthenticateAPIKeyCommand{Key: tt.key})
+			if !errors.Is(err, userDomain.ErrInvalidAPIKey) {
+				t.Errorf("Expected ErrInvalidAPIKey, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKey_DeactivatedUser(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	issued := f.issueKey(t, nil)
+	f.users.users[0].Deactivate(f.now)
+
+	_, err := f.authenticate.Handle(context.Background(), AuthenticateAPIKeyCommand{Key: issued.Key})
+	if !errors.Is(err, userDomain.ErrInvalidAPIKey) {
+		t.Errorf("Expected the keys of deactivated users rejected, got %v", err)
+	}
+}
+
+func TestIssueAPIKey_Validation(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	past := f.now.Add(-time.Hour)
+
+	_, err := f.issue.Handle(context.Background(), IssueAPIKeyCommand{
+		UserID:    1,
+		Name:      "ERP",
+		Scopes:    []auth.Permission{"order:delete:any"},
+		ExpiresAt: &past,
+	})
+	var errs validation.Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("Expected the unknown scope and past expiry rejected, got %v", err)
+	}
+}
+
+func TestRevokeAPIKey_OtherUser(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	issued := f.issueKey(t, nil)
+
+	err := f.revoke.Handle(context.Background(), RevokeAPIKeyCommand{UserID: 2, PublicID: issued.APIKey.PublicID})
+	if !errors.Is(err, userDomain.ErrAPIKeyNotFound) {
+		t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+	}
+}
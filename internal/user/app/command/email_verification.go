@@ -0,0 +1,100 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// RequestEmailVerificationCommand emails a link to verify the email address of a user
+type RequestEmailVerificationCommand struct {
+	UserID int64 `validate:"required,gt=0"`
+}
+
+type RequestEmailVerificationHandler struct {
+	UserRepo userDomain.UserRepository
+	Tokens   userDomain.AccountTokenRepository
+	Policy   TokenPolicy
+	// Events receives EmailVerificationRequested, which the notification module emails
+	Events event.Publisher
+	Now    func() time.Time
+}
+
+func (h *RequestEmailVerificationHandler) Handle(ctx context.Context, cmd RequestEmailVerificationCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	u, err := getUser(ctx, h.UserRepo, cmd.UserID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified() {
+		return userDomain.ErrEmailAlreadyVerified
+	}
+
+	t, token, err := issueToken(ctx, h.Tokens, h.Policy, u, userDomain.TokenEmailVerification, nowFunc(h.Now)())
+	if err != nil || t == nil {
+		return err
+	}
+	return h.Events.Publish(ctx, userDomain.EmailVerificationRequested{
+		UserID:       u.ID,
+		UserPublicID: u.PublicID,
+		Email:        u.Email,
+		Token:        token,
+		ExpiresAt:    t.ExpiresAt,
+	})
+}
+
+// VerifyEmailCommand verifies the email of a user with the token of a
+// RequestEmailVerificationCommand email
+type VerifyEmailCommand struct {
+	Token string `validate:"required"`
+}
+
+type VerifyEmailHandler struct {
+	UserRepo userDomain.UserRepository
+	Tokens   userDomain.AccountTokenRepository
+	Policy   TokenPolicy
+	Tx       persistence.Transactor
+	Now      func() time.Time
+}
+
+func (h *VerifyEmailHandler) Handle(ctx context.Context, cmd VerifyEmailCommand) (*userDomain.User, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	now := nowFunc(h.Now)()
+	t, err := checkToken(ctx, h.Tokens, h.Policy, cmd.Token, userDomain.TokenEmailVerification, now)
+	if err != nil {
+		return nil, err
+	}
+	u, err := getUser(ctx, h.UserRepo, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Email != t.Email {
+		return nil, userDomain.ErrInvalidToken
+	}
+	u.VerifyEmail(now)
+
+	err = h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		if err := useToken(ctx, h.Tokens, t, now); err != nil {
+			return err
+		}
+		if err := h.UserRepo.Save(ctx, u); err != nil {
+			return fmt.Errorf("save user %d: %w", u.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// RequestPasswordResetCommand emails a link to reset a forgotten password to the user with Email.
+// It succeeds whether or not the email is registered, so it cannot be used to probe for accounts.
+type RequestPasswordResetCommand struct {
+	Email string `validate:"required,email"`
+}
+
+type RequestPasswordResetHandler struct {
+	UserRepo userDomain.UserRepository
+	Tokens   userDomain.AccountTokenRepository
+	Policy   TokenPolicy
+	// Events receives PasswordResetRequested, which the notification module emails
+	Events event.Publisher
+	Now    func() time.Time
+}
+
+func (h *RequestPasswordResetHandler) Handle(ctx context.Context, cmd RequestPasswordResetCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+
+	email, err := userDomain.NewEmail(cmd.Email)
+	if err != nil {
+		return nil
+	}
+	u, err := h.UserRepo.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		return nil
+	case err != nil:
+		return fmt.Errorf("get user by email: %w", err)
+	}
+	if u.Deactivated() {
+		slog.InfoContext(ctx, "password reset of deactivated user ignored", "user_id", u.ID)
+		return nil
+	}
+
+	t, token, err := issueToken(ctx, h.Tokens, h.Policy, u, userDomain.TokenPasswordReset, nowFunc(h.Now)())
+	if err != nil || t == nil {
+		return err
+	}
+	return h.Events.Publish(ctx, userDomain.PasswordResetRequested{
+		UserID:       u.ID,
+		UserPublicID: u.PublicID,
+		Email:        u.Email,
+		Token:        token,
+		ExpiresAt:    t.ExpiresAt,
+	})
+}
+
+// ResetPasswordCommand sets a new password with the token of a RequestPasswordResetCommand email
+type ResetPasswordCommand struct {
+	Token       string `validate:"required"`
+	NewPassword string `validate:"required"`
+}
+
+type ResetPasswordHandler struct {
+	UserRepo          userDomain.UserRepository
+	Tokens            userDomain.AccountTokenRepository
+	Policy            TokenPolicy
+	PasswordValidator *userDomain.PasswordValidator
+	Tx                persistence.Transactor
+	Now               func() time.Time
+}
+
+func (h *ResetPasswordHandler) Handle(ctx context.Context, cmd ResetPasswordCommand) (*userDomain.User, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	now := nowFunc(h.Now)()
+	t, err := checkToken(ctx, h.Tokens, h.Policy, cmd.Token, userDomain.TokenPasswordReset, now)
+	if err != nil {
+		return nil, err
+	}
+	u, err := getUser(ctx, h.UserRepo, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// A token sent to an address the user no longer has is void
+	if u.Email != t.Email {
+		return nil, userDomain.ErrInvalidToken
+	}
+	if u.Deactivated() {
+		return nil, userDomain.ErrUserDeactivated
+	}
+
+	// The token stays usable when the password is rejected, so the user can pick another one
+	if err := h.PasswordValidator.Validate(ctx, cmd.NewPassword, u.Email); err != nil {
+		return nil, err
+	}
+	if err := u.SetPassword(cmd.NewPassword); err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+	// Following the emailed link proves the user receives mail at their address
+	u.VerifyEmail(now)
+
+	err = h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		if err := useToken(ctx, h.Tokens, t, now); err != nil {
+			return err
+		}
+		if err := h.Tokens.Revoke(ctx, u.ID, userDomain.TokenPasswordReset, now); err != nil {
+			return fmt.Errorf("revoke password reset tokens of user %d: %w", u.ID, err)
+		}
+		if err := h.UserRepo.Save(ctx, u); err != nil {
+			return fmt.Errorf("save user %d: %w", u.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// RequirePasswordResetCommand makes a user set a new password before logging in again, e.g. after
+// their credentials leaked
+type RequirePasswordResetCommand struct {
+	UserID int64 `validate:"required,gt=0"`
+}
+
+type RequirePasswordResetHandler struct {
+	UserRepo userDomain.UserRepository
+}
+
+func (h *RequirePasswordResetHandler) Handle(ctx context.Context, cmd RequirePasswordResetCommand) (*userDomain.User, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	u, err := getUser(ctx, h.UserRepo, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	u.RequirePasswordReset()
+	if err := h.UserRepo.Save(ctx, u); err != nil {
+		return nil, fmt.Errorf("save user %d: %w", u.ID, err)
+	}
+	return u, nil
+}
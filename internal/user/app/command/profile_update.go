@@ -0,0 +1,53 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// UpdateProfileCommand replaces the profile of a user; empty fields clear it
+type UpdateProfileCommand struct {
+	UserID    int64 `validate:"required,gt=0"`
+	FirstName string
+	LastName  string
+	Phone     string
+}
+
+type UpdateProfileHandler struct {
+	UserRepo userDomain.UserRepository
+}
+
+func (h *UpdateProfileHandler) Handle(ctx context.Context, cmd UpdateProfileCommand) (*userDomain.User, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	u, err := getUser(ctx, h.UserRepo, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.UpdateProfile(cmd.FirstName, cmd.LastName, cmd.Phone); err != nil {
+		return nil, err
+	}
+	if err := h.UserRepo.Save(ctx, u); err != nil {
+		return nil, fmt.Errorf("save user %d: %w", u.ID, err)
+	}
+	return u, nil
+}
+
+// getUser reports a missing user as ErrUserNotFound
+func getUser(ctx context.Context, users userDomain.UserRepository, id int64) (*userDomain.User, error) {
+	u, err := users.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, userDomain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("get user %d: %w", id, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+type AssignRoleCommand struct {
+	UserID int64  `validate:"required,gt=0"`
+	Role   string `validate:"required"`
+}
+
+type AssignRoleHandler struct {
+	UserRepo userDomain.UserRepository
+	Roles    userDomain.RoleRepository
+}
+
+func (h *AssignRoleHandler) Handle(ctx context.Context, cmd AssignRoleCommand) error {
+	if err := validation.Struct(cmd); err != nil {
+		return err
+	}
+	// Superadmins see every tenant, so only those already outside of one may make them
+	if cmd.Role == userDomain.RoleSuperadmin && !tenant.IsUnscoped(ctx) {
+		return fmt.Errorf("%w: assign role %s", auth.ErrForbidden, cmd.Role)
+	}
+
+	if _, err := h.UserRepo.GetByID(ctx, cmd.UserID); err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return userDomain.ErrUserNotFound
+		}
+		return fmt.Errorf("get user %d: %w", cmd.UserID, err)
+	}
+
+	if err := h.Roles.Assign(ctx, cmd.UserID, cmd.Role); err != nil {
+		if errors.Is(err, userDomain.ErrRoleNotFound) {
+			return err
+		}
+		return fmt.Errorf("assign role %s to user %d: %w", cmd.Role, cmd.UserID, err)
+	}
+	return nil
+}
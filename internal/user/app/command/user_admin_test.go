@@ -0,0 +1,175 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// noTx runs the unit of work without a transaction
+type noTx struct{}
+
+func (noTx) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// rollbackTx runs the unit of work without a transaction and records the error that would roll it back
+type rollbackTx struct {
+	err error
+}
+
+func (tx *rollbackTx) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx.err = fn(ctx)
+	return tx.err
+}
+
+// MockAccountMover records the merges it was asked to move, reporting one moved order each, and fails with err
+type MockAccountMover struct {
+	moves [][2]int64
+	err   error
+}
+
+func (m *MockAccountMover) MoveAccount(ctx context.Context, fromUserID, toUserID int64) ([]userDomain.MergeItem, error) {
+	m.moves = append(m.moves, [2]int64{fromUserID, toUserID})
+	return []userDomain.MergeItem{{Kind: "orders", Moved: 1}}, m.err
+}
+
+func newUsers(t *testing.T, emails ...string) *MockUserRepository {
+	repo := &MockUserRepository{}
+	for _, email := range emails {
+		u := userDomain.MustNewUser(email)
+		if err := u.SetPassword(strongPassword); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Save(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func TestDeactivateAndReactivateUser(t *testing.T) {
+	repo := newUsers(t, "jane@example.com")
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	deactivate := &DeactivateUserHandler{UserRepo: repo, Now: func() time.Time { return at }}
+
+	u, err := deactivate.Handle(context.Background(), DeactivateUserCommand{UserID: 1})
+	if err != nil || u.DeactivatedAt == nil || !u.DeactivatedAt.Equal(at) {
+		t.Fatalf("Expected the user deactivated at %v, got %+v, %v", at, u, err)
+	}
+	deactivate.Now = func() time.Time { return at.Add(time.Hour) }
+	if u, _ = deactivate.Handle(context.Background(), DeactivateUserCommand{UserID: 1}); !u.DeactivatedAt.Equal(at) {
+		t.Errorf("Expected deactivating twice to keep %v, got %v", at, u.DeactivatedAt)
+	}
+
+	u, err = (&ReactivateUserHandler{UserRepo: repo}).Handle(context.Background(), ReactivateUserCommand{UserID: 1})
+	if err != nil || u.Deactivated() {
+		t.Errorf("Expected the user reactivated, got %+v, %v", u, err)
+	}
+
+	if _, err := deactivate.Handle(context.Background(), DeactivateUserCommand{UserID: 9}); !errors.Is(err, userDomain.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestRequirePasswordResetAndChangePassword(t *testing.T) {
+	repo := newUsers(t, "jane@example.com")
+	if _, err := (&RequirePasswordResetHandler{UserRepo: repo}).Handle(context.Background(), RequirePasswordResetCommand{UserID: 1}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	change := &ChangePasswordHandler{UserRepo: repo, PasswordValidator: newPasswordValidator()}
+
+	tests := []struct {
+		name    string
+		current string
+		next    string
+		check   func(err error) bool
+	}{
+		{name: "wrong current password", current: "Wrong-Password-1", next: "Battery-Staple-7", check: func(err error) bool { return errors.Is(err, userDomain.ErrInvalidCredentials) }},
+		{name: "weak new password", current: strongPassword, next: "short", check: func(err error) bool { return errors.As(err, new(validation.Errors)) }},
+		{name: "unchanged password", current: strongPassword, next: strongPassword, check: func(err error) bool { return errors.As(err, new(validation.Errors)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := change.Handle(context.Background(), ChangePasswordCommand{Email: "jane@example.com", CurrentPassword: tt.current, NewPassword: tt.next})
+			if !tt.check(err) {
+				t.Errorf("Unexpected error %v", err)
+			}
+			if !repo.users[0].PasswordResetRequired {
+				t.Error("Expected the reset to stay required")
+			}
+		})
+	}
+
+	u, err := change.Handle(context.Background(), ChangePasswordCommand{Email: "jane@example.com", CurrentPassword: strongPassword, NewPassword: "Battery-Staple-7"})
+	if err != nil || u.PasswordResetRequired || !u.CheckPassword("Battery-Staple-7") {
+		t.Errorf("Expected the new password to complete the reset, got %+v, %v", u, err)
+	}
+}
+
+func TestMergeUsersHandler_Handle(t *testing.T) {
+	repo := newUsers(t, "jane@example.com", "jane.doe@example.com")
+	mover := &MockAccountMover{}
+	handler := &MergeUsersHandler{UserRepo: repo, Movers: []userDomain.AccountMover{mover}, Tx: noTx{}}
+
+	repo.users[0].FirstName, repo.users[0].LastName = "Jane", "Doe"
+	repo.users[1].LastName = "Smith"
+
+	report, err := handler.Handle(context.Background(), MergeUsersCommand{SourceID: 1, TargetID: 2})
+	if err != nil || report.Target.ID != 2 {
+		t.Fatalf("Expected the target to be returned, got %+v, %v", report, err)
+	}
+	if target := report.Target; target.FirstName != "Jane" || target.LastName != "Smith" {
+		t.Errorf("Expected the blank first name filled and the last name of the target kept, got %q %q", target.FirstName, target.LastName)
+	}
+	want := []userDomain.MergeItem{
+		{Kind: "profile", Moved: 1, Conflicts: []string{`last_name: kept "Smith" over "Doe"`}},
+		{Kind: "orders", Moved: 1},
+	}
+	if !reflect.DeepEqual(report.Items, want) {
+		t.Errorf("Expected items %+v, got %+v", want, report.Items)
+	}
+	source := repo.users[0]
+	if !source.Deactivated() || source.MergedIntoID == nil || *source.MergedIntoID != 2 {
+		t.Errorf("Expected the source deactivated and merged into 2, got %+v", source)
+	}
+	if len(mover.moves) != 1 || mover.moves[0] != [2]int64{1, 2} {
+		t.Errorf("Expected one move from 1 to 2, got %v", mover.moves)
+	}
+
+	if _, err := handler.Handle(context.Background(), MergeUsersCommand{SourceID: 1, TargetID: 2}); !errors.Is(err, userDomain.ErrUserMerged) {
+		t.Errorf("Expected merging a merged user to fail with ErrUserMerged, got %v", err)
+	}
+	if _, err := (&ReactivateUserHandler{UserRepo: repo}).Handle(context.Background(), ReactivateUserCommand{UserID: 1}); !errors.Is(err, userDomain.ErrUserMerged) {
+		t.Errorf("Expected reactivating a merged user to fail with ErrUserMerged, got %v", err)
+	}
+	if _, err := handler.Handle(context.Background(), MergeUsersCommand{SourceID: 2, TargetID: 2}); !errors.Is(err, userDomain.ErrMergeIntoSelf) {
+		t.Errorf("Expected ErrMergeIntoSelf, got %v", err)
+	}
+}
+
+func TestMergeUsersHandler_Handle_DryRunRollsBack(t *testing.T) {
+	repo := newUsers(t, "jane@example.com", "jane.doe@example.com")
+	mover, tx := &MockAccountMover{}, &rollbackTx{}
+	handler := &MergeUsersHandler{UserRepo: repo, Movers: []userDomain.AccountMover{mover}, Tx: tx}
+
+	report, err := handler.Handle(context.Background(), MergeUsersCommand{SourceID: 1, TargetID: 2, DryRun: true})
+
+	if err != nil {
+		t.Fatalf("Expected a report, got %v", err)
+	}
+	if !report.DryRun || len(report.Items) != 2 || report.Items[1].Kind != "orders" {
+		t.Errorf("Expected a dry run reporting the profile and the orders, got %+v", report)
+	}
+	if tx.err == nil {
+		t.Error("Expected the transaction of the dry run to be rolled back")
+	}
+	if len(mover.moves) != 1 {
+		t.Errorf("Expected the movers to run for the report, got %v", mover.moves)
+	}
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// DeactivateUserCommand blocks a user from logging in and placing orders
+type DeactivateUserCommand struct {
+	UserID int64 `validate:"required,gt=0"`
+}
+
+type DeactivateUserHandler struct {
+	UserRepo userDomain.UserRepository
+	Now      func() time.Time
+}
+
+// Handle keeps the original deactivation time when the user is already deactivated
+func (h *DeactivateUserHandler) Handle(ctx context.Context, cmd DeactivateUserCommand) (*userDomain.User, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	u, err := getUser(ctx, h.UserRepo, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	u.Deactivate(nowFunc(h.Now)())
+	if err := h.UserRepo.Save(ctx, u); err != nil {
+		return nil, fmt.Errorf("save user %d: %w", u.ID, err)
+	}
+	return u, nil
+}
+
+// ReactivateUserCommand lifts the deactivation of a user
+type ReactivateUserCommand struct {
+	UserID int64 `validate:"required,gt=0"`
+}
+
+type ReactivateUserHandler struct {
+	UserRepo userDomain.UserRepository
+}
+
+func (h *ReactivateUserHandler) Handle(ctx context.Context, cmd ReactivateUserCommand) (*userDomain.User, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	u, err := getUser(ctx, h.UserRepo, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.Reactivate(); err != nil {
+		return nil, err
+	}
+	if err := h.UserRepo.Save(ctx, u); err != nil {
+		return nil, fmt.Errorf("save user %d: %w", u.ID, err)
+	}
+	return u, nil
+}
+
+func nowFunc(now func() time.Time) func() time.Time {
+	if now != nil {
+		return now
+	}
+	return time.Now
+}
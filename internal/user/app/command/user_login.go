@@ -0,0 +1,138 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// loginHistorySize is how many previous successful logins anomaly detection looks at
+const loginHistorySize = 50
+
+type LoginCommand struct {
+	Email    string `validate:"required,email"`
+	Password string `validate:"required"`
+	IP       string
+	Device   string
+}
+
+type LoginResult struct {
+	User      *userDomain.User
+	Anomalies []userDomain.LoginAnomalyKind
+	// StepUpRequired asks the client to complete an additional authentication factor
+	StepUpRequired bool
+}
+
+type LoginHandler struct {
+	UserRepo     userDomain.UserRepository
+	AttemptRepo  userDomain.LoginAttemptRepository
+	GeoResolver  userDomain.GeoResolver
+	Detector     userDomain.LoginAnomalyDetector
+	Events       event.Publisher
+	StepUpOnRisk bool
+	Now          func() time.Time
+}
+
+func (h *LoginHandler) Handle(ctx context.Context, cmd LoginCommand) (*LoginResult, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	email, err := userDomain.NewEmail(cmd.Email)
+	if err != nil {
+		return nil, userDomain.ErrInvalidCredentials
+	}
+
+	attempt := &userDomain.LoginAttempt{
+		Email:       email,
+		IP:          cmd.IP,
+		Device:      cmd.Device,
+		Location:    h.resolveLocation(ctx, cmd.IP),
+		AttemptedAt: h.now(),
+	}
+
+	u, err := h.UserRepo.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		return nil, h.recordFailure(ctx, attempt, userDomain.LoginFailureUnknownEmail, userDomain.ErrInvalidCredentials)
+	case err != nil:
+		return nil, fmt.Errorf("get user by email: %w", err)
+	}
+
+	attempt.UserID = &u.ID
+	if !u.CheckPassword(cmd.Password) {
+		return nil, h.recordFailure(ctx, attempt, userDomain.LoginFailureWrongPassword, userDomain.ErrInvalidCredentials)
+	}
+	// Only callers who know the password learn the account is blocked
+	if u.Deactivated() {
+		return nil, h.recordFailure(ctx, attempt, userDomain.LoginFailureDeactivated, userDomain.ErrUserDeactivated)
+	}
+	if u.PasswordResetRequired {
+		return nil, h.recordFailure(ctx, attempt, userDomain.LoginFailurePasswordReset, userDomain.ErrPasswordResetRequired)
+	}
+
+	// History is read before the current attempt is stored so it doesn't compare against itself
+	history, err := h.AttemptRepo.RecentSuccessful(ctx, u.ID, loginHistorySize)
+	if err != nil {
+		return nil, fmt.Errorf("get login history: %w", err)
+	}
+
+	attempt.Success = true
+	if err := h.AttemptRepo.Save(ctx, attempt); err != nil {
+		return nil, fmt.Errorf("save login attempt: %w", err)
+	}
+
+	anomalies := h.Detector.Detect(attempt, history)
+	result := &LoginResult{User: u}
+	events := make([]event.Event, len(anomalies))
+	for i, anomaly := range anomalies {
+		result.Anomalies = append(result.Anomalies, anomaly.Kind)
+		events[i] = anomaly
+	}
+	result.StepUpRequired = h.StepUpOnRisk && len(anomalies) > 0
+
+	// Alerts are best effort; a failing mail server must not lock users out
+	if len(events) > 0 {
+		if err := h.Events.Publish(ctx, events...); err != nil {
+			slog.WarnContext(ctx, "publishing login anomalies failed", "user_id", u.ID, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// recordFailure stores the failed attempt and returns err, the error the caller sees
+func (h *LoginHandler) recordFailure(ctx context.Context, attempt *userDomain.LoginAttempt, reason string, err error) error {
+	attempt.FailureReason = reason
+	if saveErr := h.AttemptRepo.Save(ctx, attempt); saveErr != nil {
+		return fmt.Errorf("save login attempt: %w", saveErr)
+	}
+	return err
+}
+
+// resolveLocation treats lookup failures as an unknown location so logins keep working without geo data
+func (h *LoginHandler) resolveLocation(ctx context.Context, ip string) userDomain.GeoLocation {
+	if h.GeoResolver == nil || ip == "" {
+		return userDomain.GeoLocation{}
+	}
+	location, err := h.GeoResolver.Resolve(ctx, ip)
+	if err != nil {
+		slog.WarnContext(ctx, "resolving login location failed", "ip", ip, "error", err)
+		return userDomain.GeoLocation{}
+	}
+	return location
+}
+
+func (h *LoginHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
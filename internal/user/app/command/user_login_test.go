@@ -0,0 +1,230 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+)
+
+// MockLoginAttemptRepository keeps attempts in insertion order
+type MockLoginAttemptRepository struct {
+	attempts []*userDomain.LoginAttempt
+}
+
+func (m *MockLoginAttemptRepository) Save(ctx context.Context, a *userDomain.LoginAttempt) error {
+	a.ID = int64(len(m.attempts) + 1)
+	m.attempts = append(m.attempts, a)
+	return nil
+}
+
+func (m *MockLoginAttemptRepository) RecentSuccessful(ctx context.Context, userID int64, limit int) ([]*userDomain.LoginAttempt, error) {
+	var recent []*userDomain.LoginAttempt
+	for i := len(m.attempts) - 1; i >= 0 && len(recent) < limit; i-- {
+		if a := m.attempts[i]; a.Success && a.UserID != nil && *a.UserID == userID {
+			recent = append(recent, a)
+		}
+	}
+	return recent, nil
+}
+
+// MockGeoResolver resolves IPs from a fixed table and fails for unknown ones
+type MockGeoResolver struct {
+	locations map[string]userDomain.GeoLocation
+}
+
+func (m *MockGeoResolver) Resolve(ctx context.Context, ip string) (userDomain.GeoLocation, error) {
+	if location, ok := m.locations[ip]; ok {
+		return location, nil
+	}
+	return userDomain.GeoLocation{}, errors.New("lookup failed")
+}
+
+// RecordingPublisher collects published events
+type RecordingPublisher struct {
+	events []event.Event
+	err    error
+}
+
+func (p *RecordingPublisher) Publish(ctx context.Context, events ...event.Event) error {
+	p.events = append(p.events, events...)
+	return p.err
+}
+
+type loginFixture struct {
+	handler   *LoginHandler
+	attempts  *MockLoginAttemptRepository
+	publisher *RecordingPublisher
+	clock     time.Time
+}
+
+func newLoginFixture(t *testing.T) *loginFixture {
+	u := userDomain.MustNewUser("jane@example.com")
+	u.ID = 1
+	if err := u.SetPassword(strongPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &loginFixture{
+		attempts:  &MockLoginAttemptRepository{},
+		publisher: &RecordingPublisher{},
+		clock:     time.Date(2024, 5, 18, 8, 0, 0, 0, time.UTC),
+	}
+	f.handler = &LoginHandler{
+		UserRepo:    &MockUserRepository{users: []*userDomain.User{u}},
+		AttemptRepo: f.attempts,
+		GeoResolver: &MockGeoResolver{locations: map[string]userDomain.GeoLocation{
+			"198.51.100.1": {Country: "DE", City: "Berlin", Latitude: 52.52, Longitude: 13.405},
+			"203.0.113.7":  {Country: "AU", City: "Sydney", Latitude: -33.869, Longitude: 151.209},
+		}},
+		Detector:     userDomain.DefaultLoginAnomalyDetector,
+		Events:       f.publisher,
+		StepUpOnRisk: true,
+		Now:          func() time.Time { return f.clock },
+	}
+	return f
+}
+
+func (f *loginFixture) login(email, password, ip string) (*LoginResult, error) {
+	return f.handler.Handle(context.Background(), LoginCommand{Email: email, Password: password, IP: ip, Device: "test-agent"})
+}
+
+func TestLoginHandler_Handle_Success(t *testing.T) {
+	f := newLoginFixture(t)
+
+	result, err := f.login("Jane@Example.com", strongPassword, "198.51.100.1")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.User.ID != 1 || result.StepUpRequired || len(result.Anomalies) != 0 {
+		t.Errorf("Expected a plain successful login, got %+v", result)
+	}
+	if len(f.attempts.attempts) != 1 {
+		t.Fatalf("Expected 1 recorded attempt, got %d", len(f.attempts.attempts))
+	}
+	attempt := f.attempts.attempts[0]
+	if !attempt.Success || attempt.Location.Country != "DE" || attempt.Device != "test-agent" {
+		t.Errorf("Unexpected attempt recorded: %+v", attempt)
+	}
+}
+
+func TestLoginHandler_Handle_RecordsFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		email  string
+		reason string
+	}{
+		{name: "wrong password", email: "jane@example.com", reason: userDomain.LoginFailureWrongPassword},
+		{name: "unknown email", email: "nobody@example.com", reason: userDomain.LoginFailureUnknownEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLoginFixture(t)
+
+			_, err := f.login(tt.email, "Wrong-Password-1", "198.51.100.1")
+
+			if !errors.Is(err, userDomain.ErrInvalidCredentials) {
+				t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+			}
+			if len(f.attempts.attempts) != 1 {
+				t.Fatalf("Expected 1 recorded attempt, got %d", len(f.attempts.attempts))
+			}
+			if attempt := f.attempts.attempts[0]; attempt.Success || attempt.FailureReason != tt.reason {
+				t.Errorf("Expected failed attempt with reason %s, got %+v", tt.reason, attempt)
+			}
+		})
+	}
+}
+
+func TestLoginHandler_Handle_BlockedAccounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		block  func(u *userDomain.User)
+		err    error
+		reason string
+	}{
+		{name: "deactivated", block: func(u *userDomain.User) { u.Deactivate(time.Now()) }, err: userDomain.ErrUserDeactivated, reason: userDomain.LoginFailureDeactivated},
+		{name: "password reset required", block: (*userDomain.User).RequirePasswordReset, err: userDomain.ErrPasswordResetRequired, reason: userDomain.LoginFailurePasswordReset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLoginFixture(t)
+			tt.block(f.handler.UserRepo.(*MockUserRepository).users[0])
+
+			_, err := f.login("jane@example.com", "Wrong-Password-1", "198.51.100.1")
+			if !errors.Is(err, userDomain.ErrInvalidCredentials) {
+				t.Fatalf("Expected ErrInvalidCredentials for a wrong password, got %v", err)
+			}
+
+			_, err = f.login("jane@example.com", strongPassword, "198.51.100.1")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected %v, got %v", tt.err, err)
+			}
+			if attempt := f.attempts.attempts[1]; attempt.Success || attempt.FailureReason != tt.reason {
+				t.Errorf("Expected failed attempt with reason %s, got %+v", tt.reason, attempt)
+			}
+		})
+	}
+}
+
+func TestLoginHandler_Handle_AnomalyTriggersEventsAndStepUp(t *testing.T) {
+	f := newLoginFixture(t)
+	if _, err := f.login("jane@example.com", strongPassword, "198.51.100.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An hour later from the other side of the world
+	f.clock = f.clock.Add(time.Hour)
+	result, err := f.login("jane@example.com", strongPassword, "203.0.113.7")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.StepUpRequired {
+		t.Error("Expected step-up authentication to be required")
+	}
+	if len(result.Anomalies) != 2 {
+		t.Errorf("Expected new country and impossible travel anomalies, got %v", result.Anomalies)
+	}
+	if len(f.publisher.events) != 2 {
+		t.Fatalf("Expected 2 published events, got %d", len(f.publisher.events))
+	}
+	anomaly := f.publisher.events[0].(userDomain.LoginAnomalyDetected)
+	if anomaly.UserID != 1 || anomaly.Previous == nil || anomaly.Previous.Location.Country != "DE" {
+		t.Errorf("Unexpected anomaly event: %+v", anomaly)
+	}
+}
+
+func TestLoginHandler_Handle_PublishFailureDoesNotBlockLogin(t *testing.T) {
+	f := newLoginFixture(t)
+	f.publisher.err = errors.New("smtp down")
+	if _, err := f.login("jane@example.com", strongPassword, "198.51.100.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	f.clock = f.clock.Add(time.Hour)
+	_, err := f.login("jane@example.com", strongPassword, "203.0.113.7")
+
+	if err != nil {
+		t.Errorf("Expected login to succeed, got %v", err)
+	}
+}
+
+func TestLoginHandler_Handle_GeoLookupFailure(t *testing.T) {
+	f := newLoginFixture(t)
+
+	_, err := f.login("jane@example.com", strongPassword, "192.0.2.99")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if f.attempts.attempts[0].Location.IsKnown() {
+		t.Error("Expected unknown location when the lookup fails")
+	}
+}
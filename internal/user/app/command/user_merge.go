@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MergeUsersCommand folds the duplicate account SourceID into TargetID
+type MergeUsersCommand struct {
+	SourceID int64 `validate:"required,gt=0"`
+	TargetID int64 `validate:"required,gt=0"`
+	// DryRun reports what the merge would do without changing anything
+	DryRun bool
+}
+
+// errDryRun rolls back the transaction of a dry run once its report is complete
+var errDryRun = errors.New("dry run")
+
+// MergeUsersHandler moves the addresses, roles, orders and other data of the source account to the
+// target and leaves the source deactivated, pointing at the target. The profile of the target
+// wins, with its blank fields filled from the source; the movers resolve the conflicts of their
+// data. Everything happens in one transaction so a failing mover leaves both accounts untouched,
+// and a dry run makes the same changes before rolling them back, so its report is exact.
+type MergeUsersHandler struct {
+	UserRepo userDomain.UserRepository
+	// Movers move the data each module keeps per user
+	Movers []userDomain.AccountMover
+	Tx     persistence.Transactor
+	Now    func() time.Time
+}
+
+// Handle returns what the merge did, or would do for a dry run
+func (h *MergeUsersHandler) Handle(ctx context.Context, cmd MergeUsersCommand) (*userDomain.MergeReport, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+	if cmd.SourceID == cmd.TargetID {
+		return nil, userDomain.ErrMergeIntoSelf
+	}
+
+	report := &userDomain.MergeReport{DryRun: cmd.DryRun}
+	err := h.Tx.InTransaction(ctx, func(ctx context.Context) error {
+		source, err := getUser(ctx, h.UserRepo, cmd.SourceID)
+		if err != nil {
+			return err
+		}
+		target, err := getUser(ctx, h.UserRepo, cmd.TargetID)
+		if err != nil {
+			return err
+		}
+		if err := source.MergeInto(target, nowFunc(h.Now)()); err != nil {
+			return err
+		}
+		report.Source, report.Target = source, target
+		report.Items = append(report.Items, target.FillProfileFrom(source))
+
+		for _, m := range h.Movers {
+			items, err := m.MoveAccount(ctx, source.ID, target.ID)
+			if err != nil {
+				return fmt.Errorf("move account %d to %d: %w", source.ID, target.ID, err)
+			}
+			report.Items = append(report.Items, items...)
+		}
+		if cmd.DryRun {
+			return errDryRun
+		}
+		if err := h.UserRepo.Save(ctx, source); err != nil {
+			return fmt.Errorf("save user %d: %w", source.ID, err)
+		}
+		if err := h.UserRepo.Save(ctx, target); err != nil {
+			return fmt.Errorf("save user %d: %w", target.ID, err)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	if !cmd.DryRun {
+		slog.InfoContext(ctx, "users merged", "source_id", report.Source.ID, "target_id", report.Target.ID, "items", report.Items)
+	}
+	return report, nil
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+type RegisterUserCommand struct {
+	Email    string `validate:"required,email"`
+	Password string `validate:"required"`
+}
+
+type RegisterUserHandler struct {
+	UserRepo          userDomain.UserRepository
+	PasswordValidator *userDomain.PasswordValidator
+
+	// Roles gives new users the customer role; nil leaves them without roles
+	Roles userDomain.RoleRepository
+
+	// Events receives UserRegistered; nil disables publishing
+	Events event.Publisher
+}
+
+func (h *RegisterUserHandler) Handle(ctx context.Context, cmd RegisterUserCommand) (*userDomain.User, error) {
+	if err := validation.Struct(cmd); err != nil {
+		return nil, err
+	}
+
+	u, err := userDomain.NewUser(cmd.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce the password policy before doing any other work
+	if err := h.PasswordValidator.Validate(ctx, cmd.Password, u.Email); err != nil {
+		return nil, err
+	}
+	if err := u.SetPassword(cmd.Password); err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+
+	// Pre-check gives a friendly error in the common case
+	exists, err := h.UserRepo.ExistsByEmail(ctx, u.Email)
+	if err != nil {
+		return nil, fmt.Errorf("check email availability: %w", err)
+	}
+	if exists {
+		return nil, userDomain.ErrEmailTaken
+	}
+
+	// A concurrent registration can still win the race; the unique index is the source of truth
+	if err := h.UserRepo.Save(ctx, u); err != nil {
+		if errors.Is(err, persistence.ErrDuplicateKey) {
+			return nil, userDomain.ErrEmailTaken
+		}
+		return nil, fmt.Errorf("save user: %w", err)
+	}
+
+	if h.Roles != nil {
+		if err := h.Roles.Assign(ctx, u.ID, userDomain.RoleCustomer); err != nil {
+			return nil, fmt.Errorf("assign %s role to user %d: %w", userDomain.RoleCustomer, u.ID, err)
+		}
+	}
+
+	// The welcome mail is best effort; the account exists either way
+	if h.Events != nil {
+		if err := h.Events.Publish(ctx, userDomain.UserRegistered{UserID: u.ID, UserPublicID: u.PublicID, Email: u.Email}); err != nil {
+			slog.WarnContext(ctx, "publishing user registration failed", "user_id", u.ID, "error", err)
+		}
+	}
+
+	return u, nil
+}
@@ -0,0 +1,238 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+)
+
+// MockUserRepository keeps users in memory; saveErr simulates a failing save
+type MockUserRepository struct {
+	users   []*userDomain.User
+	saveErr error
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*userDomain.User, error) {
+	for _, user := range m.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockUserRepository) GetByPublicID(ctx context.Context, publicID string) (*userDomain.User, error) {
+	for _, user := range m.users {
+		if user.PublicID == publicID {
+			return user, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockUserRepository) GetByIDs(ctx context.Context, ids []int64) ([]userDomain.User, error) {
+	var users []userDomain.User
+	for _, user := range m.users {
+		if slices.Contains(ids, user.ID) {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func (m *MockUserRepository) Save(ctx context.Context, u *userDomain.User) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	if u.ID != 0 {
+		return nil
+	}
+	u.ID = int64(len(m.users) + 1)
+	m.users = append(m.users, u)
+	return nil
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email userDomain.Email) (*userDomain.User, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, persistence.ErrNotFound
+}
+
+func (m *MockUserRepository) ExistsByEmail(ctx context.Context, email userDomain.Email) (bool, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockUserRepository) Search(ctx context.Context, filter userDomain.UserSearchFilter, page, pageSize int) ([]userDomain.User, int64, error) {
+	var users []userDomain.User
+	for _, user := range m.users {
+		users = append(users, *user)
+	}
+	return users, int64(len(users)), nil
+}
+
+const strongPassword = "Correct-Horse-42"
+
+// MockBreachChecker reports every password in breached as compromised
+type MockBreachChecker struct {
+	breached map[string]bool
+}
+
+func (m *MockBreachChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	return m.breached[password], nil
+}
+
+func newPasswordValidator(breached ...string) *userDomain.PasswordValidator {
+	checker := &MockBreachChecker{breached: map[string]bool{}}
+	for _, password := range breached {
+		checker.breached[password] = true
+	}
+	return &userDomain.PasswordValidator{
+		Policies: &userDomain.StaticPasswordPolicyProvider{Default: userDomain.DefaultPasswordPolicy},
+		Breaches: checker,
+	}
+}
+
+func newRegisterUserHandler(repo *MockUserRepository) *RegisterUserHandler {
+	return &RegisterUserHandler{UserRepo: repo, PasswordValidator: newPasswordValidator()}
+}
+
+func TestRegisterUserHandler_Handle_Success(t *testing.T) {
+	handler := newRegisterUserHandler(&MockUserRepository{})
+
+	u, err := handler.Handle(context.Background(), RegisterUserCommand{Email: "New.User@Example.com", Password: strongPassword})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if u.ID == 0 {
+		t.Error("Expected user to be saved with an ID")
+	}
+	if u.Email != "new.user@example.com" {
+		t.Errorf("Expected normalized email, got %s", u.Email)
+	}
+	if !u.CheckPassword(strongPassword) {
+		t.Error("Expected password hash to match the registered password")
+	}
+}
+
+func TestRegisterUserHandler_Handle_PublishesUserRegistered(t *testing.T) {
+	publisher := &RecordingPublisher{}
+	handler := newRegisterUserHandler(&MockUserRepository{})
+	handler.Events = publisher
+
+	u, err := handler.Handle(context.Background(), RegisterUserCommand{Email: "new@example.com", Password: strongPassword})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(publisher.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(publisher.events))
+	}
+	if registered, ok := publisher.events[0].(userDomain.UserRegistered); !ok || registered.UserID != u.ID {
+		t.Errorf("Expected UserRegistered for user %d, got %+v", u.ID, publisher.events[0])
+	}
+}
+
+// MockRoleRepository records role assignments
+type MockRoleRepository struct {
+	userDomain.RoleRepository
+	assigned map[int64][]string
+}
+
+func (m *MockRoleRepository) Assign(ctx context.Context, userID int64, role string) error {
+	if m.assigned == nil {
+		m.assigned = make(map[int64][]string)
+	}
+	m.assigned[userID] = append(m.assigned[userID], role)
+	return nil
+}
+
+func TestRegisterUserHandler_Handle_AssignsCustomerRole(t *testing.T) {
+	roles := &MockRoleRepository{}
+	handler := newRegisterUserHandler(&MockUserRepository{})
+	handler.Roles = roles
+
+	u, err := handler.Handle(context.Background(), RegisterUserCommand{Email: "new@example.com", Password: strongPassword})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := roles.assigned[u.ID]; len(got) != 1 || got[0] != userDomain.RoleCustomer {
+		t.Errorf("Expected the customer role to be assigned, got %v", got)
+	}
+}
+
+func TestRegisterUserHandler_Handle_EmailTaken(t *testing.T) {
+	repo := &MockUserRepository{users: []*userDomain.User{{ID: 1, Email: "taken@example.com"}}}
+	handler := newRegisterUserHandler(repo)
+
+	_, err := handler.Handle(context.Background(), RegisterUserCommand{Email: "TAKEN@example.com", Password: strongPassword})
+
+	if !errors.Is(err, userDomain.ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken, got %v", err)
+	}
+}
+
+func TestRegisterUserHandler_Handle_UniqueIndexRace(t *testing.T) {
+	// Pre-check passes but a concurrent registration hits the unique index first
+	repo := &MockUserRepository{saveErr: persistence.TranslateError(persistence.ErrDuplicateKey)}
+	handler := newRegisterUserHandler(repo)
+
+	_, err := handler.Handle(context.Background(), RegisterUserCommand{Email: "race@example.com", Password: strongPassword})
+
+	if !errors.Is(err, userDomain.ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken, got %v", err)
+	}
+}
+
+func TestRegisterUserHandler_Handle_InvalidEmail(t *testing.T) {
+	handler := newRegisterUserHandler(&MockUserRepository{})
+
+	_, err := handler.Handle(context.Background(), RegisterUserCommand{Email: "not-an-email", Password: strongPassword})
+
+	if !validation.IsValidationError(err) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
+
+func TestRegisterUserHandler_Handle_PasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{name: "too short", password: "Short-1"},
+		{name: "missing digit", password: "No-Digits-Here"},
+		{name: "contains email", password: "Jane.Doe-2024!"},
+		{name: "breached", password: "Breached-Pass-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockUserRepository{}
+			handler := &RegisterUserHandler{UserRepo: repo, PasswordValidator: newPasswordValidator("Breached-Pass-1")}
+
+			_, err := handler.Handle(context.Background(), RegisterUserCommand{Email: "jane.doe@example.com", Password: tt.password})
+
+			var errs validation.Errors
+			if !errors.As(err, &errs) || errs[0].Field != "password" {
+				t.Fatalf("Expected password validation error, got %v", err)
+			}
+			if len(repo.users) != 0 {
+				t.Error("Expected no user to be saved")
+			}
+		})
+	}
+}
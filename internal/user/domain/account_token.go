@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+)
+
+var (
+	// ErrInvalidToken is returned for unknown, expired, used and locked tokens alike, so callers
+	// cannot tell which
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified is returned when requesting the verification of a verified email
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
+// TokenPurpose is what an AccountToken lets its holder do
+type TokenPurpose string
+
+const (
+	TokenPasswordReset     TokenPurpose = "password_reset"
+	TokenEmailVerification TokenPurpose = "email_verification"
+)
+
+// AccountToken is a single-use token emailed to a user to prove they control their mailbox. The
+// token sent is "<selector>.<verifier>": the selector finds the row and only a hash of the verifier
+// is stored, so a leaked table cannot be used to reset passwords. Every wrong verifier for a
+// selector counts as a failed attempt, and the token is locked after too many of them.
+type AccountToken struct {
+	ID      int64        `gorm:"primaryKey"`
+	UserID  int64        `gorm:"not null;index"`
+	Purpose TokenPurpose `gorm:"type:varchar(32);not null"`
+	// Email is the address the token was sent to; it is only valid while the user keeps it
+	Email        Email  `gorm:"type:varchar(255);not null"`
+	Selector     string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	VerifierHash string `gorm:"type:varchar(64);not null"`
+
+	ExpiresAt      time.Time `gorm:"not null"`
+	UsedAt         *time.Time
+	FailedAttempts int       `gorm:"not null;default:0"`
+	CreatedAt      time.Time `gorm:"index"`
+}
+
+// NewAccountToken creates a token of u for purpose valid for ttl and returns it with the token
+// to send, which is not stored
+func NewAccountToken(u *User, purpose TokenPurpose, ttl time.Duration, now time.Time) (*AccountToken, string, error) {
+	selector, err := randomHex(16)
+	if err != nil {
+		return nil, "", err
+	}
+	verifier, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	t := &AccountToken{
+		UserID:       u.ID,
+		Purpose:      purpose,
+		Email:        u.Email,
+		Selector:     selector,
+		VerifierHash: hashVerifier(verifier),
+		ExpiresAt:    now.Add(ttl),
+		CreatedAt:    now,
+	}
+	return t, selector + "." + verifier, nil
+}
+
+// SplitToken returns the selector and verifier of a token of NewAccountToken
+func SplitToken(token string) (selector, verifier string, ok bool) {
+	selector, verifier, ok = strings.Cut(strings.TrimSpace(token), ".")
+	return selector, verifier, ok && selector != "" && verifier != ""
+}
+
+// Usable reports whether the token is unused, unexpired and not locked after maxAttempts failed attempts
+func (t *AccountToken) Usable(maxAttempts int, now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt) && t.FailedAttempts < maxAttempts
+}
+
+// Matches reports whether verifier is the one of the token, in constant time
+func (t *AccountToken) Matches(verifier string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashVerifier(verifier)), []byte(t.VerifierHash)) == 1
+}
+
+func hashVerifier(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type AccountTokenRepository interface {
+	Create(ctx context.Context, t *AccountToken) error
+	// GetBySelector returns persistence.ErrNotFound for unknown selectors
+	GetBySelector(ctx context.Context, selector string) (*AccountToken, error)
+	// RecordFailure counts a failed attempt on the token
+	RecordFailure(ctx context.Context, id int64) error
+	// MarkUsed marks the token as used; it returns persistence.ErrNotFound when the token was
+	// used already, so concurrent requests cannot both use it
+	MarkUsed(ctx context.Context, id int64, at time.Time) error
+	// CountCreatedSince counts the tokens of a user for purpose created at since or later
+	CountCreatedSince(ctx context.Context, userID int64, purpose TokenPurpose, since time.Time) (int64, error)
+	// Revoke marks the unused tokens of a user for purpose as used
+	Revoke(ctx context.Context, userID int64, purpose TokenPurpose, at time.Time) error
+}
+
+// PasswordResetRequestedEvent is the event name of PasswordResetRequested
+const PasswordResetRequestedEvent = "user.password_reset_requested"
+
+// PasswordResetRequested is emitted when a user asks to reset a forgotten password; Token is
+// emailed to them and must go nowhere else
+type PasswordResetRequested struct {
+	UserID       int64
+	UserPublicID string
+	Email        Email
+	Token        string
+	ExpiresAt    time.Time
+}
+
+func (PasswordResetRequested) EventName() string {
+	return PasswordResetRequestedEvent
+}
+
+// EventID is unique per token, since a user may request several resets
+func (e PasswordResetRequested) EventID() string {
+	selector, _, _ := SplitToken(e.Token)
+	return event.NewID(PasswordResetRequestedEvent, selector, 1)
+}
+
+// EmailVerificationRequestedEvent is the event name of EmailVerificationRequested
+const EmailVerificationRequestedEvent = "user.email_verification_requested"
+
+// EmailVerificationRequested is emitted when a user asks to verify their email; Token is emailed
+// to them and must go nowhere else
+type EmailVerificationRequested struct {
+	UserID       int64
+	UserPublicID string
+	Email        Email
+	Token        string
+	ExpiresAt    time.Time
+}
+
+func (EmailVerificationRequested) EventName() string {
+	return EmailVerificationRequestedEvent
+}
+
+func (e EmailVerificationRequested) EventID() string {
+	selector, _, _ := SplitToken(e.Token)
+	return event.NewID(EmailVerificationRequestedEvent, selector, 1)
+}
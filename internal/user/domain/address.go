@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+var (
+	ErrAddressNotFound = errors.New("address not found")
+	ErrAddressInUse    = errors.New("address is the shipping address of an order")
+)
+
+// AddressPublicIDPrefix starts the public IDs of addresses, e.g. "adr_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const AddressPublicIDPrefix = "adr"
+
+// AddressDetails is the part of an address its owner edits; the country of the postal address
+// decides which of its fields are required
+type AddressDetails struct {
+	// Label tells the addresses of a user apart, e.g. "Home" or "Office"
+	Label string `gorm:"type:varchar(50)"`
+	address.Address
+	// Phone is for the carrier; it may differ from the phone of the user
+	Phone Phone `gorm:"type:varchar(16)"`
+}
+
+// normalize trims the details and normalizes the postal address for its country
+func (d AddressDetails) normalize() AddressDetails {
+	d.Label = strings.TrimSpace(d.Label)
+	d.Address = d.Address.Normalize()
+	if phone, err := NewPhone(string(d.Phone)); err == nil {
+		d.Phone = phone
+	}
+	return d
+}
+
+// Validate checks the details and returns validation.Errors describing every violation
+func (d AddressDetails) Validate() error {
+	var errs validation.Errors
+
+	errs.Check(len(d.Label) <= 50, "label", "must be at most 50 characters")
+	errs.Merge("", d.Address.Validate())
+	if err := d.Phone.Validate(); err != nil {
+		errs.Add("phone", err.Error())
+	}
+
+	return errs.Err()
+}
+
+// Address is a shipping address in the address book of a user; orders ship to one of them
+type Address struct {
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the address in external APIs so the primary key never leaves the service
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	// TenantID is the tenant of the user; see tenant.Plugin
+	TenantID string `gorm:"type:varchar(64);not null;default:'default';index"`
+	UserID   int64  `gorm:"not null;index"`
+	User     User   `gorm:"foreignKey:UserID"`
+	AddressDetails
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewAddress adds an address with normalized details to the address book of a user
+func NewAddress(userID int64, details AddressDetails) (*Address, error) {
+	a := &Address{UserID: userID}
+	if err := a.Update(details); err != nil {
+		return nil, err
+	}
+	a.AssignPublicID()
+	return a, nil
+}
+
+// AssignPublicID gives the address a public ID unless it already has one
+func (a *Address) AssignPublicID() {
+	if a.PublicID == "" {
+		a.PublicID = publicid.New(AddressPublicIDPrefix)
+	}
+}
+
+// Update replaces the details of the address; the address is left alone when they are invalid
+func (a *Address) Update(details AddressDetails) error {
+	details = details.normalize()
+	if err := details.Validate(); err != nil {
+		return err
+	}
+	a.AddressDetails = details
+	return nil
+}
+
+// BelongsTo reports whether the address is in the address book of the user
+func (a *Address) BelongsTo(userID int64) bool {
+	return a.UserID == userID
+}
+
+type AddressRepository interface {
+	GetByID(ctx context.Context, id int64) (*Address, error)
+	GetByPublicID(ctx context.Context, publicID string) (*Address, error)
+	// ListByUser returns the addresses of a user, oldest first
+	ListByUser(ctx context.Context, userID int64) ([]Address, error)
+	Create(ctx context.Context, a *Address) error
+	Update(ctx context.Context, a *Address) error
+	// Delete returns persistence.ErrForeignKeyViolation while an order ships to the address
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, wrong, expired and revoked keys alike, so callers
+	// cannot tell which
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyNotFound is returned when managing a key the user does not have
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// APIKeyPublicIDPrefix starts the public IDs of API keys, e.g. "key_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const APIKeyPublicIDPrefix = "key"
+
+// APIKeyPrefix starts every API key, so leaked keys are easy to spot in code and logs
+const APIKeyPrefix = "aiio_"
+
+// APIKeyTouchInterval is how stale LastUsedAt may get; a key used more often is only written once
+// per interval rather than on every request
+const APIKeyTouchInterval = time.Minute
+
+// MaxAPIKeyNameLength bounds the name of an API key
+const MaxAPIKeyNameLength = 100
+
+// APIKey lets a machine-to-machine client act as the user owning it. The key handed out is
+// "<prefix>.<secret>": the prefix finds the row and is shown in listings, only a hash of the secret
+// is stored. Requests made with the key hold the permissions of the owner's roles that are also
+// among the key's Scopes.
+type APIKey struct {
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the key in the management API
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;not null"`
+	UserID   int64  `gorm:"not null;index"`
+	// TenantID is the tenant the key was issued in; it only works there
+	TenantID string `gorm:"type:varchar(64);not null"`
+	Name     string `gorm:"type:varchar(100);not null"`
+
+	Prefix     string            `gorm:"type:varchar(32);uniqueIndex;not null"`
+	SecretHash string            `gorm:"type:varchar(64);not null"`
+	Scopes     []auth.Permission `gorm:"serializer:json;type:text;not null"`
+
+	// ExpiresAt is nil for keys that stay valid until revoked
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time `gorm:"not null"`
+}
+
+// NewAPIKey creates a key of userID in tenantID and returns it with the key to hand out, which is
+// not stored
+func NewAPIKey(userID int64, tenantID, name string, scopes []auth.Permission, expiresAt *time.Time, now time.Time) (*APIKey, string, error) {
+	prefix, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	k := &APIKey{
+		PublicID:   publicid.New(APIKeyPublicIDPrefix),
+		UserID:     userID,
+		TenantID:   tenantID,
+		Name:       name,
+		Prefix:     APIKeyPrefix + prefix,
+		SecretHash: hashVerifier(secret),
+		Scopes:     scopes,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+	}
+	return k, k.Prefix + "." + secret, nil
+}
+
+// SplitAPIKey returns the prefix and secret of a key of NewAPIKey
+func SplitAPIKey(key string) (prefix, secret string, ok bool) {
+	prefix, secret, ok = strings.Cut(strings.TrimSpace(key), ".")
+	return prefix, secret, ok && strings.HasPrefix(prefix, APIKeyPrefix) && secret != ""
+}
+
+// Usable reports whether the key is neither revoked nor expired
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Matches reports whether secret is the one of the key, in constant time
+func (k *APIKey) Matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashVerifier(secret)), []byte(k.SecretHash)) == 1
+}
+
+// NeedsTouch reports whether LastUsedAt is older than APIKeyTouchInterval
+func (k *APIKey) NeedsTouch(now time.Time) bool {
+	return k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= APIKeyTouchInterval
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *APIKey) error
+	// GetByPrefix returns persistence.ErrNotFound for unknown prefixes
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	// GetByPublicID returns persistence.ErrNotFound for unknown keys
+	GetByPublicID(ctx context.Context, publicID string) (*APIKey, error)
+	// ListByUser returns the keys of a user, revoked ones included, newest first
+	ListByUser(ctx context.Context, userID int64) ([]APIKey, error)
+	// Revoke marks the key as revoked; it returns persistence.ErrNotFound when it was revoked already
+	Revoke(ctx context.Context, id int64, at time.Time) error
+	// Touch sets LastUsedAt unless a concurrent request set it to at or later
+	Touch(ctx context.Context, id int64, at time.Time) error
+}
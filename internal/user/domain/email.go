@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// Email is a normalized, syntactically valid email address
+type Email string
+
+// NewEmail parses and normalizes a raw email address (trimmed, lower-cased)
+func NewEmail(raw string) (Email, error) {
+	email := Email(strings.ToLower(strings.TrimSpace(raw)))
+	if err := email.Validate(); err != nil {
+		return "", err
+	}
+	return email, nil
+}
+
+// Validate accepts bare addresses only (no display name such as "John <john@example.com>")
+func (e Email) Validate() error {
+	if e == "" {
+		return fmt.Errorf("%w: is required", ErrInvalidEmail)
+	}
+	addr, err := mail.ParseAddress(string(e))
+	if err != nil || addr.Address != string(e) {
+		return fmt.Errorf("%w: %q", ErrInvalidEmail, string(e))
+	}
+	return nil
+}
+
+func (e Email) String() string {
+	return string(e)
+}
+
+// Value implements driver.Valuer
+func (e Email) Value() (driver.Value, error) {
+	return string(e), nil
+}
+
+// Scan implements sql.Scanner
+func (e *Email) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*e = Email(v)
+	case []byte:
+		*e = Email(v)
+	case nil:
+		*e = ""
+	default:
+		return fmt.Errorf("cannot scan %T into Email", value)
+	}
+	return nil
+}
+
+// UnmarshalJSON normalizes and validates the address while decoding
+func (e *Email) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	email, err := NewEmail(raw)
+	if err != nil {
+		return err
+	}
+	*e = email
+	return nil
+}
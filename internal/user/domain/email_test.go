@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestNewEmail(t *testing.T) {
+	email, err := NewEmail("  John.Doe@Example.COM ")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if email != "john.doe@example.com" {
+		t.Errorf("Expected normalized email, got %s", email)
+	}
+
+	for _, raw := range []string{"", "not-an-email", "John <john@example.com>"} {
+		if _, err := NewEmail(raw); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("Expected ErrInvalidEmail for %q, got %v", raw, err)
+		}
+	}
+}
+
+func TestEmail_UnmarshalJSON(t *testing.T) {
+	var payload struct {
+		Email Email `json:"email"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"email":"USER@example.com"}`), &payload); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if payload.Email != "user@example.com" {
+		t.Errorf("Expected normalized email, got %s", payload.Email)
+	}
+
+	if err := json.Unmarshal([]byte(`{"email":"nope"}`), &payload); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("Expected ErrInvalidEmail, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/geo"
+)
+
+type LoginAnomalyKind string
+
+const (
+	// LoginAnomalyNewCountry is a successful login from a country the user never logged in from
+	LoginAnomalyNewCountry LoginAnomalyKind = "NEW_COUNTRY"
+	// LoginAnomalyImpossibleTravel is a login too far from the previous one to be reached in the time between them
+	LoginAnomalyImpossibleTravel LoginAnomalyKind = "IMPOSSIBLE_TRAVEL"
+)
+
+// LoginAnomalyDetectedEvent is the event name of LoginAnomalyDetected
+const LoginAnomalyDetectedEvent = "user.login_anomaly_detected"
+
+// LoginAnomalyDetected is emitted for every anomaly of a successful login
+type LoginAnomalyDetected struct {
+	UserID   int64
+	Email    Email
+	Kind     LoginAnomalyKind
+	Attempt  LoginAttempt
+	Previous *LoginAttempt
+}
+
+func (LoginAnomalyDetected) EventName() string {
+	return LoginAnomalyDetectedEvent
+}
+
+// EventID treats each kind of anomaly of a login attempt as an aggregate that is detected once
+func (e LoginAnomalyDetected) EventID() string {
+	return event.NewID(LoginAnomalyDetectedEvent, fmt.Sprintf("login_attempt_%d_%s", e.Attempt.ID, e.Kind), 1)
+}
+
+// LoginAnomalyDetector compares a successful login against the user's login history
+type LoginAnomalyDetector struct {
+	// MaxTravelSpeedKmh is the fastest plausible travel speed between two logins
+	MaxTravelSpeedKmh float64
+	// MinTravelDistanceKm ignores jumps shorter than the accuracy of IP geolocation
+	MinTravelDistanceKm float64
+}
+
+var DefaultLoginAnomalyDetector = LoginAnomalyDetector{
+	MaxTravelSpeedKmh:   900,
+	MinTravelDistanceKm: 300,
+}
+
+// Detect returns the anomalies of attempt given the previous successful logins, newest first.
+// Attempts with an unknown location and users without history never raise anomalies.
+func (d LoginAnomalyDetector) Detect(attempt *LoginAttempt, history []*LoginAttempt) []LoginAnomalyDetected {
+	if !attempt.Location.IsKnown() {
+		return nil
+	}
+
+	var located []*LoginAttempt
+	for _, previous := range history {
+		if previous.Location.IsKnown() {
+			located = append(located, previous)
+		}
+	}
+	if len(located) == 0 {
+		return nil
+	}
+
+	var anomalies []LoginAnomalyDetected
+	newAnomaly := func(kind LoginAnomalyKind, previous *LoginAttempt) LoginAnomalyDetected {
+		return LoginAnomalyDetected{
+			UserID:   *attempt.UserID,
+			Email:    attempt.Email,
+			Kind:     kind,
+			Attempt:  *attempt,
+			Previous: previous,
+		}
+	}
+
+	knownCountry := false
+	for _, previous := range located {
+		if previous.Location.Country == attempt.Location.Country {
+			knownCountry = true
+			break
+		}
+	}
+	if !knownCountry {
+		anomalies = append(anomalies, newAnomaly(LoginAnomalyNewCountry, located[0]))
+	}
+
+	if last := located[0]; d.isImpossibleTravel(last, attempt) {
+		anomalies = append(anomalies, newAnomaly(LoginAnomalyImpossibleTravel, last))
+	}
+
+	return anomalies
+}
+
+func (d LoginAnomalyDetector) isImpossibleTravel(from, to *LoginAttempt) bool {
+	distance := distanceKm(from.Location, to.Location)
+	if distance < d.MinTravelDistanceKm {
+		return false
+	}
+
+	elapsed := to.AttemptedAt.Sub(from.AttemptedAt)
+	if elapsed <= 0 {
+		return true
+	}
+	return distance/elapsed.Hours() > d.MaxTravelSpeedKmh
+}
+
+// distanceKm is the great-circle distance between two locations
+func distanceKm(a, b GeoLocation) float64 {
+	return geo.Distance(geo.Point{Lat: a.Latitude, Lng: a.Longitude}, geo.Point{Lat: b.Latitude, Lng: b.Longitude})
+}
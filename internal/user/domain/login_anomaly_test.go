@@ -0,0 +1,80 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	berlin = domain.GeoLocation{Country: "DE", City: "Berlin", Latitude: 52.52, Longitude: 13.405}
+	munich = domain.GeoLocation{Country: "DE", City: "Munich", Latitude: 48.137, Longitude: 11.575}
+	paris  = domain.GeoLocation{Country: "FR", City: "Paris", Latitude: 48.857, Longitude: 2.352}
+	sydney = domain.GeoLocation{Country: "AU", City: "Sydney", Latitude: -33.869, Longitude: 151.209}
+)
+
+func loginAt(location domain.GeoLocation, at time.Time) *domain.LoginAttempt {
+	userID := int64(1)
+	return &domain.LoginAttempt{UserID: &userID, Email: "jane@example.com", Success: true, Location: location, AttemptedAt: at}
+}
+
+func anomalyKinds(anomalies []domain.LoginAnomalyDetected) []domain.LoginAnomalyKind {
+	var kinds []domain.LoginAnomalyKind
+	for _, a := range anomalies {
+		kinds = append(kinds, a.Kind)
+	}
+	return kinds
+}
+
+func TestLoginAnomalyDetector_Detect(t *testing.T) {
+	start := time.Date(2024, 5, 18, 8, 0, 0, 0, time.UTC)
+	detector := domain.DefaultLoginAnomalyDetector
+
+	tests := []struct {
+		name     string
+		attempt  *domain.LoginAttempt
+		history  []*domain.LoginAttempt
+		expected []domain.LoginAnomalyKind
+	}{
+		{
+			name:    "first login",
+			attempt: loginAt(sydney, start),
+		},
+		{
+			name:    "unknown location",
+			attempt: loginAt(domain.GeoLocation{}, start.Add(time.Hour)),
+			history: []*domain.LoginAttempt{loginAt(berlin, start)},
+		},
+		{
+			name:    "same country within reach",
+			attempt: loginAt(munich, start.Add(2*time.Hour)),
+			history: []*domain.LoginAttempt{loginAt(berlin, start)},
+		},
+		{
+			name:     "new country within reach",
+			attempt:  loginAt(paris, start.Add(6*time.Hour)),
+			history:  []*domain.LoginAttempt{loginAt(berlin, start)},
+			expected: []domain.LoginAnomalyKind{domain.LoginAnomalyNewCountry},
+		},
+		{
+			name:     "known country but impossible travel",
+			attempt:  loginAt(berlin, start.Add(time.Hour)),
+			history:  []*domain.LoginAttempt{loginAt(sydney, start), loginAt(berlin, start.Add(-24*time.Hour))},
+			expected: []domain.LoginAnomalyKind{domain.LoginAnomalyImpossibleTravel},
+		},
+		{
+			name:     "new country and impossible travel",
+			attempt:  loginAt(sydney, start.Add(time.Hour)),
+			history:  []*domain.LoginAttempt{loginAt(berlin, start)},
+			expected: []domain.LoginAnomalyKind{domain.LoginAnomalyNewCountry, domain.LoginAnomalyImpossibleTravel},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, anomalyKinds(detector.Detect(tt.attempt, tt.history)))
+		})
+	}
+}
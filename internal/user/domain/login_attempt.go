@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// Login failure reasons recorded on LoginAttempt
+const (
+	LoginFailureUnknownEmail  = "unknown_email"
+	LoginFailureWrongPassword = "wrong_password"
+	// LoginFailureDeactivated and LoginFailurePasswordReset are logins with the right password
+	// that an admin blocked
+	LoginFailureDeactivated   = "deactivated"
+	LoginFailurePasswordReset = "password_reset_required"
+)
+
+// GeoLocation is the approximate origin of an IP address; the zero value means unknown
+type GeoLocation struct {
+	Country   string `gorm:"type:varchar(2)"`
+	City      string `gorm:"type:varchar(255)"`
+	Latitude  float64
+	Longitude float64
+}
+
+func (g GeoLocation) IsKnown() bool {
+	return g.Country != ""
+}
+
+// GeoResolver maps an IP address to a location
+type GeoResolver interface {
+	Resolve(ctx context.Context, ip string) (GeoLocation, error)
+}
+
+// LoginAttempt is the audit record of a single login, successful or not
+type LoginAttempt struct {
+	ID            int64       `gorm:"primaryKey"`
+	UserID        *int64      `gorm:"index"`
+	Email         Email       `gorm:"type:varchar(255);index;not null"`
+	Success       bool        `gorm:"not null"`
+	FailureReason string      `gorm:"type:varchar(32)"`
+	IP            string      `gorm:"type:varchar(45)"`
+	Device        string      `gorm:"type:varchar(512)"`
+	Location      GeoLocation `gorm:"embedded;embeddedPrefix:geo_"`
+	AttemptedAt   time.Time   `gorm:"index;not null"`
+}
+
+type LoginAttemptRepository interface {
+	Save(ctx context.Context, a *LoginAttempt) error
+	// RecentSuccessful returns the latest successful logins of a user, newest first
+	RecentSuccessful(ctx context.Context, userID int64, limit int) ([]*LoginAttempt, error)
+}
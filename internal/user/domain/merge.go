@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+)
+
+// AccountMover moves what a module stores about a user to another user when duplicate accounts are
+// merged. It runs in the transaction of the merge, bound to ctx, and reports what it moved; a dry
+// run rolls the transaction back after the report.
+type AccountMover interface {
+	MoveAccount(ctx context.Context, fromUserID, toUserID int64) ([]MergeItem, error)
+}
+
+// MergeItem is what a merge does with one kind of data of the duplicate account
+type MergeItem struct {
+	// Kind names the data, e.g. "orders"
+	Kind string
+	// Moved counts the records the target takes over
+	Moved int
+	// Conflicts tell how records clashing with those of the target were resolved
+	Conflicts []string
+}
+
+// MergeReport is the outcome of merging Source into Target
+type MergeReport struct {
+	Source *User
+	Target *User
+	// DryRun is set when nothing was changed and the report only tells what a merge would do
+	DryRun bool
+	Items  []MergeItem
+}
+
+// FillProfileFrom resolves the profiles of merged accounts: the values of u win and its blank
+// fields are taken from source
+func (u *User) FillProfileFrom(source *User) MergeItem {
+	item := MergeItem{Kind: "profile"}
+	fill := func(field string, value *string, from string) {
+		switch {
+		case from == "":
+		case *value == "":
+			*value = from
+			item.Moved++
+		case *value != from:
+			item.Conflicts = append(item.Conflicts, fmt.Sprintf("%s: kept %q over %q", field, *value, from))
+		}
+	}
+	fill("first_name", &u.FirstName, source.FirstName)
+	fill("last_name", &u.LastName, source.LastName)
+	phone := string(u.Phone)
+	fill("phone", &phone, string(source.Phone))
+	u.Phone = Phone(phone)
+	return item
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+// PasswordPolicy describes the rules a new password must satisfy
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	DisallowEmail bool // reject passwords containing the email address or its local part
+	CheckBreached bool // reject passwords found in known breaches
+}
+
+// DefaultPasswordPolicy is used when no tenant specific policy is configured
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:     12,
+	RequireUpper:  true,
+	RequireLower:  true,
+	RequireDigit:  true,
+	DisallowEmail: true,
+	CheckBreached: true,
+}
+
+// Check applies the static rules of the policy and returns validation.Errors on the "password" field
+func (p PasswordPolicy) Check(password string, email Email) error {
+	var errs validation.Errors
+
+	errs.Check(len([]rune(password)) >= p.MinLength, "password", fmt.Sprintf("must be at least %d characters long", p.MinLength))
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	errs.Check(!p.RequireUpper || hasUpper, "password", "must contain an uppercase letter")
+	errs.Check(!p.RequireLower || hasLower, "password", "must contain a lowercase letter")
+	errs.Check(!p.RequireDigit || hasDigit, "password", "must contain a digit")
+	errs.Check(!p.RequireSymbol || hasSymbol, "password", "must contain a symbol")
+
+	if p.DisallowEmail && email != "" {
+		lowered := strings.ToLower(password)
+		localPart, _, _ := strings.Cut(email.String(), "@")
+		containsEmail := strings.Contains(lowered, email.String()) || (len(localPart) >= 3 && strings.Contains(lowered, localPart))
+		errs.Check(!containsEmail, "password", "must not contain the email address")
+	}
+
+	return errs.Err()
+}
+
+// PasswordPolicyProvider resolves the policy that applies to the current request (e.g. per tenant)
+type PasswordPolicyProvider interface {
+	PolicyFor(ctx context.Context) PasswordPolicy
+}
+
+// BreachedPasswordChecker reports whether a password appears in a known data breach
+type BreachedPasswordChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// StaticPasswordPolicyProvider returns a per-tenant policy when one is configured, the default otherwise
+type StaticPasswordPolicyProvider struct {
+	Default  PasswordPolicy
+	ByTenant map[string]PasswordPolicy
+
+	// TenantID extracts the tenant from the context; nil means single tenant
+	TenantID func(ctx context.Context) string
+}
+
+func (p *StaticPasswordPolicyProvider) PolicyFor(ctx context.Context) PasswordPolicy {
+	if p.TenantID != nil {
+		if policy, ok := p.ByTenant[p.TenantID(ctx)]; ok {
+			return policy
+		}
+	}
+	return p.Default
+}
+
+// PasswordValidator enforces the resolved policy, including the breached password check
+type PasswordValidator struct {
+	Policies PasswordPolicyProvider
+	Breaches BreachedPasswordChecker // optional
+}
+
+// Validate returns validation.Errors when the password violates the policy.
+// The breach check fails open: an unavailable breach service must not block sign-ups.
+func (v *PasswordValidator) Validate(ctx context.Context, password string, email Email) error {
+	policy := v.Policies.PolicyFor(ctx)
+	if err := policy.Check(password, email); err != nil {
+		return err
+	}
+
+	if !policy.CheckBreached || v.Breaches == nil {
+		return nil
+	}
+
+	breached, err := v.Breaches.IsBreached(ctx, password)
+	if err != nil {
+		log.Printf("breached password check unavailable: %v", err)
+		return nil
+	}
+	if breached {
+		var errs validation.Errors
+		errs.Add("password", "has appeared in a data breach, choose a different one")
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// e164 is an international number: a plus sign, a country code and at most 15 digits in total
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// Phone is a phone number in E.164 format, e.g. "+14155550123"
+type Phone string
+
+// NewPhone parses a phone number, dropping the spaces, dashes, dots and parentheses people format
+// numbers with. An empty number is valid and means none.
+func NewPhone(raw string) (Phone, error) {
+	phone := Phone(strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, raw))
+	if err := phone.Validate(); err != nil {
+		return "", err
+	}
+	return phone, nil
+}
+
+func (p Phone) Validate() error {
+	if p != "" && !e164.MatchString(string(p)) {
+		return fmt.Errorf("%w: %q must start with + and the country code", ErrInvalidPhone, string(p))
+	}
+	return nil
+}
+
+func (p Phone) String() string {
+	return string(p)
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+func TestNewPhone(t *testing.T) {
+	phone, err := NewPhone(" +1 (415) 555-0123 ")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if phone != "+14155550123" {
+		t.Errorf("Expected normalized phone, got %s", phone)
+	}
+
+	if phone, err := NewPhone(""); err != nil || phone != "" {
+		t.Errorf("Expected an empty phone to be valid, got %q and %v", phone, err)
+	}
+
+	for _, raw := range []string{"555-0123", "004930123456", "+0301234567", "+49 30 CALL ME", "+1234567890123456"} {
+		if _, err := NewPhone(raw); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("Expected ErrInvalidPhone for %q, got %v", raw, err)
+		}
+	}
+}
+
+func TestUser_UpdateProfile(t *testing.T) {
+	u := &User{Email: "jane@example.com", Active: true}
+
+	if err := u.UpdateProfile(" Jane ", "Doe", "+49 30 1234567"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if u.FirstName != "Jane" || u.LastName != "Doe" || u.Phone != "+49301234567" {
+		t.Errorf("Expected a normalized profile, got %q %q %q", u.FirstName, u.LastName, u.Phone)
+	}
+
+	for _, tt := range []struct{ firstName, phone, field string }{
+		{firstName: "John", phone: "030 1234567", field: "phone"},
+		{firstName: strings.Repeat("J", MaxNameLength+1), phone: "", field: "first_name"},
+	} {
+		var errs validation.Errors
+		if err := u.UpdateProfile(tt.firstName, "Doe", tt.phone); !errors.As(err, &errs) || errs[0].Field != tt.field {
+			t.Errorf("Expected a validation error on %s, got %v", tt.field, err)
+		}
+	}
+	if u.FirstName != "Jane" || u.Phone != "+49301234567" {
+		t.Errorf("Expected an invalid profile to leave the user alone, got %q %q", u.FirstName, u.Phone)
+	}
+}
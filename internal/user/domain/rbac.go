@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+
+// Permissions checked by the HTTP ports. The :any scope covers every resource, :own only the caller's.
+const (
+	PermissionOrderCreateAny   auth.Permission = "order:create:any"
+	PermissionOrderCreateOwn   auth.Permission = "order:create:own"
+	PermissionOrderReadAny     auth.Permission = "order:read:any"
+	PermissionOrderReadOwn     auth.Permission = "order:read:own"
+	PermissionPaymentCapture   auth.Permission = "payment:capture"
+	PermissionPaymentRefund    auth.Permission = "payment:refund"
+	PermissionProductWrite     auth.Permission = "product:write"
+	PermissionUserReadAny      auth.Permission = "user:read:any"
+	PermissionUserReadOwn      auth.Permission = "user:read:own"
+	PermissionUserUpdateAny    auth.Permission = "user:update:any"
+	PermissionUserUpdateOwn    auth.Permission = "user:update:own"
+	PermissionRoleAssign       auth.Permission = "role:assign"
+	PermissionAuditRead        auth.Permission = "audit:read"
+	PermissionDisputeManage    auth.Permission = "dispute:manage"
+	PermissionCredentialManage auth.Permission = "credential:manage"
+	PermissionCampaignManage   auth.Permission = "campaign:manage"
+	PermissionDeliveryReport   auth.Permission = "delivery:report"
+	PermissionShipmentManage   auth.Permission = "shipment:manage"
+	PermissionSupportQuery     auth.Permission = "support:query"
+	PermissionWebhookManage    auth.Permission = "webhook:manage"
+	PermissionUserManage       auth.Permission = "user:manage"
+	PermissionPIIRead          auth.Permission = "pii:read"
+	PermissionAPIKeyManage     auth.Permission = "api_key:manage"
+	PermissionSettingManage    auth.Permission = "setting:manage"
+	PermissionCouponManage     auth.Permission = "coupon:manage"
+	PermissionReviewWrite      auth.Permission = "review:write"
+	PermissionReviewModerate   auth.Permission = "review:moderate"
+	PermissionReportRead       auth.Permission = "report:read"
+	// PermissionTenantBypass lets requests see the rows of every tenant, see tenant.BypassMiddleware
+	PermissionTenantBypass auth.Permission = "tenant:bypass"
+)
+
+// AllPermissions lists every permission checked by the HTTP ports
+func AllPermissions() []auth.Permission {
+	return []auth.Permission{
+		PermissionOrderCreateAny, PermissionOrderCreateOwn, PermissionOrderReadAny, PermissionOrderReadOwn, PermissionPaymentCapture,
+		PermissionProductWrite, PermissionUserReadAny, PermissionUserReadOwn, PermissionUserUpdateAny, PermissionUserUpdateOwn, PermissionRoleAssign,
+		PermissionAuditRead, PermissionDisputeManage, PermissionCredentialManage, PermissionCampaignManage,
+		PermissionDeliveryReport, PermissionShipmentManage, PermissionPaymentRefund, PermissionSupportQuery,
+		PermissionWebhookManage, PermissionUserManage, PermissionPIIRead, PermissionAPIKeyManage, PermissionSettingManage,
+		PermissionCouponManage, PermissionReviewWrite, PermissionReviewModerate, PermissionReportRead, PermissionTenantBypass,
+	}
+}
+
+// Seeded role names
+const (
+	RoleAdmin    = "admin"
+	RoleCustomer = "customer"
+	// RoleSuperadmin administers every tenant; only unscoped callers may assign it
+	RoleSuperadmin = "superadmin"
+)
+
+// Permission is a named action a role grants
+type Permission struct {
+	ID   int64           `gorm:"primaryKey"`
+	Name auth.Permission `gorm:"type:varchar(100);uniqueIndex;not null"`
+}
+
+// Role groups permissions; users get permissions only through their roles
+type Role struct {
+	ID          int64        `gorm:"primaryKey"`
+	Name        string       `gorm:"type:varchar(50);uniqueIndex;not null"`
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+}
+
+// UserRole assigns a role to a user
+type UserRole struct {
+	UserID int64 `gorm:"primaryKey"`
+	RoleID int64 `gorm:"primaryKey"`
+	// Role is never loaded; it lets filters follow assignments to role names
+	Role Role `gorm:"foreignKey:RoleID;constraint:-"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// DefaultRoles are seeded on startup: superadmins may do everything, admins everything within
+// their tenant, customers act on their own data
+func DefaultRoles() []Role {
+	tenantPermissions := slices.DeleteFunc(AllPermissions(), func(p auth.Permission) bool { return p == PermissionTenantBypass })
+	return []Role{
+		{Name: RoleSuperadmin, Permissions: permissions(AllPermissions()...)},
+		{Name: RoleAdmin, Permissions: permissions(tenantPermissions...)},
+		{Name: RoleCustomer, Permissions: permissions(
+			PermissionOrderCreateOwn, PermissionOrderReadOwn, PermissionUserReadOwn, PermissionUserUpdateOwn,
+			PermissionReviewWrite,
+		)},
+	}
+}
+
+func permissions(names ...auth.Permission) []Permission {
+	ps := make([]Permission, len(names))
+	for i, name := range names {
+		ps[i] = Permission{Name: name}
+	}
+	return ps
+}
+
+type RoleRepository interface {
+	// Seed creates missing roles and permissions and grants the listed permissions; it is safe to run repeatedly
+	Seed(ctx context.Context, roles []Role) error
+	// Assign gives the named role to a user, failing with ErrRoleNotFound for unknown roles
+	Assign(ctx context.Context, userID int64, role string) error
+	// PermissionsOf returns the permissions granted by all roles of a user
+	PermissionsOf(ctx context.Context, userID int64) ([]auth.Permission, error)
+}
+
+// PermissionChecker resolves permissions through the roles of a user. Requests limited to scopes,
+// such as those made with an API key, only hold the permissions of the roles within the scopes.
+type PermissionChecker struct {
+	Roles RoleRepository
+}
+
+func (c *PermissionChecker) Can(ctx context.Context, userID int64, p auth.Permission) (bool, error) {
+	if !auth.InScope(ctx, p) {
+		return false, nil
+	}
+	granted, err := c.Roles.PermissionsOf(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, g := range granted {
+		if g == p {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -1,11 +1,228 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email address is already registered")
+	// ErrUserDeactivated is returned when a deactivated user logs in or places an order
+	ErrUserDeactivated = errors.New("user is deactivated")
+	// ErrPasswordResetRequired is returned for logins with the right password until the user sets a new one
+	ErrPasswordResetRequired = errors.New("password reset required")
+	// ErrUserMerged is returned when reactivating or merging an account merged into another one
+	ErrUserMerged = errors.New("user was merged into another account")
+	// ErrMergeIntoSelf is returned when merging an account into itself
+	ErrMergeIntoSelf = errors.New("cannot merge a user into itself")
+)
+
+// PublicIDPrefix starts the public IDs of users, e.g. "usr_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const PublicIDPrefix = "usr"
+
 type User struct {
-	ID     int64  `gorm:"primaryKey"`
-	Active bool   `gorm:"not null"`
-	Email  string `gorm:"uniqueIndex;not null"`
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the user in external APIs so the primary key never leaves the service
+	PublicID string `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	// TenantID is the tenant the user belongs to, within which the email address is unique; see
+	// tenant.Plugin
+	TenantID     string `gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_users_tenant_email,priority:1"`
+	Active       bool   `gorm:"not null"`
+	Email        Email  `gorm:"type:varchar(255);uniqueIndex:idx_users_tenant_email,priority:2;not null"`
+	PasswordHash string `gorm:"type:varchar(255)"`
+
+	// FirstName, LastName and Phone make up the optional profile of the user
+	FirstName string `gorm:"type:varchar(100)"`
+	LastName  string `gorm:"type:varchar(100)"`
+	Phone     Phone  `gorm:"type:varchar(16)"`
+
+	// DeactivatedAt is when an admin blocked the user from logging in and placing orders
+	DeactivatedAt *time.Time
+	// PasswordResetRequired makes the user set a new password before logging in again
+	PasswordResetRequired bool `gorm:"not null;default:false"`
+	// EmailVerifiedAt is when the user proved they receive mail at Email; nil until then
+	EmailVerifiedAt *time.Time
+	// MergedIntoID is the account a duplicate was merged into; merged users stay deactivated
+	MergedIntoID *int64 `gorm:"index"`
+
+	// RoleAssignments relate users to their roles for UserSearchFilter; they are never loaded
+	RoleAssignments []UserRole `gorm:"foreignKey:UserID;constraint:-"`
+}
+
+// MaxNameLength bounds the first and last name of a user
+const MaxNameLength = 100
+
+// UserRegisteredEvent is the event name of UserRegistered
+const UserRegisteredEvent = "user.registered"
+
+// UserRegistered is emitted once a new user is saved
+type UserRegistered struct {
+	UserID       int64
+	UserPublicID string
+	Email        Email
+}
+
+func (UserRegistered) EventName() string {
+	return UserRegisteredEvent
+}
+
+// EventID is that of the first version of the user; a user registers only once
+func (e UserRegistered) EventID() string {
+	return event.NewID(UserRegisteredEvent, e.UserPublicID, 1)
+}
+
+// NewUser creates an inactive user with a normalized email address
+func NewUser(email string) (*User, error) {
+	normalized, err := NewEmail(email)
+	if err != nil {
+		var errs validation.Errors
+		errs.Add("email", err.Error())
+		return nil, errs
+	}
+
+	u := &User{Email: normalized}
+	u.AssignPublicID()
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// MustNewUser is like NewUser but panics on invalid input; intended for tests and fixtures
+func MustNewUser(email string) *User {
+	u, err := NewUser(email)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// AssignPublicID gives the user a public ID unless it already has one
+func (u *User) AssignPublicID() {
+	if u.PublicID == "" {
+		u.PublicID = publicid.New(PublicIDPrefix)
+	}
+}
+
+// UpdateProfile replaces the profile of the user; empty values clear a field
+func (u *User) UpdateProfile(firstName, lastName, phone string) error {
+	var errs validation.Errors
+	p, err := NewPhone(phone)
+	if err != nil {
+		errs.Add("phone", err.Error())
+		return errs
+	}
+
+	updated := *u
+	updated.FirstName = strings.TrimSpace(firstName)
+	updated.LastName = strings.TrimSpace(lastName)
+	updated.Phone = p
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	*u = updated
+	return nil
 }
 
 func (u *User) Activate() {
 	u.Active = true
 }
+
+// Deactivate blocks the user from logging in and placing orders until reactivated
+func (u *User) Deactivate(at time.Time) {
+	if u.DeactivatedAt == nil {
+		u.DeactivatedAt = &at
+	}
+}
+
+// Reactivate lifts a deactivation; merged accounts stay deactivated
+func (u *User) Reactivate() error {
+	if u.MergedIntoID != nil {
+		return fmt.Errorf("%w: user %s", ErrUserMerged, u.PublicID)
+	}
+	u.DeactivatedAt = nil
+	return nil
+}
+
+// Deactivated reports whether the user is blocked from logging in and placing orders
+func (u *User) Deactivated() bool {
+	return u.DeactivatedAt != nil
+}
+
+// RequirePasswordReset rejects logins until the user sets a new password
+func (u *User) RequirePasswordReset() {
+	u.PasswordResetRequired = true
+}
+
+// VerifyEmail records that the user receives mail at their email address
+func (u *User) VerifyEmail(at time.Time) {
+	if u.EmailVerifiedAt == nil {
+		u.EmailVerifiedAt = &at
+	}
+}
+
+// EmailVerified reports whether the user proved they receive mail at their email address
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// MergeInto marks the user as a duplicate of target and deactivates it. Moving the data of the
+// user to target is up to the caller.
+func (u *User) MergeInto(target *User, at time.Time) error {
+	switch {
+	case u.ID == target.ID:
+		return ErrMergeIntoSelf
+	case u.MergedIntoID != nil:
+		return fmt.Errorf("%w: user %s", ErrUserMerged, u.PublicID)
+	case target.MergedIntoID != nil:
+		return fmt.Errorf("%w: user %s", ErrUserMerged, target.PublicID)
+	}
+	u.MergedIntoID = &target.ID
+	u.Deactivate(at)
+	return nil
+}
+
+// Validate checks the user invariants and returns validation.Errors describing every violation
+func (u *User) Validate() error {
+	var errs validation.Errors
+
+	if err := u.Email.Validate(); err != nil {
+		errs.Add("email", err.Error())
+	}
+	errs.Check(utf8.RuneCountInString(u.FirstName) <= MaxNameLength, "first_name", fmt.Sprintf("must be at most %d characters", MaxNameLength))
+	errs.Check(utf8.RuneCountInString(u.LastName) <= MaxNameLength, "last_name", fmt.Sprintf("must be at most %d characters", MaxNameLength))
+	if err := u.Phone.Validate(); err != nil {
+		errs.Add("phone", err.Error())
+	}
+
+	return errs.Err()
+}
+
+// SetPassword stores a bcrypt hash of the password and lifts a required reset; policy checks
+// happen in PasswordValidator
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hash)
+	u.PasswordResetRequired = false
+	return nil
+}
+
+// CheckPassword reports whether password matches the stored hash
+func (u *User) CheckPassword(password string) bool {
+	if u.PasswordHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
@@ -1,8 +1,37 @@
 package domain
 
-import "context"
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
 
 type UserRepository interface {
 	GetByID(ctx context.Context, id int64) (*User, error)
+	// GetByPublicID looks a user up by the ID shown in external APIs
+	GetByPublicID(ctx context.Context, publicID string) (*User, error)
+	// GetByIDs returns the users with the given IDs in one query, in the order of ids; unknown
+	// and repeated IDs are omitted
+	GetByIDs(ctx context.Context, ids []int64) ([]User, error)
 	Save(ctx context.Context, u *User) error
+	GetByEmail(ctx context.Context, email Email) (*User, error)
+	ExistsByEmail(ctx context.Context, email Email) (bool, error)
+	// Search returns a page of the users matching filter in the order of its Sort, oldest first by
+	// default, and how many match in total
+	Search(ctx context.Context, filter UserSearchFilter, page, pageSize int) ([]User, int64, error)
+}
+
+// UserSearchFilter is the filter of admin user searches, applied with query.QueryBuilder.ApplyFilters
+type UserSearchFilter struct {
+	SearchTerm string `filter:"email,CONTAINS"`
+	Active     *bool  `filter:"active"`
+	// Deactivated keeps only the users an admin deactivated, merged duplicates included
+	Deactivated bool `filter:"deactivated_at,IS NOT NULL"`
+	// Roles keeps the users holding any of the named roles
+	Roles []string `filter:"RoleAssignments.Role.name,IN"`
+	// Sort orders the users by columns of UserSortColumns; ties are broken by ID
+	Sort []query.SortConfig `filter:"-"`
 }
+
+// UserSortColumns are the columns user searches sort by
+var UserSortColumns = []string{"id", "email", "first_name", "last_name", "deactivated_at", "email_verified_at"}
@@ -0,0 +1,124 @@
+package port
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+)
+
+// AddressFormatResponse describes how addresses of a country are filled in, so forms can show
+// the right fields and check them before submitting
+type AddressFormatResponse struct {
+	Country     string `json:"country"`
+	CountryName string `json:"country_name,omitempty"`
+	// Fields lists the fields of the address form in print order
+	Fields []AddressFieldResponse `json:"fields"`
+	// PostalCodePattern is a regular expression of valid, normalized postal codes; empty accepts any
+	PostalCodePattern string `json:"postal_code_pattern,omitempty"`
+	PostalCodeExample string `json:"postal_code_example,omitempty"`
+	// Regions are the accepted region codes; empty accepts any region
+	Regions []string `json:"regions,omitempty"`
+}
+
+// AddressFieldResponse is a field of an address form and what the country calls it
+type AddressFieldResponse struct {
+	Name     address.Field `json:"name"`
+	Label    string        `json:"label"`
+	Required bool          `json:"required"`
+}
+
+// PostalAddressRequest is the body of POST /addresses/validate
+type PostalAddressRequest struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// AddressValidationResponse is a valid address in normalized form and printed for invoices and labels
+type AddressValidationResponse struct {
+	Address PostalAddressRequest `json:"address"`
+	// Lines is the address as printed on an invoice; the country name is added for addresses abroad
+	Lines []string `json:"lines"`
+	// Label is the address as printed on a shipping label, with the city and country lines upper-cased
+	Label []string `json:"label"`
+}
+
+// fieldLabels are the labels of the fields that do not depend on the country
+var fieldLabels = map[address.Field]string{
+	address.FieldName:    "Full name",
+	address.FieldLine1:   "Street address",
+	address.FieldLine2:   "Apartment, suite, etc.",
+	address.FieldCity:    "City",
+	address.FieldCountry: "Country",
+}
+
+func (s *HTTPServer) addressFormat(w http.ResponseWriter, r *http.Request) {
+	f := address.FormatOf(r.PathValue("country"))
+
+	resp := AddressFormatResponse{
+		Country:           f.Country,
+		CountryName:       f.CountryName,
+		PostalCodeExample: f.PostalCodeExample,
+		Regions:           f.Regions,
+	}
+	if f.PostalCode != nil {
+		resp.PostalCodePattern = f.PostalCode.String()
+	}
+	for _, field := range layoutFields(f) {
+		label := fieldLabels[field]
+		switch field {
+		case address.FieldRegion:
+			label = f.RegionLabel
+		case address.FieldPostalCode:
+			label = f.PostalCodeLabel
+		}
+		resp.Fields = append(resp.Fields, AddressFieldResponse{Name: field, Label: label, Required: f.Requires(field)})
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) validateAddress(w http.ResponseWriter, r *http.Request) {
+	var req PostalAddressRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	a := AddressRequest{Name: req.Name, Line1: req.Line1, Line2: req.Line2, City: req.City, Region: req.Region, PostalCode: req.PostalCode, Country: req.Country}.address().Normalize()
+	if err := a.Validate(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	httpx.WriteJSON(w, http.StatusOK, AddressValidationResponse{
+		Address: PostalAddressRequest{
+			Name:       a.Name,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+		},
+		Lines: a.Lines(from),
+		Label: a.Label(from),
+	})
+}
+
+// layoutFields returns the fields of a format in the order its layout prints them, then the country
+func layoutFields(f address.Format) []address.Field {
+	var fields []address.Field
+	for _, line := range f.Layout {
+		for _, part := range strings.Split(line, "{")[1:] {
+			fields = append(fields, address.Field(part[:strings.Index(part, "}")]))
+		}
+	}
+	return append(fields, address.FieldCountry)
+}
@@ -0,0 +1,187 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// APIKeyHeader carries the API key of machine-to-machine clients
+const APIKeyHeader = "X-API-Key"
+
+// ErrorCodeInvalidAPIKey is returned with 401 when the X-API-Key of a request is unknown, wrong,
+// expired, revoked or issued in another tenant
+const ErrorCodeInvalidAPIKey = "invalid_api_key"
+
+// IssueAPIKeyRequest is the body of POST /users/{id}/api-keys
+type IssueAPIKeyRequest struct {
+	Name   string            `json:"name"`
+	Scopes []auth.Permission `json:"scopes"`
+	// ExpiresAt is optional; keys without one stay valid until revoked
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyResponse describes an API key; its secret is never shown again after it was issued
+type APIKeyResponse struct {
+	ID string `json:"id"`
+	// Prefix is the part of the key before the dot, to tell keys apart
+	Prefix     string            `json:"prefix"`
+	Name       string            `json:"name"`
+	Scopes     []auth.Permission `json:"scopes"`
+	ExpiresAt  *time.Time        `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time        `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time        `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// IssuedAPIKeyResponse is a new API key with the key to send in X-API-Key
+type IssuedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// APIKeysResponse lists the API keys of a user, newest first
+type APIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+type apiKeyContextKey struct{}
+
+// APIKeyMiddleware authenticates requests carrying an X-API-Key header as the user owning the key,
+// limited to the key's scopes. Requests without a tenant get the key's tenant, and the mode modes
+// resolves for it. It must run after tenant.Middleware, mode.Middleware and auth.Middleware, whose
+// tenant, mode and user the key replaces.
+func APIKeyMiddleware(authenticate decorator.CommandResultHandler[command.AuthenticateAPIKeyCommand, *domain.APIKey], modes mode.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			k, err := authenticate.Handle(ctx, command.AuthenticateAPIKeyCommand{Key: key})
+			if err == nil && tenant.Named(ctx) && k.TenantID != tenant.FromContext(ctx) {
+				err = domain.ErrInvalidAPIKey
+			}
+			switch {
+			case errors.Is(err, domain.ErrInvalidAPIKey):
+				httpx.WriteErrorCode(w, http.StatusUnauthorized, ErrorCodeInvalidAPIKey, err)
+				return
+			case err != nil:
+				httpx.WriteError(w, err)
+				return
+			}
+
+			ctx = mode.With(tenant.WithID(ctx, k.TenantID), modes.Of(k.TenantID))
+			ctx = auth.WithScopes(auth.WithUserID(ctx, k.UserID), k.Scopes)
+			ctx = context.WithValue(ctx, apiKeyContextKey{}, k.PublicID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// APIKeyID returns the public ID of the API key r was authenticated with, or "" for requests
+// without one
+func APIKeyID(r *http.Request) string {
+	id, _ := r.Context().Value(apiKeyContextKey{}).(string)
+	return id
+}
+
+func (s *HTTPServer) issueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req IssueAPIKeyRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	u, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	issued, err := s.IssueAPIKey.Handle(r.Context(), command.IssueAPIKeyCommand{
+		UserID:    u.ID,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, IssuedAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(issued.APIKey),
+		Key:            issued.Key,
+	})
+}
+
+func (s *HTTPServer) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	u, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	keys, err := s.APIKeys.ListByUser(r.Context(), u.ID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := APIKeysResponse{APIKeys: make([]APIKeyResponse, len(keys))}
+	for i := range keys {
+		resp.APIKeys[i] = toAPIKeyResponse(&keys[i])
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	u, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	if err := s.RevokeAPIKey.Handle(r.Context(), command.RevokeAPIKeyCommand{UserID: u.ID, PublicID: r.PathValue("keyID")}); err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAPIKeyError maps the errors of the API key commands onto HTTP status codes
+func writeAPIKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrUserDeactivated):
+		httpx.WriteErrorStatus(w, http.StatusConflict, err)
+	default:
+		writeUserError(w, err)
+	}
+}
+
+func toAPIKeyResponse(k *domain.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.PublicID,
+		Prefix:     k.Prefix,
+		Name:       k.Name,
+		Scopes:     k.Scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
@@ -0,0 +1,77 @@
+package port_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/port"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubAuthenticator struct {
+	key *domain.APIKey
+}
+
+func (s stubAuthenticator) Handle(ctx context.Context, cmd command.AuthenticateAPIKeyCommand) (*domain.APIKey, error) {
+	return s.key, nil
+}
+
+func TestAPIKeyMiddleware_SandboxKeyWithoutTenantHeader(t *testing.T) {
+	modes := mode.Resolver{SandboxTenants: []string{"acme-test"}}
+	key := &domain.APIKey{PublicID: "key_1", UserID: 7, TenantID: "acme-test"}
+	var seenTenant string
+	var seenMode mode.Mode
+	handler := tenant.Middleware(mode.Middleware(modes)(port.APIKeyMiddleware(stubAuthenticator{key: key}, modes)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seenTenant, seenMode = tenant.FromContext(r.Context()), mode.FromContext(r.Context())
+		}),
+	)))
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(port.APIKeyHeader, "ak_1.secret")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "acme-test", seenTenant)
+	assert.Equal(t, mode.Sandbox, seenMode, "the key's sandbox tenant must not be served live")
+}
+
+func TestAPIKeyMiddleware_RejectsKeyOfAnotherTenant(t *testing.T) {
+	key := &domain.APIKey{PublicID: "key_1", UserID: 7, TenantID: "acme"}
+	handler := (&tenant.Resolver{Domain: "shop.example.com"}).Middleware(port.APIKeyMiddleware(stubAuthenticator{key: key}, mode.Resolver{})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	))
+
+	tests := []struct {
+		name   string
+		host   string
+		header string
+		status int
+	}{
+		{name: "header", host: "api.example.com", header: "globex", status: http.StatusUnauthorized},
+		{name: "subdomain", host: "globex.shop.example.com", status: http.StatusUnauthorized},
+		{name: "own subdomain", host: "acme.shop.example.com", status: http.StatusOK},
+		{name: "no tenant", host: "api.example.com", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Host = tt.host
+		if tt.header != "" {
+			req.Header.Set(tenant.Header, tt.header)
+		}
+		req.Header.Set(port.APIKeyHeader, "ak_1.secret")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code, tt.name)
+	}
+}
@@ -0,0 +1,1059 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/address"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+)
+
+// ErrorCodeEmailTaken is returned with 409 when registering an email that already exists
+const ErrorCodeEmailTaken = "email_taken"
+
+// ErrorCodeInvalidCredentials is returned with 401 when the email or password is wrong
+const ErrorCodeInvalidCredentials = "invalid_credentials"
+
+// ErrorCodeAddressInUse is returned with 409 when deleting an address an order ships to
+const ErrorCodeAddressInUse = "address_in_use"
+
+// ErrorCodeUserDeactivated is returned with 403 when a deactivated user logs in
+const ErrorCodeUserDeactivated = "user_deactivated"
+
+// ErrorCodePasswordResetRequired is returned with 403 when a user must set a new password with
+// POST /password before logging in
+const ErrorCodePasswordResetRequired = "password_reset_required"
+
+// ErrorCodeInvalidToken is returned with 400 when an emailed token is unknown, expired, used or
+// locked after too many wrong guesses
+const ErrorCodeInvalidToken = "invalid_token"
+
+// ErrorCodeEmailAlreadyVerified is returned with 409 when requesting the verification of a verified email
+const ErrorCodeEmailAlreadyVerified = "email_already_verified"
+
+// defaultUserPageSize and MaxUserPageSize bound the page_size of GET /users
+const (
+	defaultUserPageSize = 50
+	MaxUserPageSize     = 100
+)
+
+// RegisterUserRequest is the body of POST /users
+type RegisterUserRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginRequest is the body of POST /login
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginResponse reports the authenticated user and whether a second factor is still required
+type LoginResponse struct {
+	User           UserResponse `json:"user"`
+	StepUpRequired bool         `json:"step_up_required"`
+	Anomalies      []string     `json:"anomalies,omitempty"`
+}
+
+// ChangePasswordRequest is the body of POST /password
+type ChangePasswordRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// PasswordResetRequest is the body of POST /password/reset-request
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest is the body of POST /password/reset; Token comes from the emailed link
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// VerifyEmailRequest is the body of POST /email-verification; Token comes from the emailed link
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// MergeUserRequest is the body of POST /users/{id}/merge
+type MergeUserRequest struct {
+	// Into is the public ID of the account that keeps the addresses, roles and orders of the duplicate
+	Into string `json:"into"`
+}
+
+// MergeReportResponse tells what merging a duplicate account would do, by kind of data
+type MergeReportResponse struct {
+	// Source and Target are public IDs
+	Source string              `json:"source"`
+	Target string              `json:"target"`
+	Items  []MergeItemResponse `json:"items"`
+}
+
+// MergeItemResponse is what a merge does with one kind of data, e.g. orders; conflicts tell how
+// the records clashing with those of the target are resolved
+type MergeItemResponse struct {
+	Kind      string   `json:"kind"`
+	Moved     int      `json:"moved"`
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// AssignRoleRequest is the body of POST /users/{id}/roles
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// UpdateProfileRequest is the body of PUT /users/{id}/profile; omitted fields are cleared
+type UpdateProfileRequest struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	// Phone is an international number such as "+14155550123"
+	Phone string `json:"phone"`
+}
+
+// AddressRequest is the body of POST /users/{id}/addresses and PUT /users/{id}/addresses/{addressID}
+type AddressRequest struct {
+	Label string `json:"label"`
+	Name  string `json:"name"`
+	Line1 string `json:"line1"`
+	Line2 string `json:"line2"`
+	City  string `json:"city"`
+	// Region is the state, province or county, e.g. "CA"; GET /address-formats/{country} tells whether it is required
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	// Country is an ISO 3166-1 alpha-2 code, e.g. "DE"
+	Country string `json:"country"`
+	Phone   string `json:"phone"`
+}
+
+// UserResponse is the public representation of a user; ID is the public "usr_" ID
+type UserResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email" mask:"email"`
+	Active    bool   `json:"active"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Phone     string `json:"phone,omitempty" mask:"phone"`
+	// DeactivatedAt is when an admin blocked the user from logging in and placing orders
+	DeactivatedAt         *time.Time `json:"deactivated_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required,omitempty"`
+	// EmailVerifiedAt is when the user proved they receive mail at Email
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// MergedInto is the public ID of the account this duplicate was merged into
+	MergedInto string `json:"merged_into,omitempty"`
+}
+
+// UsersResponse is a page of GET /users, oldest user first
+type UsersResponse struct {
+	Users    []UserResponse `json:"users"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+// AddressResponse is an address of the address book; ID is the public "adr_" ID orders ship to
+type AddressResponse struct {
+	ID         string `json:"id"`
+	Label      string `json:"label,omitempty"`
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone,omitempty" mask:"phone"`
+	// Lines is the postal address as printed in its country, with the country name last
+	Lines []string `json:"lines"`
+}
+
+// AddressesResponse lists the address book of a user, oldest address first
+type AddressesResponse struct {
+	Addresses []dto.Sparse[AddressResponse] `json:"addresses"`
+}
+
+// HTTPServer exposes the user use cases over HTTP
+type HTTPServer struct {
+	RegisterUser decorator.CommandResultHandler[command.RegisterUserCommand, *domain.User]
+	Login        decorator.CommandResultHandler[command.LoginCommand, *command.LoginResult]
+	AssignRole   decorator.CommandHandler[command.AssignRoleCommand]
+	UserRepo     domain.UserRepository
+
+	ChangePassword       decorator.CommandResultHandler[command.ChangePasswordCommand, *domain.User]
+	DeactivateUser       decorator.CommandResultHandler[command.DeactivateUserCommand, *domain.User]
+	ReactivateUser       decorator.CommandResultHandler[command.ReactivateUserCommand, *domain.User]
+	RequirePasswordReset decorator.CommandResultHandler[command.RequirePasswordResetCommand, *domain.User]
+	MergeUsers           decorator.CommandResultHandler[command.MergeUsersCommand, *domain.MergeReport]
+
+	RequestPasswordReset     decorator.CommandHandler[command.RequestPasswordResetCommand]
+	ResetPassword            decorator.CommandResultHandler[command.ResetPasswordCommand, *domain.User]
+	RequestEmailVerification decorator.CommandHandler[command.RequestEmailVerificationCommand]
+	VerifyEmail              decorator.CommandResultHandler[command.VerifyEmailCommand, *domain.User]
+
+	UpdateProfile decorator.CommandResultHandler[command.UpdateProfileCommand, *domain.User]
+	AddAddress    decorator.CommandResultHandler[command.AddAddressCommand, *domain.Address]
+	UpdateAddress decorator.CommandResultHandler[command.UpdateAddressCommand, *domain.Address]
+	DeleteAddress decorator.CommandHandler[command.DeleteAddressCommand]
+	Addresses     domain.AddressRepository
+
+	IssueAPIKey  decorator.CommandResultHandler[command.IssueAPIKeyCommand, *command.IssuedAPIKey]
+	RevokeAPIKey decorator.CommandHandler[command.RevokeAPIKeyCommand]
+	APIKeys      domain.APIKeyRepository
+
+	// Auth limits customers to their own account and address book, role changes to role:assign,
+	// API keys to api_key:manage and the admin endpoints to user:manage; nil disables access control
+	Auth auth.Authorizer
+	// Masker hides the emails and phones of other users from callers without pii:read; nil shows them
+	Masker *dto.Masker
+}
+
+// RegisterRoutes adds the user endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users",
+		Summary:  "Register a user",
+		Tags:     []string{"users"},
+		Request:  RegisterUserRequest{},
+		Response: UserResponse{},
+		Status:   http.StatusCreated,
+		Handler:  s.registerUser,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/login",
+		Summary:  "Log in with email and password",
+		Tags:     []string{"users"},
+		Request:  LoginRequest{},
+		Response: LoginResponse{},
+		Handler:  s.login,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/password",
+		Summary:  "Change a password with the current one; completes a reset required by an admin",
+		Tags:     []string{"users"},
+		Request:  ChangePasswordRequest{},
+		Response: UserResponse{},
+		Handler:  s.changePassword,
+	})
+	r.Handle(httpx.Route{
+		Method:  http.MethodPost,
+		Path:    "/password/reset-request",
+		Summary: "Email a link to reset a forgotten password; accepted whether or not the email is registered",
+		Tags:    []string{"users"},
+		Request: PasswordResetRequest{},
+		Status:  http.StatusAccepted,
+		Handler: s.requestPasswordReset,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/password/reset",
+		Summary:  "Set a new password with the token of a password reset email",
+		Tags:     []string{"users"},
+		Request:  ResetPasswordRequest{},
+		Response: UserResponse{},
+		Handler:  s.resetPassword,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/email-verification",
+		Summary:  "Verify the email of a user with the token of a verification email",
+		Tags:     []string{"users"},
+		Request:  VerifyEmailRequest{},
+		Response: UserResponse{},
+		Handler:  s.verifyEmail,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/users",
+		Summary:  "Search users, e.g. ?search=example.com&role=admin&deactivated=true&sort=-email_verified_at:nulls_last,email&page=2&page_size=50",
+		Tags:     []string{"users"},
+		Response: UsersResponse{},
+		Handler:  auth.Require(s.Auth, domain.PermissionUserManage, s.listUsers),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/users/{id}",
+		Summary:  "Get a user by ID",
+		Tags:     []string{"users"},
+		Response: UserResponse{},
+		Handler:  s.getUser,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/roles",
+		Summary:  "Assign a role to a user",
+		Tags:     []string{"users"},
+		Request:  AssignRoleRequest{},
+		Status:   http.StatusNoContent,
+		Handler:  auth.Require(s.Auth, domain.PermissionRoleAssign, s.assignRole),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/deactivate",
+		Summary:  "Block a user from logging in and placing orders",
+		Tags:     []string{"users"},
+		Response: UserResponse{},
+		Handler:  auth.Require(s.Auth, domain.PermissionUserManage, s.deactivateUser),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/reactivate",
+		Summary:  "Lift the deactivation of a user; merged accounts stay deactivated",
+		Tags:     []string{"users"},
+		Response: UserResponse{},
+		Handler:  auth.Require(s.Auth, domain.PermissionUserManage, s.reactivateUser),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/password-reset",
+		Summary:  "Make a user set a new password before logging in again",
+		Tags:     []string{"users"},
+		Response: UserResponse{},
+		Handler:  auth.Require(s.Auth, domain.PermissionUserManage, s.requirePasswordReset),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/merge",
+		Summary:  "Merge a duplicate account into another one and deactivate it",
+		Tags:     []string{"users"},
+		Request:  MergeUserRequest{},
+		Response: UserResponse{},
+		Handler:  auth.Require(s.Auth, domain.PermissionUserManage, s.mergeUser),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/merge/dry-run",
+		Summary:  "Report what merging a duplicate account into another one would move and how conflicts would be resolved, without changing anything",
+		Tags:     []string{"users"},
+		Request:  MergeUserRequest{},
+		Response: MergeReportResponse{},
+		Handler:  auth.Require(s.Auth, domain.PermissionUserManage, s.dryRunMergeUser),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/email-verification",
+		Summary:  "Email a link to verify the email of a user",
+		Tags:     []string{"users"},
+		Status:   http.StatusAccepted,
+		Handler:  s.requestEmailVerification,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/api-keys",
+		Summary:  "Issue an API key acting as a user within scopes, for machine-to-machine clients; the key is only returned here",
+		Tags:     []string{"users"},
+		Request:  IssueAPIKeyRequest{},
+		Response: IssuedAPIKeyResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, domain.PermissionAPIKeyManage, s.issueAPIKey),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/users/{id}/api-keys",
+		Summary:  "List the API keys of a user, revoked ones included",
+		Tags:     []string{"users"},
+		Response: APIKeysResponse{},
+		Handler:  auth.Require(s.Auth, domain.PermissionAPIKeyManage, s.listAPIKeys),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodDelete,
+		Path:     "/users/{id}/api-keys/{keyID}",
+		Summary:  "Revoke an API key of a user",
+		Tags:     []string{"users"},
+		Status:   http.StatusNoContent,
+		Handler:  auth.Require(s.Auth, domain.PermissionAPIKeyManage, s.revokeAPIKey),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/users/{id}/profile",
+		Summary:  "Replace the name and phone of a user",
+		Tags:     []string{"users"},
+		Request:  UpdateProfileRequest{},
+		Response: UserResponse{},
+		Handler:  s.updateProfile,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/users/{id}/addresses",
+		Summary:  "List the shipping addresses of a user, e.g. ?fields=id,label,lines for a picker",
+		Tags:     []string{"users"},
+		Response: AddressesResponse{},
+		Handler:  s.listAddresses,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/users/{id}/addresses",
+		Summary:  "Add a shipping address to the address book of a user",
+		Tags:     []string{"users"},
+		Request:  AddressRequest{},
+		Response: AddressResponse{},
+		Status:   http.StatusCreated,
+		Handler:  s.addAddress,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPut,
+		Path:     "/users/{id}/addresses/{addressID}",
+		Summary:  "Replace a shipping address of a user",
+		Tags:     []string{"users"},
+		Request:  AddressRequest{},
+		Response: AddressResponse{},
+		Handler:  s.updateAddress,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodDelete,
+		Path:     "/users/{id}/addresses/{addressID}",
+		Summary:  "Remove a shipping address no order ships to",
+		Tags:     []string{"users"},
+		Status:   http.StatusNoContent,
+		Handler:  s.deleteAddress,
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/address-formats/{country}",
+		Summary:  "Get the required fields, postal code format and regions of addresses in a country",
+		Tags:     []string{"addresses"},
+		Response: AddressFormatResponse{},
+		Handler:  s.addressFormat,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/addresses/validate",
+		Summary:  "Validate and normalize an address, e.g. ?from=DE to format its label for a sender in Germany",
+		Tags:     []string{"addresses"},
+		Request:  PostalAddressRequest{},
+		Response: AddressValidationResponse{},
+		Handler:  s.validateAddress,
+	})
+}
+
+func (s *HTTPServer) registerUser(w http.ResponseWriter, r *http.Request) {
+	var req RegisterUserRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	u, err := s.RegisterUser.Handle(r.Context(), command.RegisterUserCommand{
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrEmailTaken) {
+			httpx.WriteErrorCode(w, http.StatusConflict, ErrorCodeEmailTaken, err)
+			return
+		}
+		httpx.WriteError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, toUserResponse(u))
+}
+
+func (s *HTTPServer) login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	result, err := s.Login.Handle(r.Context(), command.LoginCommand{
+		Email:    req.Email,
+		Password: req.Password,
+		IP:       clientIP(r),
+		Device:   r.UserAgent(),
+	})
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	resp := LoginResponse{User: toUserResponse(result.User), StepUpRequired: result.StepUpRequired}
+	for _, anomaly := range result.Anomalies {
+		resp.Anomalies = append(resp.Anomalies, string(anomaly))
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) changePassword(w http.ResponseWriter, r *http.Request) {
+	var req ChangePasswordRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	u, err := s.ChangePassword.Handle(r.Context(), command.ChangePasswordCommand{
+		Email:           req.Email,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	})
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, toUserResponse(u))
+}
+
+// writeLoginError maps the errors of endpoints that check a password
+func writeLoginError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		httpx.WriteErrorCode(w, http.StatusUnauthorized, ErrorCodeInvalidCredentials, err)
+	case errors.Is(err, domain.ErrUserDeactivated):
+		httpx.WriteErrorCode(w, http.StatusForbidden, ErrorCodeUserDeactivated, err)
+	case errors.Is(err, domain.ErrPasswordResetRequired):
+		httpx.WriteErrorCode(w, http.StatusForbidden, ErrorCodePasswordResetRequired, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
+
+func (s *HTTPServer) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	if err := s.RequestPasswordReset.Handle(r.Context(), command.RequestPasswordResetCommand{Email: req.Email}); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *HTTPServer) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	u, err := s.ResetPassword.Handle(r.Context(), command.ResetPasswordCommand{Token: req.Token, NewPassword: req.NewPassword})
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, toUserResponse(u))
+}
+
+func (s *HTTPServer) requestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.ownedUser(w, r, domain.PermissionUserUpdateAny, domain.PermissionUserUpdateOwn)
+	if !ok {
+		return
+	}
+
+	if err := s.RequestEmailVerification.Handle(r.Context(), command.RequestEmailVerificationCommand{UserID: u.ID}); err != nil {
+		writeTokenError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *HTTPServer) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	u, err := s.VerifyEmail.Handle(r.Context(), command.VerifyEmailCommand{Token: req.Token})
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, toUserResponse(u))
+}
+
+// writeTokenError maps the errors of the endpoints that issue or redeem emailed tokens
+func writeTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidToken):
+		httpx.WriteErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidToken, err)
+	case errors.Is(err, domain.ErrEmailAlreadyVerified):
+		httpx.WriteErrorCode(w, http.StatusConflict, ErrorCodeEmailAlreadyVerified, err)
+	case errors.Is(err, domain.ErrUserDeactivated):
+		httpx.WriteErrorCode(w, http.StatusForbidden, ErrorCodeUserDeactivated, err)
+	default:
+		writeUserError(w, err)
+	}
+}
+
+// clientIP is the address of the peer connection
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (s *HTTPServer) getUser(w http.ResponseWriter, r *http.Request) {
+	u, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	if err := auth.CheckOwned(r.Context(), s.Auth, domain.PermissionUserReadAny, domain.PermissionUserReadOwn, u.ID); err != nil {
+		auth.WriteError(w, err)
+		return
+	}
+
+	s.writeMasked(w, r, http.StatusOK, toUserResponse(u), u.ID)
+}
+
+func (s *HTTPServer) listUsers(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	filter := domain.UserSearchFilter{SearchTerm: values.Get("search"), Roles: values["role"]}
+
+	var errs validation.Errors
+	if value := values.Get("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		errs.Check(err == nil, "active", fmt.Sprintf("must be true or false, got %q", value))
+		filter.Active = &active
+	}
+	if value := values.Get("deactivated"); value != "" {
+		deactivated, err := strconv.ParseBool(value)
+		errs.Check(err == nil, "deactivated", fmt.Sprintf("must be true or false, got %q", value))
+		filter.Deactivated = deactivated
+	}
+	sorts, err := query.ParseSort(values.Get("sort"), domain.UserSortColumns...)
+	if err != nil {
+		errs.Add("sort", err.Error())
+	}
+	filter.Sort = sorts
+	page, pageSize := query.ParsePage(values, MaxUserPageSize, errs.Add)
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultUserPageSize
+	}
+	if err := errs.Err(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	users, total, err := s.UserRepo.Search(r.Context(), filter, page, pageSize)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := UsersResponse{Users: make([]UserResponse, len(users)), Total: total, Page: page, PageSize: pageSize}
+	mergedInto, err := s.mergedInto(r, users)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	policy, err := s.Masker.Policy(r.Context())
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	for i := range users {
+		resp.Users[i] = toUserResponse(&users[i])
+		resp.Users[i].MergedInto = mergedInto[users[i].ID]
+		policy.Apply(&resp.Users[i], users[i].ID)
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// mergedInto maps the IDs of merged users to the public ID of the account they were merged into
+func (s *HTTPServer) mergedInto(r *http.Request, users []domain.User) (map[int64]string, error) {
+	var ids []int64
+	for _, u := range users {
+		if u.MergedIntoID != nil {
+			ids = append(ids, *u.MergedIntoID)
+		}
+	}
+	targets, err := s.UserRepo.GetByIDs(r.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	publicIDs := make(map[int64]string, len(targets))
+	for _, t := range targets {
+		publicIDs[t.ID] = t.PublicID
+	}
+	merged := make(map[int64]string, len(ids))
+	for _, u := range users {
+		if u.MergedIntoID != nil {
+			merged[u.ID] = publicIDs[*u.MergedIntoID]
+		}
+	}
+	return merged, nil
+}
+
+func (s *HTTPServer) deactivateUser(w http.ResponseWriter, r *http.Request) {
+	s.manageUser(w, r, func(id int64) (*domain.User, error) {
+		return s.DeactivateUser.Handle(r.Context(), command.DeactivateUserCommand{UserID: id})
+	})
+}
+
+func (s *HTTPServer) reactivateUser(w http.ResponseWriter, r *http.Request) {
+	s.manageUser(w, r, func(id int64) (*domain.User, error) {
+		return s.ReactivateUser.Handle(r.Context(), command.ReactivateUserCommand{UserID: id})
+	})
+}
+
+func (s *HTTPServer) requirePasswordReset(w http.ResponseWriter, r *http.Request) {
+	s.manageUser(w, r, func(id int64) (*domain.User, error) {
+		return s.RequirePasswordReset.Handle(r.Context(), command.RequirePasswordResetCommand{UserID: id})
+	})
+}
+
+func (s *HTTPServer) mergeUser(w http.ResponseWriter, r *http.Request) {
+	target, err := s.mergeTarget(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	s.manageUser(w, r, func(id int64) (*domain.User, error) {
+		report, err := s.MergeUsers.Handle(r.Context(), command.MergeUsersCommand{SourceID: id, TargetID: target.ID})
+		if err != nil {
+			return nil, err
+		}
+		return report.Target, nil
+	})
+}
+
+func (s *HTTPServer) dryRunMergeUser(w http.ResponseWriter, r *http.Request) {
+	target, err := s.mergeTarget(r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	source, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	report, err := s.MergeUsers.Handle(r.Context(), command.MergeUsersCommand{SourceID: source.ID, TargetID: target.ID, DryRun: true})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	resp := MergeReportResponse{Source: source.PublicID, Target: target.PublicID, Items: make([]MergeItemResponse, len(report.Items))}
+	for i, item := range report.Items {
+		resp.Items[i] = MergeItemResponse{Kind: item.Kind, Moved: item.Moved, Conflicts: item.Conflicts}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// mergeTarget reads the account a merge keeps from the MergeUserRequest of r
+func (s *HTTPServer) mergeTarget(r *http.Request) (*domain.User, error) {
+	var req MergeUserRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	target, err := s.UserRepo.GetByPublicID(r.Context(), req.Into)
+	if errors.Is(err, persistence.ErrNotFound) {
+		var errs validation.Errors
+		errs.Add("into", fmt.Sprintf("unknown user %q", req.Into))
+		return nil, errs
+	}
+	return target, err
+}
+
+// manageUser runs an admin command on the user of the {id} path parameter and responds with the
+// user it returns
+func (s *HTTPServer) manageUser(w http.ResponseWriter, r *http.Request, handle func(id int64) (*domain.User, error)) {
+	u, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	u, err = handle(u.ID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	resp := toUserResponse(u)
+	if u.MergedIntoID != nil {
+		mergedInto, err := s.mergedInto(r, []domain.User{*u})
+		if err != nil {
+			httpx.WriteError(w, err)
+			return
+		}
+		resp.MergedInto = mergedInto[u.ID]
+	}
+	s.writeMasked(w, r, http.StatusOK, resp, u.ID)
+}
+
+func (s *HTTPServer) assignRole(w http.ResponseWriter, r *http.Request) {
+	var req AssignRoleRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	u, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	if err := s.AssignRole.Handle(r.Context(), command.AssignRoleCommand{UserID: u.ID, Role: req.Role}); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrRoleNotFound):
+			httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+		default:
+			auth.WriteError(w, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *HTTPServer) updateProfile(w http.ResponseWriter, r *http.Request) {
+	var req UpdateProfileRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	u, ok := s.ownedUser(w, r, domain.PermissionUserUpdateAny, domain.PermissionUserUpdateOwn)
+	if !ok {
+		return
+	}
+
+	u, err := s.UpdateProfile.Handle(r.Context(), command.UpdateProfileCommand{
+		UserID:    u.ID,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Phone:     req.Phone,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	s.writeMasked(w, r, http.StatusOK, toUserResponse(u), u.ID)
+}
+
+func (s *HTTPServer) listAddresses(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.ownedUser(w, r, domain.PermissionUserReadAny, domain.PermissionUserReadOwn)
+	if !ok {
+		return
+	}
+
+	fields, err := dto.ParseFields[AddressResponse](r)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	addresses, err := s.Addresses.ListByUser(r.Context(), u.ID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	s.writeMasked(w, r, http.StatusOK, AddressesResponse{Addresses: dto.Map(addresses, toAddressResponse, fields)}, u.ID)
+}
+
+func (s *HTTPServer) addAddress(w http.ResponseWriter, r *http.Request) {
+	var req AddressRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	u, ok := s.ownedUser(w, r, domain.PermissionUserUpdateAny, domain.PermissionUserUpdateOwn)
+	if !ok {
+		return
+	}
+
+	a, err := s.AddAddress.Handle(r.Context(), command.AddAddressCommand{UserID: u.ID, Address: req.details()})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	s.writeMasked(w, r, http.StatusCreated, toAddressResponse(a), u.ID)
+}
+
+func (s *HTTPServer) updateAddress(w http.ResponseWriter, r *http.Request) {
+	var req AddressRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	u, ok := s.ownedUser(w, r, domain.PermissionUserUpdateAny, domain.PermissionUserUpdateOwn)
+	if !ok {
+		return
+	}
+	addressID, err := s.addressID(r)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	a, err := s.UpdateAddress.Handle(r.Context(), command.UpdateAddressCommand{UserID: u.ID, AddressID: addressID, Address: req.details()})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	s.writeMasked(w, r, http.StatusOK, toAddressResponse(a), u.ID)
+}
+
+func (s *HTTPServer) deleteAddress(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.ownedUser(w, r, domain.PermissionUserUpdateAny, domain.PermissionUserUpdateOwn)
+	if !ok {
+		return
+	}
+	addressID, err := s.addressID(r)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	if err := s.DeleteAddress.Handle(r.Context(), command.DeleteAddressCommand{UserID: u.ID, AddressID: addressID}); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeMasked writes resp with the personal data of ownerID masked for callers who may not see it
+func (s *HTTPServer) writeMasked(w http.ResponseWriter, r *http.Request, status int, resp any, ownerID int64) {
+	policy, err := s.Masker.Policy(r.Context())
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	policy.Apply(&resp, ownerID)
+	httpx.WriteJSON(w, status, resp)
+}
+
+// ownedUser looks up the user of the {id} path parameter and checks the caller may act on it with
+// the any or own permission; it writes the error response and returns false otherwise
+func (s *HTTPServer) ownedUser(w http.ResponseWriter, r *http.Request, anyPerm, ownPerm auth.Permission) (*domain.User, bool) {
+	u, err := s.UserRepo.GetByPublicID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return nil, false
+	}
+	if err := auth.CheckOwned(r.Context(), s.Auth, anyPerm, ownPerm, u.ID); err != nil {
+		auth.WriteError(w, err)
+		return nil, false
+	}
+	return u, true
+}
+
+// addressID maps the public ID of the {addressID} path parameter to its primary key; the commands
+// check the address belongs to the user
+func (s *HTTPServer) addressID(r *http.Request) (int64, error) {
+	a, err := s.Addresses.GetByPublicID(r.Context(), r.PathValue("addressID"))
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			return 0, domain.ErrAddressNotFound
+		}
+		return 0, err
+	}
+	return a.ID, nil
+}
+
+// writeUserError maps user domain errors onto HTTP status codes
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrAddressNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrAddressInUse):
+		httpx.WriteErrorCode(w, http.StatusConflict, ErrorCodeAddressInUse, err)
+	case errors.Is(err, domain.ErrUserMerged), errors.Is(err, domain.ErrMergeIntoSelf):
+		httpx.WriteErrorStatus(w, http.StatusConflict, err)
+	default:
+		httpx.WriteError(w, err)
+	}
+}
+
+func (req AddressRequest) details() domain.AddressDetails {
+	return domain.AddressDetails{
+		Label:   req.Label,
+		Address: req.address(),
+		Phone:   domain.Phone(req.Phone),
+	}
+}
+
+func (req AddressRequest) address() address.Address {
+	return address.Address{
+		Name:       req.Name,
+		Line1:      req.Line1,
+		Line2:      req.Line2,
+		City:       req.City,
+		Region:     req.Region,
+		PostalCode: req.PostalCode,
+		Country:    req.Country,
+	}
+}
+
+func toUserResponse(u *domain.User) UserResponse {
+	return UserResponse{
+		ID:        u.PublicID,
+		Email:     u.Email.String(),
+		Active:    u.Active,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Phone:     u.Phone.String(),
+
+		DeactivatedAt:         u.DeactivatedAt,
+		PasswordResetRequired: u.PasswordResetRequired,
+		EmailVerifiedAt:       u.EmailVerifiedAt,
+	}
+}
+
+func toAddressResponse(a *domain.Address) AddressResponse {
+	return AddressResponse{
+		ID:         a.PublicID,
+		Label:      a.Label,
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone.String(),
+		Lines:      a.Lines(""),
+	}
+}
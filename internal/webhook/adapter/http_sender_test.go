@@ -0,0 +1,53 @@
+package adapter_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sender := adapter.NewHTTPSender(srv.Client())
+	req := domain.Request{
+		URL:       srv.URL,
+		Secret:    "whsec_test",
+		EventID:   "order.placed_ord_1_1",
+		EventType: domain.EventOrderPlaced,
+		Payload:   []byte(`{"id":"order.placed_ord_1_1"}`),
+	}
+
+	code, err := sender.Send(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, req.Payload, body)
+	assert.Equal(t, "order.placed", got.Header.Get(adapter.EventTypeHeader))
+	assert.Equal(t, req.EventID, got.Header.Get(adapter.EventIDHeader))
+
+	timestamp, err := strconv.ParseInt(got.Header.Get(adapter.TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, adapter.Sign("whsec_test", timestamp, body), got.Header.Get(adapter.SignatureHeader))
+	assert.NotEqual(t, adapter.Sign("whsec_other", timestamp, body), got.Header.Get(adapter.SignatureHeader))
+
+	status = http.StatusGone
+	code, err = sender.Send(context.Background(), req)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusGone, code)
+}
@@ -0,0 +1,151 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormEndpointRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEndpointRepository(db *gorm.DB) domain.EndpointRepository {
+	return &GormEndpointRepository{db: db}
+}
+
+func (r *GormEndpointRepository) Create(ctx context.Context, e *domain.Endpoint) error {
+	return persistence.TranslateError(persistence.Conn(ctx, r.db).Create(e).Error)
+}
+
+func (r *GormEndpointRepository) GetByID(ctx context.Context, id int64) (*domain.Endpoint, error) {
+	return r.get(ctx, r.db.Where("id = ?", id))
+}
+
+func (r *GormEndpointRepository) GetByPublicID(ctx context.Context, tenantID, publicID string) (*domain.Endpoint, error) {
+	return r.get(ctx, r.db.Where("tenant_id = ? AND public_id = ?", tenantID, publicID))
+}
+
+func (r *GormEndpointRepository) get(ctx context.Context, cond *gorm.DB) (*domain.Endpoint, error) {
+	var e domain.Endpoint
+	if err := persistence.Conn(ctx, r.db).Where(cond).First(&e).Error; err != nil {
+		err = persistence.TranslateError(err)
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, domain.ErrEndpointNotFound
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *GormEndpointRepository) ListByTenant(ctx context.Context, tenantID string) ([]domain.Endpoint, error) {
+	var endpoints []domain.Endpoint
+	err := persistence.Conn(ctx, r.db).Where("tenant_id = ?", tenantID).Order("id").Find(&endpoints).Error
+	return endpoints, persistence.TranslateError(err)
+}
+
+// ListReceiving filters the subscriptions in Go; they are stored as JSON and tenants have few endpoints
+func (r *GormEndpointRepository) ListReceiving(ctx context.Context, tenantID string, t domain.EventType) ([]domain.Endpoint, error) {
+	var endpoints []domain.Endpoint
+	err := persistence.Conn(ctx, r.db).Where("tenant_id = ? AND disabled_at IS NULL", tenantID).Order("id").Find(&endpoints).Error
+	if err != nil {
+		return nil, persistence.TranslateError(err)
+	}
+
+	receiving := endpoints[:0]
+	for _, e := range endpoints {
+		if e.Receives(t) {
+			receiving = append(receiving, e)
+		}
+	}
+	return receiving, nil
+}
+
+func (r *GormEndpointRepository) UpdateDisabled(ctx context.Context, e *domain.Endpoint) error {
+	err := persistence.Conn(ctx, r.db).Model(e).Update("disabled_at", e.DisabledAt).Error
+	return persistence.TranslateError(err)
+}
+
+// maxDeliveries bounds List when the filter sets no limit
+const maxDeliveries = 100
+
+type GormDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDeliveryRepository(db *gorm.DB) domain.DeliveryRepository {
+	return &GormDeliveryRepository{db: db}
+}
+
+// Create relies on the unique index of endpoint and event to skip events delivered before
+func (r *GormDeliveryRepository) Create(ctx context.Context, d *domain.Delivery) (bool, error) {
+	result := persistence.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(d)
+	if result.Error != nil {
+		return false, persistence.TranslateError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GormDeliveryRepository) GetByID(ctx context.Context, id int64) (*domain.Delivery, error) {
+	return r.get(ctx, r.db.Where("id = ?", id))
+}
+
+func (r *GormDeliveryRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Delivery, error) {
+	return r.get(ctx, r.db.Where("public_id = ?", publicID))
+}
+
+func (r *GormDeliveryRepository) get(ctx context.Context, cond *gorm.DB) (*domain.Delivery, error) {
+	var d domain.Delivery
+	if err := persistence.Conn(ctx, r.db).Where(cond).First(&d).Error; err != nil {
+		err = persistence.TranslateError(err)
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, domain.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *GormDeliveryRepository) Update(ctx context.Context, d *domain.Delivery, a *domain.Attempt) error {
+	err := persistence.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(d).Select("status", "attempts", "next_attempt_at", "delivered_at", "updated_at").Updates(d).Error
+		if err != nil || a == nil {
+			return err
+		}
+		a.DeliveryID = d.ID
+		return tx.Create(a).Error
+	})
+	return persistence.TranslateError(err)
+}
+
+func (r *GormDeliveryRepository) List(ctx context.Context, endpointIDs []int64, filter domain.DeliveryFilter) ([]domain.Delivery, error) {
+	if len(endpointIDs) == 0 {
+		return nil, nil
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = maxDeliveries
+	}
+
+	q := persistence.Conn(ctx, r.db).Where("endpoint_id IN ?", endpointIDs)
+	if filter.EndpointID != 0 {
+		q = q.Where("endpoint_id = ?", filter.EndpointID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+
+	var deliveries []domain.Delivery
+	err := q.Order("created_at DESC, id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, persistence.TranslateError(err)
+}
+
+func (r *GormDeliveryRepository) Attempts(ctx context.Context, deliveryID int64) ([]domain.Attempt, error) {
+	var attempts []domain.Attempt
+	err := persistence.Conn(ctx, r.db).Where("delivery_id = ?", deliveryID).Order("number, id").Find(&attempts).Error
+	return attempts, persistence.TranslateError(err)
+}
@@ -0,0 +1,100 @@
+package adapter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/adapter"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.Endpoint{}, &domain.Delivery{}, &domain.Attempt{}))
+	return db
+}
+
+func createEndpoint(t *testing.T, repo domain.EndpointRepository, tenantID string, events ...domain.EventType) *domain.Endpoint {
+	e, err := domain.NewEndpoint(tenantID, "https://example.com/hooks", events, false)
+	require.NoError(t, err)
+	e.SecretCiphertext = "sealed"
+	require.NoError(t, repo.Create(context.Background(), e))
+	return e
+}
+
+func TestGormEndpointRepository_ListReceiving(t *testing.T) {
+	repo := adapter.NewGormEndpointRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	placed := createEndpoint(t, repo, "acme", domain.EventOrderPlaced, domain.EventStockLow)
+	createEndpoint(t, repo, "acme", domain.EventOrderCancelled)
+	createEndpoint(t, repo, "globex", domain.EventOrderPlaced)
+	disabled := createEndpoint(t, repo, "acme", domain.EventOrderPlaced)
+	disabled.Disable(time.Now())
+	require.NoError(t, repo.UpdateDisabled(ctx, disabled))
+
+	receiving, err := repo.ListReceiving(ctx, "acme", domain.EventOrderPlaced)
+	require.NoError(t, err)
+	require.Len(t, receiving, 1)
+	assert.Equal(t, placed.PublicID, receiving[0].PublicID)
+	assert.Equal(t, []domain.EventType{domain.EventOrderPlaced, domain.EventStockLow}, receiving[0].Events)
+
+	all, err := repo.ListByTenant(ctx, "acme")
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	_, err = repo.GetByPublicID(ctx, "globex", placed.PublicID)
+	assert.ErrorIs(t, err, domain.ErrEndpointNotFound, "endpoints of other tenants are hidden")
+}
+
+func TestGormDeliveryRepository_CreateUpdateAndList(t *testing.T) {
+	db := setupTestDB(t)
+	endpoints := adapter.NewGormEndpointRepository(db)
+	repo := adapter.NewGormDeliveryRepository(db)
+	ctx := context.Background()
+	endpoint := createEndpoint(t, endpoints, "acme", domain.EventOrderPlaced)
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	d := domain.NewDelivery(endpoint.ID, "order.placed_ord_1_1", domain.EventOrderPlaced, []byte(`{}`), at)
+	created, err := repo.Create(ctx, d)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = repo.Create(ctx, domain.NewDelivery(endpoint.ID, "order.placed_ord_1_1", domain.EventOrderPlaced, []byte(`{}`), at))
+	require.NoError(t, err)
+	assert.False(t, created, "an endpoint gets each event once")
+
+	policy := domain.RetryPolicy{MaxAttempts: 2, Backoff: jobs.Backoff{Base: time.Minute, Max: time.Hour}}
+	require.NoError(t, repo.Update(ctx, d, d.Failed(500, errors.New("boom"), time.Second, at, policy)))
+	require.NoError(t, repo.Update(ctx, d, d.Failed(0, errors.New("timeout"), time.Second, at.Add(time.Minute), policy)))
+
+	got, err := repo.GetByPublicID(ctx, d.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryDead, got.Status)
+	assert.Equal(t, 2, got.Attempts)
+	assert.Nil(t, got.NextAttemptAt)
+
+	attempts, err := repo.Attempts(ctx, d.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 500, attempts[0].StatusCode)
+	assert.Equal(t, "timeout", attempts[1].Error)
+
+	dead, err := repo.List(ctx, []int64{endpoint.ID}, domain.DeliveryFilter{Status: domain.DeliveryDead})
+	require.NoError(t, err)
+	assert.Len(t, dead, 1)
+	pending, err := repo.List(ctx, []int64{endpoint.ID}, domain.DeliveryFilter{Status: domain.DeliveryPending})
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	other, err := repo.List(ctx, []int64{endpoint.ID + 1}, domain.DeliveryFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+)
+
+// AttemptDeliveryCommand runs attempt number Attempt of a delivery
+type AttemptDeliveryCommand struct {
+	DeliveryID int64
+	Attempt    int
+}
+
+// AttemptDeliveryHandler owns the retries of deliveries: a failed attempt schedules the next one
+// after the backoff of Policy, so the job running it always succeeds unless storage fails.
+type AttemptDeliveryHandler struct {
+	Endpoints  domain.EndpointRepository
+	Deliveries domain.DeliveryRepository
+	Queue      domain.DeliveryQueue
+	Sender     domain.Sender
+	Keyring    *secret.Keyring
+	Policy     domain.RetryPolicy
+	Now        func() time.Time
+}
+
+func (h *AttemptDeliveryHandler) Handle(ctx context.Context, cmd AttemptDeliveryCommand) error {
+	d, err := h.Deliveries.GetByID(ctx, cmd.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("get delivery %d: %w", cmd.DeliveryID, err)
+	}
+	// A duplicate job, or one of a delivery that was redelivered since, has nothing left to do
+	if d.Status != domain.DeliveryPending || d.Attempts+1 != cmd.Attempt {
+		return nil
+	}
+
+	e, err := h.Endpoints.GetByID(ctx, d.EndpointID)
+	if err != nil {
+		return fmt.Errorf("get endpoint of delivery %s: %w", d.PublicID, err)
+	}
+	if e.DisabledAt != nil {
+		d.DeadLetter()
+		return h.update(ctx, d, nil)
+	}
+
+	now := nowFunc(h.Now)
+	start := now()
+	status, sendErr := h.send(ctx, e, d)
+	duration := now().Sub(start)
+
+	var a *domain.Attempt
+	if sendErr != nil {
+		a = d.Failed(status, sendErr, duration, start, h.Policy)
+		slog.WarnContext(ctx, "webhook delivery failed", "delivery_id", d.PublicID, "attempt", a.Number, "status", d.Status, "error", sendErr)
+	} else {
+		a = d.Succeeded(status, duration, start)
+	}
+
+	// The next attempt is scheduled before the state is stored: should storing fail, this job is
+	// retried and the duplicate of the next attempt is skipped
+	if d.Status == domain.DeliveryPending {
+		if err := h.Queue.Schedule(ctx, d.ID, d.Attempts+1, *d.NextAttemptAt); err != nil {
+			return fmt.Errorf("schedule delivery %s: %w", d.PublicID, err)
+		}
+	}
+	return h.update(ctx, d, a)
+}
+
+func (h *AttemptDeliveryHandler) send(ctx context.Context, e *domain.Endpoint, d *domain.Delivery) (int, error) {
+	signingSecret, err := h.Keyring.Decrypt(e.SecretCiphertext)
+	if err != nil {
+		return 0, fmt.Errorf("decrypt webhook secret: %w", err)
+	}
+	return h.Sender.Send(ctx, domain.Request{
+		URL:       e.URL,
+		Secret:    signingSecret,
+		EventID:   d.EventID,
+		EventType: d.EventType,
+		Payload:   []byte(d.Payload),
+	})
+}
+
+func (h *AttemptDeliveryHandler) update(ctx context.Context, d *domain.Delivery, a *domain.Attempt) error {
+	if err := h.Deliveries.Update(ctx, d, a); err != nil {
+		return fmt.Errorf("update delivery %s: %w", d.PublicID, err)
+	}
+	return nil
+}
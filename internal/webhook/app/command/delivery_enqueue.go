@@ -0,0 +1,48 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+)
+
+// EnqueueDeliveriesCommand delivers an event to every endpoint of a tenant subscribed to it
+type EnqueueDeliveriesCommand struct {
+	TenantID  string
+	EventID   string
+	EventType domain.EventType
+	Payload   []byte
+}
+
+type EnqueueDeliveriesHandler struct {
+	Endpoints  domain.EndpointRepository
+	Deliveries domain.DeliveryRepository
+	Queue      domain.DeliveryQueue
+	Now        func() time.Time
+}
+
+// Handle is idempotent: an endpoint that already has a delivery of the event is skipped
+func (h *EnqueueDeliveriesHandler) Handle(ctx context.Context, cmd EnqueueDeliveriesCommand) error {
+	endpoints, err := h.Endpoints.ListReceiving(ctx, cmd.TenantID, cmd.EventType)
+	if err != nil {
+		return fmt.Errorf("list webhook endpoints of %s: %w", cmd.TenantID, err)
+	}
+
+	now := nowFunc(h.Now)()
+	for _, e := range endpoints {
+		d := domain.NewDelivery(e.ID, cmd.EventID, cmd.EventType, cmd.Payload, now)
+		created, err := h.Deliveries.Create(ctx, d)
+		if err != nil {
+			return fmt.Errorf("create delivery of %s to endpoint %s: %w", cmd.EventID, e.PublicID, err)
+		}
+		if !created {
+			continue
+		}
+		if err := h.Queue.Schedule(ctx, d.ID, 1, now); err != nil {
+			return fmt.Errorf("schedule delivery %s: %w", d.PublicID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+)
+
+// RedeliverCommand retries a dead-lettered delivery of the tenant of the context from its first attempt
+type RedeliverCommand struct {
+	PublicID string
+}
+
+type RedeliverHandler struct {
+	Endpoints  domain.EndpointRepository
+	Deliveries domain.DeliveryRepository
+	Queue      domain.DeliveryQueue
+	Now        func() time.Time
+}
+
+func (h *RedeliverHandler) Handle(ctx context.Context, cmd RedeliverCommand) (*domain.Delivery, error) {
+	d, err := h.Deliveries.GetByPublicID(ctx, cmd.PublicID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := h.Endpoints.GetByID(ctx, d.EndpointID)
+	if err != nil {
+		return nil, fmt.Errorf("get endpoint of delivery %s: %w", d.PublicID, err)
+	}
+	// Deliveries of other tenants are reported as missing rather than forbidden
+	if e.TenantID != tenant.FromContext(ctx) {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	if e.DisabledAt != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrEndpointDisabled, e.PublicID)
+	}
+
+	now := nowFunc(h.Now)()
+	if err := d.Redeliver(now); err != nil {
+		return nil, err
+	}
+	if err := h.Queue.Schedule(ctx, d.ID, 1, now); err != nil {
+		return nil, fmt.Errorf("schedule delivery %s: %w", d.PublicID, err)
+	}
+	if err := h.Deliveries.Update(ctx, d, nil); err != nil {
+		return nil, fmt.Errorf("update delivery %s: %w", d.PublicID, err)
+	}
+	return d, nil
+}
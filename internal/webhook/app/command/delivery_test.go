@@ -0,0 +1,270 @@
+package command
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEndpointRepository keeps endpoints in memory by ID
+type MockEndpointRepository struct {
+	domain.EndpointRepository
+	endpoints map[int64]*domain.Endpoint
+}
+
+func (m *MockEndpointRepository) Create(ctx context.Context, e *domain.Endpoint) error {
+	e.ID = int64(len(m.endpoints) + 1)
+	m.endpoints[e.ID] = e
+	return nil
+}
+
+func (m *MockEndpointRepository) GetByID(ctx context.Context, id int64) (*domain.Endpoint, error) {
+	e, ok := m.endpoints[id]
+	if !ok {
+		return nil, domain.ErrEndpointNotFound
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (m *MockEndpointRepository) GetByPublicID(ctx context.Context, tenantID, publicID string) (*domain.Endpoint, error) {
+	for _, e := range m.endpoints {
+		if e.TenantID == tenantID && e.PublicID == publicID {
+			copied := *e
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrEndpointNotFound
+}
+
+func (m *MockEndpointRepository) ListReceiving(ctx context.Context, tenantID string, t domain.EventType) ([]domain.Endpoint, error) {
+	var receiving []domain.Endpoint
+	for id := int64(1); id <= int64(len(m.endpoints)); id++ {
+		if e := m.endpoints[id]; e.TenantID == tenantID && e.Receives(t) {
+			receiving = append(receiving, *e)
+		}
+	}
+	return receiving, nil
+}
+
+func (m *MockEndpointRepository) UpdateDisabled(ctx context.Context, e *domain.Endpoint) error {
+	m.endpoints[e.ID].DisabledAt = e.DisabledAt
+	return nil
+}
+
+// MockDeliveryRepository keeps deliveries in memory by ID, together with their attempts
+type MockDeliveryRepository struct {
+	domain.DeliveryRepository
+	deliveries map[int64]*domain.Delivery
+	attempts   []domain.Attempt
+}
+
+func (m *MockDeliveryRepository) Create(ctx context.Context, d *domain.Delivery) (bool, error) {
+	for _, existing := range m.deliveries {
+		if existing.EndpointID == d.EndpointID && existing.EventID == d.EventID {
+			return false, nil
+		}
+	}
+	d.ID = int64(len(m.deliveries) + 1)
+	copied := *d
+	m.deliveries[d.ID] = &copied
+	return true, nil
+}
+
+func (m *MockDeliveryRepository) GetByID(ctx context.Context, id int64) (*domain.Delivery, error) {
+	d, ok := m.deliveries[id]
+	if !ok {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *MockDeliveryRepository) GetByPublicID(ctx context.Context, publicID string) (*domain.Delivery, error) {
+	for _, d := range m.deliveries {
+		if d.PublicID == publicID {
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrDeliveryNotFound
+}
+
+func (m *MockDeliveryRepository) Update(ctx context.Context, d *domain.Delivery, a *domain.Attempt) error {
+	copied := *d
+	m.deliveries[d.ID] = &copied
+	if a != nil {
+		m.attempts = append(m.attempts, *a)
+	}
+	return nil
+}
+
+type scheduledAttempt struct {
+	DeliveryID int64
+	Attempt    int
+	At         time.Time
+}
+
+// MockDeliveryQueue records the scheduled attempts
+type MockDeliveryQueue struct {
+	scheduled []scheduledAttempt
+}
+
+func (m *MockDeliveryQueue) Schedule(ctx context.Context, deliveryID int64, attempt int, at time.Time) error {
+	m.scheduled = append(m.scheduled, scheduledAttempt{DeliveryID: deliveryID, Attempt: attempt, At: at})
+	return nil
+}
+
+// MockSender answers with the next of its status codes
+type MockSender struct {
+	statuses []int
+	requests []domain.Request
+}
+
+func (m *MockSender) Send(ctx context.Context, req domain.Request) (int, error) {
+	m.requests = append(m.requests, req)
+	status := m.statuses[0]
+	m.statuses = m.statuses[1:]
+	if status < 200 || status > 299 {
+		return status, errors.New("unexpected status")
+	}
+	return status, nil
+}
+
+type webhookFixture struct {
+	endpoints  *MockEndpointRepository
+	deliveries *MockDeliveryRepository
+	queue      *MockDeliveryQueue
+	sender     *MockSender
+	register   *RegisterEndpointHandler
+	enqueue    *EnqueueDeliveriesHandler
+	attempt    *AttemptDeliveryHandler
+	redeliver  *RedeliverHandler
+	now        time.Time
+}
+
+func newWebhookFixture(t *testing.T, statuses ...int) *webhookFixture {
+	keyring, err := secret.NewKeyring("k1:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+
+	f := &webhookFixture{
+		endpoints:  &MockEndpointRepository{endpoints: map[int64]*domain.Endpoint{}},
+		deliveries: &MockDeliveryRepository{deliveries: map[int64]*domain.Delivery{}},
+		queue:      &MockDeliveryQueue{},
+		sender:     &MockSender{statuses: statuses},
+		now:        time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	now := func() time.Time { return f.now }
+	f.register = &RegisterEndpointHandler{Endpoints: f.endpoints, Keyring: keyring, Now: now}
+	f.enqueue = &EnqueueDeliveriesHandler{Endpoints: f.endpoints, Deliveries: f.deliveries, Queue: f.queue, Now: now}
+	f.attempt = &AttemptDeliveryHandler{
+		Endpoints:  f.endpoints,
+		Deliveries: f.deliveries,
+		Queue:      f.queue,
+		Sender:     f.sender,
+		Keyring:    keyring,
+		Policy:     domain.RetryPolicy{MaxAttempts: 3, Backoff: jobs.Backoff{Base: time.Minute, Max: time.Hour}},
+		Now:        now,
+	}
+	f.redeliver = &RedeliverHandler{Endpoints: f.endpoints, Deliveries: f.deliveries, Queue: f.queue, Now: now}
+	return f
+}
+
+// runScheduled runs the attempts scheduled so far, oldest first
+func (f *webhookFixture) runScheduled(t *testing.T, ctx context.Context) {
+	for len(f.queue.scheduled) > 0 {
+		next := f.queue.scheduled[0]
+		f.queue.scheduled = f.queue.scheduled[1:]
+		f.now = next.At
+		require.NoError(t, f.attempt.Handle(ctx, AttemptDeliveryCommand{DeliveryID: next.DeliveryID, Attempt: next.Attempt}))
+	}
+}
+
+func TestWebhookDelivery_SignsAndSucceeds(t *testing.T) {
+	f := newWebhookFixture(t, 200)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	registered, err := f.register.Handle(ctx, RegisterEndpointCommand{URL: "https://example.com/hooks", Events: []domain.EventType{domain.EventOrderPlaced}})
+	require.NoError(t, err)
+	assert.Contains(t, registered.Secret, domain.SecretPrefix)
+	assert.NotContains(t, registered.Endpoint.SecretCiphertext, registered.Secret, "the secret is stored encrypted")
+
+	cmd := EnqueueDeliveriesCommand{TenantID: "acme", EventID: "evt_1", EventType: domain.EventOrderPlaced, Payload: []byte(`{"id":"evt_1"}`)}
+	require.NoError(t, f.enqueue.Handle(ctx, cmd))
+	require.NoError(t, f.enqueue.Handle(ctx, cmd), "replayed events are delivered once")
+	require.NoError(t, f.enqueue.Handle(ctx, EnqueueDeliveriesCommand{TenantID: "acme", EventID: "evt_2", EventType: domain.EventStockLow}))
+	require.NoError(t, f.enqueue.Handle(ctx, EnqueueDeliveriesCommand{TenantID: "globex", EventID: "evt_3", EventType: domain.EventOrderPlaced}))
+	require.Len(t, f.queue.scheduled, 1, "only subscribed endpoints of the tenant receive events")
+
+	f.runScheduled(t, ctx)
+	require.Len(t, f.sender.requests, 1)
+	assert.Equal(t, registered.Secret, f.sender.requests[0].Secret)
+	assert.Equal(t, `{"id":"evt_1"}`, string(f.sender.requests[0].Payload))
+
+	d := f.deliveries.deliveries[1]
+	assert.Equal(t, domain.DeliverySucceeded, d.Status)
+	assert.Equal(t, f.now, *d.DeliveredAt)
+	require.Len(t, f.deliveries.attempts, 1)
+	assert.Equal(t, 200, f.deliveries.attempts[0].StatusCode)
+
+	require.NoError(t, f.attempt.Handle(ctx, AttemptDeliveryCommand{DeliveryID: d.ID, Attempt: 1}))
+	assert.Len(t, f.sender.requests, 1, "duplicate jobs are skipped")
+}
+
+func TestWebhookDelivery_RetriesThenDeadLettersAndRedelivers(t *testing.T) {
+	f := newWebhookFixture(t, 500, 502, 503, 204)
+	ctx := tenant.WithID(context.Background(), "acme")
+	start := f.now
+
+	registered, err := f.register.Handle(ctx, RegisterEndpointCommand{URL: "https://example.com/hooks", Events: []domain.EventType{domain.EventOrderCancelled}})
+	require.NoError(t, err)
+	require.NoError(t, f.enqueue.Handle(ctx, EnqueueDeliveriesCommand{TenantID: "acme", EventID: "evt_1", EventType: domain.EventOrderCancelled}))
+	f.runScheduled(t, ctx)
+
+	d := f.deliveries.deliveries[1]
+	assert.Equal(t, domain.DeliveryDead, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	require.Len(t, f.deliveries.attempts, 3)
+	assert.Equal(t, start.Add(time.Minute), f.deliveries.attempts[1].AttemptedAt)
+	assert.Equal(t, start.Add(3*time.Minute), f.deliveries.attempts[2].AttemptedAt, "the backoff doubles")
+
+	_, err = f.redeliver.Handle(tenant.WithID(context.Background(), "globex"), RedeliverCommand{PublicID: d.PublicID})
+	assert.ErrorIs(t, err, domain.ErrDeliveryNotFound)
+
+	_, err = f.redeliver.Handle(ctx, RedeliverCommand{PublicID: d.PublicID})
+	require.NoError(t, err)
+	f.runScheduled(t, ctx)
+	assert.Equal(t, domain.DeliverySucceeded, f.deliveries.deliveries[1].Status)
+	assert.Equal(t, 1, f.deliveries.deliveries[1].Attempts)
+
+	_, err = f.redeliver.Handle(ctx, RedeliverCommand{PublicID: d.PublicID})
+	assert.ErrorIs(t, err, domain.ErrNotDeadLettered)
+
+	require.NoError(t, (&DisableEndpointHandler{Endpoints: f.endpoints}).Handle(ctx, DisableEndpointCommand{PublicID: registered.Endpoint.PublicID}))
+	require.NoError(t, f.enqueue.Handle(ctx, EnqueueDeliveriesCommand{TenantID: "acme", EventID: "evt_2", EventType: domain.EventOrderCancelled}))
+	assert.Empty(t, f.queue.scheduled, "disabled endpoints receive no events")
+}
+
+func TestAttemptDeliveryHandler_DeadLettersDeliveriesOfDisabledEndpoints(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	registered, err := f.register.Handle(ctx, RegisterEndpointCommand{URL: "https://example.com/hooks", Events: []domain.EventType{domain.EventOrderPlaced}})
+	require.NoError(t, err)
+	require.NoError(t, f.enqueue.Handle(ctx, EnqueueDeliveriesCommand{TenantID: "acme", EventID: "evt_1", EventType: domain.EventOrderPlaced}))
+	require.NoError(t, (&DisableEndpointHandler{Endpoints: f.endpoints}).Handle(ctx, DisableEndpointCommand{PublicID: registered.Endpoint.PublicID}))
+
+	f.runScheduled(t, ctx)
+	assert.Empty(t, f.sender.requests)
+	assert.Equal(t, domain.DeliveryDead, f.deliveries.deliveries[1].Status)
+	assert.Empty(t, f.deliveries.attempts)
+}
@@ -0,0 +1,37 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+)
+
+// DisableEndpointCommand stops deliveries to an endpoint of the tenant of the context. Its pending
+// deliveries are dead-lettered when they are next due.
+type DisableEndpointCommand struct {
+	PublicID string
+}
+
+type DisableEndpointHandler struct {
+	Endpoints domain.EndpointRepository
+	Now       func() time.Time
+}
+
+func (h *DisableEndpointHandler) Handle(ctx context.Context, cmd DisableEndpointCommand) error {
+	e, err := h.Endpoints.GetByPublicID(ctx, tenant.FromContext(ctx), cmd.PublicID)
+	if err != nil {
+		return err
+	}
+	if e.DisabledAt != nil {
+		return nil
+	}
+
+	e.Disable(nowFunc(h.Now)())
+	if err := h.Endpoints.UpdateDisabled(ctx, e); err != nil {
+		return fmt.Errorf("disable webhook endpoint %s: %w", e.PublicID, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+)
+
+// RegisterEndpointCommand subscribes a URL of the tenant of the context to events
+type RegisterEndpointCommand struct {
+	URL    string
+	Events []domain.EventType
+}
+
+// RegisteredEndpoint is a new endpoint with its signing secret, which is not shown again
+type RegisteredEndpoint struct {
+	Endpoint *domain.Endpoint
+	Secret   string
+}
+
+type RegisterEndpointHandler struct {
+	Endpoints domain.EndpointRepository
+	// Keyring seals the signing secrets at rest
+	Keyring *secret.Keyring
+	// AllowHTTP accepts plain http URLs, e.g. for receivers on a private network
+	AllowHTTP bool
+	Now       func() time.Time
+}
+
+func (h *RegisterEndpointHandler) Handle(ctx context.Context, cmd RegisterEndpointCommand) (*RegisteredEndpoint, error) {
+	e, err := domain.NewEndpoint(tenant.FromContext(ctx), cmd.URL, cmd.Events, h.AllowHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	signingSecret, err := domain.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	if e.SecretCiphertext, err = h.Keyring.Encrypt(signingSecret); err != nil {
+		return nil, fmt.Errorf("encrypt webhook secret: %w", err)
+	}
+	e.CreatedAt = nowFunc(h.Now)()
+
+	if err := h.Endpoints.Create(ctx, e); err != nil {
+		return nil, fmt.Errorf("create webhook endpoint: %w", err)
+	}
+	return &RegisteredEndpoint{Endpoint: e, Secret: signingSecret}, nil
+}
+
+func nowFunc(now func() time.Time) func() time.Time {
+	if now != nil {
+		return now
+	}
+	return time.Now
+}
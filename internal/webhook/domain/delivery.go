@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+)
+
+var (
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrNotDeadLettered is returned when redelivering a delivery that has not given up
+	ErrNotDeadLettered = errors.New("webhook delivery is not dead-lettered")
+)
+
+// DeliveryPublicIDPrefix starts the public IDs of deliveries, e.g. "whd_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const DeliveryPublicIDPrefix = "whd"
+
+// maxErrorLength bounds the error stored on a delivery attempt
+const maxErrorLength = 255
+
+// DeliveryStatus is how far the delivery of an event to an endpoint got
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliverySucceeded DeliveryStatus = "SUCCEEDED"
+	// DeliveryDead is dead-lettered: every attempt failed or the endpoint was removed. It stays for
+	// inspection and can be redelivered by hand.
+	DeliveryDead DeliveryStatus = "DEAD"
+)
+
+// RetryPolicy bounds the attempts of a delivery and spaces them out with exponential backoff
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     jobs.Backoff
+}
+
+// Delivery is an event on its way to an endpoint. The payload is stored when the event happens,
+// so every attempt sends the same body.
+type Delivery struct {
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the delivery in external APIs so the primary key never leaves the service
+	PublicID   string `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	EndpointID int64  `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	// EventID is the ID of the domain event; an endpoint gets each event once
+	EventID   string         `gorm:"type:varchar(64);not null;uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	EventType EventType      `gorm:"type:varchar(64);not null"`
+	Payload   string         `gorm:"type:text;not null"`
+	Status    DeliveryStatus `gorm:"type:varchar(20);not null;index"`
+	Attempts  int            `gorm:"not null"`
+	// NextAttemptAt is when a pending delivery is tried next
+	NextAttemptAt *time.Time
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time `gorm:"not null;index"`
+	UpdatedAt     time.Time
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// NewDelivery creates the pending delivery of an event to an endpoint, due at once
+func NewDelivery(endpointID int64, eventID string, eventType EventType, payload []byte, at time.Time) *Delivery {
+	return &Delivery{
+		PublicID:      publicid.New(DeliveryPublicIDPrefix),
+		EndpointID:    endpointID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       string(payload),
+		Status:        DeliveryPending,
+		NextAttemptAt: &at,
+		CreatedAt:     at,
+	}
+}
+
+// Attempt is one try to deliver an event; StatusCode is 0 when no response was received
+type Attempt struct {
+	ID         int64 `gorm:"primaryKey"`
+	DeliveryID int64 `gorm:"not null;index"`
+	Number     int   `gorm:"not null"`
+	StatusCode int
+	// Error is why the attempt failed; it is empty for successful attempts
+	Error       string    `gorm:"type:varchar(255)"`
+	DurationMs  int64     `gorm:"not null"`
+	AttemptedAt time.Time `gorm:"not null"`
+}
+
+func (Attempt) TableName() string {
+	return "webhook_attempts"
+}
+
+// Succeeded records an attempt the endpoint acknowledged
+func (d *Delivery) Succeeded(statusCode int, duration time.Duration, at time.Time) *Attempt {
+	d.Attempts++
+	d.Status = DeliverySucceeded
+	d.DeliveredAt = &at
+	d.NextAttemptAt = nil
+	return d.attempt(statusCode, "", duration, at)
+}
+
+// Failed records a failed attempt and schedules the next one, or dead-letters the delivery once
+// policy.MaxAttempts attempts failed
+func (d *Delivery) Failed(statusCode int, cause error, duration time.Duration, at time.Time, policy RetryPolicy) *Attempt {
+	d.Attempts++
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = DeliveryDead
+		d.NextAttemptAt = nil
+	} else {
+		next := at.Add(policy.Backoff.Delay(d.Attempts))
+		d.NextAttemptAt = &next
+	}
+	return d.attempt(statusCode, cause.Error(), duration, at)
+}
+
+// DeadLetter gives up on a pending delivery without another attempt, e.g. after its endpoint was removed
+func (d *Delivery) DeadLetter() {
+	d.Status = DeliveryDead
+	d.NextAttemptAt = nil
+}
+
+// Redeliver makes a dead-lettered delivery pending again with a fresh set of attempts
+func (d *Delivery) Redeliver(at time.Time) error {
+	if d.Status != DeliveryDead {
+		return fmt.Errorf("%w: delivery %s is %s", ErrNotDeadLettered, d.PublicID, d.Status)
+	}
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = &at
+	return nil
+}
+
+func (d *Delivery) attempt(statusCode int, cause string, duration time.Duration, at time.Time) *Attempt {
+	if len(cause) > maxErrorLength {
+		cause = cause[:maxErrorLength]
+	}
+	return &Attempt{
+		DeliveryID:  d.ID,
+		Number:      d.Attempts,
+		StatusCode:  statusCode,
+		Error:       cause,
+		DurationMs:  duration.Milliseconds(),
+		AttemptedAt: at,
+	}
+}
+
+// DeliveryFilter narrows the deliveries returned by List; zero fields match every delivery
+type DeliveryFilter struct {
+	EndpointID int64
+	Status     DeliveryStatus
+	Limit      int
+}
+
+type DeliveryRepository interface {
+	// Create stores the delivery unless its endpoint already has one for the event, which it
+	// reports with created false
+	Create(ctx context.Context, d *Delivery) (created bool, err error)
+	GetByID(ctx context.Context, id int64) (*Delivery, error)
+	GetByPublicID(ctx context.Context, publicID string) (*Delivery, error)
+	// Update stores the state of the delivery, together with the attempt that changed it when a is not nil
+	Update(ctx context.Context, d *Delivery, a *Attempt) error
+	// List returns the deliveries of the given endpoints matching filter, newest first
+	List(ctx context.Context, endpointIDs []int64, filter DeliveryFilter) ([]Delivery, error)
+	// Attempts returns the attempts of a delivery, oldest first
+	Attempts(ctx context.Context, deliveryID int64) ([]Attempt, error)
+}
+
+// Request is a signed event posted to an endpoint
+type Request struct {
+	URL       string
+	Secret    string
+	EventID   string
+	EventType EventType
+	Payload   []byte
+}
+
+// Sender posts events to endpoints. It returns the status code of the response, 0 when none was
+// received, and an error for anything but a 2xx response.
+type Sender interface {
+	Send(ctx context.Context, req Request) (statusCode int, err error)
+}
+
+// DeliveryQueue schedules the attempts of deliveries
+type DeliveryQueue interface {
+	// Schedule runs attempt number attempt of a delivery at the given time
+	Schedule(ctx context.Context, deliveryID int64, attempt int, at time.Time) error
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/publicid"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	// ErrEndpointDisabled is returned when redelivering to an endpoint that was removed
+	ErrEndpointDisabled = errors.New("webhook endpoint is disabled")
+)
+
+// EndpointPublicIDPrefix starts the public IDs of endpoints, e.g. "whk_01HXM3Q6Z9V4S8T2K7N1B5C0DE"
+const EndpointPublicIDPrefix = "whk"
+
+// SecretPrefix starts the signing secrets of endpoints, e.g. "whsec_3f9a..."
+const SecretPrefix = "whsec_"
+
+// EventType names an event endpoints can subscribe to
+type EventType string
+
+const (
+	EventOrderPlaced    EventType = "order.placed"
+	EventOrderCancelled EventType = "order.cancelled"
+	EventStockLow       EventType = "product.stock_low"
+)
+
+// EventTypes lists the events endpoints can subscribe to
+var EventTypes = []EventType{EventOrderPlaced, EventOrderCancelled, EventStockLow}
+
+// Endpoint is a URL a tenant registered to receive events at. Payloads are signed with the
+// endpoint's secret, which is only shown once when the endpoint is registered.
+type Endpoint struct {
+	ID int64 `gorm:"primaryKey"`
+	// PublicID identifies the endpoint in external APIs so the primary key never leaves the service
+	PublicID string      `gorm:"type:varchar(32);uniqueIndex;default:null"`
+	TenantID string      `gorm:"type:varchar(64);not null;index"`
+	URL      string      `gorm:"type:varchar(2048);not null"`
+	Events   []EventType `gorm:"serializer:json;type:text;not null"`
+	// SecretCiphertext is the signing secret sealed by secret.Keyring; it is never returned by the API
+	SecretCiphertext string `gorm:"type:text;not null"`
+	// DisabledAt is when the endpoint was removed; disabled endpoints receive no more events
+	DisabledAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (Endpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// NewEndpoint validates the URL and events of an endpoint of a tenant. URLs must use https unless
+// allowHTTP is set.
+func NewEndpoint(tenantID, rawURL string, events []EventType, allowHTTP bool) (*Endpoint, error) {
+	rawURL = strings.TrimSpace(rawURL)
+
+	var errs validation.Errors
+	u, err := url.Parse(rawURL)
+	switch {
+	case rawURL == "":
+		errs.Add("url", "is required")
+	case err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http"):
+		errs.Add("url", "must be an absolute http or https URL")
+	case u.Scheme == "http" && !allowHTTP:
+		errs.Add("url", "must use https")
+	case u.User != nil:
+		errs.Add("url", "must not contain credentials")
+	}
+	errs.Check(len(rawURL) <= 2048, "url", "must be at most 2048 characters")
+
+	errs.Check(len(events) > 0, "events", "must name at least one event")
+	var unique []EventType
+	for i, e := range events {
+		errs.Check(slices.Contains(EventTypes, e), fmt.Sprintf("events[%d]", i), fmt.Sprintf("must be one of %v, got %q", EventTypes, e))
+		if !slices.Contains(unique, e) {
+			unique = append(unique, e)
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Endpoint{
+		PublicID: publicid.New(EndpointPublicIDPrefix),
+		TenantID: tenantID,
+		URL:      rawURL,
+		Events:   unique,
+	}, nil
+}
+
+// Receives reports whether the endpoint is active and subscribed to events of type t
+func (e *Endpoint) Receives(t EventType) bool {
+	return e.DisabledAt == nil && slices.Contains(e.Events, t)
+}
+
+// Disable stops deliveries to the endpoint
+func (e *Endpoint) Disable(at time.Time) {
+	if e.DisabledAt == nil {
+		e.DisabledAt = &at
+	}
+}
+
+// NewSecret generates a signing secret for an endpoint
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return SecretPrefix + hex.EncodeToString(b), nil
+}
+
+type EndpointRepository interface {
+	Create(ctx context.Context, e *Endpoint) error
+	GetByID(ctx context.Context, id int64) (*Endpoint, error)
+	// GetByPublicID returns ErrEndpointNotFound for endpoints of other tenants
+	GetByPublicID(ctx context.Context, tenantID, publicID string) (*Endpoint, error)
+	// ListByTenant returns the endpoints of a tenant, oldest first, disabled ones included
+	ListByTenant(ctx context.Context, tenantID string) ([]Endpoint, error)
+	// ListReceiving returns the active endpoints of a tenant subscribed to events of type t
+	ListReceiving(ctx context.Context, tenantID string, t EventType) ([]Endpoint, error)
+	UpdateDisabled(ctx context.Context, e *Endpoint) error
+}
@@ -0,0 +1,138 @@
+package port
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// Envelope is the body of every webhook; Data depends on Type. ID is the ID of the domain event,
+// so receivers can drop events they got before.
+type Envelope struct {
+	ID        string           `json:"id"`
+	Type      domain.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	// Sandbox is set for events of test data
+	Sandbox bool `json:"sandbox"`
+	Data    any  `json:"data"`
+}
+
+// OrderPlacedData is the data of order.placed webhooks
+type OrderPlacedData struct {
+	OrderID     string       `json:"order_id"`
+	OrderNumber string       `json:"order_number"`
+	UserID      string       `json:"user_id"`
+	ProductID   string       `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Quantity    int          `json:"quantity"`
+	Amount      *money.Money `json:"amount,omitempty"`
+	PlacedAt    time.Time    `json:"placed_at"`
+}
+
+// OrderCancelledData is the data of order.cancelled webhooks
+type OrderCancelledData struct {
+	OrderID     string    `json:"order_id"`
+	UserID      string    `json:"user_id"`
+	ProductID   string    `json:"product_id"`
+	Quantity    int       `json:"quantity"`
+	Reason      string    `json:"reason,omitempty"`
+	Restocked   bool      `json:"restocked"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+// StockLowData is the data of product.stock_low webhooks
+type StockLowData struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"threshold"`
+}
+
+// EventServer turns domain events into deliveries to the endpoints of the tenant the event
+// happened in. The payload is rendered now, so retries send what the event looked like.
+type EventServer struct {
+	EnqueueDeliveries decorator.CommandHandler[command.EnqueueDeliveriesCommand]
+}
+
+// Subscribe registers the webhook subscribers on the event bus
+func (s *EventServer) Subscribe(bus *event.Bus) {
+	bus.Subscribe(orderDomain.OrderPlacedEvent, s.orderPlaced)
+	bus.Subscribe(orderDomain.OrderCancelledEvent, s.orderCancelled)
+	bus.Subscribe(productDomain.StockLowEvent, s.stockLow)
+}
+
+func (s *EventServer) orderPlaced(ctx context.Context, e event.Event) error {
+	placed, ok := e.(orderDomain.OrderPlaced)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	data := OrderPlacedData{
+		OrderID:     placed.OrderPublicID,
+		OrderNumber: placed.OrderNumber,
+		UserID:      placed.UserPublicID,
+		ProductID:   placed.ProductPublicID,
+		ProductName: placed.ProductName,
+		Quantity:    placed.Quantity,
+		PlacedAt:    placed.PlacedAt,
+	}
+	if !placed.Amount.IsZero() {
+		data.Amount = &placed.Amount
+	}
+	return s.deliver(ctx, e, domain.EventOrderPlaced, placed.PlacedAt, placed.Sandbox, data)
+}
+
+func (s *EventServer) orderCancelled(ctx context.Context, e event.Event) error {
+	cancelled, ok := e.(orderDomain.OrderCancelled)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	return s.deliver(ctx, e, domain.EventOrderCancelled, cancelled.CancelledAt, cancelled.Sandbox, OrderCancelledData{
+		OrderID:     cancelled.OrderPublicID,
+		UserID:      cancelled.UserPublicID,
+		ProductID:   cancelled.ProductPublicID,
+		Quantity:    cancelled.Quantity,
+		Reason:      cancelled.Reason,
+		Restocked:   cancelled.Restocked,
+		CancelledAt: cancelled.CancelledAt,
+	})
+}
+
+func (s *EventServer) stockLow(ctx context.Context, e event.Event) error {
+	low, ok := e.(productDomain.StockLow)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", e)
+	}
+
+	return s.deliver(ctx, e, domain.EventStockLow, low.DetectedAt, mode.FromContext(ctx).IsSandbox(), StockLowData{
+		ProductID: low.ProductPublicID,
+		Name:      low.Name,
+		Stock:     low.Stock,
+		Threshold: low.Threshold,
+	})
+}
+
+func (s *EventServer) deliver(ctx context.Context, e event.Event, t domain.EventType, at time.Time, sandbox bool, data any) error {
+	payload, err := json.Marshal(Envelope{ID: e.EventID(), Type: t, CreatedAt: at, Sandbox: sandbox, Data: data})
+	if err != nil {
+		return fmt.Errorf("encode %s webhook: %w", t, err)
+	}
+	return s.EnqueueDeliveries.Handle(ctx, command.EnqueueDeliveriesCommand{
+		TenantID:  tenant.FromContext(ctx),
+		EventID:   e.EventID(),
+		EventType: t,
+		Payload:   payload,
+	})
+}
@@ -0,0 +1,327 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/validation"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// RegisterEndpointRequest is the body of POST /webhooks/endpoints
+type RegisterEndpointRequest struct {
+	URL    string             `json:"url"`
+	Events []domain.EventType `json:"events"`
+}
+
+// EndpointResponse is the public representation of an endpoint; Secret is only set when it is registered
+type EndpointResponse struct {
+	ID         string             `json:"id"`
+	URL        string             `json:"url"`
+	Events     []domain.EventType `json:"events"`
+	Secret     string             `json:"secret,omitempty"`
+	DisabledAt *time.Time         `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// EndpointListResponse lists the endpoints of the tenant, oldest first
+type EndpointListResponse struct {
+	Endpoints []EndpointResponse `json:"endpoints"`
+}
+
+// AttemptResponse is one try to deliver an event; StatusCode is 0 when the endpoint did not answer
+type AttemptResponse struct {
+	Number      int       `json:"number"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// DeliveryResponse is the public representation of a delivery; Payload is only set on single deliveries
+type DeliveryResponse struct {
+	ID            string                `json:"id"`
+	EndpointID    string                `json:"endpoint_id"`
+	EventID       string                `json:"event_id"`
+	EventType     domain.EventType      `json:"event_type"`
+	Status        domain.DeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	Payload       string                `json:"payload,omitempty"`
+	History       []AttemptResponse     `json:"history,omitempty"`
+}
+
+// DeliveryListResponse lists deliveries, newest first
+type DeliveryListResponse struct {
+	Deliveries []DeliveryResponse `json:"deliveries"`
+}
+
+// HTTPServer exposes the management of webhook endpoints and their delivery history over HTTP.
+// Every route acts on the endpoints of the tenant of the request.
+type HTTPServer struct {
+	RegisterEndpoint decorator.CommandResultHandler[command.RegisterEndpointCommand, *command.RegisteredEndpoint]
+	DisableEndpoint  decorator.CommandHandler[command.DisableEndpointCommand]
+	Redeliver        decorator.CommandResultHandler[command.RedeliverCommand, *domain.Delivery]
+	Endpoints        domain.EndpointRepository
+	Deliveries       domain.DeliveryRepository
+
+	// Auth restricts webhooks to staff; nil disables access control
+	Auth auth.Authorizer
+}
+
+// RegisterRoutes adds the webhook endpoints to the router
+func (s *HTTPServer) RegisterRoutes(r *httpx.Router) {
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/webhooks/endpoints",
+		Summary:  "Register a URL to receive signed events; the signing secret is only returned now",
+		Tags:     []string{"webhooks"},
+		Request:  RegisterEndpointRequest{},
+		Response: EndpointResponse{},
+		Status:   http.StatusCreated,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionWebhookManage, s.registerEndpoint),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/webhooks/endpoints",
+		Summary:  "List the webhook endpoints",
+		Tags:     []string{"webhooks"},
+		Response: EndpointListResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionWebhookManage, s.listEndpoints),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodDelete,
+		Path:     "/webhooks/endpoints/{id}",
+		Summary:  "Stop delivering events to an endpoint; its pending deliveries are dead-lettered",
+		Tags:     []string{"webhooks"},
+		Status:   http.StatusNoContent,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionWebhookManage, s.disableEndpoint),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/webhooks/deliveries",
+		Summary:  "List webhook deliveries, e.g. ?endpoint_id=whk_...&status=DEAD&limit=50",
+		Tags:     []string{"webhooks"},
+		Response: DeliveryListResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionWebhookManage, s.listDeliveries),
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodGet,
+		Path:     "/webhooks/deliveries/{id}",
+		Summary:  "Get a webhook delivery with its payload and attempts",
+		Tags:     []string{"webhooks"},
+		Response: DeliveryResponse{},
+		Handler:  auth.Require(s.Auth, userDomain.PermissionWebhookManage, s.getDelivery),
+		StringID: true,
+	})
+	r.Handle(httpx.Route{
+		Method:   http.MethodPost,
+		Path:     "/webhooks/deliveries/{id}/retry",
+		Summary:  "Redeliver a dead-lettered webhook delivery",
+		Tags:     []string{"webhooks"},
+		Response: DeliveryResponse{},
+		Status:   http.StatusAccepted,
+		Handler:  auth.Require(s.Auth, userDomain.PermissionWebhookManage, s.redeliver),
+		StringID: true,
+	})
+}
+
+func (s *HTTPServer) registerEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req RegisterEndpointRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	registered, err := s.RegisterEndpoint.Handle(r.Context(), command.RegisterEndpointCommand{URL: req.URL, Events: req.Events})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := toEndpointResponse(registered.Endpoint)
+	resp.Secret = registered.Secret
+	httpx.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (s *HTTPServer) listEndpoints(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := s.Endpoints.ListByTenant(r.Context(), tenant.FromContext(r.Context()))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := EndpointListResponse{Endpoints: make([]EndpointResponse, len(endpoints))}
+	for i := range endpoints {
+		resp.Endpoints[i] = toEndpointResponse(&endpoints[i])
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) disableEndpoint(w http.ResponseWriter, r *http.Request) {
+	if err := s.DisableEndpoint.Handle(r.Context(), command.DisableEndpointCommand{PublicID: r.PathValue("id")}); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *HTTPServer) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var errs validation.Errors
+	filter := domain.DeliveryFilter{Status: domain.DeliveryStatus(query.Get("status")), Limit: defaultLimit}
+	switch filter.Status {
+	case "", domain.DeliveryPending, domain.DeliverySucceeded, domain.DeliveryDead:
+	default:
+		errs.Add("status", fmt.Sprintf("must be one of %s, %s or %s, got %q", domain.DeliveryPending, domain.DeliverySucceeded, domain.DeliveryDead, filter.Status))
+	}
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxLimit {
+			errs.Add("limit", fmt.Sprintf("must be between 1 and %d, got %q", maxLimit, value))
+		}
+		filter.Limit = n
+	}
+	if err := errs.Err(); err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	// Deliveries are listed through the endpoints of the tenant, which also resolves their public IDs
+	endpoints, err := s.Endpoints.ListByTenant(ctx, tenant.FromContext(ctx))
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	endpointID := query.Get("endpoint_id")
+	publicIDs := make(map[int64]string, len(endpoints))
+	ids := make([]int64, 0, len(endpoints))
+	for _, e := range endpoints {
+		publicIDs[e.ID] = e.PublicID
+		ids = append(ids, e.ID)
+		if e.PublicID == endpointID {
+			filter.EndpointID = e.ID
+		}
+	}
+	if endpointID != "" && filter.EndpointID == 0 {
+		writeWebhookError(w, domain.ErrEndpointNotFound)
+		return
+	}
+
+	deliveries, err := s.Deliveries.List(ctx, ids, filter)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := DeliveryListResponse{Deliveries: make([]DeliveryResponse, len(deliveries))}
+	for i := range deliveries {
+		resp.Deliveries[i] = toDeliveryResponse(&deliveries[i], publicIDs[deliveries[i].EndpointID])
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) getDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	d, err := s.Deliveries.GetByPublicID(ctx, r.PathValue("id"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	e, err := s.Endpoints.GetByID(ctx, d.EndpointID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	// Deliveries of other tenants are reported as missing rather than forbidden
+	if e.TenantID != tenant.FromContext(ctx) {
+		writeWebhookError(w, domain.ErrDeliveryNotFound)
+		return
+	}
+	attempts, err := s.Deliveries.Attempts(ctx, d.ID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+
+	resp := toDeliveryResponse(d, e.PublicID)
+	resp.Payload = d.Payload
+	resp.History = make([]AttemptResponse, len(attempts))
+	for i, a := range attempts {
+		resp.History[i] = AttemptResponse{Number: a.Number, StatusCode: a.StatusCode, Error: a.Error, DurationMs: a.DurationMs, AttemptedAt: a.AttemptedAt}
+	}
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (s *HTTPServer) redeliver(w http.ResponseWriter, r *http.Request) {
+	d, err := s.Redeliver.Handle(r.Context(), command.RedeliverCommand{PublicID: r.PathValue("id")})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	e, err := s.Endpoints.GetByID(r.Context(), d.EndpointID)
+	if err != nil {
+		httpx.WriteError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusAccepted, toDeliveryResponse(d, e.PublicID))
+}
+
+func toEndpointResponse(e *domain.Endpoint) EndpointResponse {
+	return EndpointResponse{
+		ID:         e.PublicID,
+		URL:        e.URL,
+		Events:     e.Events,
+		DisabledAt: e.DisabledAt,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+func toDeliveryResponse(d *domain.Delivery, endpointPublicID string) DeliveryResponse {
+	return DeliveryResponse{
+		ID:            d.PublicID,
+		EndpointID:    endpointPublicID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		DeliveredAt:   d.DeliveredAt,
+		CreatedAt:     d.CreatedAt,
+	}
+}
+
+// writeWebhookError maps webhook domain errors onto HTTP status codes
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrEndpointNotFound), errors.Is(err, domain.ErrDeliveryNotFound):
+		httpx.WriteErrorStatus(w, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrNotDeadLettered), errors.Is(err, domain.ErrEndpointDisabled):
+		httpx.WriteErrorStatus(w, http.StatusConflict, err)
+	case errors.Is(err, secret.ErrNoKeys):
+		httpx.WriteErrorStatus(w, http.StatusServiceUnavailable, errors.New("webhook secret encryption is not configured; set ENCRYPTION_KEYS"))
+	default:
+		httpx.WriteError(w, err)
+	}
+}
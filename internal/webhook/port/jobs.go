@@ -0,0 +1,50 @@
+package port
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/app/command"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+// Enqueuer is the part of jobs.Queue the delivery queue needs
+type Enqueuer interface {
+	Enqueue(ctx context.Context, job jobs.Job, opts ...jobs.EnqueueOption) error
+}
+
+// AttemptDeliveryJob runs one attempt of a webhook delivery; the command schedules the next
+// attempt itself, so the job is only retried by the worker when storage fails
+type AttemptDeliveryJob struct {
+	DeliveryID int64 `json:"delivery_id"`
+	Attempt    int   `json:"attempt"`
+}
+
+func (AttemptDeliveryJob) Kind() string {
+	return "webhook.attempt_delivery"
+}
+
+// JobDeliveryQueue runs the attempts of deliveries as jobs. The jobs are deduplicated by the time
+// they are due, so an attempt is scheduled once while a redelivery can schedule its attempts again.
+type JobDeliveryQueue struct {
+	Jobs Enqueuer
+}
+
+func (q JobDeliveryQueue) Schedule(ctx context.Context, deliveryID int64, attempt int, at time.Time) error {
+	return q.Jobs.Enqueue(ctx, AttemptDeliveryJob{DeliveryID: deliveryID, Attempt: attempt},
+		jobs.RunAt(at), jobs.WithUniqueKey(fmt.Sprintf("webhook-delivery-%d-%d-%d", deliveryID, attempt, at.UnixMilli())))
+}
+
+// JobServer runs the webhook use cases triggered by background jobs
+type JobServer struct {
+	AttemptDelivery decorator.CommandHandler[command.AttemptDeliveryCommand]
+}
+
+// RegisterJobs adds the webhook job handlers to the worker
+func (s *JobServer) RegisterJobs(w *jobs.Worker) {
+	jobs.Register(w, func(ctx context.Context, job AttemptDeliveryJob) error {
+		return s.AttemptDelivery.Handle(ctx, command.AttemptDeliveryCommand{DeliveryID: job.DeliveryID, Attempt: job.Attempt})
+	})
+}
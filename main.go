@@ -1,10 +1,136 @@
 package main
 
+//go:generate go run ./cmd/handlergen
+//go:generate go run ./cmd/openapi -out api/openapi.json
+
 import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	auditAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/audit/adapter"
+	auditPort "github.com/mohsenjafari-aiio/aiiobackend/internal/audit/port"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/app"
+	asyncAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/async/adapter"
+	asyncCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/async/app/command"
+	asyncPort "github.com/mohsenjafari-aiio/aiiobackend/internal/async/port"
+	billingAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/adapter"
+	billingCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/app/command"
+	billingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/domain"
+	billingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/billing/port"
+	checkoutAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/adapter"
+	checkoutCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/app/command"
+	checkoutDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/domain"
+	checkoutPort "github.com/mohsenjafari-aiio/aiiobackend/internal/checkout/port"
 	"github.com/mohsenjafari-aiio/aiiobackend/internal/config"
+	couponAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/adapter"
+	couponCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/app/command"
+	couponPort "github.com/mohsenjafari-aiio/aiiobackend/internal/coupon/port"
+	credentialAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/credential/adapter"
+	credentialCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/credential/app/command"
+	credentialDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/credential/domain"
+	credentialPort "github.com/mohsenjafari-aiio/aiiobackend/internal/credential/port"
+	deliveryAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/adapter"
+	deliveryCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/app/command"
+	deliveryDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/domain"
+	deliveryPort "github.com/mohsenjafari-aiio/aiiobackend/internal/delivery/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/graphql"
+	notificationAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/notification/adapter"
+	notificationCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/notification/app/command"
+	notificationDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/notification/domain"
+	notificationPort "github.com/mohsenjafari-aiio/aiiobackend/internal/notification/port"
+	orderAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/order/adapter"
+	orderCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/command"
+	orderQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/order/app/query"
+	orderDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/order/domain"
+	orderPort "github.com/mohsenjafari-aiio/aiiobackend/internal/order/port"
+	paymentAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/adapter"
+	paymentCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/app/command"
+	paymentDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/domain"
+	paymentPort "github.com/mohsenjafari-aiio/aiiobackend/internal/payment/port"
+	portabilityAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/portability/adapter"
+	portabilityCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/portability/app/command"
+	portabilityPort "github.com/mohsenjafari-aiio/aiiobackend/internal/portability/port"
+	productAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/product/adapter"
+	productCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/command"
+	productQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/product/app/query"
+	productDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/product/domain"
+	productPort "github.com/mohsenjafari-aiio/aiiobackend/internal/product/port"
+	quotaAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/adapter"
+	quotaDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/domain"
+	quotaPort "github.com/mohsenjafari-aiio/aiiobackend/internal/quota/port"
+	reportingAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/adapter"
+	reportingCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/app/command"
+	reportingQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/app/query"
+	reportingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/reporting/port"
+	reviewAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/review/adapter"
+	reviewCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/review/app/command"
+	reviewQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/review/app/query"
+	reviewPort "github.com/mohsenjafari-aiio/aiiobackend/internal/review/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/seed"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/server"
+	settingAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/setting/adapter"
+	settingCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/setting/app/command"
+	settingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/setting/domain"
+	settingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/setting/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/auth"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/bootstrap"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/buildinfo"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/cache"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/canary"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/dto"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/event"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/exchange"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/httpx"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/jobs"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/lifecycle"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/logging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/messaging"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/metrics"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/middleware"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/migration"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/mode"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/money"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/projection"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/repair"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/saga"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/secret"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/selftest"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/temporal"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tenant"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/tracing"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/warmup"
+	shippingAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/adapter"
+	shippingCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/app/command"
+	shippingDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/domain"
+	shippingPort "github.com/mohsenjafari-aiio/aiiobackend/internal/shipping/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/internal/storage"
+	supportAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/support/adapter"
+	supportQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/support/app/query"
+	supportPort "github.com/mohsenjafari-aiio/aiiobackend/internal/support/port"
+	syncQuery "github.com/mohsenjafari-aiio/aiiobackend/internal/sync/app/query"
+	syncPort "github.com/mohsenjafari-aiio/aiiobackend/internal/sync/port"
+	userAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/user/adapter"
+	userCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/user/app/command"
+	userDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/user/domain"
+	userPort "github.com/mohsenjafari-aiio/aiiobackend/internal/user/port"
+	webhookAdapter "github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/adapter"
+	webhookCommand "github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/app/command"
+	webhookDomain "github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/domain"
+	webhookPort "github.com/mohsenjafari-aiio/aiiobackend/internal/webhook/port"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
 )
 
 func main() {
@@ -13,8 +139,41 @@ func main() {
 		log.Println("No .env file found, using default values")
 	}
 
+	// Settings come from the environment and the optional CONFIG_FILE; an invalid one stops the start
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Structured logging; records logged with a request context carry its trace ID, and every
+	// record names the build so error reports can be traced to the deployed version
+	build := buildinfo.Read(cfg.Features()...)
+	loggingConfig := cfg.Logging
+	slog.SetDefault(logging.New(os.Stdout, loggingConfig.Format, loggingConfig.Level).With(build.LogAttrs()...))
+	slog.Info("starting aiiobackend", "version", build.Version, "commit", build.Commit, "build_time", build.BuildTime,
+		"go_version", build.GoVersion, "profile", cfg.Profile, "features", build.Features)
+	log.Printf("Configuration of profile %s:\n%s", cfg.Profile, cfg.Redacted())
+
+	// Components register how they stop as they start; they stop in reverse order when main returns
+	// or a signal drains the server
+	shutdown := lifecycle.New()
+	defer shutdown.Shutdown(context.Background())
+
+	// Tracing exports spans over OTLP when an endpoint is configured
+	tracingOptions := cfg.Tracing
+	tracingOptions.ServiceVersion = build.Version
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingOptions)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	shutdown.OnShutdown("tracing", shutdownTracing)
+	decorator.SetTracer(tracing.Tracer())
+
 	// Connect to database
-	db, err := config.ConnectDatabase()
+	db, err := config.ConnectDatabase(&cfg.Database)
+	if err != nil && selftestMode() {
+		writeSelftestReport(selftest.Failed("database", err))
+	}
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -24,11 +183,1208 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to get SQL DB: %v", err)
 	}
-	defer sqlDB.Close()
+	shutdown.OnShutdown("database", lifecycle.Close(sqlDB.Close))
+	// Background work that must not run on two instances at once takes a lock
+	locks := cfg.Lock.Locks(&cfg.Database, sqlDB)
 
-	log.Println("Application started successfully!")
 	log.Println("Database connection established")
 
-	// TODO: Add your application logic here
-	// For example: start HTTP server, initialize repositories, etc.
+	// Migrate when behind and refuse to serve a schema this binary isn't compatible with
+	dbMode, err := config.MigrateDatabase(context.Background(), db, &cfg.Database)
+	if err != nil {
+		log.Fatalf("Database schema check failed: %v", err)
+	}
+
+	// Scope the tables of each tenant to the tenant of the request before the audit log and history
+	// tables see the rows; child rows such as order items are scoped through their aggregate
+	if err := db.Use(tenant.NewPlugin(config.TenantTables...)); err != nil {
+		log.Fatalf("Failed to register tenant scope: %v", err)
+	}
+
+	// Record model writes with their actor once the audit table exists; raw SQL bypasses the log
+	auditConfig := cfg.Audit
+	if auditConfig.Enabled {
+		err := db.Use(auditAdapter.NewPlugin(
+			auditAdapter.WithExcludedTables(auditConfig.ExcludedTables...),
+			auditAdapter.WithRedactedColumns("password_hash", "ciphertext", "secret_hash"),
+		))
+		if err != nil {
+			log.Fatalf("Failed to register audit log: %v", err)
+		}
+	}
+	// Keep every version of orders and products so support can look at them as of a past moment
+	if err := db.Use(temporal.NewPlugin("orders", "products")); err != nil {
+		log.Fatalf("Failed to register history tables: %v", err)
+	}
+
+	appMetrics := metrics.New()
+	appMetrics.Build(build)
+	shutdown.Observer = appMetrics.Shutdown()
+
+	// Adapters register the topics, keyspaces and buckets they need; see ensureInfrastructure
+	infra := bootstrap.NewRegistry()
+	// Caches register to be filled once the server starts, so a deploy does not start cold
+	warmupConfig := cfg.Warmup
+	caches := &warmup.Warmer{Concurrency: warmupConfig.Concurrency, Timeout: warmupConfig.Timeout, Observer: appMetrics.CacheWarmup()}
+
+	// List queries are served from memory until a repository writes the entities they read
+	queryCache := &cache.Store{TTL: cfg.QueryCache.TTL, MaxEntries: cfg.QueryCache.MaxEntries}
+
+	// Initialize repositories
+	userRepo := userAdapter.NewValidatingUserRepository(
+		userAdapter.NewInstrumentedUserRepository(userAdapter.NewGormUserRepository(db), appMetrics),
+	)
+	// Modules moved to the container build their repositories from what main provides
+	container := app.New()
+	app.Value(container, db)
+	app.Value(container, appMetrics)
+	app.Value(container, queryCache)
+	container.Install(app.ProductModule{}, app.OrderModule{})
+	productRepo := app.MustResolve[productDomain.ProductRepository](container)
+	orderRepo := app.MustResolve[orderDomain.OrderRepository](container)
+	reservationRepo := app.MustResolve[productDomain.StockReservationRepository](container)
+	loginAttemptRepo := userAdapter.NewInstrumentedLoginAttemptRepository(userAdapter.NewGormLoginAttemptRepository(db), appMetrics)
+	roleRepo := userAdapter.NewGormRoleRepository(db)
+	addressRepo := userAdapter.NewGormAddressRepository(db)
+	accountTokenRepo := userAdapter.NewGormAccountTokenRepository(db)
+	apiKeyRepo := userAdapter.NewGormAPIKeyRepository(db)
+
+	// Role permissions are only enforced when a gateway in front authenticates callers
+	rbacConfig := cfg.RBAC
+	var authorizer auth.Authorizer
+	if rbacConfig.Enabled {
+		authorizer = &userDomain.PermissionChecker{Roles: roleRepo}
+	}
+	// Emails and phones of other users are masked for callers without pii:read
+	maskKinds, err := dto.ParseMaskKinds(rbacConfig.MaskedData)
+	if err != nil {
+		log.Fatalf("Invalid DATA_MASKING: %v", err)
+	}
+	masker := &dto.Masker{Kinds: maskKinds, Auth: authorizer, Unmask: userDomain.PermissionPIIRead}
+
+	// Third-party secrets come from the environment until an admin rotates them through the API
+	credentialsConfig := cfg.Credentials
+	keyring, err := secret.NewKeyring(credentialsConfig.EncryptionKeys)
+	if err != nil {
+		log.Fatalf("Invalid ENCRYPTION_KEYS: %v", err)
+	}
+	credentials := &credentialDomain.Store{
+		Credentials: credentialAdapter.NewGormCredentialRepository(db),
+		Keyring:     keyring,
+		TTL:         credentialsConfig.CacheTTL,
+	}
+	caches.Register(credentials)
+
+	// Tenants brand emails and limit price currencies through their settings, over these defaults
+	settingsConfig := cfg.Settings
+	settingDefaults, err := settingsConfig.Defaults()
+	if err != nil {
+		log.Fatalf("Invalid default settings: %v", err)
+	}
+	settings := &settingDomain.Store{
+		Settings: settingAdapter.NewGormSettingRepository(db),
+		Defaults: settingDefaults,
+		TTL:      settingsConfig.CacheTTL,
+	}
+	caches.Register(settings)
+
+	// Initialize the payment gateway; the fake one authorizes every method but pm_card_declined
+	paymentConfig := cfg.Payment
+	var paymentGateway paymentDomain.PaymentGateway
+	switch paymentConfig.Gateway {
+	case "stripe":
+		paymentGateway = paymentAdapter.NewStripeGateway(paymentConfig.StripeAPIURL, credentials.Source("stripe", "secret_key", paymentConfig.StripeSecretKey), nil)
+	case "fake":
+		paymentGateway = paymentAdapter.NewFakeGateway()
+	default:
+		log.Fatalf("Unknown payment gateway %q", paymentConfig.Gateway)
+	}
+
+	// Sandbox tenants pay at the gateway's test endpoint; a sandbox payment never falls back to the live gateway
+	modeConfig := cfg.Mode
+	defaultMode, err := mode.Parse(modeConfig.Default)
+	if err != nil {
+		log.Fatalf("Invalid ADAPTER_MODE: %v", err)
+	}
+	modeResolver := mode.Resolver{Default: defaultMode, SandboxTenants: modeConfig.SandboxTenants}
+	var sandboxGateway paymentDomain.PaymentGateway = paymentAdapter.NewFakeGateway()
+	if modeConfig.StripeSandboxSecretKey != "" {
+		sandboxGateway = paymentAdapter.NewStripeGateway(paymentConfig.StripeAPIURL, credentials.Source("stripe", "sandbox_secret_key", modeConfig.StripeSandboxSecretKey), nil)
+	}
+	paymentGateway = paymentAdapter.NewModeGateway(paymentGateway, sandboxGateway)
+	paymentRepo := paymentAdapter.NewGormPaymentRepository(db)
+	disputeRepo := paymentAdapter.NewGormDisputeRepository(db)
+	evidenceStore := paymentAdapter.NewFileEvidenceStore(paymentConfig.EvidenceDir)
+	infra.Register(evidenceStore)
+
+	// Product images are kept in the blob store of STORAGE_DRIVER and read through signed URLs; the
+	// local store serves them itself at /blobs
+	storageConfig := cfg.Storage
+	var blobStore interface {
+		storage.BlobStore
+		selftest.Check
+	}
+	var blobHandler http.Handler
+	switch storageConfig.Driver {
+	case "s3":
+		s3Store, err := storage.NewS3Store(storage.S3Options{
+			Endpoint:        storageConfig.S3Endpoint,
+			Region:          storageConfig.S3Region,
+			Bucket:          storageConfig.S3Bucket,
+			AccessKeyID:     storageConfig.S3AccessKeyID,
+			SecretAccessKey: storageConfig.S3SecretAccessKey,
+			PathStyle:       storageConfig.S3PathStyle,
+		})
+		if err != nil {
+			log.Fatalf("Invalid S3_ENDPOINT: %v", err)
+		}
+		blobStore = s3Store
+	default:
+		fileStore := storage.NewFileStore(storageConfig.Dir, storageConfig.PublicURL, storageConfig.URLSecret)
+		infra.Register(fileStore)
+		blobStore = fileStore
+		blobHandler = fileStore.Handler()
+	}
+	productImageRepo := app.MustResolve[productDomain.ProductImageRepository](container)
+
+	// GET /products/search asks the search engine when one is configured and the database otherwise
+	searchConfig := cfg.Search
+	var searchIndex productDomain.SearchIndex
+	switch searchConfig.Backend {
+	case "elasticsearch":
+		elasticsearch := productAdapter.NewElasticsearchIndex(searchConfig.URL, searchConfig.Index, searchConfig.APIKey, nil)
+		infra.Register(elasticsearch)
+		searchIndex = elasticsearch
+	case "meilisearch":
+		meilisearch := productAdapter.NewMeilisearchIndex(searchConfig.URL, searchConfig.Index, searchConfig.APIKey, nil)
+		infra.Register(meilisearch)
+		searchIndex = meilisearch
+	}
+
+	// Prices are stored in BASE_CURRENCY and converted into the currency a caller asks for at
+	// exchange rates cached for EXCHANGE_RATE_TTL
+	currencyConfig := cfg.Currency
+	rounding, err := money.ParseRoundingMode(currencyConfig.Rounding)
+	if err != nil {
+		log.Fatalf("Invalid EXCHANGE_RATE_ROUNDING: %v", err)
+	}
+	var rateSource exchange.RateSource
+	switch currencyConfig.RateProvider {
+	case "ecb":
+		rateSource = exchange.NewECBSource(currencyConfig.ECBURL, nil)
+	case "fixer":
+		rateSource = exchange.NewFixerSource(currencyConfig.FixerAPIURL, credentials.Source("fixer", "api_key", currencyConfig.FixerAPIKey), nil)
+	}
+	var currencyConverter money.CurrencyConverter
+	if rateSource != nil {
+		rates := exchange.NewConverter(rateSource, currencyConfig.RateCacheTTL, rounding)
+		// The warm-up fetches the rates, so the first priced request after a deploy does not wait for the provider
+		caches.Register(rates)
+		currencyConverter = rates
+	}
+	baseCurrency := productCommand.BaseCurrency{Currency: currencyConfig.BaseCurrency, Converter: currencyConverter}
+
+	// Gateway callbacks are signed with the webhook secret; the fake gateway uses a plain HMAC scheme
+	webhookSecret := credentials.Source(paymentConfig.Gateway, "webhook_secret", paymentConfig.WebhookSecret)
+	var webhookVerifier paymentDomain.WebhookVerifier = paymentAdapter.NewHMACWebhookVerifier(webhookSecret)
+	if paymentConfig.Gateway == "stripe" {
+		webhookVerifier = paymentAdapter.NewStripeWebhookVerifier(webhookSecret)
+	}
+
+	// Initialize plan quotas
+	quotaConfig := cfg.Quota
+	planResolver, err := quotaAdapter.NewGormPlanResolver(db, quotaConfig.DefaultPlan, quotaConfig.PlanCacheTTL)
+	if err != nil {
+		log.Fatalf("Failed to configure quotas: %v", err)
+	}
+	caches.Register(planResolver)
+	quotaEnforcer := &quotaDomain.Enforcer{Plans: planResolver, Usage: quotaAdapter.NewGormUsageRepository(db)}
+	rateLimiter := quotaAdapter.NewRateLimiter(planResolver)
+
+	// Background jobs run from a persisted queue; the scheduler enqueues the recurring ones
+	jobsConfig := cfg.Jobs
+	jobQueue := jobs.NewQueue(db, jobsConfig.MaxAttempts, jobsConfig.Lease)
+	worker := jobs.NewWorker(jobQueue, jobsConfig.Concurrency, jobsConfig.PollInterval,
+		jobs.Backoff{Base: jobsConfig.BackoffBase, Max: jobsConfig.BackoffMax})
+	scheduler := jobs.NewScheduler(jobQueue)
+
+	// Initialize API usage metering; calls are aggregated in memory and flushed in batches
+	billingConfig := cfg.Billing
+	usageRepo := billingAdapter.NewGormUsageRepository(db)
+	meter := billingAdapter.NewMeter(usageRepo, billingConfig.FlushInterval)
+	meter.Start()
+	shutdown.OnShutdown("usage meter", meter.Close)
+
+	var usageExporter billingDomain.UsageExporter = billingAdapter.LogUsageExporter{}
+	if billingConfig.StripeSecretKey != "" {
+		usageExporter = billingAdapter.NewStripeUsageExporter(
+			billingConfig.StripeAPIURL,
+			credentials.Source("stripe", "secret_key", billingConfig.StripeSecretKey),
+			billingConfig.StripeMeterEventName,
+			billingAdapter.NewGormBillingAccountRepository(db),
+			nil,
+		)
+	}
+	exportUsage := (&billingCommand.ExportUsageHandler{UsageRepo: usageRepo, Exporter: usageExporter}).Decorated()
+	(&billingPort.JobServer{ExportUsage: exportUsage}).RegisterJobs(worker)
+	// Re-exporting a day is safe because exporters deduplicate per tenant and day
+	if err := scheduler.Add("export-usage", "@every "+billingConfig.ExportInterval.String(), billingPort.ExportUsageJob{}); err != nil {
+		log.Fatalf("Failed to schedule usage export: %v", err)
+	}
+
+	// Give the stock of abandoned orders back once their reservations expire
+	inventoryConfig := cfg.Inventory
+	releaseExpiredReservations := (&productCommand.ReleaseExpiredReservationsHandler{Reservations: reservationRepo}).Decorated()
+	(&productPort.JobServer{ReleaseExpiredReservations: releaseExpiredReservations}).RegisterJobs(worker)
+	jobs.Exclusive[productPort.ReleaseExpiredReservationsJob](worker, locks)
+	if err := scheduler.Add("release-expired-reservations", "@every "+inventoryConfig.ReleaseInterval.String(), productPort.ReleaseExpiredReservationsJob{}); err != nil {
+		log.Fatalf("Failed to schedule reservation expiry: %v", err)
+	}
+
+	// Compare each day of payments with the gateway's report and log the discrepancies
+	reconcilePayments := (&paymentCommand.ReconcilePaymentsHandler{Gateway: paymentGateway, Payments: paymentRepo}).Decorated()
+	(&paymentPort.JobServer{ReconcilePayments: reconcilePayments}).RegisterJobs(worker)
+	if err := scheduler.Add("reconcile-payments", paymentConfig.ReconcileSchedule, paymentPort.ReconcilePaymentsJob{}); err != nil {
+		log.Fatalf("Failed to schedule payment reconciliation: %v", err)
+	}
+
+	// Roll the orders of the last days up into the daily sales the dashboards chart
+	reportingConfig := cfg.Reporting
+	salesReports := reportingAdapter.NewGormSalesRepository(db)
+	aggregateDailySales := (&reportingCommand.AggregateDailySalesHandler{Sales: reportingAdapter.NewGormSaleSource(db), Reports: salesReports}).Decorated()
+	(&reportingPort.JobServer{AggregateDailySales: aggregateDailySales, Days: reportingConfig.Days}).RegisterJobs(worker)
+	if err := scheduler.Add("aggregate-daily-sales", reportingConfig.Schedule, reportingPort.AggregateDailySalesJob{}); err != nil {
+		log.Fatalf("Failed to schedule daily sales aggregation: %v", err)
+	}
+
+	// Sagas coordinate writes to stores no single transaction covers. Orders and payments share
+	// the database today; giving payments a database of its own only changes its store here.
+	sagaConfig := cfg.Saga
+	sagas := saga.NewRunner(db, saga.Store{Name: "orders", DB: db}, saga.Store{Name: "payments", DB: db})
+	sagas.RegisterJobs(worker, sagaConfig.ResumeAfter)
+	jobs.Exclusive[saga.ResumeJob](worker, locks)
+	if err := scheduler.Add("resume-sagas", "@every "+sagaConfig.ResumeInterval.String(), saga.ResumeJob{}); err != nil {
+		log.Fatalf("Failed to schedule saga recovery: %v", err)
+	}
+
+	// Initialize password policy enforcement
+	passwordConfig := cfg.Password
+	passwordValidator := &userDomain.PasswordValidator{
+		Policies: &userDomain.StaticPasswordPolicyProvider{Default: passwordConfig.Policy, TenantID: tenant.FromContext},
+		Breaches: userAdapter.NewPwnedPasswordsChecker(passwordConfig.BreachAPIURL, nil),
+	}
+
+	// Initialize login auditing and security alerts
+	loginConfig := cfg.Login
+	smtpConfig := cfg.SMTP
+	loginAlerts := userAdapter.NewLoginAlertNotifier(smtpConfig.Addr(), smtpConfig.Auth(), smtpConfig.From)
+
+	eventBus := event.NewBus()
+	eventBus.Subscribe(userDomain.LoginAnomalyDetectedEvent, loginAlerts.Handle)
+	if searchIndex != nil {
+		syncSearchIndex := (&productCommand.SyncSearchIndexHandler{ProductRepo: productRepo, Index: searchIndex}).Decorated()
+		(&productPort.EventServer{SyncSearchIndex: syncSearchIndex}).Subscribe(eventBus)
+	}
+
+	// Order confirmation and welcome emails are rendered on the event and delivered by the job worker
+	notificationConfig := cfg.Notification
+	var notifier notificationDomain.Notifier = notificationAdapter.LogNotifier{}
+	// Emails of sandbox test data are only logged, or validated by SendGrid's sandbox mode without delivery
+	var sandboxNotifier notificationDomain.Notifier = notificationAdapter.LogNotifier{}
+	switch notificationConfig.Provider {
+	case "sendgrid":
+		sendGridKey := credentials.Source("sendgrid", "api_key", notificationConfig.SendGridAPIKey)
+		notifier = notificationAdapter.NewSendGridNotifier(notificationConfig.SendGridAPIURL, sendGridKey, notificationConfig.From, nil)
+		sandboxSendGrid := notificationAdapter.NewSendGridNotifier(notificationConfig.SendGridAPIURL, sendGridKey, notificationConfig.From, nil)
+		sandboxSendGrid.SandboxMode = true
+		sandboxNotifier = sandboxSendGrid
+	case "smtp":
+		if smtpConfig.Addr() != "" {
+			notifier = notificationAdapter.NewSMTPNotifier(smtpConfig.Addr(), smtpConfig.Auth(), notificationConfig.From)
+		}
+	default:
+		log.Fatalf("Unknown email provider %q", notificationConfig.Provider)
+	}
+	sendEmail := (&notificationCommand.SendEmailHandler{Notifier: notificationAdapter.ModeNotifier{Live: notifier, Sandbox: sandboxNotifier}}).Decorated()
+	// Campaigns add their recipients page by page and send each email as its own job, paced to the provider's rate limit
+	campaignRepo := notificationAdapter.NewGormCampaignRepository(db)
+	deliveryRepo := notificationAdapter.NewGormDeliveryRepository(db)
+	campaignQueue := notificationPort.JobCampaignQueue{Jobs: jobQueue}
+	dispatchCampaign := (&notificationCommand.DispatchCampaignHandler{
+		Campaigns:  campaignRepo,
+		Deliveries: deliveryRepo,
+		Audience:   notificationAdapter.NewGormAudience(db),
+		Queue:      campaignQueue,
+		Pacer:      notificationAdapter.NewGormPacer(db, notificationConfig.RateLimits()),
+		Provider:   notificationConfig.Provider,
+		BatchSize:  notificationConfig.CampaignBatchSize,
+	}).Decorated()
+	sendCampaignEmail := (&notificationCommand.SendCampaignEmailHandler{
+		Campaigns:   campaignRepo,
+		Deliveries:  deliveryRepo,
+		Notifier:    notifier,
+		TrackingURL: notificationConfig.TrackingURL,
+		MaxAttempts: jobsConfig.MaxAttempts,
+	}).Decorated()
+	(&notificationPort.JobServer{
+		SendEmail:         sendEmail,
+		DispatchCampaign:  dispatchCampaign,
+		SendCampaignEmail: sendCampaignEmail,
+	}).RegisterJobs(worker)
+	accountConfig := cfg.Account
+	(&notificationPort.EventServer{
+		Jobs:                 jobQueue,
+		StockAlertRecipients: notificationConfig.StockAlertRecipients,
+		PasswordResetURL:     accountConfig.PasswordResetURL,
+		EmailVerificationURL: accountConfig.EmailVerificationURL,
+		Branding: func(ctx context.Context) (notificationDomain.Branding, error) {
+			s, err := settings.Current(ctx)
+			if err != nil {
+				return notificationDomain.Branding{}, err
+			}
+			return notificationDomain.Branding{StoreName: s.StoreName, LogoURL: s.LogoURL, Sender: s.NotificationSender}, nil
+		},
+	}).Subscribe(eventBus)
+	passwordResetPolicy := userCommand.TokenPolicy{
+		TTL:           accountConfig.PasswordResetTTL,
+		MaxAttempts:   accountConfig.TokenMaxAttempts,
+		RequestLimit:  accountConfig.TokenRequestLimit,
+		RequestWindow: accountConfig.TokenRequestWindow,
+	}
+	emailVerificationPolicy := passwordResetPolicy
+	emailVerificationPolicy.TTL = accountConfig.EmailVerificationTTL
+
+	// Tenants receive order and stock events at their webhook endpoints; every attempt is a job
+	webhookConfig := cfg.Webhook
+	webhookEndpoints := webhookAdapter.NewGormEndpointRepository(db)
+	webhookDeliveries := webhookAdapter.NewGormDeliveryRepository(db)
+	webhookQueue := webhookPort.JobDeliveryQueue{Jobs: jobQueue}
+	attemptWebhookDelivery := (&webhookCommand.AttemptDeliveryHandler{
+		Endpoints:  webhookEndpoints,
+		Deliveries: webhookDeliveries,
+		Queue:      webhookQueue,
+		Sender:     webhookAdapter.NewHTTPSender(&http.Client{Timeout: webhookConfig.Timeout}),
+		Keyring:    keyring,
+		Policy: webhookDomain.RetryPolicy{
+			MaxAttempts: webhookConfig.MaxAttempts,
+			Backoff:     jobs.Backoff{Base: webhookConfig.BackoffBase, Max: webhookConfig.BackoffMax},
+		},
+	}).Decorated()
+	(&webhookPort.JobServer{AttemptDelivery: attemptWebhookDelivery}).RegisterJobs(worker)
+	(&webhookPort.EventServer{
+		EnqueueDeliveries: (&webhookCommand.EnqueueDeliveriesHandler{Endpoints: webhookEndpoints, Deliveries: webhookDeliveries, Queue: webhookQueue}).Decorated(),
+	}).Subscribe(eventBus)
+
+	// Order events reach other services through the outbox; the relay forwards them to the broker
+	messagingConfig := cfg.Messaging
+	var broker messaging.Publisher
+	switch messagingConfig.Driver {
+	case "none":
+	case "log":
+		broker = messaging.LogPublisher{}
+	case "kafka":
+		kafkaPublisher := messaging.NewKafkaPublisher(messagingConfig.KafkaBrokers)
+		shutdown.OnShutdown("kafka publisher", lifecycle.Close(kafkaPublisher.Close))
+		broker = kafkaPublisher
+		infra.Register(messaging.KafkaTopic{
+			Brokers:           messagingConfig.KafkaBrokers,
+			Topic:             messagingConfig.OrderTopic,
+			Partitions:        messagingConfig.KafkaPartitions,
+			ReplicationFactor: messagingConfig.KafkaReplicationFactor,
+		})
+	case "rabbitmq":
+		rabbitMQPublisher := messaging.NewRabbitMQPublisher(messagingConfig.RabbitMQURL)
+		shutdown.OnShutdown("rabbitmq publisher", lifecycle.Close(rabbitMQPublisher.Close))
+		broker = rabbitMQPublisher
+		infra.Register(messaging.RabbitMQExchange{URL: messagingConfig.RabbitMQURL, Exchange: messagingConfig.OrderTopic})
+	default:
+		log.Fatalf("Unknown messaging driver %q", messagingConfig.Driver)
+	}
+	var relay *messaging.Relay
+	outbox := messaging.NewOutbox(db)
+	// Commands write order messages in the transaction that saves the order
+	var orderOutbox event.Publisher
+	if broker != nil {
+		orderOutbox = &orderPort.MessagingServer{Publisher: outbox, Topic: messagingConfig.OrderTopic}
+		relay = messaging.NewRelay(outbox, broker, messagingConfig.RelayInterval, messagingConfig.RelayBatchSize, messagingConfig.OutboxRetention)
+		relay.Lock = locks
+	}
+	// Read models follow the outbox log at the pace of the relay and can be rebuilt from it
+	orderSummaries := orderAdapter.NewGormOrderSummaryRepository(db)
+	projections := projection.NewRunner(outbox, projection.NewCheckpoints(db), messagingConfig.RelayInterval, messagingConfig.RelayBatchSize,
+		&orderPort.OrderSummaryProjection{Summaries: orderSummaries, Topic: messagingConfig.OrderTopic},
+	)
+
+	// Coupons are checked when applied at checkout and their use is counted when the order is placed
+	coupons := couponAdapter.NewGormCouponRepository(db)
+
+	// Reviews wait for a moderator; approving one adds it to the rating of its product
+	reviews := reviewAdapter.NewGormReviewRepository(db)
+	ratings := reviewAdapter.NewGormRatingRepository(db)
+
+	// Orders are placed directly or by completing a checkout session
+	stockLocking := orderCommand.StockLocking(inventoryConfig.Locking)
+	if stockLocking != orderCommand.StockLockingOptimistic && stockLocking != orderCommand.StockLockingPessimistic {
+		log.Fatalf("Unknown STOCK_LOCKING %q, expected optimistic or pessimistic", inventoryConfig.Locking)
+	}
+	// Orders of priced products are charged the TAX_RATES of their destination and product tax
+	// class, and the SHIPPING_FEES of their destination
+	pricingEngine, err := cfg.Pricing.Engine(currencyConfig.BaseCurrency)
+	if err != nil {
+		log.Fatalf("Invalid pricing configuration: %v", err)
+	}
+	pricingEngine.Rounding, pricingEngine.Converter = rounding, currencyConverter
+	placeOrderHandler := &orderCommand.PlaceOrderHandler{
+		OrderRepo:         orderRepo,
+		UserRepo:          userRepo,
+		ProductRepo:       productRepo,
+		Addresses:         addressRepo,
+		Reservations:      reservationRepo,
+		ReservationTTL:    inventoryConfig.ReservationTTL,
+		Pricing:           pricingEngine,
+		Coupons:           coupons,
+		Quota:             quotaEnforcer,
+		Payments:          paymentGateway,
+		PaymentRepo:       paymentRepo,
+		Events:            eventBus,
+		Outbox:            orderOutbox,
+		Locking:           stockLocking,
+		LowStockThreshold: inventoryConfig.LowStockThreshold,
+		Tx:                persistence.NewGormTransactor(db),
+		Hooks:             orderHooks,
+	}
+	// CANARY_RELEASES sends a share of the users to candidate implementations registered next to
+	// the stable ones; users in the pricing release are charged the CANARY_ pricing settings
+	rollout, err := cfg.Canary.Rollout()
+	if err != nil {
+		log.Fatalf("Invalid canary configuration: %v", err)
+	}
+	rollout.Observer = appMetrics.Canary()
+	candidatePricing, err := cfg.Canary.Pricing.Engine(currencyConfig.BaseCurrency)
+	if err != nil {
+		log.Fatalf("Invalid canary pricing configuration: %v", err)
+	}
+	candidatePricing.Rounding, candidatePricing.Converter = rounding, currencyConverter
+	candidatePlaceOrderHandler := *placeOrderHandler
+	candidatePlaceOrderHandler.Pricing = candidatePricing
+	placeOrder := metrics.InstrumentPlaceOrder(
+		canary.Split(rollout, config.ReleasePricing,
+			placeOrderHandler.Decorated(),
+			candidatePlaceOrderHandler.Decorated(),
+		),
+		appMetrics,
+	)
+
+	// Delivery windows are promised at checkout and compared with the actual deliveries
+	deliveryConfig := cfg.Delivery
+	warehouseZone, err := time.LoadLocation(deliveryConfig.TimeZone)
+	if err != nil {
+		log.Fatalf("Unknown DELIVERY_TIMEZONE %q: %v", deliveryConfig.TimeZone, err)
+	}
+	standardTransit, err := deliveryDomain.ParseTransitTimes(deliveryConfig.StandardTransit)
+	if err != nil {
+		log.Fatalf("Invalid DELIVERY_TRANSIT_STANDARD: %v", err)
+	}
+	expressTransit, err := deliveryDomain.ParseTransitTimes(deliveryConfig.ExpressTransit)
+	if err != nil {
+		log.Fatalf("Invalid DELIVERY_TRANSIT_EXPRESS: %v", err)
+	}
+	warehouses, err := deliveryDomain.ParseWarehouses(deliveryConfig.Warehouses)
+	if err != nil {
+		log.Fatalf("Invalid DELIVERY_WAREHOUSES: %v", err)
+	}
+	warehouseRepo := deliveryAdapter.NewGormWarehouseRepository(db)
+	deliveryEstimator := &deliveryDomain.Estimator{
+		ProcessingDays: deliveryConfig.ProcessingDays,
+		Cutoff:         deliveryConfig.Cutoff,
+		Location:       warehouseZone,
+		Transit: deliveryDomain.TransitTable{
+			string(checkoutDomain.ShippingStandard): standardTransit,
+			string(checkoutDomain.ShippingExpress):  expressTransit,
+		},
+		Warehouses: warehouseRepo,
+	}
+	deliveryEstimates := deliveryAdapter.NewGormEstimateRepository(db)
+	recordEstimate := (&deliveryCommand.RecordEstimateHandler{Estimator: deliveryEstimator, Estimates: deliveryEstimates}).Decorated()
+
+	// Orders ship with the configured carriers, whose tracking callbacks mark them delivered
+	shippingConfig := cfg.Shipping
+	shipments := shippingAdapter.NewGormShipmentRepository(db)
+	carriers := make([]string, len(shippingConfig.Carriers))
+	trackingVerifiers := make(map[string]shippingDomain.TrackingVerifier, len(shippingConfig.Carriers))
+	for i, carrier := range shippingConfig.Carriers {
+		carriers[i] = shippingDomain.NormalizeCarrier(carrier)
+		trackingVerifiers[carriers[i]] = shippingAdapter.NewHMACTrackingVerifier(
+			credentials.Source(carriers[i], "webhook_secret", shippingConfig.WebhookSecret),
+		)
+	}
+	recordDelivery := (&deliveryCommand.RecordDeliveryHandler{Estimates: deliveryEstimates}).Decorated()
+
+	// Support staff query the allowlisted read models through the sandbox instead of the database
+	supportSandbox, err := supportAdapter.NewGormSandbox(db,
+		supportAdapter.Dataset{Name: "order_summaries", Model: &orderDomain.OrderSummary{}, Redacted: []string{"user_email"}},
+		supportAdapter.Dataset{Name: "delivery_estimates", Model: &deliveryDomain.Estimate{}},
+		supportAdapter.Dataset{Name: "shipments", Model: &shippingDomain.Shipment{}},
+	)
+	if err != nil {
+		log.Fatalf("Failed to set up the support sandbox: %v", err)
+	}
+	supportQueryLogs := supportAdapter.NewGormQueryLogRepository(db)
+
+	// Initialize multi-step checkout; abandoned sessions are purged once expired
+	checkoutConfig := cfg.Checkout
+	checkoutSessions := checkoutAdapter.NewGormSessionRepository(db)
+	purgeExpiredCheckouts := (&checkoutCommand.PurgeExpiredCheckoutsHandler{Sessions: checkoutSessions}).Decorated()
+	(&checkoutPort.JobServer{PurgeExpiredCheckouts: purgeExpiredCheckouts}).RegisterJobs(worker)
+	jobs.Exclusive[checkoutPort.PurgeExpiredCheckoutsJob](worker, locks)
+	if err := scheduler.Add("purge-expired-checkouts", "@every "+checkoutConfig.PurgeInterval.String(), checkoutPort.PurgeExpiredCheckoutsJob{}); err != nil {
+		log.Fatalf("Failed to schedule checkout purge: %v", err)
+	}
+
+	syncConfig := cfg.Sync
+
+	// Orders sent with "Prefer: respond-async" are placed by a job; clients poll the command for the result
+	asyncConfig := cfg.Async
+	asyncCommands := asyncAdapter.NewGormCommandRepository(db)
+	purgeCompletedCommands := (&asyncCommand.PurgeCompletedCommandsHandler{Commands: asyncCommands, Retention: asyncConfig.Retention}).Decorated()
+	(&asyncPort.JobServer{PurgeCompletedCommands: purgeCompletedCommands}).RegisterJobs(worker)
+	jobs.Exclusive[asyncPort.PurgeCompletedCommandsJob](worker, locks)
+	if err := scheduler.Add("purge-completed-commands", "@every "+asyncConfig.PurgeInterval.String(), asyncPort.PurgeCompletedCommandsJob{}); err != nil {
+		log.Fatalf("Failed to schedule command purge: %v", err)
+	}
+	asyncRunner := &asyncPort.Runner{Commands: asyncCommands, Jobs: jobQueue, MaxAttempts: jobsConfig.MaxAttempts}
+
+	// Users export their data through the same commands; the archives expire with the command results
+	archives := portabilityAdapter.NewFileArchiveStore(cfg.Portability.ExportDir)
+	infra.Register(archives)
+	purgeArchives := (&portabilityCommand.PurgeArchivesHandler{Archives: archives, Retention: asyncConfig.Retention}).Decorated()
+	(&portabilityPort.JobServer{PurgeArchives: purgeArchives}).RegisterJobs(worker)
+	jobs.Exclusive[portabilityPort.PurgeArchivesJob](worker, locks)
+	if err := scheduler.Add("purge-data-export-archives", "@every "+asyncConfig.PurgeInterval.String(), portabilityPort.PurgeArchivesJob{}); err != nil {
+		log.Fatalf("Failed to schedule data export purge: %v", err)
+	}
+	dataExportServer := &portabilityPort.HTTPServer{
+		ExportUserData: (&portabilityCommand.ExportUserDataHandler{Users: userRepo, Addresses: addressRepo, Orders: orderRepo, Reviews: reviews, Products: productRepo, Archives: archives}).Decorated(),
+		Async:          asyncRunner,
+		Commands:       asyncCommands,
+		Archives:       archives,
+		Retention:      asyncConfig.Retention,
+		Auth:           authorizer,
+	}
+	dataExportServer.RegisterJobs(worker)
+
+	orderServer := &orderPort.HTTPServer{
+		PlaceOrder:         placeOrder,
+		ListOrderSummaries: (&orderQuery.ListOrderSummariesHandler{Summaries: orderSummaries}).Decorated(),
+		OrderRepo:          orderRepo,
+		UserRepo:           userRepo,
+		ProductRepo:        productRepo,
+		Addresses:          addressRepo,
+		Estimates:          deliveryEstimates,
+		Auth:               authorizer,
+		Masker:             masker,
+		Converter:          currencyConverter,
+	}
+	if asyncConfig.OrdersEnabled {
+		orderServer.Async = asyncRunner
+		orderServer.RegisterJobs(worker)
+	}
+
+	// Initialize HTTP ports
+	router := server.NewRouter(server.Handlers{
+		Orders: orderServer,
+		Checkout: &checkoutPort.HTTPServer{
+			StartCheckout: (&checkoutCommand.StartCheckoutHandler{
+				Sessions:    checkoutSessions,
+				UserRepo:    userRepo,
+				ProductRepo: productRepo,
+				TTL:         checkoutConfig.SessionTTL,
+			}).Decorated(),
+			UpdateCheckout: (&checkoutCommand.UpdateCheckoutHandler{Sessions: checkoutSessions, TTL: checkoutConfig.SessionTTL}).Decorated(),
+			CompleteCheckout: (&checkoutCommand.CompleteCheckoutHandler{
+				Sessions:       checkoutSessions,
+				PlaceOrder:     placeOrder,
+				Addresses:      addressRepo,
+				RecordEstimate: recordEstimate,
+			}).Decorated(),
+			ApplyCoupon: (&checkoutCommand.ApplyCouponHandler{
+				Sessions:    checkoutSessions,
+				Coupons:     coupons,
+				ProductRepo: productRepo,
+				TTL:         checkoutConfig.SessionTTL,
+			}).Decorated(),
+			Sessions:    checkoutSessions,
+			UserRepo:    userRepo,
+			ProductRepo: productRepo,
+			Estimator:   deliveryEstimator,
+			Estimates:   deliveryEstimates,
+			Auth:        authorizer,
+		},
+		Delivery: &deliveryPort.HTTPServer{
+			Estimates: deliveryEstimates,
+			Auth:      authorizer,
+		},
+		Shipping: &shippingPort.HTTPServer{
+			CreateShipment: (&shippingCommand.CreateShipmentHandler{
+				Shipments: shipments,
+				Orders:    orderRepo,
+				Carriers:  carriers,
+				Tx:        persistence.NewGormTransactor(db),
+			}).Decorated(),
+			UpdateTrackingStatus: (&shippingCommand.UpdateTrackingStatusHandler{
+				Shipments:      shipments,
+				Orders:         orderRepo,
+				RecordDelivery: recordDelivery,
+				Tx:             persistence.NewGormTransactor(db),
+			}).Decorated(),
+			Shipments: shipments,
+			Orders:    orderRepo,
+			Auth:      authorizer,
+			Verifiers: trackingVerifiers,
+		},
+		Support: &supportPort.HTTPServer{
+			RunSandboxQuery: (&supportQuery.RunSandboxQueryHandler{Sandbox: supportSandbox, Logs: supportQueryLogs}).Decorated(),
+			Sandbox:         supportSandbox,
+			Logs:            supportQueryLogs,
+			Auth:            authorizer,
+		},
+		Webhooks: &webhookPort.HTTPServer{
+			RegisterEndpoint: (&webhookCommand.RegisterEndpointHandler{Endpoints: webhookEndpoints, Keyring: keyring, AllowHTTP: webhookConfig.AllowHTTP}).Decorated(),
+			DisableEndpoint:  (&webhookCommand.DisableEndpointHandler{Endpoints: webhookEndpoints}).Decorated(),
+			Redeliver:        (&webhookCommand.RedeliverHandler{Endpoints: webhookEndpoints, Deliveries: webhookDeliveries, Queue: webhookQueue}).Decorated(),
+			Endpoints:        webhookEndpoints,
+			Deliveries:       webhookDeliveries,
+			Auth:             authorizer,
+		},
+		Commands: &asyncPort.HTTPServer{
+			Commands:      asyncCommands,
+			PollInterval:  asyncConfig.PollInterval,
+			StreamTimeout: asyncConfig.StreamTimeout,
+			Auth:          authorizer,
+		},
+		Sync: &syncPort.HTTPServer{
+			ListChanges: (&syncQuery.ListChangesHandler{
+				ProductRepo: productRepo,
+				OrderRepo:   orderRepo,
+				SettleDelay: syncConfig.SettleDelay,
+				MaxLimit:    syncConfig.MaxBatchSize,
+			}).Decorated(),
+			Auth: authorizer,
+		},
+		Credentials: &credentialPort.HTTPServer{
+			RotateCredential: (&credentialCommand.RotateCredentialHandler{Store: credentials}).Decorated(),
+			Store:            credentials,
+			Auth:             authorizer,
+		},
+		DataExports: dataExportServer,
+		Settings: &settingPort.HTTPServer{
+			UpdateSettings: (&settingCommand.UpdateSettingsHandler{Store: settings}).Decorated(),
+			Store:          settings,
+			Auth:           authorizer,
+		},
+		Coupons: &couponPort.HTTPServer{
+			CreateCoupon: (&couponCommand.CreateCouponHandler{Coupons: coupons, Currency: currencyConfig.BaseCurrency}).Decorated(),
+			Coupons:      coupons,
+			Auth:         authorizer,
+		},
+		Reviews: &reviewPort.HTTPServer{
+			CreateReview:   (&reviewCommand.CreateReviewHandler{Reviews: reviews, Products: productRepo, Purchases: reviewAdapter.NewGormPurchaseVerifier(db)}).Decorated(),
+			ModerateReview: (&reviewCommand.ModerateReviewHandler{Reviews: reviews, Ratings: ratings, Tx: persistence.NewGormTransactor(db)}).Decorated(),
+			ListReviews:    (&reviewQuery.ListReviewsHandler{Reviews: reviews, Ratings: ratings, Products: productRepo}).Decorated(),
+			Auth:           authorizer,
+		},
+		Reports: &reportingPort.HTTPServer{
+			SalesReport: (&reportingQuery.SalesReportHandler{Reports: salesReports}).Decorated(),
+			Auth:        authorizer,
+		},
+		Audit: &auditPort.HTTPServer{
+			Entries: auditAdapter.NewGormEntryRepository(db),
+			Auth:    authorizer,
+		},
+		Payments: &paymentPort.HTTPServer{
+			CaptureOrderPayment: (&paymentCommand.CaptureOrderPaymentHandler{Gateway: paymentGateway, Payments: paymentRepo}).Decorated(),
+			RefundOrder: (&paymentCommand.RefundOrderHandler{
+				Gateway:  paymentGateway,
+				Payments: paymentRepo,
+				Refunds:  paymentAdapter.NewGormRefundRepository(db),
+				Orders:   orderRepo,
+				Products: productRepo,
+				Events:   eventBus,
+				Outbox:   orderOutbox,
+				Tx:       persistence.NewGormTransactor(db),
+			}).Decorated(),
+			HandleWebhook: (&paymentCommand.HandleWebhookHandler{
+				Payments: paymentRepo,
+				Webhooks: paymentAdapter.NewGormWebhookRepository(db),
+				Disputes: disputeRepo,
+				Orders:   orderRepo,
+				Products: productRepo,
+				Events:   eventBus,
+				Outbox:   orderOutbox,
+				Tx:       persistence.NewGormTransactor(db),
+			}).Decorated(),
+			Payments:              paymentRepo,
+			Orders:                orderRepo,
+			UploadDisputeEvidence: (&paymentCommand.UploadDisputeEvidenceHandler{Disputes: disputeRepo, Store: evidenceStore}).Decorated(),
+			Disputes:              disputeRepo,
+			Evidence:              evidenceStore,
+			Auth:                  authorizer,
+			Gateway:               paymentGateway.Name(),
+			Verifier:              webhookVerifier,
+		},
+		Products: &productPort.HTTPServer{
+			CreateProduct:        (&productCommand.CreateProductHandler{ProductRepo: productRepo, Base: baseCurrency, Quota: quotaEnforcer, Events: eventBus}).Decorated(),
+			AdjustStock:          (&productCommand.AdjustStockHandler{ProductRepo: productRepo, Events: eventBus, LowStockThreshold: inventoryConfig.LowStockThreshold}).Decorated(),
+			DeleteProduct:        (&productCommand.DeleteProductHandler{ProductRepo: productRepo, Quota: quotaEnforcer, Events: eventBus}).Decorated(),
+			ImportProducts:       (&productCommand.ImportProductsHandler{ProductRepo: productRepo, Base: baseCurrency, Quota: quotaEnforcer, Events: eventBus}).Decorated(),
+			SearchProducts:       (&productQuery.SearchProductsHandler{ProductRepo: productRepo, Index: searchIndex}).Decorated(),
+			ListLowStockProducts: (&productQuery.ListLowStockProductsHandler{ProductRepo: productRepo, LowStockThreshold: inventoryConfig.LowStockThreshold}).Decorated(),
+			UploadProductImage:   (&productCommand.UploadProductImageHandler{ProductRepo: productRepo, Images: productImageRepo, Store: blobStore, MaxSize: int64(storageConfig.MaxImageSize), ThumbnailSize: storageConfig.ThumbnailSize}).Decorated(),
+			DeleteProductImage:   (&productCommand.DeleteProductImageHandler{ProductRepo: productRepo, Images: productImageRepo, Store: blobStore}).Decorated(),
+			ListProductImages:    (&productQuery.ListProductImagesHandler{ProductRepo: productRepo, Images: productImageRepo, Store: blobStore, URLTTL: storageConfig.URLTTL}).Decorated(),
+			MaxImageSize:         int64(storageConfig.MaxImageSize),
+			ProductRepo:          productRepo,
+			Reservations:         reservationRepo,
+			Converter:            currencyConverter,
+			Auth:                 authorizer,
+		},
+		Users: &userPort.HTTPServer{
+			RegisterUser: (&userCommand.RegisterUserHandler{
+				UserRepo:          userRepo,
+				PasswordValidator: passwordValidator,
+				Roles:             roleRepo,
+				Events:            eventBus,
+			}).Decorated(),
+			Login: (&userCommand.LoginHandler{
+				UserRepo:     userRepo,
+				AttemptRepo:  loginAttemptRepo,
+				GeoResolver:  userAdapter.NewIPAPIGeoResolver(loginConfig.GeoIPAPIURL, nil),
+				Detector:     loginConfig.Detector,
+				Events:       eventBus,
+				StepUpOnRisk: loginConfig.StepUpOnAnomaly,
+			}).Decorated(),
+			AssignRole:           (&userCommand.AssignRoleHandler{UserRepo: userRepo, Roles: roleRepo}).Decorated(),
+			ChangePassword:       (&userCommand.ChangePasswordHandler{UserRepo: userRepo, PasswordValidator: passwordValidator}).Decorated(),
+			DeactivateUser:       (&userCommand.DeactivateUserHandler{UserRepo: userRepo}).Decorated(),
+			ReactivateUser:       (&userCommand.ReactivateUserHandler{UserRepo: userRepo}).Decorated(),
+			RequirePasswordReset: (&userCommand.RequirePasswordResetHandler{UserRepo: userRepo}).Decorated(),
+			MergeUsers: (&userCommand.MergeUsersHandler{
+				UserRepo: userRepo,
+				Movers: []userDomain.AccountMover{
+					userAdapter.NewGormAccountMover(db),
+					orderAdapter.NewGormAccountMover(db),
+					checkoutAdapter.NewGormAccountMover(db),
+					reviewAdapter.NewGormAccountMover(db),
+				},
+				Tx: persistence.NewGormTransactor(db),
+			}).Decorated(),
+			RequestPasswordReset: (&userCommand.RequestPasswordResetHandler{
+				UserRepo: userRepo,
+				Tokens:   accountTokenRepo,
+				Policy:   passwordResetPolicy,
+				Events:   eventBus,
+			}).Decorated(),
+			ResetPassword: (&userCommand.ResetPasswordHandler{
+				UserRepo:          userRepo,
+				Tokens:            accountTokenRepo,
+				Policy:            passwordResetPolicy,
+				PasswordValidator: passwordValidator,
+				Tx:                persistence.NewGormTransactor(db),
+			}).Decorated(),
+			RequestEmailVerification: (&userCommand.RequestEmailVerificationHandler{
+				UserRepo: userRepo,
+				Tokens:   accountTokenRepo,
+				Policy:   emailVerificationPolicy,
+				Events:   eventBus,
+			}).Decorated(),
+			VerifyEmail: (&userCommand.VerifyEmailHandler{
+				UserRepo: userRepo,
+				Tokens:   accountTokenRepo,
+				Policy:   emailVerificationPolicy,
+				Tx:       persistence.NewGormTransactor(db),
+			}).Decorated(),
+			UpdateProfile: (&userCommand.UpdateProfileHandler{UserRepo: userRepo}).Decorated(),
+			AddAddress:    (&userCommand.AddAddressHandler{UserRepo: userRepo, Addresses: addressRepo}).Decorated(),
+			UpdateAddress: (&userCommand.UpdateAddressHandler{Addresses: addressRepo}).Decorated(),
+			DeleteAddress: (&userCommand.DeleteAddressHandler{Addresses: addressRepo}).Decorated(),
+			IssueAPIKey:   (&userCommand.IssueAPIKeyHandler{UserRepo: userRepo, Keys: apiKeyRepo}).Decorated(),
+			RevokeAPIKey:  (&userCommand.RevokeAPIKeyHandler{Keys: apiKeyRepo}).Decorated(),
+			APIKeys:       apiKeyRepo,
+			UserRepo:      userRepo,
+			Addresses:     addressRepo,
+			Auth:          authorizer,
+			Masker:        masker,
+		},
+		Campaigns: &notificationPort.HTTPServer{
+			CreateCampaign: (&notificationCommand.CreateCampaignHandler{Campaigns: campaignRepo, Queue: campaignQueue}).Decorated(),
+			CancelCampaign: (&notificationCommand.CancelCampaignHandler{Campaigns: campaignRepo, Deliveries: deliveryRepo}).Decorated(),
+			RecordOpen:     (&notificationCommand.RecordOpenHandler{Deliveries: deliveryRepo}).Decorated(),
+			Campaigns:      campaignRepo,
+			Deliveries:     deliveryRepo,
+			Auth:           authorizer,
+		},
+		Quota: &quotaPort.HTTPServer{
+			Usage:       quotaEnforcer,
+			RateLimiter: rateLimiter,
+		},
+		Billing: &billingPort.HTTPServer{
+			UsageRepo: usageRepo,
+			Pricing:   billingDomain.DefaultPricing,
+		},
+		GraphQL: graphql.NewHandler(&graphql.Resolver{
+			PlaceOrder:   placeOrder,
+			ListProducts: cache.CachedQuery(queryCache, (&productQuery.ListProductsHandler{ProductRepo: productRepo}).Decorated(), productDomain.CacheTag),
+			OrderRepo:    orderRepo,
+			ProductRepo:  productRepo,
+			UserRepo:     userRepo,
+			Addresses:    addressRepo,
+			Auth:         authorizer,
+			Masker:       masker,
+			Converter:    currencyConverter,
+		}),
+		Build:   build,
+		Blobs:   blobHandler,
+		Metrics: appMetrics.Handler(),
+	})
+
+	// `aiiobackend bootstrap` prepares a new environment and exits; INFRA_BOOTSTRAP=true does it on every start
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap" {
+		ensureInfrastructure(infra)
+		return
+	}
+	// `aiiobackend assign-role <user-id> <role>` grants a role, e.g. to create the first admin
+	if len(os.Args) > 1 && os.Args[1] == "assign-role" {
+		assignRole(roleRepo, userRepo, os.Args[2:])
+		return
+	}
+	// `aiiobackend projections replay --projection <name> [--from <time>] [--rate <n>]` rebuilds a read model from the outbox log
+	if len(os.Args) > 2 && os.Args[1] == "projections" && os.Args[2] == "replay" {
+		replayProjection(projections, os.Args[3:])
+		return
+	}
+	// `aiiobackend repair list|runs|run <script> [--dry-run] [--batch-size <n>] [--again]` runs a one-off data repair
+	if len(os.Args) > 1 && os.Args[1] == "repair" {
+		if dbMode != migration.ModeReadWrite {
+			log.Fatal("Data repairs need a writable database schema")
+		}
+		repairData(repair.NewRunner(db,
+			userAdapter.NewNormalizeEmailsScript(db),
+		), os.Args[2:])
+		return
+	}
+	// `aiiobackend search reindex` writes the whole catalogue to the search index, e.g. after it was lost
+	if len(os.Args) > 2 && os.Args[1] == "search" && os.Args[2] == "reindex" {
+		if searchIndex == nil {
+			log.Fatal("Reindexing needs SEARCH_BACKEND")
+		}
+		reindexSearch((&productCommand.ReindexSearchHandler{ProductRepo: productRepo, Index: searchIndex}).Decorated())
+		return
+	}
+	// `aiiobackend seed <file>...` loads fixture files of users, products and orders, e.g. for local development
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		seedFixtures(&seed.Seeder{Users: userRepo, Roles: roleRepo, Products: productRepo, Orders: orderRepo}, os.Args[2:])
+		return
+	}
+	// `aiiobackend serve --selftest` checks every configured dependency, prints a report and exits
+	if selftestMode() {
+		suite := selftest.Suite{Timeout: selftestTimeout}
+		suite.Register(persistence.WriteCheck{
+			DB:    db,
+			Probe: &jobs.Record{Kind: messaging.PingType, Payload: "{}", Status: jobs.StatusDone, RunAt: time.Now()},
+		})
+		if broker != nil {
+			suite.Register(messaging.PublishCheck{Publisher: broker, Topic: messagingConfig.OrderTopic})
+		} else {
+			suite.Register(selftest.Func{Name: "broker", Fn: func(context.Context) error {
+				return selftest.Skip("MESSAGING_DRIVER is none")
+			}})
+		}
+		suite.Register(evidenceStore, blobStore)
+		if smtpConfig.Addr() != "" {
+			suite.Register(notificationAdapter.NewSMTPNotifier(smtpConfig.Addr(), smtpConfig.Auth(), smtpConfig.From))
+		} else {
+			suite.Register(selftest.Func{Name: "smtp", Fn: func(context.Context) error {
+				return selftest.Skip("SMTP_HOST is not set")
+			}})
+		}
+		writeSelftestReport(suite.Run(context.Background()))
+	}
+	if cfg.Bootstrap.OnStartup {
+		ensureInfrastructure(infra)
+	}
+
+	serverConfig := cfg.Server
+
+	// Jobs write to the database, so they stay off while the schema is incompatible
+	if dbMode == migration.ModeReadWrite {
+		if err := roleRepo.Seed(context.Background(), userDomain.DefaultRoles()); err != nil {
+			log.Fatalf("Failed to seed roles: %v", err)
+		}
+		for i := range warehouses {
+			if err := warehouseRepo.Save(context.Background(), &warehouses[i]); err != nil {
+				log.Fatalf("Failed to save warehouse %s: %v", warehouses[i].Code, err)
+			}
+		}
+		// The relay starts first so it stops last and flushes what the jobs stored in the outbox
+		if relay != nil {
+			relay.Start()
+			shutdown.OnShutdown("outbox relay", func(ctx context.Context) error {
+				if err := lifecycle.Wait(relay.Stop)(ctx); err != nil {
+					return err
+				}
+				return relay.Flush(ctx)
+			})
+			projections.Start()
+			shutdown.OnShutdown("projections", lifecycle.Within(serverConfig.WorkerDrainTimeout, projections.Drain))
+		}
+		// Jobs still running after the drain timeout go back to the queue for another instance
+		worker.Start()
+		shutdown.OnShutdown("job worker", lifecycle.Within(serverConfig.WorkerDrainTimeout, worker.Drain))
+		scheduler.Start()
+		shutdown.OnShutdown("job scheduler", lifecycle.Wait(scheduler.Stop))
+	}
+
+	if warmupConfig.OnStartup {
+		startCacheWarmup(caches, shutdown)
+	}
+
+	var handler http.Handler = router
+	if dbMode == migration.ModeReadOnly {
+		log.Printf("Database schema differs from version %d beyond tolerance, serving read-only", config.SchemaVersion)
+		handler = httpx.ReadOnly(handler)
+	}
+
+	// CORS runs before the tenant, auth and rate limit so that preflight requests, which carry
+	// none of their headers, are answered without counting against the quota
+	stack := []middleware.Middleware{tracing.Middleware, middleware.RequestID}
+	if serverConfig.AccessLog {
+		stack = append(stack, middleware.AccessLog)
+	}
+	stack = append(stack,
+		middleware.Recovery,
+		middleware.CORS(serverConfig.CORS),
+		middleware.Gzip(serverConfig.GzipMinSize),
+		(&tenant.Resolver{Domain: cfg.Tenant.Domain}).Middleware,
+		mode.Middleware(modeResolver),
+		auth.Middleware,
+		userPort.APIKeyMiddleware((&userCommand.AuthenticateAPIKeyHandler{UserRepo: userRepo, Keys: apiKeyRepo}).Decorated(), modeResolver),
+		tenant.BypassMiddleware(authorizer, userDomain.PermissionTenantBypass),
+		rollout.Middleware,
+		settingPort.Middleware(settings),
+		quotaPort.RateLimitMiddleware(rateLimiter),
+		billingPort.MeteringMiddleware(meter, userPort.APIKeyID),
+	)
+	server := &http.Server{
+		Addr:    serverConfig.Addr,
+		Handler: middleware.Chain(handler, stack...),
+	}
+	// Shutdown stops accepting connections and waits for the in-flight requests and their commands
+	shutdown.OnShutdown("http server", server.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("HTTP server listening on %s (API docs at /docs)", serverConfig.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		log.Fatalf("HTTP server stopped: %v", err)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process right away
+	stop()
+
+	log.Printf("Shutting down, draining for up to %s", serverConfig.ShutdownTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	if err := shutdown.Shutdown(drainCtx); err != nil {
+		log.Fatalf("Shutdown incomplete: %v", err)
+	}
+	log.Println("Shutdown complete")
+}
+
+// startCacheWarmup warms the registered caches in the background; shutting down cancels it
+func startCacheWarmup(caches *warmup.Warmer, shutdown *lifecycle.Manager) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := caches.Run(ctx); err != nil {
+			log.Printf("Cache warm-up incomplete: %v", err)
+		}
+	}()
+	shutdown.OnShutdown("cache warm-up", lifecycle.Wait(func() {
+		cancel()
+		<-done
+	}))
+}
+
+// selftestTimeout bounds each check of a self-test
+const selftestTimeout = 10 * time.Second
+
+func selftestMode() bool {
+	return len(os.Args) > 2 && os.Args[1] == "serve" && os.Args[2] == "--selftest"
+}
+
+// writeSelftestReport prints the report as JSON and exits, with status 1 when a check failed
+func writeSelftestReport(report selftest.Report) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write self-test report: %v", err)
+	}
+	if !report.OK {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// ensureInfrastructure creates every missing registered resource; it is safe to run repeatedly
+func ensureInfrastructure(infra *bootstrap.Registry) {
+	if err := infra.EnsureAll(context.Background()); err != nil {
+		log.Fatalf("Failed to bootstrap infrastructure: %v", err)
+	}
+	log.Println("Infrastructure bootstrap completed")
+}
+
+// assignRole seeds the default roles and gives one of them to the user with the given public ID
+func assignRole(roles userDomain.RoleRepository, users userDomain.UserRepository, args []string) {
+	if len(args) != 2 {
+		log.Fatal("Usage: aiiobackend assign-role <user-id> <role>")
+	}
+	// Operators may assign roles, superadmin included, to the users of every tenant
+	ctx := tenant.Unscoped(context.Background())
+	u, err := users.GetByPublicID(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to find user %s: %v", args[0], err)
+	}
+	if err := roles.Seed(ctx, userDomain.DefaultRoles()); err != nil {
+		log.Fatalf("Failed to seed roles: %v", err)
+	}
+	if err := roles.Assign(ctx, u.ID, args[1]); err != nil {
+		log.Fatalf("Failed to assign role %s to user %s: %v", args[1], args[0], err)
+	}
+	log.Printf("Assigned role %s to user %s", args[1], args[0])
+}
+
+func reindexSearch(reindex decorator.CommandHandler[productCommand.ReindexSearchCommand]) {
+	// The index holds the products of every tenant
+	if err := reindex.Handle(tenant.Unscoped(context.Background()), productCommand.ReindexSearchCommand{}); err != nil {
+		log.Fatalf("Failed to reindex the catalogue: %v", err)
+	}
+	log.Println("Reindexed the catalogue")
+}
+
+func seedFixtures(seeder *seed.Seeder, paths []string) {
+	if len(paths) == 0 {
+		log.Fatal("Usage: aiiobackend seed <file>...")
+	}
+	for _, path := range paths {
+		fixtures, err := seed.Load(path)
+		if err != nil {
+			log.Fatalf("Failed to load fixtures: %v", err)
+		}
+		res, err := seeder.Seed(context.Background(), fixtures)
+		if err != nil {
+			log.Fatalf("Failed to seed %s: %v", path, err)
+		}
+		log.Printf("Seeded %d users, %d products and %d orders from %s", res.Users, res.Products, res.Orders, path)
+	}
+}
+
+func repairData(repairs *repair.Runner, args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: aiiobackend repair list | runs <script> | run <script> [--dry-run] [--batch-size <n>] [--again]")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	switch args[0] {
+	case "list":
+		for _, s := range repairs.Scripts() {
+			fmt.Printf("%-30s %s\n", s.Name(), s.Description())
+		}
+	case "runs":
+		if len(args) != 2 {
+			usage()
+		}
+		runs, err := repairs.Runs(context.Background(), args[1])
+		if err != nil {
+			log.Fatalf("Failed to list runs of %s: %v", args[1], err)
+		}
+		for _, run := range runs {
+			fmt.Printf("%d\t%s\tdry_run=%t\tby %s\tstarted %s\tscanned=%d changed=%d cursor=%d\t%s\n",
+				run.ID, run.Status, run.DryRun, run.Operator, run.StartedAt.Format(time.RFC3339), run.Scanned, run.Changed, run.Cursor, run.Error)
+		}
+	case "run":
+		if len(args) < 2 {
+			usage()
+		}
+		flags := flag.NewFlagSet("repair run", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "roll every batch back and only report what would change")
+		batchSize := flags.Int("batch-size", 500, "rows looked at per batch, each committed in its own transaction")
+		again := flags.Bool("again", false, "run a script again although its last run completed")
+		_ = flags.Parse(args[2:])
+
+		operator := os.Getenv("USER")
+		if u, err := user.Current(); err == nil {
+			operator = u.Username
+		}
+
+		// Interrupting stops after the current batch; running the script again resumes it. Scripts
+		// repair the rows of every tenant.
+		ctx, stop := signal.NotifyContext(tenant.Unscoped(context.Background()), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		run, err := repairs.Run(ctx, args[1], repair.Options{DryRun: *dryRun, BatchSize: *batchSize, Again: *again, Operator: operator})
+		if err != nil {
+			if run != nil {
+				log.Fatalf("Repair %s stopped as run %d after %d rows (%d changed): %v", args[1], run.ID, run.Scanned, run.Changed, err)
+			}
+			log.Fatalf("Repair %s did not start: %v", args[1], err)
+		}
+		verb := "Changed"
+		if run.DryRun {
+			verb = "Would change"
+		}
+		log.Printf("%s %d of %d rows with %s (run %d)", verb, run.Changed, run.Scanned, args[1], run.ID)
+	default:
+		usage()
+	}
+}
+
+func replayProjection(projections *projection.Runner, args []string) {
+	flags := flag.NewFlagSet("projections replay", flag.ExitOnError)
+	name := flags.String("projection", "", "projection to rebuild, one of: "+strings.Join(projections.Names(), ", "))
+	from := flags.String("from", "", "replay the messages stored since this RFC 3339 time; without it an interrupted replay resumes")
+	rate := flags.Int("rate", 500, "messages handled per second at most, 0 for no limit")
+	_ = flags.Parse(args)
+	if *name == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// Interrupting stops after the current message and keeps the checkpoint, so the replay can resume
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var handled int
+	var err error
+	if *from == "" {
+		handled, err = projections.CatchUp(ctx, *name, *rate)
+	} else {
+		since, parseErr := time.Parse(time.RFC3339, *from)
+		if parseErr != nil {
+			log.Fatalf("Invalid --from %q, expected an RFC 3339 time such as 2024-05-01T00:00:00Z", *from)
+		}
+		handled, err = projections.Replay(ctx, *name, since, *rate)
+	}
+	if err != nil {
+		log.Fatalf("Replay of %s stopped after %d messages: %v", *name, handled, err)
+	}
+	log.Printf("Replayed %d messages into %s", handled, *name)
 }
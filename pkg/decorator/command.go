@@ -0,0 +1,154 @@
+// Package decorator is the command and query bus of the application layer: handlers are wrapped
+// once when wiring, so every call runs in its own span, commands read from the primary and
+// failures carry the name of the command or query that failed.
+package decorator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CommandHandler is implemented by every application command handler
+type CommandHandler[C any] interface {
+	Handle(ctx context.Context, cmd C) error
+}
+
+// ApplyCommandDecorators wraps a command handler with the shared command middleware: every command
+// runs in its own span, reads are pinned to the primary database and failures are annotated with the command name
+func ApplyCommandDecorators[C any](handler CommandHandler[C]) CommandHandler[C] {
+	return commandTracingDecorator[C]{
+		base: commandErrorDecorator[C]{
+			base: commandPrimaryDecorator[C]{base: handler},
+		},
+	}
+}
+
+type commandTracingDecorator[C any] struct {
+	base CommandHandler[C]
+}
+
+func (d commandTracingDecorator[C]) Handle(ctx context.Context, cmd C) error {
+	ctx, span := startCommandSpan(ctx, cmd)
+	defer span.End()
+
+	err := d.base.Handle(ctx, cmd)
+	recordCommandError(span, err)
+	return err
+}
+
+// commandPrimaryDecorator routes every query of a command to the primary so handlers read their own writes
+type commandPrimaryDecorator[C any] struct {
+	base CommandHandler[C]
+}
+
+func (d commandPrimaryDecorator[C]) Handle(ctx context.Context, cmd C) error {
+	return d.base.Handle(persistence.WithPrimary(ctx), cmd)
+}
+
+// CommandError annotates a failed command with its name without changing the error message,
+// so callers keep matching on the cause with errors.Is/As while logs show where it came from
+type CommandError struct {
+	Command string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+type commandErrorDecorator[C any] struct {
+	base CommandHandler[C]
+}
+
+func (d commandErrorDecorator[C]) Handle(ctx context.Context, cmd C) error {
+	err := d.base.Handle(ctx, cmd)
+	if err == nil {
+		return nil
+	}
+
+	name := commandName(cmd)
+	slog.ErrorContext(ctx, "command failed", "command", name, "error", err)
+	return &CommandError{Command: name, Err: err}
+}
+
+func startCommandSpan(ctx context.Context, cmd interface{}) (context.Context, trace.Span) {
+	name := commandName(cmd)
+	return currentTracer().Start(ctx, name, trace.WithAttributes(attribute.String("command", name)))
+}
+
+func recordCommandError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// commandName returns the unqualified type name of a command, e.g. "PlaceOrderCommand"
+func commandName(cmd interface{}) string {
+	name := fmt.Sprintf("%T", cmd)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// CommandResultHandler is implemented by command handlers that return the created or changed aggregate
+type CommandResultHandler[C any, R any] interface {
+	Handle(ctx context.Context, cmd C) (R, error)
+}
+
+// ApplyCommandResultDecorators wraps a result-returning command handler with the shared command middleware
+func ApplyCommandResultDecorators[C any, R any](handler CommandResultHandler[C, R]) CommandResultHandler[C, R] {
+	return commandResultTracingDecorator[C, R]{
+		base: commandResultErrorDecorator[C, R]{
+			base: commandResultPrimaryDecorator[C, R]{base: handler},
+		},
+	}
+}
+
+type commandResultTracingDecorator[C any, R any] struct {
+	base CommandResultHandler[C, R]
+}
+
+func (d commandResultTracingDecorator[C, R]) Handle(ctx context.Context, cmd C) (R, error) {
+	ctx, span := startCommandSpan(ctx, cmd)
+	defer span.End()
+
+	result, err := d.base.Handle(ctx, cmd)
+	recordCommandError(span, err)
+	return result, err
+}
+
+type commandResultPrimaryDecorator[C any, R any] struct {
+	base CommandResultHandler[C, R]
+}
+
+func (d commandResultPrimaryDecorator[C, R]) Handle(ctx context.Context, cmd C) (R, error) {
+	return d.base.Handle(persistence.WithPrimary(ctx), cmd)
+}
+
+type commandResultErrorDecorator[C any, R any] struct {
+	base CommandResultHandler[C, R]
+}
+
+func (d commandResultErrorDecorator[C, R]) Handle(ctx context.Context, cmd C) (R, error) {
+	result, err := d.base.Handle(ctx, cmd)
+	if err == nil {
+		return result, nil
+	}
+
+	name := commandName(cmd)
+	slog.ErrorContext(ctx, "command failed", "command", name, "error", err)
+	return result, &CommandError{Command: name, Err: err}
+}
@@ -0,0 +1,85 @@
+package decorator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type renameCommand struct {
+	Name string
+}
+
+type renameHandler struct {
+	err        error
+	usePrimary *bool
+}
+
+func (h renameHandler) Handle(ctx context.Context, cmd renameCommand) error {
+	if h.usePrimary != nil {
+		*h.usePrimary = persistence.UsesPrimary(ctx)
+	}
+	return h.err
+}
+
+func TestApplyCommandDecorators_AnnotatesErrors(t *testing.T) {
+	cause := errors.New("user not found")
+	handler := decorator.ApplyCommandDecorators[renameCommand](renameHandler{err: cause})
+
+	err := handler.Handle(context.Background(), renameCommand{Name: "x"})
+
+	var cmdErr *decorator.CommandError
+	assert.ErrorAs(t, err, &cmdErr)
+	assert.Equal(t, "renameCommand", cmdErr.Command)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "user not found", err.Error())
+}
+
+func TestApplyCommandDecorators_PassesThroughSuccess(t *testing.T) {
+	var usePrimary bool
+	handler := decorator.ApplyCommandDecorators[renameCommand](renameHandler{usePrimary: &usePrimary})
+
+	assert.NoError(t, handler.Handle(context.Background(), renameCommand{}))
+	assert.True(t, usePrimary, "commands must read from the primary")
+}
+
+func TestApplyCommandDecorators_StartsSpanPerCommand(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	cause := errors.New("user not found")
+	handler := decorator.ApplyCommandDecorators[renameCommand](renameHandler{err: cause})
+
+	_ = handler.Handle(context.Background(), renameCommand{})
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "renameCommand", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	}
+}
+
+func TestSetTracer_StartsSpansWithTheGivenTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	decorator.SetTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("app"))
+	defer decorator.SetTracer(nil)
+
+	handler := decorator.ApplyCommandDecorators[renameCommand](renameHandler{})
+	_ = handler.Handle(context.Background(), renameCommand{})
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "renameCommand", spans[0].Name())
+		assert.Equal(t, "app", spans[0].InstrumentationScope().Name)
+	}
+}
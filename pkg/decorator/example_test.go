@@ -0,0 +1,36 @@
+package decorator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+)
+
+type RenameProjectCommand struct {
+	ProjectID int64
+	Name      string
+}
+
+type RenameProjectHandler struct{}
+
+func (RenameProjectHandler) Handle(ctx context.Context, cmd RenameProjectCommand) error {
+	if cmd.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func ExampleApplyCommandDecorators() {
+	// wrapped once when wiring, so every caller gets the span, the primary pinning and the annotated error
+	rename := decorator.ApplyCommandDecorators[RenameProjectCommand](RenameProjectHandler{})
+
+	err := rename.Handle(context.Background(), RenameProjectCommand{ProjectID: 7})
+	var cmdErr *decorator.CommandError
+	if errors.As(err, &cmdErr) {
+		fmt.Printf("%s: %v\n", cmdErr.Command, cmdErr.Err)
+	}
+	// Output:
+	// RenameProjectCommand: name is required
+}
@@ -0,0 +1,52 @@
+package decorator
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryHandler is implemented by application query handlers, which read models without changing them
+type QueryHandler[Q any, R any] interface {
+	Handle(ctx context.Context, q Q) (R, error)
+}
+
+// ApplyQueryDecorators wraps a query handler with the shared query middleware: every query runs in
+// its own span and failures are annotated with the query name. Unlike commands, queries may read
+// from a replica.
+func ApplyQueryDecorators[Q any, R any](handler QueryHandler[Q, R]) QueryHandler[Q, R] {
+	return queryTracingDecorator[Q, R]{
+		base: queryErrorDecorator[Q, R]{base: handler},
+	}
+}
+
+type queryTracingDecorator[Q any, R any] struct {
+	base QueryHandler[Q, R]
+}
+
+func (d queryTracingDecorator[Q, R]) Handle(ctx context.Context, q Q) (R, error) {
+	name := commandName(q)
+	ctx, span := currentTracer().Start(ctx, name, trace.WithAttributes(attribute.String("query", name)))
+	defer span.End()
+
+	result, err := d.base.Handle(ctx, q)
+	recordCommandError(span, err)
+	return result, err
+}
+
+type queryErrorDecorator[Q any, R any] struct {
+	base QueryHandler[Q, R]
+}
+
+func (d queryErrorDecorator[Q, R]) Handle(ctx context.Context, q Q) (R, error) {
+	result, err := d.base.Handle(ctx, q)
+	if err == nil {
+		return result, nil
+	}
+
+	name := commandName(q)
+	slog.ErrorContext(ctx, "query failed", "query", name, "error", err)
+	return result, &CommandError{Command: name, Err: err}
+}
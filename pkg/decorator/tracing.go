@@ -0,0 +1,25 @@
+package decorator
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracer of the decorator spans unless SetTracer replaces it
+const InstrumentationName = "github.com/mohsenjafari-aiio/aiiobackend/pkg/decorator"
+
+var tracer trace.Tracer
+
+// SetTracer makes decorated handlers start their spans with t, e.g. the tracer of the application.
+// Call it while wiring, before any handler runs; nil restores the tracer of the global provider.
+func SetTracer(t trace.Tracer) {
+	tracer = t
+}
+
+// currentTracer is looked up per span so a provider installed after wiring still records the spans
+func currentTracer() trace.Tracer {
+	if tracer != nil {
+		return tracer
+	}
+	return otel.Tracer(InstrumentationName)
+}
@@ -0,0 +1,27 @@
+package persistence
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChangePosition is a place among the rows of a table in the order they last changed: by their
+// updated_at column, then by their id. A client that synced up to a position reads the rows
+// changed since then with ChangedAfter.
+type ChangePosition struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        int64     `json:"id"`
+}
+
+// ChangedAfter narrows db to up to limit rows that changed after pos and before until, in change
+// order. Rows changed at until or later are left for the next read, so that rows written by
+// transactions still in flight, whose updated_at may be earlier than that of rows already
+// committed, are not skipped.
+func ChangedAfter(db *gorm.DB, pos ChangePosition, until time.Time, limit int) *gorm.DB {
+	return db.
+		Where("updated_at > ? OR (updated_at = ? AND id > ?)", pos.UpdatedAt, pos.UpdatedAt, pos.ID).
+		Where("updated_at < ?", until).
+		Order("updated_at ASC, id ASC").
+		Limit(limit)
+}
@@ -0,0 +1,39 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// errRollback ends the transaction of a WriteCheck without keeping its row
+var errRollback = errors.New("rollback")
+
+// WriteCheck verifies the database serves reads and takes writes, for selftest.Suite. Probe is a
+// new row of a migrated model; it is inserted in a transaction that is rolled back.
+type WriteCheck struct {
+	DB    *gorm.DB
+	Probe any
+}
+
+func (c WriteCheck) Describe() string {
+	return "database read/write"
+}
+
+func (c WriteCheck) Check(ctx context.Context) error {
+	var one int
+	if err := c.DB.WithContext(ctx).Raw("SELECT 1").Scan(&one).Error; err != nil {
+		return TranslateError(err)
+	}
+	err := c.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c.Probe).Error; err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+	return TranslateError(err)
+}
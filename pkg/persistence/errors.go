@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Shared repository errors; adapters translate driver specific errors into these so the
+// application layer can branch on them with errors.Is without depending on GORM or pgx
+var (
+	ErrNotFound            = errors.New("record not found")
+	ErrDuplicateKey        = errors.New("duplicate key")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+)
+
+// Postgres SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// TranslateError maps GORM and driver errors onto the shared repository errors.
+// The original error stays in the chain so it can still be logged or inspected.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if kind := classify(err); kind != nil {
+		return &translatedError{kind: kind, cause: err}
+	}
+	return err
+}
+
+func classify(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrDuplicateKey), errors.Is(err, ErrForeignKeyViolation):
+		// Already translated
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateKey
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return ErrForeignKeyViolation
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return ErrDuplicateKey
+		case pgForeignKeyViolation:
+			return ErrForeignKeyViolation
+		}
+	}
+
+	return nil
+}
+
+// translatedError matches both the shared error kind and the original cause with errors.Is/As
+type translatedError struct {
+	kind  error
+	cause error
+}
+
+func (e *translatedError) Error() string {
+	if e.kind.Error() == e.cause.Error() {
+		return e.kind.Error()
+	}
+	return e.kind.Error() + ": " + e.cause.Error()
+}
+
+func (e *translatedError) Unwrap() []error {
+	return []error{e.kind, e.cause}
+}
@@ -0,0 +1,45 @@
+package persistence_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "record not found", err: gorm.ErrRecordNotFound, expected: persistence.ErrNotFound},
+		{name: "gorm duplicated key", err: gorm.ErrDuplicatedKey, expected: persistence.ErrDuplicateKey},
+		{name: "gorm foreign key", err: gorm.ErrForeignKeyViolated, expected: persistence.ErrForeignKeyViolation},
+		{name: "pg unique violation", err: &pgconn.PgError{Code: "23505"}, expected: persistence.ErrDuplicateKey},
+		{name: "pg foreign key violation", err: &pgconn.PgError{Code: "23503"}, expected: persistence.ErrForeignKeyViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translated := persistence.TranslateError(tt.err)
+
+			assert.ErrorIs(t, translated, tt.expected)
+			assert.ErrorIs(t, translated, tt.err, "original error must stay in the chain")
+		})
+	}
+}
+
+func TestTranslateError_PassesThroughOtherErrors(t *testing.T) {
+	assert.NoError(t, persistence.TranslateError(nil))
+
+	other := errors.New("connection refused")
+	assert.Equal(t, other, persistence.TranslateError(other))
+
+	translated := persistence.TranslateError(gorm.ErrRecordNotFound)
+	assert.Equal(t, translated, persistence.TranslateError(translated), "translation must be idempotent")
+	assert.Equal(t, "record not found", translated.Error())
+}
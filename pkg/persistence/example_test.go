@@ -0,0 +1,101 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type Account struct {
+	ID      int64 `gorm:"primaryKey"`
+	Owner   string
+	Balance int64
+}
+
+// AccountRepository embeds the CRUD methods and adds its own next to them
+type AccountRepository struct {
+	*persistence.GenericRepository[Account, int64]
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{GenericRepository: persistence.NewGenericRepository[Account, int64](db), db: db}
+}
+
+// Adjust changes a balance in the transaction bound to ctx, if any
+func (r *AccountRepository) Adjust(ctx context.Context, id, delta int64) error {
+	err := persistence.Conn(ctx, r.db).Model(&Account{}).Where("id = ?", id).
+		Update("balance", gorm.Expr("balance + ?", delta)).Error
+	return persistence.TranslateError(err)
+}
+
+func openBank() *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true, Logger: logger.Discard})
+	if err != nil {
+		panic(err)
+	}
+	// every connection to :memory: opens a database of its own
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&Account{}); err != nil {
+		panic(err)
+	}
+	return db
+}
+
+func ExampleGenericRepository() {
+	ctx := context.Background()
+	repo := NewAccountRepository(openBank())
+	for _, owner := range []string{"ann", "bob", "cid"} {
+		if err := repo.Create(ctx, &Account{Owner: owner, Balance: 100}); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	secondPage := func(qb *query.QueryBuilder) *query.QueryBuilder {
+		return qb.AddSort("owner", query.SortOrderAsc).SetPagination(2, 2)
+	}
+	accounts, _ := repo.List(ctx, secondPage)
+	total, _ := repo.Count(ctx, secondPage)
+	fmt.Println(len(accounts), accounts[0].Owner, total)
+
+	_, err := repo.GetByID(ctx, 42)
+	fmt.Println(errors.Is(err, persistence.ErrNotFound))
+	// Output:
+	// 1 cid 3
+	// true
+}
+
+func ExampleGormTransactor() {
+	ctx := context.Background()
+	db := openBank()
+	repo := NewAccountRepository(db)
+	from, to := &Account{Owner: "ann", Balance: 100}, &Account{Owner: "bob"}
+	_ = repo.Create(ctx, from)
+	_ = repo.Create(ctx, to)
+
+	// both adjustments are rolled back, since the transfer fails after them
+	err := persistence.NewGormTransactor(db).InTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Adjust(ctx, from.ID, -150); err != nil {
+			return err
+		}
+		if err := repo.Adjust(ctx, to.ID, 150); err != nil {
+			return err
+		}
+		return errors.New("insufficient funds")
+	})
+	fmt.Println(err)
+
+	ann, _ := repo.GetByID(ctx, from.ID)
+	fmt.Println(ann.Balance)
+	// Output:
+	// insufficient funds
+	// 100
+}
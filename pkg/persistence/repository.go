@@ -0,0 +1,136 @@
+// Package persistence holds what repositories share on top of GORM: GenericRepository for the
+// CRUD of a model, units of work spanning repositories through a transaction bound to the
+// context, routing of reads between a primary and its replicas, and driver errors translated to
+// ErrNotFound and its siblings.
+package persistence
+
+import (
+	"context"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Query narrows the rows List and Count see, e.g. with filters, sorting and pagination
+type Query func(qb *query.QueryBuilder) *query.QueryBuilder
+
+// GenericRepository implements the CRUD methods of model T with primary key type ID. Module
+// repositories embed it next to their domain-specific methods. Every method joins the transaction
+// bound to ctx and returns errors translated by TranslateError.
+type GenericRepository[T any, ID comparable] struct {
+	db *gorm.DB
+}
+
+func NewGenericRepository[T any, ID comparable](db *gorm.DB) *GenericRepository[T, ID] {
+	return &GenericRepository[T, ID]{db: db}
+}
+
+// byPrimaryKey matches the row with the given primary key, whatever its column is named
+func byPrimaryKey(id any) clause.Expression {
+	return clause.Eq{Column: clause.PrimaryColumn, Value: id}
+}
+
+// GetByID returns ErrNotFound when no row has the primary key id
+func (r *GenericRepository[T, ID]) GetByID(ctx context.Context, id ID) (*T, error) {
+	var entity T
+	if err := Conn(ctx, r.db).Where(byPrimaryKey(id)).First(&entity).Error; err != nil {
+		return nil, TranslateError(err)
+	}
+	return &entity, nil
+}
+
+// List returns the rows q selects, or every row when q is nil
+func (r *GenericRepository[T, ID]) List(ctx context.Context, q Query) ([]T, error) {
+	var entities []T
+	if err := r.build(ctx, q).Find(&entities).Error; err != nil {
+		return nil, TranslateError(err)
+	}
+	return entities, nil
+}
+
+// Count counts the rows q selects, or every row when q is nil; pagination in q is ignored
+func (r *GenericRepository[T, ID]) Count(ctx context.Context, q Query) (int64, error) {
+	var n int64
+	err := r.build(ctx, q).Offset(-1).Limit(-1).Count(&n).Error
+	return n, TranslateError(err)
+}
+
+// InBatches calls fn with the rows q selects, or every row when q is nil, size rows at a time in
+// primary key order, so large results such as exports never sit in memory at once. q must not
+// sort or paginate. fn must not keep the slice, which is reused for the next batch; an error
+// from fn stops the walk and is returned.
+func (r *GenericRepository[T, ID]) InBatches(ctx context.Context, q Query, size int, fn func([]T) error) error {
+	return InBatches(r.build(ctx, q), size, fn)
+}
+
+// InBatches is GenericRepository.InBatches for a query built by the caller, e.g. with preloads
+func InBatches[T any](db *gorm.DB, size int, fn func([]T) error) error {
+	var batch []T
+	err := db.FindInBatches(&batch, size, func(*gorm.DB, int) error {
+		return fn(batch)
+	}).Error
+	return TranslateError(err)
+}
+
+// InKeyOrder arranges rows fetched with a single IN query in the order of the keys asked for,
+// key extracting the key of a row. Keys without a row are skipped and repeated keys yield their
+// row once, at the first position they appear.
+func InKeyOrder[T any, K comparable](keys []K, rows []T, key func(*T) K) []T {
+	byKey := make(map[K]int, len(rows))
+	for i := range rows {
+		byKey[key(&rows[i])] = i
+	}
+	ordered := make([]T, 0, len(rows))
+	for _, k := range keys {
+		if i, ok := byKey[k]; ok {
+			ordered = append(ordered, rows[i])
+			delete(byKey, k)
+		}
+	}
+	return ordered
+}
+
+func (r *GenericRepository[T, ID]) build(ctx context.Context, q Query) *gorm.DB {
+	qb := query.NewQueryBuilder(Conn(ctx, r.db).Model(new(T)))
+	if q != nil {
+		qb = q(qb)
+	}
+	return qb.Build()
+}
+
+// Create inserts entity and fills in its generated primary key
+func (r *GenericRepository[T, ID]) Create(ctx context.Context, entity *T) error {
+	return TranslateError(Conn(ctx, r.db).Create(entity).Error)
+}
+
+// Update writes every column of entity, zero values included, leaving its associations alone.
+// It returns ErrNotFound when the row does not exist rather than inserting it like gorm's Save.
+func (r *GenericRepository[T, ID]) Update(ctx context.Context, entity *T) error {
+	result := Conn(ctx, r.db).Model(entity).Select("*").Omit(clause.Associations).Updates(entity)
+	if result.Error != nil {
+		return TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete returns ErrNotFound when no row has the primary key id
+func (r *GenericRepository[T, ID]) Delete(ctx context.Context, id ID) error {
+	result := Conn(ctx, r.db).Where(byPrimaryKey(id)).Delete(new(T))
+	if result.Error != nil {
+		return TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *GenericRepository[T, ID]) Exists(ctx context.Context, id ID) (bool, error) {
+	var n int64
+	err := Conn(ctx, r.db).Model(new(T)).Where(byPrimaryKey(id)).Limit(1).Count(&n).Error
+	return n > 0, TranslateError(err)
+}
@@ -0,0 +1,140 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	Code  string `gorm:"primaryKey"`
+	Name  string
+	Stock int
+}
+
+func setupWidgets(t *testing.T) *persistence.GenericRepository[widget, string] {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&widget{}))
+
+	repo := persistence.NewGenericRepository[widget, string](db)
+	for _, w := range []widget{{"w1", "Bolt", 10}, {"w2", "Nut", 0}, {"w3", "Washer", 5}} {
+		require.NoError(t, repo.Create(context.Background(), &w))
+	}
+	return repo
+}
+
+func TestGenericRepository_GetByID(t *testing.T) {
+	repo := setupWidgets(t)
+
+	w, err := repo.GetByID(context.Background(), "w2")
+	assert.NoError(t, err)
+	assert.Equal(t, "Nut", w.Name)
+
+	_, err = repo.GetByID(context.Background(), "w9")
+	assert.ErrorIs(t, err, persistence.ErrNotFound)
+}
+
+func TestGenericRepository_ListAndCount(t *testing.T) {
+	repo := setupWidgets(t)
+	ctx := context.Background()
+	inStock := func(qb *query.QueryBuilder) *query.QueryBuilder {
+		return qb.AddFilter("stock", query.OperatorGreaterThan, 0).AddSort("name", query.SortOrderDesc).SetPagination(1, 1)
+	}
+
+	all, err := repo.List(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	page, err := repo.List(ctx, inStock)
+	assert.NoError(t, err)
+	assert.Equal(t, []widget{{"w3", "Washer", 5}}, page)
+
+	n, err := repo.Count(ctx, inStock)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n, "count ignores pagination")
+}
+
+func TestGenericRepository_InBatches(t *testing.T) {
+	repo := setupWidgets(t)
+	ctx := context.Background()
+
+	var sizes []int
+	var codes []string
+	err := repo.InBatches(ctx, nil, 2, func(batch []widget) error {
+		sizes = append(sizes, len(batch))
+		for _, w := range batch {
+			codes = append(codes, w.Code)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, sizes)
+	assert.Equal(t, []string{"w1", "w2", "w3"}, codes)
+
+	inStock := func(qb *query.QueryBuilder) *query.QueryBuilder {
+		return qb.AddFilter("stock", query.OperatorGreaterThan, 0)
+	}
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.InBatches(ctx, inStock, 1, func(batch []widget) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls, "an error from fn ends the walk")
+}
+
+func TestInKeyOrder(t *testing.T) {
+	rows := []widget{{Code: "w1"}, {Code: "w2"}, {Code: "w3"}}
+
+	ordered := persistence.InKeyOrder([]string{"w3", "w9", "w1", "w3", "w2"}, rows, func(w *widget) string { return w.Code })
+
+	codes := make([]string, len(ordered))
+	for i, w := range ordered {
+		codes[i] = w.Code
+	}
+	assert.Equal(t, []string{"w3", "w1", "w2"}, codes, "unknown and repeated keys are skipped")
+}
+
+func TestGenericRepository_Update(t *testing.T) {
+	repo := setupWidgets(t)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Update(ctx, &widget{Code: "w1", Name: "Bolt"}))
+	w, err := repo.GetByID(ctx, "w1")
+	assert.NoError(t, err)
+	assert.Zero(t, w.Stock, "zero values are written too")
+
+	assert.ErrorIs(t, repo.Update(ctx, &widget{Code: "w9", Name: "Gear"}), persistence.ErrNotFound)
+	exists, err := repo.Exists(ctx, "w9")
+	assert.NoError(t, err)
+	assert.False(t, exists, "updates never insert")
+}
+
+func TestGenericRepository_Delete(t *testing.T) {
+	repo := setupWidgets(t)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Delete(ctx, "w1"))
+	exists, err := repo.Exists(ctx, "w1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.ErrorIs(t, repo.Delete(ctx, "w1"), persistence.ErrNotFound)
+}
+
+func TestGenericRepository_CreateDuplicate(t *testing.T) {
+	repo := setupWidgets(t)
+
+	err := repo.Create(context.Background(), &widget{Code: "w1", Name: "Bolt"})
+
+	assert.ErrorIs(t, err, persistence.ErrDuplicateKey)
+}
@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type primaryKey struct{}
+
+// WithPrimary marks the context so every query issued with it is routed to the primary database.
+// Command handlers use it to read their own writes; query handlers keep the replica default.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether the context was marked with WithPrimary
+func UsesPrimary(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryKey{}).(bool)
+	return pinned
+}
+
+// RegisterPrimaryRouting installs callbacks honoring WithPrimary for reads.
+// Writes always go to the primary; without configured replicas the callbacks are no-ops.
+func RegisterPrimaryRouting(db *gorm.DB) error {
+	pin := func(tx *gorm.DB) {
+		if ctx := tx.Statement.Context; ctx != nil && UsesPrimary(ctx) {
+			dbresolver.Write.ModifyStatement(tx.Statement)
+		}
+	}
+
+	if err := db.Callback().Query().Before("gorm:query").Register("persistence:primary_routing", pin); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("persistence:primary_routing", pin); err != nil {
+		return err
+	}
+	return db.Callback().Raw().Before("gorm:raw").Register("persistence:primary_routing", pin)
+}
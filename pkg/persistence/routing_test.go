@@ -0,0 +1,48 @@
+package persistence_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type routedRecord struct {
+	ID     int64 `gorm:"primaryKey"`
+	Origin string
+}
+
+func openSQLite(t *testing.T, path, origin string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&routedRecord{}))
+	assert.NoError(t, db.Create(&routedRecord{ID: 1, Origin: origin}).Error)
+	return db
+}
+
+func TestWithPrimary_RoutesReadsToPrimary(t *testing.T) {
+	dir := t.TempDir()
+	primaryPath := filepath.Join(dir, "primary.db")
+	replicaPath := filepath.Join(dir, "replica.db")
+
+	db := openSQLite(t, primaryPath, "primary")
+	openSQLite(t, replicaPath, "replica")
+
+	assert.NoError(t, db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.Open(replicaPath)},
+	})))
+	assert.NoError(t, persistence.RegisterPrimaryRouting(db))
+
+	var record routedRecord
+	assert.NoError(t, db.WithContext(context.Background()).First(&record, 1).Error)
+	assert.Equal(t, "replica", record.Origin, "reads default to the replica")
+
+	record = routedRecord{}
+	assert.NoError(t, db.WithContext(persistence.WithPrimary(context.Background())).First(&record, 1).Error)
+	assert.Equal(t, "primary", record.Origin, "WithPrimary pins reads to the primary")
+}
@@ -0,0 +1,42 @@
+package persistence
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs a unit of work spanning several repositories in one database transaction
+type Transactor interface {
+	// InTransaction runs fn in a transaction committed when fn returns nil and rolled back otherwise.
+	// Repositories called with the context passed to fn join the transaction; nested calls join the outer one.
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type GormTransactor struct {
+	db *gorm.DB
+}
+
+func NewGormTransactor(db *gorm.DB) *GormTransactor {
+	return &GormTransactor{db: db}
+}
+
+func (t *GormTransactor) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return TranslateError(t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	}))
+}
+
+// Conn returns the transaction a Transactor bound to ctx, or db outside of one. Repositories that
+// take part in units of work use it in place of db.WithContext(ctx).
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
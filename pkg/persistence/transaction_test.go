@@ -0,0 +1,68 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGormTransactor_RollsBackEveryRepositoryWrite(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "tx.db"), "seed")
+	transactor := persistence.NewGormTransactor(db)
+	create := func(ctx context.Context, id int64) error {
+		return persistence.Conn(ctx, db).Create(&routedRecord{ID: id, Origin: "tx"}).Error
+	}
+	errFailed := errors.New("payment declined")
+
+	err := transactor.InTransaction(context.Background(), func(ctx context.Context) error {
+		if err := create(ctx, 2); err != nil {
+			return err
+		}
+		// A nested unit of work joins the outer transaction instead of committing on its own
+		if err := transactor.InTransaction(ctx, func(ctx context.Context) error { return create(ctx, 3) }); err != nil {
+			return err
+		}
+		return errFailed
+	})
+
+	assert.ErrorIs(t, err, errFailed)
+	var count int64
+	assert.NoError(t, db.Model(&routedRecord{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "only the seed record is left")
+}
+
+func TestGormTransactor_Commits(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "tx.db"), "seed")
+
+	err := persistence.NewGormTransactor(db).InTransaction(context.Background(), func(ctx context.Context) error {
+		return persistence.Conn(ctx, db).Create(&routedRecord{ID: 2, Origin: "tx"}).Error
+	})
+
+	assert.NoError(t, err)
+	var count int64
+	assert.NoError(t, persistence.Conn(context.Background(), db).Model(&routedRecord{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestWriteCheck_LeavesNoRow(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "check.db"), "seed")
+
+	err := persistence.WriteCheck{DB: db, Probe: &routedRecord{Origin: "selftest"}}.Check(context.Background())
+
+	assert.NoError(t, err)
+	var count int64
+	assert.NoError(t, db.Model(&routedRecord{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "the probe is rolled back")
+}
+
+func TestWriteCheck_ReportsRejectedWrite(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "check.db"), "seed")
+
+	err := persistence.WriteCheck{DB: db, Probe: &routedRecord{ID: 1, Origin: "selftest"}}.Check(context.Background())
+
+	assert.Error(t, err)
+}
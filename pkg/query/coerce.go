@@ -0,0 +1,212 @@
+package query
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidFilterValue is returned for filter values that do not fit the operator or the type of
+// the column, e.g. a CONTAINS on a number or "soon" compared with a timestamp
+var ErrInvalidFilterValue = errors.New("invalid filter value")
+
+// InvalidFilterValueError reports why the value of a filter does not fit; it matches
+// ErrInvalidFilterValue with errors.Is
+type InvalidFilterValueError struct {
+	Column string
+	Reason string
+}
+
+func (e *InvalidFilterValueError) Error() string {
+	return fmt.Sprintf("%s for %s: %s", ErrInvalidFilterValue, e.Column, e.Reason)
+}
+
+func (e *InvalidFilterValueError) Unwrap() error {
+	return ErrInvalidFilterValue
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Coerce converts the value of a filter on a column of the model to the Go type of the column, so
+// values decoded from JSON or a query string compare the way the column does: "42" and 42.0 become
+// an int64 for an integer column, and an RFC 3339 string a time.Time for a time column. LIKE
+// operators need a string and IN and NOT IN a list, whose items are converted one by one. Values
+// that cannot be converted fail with an *InvalidFilterValueError. Filters are coerced when the query
+// is built, so Coerce is for handlers checking a filter of a request before querying.
+func (c *Columns) Coerce(filter FilterField) (FilterField, error) {
+	_, filter, err := lookupFilter(c, filter, filter.ColumnName)
+	return filter, err
+}
+
+// coerce converts the value of filter to the type of field
+func coerce(field *schema.Field, filter FilterField) (FilterField, error) {
+	invalid := func(reason string) (FilterField, error) {
+		return FilterField{}, &InvalidFilterValueError{Column: filter.ColumnName, Reason: reason}
+	}
+	value := indirect(filter.Value)
+
+	switch filter.Operator {
+	case OperatorIsNull, OperatorIsNotNull:
+		return filter, nil
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith:
+		rv := reflect.ValueOf(value)
+		if !rv.IsValid() || rv.Kind() != reflect.String {
+			return invalid(fmt.Sprintf("%s needs a string, got %T", filter.Operator, filter.Value))
+		}
+		// Named string types such as order statuses become plain strings for the LIKE pattern
+		filter.Value = rv.String()
+		return filter, nil
+	case OperatorIn, OperatorNotIn:
+		rv := reflect.ValueOf(value)
+		if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return invalid(fmt.Sprintf("%s needs a list, got %T", filter.Operator, filter.Value))
+		}
+		if rv.Len() == 0 {
+			return invalid(fmt.Sprintf("%s needs a non-empty list", filter.Operator))
+		}
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			v, err := coerceValue(field, indirect(rv.Index(i).Interface()))
+			if err != nil {
+				return invalid(fmt.Sprintf("item %d: %s", i, err))
+			}
+			values[i] = v
+		}
+		filter.Value = values
+		return filter, nil
+	default:
+		if value == nil {
+			return invalid(fmt.Sprintf("%s needs a value", filter.Operator))
+		}
+		v, err := coerceValue(field, value)
+		if err != nil {
+			return invalid(err.Error())
+		}
+		filter.Value = v
+		return filter, nil
+	}
+}
+
+// coerceValue converts a single value to the Go type of field. Columns of other types, such as
+// money or JSON columns, and values implementing driver.Valuer are passed through as they are.
+func coerceValue(field *schema.Field, value interface{}) (interface{}, error) {
+	if _, ok := value.(driver.Valuer); ok || field == nil {
+		return value, nil
+	}
+	if n, ok := value.(json.Number); ok {
+		value = string(n)
+	}
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return nil, errors.New("needs a value")
+	}
+
+	typ := field.IndirectFieldType
+	switch {
+	case typ == timeType:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an RFC 3339 time such as 2024-05-01T00:00:00Z", v)
+			}
+			return t, nil
+		}
+		return nil, fmt.Errorf("needs an RFC 3339 time, got %T", value)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		return toInt(rv)
+	case typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uint64:
+		n, err := toInt(rv)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("%d is negative", n)
+		}
+		return uint64(n), nil
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(rv.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(rv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return rv.Float(), nil
+		case reflect.String:
+			f, err := strconv.ParseFloat(rv.String(), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", rv.String())
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("needs a number, got %T", value)
+	case typ.Kind() == reflect.Bool:
+		switch rv.Kind() {
+		case reflect.Bool:
+			return rv.Bool(), nil
+		case reflect.String:
+			b, err := strconv.ParseBool(rv.String())
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a boolean", rv.String())
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("needs a boolean, got %T", value)
+	case typ.Kind() == reflect.String:
+		if rv.Kind() != reflect.String {
+			return nil, fmt.Errorf("needs a string, got %T", value)
+		}
+		return rv.String(), nil
+	}
+	return value, nil
+}
+
+// toInt converts an integer, an integral float or a decimal string to an int64
+func toInt(rv reflect.Value) (int64, error) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("%d is out of range", rv.Uint())
+		}
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is not an integer", f)
+		}
+		return int64(f), nil
+	case reflect.String:
+		n, err := strconv.ParseInt(rv.String(), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", rv.String())
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("needs an integer, got %s", rv.Type())
+}
+
+// indirect dereferences the pointers of optional filter fields; nil pointers become nil
+func indirect(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
@@ -0,0 +1,76 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder_CoercesFilterValues(t *testing.T) {
+	db := setupTestDB(t)
+
+	count := func(filters ...query.FilterField) (int64, error) {
+		var n int64
+		err := query.NewQueryBuilder(db.Model(&TestProduct{})).AddFilters(filters).Build().Count(&n).Error
+		return n, err
+	}
+
+	n, err := count(query.FilterField{ColumnName: "stock", Operator: query.OperatorGreaterOrEqual, Value: "10"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n, "a decimal string compares as an integer")
+
+	n, err = count(query.FilterField{ColumnName: "id", Operator: query.OperatorIn, Value: []any{"1", 2.0, "3"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	for _, f := range []query.FilterField{
+		{ColumnName: "name", Operator: query.OperatorContains, Value: 42},
+		{ColumnName: "name", Operator: query.OperatorStartsWith, Value: nil},
+		{ColumnName: "stock", Operator: query.OperatorEquals, Value: "many"},
+		{ColumnName: "stock", Operator: query.OperatorEquals, Value: 2.5},
+		{ColumnName: "price", Operator: query.OperatorLessThan, Value: true},
+		{ColumnName: "id", Operator: query.OperatorIn, Value: 1},
+		{ColumnName: "id", Operator: query.OperatorNotIn, Value: []int64{}},
+	} {
+		_, err := count(f)
+		assert.ErrorIs(t, err, query.ErrInvalidFilterValue, "%s %s %v", f.ColumnName, f.Operator, f.Value)
+	}
+}
+
+func TestColumns_Coerce(t *testing.T) {
+	type Event struct {
+		ID         int64
+		Kind       string
+		Archived   bool
+		OccurredAt *time.Time
+	}
+	db := setupTestDB(t)
+	columns, err := query.ColumnsOf(db, &Event{})
+	require.NoError(t, err)
+
+	f, err := columns.Coerce(query.FilterField{ColumnName: "occurred_at", Operator: query.OperatorGreaterOrEqual, Value: "2024-05-01T12:00:00+02:00"})
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Equal(f.Value.(time.Time)))
+
+	f, err = columns.Coerce(query.FilterField{ColumnName: "archived", Operator: query.OperatorEquals, Value: "true"})
+	require.NoError(t, err)
+	assert.Equal(t, true, f.Value)
+
+	at := time.Now()
+	f, err = columns.Coerce(query.FilterField{ColumnName: "occurred_at", Operator: query.OperatorLessThan, Value: &at})
+	require.NoError(t, err)
+	assert.Equal(t, at, f.Value, "optional filter fields are dereferenced")
+
+	_, err = columns.Coerce(query.FilterField{ColumnName: "occurred_at", Operator: query.OperatorEquals, Value: "2024-05-01"})
+	var invalid *query.InvalidFilterValueError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "occurred_at", invalid.Column)
+	assert.Contains(t, invalid.Reason, "RFC 3339")
+
+	_, err = columns.Coerce(query.FilterField{ColumnName: "secret", Operator: query.OperatorEquals, Value: "x"})
+	var unknown *query.UnknownColumnError
+	assert.ErrorAs(t, err, &unknown)
+}
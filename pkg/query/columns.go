@@ -0,0 +1,188 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// UnknownColumnError reports a filter, sort or group-by naming a column its model does not have.
+// Column names never reach the SQL unchecked, so a name taken from a request fails with this error
+// instead of injecting SQL.
+type UnknownColumnError struct {
+	Model  string
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("unknown column %q of %s", e.Column, e.Model)
+}
+
+// Columns is the registry of the columns of a model, derived from its gorm schema
+type Columns struct {
+	model  string
+	table  string
+	fields map[string]*schema.Field
+}
+
+// registry caches the Columns of each parsed schema, which gorm itself caches per model
+var registry sync.Map
+
+// ColumnsOf returns the columns of model, e.g. to check a sort field of a request before querying
+func ColumnsOf(db *gorm.DB, model interface{}) (*Columns, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return columnsOf(stmt.Schema), nil
+}
+
+func columnsOf(s *schema.Schema) *Columns {
+	if c, ok := registry.Load(s); ok {
+		return c.(*Columns)
+	}
+	c := &Columns{model: s.Name, table: s.Table, fields: make(map[string]*schema.Field, len(s.DBNames))}
+	for _, name := range s.DBNames {
+		c.fields[name] = s.FieldsByDBName[name]
+	}
+	actual, _ := registry.LoadOrStore(s, c)
+	return actual.(*Columns)
+}
+
+// Lookup returns the quoted, table-qualified column of a name such as "status" or "orders.status".
+// It returns an *UnknownColumnError for any other name, including expressions like "COUNT(*)".
+func (c *Columns) Lookup(name string) (clause.Column, error) {
+	column := name
+	if table, rest, ok := strings.Cut(name, "."); ok {
+		if table != c.table {
+			return clause.Column{}, &UnknownColumnError{Model: c.model, Column: name}
+		}
+		column = rest
+	}
+	if _, ok := c.fields[column]; !ok {
+		return clause.Column{}, &UnknownColumnError{Model: c.model, Column: name}
+	}
+	return clause.Column{Table: c.table, Name: column}, nil
+}
+
+// lookupColumn resolves a column against the model or destination of the statement being built
+func lookupColumn(stmt *gorm.Statement, name string) (clause.Column, error) {
+	if stmt.Schema == nil {
+		return clause.Column{}, fmt.Errorf("column %s: the query has no model to check it against", name)
+	}
+	return columnsOf(stmt.Schema).Lookup(name)
+}
+
+// lookupFilter resolves the column of a filter and coerces its value to the type of the column
+func lookupFilter(c *Columns, filter FilterField, name string) (clause.Column, FilterField, error) {
+	column, err := c.Lookup(name)
+	if err != nil {
+		return clause.Column{}, FilterField{}, err
+	}
+	filter, err = coerce(c.fields[column.Name], filter)
+	return column, filter, err
+}
+
+// columnFilter is a filter on a column of the model of the query, checked when the statement is built
+type columnFilter struct {
+	filter FilterField
+}
+
+func (f columnFilter) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	if stmt.Schema == nil {
+		stmt.AddError(fmt.Errorf("column %s: the query has no model to check it against", f.filter.ColumnName))
+		return
+	}
+	column, filter, err := lookupFilter(columnsOf(stmt.Schema), f.filter, f.filter.ColumnName)
+	if err != nil {
+		stmt.AddError(err)
+		return
+	}
+	condition(column, filter).Build(builder)
+}
+
+// orderBy sorts by columns of the model of the query. All sorts of a query are one expression,
+// because gorm keeps only the last expression of merged ORDER BY clauses.
+type orderBy struct {
+	sorts []SortConfig
+}
+
+func (o orderBy) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	columns := make([]clause.Column, 0, len(o.sorts))
+	for _, sort := range o.sorts {
+		column, err := lookupColumn(stmt, sort.Field)
+		if err != nil {
+			stmt.AddError(err)
+			return
+		}
+		if sort.Order != SortOrderAsc && sort.Order != SortOrderDesc {
+			stmt.AddError(fmt.Errorf("sort %s: unknown order %q", sort.Field, sort.Order))
+			return
+		}
+		if sort.Nulls != "" && sort.Nulls != NullsFirst && sort.Nulls != NullsLast {
+			stmt.AddError(fmt.Errorf("sort %s: unknown nulls order %q", sort.Field, sort.Nulls))
+			return
+		}
+		columns = append(columns, column)
+	}
+	// Written like clause.OrderBy, which has no NULLS FIRST or LAST
+	for i, column := range columns {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteQuoted(column)
+		if o.sorts[i].Order == SortOrderDesc {
+			builder.WriteString(" DESC")
+		}
+		if o.sorts[i].Nulls != "" {
+			builder.WriteString(" NULLS " + string(o.sorts[i].Nulls))
+		}
+	}
+}
+
+// groupBy is the GROUP BY clause of a query, grouping by columns of its model
+type groupBy struct {
+	columns []string
+	having  []clause.Expression
+}
+
+func (g groupBy) Name() string {
+	return "GROUP BY"
+}
+
+func (g groupBy) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	columns := make([]clause.Column, 0, len(g.columns))
+	for _, name := range g.columns {
+		column, err := lookupColumn(stmt, name)
+		if err != nil {
+			stmt.AddError(err)
+			return
+		}
+		columns = append(columns, column)
+	}
+	clause.GroupBy{Columns: columns, Having: g.having}.Build(builder)
+}
+
+func (g groupBy) MergeClause(c *clause.Clause) {
+	c.Expression = g
+	// Like clause.GroupBy, a HAVING without columns is written without the GROUP BY keyword
+	if len(g.columns) == 0 {
+		c.Name = ""
+	}
+}
@@ -0,0 +1,85 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder_RejectsUnknownColumns(t *testing.T) {
+	db := setupTestDB(t)
+
+	tests := []struct {
+		name   string
+		qb     *query.QueryBuilder
+		column string
+	}{
+		{name: "filter", qb: query.NewQueryBuilder(db).AddFilter("name = name OR 1", query.OperatorEquals, 1), column: "name = name OR 1"},
+		{name: "sort", qb: query.NewQueryBuilder(db).AddSort("price; DROP TABLE test_products", query.SortOrderAsc), column: "price; DROP TABLE test_products"},
+		{name: "group by", qb: query.NewQueryBuilder(db).AddGroupBy("stock", "COUNT(*)"), column: "COUNT(*)"},
+		{name: "other table", qb: query.NewQueryBuilder(db).AddFilter("users.email", query.OperatorEquals, "x"), column: "users.email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var products []TestProduct
+			err := tt.qb.Build().Find(&products).Error
+
+			var unknown *query.UnknownColumnError
+			require.ErrorAs(t, err, &unknown)
+			assert.Equal(t, tt.column, unknown.Column)
+			assert.Equal(t, "TestProduct", unknown.Model)
+		})
+	}
+
+	var count int64
+	require.NoError(t, db.Model(&TestProduct{}).Count(&count).Error)
+	assert.Equal(t, int64(4), count, "no statement ran")
+}
+
+func TestQueryBuilder_RejectsUnknownRelationColumns(t *testing.T) {
+	db := setupRelationDB(t)
+
+	var orders []TestOrder
+	err := query.NewQueryBuilder(db).AddFilter("Customer.password", query.OperatorEquals, "x").Build().Find(&orders).Error
+	var unknown *query.UnknownColumnError
+	require.ErrorAs(t, err, &unknown)
+	assert.Equal(t, "TestCustomer", unknown.Model)
+
+	var customers []TestCustomer
+	err = query.NewQueryBuilder(db).AddFilter("Orders.Customer.password", query.OperatorEquals, "x").Build().Find(&customers).Error
+	assert.ErrorAs(t, err, &unknown)
+}
+
+func TestQueryBuilder_GroupByAndQualifiedColumns(t *testing.T) {
+	db := setupTestDB(t)
+
+	var rows []struct {
+		Stock int
+		Total int
+	}
+	err := query.NewQueryBuilder(db.Model(&TestProduct{}).Select("stock, COUNT(*) AS total")).
+		AddFilter("test_products.price", query.OperatorGreaterOrEqual, 150.0).
+		AddGroupBy("stock").
+		AddHaving("COUNT(*) > ?", 0).
+		AddSort("stock", query.SortOrderDesc).
+		Build().Scan(&rows).Error
+	require.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, 20, rows[0].Stock)
+
+	err = query.NewQueryBuilder(db).AddSort("price", "DESC, id").Build().Find(&[]TestProduct{}).Error
+	assert.ErrorContains(t, err, "unknown order")
+}
+
+func TestColumnsOf(t *testing.T) {
+	columns, err := query.ColumnsOf(setupTestDB(t), &TestProduct{})
+	require.NoError(t, err)
+
+	_, err = columns.Lookup("price")
+	assert.NoError(t, err)
+	_, err = columns.Lookup("Price")
+	var unknown *query.UnknownColumnError
+	assert.ErrorAs(t, err, &unknown, "field names are not columns")
+}
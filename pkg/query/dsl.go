@@ -0,0 +1,119 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Condition is a filter written in the JSON filter DSL, e.g.
+//
+//	{"column": "status", "op": "IN", "value": ["CONFIRMED", "DELIVERED"]}
+//
+// The op defaults to "=". A condition only names columns of the model itself: relation paths
+// such as "User.email" are rejected, so clients cannot reach rows of other tables through it.
+type Condition struct {
+	Column string   `json:"column"`
+	Op     Operator `json:"op,omitempty"`
+	Value  any      `json:"value,omitempty"`
+}
+
+// Sort is a sort written in the JSON filter DSL, e.g. {"column": "placed_at", "order": "DESC"};
+// the order defaults to ASC, and "nulls": "LAST" puts the NULLs of the column last
+type Sort struct {
+	Column string     `json:"column"`
+	Order  SortOrder  `json:"order,omitempty"`
+	Nulls  NullsOrder `json:"nulls,omitempty"`
+}
+
+// InvalidConditionError reports a condition the DSL cannot turn into a filter
+type InvalidConditionError struct {
+	Column string
+	Reason string
+}
+
+func (e *InvalidConditionError) Error() string {
+	return fmt.Sprintf("condition on %q: %s", e.Column, e.Reason)
+}
+
+// Field checks the condition and returns the filter it stands for. Values are checked against
+// the operator, since a LIKE operator on a number or an IN without a list would fail the query
+// or, worse, panic while it is built.
+func (c Condition) Field() (FilterField, error) {
+	invalid := func(reason string) (FilterField, error) {
+		return FilterField{}, &InvalidConditionError{Column: c.Column, Reason: reason}
+	}
+	if c.Column == "" {
+		return invalid("column is required")
+	}
+	if _, _, ok := relationPath(c.Column); ok {
+		return invalid("relation paths are not supported")
+	}
+
+	op := Operator(strings.ToUpper(strings.TrimSpace(string(c.Op))))
+	if op == "" {
+		op = OperatorEquals
+	}
+	switch op {
+	case OperatorIsNull, OperatorIsNotNull:
+		if c.Value != nil {
+			return invalid(fmt.Sprintf("%s takes no value", op))
+		}
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith:
+		if _, ok := c.Value.(string); !ok {
+			return invalid(fmt.Sprintf("%s needs a string value", op))
+		}
+	case OperatorIn, OperatorNotIn:
+		values, ok := c.Value.([]any)
+		if !ok || len(values) == 0 {
+			return invalid(fmt.Sprintf("%s needs a non-empty list of values", op))
+		}
+		for _, v := range values {
+			if !isScalar(v) {
+				return invalid(fmt.Sprintf("%s values must be strings, numbers or booleans", op))
+			}
+		}
+	case OperatorEquals, OperatorNotEquals, OperatorGreaterThan, OperatorLessThan, OperatorGreaterOrEqual, OperatorLessOrEqual:
+		if c.Value == nil || !isScalar(c.Value) {
+			return invalid(fmt.Sprintf("%s needs a string, number or boolean value", op))
+		}
+	default:
+		return invalid(fmt.Sprintf("unknown operator %q", c.Op))
+	}
+	return FilterField{ColumnName: c.Column, Operator: op, Value: c.Value}, nil
+}
+
+// Config returns the sort configuration of the sort
+func (s Sort) Config() SortConfig {
+	order := SortOrder(strings.ToUpper(string(s.Order)))
+	if order == "" {
+		order = SortOrderAsc
+	}
+	return SortConfig{Field: s.Column, Order: order, Nulls: NullsOrder(strings.ToUpper(string(s.Nulls)))}
+}
+
+// ParseConditions decodes a JSON array of conditions into filters
+func ParseConditions(data []byte) ([]FilterField, error) {
+	var conditions []Condition
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		return nil, fmt.Errorf("decode conditions: %w", err)
+	}
+	fields := make([]FilterField, len(conditions))
+	for i, c := range conditions {
+		field, err := c.Field()
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = field
+	}
+	return fields, nil
+}
+
+// isScalar reports whether v is a value encoding/json decodes a JSON string, number or boolean to
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, float64, bool, json.Number:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConditions(t *testing.T) {
+	db := setupTestDB(t)
+
+	filters, err := query.ParseConditions([]byte(`[
+		{"column": "name", "op": "contains", "value": "Product"},
+		{"column": "stock", "op": ">", "value": 0},
+		{"column": "id", "op": "IN", "value": [1, 2, 3, 4]}
+	]`))
+	require.NoError(t, err)
+
+	var products []TestProduct
+	require.NoError(t, query.NewQueryBuilder(db).AddFilters(filters).AddSort("id", query.SortOrderAsc).Build().Find(&products).Error)
+	require.Len(t, products, 2)
+	assert.Equal(t, int64(1), products[0].ID)
+	assert.Equal(t, int64(2), products[1].ID)
+}
+
+func TestCondition_Field_RejectsInvalidConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition query.Condition
+	}{
+		{name: "missing column", condition: query.Condition{Value: "x"}},
+		{name: "relation path", condition: query.Condition{Column: "User.email", Value: "x"}},
+		{name: "unknown operator", condition: query.Condition{Column: "name", Op: "LIKE", Value: "%x"}},
+		{name: "like on a number", condition: query.Condition{Column: "name", Op: query.OperatorContains, Value: 1.0}},
+		{name: "in without a list", condition: query.Condition{Column: "id", Op: query.OperatorIn, Value: 1.0}},
+		{name: "in with objects", condition: query.Condition{Column: "id", Op: query.OperatorIn, Value: []any{map[string]any{}}}},
+		{name: "null with a value", condition: query.Condition{Column: "name", Op: query.OperatorIsNull, Value: "x"}},
+		{name: "equals without a value", condition: query.Condition{Column: "name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.condition.Field()
+			var invalid *query.InvalidConditionError
+			assert.ErrorAs(t, err, &invalid)
+		})
+	}
+}
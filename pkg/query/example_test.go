@@ -0,0 +1,98 @@
+package query_test
+
+import (
+	"fmt"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type Book struct {
+	ID     int64 `gorm:"primaryKey"`
+	Title  string
+	Author string
+	Pages  int
+}
+
+// BookFilter leaves zero fields out of the query
+type BookFilter struct {
+	Author   string `filter:"author"`
+	Title    string `filter:"title,CONTAINS"`
+	MinPages int    `filter:"pages,>="`
+}
+
+func openLibrary() *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(&Book{}); err != nil {
+		panic(err)
+	}
+	db.Create(&[]Book{
+		{ID: 1, Title: "The Go Programming Language", Author: "Donovan", Pages: 380},
+		{ID: 2, Title: "Concurrency in Go", Author: "Cox-Buday", Pages: 238},
+		{ID: 3, Title: "Learning Go", Author: "Bodner", Pages: 375},
+		{ID: 4, Title: "Go in Practice", Author: "Butcher", Pages: 288},
+	})
+	return db
+}
+
+func ExampleQueryBuilder_ApplyFilters() {
+	db := openLibrary()
+
+	var books []Book
+	query.NewQueryBuilder(db.Model(&Book{})).
+		ApplyFilters(BookFilter{Title: "Go", MinPages: 300}).
+		AddSort("pages", query.SortOrderDesc).
+		Build().
+		Find(&books)
+	for _, b := range books {
+		fmt.Println(b.Title)
+	}
+	// Output:
+	// The Go Programming Language
+	// Learning Go
+}
+
+func ExampleParseConditions() {
+	db := openLibrary()
+
+	// the conditions of the JSON filter DSL, e.g. from the body of an API request
+	fields, err := query.ParseConditions([]byte(`[{"column": "author", "op": "IN", "value": ["Cox-Buday", "Butcher"]}]`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var books []Book
+	query.NewQueryBuilder(db.Model(&Book{})).
+		AddFilters(fields).
+		AddSort("id", query.SortOrderAsc).
+		Build().
+		Find(&books)
+	for _, b := range books {
+		fmt.Println(b.Author)
+	}
+	// Output:
+	// Cox-Buday
+	// Butcher
+}
+
+func ExampleFindWithPagination() {
+	db := openLibrary()
+
+	var books []Book
+	page, err := query.FindWithPagination(db.Order("id"), 2, 3, &books)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("page %d of %d, %d books in total\n", page.Page, page.TotalPages, page.Total)
+	for _, b := range page.Data {
+		fmt.Println(b.Title)
+	}
+	// Output:
+	// page 2 of 2, 4 books in total
+	// Go in Practice
+}
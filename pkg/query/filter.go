@@ -1,11 +1,17 @@
+// Package query builds GORM queries from tagged filter structs, the fluent QueryBuilder or
+// conditions of the JSON filter DSL, with sorting and pagination. See README.md for the filter
+// tags and operators.
 package query
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"reflect"
 	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Operator represents the type of filter operation
@@ -51,6 +57,8 @@ type PreloadConfig struct {
 type SortConfig struct {
 	Field string
 	Order SortOrder
+	// Nulls places the NULLs of the column; empty leaves it to the database
+	Nulls NullsOrder
 }
 
 type SortOrder string
@@ -64,6 +72,8 @@ const (
 type PaginationConfig struct {
 	Page     int
 	PageSize int
+	// Offset skips rows when Page is zero, see SetOffset
+	Offset int
 }
 
 // QueryBuilder provides a fluent interface for building complex queries
@@ -87,7 +97,10 @@ func NewQueryBuilder(db *gorm.DB) *QueryBuilder {
 
 // ApplyFilters applies filters to a GORM query dynamically based on filter struct
 // It accepts a struct with field tags that specify how to apply the filter
-// Tag format: `filter:"column_name,operator"` where operator is optional and defaults to equals
+// Tag format: `filter:"column_name,operator"` where operator is optional and defaults to equals.
+// The column may follow relations of the model, e.g. `filter:"User.email,CONTAINS"` keeps the
+// orders whose user's email contains the value. Columns are checked against the model when the
+// query runs; unknown ones fail it with an *UnknownColumnError.
 func (qb *QueryBuilder) ApplyFilters(filterStruct interface{}) *QueryBuilder {
 	if filterStruct == nil {
 		return qb
@@ -119,6 +132,10 @@ func (qb *QueryBuilder) ApplyFilters(filterStruct interface{}) *QueryBuilder {
 			// If no filter tag, use the field name as column name and default to equals
 			filterTag = toSnakeCase(fieldType.Name)
 		}
+		// Like encoding/json, "-" leaves the field out, e.g. the sorts of a filter struct
+		if filterTag == "-" {
+			continue
+		}
 
 		// Parse the filter tag
 		parts := strings.Split(filterTag, ",")
@@ -187,6 +204,12 @@ func (qb *QueryBuilder) AddSort(field string, order SortOrder) *QueryBuilder {
 	return qb
 }
 
+// AddSorts adds sorting configurations, e.g. those of ParseSort
+func (qb *QueryBuilder) AddSorts(sorts ...SortConfig) *QueryBuilder {
+	qb.sorts = append(qb.sorts, sorts...)
+	return qb
+}
+
 // SetPagination sets pagination configuration
 func (qb *QueryBuilder) SetPagination(page, pageSize int) *QueryBuilder {
 	qb.pagination = &PaginationConfig{
@@ -196,6 +219,15 @@ func (qb *QueryBuilder) SetPagination(page, pageSize int) *QueryBuilder {
 	return qb
 }
 
+// SetOffset skips offset rows and returns at most limit, or every remaining row when limit is zero
+func (qb *QueryBuilder) SetOffset(offset, limit int) *QueryBuilder {
+	qb.pagination = &PaginationConfig{
+		PageSize: limit,
+		Offset:   offset,
+	}
+	return qb
+}
+
 // SetDistinct enables distinct selection
 func (qb *QueryBuilder) SetDistinct(distinct bool) *QueryBuilder {
 	qb.distinct = distinct
@@ -240,51 +272,102 @@ func (qb *QueryBuilder) Build() *gorm.DB {
 		}
 	}
 
-	// Apply group by
-	if len(qb.groupBy) > 0 {
-		query = query.Group(strings.Join(qb.groupBy, ", "))
-	}
-
-	// Apply having
-	if len(qb.having) > 0 {
-		query = query.Having(qb.having[0], qb.having[1:]...)
+	// Apply group by and having
+	if len(qb.groupBy) > 0 || len(qb.having) > 0 {
+		g := groupBy{columns: qb.groupBy}
+		if len(qb.having) > 0 {
+			g.having = query.Statement.BuildCondition(qb.having[0], qb.having[1:]...)
+		}
+		query = query.Clauses(g)
 	}
 
 	// Apply sorting
-	for _, sort := range qb.sorts {
-		query = query.Order(fmt.Sprintf("%s %s", sort.Field, sort.Order))
+	if len(qb.sorts) > 0 {
+		query = query.Order(clause.OrderBy{Expression: orderBy{sorts: qb.sorts}})
 	}
 
 	// Apply pagination
-	if qb.pagination != nil {
+	if qb.pagination != nil && qb.pagination.Page > 0 {
 		offset := (qb.pagination.Page - 1) * qb.pagination.PageSize
 		query = query.Offset(offset).Limit(qb.pagination.PageSize)
+	} else if qb.pagination != nil {
+		if qb.pagination.Offset > 0 {
+			query = query.Offset(qb.pagination.Offset)
+		}
+		if qb.pagination.PageSize > 0 {
+			query = query.Limit(qb.pagination.PageSize)
+		}
 	}
 
 	return query
 }
 
+// Key hashes the filters, preloads, sorts, pagination, grouping and distinct flag of the builder,
+// so builders selecting the same rows share a key, e.g. to cache their results. The database
+// and its model are not part of the key, nor are the functions of custom preloads, which count
+// by relationship only.
+func (qb *QueryBuilder) Key() string {
+	h := sha256.New()
+	for _, f := range qb.filters {
+		value := keyValue(f.Value)
+		fmt.Fprintf(h, "filter %q %q %T %#v\n", f.ColumnName, f.Operator, value, value)
+	}
+	for _, p := range qb.preloads {
+		fmt.Fprintf(h, "preload %q %#v %t\n", p.Relationship, p.Conditions, p.CustomPreload != nil)
+	}
+	for _, s := range qb.sorts {
+		fmt.Fprintf(h, "sort %q %q %q\n", s.Field, s.Order, s.Nulls)
+	}
+	if qb.pagination != nil {
+		fmt.Fprintf(h, "pagination %#v\n", *qb.pagination)
+	}
+	fmt.Fprintf(h, "distinct %t\ngroup %q\nhaving %#v\n", qb.distinct, qb.groupBy, qb.having)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// keyValue dereferences the pointers of optional filter fields, whose addresses differ between
+// otherwise equal builders
+func keyValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return v
+	}
+	return rv.Interface()
+}
+
 // applyFilter applies a single filter to the query
 func applyFilter(query *gorm.DB, filter FilterField) *gorm.DB {
+	if relations, column, ok := relationPath(filter.ColumnName); ok {
+		return query.Where(relationFilter{relations: relations, column: column, filter: filter})
+	}
+	return query.Where(columnFilter{filter: filter})
+}
+
+// condition compares a checked column with the value of the filter, which coerce has converted,
+// so the value of a LIKE operator is a string
+func condition(column clause.Column, filter FilterField) clause.Expression {
 	switch filter.Operator {
 	case OperatorContains:
 		// Use database-agnostic LIKE with % wildcards
-		return query.Where(fmt.Sprintf("%s LIKE ?", filter.ColumnName), "%"+filter.Value.(string)+"%")
+		return clause.Expr{SQL: "? LIKE ?", Vars: []interface{}{column, "%" + filter.Value.(string) + "%"}}
 	case OperatorStartsWith:
-		return query.Where(fmt.Sprintf("%s LIKE ?", filter.ColumnName), filter.Value.(string)+"%")
+		return clause.Expr{SQL: "? LIKE ?", Vars: []interface{}{column, filter.Value.(string) + "%"}}
 	case OperatorEndsWith:
-		return query.Where(fmt.Sprintf("%s LIKE ?", filter.ColumnName), "%"+filter.Value.(string))
+		return clause.Expr{SQL: "? LIKE ?", Vars: []interface{}{column, "%" + filter.Value.(string)}}
 	case OperatorIn:
-		return query.Where(fmt.Sprintf("%s IN ?", filter.ColumnName), filter.Value)
+		return clause.Expr{SQL: "? IN ?", Vars: []interface{}{column, filter.Value}}
 	case OperatorNotIn:
-		return query.Where(fmt.Sprintf("%s NOT IN ?", filter.ColumnName), filter.Value)
+		return clause.Expr{SQL: "? NOT IN ?", Vars: []interface{}{column, filter.Value}}
 	case OperatorIsNull:
-		return query.Where(fmt.Sprintf("%s IS NULL", filter.ColumnName))
+		return clause.Expr{SQL: "? IS NULL", Vars: []interface{}{column}}
 	case OperatorIsNotNull:
-		return query.Where(fmt.Sprintf("%s IS NOT NULL", filter.ColumnName))
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []interface{}{column}}
 	default:
 		// For simple operators (=, !=, >, <, >=, <=)
-		return query.Where(fmt.Sprintf("%s %s ?", filter.ColumnName, filter.Operator), filter.Value)
+		return clause.Expr{SQL: fmt.Sprintf("? %s ?", filter.Operator), Vars: []interface{}{column, filter.Value}}
 	}
 }
 
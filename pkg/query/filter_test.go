@@ -3,7 +3,7 @@ package query_test
 import (
 	"testing"
 
-	"github.com/mohsenjafari-aiio/aiiobackend/internal/shared/query"
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -146,6 +146,41 @@ func TestQueryBuilder_Pagination(t *testing.T) {
 	assert.Equal(t, int64(4), products[1].ID)
 }
 
+func TestQueryBuilder_SetOffset(t *testing.T) {
+	db := setupTestDB(t)
+
+	var products []TestProduct
+	err := query.NewQueryBuilder(db).SetOffset(1, 2).AddSort("id", query.SortOrderAsc).Build().Find(&products).Error
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, []int64{products[0].ID, products[1].ID})
+
+	// A zero limit returns every remaining row
+	products = nil
+	err = query.NewQueryBuilder(db).SetOffset(3, 0).AddSort("id", query.SortOrderAsc).Build().Find(&products).Error
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+}
+
+func TestQueryBuilder_Key(t *testing.T) {
+	inStock, alsoInStock := true, true
+	key := func(active *bool, page int) string {
+		return query.NewQueryBuilder(nil).
+			ApplyFilters(TestFilter{Name: "Product", IDs: []int64{1, 2}}).
+			AddFilter("active", query.OperatorEquals, active).
+			AddSort("id", query.SortOrderAsc).
+			SetPagination(page, 20).
+			Key()
+	}
+
+	assert.Equal(t, key(&inStock, 1), key(&alsoInStock, 1), "pointers to equal values share a key")
+	assert.NotEqual(t, key(&inStock, 1), key(&inStock, 2))
+	assert.NotEqual(t, key(&inStock, 1), key(nil, 1))
+	assert.NotEqual(t,
+		query.NewQueryBuilder(nil).AddFilter("stock", query.OperatorEquals, 1).Key(),
+		query.NewQueryBuilder(nil).AddFilter("stock", query.OperatorEquals, "1").Key(),
+		"values of different types differ")
+}
+
 func TestQueryBuilder_Sorting(t *testing.T) {
 	db := setupTestDB(t)
 
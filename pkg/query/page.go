@@ -0,0 +1,28 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ParsePage reads the page and page_size parameters of a listing, returning zero for an absent
+// parameter so the caller applies its default. Each invalid parameter is passed to invalid with
+// its name and a message, e.g. to validation.Errors.Add.
+func ParsePage(values url.Values, maxPageSize int, invalid func(param, message string)) (page, pageSize int) {
+	if value := values.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			invalid("page", fmt.Sprintf("must be a positive number, got %q", value))
+		}
+		page = n
+	}
+	if value := values.Get("page_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageSize {
+			invalid("page_size", fmt.Sprintf("must be between 1 and %d, got %q", maxPageSize, value))
+		}
+		pageSize = n
+	}
+	return page, pageSize
+}
@@ -0,0 +1,29 @@
+package query_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePage(t *testing.T) {
+	var invalid []string
+	collect := func(param, message string) { invalid = append(invalid, param+" "+message) }
+
+	page, pageSize := query.ParsePage(url.Values{"page": {"3"}, "page_size": {"50"}}, 100, collect)
+	assert.Equal(t, 3, page)
+	assert.Equal(t, 50, pageSize)
+
+	page, pageSize = query.ParsePage(url.Values{}, 100, collect)
+	assert.Zero(t, page, "absent parameters are left to the caller's defaults")
+	assert.Zero(t, pageSize)
+	assert.Empty(t, invalid)
+
+	query.ParsePage(url.Values{"page": {"0"}, "page_size": {"101"}}, 100, collect)
+	assert.Equal(t, []string{
+		`page must be a positive number, got "0"`,
+		`page_size must be between 1 and 100, got "101"`,
+	}, invalid)
+}
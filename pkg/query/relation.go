@@ -0,0 +1,121 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// relationPath splits a column like "User.email" into the relation path and the column of the
+// last relation. Plain and table-qualified columns such as "orders.status" are not relation paths,
+// because relation names are exported field names and start upper-case.
+func relationPath(column string) (relations []string, name string, ok bool) {
+	parts := strings.Split(column, ".")
+	if len(parts) < 2 {
+		return nil, "", false
+	}
+	relations = parts[:len(parts)-1]
+	for _, r := range relations {
+		if r == "" || !unicode.IsUpper([]rune(r)[0]) {
+			return nil, "", false
+		}
+	}
+	return relations, parts[len(parts)-1], true
+}
+
+// relationFilter matches the rows having a related row that passes the filter. It renders as a
+// correlated EXISTS subquery rather than a JOIN, so has-many relations never duplicate rows and
+// counts and pagination stay correct. The relation is resolved when the statement is built, from
+// the model or destination of the query.
+type relationFilter struct {
+	relations []string
+	// column is the column of the last relation the filter compares
+	column string
+	filter FilterField
+}
+
+func (f relationFilter) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	if stmt.Schema == nil {
+		stmt.AddError(fmt.Errorf("filter %s: the query has no model to resolve relation %s", f.filter.ColumnName, f.relations[0]))
+		return
+	}
+
+	// The whole path is resolved here, because errors of the subqueries would not reach the query
+	rels, column, filter, err := f.resolve(stmt.Schema)
+	if err != nil {
+		stmt.AddError(err)
+		return
+	}
+
+	builder.WriteString("EXISTS (")
+	stmt.AddVar(builder, related(stmt.DB, stmt.Table, rels, column, filter))
+	builder.WriteByte(')')
+}
+
+// resolve returns the relations of the path starting at the owner schema, the column of the last
+// one and the filter with its value coerced to the type of that column
+func (f relationFilter) resolve(owner *schema.Schema) ([]*schema.Relationship, clause.Column, FilterField, error) {
+	rels := make([]*schema.Relationship, 0, len(f.relations))
+	for _, name := range f.relations {
+		rel, ok := owner.Relationships.Relations[name]
+		if !ok {
+			return nil, clause.Column{}, FilterField{}, fmt.Errorf("filter %s: %s has no relation %s", f.filter.ColumnName, owner.Name, name)
+		}
+		if rel.JoinTable != nil {
+			return nil, clause.Column{}, FilterField{}, fmt.Errorf("filter %s: many-to-many relation %s is not supported", f.filter.ColumnName, rel.Name)
+		}
+		// Without aliases the related table would shadow the owner in a self-referencing relation
+		if rel.FieldSchema.Table == owner.Table {
+			return nil, clause.Column{}, FilterField{}, fmt.Errorf("filter %s: self-referencing relation %s is not supported", f.filter.ColumnName, rel.Name)
+		}
+		rels = append(rels, rel)
+		owner = rel.FieldSchema
+	}
+
+	column, filter, err := lookupFilter(columnsOf(owner), f.filter, f.column)
+	return rels, column, filter, err
+}
+
+// related selects the rows related to a row of the owner table through rels, whose last relation
+// has a row passing the filter on column
+func related(db *gorm.DB, owner string, rels []*schema.Relationship, column clause.Column, filter FilterField) *gorm.DB {
+	rel := rels[0]
+	sub := db.Session(&gorm.Session{NewDB: true}).
+		Model(reflect.New(rel.FieldSchema.ModelType).Interface()).
+		Select("1")
+	for _, ref := range rel.References {
+		sub = sub.Where(correlation(owner, rel, ref))
+	}
+	if len(rels) > 1 {
+		return sub.Where("EXISTS (?)", related(db, rel.FieldSchema.Table, rels[1:], column, filter))
+	}
+	return sub.Where(condition(column, filter))
+}
+
+// correlation is the condition joining a row of the related table to the row of the owner table
+func correlation(owner string, rel *schema.Relationship, ref *schema.Reference) clause.Expression {
+	related := rel.FieldSchema.Table
+	// Polymorphic relations compare the type column with a constant
+	if ref.PrimaryKey == nil {
+		return clause.Eq{Column: clause.Column{Table: related, Name: ref.ForeignKey.DBName}, Value: ref.PrimaryValue}
+	}
+
+	// OwnPrimaryKey means the owner holds the referenced key (has one, has many); otherwise the
+	// owner holds the foreign key (belongs to)
+	ownerColumn := clause.Column{Table: owner, Name: ref.ForeignKey.DBName}
+	relatedColumn := clause.Column{Table: related, Name: ref.PrimaryKey.DBName}
+	if ref.OwnPrimaryKey {
+		ownerColumn = clause.Column{Table: owner, Name: ref.PrimaryKey.DBName}
+		relatedColumn = clause.Column{Table: related, Name: ref.ForeignKey.DBName}
+	}
+	return clause.Eq{Column: relatedColumn, Value: ownerColumn}
+}
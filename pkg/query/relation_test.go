@@ -0,0 +1,114 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type TestCustomer struct {
+	ID      int64 `gorm:"primaryKey"`
+	Email   string
+	Country string
+	Orders  []TestOrder `gorm:"foreignKey:CustomerID"`
+}
+
+type TestOrder struct {
+	ID         int64 `gorm:"primaryKey"`
+	CustomerID int64
+	Customer   TestCustomer `gorm:"foreignKey:CustomerID"`
+	Status     string
+}
+
+type TestOrderFilter struct {
+	CustomerEmail   string `filter:"Customer.email,CONTAINS"`
+	CustomerCountry string `filter:"Customer.country"`
+	Status          string `filter:"status"`
+}
+
+func setupRelationDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&TestCustomer{}, &TestOrder{}))
+
+	require.NoError(t, db.Create(&[]TestCustomer{
+		{ID: 1, Email: "jane@example.com", Country: "DE", Orders: []TestOrder{{ID: 1, Status: "PAID"}, {ID: 2, Status: "PAID"}, {ID: 3, Status: "PENDING"}}},
+		{ID: 2, Email: "john@example.com", Country: "US", Orders: []TestOrder{{ID: 4, Status: "PAID"}}},
+		{ID: 3, Email: "ann@other.org", Country: "DE"},
+	}).Error)
+	return db
+}
+
+func TestQueryBuilder_ApplyFilters_BelongsTo(t *testing.T) {
+	db := setupRelationDB(t)
+
+	tests := []struct {
+		name     string
+		filter   TestOrderFilter
+		expected []int64
+	}{
+		{name: "related column", filter: TestOrderFilter{CustomerEmail: "jane"}, expected: []int64{1, 2, 3}},
+		{name: "related and own columns", filter: TestOrderFilter{CustomerEmail: "example.com", Status: "PAID"}, expected: []int64{1, 2, 4}},
+		{name: "two related columns", filter: TestOrderFilter{CustomerEmail: "example.com", CustomerCountry: "US"}, expected: []int64{4}},
+		{name: "no match", filter: TestOrderFilter{CustomerEmail: "other.org"}, expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var orders []TestOrder
+			err := query.NewQueryBuilder(db).ApplyFilters(tt.filter).AddSort("id", query.SortOrderAsc).Build().Find(&orders).Error
+			require.NoError(t, err)
+
+			var ids []int64
+			for _, o := range orders {
+				ids = append(ids, o.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestQueryBuilder_ApplyFilters_HasMany(t *testing.T) {
+	db := setupRelationDB(t)
+
+	// Jane has two paid orders, yet she is returned and counted once
+	qb := query.NewQueryBuilder(db.Model(&TestCustomer{})).AddFilter("Orders.status", query.OperatorEquals, "PAID")
+	var total int64
+	require.NoError(t, qb.Build().Count(&total).Error)
+	assert.Equal(t, int64(2), total)
+
+	var customers []TestCustomer
+	require.NoError(t, qb.AddSort("id", query.SortOrderAsc).Build().Find(&customers).Error)
+	if assert.Len(t, customers, 2) {
+		assert.Equal(t, "jane@example.com", customers[0].Email)
+		assert.Equal(t, "john@example.com", customers[1].Email)
+	}
+}
+
+func TestQueryBuilder_ApplyFilters_NestedRelation(t *testing.T) {
+	db := setupRelationDB(t)
+
+	// Customers with an order of a customer in the US, i.e. the US customers with orders
+	var customers []TestCustomer
+	err := query.NewQueryBuilder(db).AddFilter("Orders.Customer.country", query.OperatorEquals, "US").Build().Find(&customers).Error
+	require.NoError(t, err)
+	if assert.Len(t, customers, 1) {
+		assert.Equal(t, int64(2), customers[0].ID)
+	}
+}
+
+func TestQueryBuilder_ApplyFilters_UnknownRelation(t *testing.T) {
+	db := setupRelationDB(t)
+
+	var orders []TestOrder
+	err := query.NewQueryBuilder(db).AddFilter("Seller.email", query.OperatorEquals, "jane@example.com").Build().Find(&orders).Error
+	assert.ErrorContains(t, err, "has no relation Seller")
+
+	// Table-qualified columns are not relations
+	err = query.NewQueryBuilder(db).AddFilter("test_orders.status", query.OperatorEquals, "PAID").Build().Find(&orders).Error
+	assert.NoError(t, err)
+	assert.Len(t, orders, 3)
+}
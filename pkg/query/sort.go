@@ -0,0 +1,64 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidSortField is returned by ParseSort for fields an endpoint does not sort by
+var ErrInvalidSortField = errors.New("invalid sort field")
+
+// NullsOrder places the NULLs of a sort column before or after the other values. Without it
+// the database decides: PostgreSQL sorts NULLs as larger than any value, SQLite as smaller.
+type NullsOrder string
+
+const (
+	NullsFirst NullsOrder = "FIRST"
+	NullsLast  NullsOrder = "LAST"
+)
+
+// ParseSort reads a sort parameter such as "-created_at,total" into sort configurations: fields
+// are separated by commas, a leading "-" sorts descending and a "+" or nothing ascending, and a
+// ":nulls_first" or ":nulls_last" suffix places the NULLs of the field, e.g. "-shipped_at:nulls_last".
+// Fields not in sortable, the whitelist of the endpoint, fail with ErrInvalidSortField; an empty
+// parameter returns no sorts.
+func ParseSort(raw string, sortable ...string) ([]SortConfig, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var sorts []SortConfig
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.TrimSpace(term)
+		sort := SortConfig{Order: SortOrderAsc}
+		switch {
+		case strings.HasPrefix(term, "-"):
+			sort.Order, term = SortOrderDesc, term[1:]
+		case strings.HasPrefix(term, "+"):
+			term = term[1:]
+		}
+		if field, option, ok := strings.Cut(term, ":"); ok {
+			switch strings.ToLower(option) {
+			case "nulls_first":
+				sort.Nulls = NullsFirst
+			case "nulls_last":
+				sort.Nulls = NullsLast
+			default:
+				return nil, fmt.Errorf("%w %q: unknown option %q, use nulls_first or nulls_last", ErrInvalidSortField, field, option)
+			}
+			term = field
+		}
+		sort.Field = term
+
+		if !slices.Contains(sortable, sort.Field) {
+			return nil, fmt.Errorf("%w %q: sort by %s", ErrInvalidSortField, sort.Field, strings.Join(sortable, ", "))
+		}
+		if slices.ContainsFunc(sorts, func(s SortConfig) bool { return s.Field == sort.Field }) {
+			return nil, fmt.Errorf("%w %q: listed twice", ErrInvalidSortField, sort.Field)
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
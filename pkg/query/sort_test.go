@@ -0,0 +1,49 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/mohsenjafari-aiio/aiiobackend/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	sortable := []string{"created_at", "total", "shipped_at"}
+
+	sorts, err := query.ParseSort("-created_at, total,+shipped_at:nulls_last", sortable...)
+	require.NoError(t, err)
+	assert.Equal(t, []query.SortConfig{
+		{Field: "created_at", Order: query.SortOrderDesc},
+		{Field: "total", Order: query.SortOrderAsc},
+		{Field: "shipped_at", Order: query.SortOrderAsc, Nulls: query.NullsLast},
+	}, sorts)
+
+	sorts, err = query.ParseSort("", sortable...)
+	require.NoError(t, err)
+	assert.Empty(t, sorts)
+
+	for _, raw := range []string{"password_hash", "-total,total", "total,", "shipped_at:nulls_middle", "-"} {
+		_, err := query.ParseSort(raw, sortable...)
+		assert.ErrorIs(t, err, query.ErrInvalidSortField, raw)
+	}
+}
+
+func TestQueryBuilder_SortsNulls(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Exec("UPDATE test_products SET stock = NULL WHERE id = 2").Error)
+
+	ids := func(sorts ...query.SortConfig) []int64 {
+		var ids []int64
+		err := query.NewQueryBuilder(db.Model(&TestProduct{})).AddSorts(sorts...).Build().Pluck("id", &ids).Error
+		require.NoError(t, err)
+		return ids
+	}
+
+	assert.Equal(t, []int64{2, 3, 1, 4}, ids(query.SortConfig{Field: "stock", Order: query.SortOrderAsc, Nulls: query.NullsFirst}))
+	assert.Equal(t, []int64{3, 1, 4, 2}, ids(query.SortConfig{Field: "stock", Order: query.SortOrderAsc, Nulls: query.NullsLast}))
+	assert.Equal(t, []int64{4, 1, 3, 2}, ids(query.SortConfig{Field: "stock", Order: query.SortOrderDesc, Nulls: query.NullsLast}))
+
+	err := query.NewQueryBuilder(db).AddSorts(query.SortConfig{Field: "stock", Order: query.SortOrderAsc, Nulls: "MIDDLE"}).Build().Find(&[]TestProduct{}).Error
+	assert.ErrorContains(t, err, "unknown nulls order")
+}
@@ -0,0 +1,8 @@
+//go:build tools
+
+// Package main tracks the code generators run by go generate, so go mod tidy keeps them in go.mod
+package main
+
+import (
+	_ "github.com/99designs/gqlgen"
+)